@@ -124,6 +124,7 @@ export interface GrafanaConfig {
   autoAssignOrg: boolean;
   verifyEmailEnabled: boolean;
   oauth: any;
+  pluginAuthProviders: Array<{ id: string; pluginId: string; name: string }>;
   disableUserSignUp: boolean;
   loginHint: any;
   passwordHint: any;
@@ -49,6 +49,7 @@ export class GrafanaBootConfig implements GrafanaConfig {
   autoAssignOrg = true;
   verifyEmailEnabled = false;
   oauth: any;
+  pluginAuthProviders: Array<{ id: string; pluginId: string; name: string }> = [];
   disableUserSignUp = false;
   loginHint: any;
   passwordHint: any;
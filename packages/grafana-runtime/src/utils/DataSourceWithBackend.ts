@@ -157,6 +157,10 @@ class DataSourceWithBackend<
       body.to = range.to.valueOf().toString();
     }
 
+    if (request.cacheTTL) {
+      body.cacheTTL = request.cacheTTL;
+    }
+
     if (config.featureToggles.queryOverLive) {
       return getGrafanaLiveSrv().getQueryData({
         request,
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// accessControlFilterUser returns the signed in user with their permissions loaded, to be set on queries that
+// restrict their results to the resources the user can access. It returns nil when access control is disabled.
+func (hs *HTTPServer) accessControlFilterUser(c *models.ReqContext) (*models.SignedInUser, error) {
+	if hs.AccessControl == nil || hs.AccessControl.IsDisabled() {
+		return nil, nil
+	}
+
+	if c.SignedInUser.Permissions == nil {
+		c.SignedInUser.Permissions = make(map[int64]map[string][]string)
+	}
+
+	if _, ok := c.SignedInUser.Permissions[c.OrgId]; !ok {
+		permissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
+		if err != nil {
+			return nil, err
+		}
+		c.SignedInUser.Permissions[c.OrgId] = accesscontrol.GroupScopesByAction(permissions)
+	}
+
+	return c.SignedInUser, nil
+}
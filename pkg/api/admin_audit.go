@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// GET /api/admin/audit/auth
+//
+// Lists the authentication events of all the organizations and users: sign in attempts, sessions, and issuance and
+// use of the API keys. The time range is in epoch milliseconds.
+func (hs *HTTPServer) AdminGetAuthAuditEvents(c *models.ReqContext) response.Response {
+	query := &audit.AuthEventsQuery{
+		OrgId:   c.QueryInt64("orgId"),
+		UserId:  c.QueryInt64("userId"),
+		Login:   c.Query("login"),
+		Actions: c.QueryStrings("action"),
+		Page:    c.QueryInt("page"),
+		PerPage: c.QueryInt("perpage"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	result, err := hs.auditService.GetAuthEvents(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get authentication events", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/admin/audit/events
+//
+// Lists the audit events of all the types, organizations and users, filtered by the user the event is about, the
+// actor, the type, the action and the time range in epoch milliseconds.
+func (hs *HTTPServer) AdminGetAuditEvents(c *models.ReqContext) response.Response {
+	result, err := hs.auditService.GetEvents(c.Req.Context(), auditEventsQuery(c))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get audit events", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/admin/audit/events/export
+//
+// Downloads all the audit events matching the filters of the list, oldest first, as JSON lines or in the Common
+// Event Format.
+func (hs *HTTPServer) AdminExportAuditEvents(c *models.ReqContext) response.Response {
+	exportFormat := c.Query("format")
+	if exportFormat == "" {
+		exportFormat = setting.AuthAuditFormatJSON
+	}
+
+	var body bytes.Buffer
+	if err := hs.auditService.Export(c.Req.Context(), auditEventsQuery(c), exportFormat, &body); err != nil {
+		if errors.Is(err, audit.ErrInvalidExportFormat) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to export audit events", err)
+	}
+
+	extension := "jsonl"
+	if exportFormat == setting.AuthAuditFormatCEF {
+		extension = "cef"
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="grafana-audit.%s"`, extension))
+	return response.CreateNormalResponse(header, body.Bytes(), http.StatusOK)
+}
+
+func auditEventsQuery(c *models.ReqContext) *audit.EventsQuery {
+	query := &audit.EventsQuery{
+		OrgId:      c.QueryInt64("orgId"),
+		UserId:     c.QueryInt64("userId"),
+		ActorId:    c.QueryInt64("actorId"),
+		ActorLogin: c.Query("actorLogin"),
+		Actions:    c.QueryStrings("action"),
+		Page:       c.QueryInt("page"),
+		PerPage:    c.QueryInt("perpage"),
+	}
+	for _, eventType := range c.QueryStrings("type") {
+		query.Types = append(query.Types, audit.EventType(eventType))
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+	return query
+}
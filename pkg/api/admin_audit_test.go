@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAdminGetAuthAuditEvents(t *testing.T) {
+	setup := func(t *testing.T, permissions []*accesscontrol.Permission) *scenarioContext {
+		sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), "/api/admin/audit/auth", permissions)
+		auditService := newTestAuditService(t, hs.Cfg, sqlstore.InitTestDB(t))
+		hs.auditService = auditService
+
+		created := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+		for i, action := range []string{audit.ActionLoginFailed, audit.ActionLoginSucceeded, audit.ActionSessionCreated} {
+			err := auditService.Record(context.Background(), &audit.Event{
+				UserId: 2, Type: audit.EventTypeAuth, Action: action, Created: created.Add(time.Duration(i) * time.Minute),
+			})
+			require.NoError(t, err)
+		}
+		require.NoError(t, auditService.Record(context.Background(), &audit.Event{OrgId: 1, UserId: 2, Type: audit.EventTypeRole, Action: audit.ActionOrgUserAdded}))
+		sc.resp = httptest.NewRecorder()
+		return sc
+	}
+
+	t.Run("should list the authentication events matching the filters", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: ActionAuditAuthRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/auth?userId=2&action=login_failed&action=session_created&perpage=1", nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var result audit.AuthEventsResult
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		assert.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, audit.ActionSessionCreated, result.Events[0].Action)
+	})
+
+	t.Run("should filter the events by time range", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: ActionAuditAuthRead}})
+		from := time.Date(2021, 11, 10, 10, 0, 30, 0, time.UTC).UnixMilli()
+		to := time.Date(2021, 11, 10, 10, 1, 30, 0, time.UTC).UnixMilli()
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("/api/admin/audit/auth?from=%d&to=%d", from, to), nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var result audit.AuthEventsResult
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, audit.ActionLoginSucceeded, result.Events[0].Action)
+	})
+
+	t.Run("should require the permission to read the audit log", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: ActionServerCachesRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/auth", nil)
+		require.NoError(t, err)
+		sc.exec()
+		assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+	})
+}
+
+func TestAdminGetAuditEvents(t *testing.T) {
+	setup := func(t *testing.T, url string, permissions []*accesscontrol.Permission) *scenarioContext {
+		sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), url, permissions)
+		auditService := newTestAuditService(t, hs.Cfg, sqlstore.InitTestDB(t))
+		hs.auditService = auditService
+
+		created := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, auditService.Record(context.Background(), &audit.Event{UserId: 2, Type: audit.EventTypeAuth, Action: audit.ActionLoginSucceeded, Created: created}))
+		require.NoError(t, auditService.Record(context.Background(), &audit.Event{OrgId: 1, UserId: 2, ActorId: 1, Type: audit.EventTypeRole, Action: audit.ActionOrgUserAdded, Created: created.Add(time.Minute)}))
+		sc.resp = httptest.NewRecorder()
+		return sc
+	}
+
+	t.Run("should list the events of all the types matching the filters", func(t *testing.T) {
+		sc := setup(t, "/api/admin/audit/events", []*accesscontrol.Permission{{Action: ActionAuditRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/events?userId=2&type=role&type=team", nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var result audit.EventsResult
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, audit.ActionOrgUserAdded, result.Events[0].Action)
+	})
+
+	t.Run("should export the events in the common event format", func(t *testing.T) {
+		sc := setup(t, "/api/admin/audit/events/export", []*accesscontrol.Permission{{Action: ActionAuditRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/events/export?format=cef", nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		assert.Equal(t, `attachment; filename="grafana-audit.cef"`, sc.resp.Header().Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSpace(sc.resp.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "|login_succeeded|")
+		assert.Contains(t, lines[1], "|org_user_added|")
+	})
+
+	t.Run("should refuse the unknown export formats", func(t *testing.T) {
+		sc := setup(t, "/api/admin/audit/events/export", []*accesscontrol.Permission{{Action: ActionAuditRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/events/export?format=xml", nil)
+		require.NoError(t, err)
+		sc.exec()
+		assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+	})
+
+	t.Run("should require the permission to read the audit log", func(t *testing.T) {
+		sc := setup(t, "/api/admin/audit/events", []*accesscontrol.Permission{{Action: ActionAuditAuthRead}})
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/audit/events", nil)
+		require.NoError(t, err)
+		sc.exec()
+		assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+	})
+}
+
+func newTestAuditService(t *testing.T, cfg *setting.Cfg, store *sqlstore.SQLStore) *audit.Service {
+	t.Helper()
+	schedulerService := scheduler.ProvideService(nil, kvstore.ProvideService(store), accesscontrolmock.New(), routing.NewRouteRegister())
+	auditService, err := audit.ProvideService(cfg, store, bus.New(), hooks.ProvideService(), schedulerService)
+	require.NoError(t, err)
+	return auditService
+}
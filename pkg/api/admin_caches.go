@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	defaultCacheKeysLimit = 100
+	maxCacheKeysLimit     = 1000
+)
+
+// GET /api/admin/caches
+func (hs *HTTPServer) AdminGetCaches(c *models.ReqContext) response.Response {
+	caches := hs.cacheRegistry.List()
+	result := make([]dtos.CacheInfo, 0, len(caches))
+	for _, cache := range caches {
+		stats := cache.Stats()
+		info := dtos.CacheInfo{
+			Name:   cache.Name(),
+			Items:  cache.ItemCount(),
+			Hits:   stats.Hits,
+			Misses: stats.Misses,
+		}
+		if total := stats.Hits + stats.Misses; total > 0 {
+			info.HitRate = float64(stats.Hits) / float64(total)
+		}
+		result = append(result, info)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/admin/caches/:name/keys?orgId=&pattern=&limit=
+func (hs *HTTPServer) AdminGetCacheKeys(c *models.ReqContext) response.Response {
+	cache, ok := hs.cacheRegistry.Get(web.Params(c.Req)[":name"])
+	if !ok {
+		return response.Error(http.StatusNotFound, "Cache not found", nil)
+	}
+
+	keys, err := cache.Keys(localcache.Filter{OrgID: c.QueryInt64("orgId"), Pattern: c.Query("pattern")})
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	limit := c.QueryInt("limit")
+	if limit <= 0 {
+		limit = defaultCacheKeysLimit
+	}
+	if limit > maxCacheKeysLimit {
+		limit = maxCacheKeysLimit
+	}
+	result := dtos.CacheKeys{Name: cache.Name(), Total: len(keys), Keys: keys}
+	if len(keys) > limit {
+		result.Keys = keys[:limit]
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// POST /api/admin/caches/:name/purge
+func (hs *HTTPServer) AdminPurgeCache(c *models.ReqContext) response.Response {
+	cmd := dtos.PurgeCacheCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	cache, ok := hs.cacheRegistry.Get(web.Params(c.Req)[":name"])
+	if !ok {
+		return response.Error(http.StatusNotFound, "Cache not found", nil)
+	}
+
+	purged, err := cache.Purge(localcache.Filter{OrgID: cmd.OrgId, Pattern: cmd.Pattern})
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	c.Logger.Info("Purged server cache", "cache", cache.Name(), "orgId", cmd.OrgId, "pattern", cmd.Pattern, "purged", purged)
+	return response.JSON(http.StatusOK, dtos.PurgeCacheResult{Name: cache.Name(), Purged: purged})
+}
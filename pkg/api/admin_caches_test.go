@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAdminCaches(t *testing.T) {
+	setup := func(t *testing.T, url string, permissions []*accesscontrol.Permission) (*scenarioContext, *localcache.CacheService) {
+		sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), url, permissions)
+		cache := localcache.NewNamed("datasources", time.Minute, time.Minute)
+		cache.SetDefault(localcache.OrgKey(1, "ds-id-1"), 1)
+		cache.SetDefault(localcache.OrgKey(1, "ds-uid-a"), 1)
+		cache.SetDefault(localcache.OrgKey(2, "ds-id-2"), 2)
+		_, _ = cache.Get(localcache.OrgKey(1, "ds-id-1"))
+		_, _ = cache.Get(localcache.OrgKey(3, "ds-id-3"))
+		hs.cacheRegistry = localcache.ProvideRegistry()
+		hs.cacheRegistry.Register(cache)
+		sc.resp = httptest.NewRecorder()
+		return sc, cache
+	}
+	readPermissions := []*accesscontrol.Permission{{Action: ActionServerCachesRead}}
+	writePermissions := []*accesscontrol.Permission{{Action: ActionServerCachesWrite}}
+
+	t.Run("should list the caches with their hit rate", func(t *testing.T) {
+		sc, _ := setup(t, "/api/admin/caches", readPermissions)
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/caches", nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var caches []dtos.CacheInfo
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &caches))
+		assert.Equal(t, []dtos.CacheInfo{{Name: "datasources", Items: 3, Hits: 1, Misses: 1, HitRate: 0.5}}, caches)
+	})
+
+	t.Run("should list the keys of an organization", func(t *testing.T) {
+		sc, _ := setup(t, "/api/admin/caches/datasources/keys", readPermissions)
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/caches/datasources/keys?orgId=1&limit=1", nil)
+		require.NoError(t, err)
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var keys dtos.CacheKeys
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &keys))
+		assert.Equal(t, dtos.CacheKeys{Name: "datasources", Total: 2, Keys: []string{"1-ds-id-1"}}, keys)
+	})
+
+	t.Run("should return 404 for unknown caches", func(t *testing.T) {
+		sc, _ := setup(t, "/api/admin/caches/unknown/keys", readPermissions)
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/caches/unknown/keys", nil)
+		require.NoError(t, err)
+		sc.exec()
+		assert.Equal(t, http.StatusNotFound, sc.resp.Code)
+	})
+
+	t.Run("should purge the entries matching the pattern", func(t *testing.T) {
+		sc, cache := setup(t, "/api/admin/caches/datasources/purge", writePermissions)
+		var err error
+		sc.req, err = http.NewRequest(http.MethodPost, "/api/admin/caches/datasources/purge", strings.NewReader(`{"pattern": "*-ds-id-*"}`))
+		require.NoError(t, err)
+		sc.req.Header.Set("Content-Type", "application/json")
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var result dtos.PurgeCacheResult
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		assert.Equal(t, dtos.PurgeCacheResult{Name: "datasources", Purged: 2}, result)
+		assert.Equal(t, 1, cache.ItemCount())
+	})
+
+	t.Run("should return 403 for users who can only read the caches", func(t *testing.T) {
+		sc, cache := setup(t, "/api/admin/caches/datasources/purge", readPermissions)
+		var err error
+		sc.req, err = http.NewRequest(http.MethodPost, "/api/admin/caches/datasources/purge", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		sc.req.Header.Set("Content-Type", "application/json")
+		sc.exec()
+
+		assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+		assert.Equal(t, 3, cache.ItemCount())
+	})
+}
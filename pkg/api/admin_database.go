@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// GET /api/admin/database/stats
+func (hs *HTTPServer) AdminGetDatabaseStats(c *models.ReqContext) response.Response {
+	pools := hs.SQLStore.GetPoolStats()
+	result := dtos.DatabaseStats{
+		Type:  hs.SQLStore.Dialect.DriverName(),
+		Pools: make([]dtos.DatabasePoolStats, 0, len(pools)),
+	}
+	for _, pool := range pools {
+		result.Pools = append(result.Pools, dtos.DatabasePoolStats{
+			Name:              pool.Name,
+			Healthy:           pool.Healthy,
+			MaxOpenConn:       pool.MaxOpenConnections,
+			MaxIdleConn:       pool.MaxIdleConn,
+			ConnMaxLifetime:   pool.ConnMaxLifetime.Seconds(),
+			StatementTimeout:  pool.StatementTimeout.Seconds(),
+			OpenConnections:   pool.OpenConnections,
+			InUse:             pool.InUse,
+			Idle:              pool.Idle,
+			WaitCount:         pool.WaitCount,
+			WaitDuration:      pool.WaitDuration.Seconds(),
+			MaxIdleClosed:     pool.MaxIdleClosed,
+			MaxIdleTimeClosed: pool.MaxIdleTimeClosed,
+			MaxLifetimeClosed: pool.MaxLifetimeClosed,
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/admin/database/migrations
+func (hs *HTTPServer) AdminGetDatabaseMigrations(c *models.ReqContext) response.Response {
+	status, err := hs.SQLStore.GetMigrationStatus(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the migration status", err)
+	}
+	return response.JSON(http.StatusOK, hs.databaseMigrations(status))
+}
+
+func (hs *HTTPServer) databaseMigrations(status *migrator.MigrationStatus) dtos.DatabaseMigrations {
+	result := dtos.DatabaseMigrations{Locking: hs.SQLStore.MigrationLocking()}
+	if status != nil {
+		result.Status = &dtos.DatabaseMigrationStatus{
+			Holder:           status.Holder,
+			State:            status.State,
+			Pending:          status.Pending,
+			Performed:        status.Performed,
+			CurrentMigration: status.CurrentMigration,
+			Error:            status.Error,
+			Started:          status.Started,
+			Updated:          status.Updated,
+		}
+	}
+	return result
+}
+
+// POST /api/admin/database/backup
+//
+// Downloads a consistent copy of the SQLite database, taken while Grafana keeps using it.
+func (hs *HTTPServer) AdminBackupDatabase(c *models.ReqContext) {
+	dir, err := os.MkdirTemp(hs.Cfg.DataPath, "backup")
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to back up the database", err)
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			hs.log.Warn("Failed to remove the database backup", "path", dir, "error", err)
+		}
+	}()
+
+	path := filepath.Join(dir, "grafana.db")
+	if err := hs.SQLStore.BackupSQLite(c.Req.Context(), path); err != nil {
+		if errors.Is(err, sqlstore.ErrNotSQLite) {
+			c.JsonApiErr(http.StatusBadRequest, "Only the SQLite databases can be backed up", nil)
+			return
+		}
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to back up the database", err)
+		return
+	}
+
+	// nolint:gosec
+	backup, err := os.Open(path)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to back up the database", err)
+		return
+	}
+	defer func() {
+		if err := backup.Close(); err != nil {
+			hs.log.Warn("Failed to close the database backup", "path", path, "error", err)
+		}
+	}()
+
+	now := time.Now()
+	c.Resp.Header().Set("Content-Type", "application/vnd.sqlite3")
+	c.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="grafana-%s.db"`, now.UTC().Format("20060102T150405Z")))
+	http.ServeContent(c.Resp, c.Req, "", now, backup)
+}
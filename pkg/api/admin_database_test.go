@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAdminGetDatabaseStats(t *testing.T) {
+	setup := func(t *testing.T, permissions []*accesscontrol.Permission) *scenarioContext {
+		sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), "/api/admin/database/stats", permissions)
+		hs.SQLStore = sqlstore.InitTestDB(t)
+		sc.resp = httptest.NewRecorder()
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/database/stats", nil)
+		require.NoError(t, err)
+		return sc
+	}
+
+	t.Run("should return the statistics of the connection pools", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: accesscontrol.ActionServerStatsRead}})
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var stats dtos.DatabaseStats
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &stats))
+		assert.Equal(t, "sqlite3", stats.Type)
+		require.Len(t, stats.Pools, 1)
+		assert.Equal(t, sqlstore.PoolPrimary, stats.Pools[0].Name)
+		assert.True(t, stats.Pools[0].Healthy)
+	})
+
+	t.Run("should require the permission to read the server stats", func(t *testing.T) {
+		sc := setup(t, nil)
+		sc.exec()
+		assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+	})
+}
+
+func TestAdminGetDatabaseMigrations(t *testing.T) {
+	sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), "/api/admin/database/migrations",
+		[]*accesscontrol.Permission{{Action: accesscontrol.ActionServerStatsRead}})
+	hs.SQLStore = sqlstore.InitTestDB(t)
+	sc.resp = httptest.NewRecorder()
+	var err error
+	sc.req, err = http.NewRequest(http.MethodGet, "/api/admin/database/migrations", nil)
+	require.NoError(t, err)
+	sc.exec()
+
+	require.Equal(t, http.StatusOK, sc.resp.Code)
+	var migrations dtos.DatabaseMigrations
+	require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &migrations))
+	// SQLite databases are used by a single instance, their migrations are not locked
+	assert.False(t, migrations.Locking)
+	assert.Nil(t, migrations.Status)
+}
+
+func TestAdminBackupDatabase(t *testing.T) {
+	setup := func(t *testing.T, permissions []*accesscontrol.Permission) *scenarioContext {
+		cfg := setting.NewCfg()
+		cfg.DataPath = t.TempDir()
+		sc, hs := setupAccessControlScenarioContext(t, cfg, "/api/admin/database/backup", permissions)
+		hs.SQLStore = sqlstore.InitTestDB(t)
+		sc.resp = httptest.NewRecorder()
+		var err error
+		sc.req, err = http.NewRequest(http.MethodPost, "/api/admin/database/backup", nil)
+		require.NoError(t, err)
+		return sc
+	}
+
+	t.Run("should return a snapshot of the SQLite database", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: ActionServerDatabaseBackup}})
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		assert.Equal(t, "application/vnd.sqlite3", sc.resp.Header().Get("Content-Type"))
+		assert.Contains(t, sc.resp.Header().Get("Content-Disposition"), `attachment; filename="grafana-`)
+		assert.True(t, strings.HasPrefix(sc.resp.Body.String(), "SQLite format 3\x00"))
+	})
+
+	t.Run("should require the permission to back up the database", func(t *testing.T) {
+		sc := setup(t, nil)
+		sc.exec()
+		assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+	})
+}
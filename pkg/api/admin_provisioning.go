@@ -3,9 +3,13 @@ package api
 import (
 	"context"
 	"errors"
+	"net/http"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func (hs *HTTPServer) AdminProvisioningReloadDashboards(c *models.ReqContext) response.Response {
@@ -39,3 +43,65 @@ func (hs *HTTPServer) AdminProvisioningReloadNotifications(c *models.ReqContext)
 	}
 	return response.Success("Notifications config reloaded")
 }
+
+func (hs *HTTPServer) AdminProvisioningReloadGroupMappings(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionGroupMappings(c.Req.Context())
+	if err != nil {
+		return response.Error(500, "Failed to reload group mappings config", err)
+	}
+	return response.Success("Group mappings config reloaded")
+}
+
+func (hs *HTTPServer) AdminProvisioningReloadRoleBundles(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionRoleBundles(c.Req.Context())
+	if err != nil {
+		return response.Error(500, "Failed to reload role bundles config", err)
+	}
+	return response.Success("Role bundles config reloaded")
+}
+
+func (hs *HTTPServer) AdminProvisioningReloadRoleBindings(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionRoleBindings(c.Req.Context())
+	if err != nil {
+		return response.Error(500, "Failed to reload role bindings config", err)
+	}
+	return response.Success("Role bindings config reloaded")
+}
+
+// GET /api/admin/provisioning/drift
+//
+// Compares the resources of the provisioning files with their database state, and returns those modified, deleted
+// or extra.
+func (hs *HTTPServer) AdminProvisioningGetDrift(c *models.ReqContext) response.Response {
+	resources, err := hs.ProvisioningService.GetDrift(c.Req.Context(), c.QueryStrings("kind")...)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrUnknownDriftKind) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to compare the provisioned resources", err)
+	}
+	return response.JSON(http.StatusOK, dtos.ProvisioningDrift{Resources: resources})
+}
+
+// POST /api/admin/provisioning/drift/reconcile
+//
+// Applies the provisioning files to the drifted resources selected by ID.
+func (hs *HTTPServer) AdminProvisioningReconcileDrift(c *models.ReqContext) response.Response {
+	form := dtos.ReconcileProvisioningDriftForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if len(form.Ids) == 0 && !form.All {
+		return response.Error(http.StatusBadRequest, "Select the resources to reconcile with ids or all", nil)
+	}
+	ids := form.Ids
+	if form.All {
+		ids = nil
+	}
+
+	resources, err := hs.ProvisioningService.ReconcileDrift(c.Req.Context(), ids)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reconcile the provisioned resources", err)
+	}
+	return response.JSON(http.StatusOK, dtos.ProvisioningDrift{Resources: resources})
+}
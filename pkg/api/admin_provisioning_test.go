@@ -4,10 +4,12 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 )
@@ -168,3 +170,127 @@ func TestAPI_AdminProvisioningReload_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestAPI_AdminProvisioningDrift_AccessControl(t *testing.T) {
+	resource := &drift.Resource{ID: "datasource/1/Prometheus", Kind: drift.KindDatasource, Status: drift.StatusModified,
+		OrgID: 1, Name: "Prometheus", Fields: []string{"url"}}
+	expectedBody := `{"resources":[{"id":"datasource/1/Prometheus","kind":"datasource","status":"modified","orgId":1,"name":"Prometheus","fields":["url"]}]}`
+
+	tests := []struct {
+		desc         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+		expectedBody string
+		permissions  []*accesscontrol.Permission
+		checkCall    func(mock provisioning.ProvisioningServiceMock)
+	}{
+		{
+			desc:         "should return the drift with read permission",
+			method:       http.MethodGet,
+			url:          "/api/admin/provisioning/drift?kind=datasource",
+			expectedCode: http.StatusOK,
+			expectedBody: expectedBody,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningRead, Scope: ScopeProvisionersAll}},
+			checkCall: func(mock provisioning.ProvisioningServiceMock) {
+				assert.Equal(t, []interface{}{[]string{"datasource"}}, mock.Calls.GetDrift)
+			},
+		},
+		{
+			desc:         "should fail to return the drift with reload permission",
+			method:       http.MethodGet,
+			url:          "/api/admin/provisioning/drift",
+			expectedCode: http.StatusForbidden,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersAll}},
+		},
+		{
+			desc:         "should fail to return the drift of an unknown kind",
+			method:       http.MethodGet,
+			url:          "/api/admin/provisioning/drift?kind=plugin",
+			expectedCode: http.StatusBadRequest,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningRead, Scope: ScopeProvisionersAll}},
+		},
+		{
+			desc:         "should reconcile the selected resources with reload permission",
+			method:       http.MethodPost,
+			url:          "/api/admin/provisioning/drift/reconcile",
+			body:         `{"ids":["datasource/1/Prometheus"]}`,
+			expectedCode: http.StatusOK,
+			expectedBody: expectedBody,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersAll}},
+			checkCall: func(mock provisioning.ProvisioningServiceMock) {
+				assert.Equal(t, []interface{}{[]string{"datasource/1/Prometheus"}}, mock.Calls.ReconcileDrift)
+			},
+		},
+		{
+			desc:         "should reconcile all the resources",
+			method:       http.MethodPost,
+			url:          "/api/admin/provisioning/drift/reconcile",
+			body:         `{"all":true}`,
+			expectedCode: http.StatusOK,
+			expectedBody: expectedBody,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersAll}},
+			checkCall: func(mock provisioning.ProvisioningServiceMock) {
+				assert.Equal(t, []interface{}{[]string(nil)}, mock.Calls.ReconcileDrift)
+			},
+		},
+		{
+			desc:         "should fail to reconcile without selected resources",
+			method:       http.MethodPost,
+			url:          "/api/admin/provisioning/drift/reconcile",
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersAll}},
+			checkCall: func(mock provisioning.ProvisioningServiceMock) {
+				assert.Empty(t, mock.Calls.ReconcileDrift)
+			},
+		},
+		{
+			desc:         "should fail to reconcile with read permission",
+			method:       http.MethodPost,
+			url:          "/api/admin/provisioning/drift/reconcile",
+			body:         `{"all":true}`,
+			expectedCode: http.StatusForbidden,
+			permissions:  []*accesscontrol.Permission{{Action: ActionProvisioningRead, Scope: ScopeProvisionersAll}},
+		},
+	}
+
+	cfg := setting.NewCfg()
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sc, hs := setupAccessControlScenarioContext(t, cfg, test.url, test.permissions)
+
+			provisioningMock := provisioning.NewProvisioningServiceMock(context.Background())
+			provisioningMock.GetDriftFunc = func(ctx context.Context, kinds ...string) ([]*drift.Resource, error) {
+				for _, kind := range kinds {
+					if kind != drift.KindDatasource {
+						return nil, provisioning.ErrUnknownDriftKind
+					}
+				}
+				return []*drift.Resource{resource}, nil
+			}
+			provisioningMock.ReconcileDriftFunc = func(ctx context.Context, ids []string) ([]*drift.Resource, error) {
+				return []*drift.Resource{resource}, nil
+			}
+			hs.ProvisioningService = provisioningMock
+
+			sc.resp = httptest.NewRecorder()
+			var err error
+			sc.req, err = http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			assert.NoError(t, err)
+			sc.req.Header.Set("Content-Type", "application/json")
+
+			sc.exec()
+
+			assert.Equal(t, test.expectedCode, sc.resp.Code)
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, sc.resp.Body.String())
+			}
+			if test.checkCall != nil {
+				test.checkCall(*provisioningMock)
+			}
+		})
+	}
+}
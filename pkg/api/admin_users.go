@@ -9,8 +9,10 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -131,6 +133,36 @@ func AdminDeleteUser(c *models.ReqContext) response.Response {
 	return response.Success("User deleted")
 }
 
+// POST /api/admin/users/:id/restore
+//
+// Restores a user removed from their last organization, with their preferences and stars, and adds them back to this
+// organization. The deleted users are purged once the retention of the deleted users is over.
+func (hs *HTTPServer) AdminRestoreUser(c *models.ReqContext) response.Response {
+	form := dtos.AdminRestoreUserForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if form.Role == "" {
+		form.Role = models.ROLE_VIEWER
+	}
+	if !form.Role.IsValid() {
+		return response.Error(400, "Invalid role specified", nil)
+	}
+
+	cmd := models.RestoreUserCommand{UserId: c.ParamsInt64(":id"), Role: form.Role}
+	if err := hs.SQLStore.RestoreUser(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		if errors.Is(err, models.ErrUserNotDeleted) {
+			return response.Error(400, models.ErrUserNotDeleted.Error(), nil)
+		}
+		return response.Error(500, "Failed to restore user", err)
+	}
+
+	return response.Success("User restored")
+}
+
 // POST /api/admin/users/:id/disable
 func (hs *HTTPServer) AdminDisableUser(c *models.ReqContext) response.Response {
 	userID := c.ParamsInt64(":id")
@@ -195,6 +227,12 @@ func (hs *HTTPServer) AdminGetUserAuthTokens(c *models.ReqContext) response.Resp
 	return hs.getUserAuthTokensInternal(c, userID)
 }
 
+// DELETE /api/admin/users/:id/auth-tokens
+func (hs *HTTPServer) AdminRevokeUserAuthTokens(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":id")
+	return hs.revokeUserAuthTokensInternal(c, userID)
+}
+
 // POST /api/admin/users/:id/revoke-auth-token
 func (hs *HTTPServer) AdminRevokeUserAuthToken(c *models.ReqContext) response.Response {
 	cmd := models.RevokeAuthTokenCmd{}
@@ -205,6 +243,75 @@ func (hs *HTTPServer) AdminRevokeUserAuthToken(c *models.ReqContext) response.Re
 	return hs.revokeUserAuthTokenInternal(c, userID, cmd)
 }
 
+// GET /api/admin/users/:id/lockout
+//
+// Returns whether the brute force login protection locks the user out. The failed logins are counted by the username
+// typed on the login page, so the login and the email of the user are both reported.
+func (hs *HTTPServer) AdminGetUserLockout(c *models.ReqContext) response.Response {
+	userQuery := models.GetUserByIdQuery{Id: c.ParamsInt64(":id")}
+	if err := bus.Dispatch(c.Req.Context(), &userQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(500, "Failed to get user", err)
+	}
+
+	result := dtos.UserLockout{Lockouts: make([]dtos.UsernameLockout, 0, 2)}
+	for _, username := range lockoutUsernames(userQuery.Result) {
+		lockout, err := login.GetLockout(c.Req.Context(), hs.Cfg, username)
+		if err != nil {
+			return response.Error(500, "Failed to get the lockout of the user", err)
+		}
+		result.Locked = result.Locked || lockout.Locked
+		result.Lockouts = append(result.Lockouts, dtos.UsernameLockout{
+			Username:       username,
+			Locked:         lockout.Locked,
+			LockedUntil:    lockout.LockedUntil,
+			FailedAttempts: lockout.FailedAttempts,
+		})
+	}
+	result.Enabled = !hs.Cfg.DisableBruteForceLoginProtection
+	return response.JSON(200, result)
+}
+
+// POST /api/admin/users/:id/unlock
+func (hs *HTTPServer) AdminUnlockUser(c *models.ReqContext) response.Response {
+	userQuery := models.GetUserByIdQuery{Id: c.ParamsInt64(":id")}
+	if err := bus.Dispatch(c.Req.Context(), &userQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(500, "Failed to get user", err)
+	}
+
+	if err := login.Unlock(c.Req.Context(), lockoutUsernames(userQuery.Result)...); err != nil {
+		return response.Error(500, "Failed to unlock user", err)
+	}
+	return response.Success("User unlocked")
+}
+
+// DELETE /api/admin/users/:id/2fa
+//
+// Resets the two-factor authentication of a user who lost their authenticator app and recovery codes.
+func (hs *HTTPServer) AdminResetUserTwoFactor(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":id")
+	if err := hs.twoFactorService.Reset(c.Req.Context(), userID); err != nil {
+		if errors.Is(err, twofactor.ErrTOTPNotEnrolled) {
+			return response.Error(404, err.Error(), nil)
+		}
+		return response.Error(500, "Failed to reset two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication reset")
+}
+
+func lockoutUsernames(user *models.User) []string {
+	usernames := []string{user.Login}
+	if user.Email != "" && user.Email != user.Login {
+		usernames = append(usernames, user.Email)
+	}
+	return usernames
+}
+
 // updateUserPermissions updates the user's permissions.
 //
 // Stubbable by tests.
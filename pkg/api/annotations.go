@@ -1,12 +1,16 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/guardian"
@@ -29,6 +33,10 @@ func GetAnnotations(c *models.ReqContext) response.Response {
 		MatchAny:    c.QueryBool("matchAny"),
 	}
 
+	if calendarUIDs := c.QueryStrings("calendarUid"); len(calendarUIDs) > 0 {
+		return getCalendarAnnotations(c, query, calendarUIDs)
+	}
+
 	repo := annotations.GetRepository()
 
 	items, err := repo.Find(query)
@@ -45,6 +53,69 @@ func GetAnnotations(c *models.ReqContext) response.Response {
 	return response.JSON(200, items)
 }
 
+// GET /api/annotations/export
+//
+// Downloads all the annotations matching the filters of the list as JSON lines, streamed from the database rather
+// than listed in memory. Unlike the list, there is no default limit.
+func ExportAnnotations(c *models.ReqContext) response.Response {
+	query := &annotations.ItemQuery{
+		From:        c.QueryInt64("from"),
+		To:          c.QueryInt64("to"),
+		OrgId:       c.OrgId,
+		UserId:      c.QueryInt64("userId"),
+		AlertId:     c.QueryInt64("alertId"),
+		DashboardId: c.QueryInt64("dashboardId"),
+		PanelId:     c.QueryInt64("panelId"),
+		Limit:       c.QueryInt64("limit"),
+		Tags:        c.QueryStrings("tags"),
+		Type:        c.Query("type"),
+		MatchAny:    c.QueryBool("matchAny"),
+	}
+
+	repo := annotations.GetRepository()
+	return response.Attachment("grafana-annotations.jsonl", "text/plain; charset=utf-8", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return repo.Stream(c.Req.Context(), query, func(item *annotations.ItemDTO) error {
+			if item.Email != "" {
+				item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
+			}
+			return enc.Encode(item)
+		})
+	})
+}
+
+// getCalendarAnnotations returns the occurrences of the events of calendars as annotations, so that
+// panels can show business hours, holidays or deploy freezes.
+func getCalendarAnnotations(c *models.ReqContext, query *annotations.ItemQuery, calendarUIDs []string) response.Response {
+	to := time.Now()
+	if query.To > 0 {
+		to = time.UnixMilli(query.To)
+	}
+	calendarQuery := &models.GetCalendarOccurrencesQuery{
+		OrgId:        c.OrgId,
+		CalendarUids: calendarUIDs,
+		From:         time.UnixMilli(query.From),
+		To:           to,
+	}
+	if err := bus.Dispatch(c.Req.Context(), calendarQuery); err != nil {
+		return response.Error(500, "Failed to get calendar annotations", err)
+	}
+
+	items := make([]*annotations.ItemDTO, 0, len(calendarQuery.Result))
+	for _, occurrence := range calendarQuery.Result {
+		if query.Limit > 0 && int64(len(items)) >= query.Limit {
+			break
+		}
+		items = append(items, &annotations.ItemDTO{
+			Time:    occurrence.Start.UnixMilli(),
+			TimeEnd: occurrence.End.UnixMilli(),
+			Text:    occurrence.Title,
+			Tags:    occurrence.Tags,
+		})
+	}
+	return response.JSON(200, items)
+}
+
 type CreateAnnotationError struct {
 	message string
 }
@@ -253,6 +253,9 @@ func (repo *fakeAnnotationsRepo) Save(item *annotations.Item) error {
 	item.Id = 1
 	return nil
 }
+func (repo *fakeAnnotationsRepo) SaveMany(items []*annotations.Item) error {
+	return nil
+}
 func (repo *fakeAnnotationsRepo) Update(item *annotations.Item) error {
 	return nil
 }
@@ -260,6 +263,9 @@ func (repo *fakeAnnotationsRepo) Find(query *annotations.ItemQuery) ([]*annotati
 	annotations := []*annotations.ItemDTO{{Id: 1}}
 	return annotations, nil
 }
+func (repo *fakeAnnotationsRepo) Stream(_ context.Context, _ *annotations.ItemQuery, fn func(item *annotations.ItemDTO) error) error {
+	return fn(&annotations.ItemDTO{Id: 1})
+}
 func (repo *fakeAnnotationsRepo) FindTags(query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
 	result := annotations.FindTagsResult{
 		Tags: []*annotations.TagsDTO{},
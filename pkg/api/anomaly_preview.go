@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// PreviewAnomalies runs the queries of the request like QueryMetricsV2, except that the anomaly expressions return
+// the values they expect and the bands out of which the points are anomalies, to tune their sensitivity.
+//
+// POST /api/ds/query/anomaly-preview
+func (hs *HTTPServer) PreviewAnomalies(c *models.ReqContext) response.Response {
+	reqDTO := dtos.MetricRequest{}
+	if err := web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	previewed := 0
+	for _, query := range reqDTO.Queries {
+		if expr.IsAnomalyQuery(query) {
+			query.Set("output", expr.AnomalyOutputBands)
+			previewed++
+		}
+	}
+	if previewed == 0 {
+		return response.Error(http.StatusBadRequest, "The request has no anomaly expression", nil)
+	}
+
+	resp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDTO, true)
+	if err != nil {
+		return hs.handleQueryMetricsError(err)
+	}
+	return toJsonStreamingResponse(resp)
+}
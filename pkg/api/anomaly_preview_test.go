@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAPIEndpoint_PreviewAnomalies(t *testing.T) {
+	sc := setupHTTPServer(t, true, true)
+	setInitCtxSignedInViewer(sc.initCtx)
+	setAccessControlPermissions(sc.acmock, []*accesscontrol.Permission{{Action: ActionDatasourcesQuery}}, sc.initCtx.OrgId)
+
+	t.Run("should refuse a request without anomaly expression", func(t *testing.T) {
+		body := `{"queries": [{"refId": "B", "datasource": {"uid": "__expr__"}, "type": "math", "expression": "$A * 2"}]}`
+		response := callAPI(sc.server, http.MethodPost, "/api/ds/query/anomaly-preview", strings.NewReader(body), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should refuse the users who cannot query", func(t *testing.T) {
+		setAccessControlPermissions(sc.acmock, []*accesscontrol.Permission{}, sc.initCtx.OrgId)
+		response := callAPI(sc.server, http.MethodPost, "/api/ds/query/anomaly-preview", strings.NewReader(`{"queries": []}`), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+}
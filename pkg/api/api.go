@@ -32,6 +32,7 @@ func (hs *HTTPServer) registerRoutes() {
 	redirectFromLegacyPanelEditURL := middleware.RedirectFromLegacyPanelEditURL(hs.Cfg)
 	authorize := acmiddleware.Middleware(hs.AccessControl)
 	authorizeInOrg := acmiddleware.AuthorizeInOrgMiddleware(hs.AccessControl, hs.SQLStore)
+	authorizeDataSource := hs.dataSourcePermissions.Middleware
 	quota := middleware.Quota(hs.QuotaService)
 
 	r := hs.RouteRegister
@@ -40,6 +41,7 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/logout", hs.Logout)
 	r.Post("/login", quota("session"), routing.Wrap(hs.LoginPost))
 	r.Get("/login/:name", quota("session"), hs.OAuthLogin)
+	r.Get("/login/plugin/:pluginId/:providerId", quota("session"), hs.PluginLogin)
 	r.Get("/login", hs.LoginView)
 	r.Get("/invite/:code", hs.Index)
 
@@ -161,6 +163,17 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/auth-tokens", routing.Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", routing.Wrap(hs.RevokeUserAuthToken))
+			userRoute.Delete("/auth-tokens", routing.Wrap(hs.RevokeUserAuthTokens))
+
+			userRoute.Get("/access-tokens", routing.Wrap(GetUserAccessTokens))
+			userRoute.Post("/access-tokens", routing.Wrap(hs.AddUserAccessToken))
+			userRoute.Delete("/access-tokens/:id", routing.Wrap(RevokeUserAccessToken))
+
+			userRoute.Get("/2fa", routing.Wrap(hs.GetUserTwoFactor))
+			userRoute.Post("/2fa/totp", routing.Wrap(hs.EnrollUserTOTP))
+			userRoute.Post("/2fa/totp/confirm", routing.Wrap(hs.ConfirmUserTOTP))
+			userRoute.Post("/2fa/totp/disable", routing.Wrap(hs.DisableUserTOTP))
+			userRoute.Post("/2fa/recovery-codes", routing.Wrap(hs.RegenerateUserRecoveryCodes))
 		}, reqSignedInNoAnonymous)
 
 		apiRoute.Group("/users", func(usersRoute routing.RouteRegister) {
@@ -206,11 +219,13 @@ func (hs *HTTPServer) registerRoutes() {
 			userIDScope := ac.Scope("users", "id", ac.Parameter(":userId"))
 			orgRoute.Put("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionOrgsWrite)), routing.Wrap(UpdateCurrentOrg))
 			orgRoute.Put("/address", authorize(reqOrgAdmin, ac.EvalPermission(ActionOrgsWrite)), routing.Wrap(UpdateCurrentOrgAddress))
-			orgRoute.Get("/users", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRead, ac.ScopeUsersAll)), routing.Wrap(hs.GetOrgUsersForCurrentOrg))
-			orgRoute.Get("/users/search", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRead, ac.ScopeUsersAll)), routing.Wrap(hs.SearchOrgUsersWithPaging))
+			orgRoute.Get("/users", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRead)), routing.Wrap(hs.GetOrgUsersForCurrentOrg))
+			orgRoute.Get("/users/search", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRead)), routing.Wrap(hs.SearchOrgUsersWithPaging))
+			orgRoute.Get("/users/export", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRead)), routing.Wrap(hs.ExportOrgUsersForCurrentOrg))
 			orgRoute.Post("/users", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersAdd, ac.ScopeUsersAll)), quota("user"), routing.Wrap(hs.AddOrgUserToCurrentOrg))
 			orgRoute.Patch("/users/:userId", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRoleUpdate, userIDScope)), routing.Wrap(hs.UpdateOrgUserForCurrentOrg))
 			orgRoute.Delete("/users/:userId", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersRemove, userIDScope)), routing.Wrap(hs.RemoveOrgUserForCurrentOrg))
+			orgRoute.Delete("/users/:userId/sessions", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionOrgUsersLogout, userIDScope)), routing.Wrap(hs.RevokeOrgUserSessions))
 
 			// invites
 			orgRoute.Get("/invites", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionUsersCreate)), routing.Wrap(GetPendingOrgInvites))
@@ -224,7 +239,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// current org without requirement of user to be org admin
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
-			orgRoute.Get("/users/lookup", authorize(reqOrgAdminFolderAdminOrTeamAdmin, ac.EvalPermission(ac.ActionOrgUsersRead, ac.ScopeUsersAll)), routing.Wrap(hs.GetOrgUsersForCurrentOrgLookup))
+			orgRoute.Get("/users/lookup", authorize(reqOrgAdminFolderAdminOrTeamAdmin, ac.EvalPermission(ac.ActionOrgUsersRead)), routing.Wrap(hs.GetOrgUsersForCurrentOrgLookup))
 		})
 
 		// create new org
@@ -244,6 +259,7 @@ func (hs *HTTPServer) registerRoutes() {
 			orgsRoute.Post("/users", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ac.ActionOrgUsersAdd, ac.ScopeUsersAll)), routing.Wrap(hs.AddOrgUser))
 			orgsRoute.Patch("/users/:userId", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ac.ActionOrgUsersRoleUpdate, userIDScope)), routing.Wrap(hs.UpdateOrgUser))
 			orgsRoute.Delete("/users/:userId", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ac.ActionOrgUsersRemove, userIDScope)), routing.Wrap(hs.RemoveOrgUser))
+			orgsRoute.Get("/users/:userId/history", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ac.ActionOrgUsersRead, userIDScope)), routing.Wrap(hs.GetOrgUserHistory))
 			orgsRoute.Get("/quotas", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ActionOrgsQuotasRead)), routing.Wrap(hs.GetOrgQuotas))
 			orgsRoute.Put("/quotas/:target", authorizeInOrg(reqGrafanaAdmin, acmiddleware.UseOrgFromContextParams, ac.EvalPermission(ActionOrgsQuotasWrite)), routing.Wrap(hs.UpdateOrgQuota))
 		})
@@ -257,6 +273,8 @@ func (hs *HTTPServer) registerRoutes() {
 			keysRoute.Post("/", authorize(reqOrgAdmin, sa.ActionApikeyAddEv), quota("api_key"), routing.Wrap(hs.AddAPIKey))
 			keysRoute.Post("/additional", authorize(reqOrgAdmin, sa.ActionApikeyAddAdditionalEv), quota("api_key"), routing.Wrap(hs.AdditionalAPIKey))
 			keysRoute.Delete("/:id", authorize(reqOrgAdmin, sa.ActionApikeyRemoveEv), routing.Wrap(DeleteAPIKey))
+			keysRoute.Post("/:id/rotate", authorize(reqOrgAdmin, sa.ActionApikeyAddEv), routing.Wrap(hs.RotateAPIKey))
+			keysRoute.Get("/:id/rotations", authorize(reqOrgAdmin, sa.ActionApikeyListEv), routing.Wrap(GetAPIKeyRotations))
 		}, reqOrgAdmin)
 
 		// Preferences
@@ -266,14 +284,14 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Data sources
 		apiRoute.Group("/datasources", func(datasourceRoute routing.RouteRegister) {
-			datasourceRoute.Get("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead, ScopeDatasourcesAll)), routing.Wrap(hs.GetDataSources))
+			datasourceRoute.Get("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSources))
 			datasourceRoute.Post("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesCreate)), quota("data_source"), routing.Wrap(AddDataSource))
-			datasourceRoute.Put("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceID)), routing.Wrap(hs.UpdateDataSource))
-			datasourceRoute.Delete("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceID)), routing.Wrap(hs.DeleteDataSourceById))
-			datasourceRoute.Delete("/uid/:uid", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceUID)), routing.Wrap(hs.DeleteDataSourceByUID))
+			datasourceRoute.Put("/:id", authorizeDataSource(reqOrgAdmin, ActionDatasourcesWrite), routing.Wrap(hs.UpdateDataSource))
+			datasourceRoute.Delete("/:id", authorizeDataSource(reqOrgAdmin, ActionDatasourcesDelete), routing.Wrap(hs.DeleteDataSourceById))
+			datasourceRoute.Delete("/uid/:uid", authorizeDataSource(reqOrgAdmin, ActionDatasourcesDelete), routing.Wrap(hs.DeleteDataSourceByUID))
 			datasourceRoute.Delete("/name/:name", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceName)), routing.Wrap(hs.DeleteDataSourceByName))
-			datasourceRoute.Get("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead, ScopeDatasourceID)), routing.Wrap(hs.GetDataSourceById))
-			datasourceRoute.Get("/uid/:uid", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead, ScopeDatasourceUID)), routing.Wrap(hs.GetDataSourceByUID))
+			datasourceRoute.Get("/:id", authorizeDataSource(reqOrgAdmin, ActionDatasourcesRead), routing.Wrap(hs.GetDataSourceById))
+			datasourceRoute.Get("/uid/:uid", authorizeDataSource(reqOrgAdmin, ActionDatasourcesRead), routing.Wrap(hs.GetDataSourceByUID))
 			datasourceRoute.Get("/name/:name", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead, ScopeDatasourceName)), routing.Wrap(GetDataSourceByName))
 		})
 
@@ -299,11 +317,12 @@ func (hs *HTTPServer) registerRoutes() {
 		}, reqOrgAdmin)
 
 		apiRoute.Get("/frontend/settings/", hs.GetFrontendSettings)
-		apiRoute.Any("/datasources/proxy/:id/*", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), hs.ProxyDataSourceRequest)
-		apiRoute.Any("/datasources/proxy/:id", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), hs.ProxyDataSourceRequest)
-		apiRoute.Any("/datasources/:id/resources", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), hs.CallDatasourceResource)
-		apiRoute.Any("/datasources/:id/resources/*", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), hs.CallDatasourceResource)
-		apiRoute.Any("/datasources/:id/health", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), routing.Wrap(hs.CheckDatasourceHealth))
+		apiRoute.Get("/frontend/boot", routing.Wrap(hs.GetBootData))
+		apiRoute.Any("/datasources/proxy/:id/*", authorizeDataSource(reqSignedIn, ActionDatasourcesQuery), hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id", authorizeDataSource(reqSignedIn, ActionDatasourcesQuery), hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/:id/resources", authorizeDataSource(reqSignedIn, ActionDatasourcesQuery), hs.CallDatasourceResource)
+		apiRoute.Any("/datasources/:id/resources/*", authorizeDataSource(reqSignedIn, ActionDatasourcesQuery), hs.CallDatasourceResource)
+		apiRoute.Any("/datasources/:id/health", authorizeDataSource(reqSignedIn, ActionDatasourcesQuery), routing.Wrap(hs.CheckDatasourceHealth))
 
 		// Folders
 		apiRoute.Group("/folders", func(folderRoute routing.RouteRegister) {
@@ -320,6 +339,9 @@ func (hs *HTTPServer) registerRoutes() {
 					folderPermissionRoute.Get("/", routing.Wrap(hs.GetFolderPermissionList))
 					folderPermissionRoute.Post("/", routing.Wrap(hs.UpdateFolderPermissions))
 				})
+
+				folderUidRoute.Get("/datasources", routing.Wrap(hs.GetFolderDataSources))
+				folderUidRoute.Put("/datasources", routing.Wrap(hs.SetFolderDataSources))
 			})
 		})
 
@@ -327,6 +349,17 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Group("/dashboards", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/uid/:uid", routing.Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/uid/:uid", routing.Wrap(hs.DeleteDashboardByUID))
+			dashboardRoute.Get("/uid/:uid/effective-access", routing.Wrap(hs.GetDashboardEffectiveAccess))
+			dashboardRoute.Post("/uid/:uid/copy-to-org", routing.Wrap(hs.CopyDashboardToOrg))
+			dashboardRoute.Get("/uid/:uid/events", routing.Wrap(GetDashboardEvents))
+			dashboardRoute.Post("/uid/:uid/events/:eventId/undo", routing.Wrap(hs.UndoDashboardEvent))
+			dashboardRoute.Get("/uid/:uid/variable-graph", routing.Wrap(hs.GetDashboardVariableGraph))
+			dashboardRoute.Get("/uid/:uid/export", routing.Wrap(hs.GetDashboardExport))
+
+			if hs.Live != nil {
+				dashboardRoute.Get("/uid/:uid/editing", routing.Wrap(hs.GetDashboardEditing))
+				dashboardRoute.Post("/uid/:uid/editing/takeover", routing.Wrap(hs.TakeOverDashboardEditing))
+			}
 
 			if hs.ThumbService != nil {
 				dashboardRoute.Get("/uid/:uid/img/:size/:theme", hs.ThumbService.GetImage)
@@ -335,11 +368,21 @@ func (hs *HTTPServer) registerRoutes() {
 
 			dashboardRoute.Post("/calculate-diff", routing.Wrap(CalculateDashboardDiff))
 			dashboardRoute.Post("/trim", routing.Wrap(hs.TrimDashboard))
+			dashboardRoute.Post("/variable-graph", routing.Wrap(CalculateDashboardVariableGraph))
+			dashboardRoute.Post("/validate", routing.Wrap(hs.ValidateDashboard))
 
 			dashboardRoute.Post("/db", routing.Wrap(hs.PostDashboard))
 			dashboardRoute.Get("/home", routing.Wrap(hs.GetHomeDashboard))
 			dashboardRoute.Get("/tags", GetDashboardTags)
+			dashboardRoute.Get("/export", authorize(reqOrgAdmin, ac.EvalPermission(ac.ActionDashboardsRead, ac.ScopeDashboardsAll)), routing.Wrap(hs.ExportDashboards))
 			dashboardRoute.Post("/import", routing.Wrap(hs.ImportDashboard))
+			dashboardRoute.Post("/bulk", reqEditorRole, routing.Wrap(hs.BulkUpdateDashboards))
+
+			dashboardRoute.Group("/trash", func(trashRoute routing.RouteRegister) {
+				trashRoute.Get("/", reqEditorRole, routing.Wrap(hs.GetDashboardTrash))
+				trashRoute.Post("/:id/restore", reqEditorRole, routing.Wrap(hs.RestoreDashboardFromTrash))
+				trashRoute.Delete("/:id", reqOrgAdmin, routing.Wrap(hs.DeleteDashboardTrashEntry))
+			})
 
 			dashboardRoute.Group("/id/:dashboardId", func(dashIdRoute routing.RouteRegister) {
 				dashIdRoute.Get("/versions", routing.Wrap(GetDashboardVersions))
@@ -371,13 +414,16 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Search
 		apiRoute.Get("/search/sorting", routing.Wrap(hs.ListSortOptions))
-		apiRoute.Get("/search/", routing.Wrap(Search))
+		apiRoute.Get("/search/", routing.Wrap(hs.Search))
 
 		// metrics
 		apiRoute.Post("/tsdb/query", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), routing.Wrap(hs.QueryMetrics))
 
 		// DataSource w/ expressions
 		apiRoute.Post("/ds/query", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), routing.Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query/anomaly-preview", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesQuery)), routing.Wrap(hs.PreviewAnomalies))
+		apiRoute.Get("/ds/query/transformations", routing.Wrap(hs.GetQueryTransformations))
+		apiRoute.Post("/ds/query/:queryId/cancel", reqSignedIn, routing.Wrap(hs.CancelQuery))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", routing.Wrap(hs.AlertTest))
@@ -409,6 +455,7 @@ func (hs *HTTPServer) registerRoutes() {
 		})
 
 		apiRoute.Get("/annotations", routing.Wrap(GetAnnotations))
+		apiRoute.Get("/annotations/export", routing.Wrap(ExportAnnotations))
 		apiRoute.Post("/annotations/mass-delete", reqOrgAdmin, routing.Wrap(DeleteAnnotations))
 
 		apiRoute.Group("/annotations", func(annotationsRoute routing.RouteRegister) {
@@ -453,21 +500,48 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// short urls
 		apiRoute.Post("/short-urls", routing.Wrap(hs.createShortURL))
+
+		// API explorer
+		apiRoute.Group("/explorer", func(explorerRoute routing.RouteRegister) {
+			explorerRoute.Get("/operations", routing.Wrap(hs.GetAPIExplorerOperations))
+			explorerRoute.Get("/specs/:name", routing.Wrap(hs.GetAPIExplorerSpec))
+			explorerRoute.Post("/execute", routing.Wrap(hs.ExecuteAPIExplorerRequest))
+		}, reqOrgAdmin)
 	}, reqSignedIn)
 
+	// the diagnostics of the database, /api/health itself is served without authentication by apiHealthHandler
+	r.Get("/api/health/database", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.GetDatabaseHealth))
+
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
 		adminRoute.Get("/settings", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionSettingsRead)), routing.Wrap(hs.AdminGetSettings))
 		adminRoute.Get("/stats", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(AdminGetStats))
 		adminRoute.Post("/pause-all-alerts", reqGrafanaAdmin, routing.Wrap(PauseAllAlerts))
+		adminRoute.Get("/database/stats", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.AdminGetDatabaseStats))
+		adminRoute.Get("/database/migrations", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.AdminGetDatabaseMigrations))
+		adminRoute.Post("/database/backup", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionServerDatabaseBackup)), hs.AdminBackupDatabase)
+
+		adminRoute.Get("/caches", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionServerCachesRead)), routing.Wrap(hs.AdminGetCaches))
+		adminRoute.Get("/caches/:name/keys", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionServerCachesRead)), routing.Wrap(hs.AdminGetCacheKeys))
+		adminRoute.Post("/caches/:name/purge", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionServerCachesWrite)), routing.Wrap(hs.AdminPurgeCache))
+
+		adminRoute.Get("/audit/auth", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionAuditAuthRead)), routing.Wrap(hs.AdminGetAuthAuditEvents))
+		adminRoute.Get("/audit/events", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionAuditRead)), routing.Wrap(hs.AdminGetAuditEvents))
+		adminRoute.Get("/audit/events/export", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionAuditRead)), routing.Wrap(hs.AdminExportAuditEvents))
 
 		adminRoute.Post("/provisioning/dashboards/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersDashboards)), routing.Wrap(hs.AdminProvisioningReloadDashboards))
 		adminRoute.Post("/provisioning/plugins/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersPlugins)), routing.Wrap(hs.AdminProvisioningReloadPlugins))
 		adminRoute.Post("/provisioning/datasources/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersDatasources)), routing.Wrap(hs.AdminProvisioningReloadDatasources))
 		adminRoute.Post("/provisioning/notifications/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersNotifications)), routing.Wrap(hs.AdminProvisioningReloadNotifications))
+		adminRoute.Post("/provisioning/group-mappings/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersGroupMappings)), routing.Wrap(hs.AdminProvisioningReloadGroupMappings))
+		adminRoute.Post("/provisioning/role-bundles/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersRoleBundles)), routing.Wrap(hs.AdminProvisioningReloadRoleBundles))
+		adminRoute.Post("/provisioning/role-bindings/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersRoleBindings)), routing.Wrap(hs.AdminProvisioningReloadRoleBindings))
+		adminRoute.Get("/provisioning/drift", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningRead, ScopeProvisionersAll)), routing.Wrap(hs.AdminProvisioningGetDrift))
+		adminRoute.Post("/provisioning/drift/reconcile", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersAll)), routing.Wrap(hs.AdminProvisioningReconcileDrift))
 
 		adminRoute.Post("/ldap/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPConfigReload)), routing.Wrap(hs.ReloadLDAPCfg))
 		adminRoute.Post("/ldap/sync/:id", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersSync)), routing.Wrap(hs.PostSyncUserWithLDAP))
+		adminRoute.Post("/ldap/test", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersRead)), routing.Wrap(hs.PostTestLDAPMapping))
 		adminRoute.Get("/ldap/:username", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersRead)), routing.Wrap(hs.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPStatusRead)), routing.Wrap(hs.GetLDAPStatus))
 	})
@@ -482,14 +556,29 @@ func (hs *HTTPServer) registerRoutes() {
 		adminUserRoute.Delete("/:id", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersDelete, userIDScope)), routing.Wrap(AdminDeleteUser))
 		adminUserRoute.Post("/:id/disable", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersDisable, userIDScope)), routing.Wrap(hs.AdminDisableUser))
 		adminUserRoute.Post("/:id/enable", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersEnable, userIDScope)), routing.Wrap(AdminEnableUser))
+		adminUserRoute.Post("/:id/restore", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersRestore, userIDScope)), routing.Wrap(hs.AdminRestoreUser))
 		adminUserRoute.Get("/:id/quotas", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersQuotasList, userIDScope)), routing.Wrap(GetUserQuotas))
 		adminUserRoute.Put("/:id/quotas/:target", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersQuotasUpdate, userIDScope)), routing.Wrap(UpdateUserQuota))
+		adminUserRoute.Get("/:id/lockout", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersLockoutRead, userIDScope)), routing.Wrap(hs.AdminGetUserLockout))
+		adminUserRoute.Post("/:id/unlock", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersUnlock, userIDScope)), routing.Wrap(hs.AdminUnlockUser))
+		adminUserRoute.Delete("/:id/2fa", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersTwoFactorDelete, userIDScope)), routing.Wrap(hs.AdminResetUserTwoFactor))
 
 		adminUserRoute.Post("/:id/logout", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersLogout, userIDScope)), routing.Wrap(hs.AdminLogoutUser))
 		adminUserRoute.Get("/:id/auth-tokens", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersAuthTokenList, userIDScope)), routing.Wrap(hs.AdminGetUserAuthTokens))
 		adminUserRoute.Post("/:id/revoke-auth-token", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersAuthTokenUpdate, userIDScope)), routing.Wrap(hs.AdminRevokeUserAuthToken))
+		adminUserRoute.Delete("/:id/auth-tokens", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersAuthTokenUpdate, userIDScope)), routing.Wrap(hs.AdminRevokeUserAuthTokens))
 	})
 
+	// Test fixtures, for the integration tests of external tools
+	if hs.Cfg.TestFixturesEnabled {
+		r.Group("/api/test/fixtures", func(fixturesRoute routing.RouteRegister) {
+			fixturesRoute.Get("/", routing.Wrap(hs.GetTestFixtures))
+			fixturesRoute.Post("/", routing.Wrap(hs.CreateTestFixture))
+			fixturesRoute.Get("/:uid", routing.Wrap(hs.GetTestFixture))
+			fixturesRoute.Delete("/:uid", routing.Wrap(hs.DeleteTestFixture))
+		}, reqGrafanaAdmin)
+	}
+
 	// rendering
 	r.Get("/render/*", reqSignedIn, hs.RenderToPng)
 
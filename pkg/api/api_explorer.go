@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/explorer/operations
+//
+// Lists the explored OpenAPI specifications and their operations.
+func (hs *HTTPServer) GetAPIExplorerOperations(c *models.ReqContext) response.Response {
+	if !hs.apiExplorer.IsEnabled() {
+		return response.Error(http.StatusNotFound, "API explorer is disabled", nil)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"specs":      hs.apiExplorer.Specs(),
+		"operations": hs.apiExplorer.Operations(),
+	})
+}
+
+// GET /api/explorer/specs/:name
+//
+// Returns an explored OpenAPI specification, for the schemas of the operations.
+func (hs *HTTPServer) GetAPIExplorerSpec(c *models.ReqContext) response.Response {
+	if !hs.apiExplorer.IsEnabled() {
+		return response.Error(http.StatusNotFound, "API explorer is disabled", nil)
+	}
+
+	spec := hs.apiExplorer.Spec(web.Params(c.Req)[":name"])
+	if spec == nil {
+		return response.Error(http.StatusNotFound, "OpenAPI specification not found", nil)
+	}
+	return response.Respond(http.StatusOK, []byte(spec.Raw)).SetHeader("Content-Type", "application/json")
+}
+
+// POST /api/explorer/execute
+//
+// Sends a request of an operation of the explored specifications to the instance on behalf of the signed in user,
+// with their permissions in the current organization, and returns the response. The requests are recorded in the
+// audit log.
+func (hs *HTTPServer) ExecuteAPIExplorerRequest(c *models.ReqContext) response.Response {
+	if !hs.apiExplorer.IsEnabled() {
+		return response.Error(http.StatusNotFound, "API explorer is disabled", nil)
+	}
+
+	cmd := apiexplorer.ExecuteCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	result, err := hs.apiExplorer.Execute(c.Req.Context(), hs.web, c.SignedInUser, &cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, apiexplorer.ErrOperationNotFound), errors.Is(err, apiexplorer.ErrPathNotAllowed):
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, apiexplorer.ErrAPIKeyNotAllowed):
+			return response.Error(http.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, apiexplorer.ErrBodyTooLarge):
+			return response.Error(http.StatusRequestEntityTooLarge, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to send request", err)
+	}
+
+	hs.log.Info("API explorer request", "userId", c.UserId, "orgId", c.OrgId, "method", cmd.Method, "path", cmd.Path,
+		"operationId", result.OperationId, "status", result.Status, "durationMs", result.DurationMs)
+
+	event := &audit.Event{OrgId: c.OrgId, UserId: c.UserId, Type: audit.EventTypeAPIExplorer, Action: audit.ActionAPIExplorerRequest, Data: simplejson.New()}
+	event.Data.Set("method", cmd.Method)
+	event.Data.Set("path", cmd.Path)
+	event.Data.Set("operationId", result.OperationId)
+	event.Data.Set("status", result.Status)
+	event.Data.Set("durationMs", result.DurationMs)
+	if err := hs.auditService.Record(c.Req.Context(), event); err != nil {
+		hs.log.Error("Failed to record API explorer request", "userId", c.UserId, "orgId", c.OrgId, "error", err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -10,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -21,6 +24,19 @@ func GetAPIKeys(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to list api keys", err)
 	}
 
+	ids := make([]int64, len(query.Result))
+	for i, t := range query.Result {
+		ids[i] = t.Id
+	}
+	permissionsQuery := models.GetApiKeyPermissionsQuery{OrgId: c.OrgId, ApiKeyIds: ids}
+	if err := bus.Dispatch(c.Req.Context(), &permissionsQuery); err != nil {
+		return response.Error(500, "Failed to list api key permissions", err)
+	}
+	permissions := make(map[int64][]models.ApiKeyPermissionDTO)
+	for _, p := range permissionsQuery.Result {
+		permissions[p.ApiKeyId] = append(permissions[p.ApiKeyId], models.ApiKeyPermissionDTO{Action: p.Action, Scope: p.Scope})
+	}
+
 	result := make([]*models.ApiKeyDTO, len(query.Result))
 	for i, t := range query.Result {
 		var expiration *time.Time = nil
@@ -29,10 +45,12 @@ func GetAPIKeys(c *models.ReqContext) response.Response {
 			expiration = &v
 		}
 		result[i] = &models.ApiKeyDTO{
-			Id:         t.Id,
-			Name:       t.Name,
-			Role:       t.Role,
-			Expiration: expiration,
+			Id:          t.Id,
+			Name:        t.Name,
+			Role:        t.Role,
+			Expiration:  expiration,
+			LastUsedAt:  t.LastUsedAt,
+			Permissions: permissions[t.Id],
 		}
 	}
 
@@ -65,6 +83,21 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext) response.Response {
 	if err := web.Bind(c.Req, &cmd); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
+	if len(cmd.Permissions) > 0 {
+		// The role of permission-scoped keys applies to the endpoints without fine-grained access control, it is
+		// limited to Viewer for the permissions to restrict the key
+		if cmd.Role == "" {
+			cmd.Role = models.ROLE_VIEWER
+		}
+		if cmd.Role != models.ROLE_VIEWER {
+			return response.Error(400, "Permission-scoped API keys can only have the Viewer role", nil)
+		}
+		permissions, errResp := hs.validateAPIKeyPermissions(c, cmd.Permissions)
+		if errResp != nil {
+			return errResp
+		}
+		cmd.Permissions = permissions
+	}
 	if !cmd.Role.IsValid() {
 		return response.Error(400, "Invalid role specified", nil)
 	}
@@ -137,6 +170,142 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext) response.Response {
 	return response.JSON(200, result)
 }
 
+// RotateAPIKey replaces the key of an API key. The previous key remains valid for a grace period,
+// so that the clients using it can be updated without downtime.
+func (hs *HTTPServer) RotateAPIKey(c *models.ReqContext) response.Response {
+	form := dtos.RotateApiKeyForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	gracePeriod := hs.Cfg.ApiKeyRotationGracePeriod
+	if form.GracePeriodSeconds != nil {
+		requested := time.Duration(*form.GracePeriodSeconds) * time.Second
+		if requested < 0 {
+			return response.Error(400, "Grace period should not be negative", nil)
+		}
+		if requested > gracePeriod {
+			return response.Error(400, "Grace period is greater than the global limit", nil)
+		}
+		gracePeriod = requested
+	}
+	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 && form.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
+		return response.Error(400, "Number of seconds before expiration is greater than the global limit", nil)
+	}
+
+	apikey, errResp := getAPIKeyInOrg(c, c.ParamsInt64(":id"))
+	if errResp != nil {
+		return errResp
+	}
+
+	newKeyInfo, err := apikeygen.New(apikey.OrgId, apikey.Name)
+	if err != nil {
+		return response.Error(500, "Generating API key failed", err)
+	}
+
+	cmd := models.RotateApiKeyCommand{
+		Id:            apikey.Id,
+		OrgId:         apikey.OrgId,
+		Key:           newKeyInfo.HashedKey,
+		SecondsToLive: form.SecondsToLive,
+		GracePeriod:   gracePeriod,
+		RotatedBy:     c.UserId,
+	}
+	if err := bus.Dispatch(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKeyExpiration) {
+			return response.Error(400, err.Error(), nil)
+		}
+		if errors.Is(err, models.ErrApiKeyNotFound) {
+			return response.Error(404, "API key not found", err)
+		}
+		return response.Error(500, "Failed to rotate API key", err)
+	}
+
+	return response.JSON(200, &dtos.RotatedApiKeyResult{
+		ID:                    cmd.Result.Id,
+		Name:                  cmd.Result.Name,
+		Key:                   newKeyInfo.ClientSecret,
+		PreviousKeyExpiration: cmd.Rotation.PreviousKeyExpires,
+	})
+}
+
+// GetAPIKeyRotations returns the rotation history of an API key
+func GetAPIKeyRotations(c *models.ReqContext) response.Response {
+	apikey, errResp := getAPIKeyInOrg(c, c.ParamsInt64(":id"))
+	if errResp != nil {
+		return errResp
+	}
+
+	query := models.GetApiKeyRotationsQuery{ApiKeyId: apikey.Id, OrgId: apikey.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		return response.Error(500, "Failed to list API key rotations", err)
+	}
+
+	result := make([]*models.ApiKeyRotationDTO, len(query.Result))
+	for i, r := range query.Result {
+		result[i] = &models.ApiKeyRotationDTO{
+			RotatedAt:             r.RotatedAt,
+			RotatedBy:             r.RotatedBy,
+			PreviousKeyExpiration: r.PreviousKeyExpires,
+		}
+	}
+
+	return response.JSON(200, result)
+}
+
+// validateAPIKeyPermissions checks that the permissions of a new API key are valid and granted to the
+// user creating it, and returns them without duplicates.
+func (hs *HTTPServer) validateAPIKeyPermissions(c *models.ReqContext, permissions []models.ApiKeyPermissionDTO) ([]models.ApiKeyPermissionDTO, response.Response) {
+	if hs.AccessControl.IsDisabled() {
+		return nil, response.Error(400, "Permission-scoped API keys require fine-grained access control", nil)
+	}
+
+	seen := make(map[models.ApiKeyPermissionDTO]bool)
+	result := make([]models.ApiKeyPermissionDTO, 0, len(permissions))
+	for _, p := range permissions {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		if p.Action == "" || strings.HasPrefix(p.Action, accesscontrol.DenyKey("")) {
+			return nil, response.Error(400, fmt.Sprintf("Invalid permission action %q", p.Action), nil)
+		}
+		var scopes []string
+		if p.Scope != "" {
+			if !accesscontrol.ValidateScope(p.Scope) {
+				return nil, response.Error(400, fmt.Sprintf("Invalid permission scope %q", p.Scope), nil)
+			}
+			scopes = append(scopes, p.Scope)
+		}
+
+		evaluator := accesscontrol.EvalPermission(p.Action, scopes...)
+		hasAccess, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, evaluator)
+		if err != nil {
+			return nil, response.Error(500, "Failed to evaluate API key permissions", err)
+		}
+		if !hasAccess {
+			return nil, response.Error(403, fmt.Sprintf("API key permissions must be a subset of your permissions, you are not granted %s", evaluator.String()), nil)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func getAPIKeyInOrg(c *models.ReqContext, id int64) (*models.ApiKey, response.Response) {
+	query := models.GetApiKeyByIdQuery{ApiKeyId: id}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKey) {
+			return nil, response.Error(404, "API key not found", err)
+		}
+		return nil, response.Error(500, "Failed to get API key", err)
+	}
+	if query.Result.OrgId != c.OrgId {
+		return nil, response.Error(404, "API key not found", nil)
+	}
+	return query.Result, nil
+}
+
 // AddAPIKey adds an additional API key to a service account
 func (hs *HTTPServer) AdditionalAPIKey(c *models.ReqContext) response.Response {
 	cmd := models.AddApiKeyCommand{}
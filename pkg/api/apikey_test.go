@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	sa "github.com/grafana/grafana/pkg/services/serviceaccounts/manager"
+)
+
+func TestAPIEndpoint_AddAPIKey_Permissions(t *testing.T) {
+	sc := setupHTTPServer(t, true, true)
+	sc.hs.Cfg.ApiKeyMaxSecondsToLive = -1
+	bus.AddHandler("test", sc.db.AddAPIKey)
+	bus.AddHandler("test", sc.db.GetAPIKeys)
+	bus.AddHandler("test", sc.db.GetAPIKeyPermissions)
+	t.Cleanup(bus.ClearBusHandlers)
+	setInitCtxSignedInOrgAdmin(sc.initCtx)
+	setAccessControlPermissions(sc.acmock, []*accesscontrol.Permission{
+		{Action: sa.ActionApikeyAdd},
+		{Action: sa.ActionApikeyList},
+		{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:*"},
+	}, sc.initCtx.OrgId)
+
+	t.Run("should add keys with a subset of the permissions of the user", func(t *testing.T) {
+		body := `{"name": "scoped", "permissions": [{"action": "datasources:query", "scope": "datasources:uid:abc"}, {"action": "datasources:query", "scope": "datasources:uid:abc"}]}`
+		response := callAPI(sc.server, http.MethodPost, "/api/auth/keys", strings.NewReader(body), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		response = callAPI(sc.server, http.MethodGet, "/api/auth/keys", nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+		var keys []models.ApiKeyDTO
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &keys))
+		require.Len(t, keys, 1)
+		assert.Equal(t, models.ROLE_VIEWER, keys[0].Role)
+		assert.Equal(t, []models.ApiKeyPermissionDTO{{Action: "datasources:query", Scope: "datasources:uid:abc"}}, keys[0].Permissions)
+	})
+
+	t.Run("should not add keys with permissions the user is not granted", func(t *testing.T) {
+		body := `{"name": "escalated", "permissions": [{"action": "users:write", "scope": "global:users:*"}]}`
+		response := callAPI(sc.server, http.MethodPost, "/api/auth/keys", strings.NewReader(body), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should not add keys with invalid permissions", func(t *testing.T) {
+		for _, permission := range []string{
+			`{"action": "", "scope": "datasources:*"}`,
+			`{"action": "!datasources:query", "scope": "datasources:*"}`,
+			`{"action": "datasources:query", "scope": "datasources:*:abc"}`,
+		} {
+			body := `{"name": "invalid", "permissions": [` + permission + `]}`
+			response := callAPI(sc.server, http.MethodPost, "/api/auth/keys", strings.NewReader(body), t)
+			assert.Equal(t, http.StatusBadRequest, response.Code, permission)
+		}
+	})
+}
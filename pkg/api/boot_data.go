@@ -0,0 +1,191 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Sections of the boot data the frontend can request
+const (
+	bootDataSectionNavTree        = "navTree"
+	bootDataSectionSettings       = "settings"
+	bootDataSectionUser           = "user"
+	bootDataSectionUserOrgs       = "userOrgs"
+	bootDataSectionFeatureToggles = "featureToggles"
+)
+
+var bootDataSections = []string{
+	bootDataSectionNavTree,
+	bootDataSectionSettings,
+	bootDataSectionUser,
+	bootDataSectionUserOrgs,
+	bootDataSectionFeatureToggles,
+}
+
+// GET /api/frontend/boot
+//
+// Returns the sections of the boot data requested with the section query parameter, or all of them, so that the
+// frontend does not have to reload the whole boot data. Each section has an ETag, and the sections whose ETag is
+// sent in If-None-Match are not returned again.
+func (hs *HTTPServer) GetBootData(c *models.ReqContext) response.Response {
+	sections := c.QueryStrings("section")
+	if len(sections) == 0 {
+		sections = bootDataSections
+	}
+	for _, section := range sections {
+		if !isBootDataSection(section) {
+			return response.Error(http.StatusBadRequest, fmt.Sprintf("Unknown boot data section %q", section), nil)
+		}
+	}
+
+	knownETags := map[string]bool{}
+	for _, etag := range strings.Split(c.Req.Header.Get("If-None-Match"), ",") {
+		if etag = strings.TrimSpace(etag); etag != "" {
+			knownETags[etag] = true
+		}
+	}
+
+	data := dtos.BootData{
+		Sections: map[string]json.RawMessage{},
+		ETags:    map[string]string{},
+	}
+	builder := &bootDataBuilder{hs: hs, c: c}
+	for _, section := range sections {
+		if _, ok := data.ETags[section]; ok {
+			continue
+		}
+		value, err := builder.section(section)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get the boot data", err)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to encode the boot data", err)
+		}
+		etag := bootDataETag(section, encoded)
+		data.ETags[section] = etag
+		if knownETags[etag] {
+			data.NotModified = append(data.NotModified, section)
+			continue
+		}
+		data.Sections[section] = encoded
+	}
+
+	if len(data.Sections) == 0 {
+		return response.CreateNormalResponse(http.Header{"Cache-Control": {"private, no-cache"}}, nil, http.StatusNotModified)
+	}
+	return response.JSON(http.StatusOK, data).SetHeader("Cache-Control", "private, no-cache")
+}
+
+// bootDataBuilder builds the sections of the boot data, loading what several sections share once
+type bootDataBuilder struct {
+	hs *HTTPServer
+	c  *models.ReqContext
+
+	hasEditPerm *bool
+	user        *dtos.CurrentUser
+}
+
+func (b *bootDataBuilder) section(section string) (interface{}, error) {
+	switch section {
+	case bootDataSectionNavTree:
+		return b.navTree()
+	case bootDataSectionSettings:
+		settings, err := b.hs.getIndexSettings(b.c)
+		if err != nil {
+			return nil, err
+		}
+		// The feature toggles are their own section
+		delete(settings, "featureToggles")
+		return settings, nil
+	case bootDataSectionUser:
+		return b.currentUser()
+	case bootDataSectionUserOrgs:
+		query := models.GetUserOrgListQuery{UserId: b.c.UserId}
+		if b.c.UserId != 0 {
+			if err := bus.Dispatch(b.c.Req.Context(), &query); err != nil {
+				return nil, err
+			}
+		}
+		if query.Result == nil {
+			query.Result = []*models.UserOrgDTO{}
+		}
+		return query.Result, nil
+	case bootDataSectionFeatureToggles:
+		return b.hs.Cfg.FeatureToggles, nil
+	}
+	return nil, fmt.Errorf("unknown boot data section %q", section)
+}
+
+func (b *bootDataBuilder) navTree() ([]*dtos.NavLink, error) {
+	hasEditPerm, err := b.hasEditPermissionInFolders()
+	if err != nil {
+		return nil, err
+	}
+	navTree, err := b.hs.getNavTree(b.c, hasEditPerm)
+	if err != nil {
+		return nil, err
+	}
+	user, err := b.currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	// The hooks only get the nav tree and the user, the other sections are not built for them
+	data := &dtos.IndexViewData{User: user, Settings: map[string]interface{}{}, NavTree: navTree}
+	b.hs.runIndexDataHooks(data, b.c)
+	return data.NavTree, nil
+}
+
+func (b *bootDataBuilder) currentUser() (*dtos.CurrentUser, error) {
+	if b.user != nil {
+		return b.user, nil
+	}
+	hasEditPerm, err := b.hasEditPermissionInFolders()
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := b.hs.getCurrentUser(b.c, hasEditPerm)
+	if err != nil {
+		return nil, err
+	}
+	b.user = user
+	return user, nil
+}
+
+func (b *bootDataBuilder) hasEditPermissionInFolders() (bool, error) {
+	if b.hasEditPerm != nil {
+		return *b.hasEditPerm, nil
+	}
+	hasEditPerm, err := b.hs.hasEditPermissionInFolders(b.c)
+	if err != nil {
+		return false, err
+	}
+	b.hasEditPerm = &hasEditPerm
+	return hasEditPerm, nil
+}
+
+func isBootDataSection(section string) bool {
+	for _, s := range bootDataSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// bootDataETag returns the ETag of the encoded section. It includes the section so that sections with the same
+// content have different ETags.
+func bootDataETag(section string, encoded []byte) string {
+	hash := sha256.Sum256(encoded)
+	return fmt.Sprintf(`"%s-%s"`, section, hex.EncodeToString(hash[:16]))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPServer_GetBootData(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.FeatureToggles = map[string]bool{"panelTitleSearch": true}
+	m, hs := setupTestEnvironment(t, cfg)
+	m.Get("/api/frontend/boot", routing.Wrap(hs.GetBootData))
+
+	getBootData := func(t *testing.T, url string, etags string) (*httptest.ResponseRecorder, dtos.BootData) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if etags != "" {
+			req.Header.Set("If-None-Match", etags)
+		}
+		recorder := httptest.NewRecorder()
+		m.ServeHTTP(recorder, req)
+
+		data := dtos.BootData{}
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &data))
+		}
+		return recorder, data
+	}
+
+	t.Run("should only return the requested sections", func(t *testing.T) {
+		recorder, data := getBootData(t, "/api/frontend/boot?section=featureToggles&section=settings", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Len(t, data.Sections, 2)
+		require.Len(t, data.ETags, 2)
+		assert.JSONEq(t, `{"panelTitleSearch":true}`, string(data.Sections[bootDataSectionFeatureToggles]))
+
+		settings := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data.Sections[bootDataSectionSettings], &settings))
+		assert.Contains(t, settings, "buildInfo")
+		assert.NotContains(t, settings, "featureToggles")
+	})
+
+	t.Run("should not return the sections whose ETag is known", func(t *testing.T) {
+		_, data := getBootData(t, "/api/frontend/boot?section=featureToggles&section=userOrgs", "")
+		etag := data.ETags[bootDataSectionFeatureToggles]
+		require.NotEmpty(t, etag)
+
+		recorder, data := getBootData(t, "/api/frontend/boot?section=featureToggles&section=userOrgs", `"other", `+etag)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{bootDataSectionFeatureToggles}, data.NotModified)
+		assert.NotContains(t, data.Sections, bootDataSectionFeatureToggles)
+		assert.JSONEq(t, `[]`, string(data.Sections[bootDataSectionUserOrgs]))
+		assert.Equal(t, etag, data.ETags[bootDataSectionFeatureToggles])
+	})
+
+	t.Run("should return not modified when all the ETags are known", func(t *testing.T) {
+		_, data := getBootData(t, "/api/frontend/boot?section=featureToggles", "")
+
+		recorder, _ := getBootData(t, "/api/frontend/boot?section=featureToggles", data.ETags[bootDataSectionFeatureToggles])
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+		assert.Empty(t, recorder.Body.Bytes())
+	})
+
+	t.Run("should change the ETag of a modified section", func(t *testing.T) {
+		_, data := getBootData(t, "/api/frontend/boot?section=featureToggles", "")
+		hs.Cfg.FeatureToggles = map[string]bool{"panelTitleSearch": false}
+		t.Cleanup(func() { hs.Cfg.FeatureToggles = map[string]bool{"panelTitleSearch": true} })
+
+		recorder, modified := getBootData(t, "/api/frontend/boot?section=featureToggles", data.ETags[bootDataSectionFeatureToggles])
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.NotEqual(t, data.ETags[bootDataSectionFeatureToggles], modified.ETags[bootDataSectionFeatureToggles])
+		assert.JSONEq(t, `{"panelTitleSearch":false}`, string(modified.Sections[bootDataSectionFeatureToggles]))
+	})
+
+	t.Run("should fail for an unknown section", func(t *testing.T) {
+		recorder, _ := getBootData(t, "/api/frontend/boot?section=unknown", "")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -22,10 +22,13 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
 	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/ossaccesscontrol"
 	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/clientcert"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	dspermissions "github.com/grafana/grafana/pkg/services/datasources/permissions"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/searchusers"
@@ -176,7 +179,9 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg) *contexthandler.ContextHa
 	userAuthTokenSvc := auth.NewFakeUserAuthTokenService()
 	renderSvc := &fakeRenderService{}
 	authJWTSvc := models.NewFakeJWTService()
-	ctxHdlr := contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore)
+	clientCertSvc, err := clientcert.ProvideService(cfg)
+	require.NoError(t, err)
+	ctxHdlr := contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore, clientCertSvc, nil, nil, nil, nil)
 
 	return ctxHdlr
 }
@@ -225,6 +230,10 @@ func setupAccessControlScenarioContext(t *testing.T, cfg *setting.Cfg, url strin
 		searchUsersService: searchusers.ProvideUsersService(bus, filters.ProvideOSSSearchUserFilter()),
 	}
 
+	dataSourcePermissions, err := dspermissions.ProvideService(nil, hs.AccessControl, nil, hs.RouteRegister)
+	require.NoError(t, err)
+	hs.dataSourcePermissions = dataSourcePermissions
+
 	sc := setupScenarioContext(t, url)
 
 	hs.registerRoutes()
@@ -326,7 +335,8 @@ func setupHTTPServer(t *testing.T, useFakeAccessControl bool, enableAccessContro
 		}
 		hs.AccessControl = acmock
 	} else {
-		ac = ossaccesscontrol.ProvideService(cfg, &usagestats.UsageStatsMock{T: t})
+		acStore := database.ProvideService(db)
+		ac = ossaccesscontrol.ProvideService(cfg, hs.Bus, &usagestats.UsageStatsMock{T: t}, acStore, acStore, acStore, acStore, acStore, acStore, hs.RouteRegister, nil)
 		hs.AccessControl = ac
 		// Perform role registration
 		err := hs.declareFixedRoles()
@@ -335,6 +345,10 @@ func setupHTTPServer(t *testing.T, useFakeAccessControl bool, enableAccessContro
 		require.NoError(t, err)
 	}
 
+	dataSourcePermissions, err := dspermissions.ProvideService(db, hs.AccessControl, database.ProvideService(db), hs.RouteRegister)
+	require.NoError(t, err)
+	hs.dataSourcePermissions = dataSourcePermissions
+
 	// Instantiate a new Server
 	m := web.New()
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -105,6 +106,10 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) response.Response {
 	canSave, _ := guardian.CanSave()
 	canAdmin, _ := guardian.CanAdmin()
 
+	if hs.dashboardUsage != nil {
+		hs.dashboardUsage.RecordView(c.OrgId, dash.Uid)
+	}
+
 	isStarred, err := isDashboardStarredByUser(c, dash.Id)
 	if err != nil {
 		return response.Error(500, "Error while checking if dashboard was starred by user", err)
@@ -255,7 +260,7 @@ func (hs *HTTPServer) deleteDashboard(c *models.ReqContext) response.Response {
 	}
 
 	svc := dashboards.NewService(hs.SQLStore)
-	err = svc.DeleteDashboard(c.Req.Context(), dash.Id, c.OrgId)
+	err = svc.DeleteDashboard(c.Req.Context(), dash.Id, c.OrgId, c.UserId)
 	if err != nil {
 		var dashboardErr models.DashboardErr
 		if ok := errors.As(err, &dashboardErr); ok {
@@ -339,6 +344,21 @@ func (hs *HTTPServer) postDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		allowUiUpdate = hs.ProvisioningService.GetAllowUIUpdatesFromConfig(provisioningData.Name)
 	}
 
+	if hs.Live != nil && !newDashboard {
+		// Only the holder of the exclusive editing lock can save, the other editors have to take over first
+		editing, err := hs.Live.GrafanaScope.Dashboards.CheckEditingLock(ctx, c.OrgId, dash.Uid, c.UserId)
+		if errors.Is(err, models.ErrDashboardEditingLocked) {
+			return response.JSON(http.StatusLocked, util.DynMap{
+				"status":   "locked",
+				"message":  err.Error(),
+				"lockedBy": editing.LockedBy,
+			})
+		}
+		if err != nil {
+			return response.Error(500, "Error while checking the dashboard editing lock", err)
+		}
+	}
+
 	// clean up all unnecessary library panels JSON properties so we store a minimum JSON
 	err = hs.LibraryPanelService.CleanLibraryPanelsForDashboard(dash)
 	if err != nil {
@@ -430,6 +450,11 @@ func (hs *HTTPServer) dashboardSaveErrorToApiResponse(ctx context.Context, err e
 		return response.Error(422, validationErr.Error(), err)
 	}
 
+	var dataSourceErr models.DashboardDataSourceNotAllowedError
+	if ok := errors.As(err, &dataSourceErr); ok {
+		return response.JSON(400, util.DynMap{"status": "datasource-not-allowed", "message": dataSourceErr.Error(), "dataSources": dataSourceErr.DataSources})
+	}
+
 	var pluginErr models.UpdatePluginDashboardError
 	if ok := errors.As(err, &pluginErr); ok {
 		message := fmt.Sprintf("The dashboard belongs to plugin %s.", pluginErr.PluginId)
@@ -527,6 +552,19 @@ func (hs *HTTPServer) addGettingStartedPanelToHomeDashboard(c *models.ReqContext
 	dash.Set("panels", panels)
 }
 
+// GET /api/dashboards/export
+//
+// Downloads the dashboards of the current organization as JSON lines, each in the form of the body of a dashboard
+// save, streamed from the database rather than loaded in memory.
+func (hs *HTTPServer) ExportDashboards(c *models.ReqContext) response.Response {
+	return response.Attachment("grafana-dashboards.jsonl", "text/plain; charset=utf-8", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return hs.SQLStore.StreamDashboards(c.Req.Context(), c.OrgId, func(dash *models.Dashboard) error {
+			return enc.Encode(dtos.DashboardExport{Dashboard: dash.Data, FolderId: dash.FolderId})
+		})
+	})
+}
+
 // GetDashboardVersions returns all dashboard versions as JSON
 func GetDashboardVersions(c *models.ReqContext) response.Response {
 	dashID := c.ParamsInt64(":dashboardId")
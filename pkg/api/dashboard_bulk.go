@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// BulkUpdateDashboards moves, tags, untags or changes the data source of a set of dashboards, saved within a single
+// transaction. With dryRun, the changes are checked and returned without saving the dashboards.
+//
+// POST /api/dashboards/bulk
+func (hs *HTTPServer) BulkUpdateDashboards(c *models.ReqContext) response.Response {
+	cmd := dashboards.BulkUpdateDashboardsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgId = c.OrgId
+	cmd.User = c.SignedInUser
+
+	ctx := alerting.WithUAEnabled(c.Req.Context(), hs.Cfg.UnifiedAlerting.IsEnabled())
+	result, err := dashboards.NewService(hs.SQLStore).BulkUpdateDashboards(ctx, &cmd)
+	if err != nil {
+		if errors.Is(err, dashboards.ErrInvalidBulkUpdate) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		// The errors of a dashboard have the status of the errors of a single save, with the uid of the dashboard
+		var bulkErr dashboards.BulkUpdateError
+		var dashboardErr models.DashboardErr
+		if errors.As(err, &bulkErr) && errors.As(bulkErr.Err, &dashboardErr) {
+			return response.JSON(dashboardErr.StatusCode, util.DynMap{
+				"status":  dashboardErr.Status,
+				"message": bulkErr.Error(),
+				"uid":     bulkErr.Uid,
+			})
+		}
+		return hs.dashboardSaveErrorToApiResponse(ctx, err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/orgreadonly"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// CopyDashboardToOrg copies a dashboard, and optionally its folder and library panels, to another organization
+// administered by the signed in user. Data sources are mapped to the data sources of the target organization
+// using the mapping of the request, data sources without mapping are mapped to the data source with the same uid.
+//
+// POST /api/dashboards/uid/:uid/copy-to-org
+func (hs *HTTPServer) CopyDashboardToOrg(c *models.ReqContext) response.Response {
+	cmd := dtos.CopyDashboardToOrgCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.OrgId == c.OrgId {
+		return response.Error(http.StatusBadRequest, "Dashboard cannot be copied to the same organization", nil)
+	}
+
+	ctx := c.Req.Context()
+	dash, rsp := getDashboardHelper(ctx, c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(ctx, dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	// The copy is saved on behalf of the user in the target organization
+	targetUserQuery := models.GetSignedInUserQuery{UserId: c.UserId, OrgId: cmd.OrgId}
+	if err := bus.Dispatch(ctx, &targetUserQuery); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user of the target organization", err)
+	}
+	targetUser := targetUserQuery.Result
+	if targetUser.OrgId != cmd.OrgId || targetUser.OrgRole != models.ROLE_ADMIN {
+		return response.Error(http.StatusForbidden, "Dashboards can only be copied to organizations you are an administrator of", nil)
+	}
+
+	// The read-only middleware only checks the organization the user is signed in to
+	if hs.orgReadOnlyService != nil && !c.IsGrafanaAdmin {
+		var readOnlyErr orgreadonly.ReadOnlyError
+		if err := hs.orgReadOnlyService.CheckWritable(ctx, cmd.OrgId); err != nil {
+			if errors.As(err, &readOnlyErr) {
+				return response.JSON(http.StatusForbidden, readOnlyErr.Response())
+			}
+			return response.Error(http.StatusServiceUnavailable, "Failed to check the read-only state of the organization", err)
+		}
+	}
+
+	targetContext := *c
+	targetContext.SignedInUser = targetUser
+	limitReached, err := hs.QuotaService.QuotaReached(&targetContext, "dashboard")
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to get quota", err)
+	}
+	if limitReached {
+		return response.Error(http.StatusForbidden, "Quota reached", nil)
+	}
+
+	if err := hs.LibraryPanelService.LoadLibraryPanelsForDashboard(ctx, dash); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while loading library panels", err)
+	}
+	if !cmd.CopyLibraryPanels {
+		unlinkLibraryPanels(dash.Data)
+	}
+
+	unmapped, err := dashboards.RemapDashboardDataSources(ctx, dash.Data, c.OrgId, copyDataSourceMapping(cmd))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while mapping data sources", err)
+	}
+	if len(unmapped) > 0 {
+		return response.JSON(http.StatusBadRequest, util.DynMap{
+			"status":      "datasource-not-mapped",
+			"message":     "The dashboard references data sources that are not mapped to a data source of the target organization",
+			"dataSources": unmapped,
+		})
+	}
+
+	// Saving the copy would otherwise silently replace a dashboard with the same uid and version
+	if !cmd.Overwrite {
+		existing := models.GetDashboardQuery{Uid: dash.Uid, OrgId: cmd.OrgId}
+		err := bus.Dispatch(ctx, &existing)
+		if err == nil {
+			return hs.dashboardSaveErrorToApiResponse(ctx, models.ErrDashboardWithSameUIDExists)
+		}
+		if !errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(http.StatusInternalServerError, "Failed to get dashboard of the target organization", err)
+		}
+	}
+
+	folderID, rsp := hs.getCopyTargetFolder(c, cmd, dash, targetUser)
+	if rsp != nil {
+		return rsp
+	}
+
+	dash.Data.Del("id")
+	copied := models.NewDashboardFromJson(dash.Data)
+	copied.OrgId = cmd.OrgId
+	copied.FolderId = folderID
+
+	if err := hs.LibraryPanelService.ImportLibraryPanelsForDashboard(ctx, targetUser, copied, folderID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while importing library panels", err)
+	}
+	if err := hs.LibraryPanelService.CleanLibraryPanelsForDashboard(copied); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while cleaning library panels", err)
+	}
+
+	dashItem := &dashboards.SaveDashboardDTO{
+		Dashboard: copied,
+		Message:   fmt.Sprintf("Copied from organization %s", c.OrgName),
+		OrgId:     cmd.OrgId,
+		User:      targetUser,
+		Overwrite: cmd.Overwrite,
+	}
+	saved, err := dashboards.NewService(hs.SQLStore).SaveDashboard(alerting.WithUAEnabled(ctx, hs.Cfg.UnifiedAlerting.IsEnabled()), dashItem, true)
+	if err != nil {
+		return hs.dashboardSaveErrorToApiResponse(ctx, err)
+	}
+
+	if err := hs.LibraryPanelService.ConnectLibraryPanelsForDashboard(ctx, targetUser, saved); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while connecting library panels", err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"status":   "success",
+		"orgId":    saved.OrgId,
+		"folderId": saved.FolderId,
+		"slug":     saved.Slug,
+		"version":  saved.Version,
+		"id":       saved.Id,
+		"uid":      saved.Uid,
+		"url":      saved.GetUrl(),
+	})
+}
+
+// getCopyTargetFolder returns the id of the folder of the target organization the dashboard is copied to.
+// When requested, the folder of the dashboard is created in the target organization if it does not exist yet.
+func (hs *HTTPServer) getCopyTargetFolder(c *models.ReqContext, cmd dtos.CopyDashboardToOrgCommand, dash *models.Dashboard,
+	targetUser *models.SignedInUser) (int64, response.Response) {
+	ctx := c.Req.Context()
+	targetFolders := dashboards.NewFolderService(cmd.OrgId, targetUser, hs.SQLStore)
+
+	if cmd.FolderUid != "" {
+		folder, err := targetFolders.GetFolderByUID(ctx, cmd.FolderUid)
+		if err != nil {
+			if errors.Is(err, models.ErrFolderNotFound) {
+				return 0, response.Error(http.StatusBadRequest, "Folder not found", err)
+			}
+			return 0, apierrors.ToFolderErrorResponse(err)
+		}
+		return folder.Id, nil
+	}
+
+	if !cmd.CopyFolder || dash.FolderId == 0 {
+		return 0, nil
+	}
+
+	source, err := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore).GetFolderByID(ctx, dash.FolderId)
+	if err != nil {
+		return 0, apierrors.ToFolderErrorResponse(err)
+	}
+
+	folder, err := targetFolders.GetFolderByUID(ctx, source.Uid)
+	if errors.Is(err, models.ErrFolderNotFound) {
+		folder, err = targetFolders.CreateFolder(ctx, source.Title, source.Uid)
+	}
+	if err != nil {
+		return 0, apierrors.ToFolderErrorResponse(err)
+	}
+	return folder.Id, nil
+}
+
+// copyDataSourceMapping maps the data sources of the source organization to the data sources of the target organization
+func copyDataSourceMapping(cmd dtos.CopyDashboardToOrgCommand) dashboards.DataSourceMapping {
+	return func(ctx context.Context, uid string) (*models.DataSource, error) {
+		targetUID, ok := cmd.DataSourceMapping[uid]
+		if !ok {
+			targetUID = uid
+		}
+
+		query := models.GetDataSourceQuery{Uid: targetUID, OrgId: cmd.OrgId}
+		if err := bus.Dispatch(ctx, &query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return query.Result, nil
+	}
+}
+
+// unlinkLibraryPanels turns the library panels loaded into the dashboard into regular panels
+func unlinkLibraryPanels(parent *simplejson.Json) {
+	for _, p := range parent.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(p)
+		unlinkLibraryPanels(panel)
+		panel.Del("libraryPanel")
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/orgreadonly"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestAPIEndpoint_CopyDashboardToOrg(t *testing.T) {
+	ctx := context.Background()
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.LibraryPanelService = librarypanels.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister(),
+		libraryelements.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister()))
+	// Data sources are looked up through the bus, the handler is registered by the data sources service.
+	// The dashboard and user handlers registered by the sqlstore package can be cleared by previous tests.
+	sc.hs.Bus.AddHandler(sc.db.GetDataSource)
+	sc.hs.Bus.AddHandler(sqlstore.GetDashboard)
+	sc.hs.Bus.AddHandler(sqlstore.GetSignedInUser)
+	sc.hs.Bus.AddHandler(sqlstore.GetOrgById)
+	sc.hs.orgReadOnlyService = orgreadonly.ProvideService(kvstore.ProvideService(sc.db), routing.NewRouteRegister(), localcache.ProvideRegistry())
+
+	user, err := sc.db.CreateUser(ctx, models.CreateUserCommand{Login: "admin", OrgName: "source"})
+	require.NoError(t, err)
+	target, err := sc.db.CreateOrgWithMember("target", user.Id)
+	require.NoError(t, err)
+	viewerOrg, err := sc.db.CreateOrgWithMember("viewer", 1000)
+	require.NoError(t, err)
+	require.NoError(t, sc.db.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: viewerOrg.Id, UserId: user.Id, Role: models.ROLE_VIEWER}))
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: user.Id, OrgId: user.OrgId, OrgName: "source", OrgRole: models.ROLE_ADMIN, Login: user.Login})
+
+	require.NoError(t, sc.db.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: user.OrgId, Name: "Prometheus", Uid: "prom", Type: "prometheus", Access: models.DS_ACCESS_PROXY}))
+	require.NoError(t, sc.db.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: target.Id, Name: "Prometheus EU", Uid: "prom-eu", Type: "prometheus", Access: models.DS_ACCESS_PROXY}))
+
+	folder, err := sc.db.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     user.OrgId,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Services", "uid": "services"}),
+	})
+	require.NoError(t, err)
+	_, err = sc.db.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:    user.OrgId,
+		FolderId: folder.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "API",
+			"uid":   "api",
+			"panels": []interface{}{
+				map[string]interface{}{"datasource": map[string]interface{}{"uid": "prom", "type": "prometheus"}},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	copyURL := "/api/dashboards/uid/api/copy-to-org"
+
+	t.Run("should not copy to the same organization", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, copyURL, strings.NewReader(`{"orgId": 1}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should not copy to an organization the user is not an administrator of", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(map[string]interface{}{"orgId": viewerOrg.Id}), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should require all data sources to be mapped", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(map[string]interface{}{"orgId": target.Id}), t)
+		require.Equal(t, http.StatusBadRequest, response.Code)
+		body, err := simplejson.NewJson(response.Body.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, "datasource-not-mapped", body.Get("status").MustString())
+		assert.Equal(t, []interface{}{"prom"}, body.Get("dataSources").MustArray())
+	})
+
+	request := map[string]interface{}{
+		"orgId":             target.Id,
+		"copyFolder":        true,
+		"dataSourceMapping": map[string]string{"prom": "prom-eu"},
+	}
+
+	t.Run("should not copy to a read-only organization", func(t *testing.T) {
+		admin := &models.SignedInUser{UserId: user.Id, IsGrafanaAdmin: true}
+		_, err := sc.hs.orgReadOnlyService.SetState(ctx, admin, target.Id, orgreadonly.SetStateCommand{ReadOnly: true, Reason: "Frozen"})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := sc.hs.orgReadOnlyService.SetState(ctx, admin, target.Id, orgreadonly.SetStateCommand{})
+			require.NoError(t, err)
+		})
+
+		response := callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(request), t)
+		require.Equal(t, http.StatusForbidden, response.Code)
+		body, err := simplejson.NewJson(response.Body.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, "Frozen", body.Get("reason").MustString())
+
+		_, err = sc.db.GetDashboard(0, target.Id, "api", "")
+		assert.ErrorIs(t, err, models.ErrDashboardNotFound)
+	})
+
+	t.Run("should copy dashboard with its folder and mapped data sources", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(request), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		dash, err := sc.db.GetDashboard(0, target.Id, "api", "")
+		require.NoError(t, err)
+		assert.Equal(t, "prom-eu", dash.Data.Get("panels").GetIndex(0).GetPath("datasource", "uid").MustString())
+
+		folder, err := sc.db.GetDashboard(dash.FolderId, target.Id, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "services", folder.Uid)
+		assert.Equal(t, "Services", folder.Title)
+	})
+
+	t.Run("should not replace an existing dashboard unless asked to", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(request), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+
+		request["overwrite"] = true
+		response = callAPI(sc.server, http.MethodPost, copyURL, mockRequestBody(request), t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GetDashboardEditing returns the users editing a dashboard, and the holder of its exclusive editing lock.
+//
+// GET /api/dashboards/uid/:uid/editing
+func (hs *HTTPServer) GetDashboardEditing(c *models.ReqContext) response.Response {
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	state, err := hs.Live.GrafanaScope.Dashboards.GetEditingState(c.Req.Context(), c.OrgId, dash.Uid)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard editors", err)
+	}
+	return response.JSON(http.StatusOK, state)
+}
+
+// TakeOverDashboardEditing gives the exclusive editing lock of a dashboard to the signed in user. The previous
+// holder is notified over Grafana Live and can no longer save the dashboard.
+//
+// POST /api/dashboards/uid/:uid/editing/takeover
+func (hs *HTTPServer) TakeOverDashboardEditing(c *models.ReqContext) response.Response {
+	cmd := dtos.TakeOverDashboardEditingCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if !hs.Cfg.LiveExclusiveDashboardEditing {
+		return response.Error(http.StatusBadRequest, "Exclusive dashboard editing is not enabled", nil)
+	}
+
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	state, err := hs.Live.GrafanaScope.Dashboards.TakeOverEditing(c.Req.Context(), c.OrgId, dash.Uid, c.SignedInUser.ToUserDisplayDTO(), cmd.SessionId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to take over dashboard editing", err)
+	}
+	return response.JSON(http.StatusOK, state)
+}
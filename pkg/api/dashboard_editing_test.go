@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/live/features"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestAPIEndpoint_DashboardEditing(t *testing.T) {
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.LibraryPanelService = librarypanels.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister(),
+		libraryelements.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister()))
+	// The dashboard handlers registered by the sqlstore package can be cleared by previous tests.
+	sc.hs.Bus.AddHandler(sqlstore.GetDashboard)
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN, Login: "admin"})
+
+	sc.hs.Cfg.LiveExclusiveDashboardEditing = true
+	dashboards := sc.hs.Live.GrafanaScope.Dashboards.(*features.DashboardHandler)
+	dashboards.Cache = remotecache.NewFakeStore(t)
+	dashboards.ExclusiveEditing = true
+
+	dash, err := sc.db.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Edited", "uid": "edited"}),
+	})
+	require.NoError(t, err)
+
+	_, err = dashboards.EditingStarted(context.Background(), 1, "edited", &models.UserDisplayDTO{Id: 2, Login: "other"}, "other-session")
+	require.NoError(t, err)
+
+	save := func(t *testing.T) int {
+		t.Helper()
+		body := mockRequestBody(map[string]interface{}{
+			"dashboard": map[string]interface{}{"id": dash.Id, "uid": "edited", "title": "Edited", "version": dash.Version},
+			"overwrite": true,
+		})
+		response := callAPI(sc.server, http.MethodPost, "/api/dashboards/db", body, t)
+		return response.Code
+	}
+
+	t.Run("should list the editors of the dashboard", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/edited/editing", nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var state models.DashboardEditingState
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &state))
+		require.Len(t, state.Editors, 1)
+		assert.Equal(t, "other", state.LockedBy.User.Login)
+	})
+
+	t.Run("should not save a dashboard locked by another user", func(t *testing.T) {
+		assert.Equal(t, http.StatusLocked, save(t))
+	})
+
+	t.Run("should save after taking over", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, "/api/dashboards/uid/edited/editing/takeover", mockRequestBody(map[string]string{"sessionId": "session"}), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		assert.Equal(t, http.StatusOK, save(t))
+	})
+}
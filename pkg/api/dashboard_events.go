@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashevents"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GetDashboardEvents returns the activity feed of a dashboard, the events recorded for its changes, latest first.
+//
+// GET /api/dashboards/uid/:uid/events
+func GetDashboardEvents(c *models.ReqContext) response.Response {
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	query := models.GetDashboardEventsQuery{
+		OrgId:       c.OrgId,
+		DashboardId: dash.Id,
+		Limit:       c.QueryInt("limit"),
+		Start:       c.QueryInt("start"),
+	}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard events", err)
+	}
+
+	for _, e := range query.Result {
+		if e.CreatedBy == "" {
+			e.CreatedBy = anonString
+		}
+	}
+
+	return response.JSON(http.StatusOK, query.Result)
+}
+
+// UndoDashboardEvent reverts the change of a single event, the dashboard is saved as a new version.
+//
+// POST /api/dashboards/uid/:uid/events/:eventId/undo
+func (hs *HTTPServer) UndoDashboardEvent(c *models.ReqContext) response.Response {
+	eventID, err := strconv.ParseInt(web.Params(c.Req)[":eventId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "eventId is invalid", err)
+	}
+
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	eventQuery := models.GetDashboardEventQuery{Id: eventID, DashboardId: dash.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &eventQuery); err != nil {
+		if errors.Is(err, models.ErrDashboardEventNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard event not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard event", err)
+	}
+	event := eventQuery.Result
+
+	if err := dashevents.Undo(dash.Data, event); err != nil {
+		switch {
+		case errors.Is(err, dashevents.ErrConflict):
+			return response.Error(http.StatusConflict, "The dashboard has changed since the event, it cannot be undone", err)
+		case errors.Is(err, dashevents.ErrNotUndoable):
+			return response.Error(http.StatusBadRequest, "The event cannot be undone", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to undo dashboard event", err)
+	}
+
+	saveCmd := models.SaveDashboardCommand{}
+	saveCmd.OrgId = c.OrgId
+	saveCmd.UserId = c.UserId
+	saveCmd.Dashboard = dash.Data
+	saveCmd.Dashboard.Set("version", dash.Version)
+	saveCmd.Dashboard.Set("uid", dash.Uid)
+	saveCmd.Message = fmt.Sprintf("Undo %s from version %d", event.Type, event.Version)
+	saveCmd.FolderId = dash.FolderId
+
+	return hs.postDashboard(c, saveCmd)
+}
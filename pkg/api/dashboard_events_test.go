@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestAPIEndpoint_DashboardEvents(t *testing.T) {
+	ctx := context.Background()
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.LibraryPanelService = librarypanels.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister(),
+		libraryelements.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister()))
+	// The dashboard handlers registered by the sqlstore package can be cleared by previous tests.
+	sc.hs.Bus.AddHandler(sqlstore.GetDashboard)
+	sc.hs.Bus.AddHandler(sc.db.GetDashboardEvents)
+	sc.hs.Bus.AddHandler(sc.db.GetDashboardEvent)
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN, Login: "admin"})
+
+	dash, err := sc.db.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Renamed", "uid": "events"}),
+	})
+	require.NoError(t, err)
+
+	insertEvent := func(t *testing.T, event models.DashboardEvent) int64 {
+		t.Helper()
+		event.OrgId = 1
+		event.DashboardId = dash.Id
+		event.Version = dash.Version
+		event.Created = time.Now()
+		err := sc.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&event)
+			return err
+		})
+		require.NoError(t, err)
+		return event.Id
+	}
+	createdID := insertEvent(t, models.DashboardEvent{Type: models.DashboardEventCreated})
+	renamedID := insertEvent(t, models.DashboardEvent{
+		Type:     models.DashboardEventPropertyChanged,
+		Path:     "title",
+		OldValue: simplejson.NewFromAny("Events"),
+		NewValue: simplejson.NewFromAny("Renamed"),
+	})
+
+	undoURL := func(id int64) string {
+		return fmt.Sprintf("/api/dashboards/uid/events/events/%d/undo", id)
+	}
+
+	t.Run("should list the events of the dashboard", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/events/events", nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var events []models.DashboardEventDTO
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &events))
+		require.Len(t, events, 2)
+		assert.Equal(t, renamedID, events[0].Id)
+		assert.Equal(t, "title", events[0].Path)
+		assert.Equal(t, anonString, events[0].CreatedBy)
+	})
+
+	t.Run("should undo an event", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, undoURL(renamedID), nil, t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		undone, err := sc.db.GetDashboard(0, 1, "events", "")
+		require.NoError(t, err)
+		assert.Equal(t, "Events", undone.Title)
+		assert.Equal(t, dash.Version+1, undone.Version)
+	})
+
+	t.Run("should not undo an event changed since", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, undoURL(renamedID), nil, t)
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("should not undo the creation of the dashboard", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, undoURL(createdID), nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should return not found for unknown events", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, undoURL(renamedID+100), nil, t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/dashexport"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GetDashboardExport returns the dashboard as exported for sharing by the frontend: the data sources and the constants
+// are replaced with inputs chosen on import, and the plugins it requires are listed. The library panels are exported
+// as references with their models in the __elements, or inlined with the libraryPanels=inline query parameter.
+//
+// GET /api/dashboards/uid/:uid/export
+func (hs *HTTPServer) GetDashboardExport(c *models.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	dash, rsp := getDashboardHelper(ctx, c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(ctx, dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	if err := hs.LibraryPanelService.LoadLibraryPanelsForDashboard(ctx, dash); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while loading library panels", err)
+	}
+
+	resolver := &dashboardExportResolver{ctx: ctx, orgID: c.OrgId, hs: hs}
+	exported, err := dashexport.Export(dash.Data, resolver, dashexport.Options{
+		GrafanaVersion: setting.BuildVersion,
+		LibraryPanels:  dashexport.LibraryPanelMode(c.Query("libraryPanels")),
+	})
+	if err != nil {
+		if errors.Is(err, dashexport.ErrInvalidLibraryPanelMode) || errors.Is(err, dashexport.ErrDataSourceNotFound) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to export dashboard", err)
+	}
+
+	return response.JSON(http.StatusOK, exported)
+}
+
+// dashboardExportResolver looks up the data sources of the organization and the installed plugins
+type dashboardExportResolver struct {
+	ctx   context.Context
+	orgID int64
+	hs    *HTTPServer
+}
+
+func (r *dashboardExportResolver) DataSource(ref string) (*dashexport.DataSource, error) {
+	var ds *models.DataSource
+	if ref == "" {
+		query := models.GetDefaultDataSourceQuery{OrgId: r.orgID}
+		if err := r.hs.SQLStore.GetDefaultDataSource(r.ctx, &query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		ds = query.Result
+	} else {
+		// The dashboards saved before the references by uid reference the data sources by name
+		query := models.GetDataSourceQuery{OrgId: r.orgID, Uid: ref}
+		err := r.hs.SQLStore.GetDataSource(r.ctx, &query)
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			query = models.GetDataSourceQuery{OrgId: r.orgID, Name: ref}
+			err = r.hs.SQLStore.GetDataSource(r.ctx, &query)
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		ds = query.Result
+	}
+
+	result := &dashexport.DataSource{Name: ds.Name, Type: ds.Type, PluginName: ds.Type}
+	if plugin, exists := r.hs.pluginStore.Plugin(r.ctx, ds.Type); exists {
+		result.PluginName = plugin.Name
+		result.PluginVersion = plugin.Info.Version
+	}
+	return result, nil
+}
+
+func (r *dashboardExportResolver) Panel(panelType string) *dashexport.Plugin {
+	plugin, exists := r.hs.pluginStore.Plugin(r.ctx, panelType)
+	if !exists || plugin.Type != plugins.Panel {
+		return nil
+	}
+	return &dashexport.Plugin{ID: plugin.ID, Name: plugin.Name, Version: plugin.Info.Version}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestAPIEndpoint_DashboardExport(t *testing.T) {
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.LibraryPanelService = librarypanels.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister(),
+		libraryelements.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister()))
+	sc.hs.pluginStore = &fakePluginStore{plugins: map[string]plugins.PluginDTO{
+		"prometheus": {JSONData: plugins.JSONData{ID: "prometheus", Type: plugins.DataSource, Name: "Prometheus", Info: plugins.Info{Version: "1.0.0"}}},
+		"timeseries": {JSONData: plugins.JSONData{ID: "timeseries", Type: plugins.Panel, Name: "Time series"}},
+	}}
+	// The dashboard handlers registered by the sqlstore package can be cleared by previous tests.
+	sc.hs.Bus.AddHandler(sqlstore.GetDashboard)
+	bus.AddHandler("sql", sc.db.GetDashboardAclInfoList)
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER, Login: "viewer"})
+
+	require.NoError(t, sc.db.AddDataSource(context.Background(), &models.AddDataSourceCommand{
+		OrgId: 1, Name: "Prod Metrics", Type: "prometheus", Uid: "prom-uid", Access: models.DS_ACCESS_PROXY,
+	}))
+
+	save := func(t *testing.T, uid string, panels ...interface{}) {
+		t.Helper()
+		data := simplejson.NewFromAny(map[string]interface{}{"title": uid, "uid": uid, "panels": panels})
+		_, err := sc.db.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Dashboard: data})
+		require.NoError(t, err)
+	}
+
+	t.Run("should export the dashboard with inputs", func(t *testing.T) {
+		save(t, "export", map[string]interface{}{"id": 1, "type": "timeseries", "datasource": "Prod Metrics"})
+
+		response := callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/export/export", nil, t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		exported, err := simplejson.NewJson(response.Body.Bytes())
+		require.NoError(t, err)
+
+		assert.Nil(t, exported.Get("id").Interface())
+		assert.Equal(t, "${DS_PROD_METRICS}", exported.Get("panels").GetIndex(0).Get("datasource").MustString())
+		assert.Equal(t, "Prometheus", exported.Get("__inputs").GetIndex(0).Get("pluginName").MustString())
+		assert.Len(t, exported.Get("__requires").MustArray(), 3)
+	})
+
+	t.Run("should reject unknown data sources and modes", func(t *testing.T) {
+		save(t, "unknown", map[string]interface{}{"id": 1, "type": "timeseries", "datasource": "unknown"})
+
+		response := callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/unknown/export", nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+
+		response = callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/export/export?libraryPanels=copy", nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+
+		response = callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/missing/export", nil, t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -116,6 +121,298 @@ func (hs *HTTPServer) UpdateDashboardPermissions(c *models.ReqContext) response.
 	return response.Success("Dashboard permissions updated")
 }
 
+// GET /api/dashboards/uid/:uid/effective-access
+func (hs *HTTPServer) GetDashboardEffectiveAccess(c *models.ReqContext) response.Response {
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		return dashboardGuardianResponse(err)
+	}
+
+	acl, err := g.GetAcl()
+	if err != nil {
+		return response.Error(500, "Failed to get dashboard permissions", err)
+	}
+
+	var folder *models.Dashboard
+	if dash.FolderId > 0 {
+		if folder, rsp = getDashboardHelper(c.Req.Context(), c.OrgId, dash.FolderId, ""); rsp != nil {
+			return rsp
+		}
+	}
+
+	usersQuery := models.GetOrgUsersQuery{OrgId: c.OrgId}
+	if err := hs.SQLStore.GetOrgUsers(c.Req.Context(), &usersQuery); err != nil {
+		return response.Error(500, "Failed to get users for organization", err)
+	}
+
+	// The members of all the teams of the organization are loaded at once, only the ones of the teams in the acl are used
+	teamMembers := map[int64][]*models.TeamMemberDTO{}
+	membersQuery := models.GetTeamMembersQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &membersQuery); err != nil {
+		return response.Error(500, "Failed to get Team Members", err)
+	}
+	for _, member := range membersQuery.Result {
+		teamMembers[member.TeamId] = append(teamMembers[member.TeamId], member)
+	}
+
+	grants, err := hs.getDashboardAccessControlGrants(c.Req.Context(), c.OrgId, dash, folder, usersQuery.Result)
+	if err != nil {
+		return response.Error(500, "Failed to get user permissions", err)
+	}
+
+	result := resolveDashboardEffectiveAccess(acl, dash, folder, usersQuery.Result, teamMembers, grants)
+
+	filteredUsers := make([]*dtos.DashboardEffectiveUserAccess, 0, len(result.Users))
+	for _, user := range result.Users {
+		if dtos.IsHiddenUser(user.Login, c.SignedInUser, hs.Cfg) {
+			continue
+		}
+		user.AvatarURL = dtos.GetGravatarUrl(user.Email)
+		filteredUsers = append(filteredUsers, user)
+	}
+	result.Users = filteredUsers
+
+	for _, team := range result.Teams {
+		team.AvatarURL = dtos.GetGravatarUrlWithDefault(team.Email, team.Name)
+	}
+
+	return response.JSON(200, result)
+}
+
+// getDashboardAccessControlGrants returns, keyed by user id, the dashboard and folder permissions the users hold
+// through access control roles on the dashboard or its folder. It returns nil when access control is disabled.
+func (hs *HTTPServer) getDashboardAccessControlGrants(ctx context.Context, orgID int64, dash, folder *models.Dashboard,
+	orgUsers []*models.OrgUserDTO) (map[int64][]*accesscontrol.Permission, error) {
+	if hs.AccessControl == nil || hs.AccessControl.IsDisabled() {
+		return nil, nil
+	}
+
+	users := make([]*models.SignedInUser, 0, len(orgUsers))
+	for _, u := range orgUsers {
+		users = append(users, &models.SignedInUser{UserId: u.UserId, OrgId: orgID, OrgRole: models.RoleType(u.Role), Login: u.Login})
+	}
+
+	return hs.AccessControl.GetUsersPermissions(ctx, users, accesscontrol.GetUsersPermissionsQuery{
+		OrgID:          orgID,
+		Scopes:         dashboardAccessControlScopes(dash, folder),
+		ActionPrefixes: []string{"dashboards", "folders"},
+	})
+}
+
+// dashboardAccessControlScopes returns the scopes of the permissions granting access to the dashboard: the ones of
+// the dashboard, of its folder and the wildcards covering them
+func dashboardAccessControlScopes(dash, folder *models.Dashboard) []string {
+	scopes := []string{
+		"*",
+		accesscontrol.ScopeDashboardsAll,
+		accesscontrol.GetResourceAllAttributeScope("dashboards", "uid"),
+		accesscontrol.GetResourceAttributeScope("dashboards", "uid", dash.Uid),
+		accesscontrol.ScopeFoldersAll,
+		accesscontrol.GetResourceAllAttributeScope("folders", "uid"),
+	}
+	if folder != nil {
+		scopes = append(scopes, accesscontrol.GetResourceAttributeScope("folders", "uid", folder.Uid))
+	}
+	return scopes
+}
+
+// accessControlPermission returns the dashboard permission matching the access control actions allowed on the
+// dashboard. Each action is evaluated with all the grants and denies of the user, on the dashboard scope for the
+// dashboard actions and on the folder scope for the folder actions, so that denies override grants.
+func accessControlPermission(permissions []*accesscontrol.Permission, dash, folder *models.Dashboard) models.PermissionType {
+	dashboardScope := accesscontrol.GetResourceAttributeScope("dashboards", "uid", dash.Uid)
+	folderScope := ""
+	if folder != nil {
+		folderScope = accesscontrol.GetResourceAttributeScope("folders", "uid", folder.Uid)
+	}
+	scopes := accesscontrol.GroupScopesByAction(inheritFolderPermissions(permissions, dashboardScope, folderScope))
+
+	var permission models.PermissionType
+	evaluated := make(map[string]bool)
+	for _, p := range permissions {
+		if p.Deny || evaluated[p.Action] {
+			continue
+		}
+		evaluated[p.Action] = true
+
+		target := dashboardScope
+		if !strings.HasPrefix(p.Action, "dashboards") {
+			if folderScope == "" {
+				continue
+			}
+			target = folderScope
+		}
+		if allowed, err := accesscontrol.EvalPermission(p.Action, target).Evaluate(scopes); err != nil || !allowed {
+			continue
+		}
+
+		granted := models.PERMISSION_VIEW
+		switch {
+		case strings.HasPrefix(p.Action, "dashboards.permissions:") || strings.HasPrefix(p.Action, "folders.permissions:"):
+			granted = models.PERMISSION_ADMIN
+		case !strings.HasSuffix(p.Action, ":read"):
+			granted = models.PERMISSION_EDIT
+		}
+		if granted > permission {
+			permission = granted
+		}
+	}
+	return permission
+}
+
+// inheritFolderPermissions adds the dashboard scope to the dashboard actions granted, or denied, on the folder of the
+// dashboard: dashboards inherit them from their folder
+func inheritFolderPermissions(permissions []*accesscontrol.Permission, dashboardScope, folderScope string) []*accesscontrol.Permission {
+	if folderScope == "" {
+		return permissions
+	}
+	result := make([]*accesscontrol.Permission, 0, len(permissions))
+	for _, p := range permissions {
+		result = append(result, p)
+		if !strings.HasPrefix(p.Action, "dashboards") || p.Scope == "" {
+			continue
+		}
+		covered, err := accesscontrol.EvalPermission(p.Action, folderScope).Evaluate(map[string][]string{p.Action: {p.Scope}})
+		if err == nil && covered {
+			result = append(result, &accesscontrol.Permission{Action: p.Action, Scope: dashboardScope, Deny: p.Deny})
+		}
+	}
+	return result
+}
+
+// resolveDashboardEffectiveAccess expands the dashboard acl into the users and teams it applies to and computes
+// the highest permission each of them has. Organization admins always have admin permission on every dashboard, and
+// the users holding access control permissions on the dashboard are listed even without acl item.
+func resolveDashboardEffectiveAccess(acl []*models.DashboardAclInfoDTO, dash, folder *models.Dashboard, orgUsers []*models.OrgUserDTO,
+	teamMembers map[int64][]*models.TeamMemberDTO, grants map[int64][]*accesscontrol.Permission) *dtos.DashboardEffectiveAccess {
+	users := map[int64]*dtos.DashboardEffectiveUserAccess{}
+	orgUsersByID := make(map[int64]*models.OrgUserDTO, len(orgUsers))
+	for _, u := range orgUsers {
+		orgUsersByID[u.UserId] = u
+	}
+
+	grant := func(orgUser *models.OrgUserDTO, source *dtos.DashboardAccessSource) {
+		user, ok := users[orgUser.UserId]
+		if !ok {
+			user = &dtos.DashboardEffectiveUserAccess{
+				UserID:  orgUser.UserId,
+				Login:   orgUser.Login,
+				Email:   orgUser.Email,
+				OrgRole: models.RoleType(orgUser.Role),
+				Sources: []*dtos.DashboardAccessSource{},
+			}
+			users[orgUser.UserId] = user
+		}
+
+		user.Sources = append(user.Sources, source)
+		if source.Permission > user.Permission {
+			user.Permission = source.Permission
+		}
+	}
+
+	newSource := func(sourceType string, item *models.DashboardAclInfoDTO) *dtos.DashboardAccessSource {
+		source := &dtos.DashboardAccessSource{
+			Type:           sourceType,
+			TeamID:         item.TeamId,
+			Team:           item.Team,
+			Role:           item.Role,
+			Permission:     item.Permission,
+			PermissionName: item.Permission.String(),
+			Inherited:      item.Inherited,
+		}
+		if item.Inherited && folder != nil {
+			source.FolderUID = folder.Uid
+			source.FolderTitle = folder.Title
+		}
+		return source
+	}
+
+	teams := map[int64]*dtos.DashboardEffectiveTeamAccess{}
+	for _, item := range acl {
+		switch {
+		case item.UserId > 0:
+			if orgUser, ok := orgUsersByID[item.UserId]; ok {
+				grant(orgUser, newSource("user", item))
+			}
+		case item.TeamId > 0:
+			team, ok := teams[item.TeamId]
+			if !ok {
+				team = &dtos.DashboardEffectiveTeamAccess{TeamID: item.TeamId, Name: item.Team, Email: item.TeamEmail}
+				teams[item.TeamId] = team
+			}
+			if item.Permission > team.Permission {
+				team.Permission = item.Permission
+				team.Inherited = item.Inherited
+			}
+
+			for _, member := range teamMembers[item.TeamId] {
+				if orgUser, ok := orgUsersByID[member.UserId]; ok {
+					grant(orgUser, newSource("team", item))
+				}
+			}
+		case item.Role != nil:
+			for _, orgUser := range orgUsers {
+				if models.RoleType(orgUser.Role) == *item.Role {
+					grant(orgUser, newSource("role", item))
+				}
+			}
+		}
+	}
+
+	for _, orgUser := range orgUsers {
+		if models.RoleType(orgUser.Role) == models.ROLE_ADMIN {
+			grant(orgUser, &dtos.DashboardAccessSource{
+				Type:           "orgAdmin",
+				Permission:     models.PERMISSION_ADMIN,
+				PermissionName: models.PERMISSION_ADMIN.String(),
+			})
+		}
+	}
+
+	for _, orgUser := range orgUsers {
+		permissions := grants[orgUser.UserId]
+		if len(permissions) == 0 {
+			continue
+		}
+		permission := accessControlPermission(permissions, dash, folder)
+		if permission > 0 {
+			grant(orgUser, &dtos.DashboardAccessSource{
+				Type:           "accessControl",
+				Permission:     permission,
+				PermissionName: permission.String(),
+			})
+		}
+		if user, ok := users[orgUser.UserId]; ok {
+			for _, p := range permissions {
+				user.AccessControl = append(user.AccessControl, dtos.DashboardAccessControlGrant{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+			}
+		}
+	}
+
+	result := &dtos.DashboardEffectiveAccess{
+		Users: make([]*dtos.DashboardEffectiveUserAccess, 0, len(users)),
+		Teams: make([]*dtos.DashboardEffectiveTeamAccess, 0, len(teams)),
+	}
+	for _, user := range users {
+		user.PermissionName = user.Permission.String()
+		result.Users = append(result.Users, user)
+	}
+	for _, team := range teams {
+		team.PermissionName = team.Permission.String()
+		result.Teams = append(result.Teams, team)
+	}
+
+	sort.Slice(result.Users, func(i, j int) bool { return result.Users[i].Login < result.Users[j].Login })
+	sort.Slice(result.Teams, func(i, j int) bool { return result.Teams[i].Name < result.Teams[j].Name })
+
+	return result
+}
+
 func validatePermissionsUpdate(apiCmd dtos.UpdateDashboardAclCommand) error {
 	for _, item := range apiCmd.Items {
 		if item.UserID > 0 && item.TeamID > 0 {
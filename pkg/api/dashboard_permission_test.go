@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/dashboards"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -446,3 +447,183 @@ func updateDashboardPermissionScenario(t *testing.T, ctx updatePermissionContext
 		ctx.fn(sc)
 	})
 }
+
+func TestDashboardEffectiveAccessAPIEndpoint(t *testing.T) {
+	hs := &HTTPServer{Cfg: setting.NewCfg()}
+
+	t.Run("Given user has no admin permissions", func(t *testing.T) {
+		origNewGuardian := guardian.New
+		t.Cleanup(func() {
+			guardian.New = origNewGuardian
+		})
+
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanAdminValue: false})
+
+		loggedInUserScenarioWithRole(t, "When calling GET on", "GET", "/api/dashboards/uid/abc/effective-access",
+			"/api/dashboards/uid/:uid/effective-access", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardQuery) error {
+					query.Result = models.NewDashboard("Dash")
+					return nil
+				})
+
+				sc.handlerFunc = hs.GetDashboardEffectiveAccess
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+				assert.Equal(t, 403, sc.resp.Code)
+			})
+	})
+}
+
+func TestResolveDashboardEffectiveAccess(t *testing.T) {
+	viewer, editor := models.ROLE_VIEWER, models.ROLE_EDITOR
+	folder := &models.Dashboard{Id: 10, Uid: "folder", Title: "Folder"}
+
+	orgUsers := []*models.OrgUserDTO{
+		{UserId: 1, Login: "admin", Role: string(models.ROLE_ADMIN)},
+		{UserId: 2, Login: "editor", Role: string(models.ROLE_EDITOR)},
+		{UserId: 3, Login: "viewer", Role: string(models.ROLE_VIEWER)},
+		{UserId: 4, Login: "member", Role: string(models.ROLE_VIEWER)},
+	}
+	acl := []*models.DashboardAclInfoDTO{
+		{Role: &viewer, Permission: models.PERMISSION_VIEW, Inherited: true},
+		{Role: &editor, Permission: models.PERMISSION_EDIT, Inherited: true},
+		{TeamId: 1, Team: "team", Permission: models.PERMISSION_ADMIN},
+		{UserId: 3, Permission: models.PERMISSION_EDIT},
+	}
+	teamMembers := map[int64][]*models.TeamMemberDTO{1: {{TeamId: 1, UserId: 4}}}
+
+	result := resolveDashboardEffectiveAccess(acl, &models.Dashboard{Uid: "abc"}, folder, orgUsers, teamMembers, nil)
+
+	permissions := map[string]models.PermissionType{}
+	for _, u := range result.Users {
+		permissions[u.Login] = u.Permission
+	}
+	assert.Equal(t, map[string]models.PermissionType{
+		"admin":  models.PERMISSION_ADMIN,
+		"editor": models.PERMISSION_EDIT,
+		"viewer": models.PERMISSION_EDIT,
+		"member": models.PERMISSION_ADMIN,
+	}, permissions)
+
+	require.Len(t, result.Teams, 1)
+	assert.Equal(t, models.PERMISSION_ADMIN, result.Teams[0].Permission)
+
+	for _, u := range result.Users {
+		if u.Login != "viewer" {
+			continue
+		}
+		require.Len(t, u.Sources, 2)
+		assert.Equal(t, "role", u.Sources[0].Type)
+		assert.Equal(t, "folder", u.Sources[0].FolderUID)
+		assert.Equal(t, "user", u.Sources[1].Type)
+		assert.Empty(t, u.Sources[1].FolderUID)
+	}
+}
+
+func TestResolveDashboardEffectiveAccessWithAccessControl(t *testing.T) {
+	orgUsers := []*models.OrgUserDTO{
+		{UserId: 1, Login: "writer", Role: string(models.ROLE_VIEWER)},
+		{UserId: 2, Login: "denied", Role: string(models.ROLE_VIEWER)},
+		{UserId: 3, Login: "viewer", Role: string(models.ROLE_VIEWER)},
+	}
+	grants := map[int64][]*accesscontrol.Permission{
+		1: {
+			{Action: accesscontrol.ActionDashboardsRead, Scope: "dashboards:uid:abc"},
+			{Action: accesscontrol.ActionDashboardsWrite, Scope: "folders:uid:folder"},
+		},
+		2: {{Action: accesscontrol.ActionDashboardsRead, Scope: accesscontrol.ScopeDashboardsAll, Deny: true}},
+	}
+
+	result := resolveDashboardEffectiveAccess(nil, &models.Dashboard{Uid: "abc"}, &models.Dashboard{Uid: "folder"}, orgUsers, nil, grants)
+
+	// The users only denied access are not listed
+	require.Len(t, result.Users, 1)
+	writer := result.Users[0]
+	assert.Equal(t, "writer", writer.Login)
+	assert.Equal(t, models.PERMISSION_EDIT, writer.Permission)
+	require.Len(t, writer.Sources, 1)
+	assert.Equal(t, "accessControl", writer.Sources[0].Type)
+	assert.Len(t, writer.AccessControl, 2)
+}
+
+func TestAccessControlPermission(t *testing.T) {
+	dash := &models.Dashboard{Uid: "abc"}
+	folder := &models.Dashboard{Uid: "folder"}
+	wildcardEditor := []*accesscontrol.Permission{
+		{Action: accesscontrol.ActionDashboardsRead, Scope: accesscontrol.ScopeDashboardsAll},
+		{Action: accesscontrol.ActionDashboardsWrite, Scope: accesscontrol.ScopeDashboardsAll},
+	}
+
+	tests := []struct {
+		desc        string
+		permissions []*accesscontrol.Permission
+		folder      *models.Dashboard
+		expected    models.PermissionType
+	}{
+		{
+			desc:        "should grant the highest permission of the actions",
+			permissions: wildcardEditor,
+			folder:      folder,
+			expected:    models.PERMISSION_EDIT,
+		},
+		{
+			desc: "should not grant the actions denied on the dashboard over a wildcard grant",
+			permissions: append([]*accesscontrol.Permission{
+				{Action: accesscontrol.ActionDashboardsWrite, Scope: "dashboards:uid:abc", Deny: true},
+			}, wildcardEditor...),
+			folder:   folder,
+			expected: models.PERMISSION_VIEW,
+		},
+		{
+			desc: "should not grant the actions denied on the folder of the dashboard",
+			permissions: append([]*accesscontrol.Permission{
+				{Action: accesscontrol.ActionDashboardsWrite, Scope: "folders:uid:folder", Deny: true},
+			}, wildcardEditor...),
+			folder:   folder,
+			expected: models.PERMISSION_VIEW,
+		},
+		{
+			desc: "should not grant the actions denied on all scopes",
+			permissions: append([]*accesscontrol.Permission{
+				{Action: accesscontrol.ActionDashboardsRead, Deny: true},
+				{Action: accesscontrol.ActionDashboardsWrite, Deny: true},
+			}, wildcardEditor...),
+			folder:   folder,
+			expected: 0,
+		},
+		{
+			desc: "should keep the actions denied on other dashboards",
+			permissions: append([]*accesscontrol.Permission{
+				{Action: accesscontrol.ActionDashboardsWrite, Scope: "dashboards:uid:other", Deny: true},
+			}, wildcardEditor...),
+			folder:   folder,
+			expected: models.PERMISSION_EDIT,
+		},
+		{
+			desc: "should grant the dashboard actions granted on the folder",
+			permissions: []*accesscontrol.Permission{
+				{Action: accesscontrol.ActionDashboardsWrite, Scope: "folders:uid:folder"},
+			},
+			folder:   folder,
+			expected: models.PERMISSION_EDIT,
+		},
+		{
+			desc: "should not grant the folder actions to the dashboards of the General folder",
+			permissions: []*accesscontrol.Permission{
+				{Action: "folders.permissions:write", Scope: accesscontrol.ScopeFoldersAll},
+			},
+			expected: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, accessControlPermission(tt.permissions, dash, tt.folder))
+		})
+	}
+}
+
+func TestDashboardAccessControlScopes(t *testing.T) {
+	dash := &models.Dashboard{Uid: "abc"}
+	assert.Equal(t, []string{"*", "dashboards:*", "dashboards:uid:*", "dashboards:uid:abc", "folders:*", "folders:uid:*"},
+		dashboardAccessControlScopes(dash, nil))
+	assert.Contains(t, dashboardAccessControlScopes(dash, &models.Dashboard{Uid: "folder"}), "folders:uid:folder")
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/dashboards/trash
+//
+// Lists the deleted dashboards of the organization which can still be restored, the most recently deleted first.
+// The organization admins see all of them, the other editors the ones they deleted.
+func (hs *HTTPServer) GetDashboardTrash(c *models.ReqContext) response.Response {
+	query := models.GetDashboardTrashQuery{OrgId: c.OrgId}
+	if !c.HasRole(models.ROLE_ADMIN) {
+		query.DeletedBy = c.UserId
+	}
+	if err := hs.SQLStore.GetDashboardTrash(c.Req.Context(), &query); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get deleted dashboards", err)
+	}
+
+	for _, entry := range query.Result {
+		entry.Expires = entry.Deleted.Add(hs.Cfg.Cleanup.DeletedDashboardsRetention)
+	}
+	return response.JSON(http.StatusOK, query.Result)
+}
+
+// POST /api/dashboards/trash/:id/restore
+//
+// Restores a deleted dashboard or folder with its permissions. A dashboard is restored in its folder, which is restored
+// first when it was deleted too, or in the General folder when its folder is gone. The restore is checked like a save:
+// the user must be allowed to save in the folder, and the quota and the data sources of the folder apply. The editors
+// who are not organization admins can only restore the dashboards they deleted.
+func (hs *HTTPServer) RestoreDashboardFromTrash(c *models.ReqContext) response.Response {
+	limitReached, err := hs.QuotaService.QuotaReached(c, "dashboard")
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get quota", err)
+	}
+	if limitReached {
+		return response.Error(http.StatusForbidden, "Quota reached", nil)
+	}
+
+	cmd := models.RestoreDashboardCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId, User: c.SignedInUser}
+	if !c.HasRole(models.ROLE_ADMIN) {
+		cmd.DeletedBy = c.UserId
+	}
+	ctx := alerting.WithUAEnabled(c.Req.Context(), hs.Cfg.UnifiedAlerting.IsEnabled())
+	if err := dashboards.NewService(hs.SQLStore).RestoreDashboard(ctx, &cmd); err != nil {
+		if errors.Is(err, models.ErrDashboardTrashEntryNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return hs.dashboardSaveErrorToApiResponse(ctx, err)
+	}
+
+	dash := cmd.Result
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message": "Dashboard restored",
+		"id":      dash.Id,
+		"uid":     dash.Uid,
+		"title":   dash.Title,
+		"url":     models.GetDashboardUrl(dash.Uid, dash.Slug),
+	})
+}
+
+// DELETE /api/dashboards/trash/:id
+//
+// Deletes a deleted dashboard for good, before the retention of the deleted dashboards is over.
+func (hs *HTTPServer) DeleteDashboardTrashEntry(c *models.ReqContext) response.Response {
+	cmd := models.DeleteDashboardTrashEntryCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := hs.SQLStore.DeleteDashboardTrashEntry(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrDashboardTrashEntryNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete dashboard", err)
+	}
+
+	return response.Success("Dashboard deleted")
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/dashvalidate"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// ValidateDashboard lints the dashboard of the request before it is saved or provisioned: the structure of the
+// model, the data sources of the organization it references and its template variables. The mismatches with the
+// dashboard schema are reported as warnings. The dashboard is valid when there are no errors.
+//
+// POST /api/dashboards/validate
+func (hs *HTTPServer) ValidateDashboard(c *models.ReqContext) response.Response {
+	form := dtos.ValidateDashboardForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	opts := dashvalidate.Options{}
+	if hs.LoadSchemaService != nil {
+		opts.Schema = func(dash *simplejson.Json) ([]dashvalidate.Problem, error) {
+			schemaErrors, err := hs.LoadSchemaService.DashboardValidate(dash)
+			if err != nil {
+				return nil, err
+			}
+			problems := make([]dashvalidate.Problem, 0, len(schemaErrors))
+			for _, e := range schemaErrors {
+				problems = append(problems, dashvalidate.Problem{Path: e.Path, Message: e.Message})
+			}
+			return problems, nil
+		}
+	}
+
+	resolver := &dashboardExportResolver{ctx: c.Req.Context(), orgID: c.OrgId, hs: hs}
+	result, err := dashvalidate.Validate(form.Dashboard, resolver, opts)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to validate dashboard", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
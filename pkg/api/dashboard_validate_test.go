@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/dashvalidate"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/schemaloader"
+)
+
+func TestAPIEndpoint_ValidateDashboard(t *testing.T) {
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.pluginStore = &fakePluginStore{plugins: map[string]plugins.PluginDTO{
+		"timeseries": {JSONData: plugins.JSONData{ID: "timeseries", Type: plugins.Panel, Name: "Time series"}},
+	}}
+	schemaService, err := schemaloader.ProvideService(sc.cfg)
+	require.NoError(t, err)
+	sc.hs.LoadSchemaService = schemaService
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_EDITOR, Login: "editor"})
+
+	require.NoError(t, sc.db.AddDataSource(context.Background(), &models.AddDataSourceCommand{
+		OrgId: 1, Name: "Prod Metrics", Type: "prometheus", Uid: "prom-uid", Access: models.DS_ACCESS_PROXY,
+	}))
+
+	validate := func(t *testing.T, body string) *dashvalidate.Result {
+		t.Helper()
+		response := callAPI(sc.server, http.MethodPost, "/api/dashboards/validate", strings.NewReader(body), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		result := &dashvalidate.Result{}
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), result))
+		return result
+	}
+
+	t.Run("should accept a valid dashboard", func(t *testing.T) {
+		result := validate(t, `{"dashboard": {"title": "Hosts", "schemaVersion": 33, "panels": [
+			{"id": 1, "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}, "datasource": "Prod Metrics",
+				"targets": [{"refId": "A", "expr": "up"}]}
+		]}}`)
+
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Errors)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("should return the errors and the warnings", func(t *testing.T) {
+		result := validate(t, `{"dashboard": {"title": "Hosts", "refresh": 5, "panels": [
+			{"id": 1, "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}, "datasource": "Unknown",
+				"targets": [{"refId": "A", "expr": "up{host=\"$host\"}"}]}
+		]}}`)
+
+		assert.False(t, result.Valid)
+		assert.Equal(t, []dashvalidate.Problem{
+			{Code: dashvalidate.CodeDataSourceNotFound, Path: "panels[0].datasource", Message: `the data source "Unknown" does not exist`},
+		}, result.Errors)
+		require.Len(t, result.Warnings, 2)
+		assert.Equal(t, dashvalidate.CodeVariableNotFound, result.Warnings[0].Code)
+		assert.Equal(t, dashvalidate.CodeSchemaMismatch, result.Warnings[1].Code)
+		assert.Equal(t, "refresh", result.Warnings[1].Path)
+	})
+
+	t.Run("should reject the requests without a dashboard", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodPost, "/api/dashboards/validate", strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
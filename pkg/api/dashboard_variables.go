@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/dashvariables"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GetDashboardVariableGraph returns the dependency graph of the variables and the panels of a dashboard: the variables
+// each variable depends on, and the variables and panels refreshed when it changes.
+//
+// GET /api/dashboards/uid/:uid/variable-graph
+func (hs *HTTPServer) GetDashboardVariableGraph(c *models.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	dash, rsp := getDashboardHelper(ctx, c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(ctx, dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	// The variables used by the library panels are in their own models
+	if err := hs.LibraryPanelService.LoadLibraryPanelsForDashboard(ctx, dash); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while loading library panels", err)
+	}
+
+	return response.JSON(http.StatusOK, dashvariables.Build(dash.Data))
+}
+
+// CalculateDashboardVariableGraph returns the dependency graph of the variables and the panels of the dashboard of the
+// request, for the dashboards being edited.
+//
+// POST /api/dashboards/variable-graph
+func CalculateDashboardVariableGraph(c *models.ReqContext) response.Response {
+	form := dtos.DashboardVariableGraphForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	return response.JSON(http.StatusOK, dashvariables.Build(form.Dashboard))
+}
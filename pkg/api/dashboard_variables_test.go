@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashvariables"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestAPIEndpoint_DashboardVariableGraph(t *testing.T) {
+	sc := setupHTTPServer(t, true, false)
+	sc.hs.LibraryPanelService = librarypanels.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister(),
+		libraryelements.ProvideService(sc.cfg, sc.db, routing.NewRouteRegister()))
+	// The handlers registered by the sqlstore package can be cleared by previous tests, the ones of the dashboard and
+	// of its permissions are registered again
+	t.Cleanup(bus.ClearBusHandlers)
+	sc.hs.Bus.AddHandler(sqlstore.GetDashboard)
+	sc.hs.Bus.AddHandler(sc.db.GetDashboardAclInfoList)
+	sc.hs.Bus.AddHandler(sqlstore.GetTeamsByUser)
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER, Login: "viewer"})
+
+	model := map[string]interface{}{
+		"templating": map[string]interface{}{"list": []interface{}{
+			map[string]interface{}{"name": "cluster", "type": "custom", "query": "a,b"},
+			map[string]interface{}{"name": "host", "type": "query", "query": "hosts($cluster)"},
+		}},
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "timeseries", "targets": []interface{}{map[string]interface{}{"expr": "up{host=\"$host\"}"}}},
+		},
+	}
+	assertGraph := func(t *testing.T, body []byte) {
+		t.Helper()
+		var graph dashvariables.Graph
+		require.NoError(t, json.Unmarshal(body, &graph))
+		require.Len(t, graph.Variables, 2)
+		assert.Equal(t, []string{"host"}, graph.Variables[0].Dependents)
+		assert.Equal(t, []int64{1}, graph.Variables[0].Panels)
+		require.Len(t, graph.Panels, 1)
+		assert.Equal(t, []string{"host"}, graph.Panels[0].Variables)
+	}
+
+	t.Run("should return the graph of a saved dashboard", func(t *testing.T) {
+		data := simplejson.NewFromAny(model)
+		data.Set("title", "Variables")
+		data.Set("uid", "variables")
+		_, err := sc.db.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Dashboard: data})
+		require.NoError(t, err)
+
+		response := callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/variables/variable-graph", nil, t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		assertGraph(t, response.Body.Bytes())
+
+		response = callAPI(sc.server, http.MethodGet, "/api/dashboards/uid/missing/variable-graph", nil, t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("should return the graph of the dashboard of the request", func(t *testing.T) {
+		body, err := json.Marshal(map[string]interface{}{"dashboard": model})
+		require.NoError(t, err)
+
+		response := callAPI(sc.server, http.MethodPost, "/api/dashboards/variable-graph", strings.NewReader(string(body)), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		assertGraph(t, response.Body.Bytes())
+
+		response = callAPI(sc.server, http.MethodPost, "/api/dashboards/variable-graph", strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -24,14 +25,28 @@ import (
 var datasourcesLogger = log.New("datasources")
 
 func (hs *HTTPServer) GetDataSources(c *models.ReqContext) response.Response {
-	query := models.GetDataSourcesQuery{OrgId: c.OrgId, DataSourceLimit: hs.Cfg.DataSourceLimit}
+	filterUser, err := hs.accessControlFilterUser(c)
+	if err != nil {
+		return response.Error(500, "Failed to query datasources", err)
+	}
+
+	query := models.GetDataSourcesQuery{OrgId: c.OrgId, DataSourceLimit: hs.Cfg.DataSourceLimit, User: filterUser}
 
 	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
 		return response.Error(500, "Failed to query datasources", err)
 	}
 
+	labelMatch, errResp := hs.matchLabelSelector(c, resourcelabels.ResourceTypeDataSource)
+	if errResp != nil {
+		return errResp
+	}
+
 	result := make(dtos.DataSourceList, 0)
 	for _, ds := range query.Result {
+		if labelMatch != nil && !labelMatch.Matches(ds.Uid) {
+			continue
+		}
+
 		dsItem := dtos.DataSourceListItemDTO{
 			OrgId:     ds.OrgId,
 			Id:        ds.Id,
@@ -70,15 +85,13 @@ func (hs *HTTPServer) getDataSourceAccessControlMetadata(c *models.ReqContext, d
 		return nil, nil
 	}
 
-	userPermissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
-	if err != nil || len(userPermissions) == 0 {
+	key := fmt.Sprintf("%d", dsID)
+	metadata, err := hs.AccessControl.GetUserResourcesMetadata(c.Req.Context(), c.SignedInUser, "datasources", map[string]bool{key: true})
+	if err != nil {
 		return nil, err
 	}
 
-	key := fmt.Sprintf("%d", dsID)
-	dsIDs := map[string]bool{key: true}
-
-	return accesscontrol.GetResourcesMetadata(c.Req.Context(), userPermissions, "datasources", dsIDs)[key], nil
+	return metadata[key], nil
 }
 
 func (hs *HTTPServer) GetDataSourceById(c *models.ReqContext) response.Response {
@@ -12,3 +12,53 @@ type DashboardAclUpdateItem struct {
 	Role       *models.RoleType      `json:"role,omitempty"`
 	Permission models.PermissionType `json:"permission"`
 }
+
+// DashboardEffectiveAccess is the resolved list of users and teams that have access to a dashboard,
+// taking folder inheritance, default role permissions and organization roles into account.
+type DashboardEffectiveAccess struct {
+	Users []*DashboardEffectiveUserAccess `json:"users"`
+	Teams []*DashboardEffectiveTeamAccess `json:"teams"`
+}
+
+type DashboardEffectiveUserAccess struct {
+	UserID         int64                         `json:"userId"`
+	Login          string                        `json:"login"`
+	Email          string                        `json:"email"`
+	AvatarURL      string                        `json:"avatarUrl"`
+	OrgRole        models.RoleType               `json:"orgRole"`
+	Permission     models.PermissionType         `json:"permission"`
+	PermissionName string                        `json:"permissionName"`
+	Sources        []*DashboardAccessSource      `json:"sources"`
+	AccessControl  []DashboardAccessControlGrant `json:"accessControl,omitempty"`
+}
+
+type DashboardEffectiveTeamAccess struct {
+	TeamID         int64                 `json:"teamId"`
+	Name           string                `json:"name"`
+	Email          string                `json:"email"`
+	AvatarURL      string                `json:"avatarUrl"`
+	Permission     models.PermissionType `json:"permission"`
+	PermissionName string                `json:"permissionName"`
+	Inherited      bool                  `json:"inherited"`
+}
+
+// DashboardAccessSource describes a single rule granting a permission on the dashboard.
+type DashboardAccessSource struct {
+	// Type is one of user, team, role, orgAdmin or accessControl.
+	Type           string                `json:"type"`
+	TeamID         int64                 `json:"teamId,omitempty"`
+	Team           string                `json:"team,omitempty"`
+	Role           *models.RoleType      `json:"role,omitempty"`
+	Permission     models.PermissionType `json:"permission"`
+	PermissionName string                `json:"permissionName"`
+	Inherited      bool                  `json:"inherited"`
+	FolderUID      string                `json:"folderUid,omitempty"`
+	FolderTitle    string                `json:"folderTitle,omitempty"`
+}
+
+// DashboardAccessControlGrant is an access control permission a user holds on dashboards or folders.
+type DashboardAccessControlGrant struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	Deny   bool   `json:"deny,omitempty"`
+}
@@ -1,7 +1,36 @@
 package dtos
 
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
 type NewApiKeyResult struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
 	Key  string `json:"key"`
 }
+
+type RotateApiKeyForm struct {
+	// SecondsToLive resets the expiration of the API key when set
+	SecondsToLive int64 `json:"secondsToLive"`
+	// GracePeriodSeconds is the number of seconds the previous key remains valid, it defaults to
+	// the api_key_rotation_grace_period setting
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds"`
+}
+
+type RotatedApiKeyResult struct {
+	ID                    int64     `json:"id"`
+	Name                  string    `json:"name"`
+	Key                   string    `json:"key"`
+	PreviousKeyExpiration time.Time `json:"previousKeyExpiration"`
+}
+
+// AddUserAccessTokenForm creates a personal access token, the permissions restrict the token to a subset of the
+// permissions of the user
+type AddUserAccessTokenForm struct {
+	Name          string                       `json:"name" binding:"Required"`
+	SecondsToLive int64                        `json:"secondsToLive"`
+	Permissions   []models.ApiKeyPermissionDTO `json:"permissions"`
+}
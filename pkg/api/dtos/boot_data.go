@@ -0,0 +1,11 @@
+package dtos
+
+import "encoding/json"
+
+// BootData holds the requested sections of the frontend boot data. The sections whose ETag the client sent in
+// If-None-Match are only listed in NotModified.
+type BootData struct {
+	Sections    map[string]json.RawMessage `json:"sections"`
+	ETags       map[string]string          `json:"etags"`
+	NotModified []string                   `json:"notModified,omitempty"`
+}
@@ -0,0 +1,26 @@
+package dtos
+
+// CacheInfo describes a named cache of the server, its hit rate is the ratio of the lookups served from the cache
+type CacheInfo struct {
+	Name    string  `json:"name"`
+	Items   int     `json:"items"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hitRate"`
+}
+
+type CacheKeys struct {
+	Name  string   `json:"name"`
+	Total int      `json:"total"`
+	Keys  []string `json:"keys"`
+}
+
+type PurgeCacheCmd struct {
+	OrgId   int64  `json:"orgId"`
+	Pattern string `json:"pattern"`
+}
+
+type PurgeCacheResult struct {
+	Name   string `json:"name"`
+	Purged int    `json:"purged"`
+}
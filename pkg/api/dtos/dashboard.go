@@ -33,6 +33,12 @@ type DashboardMeta struct {
 	ProvisionedExternalId string    `json:"provisionedExternalId"`
 }
 
+// DashboardExport is a dashboard of a bulk export, in the form of the body of a dashboard save
+type DashboardExport struct {
+	Dashboard *simplejson.Json `json:"dashboard"`
+	FolderId  int64            `json:"folderId"`
+}
+
 type DashboardFullWithMeta struct {
 	Meta      DashboardMeta    `json:"meta"`
 	Dashboard *simplejson.Json `json:"dashboard"`
@@ -62,3 +68,27 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type CopyDashboardToOrgCommand struct {
+	OrgId             int64             `json:"orgId" binding:"Required"`
+	FolderUid         string            `json:"folderUid"`
+	CopyFolder        bool              `json:"copyFolder"`
+	CopyLibraryPanels bool              `json:"copyLibraryPanels"`
+	DataSourceMapping map[string]string `json:"dataSourceMapping"`
+	Overwrite         bool              `json:"overwrite"`
+}
+
+type TakeOverDashboardEditingCommand struct {
+	// SessionId is the Live session of the browser tab taking over the editing lock
+	SessionId string `json:"sessionId" binding:"Required"`
+}
+
+type DashboardVariableGraphForm struct {
+	// Dashboard is the JSON model of the dashboard being edited, which may not be saved yet
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+}
+
+type ValidateDashboardForm struct {
+	// Dashboard is the JSON model of the dashboard to validate, as it would be saved or provisioned
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+}
@@ -0,0 +1,83 @@
+package dtos
+
+import "time"
+
+// DatabasePoolStats describes a connection pool of the database, the primary one or the read replica. The durations
+// are in seconds.
+type DatabasePoolStats struct {
+	Name              string  `json:"name"`
+	Healthy           bool    `json:"healthy"`
+	MaxOpenConn       int     `json:"maxOpenConn"`
+	MaxIdleConn       int     `json:"maxIdleConn"`
+	ConnMaxLifetime   float64 `json:"connMaxLifetime"`
+	StatementTimeout  float64 `json:"statementTimeout"`
+	OpenConnections   int     `json:"openConnections"`
+	InUse             int     `json:"inUse"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"waitCount"`
+	WaitDuration      float64 `json:"waitDuration"`
+	MaxIdleClosed     int64   `json:"maxIdleClosed"`
+	MaxIdleTimeClosed int64   `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed int64   `json:"maxLifetimeClosed"`
+}
+
+type DatabaseStats struct {
+	Type  string              `json:"type"`
+	Pools []DatabasePoolStats `json:"pools"`
+}
+
+// DatabaseMigrationStatus is the progress of the migrations run by the instance holding the migration lock
+type DatabaseMigrationStatus struct {
+	Holder           string    `json:"holder"`
+	State            string    `json:"state"`
+	Pending          int       `json:"pending"`
+	Performed        int       `json:"performed"`
+	CurrentMigration string    `json:"currentMigration"`
+	Error            string    `json:"error"`
+	Started          time.Time `json:"started"`
+	Updated          time.Time `json:"updated"`
+}
+
+// DatabaseMigrations describes the migrations of the database, the status is nil when they have never been run
+// with locking
+type DatabaseMigrations struct {
+	Locking bool                     `json:"locking"`
+	Status  *DatabaseMigrationStatus `json:"status"`
+}
+
+// DatabaseHealth are the diagnostics of the database, the durations are in seconds. The diagnostics which failed
+// have an error, and the database is failing when the primary database cannot be queried.
+type DatabaseHealth struct {
+	Type            string                 `json:"type"`
+	Database        string                 `json:"database"`
+	Latency         float64                `json:"latency"`
+	Error           string                 `json:"error,omitempty"`
+	Replica         *DatabaseReplicaHealth `json:"replica,omitempty"`
+	Migrations      DatabaseMigrations     `json:"migrations"`
+	MigrationsError string                 `json:"migrationsError,omitempty"`
+	Tables          []DatabaseTableSize    `json:"tables"`
+	TablesError     string                 `json:"tablesError,omitempty"`
+	LockWaits       *DatabaseLockWaits     `json:"lockWaits"`
+	LockWaitsError  string                 `json:"lockWaitsError,omitempty"`
+}
+
+// DatabaseReplicaHealth is the state of the read replica, healthy when the reads are sent to it
+type DatabaseReplicaHealth struct {
+	Healthy bool    `json:"healthy"`
+	Latency float64 `json:"latency"`
+	Lag     float64 `json:"lag"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// DatabaseTableSize is the number of rows and the size in bytes of a table, the size is zero when unknown
+type DatabaseTableSize struct {
+	Name  string `json:"name"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// DatabaseLockWaits are the transactions waiting for a lock
+type DatabaseLockWaits struct {
+	Waiting     int64   `json:"waiting"`
+	LongestWait float64 `json:"longestWait"`
+}
@@ -0,0 +1,11 @@
+package dtos
+
+// LoadSignals are the signals of the workload of a Grafana instance, which the autoscalers scale the instances on.
+// The durations are in seconds.
+type LoadSignals struct {
+	Instance          string  `json:"instance"`
+	LiveConnections   int     `json:"liveConnections"`
+	QueriesInFlight   int64   `json:"queriesInFlight"`
+	AlertSchedulerLag float64 `json:"alertSchedulerLag"`
+	RenderQueueLength int     `json:"renderQueueLength"`
+}
@@ -23,6 +23,11 @@ type LoginCommand struct {
 	User     string `json:"user" binding:"Required"`
 	Password string `json:"password" binding:"Required"`
 	Remember bool   `json:"remember"`
+	// TOTPCode or RecoveryCode is the second factor of the users with two-factor authentication
+	TOTPCode     string `json:"totpCode"`
+	RecoveryCode string `json:"recoveryCode"`
+	// TOTPEnrollmentToken is the token of the enrollment of the users required to enroll, sent with their first code
+	TOTPEnrollmentToken string `json:"totpEnrollmentToken"`
 }
 
 type CurrentUser struct {
@@ -53,6 +58,25 @@ type MetricRequest struct {
 	To      string             `json:"to"`
 	Queries []*simplejson.Json `json:"queries"`
 	Debug   bool               `json:"debug"`
+	// CacheTTL is the cache TTL hint of the panel the queries come from, bounded by the data source cache policy
+	CacheTTL string `json:"cacheTTL,omitempty"`
+	// Transformations are the server-side transformations applied in order to the frames of each query response
+	Transformations []*MetricRequestTransformation `json:"transformations,omitempty"`
+	// QueryID identifies the request to cancel it and to follow its progress on Grafana Live
+	QueryID string `json:"queryId,omitempty"`
+	// Environment is the environment the dashboard is viewed in, the queries are sent to the data sources of this
+	// environment
+	Environment string `json:"environment,omitempty"`
+	// DashboardUID is the dashboard of the panel the queries come from, their count and the failed ones are added to
+	// the usage of the dashboard
+	DashboardUID string `json:"dashboardUid,omitempty"`
+}
+
+// MetricRequestTransformation is a server-side transformation registered by a backend plugin
+type MetricRequestTransformation struct {
+	// Id is the ID of the plugin and the ID of the transformation, separated by a slash
+	Id      string           `json:"id"`
+	Options *simplejson.Json `json:"options,omitempty"`
 }
 
 func GetGravatarUrl(text string) string {
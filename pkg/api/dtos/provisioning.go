@@ -0,0 +1,13 @@
+package dtos
+
+import "github.com/grafana/grafana/pkg/services/provisioning/drift"
+
+type ProvisioningDrift struct {
+	Resources []*drift.Resource `json:"resources"`
+}
+
+// ReconcileProvisioningDriftForm selects the drifted resources to reconcile by ID, or all of them
+type ReconcileProvisioningDriftForm struct {
+	Ids []string `json:"ids"`
+	All bool     `json:"all"`
+}
@@ -1,5 +1,11 @@
 package dtos
 
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
 type SignUpForm struct {
 	Email string `json:"email" binding:"Required"`
 }
@@ -29,6 +35,11 @@ type AdminUpdateUserPermissionsForm struct {
 	IsGrafanaAdmin bool `json:"isGrafanaAdmin"`
 }
 
+type AdminRestoreUserForm struct {
+	// Role is the role of the user in the organization they are added back to, Viewer when empty
+	Role models.RoleType `json:"role"`
+}
+
 type SendResetPasswordEmailForm struct {
 	UserOrEmail string `json:"userOrEmail" binding:"Required"`
 }
@@ -44,3 +55,26 @@ type UserLookupDTO struct {
 	Login     string `json:"login"`
 	AvatarURL string `json:"avatarUrl"`
 }
+
+type UserLockout struct {
+	Enabled  bool              `json:"enabled"`
+	Locked   bool              `json:"locked"`
+	Lockouts []UsernameLockout `json:"lockouts"`
+}
+
+type UsernameLockout struct {
+	Username       string     `json:"username"`
+	Locked         bool       `json:"locked"`
+	LockedUntil    *time.Time `json:"lockedUntil,omitempty"`
+	FailedAttempts int        `json:"failedAttempts"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// DisableTwoFactorCommand verifies the user with a code or a recovery code
+type DisableTwoFactorCommand struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
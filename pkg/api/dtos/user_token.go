@@ -13,4 +13,5 @@ type UserToken struct {
 	BrowserVersion         string    `json:"browserVersion"`
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
+	LastActiveAt           time.Time `json:"lastActiveAt"`
 }
@@ -9,17 +9,31 @@ import (
 	"github.com/grafana/grafana/pkg/api/apierrors"
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
+	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
 
 func (hs *HTTPServer) GetFolders(c *models.ReqContext) response.Response {
-	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
-	folders, err := s.GetFolders(c.Req.Context(), c.QueryInt64("limit"), c.QueryInt64("page"))
+	labelMatch, errResp := hs.matchLabelSelector(c, resourcelabels.ResourceTypeFolder)
+	if errResp != nil {
+		return errResp
+	}
+
+	var folders []*models.Folder
+	var err error
+	if labelMatch != nil {
+		folders, err = searchFolders(c, labelMatch.Filter("dashboard.uid"))
+	} else {
+		s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
+		folders, err = s.GetFolders(c.Req.Context(), c.QueryInt64("limit"), c.QueryInt64("page"))
+	}
 
 	if err != nil {
 		return apierrors.ToFolderErrorResponse(err)
@@ -38,6 +52,30 @@ func (hs *HTTPServer) GetFolders(c *models.ReqContext) response.Response {
 	return response.JSON(200, result)
 }
 
+// searchFolders returns the folders matching a search filter, as the folder service lists them
+func searchFolders(c *models.ReqContext, filter interface{}) ([]*models.Folder, error) {
+	searchQuery := search.Query{
+		SignedInUser: c.SignedInUser,
+		DashboardIds: make([]int64, 0),
+		FolderIds:    make([]int64, 0),
+		Limit:        c.QueryInt64("limit"),
+		OrgId:        c.OrgId,
+		Type:         "dash-folder",
+		Permission:   models.PERMISSION_VIEW,
+		Page:         c.QueryInt64("page"),
+		Filters:      []interface{}{filter},
+	}
+	if err := bus.Dispatch(c.Req.Context(), &searchQuery); err != nil {
+		return nil, err
+	}
+
+	folders := make([]*models.Folder, 0, len(searchQuery.Result))
+	for _, hit := range searchQuery.Result {
+		folders = append(folders, &models.Folder{Id: hit.ID, Uid: hit.UID, Title: hit.Title})
+	}
+	return folders, nil
+}
+
 func (hs *HTTPServer) GetFolderByUID(c *models.ReqContext) response.Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
 	folder, err := s.GetFolderByUID(c.Req.Context(), web.Params(c.Req)[":uid"])
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/folders/:uid/datasources
+func (hs *HTTPServer) GetFolderDataSources(c *models.ReqContext) response.Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
+	folder, err := s.GetFolderByUID(c.Req.Context(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	uids, err := hs.SQLStore.GetFolderDataSourceUIDs(c.Req.Context(), c.OrgId, folder.Id)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get folder data sources", err)
+	}
+
+	return response.JSON(http.StatusOK, models.SetFolderDataSourcesCommand{DataSourceUIDs: uids})
+}
+
+// PUT /api/folders/:uid/datasources
+func (hs *HTTPServer) SetFolderDataSources(c *models.ReqContext) response.Response {
+	cmd := models.SetFolderDataSourcesCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
+	folder, err := s.GetFolderByUID(c.Req.Context(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	g := guardian.New(c.Req.Context(), folder.Id, c.OrgId, c.SignedInUser)
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		return apierrors.ToFolderErrorResponse(models.ErrFolderAccessDenied)
+	}
+
+	for _, uid := range cmd.DataSourceUIDs {
+		query := models.GetDataSourceQuery{Uid: uid, OrgId: c.OrgId}
+		if err := hs.SQLStore.GetDataSource(c.Req.Context(), &query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return response.Error(http.StatusBadRequest, "Data source not found: "+uid, err)
+			}
+			return response.Error(http.StatusInternalServerError, "Failed to get data source", err)
+		}
+	}
+
+	if err := hs.SQLStore.SetFolderDataSourceUIDs(c.Req.Context(), c.OrgId, folder.Id, cmd.DataSourceUIDs); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to update folder data sources", err)
+	}
+
+	return response.Success("Folder data sources updated")
+}
@@ -2,12 +2,18 @@ package api
 
 import (
 	"context"
+	"net/http"
 	"time"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 )
 
+// databaseHealthTables is the number of tables whose sizes are reported by the database health
+const databaseHealthTables = 10
+
 func (hs *HTTPServer) databaseHealthy(ctx context.Context) bool {
 	const cacheKey = "db-healthy"
 
@@ -20,3 +26,45 @@ func (hs *HTTPServer) databaseHealthy(ctx context.Context) bool {
 	hs.CacheService.Set(cacheKey, healthy, time.Second*5)
 	return healthy
 }
+
+// GET /api/health/database
+//
+// Returns the diagnostics of the database for the monitoring, with a 503 status when the database cannot be queried.
+func (hs *HTTPServer) GetDatabaseHealth(c *models.ReqContext) response.Response {
+	health := hs.SQLStore.GetDatabaseHealth(c.Req.Context(), databaseHealthTables)
+
+	result := dtos.DatabaseHealth{
+		Type:            health.Type,
+		Database:        "ok",
+		Latency:         health.Latency.Seconds(),
+		Error:           health.Error,
+		Migrations:      hs.databaseMigrations(health.Migrations),
+		MigrationsError: health.MigrationsError,
+		Tables:          make([]dtos.DatabaseTableSize, 0, len(health.Tables)),
+		TablesError:     health.TablesError,
+		LockWaitsError:  health.LockWaitsError,
+	}
+	if health.Replica != nil {
+		result.Replica = &dtos.DatabaseReplicaHealth{
+			Healthy: health.Replica.Healthy,
+			Latency: health.Replica.Latency.Seconds(),
+			Lag:     health.Replica.Lag.Seconds(),
+			Error:   health.Replica.Error,
+		}
+	}
+	for _, table := range health.Tables {
+		result.Tables = append(result.Tables, dtos.DatabaseTableSize{Name: table.Name, Rows: table.Rows, Bytes: table.Bytes})
+	}
+	if health.LockWaits != nil {
+		result.LockWaits = &dtos.DatabaseLockWaits{
+			Waiting:     health.LockWaits.Waiting,
+			LongestWait: health.LockWaits.LongestWait.Seconds(),
+		}
+	}
+
+	if health.Error != "" {
+		result.Database = "failing"
+		return response.JSON(http.StatusServiceUnavailable, result)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -2,15 +2,19 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/require"
@@ -168,6 +172,41 @@ func TestHealthAPI_DatabaseHealthCached(t *testing.T) {
 	require.True(t, healthy.(bool))
 }
 
+func TestHealthAPI_DatabaseDiagnostics(t *testing.T) {
+	setup := func(t *testing.T, permissions []*accesscontrol.Permission) *scenarioContext {
+		sc, hs := setupAccessControlScenarioContext(t, setting.NewCfg(), "/api/health/database", permissions)
+		hs.SQLStore = sqlstore.InitTestDB(t)
+		sc.resp = httptest.NewRecorder()
+		var err error
+		sc.req, err = http.NewRequest(http.MethodGet, "/api/health/database", nil)
+		require.NoError(t, err)
+		return sc
+	}
+
+	t.Run("should return the diagnostics of the database", func(t *testing.T) {
+		sc := setup(t, []*accesscontrol.Permission{{Action: accesscontrol.ActionServerStatsRead}})
+		sc.exec()
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		var health dtos.DatabaseHealth
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &health))
+		require.Equal(t, "sqlite3", health.Type)
+		require.Equal(t, "ok", health.Database)
+		require.Nil(t, health.Replica)
+		require.Empty(t, health.TablesError)
+		require.NotEmpty(t, health.Tables)
+		require.LessOrEqual(t, len(health.Tables), databaseHealthTables)
+		// SQLite does not report its lock waits
+		require.Nil(t, health.LockWaits)
+	})
+
+	t.Run("should require the permission to read the server stats", func(t *testing.T) {
+		sc := setup(t, nil)
+		sc.exec()
+		require.Equal(t, http.StatusForbidden, sc.resp.Code)
+	})
+}
+
 func setupHealthAPITestEnvironment(t *testing.T, cbs ...func(*setting.Cfg)) (*web.Mux, *HTTPServer) {
 	t.Helper()
 
@@ -13,7 +13,11 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/grafana/grafana/pkg/services/dashboardimport"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/ownership"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/savedsearch"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/thumbs"
 
@@ -33,10 +37,18 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
+	"github.com/grafana/grafana/pkg/services/apireplay"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/auth/clientcert"
+	"github.com/grafana/grafana/pkg/services/auth/pluginauth"
+	"github.com/grafana/grafana/pkg/services/calendar"
 	"github.com/grafana/grafana/pkg/services/cleanup"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	dashboardpermissions "github.com/grafana/grafana/pkg/services/dashboards/permissions"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dspermissions "github.com/grafana/grafana/pkg/services/datasources/permissions"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/hooks"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
@@ -45,15 +57,23 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/pushhttp"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/onboarding"
+	"github.com/grafana/grafana/pkg/services/orgreadonly"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
+	"github.com/grafana/grafana/pkg/services/schedule"
 	"github.com/grafana/grafana/pkg/services/schemaloader"
 	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/services/searchusers"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/terms"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/services/updatechecker"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -115,6 +135,26 @@ type HTTPServer struct {
 	searchUsersService        searchusers.Service
 	queryDataService          *query.Service
 	serviceAccountsService    serviceaccounts.Service
+	ownershipService          *ownership.Service
+	savedSearchService        *savedsearch.Service
+	dataSourcePermissions     *dspermissions.Service
+	dashboardPermissions      *dashboardpermissions.Service
+	apiReplay                 *apireplay.Service
+	calendarService           *calendar.Service
+	cacheRegistry             *localcache.Registry
+	dashboardImportService    *dashboardimport.Service
+	onboardingService         *onboarding.Service
+	auditService              *audit.Service
+	termsService              *terms.Service
+	scheduleService           *schedule.Service
+	oauthLogoutService        *oauthtoken.LogoutService
+	twoFactorService          *twofactor.Service
+	resourceLabelsService     *resourcelabels.Service
+	orgReadOnlyService        *orgreadonly.Service
+	pluginAuthService         *pluginauth.Service
+	apiExplorer               *apiexplorer.Service
+	publicDashboards          *publicdashboards.Service
+	dashboardUsage            *dashboardusage.Service
 }
 
 type ServerOptions struct {
@@ -139,7 +179,16 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	quotaService *quota.QuotaService, socialService social.Service, tracingService tracing.Tracer,
 	encryptionService encryption.Internal, updateChecker *updatechecker.Service, searchUsersService searchusers.Service,
 	dataSourcesService *datasources.Service, secretsService secrets.Service,
-	queryDataService *query.Service, serviceaccountsService serviceaccounts.Service) (*HTTPServer, error) {
+	queryDataService *query.Service, serviceaccountsService serviceaccounts.Service,
+	ownershipService *ownership.Service, dataSourcePermissions *dspermissions.Service, savedSearchService *savedsearch.Service,
+	dashboardPermissions *dashboardpermissions.Service, apiReplay *apireplay.Service, calendarService *calendar.Service,
+	cacheRegistry *localcache.Registry, dashboardImportService *dashboardimport.Service,
+	onboardingService *onboarding.Service, auditService *audit.Service, termsService *terms.Service,
+	scheduleService *schedule.Service, oauthLogoutService *oauthtoken.LogoutService,
+	twoFactorService *twofactor.Service, resourceLabelsService *resourcelabels.Service,
+	orgReadOnlyService *orgreadonly.Service, pluginAuthService *pluginauth.Service,
+	apiExplorer *apiexplorer.Service, publicDashboards *publicdashboards.Service,
+	dashboardUsage *dashboardusage.Service) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
 
@@ -192,6 +241,26 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		searchUsersService:        searchUsersService,
 		queryDataService:          queryDataService,
 		serviceAccountsService:    serviceaccountsService,
+		ownershipService:          ownershipService,
+		savedSearchService:        savedSearchService,
+		dataSourcePermissions:     dataSourcePermissions,
+		dashboardPermissions:      dashboardPermissions,
+		apiReplay:                 apiReplay,
+		calendarService:           calendarService,
+		cacheRegistry:             cacheRegistry,
+		dashboardImportService:    dashboardImportService,
+		onboardingService:         onboardingService,
+		auditService:              auditService,
+		termsService:              termsService,
+		scheduleService:           scheduleService,
+		oauthLogoutService:        oauthLogoutService,
+		twoFactorService:          twoFactorService,
+		resourceLabelsService:     resourceLabelsService,
+		orgReadOnlyService:        orgReadOnlyService,
+		pluginAuthService:         pluginAuthService,
+		apiExplorer:               apiExplorer,
+		publicDashboards:          publicDashboards,
+		dashboardUsage:            dashboardUsage,
 	}
 	if hs.Listener != nil {
 		hs.log.Debug("Using provided listener")
@@ -345,6 +414,10 @@ func (hs *HTTPServer) configureHttps() error {
 		},
 	}
 
+	if err := hs.configureClientCertificates(tlsCfg); err != nil {
+		return err
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 	hs.httpSrv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
 
@@ -385,11 +458,36 @@ func (hs *HTTPServer) configureHttp2() error {
 		NextProtos: []string{"h2", "http/1.1"},
 	}
 
+	if err := hs.configureClientCertificates(tlsCfg); err != nil {
+		return err
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 
 	return nil
 }
 
+// configureClientCertificates verifies the client certificates with the configured certificate authorities. The
+// connections without certificate are refused only when the certificates are required, the users can otherwise
+// sign in another way.
+func (hs *HTTPServer) configureClientCertificates(tlsCfg *tls.Config) error {
+	if !hs.Cfg.ClientCertAuthEnabled || hs.Cfg.ClientCertAuthCAFile == "" {
+		return nil
+	}
+
+	pool, err := clientcert.LoadCertPool(hs.Cfg.ClientCertAuthCAFile)
+	if err != nil {
+		return err
+	}
+	tlsCfg.ClientCAs = pool
+	tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if hs.Cfg.ClientCertAuthRequired {
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return nil
+}
+
 func (hs *HTTPServer) applyRoutes() {
 	// start with middlewares & static routes
 	hs.addMiddlewaresAndStaticRoutes()
@@ -435,6 +533,12 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m.Use(hs.healthzHandler)
 	m.Use(hs.apiHealthHandler)
 	m.Use(hs.metricsEndpoint)
+	m.Use(hs.loadSignalsEndpoint)
+
+	// Replayed API requests are served before the context handler, without authentication
+	if hs.apiReplay.IsEnabled() {
+		m.UseMiddleware(hs.apiReplay.Middleware())
+	}
 
 	m.Use(hs.ContextHandler.Middleware)
 	m.Use(middleware.OrgRedirect(hs.Cfg))
@@ -445,6 +549,13 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 		m.Use(middleware.ValidateHostHeader(hs.Cfg))
 	}
 
+	// needs to be after context handler, the users who haven't accepted the terms of use of their organization are
+	// denied access to the API
+	m.Use(hs.termsService.Middleware)
+
+	// needs to be after context handler, the changes to read-only organizations are denied
+	m.Use(hs.orgReadOnlyService.Middleware)
+
 	m.Use(middleware.HandleNoCacheHeader)
 	m.UseMiddleware(middleware.AddCSPHeader(hs.Cfg, hs.log))
 
@@ -537,33 +537,20 @@ func (hs *HTTPServer) buildAdminNavLinks(c *models.ReqContext) []*dtos.NavLink {
 }
 
 func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewData, error) {
-	hasEditPermissionInFoldersQuery := models.HasEditPermissionInFoldersQuery{SignedInUser: c.SignedInUser}
-	if err := bus.Dispatch(c.Req.Context(), &hasEditPermissionInFoldersQuery); err != nil {
+	hasEditPerm, err := hs.hasEditPermissionInFolders(c)
+	if err != nil {
 		return nil, err
 	}
-	hasEditPerm := hasEditPermissionInFoldersQuery.Result
 
-	settings, err := hs.getFrontendSettingsMap(c)
+	settings, err := hs.getIndexSettings(c)
 	if err != nil {
 		return nil, err
 	}
 
-	settings["dateFormats"] = hs.Cfg.DateFormats
-
-	prefsQuery := models.GetPreferencesWithDefaultsQuery{User: c.SignedInUser}
-	if err := bus.Dispatch(c.Req.Context(), &prefsQuery); err != nil {
+	user, prefs, err := hs.getCurrentUser(c, hasEditPerm)
+	if err != nil {
 		return nil, err
 	}
-	prefs := prefsQuery.Result
-
-	// Read locale from accept-language
-	acceptLang := c.Req.Header.Get("Accept-Language")
-	locale := "en-US"
-
-	if len(acceptLang) > 0 {
-		parts := strings.Split(acceptLang, ",")
-		locale = parts[0]
-	}
 
 	appURL := setting.AppUrl
 	appSubURL := hs.Cfg.AppSubURL
@@ -572,7 +559,6 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 	if c.IsRenderCall && !hs.Cfg.ServeFromSubPath {
 		appURL = fmt.Sprintf("%s://localhost:%s", hs.Cfg.Protocol, hs.Cfg.HTTPPort)
 		appSubURL = ""
-		settings["appSubUrl"] = ""
 	}
 
 	navTree, err := hs.getNavTree(c, hasEditPerm)
@@ -581,25 +567,7 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 	}
 
 	data := dtos.IndexViewData{
-		User: &dtos.CurrentUser{
-			Id:                         c.UserId,
-			IsSignedIn:                 c.IsSignedIn,
-			Login:                      c.Login,
-			Email:                      c.Email,
-			Name:                       c.Name,
-			OrgCount:                   c.OrgCount,
-			OrgId:                      c.OrgId,
-			OrgName:                    c.OrgName,
-			OrgRole:                    c.OrgRole,
-			GravatarUrl:                dtos.GetGravatarUrl(c.Email),
-			IsGrafanaAdmin:             c.IsGrafanaAdmin,
-			LightTheme:                 prefs.Theme == lightName,
-			Timezone:                   prefs.Timezone,
-			WeekStart:                  prefs.WeekStart,
-			Locale:                     locale,
-			HelpFlags1:                 c.HelpFlags1,
-			HasEditPermissionInFolders: hasEditPerm,
-		},
+		User:                    user,
 		Settings:                settings,
 		Theme:                   prefs.Theme,
 		AppUrl:                  appURL,
@@ -622,39 +590,109 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 		LoadingLogo:             "public/img/grafana_icon.svg",
 	}
 
+	themeURLParam := c.Query("theme")
+	if themeURLParam == lightName {
+		data.User.LightTheme = true
+		data.Theme = lightName
+	} else if themeURLParam == darkName {
+		data.User.LightTheme = false
+		data.Theme = darkName
+	}
+
+	hs.runIndexDataHooks(&data, c)
+
+	return &data, nil
+}
+
+func (hs *HTTPServer) hasEditPermissionInFolders(c *models.ReqContext) (bool, error) {
+	hasEditPermissionInFoldersQuery := models.HasEditPermissionInFoldersQuery{SignedInUser: c.SignedInUser}
+	if err := bus.Dispatch(c.Req.Context(), &hasEditPermissionInFoldersQuery); err != nil {
+		return false, err
+	}
+	return hasEditPermissionInFoldersQuery.Result, nil
+}
+
+// getIndexSettings returns the frontend settings of the boot data
+func (hs *HTTPServer) getIndexSettings(c *models.ReqContext) (map[string]interface{}, error) {
+	settings, err := hs.getFrontendSettingsMap(c)
+	if err != nil {
+		return nil, err
+	}
+
+	settings["dateFormats"] = hs.Cfg.DateFormats
+
+	// special case when doing localhost call from image renderer
+	if c.IsRenderCall && !hs.Cfg.ServeFromSubPath {
+		settings["appSubUrl"] = ""
+	}
+
+	return settings, nil
+}
+
+// getCurrentUser returns the signed in user of the boot data, along with their preferences
+func (hs *HTTPServer) getCurrentUser(c *models.ReqContext, hasEditPerm bool) (*dtos.CurrentUser, *models.Preferences, error) {
+	prefsQuery := models.GetPreferencesWithDefaultsQuery{User: c.SignedInUser}
+	if err := bus.Dispatch(c.Req.Context(), &prefsQuery); err != nil {
+		return nil, nil, err
+	}
+	prefs := prefsQuery.Result
+
+	// Read locale from accept-language
+	acceptLang := c.Req.Header.Get("Accept-Language")
+	locale := "en-US"
+
+	if len(acceptLang) > 0 {
+		parts := strings.Split(acceptLang, ",")
+		locale = parts[0]
+	}
+
+	user := &dtos.CurrentUser{
+		Id:                         c.UserId,
+		IsSignedIn:                 c.IsSignedIn,
+		Login:                      c.Login,
+		Email:                      c.Email,
+		Name:                       c.Name,
+		OrgCount:                   c.OrgCount,
+		OrgId:                      c.OrgId,
+		OrgName:                    c.OrgName,
+		OrgRole:                    c.OrgRole,
+		GravatarUrl:                dtos.GetGravatarUrl(c.Email),
+		IsGrafanaAdmin:             c.IsGrafanaAdmin,
+		LightTheme:                 prefs.Theme == lightName,
+		Timezone:                   prefs.Timezone,
+		WeekStart:                  prefs.WeekStart,
+		Locale:                     locale,
+		HelpFlags1:                 c.HelpFlags1,
+		HasEditPermissionInFolders: hasEditPerm,
+	}
+
 	if hs.Cfg.FeatureToggles["accesscontrol"] {
 		userPermissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		data.User.Permissions = ac.BuildPermissionsMap(userPermissions)
+		user.Permissions = ac.BuildPermissionsMap(userPermissions)
 	}
 
 	if setting.DisableGravatar {
-		data.User.GravatarUrl = hs.Cfg.AppSubURL + "/public/img/user_profile.png"
+		user.GravatarUrl = hs.Cfg.AppSubURL + "/public/img/user_profile.png"
 	}
 
-	if len(data.User.Name) == 0 {
-		data.User.Name = data.User.Login
+	if len(user.Name) == 0 {
+		user.Name = user.Login
 	}
 
-	themeURLParam := c.Query("theme")
-	if themeURLParam == lightName {
-		data.User.LightTheme = true
-		data.Theme = lightName
-	} else if themeURLParam == darkName {
-		data.User.LightTheme = false
-		data.Theme = darkName
-	}
+	return user, prefs, nil
+}
 
-	hs.HooksService.RunIndexDataHooks(&data, c)
+// runIndexDataHooks runs the index data hooks, which may add links to the nav tree, and sorts the nav tree
+func (hs *HTTPServer) runIndexDataHooks(data *dtos.IndexViewData, c *models.ReqContext) {
+	hs.HooksService.RunIndexDataHooks(data, c)
 
 	sort.SliceStable(data.NavTree, func(i, j int) bool {
 		return data.NavTree[i].SortWeight < data.NavTree[j].SortWeight
 	})
-
-	return &data, nil
 }
 
 func (hs *HTTPServer) Index(c *models.ReqContext) {
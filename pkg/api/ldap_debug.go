@@ -18,8 +18,9 @@ import (
 )
 
 var (
-	getLDAPConfig = multildap.GetConfig
-	newLDAP       = multildap.New
+	getLDAPConfig      = multildap.GetConfig
+	readLDAPConfigFile = ldap.ReadConfigFile
+	newLDAP            = multildap.New
 
 	ldapLogger = log.New("LDAP.debug")
 
@@ -54,6 +55,48 @@ type LDAPUserDTO struct {
 	Teams          []models.TeamOrgGroupDTO `json:"teams"`
 }
 
+// LDAPTestCommand is the user whose LDAP mappings are tested
+type LDAPTestCommand struct {
+	Username string `json:"username"`
+	// UseConfigFile tests the LDAP configuration file as it is on the disk instead of the loaded configuration
+	UseConfigFile bool `json:"useConfigFile"`
+}
+
+// LDAPGroupMappingDTO is a serializer for the group mappings matching the groups of a user
+type LDAPGroupMappingDTO struct {
+	GroupDN        string          `json:"groupDN"`
+	OrgId          int64           `json:"orgId"`
+	OrgName        string          `json:"orgName"`
+	OrgRole        models.RoleType `json:"orgRole"`
+	IsGrafanaAdmin *bool           `json:"isGrafanaAdmin"`
+	// Applied is false when an earlier mapping of the organization applies instead
+	Applied bool `json:"applied"`
+}
+
+// LDAPMappingConflictDTO is a serializer for the group mappings of an organization that disagree for a user, or that
+// map to an organization that does not exist
+type LDAPMappingConflictDTO struct {
+	OrgId    int64    `json:"orgId"`
+	OrgName  string   `json:"orgName"`
+	GroupDNs []string `json:"groupDNs"`
+	Message  string   `json:"message"`
+}
+
+// LDAPTestResultDTO is a serializer for what would be applied to a user logging in with LDAP
+type LDAPTestResultDTO struct {
+	Server         string                   `json:"server"`
+	Login          string                   `json:"login"`
+	Email          string                   `json:"email"`
+	Name           string                   `json:"name"`
+	Groups         []string                 `json:"groups"`
+	Mappings       []LDAPGroupMappingDTO    `json:"mappings"`
+	OrgRoles       []LDAPRoleDTO            `json:"roles"`
+	IsGrafanaAdmin *bool                    `json:"isGrafanaAdmin"`
+	IsDisabled     bool                     `json:"isDisabled"`
+	Teams          []models.TeamOrgGroupDTO `json:"teams"`
+	Conflicts      []LDAPMappingConflictDTO `json:"conflicts"`
+}
+
 // LDAPServerDTO is a serializer for LDAP server statuses
 type LDAPServerDTO struct {
 	Host      string `json:"host"`
@@ -318,6 +361,140 @@ func (hs *HTTPServer) GetUserFromLDAP(c *models.ReqContext) response.Response {
 	return response.JSON(200, u)
 }
 
+// PostTestLDAPMapping searches a user in LDAP and returns the groups of the user, the organization roles the group
+// mappings would apply and the mappings that conflict, without logging the user in or changing the user in Grafana.
+func (hs *HTTPServer) PostTestLDAPMapping(c *models.ReqContext) response.Response {
+	if !ldap.IsEnabled() {
+		return response.Error(http.StatusBadRequest, "LDAP is not enabled", nil)
+	}
+
+	cmd := LDAPTestCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.Username == "" {
+		return response.Error(http.StatusBadRequest, "Validation error. You must specify an username", nil)
+	}
+
+	var ldapConfig *ldap.Config
+	var err error
+	if cmd.UseConfigFile {
+		ldapConfig, err = readLDAPConfigFile()
+	} else {
+		ldapConfig, err = getLDAPConfig(hs.Cfg)
+	}
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to obtain the LDAP configuration", err)
+	}
+
+	user, serverConfig, err := newLDAP(ldapConfig.Servers).User(cmd.Username)
+	if user == nil || errors.Is(err, multildap.ErrDidNotFindUser) {
+		return response.Error(http.StatusNotFound, "No user was found in the LDAP server(s) with that username", err)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search the user in LDAP", err)
+	}
+
+	matches := ldap.MatchGroupMappings(serverConfig.Groups, user.Groups)
+	orgIDs := []int64{}
+	for _, match := range matches {
+		orgIDs = append(orgIDs, match.OrgId)
+	}
+	orgNames, err := getOrgNames(c.Req.Context(), orgIDs)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get organizations", err)
+	}
+
+	result := &LDAPTestResultDTO{
+		Server:         serverConfig.Host,
+		Login:          user.Login,
+		Email:          user.Email,
+		Name:           user.Name,
+		Groups:         user.Groups,
+		Mappings:       []LDAPGroupMappingDTO{},
+		OrgRoles:       []LDAPRoleDTO{},
+		IsGrafanaAdmin: user.IsGrafanaAdmin,
+		IsDisabled:     user.IsDisabled,
+		Conflicts:      []LDAPMappingConflictDTO{},
+	}
+	if result.Groups == nil {
+		result.Groups = []string{}
+	}
+
+	missingOrgs := map[int64][]string{}
+	for _, match := range matches {
+		result.Mappings = append(result.Mappings, LDAPGroupMappingDTO{
+			GroupDN:        match.GroupDN,
+			OrgId:          match.OrgId,
+			OrgName:        orgNames[match.OrgId],
+			OrgRole:        match.OrgRole,
+			IsGrafanaAdmin: match.IsGrafanaAdmin,
+			Applied:        match.Applied,
+		})
+		if match.Applied && match.OrgRole != "" {
+			result.OrgRoles = append(result.OrgRoles, LDAPRoleDTO{
+				OrgId:   match.OrgId,
+				OrgName: orgNames[match.OrgId],
+				OrgRole: match.OrgRole,
+				GroupDN: match.GroupDN,
+			})
+		}
+		if _, ok := orgNames[match.OrgId]; !ok {
+			missingOrgs[match.OrgId] = append(missingOrgs[match.OrgId], match.GroupDN)
+		}
+	}
+
+	for _, conflict := range ldap.FindGroupMappingConflicts(matches) {
+		result.Conflicts = append(result.Conflicts, LDAPMappingConflictDTO{
+			OrgId:    conflict.OrgId,
+			OrgName:  orgNames[conflict.OrgId],
+			GroupDNs: conflict.GroupDNs,
+			Message: fmt.Sprintf("The user matches several group mappings of the organization with different roles %v, "+
+				"only the first one applies", conflict.Roles),
+		})
+	}
+	for _, orgID := range orgIDs {
+		groupDNs, ok := missingOrgs[orgID]
+		if !ok {
+			continue
+		}
+		delete(missingOrgs, orgID)
+		result.Conflicts = append(result.Conflicts, LDAPMappingConflictDTO{
+			OrgId:    orgID,
+			GroupDNs: groupDNs,
+			Message:  errOrganizationNotFound(orgID).Error(),
+		})
+	}
+
+	teams := &models.GetTeamsForLDAPGroupCommand{Groups: user.Groups}
+	if err := bus.Dispatch(c.Req.Context(), teams); err != nil && !errors.Is(err, bus.ErrHandlerNotFound) {
+		return response.Error(http.StatusInternalServerError, "Unable to find the teams for this user", err)
+	}
+	result.Teams = []models.TeamOrgGroupDTO{}
+	if teams.Result != nil {
+		result.Teams = teams.Result
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// getOrgNames returns the names of the organizations by ID, the organizations that do not exist are left out
+func getOrgNames(ctx context.Context, orgIDs []int64) (map[int64]string, error) {
+	names := map[int64]string{}
+	if len(orgIDs) == 0 {
+		return names, nil
+	}
+
+	query := &models.SearchOrgsQuery{Ids: orgIDs}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		return nil, err
+	}
+	for _, org := range query.Result {
+		names[org.Id] = org.Name
+	}
+	return names, nil
+}
+
 // splitName receives the full name of a user and splits it into two parts: A name and a surname.
 func splitName(name string) (string, string) {
 	names := util.SplitString(name)
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -317,6 +318,146 @@ func TestGetUserFromLDAPAPIEndpoint_WithTeamHandler(t *testing.T) {
 	assert.JSONEq(t, expected, sc.resp.Body.String())
 }
 
+// ***
+// PostTestLDAPMapping tests
+// ***
+
+func postTestLDAPMappingContext(t *testing.T, body string, preHook func(*testing.T, *scenarioContext)) *scenarioContext {
+	t.Helper()
+
+	sc := setupScenarioContext(t, "/api/admin/ldap/test")
+
+	origLDAP := setting.LDAPEnabled
+	setting.LDAPEnabled = true
+	t.Cleanup(func() { setting.LDAPEnabled = origLDAP })
+
+	hs := &HTTPServer{Cfg: setting.NewCfg()}
+
+	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+		sc.context = c
+		return hs.PostTestLDAPMapping(c)
+	})
+
+	sc.m.Post("/api/admin/ldap/test", sc.defaultHandler)
+
+	sc.resp = httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/api/admin/ldap/test", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	preHook(t, sc)
+
+	sc.req = req
+	sc.exec()
+
+	return sc
+}
+
+func TestPostTestLDAPMappingAPIEndpoint(t *testing.T) {
+	isAdmin := true
+	userSearchResult = &models.ExternalUserInfo{
+		Name:           "John Doe",
+		Email:          "john.doe@example.com",
+		Login:          "johndoe",
+		Groups:         []string{"cn=admins,ou=groups,dc=grafana,dc=org", "cn=editors,ou=groups,dc=grafana,dc=org"},
+		OrgRoles:       map[int64]models.RoleType{1: models.ROLE_ADMIN, 3: models.ROLE_VIEWER},
+		IsGrafanaAdmin: &isAdmin,
+	}
+	userSearchError = nil
+	config := ldap.ServerConfig{
+		Host: "ldap.example.org",
+		Groups: []*ldap.GroupToOrgRole{
+			{GroupDN: "cn=admins,ou=groups,dc=grafana,dc=org", OrgId: 1, OrgRole: models.ROLE_ADMIN, IsGrafanaAdmin: &isAdmin},
+			{GroupDN: "cn=editors,ou=groups,dc=grafana,dc=org", OrgId: 1, OrgRole: models.ROLE_EDITOR},
+			{GroupDN: "cn=viewers,ou=groups,dc=grafana,dc=org", OrgId: 2, OrgRole: models.ROLE_VIEWER},
+			{GroupDN: "*", OrgId: 3, OrgRole: models.ROLE_VIEWER},
+		},
+	}
+	userSearchConfig = config
+	t.Cleanup(func() {
+		userSearchResult = nil
+		userSearchConfig = ldap.ServerConfig{}
+	})
+
+	getLDAPConfig = func(*setting.Cfg) (*ldap.Config, error) {
+		return &ldap.Config{}, nil
+	}
+	newLDAP = func(_ []*ldap.ServerConfig) multildap.IMultiLDAP {
+		return &LDAPMock{}
+	}
+
+	t.Run("should return the mappings and the conflicts", func(t *testing.T) {
+		sc := postTestLDAPMappingContext(t, `{"username": "johndoe"}`, func(t *testing.T, sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, query *models.SearchOrgsQuery) error {
+				query.Result = []*models.OrgDTO{{Id: 1, Name: "Main Org."}}
+				return nil
+			})
+		})
+
+		require.Equal(t, http.StatusOK, sc.resp.Code)
+		expected := `
+		{
+			"server": "ldap.example.org",
+			"login": "johndoe",
+			"email": "john.doe@example.com",
+			"name": "John Doe",
+			"groups": ["cn=admins,ou=groups,dc=grafana,dc=org", "cn=editors,ou=groups,dc=grafana,dc=org"],
+			"mappings": [
+				{"groupDN": "cn=admins,ou=groups,dc=grafana,dc=org", "orgId": 1, "orgName": "Main Org.", "orgRole": "Admin", "isGrafanaAdmin": true, "applied": true},
+				{"groupDN": "cn=editors,ou=groups,dc=grafana,dc=org", "orgId": 1, "orgName": "Main Org.", "orgRole": "Editor", "isGrafanaAdmin": null, "applied": false},
+				{"groupDN": "*", "orgId": 3, "orgName": "", "orgRole": "Viewer", "isGrafanaAdmin": null, "applied": true}
+			],
+			"roles": [
+				{"orgId": 1, "orgName": "Main Org.", "orgRole": "Admin", "groupDN": "cn=admins,ou=groups,dc=grafana,dc=org"},
+				{"orgId": 3, "orgName": "", "orgRole": "Viewer", "groupDN": "*"}
+			],
+			"isGrafanaAdmin": true,
+			"isDisabled": false,
+			"teams": [],
+			"conflicts": [
+				{
+					"orgId": 1,
+					"orgName": "Main Org.",
+					"groupDNs": ["cn=admins,ou=groups,dc=grafana,dc=org", "cn=editors,ou=groups,dc=grafana,dc=org"],
+					"message": "The user matches several group mappings of the organization with different roles [Admin Editor], only the first one applies"
+				},
+				{
+					"orgId": 3,
+					"orgName": "",
+					"groupDNs": ["*"],
+					"message": "unable to find organization with ID '3'"
+				}
+			]
+		}`
+		assert.JSONEq(t, expected, sc.resp.Body.String())
+	})
+
+	t.Run("should test the configuration file when asked to", func(t *testing.T) {
+		origReadLDAPConfigFile := readLDAPConfigFile
+		t.Cleanup(func() { readLDAPConfigFile = origReadLDAPConfigFile })
+		readLDAPConfigFile = func() (*ldap.Config, error) {
+			return nil, errors.New("invalid config file")
+		}
+
+		sc := postTestLDAPMappingContext(t, `{"username": "johndoe", "useConfigFile": true}`, func(*testing.T, *scenarioContext) {})
+		assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+	})
+
+	t.Run("should require a username", func(t *testing.T) {
+		sc := postTestLDAPMappingContext(t, `{}`, func(*testing.T, *scenarioContext) {})
+		assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+	})
+
+	t.Run("should return 404 when the user is not found", func(t *testing.T) {
+		userSearchResult = nil
+		userSearchError = multildap.ErrDidNotFindUser
+		t.Cleanup(func() { userSearchError = nil })
+
+		sc := postTestLDAPMappingContext(t, `{"username": "janedoe"}`, func(*testing.T, *scenarioContext) {})
+		assert.Equal(t, http.StatusNotFound, sc.resp.Code)
+	})
+}
+
 // ***
 // GetLDAPStatus tests
 // ***
@@ -638,6 +779,15 @@ func TestLDAP_AccessControl(t *testing.T) {
 				{Action: "wrong"},
 			},
 		},
+		{
+			url:          "/api/admin/ldap/test",
+			method:       http.MethodPost,
+			desc:         "PostTestLDAPMapping should return 403 for user without required permissions",
+			expectedCode: http.StatusForbidden,
+			permissions: []*accesscontrol.Permission{
+				{Action: "wrong"},
+			},
+		},
 		{
 			url:          "/api/admin/ldap/sync/test",
 			method:       http.MethodPost,
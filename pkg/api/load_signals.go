@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// loadSignalsEndpoint returns the signals of the workload of the instance for the autoscalers, such as the KEDA
+// metrics API scaler. It is served with the metrics endpoint and protected by the same basic authentication.
+func (hs *HTTPServer) loadSignalsEndpoint(ctx *web.Context) {
+	if !hs.Cfg.MetricsEndpointEnabled {
+		return
+	}
+
+	if ctx.Req.Method != http.MethodGet || ctx.Req.URL.Path != "/api/health/load" {
+		return
+	}
+
+	if hs.metricsEndpointBasicAuthEnabled() && !BasicAuthenticatedRequest(ctx.Req, hs.Cfg.MetricsEndpointBasicAuthUsername, hs.Cfg.MetricsEndpointBasicAuthPassword) {
+		ctx.Resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	signals := hs.loadSignals()
+	data, err := json.Marshal(signals)
+	if err != nil {
+		hs.log.Error("Failed to encode the load signals", "err", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	ctx.Resp.Header().Set("Cache-Control", "no-store")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if _, err := ctx.Resp.Write(data); err != nil {
+		hs.log.Error("Failed to write to response", "err", err)
+	}
+}
+
+func (hs *HTTPServer) loadSignals() dtos.LoadSignals {
+	return dtos.LoadSignals{
+		Instance:          setting.InstanceName,
+		LiveConnections:   hs.Live.NumConnections(),
+		QueriesInFlight:   hs.queryDataService.QueriesInFlight(),
+		AlertSchedulerLag: hs.AlertNG.SchedulerLag().Seconds(),
+		RenderQueueLength: hs.RenderService.QueueLength(),
+	}
+}
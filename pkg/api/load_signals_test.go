@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestLoadSignalsEndpoint(t *testing.T) {
+	setup := func(t *testing.T, cb func(cfg *setting.Cfg)) *web.Mux {
+		cfg := setting.NewCfg()
+		cfg.MetricsEndpointEnabled = true
+		cb(cfg)
+		hs := &HTTPServer{
+			Cfg:              cfg,
+			Live:             newTestLive(t),
+			queryDataService: &query.Service{},
+			AlertNG:          &ngalert.AlertNG{},
+			RenderService:    &rendering.RenderingService{},
+		}
+		m := web.New()
+		m.Use(hs.loadSignalsEndpoint)
+		m.Get("/api/health/load", func(c *web.Context) {
+			c.Resp.WriteHeader(http.StatusNotFound)
+		})
+		return m
+	}
+	get := func(m *web.Mux, user, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/health/load", nil)
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("should return the load signals of the instance", func(t *testing.T) {
+		rec := get(setup(t, func(cfg *setting.Cfg) {}), "", "")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var signals dtos.LoadSignals
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &signals))
+		assert.Equal(t, dtos.LoadSignals{Instance: setting.InstanceName}, signals)
+	})
+
+	t.Run("should require the basic authentication of the metrics endpoint", func(t *testing.T) {
+		m := setup(t, func(cfg *setting.Cfg) {
+			cfg.MetricsEndpointBasicAuthUsername = "scaler"
+			cfg.MetricsEndpointBasicAuthPassword = "secret"
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, get(m, "", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, get(m, "scaler", "wrong").Code)
+		assert.Equal(t, http.StatusOK, get(m, "scaler", "secret").Code)
+	})
+
+	t.Run("should not be served when the metrics endpoint is disabled", func(t *testing.T) {
+		rec := get(setup(t, func(cfg *setting.Cfg) {
+			cfg.MetricsEndpointEnabled = false
+		}), "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
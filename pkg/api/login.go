@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/web"
@@ -97,6 +98,9 @@ func (hs *HTTPServer) LoginView(c *models.ReqContext) {
 	}
 
 	viewData.Settings["oauth"] = enabledOAuths
+	if hs.pluginAuthService != nil {
+		viewData.Settings["pluginAuthProviders"] = hs.pluginAuthService.Providers()
+	}
 	viewData.Settings["samlEnabled"] = hs.samlEnabled()
 	viewData.Settings["samlName"] = hs.samlName()
 
@@ -229,6 +233,12 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 
 	user = authQuery.User
 
+	twoFactor, twoFactorResp := hs.verifyTwoFactor(c, authQuery, cmd)
+	if twoFactorResp != nil {
+		resp = twoFactorResp
+		return resp
+	}
+
 	err = hs.loginUserWithUser(user, c)
 	if err != nil {
 		var createTokenErr *models.CreateTokenErr
@@ -243,6 +253,9 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 	result := map[string]interface{}{
 		"message": "Logged in",
 	}
+	if len(twoFactor.RecoveryCodes) > 0 {
+		result["recoveryCodes"] = twoFactor.RecoveryCodes
+	}
 
 	if redirectTo := c.GetCookie("redirect_to"); len(redirectTo) > 0 {
 		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
@@ -258,6 +271,40 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 	return resp
 }
 
+// verifyTwoFactor verifies the two-factor authentication of a user who logged in with a password, and returns the
+// response sent instead of logging in the user when the second factor is missing or invalid
+func (hs *HTTPServer) verifyTwoFactor(c *models.ReqContext, authQuery *models.LoginUserQuery,
+	cmd dtos.LoginCommand) (*twofactor.LoginResult, *response.NormalResponse) {
+	result, err := hs.twoFactorService.VerifyLogin(c.Req.Context(), authQuery.User, cmd.TOTPCode, cmd.RecoveryCode,
+		cmd.TOTPEnrollmentToken)
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.Is(err, twofactor.ErrCodeRequired):
+		return nil, response.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"message":           "Two-factor authentication code required",
+			"twoFactorRequired": true,
+		})
+	case errors.Is(err, twofactor.ErrEnrollmentRequired):
+		return nil, response.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"message":                     "Two-factor authentication enrollment required",
+			"twoFactorEnrollmentRequired": true,
+			"totp":                        result.Enrollment,
+		})
+	case errors.Is(err, twofactor.ErrEnrollmentExpired):
+		return nil, response.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"message":                     "Two-factor authentication enrollment expired",
+			"twoFactorEnrollmentRequired": true,
+		})
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		if err := login.SaveInvalidLoginAttempt(c.Req.Context(), authQuery); err != nil {
+			hs.log.Error("Failed to save invalid login attempt", "err", err)
+		}
+		return nil, response.Error(http.StatusUnauthorized, "Invalid two-factor authentication code", err)
+	}
+	return nil, response.Error(http.StatusInternalServerError, "Error while verifying two-factor authentication", err)
+}
+
 func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext) error {
 	if user == nil {
 		return errors.New("could not login user")
@@ -289,6 +336,9 @@ func (hs *HTTPServer) Logout(c *models.ReqContext) {
 		return
 	}
 
+	// Ends the single sign-on session of the user when they logged in with an OAuth provider with single logout
+	endSessionURL := hs.oauthLogoutService.Logout(c.Req.Context(), c.UserToken)
+
 	err := hs.AuthTokenService.RevokeToken(c.Req.Context(), c.UserToken, false)
 	if err != nil && !errors.Is(err, models.ErrUserTokenNotFound) {
 		hs.log.Error("failed to revoke auth token", "error", err)
@@ -296,7 +346,10 @@ func (hs *HTTPServer) Logout(c *models.ReqContext) {
 
 	cookies.WriteSessionCookie(c, hs.Cfg, "", -1)
 
-	if setting.SignoutRedirectUrl != "" {
+	if endSessionURL != "" {
+		hs.log.Info("Successful Logout, ending the OAuth session", "User", c.Email)
+		c.Redirect(endSessionURL)
+	} else if setting.SignoutRedirectUrl != "" {
 		c.Redirect(setting.SignoutRedirectUrl)
 	} else {
 		hs.log.Info("Successful Logout", "User", c.Email)
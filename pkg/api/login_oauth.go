@@ -246,6 +246,9 @@ func (hs *HTTPServer) OAuthLogin(ctx *models.ReqContext) response.Response {
 		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, err)
 		return nil
 	}
+	if err := hs.oauthLogoutService.SessionCreated(ctx.Req.Context(), ctx.UserToken, &loginInfo.ExternalUser); err != nil {
+		oauthLogger.Error("Failed to record the OAuth session for single logout", "userId", loginInfo.User.Id, "error", err)
+	}
 
 	loginInfo.HTTPStatus = http.StatusOK
 	hs.HooksService.RunLoginHook(&loginInfo, ctx)
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth/pluginauth"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /login/plugin/:pluginId/:providerId
+//
+// Signs in the user with the authentication provider of an app plugin. The provider first redirects the browser,
+// usually to its single sign-on system which redirects back to this endpoint, until it answers with the user.
+func (hs *HTTPServer) PluginLogin(ctx *models.ReqContext) response.Response {
+	id := web.Params(ctx.Req)[":pluginId"] + "/" + web.Params(ctx.Req)[":providerId"]
+	loginInfo := models.LoginInfo{AuthModule: pluginauth.AuthModule(id)}
+
+	if !hs.pluginAuthService.IsEnabled() {
+		hs.handleOAuthLoginError(ctx, loginInfo, LoginError{
+			HttpStatus:    http.StatusNotFound,
+			PublicMessage: "Plugin authentication not enabled",
+		})
+		return nil
+	}
+
+	result, err := hs.pluginAuthService.Login(ctx.Req.Context(), id, ctx.Req)
+	if err != nil {
+		if errors.Is(err, pluginauth.ErrProviderNotFound) {
+			hs.handleOAuthLoginError(ctx, loginInfo, LoginError{
+				HttpStatus:    http.StatusNotFound,
+				PublicMessage: "Authentication provider not found",
+			})
+			return nil
+		}
+		ctx.Logger.Warn("Failed to sign in with the authentication provider", "provider", id, "error", err)
+		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, login.ErrInvalidCredentials)
+		return nil
+	}
+	for _, cookie := range result.Cookies {
+		ctx.Resp.Header().Add("Set-Cookie", cookie)
+	}
+	if result.User == nil {
+		ctx.Redirect(result.RedirectURL)
+		return nil
+	}
+
+	loginInfo.ExternalUser = *result.User
+	cmd := &models.UpsertUserCommand{
+		ReqContext:    ctx,
+		ExternalUser:  result.User,
+		SignupAllowed: hs.Cfg.PluginAuthAutoSignUp,
+	}
+	if err := bus.Dispatch(ctx.Req.Context(), cmd); err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, err)
+		return nil
+	}
+	// Do not expose disabled status, just show incorrect user credentials error (see #17947)
+	if cmd.Result.IsDisabled {
+		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, login.ErrInvalidCredentials)
+		return nil
+	}
+	loginInfo.User = cmd.Result
+
+	if err := hs.loginUserWithUser(loginInfo.User, ctx); err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, err)
+		return nil
+	}
+
+	loginInfo.HTTPStatus = http.StatusOK
+	hs.HooksService.RunLoginHook(&loginInfo, ctx)
+
+	if redirectTo, err := url.QueryUnescape(ctx.GetCookie("redirect_to")); err == nil && len(redirectTo) > 0 {
+		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
+			cookies.DeleteCookie(ctx.Resp, "redirect_to", hs.CookieOptionsFromCfg)
+			ctx.Redirect(redirectTo)
+			return nil
+		}
+		ctx.Logger.Debug("Ignored invalid redirect_to cookie value", "redirect_to", redirectTo)
+	}
+
+	ctx.Redirect(setting.AppSubUrl + "/")
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -28,6 +29,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -341,6 +344,7 @@ func TestLoginPostRedirect(t *testing.T) {
 		AuthTokenService: auth.NewFakeUserAuthTokenService(),
 	}
 	hs.Cfg.CookieSecure = true
+	hs.twoFactorService = twofactor.ProvideService(hs.Cfg, nil, nil)
 
 	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
 		c.Req.Header.Set("Content-Type", "application/json")
@@ -620,6 +624,7 @@ func TestLoginPostRunLokingHook(t *testing.T) {
 		AuthTokenService: auth.NewFakeUserAuthTokenService(),
 		HooksService:     hookService,
 	}
+	hs.twoFactorService = twofactor.ProvideService(hs.Cfg, nil, nil)
 
 	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
 		c.Req.Header.Set("Content-Type", "application/json")
@@ -708,6 +713,79 @@ func TestLoginPostRunLokingHook(t *testing.T) {
 	}
 }
 
+func TestLoginPostTwoFactor(t *testing.T) {
+	sc := setupScenarioContext(t, "/login")
+	sqlStore := sqlstore.InitTestDB(t)
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "admin"})
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.TOTPEnabled = true
+	cfg.TOTPIssuer = "Grafana"
+	cfg.TOTPEnforcedOrgIDs = []int64{user.OrgId}
+	cfg.DisableBruteForceLoginProtection = true
+	hs := &HTTPServer{
+		log:              log.New("test"),
+		Cfg:              cfg,
+		HooksService:     &hooks.HooksService{},
+		License:          &licensing.OSSLicensingService{},
+		AuthTokenService: auth.NewFakeUserAuthTokenService(),
+		twoFactorService: twofactor.ProvideService(cfg, sqlStore, fakes.NewFakeSecretsService()),
+	}
+
+	bus.AddHandler("grafana-auth", func(ctx context.Context, query *models.LoginUserQuery) error {
+		query.User = user
+		query.AuthModule = "grafana"
+		return nil
+	})
+
+	var body string
+	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+		c.Req.Header.Set("Content-Type", "application/json")
+		c.Req.Body = io.NopCloser(bytes.NewBufferString(body))
+		return hs.LoginPost(c)
+	})
+	sc.m.Post(sc.url, sc.defaultHandler)
+
+	login := func(t *testing.T, loginBody string) map[string]interface{} {
+		t.Helper()
+		body = loginBody
+		sc.fakeReqNoAssertions("POST", sc.url).exec()
+
+		result := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		return result
+	}
+
+	var enrollmentToken string
+	t.Run("should require the enrollment of the enforced users", func(t *testing.T) {
+		result := login(t, `{"user":"admin","password":"admin"}`)
+		assert.Equal(t, http.StatusUnauthorized, sc.resp.Code)
+		assert.Equal(t, true, result["twoFactorEnrollmentRequired"])
+		require.IsType(t, map[string]interface{}{}, result["totp"])
+		enrollment := result["totp"].(map[string]interface{})
+		assert.NotEmpty(t, enrollment["secret"])
+		require.NotEmpty(t, enrollment["token"])
+		enrollmentToken = enrollment["token"].(string)
+		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("should reject an invalid code", func(t *testing.T) {
+		result := login(t, `{"user":"admin","password":"admin","totpCode":"abcdef","totpEnrollmentToken":"`+enrollmentToken+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, sc.resp.Code)
+		assert.Equal(t, "Invalid two-factor authentication code", result["message"])
+		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("should reject a code without the token of the enrollment", func(t *testing.T) {
+		result := login(t, `{"user":"admin","password":"admin","totpCode":"123456","totpEnrollmentToken":"other"}`)
+		assert.Equal(t, http.StatusUnauthorized, sc.resp.Code)
+		assert.Equal(t, "Two-factor authentication enrollment expired", result["message"])
+		assert.Nil(t, result["totp"])
+		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
+	})
+}
+
 type mockSocialService struct {
 	oAuthInfo       *social.OAuthInfo
 	oAuthInfos      map[string]*social.OAuthInfo
@@ -14,10 +14,16 @@ import (
 	"github.com/grafana/grafana/pkg/web"
 )
 
+// statusClientClosedRequest is the non-standard status of the requests canceled by the client
+const statusClientClosedRequest = 499
+
 func (hs *HTTPServer) handleQueryMetricsError(err error) *response.NormalResponse {
 	if errors.Is(err, models.ErrDataSourceAccessDenied) {
 		return response.Error(http.StatusForbidden, "Access denied to data source", err)
 	}
+	if errors.Is(err, query.ErrQueryCanceled) {
+		return response.Error(statusClientClosedRequest, "Query canceled", err)
+	}
 	var badQuery *query.ErrBadQuery
 	if errors.As(err, &badQuery) {
 		return response.Error(http.StatusBadRequest, util.Capitalize(badQuery.Message), err)
@@ -34,12 +40,52 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext) response.Response {
 	}
 
 	resp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDTO, true)
+	hs.recordDashboardQueries(c, reqDTO, resp, err)
 	if err != nil {
 		return hs.handleQueryMetricsError(err)
 	}
 	return toJsonStreamingResponse(resp)
 }
 
+// recordDashboardQueries adds the queries of the request and the ones which failed to the usage of the dashboard
+// they come from
+func (hs *HTTPServer) recordDashboardQueries(c *models.ReqContext, reqDTO dtos.MetricRequest, resp *backend.QueryDataResponse, err error) {
+	if hs.dashboardUsage == nil || reqDTO.DashboardUID == "" {
+		return
+	}
+	failed := 0
+	if err != nil {
+		failed = len(reqDTO.Queries)
+	} else if resp != nil {
+		for _, res := range resp.Responses {
+			if res.Error != nil {
+				failed++
+			}
+		}
+	}
+	hs.dashboardUsage.RecordQueries(c.OrgId, reqDTO.DashboardUID, len(reqDTO.Queries), failed)
+}
+
+// GetQueryTransformations returns the server-side transformations registered by the backend plugins, which can be
+// applied to the query responses
+// GET /api/ds/query/transformations
+func (hs *HTTPServer) GetQueryTransformations(c *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.queryDataService.Transformations(c.Req.Context()))
+}
+
+// CancelQuery cancels a running query of the signed in user started with a query ID. The cancellation is propagated
+// to the data source.
+// POST /api/ds/query/:queryId/cancel
+func (hs *HTTPServer) CancelQuery(c *models.ReqContext) response.Response {
+	if err := hs.queryDataService.CancelQuery(c.SignedInUser, web.Params(c.Req)[":queryId"]); err != nil {
+		if errors.Is(err, query.ErrQueryNotFound) {
+			return response.Error(http.StatusNotFound, "Query not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to cancel query", err)
+	}
+	return response.Success("Query canceled")
+}
+
 // QueryMetrics returns query metrics
 // POST /api/tsdb/query
 //nolint: staticcheck // legacydata.DataResponse deprecated
@@ -51,6 +97,7 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext) response.Response {
 	}
 
 	sdkResp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDto, false)
+	hs.recordDashboardQueries(c, reqDto, sdkResp, err)
 	if err != nil {
 		return hs.handleQueryMetricsError(err)
 	}
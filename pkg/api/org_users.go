@@ -2,14 +2,18 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -67,10 +71,16 @@ func (hs *HTTPServer) addOrgUserHelper(ctx context.Context, cmd models.AddOrgUse
 
 // GET /api/org/users
 func (hs *HTTPServer) GetOrgUsersForCurrentOrg(c *models.ReqContext) response.Response {
+	filterUser, err := hs.accessControlFilterUser(c)
+	if err != nil {
+		return response.Error(500, "Failed to get users for current organization", err)
+	}
+
 	result, err := hs.getOrgUsersHelper(c, &models.GetOrgUsersQuery{
 		OrgId: c.OrgId,
 		Query: c.Query("query"),
 		Limit: c.QueryInt("limit"),
+		User:  filterUser,
 	}, c.SignedInUser)
 
 	if err != nil {
@@ -80,12 +90,45 @@ func (hs *HTTPServer) GetOrgUsersForCurrentOrg(c *models.ReqContext) response.Re
 	return response.JSON(200, result)
 }
 
+// GET /api/org/users/export
+//
+// Downloads the users of the current organization matching the query as JSON lines, streamed from the database rather
+// than listed in memory.
+func (hs *HTTPServer) ExportOrgUsersForCurrentOrg(c *models.ReqContext) response.Response {
+	filterUser, err := hs.accessControlFilterUser(c)
+	if err != nil {
+		return response.Error(500, "Failed to get users for current organization", err)
+	}
+
+	query := &models.GetOrgUsersQuery{
+		OrgId: c.OrgId,
+		Query: c.Query("query"),
+		User:  filterUser,
+	}
+	return response.Attachment("grafana-org-users.jsonl", "text/plain; charset=utf-8", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return hs.SQLStore.StreamOrgUsers(c.Req.Context(), query, func(user *models.OrgUserDTO) error {
+			if dtos.IsHiddenUser(user.Login, c.SignedInUser, hs.Cfg) {
+				return nil
+			}
+			user.AvatarUrl = dtos.GetGravatarUrl(user.Email)
+			return enc.Encode(user)
+		})
+	})
+}
+
 // GET /api/org/users/lookup
 func (hs *HTTPServer) GetOrgUsersForCurrentOrgLookup(c *models.ReqContext) response.Response {
+	filterUser, err := hs.accessControlFilterUser(c)
+	if err != nil {
+		return response.Error(500, "Failed to get users for current organization", err)
+	}
+
 	orgUsers, err := hs.getOrgUsersHelper(c, &models.GetOrgUsersQuery{
 		OrgId: c.OrgId,
 		Query: c.Query("query"),
 		Limit: c.QueryInt("limit"),
+		User:  filterUser,
 	}, c.SignedInUser)
 
 	if err != nil {
@@ -110,12 +153,7 @@ func (hs *HTTPServer) getUserAccessControlMetadata(c *models.ReqContext, resourc
 		return nil, nil
 	}
 
-	userPermissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
-	if err != nil || len(userPermissions) == 0 {
-		return nil, err
-	}
-
-	return accesscontrol.GetResourcesMetadata(c.Req.Context(), userPermissions, "users", resourceIDs), nil
+	return hs.AccessControl.GetUserResourcesMetadata(c.Req.Context(), c.SignedInUser, "users", resourceIDs)
 }
 
 // GET /api/orgs/:orgId/users
@@ -133,6 +171,29 @@ func (hs *HTTPServer) GetOrgUsers(c *models.ReqContext) response.Response {
 	return response.JSON(200, result)
 }
 
+// GET /api/orgs/:orgId/users/:userId/history
+//
+// Returns the changes of the roles and of the team memberships of the user in the organization, and the sign in
+// attempts of the user, newest first.
+func (hs *HTTPServer) GetOrgUserHistory(c *models.ReqContext) response.Response {
+	query := &audit.UserHistoryQuery{
+		OrgId:   c.ParamsInt64(":orgId"),
+		UserId:  c.ParamsInt64(":userId"),
+		Page:    c.QueryInt("page"),
+		PerPage: c.QueryInt("perpage"),
+	}
+	for _, t := range c.QueryStrings("type") {
+		query.Types = append(query.Types, audit.EventType(t))
+	}
+
+	result, err := hs.auditService.GetUserHistory(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(500, "Failed to get user history", err)
+	}
+
+	return response.JSON(200, result)
+}
+
 func (hs *HTTPServer) getOrgUsersHelper(c *models.ReqContext, query *models.GetOrgUsersQuery, signedInUser *models.SignedInUser) ([]*models.OrgUserDTO, error) {
 	if err := hs.SQLStore.GetOrgUsers(c.Req.Context(), query); err != nil {
 		return nil, err
@@ -178,11 +239,17 @@ func (hs *HTTPServer) SearchOrgUsersWithPaging(c *models.ReqContext) response.Re
 		page = 1
 	}
 
+	filterUser, err := hs.accessControlFilterUser(c)
+	if err != nil {
+		return response.Error(500, "Failed to get users for current organization", err)
+	}
+
 	query := &models.SearchOrgUsersQuery{
 		OrgID: c.OrgId,
 		Query: c.Query("query"),
 		Limit: perPage,
 		Page:  page,
+		User:  filterUser,
 	}
 
 	if err := hs.SQLStore.SearchOrgUsers(ctx, query); err != nil {
@@ -251,6 +318,30 @@ func (hs *HTTPServer) RemoveOrgUserForCurrentOrg(c *models.ReqContext) response.
 	})
 }
 
+// DELETE /api/org/users/:userId/sessions
+//
+// Revokes the sessions of a user of the current organization. The sessions of the Grafana admins can only be revoked by
+// Grafana admins, an organization admin cannot sign out the administrators of the server.
+func (hs *HTTPServer) RevokeOrgUserSessions(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":userId")
+
+	query := models.GetSignedInUserQuery{UserId: userID, OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, "User not found", err)
+		}
+		return response.Error(500, "Failed to get user", err)
+	}
+	if query.Result.OrgRole == "" {
+		return response.Error(404, "User not found in the organization", nil)
+	}
+	if query.Result.IsGrafanaAdmin && !c.IsGrafanaAdmin {
+		return response.Error(403, "Only Grafana admins can revoke the sessions of Grafana admins", nil)
+	}
+
+	return hs.revokeUserAuthTokensInternal(c, userID)
+}
+
 // DELETE /api/orgs/:orgId/users/:userId
 func (hs *HTTPServer) RemoveOrgUser(c *models.ReqContext) response.Response {
 	return hs.removeOrgUserHelper(c.Req.Context(), &models.RemoveOrgUserCommand{
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -113,6 +114,26 @@ func TestOrgUsersAPIEndpoint_userLoggedIn(t *testing.T) {
 			assert.Equal(t, "user2", resp[1].Login)
 		})
 
+		loggedInUserScenario(t, "When calling GET on", "api/org/users/export", func(sc *scenarioContext) {
+			setUpGetOrgUsersDB(t, sqlStore)
+
+			sc.handlerFunc = hs.ExportOrgUsersForCurrentOrg
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+			assert.Equal(t, `attachment; filename="grafana-org-users.jsonl"`, sc.resp.Header().Get("Content-Disposition"))
+
+			var logins []string
+			dec := json.NewDecoder(sc.resp.Body)
+			for dec.More() {
+				var user models.OrgUserDTO
+				require.NoError(t, dec.Decode(&user))
+				assert.NotEmpty(t, user.AvatarUrl)
+				logins = append(logins, user.Login)
+			}
+			assert.Equal(t, []string{testUserLogin, "user2"}, logins)
+		})
+
 		loggedInUserScenarioWithRole(t, "When calling GET as an admin on", "GET", "api/org/users/lookup",
 			"api/org/users/lookup", models.ROLE_ADMIN, func(sc *scenarioContext) {
 				setUpGetOrgUsersDB(t, sqlStore)
@@ -324,6 +345,7 @@ func TestGetOrgUsersAPIEndpoint_AccessControlMetadata(t *testing.T) {
 			expectedMetadata: map[string]bool{
 				"org.users.role:update": true,
 				"org.users:add":         true,
+				"org.users:logout":      true,
 				"org.users:read":        true,
 				"org.users:remove":      true},
 			user:      testServerAdminViewer,
@@ -807,3 +829,71 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOrgUserHistoryAPIEndpoint_AccessControl(t *testing.T) {
+	url := "/api/orgs/%v/users/%v/history"
+	type testCase struct {
+		name                string
+		enableAccessControl bool
+		user                models.SignedInUser
+		targetOrg           int64
+		expectedCode        int
+		expectedEvents      int
+	}
+
+	tests := []testCase{
+		{
+			name:           "server admin can get the history of users of another org (legacy)",
+			user:           testServerAdminViewer,
+			targetOrg:      2,
+			expectedCode:   http.StatusOK,
+			expectedEvents: 1,
+		},
+		{
+			name:         "org admin cannot get the history of users of his org (legacy)",
+			user:         testAdminOrg2,
+			targetOrg:    testAdminOrg2.OrgId,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:                "org admin can get the history of users of his org",
+			enableAccessControl: true,
+			user:                testAdminOrg2,
+			targetOrg:           testAdminOrg2.OrgId,
+			expectedCode:        http.StatusOK,
+			expectedEvents:      1,
+		},
+		{
+			name:                "org admin cannot get the history of users of another org",
+			enableAccessControl: true,
+			user:                testAdminOrg2,
+			targetOrg:           1,
+			expectedCode:        http.StatusForbidden,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sc := setupHTTPServer(t, false, tc.enableAccessControl)
+			setupOrgUsersDBForAccessControlTests(t, *sc.db)
+			setInitCtxSignedInUser(sc.initCtx, tc.user)
+
+			sc.hs.auditService = newTestAuditService(t, sc.hs.Cfg, sc.db)
+			for _, orgID := range []int64{1, 2} {
+				err := sc.hs.auditService.Record(context.Background(), &audit.Event{
+					OrgId: orgID, UserId: testServerAdminViewer.UserId, Type: audit.EventTypeRole, Action: audit.ActionOrgUserAdded,
+				})
+				require.NoError(t, err)
+			}
+
+			response := callAPI(sc.server, http.MethodGet, fmt.Sprintf(url, tc.targetOrg, testServerAdminViewer.UserId), nil, t)
+			assert.Equal(t, tc.expectedCode, response.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				var result audit.UserHistoryResult
+				require.NoError(t, json.NewDecoder(response.Body).Decode(&result))
+				require.Len(t, result.Events, tc.expectedEvents)
+				assert.Equal(t, tc.targetOrg, result.Events[0].OrgId)
+			}
+		})
+	}
+}
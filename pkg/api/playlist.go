@@ -64,11 +64,12 @@ func GetPlaylist(c *models.ReqContext) response.Response {
 	playlistDTOs, _ := LoadPlaylistItemDTOs(c.Req.Context(), id)
 
 	dto := &models.PlaylistDTO{
-		Id:       cmd.Result.Id,
-		Name:     cmd.Result.Name,
-		Interval: cmd.Result.Interval,
-		OrgId:    cmd.Result.OrgId,
-		Items:    playlistDTOs,
+		Id:          cmd.Result.Id,
+		Name:        cmd.Result.Name,
+		Interval:    cmd.Result.Interval,
+		ScheduleUid: cmd.Result.ScheduleUid,
+		OrgId:       cmd.Result.OrgId,
+		Items:       playlistDTOs,
 	}
 
 	return response.JSON(200, dto)
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
+)
+
+// matchLabelSelector returns the resources of the types matching the labelSelector query parameter of the list
+// endpoints, the match is nil when the parameter is not set
+func (hs *HTTPServer) matchLabelSelector(c *models.ReqContext, resourceTypes ...resourcelabels.ResourceType) (*resourcelabels.Match, response.Response) {
+	selector := c.Query("labelSelector")
+	if selector == "" || hs.resourceLabelsService == nil {
+		return nil, nil
+	}
+
+	match, err := hs.resourceLabelsService.MatchSelector(c.Req.Context(), c.OrgId, selector, resourceTypes...)
+	if err != nil {
+		return nil, resourcelabels.SelectorErrorResponse(err)
+	}
+	return match, nil
+}
@@ -3,6 +3,8 @@ package response
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -129,6 +131,64 @@ func (r StreamingResponse) WriteTo(ctx *models.ReqContext) {
 	}
 }
 
+// AttachmentResponse is a response downloaded as a file, whose body is written to the client as it is produced.
+type AttachmentResponse struct {
+	filename    string
+	contentType string
+	write       func(w io.Writer) error
+}
+
+// Status gets the response's status.
+// Required to implement api.Response.
+func (r *AttachmentResponse) Status() int {
+	return http.StatusOK
+}
+
+// Body gets the response's body.
+// Required to implement api.Response.
+func (r *AttachmentResponse) Body() []byte {
+	return nil
+}
+
+// WriteTo writes the response to the provided context. An error of the writing before anything is written is
+// answered with an Internal Server Error, after that the body can only be cut short.
+func (r *AttachmentResponse) WriteTo(ctx *models.ReqContext) {
+	w := &attachmentWriter{ctx: ctx, r: r}
+	if err := r.write(w); err != nil {
+		if !w.started {
+			Error(http.StatusInternalServerError, "Failed to write "+r.filename, err).WriteTo(ctx)
+			return
+		}
+		ctx.Logger.Error("Failed to write "+r.filename, "error", err, "remote_addr", ctx.RemoteAddr())
+		return
+	}
+	if !w.started {
+		w.writeHeader()
+	}
+}
+
+// attachmentWriter delays the status and the headers of an attachment until its first write
+type attachmentWriter struct {
+	ctx     *models.ReqContext
+	r       *AttachmentResponse
+	started bool
+}
+
+func (w *attachmentWriter) writeHeader() {
+	w.started = true
+	header := w.ctx.Resp.Header()
+	header.Set("Content-Type", w.r.contentType)
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.r.filename))
+	w.ctx.Resp.WriteHeader(http.StatusOK)
+}
+
+func (w *attachmentWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.writeHeader()
+	}
+	return w.ctx.Resp.Write(b)
+}
+
 // RedirectResponse represents a redirect response.
 type RedirectResponse struct {
 	location string
@@ -232,6 +292,12 @@ func Respond(status int, body interface{}) *NormalResponse {
 	}
 }
 
+// Attachment creates a response downloaded as the file named filename, whose body is written by write as it is
+// produced rather than buffered.
+func Attachment(filename string, contentType string, write func(w io.Writer) error) *AttachmentResponse {
+	return &AttachmentResponse{filename: filename, contentType: contentType, write: write}
+}
+
 func Redirect(location string) *RedirectResponse {
 	return &RedirectResponse{location: location}
 }
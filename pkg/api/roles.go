@@ -8,12 +8,21 @@ import (
 // API related actions
 const (
 	ActionProvisioningReload = "provisioning:reload"
+	ActionProvisioningRead   = "provisioning:read"
 
-	ActionDatasourcesRead   = "datasources:read"
-	ActionDatasourcesQuery  = "datasources:query"
+	ActionServerCachesRead  = "server.caches:read"
+	ActionServerCachesWrite = "server.caches:write"
+
+	ActionServerDatabaseBackup = "server.database:backup"
+
+	ActionAuditAuthRead = "audit.auth:read"
+	ActionAuditRead     = "audit:read"
+
+	ActionDatasourcesRead   = accesscontrol.ActionDatasourcesRead
+	ActionDatasourcesQuery  = accesscontrol.ActionDatasourcesQuery
 	ActionDatasourcesCreate = "datasources:create"
-	ActionDatasourcesWrite  = "datasources:write"
-	ActionDatasourcesDelete = "datasources:delete"
+	ActionDatasourcesWrite  = accesscontrol.ActionDatasourcesWrite
+	ActionDatasourcesDelete = accesscontrol.ActionDatasourcesDelete
 	ActionDatasourcesIDRead = "datasources.id:read"
 
 	ActionOrgsRead             = "orgs:read"
@@ -33,6 +42,9 @@ var (
 	ScopeProvisionersPlugins       = accesscontrol.Scope("provisioners", "plugins")
 	ScopeProvisionersDatasources   = accesscontrol.Scope("provisioners", "datasources")
 	ScopeProvisionersNotifications = accesscontrol.Scope("provisioners", "notifications")
+	ScopeProvisionersGroupMappings = accesscontrol.Scope("provisioners", "groupmappings")
+	ScopeProvisionersRoleBundles   = accesscontrol.Scope("provisioners", "rolebundles")
+	ScopeProvisionersRoleBindings  = accesscontrol.Scope("provisioners", "rolebindings")
 
 	ScopeDatasourcesAll = accesscontrol.Scope("datasources", "*")
 	ScopeDatasourceID   = accesscontrol.Scope("datasources", "id", accesscontrol.Parameter(":id"))
@@ -46,16 +58,20 @@ var (
 func (hs *HTTPServer) declareFixedRoles() error {
 	provisioningWriterRole := accesscontrol.RoleRegistration{
 		Role: accesscontrol.RoleDTO{
-			Version:     3,
+			Version:     4,
 			Name:        "fixed:provisioning:writer",
 			DisplayName: "Provisioning writer",
-			Description: "Reload provisioning.",
+			Description: "Reload provisioning, and detect and reconcile the drift of the provisioned resources.",
 			Group:       "Provisioning",
 			Permissions: []accesscontrol.Permission{
 				{
 					Action: ActionProvisioningReload,
 					Scope:  ScopeProvisionersAll,
 				},
+				{
+					Action: ActionProvisioningRead,
+					Scope:  ScopeProvisionersAll,
+				},
 			},
 		},
 		Grants: []string{accesscontrol.RoleGrafanaAdmin},
@@ -185,9 +201,68 @@ func (hs *HTTPServer) declareFixedRoles() error {
 		Grants: []string{string(accesscontrol.RoleGrafanaAdmin)},
 	}
 
+	serverCachesWriterRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:server.caches:writer",
+			DisplayName: "Server caches writer",
+			Description: "Inspect and purge the caches of the server.",
+			Group:       "Infrequently used",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionServerCachesRead},
+				{Action: ActionServerCachesWrite},
+			},
+		},
+		Grants: []string{string(accesscontrol.RoleGrafanaAdmin)},
+	}
+
+	serverDatabaseBackupRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:server.database:backup",
+			DisplayName: "Database backup",
+			Description: "Download a backup of the SQLite database, which holds the secrets of all organizations.",
+			Group:       "Infrequently used",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionServerDatabaseBackup},
+			},
+		},
+		Grants: []string{string(accesscontrol.RoleGrafanaAdmin)},
+	}
+
+	auditAuthReaderRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:audit.auth:reader",
+			DisplayName: "Authentication audit reader",
+			Description: "Read the audit log of the sign ins, sessions, and API keys of all users.",
+			Group:       "Infrequently used",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionAuditAuthRead},
+			},
+		},
+		Grants: []string{string(accesscontrol.RoleGrafanaAdmin)},
+	}
+
+	auditReaderRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:audit:reader",
+			DisplayName: "Audit reader",
+			Description: "Query and export the audit log of the role, team and authentication changes of all users.",
+			Group:       "Infrequently used",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionAuditRead},
+				{Action: ActionAuditAuthRead},
+			},
+		},
+		Grants: []string{string(accesscontrol.RoleGrafanaAdmin)},
+	}
+
 	return hs.AccessControl.DeclareFixedRoles(
 		provisioningWriterRole, datasourcesReaderRole, datasourcesWriterRole, datasourcesIdReaderRole,
-		datasourcesCompatibilityReaderRole, orgReaderRole, orgWriterRole, orgMaintainerRole,
+		datasourcesCompatibilityReaderRole, orgReaderRole, orgWriterRole, orgMaintainerRole, serverCachesWriterRole,
+		serverDatabaseBackupRole, auditAuthReaderRole, auditReaderRole,
 	)
 }
 
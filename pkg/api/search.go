@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,10 +11,13 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
+	"github.com/grafana/grafana/pkg/services/savedsearch"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
-func Search(c *models.ReqContext) response.Response {
+func (hs *HTTPServer) Search(c *models.ReqContext) response.Response {
 	query := c.Query("query")
 	tags := c.QueryStrings("tag")
 	starred := c.Query("starred")
@@ -62,15 +66,61 @@ func Search(c *models.ReqContext) response.Response {
 		Sort:         sort,
 	}
 
-	err := bus.Dispatch(c.Req.Context(), &searchQuery)
+	filterUser, err := hs.accessControlFilterUser(c)
 	if err != nil {
 		return response.Error(500, "Search failed", err)
 	}
+	if filterUser != nil {
+		searchQuery.Filters = append(searchQuery.Filters, permissions.AccessControlDashboardFilter{User: filterUser})
+	}
+
+	labelMatch, errResp := hs.matchLabelSelector(c, resourcelabels.ResourceTypeDashboard, resourcelabels.ResourceTypeFolder)
+	if errResp != nil {
+		return errResp
+	}
+	if labelMatch != nil {
+		searchQuery.Filters = append(searchQuery.Filters, labelMatch.Filter("dashboard.uid"))
+	}
+
+	savedSearchUID := c.Query("savedSearchUid")
+	if savedSearchUID != "" {
+		savedSearch, err := hs.savedSearchService.GetSavedSearch(c.Req.Context(), c.SignedInUser, savedSearchUID)
+		if errors.Is(err, savedsearch.ErrSavedSearchNotFound) {
+			return response.Error(404, "Saved search not found", err)
+		}
+		if err != nil {
+			return response.Error(500, "Search failed", err)
+		}
+		savedSearch.Apply(&searchQuery)
+	}
+
+	if err := bus.Dispatch(c.Req.Context(), &searchQuery); err != nil {
+		return response.Error(500, "Search failed", err)
+	}
+
+	// Saved searches are listed as virtual folders next to the folders at the root of the dashboard list
+	if savedSearchUID == "" && isRootListing(searchQuery) && hs.savedSearchService != nil && c.IsSignedIn && !c.IsAnonymous {
+		savedSearches, err := hs.savedSearchService.GetSavedSearches(c.Req.Context(), c.SignedInUser)
+		if err != nil {
+			return response.Error(500, "Search failed", err)
+		}
+		hits := make(search.HitList, 0, len(savedSearches)+len(searchQuery.Result))
+		for _, savedSearch := range savedSearches {
+			hits = append(hits, savedSearch.Hit())
+		}
+		searchQuery.Result = append(hits, searchQuery.Result...)
+	}
 
 	c.TimeRequest(metrics.MApiDashboardSearch)
 	return response.JSON(200, searchQuery.Result)
 }
 
+// isRootListing returns true if the query lists the folders and dashboards at the root of the dashboard list
+func isRootListing(query search.Query) bool {
+	return query.Title == "" && len(query.Tags) == 0 && !query.IsStarred && len(query.DashboardIds) == 0 &&
+		len(query.FolderIds) == 0 && (query.Type == "" || query.Type == string(search.DashHitFolder)) && query.Page <= 1
+}
+
 func (hs *HTTPServer) ListSortOptions(c *models.ReqContext) response.Response {
 	opts := hs.SearchService.SortOptions()
 
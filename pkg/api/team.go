@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/teamguardian"
 	"github.com/grafana/grafana/pkg/util"
@@ -121,6 +122,14 @@ func (hs *HTTPServer) SearchTeams(c *models.ReqContext) response.Response {
 		HiddenUsers:  hs.Cfg.HiddenUsers,
 	}
 
+	labelMatch, errResp := hs.matchLabelSelector(c, resourcelabels.ResourceTypeTeam)
+	if errResp != nil {
+		return errResp
+	}
+	if labelMatch != nil {
+		query.Filters = append(query.Filters, labelMatch.IDFilter("team.id"))
+	}
+
 	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
 		return response.Error(500, "Failed to search Teams", err)
 	}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/test/fixtures
+func (hs *HTTPServer) GetTestFixtures(c *models.ReqContext) response.Response {
+	query := models.GetTestFixturesQuery{}
+	if err := hs.SQLStore.GetTestFixtures(c.Req.Context(), &query); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get test fixtures", err)
+	}
+	return response.JSON(http.StatusOK, query.Result)
+}
+
+// GET /api/test/fixtures/:uid
+func (hs *HTTPServer) GetTestFixture(c *models.ReqContext) response.Response {
+	query := models.GetTestFixtureQuery{Uid: web.Params(c.Req)[":uid"]}
+	if err := hs.SQLStore.GetTestFixture(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrTestFixtureNotFound) {
+			return response.Error(http.StatusNotFound, "Test fixture not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get test fixture", err)
+	}
+	return response.JSON(http.StatusOK, query.Result)
+}
+
+// POST /api/test/fixtures
+func (hs *HTTPServer) CreateTestFixture(c *models.ReqContext) response.Response {
+	cmd := models.CreateTestFixtureCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.SQLStore.CreateTestFixture(c.Req.Context(), &cmd); err != nil {
+		switch {
+		case errors.Is(err, models.ErrTestFixtureInvalid):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, models.ErrUserAlreadyExists), errors.Is(err, models.ErrOrgNameTaken):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to create test fixture", err)
+	}
+
+	return response.JSON(http.StatusOK, cmd.Result)
+}
+
+// DELETE /api/test/fixtures/:uid
+func (hs *HTTPServer) DeleteTestFixture(c *models.ReqContext) response.Response {
+	cmd := models.DeleteTestFixtureCommand{Uid: web.Params(c.Req)[":uid"]}
+	if err := hs.SQLStore.DeleteTestFixture(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrTestFixtureNotFound) {
+			return response.Error(http.StatusNotFound, "Test fixture not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete test fixture", err)
+	}
+	return response.Success("Test fixture deleted")
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAPIEndpoint_TestFixtures(t *testing.T) {
+	fixture := `{
+		"users": [{"login": "tf-user", "password": "secret"}],
+		"orgs": [{"name": "tf-org", "members": [{"login": "tf-user", "role": "Editor"}]}]
+	}`
+
+	t.Run("should not be registered when disabled", func(t *testing.T) {
+		sc := setupHTTPServer(t, true, false)
+		setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, IsGrafanaAdmin: true})
+		response := callAPI(sc.server, http.MethodPost, "/api/test/fixtures", strings.NewReader(fixture), t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	sc := setupHTTPServer(t, true, false)
+	sc.cfg.TestFixturesEnabled = true
+	sc.hs.RouteRegister = routing.NewRouteRegister()
+	sc.hs.registerRoutes()
+	server := web.New()
+	server.Use(func(c *web.Context) {
+		sc.initCtx.Context = c
+		sc.initCtx.Logger = log.New("api-test")
+		c.Map(sc.initCtx)
+	})
+	sc.hs.RouteRegister.Register(server.Router)
+
+	t.Run("should require a server admin", func(t *testing.T) {
+		setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN})
+		response := callAPI(server, http.MethodPost, "/api/test/fixtures", strings.NewReader(fixture), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	setInitCtxSignedInUser(sc.initCtx, models.SignedInUser{UserId: 1, OrgId: 1, IsGrafanaAdmin: true})
+
+	response := callAPI(server, http.MethodPost, "/api/test/fixtures", strings.NewReader(fixture), t)
+	require.Equal(t, http.StatusOK, response.Code)
+	var created models.TestFixture
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+	require.Len(t, created.Orgs, 1)
+	assert.Equal(t, "tf-org", created.Orgs[0].Name)
+
+	t.Run("should reject conflicting fixtures", func(t *testing.T) {
+		response := callAPI(server, http.MethodPost, "/api/test/fixtures", strings.NewReader(fixture), t)
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("should reject invalid fixtures", func(t *testing.T) {
+		response := callAPI(server, http.MethodPost, "/api/test/fixtures", strings.NewReader(`{"orgs": []}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should tear down fixtures", func(t *testing.T) {
+		response := callAPI(server, http.MethodDelete, "/api/test/fixtures/"+created.Uid, nil, t)
+		assert.Equal(t, http.StatusOK, response.Code)
+		response = callAPI(server, http.MethodGet, "/api/test/fixtures/"+created.Uid, nil, t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -60,15 +61,13 @@ func (hs *HTTPServer) getGlobalUserAccessControlMetadata(c *models.ReqContext, u
 		return nil, nil
 	}
 
-	userPermissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
-	if err != nil || len(userPermissions) == 0 {
+	key := fmt.Sprintf("%d", userID)
+	metadata, err := hs.AccessControl.GetUserResourcesMetadata(c.Req.Context(), c.SignedInUser, "global:users", map[string]bool{key: true})
+	if err != nil {
 		return nil, err
 	}
 
-	key := fmt.Sprintf("%d", userID)
-	userIDs := map[string]bool{key: true}
-
-	return accesscontrol.GetResourcesMetadata(c.Req.Context(), userPermissions, "global:users", userIDs)[key], nil
+	return metadata[key], nil
 }
 
 // GET /api/users/lookup
@@ -329,6 +328,11 @@ func ClearHelpFlags(c *models.ReqContext) response.Response {
 }
 
 func GetAuthProviderLabel(authModule string) string {
+	// the authentication providers of the app plugins
+	if strings.HasPrefix(authModule, "plugin_") {
+		return "Plugin"
+	}
+
 	switch authModule {
 	case "oauth_github":
 		return "GitHub"
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/user/access-tokens
+//
+// Returns the personal access tokens of the signed in user in the current organization.
+func GetUserAccessTokens(c *models.ReqContext) response.Response {
+	query := models.GetUserAccessTokensQuery{UserId: c.UserId, OrgId: c.OrgId, IncludeExpired: c.QueryBool("includeExpired")}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list personal access tokens", err)
+	}
+
+	ids := make([]int64, len(query.Result))
+	for i, t := range query.Result {
+		ids[i] = t.Id
+	}
+	permissionsQuery := models.GetApiKeyPermissionsQuery{OrgId: c.OrgId, ApiKeyIds: ids}
+	if err := bus.Dispatch(c.Req.Context(), &permissionsQuery); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list personal access token permissions", err)
+	}
+	permissions := make(map[int64][]models.ApiKeyPermissionDTO)
+	for _, p := range permissionsQuery.Result {
+		permissions[p.ApiKeyId] = append(permissions[p.ApiKeyId], models.ApiKeyPermissionDTO{Action: p.Action, Scope: p.Scope})
+	}
+
+	result := make([]*models.UserAccessTokenDTO, len(query.Result))
+	for i, t := range query.Result {
+		var expiration *time.Time
+		if t.Expires != nil {
+			v := time.Unix(*t.Expires, 0)
+			expiration = &v
+		}
+		result[i] = &models.UserAccessTokenDTO{
+			Id:          t.Id,
+			Name:        t.Name,
+			Created:     t.Created,
+			Expiration:  expiration,
+			LastUsedAt:  t.LastUsedAt,
+			LastUsedIp:  t.LastUsedIp,
+			Permissions: permissions[t.Id],
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// POST /api/user/access-tokens
+//
+// Creates a personal access token of the signed in user in the current organization. The requests authenticated with
+// the token are made as the user, with only the permissions of the token when it has some.
+func (hs *HTTPServer) AddUserAccessToken(c *models.ReqContext) response.Response {
+	// the tokens cannot be used to create other tokens, which would outlive them
+	if c.UserId == 0 || c.ApiKeyId != 0 {
+		return response.Error(http.StatusBadRequest, "Personal access tokens can only be created by signed in users", nil)
+	}
+
+	form := dtos.AddUserAccessTokenForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd := models.AddApiKeyCommand{
+		Name:          form.Name,
+		Role:          c.OrgRole,
+		OrgId:         c.OrgId,
+		SecondsToLive: form.SecondsToLive,
+		UserId:        c.UserId,
+	}
+	if len(form.Permissions) > 0 {
+		permissions, errResp := hs.validateAPIKeyPermissions(c, form.Permissions)
+		if errResp != nil {
+			return errResp
+		}
+		cmd.Permissions = permissions
+		// The requests of the scoped tokens are made with the Viewer role, not to grant the role of the user to the
+		// routes not evaluated with access control
+		cmd.Role = models.ROLE_VIEWER
+	}
+	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
+		if cmd.SecondsToLive == 0 {
+			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
+		}
+		if cmd.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
+			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
+		}
+	}
+
+	newKeyInfo, err := apikeygen.New(cmd.OrgId, cmd.Name)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Generating personal access token failed", err)
+	}
+	cmd.Key = newKeyInfo.HashedKey
+
+	if err := bus.Dispatch(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKeyExpiration) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		if errors.Is(err, models.ErrDuplicateApiKey) {
+			return response.Error(http.StatusConflict, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add personal access token", err)
+	}
+
+	return response.JSON(http.StatusOK, &dtos.NewApiKeyResult{
+		ID:   cmd.Result.Id,
+		Name: cmd.Result.Name,
+		Key:  newKeyInfo.ClientSecret,
+	})
+}
+
+// DELETE /api/user/access-tokens/:id
+//
+// Revokes a personal access token of the signed in user.
+func RevokeUserAccessToken(c *models.ReqContext) response.Response {
+	cmd := models.DeleteUserAccessTokenCommand{Id: c.ParamsInt64(":id"), UserId: c.UserId}
+	if err := bus.Dispatch(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrUserAccessTokenNotFound) {
+			return response.Error(http.StatusNotFound, "Personal access token not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to revoke personal access token", err)
+	}
+	return response.Success("Personal access token revoked")
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	sa "github.com/grafana/grafana/pkg/services/serviceaccounts/manager"
+)
+
+func TestAPIEndpoint_UserAccessTokens(t *testing.T) {
+	sc := setupHTTPServer(t, true, true)
+	sc.hs.Cfg.ApiKeyMaxSecondsToLive = -1
+	bus.AddHandler("test", sc.db.AddAPIKey)
+	bus.AddHandler("test", sc.db.GetAPIKeys)
+	bus.AddHandler("test", sc.db.GetAPIKeyPermissions)
+	bus.AddHandler("test", sc.db.GetUserAccessTokens)
+	bus.AddHandler("test", sc.db.DeleteUserAccessToken)
+	t.Cleanup(bus.ClearBusHandlers)
+	setInitCtxSignedInOrgAdmin(sc.initCtx)
+	setAccessControlPermissions(sc.acmock, []*accesscontrol.Permission{
+		{Action: sa.ActionApikeyList},
+		{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:*"},
+	}, sc.initCtx.OrgId)
+
+	var token models.UserAccessTokenDTO
+	t.Run("should add tokens with a subset of the permissions of the user", func(t *testing.T) {
+		body := `{"name": "ci", "permissions": [{"action": "datasources:query", "scope": "datasources:uid:abc"}]}`
+		response := callAPI(sc.server, http.MethodPost, "/api/user/access-tokens", strings.NewReader(body), t)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		response = callAPI(sc.server, http.MethodGet, "/api/user/access-tokens", nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+		var tokens []models.UserAccessTokenDTO
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &tokens))
+		require.Len(t, tokens, 1)
+		token = tokens[0]
+		assert.Equal(t, "ci", token.Name)
+		assert.Nil(t, token.LastUsedAt)
+		assert.Equal(t, []models.ApiKeyPermissionDTO{{Action: "datasources:query", Scope: "datasources:uid:abc"}}, token.Permissions)
+	})
+
+	t.Run("should not list the tokens with the API keys of the organization", func(t *testing.T) {
+		response := callAPI(sc.server, http.MethodGet, "/api/auth/keys", nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `[]`, response.Body.String())
+	})
+
+	t.Run("should not add tokens with permissions the user is not granted", func(t *testing.T) {
+		body := `{"name": "escalated", "permissions": [{"action": "users:write", "scope": "global:users:*"}]}`
+		response := callAPI(sc.server, http.MethodPost, "/api/user/access-tokens", strings.NewReader(body), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should not add tokens with a token", func(t *testing.T) {
+		sc.initCtx.ApiKeyId = token.Id
+		t.Cleanup(func() { sc.initCtx.ApiKeyId = 0 })
+
+		body := `{"name": "child"}`
+		response := callAPI(sc.server, http.MethodPost, "/api/user/access-tokens", strings.NewReader(body), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should revoke the tokens of the user", func(t *testing.T) {
+		url := fmt.Sprintf("/api/user/access-tokens/%d", token.Id)
+		response := callAPI(sc.server, http.MethodDelete, url, nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		response = callAPI(sc.server, http.MethodDelete, url, nil, t)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
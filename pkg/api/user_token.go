@@ -29,6 +29,11 @@ func (hs *HTTPServer) RevokeUserAuthToken(c *models.ReqContext) response.Respons
 	return hs.revokeUserAuthTokenInternal(c, c.UserId, cmd)
 }
 
+// DELETE /api/user/auth-tokens
+func (hs *HTTPServer) RevokeUserAuthTokens(c *models.ReqContext) response.Response {
+	return hs.revokeUserAuthTokensInternal(c, c.UserId)
+}
+
 func (hs *HTTPServer) logoutUserFromAllDevicesInternal(ctx context.Context, userID int64) response.Response {
 	userQuery := models.GetUserByIdQuery{Id: userID}
 
@@ -64,56 +69,64 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *models.ReqContext, userID int
 		return response.Error(500, "Failed to get user auth tokens", err)
 	}
 
+	parser := uaparser.NewFromSaved()
 	result := []*dtos.UserToken{}
 	for _, token := range tokens {
-		isActive := false
-		if c.UserToken != nil && c.UserToken.Id == token.Id {
-			isActive = true
-		}
+		isActive := c.UserToken != nil && c.UserToken.Id == token.Id
+		result = append(result, newUserTokenDTO(parser, token, isActive))
+	}
 
-		parser := uaparser.NewFromSaved()
-		client := parser.Parse(token.UserAgent)
+	return response.JSON(200, result)
+}
 
-		osVersion := ""
-		if client.Os.Major != "" {
-			osVersion = client.Os.Major
+// newUserTokenDTO returns the device, the address and the activity of the session of the auth token
+func newUserTokenDTO(parser *uaparser.Parser, token *models.UserToken, isActive bool) *dtos.UserToken {
+	client := parser.Parse(token.UserAgent)
 
-			if client.Os.Minor != "" {
-				osVersion = osVersion + "." + client.Os.Minor
-			}
+	osVersion := ""
+	if client.Os.Major != "" {
+		osVersion = client.Os.Major
+
+		if client.Os.Minor != "" {
+			osVersion = osVersion + "." + client.Os.Minor
 		}
+	}
 
-		browserVersion := ""
-		if client.UserAgent.Major != "" {
-			browserVersion = client.UserAgent.Major
+	browserVersion := ""
+	if client.UserAgent.Major != "" {
+		browserVersion = client.UserAgent.Major
 
-			if client.UserAgent.Minor != "" {
-				browserVersion = browserVersion + "." + client.UserAgent.Minor
-			}
+		if client.UserAgent.Minor != "" {
+			browserVersion = browserVersion + "." + client.UserAgent.Minor
 		}
+	}
 
-		createdAt := time.Unix(token.CreatedAt, 0)
-		seenAt := time.Unix(token.SeenAt, 0)
+	createdAt := time.Unix(token.CreatedAt, 0)
+	seenAt := time.Unix(token.SeenAt, 0)
 
-		if token.SeenAt == 0 {
-			seenAt = createdAt
-		}
+	if token.SeenAt == 0 {
+		seenAt = createdAt
+	}
 
-		result = append(result, &dtos.UserToken{
-			Id:                     token.Id,
-			IsActive:               isActive,
-			ClientIp:               token.ClientIp,
-			Device:                 client.Device.ToString(),
-			OperatingSystem:        client.Os.Family,
-			OperatingSystemVersion: osVersion,
-			Browser:                client.UserAgent.Family,
-			BrowserVersion:         browserVersion,
-			CreatedAt:              createdAt,
-			SeenAt:                 seenAt,
-		})
+	// The tokens of the sessions in use are rotated, the last rotation is the last activity when it is more recent
+	lastActiveAt := seenAt
+	if rotatedAt := time.Unix(token.RotatedAt, 0); rotatedAt.After(lastActiveAt) {
+		lastActiveAt = rotatedAt
 	}
 
-	return response.JSON(200, result)
+	return &dtos.UserToken{
+		Id:                     token.Id,
+		IsActive:               isActive,
+		ClientIp:               token.ClientIp,
+		Device:                 client.Device.ToString(),
+		OperatingSystem:        client.Os.Family,
+		OperatingSystemVersion: osVersion,
+		Browser:                client.UserAgent.Family,
+		BrowserVersion:         browserVersion,
+		CreatedAt:              createdAt,
+		SeenAt:                 seenAt,
+		LastActiveAt:           lastActiveAt,
+	}
 }
 
 func (hs *HTTPServer) revokeUserAuthTokenInternal(c *models.ReqContext, userID int64, cmd models.RevokeAuthTokenCmd) response.Response {
@@ -150,3 +163,43 @@ func (hs *HTTPServer) revokeUserAuthTokenInternal(c *models.ReqContext, userID i
 		"message": "User auth token revoked",
 	})
 }
+
+// revokeUserAuthTokensInternal revokes the auth tokens of all the sessions of the user, except the current session,
+// and returns the revoked sessions
+func (hs *HTTPServer) revokeUserAuthTokensInternal(c *models.ReqContext, userID int64) response.Response {
+	userQuery := models.GetUserByIdQuery{Id: userID}
+
+	if err := bus.Dispatch(c.Req.Context(), &userQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, "User not found", err)
+		}
+		return response.Error(500, "Failed to get user", err)
+	}
+
+	tokens, err := hs.AuthTokenService.GetUserTokens(c.Req.Context(), userID)
+	if err != nil {
+		return response.Error(500, "Failed to get user auth tokens", err)
+	}
+
+	parser := uaparser.NewFromSaved()
+	revoked := []*dtos.UserToken{}
+	for _, token := range tokens {
+		if c.UserToken != nil && c.UserToken.Id == token.Id {
+			continue
+		}
+		if err := hs.AuthTokenService.RevokeToken(c.Req.Context(), token, false); err != nil {
+			if errors.Is(err, models.ErrUserTokenNotFound) {
+				// revoked in the meantime
+				continue
+			}
+			return response.Error(500, "Failed to revoke user auth tokens", err)
+		}
+		revoked = append(revoked, newUserTokenDTO(parser, token, false))
+	}
+
+	hs.log.Info("Revoked user auth tokens", "userId", userID, "revokedBy", c.UserId, "count", len(revoked))
+	return response.JSON(200, util.DynMap{
+		"message":       "User auth tokens revoked",
+		"revokedTokens": revoked,
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/stretchr/testify/assert"
@@ -167,6 +168,147 @@ func TestUserTokenAPIEndpoint(t *testing.T) {
 			assert.Equal(t, "11.0", resultTwo.Get("osVersion").MustString())
 		})
 	})
+
+	t.Run("When revoking all the auth tokens of a user", func(t *testing.T) {
+		currentToken := &models.UserToken{Id: 1}
+
+		revokeUserAuthTokensInternalScenario(t, "Should revoke all the tokens except the current one", currentToken, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, cmd *models.GetUserByIdQuery) error {
+				cmd.Result = &models.User{Id: testUserID}
+				return nil
+			})
+
+			now := time.Now().Unix()
+			sc.userAuthTokenService.GetUserTokensProvider = func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
+				return []*models.UserToken{
+					{Id: 1, ClientIp: "127.0.0.1", CreatedAt: now - 60},
+					{Id: 2, ClientIp: "127.0.0.2", CreatedAt: now - 60, SeenAt: now - 30, RotatedAt: now},
+					{Id: 3, ClientIp: "127.0.0.3", CreatedAt: now - 60},
+				}, nil
+			}
+			var revoked []int64
+			sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken, soft bool) error {
+				revoked = append(revoked, token.Id)
+				if token.Id == 3 {
+					return models.ErrUserTokenNotFound
+				}
+				return nil
+			}
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, []int64{2, 3}, revoked)
+			result := sc.ToJSON().Get("revokedTokens")
+			assert.Len(t, result.MustArray(), 1)
+			assert.Equal(t, int64(2), result.GetIndex(0).Get("id").MustInt64())
+			assert.Equal(t, "127.0.0.2", result.GetIndex(0).Get("clientIp").MustString())
+			assert.Equal(t, time.Unix(now, 0).Format(time.RFC3339), result.GetIndex(0).Get("lastActiveAt").MustString())
+		})
+	})
+
+	t.Run("When an organization admin revokes the sessions of a user", func(t *testing.T) {
+		revokeOrgUserSessionsScenario(t, "Should revoke the sessions of the users of the organization", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, cmd *models.GetUserByIdQuery) error {
+				cmd.Result = &models.User{Id: cmd.Id}
+				return nil
+			})
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_VIEWER}
+				return nil
+			})
+
+			revoked := 0
+			sc.userAuthTokenService.GetUserTokensProvider = func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
+				return []*models.UserToken{{Id: 10, UserId: userId}}, nil
+			}
+			sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken, soft bool) error {
+				revoked++
+				return nil
+			}
+			sc.fakeReqWithParams("DELETE", "/api/org/users/2/sessions", map[string]string{}).exec()
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, 1, revoked)
+		})
+
+		revokeOrgUserSessionsScenario(t, "Should not find the users of other organizations", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId}
+				return nil
+			})
+
+			sc.fakeReqWithParams("DELETE", "/api/org/users/2/sessions", map[string]string{}).exec()
+			assert.Equal(t, 404, sc.resp.Code)
+		})
+
+		revokeOrgUserSessionsScenario(t, "Should not revoke the sessions of Grafana admins", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_ADMIN, IsGrafanaAdmin: true}
+				return nil
+			})
+
+			sc.fakeReqWithParams("DELETE", "/api/org/users/2/sessions", map[string]string{}).exec()
+			assert.Equal(t, 403, sc.resp.Code)
+		})
+	})
+}
+
+func revokeUserAuthTokensInternalScenario(t *testing.T, desc string, token *models.UserToken, fn scenarioFunc) {
+	t.Run(desc, func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := HTTPServer{
+			Bus:              bus.GetBus(),
+			AuthTokenService: fakeAuthTokenService,
+			log:              log.New("test"),
+		}
+
+		sc := setupScenarioContext(t, "/")
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.UserId = testUserID
+			sc.context.OrgId = testOrgID
+			sc.context.OrgRole = models.ROLE_ADMIN
+			sc.context.UserToken = token
+
+			return hs.revokeUserAuthTokensInternal(c, testUserID)
+		})
+
+		sc.m.Delete("/", sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
+func revokeOrgUserSessionsScenario(t *testing.T, desc string, fn scenarioFunc) {
+	t.Run(desc, func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := HTTPServer{
+			Bus:              bus.GetBus(),
+			AuthTokenService: fakeAuthTokenService,
+			log:              log.New("test"),
+		}
+
+		sc := setupScenarioContext(t, "/api/org/users/:userId/sessions")
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.UserId = testUserID
+			sc.context.OrgId = testOrgID
+			sc.context.OrgRole = models.ROLE_ADMIN
+
+			return hs.RevokeOrgUserSessions(c)
+		})
+
+		sc.m.Delete("/api/org/users/:userId/sessions", sc.defaultHandler)
+
+		fn(sc)
+	})
 }
 
 func revokeUserAuthTokenScenario(t *testing.T, desc string, url string, routePattern string, cmd models.RevokeAuthTokenCmd,
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/user/2fa
+func (hs *HTTPServer) GetUserTwoFactor(c *models.ReqContext) response.Response {
+	if resp := requireTwoFactorUser(c); resp != nil {
+		return resp
+	}
+	status, err := hs.twoFactorService.GetStatus(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication", err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// POST /api/user/2fa/totp
+//
+// Starts the TOTP enrollment of the signed in user, confirmed with POST /api/user/2fa/totp/confirm.
+func (hs *HTTPServer) EnrollUserTOTP(c *models.ReqContext) response.Response {
+	if resp := requireTwoFactorUser(c); resp != nil {
+		return resp
+	}
+	enrollment, err := hs.twoFactorService.Enroll(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return twoFactorErrorResponse(err, "Failed to enroll two-factor authentication")
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+// POST /api/user/2fa/totp/confirm
+func (hs *HTTPServer) ConfirmUserTOTP(c *models.ReqContext) response.Response {
+	if resp := requireTwoFactorUser(c); resp != nil {
+		return resp
+	}
+	cmd := twofactor.CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	recoveryCodes, err := hs.twoFactorService.Confirm(c.Req.Context(), c.UserId, cmd.Code)
+	if err != nil {
+		return twoFactorErrorResponse(err, "Failed to confirm two-factor authentication")
+	}
+	return response.JSON(http.StatusOK, dtos.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// POST /api/user/2fa/totp/disable
+func (hs *HTTPServer) DisableUserTOTP(c *models.ReqContext) response.Response {
+	if resp := requireTwoFactorUser(c); resp != nil {
+		return resp
+	}
+	cmd := dtos.DisableTwoFactorCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.twoFactorService.Disable(c.Req.Context(), c.SignedInUser, cmd.Code, cmd.RecoveryCode); err != nil {
+		return twoFactorErrorResponse(err, "Failed to disable two-factor authentication")
+	}
+	return response.Success("Two-factor authentication disabled")
+}
+
+// POST /api/user/2fa/recovery-codes
+//
+// Replaces the recovery codes of the signed in user.
+func (hs *HTTPServer) RegenerateUserRecoveryCodes(c *models.ReqContext) response.Response {
+	if resp := requireTwoFactorUser(c); resp != nil {
+		return resp
+	}
+	cmd := twofactor.CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	recoveryCodes, err := hs.twoFactorService.RegenerateRecoveryCodes(c.Req.Context(), c.UserId, cmd.Code)
+	if err != nil {
+		return twoFactorErrorResponse(err, "Failed to regenerate the recovery codes")
+	}
+	return response.JSON(http.StatusOK, dtos.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// requireTwoFactorUser rejects the requests of API keys and service accounts, which do not log in
+func requireTwoFactorUser(c *models.ReqContext) response.Response {
+	if c.UserId == 0 || c.ApiKeyId != 0 {
+		return response.Error(http.StatusBadRequest, twofactor.ErrNotSupportedForUser.Error(), nil)
+	}
+	return nil
+}
+
+func twoFactorErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, twofactor.ErrTOTPNotEnrolled):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, twofactor.ErrTOTPAlreadyEnabled):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, twofactor.ErrTOTPRequired), errors.Is(err, twofactor.ErrTOTPDisabled):
+		return response.Error(http.StatusForbidden, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
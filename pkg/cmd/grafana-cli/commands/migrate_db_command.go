@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// migrateDBCommand copies the SQLite database of the configuration to an empty Postgres or MySQL database
+func migrateDBCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	from := c.String("from")
+	if from == "sqlite" {
+		from = migrator.SQLite
+	}
+	if from != migrator.SQLite {
+		return fmt.Errorf("only SQLite databases can be migrated, --from %q is not supported", c.String("from"))
+	}
+	if sourceType := sqlStore.Dialect.DriverName(); sourceType != from {
+		return fmt.Errorf("the configured database is %s, not %s", sourceType, from)
+	}
+
+	to := c.String("to")
+	if to != migrator.Postgres && to != migrator.MySQL {
+		return fmt.Errorf("the database can be migrated to postgres or mysql, --to %q is not supported", to)
+	}
+	targetURL := c.String("to-url")
+	if targetURL == "" {
+		return fmt.Errorf("the URL of the target database is required, for instance --to-url %s://user:secret@host:port/database", to)
+	}
+	parsed, err := url.Parse(targetURL)
+	if err != nil {
+		return errutil.Wrap("invalid URL of the target database", err)
+	}
+	if parsed.Scheme != to {
+		return fmt.Errorf("the URL of the target database is a %s URL, not %s", parsed.Scheme, to)
+	}
+
+	dbCopy, err := sqlstore.NewDatabaseCopy(sqlStore, targetURL)
+	if err != nil {
+		return errutil.Wrap("failed to prepare the target database", err)
+	}
+
+	logger.Infof("Copying the %s database to %s\n", from, to)
+	tables, err := dbCopy.Run(context.Background(), func(table sqlstore.TableCopy) {
+		logger.Infof("%-40s %10d rows  checksum %s %s\n", table.Name, table.Rows, table.Checksum, color.GreenString("✔"))
+	})
+	if err != nil {
+		return errutil.Wrap("failed to copy the database", err)
+	}
+
+	var rows int64
+	for _, table := range tables {
+		rows += table.Rows
+	}
+	logger.Infof("\n")
+	logger.Infof("Copied %d rows of %d tables, update the [database] section of the configuration to use the %s database %s",
+		rows, len(tables), to, color.GreenString("✔"))
+	return nil
+}
@@ -0,0 +1,389 @@
+// Package dashevents computes the fine-grained changes between two versions of a dashboard JSON model, and reverts
+// them one at a time.
+package dashevents
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	// ErrConflict occurs when undoing an event whose change has been modified since.
+	ErrConflict = errors.New("dashevents: dashboard has changed since the event")
+	// ErrNotUndoable occurs when undoing an event that cannot be reverted.
+	ErrNotUndoable = errors.New("dashevents: event cannot be undone")
+)
+
+// ignoredProperties are the dashboard properties not recorded as property changes, either because they are set
+// on save or because their changes are recorded as panel and variable events
+var ignoredProperties = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"panels":     true,
+	"templating": true,
+}
+
+// nestedPaths are the properties whose own properties are compared one by one, for the panel field configs and
+// options
+var nestedPaths = map[string]bool{
+	"fieldConfig":          true,
+	"fieldConfig.defaults": true,
+	"options":              true,
+}
+
+// Compute returns the events changing the base dashboard into the next one. Only the type, the panel or variable,
+// the path and the values of the events are set.
+func Compute(base, next *simplejson.Json) []*models.DashboardEvent {
+	events := make([]*models.DashboardEvent, 0)
+
+	for _, c := range diffObjects("", base.MustMap(), next.MustMap(), func(path string) bool {
+		return ignoredProperties[path]
+	}) {
+		events = append(events, &models.DashboardEvent{
+			Type:     models.DashboardEventPropertyChanged,
+			Path:     c.path,
+			OldValue: c.oldValue,
+			NewValue: c.newValue,
+		})
+	}
+
+	events = append(events, diffPanels(base, next)...)
+	events = append(events, diffVariables(base, next)...)
+	return events
+}
+
+func diffPanels(base, next *simplejson.Json) []*models.DashboardEvent {
+	events := make([]*models.DashboardEvent, 0)
+	basePanels := panelsByID(base)
+	nextPanels := panelsByID(next)
+
+	for _, panel := range panels(next) {
+		id := panelID(panel)
+		old, ok := basePanels[id]
+		if !ok {
+			events = append(events, &models.DashboardEvent{
+				Type:     models.DashboardEventPanelAdded,
+				PanelId:  id,
+				NewValue: simplejson.NewFromAny(panel),
+			})
+			continue
+		}
+
+		for _, c := range diffObjects("", old, panel, func(path string) bool {
+			return path == "id"
+		}) {
+			events = append(events, &models.DashboardEvent{
+				Type:     panelEventType(c.path),
+				PanelId:  id,
+				Path:     c.path,
+				OldValue: c.oldValue,
+				NewValue: c.newValue,
+			})
+		}
+	}
+
+	for _, panel := range panels(base) {
+		id := panelID(panel)
+		if _, ok := nextPanels[id]; !ok {
+			events = append(events, &models.DashboardEvent{
+				Type:     models.DashboardEventPanelRemoved,
+				PanelId:  id,
+				OldValue: simplejson.NewFromAny(panel),
+			})
+		}
+	}
+	return events
+}
+
+func diffVariables(base, next *simplejson.Json) []*models.DashboardEvent {
+	events := make([]*models.DashboardEvent, 0)
+	baseVariables := variablesByName(base)
+	nextVariables := variablesByName(next)
+
+	for _, variable := range variables(next) {
+		name := variableName(variable)
+		old, ok := baseVariables[name]
+		if !ok {
+			events = append(events, &models.DashboardEvent{
+				Type:     models.DashboardEventVariableAdded,
+				Variable: name,
+				NewValue: simplejson.NewFromAny(variable),
+			})
+			continue
+		}
+
+		for _, c := range diffObjects("", old, variable, func(path string) bool {
+			return path == "name"
+		}) {
+			events = append(events, &models.DashboardEvent{
+				Type:     models.DashboardEventVariableChanged,
+				Variable: name,
+				Path:     c.path,
+				OldValue: c.oldValue,
+				NewValue: c.newValue,
+			})
+		}
+	}
+
+	for _, variable := range variables(base) {
+		name := variableName(variable)
+		if _, ok := nextVariables[name]; !ok {
+			events = append(events, &models.DashboardEvent{
+				Type:     models.DashboardEventVariableRemoved,
+				Variable: name,
+				OldValue: simplejson.NewFromAny(variable),
+			})
+		}
+	}
+	return events
+}
+
+func panelEventType(path string) string {
+	switch {
+	case path == "gridPos":
+		return models.DashboardEventPanelMoved
+	case path == "targets":
+		return models.DashboardEventPanelQueriesChanged
+	case path == "thresholds" || path == "fieldConfig.defaults.thresholds":
+		return models.DashboardEventPanelThresholdsChanged
+	}
+	return models.DashboardEventPanelChanged
+}
+
+type change struct {
+	path     string
+	oldValue *simplejson.Json
+	newValue *simplejson.Json
+}
+
+// diffObjects returns the changed properties of two objects, sorted by path. The nested paths are compared property
+// by property when both sides are objects.
+func diffObjects(prefix string, base, next map[string]interface{}, ignored func(path string) bool) []change {
+	keys := make(map[string]struct{}, len(base)+len(next))
+	for k := range base {
+		keys[k] = struct{}{}
+	}
+	for k := range next {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]change, 0)
+	for _, k := range sorted {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if ignored(path) {
+			continue
+		}
+
+		oldValue, newValue := base[k], next[k]
+		if equal(oldValue, newValue) {
+			continue
+		}
+		if nestedPaths[path] {
+			oldObject, oldOk := oldValue.(map[string]interface{})
+			newObject, newOk := newValue.(map[string]interface{})
+			if oldOk && newOk {
+				changes = append(changes, diffObjects(path, oldObject, newObject, ignored)...)
+				continue
+			}
+		}
+		changes = append(changes, change{path: path, oldValue: wrap(oldValue), newValue: wrap(newValue)})
+	}
+	return changes
+}
+
+// Undo reverts the change of the event in the dashboard data. ErrConflict is returned when what the event changed
+// doesn't match the data anymore.
+func Undo(data *simplejson.Json, event *models.DashboardEvent) error {
+	switch event.Type {
+	case models.DashboardEventPropertyChanged:
+		return revert(data.MustMap(), event)
+
+	case models.DashboardEventPanelAdded:
+		array := data.Get("panels").MustArray()
+		i := panelIndex(array, event.PanelId)
+		if i < 0 || !equal(array[i], unwrap(event.NewValue)) {
+			return ErrConflict
+		}
+		data.Set("panels", removeAt(array, i))
+		return nil
+
+	case models.DashboardEventPanelRemoved:
+		array := data.Get("panels").MustArray()
+		if panelIndex(array, event.PanelId) >= 0 {
+			return ErrConflict
+		}
+		data.Set("panels", append(array, unwrap(event.OldValue)))
+		return nil
+
+	case models.DashboardEventPanelMoved, models.DashboardEventPanelQueriesChanged,
+		models.DashboardEventPanelThresholdsChanged, models.DashboardEventPanelChanged:
+		array := data.Get("panels").MustArray()
+		i := panelIndex(array, event.PanelId)
+		if i < 0 {
+			return ErrConflict
+		}
+		return revert(array[i].(map[string]interface{}), event)
+
+	case models.DashboardEventVariableAdded:
+		array := data.GetPath("templating", "list").MustArray()
+		i := variableIndex(array, event.Variable)
+		if i < 0 || !equal(array[i], unwrap(event.NewValue)) {
+			return ErrConflict
+		}
+		data.SetPath([]string{"templating", "list"}, removeAt(array, i))
+		return nil
+
+	case models.DashboardEventVariableRemoved:
+		array := data.GetPath("templating", "list").MustArray()
+		if variableIndex(array, event.Variable) >= 0 {
+			return ErrConflict
+		}
+		data.SetPath([]string{"templating", "list"}, append(array, unwrap(event.OldValue)))
+		return nil
+
+	case models.DashboardEventVariableChanged:
+		array := data.GetPath("templating", "list").MustArray()
+		i := variableIndex(array, event.Variable)
+		if i < 0 {
+			return ErrConflict
+		}
+		return revert(array[i].(map[string]interface{}), event)
+	}
+
+	return ErrNotUndoable
+}
+
+// revert sets the property at the path of the event back to its old value, creating the missing parent objects
+func revert(object map[string]interface{}, event *models.DashboardEvent) error {
+	if object == nil || event.Path == "" {
+		return ErrConflict
+	}
+
+	keys := strings.Split(event.Path, ".")
+	for _, k := range keys[:len(keys)-1] {
+		child, ok := object[k].(map[string]interface{})
+		if !ok {
+			if _, exists := object[k]; exists {
+				return ErrConflict
+			}
+			child = make(map[string]interface{})
+			object[k] = child
+		}
+		object = child
+	}
+
+	last := keys[len(keys)-1]
+	if !equal(object[last], unwrap(event.NewValue)) {
+		return ErrConflict
+	}
+	if old := unwrap(event.OldValue); old != nil {
+		object[last] = old
+	} else {
+		delete(object, last)
+	}
+	return nil
+}
+
+func panels(data *simplejson.Json) []map[string]interface{} {
+	return objects(data.Get("panels").MustArray())
+}
+
+func panelsByID(data *simplejson.Json) map[int64]map[string]interface{} {
+	result := make(map[int64]map[string]interface{})
+	for _, p := range panels(data) {
+		result[panelID(p)] = p
+	}
+	return result
+}
+
+func panelID(panel map[string]interface{}) int64 {
+	return simplejson.NewFromAny(panel).Get("id").MustInt64()
+}
+
+// panelIndex returns the index of the panel in the panels array, or -1 when missing
+func panelIndex(array []interface{}, id int64) int {
+	for i, v := range array {
+		if p, ok := v.(map[string]interface{}); ok && panelID(p) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func variables(data *simplejson.Json) []map[string]interface{} {
+	return objects(data.GetPath("templating", "list").MustArray())
+}
+
+func variablesByName(data *simplejson.Json) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	for _, v := range variables(data) {
+		result[variableName(v)] = v
+	}
+	return result
+}
+
+func variableName(variable map[string]interface{}) string {
+	name, _ := variable["name"].(string)
+	return name
+}
+
+// variableIndex returns the index of the variable in the templating list, or -1 when missing
+func variableIndex(array []interface{}, name string) int {
+	for i, v := range array {
+		if variable, ok := v.(map[string]interface{}); ok && variableName(variable) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// objects returns the objects of the array, skipping the other values
+func objects(array []interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(array))
+	for _, v := range array {
+		if o, ok := v.(map[string]interface{}); ok {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+func removeAt(array []interface{}, i int) []interface{} {
+	result := make([]interface{}, 0, len(array))
+	result = append(result, array[:i]...)
+	return append(result, array[i+1:]...)
+}
+
+func wrap(v interface{}) *simplejson.Json {
+	if v == nil {
+		return nil
+	}
+	return simplejson.NewFromAny(v)
+}
+
+func unwrap(j *simplejson.Json) interface{} {
+	if j == nil {
+		return nil
+	}
+	return j.Interface()
+}
+
+// equal compares the JSON encodings of the values, numbers decoded as json.Number and as float64 being equal
+func equal(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,140 @@
+package dashevents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const baseDashboard = `{
+	"id": 1,
+	"title": "Base",
+	"version": 1,
+	"panels": [
+		{
+			"id": 1,
+			"title": "CPU",
+			"gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+			"targets": [{"refId": "A", "expr": "cpu"}],
+			"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"value": null, "color": "green"}]}}}
+		},
+		{"id": 2, "title": "Memory"}
+	],
+	"templating": {"list": [{"name": "host", "query": "hosts"}]}
+}`
+
+const nextDashboard = `{
+	"id": 1,
+	"title": "Next",
+	"version": 2,
+	"panels": [
+		{
+			"id": 1,
+			"title": "CPU",
+			"gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+			"targets": [{"refId": "A", "expr": "cpu"}],
+			"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"value": null, "color": "green"}, {"value": 80, "color": "red"}]}}}
+		},
+		{"id": 3, "title": "Disk"}
+	],
+	"templating": {"list": [{"name": "host", "query": "hosts"}, {"name": "env", "query": "envs"}]}
+}`
+
+func parse(t *testing.T, s string) *simplejson.Json {
+	t.Helper()
+	data, err := simplejson.NewJson([]byte(s))
+	require.NoError(t, err)
+	return data
+}
+
+func TestCompute(t *testing.T) {
+	events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+
+	type summary struct {
+		Type     string
+		PanelId  int64
+		Variable string
+		Path     string
+	}
+	summaries := make([]summary, 0, len(events))
+	for _, e := range events {
+		summaries = append(summaries, summary{e.Type, e.PanelId, e.Variable, e.Path})
+	}
+
+	assert.Equal(t, []summary{
+		{models.DashboardEventPropertyChanged, 0, "", "title"},
+		{models.DashboardEventPanelThresholdsChanged, 1, "", "fieldConfig.defaults.thresholds"},
+		{models.DashboardEventPanelMoved, 1, "", "gridPos"},
+		{models.DashboardEventPanelAdded, 3, "", ""},
+		{models.DashboardEventPanelRemoved, 2, "", ""},
+		{models.DashboardEventVariableAdded, 0, "env", ""},
+	}, summaries)
+
+	assert.Equal(t, "Base", events[0].OldValue.MustString())
+	assert.Equal(t, "Next", events[0].NewValue.MustString())
+	assert.Len(t, events[1].NewValue.Get("steps").MustArray(), 2)
+
+	assert.Empty(t, Compute(parse(t, baseDashboard), parse(t, baseDashboard)))
+}
+
+func TestUndo(t *testing.T) {
+	undone := func(t *testing.T, event *models.DashboardEvent) *simplejson.Json {
+		t.Helper()
+		data := parse(t, nextDashboard)
+		require.NoError(t, Undo(data, event))
+		return data
+	}
+
+	t.Run("should revert each event", func(t *testing.T) {
+		base := parse(t, baseDashboard)
+		for _, event := range Compute(base, parse(t, nextDashboard)) {
+			data := undone(t, event)
+			assert.Len(t, Compute(data, parse(t, nextDashboard)), 1, event.Type)
+		}
+	})
+
+	t.Run("should revert a property", func(t *testing.T) {
+		events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+		data := undone(t, events[0])
+		assert.Equal(t, "Base", data.Get("title").MustString())
+	})
+
+	t.Run("should revert a panel change", func(t *testing.T) {
+		events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+		data := undone(t, events[2])
+		assert.Equal(t, int64(0), data.Get("panels").GetIndex(0).GetPath("gridPos", "x").MustInt64())
+		assert.Len(t, data.Get("panels").GetIndex(0).GetPath("fieldConfig", "defaults", "thresholds", "steps").MustArray(), 2)
+	})
+
+	t.Run("should restore a removed panel", func(t *testing.T) {
+		events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+		data := undone(t, events[4])
+		require.Len(t, data.Get("panels").MustArray(), 3)
+		assert.Equal(t, "Memory", data.Get("panels").GetIndex(2).Get("title").MustString())
+	})
+
+	t.Run("should remove an added variable", func(t *testing.T) {
+		events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+		data := undone(t, events[5])
+		require.Len(t, data.GetPath("templating", "list").MustArray(), 1)
+	})
+
+	t.Run("should fail when the dashboard has changed since", func(t *testing.T) {
+		events := Compute(parse(t, baseDashboard), parse(t, nextDashboard))
+		data := parse(t, nextDashboard)
+		data.Set("title", "Changed")
+		assert.ErrorIs(t, Undo(data, events[0]), ErrConflict)
+
+		data.Set("panels", []interface{}{})
+		assert.ErrorIs(t, Undo(data, events[2]), ErrConflict)
+	})
+
+	t.Run("should not undo the creation of the dashboard", func(t *testing.T) {
+		err := Undo(parse(t, nextDashboard), &models.DashboardEvent{Type: models.DashboardEventCreated})
+		assert.ErrorIs(t, err, ErrNotUndoable)
+	})
+}
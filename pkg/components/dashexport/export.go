@@ -0,0 +1,375 @@
+// Package dashexport makes a dashboard JSON model portable between Grafana instances, as the "export for sharing" of
+// the frontend does: the data sources become inputs chosen on import, the constants become inputs and the plugins
+// the dashboard requires are listed.
+package dashexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	ErrDataSourceNotFound      = errors.New("data source not found")
+	ErrInvalidLibraryPanelMode = errors.New("invalid library panels mode")
+)
+
+// LibraryPanelMode is how the library panels are exported
+type LibraryPanelMode string
+
+const (
+	// LibraryPanelsReference keeps the references to the library panels in the dashboard and exports their models
+	// in the __elements, as the frontend does
+	LibraryPanelsReference LibraryPanelMode = "reference"
+	// LibraryPanelsInline replaces the library panels with their models, the dashboard does not depend on them
+	// anymore
+	LibraryPanelsInline LibraryPanelMode = "inline"
+)
+
+// libraryPanelElementKind is the kind of the library panel elements
+const libraryPanelElementKind = 1
+
+// builtInDataSources are the data sources of Grafana itself, referenced by name or uid, they exist in all instances
+var builtInDataSources = map[string]bool{
+	"-- Grafana --":   true,
+	"grafana":         true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+// DataSource is a data source the dashboard uses, with its plugin
+type DataSource struct {
+	Name          string
+	Type          string
+	PluginName    string
+	PluginVersion string
+}
+
+// Plugin is a panel plugin the dashboard uses
+type Plugin struct {
+	ID      string
+	Name    string
+	Version string
+}
+
+// Resolver looks up the data sources and the plugins the dashboard uses
+type Resolver interface {
+	// DataSource returns the data source with the uid or the name, the default data source when the reference is
+	// empty, or nil when it does not exist
+	DataSource(ref string) (*DataSource, error)
+	// Panel returns the panel plugin of a type, or nil when it is not installed
+	Panel(panelType string) *Plugin
+}
+
+// Options of the export
+type Options struct {
+	// GrafanaVersion is the version of Grafana required by the dashboard
+	GrafanaVersion string
+	// LibraryPanels is how the library panels are exported, as references when empty. The dashboard is expected to
+	// have its library panels loaded.
+	LibraryPanels LibraryPanelMode
+}
+
+// Input is an input of the exported dashboard, a value chosen on import
+type Input struct {
+	Name        string      `json:"name"`
+	Label       string      `json:"label"`
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	PluginID    string      `json:"pluginId,omitempty"`
+	PluginName  string      `json:"pluginName,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+}
+
+// Require is a plugin, or Grafana itself, the exported dashboard requires
+type Require struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// LibraryPanelElement is a library panel used by the exported dashboard
+type LibraryPanelElement struct {
+	Name  string                 `json:"name"`
+	UID   string                 `json:"uid"`
+	Kind  int                    `json:"kind"`
+	Model map[string]interface{} `json:"model"`
+}
+
+type exporter struct {
+	resolver  Resolver
+	variables map[string]map[string]interface{}
+	inputs    []Input
+	inputSeen map[string]bool
+	requires  map[string]Require
+}
+
+// Export returns the portable model of the dashboard, the dashboard is left unchanged
+func Export(dash *simplejson.Json, resolver Resolver, opts Options) (*simplejson.Json, error) {
+	if opts.LibraryPanels == "" {
+		opts.LibraryPanels = LibraryPanelsReference
+	}
+	if opts.LibraryPanels != LibraryPanelsReference && opts.LibraryPanels != LibraryPanelsInline {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLibraryPanelMode, opts.LibraryPanels)
+	}
+
+	raw, err := dash.Encode()
+	if err != nil {
+		return nil, err
+	}
+	model := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	// The numbers are kept as they are, such as the IDs
+	dec.UseNumber()
+	if err := dec.Decode(&model); err != nil {
+		return nil, err
+	}
+	model["id"] = nil
+
+	e := &exporter{
+		resolver:  resolver,
+		variables: make(map[string]map[string]interface{}),
+		inputSeen: make(map[string]bool),
+		requires:  make(map[string]Require),
+	}
+	variables := objects(nested(model, "templating")["list"])
+	for _, v := range variables {
+		if name, ok := v["name"].(string); ok {
+			e.variables[name] = v
+		}
+	}
+
+	panels := e.panels(model)
+	for _, panel := range panels {
+		if err := e.processPanel(panel); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range variables {
+		if v["type"] != "query" {
+			continue
+		}
+		if err := e.templateDataSource(v); err != nil {
+			return nil, err
+		}
+		v["options"] = []interface{}{}
+		v["current"] = map[string]interface{}{}
+		// Variables which are never refreshed would have no options once imported
+		if refresh, ok := v["refresh"].(json.Number); ok && refresh.String() == "0" {
+			v["refresh"] = 1
+		}
+	}
+
+	for _, annotation := range objects(nested(model, "annotations")["list"]) {
+		if err := e.templateDataSource(annotation); err != nil {
+			return nil, err
+		}
+	}
+
+	e.requires["grafana"] = Require{Type: "grafana", ID: "grafana", Name: "Grafana", Version: opts.GrafanaVersion}
+
+	elements := make([]LibraryPanelElement, 0)
+	elementSeen := make(map[string]bool)
+	for _, panel := range panels {
+		libraryPanel, ok := panel["libraryPanel"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delete(panel, "libraryPanel")
+		if opts.LibraryPanels == LibraryPanelsInline {
+			continue
+		}
+
+		uid, _ := libraryPanel["uid"].(string)
+		name, _ := libraryPanel["name"].(string)
+		if !elementSeen[uid] {
+			elementSeen[uid] = true
+			model := make(map[string]interface{}, len(panel))
+			for k, v := range panel {
+				model[k] = v
+			}
+			elements = append(elements, LibraryPanelElement{Name: name, UID: uid, Kind: libraryPanelElementKind, Model: model})
+		}
+		// The panel is only a reference to the element
+		for k := range panel {
+			if k != "id" && k != "gridPos" {
+				delete(panel, k)
+			}
+		}
+		panel["libraryPanel"] = map[string]interface{}{"uid": uid, "name": name}
+	}
+
+	for _, v := range variables {
+		if v["type"] != "constant" {
+			continue
+		}
+		name, _ := v["name"].(string)
+		label, _ := v["label"].(string)
+		if label == "" {
+			label = name
+		}
+		refName := "VAR_" + inputName(name)
+		e.inputs = append(e.inputs, Input{Name: refName, Type: "constant", Label: label, Value: v["query"]})
+
+		ref := "${" + refName + "}"
+		v["query"] = ref
+		current := map[string]interface{}{"value": ref, "text": ref, "selected": false}
+		v["current"] = current
+		v["options"] = []interface{}{current}
+	}
+
+	requires := make([]Require, 0, len(e.requires))
+	for _, r := range e.requires {
+		requires = append(requires, r)
+	}
+	sort.Slice(requires, func(i, j int) bool {
+		if requires[i].ID != requires[j].ID {
+			return requires[i].ID < requires[j].ID
+		}
+		return requires[i].Type < requires[j].Type
+	})
+
+	inputs := e.inputs
+	if inputs == nil {
+		inputs = []Input{}
+	}
+	model["__inputs"] = inputs
+	model["__elements"] = elements
+	model["__requires"] = requires
+
+	raw, err = json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	return simplejson.NewJson(raw)
+}
+
+// panels returns the panels of the dashboard, with the panels of the collapsed rows
+func (e *exporter) panels(model map[string]interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0)
+	for _, panel := range objects(model["panels"]) {
+		result = append(result, panel)
+		if collapsed, _ := panel["collapsed"].(bool); collapsed {
+			result = append(result, objects(panel["panels"])...)
+		}
+	}
+	return result
+}
+
+func (e *exporter) processPanel(panel map[string]interface{}) error {
+	if panel["datasource"] != nil {
+		if err := e.templateDataSource(panel); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range objects(panel["targets"]) {
+		if _, ok := target["datasource"]; !ok {
+			continue
+		}
+		if err := e.templateDataSource(target); err != nil {
+			return err
+		}
+	}
+
+	panelType, _ := panel["type"].(string)
+	if plugin := e.resolver.Panel(panelType); plugin != nil {
+		e.requires["panel"+plugin.ID] = Require{Type: "panel", ID: plugin.ID, Name: plugin.Name, Version: plugin.Version}
+	}
+	return nil
+}
+
+// templateDataSource replaces the data source of the object, referenced by name or by uid, with an input. The data
+// sources set by a variable are left as they are, the variable is exported instead.
+func (e *exporter) templateDataSource(obj map[string]interface{}) error {
+	var ref string
+	switch ds := obj["datasource"].(type) {
+	case string:
+		ref = ds
+	case map[string]interface{}:
+		ref, _ = ds["uid"].(string)
+	}
+
+	viaVariable := false
+	if strings.HasPrefix(ref, "$") {
+		viaVariable = true
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(ref, "$"), "{"), "}")
+		variable, ok := e.variables[name]
+		if !ok {
+			return nil
+		}
+		ref, _ = nested(variable, "current")["value"].(string)
+	}
+	if builtInDataSources[ref] {
+		return nil
+	}
+
+	ds, err := e.resolver.DataSource(ref)
+	if err != nil {
+		return err
+	}
+	if ds == nil {
+		// Without a default data source, the objects using it are left as they are
+		if viaVariable || ref == "" {
+			return nil
+		}
+		return fmt.Errorf("%w: %q", ErrDataSourceNotFound, ref)
+	}
+
+	version := ds.PluginVersion
+	if version == "" {
+		version = "1.0.0"
+	}
+	e.requires["datasource"+ds.Type] = Require{Type: "datasource", ID: ds.Type, Name: ds.PluginName, Version: version}
+	if viaVariable {
+		return nil
+	}
+
+	refName := "DS_" + inputName(ds.Name)
+	if !e.inputSeen[refName] {
+		e.inputSeen[refName] = true
+		e.inputs = append(e.inputs, Input{
+			Name:       refName,
+			Label:      ds.Name,
+			Type:       "datasource",
+			PluginID:   ds.Type,
+			PluginName: ds.PluginName,
+		})
+	}
+
+	if m, ok := obj["datasource"].(map[string]interface{}); ok {
+		m["uid"] = "${" + refName + "}"
+	} else {
+		obj["datasource"] = "${" + refName + "}"
+	}
+	return nil
+}
+
+// inputName returns the name of the input of a data source or a constant. As in the frontend, only the first space
+// is replaced so that the inputs of the dashboards exported by both match.
+func inputName(name string) string {
+	return strings.ToUpper(strings.Replace(name, " ", "_", 1))
+}
+
+func nested(obj map[string]interface{}, key string) map[string]interface{} {
+	m, _ := obj[key].(map[string]interface{})
+	return m
+}
+
+func objects(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
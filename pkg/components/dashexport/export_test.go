@@ -0,0 +1,162 @@
+package dashexport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const dashboard = `{
+	"id": 12,
+	"uid": "hosts",
+	"title": "Hosts",
+	"annotations": {"list": [
+		{"name": "Annotations & Alerts", "builtIn": 1, "datasource": "-- Grafana --"},
+		{"name": "Deployments", "datasource": {"type": "loki", "uid": "loki-uid"}}
+	]},
+	"templating": {"list": [
+		{"name": "ds", "type": "datasource", "query": "prometheus", "current": {"text": "Prod Metrics", "value": "Prod Metrics"}},
+		{"name": "host", "type": "query", "datasource": "Prod Metrics", "query": "label_values(host)", "refresh": 0,
+			"current": {"text": "a", "value": "a"}, "options": [{"text": "a", "value": "a"}]},
+		{"name": "cluster", "type": "query", "datasource": {"uid": "$ds"}, "query": "label_values(cluster)", "refresh": 2},
+		{"name": "env", "type": "constant", "label": "Environment", "query": "production"}
+	]},
+	"panels": [
+		{"id": 1, "type": "timeseries", "datasource": {"type": "prometheus", "uid": "prom-uid"},
+			"targets": [{"expr": "up", "datasource": {"type": "prometheus", "uid": "prom-uid"}}]},
+		{"id": 2, "type": "stat", "datasource": "${ds}"},
+		{"id": 3, "type": "row", "collapsed": true, "panels": [
+			{"id": 4, "type": "timeseries", "gridPos": {"x": 0, "y": 1}, "datasource": "Prod Metrics",
+				"libraryPanel": {"uid": "lib-uid", "name": "Uptime", "meta": {"folderName": "General"}}}
+		]}
+	]
+}`
+
+type fakeResolver struct{}
+
+func (fakeResolver) DataSource(ref string) (*DataSource, error) {
+	switch ref {
+	case "prom-uid", "Prod Metrics":
+		return &DataSource{Name: "Prod Metrics", Type: "prometheus", PluginName: "Prometheus", PluginVersion: "1.0.0"}, nil
+	case "loki-uid":
+		return &DataSource{Name: "Logs", Type: "loki", PluginName: "Loki"}, nil
+	}
+	return nil, nil
+}
+
+func (fakeResolver) Panel(panelType string) *Plugin {
+	if panelType == "timeseries" {
+		return &Plugin{ID: "timeseries", Name: "Time series", Version: ""}
+	}
+	return nil
+}
+
+func export(t *testing.T, dash string, opts Options) *simplejson.Json {
+	t.Helper()
+	data, err := simplejson.NewJson([]byte(dash))
+	require.NoError(t, err)
+	exported, err := Export(data, fakeResolver{}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), data.Get("id").MustInt64(), "the dashboard is left unchanged")
+	return exported
+}
+
+func TestExport(t *testing.T) {
+	exported := export(t, dashboard, Options{GrafanaVersion: "8.4.0"})
+
+	t.Run("should replace the data sources with inputs", func(t *testing.T) {
+		assert.Nil(t, exported.Get("id").Interface())
+		assert.Equal(t, "hosts", exported.Get("uid").MustString())
+
+		panels := exported.Get("panels")
+		assert.Equal(t, "${DS_PROD_METRICS}", panels.GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "prometheus", panels.GetIndex(0).GetPath("datasource", "type").MustString())
+		assert.Equal(t, "${DS_PROD_METRICS}", panels.GetIndex(0).Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "${ds}", panels.GetIndex(1).Get("datasource").MustString(), "variables are left as they are")
+
+		annotations := exported.GetPath("annotations", "list")
+		assert.Equal(t, "-- Grafana --", annotations.GetIndex(0).Get("datasource").MustString())
+		assert.Equal(t, "${DS_LOGS}", annotations.GetIndex(1).GetPath("datasource", "uid").MustString())
+
+		inputs := exported.Get("__inputs").MustArray()
+		require.Len(t, inputs, 3)
+		assert.Equal(t, map[string]interface{}{
+			"name": "DS_PROD_METRICS", "label": "Prod Metrics", "description": "", "type": "datasource",
+			"pluginId": "prometheus", "pluginName": "Prometheus",
+		}, inputs[0])
+		assert.Equal(t, "DS_LOGS", exported.Get("__inputs").GetIndex(1).Get("name").MustString())
+	})
+
+	t.Run("should reset the query variables", func(t *testing.T) {
+		host := exported.GetPath("templating", "list").GetIndex(1)
+		assert.Equal(t, "${DS_PROD_METRICS}", host.Get("datasource").MustString())
+		assert.Empty(t, host.Get("options").MustArray())
+		assert.Empty(t, host.Get("current").MustMap())
+		assert.Equal(t, int64(1), host.Get("refresh").MustInt64())
+
+		cluster := exported.GetPath("templating", "list").GetIndex(2)
+		assert.Equal(t, "$ds", cluster.GetPath("datasource", "uid").MustString())
+		assert.Equal(t, int64(2), cluster.Get("refresh").MustInt64())
+	})
+
+	t.Run("should replace the constants with inputs", func(t *testing.T) {
+		env := exported.GetPath("templating", "list").GetIndex(3)
+		assert.Equal(t, "${VAR_ENV}", env.Get("query").MustString())
+		assert.Equal(t, "${VAR_ENV}", env.GetPath("current", "value").MustString())
+
+		input := exported.Get("__inputs").GetIndex(2)
+		assert.Equal(t, "VAR_ENV", input.Get("name").MustString())
+		assert.Equal(t, "constant", input.Get("type").MustString())
+		assert.Equal(t, "Environment", input.Get("label").MustString())
+		assert.Equal(t, "production", input.Get("value").MustString())
+	})
+
+	t.Run("should list the requirements", func(t *testing.T) {
+		requires := exported.Get("__requires").MustArray()
+		ids := make([]string, 0, len(requires))
+		for i := range requires {
+			ids = append(ids, exported.Get("__requires").GetIndex(i).Get("id").MustString())
+		}
+		assert.Equal(t, []string{"grafana", "loki", "prometheus", "timeseries"}, ids)
+		assert.Equal(t, "8.4.0", exported.Get("__requires").GetIndex(0).Get("version").MustString())
+		assert.Equal(t, "1.0.0", exported.Get("__requires").GetIndex(1).Get("version").MustString())
+	})
+
+	t.Run("should reference the library panels", func(t *testing.T) {
+		panel := exported.Get("panels").GetIndex(2).Get("panels").GetIndex(0)
+		assert.Equal(t, map[string]interface{}{"uid": "lib-uid", "name": "Uptime"}, panel.Get("libraryPanel").MustMap())
+		assert.Nil(t, panel.Get("datasource").Interface())
+		assert.Equal(t, int64(4), panel.Get("id").MustInt64())
+
+		elements := exported.Get("__elements")
+		require.Len(t, elements.MustArray(), 1)
+		assert.Equal(t, "lib-uid", elements.GetIndex(0).Get("uid").MustString())
+		assert.Equal(t, int64(1), elements.GetIndex(0).Get("kind").MustInt64())
+		model := elements.GetIndex(0).Get("model")
+		assert.Equal(t, "${DS_PROD_METRICS}", model.Get("datasource").MustString())
+		assert.Nil(t, model.Get("libraryPanel").Interface())
+	})
+}
+
+func TestExport_InlineLibraryPanels(t *testing.T) {
+	exported := export(t, dashboard, Options{LibraryPanels: LibraryPanelsInline})
+
+	panel := exported.Get("panels").GetIndex(2).Get("panels").GetIndex(0)
+	assert.Equal(t, "${DS_PROD_METRICS}", panel.Get("datasource").MustString())
+	assert.Nil(t, panel.Get("libraryPanel").Interface())
+	assert.Empty(t, exported.Get("__elements").MustArray())
+}
+
+func TestExport_Errors(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{"panels": [{"id": 1, "datasource": "unknown"}]}`))
+	require.NoError(t, err)
+
+	_, err = Export(data, fakeResolver{}, Options{})
+	assert.ErrorIs(t, err, ErrDataSourceNotFound)
+
+	_, err = Export(data, fakeResolver{}, Options{LibraryPanels: "copy"})
+	assert.ErrorIs(t, err, ErrInvalidLibraryPanelMode)
+}
@@ -0,0 +1,391 @@
+// Package dashvalidate lints a dashboard JSON model before it is saved, so that the provisioning pipelines can check
+// the dashboards they deploy: the structure of the model, the data sources it references and its template variables.
+package dashvalidate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/dashexport"
+	"github.com/grafana/grafana/pkg/components/dashvariables"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// The codes of the problems
+const (
+	CodeInvalidType           = "invalid_type"
+	CodeTitleRequired         = "title_required"
+	CodeDuplicatePanelID      = "duplicate_panel_id"
+	CodePanelIDRequired       = "panel_id_required"
+	CodePanelTypeRequired     = "panel_type_required"
+	CodeGridPosRequired       = "grid_pos_required"
+	CodePanelPluginNotFound   = "panel_plugin_not_found"
+	CodeDuplicateRefID        = "duplicate_ref_id"
+	CodeVariableNameRequired  = "variable_name_required"
+	CodeDuplicateVariableName = "duplicate_variable_name"
+	CodeVariableCycle         = "variable_cycle"
+	CodeVariableNotFound      = "variable_not_found"
+	CodeDataSourceNotFound    = "datasource_not_found"
+	CodeSchemaMismatch        = "schema_mismatch"
+)
+
+// builtInDataSources are the data sources of Grafana itself, referenced by name or uid, they exist in all instances
+var builtInDataSources = map[string]bool{
+	"-- Grafana --":   true,
+	"grafana":         true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+	"__expr__":        true,
+	"-100":            true,
+}
+
+// legacyGlobalVariables are the global variables and macros of the data sources whose names do not start with __
+var legacyGlobalVariables = map[string]bool{
+	"interval":    true,
+	"timeFilter":  true,
+	"col":         true,
+	"m":           true,
+	"measurement": true,
+}
+
+// Problem is an error or a warning about a dashboard
+type Problem struct {
+	Code string `json:"code"`
+	// Path is the path of the value the problem is about in the dashboard, such as panels[0].targets[1].datasource,
+	// empty for the whole dashboard
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Result is the result of the validation of a dashboard. The dashboard is valid when it has no errors, the warnings
+// are about what the dashboard may not display as expected.
+type Result struct {
+	Valid    bool      `json:"valid"`
+	Errors   []Problem `json:"errors"`
+	Warnings []Problem `json:"warnings"`
+}
+
+// Options of the validation
+type Options struct {
+	// Schema returns the mismatches between the dashboard and the dashboard schema, which are reported as warnings
+	// since the schema does not describe all the panel plugins yet. The dashboard is not checked against the schema
+	// when nil.
+	Schema func(dash *simplejson.Json) ([]Problem, error)
+}
+
+type validator struct {
+	resolver  dashexport.Resolver
+	variables map[string]bool
+	result    *Result
+}
+
+// Validate returns the errors and the warnings of the dashboard. The data sources and the panel plugins are looked up
+// with the resolver, an error is returned only when they cannot be.
+func Validate(dash *simplejson.Json, resolver dashexport.Resolver, opts Options) (*Result, error) {
+	v := &validator{
+		resolver:  resolver,
+		variables: map[string]bool{},
+		result:    &Result{Errors: []Problem{}, Warnings: []Problem{}},
+	}
+
+	model, ok := dash.Interface().(map[string]interface{})
+	if !ok {
+		v.error(CodeInvalidType, "", "the dashboard must be an object")
+		return v.done(), nil
+	}
+	if title, _ := model["title"].(string); strings.TrimSpace(title) == "" {
+		v.error(CodeTitleRequired, "title", "the dashboard must have a title")
+	}
+
+	if err := v.validateVariables(model); err != nil {
+		return nil, err
+	}
+	if err := v.validateAnnotations(model); err != nil {
+		return nil, err
+	}
+	if panels, ok := v.array(model, "panels", "panels"); ok {
+		if err := v.validatePanels(panels, "panels", map[int64]string{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Schema != nil {
+		problems, err := opts.Schema(dash)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range problems {
+			p.Code = CodeSchemaMismatch
+			v.result.Warnings = append(v.result.Warnings, p)
+		}
+	}
+	return v.done(), nil
+}
+
+func (v *validator) validateVariables(model map[string]interface{}) error {
+	templating, _ := model["templating"].(map[string]interface{})
+	list, ok := v.array(templating, "list", "templating.list")
+	if !ok {
+		return nil
+	}
+
+	for i, item := range list {
+		path := fmt.Sprintf("templating.list[%d]", i)
+		variable, ok := item.(map[string]interface{})
+		if !ok {
+			v.error(CodeInvalidType, path, "the variable must be an object")
+			continue
+		}
+		name, _ := variable["name"].(string)
+		switch {
+		case name == "":
+			v.error(CodeVariableNameRequired, path+".name", "the variable must have a name")
+		case v.variables[name]:
+			v.error(CodeDuplicateVariableName, path+".name", fmt.Sprintf("the variable %q is defined more than once", name))
+		}
+		v.variables[name] = true
+	}
+
+	for i, item := range list {
+		variable, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("templating.list[%d]", i)
+		definition := map[string]interface{}{}
+		for key, value := range variable {
+			if key != "current" && key != "options" && key != "datasource" {
+				definition[key] = value
+			}
+		}
+		v.checkReferences(definition, path, nil)
+		if _, ok := variable["datasource"]; ok && variable["datasource"] != nil {
+			if err := v.checkDataSource(variable["datasource"], path+".datasource"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cycle := range dashvariables.Build(simplejson.NewFromAny(model)).Cycles {
+		v.error(CodeVariableCycle, "templating.list", fmt.Sprintf("the variables %s depend on each other", strings.Join(cycle, ", ")))
+	}
+	return nil
+}
+
+func (v *validator) validateAnnotations(model map[string]interface{}) error {
+	annotations, _ := model["annotations"].(map[string]interface{})
+	list, ok := v.array(annotations, "list", "annotations.list")
+	if !ok {
+		return nil
+	}
+	for i, item := range list {
+		annotation, ok := item.(map[string]interface{})
+		if !ok || annotation["datasource"] == nil {
+			continue
+		}
+		if err := v.checkDataSource(annotation["datasource"], fmt.Sprintf("annotations.list[%d].datasource", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) validatePanels(panels []interface{}, path string, ids map[int64]string) error {
+	for i, item := range panels {
+		panelPath := fmt.Sprintf("%s[%d]", path, i)
+		panel, ok := item.(map[string]interface{})
+		if !ok {
+			v.error(CodeInvalidType, panelPath, "the panel must be an object")
+			continue
+		}
+
+		if panel["id"] == nil {
+			v.warning(CodePanelIDRequired, panelPath+".id", "the panel has no id, it is assigned one when the dashboard is loaded")
+		} else if id, err := simplejson.NewFromAny(panel["id"]).Int64(); err != nil {
+			v.error(CodeInvalidType, panelPath+".id", "the panel id must be a number")
+		} else {
+			if other, ok := ids[id]; ok {
+				v.error(CodeDuplicatePanelID, panelPath+".id", fmt.Sprintf("the panel id %d is also the id of %s", id, other))
+			}
+			ids[id] = panelPath
+		}
+		if _, ok := panel["gridPos"].(map[string]interface{}); !ok {
+			v.warning(CodeGridPosRequired, panelPath+".gridPos", "the panel has no position in the grid")
+		}
+
+		// The library panels are references to models saved on their own
+		if _, ok := panel["libraryPanel"].(map[string]interface{}); !ok {
+			panelType, _ := panel["type"].(string)
+			switch {
+			case panelType == "":
+				v.error(CodePanelTypeRequired, panelPath+".type", "the panel must have a type")
+			case panelType != "row" && v.resolver.Panel(panelType) == nil:
+				v.warning(CodePanelPluginNotFound, panelPath+".type", fmt.Sprintf("the panel plugin %q is not installed", panelType))
+			}
+		}
+
+		if err := v.validatePanelQueries(panel, panelPath); err != nil {
+			return err
+		}
+
+		checked := map[string]interface{}{}
+		for key, value := range panel {
+			if key != "panels" && key != "scopedVars" && key != "datasource" && key != "targets" {
+				checked[key] = value
+			}
+		}
+		v.checkReferences(checked, panelPath, nil)
+		if repeat, _ := panel["repeat"].(string); repeat != "" && !v.variables[repeat] {
+			v.warning(CodeVariableNotFound, panelPath+".repeat", fmt.Sprintf("the panel is repeated for the variable %q which is not defined", repeat))
+		}
+
+		if nested, ok := v.array(panel, "panels", panelPath+".panels"); ok {
+			if err := v.validatePanels(nested, panelPath+".panels", ids); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) validatePanelQueries(panel map[string]interface{}, path string) error {
+	targets, ok := v.array(panel, "targets", path+".targets")
+	if !ok || len(targets) == 0 {
+		if panel["datasource"] != nil {
+			return v.checkDataSource(panel["datasource"], path+".datasource")
+		}
+		return nil
+	}
+
+	// The panels without a data source use the default one
+	if err := v.checkDataSource(panel["datasource"], path+".datasource"); err != nil {
+		return err
+	}
+	// The expressions reference the other queries of the panel by refId, as variables
+	refIDs := map[string]bool{}
+	for _, item := range targets {
+		if target, ok := item.(map[string]interface{}); ok {
+			if refID, _ := target["refId"].(string); refID != "" {
+				refIDs[refID] = true
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for i, item := range targets {
+		targetPath := fmt.Sprintf("%s.targets[%d]", path, i)
+		target, ok := item.(map[string]interface{})
+		if !ok {
+			v.error(CodeInvalidType, targetPath, "the query must be an object")
+			continue
+		}
+		if refID, _ := target["refId"].(string); refID != "" {
+			if seen[refID] {
+				v.error(CodeDuplicateRefID, targetPath+".refId", fmt.Sprintf("the refId %q is used by more than one query of the panel", refID))
+			}
+			seen[refID] = true
+		}
+
+		checked := map[string]interface{}{}
+		for key, value := range target {
+			if key != "datasource" {
+				checked[key] = value
+			}
+		}
+		v.checkReferences(checked, targetPath, refIDs)
+		if target["datasource"] != nil {
+			if err := v.checkDataSource(target["datasource"], targetPath+".datasource"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkDataSource checks that the data source referenced by name or by uid exists, the default data source when the
+// reference is empty. The data sources set by variables are checked for their variables only, since their values are
+// chosen on the dashboard.
+func (v *validator) checkDataSource(ref interface{}, path string) error {
+	var name string
+	switch r := ref.(type) {
+	case nil:
+	case string:
+		name = r
+	case map[string]interface{}:
+		name, _ = r["uid"].(string)
+	default:
+		v.error(CodeInvalidType, path, "the data source must be a name or a reference to a uid")
+		return nil
+	}
+
+	if refs := dashvariables.References(name); len(refs) > 0 {
+		for _, ref := range refs {
+			if !v.variables[ref] {
+				v.error(CodeVariableNotFound, path, fmt.Sprintf("the data source is set by the variable %q which is not defined", ref))
+			}
+		}
+		return nil
+	}
+	if builtInDataSources[name] {
+		return nil
+	}
+
+	ds, err := v.resolver.DataSource(name)
+	if err != nil {
+		return err
+	}
+	if ds != nil {
+		return nil
+	}
+	if name == "" {
+		v.warning(CodeDataSourceNotFound, path, "the default data source is used but none is set")
+		return nil
+	}
+	v.error(CodeDataSourceNotFound, path, fmt.Sprintf("the data source %q does not exist", name))
+	return nil
+}
+
+// checkReferences warns about the references to the variables the dashboard does not define. The global variables
+// are left out, as well as the macros of the data sources which look like variables and the other names in scope.
+func (v *validator) checkReferences(value interface{}, path string, scope map[string]bool) {
+	for _, name := range dashvariables.References(value) {
+		if v.variables[name] || scope[name] || strings.HasPrefix(name, "__") || legacyGlobalVariables[name] || strings.HasPrefix(name, "tag_") || isNumber(name) {
+			continue
+		}
+		v.warning(CodeVariableNotFound, path, fmt.Sprintf("the variable %q is not defined", name))
+	}
+}
+
+func (v *validator) error(code, path, message string) {
+	v.result.Errors = append(v.result.Errors, Problem{Code: code, Path: path, Message: message})
+}
+
+func (v *validator) warning(code, path, message string) {
+	v.result.Warnings = append(v.result.Warnings, Problem{Code: code, Path: path, Message: message})
+}
+
+func (v *validator) done() *Result {
+	v.result.Valid = len(v.result.Errors) == 0
+	return v.result
+}
+
+// array returns the array of the property of the object, and reports an error when the property is not an array
+func (v *validator) array(object map[string]interface{}, key string, path string) ([]interface{}, bool) {
+	value, ok := object[key]
+	if !ok || value == nil {
+		return nil, false
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		v.error(CodeInvalidType, path, fmt.Sprintf("%s must be an array", key))
+	}
+	return items, ok
+}
+
+func isNumber(name string) bool {
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
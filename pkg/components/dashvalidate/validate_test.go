@@ -0,0 +1,183 @@
+package dashvalidate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/dashexport"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type fakeResolver struct {
+	defaultDataSource bool
+}
+
+func (r fakeResolver) DataSource(ref string) (*dashexport.DataSource, error) {
+	switch ref {
+	case "prom-uid", "Prod Metrics":
+		return &dashexport.DataSource{Name: "Prod Metrics", Type: "prometheus"}, nil
+	case "":
+		if r.defaultDataSource {
+			return &dashexport.DataSource{Name: "Prod Metrics", Type: "prometheus"}, nil
+		}
+	case "broken":
+		return nil, errors.New("database is locked")
+	}
+	return nil, nil
+}
+
+func (fakeResolver) Panel(panelType string) *dashexport.Plugin {
+	if panelType == "timeseries" || panelType == "text" {
+		return &dashexport.Plugin{ID: panelType}
+	}
+	return nil
+}
+
+func validate(t *testing.T, dash string, resolver fakeResolver, opts Options) *Result {
+	t.Helper()
+	data, err := simplejson.NewJson([]byte(dash))
+	require.NoError(t, err)
+	result, err := Validate(data, resolver, opts)
+	require.NoError(t, err)
+	return result
+}
+
+func codes(problems []Problem) map[string]string {
+	result := map[string]string{}
+	for _, p := range problems {
+		result[p.Path] = p.Code
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("should accept a valid dashboard", func(t *testing.T) {
+		result := validate(t, `{
+			"title": "Hosts",
+			"annotations": {"list": [{"name": "Annotations & Alerts", "builtIn": 1, "datasource": "-- Grafana --"}]},
+			"templating": {"list": [
+				{"name": "ds", "type": "datasource", "query": "prometheus"},
+				{"name": "host", "type": "query", "datasource": {"uid": "$ds"}, "query": "label_values(host)"}
+			]},
+			"panels": [
+				{"id": 1, "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}, "datasource": {"uid": "${ds}"},
+					"targets": [{"refId": "A", "expr": "rate(up{host=\"$host\"}[$__rate_interval])"}]},
+				{"id": 2, "type": "row", "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1}, "collapsed": true, "panels": [
+					{"id": 3, "type": "timeseries", "gridPos": {"x": 0, "y": 9, "w": 12, "h": 8}, "datasource": "Prod Metrics",
+						"targets": [{"refId": "A", "expr": "up"}, {"refId": "B", "datasource": {"uid": "__expr__"}, "expression": "$A"}]}
+				]}
+			]
+		}`, fakeResolver{}, Options{})
+
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Errors)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("should report the errors of the structure", func(t *testing.T) {
+		result := validate(t, `{
+			"templating": {"list": [{"name": "host"}, {"name": "host"}, {"type": "query"}]},
+			"panels": [
+				{"id": 1, "type": "timeseries", "gridPos": {}},
+				{"id": 1, "gridPos": {}},
+				{"id": "2", "type": "text", "gridPos": {}, "targets": "A"},
+				{"type": "text"}
+			]
+		}`, fakeResolver{}, Options{})
+
+		assert.False(t, result.Valid)
+		assert.Equal(t, map[string]string{
+			"title":                   CodeTitleRequired,
+			"templating.list[1].name": CodeDuplicateVariableName,
+			"templating.list[2].name": CodeVariableNameRequired,
+			"panels[1].id":            CodeDuplicatePanelID,
+			"panels[1].type":          CodePanelTypeRequired,
+			"panels[2].id":            CodeInvalidType,
+			"panels[2].targets":       CodeInvalidType,
+		}, codes(result.Errors))
+		assert.Equal(t, map[string]string{
+			"panels[3].id":      CodePanelIDRequired,
+			"panels[3].gridPos": CodeGridPosRequired,
+		}, codes(result.Warnings))
+	})
+
+	t.Run("should report the data sources which do not exist", func(t *testing.T) {
+		result := validate(t, `{
+			"title": "Hosts",
+			"annotations": {"list": [{"name": "Deployments", "datasource": {"uid": "loki-uid"}}]},
+			"templating": {"list": [{"name": "host", "type": "query", "datasource": "Prod Metrics"}]},
+			"panels": [
+				{"id": 1, "type": "timeseries", "gridPos": {}, "datasource": "-- Mixed --", "targets": [
+					{"refId": "A", "datasource": {"uid": "prom-uid"}},
+					{"refId": "B", "datasource": {"uid": "influx-uid"}},
+					{"refId": "B", "datasource": {"uid": "$ds"}}
+				]},
+				{"id": 2, "type": "timeseries", "gridPos": {}, "targets": [{"refId": "A"}]}
+			]
+		}`, fakeResolver{}, Options{})
+
+		assert.False(t, result.Valid)
+		assert.Equal(t, map[string]string{
+			"annotations.list[0].datasource":  CodeDataSourceNotFound,
+			"panels[0].targets[1].datasource": CodeDataSourceNotFound,
+			"panels[0].targets[2].refId":      CodeDuplicateRefID,
+			"panels[0].targets[2].datasource": CodeVariableNotFound,
+		}, codes(result.Errors))
+		assert.Equal(t, map[string]string{
+			"panels[1].datasource": CodeDataSourceNotFound,
+		}, codes(result.Warnings))
+
+		result = validate(t, `{"title": "Hosts", "panels": [{"id": 1, "type": "timeseries", "gridPos": {}, "targets": [{"refId": "A"}]}]}`,
+			fakeResolver{defaultDataSource: true}, Options{})
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("should report the variables which cannot be resolved", func(t *testing.T) {
+		result := validate(t, `{
+			"title": "Hosts",
+			"templating": {"list": [
+				{"name": "a", "type": "query", "query": "$b"},
+				{"name": "b", "type": "query", "query": "${a:csv}"},
+				{"name": "c", "type": "query", "query": "label_values(up{env=\"$env\"}, c)"}
+			]},
+			"panels": [
+				{"id": 1, "type": "text", "title": "$host on $__interval", "gridPos": {}, "repeat": "host",
+					"options": {"content": "[[cluster]] and ${a} at $timeFilter, $1"}}
+			]
+		}`, fakeResolver{}, Options{})
+
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, Problem{Code: CodeVariableCycle, Path: "templating.list", Message: "the variables a, b depend on each other"}, result.Errors[0])
+		assert.Equal(t, []Problem{
+			{Code: CodeVariableNotFound, Path: "templating.list[2]", Message: `the variable "env" is not defined`},
+			{Code: CodeVariableNotFound, Path: "panels[0]", Message: `the variable "cluster" is not defined`},
+			{Code: CodeVariableNotFound, Path: "panels[0]", Message: `the variable "host" is not defined`},
+			{Code: CodeVariableNotFound, Path: "panels[0].repeat", Message: `the panel is repeated for the variable "host" which is not defined`},
+		}, result.Warnings)
+	})
+
+	t.Run("should report the schema mismatches as warnings", func(t *testing.T) {
+		result := validate(t, `{"title": "Hosts"}`, fakeResolver{}, Options{
+			Schema: func(*simplejson.Json) ([]Problem, error) {
+				return []Problem{{Path: "refresh", Message: "conflicting values 5 and string"}}, nil
+			},
+		})
+
+		assert.True(t, result.Valid)
+		assert.Equal(t, []Problem{{Code: CodeSchemaMismatch, Path: "refresh", Message: "conflicting values 5 and string"}}, result.Warnings)
+	})
+
+	t.Run("should return an error when the data sources cannot be looked up", func(t *testing.T) {
+		data := simplejson.NewFromAny(map[string]interface{}{
+			"title":  "Hosts",
+			"panels": []interface{}{map[string]interface{}{"id": 1, "type": "text", "datasource": "broken"}},
+		})
+		_, err := Validate(data, fakeResolver{}, Options{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,255 @@
+// Package dashvariables computes the dependency graph of the template variables and the panels of a dashboard JSON
+// model, which tells what has to be refreshed when a variable changes.
+package dashvariables
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// variableRegex matches the references to variables, $var, [[var]] and ${var}, with their fields and formats. It is
+// the regex of the frontend template service.
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?\}`)
+
+// ignoredVariableProperties are the properties of a variable which are not part of its definition
+var ignoredVariableProperties = map[string]bool{
+	"name":        true,
+	"label":       true,
+	"description": true,
+	"current":     true,
+	"options":     true,
+}
+
+// ignoredPanelProperties are the properties of a panel which do not use variables, the panels of collapsed rows are
+// panels of their own and the scoped variables are set by the repeats
+var ignoredPanelProperties = map[string]bool{
+	"panels":     true,
+	"scopedVars": true,
+	"repeat":     true,
+}
+
+// Graph is the dependency graph of the variables and the panels of a dashboard
+type Graph struct {
+	Variables []*Variable `json:"variables"`
+	Panels    []*Panel    `json:"panels"`
+	// Cycles are the groups of variables which depend on each other, the dashboard cannot refresh them
+	Cycles [][]string `json:"cycles"`
+}
+
+// Variable is a template variable and what depends on it
+type Variable struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// DependsOn are the variables used by the definition of the variable, it is refreshed when they change
+	DependsOn []string `json:"dependsOn"`
+	// Dependents are the variables refreshed when the variable changes, directly or through other variables
+	Dependents []string `json:"dependents"`
+	// Panels are the IDs of the panels refreshed when the variable changes, the panels using it or its dependents
+	Panels []int64 `json:"panels"`
+}
+
+// Panel is a panel, or a row, and the variables it uses
+type Panel struct {
+	Id    int64  `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	// Variables are the variables used by the queries, the data source, the title, the links or the options of the
+	// panel
+	Variables []string `json:"variables"`
+	// Repeat is the variable the panel is repeated for
+	Repeat string `json:"repeat,omitempty"`
+}
+
+// Build returns the dependency graph of a dashboard. The references to variables the dashboard does not define, such
+// as the global variables, are ignored.
+func Build(data *simplejson.Json) *Graph {
+	graph := &Graph{Variables: []*Variable{}, Panels: []*Panel{}, Cycles: [][]string{}}
+	byName := map[string]*Variable{}
+
+	definitions := []interface{}{}
+	for _, v := range data.GetPath("templating", "list").MustArray() {
+		definition := simplejson.NewFromAny(v)
+		name := definition.Get("name").MustString()
+		if name == "" || byName[name] != nil {
+			continue
+		}
+		variable := &Variable{Name: name, Type: definition.Get("type").MustString(), DependsOn: []string{}}
+		byName[name] = variable
+		graph.Variables = append(graph.Variables, variable)
+		definitions = append(definitions, v)
+	}
+
+	for i, variable := range graph.Variables {
+		refs := references(definitions[i], ignoredVariableProperties)
+		for _, ref := range graph.Variables {
+			if ref != variable && refs[ref.Name] {
+				variable.DependsOn = append(variable.DependsOn, ref.Name)
+			}
+		}
+	}
+
+	var visitPanels func(panels []interface{})
+	visitPanels = func(panels []interface{}) {
+		for _, p := range panels {
+			model := simplejson.NewFromAny(p)
+			panel := &Panel{
+				Id:        model.Get("id").MustInt64(),
+				Title:     model.Get("title").MustString(),
+				Type:      model.Get("type").MustString(),
+				Variables: []string{},
+			}
+			refs := references(p, ignoredPanelProperties)
+			if repeat := model.Get("repeat").MustString(); byName[repeat] != nil {
+				panel.Repeat = repeat
+				refs[repeat] = true
+			}
+			for _, variable := range graph.Variables {
+				if refs[variable.Name] {
+					panel.Variables = append(panel.Variables, variable.Name)
+				}
+			}
+			graph.Panels = append(graph.Panels, panel)
+
+			visitPanels(model.Get("panels").MustArray())
+		}
+	}
+	visitPanels(data.Get("panels").MustArray())
+
+	for _, variable := range graph.Variables {
+		variable.Dependents = dependents(graph.Variables, variable)
+		refreshed := map[string]bool{variable.Name: true}
+		for _, name := range variable.Dependents {
+			refreshed[name] = true
+		}
+		variable.Panels = []int64{}
+		for _, panel := range graph.Panels {
+			for _, name := range panel.Variables {
+				if refreshed[name] {
+					variable.Panels = append(variable.Panels, panel.Id)
+					break
+				}
+			}
+		}
+	}
+
+	graph.Cycles = cycles(graph.Variables)
+	return graph
+}
+
+// References returns the names of the variables referenced by the strings of a JSON value, sorted, including the
+// global variables and the variables the dashboard does not define
+func References(value interface{}) []string {
+	refs := references(value, nil)
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// references returns the names referenced by the strings of a JSON value, except in the ignored properties of the
+// top level object
+func references(value interface{}, ignored map[string]bool) map[string]bool {
+	refs := map[string]bool{}
+	var visit func(value interface{})
+	visit = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range variableRegex.FindAllStringSubmatch(v, -1) {
+				for _, name := range []string{match[1], match[2], match[4]} {
+					if name != "" {
+						refs[name] = true
+					}
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				visit(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				visit(item)
+			}
+		}
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		for key, item := range object {
+			if !ignored[key] {
+				visit(item)
+			}
+		}
+		return refs
+	}
+	visit(value)
+	return refs
+}
+
+// dependents returns the variables depending on a variable, directly or not, in the order of the dashboard
+func dependents(variables []*Variable, variable *Variable) []string {
+	found := map[string]bool{variable.Name: true}
+	for changed := true; changed; {
+		changed = false
+		for _, other := range variables {
+			if found[other.Name] {
+				continue
+			}
+			for _, name := range other.DependsOn {
+				if found[name] {
+					found[other.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	result := []string{}
+	for _, other := range variables {
+		if other != variable && found[other.Name] {
+			result = append(result, other.Name)
+		}
+	}
+	return result
+}
+
+// cycles returns the groups of variables which depend on each other, in the order of the dashboard
+func cycles(variables []*Variable) [][]string {
+	result := [][]string{}
+	grouped := map[string]bool{}
+	for _, variable := range variables {
+		if grouped[variable.Name] {
+			continue
+		}
+		group := []string{}
+		for _, name := range variable.Dependents {
+			// a dependent the variable also depends on is in a cycle with it
+			for _, other := range variables {
+				if other.Name == name && contains(other.Dependents, variable.Name) {
+					group = append(group, name)
+				}
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		group = append([]string{variable.Name}, group...)
+		for _, name := range group {
+			grouped[name] = true
+		}
+		result = append(result, group)
+	}
+	return result
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package dashvariables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const dashboard = `{
+	"title": "Hosts",
+	"templating": {"list": [
+		{"name": "ds", "type": "datasource", "query": "prometheus"},
+		{"name": "cluster", "type": "query", "datasource": "${ds}", "query": "label_values(cluster)",
+			"current": {"text": "$host", "value": "$host"}},
+		{"name": "host", "type": "query", "datasource": {"uid": "$ds"}, "query": "label_values(up{cluster=\"[[cluster]]\"}, host)",
+			"regex": "/$__interval/"},
+		{"name": "interval", "type": "interval", "query": "1m,5m"},
+		{"name": "a", "type": "query", "query": "$b"},
+		{"name": "b", "type": "query", "query": "${a:csv}"}
+	]},
+	"panels": [
+		{"id": 1, "title": "CPU of $host", "type": "timeseries", "targets": [{"expr": "cpu{host=\"$host\"}[$interval]"}]},
+		{"id": 2, "title": "Clusters", "type": "stat", "datasource": "${ds}", "repeat": "cluster", "scopedVars": {"host": {"value": "x"}}},
+		{"id": 3, "title": "Row", "type": "row", "collapsed": true, "panels": [
+			{"id": 4, "title": "Static", "type": "text", "options": {"content": "$unknown and $__dashboard"}}
+		]}
+	]
+}`
+
+func TestBuild(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(dashboard))
+	require.NoError(t, err)
+	graph := Build(data)
+
+	variables := map[string]*Variable{}
+	for _, v := range graph.Variables {
+		variables[v.Name] = v
+	}
+	require.Len(t, variables, 6)
+
+	t.Run("should find the variables used by the variables", func(t *testing.T) {
+		assert.Empty(t, variables["ds"].DependsOn)
+		assert.Equal(t, []string{"ds"}, variables["cluster"].DependsOn)
+		assert.Equal(t, []string{"ds", "cluster"}, variables["host"].DependsOn)
+		assert.Equal(t, "query", variables["host"].Type)
+	})
+
+	t.Run("should find the dependents of the variables", func(t *testing.T) {
+		assert.Equal(t, []string{"cluster", "host"}, variables["ds"].Dependents)
+		assert.Equal(t, []string{"host"}, variables["cluster"].Dependents)
+		assert.Empty(t, variables["host"].Dependents)
+	})
+
+	t.Run("should find the panels refreshed by the variables", func(t *testing.T) {
+		assert.Equal(t, []int64{1, 2}, variables["ds"].Panels)
+		assert.Equal(t, []int64{1, 2}, variables["cluster"].Panels)
+		assert.Equal(t, []int64{1}, variables["host"].Panels)
+		assert.Equal(t, []int64{1}, variables["interval"].Panels)
+		assert.Empty(t, variables["a"].Panels)
+	})
+
+	t.Run("should find the variables used by the panels", func(t *testing.T) {
+		require.Len(t, graph.Panels, 4)
+		assert.Equal(t, &Panel{Id: 1, Title: "CPU of $host", Type: "timeseries", Variables: []string{"host", "interval"}}, graph.Panels[0])
+		assert.Equal(t, &Panel{Id: 2, Title: "Clusters", Type: "stat", Variables: []string{"ds", "cluster"}, Repeat: "cluster"}, graph.Panels[1])
+		assert.Equal(t, &Panel{Id: 3, Title: "Row", Type: "row", Variables: []string{}}, graph.Panels[2])
+		assert.Equal(t, &Panel{Id: 4, Title: "Static", Type: "text", Variables: []string{}}, graph.Panels[3])
+	})
+
+	t.Run("should find the cycles", func(t *testing.T) {
+		assert.Equal(t, [][]string{{"a", "b"}}, graph.Cycles)
+	})
+
+	t.Run("should build the graph of a dashboard without variables", func(t *testing.T) {
+		graph := Build(simplejson.New())
+		assert.Empty(t, graph.Variables)
+		assert.Empty(t, graph.Panels)
+		assert.Empty(t, graph.Cycles)
+	})
+}
+
+func TestReferences(t *testing.T) {
+	value := map[string]interface{}{
+		"title":   "$host on [[cluster:csv]]",
+		"targets": []interface{}{map[string]interface{}{"expr": "rate(up{job=\"${job.value:regex}\"}[$__rate_interval])"}},
+	}
+	assert.Equal(t, []string{"__rate_interval", "cluster", "host", "job"}, References(value))
+	assert.Empty(t, References("no variables"))
+}
@@ -0,0 +1,480 @@
+// Package parquet writes and reads Apache Parquet files of flat tables of integers and strings, compressed with gzip.
+// It implements the subset of the format needed to archive the rows of the database in files which can also be read
+// by the usual data tools.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	// Int64 columns hold int64 values
+	Int64 ColumnType = iota
+	// String columns hold UTF-8 string values
+	String
+)
+
+// Column is a required column of a file, which has a value in every row
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// DefaultRowGroupSize is the number of rows of the row groups of the files, the unit in which they are read
+const DefaultRowGroupSize = 10000
+
+var magic = []byte("PAR1")
+
+// The values of the enums of the parquet metadata used by this package
+const (
+	typeInt64     = 2
+	typeByteArray = 6
+
+	convertedTypeUTF8 = 0
+	repetitionReq     = 0
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	codecGzip         = 2
+
+	pageTypeData = 0
+)
+
+// Writer writes the rows of a parquet file, which is complete once the writer is closed
+type Writer struct {
+	w            io.Writer
+	columns      []Column
+	rowGroupSize int
+	offset       int64
+	// values are the plain encoded values of the columns in the current row group
+	values    []bytes.Buffer
+	groupRows int
+	rowGroups []rowGroupMeta
+	numRows   int64
+}
+
+type rowGroupMeta struct {
+	numRows   int64
+	totalSize int64
+	columns   []columnChunkMeta
+}
+
+type columnChunkMeta struct {
+	dataPageOffset   int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+	codec            int64
+}
+
+// NewWriter returns a writer of a file with the columns
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("a parquet file needs at least one column")
+	}
+	if _, err := w.Write(magic); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: DefaultRowGroupSize,
+		offset:       int64(len(magic)),
+		values:       make([]bytes.Buffer, len(columns)),
+	}, nil
+}
+
+// Write adds a row, with an int64 or a string value for each column in the order of the columns
+func (w *Writer) Write(row ...interface{}) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(row))
+	}
+	for i, column := range w.columns {
+		switch column.Type {
+		case Int64:
+			v, ok := row[i].(int64)
+			if !ok {
+				return fmt.Errorf("expected an int64 for column %s, got %T", column.Name, row[i])
+			}
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], uint64(v))
+			w.values[i].Write(b[:])
+		case String:
+			v, ok := row[i].(string)
+			if !ok {
+				return fmt.Errorf("expected a string for column %s, got %T", column.Name, row[i])
+			}
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], uint32(len(v)))
+			w.values[i].Write(b[:])
+			w.values[i].WriteString(v)
+		}
+	}
+
+	w.groupRows++
+	if w.groupRows >= w.rowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+// flushRowGroup writes the buffered rows as a row group, with a data page per column
+func (w *Writer) flushRowGroup() error {
+	if w.groupRows == 0 {
+		return nil
+	}
+	group := rowGroupMeta{numRows: int64(w.groupRows)}
+	for i := range w.columns {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(w.values[i].Bytes()); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+
+		header := thriftWriter{}
+		header.beginStruct()
+		header.i32Field(1, pageTypeData)
+		header.i32Field(2, int32(w.values[i].Len()))
+		header.i32Field(3, int32(compressed.Len()))
+		header.structField(5)
+		header.beginStruct()
+		header.i32Field(1, int32(w.groupRows))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRLE)
+		header.i32Field(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunk := columnChunkMeta{
+			dataPageOffset:   w.offset,
+			numValues:        int64(w.groupRows),
+			uncompressedSize: int64(len(header.buf) + w.values[i].Len()),
+			compressedSize:   int64(len(header.buf) + compressed.Len()),
+			codec:            codecGzip,
+		}
+		if err := w.write(header.buf); err != nil {
+			return err
+		}
+		if err := w.write(compressed.Bytes()); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.totalSize += chunk.uncompressedSize
+		w.values[i].Reset()
+	}
+
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += int64(w.groupRows)
+	w.groupRows = 0
+	return nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// Close writes the buffered rows and the metadata of the file, it does not close the underlying writer
+func (w *Writer) Close() error {
+	if err := w.flushRowGroup(); err != nil {
+		return err
+	}
+
+	meta := thriftWriter{}
+	meta.beginStruct()
+	meta.i32Field(1, 1)
+	meta.listField(2, thriftStruct, len(w.columns)+1)
+	meta.beginStruct()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(w.columns)))
+	meta.endStruct()
+	for _, column := range w.columns {
+		meta.beginStruct()
+		if column.Type == String {
+			meta.i32Field(1, typeByteArray)
+		} else {
+			meta.i32Field(1, typeInt64)
+		}
+		meta.i32Field(3, repetitionReq)
+		meta.stringField(4, column.Name)
+		if column.Type == String {
+			meta.i32Field(6, convertedTypeUTF8)
+		}
+		meta.endStruct()
+	}
+	meta.i64Field(3, w.numRows)
+	meta.listField(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		meta.beginStruct()
+		meta.listField(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			meta.beginStruct()
+			meta.i64Field(2, chunk.dataPageOffset)
+			meta.structField(3)
+			meta.beginStruct()
+			if w.columns[i].Type == String {
+				meta.i32Field(1, typeByteArray)
+			} else {
+				meta.i32Field(1, typeInt64)
+			}
+			meta.listField(2, thriftI32, 1)
+			meta.varint(encodingPlain)
+			meta.listField(3, thriftBinary, 1)
+			meta.binary(w.columns[i].Name)
+			meta.i32Field(4, int32(chunk.codec))
+			meta.i64Field(5, chunk.numValues)
+			meta.i64Field(6, chunk.uncompressedSize)
+			meta.i64Field(7, chunk.compressedSize)
+			meta.i64Field(9, chunk.dataPageOffset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64Field(2, group.totalSize)
+		meta.i64Field(3, group.numRows)
+		meta.endStruct()
+	}
+	meta.stringField(6, "grafana")
+	meta.endStruct()
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(meta.buf)))
+	for _, b := range [][]byte{meta.buf, length[:], magic} {
+		if err := w.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reader reads the rows of a parquet file of required integer and string columns with plain encoded data pages, such
+// as the files of Writer
+type Reader struct {
+	data      []byte
+	columns   []Column
+	numRows   int64
+	rowGroups []rowGroupMeta
+}
+
+// NewReader reads the metadata of the file
+func NewReader(data []byte) (*Reader, error) {
+	if len(data) < 2*len(magic)+4 || !bytes.Equal(data[:4], magic) || !bytes.Equal(data[len(data)-4:], magic) {
+		return nil, errors.New("not a parquet file")
+	}
+	length := int64(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	start := int64(len(data)) - 8 - length
+	if start < int64(len(magic)) {
+		return nil, errors.New("invalid parquet metadata length")
+	}
+	meta, err := (&thriftReader{r: bytes.NewReader(data[start : len(data)-8])}).readStruct(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the parquet metadata: %w", err)
+	}
+
+	r := &Reader{data: data}
+	if r.numRows, err = meta.int(3); err != nil {
+		return nil, err
+	}
+	schema, err := meta.list(2)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range schema[1:] {
+		element, ok := raw.(thriftStructValue)
+		if !ok {
+			return nil, errors.New("invalid parquet schema")
+		}
+		name, err := element.string(4)
+		if err != nil {
+			return nil, err
+		}
+		if repetition, _ := element.int(3); repetition != repetitionReq {
+			return nil, fmt.Errorf("column %s is not required", name)
+		}
+		column := Column{Name: name}
+		switch typ, _ := element.int(1); typ {
+		case typeInt64:
+			column.Type = Int64
+		case typeByteArray:
+			column.Type = String
+		default:
+			return nil, fmt.Errorf("column %s has the unsupported type %d", name, typ)
+		}
+		r.columns = append(r.columns, column)
+	}
+
+	groups, err := meta.list(4)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range groups {
+		group, ok := raw.(thriftStructValue)
+		if !ok {
+			return nil, errors.New("invalid parquet row group")
+		}
+		chunks, err := group.list(1)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) != len(r.columns) {
+			return nil, errors.New("the row group does not match the schema")
+		}
+		var rowGroup rowGroupMeta
+		if rowGroup.numRows, err = group.int(3); err != nil {
+			return nil, err
+		}
+		for _, raw := range chunks {
+			chunk, ok := raw.(thriftStructValue)
+			if !ok {
+				return nil, errors.New("invalid parquet column chunk")
+			}
+			columnMeta, ok := chunk.structure(3)
+			if !ok {
+				return nil, errors.New("the parquet column chunk has no metadata")
+			}
+			var c columnChunkMeta
+			if c.dataPageOffset, err = columnMeta.int(9); err != nil {
+				return nil, err
+			}
+			if c.numValues, err = columnMeta.int(5); err != nil {
+				return nil, err
+			}
+			if c.codec, err = columnMeta.int(4); err != nil {
+				return nil, err
+			}
+			rowGroup.columns = append(rowGroup.columns, c)
+		}
+		r.rowGroups = append(r.rowGroups, rowGroup)
+	}
+	return r, nil
+}
+
+// Columns returns the columns of the file
+func (r *Reader) Columns() []Column {
+	return r.columns
+}
+
+// NumRows returns the number of rows of the file
+func (r *Reader) NumRows() int64 {
+	return r.numRows
+}
+
+// Read calls fn with each of the rows of the file, whose values are an int64 or a string by column. The row is
+// reused by the next calls.
+func (r *Reader) Read(fn func(row []interface{}) error) error {
+	row := make([]interface{}, len(r.columns))
+	for _, group := range r.rowGroups {
+		columns := make([][]interface{}, len(r.columns))
+		for i, chunk := range group.columns {
+			values, err := r.readColumnChunk(r.columns[i], chunk)
+			if err != nil {
+				return fmt.Errorf("failed to read column %s: %w", r.columns[i].Name, err)
+			}
+			if int64(len(values)) != group.numRows {
+				return fmt.Errorf("column %s has %d values instead of %d", r.columns[i].Name, len(values), group.numRows)
+			}
+			columns[i] = values
+		}
+		for n := int64(0); n < group.numRows; n++ {
+			for i := range columns {
+				row[i] = columns[i][n]
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readColumnChunk decodes the data pages of a column chunk
+func (r *Reader) readColumnChunk(column Column, chunk columnChunkMeta) ([]interface{}, error) {
+	values := make([]interface{}, 0, chunk.numValues)
+	offset := chunk.dataPageOffset
+	for int64(len(values)) < chunk.numValues {
+		if offset < 0 || offset >= int64(len(r.data)) {
+			return nil, errors.New("page out of the file")
+		}
+		headerReader := &thriftReader{r: bytes.NewReader(r.data[offset:])}
+		header, err := headerReader.readStruct(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the page header: %w", err)
+		}
+		offset += headerReader.read
+
+		size, err := header.int(3)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 || offset+size > int64(len(r.data)) {
+			return nil, errors.New("page out of the file")
+		}
+		page := r.data[offset : offset+size]
+		offset += size
+
+		if typ, _ := header.int(1); typ != pageTypeData {
+			return nil, fmt.Errorf("unsupported page type %d", typ)
+		}
+		dataHeader, ok := header.structure(5)
+		if !ok {
+			return nil, errors.New("the data page has no header")
+		}
+		if encoding, _ := dataHeader.int(2); encoding != encodingPlain {
+			return nil, fmt.Errorf("unsupported encoding %d", encoding)
+		}
+		count, err := dataHeader.int(1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch chunk.codec {
+		case codecUncompressed:
+		case codecGzip:
+			gz, err := gzip.NewReader(bytes.NewReader(page))
+			if err != nil {
+				return nil, err
+			}
+			if page, err = ioutil.ReadAll(gz); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported compression codec %d", chunk.codec)
+		}
+
+		for i := int64(0); i < count; i++ {
+			switch column.Type {
+			case Int64:
+				if len(page) < 8 {
+					return nil, io.ErrUnexpectedEOF
+				}
+				values = append(values, int64(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case String:
+				if len(page) < 4 {
+					return nil, io.ErrUnexpectedEOF
+				}
+				n := binary.LittleEndian.Uint32(page)
+				if uint64(len(page)-4) < uint64(n) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				values = append(values, string(page[4:4+n]))
+				page = page[4+n:]
+			}
+		}
+	}
+	return values, nil
+}
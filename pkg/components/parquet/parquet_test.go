@@ -0,0 +1,94 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	columns := []Column{{Name: "id", Type: Int64}, {Name: "text", Type: String}}
+
+	t.Run("should read the rows back", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, columns)
+		require.NoError(t, err)
+		w.rowGroupSize = 3
+		for i := 0; i < 10; i++ {
+			require.NoError(t, w.Write(int64(i-5), fmt.Sprintf("row %d é", i)))
+		}
+		require.NoError(t, w.Close())
+		require.Len(t, w.rowGroups, 4)
+
+		r, err := NewReader(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, columns, r.Columns())
+		assert.Equal(t, int64(10), r.NumRows())
+
+		var rows [][]interface{}
+		require.NoError(t, r.Read(func(row []interface{}) error {
+			rows = append(rows, append([]interface{}{}, row...))
+			return nil
+		}))
+		require.Len(t, rows, 10)
+		assert.Equal(t, []interface{}{int64(-5), "row 0 é"}, rows[0])
+		assert.Equal(t, []interface{}{int64(4), "row 9 é"}, rows[9])
+	})
+
+	t.Run("should write a file without rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, columns)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		r, err := NewReader(buf.Bytes())
+		require.NoError(t, err)
+		assert.Zero(t, r.NumRows())
+		require.NoError(t, r.Read(func(row []interface{}) error {
+			return fmt.Errorf("unexpected row %v", row)
+		}))
+	})
+
+	t.Run("should refuse values of the wrong type", func(t *testing.T) {
+		w, err := NewWriter(&bytes.Buffer{}, columns)
+		require.NoError(t, err)
+		assert.Error(t, w.Write("1", "text"))
+		assert.Error(t, w.Write(int64(1)))
+	})
+
+	t.Run("should refuse files which are not parquet", func(t *testing.T) {
+		_, err := NewReader([]byte("PAR1 not really PAR1"))
+		assert.Error(t, err)
+	})
+}
+
+func TestThriftCompact(t *testing.T) {
+	w := thriftWriter{}
+	w.beginStruct()
+	w.i32Field(1, -3)
+	w.i64Field(20, 1<<40)
+	w.listField(21, thriftBinary, 20)
+	for i := 0; i < 20; i++ {
+		w.binary(fmt.Sprint(i))
+	}
+	w.structField(22)
+	w.beginStruct()
+	w.stringField(1, "nested")
+	w.endStruct()
+	w.endStruct()
+
+	s, err := (&thriftReader{r: bytes.NewReader(w.buf)}).readStruct(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-3), s[1])
+	assert.Equal(t, int64(1<<40), s[20])
+	list, err := s.list(21)
+	require.NoError(t, err)
+	require.Len(t, list, 20)
+	assert.Equal(t, []byte("19"), list[19])
+	nested, ok := s.structure(22)
+	require.True(t, ok)
+	assert.Equal(t, []byte("nested"), nested[1])
+}
@@ -0,0 +1,280 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The types of the thrift compact protocol, in which the metadata of the parquet files are encoded
+const (
+	thriftStop   = 0
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+// maxThriftDepth bounds the nesting of the decoded structs, the parquet metadata are a few levels deep
+const maxThriftDepth = 16
+
+// thriftWriter encodes the structs of the thrift compact protocol. The fields of a struct must be written in the
+// order of their ids.
+type thriftWriter struct {
+	buf     []byte
+	lastIDs []int16
+}
+
+func (w *thriftWriter) beginStruct() {
+	w.lastIDs = append(w.lastIDs, 0)
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, thriftStop)
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &w.lastIDs[len(w.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) binary(v string) {
+	w.uvarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *thriftWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.binary(v)
+}
+
+// listField writes the header of a list field, followed by its elements written by the caller
+func (w *thriftWriter) listField(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.uvarint(uint64(size))
+	}
+}
+
+// structField writes the header of a struct field, followed by its fields written by the caller between beginStruct
+// and endStruct
+func (w *thriftWriter) structField(id int16) {
+	w.fieldHeader(id, thriftStruct)
+}
+
+// thriftStructValue is a decoded struct, its fields by id. The integers are decoded as int64, the binaries as []byte,
+// the lists and sets as []interface{} and the structs as thriftStructValue.
+type thriftStructValue map[int16]interface{}
+
+func (s thriftStructValue) int(id int16) (int64, error) {
+	v, ok := s[id].(int64)
+	if !ok {
+		return 0, fmt.Errorf("missing integer field %d", id)
+	}
+	return v, nil
+}
+
+func (s thriftStructValue) string(id int16) (string, error) {
+	v, ok := s[id].([]byte)
+	if !ok {
+		return "", fmt.Errorf("missing string field %d", id)
+	}
+	return string(v), nil
+}
+
+func (s thriftStructValue) list(id int16) ([]interface{}, error) {
+	v, ok := s[id].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("missing list field %d", id)
+	}
+	return v, nil
+}
+
+func (s thriftStructValue) structure(id int16) (thriftStructValue, bool) {
+	v, ok := s[id].(thriftStructValue)
+	return v, ok
+}
+
+// thriftReader decodes the structs of the thrift compact protocol
+type thriftReader struct {
+	r interface {
+		io.Reader
+		io.ByteReader
+	}
+	// read is the number of bytes read, which tells where the data of a page starts after its header
+	read int64
+}
+
+func (r *thriftReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.read++
+	}
+	return b, err
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	return binary.ReadUvarint(r)
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	u, err := r.uvarint()
+	return int64(u>>1) ^ -int64(u&1), err
+}
+
+func (r *thriftReader) bytes(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, errors.New("thrift value too long")
+	}
+	b := make([]byte, n)
+	read, err := io.ReadFull(r.r, b)
+	r.read += int64(read)
+	return b, err
+}
+
+func (r *thriftReader) readStruct(depth int) (thriftStructValue, error) {
+	if depth > maxThriftDepth {
+		return nil, errors.New("thrift struct nested too deep")
+	}
+	s := thriftStructValue{}
+	var id int16
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		typ := header & 0x0f
+		if typ == thriftStop {
+			return s, nil
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		if s[id], err = r.readValue(typ, depth); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case thriftTrue:
+		return true, nil
+	case thriftFalse:
+		return false, nil
+	case thriftByte:
+		b, err := r.ReadByte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return r.varint()
+	case thriftDouble:
+		b, err := r.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case thriftBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		return r.bytes(n)
+	case thriftList, thriftSet:
+		header, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		elemType := header & 0x0f
+		list := make([]interface{}, 0)
+		for i := uint64(0); i < size; i++ {
+			var v interface{}
+			if elemType == thriftTrue || elemType == thriftFalse {
+				// the booleans of the lists are encoded in a byte of their own
+				var b byte
+				b, err = r.ReadByte()
+				v = b == thriftTrue
+			} else {
+				v, err = r.readValue(elemType, depth+1)
+			}
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case thriftMap:
+		size, err := r.uvarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		// the maps are not part of the metadata read by this package, they are skipped
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.readValue(types>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftStruct:
+		return r.readStruct(depth + 1)
+	default:
+		return nil, fmt.Errorf("unknown thrift type %d", typ)
+	}
+}
@@ -0,0 +1,174 @@
+// Package textanalysis splits texts, such as the titles of the dashboards, into the terms they are searched by. The
+// terms are lowercased words, bigrams of the Chinese, Japanese and Korean (CJK) scripts which are not written with
+// spaces between the words, optionally folded to their letters without the diacritics and stemmed.
+package textanalysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrUnknownLanguage = errors.New("unknown analyzer language")
+
+// The languages of the stemmers
+const (
+	LanguageEnglish = "english"
+	LanguageFrench  = "french"
+	LanguageGerman  = "german"
+	LanguageSpanish = "spanish"
+)
+
+var stemmers = map[string]func(string) string{
+	LanguageEnglish: stemEnglish,
+	LanguageFrench:  stemFrench,
+	LanguageGerman:  stemGerman,
+	LanguageSpanish: stemSpanish,
+}
+
+// foldings are the letters which are not a base letter with combining diacritics once decomposed
+var foldings = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+}
+
+// Analyzer analyzes the texts
+type Analyzer struct {
+	// Language of the stemmer, the words are not stemmed when empty
+	Language string
+	// FoldDiacritics removes the diacritics, so that "resume" matches "résumé"
+	FoldDiacritics bool
+}
+
+// Validate returns ErrUnknownLanguage when there is no stemmer for the language
+func (a Analyzer) Validate() error {
+	if _, ok := stemmers[a.Language]; a.Language != "" && !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownLanguage, a.Language)
+	}
+	return nil
+}
+
+// String returns a key identifying the analyzer, the texts analyzed by analyzers of different keys have different
+// terms
+func (a Analyzer) String() string {
+	key := "standard"
+	if a.Language != "" {
+		key += "+" + a.Language
+	}
+	if a.FoldDiacritics {
+		key += "+fold"
+	}
+	return key
+}
+
+// Analyze returns the distinct terms of the text, in the order they appear in it
+func (a Analyzer) Analyze(text string) []string {
+	terms := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, token := range tokenize(text) {
+		if token.cjk {
+			for _, bigram := range bigrams(token.text) {
+				add(bigram)
+			}
+			continue
+		}
+		term := strings.ToLower(token.text)
+		if stem, ok := stemmers[a.Language]; ok {
+			term = stem(term)
+		}
+		if a.FoldDiacritics {
+			term = fold(term)
+		}
+		add(term)
+	}
+	return terms
+}
+
+type token struct {
+	text string
+	cjk  bool
+}
+
+// tokenize splits the text into the words of letters and digits and the runs of CJK characters
+func tokenize(text string) []token {
+	tokens := make([]token, 0)
+	var current []rune
+	currentCJK := false
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, token{text: string(current), cjk: currentCJK})
+			current = current[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if !currentCJK {
+				flush()
+			}
+			currentCJK = true
+			current = append(current, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || (unicode.Is(unicode.Mn, r) && len(current) > 0 && !currentCJK):
+			if currentCJK {
+				flush()
+			}
+			currentCJK = false
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	// The block of the kana also has common characters of the Japanese words, such as the prolonged sound mark
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || (r >= 0x3040 && r <= 0x30ff)
+}
+
+// bigrams returns the overlapping pairs of characters of a CJK run, as the words are not delimited. A run of a
+// single character is its own term.
+func bigrams(text string) []string {
+	chars := []rune(text)
+	if len(chars) == 1 {
+		return []string{text}
+	}
+	result := make([]string, 0, len(chars)-1)
+	for i := 0; i < len(chars)-1; i++ {
+		result = append(result, string(chars[i:i+2]))
+	}
+	return result
+}
+
+// fold removes the diacritics of the letters of the term
+func fold(term string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), term)
+	if err != nil {
+		folded = term
+	}
+	if strings.IndexFunc(folded, func(r rune) bool { return r > unicode.MaxASCII }) < 0 {
+		return folded
+	}
+	var b strings.Builder
+	for _, r := range folded {
+		if s, ok := foldings[r]; ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package textanalysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer Analyzer
+		text     string
+		terms    []string
+	}{
+		{
+			name:  "lowercases the words",
+			text:  "Kubernetes / API-Server (prod) 2",
+			terms: []string{"kubernetes", "api", "server", "prod", "2"},
+		},
+		{
+			name:  "keeps the diacritics by default",
+			text:  "Résumé Ärzte",
+			terms: []string{"résumé", "ärzte"},
+		},
+		{
+			name:     "folds the diacritics",
+			analyzer: Analyzer{FoldDiacritics: true},
+			text:     "Résumé Ärzte Straße Øresund",
+			terms:    []string{"resume", "arzte", "strasse", "oresund"},
+		},
+		{
+			name:  "splits the CJK runs into bigrams",
+			text:  "服务器监控 東京 CPU使用率 a 字",
+			terms: []string{"服务", "务器", "器监", "监控", "東京", "cpu", "使用", "用率", "a", "字"},
+		},
+		{
+			name:  "splits the Japanese and Korean runs",
+			text:  "ダッシュボード 대시보드",
+			terms: []string{"ダッ", "ッシ", "シュ", "ュボ", "ボー", "ード", "대시", "시보", "보드"},
+		},
+		{
+			name:     "stems the English words",
+			analyzer: Analyzer{Language: LanguageEnglish},
+			text:     "Monitoring monitored monitors queries running stopped processes status",
+			terms:    []string{"monitor", "query", "run", "stop", "process", "status"},
+		},
+		{
+			name:     "stems the French words",
+			analyzer: Analyzer{Language: LanguageFrench, FoldDiacritics: true},
+			text:     "Réseaux journaux métriques journalières",
+			terms:    []string{"reseau", "journal", "metriqu", "journalier"},
+		},
+		{
+			name:     "stems the German words",
+			analyzer: Analyzer{Language: LanguageGerman},
+			text:     "Übersichten Übersicht Servern",
+			terms:    []string{"ubersicht", "serv"},
+		},
+		{
+			name:     "stems the Spanish words",
+			analyzer: Analyzer{Language: LanguageSpanish, FoldDiacritics: true},
+			text:     "Métricas métrico servidores",
+			terms:    []string{"metric", "servidor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.terms, tt.analyzer.Analyze(tt.text))
+		})
+	}
+}
+
+func TestAnalyzer_Validate(t *testing.T) {
+	assert.NoError(t, Analyzer{}.Validate())
+	assert.NoError(t, Analyzer{Language: LanguageGerman}.Validate())
+	assert.ErrorIs(t, Analyzer{Language: "klingon"}.Validate(), ErrUnknownLanguage)
+
+	assert.Equal(t, "standard", Analyzer{}.String())
+	assert.Equal(t, "standard+english+fold", Analyzer{Language: LanguageEnglish, FoldDiacritics: true}.String())
+}
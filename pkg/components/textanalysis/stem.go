@@ -0,0 +1,128 @@
+package textanalysis
+
+import "strings"
+
+// The stemmers are light stemmers: they only remove the inflections, such as the plurals, and a few common suffixes
+// of the lowercased words, which is enough to match the short texts such as titles and is less likely to merge
+// unrelated words than the full stemmers.
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyàâäéèêëíìîïóòôöúùûüý", r)
+}
+
+func hasVowel(s []rune) bool {
+	for _, r := range s {
+		if isVowel(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSuffix(s []rune, suffix string) bool {
+	return strings.HasSuffix(string(s), suffix)
+}
+
+// stemEnglish removes the plurals and the -ing and -ed endings: "monitoring", "monitored" and "monitors" become
+// "monitor"
+func stemEnglish(word string) string {
+	s := []rune(word)
+	if len(s) <= 3 {
+		return word
+	}
+
+	switch {
+	case hasSuffix(s, "ies") && !hasSuffix(s, "eies") && !hasSuffix(s, "aies") && len(s) > 4:
+		s = append(s[:len(s)-3], 'y')
+	case hasSuffix(s, "sses"), hasSuffix(s, "xes"), hasSuffix(s, "ches"), hasSuffix(s, "shes"), hasSuffix(s, "zzes"):
+		s = s[:len(s)-2]
+	case hasSuffix(s, "s") && !hasSuffix(s, "ss") && !hasSuffix(s, "us") && !hasSuffix(s, "is"):
+		s = s[:len(s)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		n := len([]rune(suffix))
+		if !hasSuffix(s, suffix) || len(s)-n < 3 || !hasVowel(s[:len(s)-n]) {
+			continue
+		}
+		s = s[:len(s)-n]
+		// "running" and "stopped" become "run" and "stop"
+		if l := len(s); l > 3 && s[l-1] == s[l-2] && !isVowel(s[l-1]) && !strings.ContainsRune("lsz", s[l-1]) {
+			s = s[:l-1]
+		}
+		break
+	}
+	return string(s)
+}
+
+// stemFrench removes the plurals and the feminine endings: "réseaux" becomes "réseau", "journalières" becomes
+// "journalier"
+func stemFrench(word string) string {
+	s := []rune(word)
+	if len(s) <= 3 {
+		return word
+	}
+
+	switch {
+	case hasSuffix(s, "eaux"):
+		s = s[:len(s)-1]
+	case hasSuffix(s, "aux") && len(s) > 4:
+		s = append(s[:len(s)-3], 'a', 'l')
+	case hasSuffix(s, "s"), hasSuffix(s, "x"):
+		s = s[:len(s)-1]
+	}
+
+	switch {
+	case hasSuffix(s, "ière"):
+		s = append(s[:len(s)-4], 'i', 'e', 'r')
+	case hasSuffix(s, "euse") && len(s) > 5:
+		s = append(s[:len(s)-4], 'e', 'u', 'r')
+	case hasSuffix(s, "e") && len(s) > 4:
+		s = s[:len(s)-1]
+	}
+	return string(s)
+}
+
+// stemGerman normalizes the umlauts and removes the inflections: "Übersichten" becomes "ubersicht"
+func stemGerman(word string) string {
+	s := []rune(strings.NewReplacer("ä", "a", "ö", "o", "ü", "u").Replace(word))
+
+	switch {
+	case len(s) > 5 && hasSuffix(s, "ern"):
+		s = s[:len(s)-3]
+	case len(s) > 4 && (hasSuffix(s, "em") || hasSuffix(s, "en") || hasSuffix(s, "er") || hasSuffix(s, "es")):
+		s = s[:len(s)-2]
+	case len(s) > 3 && hasSuffix(s, "e"):
+		s = s[:len(s)-1]
+	case len(s) > 3 && hasSuffix(s, "s") && strings.ContainsRune("bdfghklmnrt", s[len(s)-2]):
+		s = s[:len(s)-1]
+	}
+
+	switch {
+	case len(s) > 5 && hasSuffix(s, "est"):
+		s = s[:len(s)-3]
+	case len(s) > 4 && (hasSuffix(s, "er") || hasSuffix(s, "en")):
+		s = s[:len(s)-2]
+	}
+	return string(s)
+}
+
+// stemSpanish removes the plurals and the gender endings: "métricas" and "métrico" become "métric"
+func stemSpanish(word string) string {
+	s := []rune(word)
+	if len(s) < 5 {
+		return word
+	}
+
+	switch {
+	case hasSuffix(s, "eses"):
+		s = s[:len(s)-2]
+	case hasSuffix(s, "ces"):
+		s = append(s[:len(s)-3], 'z')
+	case hasSuffix(s, "os"), hasSuffix(s, "as"), hasSuffix(s, "es"):
+		s = s[:len(s)-2]
+	case hasSuffix(s, "o"), hasSuffix(s, "a"), hasSuffix(s, "e"):
+		s = s[:len(s)-1]
+	}
+	return string(s)
+}
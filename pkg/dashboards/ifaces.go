@@ -3,6 +3,7 @@ package dashboards
 import (
 	"context"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 )
 
@@ -17,7 +18,19 @@ type Store interface {
 	GetProvisionedDashboardData(name string) ([]*models.DashboardProvisioning, error)
 	SaveProvisionedDashboard(cmd models.SaveDashboardCommand, provisioning *models.DashboardProvisioning) (*models.Dashboard, error)
 	SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error)
+	// SaveDashboards saves several dashboards within a single transaction, none of them is saved when one fails.
+	SaveDashboards(ctx context.Context, cmds []*models.SaveDashboardCommand) ([]*models.Dashboard, error)
 	UpdateDashboardACLCtx(ctx context.Context, uid int64, items []*models.DashboardAcl) error
+	// GetFolderDataSourceUIDs returns the data sources dashboards in the folder are allowed to use, all are allowed if empty.
+	GetFolderDataSourceUIDs(ctx context.Context, orgID, folderID int64) ([]string, error)
+	// GetLibraryPanelModels returns the models of the existing library panels with the uids, keyed by uid.
+	GetLibraryPanelModels(ctx context.Context, orgID int64, uids []string) (map[string]*simplejson.Json, error)
+	// GetDashboardTrashEntry returns a deleted dashboard or folder of the organization.
+	GetDashboardTrashEntry(ctx context.Context, orgID, id int64) (*models.DashboardTrashEntry, error)
+	// GetFolderTrashEntry returns the most recently deleted folder of the organization with the uid.
+	GetFolderTrashEntry(ctx context.Context, orgID int64, uid string) (*models.DashboardTrashEntry, error)
+	// RestoreDashboardTrashEntry saves a deleted dashboard or folder again, with its permissions, and removes it from the trash.
+	RestoreDashboardTrashEntry(ctx context.Context, cmd models.SaveDashboardCommand, entry *models.DashboardTrashEntry) (*models.Dashboard, error)
 	// SaveAlerts saves dashboard alerts.
 	SaveAlerts(ctx context.Context, dashID int64, alerts []*models.Alert) error
 }
@@ -62,3 +62,106 @@ type DataSourceCreated struct {
 	UID       string    `json:"uid"`
 	OrgID     int64     `json:"org_id"`
 }
+
+// TeamMembershipChanged is published when users are added to or removed from a team.
+// UserID is zero when the memberships of all the team members changed, as when the team is deleted.
+type TeamMembershipChanged struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	TeamID    int64     `json:"team_id"`
+	UserID    int64     `json:"user_id"`
+	Removed   bool      `json:"removed"`
+}
+
+// OrgUserRoleChanged is published when a user is added to an organization, removed from it or given another role in it.
+// Role is empty when the user is removed from the organization.
+type OrgUserRoleChanged struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+}
+
+// RoleAssignmentChanged is published when an access control role is assigned to a user or a team, or unassigned from it.
+// Either UserID or TeamID is set. RoleName is empty when the assignment is removed by role uid.
+type RoleAssignmentChanged struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	RoleUID   string    `json:"role_uid"`
+	RoleName  string    `json:"role_name"`
+	UserID    int64     `json:"user_id"`
+	TeamID    int64     `json:"team_id"`
+	Removed   bool      `json:"removed"`
+}
+
+// AccessControlRolesChanged is published when the permissions of access control roles or their assignments change.
+type AccessControlRolesChanged struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+}
+
+// DashboardSaved is published when a dashboard is created or updated, folders excluded.
+type DashboardSaved struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	UID       string    `json:"uid"`
+	Version   int       `json:"version"`
+}
+
+// UserTokenCreated is published when a user signs in and is issued a session token.
+type UserTokenCreated struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserID    int64     `json:"user_id"`
+	TokenID   int64     `json:"token_id"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+}
+
+// UserTokensRevoked is published when session tokens of a user are revoked, as when they sign out.
+// TokenID is zero when all the session tokens of the user are revoked.
+type UserTokensRevoked struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserID    int64     `json:"user_id"`
+	TokenID   int64     `json:"token_id"`
+}
+
+// APIKeyCreated is published when an API key, or a token of a service account, is added.
+type APIKeyCreated struct {
+	Timestamp        time.Time `json:"timestamp"`
+	OrgID            int64     `json:"org_id"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	ServiceAccountID int64     `json:"service_account_id"`
+}
+
+// APIKeyUsed is published when a request is authenticated with an API key. As the last use of the key, it is
+// published at most once per minute for each key.
+type APIKeyUsed struct {
+	Timestamp        time.Time `json:"timestamp"`
+	OrgID            int64     `json:"org_id"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	ServiceAccountID int64     `json:"service_account_id"`
+	ClientIP         string    `json:"client_ip"`
+}
+
+// QuotaWarning is published when a resource is about to be created in an organization whose usage of the quota of
+// the resource is above the warning threshold. Used is the usage once the resource is created.
+type QuotaWarning struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	Target    string    `json:"target"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+}
+
+// AlertFiring is published when an alert rule starts firing, by the legacy alerting when the alert enters the
+// alerting state and by the unified alerting when an alert instance of the rule does. AlertID is the ID of the
+// legacy alert or of the alert rule, AlertUID is empty for the legacy alerts.
+type AlertFiring struct {
+	Timestamp  time.Time `json:"timestamp"`
+	OrgID      int64     `json:"org_id"`
+	AlertID    int64     `json:"alert_id"`
+	AlertUID   string    `json:"alert_uid"`
+	AlertTitle string    `json:"alert_title"`
+}
@@ -2,11 +2,14 @@ package expr
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 )
 
@@ -226,6 +229,358 @@ func (gr *ResampleCommand) Execute(ctx context.Context, vars mathexp.Vars) (math
 	return newRes, nil
 }
 
+const (
+	// AnomalyOutputAnomalies is the output of the anomaly command which is 1 at the anomalies and 0 elsewhere
+	AnomalyOutputAnomalies = "anomalies"
+	// AnomalyOutputScore is the output of the anomaly command which is the deviation of the points from their
+	// expected values, in standard deviations
+	AnomalyOutputScore = "score"
+	// AnomalyOutputBands is the output of the anomaly command which is the expected values and the lower and upper
+	// bands out of which the points are anomalies, as series with a band label
+	AnomalyOutputBands = "bands"
+
+	defaultAnomalySensitivity = 3.0
+	defaultAnomalyAlpha       = 0.3
+)
+
+// AnomalyCommand is an expression command detecting the points of a timeseries which deviate from their expected
+// values.
+type AnomalyCommand struct {
+	VarToCheck string
+	Detector   mathexp.AnomalyDetector
+	// Sensitivity is the number of standard deviations from the expected value beyond which a point is an anomaly
+	Sensitivity float64
+	Output      string
+	refID       string
+}
+
+// NewAnomalyCommand creates a new AnomalyCommand.
+func NewAnomalyCommand(refID, varToCheck string, detector mathexp.AnomalyDetector, sensitivity float64, output string) (*AnomalyCommand, error) {
+	if sensitivity <= 0 {
+		return nil, fmt.Errorf("the sensitivity of the anomaly detection must be positive, got %v", sensitivity)
+	}
+	switch output {
+	case AnomalyOutputAnomalies, AnomalyOutputScore, AnomalyOutputBands:
+	default:
+		return nil, fmt.Errorf("anomaly output %q not implemented", output)
+	}
+	return &AnomalyCommand{
+		VarToCheck:  varToCheck,
+		Detector:    detector,
+		Sensitivity: sensitivity,
+		Output:      output,
+		refID:       refID,
+	}, nil
+}
+
+// UnmarshalAnomalyCommand creates an AnomalyCommand from Grafana's frontend query.
+func UnmarshalAnomalyCommand(rn *rawNode) (*AnomalyCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, fmt.Errorf("no variable specified to detect anomalies in for refId %v", rn.RefID)
+	}
+	varToCheck, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected anomaly variable to be a string, got %T for refId %v", rawVar, rn.RefID)
+	}
+	varToCheck = strings.TrimPrefix(varToCheck, "$")
+
+	detector := mathexp.AnomalyDetector{Method: mathexp.AnomalyZScore, Alpha: defaultAnomalyAlpha}
+	if rawMethod, ok := rn.Query["method"]; ok {
+		if detector.Method, ok = rawMethod.(string); !ok {
+			return nil, fmt.Errorf("expected anomaly method to be a string, got %T for refId %v", rawMethod, rn.RefID)
+		}
+	}
+	if rawAlpha, ok := rn.Query["alpha"]; ok {
+		if detector.Alpha, ok = rawAlpha.(float64); !ok {
+			return nil, fmt.Errorf("expected anomaly alpha to be a number, got %T for refId %v", rawAlpha, rn.RefID)
+		}
+	}
+	if rawSeason, ok := rn.Query["season"]; ok {
+		season, ok := rawSeason.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected anomaly season to be a string, got %T for refId %v", rawSeason, rn.RefID)
+		}
+		var err error
+		if detector.Season, err = gtime.ParseDuration(season); err != nil {
+			return nil, fmt.Errorf(`failed to parse anomaly "season" duration field %q: %w`, season, err)
+		}
+	}
+
+	sensitivity := defaultAnomalySensitivity
+	if rawSensitivity, ok := rn.Query["sensitivity"]; ok {
+		if sensitivity, ok = rawSensitivity.(float64); !ok {
+			return nil, fmt.Errorf("expected anomaly sensitivity to be a number, got %T for refId %v", rawSensitivity, rn.RefID)
+		}
+	}
+
+	output := AnomalyOutputAnomalies
+	if rawOutput, ok := rn.Query["output"]; ok {
+		if output, ok = rawOutput.(string); !ok {
+			return nil, fmt.Errorf("expected anomaly output to be a string, got %T for refId %v", rawOutput, rn.RefID)
+		}
+	}
+
+	return NewAnomalyCommand(rn.RefID, varToCheck, detector, sensitivity, output)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (gr *AnomalyCommand) NeedsVars() []string {
+	return []string{gr.VarToCheck}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (gr *AnomalyCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	newRes := mathexp.Results{}
+	for _, val := range vars[gr.VarToCheck].Values {
+		series, ok := val.(mathexp.Series)
+		if !ok {
+			return newRes, fmt.Errorf("can only detect anomalies in type series, got type %v", val.Type())
+		}
+		bands, err := series.DetectAnomalies(gr.Detector)
+		if err != nil {
+			return newRes, err
+		}
+		if gr.Output == AnomalyOutputBands {
+			newRes.Values = append(newRes.Values, gr.bandSeries(series, bands)...)
+			continue
+		}
+		newRes.Values = append(newRes.Values, gr.scoreSeries(series, bands))
+	}
+	return newRes, nil
+}
+
+// scoreSeries returns the scores of the points, or whether they are anomalies. The points without expected value
+// are not anomalies.
+func (gr *AnomalyCommand) scoreSeries(series mathexp.Series, bands mathexp.AnomalyBands) mathexp.Series {
+	result := mathexp.NewSeries(gr.refID, series.GetLabels(), series.Len())
+	for i := 0; i < series.Len(); i++ {
+		t, v := series.GetPoint(i)
+		var value *float64
+		if v != nil && !math.IsNaN(*v) {
+			var score float64
+			if expected, deviation := bands.Expected[i], bands.Deviation[i]; expected != nil && deviation != nil {
+				// constant series have no deviation, any change is an anomaly
+				score = math.Abs(*v-*expected) / math.Max(*deviation, 1e-9*math.Max(1, math.Abs(*expected)))
+			}
+			if gr.Output == AnomalyOutputAnomalies {
+				if score > gr.Sensitivity {
+					score = 1
+				} else {
+					score = 0
+				}
+			}
+			value = &score
+		}
+		_ = result.SetPoint(i, t, value)
+	}
+	return result
+}
+
+// bandSeries returns the expected values, and the lower and upper bands at the sensitivity of the command
+func (gr *AnomalyCommand) bandSeries(series mathexp.Series, bands mathexp.AnomalyBands) []mathexp.Value {
+	names := []string{"expected", "lower", "upper"}
+	factors := []float64{0, -gr.Sensitivity, gr.Sensitivity}
+	result := make([]mathexp.Value, 0, len(names))
+	for b, name := range names {
+		labels := series.GetLabels().Copy()
+		labels["band"] = name
+		band := mathexp.NewSeries(gr.refID, labels, series.Len())
+		for i := 0; i < series.Len(); i++ {
+			var value *float64
+			if expected, deviation := bands.Expected[i], bands.Deviation[i]; expected != nil && deviation != nil {
+				v := *expected + *deviation*factors[b]
+				value = &v
+			}
+			_ = band.SetPoint(i, series.GetTime(i), value)
+		}
+		result = append(result, band)
+	}
+	return result
+}
+
+const (
+	// ForecastOutputForecast is the output of the forecast command which is the predicted values
+	ForecastOutputForecast = "forecast"
+	// ForecastOutputBands is the output of the forecast command which is the predicted values and the lower and upper
+	// bounds of their confidence interval, as series with a band label
+	ForecastOutputBands = "bands"
+
+	defaultForecastConfidence = 0.95
+	defaultForecastAlpha      = 0.5
+	defaultForecastBeta       = 0.1
+	defaultForecastGamma      = 0.1
+)
+
+// ForecastCommand is an expression command predicting the next values of a timeseries, for example to alert
+// before a disk fills up.
+type ForecastCommand struct {
+	VarToForecast string
+	Forecaster    mathexp.Forecaster
+	// Horizon is how far after the last point of the series the values are predicted
+	Horizon time.Duration
+	// Confidence is the probability of the values to be in the bands, between 0 and 1
+	Confidence float64
+	Output     string
+	refID      string
+}
+
+// NewForecastCommand creates a new ForecastCommand.
+func NewForecastCommand(refID, varToForecast string, forecaster mathexp.Forecaster, horizon time.Duration, confidence float64, output string) (*ForecastCommand, error) {
+	if horizon <= 0 {
+		return nil, fmt.Errorf("the horizon of the forecast must be positive, got %v", horizon)
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return nil, fmt.Errorf("the confidence of the forecast must be between 0 and 1, got %v", confidence)
+	}
+	switch output {
+	case ForecastOutputForecast, ForecastOutputBands:
+	default:
+		return nil, fmt.Errorf("forecast output %q not implemented", output)
+	}
+	return &ForecastCommand{
+		VarToForecast: varToForecast,
+		Forecaster:    forecaster,
+		Horizon:       horizon,
+		Confidence:    confidence,
+		Output:        output,
+		refID:         refID,
+	}, nil
+}
+
+// UnmarshalForecastCommand creates a ForecastCommand from Grafana's frontend query.
+func UnmarshalForecastCommand(rn *rawNode) (*ForecastCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, fmt.Errorf("no variable specified to forecast for refId %v", rn.RefID)
+	}
+	varToForecast, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected forecast variable to be a string, got %T for refId %v", rawVar, rn.RefID)
+	}
+	varToForecast = strings.TrimPrefix(varToForecast, "$")
+
+	parseDuration := func(field string) (time.Duration, error) {
+		raw, ok := rn.Query[field]
+		if !ok {
+			return 0, nil
+		}
+		s, ok := raw.(string)
+		if !ok {
+			return 0, fmt.Errorf("expected forecast %v to be a string, got %T for refId %v", field, raw, rn.RefID)
+		}
+		d, err := gtime.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf(`failed to parse forecast %q duration field %q: %w`, field, s, err)
+		}
+		return d, nil
+	}
+	parseNumber := func(field string, value *float64) error {
+		if raw, ok := rn.Query[field]; ok {
+			if *value, ok = raw.(float64); !ok {
+				return fmt.Errorf("expected forecast %v to be a number, got %T for refId %v", field, raw, rn.RefID)
+			}
+		}
+		return nil
+	}
+
+	horizon, err := parseDuration("horizon")
+	if err != nil {
+		return nil, err
+	}
+	if horizon == 0 {
+		return nil, fmt.Errorf("no horizon specified to forecast for refId %v", rn.RefID)
+	}
+
+	forecaster := mathexp.Forecaster{
+		Method: mathexp.ForecastLinear,
+		Alpha:  defaultForecastAlpha,
+		Beta:   defaultForecastBeta,
+		Gamma:  defaultForecastGamma,
+	}
+	if rawMethod, ok := rn.Query["method"]; ok {
+		if forecaster.Method, ok = rawMethod.(string); !ok {
+			return nil, fmt.Errorf("expected forecast method to be a string, got %T for refId %v", rawMethod, rn.RefID)
+		}
+	}
+	if forecaster.Season, err = parseDuration("season"); err != nil {
+		return nil, err
+	}
+	confidence := defaultForecastConfidence
+	for field, value := range map[string]*float64{
+		"alpha":      &forecaster.Alpha,
+		"beta":       &forecaster.Beta,
+		"gamma":      &forecaster.Gamma,
+		"confidence": &confidence,
+	} {
+		if err := parseNumber(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	output := ForecastOutputForecast
+	if rawOutput, ok := rn.Query["output"]; ok {
+		if output, ok = rawOutput.(string); !ok {
+			return nil, fmt.Errorf("expected forecast output to be a string, got %T for refId %v", rawOutput, rn.RefID)
+		}
+	}
+
+	return NewForecastCommand(rn.RefID, varToForecast, forecaster, horizon, confidence, output)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (gr *ForecastCommand) NeedsVars() []string {
+	return []string{gr.VarToForecast}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute. The series too short to be forecast have no result.
+func (gr *ForecastCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	newRes := mathexp.Results{}
+	for _, val := range vars[gr.VarToForecast].Values {
+		series, ok := val.(mathexp.Series)
+		if !ok {
+			return newRes, fmt.Errorf("can only forecast type series, got type %v", val.Type())
+		}
+		forecast, err := series.Forecast(gr.Forecaster, gr.Horizon)
+		if errors.Is(err, mathexp.ErrForecastNotEnoughPoints) {
+			continue
+		}
+		if err != nil {
+			return newRes, err
+		}
+
+		if gr.Output == ForecastOutputForecast {
+			newRes.Values = append(newRes.Values, gr.forecastSeries(series.GetLabels(), forecast, 0))
+			continue
+		}
+		// the bands are the quantiles of the normal distribution of the errors
+		z := math.Sqrt2 * math.Erfinv(gr.Confidence)
+		for _, band := range []struct {
+			name   string
+			factor float64
+		}{{"forecast", 0}, {"lower", -z}, {"upper", z}} {
+			labels := series.GetLabels().Copy()
+			labels["band"] = band.name
+			newRes.Values = append(newRes.Values, gr.forecastSeries(labels, forecast, band.factor))
+		}
+	}
+	return newRes, nil
+}
+
+// forecastSeries returns the predicted values moved by factor standard deviations of their errors
+func (gr *ForecastCommand) forecastSeries(labels data.Labels, forecast mathexp.Forecast, factor float64) mathexp.Series {
+	result := mathexp.NewSeries(gr.refID, labels, len(forecast.Values))
+	for i, t := range forecast.Times {
+		v := forecast.Values[i] + factor*forecast.Deviation[i]
+		_ = result.SetPoint(i, t, &v)
+	}
+	return result
+}
+
 // CommandType is the type of the expression command.
 type CommandType int
 
@@ -240,6 +595,10 @@ const (
 	TypeResample
 	// TypeClassicConditions is the CMDType for the classic condition operation.
 	TypeClassicConditions
+	// TypeAnomaly is the CMDType for an anomaly detection expression.
+	TypeAnomaly
+	// TypeForecast is the CMDType for a forecast expression.
+	TypeForecast
 )
 
 func (gt CommandType) String() string {
@@ -252,6 +611,10 @@ func (gt CommandType) String() string {
 		return "resample"
 	case TypeClassicConditions:
 		return "classic_conditions"
+	case TypeAnomaly:
+		return "anomaly"
+	case TypeForecast:
+		return "forecast"
 	default:
 		return "unknown"
 	}
@@ -268,6 +631,10 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeResample, nil
 	case "classic_conditions":
 		return TypeClassicConditions, nil
+	case "anomaly":
+		return TypeAnomaly, nil
+	case "forecast":
+		return TypeForecast, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
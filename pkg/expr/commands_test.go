@@ -0,0 +1,157 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+)
+
+func TestAnomalyCommand(t *testing.T) {
+	series := mathexp.NewSeries("A", data.Labels{"host": "a"}, 10)
+	for i := 0; i < 10; i++ {
+		v := 10.0
+		if i%2 == 1 {
+			v = 12
+		}
+		if i == 8 {
+			v = 40
+		}
+		require.NoError(t, series.SetPoint(i, time.Unix(int64(i*60), 0), &v))
+	}
+	vars := mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{series}}}
+
+	execute := func(t *testing.T, query map[string]interface{}) mathexp.Results {
+		t.Helper()
+		query["expression"] = "$A"
+		cmd, err := UnmarshalAnomalyCommand(&rawNode{RefID: "B", Query: query})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, cmd.NeedsVars())
+		res, err := cmd.Execute(context.Background(), vars)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should flag the anomalies", func(t *testing.T) {
+		res := execute(t, map[string]interface{}{"method": "zscore", "sensitivity": 2.0})
+		require.Len(t, res.Values, 1)
+		anomalies := res.Values[0].(mathexp.Series)
+		assert.Equal(t, data.Labels{"host": "a"}, anomalies.GetLabels())
+		for i := 0; i < anomalies.Len(); i++ {
+			expected := 0.0
+			if i == 8 {
+				expected = 1
+			}
+			assert.Equal(t, expected, *anomalies.GetValue(i), "point %d", i)
+		}
+	})
+
+	t.Run("should return the scores", func(t *testing.T) {
+		res := execute(t, map[string]interface{}{"method": "ewma", "alpha": 0.5, "output": "score"})
+		scores := res.Values[0].(mathexp.Series)
+		assert.Equal(t, 0.0, *scores.GetValue(0), "no score during the warm-up")
+		assert.Greater(t, *scores.GetValue(8), 3.0)
+		assert.Less(t, *scores.GetValue(9), *scores.GetValue(8))
+	})
+
+	t.Run("should return the bands", func(t *testing.T) {
+		res := execute(t, map[string]interface{}{"sensitivity": 1.0, "output": "bands"})
+		require.Len(t, res.Values, 3)
+		expected := res.Values[0].(mathexp.Series)
+		lower := res.Values[1].(mathexp.Series)
+		upper := res.Values[2].(mathexp.Series)
+		assert.Equal(t, data.Labels{"host": "a", "band": "lower"}, lower.GetLabels())
+		assert.Equal(t, 14.0, *expected.GetValue(0))
+		assert.InDelta(t, *expected.GetValue(0)-*lower.GetValue(0), *upper.GetValue(0)-*expected.GetValue(0), 1e-9)
+		assert.Greater(t, *upper.GetValue(0), *expected.GetValue(0))
+	})
+
+	t.Run("should refuse invalid settings", func(t *testing.T) {
+		for _, query := range []map[string]interface{}{
+			{},
+			{"expression": "$A", "sensitivity": 0.0},
+			{"expression": "$A", "sensitivity": "high"},
+			{"expression": "$A", "output": "graph"},
+			{"expression": "$A", "season": "daily"},
+		} {
+			_, err := UnmarshalAnomalyCommand(&rawNode{RefID: "B", Query: query})
+			assert.Error(t, err, "query %v", query)
+		}
+	})
+}
+
+func TestIsAnomalyQuery(t *testing.T) {
+	for query, expected := range map[string]bool{
+		`{"datasource": {"uid": "__expr__"}, "type": "anomaly"}`: true,
+		`{"datasource": "__expr__", "type": "anomaly"}`:          true,
+		`{"datasource": {"uid": "__expr__"}, "type": "math"}`:    false,
+		`{"datasource": {"uid": "prom"}, "type": "anomaly"}`:     false,
+	} {
+		json, err := simplejson.NewJson([]byte(query))
+		require.NoError(t, err)
+		assert.Equal(t, expected, IsAnomalyQuery(json), query)
+	}
+}
+
+func TestForecastCommand(t *testing.T) {
+	series := mathexp.NewSeries("A", data.Labels{"mountpoint": "/"}, 10)
+	for i := 0; i < 10; i++ {
+		v := 50 + 5*float64(i)
+		require.NoError(t, series.SetPoint(i, time.Unix(int64(i*3600), 0), &v))
+	}
+	short := mathexp.NewSeries("A", data.Labels{"mountpoint": "/data"}, 1)
+	require.NoError(t, short.SetPoint(0, time.Unix(0, 0), nil))
+	vars := mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{series, short}}}
+
+	execute := func(t *testing.T, query map[string]interface{}) mathexp.Results {
+		t.Helper()
+		query["expression"] = "$A"
+		cmd, err := UnmarshalForecastCommand(&rawNode{RefID: "B", Query: query})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, cmd.NeedsVars())
+		res, err := cmd.Execute(context.Background(), vars)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should forecast the series", func(t *testing.T) {
+		res := execute(t, map[string]interface{}{"horizon": "5h"})
+		require.Len(t, res.Values, 1, "the series too short should have no forecast")
+		forecast := res.Values[0].(mathexp.Series)
+		assert.Equal(t, data.Labels{"mountpoint": "/"}, forecast.GetLabels())
+		require.Equal(t, 5, forecast.Len())
+		assert.Equal(t, time.Unix(14*3600, 0), forecast.GetTime(4))
+		assert.InDelta(t, 120, *forecast.GetValue(4), 1e-9)
+	})
+
+	t.Run("should return the bands", func(t *testing.T) {
+		res := execute(t, map[string]interface{}{"horizon": "2h", "method": "holtwinters", "confidence": 0.9, "output": "bands"})
+		require.Len(t, res.Values, 3)
+		forecast := res.Values[0].(mathexp.Series)
+		lower := res.Values[1].(mathexp.Series)
+		upper := res.Values[2].(mathexp.Series)
+		assert.Equal(t, data.Labels{"mountpoint": "/", "band": "upper"}, upper.GetLabels())
+		assert.LessOrEqual(t, *lower.GetValue(1), *forecast.GetValue(1))
+		assert.GreaterOrEqual(t, *upper.GetValue(1), *forecast.GetValue(1))
+	})
+
+	t.Run("should refuse invalid settings", func(t *testing.T) {
+		for _, query := range []map[string]interface{}{
+			{"horizon": "1d"},
+			{"expression": "$A"},
+			{"expression": "$A", "horizon": "soon"},
+			{"expression": "$A", "horizon": "1d", "confidence": 1.0},
+			{"expression": "$A", "horizon": "1d", "alpha": "fast"},
+			{"expression": "$A", "horizon": "1d", "output": "graph"},
+		} {
+			_, err := UnmarshalForecastCommand(&rawNode{RefID: "B", Query: query})
+			assert.Error(t, err, "query %v", query)
+		}
+	})
+}
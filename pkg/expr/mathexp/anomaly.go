@@ -0,0 +1,221 @@
+package mathexp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// AnomalyZScore compares the values to the mean of the series
+	AnomalyZScore = "zscore"
+	// AnomalyEWMA compares the values to the exponentially weighted moving average of the previous values
+	AnomalyEWMA = "ewma"
+	// AnomalySeasonal compares the values to the trend and the seasonality of the series
+	AnomalySeasonal = "seasonal"
+)
+
+// AnomalyDetector computes the values expected at the points of a series, and how much they are expected to deviate
+type AnomalyDetector struct {
+	Method string
+	// Alpha is the smoothing factor of the EWMA method, between 0 and 1. The higher it is, the faster the average
+	// follows the changes of the series.
+	Alpha float64
+	// Season is the period of the seasonal method, such as a day
+	Season time.Duration
+}
+
+// AnomalyBands are the values expected at the points of a series and their standard deviations. They are nil for the
+// points the detector has too little data for.
+type AnomalyBands struct {
+	Expected  []*float64
+	Deviation []*float64
+}
+
+// DetectAnomalies returns the anomaly bands of the series, whose points must be sorted by time
+func (s Series) DetectAnomalies(d AnomalyDetector) (AnomalyBands, error) {
+	values := make([]*float64, s.Len())
+	for i := range values {
+		values[i] = s.GetValue(i)
+	}
+
+	switch d.Method {
+	case AnomalyZScore:
+		return zScoreBands(values), nil
+	case AnomalyEWMA:
+		if d.Alpha <= 0 || d.Alpha > 1 {
+			return AnomalyBands{}, fmt.Errorf("the alpha of the ewma anomaly detection must be between 0 and 1, got %v", d.Alpha)
+		}
+		return ewmaBands(values, d.Alpha), nil
+	case AnomalySeasonal:
+		times := make([]time.Time, s.Len())
+		for i := range times {
+			times[i] = s.GetTime(i)
+		}
+		return seasonalBands(times, values, d.Season)
+	default:
+		return AnomalyBands{}, fmt.Errorf("anomaly detection method %v not implemented", d.Method)
+	}
+}
+
+func newAnomalyBands(n int) AnomalyBands {
+	return AnomalyBands{Expected: make([]*float64, n), Deviation: make([]*float64, n)}
+}
+
+// zScoreBands expects the mean of the series at every point
+func zScoreBands(values []*float64) AnomalyBands {
+	bands := newAnomalyBands(len(values))
+	mean, deviation, count := meanDeviation(values)
+	if count < 2 {
+		return bands
+	}
+	for i := range values {
+		bands.Expected[i] = &mean
+		bands.Deviation[i] = &deviation
+	}
+	return bands
+}
+
+// ewmaBands expects the exponentially weighted moving average of the previous points. The points of the warm-up,
+// about the number of points the average is made of, have no band.
+func ewmaBands(values []*float64, alpha float64) AnomalyBands {
+	bands := newAnomalyBands(len(values))
+	warmUp := int(math.Ceil(1 / alpha))
+
+	var mean, variance float64
+	seen := 0
+	for i, v := range values {
+		if v == nil || math.IsNaN(*v) {
+			continue
+		}
+		if seen >= warmUp {
+			expected, deviation := mean, math.Sqrt(variance)
+			bands.Expected[i] = &expected
+			bands.Deviation[i] = &deviation
+		}
+
+		if seen == 0 {
+			mean = *v
+		} else {
+			diff := *v - mean
+			increment := alpha * diff
+			mean += increment
+			variance = (1 - alpha) * (variance + diff*increment)
+		}
+		seen++
+	}
+	return bands
+}
+
+// seasonalBands decomposes the series in a trend, the moving average over a season, a seasonal component, the mean
+// difference to the trend at the same time of the season, and a residual. The expected values are the trend plus the
+// seasonal component, and the deviation is the one of the residuals.
+func seasonalBands(times []time.Time, values []*float64, season time.Duration) (AnomalyBands, error) {
+	bands := newAnomalyBands(len(values))
+	if season <= 0 {
+		return bands, fmt.Errorf("the season of the seasonal anomaly detection is required")
+	}
+	step := medianStep(times)
+	if step <= 0 {
+		return bands, fmt.Errorf("the seasonal anomaly detection needs a series with at least two points")
+	}
+	period := int(math.Round(float64(season) / float64(step)))
+	if period < 2 {
+		return bands, fmt.Errorf("the season %v of the seasonal anomaly detection is shorter than two points of %v", season, step)
+	}
+	if times[len(times)-1].Sub(times[0]) < 2*season {
+		return bands, fmt.Errorf("the seasonal anomaly detection needs at least two seasons of %v", season)
+	}
+
+	trend := make([]*float64, len(values))
+	for i := range values {
+		from, to := i-period/2, i+period-period/2
+		if from < 0 {
+			from = 0
+		}
+		if to > len(values) {
+			to = len(values)
+		}
+		if mean, _, count := meanDeviation(values[from:to]); count > 0 {
+			trend[i] = &mean
+		}
+	}
+
+	slot := func(i int) int {
+		return int(math.Round(float64(times[i].Sub(times[0]))/float64(step))) % period
+	}
+	sums := make([]float64, period)
+	counts := make([]int, period)
+	for i, v := range values {
+		if v != nil && !math.IsNaN(*v) && trend[i] != nil {
+			sums[slot(i)] += *v - *trend[i]
+			counts[slot(i)]++
+		}
+	}
+	seasonal := make([]float64, period)
+	var seasonalMean float64
+	for i := range seasonal {
+		if counts[i] > 0 {
+			seasonal[i] = sums[i] / float64(counts[i])
+		}
+		seasonalMean += seasonal[i] / float64(period)
+	}
+
+	residuals := make([]*float64, len(values))
+	for i, v := range values {
+		if trend[i] == nil {
+			continue
+		}
+		expected := *trend[i] + seasonal[slot(i)] - seasonalMean
+		bands.Expected[i] = &expected
+		if v != nil && !math.IsNaN(*v) {
+			residual := *v - expected
+			residuals[i] = &residual
+		}
+	}
+	_, deviation, _ := meanDeviation(residuals)
+	for i := range bands.Expected {
+		if bands.Expected[i] != nil {
+			bands.Deviation[i] = &deviation
+		}
+	}
+	return bands, nil
+}
+
+// meanDeviation returns the mean and the standard deviation of the values which are not nil, and their number
+func meanDeviation(values []*float64) (float64, float64, int) {
+	var sum float64
+	count := 0
+	for _, v := range values {
+		if v != nil && !math.IsNaN(*v) {
+			sum += *v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, 0
+	}
+	mean := sum / float64(count)
+
+	var squares float64
+	for _, v := range values {
+		if v != nil && !math.IsNaN(*v) {
+			squares += (*v - mean) * (*v - mean)
+		}
+	}
+	return mean, math.Sqrt(squares / float64(count)), count
+}
+
+// medianStep returns the median interval between the points
+func medianStep(times []time.Time) time.Duration {
+	if len(times) < 2 {
+		return 0
+	}
+	steps := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		steps = append(steps, times[i].Sub(times[i-1]))
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+	return steps[len(steps)/2]
+}
@@ -0,0 +1,76 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// anomalySeries returns a series with a point per minute
+func anomalySeries(values ...float64) Series {
+	s := NewSeries("A", nil, len(values))
+	for i, v := range values {
+		v := v
+		_ = s.SetPoint(i, time.Unix(int64(i*60), 0), &v)
+	}
+	return s
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	t.Run("zscore should expect the mean of the series", func(t *testing.T) {
+		bands, err := anomalySeries(2, 4, 4, 4, 5, 5, 7, 9).DetectAnomalies(AnomalyDetector{Method: AnomalyZScore})
+		require.NoError(t, err)
+		require.Len(t, bands.Expected, 8)
+		assert.Equal(t, 5.0, *bands.Expected[0])
+		assert.Equal(t, 2.0, *bands.Deviation[7])
+	})
+
+	t.Run("ewma should expect the average of the previous points after the warm-up", func(t *testing.T) {
+		bands, err := anomalySeries(10, 10, 10, 10, 20).DetectAnomalies(AnomalyDetector{Method: AnomalyEWMA, Alpha: 0.5})
+		require.NoError(t, err)
+		assert.Nil(t, bands.Expected[0])
+		assert.Nil(t, bands.Expected[1])
+		assert.Equal(t, 10.0, *bands.Expected[2])
+		assert.Equal(t, 10.0, *bands.Expected[4])
+		assert.Equal(t, 0.0, *bands.Deviation[4])
+
+		_, err = anomalySeries(1, 2).DetectAnomalies(AnomalyDetector{Method: AnomalyEWMA, Alpha: 2})
+		require.Error(t, err)
+	})
+
+	t.Run("seasonal should expect the trend and the seasonality of the series", func(t *testing.T) {
+		// a season of 4 minutes repeated 6 times, with a spike in the fifth season
+		values := make([]float64, 24)
+		for i := range values {
+			values[i] = 100 + 10*math.Sin(float64(i%4)*math.Pi/2)
+		}
+		values[17] += 50
+		bands, err := anomalySeries(values...).DetectAnomalies(AnomalyDetector{Method: AnomalySeasonal, Season: 4 * time.Minute})
+		require.NoError(t, err)
+
+		deviation := func(i int) float64 {
+			return math.Abs(values[i]-*bands.Expected[i]) / *bands.Deviation[i]
+		}
+		assert.Greater(t, deviation(17), 3.0)
+		for _, i := range []int{1, 5, 9, 21} {
+			assert.Less(t, deviation(i), 3.0, "point %d", i)
+			assert.InDelta(t, 110, *bands.Expected[i], 15, "point %d", i)
+		}
+	})
+
+	t.Run("seasonal should need two seasons", func(t *testing.T) {
+		_, err := anomalySeries(1, 2, 3, 4, 5).DetectAnomalies(AnomalyDetector{Method: AnomalySeasonal, Season: 4 * time.Minute})
+		require.Error(t, err)
+
+		_, err = anomalySeries(1, 2, 3, 4, 5).DetectAnomalies(AnomalyDetector{Method: AnomalySeasonal})
+		require.Error(t, err)
+	})
+
+	t.Run("should fail with an unknown method", func(t *testing.T) {
+		_, err := anomalySeries(1, 2).DetectAnomalies(AnomalyDetector{Method: "prophet"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,254 @@
+package mathexp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// ForecastLinear extends the least squares line of the series
+	ForecastLinear = "linear"
+	// ForecastHoltWinters extends the level, the trend and the seasonality of the series, smoothed exponentially
+	ForecastHoltWinters = "holtwinters"
+
+	// maxForecastPoints is the maximum number of points of a forecast, the interval between the points is made
+	// longer than the one of the series when the horizon needs more
+	maxForecastPoints = 1000
+)
+
+// ErrForecastNotEnoughPoints is returned when a series has too few points to fit the model of the forecaster
+var ErrForecastNotEnoughPoints = errors.New("not enough points to forecast the series")
+
+// Forecaster fits a model to a series to predict its next values
+type Forecaster struct {
+	Method string
+	// Alpha, Beta and Gamma are the smoothing factors of the level, the trend and the seasonality of the Holt-Winters
+	// method, between 0 and 1. The higher they are, the faster the model follows the changes of the series.
+	Alpha float64
+	Beta  float64
+	Gamma float64
+	// Season is the period of the seasonality of the Holt-Winters method, the series has no seasonality when zero
+	Season time.Duration
+}
+
+// Forecast are the values predicted after the last point of a series, and the standard deviations of their errors
+type Forecast struct {
+	Times     []time.Time
+	Values    []float64
+	Deviation []float64
+}
+
+// Forecast predicts the values of the series, whose points must be sorted by time, up to horizon after its last
+// point. It returns ErrForecastNotEnoughPoints when the series is too short for the model.
+func (s Series) Forecast(f Forecaster, horizon time.Duration) (Forecast, error) {
+	if horizon <= 0 {
+		return Forecast{}, fmt.Errorf("the horizon of the forecast must be positive, got %v", horizon)
+	}
+	switch f.Method {
+	case ForecastLinear:
+	case ForecastHoltWinters:
+		for name, factor := range map[string]float64{"alpha": f.Alpha, "beta": f.Beta, "gamma": f.Gamma} {
+			if factor <= 0 || factor > 1 {
+				return Forecast{}, fmt.Errorf("the %v of the holtwinters forecast must be between 0 and 1, got %v", name, factor)
+			}
+		}
+		if f.Season < 0 {
+			return Forecast{}, fmt.Errorf("the season of the holtwinters forecast must be positive, got %v", f.Season)
+		}
+	default:
+		return Forecast{}, fmt.Errorf("forecast method %v not implemented", f.Method)
+	}
+
+	times := make([]time.Time, s.Len())
+	for i := range times {
+		times[i] = s.GetTime(i)
+	}
+	step := medianStep(times)
+	if step <= 0 {
+		return Forecast{}, ErrForecastNotEnoughPoints
+	}
+
+	// values is the series on a regular grid of the median interval, nil where a point is missing
+	values := make([]*float64, int(math.Round(float64(times[len(times)-1].Sub(times[0]))/float64(step)))+1)
+	for i := 0; i < s.Len(); i++ {
+		if v := s.GetValue(i); v != nil && !math.IsNaN(*v) {
+			values[int(math.Round(float64(times[i].Sub(times[0]))/float64(step)))] = v
+		}
+	}
+
+	// the forecast has a point every stride intervals of the series
+	steps := int(math.Ceil(float64(horizon) / float64(step)))
+	stride := (steps + maxForecastPoints - 1) / maxForecastPoints
+	ahead := make([]int, 0, steps/stride+1)
+	for k := stride; k <= steps; k += stride {
+		ahead = append(ahead, k)
+	}
+	if len(ahead) == 0 || ahead[len(ahead)-1] != steps {
+		ahead = append(ahead, steps)
+	}
+
+	var forecast Forecast
+	var err error
+	if f.Method == ForecastLinear {
+		forecast, err = linearForecast(values, ahead)
+	} else {
+		period := 0
+		if f.Season > 0 {
+			if period = int(math.Round(float64(f.Season) / float64(step))); period < 2 {
+				return Forecast{}, fmt.Errorf("the season %v of the holtwinters forecast is shorter than two points of %v", f.Season, step)
+			}
+		}
+		forecast, err = holtWintersForecast(values, ahead, f.Alpha, f.Beta, f.Gamma, period)
+	}
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	forecast.Times = make([]time.Time, len(ahead))
+	last := times[0].Add(time.Duration(len(values)-1) * step)
+	for i, k := range ahead {
+		forecast.Times[i] = last.Add(time.Duration(k) * step)
+	}
+	return forecast, nil
+}
+
+// linearForecast extends the least squares line of the values at the given numbers of steps after the last value.
+// The deviations are the ones of the prediction intervals of the regression.
+func linearForecast(values []*float64, ahead []int) (Forecast, error) {
+	var n, sumX, sumY float64
+	for x, v := range values {
+		if v != nil {
+			n++
+			sumX += float64(x)
+			sumY += *v
+		}
+	}
+	if n < 2 {
+		return Forecast{}, ErrForecastNotEnoughPoints
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy float64
+	for x, v := range values {
+		if v != nil {
+			sxx += (float64(x) - meanX) * (float64(x) - meanX)
+			sxy += (float64(x) - meanX) * (*v - meanY)
+		}
+	}
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	var squares float64
+	for x, v := range values {
+		if v != nil {
+			residual := *v - (intercept + slope*float64(x))
+			squares += residual * residual
+		}
+	}
+	var sigma float64
+	if n > 2 {
+		sigma = math.Sqrt(squares / (n - 2))
+	}
+
+	forecast := Forecast{Values: make([]float64, len(ahead)), Deviation: make([]float64, len(ahead))}
+	for i, k := range ahead {
+		x := float64(len(values) - 1 + k)
+		forecast.Values[i] = intercept + slope*x
+		forecast.Deviation[i] = sigma * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+	}
+	return forecast, nil
+}
+
+// holtWintersForecast fits the additive Holt-Winters model to the values, without seasonality when the period is
+// zero, and extends it at the given numbers of steps after the last value. The missing values are replaced by the
+// predictions of the model. The deviations grow with the steps from the one of the errors of the predictions of the
+// next value.
+func holtWintersForecast(values []*float64, ahead []int, alpha, beta, gamma float64, period int) (Forecast, error) {
+	seasonal := make([]float64, period)
+	var level, trend float64
+	var start int
+	if period == 0 {
+		first, second := -1, -1
+		for i, v := range values {
+			if v != nil {
+				if first < 0 {
+					first = i
+				} else {
+					second = i
+					break
+				}
+			}
+		}
+		if second < 0 {
+			return Forecast{}, ErrForecastNotEnoughPoints
+		}
+		level = *values[first]
+		trend = (*values[second] - *values[first]) / float64(second-first)
+		start = first + 1
+	} else {
+		if len(values) < 2*period {
+			return Forecast{}, ErrForecastNotEnoughPoints
+		}
+		firstMean, _, firstCount := meanDeviation(values[:period])
+		secondMean, _, secondCount := meanDeviation(values[period : 2*period])
+		if firstCount == 0 || secondCount == 0 {
+			return Forecast{}, ErrForecastNotEnoughPoints
+		}
+		level = firstMean
+		trend = (secondMean - firstMean) / float64(period)
+		for i := 0; i < period; i++ {
+			if values[i] != nil {
+				seasonal[i] = *values[i] - firstMean
+			}
+		}
+		start = period
+	}
+
+	season := func(t int) float64 {
+		if period == 0 {
+			return 0
+		}
+		return seasonal[t%period]
+	}
+
+	var squares float64
+	var errorsCount int
+	for t := start; t < len(values); t++ {
+		predicted := level + trend + season(t)
+		value := predicted
+		if values[t] != nil {
+			value = *values[t]
+			squares += (value - predicted) * (value - predicted)
+			errorsCount++
+		}
+
+		previousLevel := level
+		level = alpha*(value-season(t)) + (1-alpha)*(level+trend)
+		trend = beta*(level-previousLevel) + (1-beta)*trend
+		if period > 0 {
+			seasonal[t%period] = gamma*(value-level) + (1-gamma)*seasonal[t%period]
+		}
+	}
+	var sigma float64
+	if errorsCount > 0 {
+		sigma = math.Sqrt(squares / float64(errorsCount))
+	}
+
+	forecast := Forecast{Values: make([]float64, len(ahead)), Deviation: make([]float64, len(ahead))}
+	last := len(values) - 1
+	variance, j := 1.0, 1
+	for i, k := range ahead {
+		for ; j < k; j++ {
+			c := alpha * (1 + float64(j)*beta)
+			if period > 0 && j%period == 0 {
+				c += gamma
+			}
+			variance += c * c
+		}
+		forecast.Values[i] = level + float64(k)*trend + season(last+k)
+		forecast.Deviation[i] = sigma * math.Sqrt(variance)
+	}
+	return forecast, nil
+}
@@ -0,0 +1,81 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecast(t *testing.T) {
+	t.Run("linear should extend the line of the series", func(t *testing.T) {
+		forecast, err := anomalySeries(1, 2, 3, 4).Forecast(Forecaster{Method: ForecastLinear}, 2*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, forecast.Values, 2)
+		assert.Equal(t, []time.Time{time.Unix(240, 0), time.Unix(300, 0)}, forecast.Times)
+		assert.InDelta(t, 5, forecast.Values[0], 1e-9)
+		assert.InDelta(t, 6, forecast.Values[1], 1e-9)
+		assert.InDelta(t, 0, forecast.Deviation[1], 1e-9)
+	})
+
+	t.Run("linear should widen the deviation with the horizon", func(t *testing.T) {
+		forecast, err := anomalySeries(1, 3, 2, 4, 3, 5).Forecast(Forecaster{Method: ForecastLinear}, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Greater(t, forecast.Deviation[0], 0.0)
+		assert.Greater(t, forecast.Deviation[9], forecast.Deviation[0])
+	})
+
+	t.Run("holtwinters should extend the trend of the series", func(t *testing.T) {
+		values := make([]float64, 20)
+		for i := range values {
+			values[i] = 10 + 2*float64(i)
+		}
+		forecast, err := anomalySeries(values...).Forecast(Forecaster{Method: ForecastHoltWinters, Alpha: 0.5, Beta: 0.1, Gamma: 0.1}, 3*time.Minute)
+		require.NoError(t, err)
+		assert.InDelta(t, 50, forecast.Values[0], 1e-6)
+		assert.InDelta(t, 54, forecast.Values[2], 1e-6)
+	})
+
+	t.Run("holtwinters should extend the seasonality of the series", func(t *testing.T) {
+		// a season of 4 minutes repeated 6 times over a rising trend
+		values := make([]float64, 24)
+		for i := range values {
+			values[i] = 100 + float64(i) + 10*math.Sin(float64(i%4)*math.Pi/2)
+		}
+		forecast, err := anomalySeries(values...).Forecast(Forecaster{Method: ForecastHoltWinters, Alpha: 0.5, Beta: 0.1, Gamma: 0.5, Season: 4 * time.Minute}, 4*time.Minute)
+		require.NoError(t, err)
+		for i, v := range forecast.Values {
+			expected := 100 + float64(24+i) + 10*math.Sin(float64((24+i)%4)*math.Pi/2)
+			assert.InDelta(t, expected, v, 2, "point %d", i)
+		}
+	})
+
+	t.Run("should limit the number of points", func(t *testing.T) {
+		forecast, err := anomalySeries(1, 2, 3).Forecast(Forecaster{Method: ForecastLinear}, 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(forecast.Values), maxForecastPoints+1)
+		assert.Equal(t, time.Unix(120, 0).Add(30*24*time.Hour), forecast.Times[len(forecast.Times)-1])
+	})
+
+	t.Run("should need enough points", func(t *testing.T) {
+		_, err := anomalySeries(1).Forecast(Forecaster{Method: ForecastLinear}, time.Minute)
+		require.ErrorIs(t, err, ErrForecastNotEnoughPoints)
+
+		_, err = anomalySeries(1, 2, 3, 4, 5).Forecast(Forecaster{Method: ForecastHoltWinters, Alpha: 0.5, Beta: 0.1, Gamma: 0.1, Season: 4 * time.Minute}, time.Minute)
+		require.ErrorIs(t, err, ErrForecastNotEnoughPoints)
+	})
+
+	t.Run("should refuse invalid settings", func(t *testing.T) {
+		for _, f := range []Forecaster{
+			{Method: "prophet"},
+			{Method: ForecastHoltWinters, Alpha: 2, Beta: 0.1, Gamma: 0.1},
+			{Method: ForecastHoltWinters, Alpha: 0.5, Beta: 0.1, Gamma: 0.1, Season: time.Second},
+		} {
+			_, err := anomalySeries(1, 2, 3, 4).Forecast(f, time.Minute)
+			assert.Error(t, err, "forecaster %v", f)
+			assert.NotErrorIs(t, err, ErrForecastNotEnoughPoints)
+		}
+	})
+}
@@ -34,6 +34,15 @@ func IsDataSource(uid string) bool {
 	return uid == DatasourceUID || uid == OldDatasourceUID
 }
 
+// IsAnomalyQuery checks if the query of a request is an anomaly detection expression
+func IsAnomalyQuery(query *simplejson.Json) bool {
+	uid := query.Get("datasource").Get("uid").MustString()
+	if uid == "" {
+		uid = query.Get("datasource").MustString()
+	}
+	return IsDataSource(uid) && query.Get("type").MustString() == TypeAnomaly.String()
+}
+
 // Service is service representation for expression handling.
 type Service struct {
 	cfg            *setting.Cfg
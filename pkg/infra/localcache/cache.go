@@ -1,14 +1,28 @@
 package localcache
 
 import (
+	"sync/atomic"
 	"time"
 
 	gocache "github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
 )
 
 // CacheService cache any object in memory on the local instance.
 type CacheService struct {
 	*gocache.Cache
+
+	// name is set for the caches that can be inspected and purged, their hits and misses are counted
+	name   string
+	hits   uint64
+	misses uint64
+}
+
+// Stats are the hits and misses of a named cache since the server started
+type Stats struct {
+	Hits   uint64
+	Misses uint64
 }
 
 func ProvideService() *CacheService {
@@ -21,3 +35,42 @@ func New(defaultExpiration, cleanupInterval time.Duration) *CacheService {
 		Cache: gocache.New(defaultExpiration, cleanupInterval),
 	}
 }
+
+// NewNamed returns a new CacheService whose hits and misses are counted under the name. The keys of the entries
+// that belong to an organization must start with the ID of the organization followed by a dash, so that the entries
+// of an organization can be purged, see OrgKey.
+func NewNamed(name string, defaultExpiration, cleanupInterval time.Duration) *CacheService {
+	c := New(defaultExpiration, cleanupInterval)
+	c.name = name
+	return c
+}
+
+// Name returns the name of the cache, empty for the caches that are not named
+func (c *CacheService) Name() string {
+	return c.name
+}
+
+// Get returns an item from the cache, counting the hits and misses of named caches
+func (c *CacheService) Get(k string) (interface{}, bool) {
+	v, found := c.Cache.Get(k)
+	if c.name == "" {
+		return v, found
+	}
+
+	if found {
+		atomic.AddUint64(&c.hits, 1)
+		metrics.MLocalCacheRequests.WithLabelValues(c.name, "hit").Inc()
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+		metrics.MLocalCacheRequests.WithLabelValues(c.name, "miss").Inc()
+	}
+	return v, found
+}
+
+// Stats returns the hits and misses of the cache
+func (c *CacheService) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
@@ -0,0 +1,117 @@
+package localcache
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/glob"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// Registry holds the named caches of the server so that they can be inspected and purged by the administrators
+type Registry struct {
+	mu     sync.RWMutex
+	caches map[string]*CacheService
+}
+
+func ProvideRegistry() *Registry {
+	return &Registry{caches: map[string]*CacheService{}}
+}
+
+// Register adds a named cache to the registry, replacing the cache registered with the same name. It does nothing
+// when the registry is nil, for the services set up without a registry.
+func (r *Registry) Register(cache *CacheService) {
+	if r == nil || cache.name == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.caches[cache.name] = cache
+}
+
+// Get returns the cache registered with the name
+func (r *Registry) Get(name string) (*CacheService, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cache, ok := r.caches[name]
+	return cache, ok
+}
+
+// List returns the registered caches sorted by name
+func (r *Registry) List() []*CacheService {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	caches := make([]*CacheService, 0, len(r.caches))
+	for _, cache := range r.caches {
+		caches = append(caches, cache)
+	}
+	sort.Slice(caches, func(i, j int) bool { return caches[i].name < caches[j].name })
+	return caches
+}
+
+// OrgKey returns the key of an entry of a named cache that belongs to the organization
+func OrgKey(orgID int64, key string) string {
+	return fmt.Sprintf("%d-%s", orgID, key)
+}
+
+// Filter selects entries of a cache, by organization and by key pattern. The zero value selects all the entries.
+type Filter struct {
+	// OrgID selects the entries of the organization when it is not zero
+	OrgID int64
+	// Pattern selects the entries whose key matches the glob pattern when it is not empty
+	Pattern string
+}
+
+// Keys returns the sorted keys of the entries of the cache selected by the filter
+func (c *CacheService) Keys(filter Filter) ([]string, error) {
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	for key := range c.Items() {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Purge deletes the entries of the cache selected by the filter and returns how many were deleted
+func (c *CacheService) Purge(filter Filter) (int, error) {
+	keys, err := c.Keys(filter)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		c.Delete(key)
+	}
+	if c.name != "" {
+		metrics.MLocalCachePurgedItems.WithLabelValues(c.name).Add(float64(len(keys)))
+	}
+	return len(keys), nil
+}
+
+func (f Filter) matcher() (func(key string) bool, error) {
+	var pattern glob.Glob
+	if f.Pattern != "" {
+		var err error
+		if pattern, err = glob.Compile(f.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %w", f.Pattern, err)
+		}
+	}
+	orgPrefix := strconv.FormatInt(f.OrgID, 10) + "-"
+
+	return func(key string) bool {
+		if f.OrgID != 0 && !strings.HasPrefix(key, orgPrefix) {
+			return false
+		}
+		return pattern == nil || pattern.Match(key)
+	}, nil
+}
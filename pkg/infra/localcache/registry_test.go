@@ -0,0 +1,91 @@
+package localcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheService_KeysAndPurge(t *testing.T) {
+	newCache := func() *CacheService {
+		cache := NewNamed("test", time.Minute, time.Minute)
+		cache.SetDefault(OrgKey(1, "ds-id-1"), 1)
+		cache.SetDefault(OrgKey(1, "ds-uid-a"), 2)
+		cache.SetDefault(OrgKey(2, "ds-id-3"), 3)
+		cache.SetDefault(OrgKey(11, "ds-id-4"), 4)
+		return cache
+	}
+
+	t.Run("should select the keys by organization and pattern", func(t *testing.T) {
+		cache := newCache()
+
+		keys, err := cache.Keys(Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1-ds-id-1", "1-ds-uid-a", "11-ds-id-4", "2-ds-id-3"}, keys)
+
+		keys, err = cache.Keys(Filter{OrgID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1-ds-id-1", "1-ds-uid-a"}, keys)
+
+		keys, err = cache.Keys(Filter{Pattern: "*-ds-id-*"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1-ds-id-1", "11-ds-id-4", "2-ds-id-3"}, keys)
+
+		_, err = cache.Keys(Filter{Pattern: "[invalid"})
+		require.Error(t, err)
+	})
+
+	t.Run("should purge the selected entries", func(t *testing.T) {
+		cache := newCache()
+
+		purged, err := cache.Purge(Filter{OrgID: 1, Pattern: "*-ds-id-*"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Equal(t, 3, cache.ItemCount())
+
+		purged, err = cache.Purge(Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, purged)
+		assert.Equal(t, 0, cache.ItemCount())
+	})
+}
+
+func TestCacheService_Stats(t *testing.T) {
+	cache := NewNamed("test", time.Minute, time.Minute)
+	cache.SetDefault("1-key", "value")
+
+	_, found := cache.Get("1-key")
+	assert.True(t, found)
+	_, found = cache.Get("1-missing")
+	assert.False(t, found)
+	_, _ = cache.Get("1-key")
+
+	assert.Equal(t, Stats{Hits: 2, Misses: 1}, cache.Stats())
+	assert.Equal(t, Stats{}, New(time.Minute, time.Minute).Stats(), "hits and misses of unnamed caches should not be counted")
+}
+
+func TestRegistry(t *testing.T) {
+	registry := ProvideRegistry()
+	registry.Register(New(time.Minute, time.Minute))
+	registry.Register(NewNamed("query", time.Minute, time.Minute))
+	first := NewNamed("datasources", time.Minute, time.Minute)
+	registry.Register(first)
+	second := NewNamed("datasources", time.Minute, time.Minute)
+	registry.Register(second)
+
+	caches := registry.List()
+	require.Len(t, caches, 2)
+	assert.Equal(t, "datasources", caches[0].Name())
+	assert.Equal(t, "query", caches[1].Name())
+
+	cache, ok := registry.Get("datasources")
+	require.True(t, ok)
+	assert.Same(t, second, cache)
+	_, ok = registry.Get("missing")
+	assert.False(t, ok)
+
+	var nilRegistry *Registry
+	nilRegistry.Register(first)
+}
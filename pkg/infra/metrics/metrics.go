@@ -108,6 +108,36 @@ var (
 
 	// MAccessEvaluationCount is a metric gauge for total number of evaluation requests
 	MAccessEvaluationCount prometheus.Counter
+
+	// MAccessPermissionsCacheUsage is a metric counter for the lookups of user permissions in the cache, by hit or miss
+	MAccessPermissionsCacheUsage *prometheus.CounterVec
+
+	// MAccessPermissionsCacheInvalidations is a metric counter for the invalidations of the cached user permissions
+	MAccessPermissionsCacheInvalidations prometheus.Counter
+
+	// MLocalCacheRequests is a metric counter for the lookups in the named local caches, by cache and hit or miss
+	MLocalCacheRequests *prometheus.CounterVec
+
+	// MLocalCachePurgedItems is a metric counter for the items purged from the named local caches, by cache
+	MLocalCachePurgedItems *prometheus.CounterVec
+
+	// MOAuthTokenRefreshTotal is a metric counter for the background refreshes of the OAuth tokens, by provider and status
+	MOAuthTokenRefreshTotal *prometheus.CounterVec
+
+	// MJWTVerificationTotal is a metric counter for the verifications of the JWT tokens, by result
+	MJWTVerificationTotal *prometheus.CounterVec
+
+	// MLoginFailedAttemptsTotal is a metric counter for the failed logins counted by the brute force login protection
+	MLoginFailedAttemptsTotal prometheus.Counter
+
+	// MLoginLockoutTotal is a metric counter for the logins refused because of too many failed logins, by username or ip
+	MLoginLockoutTotal *prometheus.CounterVec
+
+	// MJWTKeySetCacheTotal is a metric counter for the lookups of the JWT key set in the cache, by hit or miss
+	MJWTKeySetCacheTotal *prometheus.CounterVec
+
+	// MJWTKeySetFetchTotal is a metric counter for the fetches of the JWT key set endpoint, by reason and status
+	MJWTKeySetFetchTotal *prometheus.CounterVec
 )
 
 // Timers
@@ -554,6 +584,66 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MAccessPermissionsCacheUsage = newCounterVecStartingAtZero(prometheus.CounterOpts{
+		Name:      "access_permissions_cache_usage",
+		Help:      "access control permissions cache hit/miss",
+		Namespace: ExporterName,
+	}, []string{"status"}, "hit", "miss")
+
+	MAccessPermissionsCacheInvalidations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "access_permissions_cache_invalidations_total",
+		Help:      "number of invalidations of the access control permissions cache",
+		Namespace: ExporterName,
+	})
+
+	MLocalCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "local_cache_requests_total",
+		Help:      "number of lookups in the local caches of the server, by hit or miss",
+		Namespace: ExporterName,
+	}, []string{"cache", "status"})
+
+	MLocalCachePurgedItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "local_cache_purged_items_total",
+		Help:      "number of items purged from the local caches of the server through the admin API",
+		Namespace: ExporterName,
+	}, []string{"cache"})
+
+	MOAuthTokenRefreshTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "oauth_token_refresh_total",
+		Help:      "number of OAuth tokens refreshed before they expire, by provider and success, failure or reauth when the user must log in again",
+		Namespace: ExporterName,
+	}, []string{"provider", "status"})
+
+	MLoginFailedAttemptsTotal = newCounterStartingAtZero(prometheus.CounterOpts{
+		Name:      "login_failed_attempts_total",
+		Help:      "number of failed logins counted by the brute force login protection",
+		Namespace: ExporterName,
+	})
+
+	MLoginLockoutTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "login_lockout_total",
+		Help:      "number of logins refused because the username or the ip address had too many failed logins",
+		Namespace: ExporterName,
+	}, []string{"key"})
+
+	MJWTVerificationTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "jwt_verification_total",
+		Help:      "number of JWT tokens verified, by success or the reason of the failure",
+		Namespace: ExporterName,
+	}, []string{"result"})
+
+	MJWTKeySetCacheTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "jwt_key_set_cache_total",
+		Help:      "number of lookups of the JWT key set in the cache, by hit or miss",
+		Namespace: ExporterName,
+	}, []string{"result"})
+
+	MJWTKeySetFetchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "jwt_key_set_fetch_total",
+		Help:      "number of fetches of the JWT key set endpoint, by reason (expired cache or unknown key) and status",
+		Namespace: ExporterName,
+	}, []string{"reason", "status"})
+
 	StatsTotalLibraryPanels = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name:      "stat_totals_library_panels",
 		Help:      "total amount of library panels in the database",
@@ -658,6 +748,16 @@ func initMetricVars() {
 		StatsTotalDashboardVersions,
 		StatsTotalAnnotations,
 		MAccessEvaluationCount,
+		MAccessPermissionsCacheUsage,
+		MAccessPermissionsCacheInvalidations,
+		MLocalCacheRequests,
+		MLocalCachePurgedItems,
+		MOAuthTokenRefreshTotal,
+		MLoginFailedAttemptsTotal,
+		MLoginLockoutTotal,
+		MJWTVerificationTotal,
+		MJWTKeySetCacheTotal,
+		MJWTKeySetFetchTotal,
 		StatsTotalLibraryPanels,
 		StatsTotalLibraryVariables,
 	)
@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -13,24 +16,41 @@ var (
 	loginAttemptsWindow           = time.Minute * 5
 )
 
+// Lockout is the state of the brute force login protection of a username or an IP address
+type Lockout struct {
+	Locked bool
+	// LockedUntil is when the lockout ends, set when locked
+	LockedUntil *time.Time
+	// FailedAttempts is the number of failed logins within the window
+	FailedAttempts int
+}
+
 var validateLoginAttempts = func(ctx context.Context, query *models.LoginUserQuery) error {
 	if query.Cfg.DisableBruteForceLoginProtection {
 		return nil
 	}
 
-	loginAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
-		Username: query.Username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
-	}
-
-	if err := bus.Dispatch(ctx, &loginAttemptCountQuery); err != nil {
+	lockout, err := GetLockout(ctx, query.Cfg, query.Username)
+	if err != nil {
 		return err
 	}
-
-	if loginAttemptCountQuery.Result >= maxInvalidLoginAttempts {
+	if lockout.Locked {
+		metrics.MLoginLockoutTotal.WithLabelValues("username").Inc()
 		return ErrTooManyLoginAttempts
 	}
 
+	if query.Cfg.BruteForceLoginMaxAttemptsPerIP > 0 && query.IpAddress != "" {
+		lockout, err := getLockout(ctx, query.Cfg, models.GetLoginAttemptsQuery{IpAddress: loginAttemptIP(query.IpAddress)},
+			query.Cfg.BruteForceLoginMaxAttemptsPerIP)
+		if err != nil {
+			return err
+		}
+		if lockout.Locked {
+			metrics.MLoginLockoutTotal.WithLabelValues("ip").Inc()
+			return ErrTooManyLoginAttempts
+		}
+	}
+
 	return nil
 }
 
@@ -41,8 +61,91 @@ var saveInvalidLoginAttempt = func(ctx context.Context, query *models.LoginUserQ
 
 	loginAttemptCommand := models.CreateLoginAttemptCommand{
 		Username:  query.Username,
-		IpAddress: query.IpAddress,
+		IpAddress: loginAttemptIP(query.IpAddress),
+	}
+
+	if err := bus.Dispatch(ctx, &loginAttemptCommand); err != nil {
+		return err
+	}
+	metrics.MLoginFailedAttemptsTotal.Inc()
+	return nil
+}
+
+// SaveInvalidLoginAttempt counts a failed login which the authentication did not count, such as a login with an
+// invalid two-factor authentication code, towards the lockout of the username
+func SaveInvalidLoginAttempt(ctx context.Context, query *models.LoginUserQuery) error {
+	return saveInvalidLoginAttempt(ctx, query)
+}
+
+// GetLockout returns the state of the brute force login protection of the username
+func GetLockout(ctx context.Context, cfg *setting.Cfg, username string) (*Lockout, error) {
+	maxAttempts := cfg.BruteForceLoginMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = maxInvalidLoginAttempts
 	}
+	return getLockout(ctx, cfg, models.GetLoginAttemptsQuery{Username: username}, maxAttempts)
+}
+
+// Unlock deletes the failed logins of the usernames, so that they are no longer locked out
+func Unlock(ctx context.Context, usernames ...string) error {
+	return bus.Dispatch(ctx, &models.DeleteLoginAttemptsCommand{Usernames: usernames})
+}
+
+func getLockout(ctx context.Context, cfg *setting.Cfg, query models.GetLoginAttemptsQuery, maxAttempts int64) (*Lockout, error) {
+	window := cfg.BruteForceLoginWindow
+	if window <= 0 {
+		window = loginAttemptsWindow
+	}
+
+	now := time.Now()
+	query.Since = now.Add(-window - cfg.BruteForceLoginCooldown)
+	if err := bus.Dispatch(ctx, &query); err != nil {
+		return nil, err
+	}
+
+	lockout := &Lockout{}
+	for _, attempt := range query.Result {
+		if attempt.Created >= now.Add(-window).Unix() {
+			lockout.FailedAttempts++
+		}
+	}
+	if lockedUntil := lockedUntil(query.Result, int(maxAttempts), window, cfg.BruteForceLoginCooldown); lockedUntil.After(now) {
+		lockout.Locked = true
+		lockout.LockedUntil = &lockedUntil
+	}
+	return lockout, nil
+}
+
+// lockedUntil returns when the lockout of the failed logins, the oldest first, ends. Each run of maxAttempts failed
+// logins within the window locks the login out for the cooldown after the last of them or, without cooldown, until
+// the first of them leaves the window.
+func lockedUntil(attempts []*models.LoginAttempt, maxAttempts int, window, cooldown time.Duration) time.Time {
+	var until time.Time
+	if maxAttempts <= 0 {
+		return until
+	}
+	for i := maxAttempts - 1; i < len(attempts); i++ {
+		first, last := time.Unix(attempts[i-maxAttempts+1].Created, 0), time.Unix(attempts[i].Created, 0)
+		if last.Sub(first) > window {
+			continue
+		}
+		end := first.Add(window)
+		if cooldown > 0 {
+			end = last.Add(cooldown)
+		}
+		if end.After(until) {
+			until = end
+		}
+	}
+	return until
+}
 
-	return bus.Dispatch(ctx, &loginAttemptCommand)
+// loginAttemptIP returns the IP address of the client address, without the port, so that the failed logins of an IP
+// address can be counted
+func loginAttemptIP(addr string) string {
+	ip, err := network.GetIPFromAddress(addr)
+	if err != nil {
+		return addr
+	}
+	return ip.String()
 }
@@ -3,6 +3,7 @@ package login
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -68,6 +69,60 @@ func TestValidateLoginAttempts(t *testing.T) {
 	}
 }
 
+func TestValidateLoginAttemptsCooldown(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	now := time.Now()
+	cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+	cfg.BruteForceLoginMaxAttempts = 3
+	cfg.BruteForceLoginWindow = time.Minute
+	cfg.BruteForceLoginCooldown = time.Hour
+
+	t.Run("Should lock the user out for the cooldown after the last failed login", func(t *testing.T) {
+		withLoginAttemptsAt(t, []time.Time{now.Add(-50 * time.Minute), now.Add(-50*time.Minute + 10*time.Second),
+			now.Add(-50*time.Minute + 20*time.Second)}, nil)
+
+		err := validateLoginAttempts(context.Background(), &models.LoginUserQuery{Username: "user", Cfg: cfg})
+		require.Equal(t, ErrTooManyLoginAttempts, err)
+
+		lockout, err := GetLockout(context.Background(), cfg, "user")
+		require.NoError(t, err)
+		require.True(t, lockout.Locked)
+		require.Equal(t, 0, lockout.FailedAttempts)
+		require.Equal(t, now.Add(10*time.Minute+20*time.Second).Unix(), lockout.LockedUntil.Unix())
+	})
+
+	t.Run("Should not lock the user out when the failed logins are spread over more than the window", func(t *testing.T) {
+		withLoginAttemptsAt(t, []time.Time{now.Add(-3 * time.Minute), now.Add(-2 * time.Minute), now.Add(-time.Minute)}, nil)
+
+		err := validateLoginAttempts(context.Background(), &models.LoginUserQuery{Username: "user", Cfg: cfg})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should not lock the user out once the cooldown ended", func(t *testing.T) {
+		withLoginAttemptsAt(t, []time.Time{now.Add(-62 * time.Minute), now.Add(-61 * time.Minute), now.Add(-61 * time.Minute)}, nil)
+
+		lockout, err := GetLockout(context.Background(), cfg, "user")
+		require.NoError(t, err)
+		require.False(t, lockout.Locked)
+		require.Nil(t, lockout.LockedUntil)
+	})
+}
+
+func TestValidateLoginAttemptsPerIP(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	now := time.Now()
+	cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+	withLoginAttemptsAt(t, nil, []time.Time{now, now, now})
+
+	query := &models.LoginUserQuery{Username: "user", IpAddress: "192.168.1.1:56433", Cfg: cfg}
+	err := validateLoginAttempts(context.Background(), query)
+	require.NoError(t, err)
+
+	cfg.BruteForceLoginMaxAttemptsPerIP = 3
+	err = validateLoginAttempts(context.Background(), query)
+	require.Equal(t, ErrTooManyLoginAttempts, err)
+}
+
 func TestSaveInvalidLoginAttempt(t *testing.T) {
 	t.Run("When brute force protection enabled", func(t *testing.T) {
 		t.Cleanup(func() { bus.ClearBusHandlers() })
@@ -88,7 +143,7 @@ func TestSaveInvalidLoginAttempt(t *testing.T) {
 
 		require.NotNil(t, createLoginAttemptCmd)
 		assert.Equal(t, "user", createLoginAttemptCmd.Username)
-		assert.Equal(t, "192.168.1.1:56433", createLoginAttemptCmd.IpAddress)
+		assert.Equal(t, "192.168.1.1", createLoginAttemptCmd.IpAddress)
 	})
 
 	t.Run("When brute force protection disabled", func(t *testing.T) {
@@ -128,8 +183,28 @@ func cfgWithBruteForceLoginProtectionEnabled(t *testing.T) *setting.Cfg {
 
 func withLoginAttempts(t *testing.T, loginAttempts int64) {
 	t.Helper()
-	bus.AddHandler("test", func(ctx context.Context, query *models.GetUserLoginAttemptCountQuery) error {
-		query.Result = loginAttempts
+	now := time.Now()
+	attempts := make([]time.Time, loginAttempts)
+	for i := range attempts {
+		attempts[i] = now
+	}
+	withLoginAttemptsAt(t, attempts, nil)
+}
+
+// withLoginAttemptsAt mocks the login attempts of the username and of the IP address at the given times
+func withLoginAttemptsAt(t *testing.T, byUsername []time.Time, byIP []time.Time) {
+	t.Helper()
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetLoginAttemptsQuery) error {
+		times := byUsername
+		if query.Username == "" {
+			times = byIP
+		}
+		query.Result = make([]*models.LoginAttempt, 0)
+		for _, at := range times {
+			if !at.Before(query.Since.Truncate(time.Second)) {
+				query.Result = append(query.Result, &models.LoginAttempt{Username: query.Username, IpAddress: query.IpAddress, Created: at.Unix()})
+			}
+		}
 		return nil
 	})
 }
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"context"
 
@@ -50,6 +51,17 @@ type OAuthInfo struct {
 	TlsClientCa            string
 	TlsSkipVerify          bool
 	UsePKCE                bool
+	TokenRefreshJitter     time.Duration
+	EndSessionUrl          string
+	RevocationUrl          string
+	BackchannelLogout      bool
+	JwkSetUrl              string
+}
+
+// SingleLogoutEnabled returns whether the end of the Grafana sessions is propagated to the provider, or the provider
+// can end the Grafana sessions
+func (info *OAuthInfo) SingleLogoutEnabled() bool {
+	return info.EndSessionUrl != "" || info.RevocationUrl != "" || info.BackchannelLogout
 }
 
 func ProvideService(cfg *setting.Cfg) *SocialService {
@@ -86,6 +98,11 @@ func ProvideService(cfg *setting.Cfg) *SocialService {
 			TlsClientCa:          sec.Key("tls_client_ca").String(),
 			TlsSkipVerify:        sec.Key("tls_skip_verify_insecure").MustBool(),
 			UsePKCE:              sec.Key("use_pkce").MustBool(),
+			TokenRefreshJitter:   sec.Key("token_refresh_jitter").MustDuration(30 * time.Second),
+			EndSessionUrl:        sec.Key("end_session_url").String(),
+			RevocationUrl:        sec.Key("revocation_url").String(),
+			BackchannelLogout:    sec.Key("backchannel_logout_enabled").MustBool(),
+			JwkSetUrl:            sec.Key("jwk_set_url").String(),
 		}
 
 		// when empty_scopes parameter exists and is true, overwrite scope with empty value
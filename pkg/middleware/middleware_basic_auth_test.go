@@ -9,6 +9,8 @@ import (
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/stretchr/testify/assert"
@@ -32,6 +34,10 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 			query.Result = &models.ApiKey{OrgId: orgID, Role: models.ROLE_EDITOR, Key: keyhash}
 			return nil
 		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{}
+			return nil
+		})
 
 		authHeader := util.GetBasicAuthHeader("api_key", "eyJrIjoidjVuQXdwTWFmRlA2em5hUzR1cmhkV0RMUzU1MTFNNDIiLCJuIjoiYXNkIiwiaWQiOjF9")
 		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
@@ -74,6 +80,25 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 		assert.Equal(t, id, sc.context.UserId)
 	}, configure)
 
+	middlewareScenario(t, "Should refuse basic auth for users required to use two-factor authentication", func(t *testing.T, sc *scenarioContext) {
+		user, err := sc.sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "totp", Password: "MyPass"})
+		require.NoError(t, err)
+		sc.cfg.TOTPEnabled = true
+		sc.cfg.TOTPEnforcedOrgIDs = []int64{user.OrgId}
+		sc.contextHandler.TwoFactor = twofactor.ProvideService(sc.cfg, sc.sqlStore, fakes.NewFakeSecretsService())
+
+		bus.AddHandler("grafana-auth", func(ctx context.Context, query *models.LoginUserQuery) error {
+			query.User = user
+			return nil
+		})
+
+		authHeader := util.GetBasicAuthHeader("totp", "MyPass")
+		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, "Basic auth is not allowed for users with two-factor authentication", sc.respJson["message"])
+	}, configure)
+
 	middlewareScenario(t, "Auth sequence", func(t *testing.T, sc *scenarioContext) {
 		const password = "MyPass"
 		const salt = "Salt"
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestMiddlewareClientCertAuth(t *testing.T) {
+	const id int64 = 12
+	const orgID int64 = 2
+
+	configure := func(cfg *setting.Cfg) {
+		cfg.ClientCertAuthEnabled = true
+		cfg.ClientCertAuthLoginAttribute = "common_name"
+		cfg.ClientCertAuthHeaderName = "X-Client-Cert"
+		cfg.ClientCertAuthAllowedProxies = []string{"10.0.0.1"}
+	}
+
+	configureAutoSignUp := func(cfg *setting.Cfg) {
+		cfg.ClientCertAuthAutoSignUp = true
+	}
+
+	cert := newTestClientCertificate(t, "alice")
+
+	middlewareScenario(t, "Valid certificate of an existing user", func(t *testing.T, sc *scenarioContext) {
+		bus.AddHandler("get-sign-user", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: id, OrgId: orgID, Login: query.Login}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withClientCertHeader("10.0.0.1:4000", cert).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, id, sc.context.UserId)
+		assert.Equal(t, "alice", sc.context.Login)
+	}, configure)
+
+	middlewareScenario(t, "Valid certificate of an unknown user", func(t *testing.T, sc *scenarioContext) {
+		bus.AddHandler("get-sign-user", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			return models.ErrUserNotFound
+		})
+
+		sc.fakeReq("GET", "/").withClientCertHeader("10.0.0.1:4000", cert).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidClientCert, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "Valid certificate of an unknown user with auto sign up", func(t *testing.T, sc *scenarioContext) {
+		var upserted *models.ExternalUserInfo
+		bus.AddHandler("upsert-user", func(ctx context.Context, cmd *models.UpsertUserCommand) error {
+			upserted = cmd.ExternalUser
+			cmd.Result = &models.User{Id: id, Login: cmd.ExternalUser.Login}
+			return nil
+		})
+		bus.AddHandler("get-sign-user", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			if query.UserId != id {
+				return models.ErrUserNotFound
+			}
+			query.Result = &models.SignedInUser{UserId: id, OrgId: orgID, Login: "alice"}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withClientCertHeader("10.0.0.1:4000", cert).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, id, sc.context.UserId)
+		require.NotNil(t, upserted)
+		assert.Equal(t, models.AuthModuleClientCert, upserted.AuthModule)
+		assert.Equal(t, "CN=alice", upserted.AuthId)
+	}, configure, configureAutoSignUp)
+
+	middlewareScenario(t, "Certificate forwarded by another address", func(t *testing.T, sc *scenarioContext) {
+		sc.fakeReq("GET", "/").withClientCertHeader("10.0.0.2:4000", cert).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidClientCert, sc.respJson["message"])
+	}, configure)
+}
+
+func (sc *scenarioContext) withClientCertHeader(remoteAddr string, cert *x509.Certificate) *scenarioContext {
+	sc.req.RemoteAddr = remoteAddr
+	sc.req.Header.Set("X-Client-Cert", base64.StdEncoding.EncodeToString(cert.Raw))
+	return sc
+}
+
+func newTestClientCertificate(t *testing.T, commonName string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
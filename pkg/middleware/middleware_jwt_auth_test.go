@@ -123,4 +123,42 @@ func TestMiddlewareJWTAuth(t *testing.T) {
 		assert.Equal(t, 401, sc.resp.Code)
 		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
 	}, configure, configureUsernameClaim)
+	configureClaimMapping := func(cfg *setting.Cfg) {
+		cfg.JWTAuthClaimMapping = `[{"org": "{{ .tenant }}", "role": "Editor"}]`
+	}
+
+	middlewareScenario(t, "Valid token with claim mapping", func(t *testing.T, sc *scenarioContext) {
+		var syncedClaims models.JWTClaims
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{"foo-username": "vladimir", "tenant": "main"}, nil
+		}
+		sc.jwtAuthService.SyncUserProvider = func(ctx *models.ReqContext, claims models.JWTClaims) (int64, error) {
+			syncedClaims = claims
+			return id, nil
+		}
+		bus.AddHandler("get-sign-user", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{
+				UserId: query.UserId,
+				OrgId:  orgID,
+				Login:  "vladimir",
+			}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, "main", syncedClaims["tenant"])
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, id, sc.context.UserId)
+	}, configure, configureUsernameClaim, configureClaimMapping)
+
+	middlewareScenario(t, "Valid token of a user that cannot be synced", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{"foo-username": "vladimir"}, nil
+		}
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure, configureUsernameClaim, configureClaimMapping)
 }
@@ -21,7 +21,9 @@ import (
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
 	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/clientcert"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -153,6 +155,10 @@ func TestMiddlewareContext(t *testing.T) {
 			query.Result = &models.ApiKey{OrgId: orgID, Role: models.ROLE_EDITOR, Key: keyhash}
 			return nil
 		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{}
+			return nil
+		})
 
 		sc.fakeReq("GET", "/").withValidApiKey().exec()
 
@@ -163,6 +169,128 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Equal(t, models.ROLE_EDITOR, sc.context.OrgRole)
 	})
 
+	middlewareScenario(t, "Valid permission-scoped API key", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 3, OrgId: 12, Role: models.ROLE_ADMIN, Key: keyhash}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{{ApiKeyId: 3, Action: "dashboards:read", Scope: "dashboards:*"}}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, int64(3), sc.context.ApiKeyId)
+		assert.Equal(t, models.ROLE_VIEWER, sc.context.OrgRole, "the role should be downgraded to Viewer")
+	})
+
+	middlewareScenario(t, "Valid permission-scoped service account token", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 3, OrgId: 12, Role: models.ROLE_ADMIN, Key: keyhash, ServiceAccountId: 5}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_ADMIN}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{{ApiKeyId: 3, Action: "dashboards:read", Scope: "dashboards:*"}}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, int64(5), sc.context.UserId)
+		assert.Equal(t, models.ROLE_VIEWER, sc.context.OrgRole, "the role should be downgraded to Viewer")
+	})
+
+	middlewareScenario(t, "Valid personal access token", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 3, OrgId: 12, Role: models.ROLE_EDITOR, Key: keyhash, UserId: 7}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetUserByIdQuery) error {
+			query.Result = &models.User{Id: query.Id}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_VIEWER}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, int64(7), sc.context.UserId)
+		assert.Equal(t, int64(12), sc.context.OrgId)
+		assert.Equal(t, int64(3), sc.context.ApiKeyId)
+		assert.Equal(t, models.ROLE_VIEWER, sc.context.OrgRole, "the role of the user should apply, not the role of the token")
+	})
+
+	middlewareScenario(t, "Valid permission-scoped personal access token", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 3, OrgId: 12, Role: models.ROLE_VIEWER, Key: keyhash, UserId: 7}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetUserByIdQuery) error {
+			query.Result = &models.User{Id: query.Id}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_ADMIN, IsGrafanaAdmin: true}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{{ApiKeyId: 3, Action: "dashboards:read", Scope: "dashboards:*"}}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, int64(7), sc.context.UserId)
+		assert.Equal(t, models.ROLE_VIEWER, sc.context.OrgRole, "the role should be downgraded to Viewer")
+		assert.False(t, sc.context.IsGrafanaAdmin)
+	})
+
+	middlewareScenario(t, "Valid personal access token, but the user is disabled", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 3, OrgId: 12, Role: models.ROLE_EDITOR, Key: keyhash, UserId: 7}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetUserByIdQuery) error {
+			query.Result = &models.User{Id: query.Id, IsDisabled: true}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+	})
+
 	middlewareScenario(t, "Valid API key, but does not match DB hash", func(t *testing.T, sc *scenarioContext) {
 		const keyhash = "Something_not_matching"
 
@@ -177,6 +305,58 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Equal(t, contexthandler.InvalidAPIKey, sc.respJson["message"])
 	})
 
+	middlewareScenario(t, "Valid API key, rotated during the grace period", func(t *testing.T, sc *scenarioContext) {
+		sc.contextHandler.GetTime = fakeGetTime()
+
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 1, OrgId: 12, Role: models.ROLE_EDITOR, Key: "rotated"}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyRotationsQuery) error {
+			query.Result = []*models.ApiKeyRotation{
+				{ApiKeyId: 1, PreviousKey: "other", PreviousKeyExpires: sc.contextHandler.GetTime().Add(time.Hour)},
+				{ApiKeyId: 1, PreviousKey: keyhash, PreviousKeyExpires: sc.contextHandler.GetTime().Add(time.Hour)},
+			}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+			query.Result = []*models.ApiKeyPermission{}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, int64(12), sc.context.OrgId)
+	})
+
+	middlewareScenario(t, "Valid API key, rotated after the grace period", func(t *testing.T, sc *scenarioContext) {
+		sc.contextHandler.GetTime = fakeGetTime()
+
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{Id: 1, OrgId: 12, Role: models.ROLE_EDITOR, Key: "rotated"}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyRotationsQuery) error {
+			query.Result = []*models.ApiKeyRotation{
+				{ApiKeyId: 1, PreviousKey: keyhash, PreviousKeyExpires: sc.contextHandler.GetTime().Add(-time.Second)},
+			}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidAPIKey, sc.respJson["message"])
+	})
+
 	middlewareScenario(t, "Valid API key, but expired", func(t *testing.T, sc *scenarioContext) {
 		sc.contextHandler.GetTime = fakeGetTime()
 
@@ -330,6 +510,36 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Nil(t, sc.context.UserToken)
 	})
 
+	middlewareScenario(t, "Request sent by the API explorer", func(t *testing.T, sc *scenarioContext) {
+		apiExplorer, err := apiexplorer.ProvideService(sc.cfg)
+		require.NoError(t, err)
+		sc.contextHandler.APIExplorer = apiExplorer
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{OrgId: query.OrgId, UserId: query.UserId}
+			return nil
+		})
+		sc.m.Get("/api/alertmanager/:recipient/api/v2/status", sc.defaultHandler)
+
+		user := &models.SignedInUser{OrgId: 2, UserId: 12}
+		result, err := apiExplorer.Execute(context.Background(), sc.m, user, &apiexplorer.ExecuteCommand{
+			Method: "GET", Path: "/api/alertmanager/grafana/api/v2/status",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, result.Status)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, user.UserId, sc.context.UserId)
+		assert.Equal(t, user.OrgId, sc.context.OrgId)
+
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set(apiexplorer.TokenHeader, "invalid")
+		sc.exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidAPIExplorerToken, sc.respJson["message"])
+	}, func(cfg *setting.Cfg) {
+		cfg.APIExplorer = setting.APIExplorerSettings{Enabled: true, Timeout: time.Second, MaxBodySize: 1 << 20}
+	})
+
 	middlewareScenario(t, "When anonymous access is enabled", func(t *testing.T, sc *scenarioContext) {
 		org, err := sc.sqlStore.CreateOrgWithMember(sc.cfg.AnonymousOrgName, 1)
 		require.NoError(t, err)
@@ -346,6 +556,22 @@ func TestMiddlewareContext(t *testing.T) {
 		cfg.AnonymousOrgRole = string(models.ROLE_EDITOR)
 	})
 
+	middlewareScenario(t, "When anonymous access is restricted", func(t *testing.T, sc *scenarioContext) {
+		_, err := sc.sqlStore.CreateOrgWithMember(sc.cfg.AnonymousOrgName, 1)
+		require.NoError(t, err)
+		sc.contextHandler.AnonymousAccess = fakeAnonymousAccess{allowed: "/d/allowed"}
+
+		sc.fakeReq("GET", "/").exec()
+
+		assert.Equal(t, int64(0), sc.context.OrgId)
+		assert.False(t, sc.context.AllowAnonymous)
+		assert.False(t, sc.context.IsSignedIn)
+	}, func(cfg *setting.Cfg) {
+		cfg.AnonymousEnabled = true
+		cfg.AnonymousOrgName = "test"
+		cfg.AnonymousOrgRole = string(models.ROLE_VIEWER)
+	})
+
 	t.Run("auth_proxy", func(t *testing.T) {
 		const userID int64 = 33
 		const orgID int64 = 4
@@ -731,7 +957,17 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg) *contexthandler.ContextHa
 	userAuthTokenSvc := auth.NewFakeUserAuthTokenService()
 	renderSvc := &fakeRenderService{}
 	authJWTSvc := models.NewFakeJWTService()
-	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore)
+	clientCertSvc, err := clientcert.ProvideService(cfg)
+	require.NoError(t, err)
+	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore, clientCertSvc, nil, nil, nil, nil)
+}
+
+type fakeAnonymousAccess struct {
+	allowed string
+}
+
+func (f fakeAnonymousAccess) IsAllowed(_ *http.Request, _ int64, path string) (bool, error) {
+	return path == f.allowed, nil
 }
 
 type fakeRenderService struct {
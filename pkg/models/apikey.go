@@ -10,8 +10,12 @@ var (
 	ErrInvalidApiKey           = errors.New("invalid API key")
 	ErrInvalidApiKeyExpiration = errors.New("negative value for SecondsToLive")
 	ErrDuplicateApiKey         = errors.New("API key, organization ID and name must be unique")
+	ErrUserAccessTokenNotFound = errors.New("personal access token not found")
 )
 
+// FederatedTokenNamePrefix starts the names of the service account tokens exchanged for workload identity tokens
+const FederatedTokenNamePrefix = "federated-"
+
 type ApiKey struct {
 	Id               int64
 	OrgId            int64
@@ -22,27 +26,85 @@ type ApiKey struct {
 	Updated          time.Time
 	Expires          *int64
 	ServiceAccountId int64
+	// UserId is the owner of a personal access token, the requests authenticated with it are made as the user
+	UserId     int64
+	LastUsedAt *time.Time
+	LastUsedIp string
+}
+
+// ApiKeyRotation records the rotation of an API key. The previous key remains valid until
+// PreviousKeyExpires, so that clients can be updated without downtime.
+type ApiKeyRotation struct {
+	Id                 int64
+	OrgId              int64
+	ApiKeyId           int64
+	PreviousKey        string
+	PreviousKeyExpires time.Time
+	RotatedBy          int64
+	RotatedAt          time.Time
+}
+
+// ApiKeyPermission is a permission of a permission-scoped API key. The requests authenticated with
+// the key are only granted its permissions, not the ones of the role of the key.
+type ApiKeyPermission struct {
+	Id       int64
+	OrgId    int64
+	ApiKeyId int64
+	Action   string
+	Scope    string
 }
 
 // ---------------------
 // COMMANDS
 type AddApiKeyCommand struct {
-	Name                    string   `json:"name" binding:"Required"`
-	Role                    RoleType `json:"role" binding:"Required"`
-	OrgId                   int64    `json:"-"`
-	Key                     string   `json:"-"`
-	SecondsToLive           int64    `json:"secondsToLive"`
-	ServiceAccountId        int64    `json:"serviceAccount"`
-	CreateNewServiceAccount bool     `json:"createServiceAccount"`
+	Name                    string                `json:"name" binding:"Required"`
+	Role                    RoleType              `json:"role"`
+	OrgId                   int64                 `json:"-"`
+	Key                     string                `json:"-"`
+	SecondsToLive           int64                 `json:"secondsToLive"`
+	ServiceAccountId        int64                 `json:"serviceAccount"`
+	CreateNewServiceAccount bool                  `json:"createServiceAccount"`
+	Permissions             []ApiKeyPermissionDTO `json:"permissions"`
+	UserId                  int64                 `json:"-"`
 
 	Result *ApiKey `json:"-"`
 }
 
+// RotateApiKeyCommand replaces the key of an API key. The previous key remains valid for the
+// grace period. When SecondsToLive is set, the expiration of the API key is reset.
+type RotateApiKeyCommand struct {
+	Id            int64
+	OrgId         int64
+	Key           string
+	SecondsToLive int64
+	GracePeriod   time.Duration
+	RotatedBy     int64
+
+	Result   *ApiKey
+	Rotation *ApiKeyRotation
+}
+
 type DeleteApiKeyCommand struct {
 	Id    int64 `json:"id"`
 	OrgId int64 `json:"-"`
 }
 
+// DeleteExpiredFederatedTokensCommand deletes the service account tokens exchanged for workload identity tokens that
+// expired before a time
+type DeleteExpiredFederatedTokensCommand struct {
+	ExpiredBefore time.Time
+	// BatchSize is the maximum number of tokens deleted by a transaction, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
+	DeletedRows int64
+}
+
+// DeleteUserAccessTokenCommand revokes a personal access token of the user
+type DeleteUserAccessTokenCommand struct {
+	Id     int64
+	UserId int64
+}
+
 // ----------------------
 // QUERIES
 
@@ -52,6 +114,14 @@ type GetApiKeysQuery struct {
 	Result         []*ApiKey
 }
 
+// GetUserAccessTokensQuery returns the personal access tokens of the user in the organization
+type GetUserAccessTokensQuery struct {
+	UserId         int64
+	OrgId          int64
+	IncludeExpired bool
+	Result         []*ApiKey
+}
+
 type GetApiKeyByNameQuery struct {
 	KeyName string
 	OrgId   int64
@@ -63,12 +133,48 @@ type GetApiKeyByIdQuery struct {
 	Result   *ApiKey
 }
 
+// GetApiKeyPermissionsQuery returns the permissions of the API keys of the organization
+type GetApiKeyPermissionsQuery struct {
+	OrgId     int64
+	ApiKeyIds []int64
+	Result    []*ApiKeyPermission
+}
+
+type GetApiKeyRotationsQuery struct {
+	ApiKeyId int64
+	OrgId    int64
+	Result   []*ApiKeyRotation
+}
+
 // ------------------------
 // DTO & Projections
 
 type ApiKeyDTO struct {
-	Id         int64      `json:"id"`
-	Name       string     `json:"name"`
-	Role       RoleType   `json:"role"`
-	Expiration *time.Time `json:"expiration,omitempty"`
+	Id          int64                 `json:"id"`
+	Name        string                `json:"name"`
+	Role        RoleType              `json:"role"`
+	Expiration  *time.Time            `json:"expiration,omitempty"`
+	LastUsedAt  *time.Time            `json:"lastUsedAt,omitempty"`
+	Permissions []ApiKeyPermissionDTO `json:"permissions,omitempty"`
+}
+
+type UserAccessTokenDTO struct {
+	Id          int64                 `json:"id"`
+	Name        string                `json:"name"`
+	Created     time.Time             `json:"created"`
+	Expiration  *time.Time            `json:"expiration,omitempty"`
+	LastUsedAt  *time.Time            `json:"lastUsedAt,omitempty"`
+	LastUsedIp  string                `json:"lastUsedIp,omitempty"`
+	Permissions []ApiKeyPermissionDTO `json:"permissions,omitempty"`
+}
+
+type ApiKeyPermissionDTO struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+}
+
+type ApiKeyRotationDTO struct {
+	RotatedAt             time.Time `json:"rotatedAt"`
+	RotatedBy             int64     `json:"rotatedBy"`
+	PreviousKeyExpiration time.Time `json:"previousKeyExpiration"`
 }
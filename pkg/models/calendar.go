@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarOccurrence is a single occurrence of a calendar event, recurring events having one
+// occurrence per repetition.
+type CalendarOccurrence struct {
+	CalendarUid string    `json:"calendarUid"`
+	EventUid    string    `json:"eventUid"`
+	Title       string    `json:"title"`
+	Tags        []string  `json:"tags"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"allDay"`
+}
+
+// GetCalendarOccurrencesQuery returns the occurrences of the events of the calendars that overlap
+// the From - To range. From and To can be equal to get the events active at a point in time.
+type GetCalendarOccurrencesQuery struct {
+	OrgId        int64
+	CalendarUids []string
+	From         time.Time
+	To           time.Time
+
+	Result []*CalendarOccurrence
+}
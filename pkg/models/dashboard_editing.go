@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDashboardEditingLocked is returned when saving a dashboard while another
+// user holds its exclusive editing lock
+var ErrDashboardEditingLocked = errors.New("dashboard is being edited by another user")
+
+// DashboardEditor is a user editing a dashboard in one browser session
+type DashboardEditor struct {
+	User      *UserDisplayDTO `json:"user"`
+	SessionID string          `json:"sessionId"`
+	Since     time.Time       `json:"since"`
+	LastSeen  time.Time       `json:"lastSeen"`
+}
+
+// DashboardEditingState lists the users editing a dashboard
+type DashboardEditingState struct {
+	UID     string             `json:"uid"`
+	Editors []*DashboardEditor `json:"editors"`
+	// LockedBy is the editor holding the exclusive editing lock, only set in
+	// exclusive editing mode
+	LockedBy *DashboardEditor `json:"lockedBy,omitempty"`
+}
+
+// LockedByOther returns true when the exclusive editing lock is held by
+// another user
+func (s *DashboardEditingState) LockedByOther(userID int64) bool {
+	return s.LockedBy != nil && s.LockedBy.User != nil && s.LockedBy.User.Id != userID
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var ErrDashboardEventNotFound = errors.New("dashboard event not found")
+
+// Types of the dashboard events
+const (
+	DashboardEventCreated                = "dashboard-created"
+	DashboardEventPropertyChanged        = "property-changed"
+	DashboardEventPanelAdded             = "panel-added"
+	DashboardEventPanelRemoved           = "panel-removed"
+	DashboardEventPanelMoved             = "panel-moved"
+	DashboardEventPanelQueriesChanged    = "panel-queries-changed"
+	DashboardEventPanelThresholdsChanged = "panel-thresholds-changed"
+	DashboardEventPanelChanged           = "panel-changed"
+	DashboardEventVariableAdded          = "variable-added"
+	DashboardEventVariableRemoved        = "variable-removed"
+	DashboardEventVariableChanged        = "variable-changed"
+)
+
+// A DashboardEvent is a single change of a dashboard, recorded along with the
+// version it has been saved in. The events of a dashboard form an append-only
+// stream complementing the version snapshots.
+type DashboardEvent struct {
+	Id          int64  `json:"id"`
+	OrgId       int64  `json:"-"`
+	DashboardId int64  `json:"dashboardId"`
+	Version     int    `json:"version"`
+	Type        string `json:"type"`
+	// PanelId is the panel of the panel events
+	PanelId int64 `json:"panelId,omitempty"`
+	// Variable is the name of the template variable of the variable events
+	Variable string `json:"variable,omitempty"`
+	// Path is the changed property, relative to the panel or the variable of
+	// the event, using dots to separate nested properties
+	Path string `json:"path,omitempty"`
+	// OldValue is the value before the change, nil when the property has been
+	// added
+	OldValue *simplejson.Json `json:"oldValue,omitempty"`
+	// NewValue is the value after the change, nil when the property has been
+	// removed
+	NewValue *simplejson.Json `json:"newValue,omitempty"`
+
+	Created   time.Time `json:"created"`
+	CreatedBy int64     `json:"-"`
+}
+
+// DashboardEventDTO is a dashboard event along with the login of the user
+// who made the change.
+type DashboardEventDTO struct {
+	DashboardEvent `xorm:"extends"`
+	CreatedBy      string `json:"createdBy" xorm:"created_by_login"`
+}
+
+//
+// Queries
+//
+
+type GetDashboardEventsQuery struct {
+	DashboardId int64
+	OrgId       int64
+	Limit       int
+	Start       int
+
+	Result []*DashboardEventDTO
+}
+
+type GetDashboardEventQuery struct {
+	Id          int64
+	DashboardId int64
+	OrgId       int64
+
+	Result *DashboardEvent
+}
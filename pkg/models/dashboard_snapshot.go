@@ -70,6 +70,11 @@ type DeleteDashboardSnapshotCommand struct {
 }
 
 type DeleteExpiredSnapshotsCommand struct {
+	// ExpiredBefore deletes the snapshots which expired before, the snapshots expired by now when zero
+	ExpiredBefore time.Time
+	// BatchSize is the maximum number of snapshots deleted by a statement, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
 	DeletedRows int64
 }
 
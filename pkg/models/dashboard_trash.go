@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var ErrDashboardTrashEntryNotFound = errors.New("deleted dashboard not found")
+
+// A DashboardTrashEntry is a copy of a deleted dashboard, kept with its permissions until the retention of the
+// deleted dashboards is over so that it can be restored. A deleted folder is kept along with each of its dashboards.
+type DashboardTrashEntry struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	Uid         string
+	Title       string
+	IsFolder    bool
+	// FolderUid and FolderTitle are the folder of the dashboard when it was deleted, empty for the General folder
+	FolderUid   string
+	FolderTitle string
+	Data        *simplejson.Json
+	// HasAcl tells whether the dashboard had permissions of its own, Acl, or those of its folder
+	HasAcl bool
+	Acl    []*DashboardTrashPermission
+	// DatasourceUids are the data sources the dashboards of a deleted folder were allowed to use
+	DatasourceUids []string
+	DeletedBy      int64
+	Deleted        time.Time
+}
+
+func (DashboardTrashEntry) TableName() string {
+	return "dashboard_trash"
+}
+
+// DashboardTrashPermission is a permission of a deleted dashboard
+type DashboardTrashPermission struct {
+	UserID     int64          `json:"userId,omitempty"`
+	TeamID     int64          `json:"teamId,omitempty"`
+	Role       *RoleType      `json:"role,omitempty"`
+	Permission PermissionType `json:"permission"`
+}
+
+// DashboardTrashEntryDTO is a deleted dashboard, without its data, along with the login of the user who deleted it
+type DashboardTrashEntryDTO struct {
+	Id             int64     `json:"id"`
+	DashboardId    int64     `json:"dashboardId"`
+	Uid            string    `json:"uid"`
+	Title          string    `json:"title"`
+	IsFolder       bool      `json:"isFolder"`
+	FolderUid      string    `json:"folderUid"`
+	FolderTitle    string    `json:"folderTitle"`
+	DeletedBy      int64     `json:"deletedBy"`
+	DeletedByLogin string    `json:"deletedByLogin"`
+	Deleted        time.Time `json:"deleted"`
+	// Expires is when the dashboard is deleted for good
+	Expires time.Time `json:"expires" xorm:"-"`
+}
+
+//
+// Commands
+//
+
+// RestoreDashboardCommand restores a deleted dashboard or folder, with its permissions. A dashboard is restored in its
+// folder, which is restored first when it was deleted too, or in the General folder when its folder is gone.
+type RestoreDashboardCommand struct {
+	Id    int64
+	OrgId int64
+	User  *SignedInUser
+	// DeletedBy only lets restore the dashboards deleted by this user when set
+	DeletedBy int64
+
+	Result *Dashboard
+}
+
+// DeleteDashboardTrashEntryCommand deletes a deleted dashboard for good
+type DeleteDashboardTrashEntryCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+// DeleteExpiredDashboardTrashCommand deletes for good the dashboards deleted before a time
+type DeleteExpiredDashboardTrashCommand struct {
+	DeletedBefore time.Time
+	// BatchSize is the maximum number of rows deleted by a statement, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
+	DeletedRows int64
+}
+
+//
+// Queries
+//
+
+// GetDashboardTrashQuery lists the deleted dashboards of an organization, the most recently deleted first. Only the
+// ones deleted by DeletedBy are listed when it is set.
+type GetDashboardTrashQuery struct {
+	OrgId     int64
+	DeletedBy int64
+
+	Result []*DashboardTrashEntryDTO
+}
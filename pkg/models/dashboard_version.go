@@ -82,5 +82,8 @@ type GetDashboardVersionsQuery struct {
 //
 
 type DeleteExpiredVersionsCommand struct {
+	// BatchSize is the maximum number of versions deleted by a transaction, 100 when zero
+	BatchSize int
+
 	DeletedRows int64
 }
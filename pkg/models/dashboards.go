@@ -371,6 +371,10 @@ type DeleteDashboardCommand struct {
 	Id                     int64
 	OrgId                  int64
 	ForceDeleteFolderRules bool
+	// MoveToTrash keeps a copy of the dashboard, or of the dashboards of the folder, which can be restored until the
+	// retention of the deleted dashboards is over. The dashboards are deleted for good when the retention is zero.
+	MoveToTrash bool
+	DeletedBy   int64
 }
 
 type DeleteOrphanedProvisionedDashboardsCommand struct {
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -18,6 +19,26 @@ var (
 	ErrFolderContainsAlertRules      = errors.New("folder contains alert rules")
 )
 
+// FolderDataSource is a data source that dashboards of a folder are allowed to use.
+// Folders without any entry allow all data sources.
+type FolderDataSource struct {
+	Id            int64
+	OrgId         int64
+	FolderId      int64
+	DatasourceUid string
+	Created       time.Time
+}
+
+// DashboardDataSourceNotAllowedError is returned when a dashboard references data sources
+// that are not allowed in its folder.
+type DashboardDataSourceNotAllowedError struct {
+	DataSources []string
+}
+
+func (e DashboardDataSourceNotAllowedError) Error() string {
+	return fmt.Sprintf("data sources not allowed in the folder: %s", strings.Join(e.DataSources, ", "))
+}
+
 type Folder struct {
 	Id      int64
 	Uid     string
@@ -74,6 +95,10 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+type SetFolderDataSourcesCommand struct {
+	DataSourceUIDs []string `json:"dataSourceUids"`
+}
+
 //
 // QUERIES
 //
@@ -8,20 +8,31 @@ type JWTClaims map[string]interface{}
 
 type JWTService interface {
 	Verify(ctx context.Context, strToken string) (JWTClaims, error)
+	// SyncUser creates or updates the user of the verified claims, with the organizations, roles and teams
+	// mapped from the claims, and returns the ID of the user
+	SyncUser(ctx *ReqContext, claims JWTClaims) (int64, error)
 }
 
 type FakeJWTService struct {
-	VerifyProvider func(context.Context, string) (JWTClaims, error)
+	VerifyProvider   func(context.Context, string) (JWTClaims, error)
+	SyncUserProvider func(*ReqContext, JWTClaims) (int64, error)
 }
 
 func (s *FakeJWTService) Verify(ctx context.Context, token string) (JWTClaims, error) {
 	return s.VerifyProvider(ctx, token)
 }
 
+func (s *FakeJWTService) SyncUser(ctx *ReqContext, claims JWTClaims) (int64, error) {
+	return s.SyncUserProvider(ctx, claims)
+}
+
 func NewFakeJWTService() *FakeJWTService {
 	return &FakeJWTService{
 		VerifyProvider: func(ctx context.Context, token string) (JWTClaims, error) {
 			return JWTClaims{}, nil
 		},
+		SyncUserProvider: func(ctx *ReqContext, claims JWTClaims) (int64, error) {
+			return 0, ErrUserNotFound
+		},
 	}
 }
@@ -79,6 +79,16 @@ type DashboardActivityChannel interface {
 	// Experimental! Indicate is GitOps is active.  This really means
 	// someone is subscribed to the `grafana/dashboards/gitops` channel
 	HasGitOpsObserver(orgID int64) bool
+
+	// GetEditingState returns the users editing a dashboard
+	GetEditingState(ctx context.Context, orgID int64, uid string) (*DashboardEditingState, error)
+
+	// TakeOverEditing gives the exclusive editing lock of a dashboard to a user session
+	TakeOverEditing(ctx context.Context, orgID int64, uid string, user *UserDisplayDTO, sessionID string) (*DashboardEditingState, error)
+
+	// CheckEditingLock returns ErrDashboardEditingLocked when another user holds the exclusive
+	// editing lock of the dashboard
+	CheckEditingLock(ctx context.Context, orgID int64, uid string, userID int64) (*DashboardEditingState, error)
 }
 
 type LiveMessage struct {
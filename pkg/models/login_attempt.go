@@ -22,7 +22,16 @@ type CreateLoginAttemptCommand struct {
 }
 
 type DeleteOldLoginAttemptsCommand struct {
-	OlderThan   time.Time
+	OlderThan time.Time
+	// BatchSize is the maximum number of attempts deleted by a statement, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
+	DeletedRows int64
+}
+
+// DeleteLoginAttemptsCommand deletes the failed login attempts of the usernames, which unlocks them
+type DeleteLoginAttemptsCommand struct {
+	Usernames   []string
 	DeletedRows int64
 }
 
@@ -34,3 +43,12 @@ type GetUserLoginAttemptCountQuery struct {
 	Since    time.Time
 	Result   int64
 }
+
+// GetLoginAttemptsQuery returns the failed login attempts of the username, or of the IP address when the username is
+// empty, since Since, the oldest first
+type GetLoginAttemptsQuery struct {
+	Username  string
+	IpAddress string
+	Since     time.Time
+	Result    []*LoginAttempt
+}
@@ -110,6 +110,8 @@ type GetOrgUsersQuery struct {
 	OrgId int64
 	Query string
 	Limit int
+	// User, when set, restricts the result to the users the signed in user can read according to their access control permissions
+	User *SignedInUser
 
 	Result []*OrgUserDTO
 }
@@ -119,6 +121,8 @@ type SearchOrgUsersQuery struct {
 	Query string
 	Page  int
 	Limit int
+	// User, when set, restricts the result to the users the signed in user can read according to their access control permissions
+	User *SignedInUser
 
 	Result SearchOrgUsersQueryResult
 }
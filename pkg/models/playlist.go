@@ -15,14 +15,17 @@ type Playlist struct {
 	Name     string `json:"name"`
 	Interval string `json:"interval"`
 	OrgId    int64  `json:"-"`
+	// ScheduleUid is the uid of the schedule during which the playlist plays, it plays all the time when empty
+	ScheduleUid string `json:"scheduleUid"`
 }
 
 type PlaylistDTO struct {
-	Id       int64             `json:"id"`
-	Name     string            `json:"name"`
-	Interval string            `json:"interval"`
-	OrgId    int64             `json:"-"`
-	Items    []PlaylistItemDTO `json:"items"`
+	Id          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Interval    string            `json:"interval"`
+	ScheduleUid string            `json:"scheduleUid"`
+	OrgId       int64             `json:"-"`
+	Items       []PlaylistItemDTO `json:"items"`
 }
 
 type PlaylistItemDTO struct {
@@ -50,19 +53,21 @@ type Playlists []*Playlist
 //
 
 type UpdatePlaylistCommand struct {
-	OrgId    int64             `json:"-"`
-	Id       int64             `json:"id"`
-	Name     string            `json:"name" binding:"Required"`
-	Interval string            `json:"interval"`
-	Items    []PlaylistItemDTO `json:"items"`
+	OrgId       int64             `json:"-"`
+	Id          int64             `json:"id"`
+	Name        string            `json:"name" binding:"Required"`
+	Interval    string            `json:"interval"`
+	ScheduleUid string            `json:"scheduleUid"`
+	Items       []PlaylistItemDTO `json:"items"`
 
 	Result *PlaylistDTO
 }
 
 type CreatePlaylistCommand struct {
-	Name     string            `json:"name" binding:"Required"`
-	Interval string            `json:"interval"`
-	Items    []PlaylistItemDTO `json:"items"`
+	Name        string            `json:"name" binding:"Required"`
+	Interval    string            `json:"interval"`
+	ScheduleUid string            `json:"scheduleUid"`
+	Items       []PlaylistItemDTO `json:"items"`
 
 	OrgId  int64 `json:"-"`
 	Result *Playlist
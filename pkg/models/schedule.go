@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduleOccurrence is an activation of a schedule. Schedules with a duration are active from
+// the start to the end of their occurrences, the others start and end at the same time.
+type ScheduleOccurrence struct {
+	ScheduleUid string    `json:"scheduleUid"`
+	Name        string    `json:"name"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+// GetScheduleOccurrencesQuery returns the occurrences of the schedules that overlap the From - To
+// range. From and To can be equal to get the schedules active at a point in time.
+type GetScheduleOccurrencesQuery struct {
+	OrgId        int64
+	ScheduleUids []string
+	From         time.Time
+	To           time.Time
+
+	Result []*ScheduleOccurrence
+}
@@ -21,6 +21,8 @@ type ShortUrl struct {
 
 type DeleteShortUrlCommand struct {
 	OlderThan time.Time
+	// BatchSize is the maximum number of short URLs deleted by a statement, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
 
 	NumDeleted int64
 }
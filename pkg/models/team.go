@@ -72,6 +72,8 @@ type SearchTeamsQuery struct {
 	UserIdFilter int64
 	SignedInUser *SignedInUser
 	HiddenUsers  map[string]struct{}
+	// Filters are added to the conditions on the teams, see searchstore.FilterWhere
+	Filters []interface{}
 
 	Result SearchTeamQueryResult
 }
@@ -67,6 +67,15 @@ type ExpireTempUsersCommand struct {
 	NumExpired int64
 }
 
+// DeleteExpiredTempUsersCommand deletes the invites and sign ups which expired before a time
+type DeleteExpiredTempUsersCommand struct {
+	ExpiredBefore time.Time
+	// BatchSize is the maximum number of invites deleted by a statement, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
+	DeletedRows int64
+}
+
 type UpdateTempUserWithEmailSentCommand struct {
 	Code string
 }
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	ErrTestFixtureNotFound = errors.New("test fixture not found")
+	ErrTestFixtureInvalid  = errors.New("invalid test fixture")
+)
+
+// CreateTestFixtureCommand creates organizations along with their users, folders and dashboards at once, for the
+// integration tests of external tools. Every user must be a member of at least one of the organizations of the
+// fixture, so that the whole fixture can be torn down without leaving anything behind.
+type CreateTestFixtureCommand struct {
+	Users []TestFixtureUser `json:"users"`
+	Orgs  []TestFixtureOrg  `json:"orgs"`
+
+	Result *TestFixture `json:"-"`
+}
+
+type TestFixtureUser struct {
+	Login          string `json:"login"`
+	Email          string `json:"email"`
+	Name           string `json:"name"`
+	Password       string `json:"password"`
+	IsGrafanaAdmin bool   `json:"isGrafanaAdmin"`
+}
+
+type TestFixtureOrg struct {
+	Name       string                 `json:"name"`
+	Members    []TestFixtureOrgMember `json:"members"`
+	Folders    []TestFixtureFolder    `json:"folders"`
+	Dashboards []TestFixtureDashboard `json:"dashboards"`
+}
+
+type TestFixtureOrgMember struct {
+	Login string   `json:"login"`
+	Role  RoleType `json:"role"`
+}
+
+type TestFixtureFolder struct {
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+type TestFixtureDashboard struct {
+	// FolderUid is the uid of one of the folders of the organization, the dashboard is saved in the General folder
+	// when empty
+	FolderUid string           `json:"folderUid"`
+	Dashboard *simplejson.Json `json:"dashboard"`
+}
+
+// TestFixture lists what has been created for a test fixture.
+type TestFixture struct {
+	Uid     string               `json:"uid"`
+	Created time.Time            `json:"created"`
+	Users   []TestFixtureUserRef `json:"users"`
+	Orgs    []TestFixtureOrgRef  `json:"orgs"`
+}
+
+type TestFixtureUserRef struct {
+	Id    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+type TestFixtureOrgRef struct {
+	Id         int64                     `json:"id"`
+	Name       string                    `json:"name"`
+	Folders    []TestFixtureDashboardRef `json:"folders"`
+	Dashboards []TestFixtureDashboardRef `json:"dashboards"`
+}
+
+type TestFixtureDashboardRef struct {
+	Id    int64  `json:"id"`
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+type GetTestFixturesQuery struct {
+	Result []*TestFixture
+}
+
+type GetTestFixtureQuery struct {
+	Uid string
+
+	Result *TestFixture
+}
+
+// DeleteTestFixtureCommand deletes the organizations and users of a test fixture, along with everything that has been
+// created in the organizations since, such as dashboards, data sources or alert rules.
+type DeleteTestFixtureCommand struct {
+	Uid string
+}
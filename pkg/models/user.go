@@ -11,6 +11,7 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrLastGrafanaAdmin  = errors.New("cannot remove last grafana admin")
 	ErrProtectedUser     = errors.New("cannot adopt protected user")
+	ErrUserNotDeleted    = errors.New("user is not deleted")
 )
 
 type Password string
@@ -104,6 +105,21 @@ type DeleteUserCommand struct {
 	UserId int64
 }
 
+// RestoreUserCommand restores a user removed from their last organization, and adds them back to it with Role
+type RestoreUserCommand struct {
+	UserId int64
+	Role   RoleType
+}
+
+// DeleteExpiredDeletedUsersCommand purges the users removed from their last organization before a time
+type DeleteExpiredDeletedUsersCommand struct {
+	DeletedBefore time.Time
+	// BatchSize is the maximum number of users purged by a run, sqlstore.DefaultDeleteBatchSize when zero
+	BatchSize int64
+
+	DeletedRows int64
+}
+
 type SetUsingOrgCommand struct {
 	UserId int64
 	OrgId  int64
@@ -149,6 +165,8 @@ type SearchUsersQuery struct {
 	Filters    []Filter
 
 	IsDisabled *bool
+	// IsDeleted returns the deleted users which can still be restored instead of the active users
+	IsDeleted bool
 
 	Result SearchUserQueryResult
 }
@@ -8,7 +8,9 @@ import (
 )
 
 const (
-	AuthModuleLDAP = "ldap"
+	AuthModuleLDAP       = "ldap"
+	AuthModuleJWT        = "jwt"
+	AuthModuleClientCert = "clientcert"
 )
 
 type UserAuth struct {
@@ -115,6 +117,16 @@ type GetAuthInfoQuery struct {
 	Result *UserAuth
 }
 
+// GetExpiringOAuthTokensQuery returns the OAuth tokens that expire before ExpiresBefore of the users with a session
+// rotated since ActiveSince, the tokens that expire first first
+type GetExpiringOAuthTokensQuery struct {
+	ExpiresBefore time.Time
+	ActiveSince   time.Time
+	Limit         int
+
+	Result []*UserAuth
+}
+
 type TeamOrgGroupDTO struct {
 	TeamName string `json:"teamName"`
 	OrgName  string `json:"orgName"`
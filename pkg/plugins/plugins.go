@@ -125,6 +125,8 @@ type JSONData struct {
 
 	// App settings
 	AutoEnabled bool `json:"autoEnabled"`
+	// AuthProviders are the authentication providers of a backend app plugin
+	AuthProviders []*AuthProvider `json:"authProviders,omitempty"`
 
 	// Datasource settings
 	Annotations  bool            `json:"annotations"`
@@ -142,6 +144,26 @@ type JSONData struct {
 
 	// Backend (Datasource + Renderer)
 	Executable string `json:"executable,omitempty"`
+
+	// Transformations are the server-side transformations of a backend plugin
+	Transformations []*Transformation `json:"transformations,omitempty"`
+}
+
+// Transformation describes a server-side transformation that is defined in the plugin.json file of a backend
+// plugin. The transformation is executed by the query service, which calls the transformations/<id> resource of the
+// plugin with the frames to transform.
+type Transformation struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AuthProvider describes an authentication provider that is defined in the plugin.json file of a backend app plugin.
+// The users sign in with the provider through the auth/<id>/login resource of the plugin, and the tokens of the
+// provider are validated by the auth/<id>/token resource.
+type AuthProvider struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 // Route describes a plugin route that is defined in
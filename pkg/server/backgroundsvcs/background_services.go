@@ -10,19 +10,36 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager"
 	"github.com/grafana/grafana/pkg/plugins/plugindashboards"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permissionexport"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permissionwebhooks"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/annotations/coldstorage"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/cleanup"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/dashboardthumbnails"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/entitystore"
+	"github.com/grafana/grafana/pkg/services/kioskrecording"
+	"github.com/grafana/grafana/pkg/services/ldapsync"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/pushhttp"
 	"github.com/grafana/grafana/pkg/services/ngalert"
 	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/quota/quotawarnings"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduler"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/secrets/orgkeys"
+	"github.com/grafana/grafana/pkg/services/secrets/rotation"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/sqlitemaintenance"
+	"github.com/grafana/grafana/pkg/services/tokencleanup"
 	"github.com/grafana/grafana/pkg/services/updatechecker"
+	"github.com/grafana/grafana/pkg/services/userdevices"
 	"github.com/grafana/grafana/pkg/tsdb/azuremonitor"
 	"github.com/grafana/grafana/pkg/tsdb/cloudmonitoring"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
@@ -41,23 +58,28 @@ import (
 )
 
 func ProvideBackgroundServiceRegistry(
-	httpServer *api.HTTPServer, ng *ngalert.AlertNG, cleanup *cleanup.CleanUpService,
+	httpServer *api.HTTPServer, ng *ngalert.AlertNG, _ *cleanup.CleanUpService,
 	live *live.GrafanaLive, pushGateway *pushhttp.Gateway, notifications *notifications.NotificationService,
 	rendering *rendering.RenderingService, tokenService models.UserTokenBackgroundService,
 	provisioning *provisioning.ProvisioningServiceImpl, alerting *alerting.AlertEngine, pm *manager.PluginManager,
 	metrics *metrics.InternalMetricsService, usageStats *uss.UsageStats, updateChecker *updatechecker.Service,
 	tracing tracing.Tracer, remoteCache *remotecache.RemoteCache, secretsService *secretsManager.SecretsService,
+	_ *permissionexport.Service, permissionWebhooks *permissionwebhooks.Service,
+	oauthTokenRefresh *oauthtoken.RefreshService, _ *ldapsync.Service, _ *tokencleanup.Service,
+	oauthLogout *oauthtoken.LogoutService, audit *audit.Service, scheduler *scheduler.Service,
 	// Need to make sure these are initialized, is there a better place to put them?
 	_ *azuremonitor.Service, _ *cloudwatch.CloudWatchService, _ *elasticsearch.Service, _ *graphite.Service,
 	_ *influxdb.Service, _ *loki.Service, _ *opentsdb.Service, _ *prometheus.Service, _ *tempo.Service,
 	_ *testdatasource.Service, _ *plugindashboards.Service, _ *dashboardsnapshots.Service,
 	_ *postgres.Service, _ *mysql.Service, _ *mssql.Service, _ *grafanads.Service, _ *cloudmonitoring.Service,
 	_ *pluginsettings.Service, _ *alerting.AlertNotificationService, _ serviceaccounts.Service,
+	_ *userdevices.Service, dashboardThumbnails *dashboardthumbnails.Service, _ *rotation.Service,
+	_ *orgkeys.Service, _ *entitystore.Service, _ *sqlitemaintenance.Service, quotaWarnings *quotawarnings.Service,
+	_ *coldstorage.Service, kioskRecording *kioskrecording.Service, dashboardUsage *dashboardusage.Service,
 ) *BackgroundServiceRegistry {
 	return NewBackgroundServiceRegistry(
 		httpServer,
 		ng,
-		cleanup,
 		live,
 		pushGateway,
 		notifications,
@@ -71,7 +93,16 @@ func ProvideBackgroundServiceRegistry(
 		usageStats,
 		tracing,
 		remoteCache,
-		secretsService)
+		secretsService,
+		permissionWebhooks,
+		oauthTokenRefresh,
+		oauthLogout,
+		audit,
+		scheduler,
+		dashboardThumbnails,
+		quotaWarnings,
+		kioskRecording,
+		dashboardUsage)
 }
 
 // BackgroundServiceRegistry provides background services.
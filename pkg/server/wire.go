@@ -27,14 +27,33 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager/loader"
 	"github.com/grafana/grafana/pkg/plugins/plugincontext"
 	"github.com/grafana/grafana/pkg/plugins/plugindashboards"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permissionexport"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permissionwebhooks"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/annotations/coldstorage"
+	"github.com/grafana/grafana/pkg/services/anonymous"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
+	"github.com/grafana/grafana/pkg/services/apireplay"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/auth/clientcert"
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
+	"github.com/grafana/grafana/pkg/services/auth/pluginauth"
+	"github.com/grafana/grafana/pkg/services/calendar"
 	"github.com/grafana/grafana/pkg/services/cleanup"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/dashboardimport"
+	dashboardpermissions "github.com/grafana/grafana/pkg/services/dashboards/permissions"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/dashboardthumbnails"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dspermissions "github.com/grafana/grafana/pkg/services/datasources/permissions"
+	"github.com/grafana/grafana/pkg/services/entitystore"
+	"github.com/grafana/grafana/pkg/services/environments"
 	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/kioskrecording"
+	"github.com/grafana/grafana/pkg/services/ldapsync"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/live"
@@ -46,21 +65,40 @@ import (
 	ngmetrics "github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/onboarding"
+	"github.com/grafana/grafana/pkg/services/orgreadonly"
+	"github.com/grafana/grafana/pkg/services/ownership"
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/querypipelines"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/quota/quotawarnings"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
+	"github.com/grafana/grafana/pkg/services/retention"
+	"github.com/grafana/grafana/pkg/services/savedsearch"
+	"github.com/grafana/grafana/pkg/services/schedule"
+	"github.com/grafana/grafana/pkg/services/scheduler"
 	"github.com/grafana/grafana/pkg/services/schemaloader"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/searchanalyzers"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	secretsDatabase "github.com/grafana/grafana/pkg/services/secrets/database"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/secrets/orgkeys"
+	"github.com/grafana/grafana/pkg/services/secrets/rotation"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	serviceaccountsmanager "github.com/grafana/grafana/pkg/services/serviceaccounts/manager"
 	"github.com/grafana/grafana/pkg/services/shorturls"
+	"github.com/grafana/grafana/pkg/services/sqlitemaintenance"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/terms"
 	"github.com/grafana/grafana/pkg/services/thumbs"
+	"github.com/grafana/grafana/pkg/services/tokencleanup"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/services/updatechecker"
+	"github.com/grafana/grafana/pkg/services/userdevices"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/azuremonitor"
 	"github.com/grafana/grafana/pkg/tsdb/cloudmonitoring"
@@ -100,6 +138,7 @@ var wireBasicSet = wire.NewSet(
 	hooks.ProvideService,
 	kvstore.ProvideService,
 	localcache.ProvideService,
+	localcache.ProvideRegistry,
 	updatechecker.ProvideService,
 	uss.ProvideService,
 	wire.Bind(new(usagestats.Service), new(*uss.UsageStats)),
@@ -137,7 +176,11 @@ var wireBasicSet = wire.NewSet(
 	pushhttp.ProvideService,
 	plugincontext.ProvideService,
 	contexthandler.ProvideService,
+	anonymous.ProvideService,
+	wire.Bind(new(contexthandler.AnonymousAccess), new(*anonymous.Service)),
 	jwt.ProvideService,
+	clientcert.ProvideService,
+	pluginauth.ProvideService,
 	wire.Bind(new(models.JWTService), new(*jwt.AuthService)),
 	plugindashboards.ProvideService,
 	schemaloader.ProvideService,
@@ -156,6 +199,8 @@ var wireBasicSet = wire.NewSet(
 	wire.Bind(new(social.Service), new(*social.SocialService)),
 	oauthtoken.ProvideService,
 	wire.Bind(new(oauthtoken.OAuthTokenService), new(*oauthtoken.Service)),
+	oauthtoken.ProvideRefreshService,
+	oauthtoken.ProvideLogoutService,
 	tempo.ProvideService,
 	loki.ProvideService,
 	graphite.ProvideService,
@@ -173,6 +218,43 @@ var wireBasicSet = wire.NewSet(
 	serviceaccountsmanager.ProvideServiceAccountsService,
 	wire.Bind(new(serviceaccounts.Service), new(*serviceaccountsmanager.ServiceAccountsService)),
 	expr.ProvideService,
+	ownership.ProvideService,
+	savedsearch.ProvideService,
+	calendar.ProvideService,
+	dashboardimport.ProvideService,
+	onboarding.ProvideService,
+	audit.ProvideService,
+	dspermissions.ProvideService,
+	wire.Bind(new(datasources.PermissionsService), new(*dspermissions.Service)),
+	dashboardpermissions.ProvideService,
+	permissionexport.ProvideService,
+	permissionwebhooks.ProvideService,
+	apireplay.ProvideService,
+	ldapsync.ProvideService,
+	terms.ProvideService,
+	schedule.ProvideService,
+	tokencleanup.ProvideService,
+	twofactor.ProvideService,
+	retention.ProvideService,
+	scheduler.ProvideService,
+	userdevices.ProvideService,
+	dashboardthumbnails.ProvideService,
+	environments.ProvideService,
+	querypipelines.ProvideService,
+	resourcelabels.ProvideService,
+	rotation.ProvideService,
+	orgkeys.ProvideService,
+	entitystore.ProvideService,
+	orgreadonly.ProvideService,
+	sqlitemaintenance.ProvideService,
+	quotawarnings.ProvideService,
+	coldstorage.ProvideService,
+	apiexplorer.ProvideService,
+	searchanalyzers.ProvideService,
+	wire.Bind(new(search.TitleAnalyzer), new(*searchanalyzers.Service)),
+	publicdashboards.ProvideService,
+	kioskrecording.ProvideService,
+	dashboardusage.ProvideService,
 )
 
 var wireSet = wire.NewSet(
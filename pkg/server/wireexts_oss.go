@@ -64,6 +64,12 @@ var wireExtsBasicSet = wire.NewSet(
 	acdb.ProvideService,
 	wire.Bind(new(accesscontrol.ResourcePermissionsStore), new(*acdb.AccessControlStore)),
 	wire.Bind(new(accesscontrol.PermissionsProvider), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.TeamRoleStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.UserRoleStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.GroupMappingStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.RoleBundleStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.RoleBindingStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.DenyPermissionStore), new(*acdb.AccessControlStore)),
 	osskmsproviders.ProvideService,
 	wire.Bind(new(kmsproviders.Service), new(osskmsproviders.Service)),
 )
@@ -3,6 +3,7 @@ package accesscontrol
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 )
@@ -17,6 +18,13 @@ type AccessControl interface {
 	// GetUserRoles returns user roles.
 	GetUserRoles(ctx context.Context, user *models.SignedInUser) ([]*RoleDTO, error)
 
+	// GetUserResourcesMetadata returns, for each of the resources, the actions the user can perform on it.
+	GetUserResourcesMetadata(ctx context.Context, user *models.SignedInUser, resource string, resourceIDs map[string]bool) (map[string]Metadata, error)
+
+	// GetUsersPermissions returns, keyed by user id, the permissions of the users matching the query, looked up
+	// for all the users at once
+	GetUsersPermissions(ctx context.Context, users []*models.SignedInUser, query GetUsersPermissionsQuery) (map[int64][]*Permission, error)
+
 	//IsDisabled returns if access control is enabled or not
 	IsDisabled() bool
 
@@ -27,6 +35,70 @@ type AccessControl interface {
 
 type PermissionsProvider interface {
 	GetUserPermissions(ctx context.Context, query GetUserPermissionsQuery) ([]*Permission, error)
+	// GetUserAssignedRoles returns the roles the permissions returned by GetUserPermissions come from
+	GetUserAssignedRoles(ctx context.Context, query GetUserPermissionsQuery) ([]*Role, error)
+	// GetUsersPermissions returns the roles assigned to the users and the built-in roles of the organization, with
+	// their permissions matching the query
+	GetUsersPermissions(ctx context.Context, query GetUsersPermissionsQuery) ([]RoleAssignmentPermission, error)
+}
+
+type TeamRoleStore interface {
+	// GetTeamRoles returns the roles assigned to a team
+	GetTeamRoles(ctx context.Context, orgID, teamID int64) ([]*Role, error)
+	// GetUserTeamRoles returns the roles assigned to all teams the user is a member of
+	GetUserTeamRoles(ctx context.Context, orgID, userID int64) ([]*Role, error)
+	// GetUserTeamRolesByName returns the roles with one of the given names assigned to the teams the user is a member of
+	GetUserTeamRolesByName(ctx context.Context, orgID, userID int64, names []string) ([]*Role, error)
+	// AddTeamRole assigns a role to a team, the role is stored if it does not exist yet
+	AddTeamRole(ctx context.Context, orgID, teamID int64, role Role) error
+	// RemoveTeamRole removes a role assignment from a team
+	RemoveTeamRole(ctx context.Context, orgID, teamID int64, roleUID string) error
+}
+
+type UserRoleStore interface {
+	// GetUserRoles returns the roles assigned directly to a user, expired assignments are left out
+	GetUserRoles(ctx context.Context, orgID, userID int64) ([]*UserRoleAssignment, error)
+	// AddUserRole assigns a role to a user until expiresAt, or without expiry when expiresAt is nil. The expiry is
+	// updated when the role is already assigned to the user, the role is stored if it does not exist yet
+	AddUserRole(ctx context.Context, orgID, userID int64, role Role, expiresAt *time.Time) error
+	// RemoveUserRole removes a role assignment from a user
+	RemoveUserRole(ctx context.Context, orgID, userID int64, roleUID string) error
+	// DeleteExpiredUserRoles removes the role assignments of all organizations that expired before now
+	DeleteExpiredUserRoles(ctx context.Context, now time.Time) (int64, error)
+}
+
+type GroupMappingStore interface {
+	// GetGroupMappings returns the group mappings of an organization
+	GetGroupMappings(ctx context.Context, orgID int64) ([]*GroupMappingDTO, error)
+	// AddGroupMapping maps a group to a role, the role is stored if it does not exist yet
+	AddGroupMapping(ctx context.Context, cmd AddGroupMappingCommand) (*GroupMapping, error)
+	// RemoveGroupMapping removes a group mapping of an organization, provisioned mappings cannot be removed
+	RemoveGroupMapping(ctx context.Context, orgID, id int64) error
+	// SetProvisionedGroupMappings replaces all provisioned group mappings
+	SetProvisionedGroupMappings(ctx context.Context, cmds []AddGroupMappingCommand) error
+	// SyncUserGroupRoles assigns to the user the roles the groups are mapped to for the authentication module, in all
+	// organizations the user is a member of. The roles previously synced from the module for groups the user no
+	// longer belongs to are removed.
+	SyncUserGroupRoles(ctx context.Context, userID int64, authModule string, groups []string) error
+}
+
+type RoleBundleStore interface {
+	// ExportRoleBundle returns the custom roles available to an organization, global ones included, and the roles
+	// assigned in the organization to users, teams and built-in roles. Managed roles, the roles synced from external
+	// groups and the expiring assignments are left out.
+	ExportRoleBundle(ctx context.Context, orgID int64) (*RoleBundle, error)
+	// ImportRoleBundle stores the custom roles of the bundle, replacing their permissions, and adds the assignments
+	// that are missing. Importing the same bundle again changes nothing.
+	ImportRoleBundle(ctx context.Context, bundle RoleBundle) error
+}
+
+type RoleBindingStore interface {
+	// GetRoleBindings returns the role bindings of an organization
+	GetRoleBindings(ctx context.Context, orgID int64) ([]*RoleBinding, error)
+	// SetProvisionedRoleBindings reconciles the role bindings with the commands: the missing bindings are assigned
+	// and the bindings without a command anymore are unassigned. Bindings to users and teams that do not exist are
+	// skipped until they do.
+	SetProvisionedRoleBindings(ctx context.Context, cmds []AddRoleBindingCommand) error
 }
 
 type ResourcePermissionsService interface {
@@ -55,6 +127,15 @@ type ResourcePermissionsStore interface {
 	GetResourcesPermissions(ctx context.Context, orgID int64, query GetResourcesPermissionsQuery) ([]ResourcePermission, error)
 }
 
+type DenyPermissionStore interface {
+	// GetDenyPermissions returns the deny permissions of the users, teams and built-in roles of the organization
+	GetDenyPermissions(ctx context.Context, orgID int64) ([]DenyPermission, error)
+	// AddDenyPermission denies an action on a scope to a user, a team or a built-in role
+	AddDenyPermission(ctx context.Context, orgID int64, cmd AddDenyPermissionCommand) (*DenyPermission, error)
+	// RemoveDenyPermission removes a deny permission of the organization
+	RemoveDenyPermission(ctx context.Context, orgID, id int64) error
+}
+
 // Metadata contains user accesses for a given resource
 // Ex: map[string]bool{"create":true, "delete": true}
 type Metadata map[string]bool
@@ -104,24 +185,48 @@ var ReqOrgAdmin = func(c *models.ReqContext) bool {
 	return c.OrgRole == models.ROLE_ADMIN
 }
 
+// BuildPermissionsMap returns the actions granted by the permissions. Actions denied on all scopes are left out.
 func BuildPermissionsMap(permissions []*Permission) map[string]bool {
 	permissionsMap := make(map[string]bool)
 	for _, p := range permissions {
-		permissionsMap[p.Action] = true
+		if !p.Deny {
+			permissionsMap[p.Action] = true
+		}
+	}
+	for _, p := range permissions {
+		if p.Deny && deniesAllScopes(p.Scope) {
+			delete(permissionsMap, p.Action)
+		}
 	}
 
 	return permissionsMap
 }
 
-// GroupScopesByAction will group scopes on action
+// GroupScopesByAction will group scopes on action. The scopes of deny permissions are grouped apart, under
+// DenyKey(action), so that they never grant the action.
 func GroupScopesByAction(permissions []*Permission) map[string][]string {
 	m := make(map[string][]string)
 	for _, p := range permissions {
-		m[p.Action] = append(m[p.Action], p.Scope)
+		key := p.Action
+		if p.Deny {
+			key = DenyKey(p.Action)
+		}
+		m[key] = append(m[key], p.Scope)
 	}
 	return m
 }
 
+// DenyKey returns the key the scopes denied for the action are grouped under by GroupScopesByAction
+func DenyKey(action string) string {
+	return "!" + action
+}
+
+// deniesAllScopes returns true if a deny permission with the scope excludes the action whatever the scope,
+// that is when the scope is empty or `*`
+func deniesAllScopes(scope string) bool {
+	return scope == "" || scope == "*"
+}
+
 func ValidateScope(scope string) bool {
 	prefix, last := scope[:len(scope)-1], scope[len(scope)-1]
 	// verify that last char is either ':' or '/' if last character of scope is '*'
@@ -145,6 +250,12 @@ func addActionToMetadata(allMetadata map[string]Metadata, action, id string) map
 	return allMetadata
 }
 
+// IsResourceScope returns true if a permission with the scope can grant actions on resources of the given type
+func IsResourceScope(resource, scope string) bool {
+	return scope == "*" || scope == GetResourceAllScope(resource) || scope == GetResourceAllIDScope(resource) ||
+		strings.HasPrefix(scope, Scope(resource, "id")+":")
+}
+
 // GetResourcesMetadata returns a map of accesscontrol metadata, listing for each resource, users available actions
 func GetResourcesMetadata(ctx context.Context, permissions []*Permission, resource string, resourceIDs map[string]bool) map[string]Metadata {
 	allScope := GetResourceAllScope(resource)
@@ -157,7 +268,12 @@ func GetResourcesMetadata(ctx context.Context, permissions []*Permission, resour
 
 	// Loop through permissions once
 	result := map[string]Metadata{}
+	var denies []*Permission
 	for _, p := range permissions {
+		if p.Deny {
+			denies = append(denies, p)
+			continue
+		}
 		if p.Scope == "*" || p.Scope == allScope || p.Scope == allIDScope {
 			// Add global action to all resources
 			for id := range resourceIDs {
@@ -171,5 +287,21 @@ func GetResourcesMetadata(ctx context.Context, permissions []*Permission, resour
 		}
 	}
 
+	// Denied actions are removed whatever the permissions granting them
+	for _, p := range denies {
+		if p.Scope == "" || p.Scope == "*" || p.Scope == allScope || p.Scope == allIDScope {
+			for id := range result {
+				delete(result[id], p.Action)
+			}
+		} else if len(p.Scope) > idIndex && strings.HasPrefix(p.Scope, idPrefix) {
+			delete(result[p.Scope[idIndex:]], p.Action)
+		}
+	}
+	for id, metadata := range result {
+		if len(metadata) == 0 {
+			delete(result, id)
+		}
+	}
+
 	return result
 }
@@ -91,6 +91,23 @@ func TestGetResourcesMetadata(t *testing.T) {
 				"123": {"resources:action1": true},
 			},
 		},
+		{
+			desc:     "Should remove denied actions for resources 1,2,3",
+			resource: "resources",
+			permissions: []*Permission{
+				{Action: "resources:action1", Scope: Scope("resources", "*")},
+				{Action: "resources:action2", Scope: Scope("resources", "*")},
+				{Action: "resources:action3", Scope: Scope("resources", "id", "1")},
+				{Action: "resources:action1", Scope: Scope("resources", "id", "2"), Deny: true},
+				{Action: "resources:action2", Scope: "", Deny: true},
+				{Action: "resources:action3", Scope: Scope("resources", "id", "*"), Deny: true},
+			},
+			resourcesIDs: map[string]bool{"1": true, "2": true, "3": true},
+			expected: map[string]Metadata{
+				"1": {"resources:action1": true},
+				"3": {"resources:action1": true},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -99,3 +116,28 @@ func TestGetResourcesMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPermissionsMap(t *testing.T) {
+	permissions := BuildPermissionsMap([]*Permission{
+		{Action: "resources:action1", Scope: Scope("resources", "*")},
+		{Action: "resources:action2", Scope: Scope("resources", "*")},
+		{Action: "resources:action3", Scope: Scope("resources", "*")},
+		{Action: "resources:action1", Scope: Scope("resources", "id", "1"), Deny: true},
+		{Action: "resources:action2", Scope: "*", Deny: true},
+		{Action: "resources:action4", Scope: Scope("resources", "id", "1"), Deny: true},
+	})
+	assert.Equal(t, map[string]bool{"resources:action1": true, "resources:action3": true}, permissions)
+}
+
+func TestGroupScopesByAction(t *testing.T) {
+	permissions := GroupScopesByAction([]*Permission{
+		{Action: "resources:action1", Scope: Scope("resources", "*")},
+		{Action: "resources:action1", Scope: Scope("resources", "id", "1"), Deny: true},
+		{Action: "resources:action2", Scope: "", Deny: true},
+	})
+	assert.Equal(t, map[string][]string{
+		"resources:action1":          {Scope("resources", "*")},
+		DenyKey("resources:action1"): {Scope("resources", "id", "1")},
+		DenyKey("resources:action2"): {""},
+	}, permissions)
+}
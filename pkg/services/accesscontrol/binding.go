@@ -0,0 +1,87 @@
+package accesscontrol
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RoleBindingRolePrefix is the prefix of the roles holding the permissions of the scoped role bindings
+const RoleBindingRolePrefix = "binding:"
+
+// RoleBinding is a role assigned to a user or a team by the provisioning files. The bindings removed from the files
+// are unassigned the next time the files are provisioned.
+type RoleBinding struct {
+	ID     int64 `json:"id" xorm:"pk autoincr 'id'"`
+	OrgID  int64 `json:"orgId" xorm:"org_id"`
+	UserID int64 `json:"userId,omitempty" xorm:"user_id"`
+	TeamID int64 `json:"teamId,omitempty" xorm:"team_id"`
+	// RoleID is the role assigned to the user or the team, the role holding the permissions of the bound role
+	// restricted to the scope for scoped bindings
+	RoleID int64 `json:"-" xorm:"role_id"`
+	// RoleName is the name of the bound role, a fixed or a custom role
+	RoleName string `json:"roleName" xorm:"role_name"`
+	// Scope restricts the permissions of the bound role to a scope, the role is assigned as is when empty
+	Scope string `json:"scope,omitempty"`
+
+	Updated time.Time `json:"updated"`
+	Created time.Time `json:"created"`
+}
+
+// AddRoleBindingCommand binds a role to either a user, referenced by login or email, or a team, referenced by name
+type AddRoleBindingCommand struct {
+	OrgID int64
+	User  string
+	Team  string
+	Role  string
+	Scope string
+}
+
+// RoleBindingRoleName returns the name of the role holding the permissions of a role restricted to a scope
+func RoleBindingRoleName(roleName, scope string) string {
+	return RoleBindingRolePrefix + roleName + ":" + scope
+}
+
+// RoleBindingRoleUID returns the uid of the role holding the permissions of a role restricted to a scope in an
+// organization
+func RoleBindingRoleUID(orgID int64, roleName, scope string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d\x00%s\x00%s", orgID, roleName, scope)))
+	return "binding_" + hex.EncodeToString(sum[:])[:24]
+}
+
+// ScopePermissions returns the permissions restricted to the scope: the actions of the permissions whose scope
+// covers the scope are granted, or denied, on the scope only. The denies on scopes within the scope are kept as is,
+// the other permissions are left out.
+func ScopePermissions(permissions []Permission, scope string) []Permission {
+	scoped := make([]Permission, 0)
+	seen := make(map[Permission]bool)
+	add := func(p Permission) {
+		if !seen[p] {
+			seen[p] = true
+			scoped = append(scoped, p)
+		}
+	}
+	for _, p := range permissions {
+		if p.Deny && deniesAllScopes(p.Scope) {
+			add(Permission{Action: p.Action, Scope: scope, Deny: true})
+			continue
+		}
+		covered, err := EvalPermission(p.Action, scope).Evaluate(map[string][]string{p.Action: {p.Scope}})
+		if err != nil {
+			continue
+		}
+		if covered {
+			add(Permission{Action: p.Action, Scope: scope, Deny: p.Deny})
+			continue
+		}
+		if !p.Deny {
+			continue
+		}
+		within, err := EvalPermission(p.Action, p.Scope).Evaluate(map[string][]string{p.Action: {scope}})
+		if err == nil && within {
+			add(Permission{Action: p.Action, Scope: p.Scope, Deny: true})
+		}
+	}
+	return scoped
+}
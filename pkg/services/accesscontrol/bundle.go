@@ -0,0 +1,54 @@
+package accesscontrol
+
+// RoleBundleAPIVersion is the version of the role bundles exported by Grafana
+const RoleBundleAPIVersion = 1
+
+// RoleBundle is a set of custom roles, of role assignments and of deny permissions, exported and provisioned as YAML
+// files so that the access control configuration can be kept in version control and promoted between environments.
+// Users and teams are referenced by login and name, as their ids differ between environments.
+type RoleBundle struct {
+	APIVersion  int64                  `json:"apiVersion" yaml:"apiVersion"`
+	Roles       []RoleBundleRole       `json:"roles" yaml:"roles"`
+	Assignments []RoleBundleAssignment `json:"assignments" yaml:"assignments"`
+	Denies      []RoleBundleDeny       `json:"denies,omitempty" yaml:"denies,omitempty"`
+}
+
+// RoleBundleRole is a custom role, a role stored in the database that is neither a fixed role nor a managed role.
+// Roles without organization are global roles.
+type RoleBundleRole struct {
+	UID         string                 `json:"uid" yaml:"uid"`
+	Name        string                 `json:"name" yaml:"name"`
+	OrgID       int64                  `json:"orgId" yaml:"orgId"`
+	Version     int64                  `json:"version" yaml:"version"`
+	DisplayName string                 `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Group       string                 `json:"group,omitempty" yaml:"group,omitempty"`
+	Permissions []RoleBundlePermission `json:"permissions" yaml:"permissions"`
+}
+
+type RoleBundlePermission struct {
+	Action string `json:"action" yaml:"action"`
+	Scope  string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Deny   bool   `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// RoleBundleAssignment assigns a custom or a fixed role, by name, to users, teams and built-in roles of an
+// organization
+type RoleBundleAssignment struct {
+	OrgID        int64    `json:"orgId" yaml:"orgId"`
+	Role         string   `json:"role" yaml:"role"`
+	Users        []string `json:"users,omitempty" yaml:"users,omitempty"`
+	Teams        []string `json:"teams,omitempty" yaml:"teams,omitempty"`
+	BuiltInRoles []string `json:"builtInRoles,omitempty" yaml:"builtInRoles,omitempty"`
+}
+
+// RoleBundleDeny denies an action on a scope to users, teams and built-in roles of an organization, like the deny
+// permissions added through the API it is stored in the managed roles of the subjects
+type RoleBundleDeny struct {
+	OrgID        int64    `json:"orgId" yaml:"orgId"`
+	Action       string   `json:"action" yaml:"action"`
+	Scope        string   `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Users        []string `json:"users,omitempty" yaml:"users,omitempty"`
+	Teams        []string `json:"teams,omitempty" yaml:"teams,omitempty"`
+	BuiltInRoles []string `json:"builtInRoles,omitempty" yaml:"builtInRoles,omitempty"`
+}
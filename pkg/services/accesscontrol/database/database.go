@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
@@ -12,6 +15,8 @@ const (
 	globalOrgID = 0
 )
 
+var logger = log.New("accesscontrol.database")
+
 func ProvideService(sqlStore *sqlstore.SQLStore) *AccessControlStore {
 	return &AccessControlStore{sqlStore}
 }
@@ -31,12 +36,21 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 			permission.role_id,
 			permission.action,
 			permission.scope,
+			permission.deny,
 			permission.updated,
 			permission.created
 			FROM permission
 			INNER JOIN role ON role.id = permission.role_id
 		` + filter
 
+		if query.Resource != "" {
+			// The scopes matched by accesscontrol.IsResourceScope, and the deny permissions without scope
+			q += ` AND (permission.scope IN (?, ?) OR permission.scope LIKE ? OR (permission.deny = ` +
+				s.sql.Dialect.BooleanStr(true) + ` AND permission.scope = ''))`
+			params = append(params, "*", accesscontrol.GetResourceAllScope(query.Resource),
+				accesscontrol.Scope(query.Resource, "id")+":%")
+		}
+
 		if err := sess.SQL(q, params...).Find(&result); err != nil {
 			return err
 		}
@@ -47,6 +61,80 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 	return result, err
 }
 
+func (s *AccessControlStore) GetUserAssignedRoles(ctx context.Context, query accesscontrol.GetUserPermissionsQuery) ([]*accesscontrol.Role, error) {
+	result := make([]*accesscontrol.Role, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		filter, params := userRolesFilter(query.OrgID, query.UserID, query.Roles)
+		return sess.SQL("SELECT role.* FROM role "+filter, params...).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) GetUsersPermissions(ctx context.Context, query accesscontrol.GetUsersPermissionsQuery) ([]accesscontrol.RoleAssignmentPermission, error) {
+	result := make([]accesscontrol.RoleAssignmentPermission, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// The roles without matching permissions are kept, the fixed roles hold their permissions in memory
+		join, joinParams := s.permissionsFilter(query)
+		columns := `role.name AS role_name, permission.action, permission.scope, permission.deny`
+
+		q := `SELECT ur.user_id, '' AS built_in_role, ` + columns + `
+			FROM user_role AS ur
+			INNER JOIN role ON role.id = ur.role_id
+			LEFT JOIN permission ON permission.role_id = role.id AND ` + join + `
+			WHERE (ur.org_id = ? OR ur.org_id = ?)
+			AND (ur.expires_at IS NULL OR ur.expires_at > ?)
+			UNION ALL
+			SELECT tm.user_id, '' AS built_in_role, ` + columns + `
+			FROM team_role AS tr
+			INNER JOIN team_member AS tm ON tm.team_id = tr.team_id
+			INNER JOIN role ON role.id = tr.role_id
+			LEFT JOIN permission ON permission.role_id = role.id AND ` + join + `
+			WHERE tr.org_id = ?
+			UNION ALL
+			SELECT 0 AS user_id, br.role AS built_in_role, ` + columns + `
+			FROM builtin_role AS br
+			INNER JOIN role ON role.id = br.role_id
+			LEFT JOIN permission ON permission.role_id = role.id AND ` + join + `
+			WHERE (br.org_id = ? OR br.org_id = ?)`
+
+		params := append([]interface{}{}, joinParams...)
+		params = append(params, query.OrgID, globalOrgID, time.Now())
+		params = append(params, joinParams...)
+		params = append(params, query.OrgID)
+		params = append(params, joinParams...)
+		params = append(params, query.OrgID, globalOrgID)
+
+		return sess.SQL(q, params...).Find(&result)
+	})
+
+	return result, err
+}
+
+// permissionsFilter returns the condition selecting the permissions on one of the scopes of the query with an action
+// starting with one of its prefixes. Deny permissions without scope exclude the action on all scopes, they are kept.
+func (s *AccessControlStore) permissionsFilter(query accesscontrol.GetUsersPermissionsQuery) (string, []interface{}) {
+	params := make([]interface{}, 0, len(query.Scopes)+len(query.ActionPrefixes))
+
+	scopes := "permission.deny = " + s.sql.Dialect.BooleanStr(true) + " AND permission.scope = ''"
+	if len(query.Scopes) > 0 {
+		scopes = "permission.scope IN (?" + strings.Repeat(", ?", len(query.Scopes)-1) + ") OR (" + scopes + ")"
+		for _, scope := range query.Scopes {
+			params = append(params, scope)
+		}
+	}
+
+	if len(query.ActionPrefixes) == 0 {
+		return "(" + scopes + ")", params
+	}
+	actions := make([]string, 0, len(query.ActionPrefixes))
+	for _, prefix := range query.ActionPrefixes {
+		actions = append(actions, "permission.action LIKE ?")
+		params = append(params, prefix+"%")
+	}
+	return "(" + scopes + ") AND (" + strings.Join(actions, " OR ") + ")", params
+}
+
 func userRolesFilter(orgID, userID int64, roles []string) (string, []interface{}) {
 	q := `
 	WHERE role.id IN (
@@ -54,12 +142,13 @@ func userRolesFilter(orgID, userID int64, roles []string) (string, []interface{}
 		FROM user_role AS ur
 		WHERE ur.user_id = ?
 		AND (ur.org_id = ? OR ur.org_id = ?)
+		AND (ur.expires_at IS NULL OR ur.expires_at > ?)
 		UNION
 		SELECT tr.role_id FROM team_role as tr
 		INNER JOIN team_member as tm ON tm.team_id = tr.team_id
 		WHERE tm.user_id = ? AND tr.org_id = ?
 	`
-	params := []interface{}{userID, orgID, globalOrgID, userID, orgID}
+	params := []interface{}{userID, orgID, globalOrgID, time.Now(), userID, orgID}
 
 	if len(roles) != 0 {
 		q += `
@@ -99,3 +188,26 @@ func deletePermissions(sess *sqlstore.DBSession, ids []int64) error {
 
 	return nil
 }
+
+// publishRolesChanged notifies that roles or their assignments changed in the organization, so that the permissions
+// cached before the change are not used anymore. The change is stored already, failing to publish it is only logged.
+func (s *AccessControlStore) publishRolesChanged(ctx context.Context, orgID int64) {
+	if s.sql.Bus == nil {
+		return
+	}
+	if err := s.sql.Bus.Publish(ctx, &events.AccessControlRolesChanged{Timestamp: time.Now(), OrgID: orgID}); err != nil {
+		logger.Error("Failed to publish access control roles change", "orgID", orgID, "error", err)
+	}
+}
+
+// publishRoleAssignmentChanged notifies that a role was assigned or unassigned. As for publishRolesChanged, failing to
+// publish it is only logged.
+func (s *AccessControlStore) publishRoleAssignmentChanged(ctx context.Context, event *events.RoleAssignmentChanged) {
+	if s.sql.Bus == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	if err := s.sql.Bus.Publish(ctx, event); err != nil {
+		logger.Error("Failed to publish role assignment change", "orgID", event.OrgID, "roleUID", event.RoleUID, "error", err)
+	}
+}
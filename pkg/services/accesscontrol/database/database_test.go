@@ -108,6 +108,121 @@ func TestAccessControlStore_GetUserPermissions(t *testing.T) {
 	}
 }
 
+func TestAccessControlStore_GetUserAssignedRoles(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+
+	for _, set := range []func(cmd accesscontrol.SetResourcePermissionCommand) error{
+		func(cmd accesscontrol.SetResourcePermissionCommand) error {
+			_, err := store.SetUserResourcePermission(context.Background(), 1, user.Id, cmd)
+			return err
+		},
+		func(cmd accesscontrol.SetResourcePermissionCommand) error {
+			_, err := store.SetTeamResourcePermission(context.Background(), 1, team.Id, cmd)
+			return err
+		},
+		func(cmd accesscontrol.SetResourcePermissionCommand) error {
+			_, err := store.SetBuiltInResourcePermission(context.Background(), 1, "Admin", cmd)
+			return err
+		},
+	} {
+		require.NoError(t, set(accesscontrol.SetResourcePermissionCommand{
+			Actions:    []string{"dashboards:read"},
+			Resource:   "dashboards",
+			ResourceID: "1",
+		}))
+	}
+
+	query := accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id, Roles: []string{"Viewer"}}
+	roles, err := store.GetUserAssignedRoles(context.Background(), query)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	assert.ElementsMatch(t, []string{accesscontrol.ManagedUserRoleName(user.Id), accesscontrol.ManagedTeamRoleName(team.Id)}, names)
+
+	permissions, err := store.GetUserPermissions(context.Background(), query)
+	require.NoError(t, err)
+	for _, p := range permissions {
+		assert.Contains(t, []int64{roles[0].ID, roles[1].ID}, p.RoleID)
+	}
+}
+
+func TestAccessControlStore_GetUserPermissionsOfResource(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, 1)
+
+	for _, cmd := range []accesscontrol.SetResourcePermissionCommand{
+		{Actions: []string{"dashboards:read"}, Resource: "dashboards", ResourceID: "1"},
+		{Actions: []string{"dashboards:read"}, Resource: "dashboards", ResourceID: "*"},
+		{Actions: []string{"datasources:query"}, Resource: "datasources", ResourceID: "1"},
+		{Actions: []string{"dashboards:read"}, Resource: "dashboardsother", ResourceID: "1"},
+	} {
+		_, err := store.SetUserResourcePermission(context.Background(), 1, user.Id, cmd)
+		require.NoError(t, err)
+	}
+
+	permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{
+		OrgID:    1,
+		UserID:   user.Id,
+		Resource: "dashboards",
+	})
+	require.NoError(t, err)
+
+	scopes := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		scopes = append(scopes, p.Scope)
+	}
+	assert.ElementsMatch(t, []string{"dashboards:id:1", "dashboards:id:*"}, scopes)
+}
+
+func TestAccessControlStore_GetUsersPermissions(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+
+	cmd := func(action, resourceID string) accesscontrol.SetResourcePermissionCommand {
+		return accesscontrol.SetResourcePermissionCommand{Actions: []string{action}, Resource: "dashboards", ResourceAttribute: "uid", ResourceID: resourceID}
+	}
+	_, err := store.SetUserResourcePermission(context.Background(), 1, user.Id, cmd("dashboards:read", "abc"))
+	require.NoError(t, err)
+	_, err = store.SetUserResourcePermission(context.Background(), 1, user.Id, cmd("dashboards:read", "other"))
+	require.NoError(t, err)
+	_, err = store.SetTeamResourcePermission(context.Background(), 1, team.Id, cmd("dashboards:write", "abc"))
+	require.NoError(t, err)
+	_, err = store.SetBuiltInResourcePermission(context.Background(), 1, "Viewer", cmd("dashboards:read", "abc"))
+	require.NoError(t, err)
+	_, err = store.SetBuiltInResourcePermission(context.Background(), 2, "Viewer", cmd("dashboards:read", "abc"))
+	require.NoError(t, err)
+
+	assignments, err := store.GetUsersPermissions(context.Background(), accesscontrol.GetUsersPermissionsQuery{
+		OrgID:          1,
+		Scopes:         []string{"dashboards:uid:abc"},
+		ActionPrefixes: []string{"dashboards"},
+	})
+	require.NoError(t, err)
+
+	granted := make([]string, 0)
+	for _, a := range assignments {
+		if a.Action == "" {
+			continue
+		}
+		assert.Equal(t, "dashboards:uid:abc", a.Scope)
+		if a.UserID == 0 {
+			granted = append(granted, a.BuiltInRole+" "+a.Action)
+			continue
+		}
+		assert.Equal(t, user.Id, a.UserID)
+		granted = append(granted, a.RoleName+" "+a.Action)
+	}
+	assert.ElementsMatch(t, []string{
+		accesscontrol.ManagedUserRoleName(user.Id) + " dashboards:read",
+		accesscontrol.ManagedTeamRoleName(team.Id) + " dashboards:write",
+		"Viewer dashboards:read",
+	}, granted)
+}
+
 func createUserAndTeam(t *testing.T, sql *sqlstore.SQLStore, orgID int64) (*models.User, models.Team) {
 	t.Helper()
 
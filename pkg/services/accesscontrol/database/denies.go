@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *AccessControlStore) GetDenyPermissions(ctx context.Context, orgID int64) ([]accesscontrol.DenyPermission, error) {
+	result := make([]accesscontrol.DenyPermission, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT
+			p.id,
+			p.action,
+			p.scope,
+			p.created,
+			COALESCE(ur.user_id, 0) AS user_id,
+			COALESCE(tr.team_id, 0) AS team_id,
+			COALESCE(br.role, '') AS built_in_role
+			FROM permission AS p
+			INNER JOIN role AS r ON r.id = p.role_id
+			LEFT JOIN user_role AS ur ON ur.role_id = r.id
+			LEFT JOIN team_role AS tr ON tr.role_id = r.id
+			LEFT JOIN builtin_role AS br ON br.role_id = r.id
+			WHERE r.org_id = ? AND r.name LIKE 'managed:%' AND p.deny = ` + s.sql.Dialect.BooleanStr(true) + `
+			ORDER BY p.id
+		`
+		return sess.SQL(q, orgID).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) AddDenyPermission(ctx context.Context, orgID int64, cmd accesscontrol.AddDenyPermissionCommand) (*accesscontrol.DenyPermission, error) {
+	if err := validateDenyPermission(cmd); err != nil {
+		return nil, err
+	}
+
+	var result *accesscontrol.DenyPermission
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		result, _, err = s.addDenyPermission(sess, orgID, cmd)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	return result, nil
+}
+
+// addDenyPermission stores the deny permission in the managed role of its subject. It returns true when the
+// permission has been added or a grant turned into a deny, false when the action was already denied on the scope.
+func (s *AccessControlStore) addDenyPermission(sess *sqlstore.DBSession, orgID int64, cmd accesscontrol.AddDenyPermissionCommand) (*accesscontrol.DenyPermission, bool, error) {
+	var roleName string
+	var adder roleAdder
+	switch {
+	case cmd.UserID != 0:
+		roleName, adder = accesscontrol.ManagedUserRoleName(cmd.UserID), s.userAdder(sess, orgID, cmd.UserID)
+	case cmd.TeamID != 0:
+		roleName, adder = accesscontrol.ManagedTeamRoleName(cmd.TeamID), s.teamAdder(sess, orgID, cmd.TeamID)
+	default:
+		roleName, adder = accesscontrol.ManagedBuiltInRoleName(cmd.BuiltInRole), s.builtInRoleAdder(sess, orgID, cmd.BuiltInRole)
+	}
+
+	role, err := s.getOrCreateManagedRole(sess, orgID, roleName, adder)
+	if err != nil {
+		return nil, false, err
+	}
+
+	permission := accesscontrol.Permission{}
+	has, err := sess.Where("role_id = ? AND action = ? AND scope = ?", role.ID, cmd.Action, cmd.Scope).Get(&permission)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := !has || !permission.Deny
+	if has && !permission.Deny {
+		// The deny replaces the permission granted to the subject on the same scope
+		permission.Deny = true
+		permission.Updated = time.Now()
+		if _, err := sess.ID(permission.ID).Cols("deny", "updated").Update(&permission); err != nil {
+			return nil, false, err
+		}
+	} else if !has {
+		permission = accesscontrol.Permission{
+			RoleID:  role.ID,
+			Action:  cmd.Action,
+			Scope:   cmd.Scope,
+			Deny:    true,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}
+		if _, err := sess.Insert(&permission); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return &accesscontrol.DenyPermission{
+		ID:          permission.ID,
+		Action:      permission.Action,
+		Scope:       permission.Scope,
+		UserID:      cmd.UserID,
+		TeamID:      cmd.TeamID,
+		BuiltInRole: cmd.BuiltInRole,
+		Created:     permission.Created,
+	}, changed, nil
+}
+
+func (s *AccessControlStore) RemoveDenyPermission(ctx context.Context, orgID, id int64) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `DELETE FROM permission
+			WHERE id = ? AND deny = ` + s.sql.Dialect.BooleanStr(true) + `
+			AND role_id IN (SELECT id FROM role WHERE org_id = ? AND name LIKE 'managed:%')
+		`
+		res, err := sess.Exec(q, id, orgID)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return accesscontrol.ErrDenyPermissionNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	return nil
+}
+
+func validateDenyPermission(cmd accesscontrol.AddDenyPermissionCommand) error {
+	if cmd.Action == "" {
+		return fmt.Errorf("%w: missing action", accesscontrol.ErrInvalidDenyPermission)
+	}
+	// Denying the management of denies could lock everyone out of removing them
+	if cmd.Action == accesscontrol.ActionPermissionsDeniesRead || cmd.Action == accesscontrol.ActionPermissionsDeniesWrite {
+		return fmt.Errorf("%w: cannot deny %s", accesscontrol.ErrInvalidDenyPermission, cmd.Action)
+	}
+	if cmd.Scope != "" && !accesscontrol.ValidateScope(cmd.Scope) {
+		return fmt.Errorf("%w: invalid scope %s", accesscontrol.ErrInvalidDenyPermission, cmd.Scope)
+	}
+
+	subjects := 0
+	if cmd.UserID != 0 {
+		subjects++
+	}
+	if cmd.TeamID != 0 {
+		subjects++
+	}
+	if cmd.BuiltInRole != "" {
+		if !models.RoleType(cmd.BuiltInRole).IsValid() {
+			return fmt.Errorf("%w: invalid built-in role %s", accesscontrol.ErrInvalidDenyPermission, cmd.BuiltInRole)
+		}
+		subjects++
+	}
+	if subjects != 1 {
+		return fmt.Errorf("%w: expected exactly one of userId, teamId or builtInRole", accesscontrol.ErrInvalidDenyPermission)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_DenyPermissions(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+	ctx := context.Background()
+
+	_, err := store.SetUserResourcePermission(ctx, 1, user.Id, accesscontrol.SetResourcePermissionCommand{
+		Actions:           []string{"datasources:query"},
+		Resource:          "datasources",
+		ResourceID:        "abc",
+		ResourceAttribute: "uid",
+	})
+	require.NoError(t, err)
+
+	var teamDeny, builtInDeny *accesscontrol.DenyPermission
+
+	t.Run("should add deny permissions", func(t *testing.T) {
+		teamDeny, err = store.AddDenyPermission(ctx, 1, accesscontrol.AddDenyPermissionCommand{
+			Action: "datasources:query", Scope: "datasources:uid:abc", TeamID: team.Id,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, team.Id, teamDeny.TeamID)
+
+		builtInDeny, err = store.AddDenyPermission(ctx, 1, accesscontrol.AddDenyPermissionCommand{
+			Action: "datasources:write", BuiltInRole: "Editor",
+		})
+		require.NoError(t, err)
+
+		permissions, err := store.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{
+			OrgID: 1, UserID: user.Id, Roles: []string{"Editor", "Viewer"},
+		})
+		require.NoError(t, err)
+		grouped := accesscontrol.GroupScopesByAction(permissions)
+		assert.Equal(t, []string{"datasources:uid:abc"}, grouped["datasources:query"])
+		assert.Equal(t, []string{"datasources:uid:abc"}, grouped[accesscontrol.DenyKey("datasources:query")])
+		assert.Equal(t, []string{""}, grouped[accesscontrol.DenyKey("datasources:write")])
+	})
+
+	t.Run("should replace the permission granted on the same scope", func(t *testing.T) {
+		deny, err := store.AddDenyPermission(ctx, 1, accesscontrol.AddDenyPermissionCommand{
+			Action: "datasources:query", Scope: "datasources:uid:abc", UserID: user.Id,
+		})
+		require.NoError(t, err)
+
+		permissions, err := store.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+		require.NoError(t, err)
+		grouped := accesscontrol.GroupScopesByAction(permissions)
+		assert.Empty(t, grouped["datasources:query"])
+
+		// Setting the permission on the resource again replaces the deny
+		_, err = store.SetUserResourcePermission(ctx, 1, user.Id, accesscontrol.SetResourcePermissionCommand{
+			Actions:           []string{"datasources:query"},
+			Resource:          "datasources",
+			ResourceID:        "abc",
+			ResourceAttribute: "uid",
+		})
+		require.NoError(t, err)
+		assert.ErrorIs(t, store.RemoveDenyPermission(ctx, 1, deny.ID), accesscontrol.ErrDenyPermissionNotFound)
+	})
+
+	t.Run("should not return denies as resource permissions", func(t *testing.T) {
+		permissions, err := store.GetResourcesPermissions(ctx, 1, accesscontrol.GetResourcesPermissionsQuery{
+			Actions:           []string{"datasources:query"},
+			Resource:          "datasources",
+			ResourceIDs:       []string{"abc"},
+			ResourceAttribute: "uid",
+		})
+		require.NoError(t, err)
+		require.Len(t, permissions, 1)
+		assert.Equal(t, user.Id, permissions[0].UserId)
+	})
+
+	t.Run("should list deny permissions of the org", func(t *testing.T) {
+		denies, err := store.GetDenyPermissions(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, denies, 2)
+		assert.Equal(t, teamDeny.ID, denies[0].ID)
+		assert.Equal(t, team.Id, denies[0].TeamID)
+		assert.Equal(t, "Editor", denies[1].BuiltInRole)
+		assert.Equal(t, "datasources:write", denies[1].Action)
+
+		denies, err = store.GetDenyPermissions(ctx, 2)
+		require.NoError(t, err)
+		assert.Len(t, denies, 0)
+	})
+
+	t.Run("should fail on invalid deny permissions", func(t *testing.T) {
+		for _, cmd := range []accesscontrol.AddDenyPermissionCommand{
+			{Scope: "datasources:*", UserID: user.Id},
+			{Action: "datasources:query", Scope: "datasources:*"},
+			{Action: "datasources:query", UserID: user.Id, TeamID: team.Id},
+			{Action: "datasources:query", Scope: "datasources:*:abc", UserID: user.Id},
+			{Action: "datasources:query", BuiltInRole: "Owner"},
+			{Action: accesscontrol.ActionPermissionsDeniesWrite, BuiltInRole: "Editor"},
+		} {
+			_, err := store.AddDenyPermission(ctx, 1, cmd)
+			assert.ErrorIs(t, err, accesscontrol.ErrInvalidDenyPermission)
+		}
+	})
+
+	t.Run("should remove deny permissions", func(t *testing.T) {
+		require.NoError(t, store.RemoveDenyPermission(ctx, 1, builtInDeny.ID))
+		assert.ErrorIs(t, store.RemoveDenyPermission(ctx, 1, builtInDeny.ID), accesscontrol.ErrDenyPermissionNotFound)
+		// Deny permissions of other organizations cannot be removed
+		assert.ErrorIs(t, store.RemoveDenyPermission(ctx, 2, teamDeny.ID), accesscontrol.ErrDenyPermissionNotFound)
+
+		denies, err := store.GetDenyPermissions(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, denies, 1)
+	})
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *AccessControlStore) GetGroupMappings(ctx context.Context, orgID int64) ([]*accesscontrol.GroupMappingDTO, error) {
+	result := make([]*accesscontrol.GroupMappingDTO, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT gm.*, role.uid AS role_uid, role.name AS role_name
+			FROM group_mapping AS gm
+			INNER JOIN role ON role.id = gm.role_id
+			WHERE gm.org_id = ?
+			ORDER BY gm.id
+		`
+		return sess.SQL(q, orgID).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) AddGroupMapping(ctx context.Context, cmd accesscontrol.AddGroupMappingCommand) (*accesscontrol.GroupMapping, error) {
+	var mapping *accesscontrol.GroupMapping
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		mapping, err = addGroupMapping(sess, cmd, false)
+		return err
+	})
+
+	return mapping, err
+}
+
+func (s *AccessControlStore) RemoveGroupMapping(ctx context.Context, orgID, id int64) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		mapping := accesscontrol.GroupMapping{}
+		has, err := sess.Where("org_id = ? AND id = ?", orgID, id).Get(&mapping)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrGroupMappingNotFound
+		}
+		if mapping.Provisioned {
+			return accesscontrol.ErrGroupMappingProvisioned
+		}
+
+		_, err = sess.Exec("DELETE FROM group_mapping WHERE id = ?", id)
+		return err
+	})
+}
+
+func (s *AccessControlStore) SetProvisionedGroupMappings(ctx context.Context, cmds []accesscontrol.AddGroupMappingCommand) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM group_mapping WHERE provisioned = " + s.sql.Dialect.BooleanStr(true)); err != nil {
+			return err
+		}
+
+		for _, cmd := range cmds {
+			if _, err := addGroupMapping(sess, cmd, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addGroupMapping stores a group mapping. Provisioning a mapping that already exists takes it over from the API.
+func addGroupMapping(sess *sqlstore.DBSession, cmd accesscontrol.AddGroupMappingCommand, provisioned bool) (*accesscontrol.GroupMapping, error) {
+	role, err := getOrCreateRole(sess, cmd.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := accesscontrol.GroupMapping{}
+	has, err := sess.Where("org_id = ? AND auth_module = ? AND group_id = ? AND role_id = ?", cmd.OrgID, cmd.AuthModule, cmd.GroupID, role.ID).Get(&mapping)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		if !provisioned {
+			return nil, accesscontrol.ErrGroupMappingExists
+		}
+		mapping.Provisioned = true
+		mapping.Updated = time.Now()
+		if _, err := sess.ID(mapping.ID).Cols("provisioned", "updated").Update(&mapping); err != nil {
+			return nil, err
+		}
+		return &mapping, nil
+	}
+
+	mapping = accesscontrol.GroupMapping{
+		OrgID:       cmd.OrgID,
+		AuthModule:  cmd.AuthModule,
+		GroupID:     cmd.GroupID,
+		RoleID:      role.ID,
+		Provisioned: provisioned,
+		Created:     time.Now(),
+		Updated:     time.Now(),
+	}
+	if _, err := sess.Insert(&mapping); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (s *AccessControlStore) SyncUserGroupRoles(ctx context.Context, userID int64, authModule string, groups []string) error {
+	if authModule == "" {
+		return nil
+	}
+
+	changed := make(map[int64]struct{})
+	assignmentChanges := make([]*events.RoleAssignmentChanged, 0)
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		mappings := make([]*accesscontrol.GroupMapping, 0)
+		q := `SELECT gm.* FROM group_mapping AS gm
+			WHERE (gm.auth_module = '' OR gm.auth_module = ?)
+			AND gm.org_id IN (SELECT org_id FROM org_user WHERE user_id = ?)
+		`
+		if err := sess.SQL(q, authModule, userID).Find(&mappings); err != nil {
+			return err
+		}
+
+		type assignment struct{ orgID, roleID int64 }
+		type change struct {
+			assignment
+			removed bool
+		}
+		changes := make([]change, 0)
+		desired := make(map[assignment]struct{})
+		for _, m := range mappings {
+			if isMemberOf(groups, m.GroupID) {
+				desired[assignment{m.OrgID, m.RoleID}] = struct{}{}
+			}
+		}
+
+		assigned := make([]*accesscontrol.UserRole, 0)
+		if err := sess.Where("user_id = ?", userID).Find(&assigned); err != nil {
+			return err
+		}
+
+		existing := make(map[assignment]struct{}, len(assigned))
+		for _, ur := range assigned {
+			a := assignment{ur.OrgID, ur.RoleID}
+			existing[a] = struct{}{}
+			// Only the roles synced from the same module are removed, other roles are left as they are
+			if _, ok := desired[a]; ok || ur.AuthModule != authModule {
+				continue
+			}
+			if _, err := sess.Exec("DELETE FROM user_role WHERE id = ?", ur.ID); err != nil {
+				return err
+			}
+			changed[ur.OrgID] = struct{}{}
+			changes = append(changes, change{a, true})
+		}
+
+		for a := range desired {
+			if _, ok := existing[a]; ok {
+				continue
+			}
+			if _, err := sess.Insert(&accesscontrol.UserRole{
+				OrgID:      a.orgID,
+				UserID:     userID,
+				RoleID:     a.roleID,
+				Created:    time.Now(),
+				AuthModule: authModule,
+			}); err != nil {
+				return err
+			}
+			changed[a.orgID] = struct{}{}
+			changes = append(changes, change{a, false})
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+		roleIDs := make([]int64, 0, len(changes))
+		for _, c := range changes {
+			roleIDs = append(roleIDs, c.roleID)
+		}
+		roles := make([]*accesscontrol.Role, 0)
+		if err := sess.In("id", roleIDs).Find(&roles); err != nil {
+			return err
+		}
+		rolesByID := make(map[int64]*accesscontrol.Role, len(roles))
+		for _, r := range roles {
+			rolesByID[r.ID] = r
+		}
+		for _, c := range changes {
+			event := &events.RoleAssignmentChanged{OrgID: c.orgID, UserID: userID, Removed: c.removed}
+			if r, ok := rolesByID[c.roleID]; ok {
+				event.RoleUID, event.RoleName = r.UID, r.Name
+			}
+			assignmentChanges = append(assignmentChanges, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for orgID := range changed {
+		s.publishRolesChanged(ctx, orgID)
+	}
+	for _, event := range assignmentChanges {
+		s.publishRoleAssignmentChanged(ctx, event)
+	}
+	return nil
+}
+
+// isMemberOf returns true if group is one of the groups, "*" matches any group. Groups are compared case
+// insensitively, as LDAP distinguished names are.
+func isMemberOf(groups []string, group string) bool {
+	if group == "*" {
+		return true
+	}
+
+	for _, g := range groups {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
+}
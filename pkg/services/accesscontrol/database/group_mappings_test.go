@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_GroupMappings(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, 1)
+
+	role := func(name string) accesscontrol.Role {
+		return accesscontrol.Role{
+			OrgID:   accesscontrol.GlobalOrgID,
+			UID:     accesscontrol.FixedRoleUID(name),
+			Name:    name,
+			Version: 1,
+		}
+	}
+	reader, writer, admin := role("fixed:test:reader"), role("fixed:test:writer"), role("fixed:test:admin")
+
+	assignedRoles := func(t *testing.T) map[string]string {
+		t.Helper()
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		assigned := make(map[string]string, len(roles))
+		for _, r := range roles {
+			assigned[r.Name] = r.AuthModule
+		}
+		return assigned
+	}
+
+	t.Run("should add group mappings", func(t *testing.T) {
+		_, err := store.AddGroupMapping(context.Background(), accesscontrol.AddGroupMappingCommand{OrgID: 1, AuthModule: "ldap", GroupID: "cn=readers", Role: reader})
+		require.NoError(t, err)
+		_, err = store.AddGroupMapping(context.Background(), accesscontrol.AddGroupMappingCommand{OrgID: 1, GroupID: "writers", Role: writer})
+		require.NoError(t, err)
+
+		_, err = store.AddGroupMapping(context.Background(), accesscontrol.AddGroupMappingCommand{OrgID: 1, GroupID: "writers", Role: writer})
+		assert.ErrorIs(t, err, accesscontrol.ErrGroupMappingExists)
+
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, mappings, 2)
+		assert.Equal(t, "cn=readers", mappings[0].GroupID)
+		assert.Equal(t, reader.UID, mappings[0].RoleUID)
+		assert.Equal(t, writer.Name, mappings[1].RoleName)
+
+		mappings, err = store.GetGroupMappings(context.Background(), 2)
+		require.NoError(t, err)
+		assert.Len(t, mappings, 0)
+	})
+
+	t.Run("should assign the roles of the groups on sync", func(t *testing.T) {
+		require.NoError(t, store.SyncUserGroupRoles(context.Background(), user.Id, "ldap", []string{"CN=Readers", "writers"}))
+		assert.Equal(t, map[string]string{reader.Name: "ldap", writer.Name: "ldap"}, assignedRoles(t))
+	})
+
+	t.Run("should only apply the mappings of the module", func(t *testing.T) {
+		require.NoError(t, store.SyncUserGroupRoles(context.Background(), user.Id, "oauth_generic_oauth", []string{"cn=readers"}))
+		assert.Equal(t, map[string]string{reader.Name: "ldap", writer.Name: "ldap"}, assignedRoles(t))
+	})
+
+	t.Run("should keep roles assigned through the API", func(t *testing.T) {
+		require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, admin, nil))
+		require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, writer, nil))
+
+		require.NoError(t, store.SyncUserGroupRoles(context.Background(), user.Id, "ldap", nil))
+		assert.Equal(t, map[string]string{admin.Name: "", writer.Name: ""}, assignedRoles(t))
+	})
+
+	t.Run("should replace provisioned mappings", func(t *testing.T) {
+		require.NoError(t, store.SetProvisionedGroupMappings(context.Background(), []accesscontrol.AddGroupMappingCommand{
+			{OrgID: 1, GroupID: "admins", Role: admin},
+			{OrgID: 1, GroupID: "writers", Role: writer},
+		}))
+		require.NoError(t, store.SetProvisionedGroupMappings(context.Background(), []accesscontrol.AddGroupMappingCommand{
+			{OrgID: 1, GroupID: "admins", Role: admin},
+		}))
+
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, mappings, 2)
+		assert.False(t, mappings[0].Provisioned)
+		assert.Equal(t, "admins", mappings[1].GroupID)
+		assert.True(t, mappings[1].Provisioned)
+
+		err = store.RemoveGroupMapping(context.Background(), 1, mappings[1].ID)
+		assert.ErrorIs(t, err, accesscontrol.ErrGroupMappingProvisioned)
+	})
+
+	t.Run("should remove group mappings", func(t *testing.T) {
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+
+		require.NoError(t, store.RemoveGroupMapping(context.Background(), 1, mappings[0].ID))
+		err = store.RemoveGroupMapping(context.Background(), 1, mappings[0].ID)
+		assert.ErrorIs(t, err, accesscontrol.ErrGroupMappingNotFound)
+	})
+}
@@ -40,7 +40,7 @@ func (s *AccessControlStore) SetUserResourcePermission(ctx context.Context, orgI
 	var err error
 	var permission *accesscontrol.ResourcePermission
 	err = s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		permission, err = s.setResourcePermission(sess, orgID, managedUserRoleName(userID), s.userAdder(sess, orgID, userID), cmd)
+		permission, err = s.setResourcePermission(sess, orgID, accesscontrol.ManagedUserRoleName(userID), s.userAdder(sess, orgID, userID), cmd)
 		if err != nil {
 			return err
 		}
@@ -51,6 +51,7 @@ func (s *AccessControlStore) SetUserResourcePermission(ctx context.Context, orgI
 		return nil, err
 	}
 
+	s.publishRolesChanged(ctx, orgID)
 	return permission, nil
 }
 
@@ -63,7 +64,7 @@ func (s *AccessControlStore) SetTeamResourcePermission(ctx context.Context, orgI
 	var permission *accesscontrol.ResourcePermission
 
 	err = s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		permission, err = s.setResourcePermission(sess, orgID, managedTeamRoleName(teamID), s.teamAdder(sess, orgID, teamID), cmd)
+		permission, err = s.setResourcePermission(sess, orgID, accesscontrol.ManagedTeamRoleName(teamID), s.teamAdder(sess, orgID, teamID), cmd)
 		if err != nil {
 			return err
 		}
@@ -74,6 +75,7 @@ func (s *AccessControlStore) SetTeamResourcePermission(ctx context.Context, orgI
 		return nil, err
 	}
 
+	s.publishRolesChanged(ctx, orgID)
 	return permission, nil
 }
 
@@ -86,7 +88,7 @@ func (s *AccessControlStore) SetBuiltInResourcePermission(ctx context.Context, o
 	var permission *accesscontrol.ResourcePermission
 
 	err = s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		permission, err = s.setResourcePermission(sess, orgID, managedBuiltInRoleName(builtInRole), s.builtInRoleAdder(sess, orgID, builtInRole), cmd)
+		permission, err = s.setResourcePermission(sess, orgID, accesscontrol.ManagedBuiltInRoleName(builtInRole), s.builtInRoleAdder(sess, orgID, builtInRole), cmd)
 		return err
 	})
 
@@ -94,6 +96,7 @@ func (s *AccessControlStore) SetBuiltInResourcePermission(ctx context.Context, o
 		return nil, err
 	}
 
+	s.publishRolesChanged(ctx, orgID)
 	return permission, nil
 }
 
@@ -117,7 +120,7 @@ func (s *AccessControlStore) setResourcePermission(
 	`
 
 	var current []accesscontrol.Permission
-	if err := sess.SQL(rawSQL, role.ID, accesscontrol.GetResourceScope(cmd.Resource, cmd.ResourceID)).Find(&current); err != nil {
+	if err := sess.SQL(rawSQL, role.ID, accesscontrol.GetResourceAttributeScope(cmd.Resource, cmd.ResourceAttribute, cmd.ResourceID)).Find(&current); err != nil {
 		return nil, err
 	}
 
@@ -129,6 +132,13 @@ func (s *AccessControlStore) setResourcePermission(
 	var keep []int64
 	var remove []int64
 	for _, p := range current {
+		if p.Deny {
+			// Denies are managed apart, setting the permission on the resource only replaces the denies of its actions
+			if _, ok := missing[p.Action]; ok {
+				remove = append(remove, p.ID)
+			}
+			continue
+		}
 		if _, ok := missing[p.Action]; ok {
 			keep = append(keep, p.ID)
 			delete(missing, p.Action)
@@ -144,7 +154,7 @@ func (s *AccessControlStore) setResourcePermission(
 	var permissions []flatResourcePermission
 
 	for action := range missing {
-		p, err := s.createResourcePermission(sess, role.ID, action, cmd.Resource, cmd.ResourceID)
+		p, err := s.createResourcePermission(sess, role.ID, action, cmd)
 		if err != nil {
 			return nil, err
 		}
@@ -176,8 +186,8 @@ func (s *AccessControlStore) GetResourcesPermissions(ctx context.Context, orgID
 	return result, err
 }
 
-func (s *AccessControlStore) createResourcePermission(sess *sqlstore.DBSession, roleID int64, action, resource string, resourceID string) (*flatResourcePermission, error) {
-	permission := managedPermission(action, resource, resourceID)
+func (s *AccessControlStore) createResourcePermission(sess *sqlstore.DBSession, roleID int64, action string, cmd accesscontrol.SetResourcePermissionCommand) (*flatResourcePermission, error) {
+	permission := managedPermission(action, cmd.Resource, cmd.ResourceAttribute, cmd.ResourceID)
 	permission.RoleID = roleID
 	permission.Created = time.Now()
 	permission.Updated = time.Now()
@@ -207,7 +217,7 @@ func (s *AccessControlStore) createResourcePermission(sess *sqlstore.DBSession,
 	`
 
 	p := &flatResourcePermission{}
-	if _, err := sess.SQL(rawSql, resourceID, permission.ID).Get(p); err != nil {
+	if _, err := sess.SQL(rawSql, cmd.ResourceID, permission.ID).Get(p); err != nil {
 		return nil, err
 	}
 
@@ -279,6 +289,7 @@ func (s *AccessControlStore) getResourcesPermissions(sess *sqlstore.DBSession, o
 	WHERE (r.org_id = ? OR r.org_id = 0)
 		AND (p.scope = '*' OR p.scope = ? OR p.scope = ? OR p.scope IN (?` + strings.Repeat(",?", len(query.ResourceIDs)-1) + `))
 		AND p.action IN (?` + strings.Repeat(",?", len(query.Actions)-1) + `)
+		AND p.deny = ` + s.sql.Dialect.BooleanStr(false) + `
 	`
 
 	if query.OnlyManaged {
@@ -289,11 +300,11 @@ func (s *AccessControlStore) getResourcesPermissions(sess *sqlstore.DBSession, o
 		orgID,
 		orgID,
 		accesscontrol.GetResourceAllScope(query.Resource),
-		accesscontrol.GetResourceAllIDScope(query.Resource),
+		accesscontrol.GetResourceAllAttributeScope(query.Resource, query.ResourceAttribute),
 	}
 
 	for _, id := range query.ResourceIDs {
-		args = append(args, accesscontrol.GetResourceScope(query.Resource, id))
+		args = append(args, accesscontrol.GetResourceAttributeScope(query.Resource, query.ResourceAttribute, id))
 	}
 
 	for _, a := range query.Actions {
@@ -316,12 +327,12 @@ func (s *AccessControlStore) getResourcesPermissions(sess *sqlstore.DBSession, o
 	}
 
 	scopeAll := accesscontrol.GetResourceAllScope(query.Resource)
-	scopeAllIDs := accesscontrol.GetResourceAllIDScope(query.Resource)
+	scopeAllIDs := accesscontrol.GetResourceAllAttributeScope(query.Resource, query.ResourceAttribute)
 
 	byResource := make(map[string][]flatResourcePermission)
-	// Add resourceIds and generate permissions for `*`, `resource:*` and `resource:id:*`
+	// Add resourceIds and generate permissions for `*`, `resource:*` and `resource:<attribute>:*`
 	for _, id := range query.ResourceIDs {
-		scope := accesscontrol.GetResourceScope(query.Resource, id)
+		scope := accesscontrol.GetResourceAttributeScope(query.Resource, query.ResourceAttribute, id)
 		for _, p := range queryResults {
 			if p.Scope == scope || p.Scope == scopeAll || p.Scope == scopeAllIDs || p.Scope == "*" {
 				p.ResourceID = id
@@ -549,21 +560,9 @@ func (s *AccessControlStore) getResourcePermissions(sess *sqlstore.DBSession, re
 	return result, nil
 }
 
-func managedPermission(action, resource string, resourceID string) accesscontrol.Permission {
+func managedPermission(action, resource, attribute, resourceID string) accesscontrol.Permission {
 	return accesscontrol.Permission{
 		Action: action,
-		Scope:  accesscontrol.GetResourceScope(resource, resourceID),
+		Scope:  accesscontrol.GetResourceAttributeScope(resource, attribute, resourceID),
 	}
 }
-
-func managedUserRoleName(userID int64) string {
-	return fmt.Sprintf("managed:users:%d:permissions", userID)
-}
-
-func managedTeamRoleName(teamID int64) string {
-	return fmt.Sprintf("managed:teams:%d:permissions", teamID)
-}
-
-func managedBuiltInRoleName(builtInRole string) string {
-	return fmt.Sprintf("managed:builtins:%s:permissions", strings.ToLower(builtInRole))
-}
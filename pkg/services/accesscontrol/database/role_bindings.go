@@ -0,0 +1,358 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// roleNameMaxLength is the length of the name column of the role table
+const roleNameMaxLength = 190
+
+func (s *AccessControlStore) GetRoleBindings(ctx context.Context, orgID int64) ([]*accesscontrol.RoleBinding, error) {
+	result := make([]*accesscontrol.RoleBinding, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).OrderBy("id").Find(&result)
+	})
+
+	return result, err
+}
+
+type roleBindingKey struct {
+	orgID, userID, teamID, roleID int64
+}
+
+func (s *AccessControlStore) SetProvisionedRoleBindings(ctx context.Context, cmds []accesscontrol.AddRoleBindingCommand) error {
+	changed := make(map[int64]struct{})
+	assignmentChanges := make([]*events.RoleAssignmentChanged, 0)
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		existing := make([]*accesscontrol.RoleBinding, 0)
+		if err := sess.Find(&existing); err != nil {
+			return err
+		}
+		bindings := make(map[roleBindingKey]*accesscontrol.RoleBinding, len(existing))
+		for _, b := range existing {
+			bindings[roleBindingKey{b.OrgID, b.UserID, b.TeamID, b.RoleID}] = b
+		}
+
+		desired := make(map[roleBindingKey]struct{}, len(cmds))
+		for _, cmd := range cmds {
+			key, role, skipped, err := s.bindRole(sess, cmd, changed)
+			if err != nil {
+				return err
+			}
+			if skipped {
+				continue
+			}
+			desired[key] = struct{}{}
+
+			if b, ok := bindings[key]; ok {
+				if b.RoleName != cmd.Role || b.Scope != cmd.Scope {
+					b.RoleName, b.Scope, b.Updated = cmd.Role, cmd.Scope, time.Now()
+					if _, err := sess.ID(b.ID).Cols("role_name", "scope", "updated").Update(b); err != nil {
+						return err
+					}
+				}
+			} else {
+				b := &accesscontrol.RoleBinding{
+					OrgID:    key.orgID,
+					UserID:   key.userID,
+					TeamID:   key.teamID,
+					RoleID:   key.roleID,
+					RoleName: cmd.Role,
+					Scope:    cmd.Scope,
+					Created:  time.Now(),
+					Updated:  time.Now(),
+				}
+				if _, err := sess.Insert(b); err != nil {
+					return err
+				}
+				bindings[key] = b
+			}
+
+			added, err := addRoleBindingAssignment(sess, key)
+			if err != nil {
+				return err
+			}
+			if added {
+				changed[key.orgID] = struct{}{}
+				assignmentChanges = append(assignmentChanges, &events.RoleAssignmentChanged{
+					OrgID: key.orgID, RoleUID: role.UID, RoleName: role.Name, UserID: key.userID, TeamID: key.teamID,
+				})
+			}
+		}
+
+		for key, b := range bindings {
+			if _, ok := desired[key]; ok {
+				continue
+			}
+			removed, err := removeRoleBinding(sess, b)
+			if err != nil {
+				return err
+			}
+			changed[key.orgID] = struct{}{}
+			if removed {
+				event := &events.RoleAssignmentChanged{OrgID: b.OrgID, UserID: b.UserID, TeamID: b.TeamID, Removed: true}
+				role := accesscontrol.Role{}
+				if has, err := sess.ID(b.RoleID).Get(&role); err != nil {
+					return err
+				} else if has {
+					event.RoleUID, event.RoleName = role.UID, role.Name
+				}
+				assignmentChanges = append(assignmentChanges, event)
+			}
+		}
+
+		return deleteUnboundRoles(sess)
+	})
+	if err != nil {
+		return err
+	}
+
+	for orgID := range changed {
+		s.publishRolesChanged(ctx, orgID)
+	}
+	for _, event := range assignmentChanges {
+		s.publishRoleAssignmentChanged(ctx, event)
+	}
+	return nil
+}
+
+// bindRole resolves the subject and the role of a binding. The role of a scoped binding holds the permissions of the
+// bound role restricted to the scope, it is stored or its permissions updated when needed. Bindings to users and
+// teams that do not exist are skipped.
+func (s *AccessControlStore) bindRole(sess *sqlstore.DBSession, cmd accesscontrol.AddRoleBindingCommand, changed map[int64]struct{}) (roleBindingKey, *accesscontrol.Role, bool, error) {
+	key := roleBindingKey{orgID: cmd.OrgID}
+	if err := validateRoleBinding(cmd); err != nil {
+		return key, nil, false, err
+	}
+
+	if cmd.User != "" {
+		has, err := sess.SQL(`SELECT u.id FROM `+s.sql.Dialect.Quote("user")+` AS u
+			INNER JOIN org_user AS ou ON ou.user_id = u.id
+			WHERE ou.org_id = ? AND (u.login = ? OR u.email = ?)`, cmd.OrgID, cmd.User, cmd.User).Get(&key.userID)
+		if err != nil {
+			return key, nil, false, err
+		}
+		if !has {
+			logger.Warn("Skipping role binding to unknown user", "orgID", cmd.OrgID, "role", cmd.Role, "user", cmd.User)
+			return key, nil, true, nil
+		}
+	} else {
+		team := models.Team{}
+		has, err := sess.Where("org_id = ? AND name = ?", cmd.OrgID, cmd.Team).Get(&team)
+		if err != nil {
+			return key, nil, false, err
+		}
+		if !has {
+			logger.Warn("Skipping role binding to unknown team", "orgID", cmd.OrgID, "role", cmd.Role, "team", cmd.Team)
+			return key, nil, true, nil
+		}
+		key.teamID = team.Id
+	}
+
+	var role *accesscontrol.Role
+	var permissions []accesscontrol.Permission
+	if strings.HasPrefix(cmd.Role, accesscontrol.FixedRolePrefix) {
+		fixed, ok := accesscontrol.FixedRoles[cmd.Role]
+		if !ok {
+			return key, nil, false, fmt.Errorf("role '%s': %w", cmd.Role, accesscontrol.ErrRoleNotFound)
+		}
+		stored, err := getOrCreateRole(sess, accesscontrol.Role{
+			OrgID:   accesscontrol.GlobalOrgID,
+			UID:     accesscontrol.FixedRoleUID(cmd.Role),
+			Name:    cmd.Role,
+			Version: 1,
+		})
+		if err != nil {
+			return key, nil, false, err
+		}
+		role, permissions = stored, fixed.Permissions
+	} else {
+		stored := accesscontrol.Role{}
+		has, err := sess.Where("name = ? AND (org_id = ? OR org_id = ?)", cmd.Role, cmd.OrgID, globalOrgID).Get(&stored)
+		if err != nil {
+			return key, nil, false, err
+		}
+		if !has {
+			return key, nil, false, fmt.Errorf("role '%s' of organization %d: %w", cmd.Role, cmd.OrgID, accesscontrol.ErrRoleNotFound)
+		}
+		if cmd.Scope != "" {
+			if err := sess.Where("role_id = ?", stored.ID).Find(&permissions); err != nil {
+				return key, nil, false, err
+			}
+		}
+		role = &stored
+	}
+
+	if cmd.Scope == "" {
+		key.roleID = role.ID
+		return key, role, false, nil
+	}
+
+	scoped := accesscontrol.ScopePermissions(permissions, cmd.Scope)
+	if len(scoped) == 0 {
+		return key, nil, false, fmt.Errorf("%w: role '%s' has no permission on scope %s", accesscontrol.ErrInvalidRoleBinding, cmd.Role, cmd.Scope)
+	}
+	bound, err := getOrCreateRole(sess, accesscontrol.Role{
+		OrgID:   cmd.OrgID,
+		UID:     accesscontrol.RoleBindingRoleUID(cmd.OrgID, cmd.Role, cmd.Scope),
+		Name:    accesscontrol.RoleBindingRoleName(cmd.Role, cmd.Scope),
+		Version: 1,
+	})
+	if err != nil {
+		return key, nil, false, err
+	}
+	updated, err := setRoleBindingPermissions(sess, bound.ID, scoped)
+	if err != nil {
+		return key, nil, false, err
+	}
+	if updated {
+		changed[cmd.OrgID] = struct{}{}
+	}
+
+	key.roleID = bound.ID
+	return key, bound, false, nil
+}
+
+func validateRoleBinding(cmd accesscontrol.AddRoleBindingCommand) error {
+	if (cmd.User == "") == (cmd.Team == "") {
+		return fmt.Errorf("%w: role '%s' should be bound to either a user or a team", accesscontrol.ErrInvalidRoleBinding, cmd.Role)
+	}
+	if cmd.Role == "" {
+		return fmt.Errorf("%w: binding without role", accesscontrol.ErrInvalidRoleBinding)
+	}
+	if strings.HasPrefix(cmd.Role, accesscontrol.ManagedRolePrefix) || strings.HasPrefix(cmd.Role, accesscontrol.RoleBindingRolePrefix) {
+		return fmt.Errorf("%w: role '%s' cannot be bound", accesscontrol.ErrInvalidRoleBinding, cmd.Role)
+	}
+	if cmd.Scope != "" {
+		if !accesscontrol.ValidateScope(cmd.Scope) {
+			return fmt.Errorf("%w: invalid scope %s", accesscontrol.ErrInvalidRoleBinding, cmd.Scope)
+		}
+		if len(accesscontrol.RoleBindingRoleName(cmd.Role, cmd.Scope)) > roleNameMaxLength {
+			return fmt.Errorf("%w: scope %s of role '%s' is too long", accesscontrol.ErrInvalidRoleBinding, cmd.Scope, cmd.Role)
+		}
+	}
+	return nil
+}
+
+// setRoleBindingPermissions replaces the permissions of a binding role when they differ. It returns true when they
+// have been replaced.
+func setRoleBindingPermissions(sess *sqlstore.DBSession, roleID int64, permissions []accesscontrol.Permission) (bool, error) {
+	stored := make([]*accesscontrol.Permission, 0)
+	if err := sess.Where("role_id = ?", roleID).Find(&stored); err != nil {
+		return false, err
+	}
+	wanted := make([]accesscontrol.RoleBundlePermission, 0, len(permissions))
+	for _, p := range permissions {
+		wanted = append(wanted, accesscontrol.RoleBundlePermission{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+	}
+	if samePermissions(stored, wanted) {
+		return false, nil
+	}
+
+	if _, err := sess.Exec("DELETE FROM permission WHERE role_id = ?", roleID); err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if _, err := sess.Insert(&accesscontrol.Permission{
+			RoleID:  roleID,
+			Action:  p.Action,
+			Scope:   p.Scope,
+			Deny:    p.Deny,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// addRoleBindingAssignment assigns the role of a binding to its user or team. It returns true when the role was not
+// assigned yet.
+func addRoleBindingAssignment(sess *sqlstore.DBSession, key roleBindingKey) (bool, error) {
+	if key.userID != 0 {
+		exists, err := sess.Where("org_id = ? AND user_id = ? AND role_id = ?", key.orgID, key.userID, key.roleID).Exist(&accesscontrol.UserRole{})
+		if err != nil || exists {
+			return false, err
+		}
+		_, err = sess.Insert(&accesscontrol.UserRole{OrgID: key.orgID, UserID: key.userID, RoleID: key.roleID, Created: time.Now()})
+		return err == nil, err
+	}
+
+	exists, err := sess.Where("org_id = ? AND team_id = ? AND role_id = ?", key.orgID, key.teamID, key.roleID).Exist(&accesscontrol.TeamRole{})
+	if err != nil || exists {
+		return false, err
+	}
+	_, err = sess.Insert(&accesscontrol.TeamRole{OrgID: key.orgID, TeamID: key.teamID, RoleID: key.roleID, Created: time.Now()})
+	return err == nil, err
+}
+
+// removeRoleBinding removes a binding and the role assignment it provisioned. It returns true when the role was
+// still assigned.
+func removeRoleBinding(sess *sqlstore.DBSession, b *accesscontrol.RoleBinding) (bool, error) {
+	if _, err := sess.Exec("DELETE FROM role_binding WHERE id = ?", b.ID); err != nil {
+		return false, err
+	}
+
+	q := "DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?"
+	subjectID := b.TeamID
+	if b.UserID != 0 {
+		q = "DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?"
+		subjectID = b.UserID
+	}
+	res, err := sess.Exec(q, b.OrgID, subjectID, b.RoleID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// deleteUnboundRoles deletes the binding roles no binding refers to anymore, with their permissions and assignments
+func deleteUnboundRoles(sess *sqlstore.DBSession) error {
+	roleIDs := make([]int64, 0)
+	q := `SELECT id FROM role
+		WHERE name LIKE '` + accesscontrol.RoleBindingRolePrefix + `%'
+		AND id NOT IN (SELECT role_id FROM role_binding)
+	`
+	if err := sess.SQL(q).Find(&roleIDs); err != nil {
+		return err
+	}
+
+	for _, id := range roleIDs {
+		for _, table := range []string{"permission", "user_role", "team_role", "builtin_role"} {
+			if _, err := sess.Exec("DELETE FROM "+table+" WHERE role_id = ?", id); err != nil {
+				return err
+			}
+		}
+		if _, err := sess.Exec("DELETE FROM role WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleBindingExists returns ErrRoleBindingProvisioned when the role is assigned to the user or the team by a role
+// binding, such assignments are removed from the provisioning files only
+func roleBindingExists(sess *sqlstore.DBSession, subjectColumn string, orgID, subjectID int64, roleUID string) error {
+	var id int64
+	q := `SELECT rb.id FROM role_binding AS rb
+		INNER JOIN role ON role.id = rb.role_id
+		WHERE rb.org_id = ? AND rb.` + subjectColumn + ` = ? AND role.uid = ?`
+	has, err := sess.SQL(q, orgID, subjectID, roleUID).Get(&id)
+	if err != nil {
+		return err
+	}
+	if has {
+		return accesscontrol.ErrRoleBindingProvisioned
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_RoleBindings(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+
+	require.NoError(t, store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+		APIVersion: accesscontrol.RoleBundleAPIVersion,
+		Roles: []accesscontrol.RoleBundleRole{
+			{
+				UID:     "custom_editor",
+				Name:    "custom:editor",
+				OrgID:   1,
+				Version: 1,
+				Permissions: []accesscontrol.RoleBundlePermission{
+					{Action: "dashboards:read", Scope: "dashboards:*"},
+					{Action: "dashboards:write", Scope: "dashboards:*"},
+					{Action: "folders:read", Scope: "folders:*"},
+				},
+			},
+		},
+	}))
+
+	userPermissions := func(t *testing.T) map[string][]string {
+		t.Helper()
+		permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+		require.NoError(t, err)
+		grouped := make(map[string][]string)
+		for _, p := range permissions {
+			grouped[p.Action] = append(grouped[p.Action], p.Scope)
+		}
+		return grouped
+	}
+
+	bindings := []accesscontrol.AddRoleBindingCommand{
+		{OrgID: 1, User: user.Login, Role: "custom:editor"},
+		{OrgID: 1, Team: team.Name, Role: "custom:editor", Scope: "dashboards:uid:abc"},
+		{OrgID: 1, User: "unknown", Role: "custom:editor"},
+	}
+
+	t.Run("should assign the bound roles", func(t *testing.T) {
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), bindings))
+
+		stored, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		assert.Equal(t, user.Id, stored[0].UserID)
+		assert.Equal(t, team.Id, stored[1].TeamID)
+		assert.Equal(t, "dashboards:uid:abc", stored[1].Scope)
+
+		roles, err := store.GetTeamRoles(context.Background(), 1, team.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, accesscontrol.RoleBindingRoleName("custom:editor", "dashboards:uid:abc"), roles[0].Name)
+
+		assert.ElementsMatch(t, []string{"dashboards:*", "dashboards:uid:abc"}, userPermissions(t)["dashboards:write"])
+	})
+
+	t.Run("should not change anything when applied again", func(t *testing.T) {
+		before, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), bindings))
+		after, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("should not remove provisioned assignments through the API", func(t *testing.T) {
+		err := store.RemoveUserRole(context.Background(), 1, user.Id, "custom_editor")
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleBindingProvisioned)
+
+		roles, err := store.GetTeamRoles(context.Background(), 1, team.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		err = store.RemoveTeamRole(context.Background(), 1, team.Id, roles[0].UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleBindingProvisioned)
+	})
+
+	t.Run("should unassign the bindings removed from the commands", func(t *testing.T) {
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), bindings[1:2]))
+		assert.Equal(t, []string{"dashboards:uid:abc"}, userPermissions(t)["dashboards:write"])
+		assert.NotContains(t, userPermissions(t), "folders:read")
+
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), nil))
+		assert.Empty(t, userPermissions(t))
+
+		stored, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+
+		count, err := sql.NewSession(context.Background()).Where("name LIKE ?", accesscontrol.RoleBindingRolePrefix+"%").Count(&accesscontrol.Role{})
+		require.NoError(t, err)
+		assert.Zero(t, count, "the roles of the scoped bindings are deleted")
+	})
+
+	t.Run("should restrict the deny permissions of the bound roles to the scope", func(t *testing.T) {
+		require.NoError(t, store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+			APIVersion: accesscontrol.RoleBundleAPIVersion,
+			Roles: []accesscontrol.RoleBundleRole{{
+				Name:  "custom:restricted",
+				OrgID: 1,
+				Permissions: []accesscontrol.RoleBundlePermission{
+					{Action: "dashboards:read", Scope: "dashboards:*"},
+					{Action: "dashboards:write", Scope: "dashboards:*", Deny: true},
+					{Action: "dashboards:delete", Deny: true},
+					{Action: "folders:read", Scope: "folders:uid:abc", Deny: true},
+				},
+			}},
+		}))
+		t.Cleanup(func() {
+			require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), nil))
+		})
+		scopedPermissions := func(t *testing.T) []accesscontrol.Permission {
+			t.Helper()
+			permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+			require.NoError(t, err)
+			result := make([]accesscontrol.Permission, 0, len(permissions))
+			for _, p := range permissions {
+				result = append(result, accesscontrol.Permission{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+			}
+			return result
+		}
+
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), []accesscontrol.AddRoleBindingCommand{
+			{OrgID: 1, User: user.Login, Role: "custom:restricted", Scope: "dashboards:uid:abc"},
+		}))
+		assert.ElementsMatch(t, []accesscontrol.Permission{
+			{Action: "dashboards:read", Scope: "dashboards:uid:abc"},
+			{Action: "dashboards:write", Scope: "dashboards:uid:abc", Deny: true},
+			{Action: "dashboards:delete", Scope: "dashboards:uid:abc", Deny: true},
+		}, scopedPermissions(t))
+
+		// A role only denying actions on the scope can be bound too
+		require.NoError(t, store.SetProvisionedRoleBindings(context.Background(), []accesscontrol.AddRoleBindingCommand{
+			{OrgID: 1, User: user.Login, Role: "custom:restricted", Scope: "folders:uid:abc"},
+		}))
+		assert.ElementsMatch(t, []accesscontrol.Permission{
+			{Action: "dashboards:delete", Scope: "folders:uid:abc", Deny: true},
+			{Action: "folders:read", Scope: "folders:uid:abc", Deny: true},
+		}, scopedPermissions(t))
+	})
+
+	t.Run("should reject invalid bindings", func(t *testing.T) {
+		for _, cmd := range []accesscontrol.AddRoleBindingCommand{
+			{OrgID: 1, Role: "custom:editor"},
+			{OrgID: 1, User: user.Login, Team: team.Name, Role: "custom:editor"},
+			{OrgID: 1, User: user.Login},
+			{OrgID: 1, User: user.Login, Role: "managed:users:1:permissions"},
+			{OrgID: 1, User: user.Login, Role: "custom:editor", Scope: "dashboards:*:abc"},
+			{OrgID: 1, User: user.Login, Role: "custom:editor", Scope: "datasources:uid:abc"},
+		} {
+			err := store.SetProvisionedRoleBindings(context.Background(), []accesscontrol.AddRoleBindingCommand{cmd})
+			assert.ErrorIs(t, err, accesscontrol.ErrInvalidRoleBinding, cmd)
+		}
+
+		err := store.SetProvisionedRoleBindings(context.Background(), []accesscontrol.AddRoleBindingCommand{{OrgID: 1, User: user.Login, Role: "custom:unknown"}})
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+	})
+}
@@ -0,0 +1,484 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *AccessControlStore) ExportRoleBundle(ctx context.Context, orgID int64) (*accesscontrol.RoleBundle, error) {
+	bundle := &accesscontrol.RoleBundle{
+		APIVersion:  accesscontrol.RoleBundleAPIVersion,
+		Roles:       make([]accesscontrol.RoleBundleRole, 0),
+		Assignments: make([]accesscontrol.RoleBundleAssignment, 0),
+		Denies:      make([]accesscontrol.RoleBundleDeny, 0),
+	}
+
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		roles := make([]*accesscontrol.Role, 0)
+		q := `SELECT * FROM role
+			WHERE (org_id = ? OR org_id = ?) AND name NOT LIKE 'fixed:%' AND name NOT LIKE 'managed:%'
+			ORDER BY org_id, name
+		`
+		if err := sess.SQL(q, orgID, globalOrgID).Find(&roles); err != nil {
+			return err
+		}
+
+		for _, r := range roles {
+			permissions := make([]*accesscontrol.Permission, 0)
+			if err := sess.Where("role_id = ?", r.ID).OrderBy("action, scope").Find(&permissions); err != nil {
+				return err
+			}
+
+			role := accesscontrol.RoleBundleRole{
+				UID:         r.UID,
+				Name:        r.Name,
+				OrgID:       r.OrgID,
+				Version:     r.Version,
+				DisplayName: r.DisplayName,
+				Description: r.Description,
+				Group:       r.Group,
+				Permissions: make([]accesscontrol.RoleBundlePermission, 0, len(permissions)),
+			}
+			for _, p := range permissions {
+				role.Permissions = append(role.Permissions, accesscontrol.RoleBundlePermission{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+			}
+			bundle.Roles = append(bundle.Roles, role)
+		}
+
+		type assignmentRow struct {
+			RoleName string `xorm:"role_name"`
+			Kind     string `xorm:"kind"`
+			Subject  string `xorm:"subject"`
+		}
+		rows := make([]assignmentRow, 0)
+		q = `SELECT r.name AS role_name, 'user' AS kind, u.login AS subject
+			FROM user_role AS ur
+			INNER JOIN role AS r ON r.id = ur.role_id
+			INNER JOIN ` + s.sql.Dialect.Quote("user") + ` AS u ON u.id = ur.user_id
+			WHERE ur.org_id = ? AND ur.auth_module = '' AND ur.expires_at IS NULL AND r.name NOT LIKE 'managed:%'
+			UNION ALL
+			SELECT r.name AS role_name, 'team' AS kind, t.name AS subject
+			FROM team_role AS tr
+			INNER JOIN role AS r ON r.id = tr.role_id
+			INNER JOIN team AS t ON t.id = tr.team_id
+			WHERE tr.org_id = ? AND r.name NOT LIKE 'managed:%'
+			UNION ALL
+			SELECT r.name AS role_name, 'builtin' AS kind, br.role AS subject
+			FROM builtin_role AS br
+			INNER JOIN role AS r ON r.id = br.role_id
+			WHERE br.org_id = ? AND r.name NOT LIKE 'fixed:%' AND r.name NOT LIKE 'managed:%'
+			ORDER BY role_name, kind, subject
+		`
+		if err := sess.SQL(q, orgID, orgID, orgID).Find(&rows); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			n := len(bundle.Assignments)
+			if n == 0 || bundle.Assignments[n-1].Role != row.RoleName {
+				bundle.Assignments = append(bundle.Assignments, accesscontrol.RoleBundleAssignment{OrgID: orgID, Role: row.RoleName})
+				n++
+			}
+			assignment := &bundle.Assignments[n-1]
+			switch row.Kind {
+			case "user":
+				assignment.Users = append(assignment.Users, row.Subject)
+			case "team":
+				assignment.Teams = append(assignment.Teams, row.Subject)
+			case "builtin":
+				assignment.BuiltInRoles = append(assignment.BuiltInRoles, row.Subject)
+			}
+		}
+
+		return s.exportBundleDenies(sess, orgID, bundle)
+	})
+
+	return bundle, err
+}
+
+// exportBundleDenies adds the deny permissions stored in the managed roles of the users, teams and built-in roles of
+// the organization, grouped by action and scope
+func (s *AccessControlStore) exportBundleDenies(sess *sqlstore.DBSession, orgID int64, bundle *accesscontrol.RoleBundle) error {
+	type denyRow struct {
+		Action  string `xorm:"action"`
+		Scope   string `xorm:"scope"`
+		Kind    string `xorm:"kind"`
+		Subject string `xorm:"subject"`
+	}
+	rows := make([]denyRow, 0)
+	denied := `permission AS p
+		INNER JOIN role AS r ON r.id = p.role_id`
+	where := `WHERE r.org_id = ? AND r.name LIKE 'managed:%' AND p.deny = ` + s.sql.Dialect.BooleanStr(true)
+	q := `SELECT p.action, p.scope, 'user' AS kind, u.login AS subject
+		FROM ` + denied + `
+		INNER JOIN user_role AS ur ON ur.role_id = r.id
+		INNER JOIN ` + s.sql.Dialect.Quote("user") + ` AS u ON u.id = ur.user_id
+		` + where + `
+		UNION ALL
+		SELECT p.action, p.scope, 'team' AS kind, t.name AS subject
+		FROM ` + denied + `
+		INNER JOIN team_role AS tr ON tr.role_id = r.id
+		INNER JOIN team AS t ON t.id = tr.team_id
+		` + where + `
+		UNION ALL
+		SELECT p.action, p.scope, 'builtin' AS kind, br.role AS subject
+		FROM ` + denied + `
+		INNER JOIN builtin_role AS br ON br.role_id = r.id
+		` + where + `
+		ORDER BY action, scope, kind, subject
+	`
+	if err := sess.SQL(q, orgID, orgID, orgID).Find(&rows); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		n := len(bundle.Denies)
+		if n == 0 || bundle.Denies[n-1].Action != row.Action || bundle.Denies[n-1].Scope != row.Scope {
+			bundle.Denies = append(bundle.Denies, accesscontrol.RoleBundleDeny{OrgID: orgID, Action: row.Action, Scope: row.Scope})
+			n++
+		}
+		deny := &bundle.Denies[n-1]
+		switch row.Kind {
+		case "user":
+			deny.Users = append(deny.Users, row.Subject)
+		case "team":
+			deny.Teams = append(deny.Teams, row.Subject)
+		case "builtin":
+			deny.BuiltInRoles = append(deny.BuiltInRoles, row.Subject)
+		}
+	}
+	return nil
+}
+
+func (s *AccessControlStore) ImportRoleBundle(ctx context.Context, bundle accesscontrol.RoleBundle) error {
+	changed := make(map[int64]struct{})
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, r := range bundle.Roles {
+			roleChanged, err := importBundleRole(sess, r)
+			if err != nil {
+				return err
+			}
+			if roleChanged {
+				changed[r.OrgID] = struct{}{}
+			}
+		}
+
+		for _, a := range bundle.Assignments {
+			assigned, err := s.importBundleAssignment(sess, a)
+			if err != nil {
+				return err
+			}
+			if assigned {
+				changed[a.OrgID] = struct{}{}
+			}
+		}
+
+		for _, d := range bundle.Denies {
+			denied, err := s.importBundleDeny(sess, d)
+			if err != nil {
+				return err
+			}
+			if denied {
+				changed[d.OrgID] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for orgID := range changed {
+		s.publishRolesChanged(ctx, orgID)
+	}
+	return nil
+}
+
+// importBundleRole stores a custom role, looked up by uid or else by name, and replaces its permissions when they
+// differ. It returns true when the role has been inserted or its permissions changed.
+func importBundleRole(sess *sqlstore.DBSession, r accesscontrol.RoleBundleRole) (bool, error) {
+	if r.Name == "" {
+		return false, fmt.Errorf("%w: role without name", accesscontrol.ErrInvalidRoleBundle)
+	}
+	if strings.HasPrefix(r.Name, accesscontrol.FixedRolePrefix) || strings.HasPrefix(r.Name, accesscontrol.ManagedRolePrefix) {
+		return false, fmt.Errorf("%w: '%s' is not a custom role", accesscontrol.ErrInvalidRoleBundle, r.Name)
+	}
+	for _, p := range r.Permissions {
+		if p.Action == "" {
+			return false, fmt.Errorf("%w: permission of '%s' without action", accesscontrol.ErrInvalidRoleBundle, r.Name)
+		}
+		if p.Scope != "" && !accesscontrol.ValidateScope(p.Scope) {
+			return false, fmt.Errorf("%w: invalid scope %s of '%s'", accesscontrol.ErrInvalidRoleBundle, p.Scope, r.Name)
+		}
+	}
+
+	stored := accesscontrol.Role{}
+	var has bool
+	var err error
+	if r.UID != "" {
+		has, err = sess.Where("uid = ?", r.UID).Get(&stored)
+	} else {
+		has, err = sess.Where("org_id = ? AND name = ?", r.OrgID, r.Name).Get(&stored)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	inserted := !has
+	if has {
+		if stored.OrgID != r.OrgID || stored.IsFixed() || stored.IsManaged() {
+			return false, fmt.Errorf("%w: uid %s of '%s' belongs to another role", accesscontrol.ErrInvalidRoleBundle, r.UID, r.Name)
+		}
+		stored.Name = r.Name
+		stored.Version = r.Version
+		stored.DisplayName = r.DisplayName
+		stored.Description = r.Description
+		stored.Group = r.Group
+		stored.Updated = time.Now()
+		if _, err := sess.ID(stored.ID).Cols("name", "version", "display_name", "description", "group_name", "updated").Update(&stored); err != nil {
+			return false, err
+		}
+	} else {
+		uid := r.UID
+		if uid == "" {
+			if uid, err = generateNewRoleUID(sess, r.OrgID); err != nil {
+				return false, err
+			}
+		}
+		stored = accesscontrol.Role{
+			OrgID:       r.OrgID,
+			UID:         uid,
+			Name:        r.Name,
+			Version:     r.Version,
+			DisplayName: r.DisplayName,
+			Description: r.Description,
+			Group:       r.Group,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+		if _, err := sess.Insert(&stored); err != nil {
+			return false, err
+		}
+	}
+
+	permissions := make([]*accesscontrol.Permission, 0)
+	if err := sess.Where("role_id = ?", stored.ID).Find(&permissions); err != nil {
+		return false, err
+	}
+	if samePermissions(permissions, r.Permissions) {
+		return inserted, nil
+	}
+
+	if _, err := sess.Exec("DELETE FROM permission WHERE role_id = ?", stored.ID); err != nil {
+		return false, err
+	}
+	for _, p := range r.Permissions {
+		if _, err := sess.Insert(&accesscontrol.Permission{
+			RoleID:  stored.ID,
+			Action:  p.Action,
+			Scope:   p.Scope,
+			Deny:    p.Deny,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func samePermissions(stored []*accesscontrol.Permission, permissions []accesscontrol.RoleBundlePermission) bool {
+	type key struct {
+		action, scope string
+		deny          bool
+	}
+	existing := make(map[key]struct{}, len(stored))
+	for _, p := range stored {
+		existing[key{p.Action, p.Scope, p.Deny}] = struct{}{}
+	}
+	wanted := make(map[key]struct{}, len(permissions))
+	for _, p := range permissions {
+		wanted[key{p.Action, p.Scope, p.Deny}] = struct{}{}
+	}
+
+	if len(existing) != len(wanted) {
+		return false
+	}
+	for k := range wanted {
+		if _, ok := existing[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// importBundleAssignment adds the missing assignments of the role. Users and teams that do not exist are skipped,
+// so that a bundle can be imported before the users signed in for the first time. It returns true when an
+// assignment has been added.
+func (s *AccessControlStore) importBundleAssignment(sess *sqlstore.DBSession, a accesscontrol.RoleBundleAssignment) (bool, error) {
+	if strings.HasPrefix(a.Role, accesscontrol.ManagedRolePrefix) {
+		return false, fmt.Errorf("%w: managed role '%s' cannot be assigned", accesscontrol.ErrInvalidRoleBundle, a.Role)
+	}
+	if err := accesscontrol.ValidateBuiltInRoles(a.BuiltInRoles); err != nil {
+		return false, fmt.Errorf("%w: %s", accesscontrol.ErrInvalidRoleBundle, err)
+	}
+
+	var role *accesscontrol.Role
+	if strings.HasPrefix(a.Role, accesscontrol.FixedRolePrefix) {
+		// The permissions of fixed roles are not stored, they are granted to built-in roles by Grafana only
+		if len(a.BuiltInRoles) > 0 {
+			return false, fmt.Errorf("%w: fixed role '%s' cannot be assigned to built-in roles", accesscontrol.ErrInvalidRoleBundle, a.Role)
+		}
+		stored, err := getOrCreateRole(sess, accesscontrol.Role{
+			OrgID:   accesscontrol.GlobalOrgID,
+			UID:     accesscontrol.FixedRoleUID(a.Role),
+			Name:    a.Role,
+			Version: 1,
+		})
+		if err != nil {
+			return false, err
+		}
+		role = stored
+	} else {
+		stored := accesscontrol.Role{}
+		has, err := sess.Where("name = ? AND (org_id = ? OR org_id = ?)", a.Role, a.OrgID, globalOrgID).Get(&stored)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			return false, fmt.Errorf("role '%s' of organization %d: %w", a.Role, a.OrgID, accesscontrol.ErrRoleNotFound)
+		}
+		role = &stored
+	}
+
+	assigned := false
+	for _, login := range a.Users {
+		var userID int64
+		has, err := sess.SQL(`SELECT u.id FROM `+s.sql.Dialect.Quote("user")+` AS u
+			INNER JOIN org_user AS ou ON ou.user_id = u.id
+			WHERE ou.org_id = ? AND u.login = ?`, a.OrgID, login).Get(&userID)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			logger.Warn("Skipping role assignment to unknown user", "orgID", a.OrgID, "role", a.Role, "login", login)
+			continue
+		}
+
+		exists, err := sess.Where("org_id = ? AND user_id = ? AND role_id = ?", a.OrgID, userID, role.ID).Exist(&accesscontrol.UserRole{})
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			continue
+		}
+		if _, err := sess.Insert(&accesscontrol.UserRole{OrgID: a.OrgID, UserID: userID, RoleID: role.ID, Created: time.Now()}); err != nil {
+			return false, err
+		}
+		assigned = true
+	}
+
+	for _, name := range a.Teams {
+		team := models.Team{}
+		has, err := sess.Where("org_id = ? AND name = ?", a.OrgID, name).Get(&team)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			logger.Warn("Skipping role assignment to unknown team", "orgID", a.OrgID, "role", a.Role, "team", name)
+			continue
+		}
+
+		exists, err := sess.Where("org_id = ? AND team_id = ? AND role_id = ?", a.OrgID, team.Id, role.ID).Exist(&accesscontrol.TeamRole{})
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			continue
+		}
+		if _, err := sess.Insert(&accesscontrol.TeamRole{OrgID: a.OrgID, TeamID: team.Id, RoleID: role.ID, Created: time.Now()}); err != nil {
+			return false, err
+		}
+		assigned = true
+	}
+
+	for _, builtInRole := range a.BuiltInRoles {
+		exists, err := sess.Where("org_id = ? AND role_id = ? AND role = ?", a.OrgID, role.ID, builtInRole).Exist(&accesscontrol.BuiltinRole{})
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			continue
+		}
+		if _, err := sess.Insert(&accesscontrol.BuiltinRole{
+			OrgID:   a.OrgID,
+			RoleID:  role.ID,
+			Role:    builtInRole,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}); err != nil {
+			return false, err
+		}
+		assigned = true
+	}
+	return assigned, nil
+}
+
+// importBundleDeny adds the missing deny permissions to the managed roles of the users, teams and built-in roles.
+// Like assignments, users and teams that do not exist are skipped and the denies removed from the bundles are kept.
+// It returns true when a deny permission has been added.
+func (s *AccessControlStore) importBundleDeny(sess *sqlstore.DBSession, d accesscontrol.RoleBundleDeny) (bool, error) {
+	if d.Action == "" {
+		return false, fmt.Errorf("%w: deny permission without action", accesscontrol.ErrInvalidRoleBundle)
+	}
+	cmds := make([]accesscontrol.AddDenyPermissionCommand, 0, len(d.Users)+len(d.Teams)+len(d.BuiltInRoles))
+	for _, login := range d.Users {
+		var userID int64
+		has, err := sess.SQL(`SELECT u.id FROM `+s.sql.Dialect.Quote("user")+` AS u
+			INNER JOIN org_user AS ou ON ou.user_id = u.id
+			WHERE ou.org_id = ? AND u.login = ?`, d.OrgID, login).Get(&userID)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			logger.Warn("Skipping deny permission of unknown user", "orgID", d.OrgID, "action", d.Action, "login", login)
+			continue
+		}
+		cmds = append(cmds, accesscontrol.AddDenyPermissionCommand{UserID: userID})
+	}
+	for _, name := range d.Teams {
+		team := models.Team{}
+		has, err := sess.Where("org_id = ? AND name = ?", d.OrgID, name).Get(&team)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			logger.Warn("Skipping deny permission of unknown team", "orgID", d.OrgID, "action", d.Action, "team", name)
+			continue
+		}
+		cmds = append(cmds, accesscontrol.AddDenyPermissionCommand{TeamID: team.Id})
+	}
+	for _, builtInRole := range d.BuiltInRoles {
+		cmds = append(cmds, accesscontrol.AddDenyPermissionCommand{BuiltInRole: builtInRole})
+	}
+
+	denied := false
+	for _, cmd := range cmds {
+		cmd.Action, cmd.Scope = d.Action, d.Scope
+		if err := validateDenyPermission(cmd); err != nil {
+			return false, fmt.Errorf("%w: %s", accesscontrol.ErrInvalidRoleBundle, err)
+		}
+		_, added, err := s.addDenyPermission(sess, d.OrgID, cmd)
+		if err != nil {
+			return false, err
+		}
+		denied = denied || added
+	}
+	return denied, nil
+}
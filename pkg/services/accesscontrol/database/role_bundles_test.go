@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_RoleBundles(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+
+	bundle := accesscontrol.RoleBundle{
+		APIVersion: accesscontrol.RoleBundleAPIVersion,
+		Roles: []accesscontrol.RoleBundleRole{
+			{
+				UID:     "custom_editor",
+				Name:    "custom:editor",
+				OrgID:   1,
+				Version: 1,
+				Group:   "Custom",
+				Permissions: []accesscontrol.RoleBundlePermission{
+					{Action: "dashboards:write", Scope: "dashboards:*"},
+					{Action: "dashboards:read", Scope: "dashboards:*"},
+				},
+			},
+		},
+		Assignments: []accesscontrol.RoleBundleAssignment{
+			{OrgID: 1, Role: "custom:editor", Users: []string{user.Login, "unknown"}, Teams: []string{team.Name}, BuiltInRoles: []string{"Viewer"}},
+			{OrgID: 1, Role: "fixed:users:reader", Users: []string{user.Login}},
+		},
+	}
+
+	t.Run("should import the roles and the assignments", func(t *testing.T) {
+		require.NoError(t, store.ImportRoleBundle(context.Background(), bundle))
+
+		exported, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, exported.Roles, 1)
+		assert.Equal(t, "custom_editor", exported.Roles[0].UID)
+		assert.Equal(t, "Custom", exported.Roles[0].Group)
+		assert.Equal(t, []accesscontrol.RoleBundlePermission{
+			{Action: "dashboards:read", Scope: "dashboards:*"},
+			{Action: "dashboards:write", Scope: "dashboards:*"},
+		}, exported.Roles[0].Permissions)
+
+		assert.Equal(t, []accesscontrol.RoleBundleAssignment{
+			{OrgID: 1, Role: "custom:editor", Users: []string{user.Login}, Teams: []string{team.Name}, BuiltInRoles: []string{"Viewer"}},
+			{OrgID: 1, Role: "fixed:users:reader", Users: []string{user.Login}},
+		}, exported.Assignments)
+
+		permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+		require.NoError(t, err)
+		assert.Len(t, permissions, 2)
+	})
+
+	t.Run("should be idempotent", func(t *testing.T) {
+		require.NoError(t, store.ImportRoleBundle(context.Background(), bundle))
+
+		exported, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, exported.Roles, 1)
+		assert.Len(t, exported.Assignments, 2)
+		assert.Len(t, exported.Assignments[0].Users, 1)
+	})
+
+	t.Run("should replace the permissions of the roles", func(t *testing.T) {
+		updated := bundle
+		updated.Roles = []accesscontrol.RoleBundleRole{bundle.Roles[0]}
+		updated.Roles[0].Version = 2
+		updated.Roles[0].Permissions = []accesscontrol.RoleBundlePermission{{Action: "dashboards:read", Scope: "dashboards:*"}}
+		require.NoError(t, store.ImportRoleBundle(context.Background(), updated))
+
+		exported, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, exported.Roles, 1)
+		assert.Equal(t, int64(2), exported.Roles[0].Version)
+		assert.Len(t, exported.Roles[0].Permissions, 1)
+	})
+
+	t.Run("should import and export the deny permissions", func(t *testing.T) {
+		updated := bundle
+		updated.Roles = []accesscontrol.RoleBundleRole{bundle.Roles[0]}
+		updated.Roles[0].Permissions = []accesscontrol.RoleBundlePermission{
+			{Action: "dashboards:read", Scope: "dashboards:*"},
+			{Action: "dashboards:read", Scope: "dashboards:uid:secret", Deny: true},
+		}
+		updated.Denies = []accesscontrol.RoleBundleDeny{
+			{OrgID: 1, Action: "dashboards:write", Scope: "dashboards:uid:production", Users: []string{user.Login, "unknown"}, Teams: []string{team.Name}},
+		}
+		require.NoError(t, store.ImportRoleBundle(context.Background(), updated))
+		require.NoError(t, store.ImportRoleBundle(context.Background(), updated))
+
+		exported, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, exported.Roles, 1)
+		assert.Equal(t, updated.Roles[0].Permissions, exported.Roles[0].Permissions)
+		assert.Equal(t, []accesscontrol.RoleBundleDeny{
+			{OrgID: 1, Action: "dashboards:write", Scope: "dashboards:uid:production", Users: []string{user.Login}, Teams: []string{team.Name}},
+		}, exported.Denies)
+
+		denies, err := store.GetDenyPermissions(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, denies, 2)
+
+		permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+		require.NoError(t, err)
+		denied := make([]string, 0)
+		for _, p := range permissions {
+			if p.Deny {
+				denied = append(denied, p.Action+" "+p.Scope)
+			}
+		}
+		// The production dashboard is denied to the user and to the team of the user
+		assert.ElementsMatch(t, []string{
+			"dashboards:read dashboards:uid:secret",
+			"dashboards:write dashboards:uid:production",
+			"dashboards:write dashboards:uid:production",
+		}, denied)
+
+		// Turning the deny back into a grant replaces the permissions of the role
+		updated.Roles[0].Permissions = []accesscontrol.RoleBundlePermission{
+			{Action: "dashboards:read", Scope: "dashboards:*"},
+			{Action: "dashboards:read", Scope: "dashboards:uid:secret"},
+		}
+		require.NoError(t, store.ImportRoleBundle(context.Background(), updated))
+		exported, err = store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Roles[0].Permissions, exported.Roles[0].Permissions)
+	})
+
+	t.Run("should not export the roles of other organizations", func(t *testing.T) {
+		exported, err := store.ExportRoleBundle(context.Background(), 2)
+		require.NoError(t, err)
+		assert.Len(t, exported.Roles, 0)
+		assert.Len(t, exported.Assignments, 0)
+		assert.Len(t, exported.Denies, 0)
+	})
+
+	t.Run("should reject invalid bundles", func(t *testing.T) {
+		err := store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+			Roles: []accesscontrol.RoleBundleRole{{Name: "fixed:users:writer", OrgID: 1}},
+		})
+		assert.ErrorIs(t, err, accesscontrol.ErrInvalidRoleBundle)
+
+		err = store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+			Assignments: []accesscontrol.RoleBundleAssignment{{OrgID: 1, Role: "fixed:users:writer", BuiltInRoles: []string{"Viewer"}}},
+		})
+		assert.ErrorIs(t, err, accesscontrol.ErrInvalidRoleBundle)
+
+		err = store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+			Assignments: []accesscontrol.RoleBundleAssignment{{OrgID: 1, Role: "custom:unknown", Users: []string{user.Login}}},
+		})
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+		err = store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+			Denies: []accesscontrol.RoleBundleDeny{{OrgID: 1, Action: accesscontrol.ActionPermissionsDeniesWrite, BuiltInRoles: []string{"Admin"}}},
+		})
+		assert.ErrorIs(t, err, accesscontrol.ErrInvalidRoleBundle)
+	})
+}
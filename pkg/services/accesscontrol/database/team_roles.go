@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *AccessControlStore) GetTeamRoles(ctx context.Context, orgID, teamID int64) ([]*accesscontrol.Role, error) {
+	result := make([]*accesscontrol.Role, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT role.*
+			FROM role
+			INNER JOIN team_role AS tr ON tr.role_id = role.id
+			WHERE tr.org_id = ? AND tr.team_id = ?
+		`
+		return sess.SQL(q, orgID, teamID).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) GetUserTeamRoles(ctx context.Context, orgID, userID int64) ([]*accesscontrol.Role, error) {
+	result := make([]*accesscontrol.Role, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT role.*
+			FROM role
+			WHERE role.id IN (
+				SELECT tr.role_id FROM team_role AS tr
+				INNER JOIN team_member AS tm ON tm.team_id = tr.team_id
+				WHERE tm.user_id = ? AND tr.org_id = ?
+			)
+		`
+		return sess.SQL(q, userID, orgID).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) GetUserTeamRolesByName(ctx context.Context, orgID, userID int64, names []string) ([]*accesscontrol.Role, error) {
+	result := make([]*accesscontrol.Role, 0)
+	if len(names) == 0 {
+		return result, nil
+	}
+
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT role.*
+			FROM role
+			WHERE role.name IN (?` + strings.Repeat(", ?", len(names)-1) + `)
+			AND role.id IN (
+				SELECT tr.role_id FROM team_role AS tr
+				INNER JOIN team_member AS tm ON tm.team_id = tr.team_id
+				WHERE tm.user_id = ? AND tr.org_id = ?
+			)
+		`
+		params := make([]interface{}, 0, len(names)+2)
+		for _, name := range names {
+			params = append(params, name)
+		}
+		params = append(params, userID, orgID)
+
+		return sess.SQL(q, params...).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) AddTeamRole(ctx context.Context, orgID, teamID int64, role accesscontrol.Role) error {
+	var stored *accesscontrol.Role
+	added := false
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := teamExists(sess, orgID, teamID); err != nil {
+			return err
+		}
+
+		var err error
+		stored, err = getOrCreateRole(sess, role)
+		if err != nil {
+			return err
+		}
+
+		assigned, err := sess.Where("org_id = ? AND team_id = ? AND role_id = ?", orgID, teamID, stored.ID).Exist(&accesscontrol.TeamRole{})
+		if err != nil {
+			return err
+		}
+		if assigned {
+			return nil
+		}
+
+		_, err = sess.Insert(&accesscontrol.TeamRole{
+			OrgID:   orgID,
+			TeamID:  teamID,
+			RoleID:  stored.ID,
+			Created: time.Now(),
+		})
+		added = err == nil
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	if added {
+		s.publishRoleAssignmentChanged(ctx, &events.RoleAssignmentChanged{OrgID: orgID, RoleUID: stored.UID, RoleName: stored.Name, TeamID: teamID})
+	}
+	return nil
+}
+
+func (s *AccessControlStore) RemoveTeamRole(ctx context.Context, orgID, teamID int64, roleUID string) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := teamExists(sess, orgID, teamID); err != nil {
+			return err
+		}
+		if err := roleBindingExists(sess, "team_id", orgID, teamID, roleUID); err != nil {
+			return err
+		}
+
+		q := `DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id IN (SELECT id FROM role WHERE uid = ?)`
+		res, err := sess.Exec(q, orgID, teamID, roleUID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return accesscontrol.ErrTeamRoleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	s.publishRoleAssignmentChanged(ctx, &events.RoleAssignmentChanged{OrgID: orgID, RoleUID: roleUID, TeamID: teamID, Removed: true})
+	return nil
+}
+
+// getOrCreateRole returns the stored role matching the role uid, the role is inserted if it has not been stored yet
+func getOrCreateRole(sess *sqlstore.DBSession, role accesscontrol.Role) (*accesscontrol.Role, error) {
+	stored := accesscontrol.Role{}
+	has, err := sess.Where("uid = ?", role.UID).Get(&stored)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return &stored, nil
+	}
+
+	role.ID = 0
+	role.Created = time.Now()
+	role.Updated = time.Now()
+	if _, err := sess.Insert(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func teamExists(sess *sqlstore.DBSession, orgID, teamID int64) error {
+	exists, err := sess.Where("org_id = ? AND id = ?", orgID, teamID).Exist(&models.Team{})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrTeamNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_TeamRoles(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, team := createUserAndTeam(t, sql, 1)
+
+	role := accesscontrol.Role{
+		OrgID:   accesscontrol.GlobalOrgID,
+		UID:     accesscontrol.FixedRoleUID("fixed:test:reader"),
+		Name:    "fixed:test:reader",
+		Version: 1,
+	}
+
+	t.Run("should add role to team", func(t *testing.T) {
+		require.NoError(t, store.AddTeamRole(context.Background(), 1, team.Id, role))
+		// Adding the same role twice is a no-op
+		require.NoError(t, store.AddTeamRole(context.Background(), 1, team.Id, role))
+
+		roles, err := store.GetTeamRoles(context.Background(), 1, team.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, role.UID, roles[0].UID)
+		assert.Equal(t, role.Name, roles[0].Name)
+	})
+
+	t.Run("should get roles of the user's teams", func(t *testing.T) {
+		roles, err := store.GetUserTeamRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, role.Name, roles[0].Name)
+
+		roles, err = store.GetUserTeamRoles(context.Background(), 2, user.Id)
+		require.NoError(t, err)
+		assert.Len(t, roles, 0)
+	})
+
+	t.Run("should get roles of the user's teams by name", func(t *testing.T) {
+		roles, err := store.GetUserTeamRolesByName(context.Background(), 1, user.Id, []string{"fixed:test:writer", role.Name})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, role.Name, roles[0].Name)
+
+		roles, err = store.GetUserTeamRolesByName(context.Background(), 1, user.Id, []string{"fixed:test:writer"})
+		require.NoError(t, err)
+		assert.Len(t, roles, 0)
+	})
+
+	t.Run("should fail to add role to team in another org", func(t *testing.T) {
+		err := store.AddTeamRole(context.Background(), 2, team.Id, role)
+		assert.ErrorIs(t, err, models.ErrTeamNotFound)
+	})
+
+	t.Run("should remove role from team", func(t *testing.T) {
+		require.NoError(t, store.RemoveTeamRole(context.Background(), 1, team.Id, role.UID))
+
+		roles, err := store.GetTeamRoles(context.Background(), 1, team.Id)
+		require.NoError(t, err)
+		assert.Len(t, roles, 0)
+
+		err = store.RemoveTeamRole(context.Background(), 1, team.Id, role.UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrTeamRoleNotFound)
+	})
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *AccessControlStore) GetUserRoles(ctx context.Context, orgID, userID int64) ([]*accesscontrol.UserRoleAssignment, error) {
+	result := make([]*accesscontrol.UserRoleAssignment, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT role.*, ur.expires_at, ur.auth_module
+			FROM role
+			INNER JOIN user_role AS ur ON ur.role_id = role.id
+			WHERE ur.org_id = ? AND ur.user_id = ?
+			AND (ur.expires_at IS NULL OR ur.expires_at > ?)
+		`
+		return sess.SQL(q, orgID, userID, time.Now()).Find(&result)
+	})
+
+	return result, err
+}
+
+func (s *AccessControlStore) AddUserRole(ctx context.Context, orgID, userID int64, role accesscontrol.Role, expiresAt *time.Time) error {
+	var stored *accesscontrol.Role
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := orgUserExists(sess, orgID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		stored, err = getOrCreateRole(sess, role)
+		if err != nil {
+			return err
+		}
+
+		assignment := accesscontrol.UserRole{}
+		assigned, err := sess.Where("org_id = ? AND user_id = ? AND role_id = ?", orgID, userID, stored.ID).Get(&assignment)
+		if err != nil {
+			return err
+		}
+		if assigned {
+			// The role is now managed through the API, it is no longer removed by the group sync
+			assignment.ExpiresAt = expiresAt
+			assignment.AuthModule = ""
+			_, err = sess.ID(assignment.ID).Cols("expires_at", "auth_module").Update(&assignment)
+			return err
+		}
+
+		_, err = sess.Insert(&accesscontrol.UserRole{
+			OrgID:     orgID,
+			UserID:    userID,
+			RoleID:    stored.ID,
+			Created:   time.Now(),
+			ExpiresAt: expiresAt,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	s.publishRoleAssignmentChanged(ctx, &events.RoleAssignmentChanged{OrgID: orgID, RoleUID: stored.UID, RoleName: stored.Name, UserID: userID})
+	return nil
+}
+
+func (s *AccessControlStore) RemoveUserRole(ctx context.Context, orgID, userID int64, roleUID string) error {
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := roleBindingExists(sess, "user_id", orgID, userID, roleUID); err != nil {
+			return err
+		}
+
+		q := `DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id IN (SELECT id FROM role WHERE uid = ?)`
+		res, err := sess.Exec(q, orgID, userID, roleUID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return accesscontrol.ErrUserRoleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishRolesChanged(ctx, orgID)
+	s.publishRoleAssignmentChanged(ctx, &events.RoleAssignmentChanged{OrgID: orgID, RoleUID: roleUID, UserID: userID, Removed: true})
+	return nil
+}
+
+func (s *AccessControlStore) DeleteExpiredUserRoles(ctx context.Context, now time.Time) (int64, error) {
+	var expired []*expiredUserRole
+	var deleted int64
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := `SELECT ur.org_id, ur.user_id, role.uid, role.name
+			FROM user_role AS ur
+			INNER JOIN role ON role.id = ur.role_id
+			WHERE ur.expires_at IS NOT NULL AND ur.expires_at <= ?
+		`
+		if err := sess.SQL(q, now).Find(&expired); err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		res, err := sess.Exec("DELETE FROM user_role WHERE expires_at IS NOT NULL AND expires_at <= ?", now)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	orgIDs := make(map[int64]struct{})
+	for _, e := range expired {
+		orgIDs[e.OrgID] = struct{}{}
+	}
+	for orgID := range orgIDs {
+		s.publishRolesChanged(ctx, orgID)
+	}
+	for _, e := range expired {
+		s.publishRoleAssignmentChanged(ctx, &events.RoleAssignmentChanged{OrgID: e.OrgID, RoleUID: e.UID, RoleName: e.Name, UserID: e.UserID, Removed: true})
+	}
+	return deleted, nil
+}
+
+type expiredUserRole struct {
+	OrgID  int64  `xorm:"org_id"`
+	UserID int64  `xorm:"user_id"`
+	UID    string `xorm:"uid"`
+	Name   string
+}
+
+func orgUserExists(sess *sqlstore.DBSession, orgID, userID int64) error {
+	exists, err := sess.Where("org_id = ? AND user_id = ?", orgID, userID).Exist(&models.OrgUser{})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
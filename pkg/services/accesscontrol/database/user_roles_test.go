@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestAccessControlStore_UserRoles(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, 1)
+
+	reader := accesscontrol.Role{
+		OrgID:   accesscontrol.GlobalOrgID,
+		UID:     accesscontrol.FixedRoleUID("fixed:test:reader"),
+		Name:    "fixed:test:reader",
+		Version: 1,
+	}
+	writer := accesscontrol.Role{
+		OrgID:   accesscontrol.GlobalOrgID,
+		UID:     accesscontrol.FixedRoleUID("fixed:test:writer"),
+		Name:    "fixed:test:writer",
+		Version: 1,
+	}
+
+	t.Run("should add role to user", func(t *testing.T) {
+		require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, reader, nil))
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, reader.UID, roles[0].UID)
+		assert.Equal(t, reader.Name, roles[0].Name)
+		assert.Nil(t, roles[0].ExpiresAt)
+	})
+
+	t.Run("should update the expiry of an assigned role", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, reader, &expiresAt))
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.NotNil(t, roles[0].ExpiresAt)
+		assert.True(t, expiresAt.Equal(*roles[0].ExpiresAt))
+	})
+
+	t.Run("should fail to add role to user outside of the org", func(t *testing.T) {
+		err := store.AddUserRole(context.Background(), 2, user.Id, reader, nil)
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	})
+
+	t.Run("should leave out expired assignments", func(t *testing.T) {
+		expiredAt := time.Now().Add(-time.Minute)
+		require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, writer, &expiredAt))
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, reader.UID, roles[0].UID)
+
+		assigned, err := store.GetUserAssignedRoles(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.Id})
+		require.NoError(t, err)
+		for _, role := range assigned {
+			assert.NotEqual(t, writer.UID, role.UID)
+		}
+	})
+
+	t.Run("should delete expired assignments", func(t *testing.T) {
+		deleted, err := store.DeleteExpiredUserRoles(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		err = store.RemoveUserRole(context.Background(), 1, user.Id, writer.UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrUserRoleNotFound)
+
+		deleted, err = store.DeleteExpiredUserRoles(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+	})
+
+	t.Run("should remove role from user", func(t *testing.T) {
+		require.NoError(t, store.RemoveUserRole(context.Background(), 1, user.Id, reader.UID))
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		assert.Len(t, roles, 0)
+
+		err = store.RemoveUserRole(context.Background(), 1, user.Id, reader.UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrUserRoleNotFound)
+	})
+}
+
+func TestAccessControlStore_UserRoles_PublishesAssignmentChanges(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, 1)
+
+	previous := sql.Bus
+	sql.Bus = bus.New()
+	t.Cleanup(func() { sql.Bus = previous })
+
+	published := make([]*events.RoleAssignmentChanged, 0)
+	sql.Bus.AddEventListener(func(_ context.Context, e *events.RoleAssignmentChanged) error {
+		published = append(published, e)
+		return nil
+	})
+
+	role := accesscontrol.Role{OrgID: accesscontrol.GlobalOrgID, UID: "test_reader", Name: "fixed:test:reader", Version: 1}
+	expiredAt := time.Now().Add(-time.Minute)
+	require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, role, &expiredAt))
+	_, err := store.DeleteExpiredUserRoles(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, store.AddUserRole(context.Background(), 1, user.Id, role, nil))
+	require.NoError(t, store.RemoveUserRole(context.Background(), 1, user.Id, role.UID))
+
+	require.Len(t, published, 4)
+	assert.Equal(t, events.RoleAssignmentChanged{Timestamp: published[0].Timestamp, OrgID: 1, RoleUID: role.UID, RoleName: role.Name, UserID: user.Id}, *published[0])
+	assert.Equal(t, events.RoleAssignmentChanged{Timestamp: published[1].Timestamp, OrgID: 1, RoleUID: role.UID, RoleName: role.Name, UserID: user.Id, Removed: true}, *published[1])
+	assert.False(t, published[2].Removed)
+	assert.Equal(t, events.RoleAssignmentChanged{Timestamp: published[3].Timestamp, OrgID: 1, RoleUID: role.UID, UserID: user.Id, Removed: true}, *published[3])
+}
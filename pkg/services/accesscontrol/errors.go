@@ -3,6 +3,18 @@ package accesscontrol
 import "errors"
 
 var (
-	ErrFixedRolePrefixMissing = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
-	ErrInvalidBuiltinRole     = errors.New("built-in role is not valid")
+	ErrFixedRolePrefixMissing  = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
+	ErrInvalidBuiltinRole      = errors.New("built-in role is not valid")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrTeamRoleNotFound        = errors.New("role is not assigned to team")
+	ErrUserRoleNotFound        = errors.New("role is not assigned to user")
+	ErrRoleIncludeCycle        = errors.New("role includes itself")
+	ErrDenyPermissionNotFound  = errors.New("deny permission not found")
+	ErrInvalidDenyPermission   = errors.New("deny permission is not valid")
+	ErrGroupMappingNotFound    = errors.New("group mapping not found")
+	ErrGroupMappingExists      = errors.New("group mapping already exists")
+	ErrGroupMappingProvisioned = errors.New("group mapping is provisioned")
+	ErrInvalidRoleBundle       = errors.New("role bundle is not valid")
+	ErrInvalidRoleBinding      = errors.New("role binding is not valid")
+	ErrRoleBindingProvisioned  = errors.New("role assignment is provisioned by a role binding")
 )
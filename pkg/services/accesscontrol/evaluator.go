@@ -32,13 +32,25 @@ type permissionEvaluator struct {
 	Scopes []string
 }
 
+// Evaluate returns true if the action is granted on all the scopes and denied on none of them. Deny permissions
+// take precedence over the permissions granting the action, whatever the roles they come from:
+//   - a deny on the empty scope or on `*` excludes the action on all scopes
+//   - a deny on a scope overlapping the target scope, when either one matches the other, excludes the target
+//   - evaluations without scopes only require the action to be granted on some scope, so only denies on all
+//     scopes fail them
 func (p permissionEvaluator) Evaluate(permissions map[string][]string) (bool, error) {
 	userScopes, ok := permissions[p.Action]
 	if !ok {
 		return false, nil
 	}
+	deniedScopes := permissions[DenyKey(p.Action)]
 
 	if len(p.Scopes) == 0 {
+		for _, denied := range deniedScopes {
+			if deniesAllScopes(denied) {
+				return false, nil
+			}
+		}
 		return true, nil
 	}
 
@@ -46,6 +58,19 @@ func (p permissionEvaluator) Evaluate(permissions map[string][]string) (bool, er
 		var err error
 		var matches bool
 
+		for _, denied := range deniedScopes {
+			if deniesAllScopes(denied) {
+				return false, nil
+			}
+			if matches, err = match(denied, target); err != nil || matches {
+				return false, err
+			}
+			// A target covering many resources is denied as soon as one of them is
+			if matches, err = match(target, denied); err != nil || matches {
+				return false, err
+			}
+		}
+
 		for _, scope := range userScopes {
 			matches, err = match(scope, target)
 			if err != nil {
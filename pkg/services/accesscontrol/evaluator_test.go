@@ -47,6 +47,77 @@ func TestPermission_Evaluate(t *testing.T) {
 				"reports:read": {"reports:1"},
 			},
 		},
+		{
+			desc:      "should evaluate to false when the scope is denied",
+			expected:  false,
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:*"},
+				DenyKey("reports:read"): {"reports:1"},
+			},
+		},
+		{
+			desc:      "should evaluate to true when another scope is denied",
+			expected:  true,
+			evaluator: EvalPermission("reports:read", "reports:2"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:*"},
+				DenyKey("reports:read"): {"reports:1"},
+			},
+		},
+		{
+			desc:      "should evaluate to false when a deny wildcard matches the scope",
+			expected:  false,
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:1"},
+				DenyKey("reports:read"): {"reports:*"},
+			},
+		},
+		{
+			desc:      "should evaluate to false when the wildcard scope covers a denied scope",
+			expected:  false,
+			evaluator: EvalPermission("reports:read", "reports:*"),
+			permissions: map[string][]string{
+				"reports:read":          {"*"},
+				DenyKey("reports:read"): {"reports:1"},
+			},
+		},
+		{
+			desc:      "should evaluate to false when the action is denied on all scopes",
+			expected:  false,
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:1"},
+				DenyKey("reports:read"): {""},
+			},
+		},
+		{
+			desc:      "should evaluate to true for empty scope when some scopes are denied",
+			expected:  true,
+			evaluator: EvalPermission("reports:read"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:*"},
+				DenyKey("reports:read"): {"reports:1"},
+			},
+		},
+		{
+			desc:      "should evaluate to false for empty scope when all scopes are denied",
+			expected:  false,
+			evaluator: EvalPermission("reports:read"),
+			permissions: map[string][]string{
+				"reports:read":          {"reports:*"},
+				DenyKey("reports:read"): {"*"},
+			},
+		},
+		{
+			desc:      "should evaluate to false when only denied",
+			expected:  false,
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				DenyKey("reports:read"): {"reports:2"},
+			},
+		},
 	}
 
 	for _, test := range tests {
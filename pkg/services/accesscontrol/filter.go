@@ -0,0 +1,145 @@
+package accesscontrol
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	ErrFilterSQLIDNotAllowed   = errors.New("sql id is not in the filter accept list")
+	ErrFilterMissingPermission = errors.New("user permissions have not been loaded")
+)
+
+var (
+	denyQuery     = SQLFilter{Where: "1 = 0"}
+	allowAllQuery = SQLFilter{Where: "1 = 1"}
+)
+
+// sqlIDAcceptList contains the columns that can be passed to Filter. Since the column is injected as is in the
+// condition, it must never come from user input.
+var sqlIDAcceptList = map[string]struct{}{
+	"org_user.user_id":    {},
+	"dashboard.id":        {},
+	"dashboard.folder_id": {},
+	"data_source.id":      {},
+}
+
+// SQLFilter is a condition, with its parameters, to add to the WHERE clause of a query.
+type SQLFilter struct {
+	Where string
+	Args  []interface{}
+}
+
+// Filter builds a SQL condition restricting sqlID to the resources the user can perform action on in their
+// current organization. Scopes granting access to all resources, such as `*`, `<prefix>:*` or `<prefix>:id:*`,
+// return a condition matching all rows, `<prefix>:id:<id>` scopes restrict the rows to the listed ids and any
+// other scope is ignored. Deny permissions on the same scopes take precedence and exclude the rows they match.
+// The user permissions must have been loaded beforehand, which is done while evaluating the permissions of the
+// request.
+func Filter(user *models.SignedInUser, sqlID, prefix, action string) (SQLFilter, error) {
+	if _, ok := sqlIDAcceptList[sqlID]; !ok {
+		return denyQuery, ErrFilterSQLIDNotAllowed
+	}
+
+	if user == nil || user.Permissions == nil || user.Permissions[user.OrgId] == nil {
+		return denyQuery, ErrFilterMissingPermission
+	}
+
+	permissions := user.Permissions[user.OrgId]
+	for _, scope := range permissions[DenyKey(action)] {
+		if deniesAllScopes(scope) {
+			return denyQuery, nil
+		}
+	}
+
+	allowAll, ids := filterIDs(permissions[action], prefix)
+	deniedAll, deniedIDs := filterIDs(permissions[DenyKey(action)], prefix)
+	if deniedAll {
+		return denyQuery, nil
+	}
+
+	denied := make(map[int64]struct{}, len(deniedIDs))
+	for _, id := range deniedIDs {
+		denied[id] = struct{}{}
+	}
+
+	if allowAll {
+		if len(deniedIDs) == 0 {
+			return allowAllQuery, nil
+		}
+		return SQLFilter{
+			Where: fmt.Sprintf("%s NOT IN (?%s)", sqlID, strings.Repeat(",?", len(deniedIDs)-1)),
+			Args:  int64sToArgs(deniedIDs),
+		}, nil
+	}
+
+	allowed := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := denied[id]; !ok {
+			allowed = append(allowed, id)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return denyQuery, nil
+	}
+
+	return SQLFilter{
+		Where: fmt.Sprintf("%s IN (?%s)", sqlID, strings.Repeat(",?", len(allowed)-1)),
+		Args:  int64sToArgs(allowed),
+	}, nil
+}
+
+// filterIDs returns whether the scopes cover all resources of the prefix, and otherwise the ids they list
+func filterIDs(scopes []string, prefix string) (bool, []int64) {
+	idPrefix := Scope(prefix, "id") + ":"
+	ids := make([]int64, 0)
+	for _, scope := range scopes {
+		switch scope {
+		case "*", Scope(prefix, "*"), Scope(prefix, "id", "*"):
+			return true, nil
+		}
+
+		if !strings.HasPrefix(scope, idPrefix) {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimPrefix(scope, idPrefix), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return false, ids
+}
+
+func int64sToArgs(ids []int64) []interface{} {
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return args
+}
+
+// FilterAny combines filters so that rows matching any of them are kept.
+func FilterAny(filters ...SQLFilter) SQLFilter {
+	wheres := make([]string, 0, len(filters))
+	args := make([]interface{}, 0)
+	for _, f := range filters {
+		if f.Where == allowAllQuery.Where {
+			return allowAllQuery
+		}
+		if f.Where == denyQuery.Where {
+			continue
+		}
+		wheres = append(wheres, f.Where)
+		args = append(args, f.Args...)
+	}
+
+	if len(wheres) == 0 {
+		return denyQuery
+	}
+
+	return SQLFilter{Where: "(" + strings.Join(wheres, " OR ") + ")", Args: args}
+}
@@ -0,0 +1,116 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		sqlID       string
+		permissions map[string][]string
+		wantWhere   string
+		wantArgs    []interface{}
+		wantErr     error
+	}{
+		{
+			name:        "should allow all with wildcard scope",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"users:id:1", ScopeUsersAll}},
+			wantWhere:   "1 = 1",
+		},
+		{
+			name:        "should allow all with id wildcard scope",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"users:id:*"}},
+			wantWhere:   "1 = 1",
+		},
+		{
+			name:        "should restrict to ids",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"users:id:1", "users:id:3", "users:login:admin", "teams:id:2"}},
+			wantWhere:   "org_user.user_id IN (?,?)",
+			wantArgs:    []interface{}{int64(1), int64(3)},
+		},
+		{
+			name:        "should deny without matching scopes",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"teams:*"}, ActionUsersRead: {ScopeUsersAll}},
+			wantWhere:   "1 = 0",
+		},
+		{
+			name:        "should exclude denied ids when all are allowed",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {ScopeUsersAll}, DenyKey(ActionOrgUsersRead): {"users:id:2", "users:id:4"}},
+			wantWhere:   "org_user.user_id NOT IN (?,?)",
+			wantArgs:    []interface{}{int64(2), int64(4)},
+		},
+		{
+			name:        "should remove denied ids",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"users:id:1", "users:id:3"}, DenyKey(ActionOrgUsersRead): {"users:id:3"}},
+			wantWhere:   "org_user.user_id IN (?)",
+			wantArgs:    []interface{}{int64(1)},
+		},
+		{
+			name:        "should deny when all ids are denied",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {ScopeUsersAll}, DenyKey(ActionOrgUsersRead): {"users:id:*"}},
+			wantWhere:   "1 = 0",
+		},
+		{
+			name:        "should deny when action is denied on all scopes",
+			sqlID:       "org_user.user_id",
+			permissions: map[string][]string{ActionOrgUsersRead: {"users:id:1"}, DenyKey(ActionOrgUsersRead): {""}},
+			wantWhere:   "1 = 0",
+		},
+		{
+			name:        "should fail with column not in accept list",
+			sqlID:       "1 = 1 OR id",
+			permissions: map[string][]string{ActionOrgUsersRead: {ScopeUsersAll}},
+			wantWhere:   "1 = 0",
+			wantErr:     ErrFilterSQLIDNotAllowed,
+		},
+		{
+			name:      "should fail when permissions are missing",
+			sqlID:     "org_user.user_id",
+			wantWhere: "1 = 0",
+			wantErr:   ErrFilterMissingPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.SignedInUser{OrgId: 1}
+			if tt.permissions != nil {
+				user.Permissions = map[int64]map[string][]string{1: tt.permissions}
+			}
+
+			filter, err := Filter(user, tt.sqlID, "users", ActionOrgUsersRead)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantWhere, filter.Where)
+			assert.Equal(t, tt.wantArgs, filter.Args)
+		})
+	}
+}
+
+func TestFilterAny(t *testing.T) {
+	ids := SQLFilter{Where: "dashboard.id IN (?)", Args: []interface{}{int64(1)}}
+	folders := SQLFilter{Where: "dashboard.folder_id IN (?,?)", Args: []interface{}{int64(2), int64(3)}}
+
+	assert.Equal(t, allowAllQuery, FilterAny(ids, allowAllQuery))
+	assert.Equal(t, denyQuery, FilterAny(denyQuery, denyQuery))
+	assert.Equal(t, SQLFilter{
+		Where: "(dashboard.id IN (?) OR dashboard.folder_id IN (?,?))",
+		Args:  []interface{}{int64(1), int64(2), int64(3)},
+	}, FilterAny(ids, denyQuery, folders))
+}
@@ -0,0 +1,41 @@
+package accesscontrol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManagedUserRoleName returns the name of the role holding the managed permissions of a user
+func ManagedUserRoleName(userID int64) string {
+	return fmt.Sprintf("managed:users:%d:permissions", userID)
+}
+
+// ManagedTeamRoleName returns the name of the role holding the managed permissions of a team
+func ManagedTeamRoleName(teamID int64) string {
+	return fmt.Sprintf("managed:teams:%d:permissions", teamID)
+}
+
+// ManagedBuiltInRoleName returns the name of the role holding the managed permissions of a built-in role
+func ManagedBuiltInRoleName(builtInRole string) string {
+	return fmt.Sprintf("managed:builtins:%s:permissions", strings.ToLower(builtInRole))
+}
+
+// DashboardPermissionsToActions maps the permissions that can be set on a dashboard to the actions they grant,
+// the permission names are the names of the legacy dashboard permissions (models.PermissionType).
+var DashboardPermissionsToActions = map[string][]string{
+	"View": {ActionDashboardsRead},
+	"Edit": {ActionDashboardsRead, ActionDashboardsWrite, ActionDashboardsDelete},
+	"Admin": {ActionDashboardsRead, ActionDashboardsWrite, ActionDashboardsDelete,
+		ActionDashboardsPermissionsRead, ActionDashboardsPermissionsWrite},
+}
+
+// FolderPermissionsToActions maps the permissions that can be set on a folder to the actions they grant.
+// The dashboards actions apply to the dashboards of the folder.
+var FolderPermissionsToActions = map[string][]string{
+	"View": {ActionFoldersRead, ActionDashboardsRead},
+	"Edit": {ActionFoldersRead, ActionFoldersWrite, ActionFoldersDelete,
+		ActionDashboardsRead, ActionDashboardsWrite, ActionDashboardsDelete, ActionDashboardsCreate},
+	"Admin": {ActionFoldersRead, ActionFoldersWrite, ActionFoldersDelete, ActionFoldersPermissionsRead, ActionFoldersPermissionsWrite,
+		ActionDashboardsRead, ActionDashboardsWrite, ActionDashboardsDelete, ActionDashboardsCreate,
+		ActionDashboardsPermissionsRead, ActionDashboardsPermissionsWrite},
+}
@@ -14,13 +14,15 @@ type fullAccessControl interface {
 }
 
 type Calls struct {
-	Evaluate            []interface{}
-	GetUserPermissions  []interface{}
-	GetUserRoles        []interface{}
-	IsDisabled          []interface{}
-	DeclareFixedRoles   []interface{}
-	GetUserBuiltInRoles []interface{}
-	RegisterFixedRoles  []interface{}
+	Evaluate                 []interface{}
+	GetUserPermissions       []interface{}
+	GetUserRoles             []interface{}
+	GetUserResourcesMetadata []interface{}
+	GetUsersPermissions      []interface{}
+	IsDisabled               []interface{}
+	DeclareFixedRoles        []interface{}
+	GetUserBuiltInRoles      []interface{}
+	RegisterFixedRoles       []interface{}
 }
 
 type Mock struct {
@@ -37,13 +39,15 @@ type Mock struct {
 	Calls Calls
 
 	// Override functions
-	EvaluateFunc            func(context.Context, *models.SignedInUser, accesscontrol.Evaluator) (bool, error)
-	GetUserPermissionsFunc  func(context.Context, *models.SignedInUser) ([]*accesscontrol.Permission, error)
-	GetUserRolesFunc        func(context.Context, *models.SignedInUser) ([]*accesscontrol.RoleDTO, error)
-	IsDisabledFunc          func() bool
-	DeclareFixedRolesFunc   func(...accesscontrol.RoleRegistration) error
-	GetUserBuiltInRolesFunc func(user *models.SignedInUser) []string
-	RegisterFixedRolesFunc  func() error
+	EvaluateFunc                 func(context.Context, *models.SignedInUser, accesscontrol.Evaluator) (bool, error)
+	GetUserPermissionsFunc       func(context.Context, *models.SignedInUser) ([]*accesscontrol.Permission, error)
+	GetUserRolesFunc             func(context.Context, *models.SignedInUser) ([]*accesscontrol.RoleDTO, error)
+	GetUserResourcesMetadataFunc func(context.Context, *models.SignedInUser, string, map[string]bool) (map[string]accesscontrol.Metadata, error)
+	GetUsersPermissionsFunc      func(context.Context, []*models.SignedInUser, accesscontrol.GetUsersPermissionsQuery) (map[int64][]*accesscontrol.Permission, error)
+	IsDisabledFunc               func() bool
+	DeclareFixedRolesFunc        func(...accesscontrol.RoleRegistration) error
+	GetUserBuiltInRolesFunc      func(user *models.SignedInUser) []string
+	RegisterFixedRolesFunc       func() error
 }
 
 // Ensure the mock stays in line with the interface
@@ -113,6 +117,38 @@ func (m *Mock) GetUserRoles(ctx context.Context, user *models.SignedInUser) ([]*
 	return m.roles, nil
 }
 
+// GetUserResourcesMetadata returns, for each of the resources, the actions the user can perform on it.
+// This mock computes the metadata from GetUserPermissions unless an override is provided.
+func (m *Mock) GetUserResourcesMetadata(ctx context.Context, user *models.SignedInUser, resource string, resourceIDs map[string]bool) (map[string]accesscontrol.Metadata, error) {
+	m.Calls.GetUserResourcesMetadata = append(m.Calls.GetUserResourcesMetadata, []interface{}{ctx, user, resource, resourceIDs})
+	// Use override if provided
+	if m.GetUserResourcesMetadataFunc != nil {
+		return m.GetUserResourcesMetadataFunc(ctx, user, resource, resourceIDs)
+	}
+	// Otherwise compute the metadata from the Permissions list
+	permissions, err := m.GetUserPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return accesscontrol.GetResourcesMetadata(ctx, permissions, resource, resourceIDs), nil
+}
+
+// GetUsersPermissions returns the permissions of the users.
+// This mock returns m.permissions for every user unless an override is provided.
+func (m *Mock) GetUsersPermissions(ctx context.Context, users []*models.SignedInUser, query accesscontrol.GetUsersPermissionsQuery) (map[int64][]*accesscontrol.Permission, error) {
+	m.Calls.GetUsersPermissions = append(m.Calls.GetUsersPermissions, []interface{}{ctx, users, query})
+	// Use override if provided
+	if m.GetUsersPermissionsFunc != nil {
+		return m.GetUsersPermissionsFunc(ctx, users, query)
+	}
+	// Otherwise return the Permissions list
+	result := make(map[int64][]*accesscontrol.Permission, len(users))
+	for _, user := range users {
+		result[user.UserId] = m.permissions
+	}
+	return result, nil
+}
+
 // Middleware checks if service disabled or not to switch to fallback authorization.
 // This mock return m.disabled unless an override is provided.
 func (m *Mock) IsDisabled() bool {
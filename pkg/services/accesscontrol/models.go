@@ -36,6 +36,10 @@ func (r Role) IsFixed() bool {
 	return strings.HasPrefix(r.Name, FixedRolePrefix)
 }
 
+func (r Role) IsManaged() bool {
+	return strings.HasPrefix(r.Name, ManagedRolePrefix)
+}
+
 func (r Role) GetDisplayName() string {
 	if r.IsFixed() && r.DisplayName == "" {
 		r.DisplayName = fallbackDisplayName(r.Name)
@@ -65,6 +69,8 @@ type RoleDTO struct {
 	Group       string       `xorm:"group_name" json:"group"`
 	Permissions []Permission `json:"permissions,omitempty"`
 	Delegatable *bool        `json:"delegatable,omitempty"`
+	// Includes holds the names of the roles whose permissions are granted by this role as well
+	Includes []string `json:"includes,omitempty" xorm:"-"`
 
 	ID    int64 `json:"-" xorm:"pk autoincr 'id'"`
 	OrgID int64 `json:"-" xorm:"org_id"`
@@ -145,6 +151,50 @@ type UserRole struct {
 	UserID int64 `json:"userId" xorm:"user_id"`
 
 	Created time.Time
+	// ExpiresAt is when the assignment is revoked, assignments without expiry are kept until they are removed
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" xorm:"expires_at"`
+	// AuthModule is the authentication module the role has been synced from through a group mapping, it is empty
+	// for roles assigned through the API
+	AuthModule string `json:"authModule,omitempty" xorm:"auth_module"`
+}
+
+// UserRoleAssignment is a role assigned directly to a user along with the expiry of the assignment
+type UserRoleAssignment struct {
+	Role       `xorm:"extends"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" xorm:"expires_at"`
+	AuthModule string     `json:"authModule,omitempty" xorm:"auth_module"`
+}
+
+// GroupMapping assigns a role to the members of an organization who belong to a group of an external identity
+// provider. The assignments are synced every time the users log in.
+type GroupMapping struct {
+	ID    int64 `json:"id" xorm:"pk autoincr 'id'"`
+	OrgID int64 `json:"-" xorm:"org_id"`
+	// AuthModule restricts the mapping to the logins through an authentication module, such as ldap or
+	// oauth_generic_oauth, the mapping applies to all of them when empty
+	AuthModule string `json:"authModule" xorm:"auth_module"`
+	// GroupID is the group as returned by the identity provider, such as the DN of LDAP groups
+	GroupID string `json:"groupId" xorm:"group_id"`
+	RoleID  int64  `json:"-" xorm:"role_id"`
+	// Provisioned mappings are managed by provisioning files and cannot be removed through the API
+	Provisioned bool `json:"provisioned"`
+
+	Updated time.Time `json:"updated"`
+	Created time.Time `json:"created"`
+}
+
+// GroupMappingDTO is a group mapping along with the role it assigns
+type GroupMappingDTO struct {
+	GroupMapping `xorm:"extends"`
+	RoleUID      string `json:"roleUid" xorm:"role_uid"`
+	RoleName     string `json:"roleName" xorm:"role_name"`
+}
+
+type AddGroupMappingCommand struct {
+	OrgID      int64
+	AuthModule string
+	GroupID    string
+	Role       Role
 }
 
 type BuiltinRole struct {
@@ -163,6 +213,8 @@ type Permission struct {
 	RoleID int64  `json:"-" xorm:"role_id"`
 	Action string `json:"action"`
 	Scope  string `json:"scope"`
+	// Deny makes the permission exclude the action on the scope, overriding any permission granting it
+	Deny bool `json:"deny,omitempty"`
 
 	Updated time.Time `json:"updated"`
 	Created time.Time `json:"created"`
@@ -172,6 +224,7 @@ func (p Permission) OSSPermission() Permission {
 	return Permission{
 		Action: p.Action,
 		Scope:  p.Scope,
+		Deny:   p.Deny,
 	}
 }
 
@@ -179,6 +232,27 @@ type GetUserPermissionsQuery struct {
 	OrgID  int64 `json:"-"`
 	UserID int64 `json:"userId"`
 	Roles  []string
+	// Resource limits the permissions to the ones that can grant, or deny, actions on resources of the type
+	Resource string `json:"-"`
+}
+
+// GetUsersPermissionsQuery selects, for all the users of an organization, the permissions on one of the scopes with
+// an action starting with one of the prefixes
+type GetUsersPermissionsQuery struct {
+	OrgID          int64
+	Scopes         []string
+	ActionPrefixes []string
+}
+
+// RoleAssignmentPermission is a role assigned to a user, or to a built-in role when UserID is 0, with one of its
+// permissions matching a GetUsersPermissionsQuery. Action is empty when none of the permissions of the role match.
+type RoleAssignmentPermission struct {
+	UserID      int64  `xorm:"user_id"`
+	BuiltInRole string `xorm:"built_in_role"`
+	RoleName    string `xorm:"role_name"`
+	Action      string `xorm:"action"`
+	Scope       string `xorm:"scope"`
+	Deny        bool   `xorm:"deny"`
 }
 
 // ScopeParams holds the parameters used to fill in scope templates
@@ -237,6 +311,30 @@ type SetResourcePermissionCommand struct {
 	Actions    []string
 	Resource   string
 	ResourceID string
+	// ResourceAttribute is the attribute identifying the resource in scopes, "id" when empty
+	ResourceAttribute string
+}
+
+// DenyPermission excludes an action on a scope for a user, a team or a built-in role of an organization, whatever
+// the permissions granting it. Deny permissions are stored in the managed role of the subject.
+type DenyPermission struct {
+	ID          int64     `json:"id" xorm:"id"`
+	Action      string    `json:"action"`
+	Scope       string    `json:"scope"`
+	UserID      int64     `json:"userId,omitempty" xorm:"user_id"`
+	TeamID      int64     `json:"teamId,omitempty" xorm:"team_id"`
+	BuiltInRole string    `json:"builtInRole,omitempty" xorm:"built_in_role"`
+	Created     time.Time `json:"created"`
+}
+
+// AddDenyPermissionCommand denies the action on the scope to exactly one of the user, the team or the built-in role.
+// An empty scope denies the action on all scopes.
+type AddDenyPermissionCommand struct {
+	Action      string `json:"action"`
+	Scope       string `json:"scope"`
+	UserID      int64  `json:"userId"`
+	TeamID      int64  `json:"teamId"`
+	BuiltInRole string `json:"builtInRole"`
 }
 
 type GetResourcesPermissionsQuery struct {
@@ -244,6 +342,8 @@ type GetResourcesPermissionsQuery struct {
 	Resource    string
 	ResourceIDs []string
 	OnlyManaged bool
+	// ResourceAttribute is the attribute identifying the resource in scopes, "id" when empty
+	ResourceAttribute string
 }
 
 const (
@@ -268,15 +368,21 @@ const (
 	ActionUsersEnable            = "users:enable"
 	ActionUsersDisable           = "users:disable"
 	ActionUsersPermissionsUpdate = "users.permissions:update"
+	ActionUsersPermissionsList   = "users.permissions:list"
 	ActionUsersLogout            = "users:logout"
 	ActionUsersQuotasList        = "users.quotas:list"
 	ActionUsersQuotasUpdate      = "users.quotas:update"
+	ActionUsersLockoutRead       = "users.lockout:read"
+	ActionUsersUnlock            = "users:unlock"
+	ActionUsersTwoFactorDelete   = "users.2fa:delete"
+	ActionUsersRestore           = "users:restore"
 
 	// Org actions
 	ActionOrgUsersRead       = "org.users:read"
 	ActionOrgUsersAdd        = "org.users:add"
 	ActionOrgUsersRemove     = "org.users:remove"
 	ActionOrgUsersRoleUpdate = "org.users.role:update"
+	ActionOrgUsersLogout     = "org.users:logout"
 
 	// LDAP actions
 	ActionLDAPUsersRead    = "ldap.user:read"
@@ -290,8 +396,48 @@ const (
 	// Settings actions
 	ActionSettingsRead = "settings:read"
 
+	// Team roles actions
+	ActionTeamsRolesList   = "teams.roles:list"
+	ActionTeamsRolesAdd    = "teams.roles:add"
+	ActionTeamsRolesRemove = "teams.roles:remove"
+
+	// User roles actions
+	ActionUsersRolesList   = "users.roles:list"
+	ActionUsersRolesAdd    = "users.roles:add"
+	ActionUsersRolesRemove = "users.roles:remove"
+
+	// Group mappings actions
+	ActionGroupMappingsRead  = "groupmappings:read"
+	ActionGroupMappingsWrite = "groupmappings:write"
+
+	// Role bundles actions
+	ActionRolesExport = "roles:export"
+
+	// Deny permissions actions
+	ActionPermissionsDeniesRead  = "permissions.denies:read"
+	ActionPermissionsDeniesWrite = "permissions.denies:write"
+
+	// Dashboards actions
+	ActionDashboardsCreate           = "dashboards:create"
+	ActionDashboardsRead             = "dashboards:read"
+	ActionDashboardsWrite            = "dashboards:write"
+	ActionDashboardsDelete           = "dashboards:delete"
+	ActionDashboardsPermissionsRead  = "dashboards.permissions:read"
+	ActionDashboardsPermissionsWrite = "dashboards.permissions:write"
+
+	// Folders actions
+	ActionFoldersRead             = "folders:read"
+	ActionFoldersWrite            = "folders:write"
+	ActionFoldersDelete           = "folders:delete"
+	ActionFoldersPermissionsRead  = "folders.permissions:read"
+	ActionFoldersPermissionsWrite = "folders.permissions:write"
+
 	// Datasources actions
 	ActionDatasourcesExplore = "datasources:explore"
+	ActionDatasourcesRead    = "datasources:read"
+	ActionDatasourcesQuery   = "datasources:query"
+	ActionDatasourcesWrite   = "datasources:write"
+	ActionDatasourcesDelete  = "datasources:delete"
 
 	// Plugin actions
 	ActionPluginsManage = "plugins:manage"
@@ -305,6 +451,16 @@ const (
 	// Settings scope
 	ScopeSettingsAll = "settings:*"
 
+	// Teams scope
+	ScopeTeamsAll = "teams:*"
+
+	// Dashboards and folders scopes
+	ScopeDashboardsAll = "dashboards:*"
+	ScopeFoldersAll    = "folders:*"
+
+	// Delegation scope, the permissions granted by the assigned role have to be held by the assigner
+	ScopePermissionsDelegate = "permissions:delegate"
+
 	// Licensing related actions
 	ActionLicensingRead        = "licensing:read"
 	ActionLicensingUpdate      = "licensing:update"
@@ -316,6 +472,8 @@ const RoleGrafanaAdmin = "Grafana Admin"
 
 const FixedRolePrefix = "fixed:"
 
+const ManagedRolePrefix = "managed:"
+
 // LicensingPageReaderAccess defines permissions that grant access to the licensing and stats page
 var LicensingPageReaderAccess = EvalAny(
 	EvalPermission(ActionLicensingRead),
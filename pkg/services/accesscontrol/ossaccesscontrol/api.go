@@ -0,0 +1,599 @@
+package ossaccesscontrol
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	ac     *OSSAccessControlService
+	router routing.RouteRegister
+}
+
+func newAPI(ac *OSSAccessControlService, router routing.RouteRegister) *api {
+	return &api{ac: ac, router: router}
+}
+
+func (a *api) registerEndpoints() {
+	if a.router == nil {
+		return
+	}
+
+	auth := acmiddleware.Middleware(a.ac)
+	disable := acmiddleware.Disable(a.ac.IsDisabled())
+	a.router.Post("/api/access-control/check", middleware.ReqSignedIn, disable, routing.Wrap(a.checkPermission))
+	userIDScope := accesscontrol.Scope("users", "id", accesscontrol.Parameter(":userId"))
+	a.router.Get("/api/access-control/users/:userId/report", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersPermissionsList, userIDScope)), routing.Wrap(a.getUserPermissionsReport))
+	a.router.Group("/api/access-control/teams/:teamId/roles", func(r routing.RouteRegister) {
+		teamIDScope := accesscontrol.Scope("teams", "id", accesscontrol.Parameter(":teamId"))
+		r.Get("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesList, teamIDScope)), routing.Wrap(a.getTeamRoles))
+		r.Post("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesAdd, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.addTeamRole))
+		r.Delete("/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesRemove, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.removeTeamRole))
+	})
+	if a.ac.userRoles != nil {
+		a.router.Group("/api/access-control/users/:userId/roles", func(r routing.RouteRegister) {
+			r.Get("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesList, userIDScope)), routing.Wrap(a.getUserRoles))
+			r.Post("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesAdd, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.addUserRole))
+			r.Delete("/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesRemove, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.removeUserRole))
+		})
+	}
+	if a.ac.groupMappings != nil {
+		a.router.Group("/api/access-control/group-mappings", func(r routing.RouteRegister) {
+			r.Get("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionGroupMappingsRead)), routing.Wrap(a.getGroupMappings))
+			r.Post("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionGroupMappingsWrite, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.addGroupMapping))
+			r.Delete("/:mappingId", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionGroupMappingsWrite, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.removeGroupMapping))
+		})
+	}
+	if a.ac.roleBundles != nil {
+		a.router.Get("/api/access-control/export", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesExport)), routing.Wrap(a.exportRoleBundle))
+	}
+	if a.ac.denies == nil {
+		return
+	}
+	a.router.Group("/api/access-control/denies", func(r routing.RouteRegister) {
+		r.Get("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionPermissionsDeniesRead)), routing.Wrap(a.getDenyPermissions))
+		r.Post("/", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionPermissionsDeniesWrite)), routing.Wrap(a.addDenyPermission))
+		r.Delete("/:denyId", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionPermissionsDeniesWrite)), routing.Wrap(a.removeDenyPermission))
+	})
+}
+
+func (a *api) getTeamRoles(c *models.ReqContext) response.Response {
+	teamID := c.ParamsInt64(":teamId")
+
+	roles, err := a.ac.store.GetTeamRoles(c.Req.Context(), c.OrgId, teamID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get team roles", err)
+	}
+
+	dto := make([]accesscontrol.RoleDTO, 0, len(roles))
+	for _, r := range roles {
+		role, err := a.ac.GetFixedRoleByUID(r.UID)
+		if err != nil {
+			// The role is no longer declared, it grants nothing
+			continue
+		}
+		dto = append(dto, role)
+	}
+
+	return response.JSON(http.StatusOK, dto)
+}
+
+type addTeamRoleCommand struct {
+	RoleUID string `json:"roleUid"`
+}
+
+func (a *api) addTeamRole(c *models.ReqContext) response.Response {
+	teamID := c.ParamsInt64(":teamId")
+
+	var cmd addTeamRoleCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	role, err := a.ac.GetFixedRoleByUID(cmd.RoleUID)
+	if err != nil {
+		return response.Error(http.StatusNotFound, "Role not found", err)
+	}
+
+	if resp := a.checkDelegation(c, role); resp != nil {
+		return resp
+	}
+
+	assigned := role.Role()
+	assigned.OrgID = accesscontrol.GlobalOrgID
+	if err := a.ac.store.AddTeamRole(c.Req.Context(), c.OrgId, teamID, assigned); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(http.StatusNotFound, "Team not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add role to team", err)
+	}
+
+	return response.Success("Role added to the team.")
+}
+
+func (a *api) removeTeamRole(c *models.ReqContext) response.Response {
+	teamID := c.ParamsInt64(":teamId")
+	roleUID := web.Params(c.Req)[":roleUID"]
+
+	// Roles that are no longer declared grant nothing and can always be removed
+	if role, err := a.ac.GetFixedRoleByUID(roleUID); err == nil {
+		if resp := a.checkDelegation(c, role); resp != nil {
+			return resp
+		}
+	}
+
+	if err := a.ac.store.RemoveTeamRole(c.Req.Context(), c.OrgId, teamID, roleUID); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(http.StatusNotFound, "Team not found", err)
+		}
+		if errors.Is(err, accesscontrol.ErrTeamRoleNotFound) {
+			return response.Error(http.StatusNotFound, "Role is not assigned to the team", err)
+		}
+		if errors.Is(err, accesscontrol.ErrRoleBindingProvisioned) {
+			return response.Error(http.StatusBadRequest, "Provisioned role assignments cannot be removed through the API", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove role from team", err)
+	}
+
+	return response.Success("Role removed from the team.")
+}
+
+// userRoleDTO is a role assigned directly to a user, along with the expiry of the assignment
+type userRoleDTO struct {
+	accesscontrol.RoleDTO
+	// ExpiresAt is when the assignment is revoked, the role is assigned until it is removed otherwise
+	ExpiresAt *time.Time
+	// AuthModule is the authentication module the role is synced from through a group mapping
+	AuthModule string
+}
+
+func (r userRoleDTO) MarshalJSON() ([]byte, error) {
+	type Alias accesscontrol.RoleDTO
+
+	r.DisplayName = r.GetDisplayName()
+	return json.Marshal(&struct {
+		Alias
+		Global     bool       `json:"global"`
+		ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+		AuthModule string     `json:"authModule,omitempty"`
+	}{
+		Alias:      (Alias)(r.RoleDTO),
+		Global:     r.Global(),
+		ExpiresAt:  r.ExpiresAt,
+		AuthModule: r.AuthModule,
+	})
+}
+
+func (a *api) getUserRoles(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":userId")
+
+	roles, err := a.ac.userRoles.GetUserRoles(c.Req.Context(), c.OrgId, userID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user roles", err)
+	}
+
+	dto := make([]userRoleDTO, 0, len(roles))
+	for _, r := range roles {
+		role, err := a.ac.GetFixedRoleByUID(r.UID)
+		if err != nil {
+			// The role is no longer declared, it grants nothing
+			continue
+		}
+		dto = append(dto, userRoleDTO{RoleDTO: role, ExpiresAt: r.ExpiresAt, AuthModule: r.AuthModule})
+	}
+
+	return response.JSON(http.StatusOK, dto)
+}
+
+type addUserRoleCommand struct {
+	RoleUID string `json:"roleUid"`
+	// ExpiresAt is when the assignment is revoked, the role is assigned until it is removed when empty
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func (a *api) addUserRole(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":userId")
+
+	var cmd addUserRoleCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.ExpiresAt != nil && !cmd.ExpiresAt.After(time.Now()) {
+		return response.Error(http.StatusBadRequest, "Expiry must be in the future", nil)
+	}
+
+	role, err := a.ac.GetFixedRoleByUID(cmd.RoleUID)
+	if err != nil {
+		return response.Error(http.StatusNotFound, "Role not found", err)
+	}
+
+	if resp := a.checkDelegation(c, role); resp != nil {
+		return resp
+	}
+
+	assigned := role.Role()
+	assigned.OrgID = accesscontrol.GlobalOrgID
+	if err := a.ac.userRoles.AddUserRole(c.Req.Context(), c.OrgId, userID, assigned, cmd.ExpiresAt); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, "User not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add role to user", err)
+	}
+
+	return response.Success("Role added to the user.")
+}
+
+func (a *api) removeUserRole(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":userId")
+	roleUID := web.Params(c.Req)[":roleUID"]
+
+	// Roles that are no longer declared grant nothing and can always be removed
+	if role, err := a.ac.GetFixedRoleByUID(roleUID); err == nil {
+		if resp := a.checkDelegation(c, role); resp != nil {
+			return resp
+		}
+	}
+
+	if err := a.ac.userRoles.RemoveUserRole(c.Req.Context(), c.OrgId, userID, roleUID); err != nil {
+		if errors.Is(err, accesscontrol.ErrUserRoleNotFound) {
+			return response.Error(http.StatusNotFound, "Role is not assigned to the user", err)
+		}
+		if errors.Is(err, accesscontrol.ErrRoleBindingProvisioned) {
+			return response.Error(http.StatusBadRequest, "Provisioned role assignments cannot be removed through the API", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove role from user", err)
+	}
+
+	return response.Success("Role removed from the user.")
+}
+
+func (a *api) getGroupMappings(c *models.ReqContext) response.Response {
+	mappings, err := a.ac.groupMappings.GetGroupMappings(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get group mappings", err)
+	}
+	return response.JSON(http.StatusOK, mappings)
+}
+
+// exportRoleBundle returns the custom roles and assignments of the organization as a provisioning file
+func (a *api) exportRoleBundle(c *models.ReqContext) response.Response {
+	bundle, err := a.ac.roleBundles.ExportRoleBundle(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to export roles", err)
+	}
+
+	body, err := yaml.Marshal(bundle)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to encode roles", err)
+	}
+
+	return response.Respond(http.StatusOK, body).
+		SetHeader("Content-Type", "application/x-yaml").
+		SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="access-control-%d.yaml"`, c.OrgId))
+}
+
+type addGroupMappingCommand struct {
+	// AuthModule restricts the mapping to an authentication module, such as ldap or oauth_generic_oauth
+	AuthModule string `json:"authModule"`
+	GroupID    string `json:"groupId" binding:"Required"`
+	RoleUID    string `json:"roleUid" binding:"Required"`
+}
+
+func (a *api) addGroupMapping(c *models.ReqContext) response.Response {
+	var cmd addGroupMappingCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	role, err := a.ac.GetFixedRoleByUID(cmd.RoleUID)
+	if err != nil {
+		return response.Error(http.StatusNotFound, "Role not found", err)
+	}
+
+	if resp := a.checkDelegation(c, role); resp != nil {
+		return resp
+	}
+
+	mapped := role.Role()
+	mapped.OrgID = accesscontrol.GlobalOrgID
+	mapping, err := a.ac.groupMappings.AddGroupMapping(c.Req.Context(), accesscontrol.AddGroupMappingCommand{
+		OrgID:      c.OrgId,
+		AuthModule: cmd.AuthModule,
+		GroupID:    cmd.GroupID,
+		Role:       mapped,
+	})
+	if err != nil {
+		if errors.Is(err, accesscontrol.ErrGroupMappingExists) {
+			return response.Error(http.StatusConflict, "Group is already mapped to the role", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add group mapping", err)
+	}
+
+	return response.JSON(http.StatusOK, accesscontrol.GroupMappingDTO{GroupMapping: *mapping, RoleUID: role.UID, RoleName: role.Name})
+}
+
+func (a *api) removeGroupMapping(c *models.ReqContext) response.Response {
+	mappingID := c.ParamsInt64(":mappingId")
+
+	mappings, err := a.ac.groupMappings.GetGroupMappings(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get group mappings", err)
+	}
+	for _, m := range mappings {
+		if m.ID != mappingID {
+			continue
+		}
+		// Roles that are no longer declared grant nothing and can always be unmapped
+		if role, err := a.ac.GetFixedRoleByUID(m.RoleUID); err == nil {
+			if resp := a.checkDelegation(c, role); resp != nil {
+				return resp
+			}
+		}
+	}
+
+	if err := a.ac.groupMappings.RemoveGroupMapping(c.Req.Context(), c.OrgId, mappingID); err != nil {
+		if errors.Is(err, accesscontrol.ErrGroupMappingNotFound) {
+			return response.Error(http.StatusNotFound, "Group mapping not found", err)
+		}
+		if errors.Is(err, accesscontrol.ErrGroupMappingProvisioned) {
+			return response.Error(http.StatusBadRequest, "Provisioned group mappings cannot be removed through the API", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove group mapping", err)
+	}
+
+	return response.Success("Group mapping removed. The role is unassigned from the members of the group the next time they log in.")
+}
+
+// checkDelegation makes sure users can only (un)assign roles granting permissions they already have
+// to prevent escalation of privileges. Deny permissions only restrict access and are not checked.
+func (a *api) checkDelegation(c *models.ReqContext, role accesscontrol.RoleDTO) response.Response {
+	evaluators := make([]accesscontrol.Evaluator, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		if p.Deny {
+			continue
+		}
+		permission, err := a.ac.scopeResolver.ResolveKeyword(c.SignedInUser, p)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to resolve role permissions", err)
+		}
+		if permission.Scope == "" {
+			evaluators = append(evaluators, accesscontrol.EvalPermission(permission.Action))
+			continue
+		}
+		evaluators = append(evaluators, accesscontrol.EvalPermission(permission.Action, permission.Scope))
+	}
+
+	hasAccess, err := a.ac.Evaluate(c.Req.Context(), c.SignedInUser, accesscontrol.EvalAll(evaluators...))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to evaluate role permissions", err)
+	}
+	if !hasAccess {
+		return response.Error(http.StatusForbidden, "Cannot delegate permissions the user does not have", nil)
+	}
+	return nil
+}
+
+type checkPermissionCommand struct {
+	Action string `json:"action" binding:"Required"`
+	Scope  string `json:"scope"`
+	// UserID is the user to check the permission for, defaults to the signed in user
+	UserID int64 `json:"userId"`
+}
+
+type checkPermissionGrant struct {
+	RoleName    string `json:"roleName"`
+	RoleUID     string `json:"roleUid"`
+	BuiltInRole string `json:"builtInRole,omitempty"`
+	TeamID      int64  `json:"teamId,omitempty"`
+	UserID      int64  `json:"userId,omitempty"`
+	// ExpiresAt is when the role assigned directly to the user is revoked
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Action    string     `json:"action"`
+	Scope     string     `json:"scope"`
+}
+
+// checkPermissionDeny is a deny permission of the user overriding the grants
+type checkPermissionDeny struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+}
+
+type checkPermissionResult struct {
+	Allowed bool                   `json:"allowed"`
+	Grants  []checkPermissionGrant `json:"grants"`
+	Denies  []checkPermissionDeny  `json:"denies"`
+}
+
+func (a *api) checkPermission(c *models.ReqContext) response.Response {
+	var cmd checkPermissionCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	user := c.SignedInUser
+	if cmd.UserID != 0 && cmd.UserID != c.UserId {
+		scope := accesscontrol.Scope("users", "id", strconv.FormatInt(cmd.UserID, 10))
+		hasAccess, err := a.ac.Evaluate(c.Req.Context(), c.SignedInUser, accesscontrol.EvalPermission(accesscontrol.ActionUsersPermissionsList, scope))
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to evaluate permissions", err)
+		}
+		if !hasAccess {
+			return response.Error(http.StatusForbidden, "Not allowed to check the permissions of other users", nil)
+		}
+
+		orgUser, resp := a.getOrgUser(c, cmd.UserID)
+		if resp != nil {
+			return resp
+		}
+		user = orgUser
+	}
+
+	evaluator := accesscontrol.EvalPermission(cmd.Action)
+	if cmd.Scope != "" {
+		evaluator = accesscontrol.EvalPermission(cmd.Action, cmd.Scope)
+	}
+
+	allowed, err := a.ac.Evaluate(c.Req.Context(), user, evaluator)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to evaluate permissions", err)
+	}
+
+	grants, err := a.ac.explainPermission(c.Req.Context(), user, evaluator)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get granting roles", err)
+	}
+
+	denies, err := a.ac.explainDenies(c.Req.Context(), user, evaluator)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get deny permissions", err)
+	}
+
+	return response.JSON(http.StatusOK, checkPermissionResult{Allowed: allowed, Grants: grants, Denies: denies})
+}
+
+// getOrgUser returns the user with the given id as a member of the organization of the signed in user
+func (a *api) getOrgUser(c *models.ReqContext, userID int64) (*models.SignedInUser, response.Response) {
+	query := models.GetSignedInUserQuery{UserId: userID, OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, response.Error(http.StatusNotFound, "User not found", err)
+		}
+		return nil, response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+	if query.Result.OrgId != c.OrgId {
+		return nil, response.Error(http.StatusNotFound, "User not found", models.ErrUserNotFound)
+	}
+	return query.Result, nil
+}
+
+func (a *api) getUserPermissionsReport(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":userId")
+	format := c.Query("format")
+	if format != "" && format != "json" && format != "csv" {
+		return response.Error(http.StatusBadRequest, "Unsupported report format, expected json or csv", nil)
+	}
+
+	user, resp := a.getOrgUser(c, userID)
+	if resp != nil {
+		return resp
+	}
+
+	report, err := a.ac.reportUserPermissions(c.Req.Context(), user)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user permissions", err)
+	}
+
+	if format != "csv" {
+		return response.JSON(http.StatusOK, report)
+	}
+
+	body, err := permissionReportCSV(report)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to write user permissions report", err)
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "text/csv; charset=utf-8")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-permissions.csv"`, userID))
+	return response.CreateNormalResponse(header, body, http.StatusOK)
+}
+
+// permissionReportCSV writes the report with one row per permission and source
+func permissionReportCSV(report []permissionReportEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"action", "scope", "role_type", "role_name", "role_uid", "built_in_role", "team_id", "effect", "expires_at"}); err != nil {
+		return nil, err
+	}
+	for _, entry := range report {
+		effect := "allow"
+		if entry.Deny {
+			effect = "deny"
+		}
+		for _, source := range entry.Sources {
+			teamID := ""
+			if source.TeamID != 0 {
+				teamID = strconv.FormatInt(source.TeamID, 10)
+			}
+			expiresAt := ""
+			if source.ExpiresAt != nil {
+				expiresAt = source.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+			if err := w.Write([]string{entry.Action, entry.Scope, source.RoleType, source.RoleName, source.RoleUID, source.BuiltInRole, teamID, effect, expiresAt}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (a *api) getDenyPermissions(c *models.ReqContext) response.Response {
+	denies, err := a.ac.denies.GetDenyPermissions(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get deny permissions", err)
+	}
+	return response.JSON(http.StatusOK, denies)
+}
+
+func (a *api) addDenyPermission(c *models.ReqContext) response.Response {
+	var cmd accesscontrol.AddDenyPermissionCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	// Make sure the subject belongs to the organization of the signed in user
+	if cmd.UserID != 0 {
+		if _, resp := a.getOrgUser(c, cmd.UserID); resp != nil {
+			return resp
+		}
+	}
+	if cmd.TeamID != 0 {
+		query := models.GetTeamByIdQuery{OrgId: c.OrgId, Id: cmd.TeamID}
+		if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+			if errors.Is(err, models.ErrTeamNotFound) {
+				return response.Error(http.StatusNotFound, "Team not found", err)
+			}
+			return response.Error(http.StatusInternalServerError, "Failed to get team", err)
+		}
+	}
+
+	deny, err := a.ac.denies.AddDenyPermission(c.Req.Context(), c.OrgId, cmd)
+	if err != nil {
+		if errors.Is(err, accesscontrol.ErrInvalidDenyPermission) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add deny permission", err)
+	}
+
+	return response.JSON(http.StatusOK, deny)
+}
+
+func (a *api) removeDenyPermission(c *models.ReqContext) response.Response {
+	denyID := c.ParamsInt64(":denyId")
+
+	if err := a.ac.denies.RemoveDenyPermission(c.Req.Context(), c.OrgId, denyID); err != nil {
+		if errors.Is(err, accesscontrol.ErrDenyPermissionNotFound) {
+			return response.Error(http.StatusNotFound, "Deny permission not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove deny permission", err)
+	}
+
+	return response.Success("Deny permission removed")
+}
@@ -0,0 +1,74 @@
+package ossaccesscontrol
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// getAPIKeyPermissions returns the permissions of the API key the user is authenticated with, and whether the key
+// is permission-scoped. The requests authenticated with permission-scoped keys are only granted the permissions of
+// the key, which were a subset of the permissions of its creator when it was added.
+func (ac *OSSAccessControlService) getAPIKeyPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, bool, error) {
+	if user.ApiKeyId == 0 {
+		return nil, false, nil
+	}
+
+	query := models.GetApiKeyPermissionsQuery{OrgId: user.OrgId, ApiKeyIds: []int64{user.ApiKeyId}}
+	if err := bus.Dispatch(ctx, &query); err != nil {
+		return nil, false, err
+	}
+	if len(query.Result) == 0 {
+		return nil, false, nil
+	}
+
+	permissions := make([]*accesscontrol.Permission, 0, len(query.Result))
+	for _, p := range query.Result {
+		permissions = append(permissions, &accesscontrol.Permission{Action: p.Action, Scope: p.Scope})
+	}
+
+	keyQuery := models.GetApiKeyByIdQuery{ApiKeyId: user.ApiKeyId}
+	if err := bus.Dispatch(ctx, &keyQuery); err != nil {
+		return nil, false, err
+	}
+	if keyQuery.Result.UserId > 0 {
+		permissions, err := ac.userAccessTokenPermissions(ctx, user, permissions)
+		return permissions, true, err
+	}
+	return permissions, true, nil
+}
+
+// userAccessTokenPermissions returns the permissions of a personal access token which are still granted to its owner,
+// so that the token loses the permissions the user loses after it was created. The owner is looked up again, the
+// user authenticated with a scoped token is downgraded to the role of the token.
+func (ac *OSSAccessControlService) userAccessTokenPermissions(ctx context.Context, user *models.SignedInUser, permissions []*accesscontrol.Permission) ([]*accesscontrol.Permission, error) {
+	ownerQuery := models.GetSignedInUserQuery{UserId: user.UserId, OrgId: user.OrgId}
+	if err := bus.Dispatch(ctx, &ownerQuery); err != nil {
+		return nil, err
+	}
+	owner := ownerQuery.Result
+	owner.ApiKeyId = 0
+	ownerPermissions, err := ac.GetUserPermissions(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	granted := accesscontrol.GroupScopesByAction(ownerPermissions)
+
+	result := make([]*accesscontrol.Permission, 0, len(permissions))
+	for _, p := range permissions {
+		var scopes []string
+		if p.Scope != "" {
+			scopes = append(scopes, p.Scope)
+		}
+		ok, err := accesscontrol.EvalPermission(p.Action, scopes...).Evaluate(granted)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package ossaccesscontrol
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// permissionsCacheTTL is kept short as the changes made through other Grafana instances are not received, they are
+// only picked up once the permissions cached before them expire
+const permissionsCacheTTL = 30 * time.Second
+
+// permissionsCacheKey returns the key the permissions of the user are cached under. It holds the permissions version,
+// so that the permissions cached before roles, team memberships or organization roles changed are not used anymore.
+// An empty resource is used for the permissions of all the resource types. The API key is part of the key as
+// permission-scoped API keys don't get the permissions of their user or role.
+func (ac *OSSAccessControlService) permissionsCacheKey(user *models.SignedInUser, resource string) string {
+	return fmt.Sprintf("%d-%d-%d-%s-%t-%d-%s", user.OrgId, user.UserId, user.ApiKeyId, user.OrgRole, user.IsGrafanaAdmin, atomic.LoadInt64(&ac.permissionsVersion), resource)
+}
+
+func (ac *OSSAccessControlService) getCachedPermissions(key string) ([]*accesscontrol.Permission, bool) {
+	if cached, ok := ac.permissionsCache.Get(key); ok {
+		metrics.MAccessPermissionsCacheUsage.WithLabelValues("hit").Inc()
+		return cached.([]*accesscontrol.Permission), true
+	}
+	metrics.MAccessPermissionsCacheUsage.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+// invalidatePermissions bumps the permissions version so that permissions cached before the change are not used anymore
+func (ac *OSSAccessControlService) invalidatePermissions() {
+	atomic.AddInt64(&ac.permissionsVersion, 1)
+	metrics.MAccessPermissionsCacheInvalidations.Inc()
+}
+
+// registerInvalidationListeners invalidates the cached permissions when the roles, team memberships or organization
+// roles they are computed from change
+func (ac *OSSAccessControlService) registerInvalidationListeners(b bus.Bus) {
+	if b == nil {
+		return
+	}
+	b.AddEventListener(func(context.Context, *events.AccessControlRolesChanged) error {
+		ac.invalidatePermissions()
+		return nil
+	})
+	b.AddEventListener(func(context.Context, *events.TeamMembershipChanged) error {
+		ac.invalidatePermissions()
+		return nil
+	})
+	b.AddEventListener(func(context.Context, *events.OrgUserRoleChanged) error {
+		ac.invalidatePermissions()
+		return nil
+	})
+}
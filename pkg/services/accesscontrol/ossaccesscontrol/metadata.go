@@ -0,0 +1,114 @@
+package ossaccesscontrol
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// GetUserResourcesMetadata returns, for each of the resources, the actions the user can perform on it.
+// Only the permissions scoped to the resource type are loaded, and they are cached per user and permissions version.
+func (ac *OSSAccessControlService) GetUserResourcesMetadata(ctx context.Context, user *models.SignedInUser, resource string, resourceIDs map[string]bool) (map[string]accesscontrol.Metadata, error) {
+	permissions, err := ac.getUserResourcePermissions(ctx, user, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return accesscontrol.GetResourcesMetadata(ctx, permissions, resource, resourceIDs), nil
+}
+
+func (ac *OSSAccessControlService) getUserResourcePermissions(ctx context.Context, user *models.SignedInUser, resource string) ([]*accesscontrol.Permission, error) {
+	key := ac.permissionsCacheKey(user, resource)
+	if cached, ok := ac.getCachedPermissions(key); ok {
+		return cached, nil
+	}
+
+	if scoped, ok, err := ac.getAPIKeyPermissions(ctx, user); err != nil || ok {
+		if err != nil {
+			return nil, err
+		}
+		permissions := make([]*accesscontrol.Permission, 0, len(scoped))
+		for _, p := range scoped {
+			if appliesToResource(resource, p) {
+				permissions = append(permissions, p)
+			}
+		}
+		ac.permissionsCache.Set(key, permissions, permissionsCacheTTL)
+		return permissions, nil
+	}
+
+	roleNames := make(map[string]struct{})
+	for _, builtin := range ac.GetUserBuiltInRoles(user) {
+		for _, name := range accesscontrol.FixedRoleGrants[builtin] {
+			roleNames[name] = struct{}{}
+		}
+	}
+
+	// Only look up the team assignments of the roles that grant something on the resource type
+	candidates := make([]string, 0)
+	for name, role := range accesscontrol.FixedRoles {
+		if _, granted := roleNames[name]; granted {
+			continue
+		}
+		for _, p := range role.Permissions {
+			permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+			if err != nil {
+				return nil, err
+			}
+			if appliesToResource(resource, permission) {
+				candidates = append(candidates, name)
+				break
+			}
+		}
+	}
+
+	if ac.store != nil && user.UserId != 0 && len(candidates) > 0 {
+		teamRoles, err := ac.store.GetUserTeamRolesByName(ctx, user.OrgId, user.UserId, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range teamRoles {
+			roleNames[role.Name] = struct{}{}
+		}
+	}
+
+	permissions := make([]*accesscontrol.Permission, 0)
+	for name := range roleNames {
+		role, exists := accesscontrol.FixedRoles[name]
+		if !exists {
+			continue
+		}
+		for _, p := range role.Permissions {
+			permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+			if err != nil {
+				return nil, err
+			}
+			if appliesToResource(resource, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	if ac.provider != nil {
+		managed, err := ac.provider.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{
+			OrgID:    user.OrgId,
+			UserID:   user.UserId,
+			Roles:    ac.GetUserBuiltInRoles(user),
+			Resource: resource,
+		})
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, managed...)
+	}
+
+	ac.permissionsCache.Set(key, permissions, permissionsCacheTTL)
+	return permissions, nil
+}
+
+// appliesToResource returns true if the permission can grant, or deny, actions on resources of the given type.
+// Deny permissions without scope exclude the action on all resources.
+func appliesToResource(resource string, permission *accesscontrol.Permission) bool {
+	return accesscontrol.IsResourceScope(resource, permission.Scope) || (permission.Deny && permission.Scope == "")
+}
@@ -3,7 +3,13 @@ package ossaccesscontrol
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
@@ -13,14 +19,27 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func ProvideService(cfg *setting.Cfg, usageStats usagestats.Service) *OSSAccessControlService {
+func ProvideService(cfg *setting.Cfg, bus bus.Bus, usageStats usagestats.Service, store accesscontrol.TeamRoleStore,
+	userRoles accesscontrol.UserRoleStore, groupMappings accesscontrol.GroupMappingStore, provider accesscontrol.PermissionsProvider,
+	denies accesscontrol.DenyPermissionStore, roleBundles accesscontrol.RoleBundleStore, routeRegister routing.RouteRegister,
+	cacheRegistry *localcache.Registry) *OSSAccessControlService {
 	s := &OSSAccessControlService{
-		Cfg:           cfg,
-		UsageStats:    usageStats,
-		Log:           log.New("accesscontrol"),
-		scopeResolver: accesscontrol.NewScopeResolver(),
+		Cfg:              cfg,
+		UsageStats:       usageStats,
+		Log:              log.New("accesscontrol"),
+		scopeResolver:    accesscontrol.NewScopeResolver(),
+		store:            store,
+		userRoles:        userRoles,
+		groupMappings:    groupMappings,
+		provider:         provider,
+		denies:           denies,
+		roleBundles:      roleBundles,
+		permissionsCache: localcache.NewNamed("accesscontrol", permissionsCacheTTL, 2*permissionsCacheTTL),
 	}
+	cacheRegistry.Register(s.permissionsCache)
 	s.registerUsageMetrics()
+	s.registerInvalidationListeners(bus)
+	newAPI(s, routeRegister).registerEndpoints()
 	return s
 }
 
@@ -31,6 +50,21 @@ type OSSAccessControlService struct {
 	Log           log.Logger
 	registrations accesscontrol.RegistrationList
 	scopeResolver accesscontrol.ScopeResolver
+	store         accesscontrol.TeamRoleStore
+	// userRoles manages the roles assigned directly to users, which can expire
+	userRoles accesscontrol.UserRoleStore
+	// groupMappings manages the roles assigned to the groups of external identity providers
+	groupMappings accesscontrol.GroupMappingStore
+	// provider loads the managed permissions, set on individual resources, from the database
+	provider accesscontrol.PermissionsProvider
+	// denies manages the deny permissions of users, teams and built-in roles, stored with the managed permissions
+	denies accesscontrol.DenyPermissionStore
+	// roleBundles exports the custom roles and their assignments as provisioning files
+	roleBundles accesscontrol.RoleBundleStore
+	// permissionsCache holds the permissions of the users, keyed by user and permissions version
+	permissionsCache *localcache.CacheService
+	// permissionsVersion is increased every time roles, their assignments, team memberships or organization roles change
+	permissionsVersion int64
 }
 
 func (ac *OSSAccessControlService) IsDisabled() bool {
@@ -82,33 +116,302 @@ func (ac *OSSAccessControlService) GetUserRoles(ctx context.Context, user *model
 	return nil, errors.New("unsupported function") //OSS users will continue to use builtin roles via GetUserPermissions
 }
 
-// GetUserPermissions returns user permissions based on built-in roles
+// GetUserPermissions returns user permissions based on built-in roles, the roles assigned to the user and the
+// user's teams and the permissions managed on individual resources
 func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, error) {
 	timer := prometheus.NewTimer(metrics.MAccessPermissionsSummary)
 	defer timer.ObserveDuration()
 
-	builtinRoles := ac.GetUserBuiltInRoles(user)
+	key := ac.permissionsCacheKey(user, "")
+	if cached, ok := ac.getCachedPermissions(key); ok {
+		return cached, nil
+	}
+
+	if scoped, ok, err := ac.getAPIKeyPermissions(ctx, user); err != nil || ok {
+		if ok {
+			ac.permissionsCache.Set(key, scoped, permissionsCacheTTL)
+		}
+		return scoped, err
+	}
+
+	roleNames := make(map[string]struct{})
+	for _, builtin := range ac.GetUserBuiltInRoles(user) {
+		for _, name := range accesscontrol.FixedRoleGrants[builtin] {
+			roleNames[name] = struct{}{}
+		}
+	}
+
+	teamRoles, err := ac.getUserTeamRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range teamRoles {
+		roleNames[role.Name] = struct{}{}
+	}
+
+	// The permissions are not cached past the expiry of the roles assigned to the user
+	ttl := permissionsCacheTTL
+	userRoles, err := ac.getUserRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range userRoles {
+		roleNames[role.Name] = struct{}{}
+		if role.ExpiresAt != nil && time.Until(*role.ExpiresAt) < ttl {
+			ttl = time.Until(*role.ExpiresAt)
+		}
+	}
+
 	permissions := make([]*accesscontrol.Permission, 0)
-	for _, builtin := range builtinRoles {
-		if roleNames, ok := accesscontrol.FixedRoleGrants[builtin]; ok {
-			for _, name := range roleNames {
-				role, exists := accesscontrol.FixedRoles[name]
-				if !exists {
-					continue
+	for name := range roleNames {
+		role, exists := accesscontrol.FixedRoles[name]
+		if !exists {
+			continue
+		}
+		for _, p := range role.Permissions {
+			// if the permission has a keyword in its scope it will be resolved
+			permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+			if err != nil {
+				return nil, err
+			}
+			permissions = append(permissions, permission)
+		}
+	}
+
+	managed, err := ac.getUserManagedPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions = append(permissions, managed...)
+	if ttl > 0 {
+		ac.permissionsCache.Set(key, permissions, ttl)
+	}
+	return permissions, nil
+}
+
+// getUserManagedPermissions returns the permissions set on individual resources for the user, the user's teams
+// and the user's built-in roles
+func (ac *OSSAccessControlService) getUserManagedPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+	if ac.provider == nil {
+		return nil, nil
+	}
+	return ac.provider.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{
+		OrgID:  user.OrgId,
+		UserID: user.UserId,
+		Roles:  ac.GetUserBuiltInRoles(user),
+	})
+}
+
+// GetUsersPermissions returns, keyed by user id, the permissions of the users matching the query. The roles assigned
+// to the users, their teams and the built-in roles of the organization are loaded in a single query.
+func (ac *OSSAccessControlService) GetUsersPermissions(ctx context.Context, users []*models.SignedInUser, query accesscontrol.GetUsersPermissionsQuery) (map[int64][]*accesscontrol.Permission, error) {
+	assignments := make([]accesscontrol.RoleAssignmentPermission, 0)
+	if ac.provider != nil && len(users) > 0 {
+		var err error
+		if assignments, err = ac.provider.GetUsersPermissions(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	byUser := make(map[int64][]accesscontrol.RoleAssignmentPermission)
+	byBuiltInRole := make(map[string][]accesscontrol.RoleAssignmentPermission)
+	for _, a := range assignments {
+		if a.UserID == 0 {
+			byBuiltInRole[a.BuiltInRole] = append(byBuiltInRole[a.BuiltInRole], a)
+			continue
+		}
+		byUser[a.UserID] = append(byUser[a.UserID], a)
+	}
+
+	result := make(map[int64][]*accesscontrol.Permission, len(users))
+	for _, user := range users {
+		roleNames := make(map[string]struct{})
+		permissions := make([]*accesscontrol.Permission, 0)
+		add := func(a accesscontrol.RoleAssignmentPermission) {
+			roleNames[a.RoleName] = struct{}{}
+			if a.Action != "" {
+				permissions = append(permissions, &accesscontrol.Permission{Action: a.Action, Scope: a.Scope, Deny: a.Deny})
+			}
+		}
+
+		for _, builtin := range ac.GetUserBuiltInRoles(user) {
+			for _, name := range accesscontrol.FixedRoleGrants[builtin] {
+				roleNames[name] = struct{}{}
+			}
+			for _, a := range byBuiltInRole[builtin] {
+				add(a)
+			}
+		}
+		for _, a := range byUser[user.UserId] {
+			add(a)
+		}
+
+		for name := range roleNames {
+			role, exists := accesscontrol.FixedRoles[name]
+			if !exists {
+				continue
+			}
+			for _, p := range role.Permissions {
+				permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+				if err != nil {
+					return nil, err
 				}
-				for _, p := range role.Permissions {
-					// if the permission has a keyword in its scope it will be resolved
-					permission, err := ac.scopeResolver.ResolveKeyword(user, p)
-					if err != nil {
-						return nil, err
-					}
+				if matchesUsersPermissionsQuery(query, permission) {
 					permissions = append(permissions, permission)
 				}
 			}
 		}
+		result[user.UserId] = permissions
 	}
 
-	return permissions, nil
+	return result, nil
+}
+
+// matchesUsersPermissionsQuery tells whether the permission is selected by the query, as the database does for the
+// permissions it stores
+func matchesUsersPermissionsQuery(query accesscontrol.GetUsersPermissionsQuery, permission *accesscontrol.Permission) bool {
+	matchesScope := permission.Deny && permission.Scope == ""
+	for _, scope := range query.Scopes {
+		matchesScope = matchesScope || permission.Scope == scope
+	}
+	if !matchesScope {
+		return false
+	}
+	if len(query.ActionPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range query.ActionPrefixes {
+		if strings.HasPrefix(permission.Action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ac *OSSAccessControlService) getUserTeamRoles(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Role, error) {
+	if ac.store == nil || user.UserId == 0 {
+		return nil, nil
+	}
+	return ac.store.GetUserTeamRoles(ctx, user.OrgId, user.UserId)
+}
+
+func (ac *OSSAccessControlService) getUserRoles(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.UserRoleAssignment, error) {
+	if ac.userRoles == nil || user.UserId == 0 {
+		return nil, nil
+	}
+	return ac.userRoles.GetUserRoles(ctx, user.OrgId, user.UserId)
+}
+
+// explainPermission returns the permissions, and the roles and assignments they come from, that satisfy the evaluator
+// on their own
+func (ac *OSSAccessControlService) explainPermission(ctx context.Context, user *models.SignedInUser, evaluator accesscontrol.Evaluator) ([]checkPermissionGrant, error) {
+	grants := make([]checkPermissionGrant, 0)
+	collect := func(roleName string, grant checkPermissionGrant) error {
+		role, exists := accesscontrol.FixedRoles[roleName]
+		if !exists {
+			return nil
+		}
+		for _, p := range role.Permissions {
+			permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+			if err != nil {
+				return err
+			}
+			ok, err := evaluator.Evaluate(accesscontrol.GroupScopesByAction([]*accesscontrol.Permission{permission}))
+			if err != nil {
+				return err
+			}
+			if ok {
+				grant.RoleName = role.Name
+				grant.RoleUID = accesscontrol.FixedRoleUID(role.Name)
+				grant.Action = permission.Action
+				grant.Scope = permission.Scope
+				grants = append(grants, grant)
+			}
+		}
+		return nil
+	}
+
+	for _, builtin := range ac.GetUserBuiltInRoles(user) {
+		for _, name := range accesscontrol.FixedRoleGrants[builtin] {
+			if err := collect(name, checkPermissionGrant{BuiltInRole: builtin}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	userRoles, err := ac.getUserRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range userRoles {
+		if err := collect(role.Name, checkPermissionGrant{UserID: user.UserId, ExpiresAt: role.ExpiresAt}); err != nil {
+			return nil, err
+		}
+	}
+
+	if ac.store == nil || user.UserId == 0 {
+		return grants, nil
+	}
+
+	for _, teamID := range user.Teams {
+		roles, err := ac.store.GetTeamRoles(ctx, user.OrgId, teamID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if err := collect(role.Name, checkPermissionGrant{TeamID: teamID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return grants, nil
+}
+
+// explainDenies returns the deny permissions of the user that fail the evaluator on their own, whatever the
+// permissions granting the action
+func (ac *OSSAccessControlService) explainDenies(ctx context.Context, user *models.SignedInUser, evaluator accesscontrol.Evaluator) ([]checkPermissionDeny, error) {
+	permissions, err := ac.GetUserPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	denies := make([]checkPermissionDeny, 0)
+	for _, p := range permissions {
+		if !p.Deny {
+			continue
+		}
+		// Evaluate the deny against a grant of the action on all scopes
+		allowed, err := evaluator.Evaluate(map[string][]string{p.Action: {"*"}})
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+		allowed, err = evaluator.Evaluate(map[string][]string{p.Action: {"*"}, accesscontrol.DenyKey(p.Action): {p.Scope}})
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			denies = append(denies, checkPermissionDeny{Action: p.Action, Scope: p.Scope})
+		}
+	}
+	return denies, nil
+}
+
+// GetFixedRoleByUID returns the registered fixed role matching the uid
+func (ac *OSSAccessControlService) GetFixedRoleByUID(uid string) (accesscontrol.RoleDTO, error) {
+	for _, role := range accesscontrol.FixedRoles {
+		if role.UID == "" {
+			role.UID = accesscontrol.FixedRoleUID(role.Name)
+		}
+		if role.UID == uid {
+			return role, nil
+		}
+	}
+	return accesscontrol.RoleDTO{}, accesscontrol.ErrRoleNotFound
 }
 
 func (ac *OSSAccessControlService) GetUserBuiltInRoles(user *models.SignedInUser) []string {
@@ -163,14 +466,38 @@ func (ac *OSSAccessControlService) RegisterFixedRoles() error {
 	if ac.IsDisabled() {
 		return nil
 	}
-	var err error
 	ac.registrations.Range(func(registration accesscontrol.RoleRegistration) bool {
 		ac.registerFixedRole(registration.Role, registration.Grants)
 		return true
 	})
+	err := ac.resolveFixedRoleIncludes()
+	ac.invalidatePermissions()
 	return err
 }
 
+// resolveFixedRoleIncludes flattens the roles including other roles, so that they hold the permissions of the roles
+// they include once all the roles have been registered
+func (ac *OSSAccessControlService) resolveFixedRoleIncludes() error {
+	resolved := make(map[string][]accesscontrol.Permission)
+	for name, role := range accesscontrol.FixedRoles {
+		if len(role.Includes) == 0 {
+			continue
+		}
+		permissions, err := accesscontrol.ResolveRoleIncludes(role, accesscontrol.FixedRoles)
+		if err != nil {
+			return err
+		}
+		resolved[name] = permissions
+	}
+
+	for name, permissions := range resolved {
+		role := accesscontrol.FixedRoles[name]
+		role.Permissions = permissions
+		accesscontrol.FixedRoles[name] = role
+	}
+	return nil
+}
+
 // RegisterFixedRole saves a fixed role and assigns it to built-in roles
 func (ac *OSSAccessControlService) registerFixedRole(role accesscontrol.RoleDTO, builtInRoles []string) {
 	ac.saveFixedRole(role)
@@ -191,6 +518,12 @@ func (ac *OSSAccessControlService) DeclareFixedRoles(registrations ...accesscont
 			return err
 		}
 
+		for _, name := range r.Role.Includes {
+			if name == r.Role.Name {
+				return fmt.Errorf("%s: %w", name, accesscontrol.ErrRoleIncludeCycle)
+			}
+		}
+
 		err = accesscontrol.ValidateBuiltInRoles(r.Grants)
 		if err != nil {
 			return err
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/models"
@@ -22,11 +26,12 @@ func setupTestEnv(t testing.TB) *OSSAccessControlService {
 	cfg.FeatureToggles = map[string]bool{"accesscontrol": true}
 
 	ac := &OSSAccessControlService{
-		Cfg:           cfg,
-		UsageStats:    &usagestats.UsageStatsMock{T: t},
-		Log:           log.New("accesscontrol"),
-		registrations: accesscontrol.RegistrationList{},
-		scopeResolver: accesscontrol.NewScopeResolver(),
+		Cfg:              cfg,
+		UsageStats:       &usagestats.UsageStatsMock{T: t},
+		Log:              log.New("accesscontrol"),
+		registrations:    accesscontrol.RegistrationList{},
+		scopeResolver:    accesscontrol.NewScopeResolver(),
+		permissionsCache: localcache.New(permissionsCacheTTL, 2*permissionsCacheTTL),
 	}
 	return ac
 }
@@ -38,7 +43,7 @@ func removeRoleHelper(role string) {
 	replaceGrants := map[string][]string{}
 
 	for builtInRole, grants := range accesscontrol.FixedRoleGrants {
-		newGrants := make([]string, len(grants))
+		newGrants := make([]string, 0, len(grants))
 		for _, r := range grants {
 			if r != role {
 				newGrants = append(newGrants, r)
@@ -153,7 +158,7 @@ func TestUsageMetrics(t *testing.T) {
 				cfg.FeatureToggles = map[string]bool{"accesscontrol": true}
 			}
 
-			s := ProvideService(cfg, &usagestats.UsageStatsMock{T: t})
+			s := ProvideService(cfg, nil, &usagestats.UsageStatsMock{T: t}, nil, nil, nil, nil, nil, nil, nil, nil)
 			report, err := s.UsageStats.GetUsageReport(context.Background())
 			assert.Nil(t, err)
 
@@ -362,6 +367,21 @@ func TestOSSAccessControlService_DeclareFixedRoles(t *testing.T) {
 			wantErr: true,
 			err:     accesscontrol.ErrInvalidBuiltinRole,
 		},
+		{
+			name: "should fail registration of a role including itself",
+			registrations: []accesscontrol.RoleRegistration{
+				{
+					Role: accesscontrol.RoleDTO{
+						Version:  1,
+						Name:     "fixed:test:test",
+						Includes: []string{"fixed:test:test"},
+					},
+					Grants: []string{"Admin"},
+				},
+			},
+			wantErr: true,
+			err:     accesscontrol.ErrRoleIncludeCycle,
+		},
 		{
 			name: "should add multiple registrations at once",
 			registrations: []accesscontrol.RoleRegistration{
@@ -436,6 +456,40 @@ func TestOSSAccessControlService_RegisterFixedRoles(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "should register roles including other roles",
+			registrations: []accesscontrol.RoleRegistration{
+				{
+					Role: accesscontrol.RoleDTO{
+						Version:  1,
+						Name:     "fixed:test:plus",
+						Includes: []string{"fixed:test:test"},
+					},
+					Grants: []string{"Editor"},
+				},
+				{
+					Role: accesscontrol.RoleDTO{
+						Version: 1,
+						Name:    "fixed:test:test",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "should fail to register roles including unknown roles",
+			registrations: []accesscontrol.RoleRegistration{
+				{
+					Role: accesscontrol.RoleDTO{
+						Version:  1,
+						Name:     "fixed:test:plus",
+						Includes: []string{"fixed:test:unknown"},
+					},
+					Grants: []string{"Editor"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "should register and assign multiple roles",
 			registrations: []accesscontrol.RoleRegistration{
@@ -576,3 +630,602 @@ func TestOSSAccessControlService_GetUserPermissions(t *testing.T) {
 		})
 	}
 }
+
+type fakeTeamRoleStore struct {
+	accesscontrol.TeamRoleStore
+	userTeamRoles []*accesscontrol.Role
+	calls         int
+	byNameCalls   int
+}
+
+func (f *fakeTeamRoleStore) GetUserTeamRoles(ctx context.Context, orgID, userID int64) ([]*accesscontrol.Role, error) {
+	f.calls++
+	return f.userTeamRoles, nil
+}
+
+func (f *fakeTeamRoleStore) GetUserTeamRolesByName(ctx context.Context, orgID, userID int64, names []string) ([]*accesscontrol.Role, error) {
+	f.byNameCalls++
+	result := make([]*accesscontrol.Role, 0)
+	for _, role := range f.userTeamRoles {
+		for _, name := range names {
+			if role.Name == name {
+				result = append(result, role)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeTeamRoleStore) GetTeamRoles(ctx context.Context, orgID, teamID int64) ([]*accesscontrol.Role, error) {
+	return f.userTeamRoles, nil
+}
+
+func TestOSSAccessControlService_GetUserPermissionsWithTeamRoles(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "fixed:test:team",
+			Permissions: []accesscontrol.Permission{
+				{Action: "test:read", Scope: "test:*"},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	ac.store = &fakeTeamRoleStore{userTeamRoles: []*accesscontrol.Role{
+		{Name: registration.Role.Name, UID: accesscontrol.FixedRoleUID(registration.Role.Name)},
+		{Name: "fixed:test:undeclared"},
+	}}
+
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	permissions, err := ac.GetUserPermissions(context.Background(), user)
+	require.NoError(t, err)
+	assert.Contains(t, extractRawPermissionsHelper(permissions), &accesscontrol.Permission{Action: "test:read", Scope: "test:*"})
+
+	role, err := ac.GetFixedRoleByUID(accesscontrol.FixedRoleUID(registration.Role.Name))
+	require.NoError(t, err)
+	assert.Equal(t, registration.Role.Name, role.Name)
+
+	_, err = ac.GetFixedRoleByUID("unknown")
+	assert.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+}
+
+type fakeUserRoleStore struct {
+	accesscontrol.UserRoleStore
+	userRoles []*accesscontrol.UserRoleAssignment
+	calls     int
+}
+
+func (f *fakeUserRoleStore) GetUserRoles(ctx context.Context, orgID, userID int64) ([]*accesscontrol.UserRoleAssignment, error) {
+	f.calls++
+	return f.userRoles, nil
+}
+
+func TestOSSAccessControlService_GetUserPermissionsWithUserRoles(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:test:breakglass",
+			Permissions: []accesscontrol.Permission{{Action: "test:write", Scope: "test:*"}},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	expiresAt := time.Now().Add(time.Hour)
+	store := &fakeUserRoleStore{userRoles: []*accesscontrol.UserRoleAssignment{
+		{Role: accesscontrol.Role{Name: registration.Role.Name}, ExpiresAt: &expiresAt},
+	}}
+	ac.userRoles = store
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	t.Run("should grant the permissions of roles assigned to the user", func(t *testing.T) {
+		permissions, err := ac.GetUserPermissions(context.Background(), user)
+		require.NoError(t, err)
+		assert.Contains(t, extractRawPermissionsHelper(permissions), &accesscontrol.Permission{Action: "test:write", Scope: "test:*"})
+
+		grants, err := ac.explainPermission(context.Background(), user, accesscontrol.EvalPermission("test:write", "test:1"))
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		assert.Equal(t, int64(2), grants[0].UserID)
+		assert.Equal(t, &expiresAt, grants[0].ExpiresAt)
+	})
+
+	t.Run("should not cache the permissions past the expiry of the roles", func(t *testing.T) {
+		user := &models.SignedInUser{UserId: 3, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+		expiredAt := time.Now().Add(-time.Second)
+		store.userRoles[0].ExpiresAt = &expiredAt
+
+		_, err := ac.GetUserPermissions(context.Background(), user)
+		require.NoError(t, err)
+		calls := store.calls
+		_, err = ac.GetUserPermissions(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, calls+1, store.calls)
+	})
+}
+
+type fakePermissionsProvider struct {
+	permissions []*accesscontrol.Permission
+	roles       []*accesscontrol.Role
+	assignments []accesscontrol.RoleAssignmentPermission
+	query       accesscontrol.GetUserPermissionsQuery
+}
+
+func (f *fakePermissionsProvider) GetUserPermissions(ctx context.Context, query accesscontrol.GetUserPermissionsQuery) ([]*accesscontrol.Permission, error) {
+	f.query = query
+	return f.permissions, nil
+}
+
+func (f *fakePermissionsProvider) GetUserAssignedRoles(ctx context.Context, query accesscontrol.GetUserPermissionsQuery) ([]*accesscontrol.Role, error) {
+	return f.roles, nil
+}
+
+func (f *fakePermissionsProvider) GetUsersPermissions(ctx context.Context, query accesscontrol.GetUsersPermissionsQuery) ([]accesscontrol.RoleAssignmentPermission, error) {
+	return f.assignments, nil
+}
+
+func TestOSSAccessControlService_GetUsersPermissions(t *testing.T) {
+	ac := setupTestEnv(t)
+	ac.RegisterFixedRoles()
+	accesscontrol.FixedRoles["fixed:dashboards:writer"] = accesscontrol.RoleDTO{
+		Name: "fixed:dashboards:writer",
+		Permissions: []accesscontrol.Permission{
+			{Action: accesscontrol.ActionDashboardsWrite, Scope: accesscontrol.ScopeDashboardsAll},
+			{Action: accesscontrol.ActionUsersRead, Scope: accesscontrol.ScopeGlobalUsersAll},
+		},
+	}
+	t.Cleanup(func() { delete(accesscontrol.FixedRoles, "fixed:dashboards:writer") })
+
+	ac.provider = &fakePermissionsProvider{assignments: []accesscontrol.RoleAssignmentPermission{
+		{UserID: 1, RoleName: "managed:users:1:permissions", Action: accesscontrol.ActionDashboardsRead, Scope: "dashboards:uid:abc"},
+		{UserID: 2, RoleName: "fixed:dashboards:writer"},
+		{BuiltInRole: "Editor", RoleName: "managed:builtins:editor:permissions", Action: accesscontrol.ActionDashboardsDelete, Scope: "dashboards:uid:abc"},
+	}}
+
+	users := []*models.SignedInUser{
+		{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+		{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+		{UserId: 3, OrgId: 1, OrgRole: models.ROLE_EDITOR},
+	}
+	permissions, err := ac.GetUsersPermissions(context.Background(), users, accesscontrol.GetUsersPermissionsQuery{
+		OrgID:          1,
+		Scopes:         []string{accesscontrol.ScopeDashboardsAll, "dashboards:uid:abc"},
+		ActionPrefixes: []string{"dashboards"},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, permissions[1], &accesscontrol.Permission{Action: accesscontrol.ActionDashboardsRead, Scope: "dashboards:uid:abc"})
+	assert.Contains(t, permissions[2], &accesscontrol.Permission{Action: accesscontrol.ActionDashboardsWrite, Scope: accesscontrol.ScopeDashboardsAll})
+	assert.Contains(t, permissions[3], &accesscontrol.Permission{Action: accesscontrol.ActionDashboardsDelete, Scope: "dashboards:uid:abc"})
+	assert.NotContains(t, permissions[1], &accesscontrol.Permission{Action: accesscontrol.ActionDashboardsDelete, Scope: "dashboards:uid:abc"})
+	for _, p := range permissions[2] {
+		assert.NotEqual(t, accesscontrol.ActionUsersRead, p.Action)
+	}
+}
+
+func TestOSSAccessControlService_GetUserPermissionsWithManagedPermissions(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+	managed := &accesscontrol.Permission{Action: "datasources:query", Scope: "datasources:uid:abc"}
+
+	ac := setupTestEnv(t)
+	provider := &fakePermissionsProvider{permissions: []*accesscontrol.Permission{managed}}
+	ac.provider = provider
+
+	permissions, err := ac.GetUserPermissions(context.Background(), user)
+	require.NoError(t, err)
+	assert.Contains(t, permissions, managed)
+	assert.Equal(t, int64(1), provider.query.OrgID)
+	assert.Equal(t, int64(2), provider.query.UserID)
+	assert.ElementsMatch(t, []string{"Editor", "Viewer"}, provider.query.Roles)
+}
+
+func TestOSSAccessControlService_EvaluateWithDenyPermissions(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+	ac := setupTestEnv(t)
+	ac.provider = &fakePermissionsProvider{permissions: []*accesscontrol.Permission{
+		{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:*"},
+		{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:uid:abc", Deny: true},
+	}}
+
+	allowed, err := ac.Evaluate(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, "datasources:uid:def"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = ac.Evaluate(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, "datasources:uid:abc"))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	denies, err := ac.explainDenies(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, "datasources:uid:abc"))
+	require.NoError(t, err)
+	assert.Equal(t, []checkPermissionDeny{{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:uid:abc"}}, denies)
+
+	denies, err = ac.explainDenies(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, "datasources:uid:def"))
+	require.NoError(t, err)
+	assert.Empty(t, denies)
+}
+
+func TestOSSAccessControlService_ExplainPermission(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_ADMIN, Teams: []int64{5}}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "fixed:test:team",
+			Permissions: []accesscontrol.Permission{
+				{Action: accesscontrol.ActionTeamsRolesList, Scope: "teams:id:1"},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	ac.store = &fakeTeamRoleStore{userTeamRoles: []*accesscontrol.Role{{Name: registration.Role.Name}}}
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	grants, err := ac.explainPermission(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesList, "teams:id:1"))
+	require.NoError(t, err)
+	require.Len(t, grants, 3)
+
+	byRole := map[string]checkPermissionGrant{}
+	for _, g := range grants {
+		byRole[g.RoleName] = g
+	}
+	assert.Equal(t, string(models.ROLE_ADMIN), byRole["fixed:teams.roles:reader"].BuiltInRole)
+	assert.Equal(t, accesscontrol.ScopeTeamsAll, byRole["fixed:teams.roles:writer"].Scope)
+	assert.Equal(t, int64(5), byRole[registration.Role.Name].TeamID)
+	assert.Equal(t, accesscontrol.FixedRoleUID(registration.Role.Name), byRole[registration.Role.Name].RoleUID)
+
+	grants, err = ac.explainPermission(context.Background(), user, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesList, "teams:id:2"))
+	require.NoError(t, err)
+	assert.Len(t, grants, 2)
+}
+
+func TestOSSAccessControlService_ReportUserPermissions(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_ADMIN, Teams: []int64{5}}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "fixed:test:team",
+			Permissions: []accesscontrol.Permission{
+				{Action: accesscontrol.ActionTeamsRolesList, Scope: accesscontrol.ScopeTeamsAll},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	ac.store = &fakeTeamRoleStore{userTeamRoles: []*accesscontrol.Role{{Name: registration.Role.Name}}}
+	ac.provider = &fakePermissionsProvider{
+		roles: []*accesscontrol.Role{
+			{ID: 1, UID: "user", Name: accesscontrol.ManagedUserRoleName(2)},
+			{ID: 2, UID: "team", Name: accesscontrol.ManagedTeamRoleName(5)},
+			{ID: 3, UID: "viewer", Name: accesscontrol.ManagedBuiltInRoleName("Viewer")},
+			{ID: 4, UID: "custom", Name: "custom:reader"},
+		},
+		permissions: []*accesscontrol.Permission{
+			{RoleID: 1, Action: "dashboards:read", Scope: "dashboards:uid:abc"},
+			{RoleID: 2, Action: "dashboards:read", Scope: "dashboards:uid:abc"},
+			{RoleID: 3, Action: "dashboards:write", Scope: "dashboards:uid:abc"},
+			{RoleID: 4, Action: "datasources:query", Scope: "datasources:uid:abc"},
+		},
+	}
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	report, err := ac.reportUserPermissions(context.Background(), user)
+	require.NoError(t, err)
+
+	byPermission := map[string][]permissionReportSource{}
+	for i, entry := range report {
+		if i > 0 {
+			previous := report[i-1]
+			assert.True(t, previous.Action < entry.Action || (previous.Action == entry.Action && previous.Scope < entry.Scope),
+				"report should be sorted and deduplicated")
+		}
+		byPermission[entry.Action+" "+entry.Scope] = entry.Sources
+	}
+
+	assert.ElementsMatch(t, []permissionReportSource{
+		{RoleType: "fixed", RoleName: "fixed:teams.roles:reader", RoleUID: accesscontrol.FixedRoleUID("fixed:teams.roles:reader"), BuiltInRole: "Admin"},
+		{RoleType: "fixed", RoleName: "fixed:teams.roles:writer", RoleUID: accesscontrol.FixedRoleUID("fixed:teams.roles:writer"), BuiltInRole: "Admin"},
+		{RoleType: "fixed", RoleName: registration.Role.Name, RoleUID: accesscontrol.FixedRoleUID(registration.Role.Name), TeamID: 5},
+	}, byPermission[accesscontrol.ActionTeamsRolesList+" "+accesscontrol.ScopeTeamsAll])
+	assert.Equal(t, []permissionReportSource{
+		{RoleType: "managed", RoleName: "managed:users:2:permissions", RoleUID: "user"},
+		{RoleType: "managed", RoleName: "managed:teams:5:permissions", RoleUID: "team", TeamID: 5},
+	}, byPermission["dashboards:read dashboards:uid:abc"])
+	assert.Equal(t, []permissionReportSource{
+		{RoleType: "managed", RoleName: "managed:builtins:viewer:permissions", RoleUID: "viewer", BuiltInRole: "Viewer"},
+	}, byPermission["dashboards:write dashboards:uid:abc"])
+	assert.Equal(t, []permissionReportSource{
+		{RoleType: "custom", RoleName: "custom:reader", RoleUID: "custom"},
+	}, byPermission["datasources:query datasources:uid:abc"])
+}
+
+func TestPermissionReportCSV(t *testing.T) {
+	expiresAt := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	body, err := permissionReportCSV([]permissionReportEntry{
+		{Action: "dashboards:read", Scope: "dashboards:uid:abc", Sources: []permissionReportSource{
+			{RoleType: "managed", RoleName: "managed:teams:5:permissions", RoleUID: "team", TeamID: 5},
+			{RoleType: "fixed", RoleName: "fixed:dashboards:reader", RoleUID: "reader", BuiltInRole: "Viewer"},
+			{RoleType: "fixed", RoleName: "fixed:dashboards:writer", RoleUID: "writer", ExpiresAt: &expiresAt},
+		}},
+		{Action: "dashboards:read", Scope: "dashboards:uid:abc", Deny: true, Sources: []permissionReportSource{
+			{RoleType: "managed", RoleName: "managed:builtins:editor:permissions", RoleUID: "editor", BuiltInRole: "Editor"},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `action,scope,role_type,role_name,role_uid,built_in_role,team_id,effect,expires_at
+dashboards:read,dashboards:uid:abc,managed,managed:teams:5:permissions,team,,5,allow,
+dashboards:read,dashboards:uid:abc,fixed,fixed:dashboards:reader,reader,Viewer,,allow,
+dashboards:read,dashboards:uid:abc,fixed,fixed:dashboards:writer,writer,,,allow,2021-11-01T10:00:00Z
+dashboards:read,dashboards:uid:abc,managed,managed:builtins:editor:permissions,editor,Editor,,deny,
+`, string(body))
+}
+
+func TestOSSAccessControlService_GetUserResourcesMetadata(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "fixed:test:team",
+			Permissions: []accesscontrol.Permission{
+				{Action: "test:read", Scope: "test:id:1"},
+				{Action: "test:write", Scope: "test:*"},
+				{Action: "other:read", Scope: "other:*"},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	store := &fakeTeamRoleStore{userTeamRoles: []*accesscontrol.Role{{Name: registration.Role.Name}}}
+	ac.store = store
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	metadata, err := ac.GetUserResourcesMetadata(context.Background(), user, "test", map[string]bool{"1": true, "2": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]accesscontrol.Metadata{
+		"1": {"test:read": true, "test:write": true},
+		"2": {"test:write": true},
+	}, metadata)
+	assert.Equal(t, 1, store.byNameCalls)
+
+	t.Run("should use cached permissions", func(t *testing.T) {
+		_, err := ac.GetUserResourcesMetadata(context.Background(), user, "test", map[string]bool{"3": true})
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.byNameCalls)
+	})
+
+	t.Run("should reload permissions after they changed", func(t *testing.T) {
+		ac.invalidatePermissions()
+		store.userTeamRoles = nil
+
+		metadata, err := ac.GetUserResourcesMetadata(context.Background(), user, "test", map[string]bool{"1": true})
+		require.NoError(t, err)
+		assert.Empty(t, metadata)
+		assert.Equal(t, 2, store.byNameCalls)
+	})
+
+	t.Run("should only load the managed permissions on the resource type", func(t *testing.T) {
+		provider := &fakePermissionsProvider{permissions: []*accesscontrol.Permission{{Action: "test:delete", Scope: "test:id:1"}}}
+		ac.provider = provider
+		ac.invalidatePermissions()
+
+		metadata, err := ac.GetUserResourcesMetadata(context.Background(), user, "test", map[string]bool{"1": true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]accesscontrol.Metadata{"1": {"test:delete": true}}, metadata)
+		assert.Equal(t, "test", provider.query.Resource)
+	})
+}
+
+func TestOSSAccessControlService_PermissionsCache(t *testing.T) {
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	registration := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:test:cache",
+			Permissions: []accesscontrol.Permission{{Action: "test:read", Scope: "test:*"}},
+		},
+	}
+	t.Cleanup(func() {
+		removeRoleHelper(registration.Role.Name)
+	})
+
+	ac := setupTestEnv(t)
+	store := &fakeTeamRoleStore{userTeamRoles: []*accesscontrol.Role{{Name: registration.Role.Name}}}
+	ac.store = store
+	require.NoError(t, ac.DeclareFixedRoles(registration))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	b := bus.New()
+	ac.registerInvalidationListeners(b)
+
+	hasPermission := func() bool {
+		permissions, err := ac.GetUserPermissions(context.Background(), user)
+		require.NoError(t, err)
+		for _, p := range permissions {
+			if p.Action == "test:read" {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, hasPermission())
+	assert.True(t, hasPermission())
+	assert.Equal(t, 1, store.calls)
+
+	t.Run("should not share the cached permissions between roles", func(t *testing.T) {
+		editor := *user
+		editor.OrgRole = models.ROLE_EDITOR
+		_, err := ac.GetUserPermissions(context.Background(), &editor)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.calls)
+	})
+
+	for _, event := range []interface{}{
+		&events.AccessControlRolesChanged{OrgID: 1},
+		&events.TeamMembershipChanged{OrgID: 1, TeamID: 1, UserID: 2},
+		&events.OrgUserRoleChanged{OrgID: 1, UserID: 2, Role: string(models.ROLE_VIEWER)},
+	} {
+		t.Run(fmt.Sprintf("should reload permissions on %T", event), func(t *testing.T) {
+			hasPermission()
+			calls := store.calls
+
+			require.NoError(t, b.Publish(context.Background(), event))
+			hasPermission()
+			assert.Equal(t, calls+1, store.calls)
+		})
+	}
+
+	t.Run("should drop the permissions of unassigned roles", func(t *testing.T) {
+		store.userTeamRoles = nil
+		require.NoError(t, b.Publish(context.Background(), &events.TeamMembershipChanged{OrgID: 1, TeamID: 1, UserID: 2}))
+		assert.False(t, hasPermission())
+	})
+}
+
+func TestOSSAccessControlService_RegisterFixedRolesWithIncludes(t *testing.T) {
+	registrations := []accesscontrol.RoleRegistration{
+		{
+			Role: accesscontrol.RoleDTO{
+				Version:     1,
+				Name:        "fixed:test:editor_plus_annotations",
+				Permissions: []accesscontrol.Permission{{Action: "test:write", Scope: "test:*"}},
+				Includes:    []string{"fixed:test:annotations", "fixed:test:reader"},
+			},
+			Grants: []string{"Editor"},
+		},
+		{
+			Role: accesscontrol.RoleDTO{
+				Version:     1,
+				Name:        "fixed:test:annotations",
+				Permissions: []accesscontrol.Permission{{Action: "test.annotations:write", Scope: "test:*"}},
+				Includes:    []string{"fixed:test:reader"},
+			},
+		},
+		{
+			Role: accesscontrol.RoleDTO{
+				Version:     1,
+				Name:        "fixed:test:reader",
+				Permissions: []accesscontrol.Permission{{Action: "test:read", Scope: "test:*"}},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		for _, registration := range registrations {
+			removeRoleHelper(registration.Role.Name)
+		}
+	})
+
+	ac := setupTestEnv(t)
+	require.NoError(t, ac.DeclareFixedRoles(registrations...))
+	require.NoError(t, ac.RegisterFixedRoles())
+
+	assert.ElementsMatch(t, []accesscontrol.Permission{
+		{Action: "test:write", Scope: "test:*"},
+		{Action: "test.annotations:write", Scope: "test:*"},
+		{Action: "test:read", Scope: "test:*"},
+	}, accesscontrol.FixedRoles["fixed:test:editor_plus_annotations"].Permissions)
+
+	permissions, err := ac.GetUserPermissions(context.Background(), &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_EDITOR})
+	require.NoError(t, err)
+	assert.Contains(t, permissions, &accesscontrol.Permission{Action: "test.annotations:write", Scope: "test:*"})
+}
+
+func TestOSSAccessControlService_GetUserPermissionsWithScopedAPIKey(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+		query.Result = []*models.ApiKeyPermission{}
+		if query.OrgId == 1 && len(query.ApiKeyIds) == 1 && query.ApiKeyIds[0] == 10 {
+			query.Result = append(query.Result,
+				&models.ApiKeyPermission{OrgId: 1, ApiKeyId: 10, Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:id:1"},
+				&models.ApiKeyPermission{OrgId: 1, ApiKeyId: 10, Action: "dashboards:read", Scope: "dashboards:*"},
+			)
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByIdQuery) error {
+		query.Result = &models.ApiKey{Id: query.ApiKeyId, OrgId: 1}
+		return nil
+	})
+	ac := setupTestEnv(t)
+
+	scoped := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN, ApiKeyId: 10}
+	permissions, err := ac.GetUserPermissions(context.Background(), scoped)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*accesscontrol.Permission{
+		{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:id:1"},
+		{Action: "dashboards:read", Scope: "dashboards:*"},
+	}, permissions, "the permissions of the role of a scoped key should not be granted")
+
+	allowed, err := ac.Evaluate(context.Background(), scoped, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, "datasources:id:2"))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	metadata, err := ac.GetUserResourcesMetadata(context.Background(), scoped, "datasources", map[string]bool{"1": true, "2": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]accesscontrol.Metadata{"1": {accesscontrol.ActionDatasourcesQuery: true}}, metadata)
+
+	unscoped := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN, ApiKeyId: 11}
+	permissions, err = ac.GetUserPermissions(context.Background(), unscoped)
+	require.NoError(t, err)
+	assert.Greater(t, len(permissions), 2, "keys without permissions should be granted the permissions of their role")
+}
+
+func TestOSSAccessControlService_GetUserPermissionsWithUserAccessToken(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+		query.Result = []*models.ApiKeyPermission{
+			{OrgId: 1, ApiKeyId: 20, Action: accesscontrol.ActionDashboardsRead, Scope: "dashboards:uid:abc"},
+			{OrgId: 1, ApiKeyId: 20, Action: accesscontrol.ActionOrgUsersRead, Scope: accesscontrol.ScopeUsersAll},
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByIdQuery) error {
+		query.Result = &models.ApiKey{Id: query.ApiKeyId, OrgId: 1, UserId: 2}
+		return nil
+	})
+	ownerRole := models.ROLE_VIEWER
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+		query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: ownerRole}
+		return nil
+	})
+	ac := setupTestEnv(t)
+
+	// the requests of the scoped tokens are made with the Viewer role
+	token := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER, ApiKeyId: 20}
+
+	// the permissions of the token were granted to its owner when it was an admin
+	permissions, err := ac.GetUserPermissions(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, []*accesscontrol.Permission{
+		{Action: accesscontrol.ActionDashboardsRead, Scope: "dashboards:uid:abc"},
+	}, permissions, "the permissions the owner of the token lost should not be granted")
+
+	// the change of the role of the owner invalidates the cached permissions
+	ownerRole = models.ROLE_ADMIN
+	ac.invalidatePermissions()
+	permissions, err = ac.GetUserPermissions(context.Background(), token)
+	require.NoError(t, err)
+	assert.Len(t, permissions, 2, "the permissions of the owner should apply, not the ones of the role of the request")
+}
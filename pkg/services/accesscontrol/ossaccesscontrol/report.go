@@ -0,0 +1,184 @@
+package ossaccesscontrol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	// reportRoleTypeFixed is the type of the fixed roles declared by Grafana
+	reportRoleTypeFixed = "fixed"
+	// reportRoleTypeManaged is the type of the roles holding the permissions set on individual resources
+	reportRoleTypeManaged = "managed"
+	// reportRoleTypeCustom is the type of the other roles stored in the database
+	reportRoleTypeCustom = "custom"
+)
+
+// permissionReportSource is a role the permission comes from, and how the role is granted to the user:
+// through a built-in role, through a team or by being assigned to the user directly when both are empty
+type permissionReportSource struct {
+	RoleType    string `json:"roleType"`
+	RoleName    string `json:"roleName"`
+	RoleUID     string `json:"roleUid"`
+	BuiltInRole string `json:"builtInRole,omitempty"`
+	TeamID      int64  `json:"teamId,omitempty"`
+	// ExpiresAt is when the role assigned directly to the user is revoked
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type permissionReportEntry struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	// Deny tells the action is excluded on the scope, overriding the entries granting it
+	Deny    bool                     `json:"deny,omitempty"`
+	Sources []permissionReportSource `json:"sources"`
+}
+
+type permissionReport struct {
+	entries map[string]*permissionReportEntry
+}
+
+func (r *permissionReport) add(permission *accesscontrol.Permission, source permissionReportSource) {
+	key := fmt.Sprintf("%s\x00%s\x00%t", permission.Action, permission.Scope, permission.Deny)
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &permissionReportEntry{Action: permission.Action, Scope: permission.Scope, Deny: permission.Deny}
+		r.entries[key] = entry
+	}
+	for _, s := range entry.Sources {
+		if s == source {
+			return
+		}
+	}
+	entry.Sources = append(entry.Sources, source)
+}
+
+// list returns the entries of the report sorted by action and scope
+func (r *permissionReport) list() []permissionReportEntry {
+	result := make([]permissionReportEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Action != result[j].Action {
+			return result[i].Action < result[j].Action
+		}
+		if result[i].Scope != result[j].Scope {
+			return result[i].Scope < result[j].Scope
+		}
+		return !result[i].Deny && result[j].Deny
+	})
+	return result
+}
+
+// reportUserPermissions returns all the permissions of the user, deduplicated, with the roles and assignments
+// each of them comes from. It covers the same permissions as GetUserPermissions.
+func (ac *OSSAccessControlService) reportUserPermissions(ctx context.Context, user *models.SignedInUser) ([]permissionReportEntry, error) {
+	report := &permissionReport{entries: map[string]*permissionReportEntry{}}
+	addFixedRole := func(roleName string, source permissionReportSource) error {
+		role, exists := accesscontrol.FixedRoles[roleName]
+		if !exists {
+			return nil
+		}
+		source.RoleType = reportRoleTypeFixed
+		source.RoleName = role.Name
+		source.RoleUID = accesscontrol.FixedRoleUID(role.Name)
+		for _, p := range role.Permissions {
+			permission, err := ac.scopeResolver.ResolveKeyword(user, p)
+			if err != nil {
+				return err
+			}
+			report.add(permission, source)
+		}
+		return nil
+	}
+
+	builtInRoles := ac.GetUserBuiltInRoles(user)
+	for _, builtin := range builtInRoles {
+		for _, name := range accesscontrol.FixedRoleGrants[builtin] {
+			if err := addFixedRole(name, permissionReportSource{BuiltInRole: builtin}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	userRoles, err := ac.getUserRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range userRoles {
+		if err := addFixedRole(role.Name, permissionReportSource{ExpiresAt: role.ExpiresAt}); err != nil {
+			return nil, err
+		}
+	}
+
+	if ac.store != nil && user.UserId != 0 {
+		for _, teamID := range user.Teams {
+			roles, err := ac.store.GetTeamRoles(ctx, user.OrgId, teamID)
+			if err != nil {
+				return nil, err
+			}
+			for _, role := range roles {
+				if err := addFixedRole(role.Name, permissionReportSource{TeamID: teamID}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if ac.provider != nil {
+		query := accesscontrol.GetUserPermissionsQuery{OrgID: user.OrgId, UserID: user.UserId, Roles: builtInRoles}
+		roles, err := ac.provider.GetUserAssignedRoles(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		sources := make(map[int64]permissionReportSource, len(roles))
+		for _, role := range roles {
+			sources[role.ID] = storedRoleSource(role, builtInRoles)
+		}
+
+		permissions, err := ac.provider.GetUserPermissions(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range permissions {
+			source, ok := sources[p.RoleID]
+			if !ok {
+				// The role has been assigned between the two queries
+				source = permissionReportSource{RoleType: reportRoleTypeCustom}
+			}
+			report.add(p, source)
+		}
+	}
+
+	return report.list(), nil
+}
+
+// storedRoleSource returns the source of the permissions of a role stored in the database. The assignment of the
+// managed roles is told from their name, the assignment of the other roles is not reported.
+func storedRoleSource(role *accesscontrol.Role, builtInRoles []string) permissionReportSource {
+	source := permissionReportSource{RoleType: reportRoleTypeCustom, RoleName: role.Name, RoleUID: role.UID}
+	if !strings.HasPrefix(role.Name, "managed:") {
+		return source
+	}
+
+	source.RoleType = reportRoleTypeManaged
+	var teamID int64
+	if _, err := fmt.Sscanf(role.Name, "managed:teams:%d:permissions", &teamID); err == nil {
+		source.TeamID = teamID
+		return source
+	}
+	for _, builtin := range builtInRoles {
+		if role.Name == accesscontrol.ManagedBuiltInRoleName(builtin) {
+			source.BuiltInRole = builtin
+			break
+		}
+	}
+	return source
+}
@@ -0,0 +1,101 @@
+package permissionexport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// signatureHeader holds the signature of the exports downloaded through the API
+const signatureHeader = "X-Grafana-Signature"
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/admin/permissions/export", func(exportRoute routing.RouteRegister) {
+		exportRoute.Get("/", middleware.ReqGrafanaAdmin, routing.Wrap(s.downloadExportHandler))
+		exportRoute.Post("/", middleware.ReqGrafanaAdmin, routing.Wrap(s.storeExportHandler))
+	})
+	routeRegister.Group("/api/admin/permissions/policy", func(policyRoute routing.RouteRegister) {
+		policyRoute.Post("/validate", middleware.ReqGrafanaAdmin, routing.Wrap(s.validatePolicyHandler))
+		policyRoute.Post("/import", middleware.ReqGrafanaAdmin, routing.Wrap(s.importPolicyHandler))
+	})
+}
+
+// GET /api/admin/permissions/export
+func (s *Service) downloadExportHandler(c *models.ReqContext) response.Response {
+	artifact, err := s.Export(c.Req.Context(), s.format(c))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return response.CreateNormalResponse(http.Header{
+		"Content-Type":        []string{artifact.ContentType},
+		"Content-Disposition": []string{fmt.Sprintf(`attachment;filename="%s"`, artifact.Name)},
+		signatureHeader:       []string{"sha256=" + artifact.Signature},
+	}, artifact.Data, http.StatusOK)
+}
+
+// POST /api/admin/permissions/export
+func (s *Service) storeExportHandler(c *models.ReqContext) response.Response {
+	stored, err := s.ExportToStorage(c.Req.Context(), s.format(c))
+	if err != nil {
+		return errorResponse(err)
+	}
+	return response.JSON(http.StatusOK, stored)
+}
+
+// POST /api/admin/permissions/policy/validate
+func (s *Service) validatePolicyHandler(c *models.ReqContext) response.Response {
+	policy, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to read the policy", err)
+	}
+	report, err := s.ValidatePolicy(c.Req.Context(), c.Query("format"), policy)
+	if err != nil {
+		return policyErrorResponse(err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// POST /api/admin/permissions/policy/import
+func (s *Service) importPolicyHandler(c *models.ReqContext) response.Response {
+	policy, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to read the policy", err)
+	}
+	result, err := s.ImportPolicy(c.Req.Context(), c.Query("format"), policy, c.QueryBool("dryRun"))
+	if errors.Is(err, ErrInvalidPolicy) {
+		return response.JSON(http.StatusBadRequest, result)
+	}
+	if err != nil {
+		return policyErrorResponse(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// format returns the format requested through the format query parameter, or the configured format
+func (s *Service) format(c *models.ReqContext) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	return s.cfg.PermissionExport.Format
+}
+
+func errorResponse(err error) response.Response {
+	if errors.Is(err, ErrInvalidFormat) {
+		return response.Error(http.StatusBadRequest, "Invalid format, expected json, csv, rego or cedar", err)
+	}
+	return response.Error(http.StatusInternalServerError, "Failed to export permissions", err)
+}
+
+func policyErrorResponse(err error) response.Response {
+	if errors.Is(err, ErrInvalidFormat) {
+		return response.Error(http.StatusBadRequest, "Invalid format, expected rego or cedar", err)
+	}
+	return response.Error(http.StatusInternalServerError, "Failed to process policy", err)
+}
@@ -0,0 +1,326 @@
+package permissionexport
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Export is the complete permission model of the Grafana instance at the time it was generated.
+type Export struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// FixedRoles are the roles declared by Grafana and the built-in roles they are granted to in all organizations
+	FixedRoles []FixedRole `json:"fixedRoles"`
+	Orgs       []OrgExport `json:"orgs"`
+}
+
+type Permission struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	Deny   bool   `json:"deny,omitempty"`
+}
+
+type FixedRole struct {
+	Name         string       `json:"name"`
+	UID          string       `json:"uid"`
+	BuiltInRoles []string     `json:"builtInRoles"`
+	Permissions  []Permission `json:"permissions"`
+}
+
+type OrgExport struct {
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	Users         []User         `json:"users"`
+	Teams         []Team         `json:"teams"`
+	Roles         []Role         `json:"roles"`
+	DashboardACLs []DashboardACL `json:"dashboardAcls"`
+}
+
+type User struct {
+	ID             int64  `json:"id"`
+	Login          string `json:"login"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	IsGrafanaAdmin bool   `json:"isGrafanaAdmin"`
+}
+
+type Team struct {
+	ID      int64   `json:"id"`
+	Name    string  `json:"name"`
+	Members []int64 `json:"members"`
+}
+
+// Role is a role stored in the database, along with the users, teams and built-in roles it is assigned to in the
+// organization.
+type Role struct {
+	UID          string       `json:"uid"`
+	Name         string       `json:"name"`
+	Global       bool         `json:"global"`
+	Users        []int64      `json:"users"`
+	Teams        []int64      `json:"teams"`
+	BuiltInRoles []string     `json:"builtInRoles"`
+	Permissions  []Permission `json:"permissions"`
+}
+
+// DashboardACL is a permission set on a dashboard or folder, for a user, a team or a built-in role.
+type DashboardACL struct {
+	DashboardUID string `json:"dashboardUid"`
+	Title        string `json:"title"`
+	IsFolder     bool   `json:"isFolder"`
+	UserID       int64  `json:"userId,omitempty"`
+	TeamID       int64  `json:"teamId,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Permission   string `json:"permission"`
+}
+
+type orgRow struct {
+	ID   int64 `xorm:"id"`
+	Name string
+}
+
+type userRow struct {
+	OrgID   int64 `xorm:"org_id"`
+	ID      int64 `xorm:"id"`
+	Login   string
+	Email   string
+	IsAdmin bool
+	Role    string
+}
+
+type teamRow struct {
+	ID    int64 `xorm:"id"`
+	OrgID int64 `xorm:"org_id"`
+	Name  string
+}
+
+type teamMemberRow struct {
+	TeamID int64 `xorm:"team_id"`
+	UserID int64 `xorm:"user_id"`
+}
+
+type roleRow struct {
+	ID    int64  `xorm:"id"`
+	OrgID int64  `xorm:"org_id"`
+	UID   string `xorm:"uid"`
+	Name  string
+}
+
+type permissionRow struct {
+	RoleID int64 `xorm:"role_id"`
+	Action string
+	Scope  string
+	Deny   bool
+}
+
+type assignmentRow struct {
+	OrgID       int64  `xorm:"org_id"`
+	RoleID      int64  `xorm:"role_id"`
+	UserID      int64  `xorm:"user_id"`
+	TeamID      int64  `xorm:"team_id"`
+	BuiltInRole string `xorm:"builtin_role"`
+}
+
+type aclRow struct {
+	OrgID      int64  `xorm:"org_id"`
+	UID        string `xorm:"uid"`
+	Title      string
+	IsFolder   bool
+	UserID     int64 `xorm:"user_id"`
+	TeamID     int64 `xorm:"team_id"`
+	Role       string
+	Permission models.PermissionType
+}
+
+// collect reads the permission model of all the organizations in a single pass over the tables holding it.
+func collect(ctx context.Context, sqlStore *sqlstore.SQLStore, now time.Time) (*Export, error) {
+	var (
+		orgs        []orgRow
+		users       []userRow
+		teams       []teamRow
+		members     []teamMemberRow
+		roles       []roleRow
+		permissions []permissionRow
+		assignments []assignmentRow
+		acls        []aclRow
+	)
+
+	err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		queries := []struct {
+			sql    string
+			result interface{}
+		}{
+			{`SELECT id, name FROM org ORDER BY id`, &orgs},
+			{`SELECT org_user.org_id, u.id, u.login, u.email, u.is_admin, org_user.role FROM org_user
+				INNER JOIN ` + sqlStore.Dialect.Quote("user") + ` AS u ON u.id = org_user.user_id
+				ORDER BY org_user.org_id, u.id`, &users},
+			{`SELECT id, org_id, name FROM team ORDER BY org_id, id`, &teams},
+			{`SELECT team_id, user_id FROM team_member ORDER BY team_id, user_id`, &members},
+			{`SELECT id, org_id, uid, name FROM role ORDER BY name`, &roles},
+			{`SELECT role_id, action, scope, deny FROM permission ORDER BY role_id, action, scope`, &permissions},
+			{`SELECT org_id, role_id, user_id, 0 AS team_id, '' AS builtin_role FROM user_role
+				UNION ALL SELECT org_id, role_id, 0 AS user_id, team_id, '' AS builtin_role FROM team_role
+				UNION ALL SELECT org_id, role_id, 0 AS user_id, 0 AS team_id, role AS builtin_role FROM builtin_role`, &assignments},
+			{`SELECT dashboard_acl.org_id, dashboard.uid, dashboard.title, dashboard.is_folder,
+				dashboard_acl.user_id, dashboard_acl.team_id, dashboard_acl.role, dashboard_acl.permission
+				FROM dashboard_acl
+				INNER JOIN dashboard ON dashboard.id = dashboard_acl.dashboard_id
+				ORDER BY dashboard_acl.org_id, dashboard.uid, dashboard_acl.id`, &acls},
+		}
+		for _, q := range queries {
+			if err := sess.SQL(q.sql).Find(q.result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{GeneratedAt: now, FixedRoles: fixedRoles(), Orgs: make([]OrgExport, 0, len(orgs))}
+	for _, o := range orgs {
+		export.Orgs = append(export.Orgs, OrgExport{
+			ID:            o.ID,
+			Name:          o.Name,
+			Users:         []User{},
+			Teams:         []Team{},
+			Roles:         []Role{},
+			DashboardACLs: []DashboardACL{},
+		})
+	}
+	byOrg := make(map[int64]*OrgExport, len(orgs))
+	for i := range export.Orgs {
+		byOrg[export.Orgs[i].ID] = &export.Orgs[i]
+	}
+
+	for _, u := range users {
+		if org, ok := byOrg[u.OrgID]; ok {
+			org.Users = append(org.Users, User{ID: u.ID, Login: u.Login, Email: u.Email, Role: u.Role, IsGrafanaAdmin: u.IsAdmin})
+		}
+	}
+
+	teamMembers := make(map[int64][]int64)
+	for _, m := range members {
+		teamMembers[m.TeamID] = append(teamMembers[m.TeamID], m.UserID)
+	}
+	for _, t := range teams {
+		if org, ok := byOrg[t.OrgID]; ok {
+			m := teamMembers[t.ID]
+			if m == nil {
+				m = []int64{}
+			}
+			org.Teams = append(org.Teams, Team{ID: t.ID, Name: t.Name, Members: m})
+		}
+	}
+
+	rolePermissions := make(map[int64][]Permission)
+	for _, p := range permissions {
+		rolePermissions[p.RoleID] = append(rolePermissions[p.RoleID], Permission{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+	}
+	roleAssignments := make(map[int64][]assignmentRow)
+	for _, a := range assignments {
+		roleAssignments[a.RoleID] = append(roleAssignments[a.RoleID], a)
+	}
+	for i := range export.Orgs {
+		org := &export.Orgs[i]
+		for _, r := range roles {
+			if role, ok := orgRole(org.ID, r, roleAssignments[r.ID], rolePermissions[r.ID]); ok {
+				org.Roles = append(org.Roles, role)
+			}
+		}
+	}
+
+	for _, a := range acls {
+		if org, ok := byOrg[a.OrgID]; ok {
+			org.DashboardACLs = append(org.DashboardACLs, DashboardACL{
+				DashboardUID: a.UID,
+				Title:        a.Title,
+				IsFolder:     a.IsFolder,
+				UserID:       a.UserID,
+				TeamID:       a.TeamID,
+				Role:         a.Role,
+				Permission:   a.Permission.String(),
+			})
+		}
+	}
+
+	return export, nil
+}
+
+// orgRole returns the role with its assignments in the organization. Roles of other organizations are skipped, as well
+// as the global roles not assigned in the organization.
+func orgRole(orgID int64, r roleRow, assignments []assignmentRow, permissions []Permission) (Role, bool) {
+	role := Role{
+		UID:          r.UID,
+		Name:         r.Name,
+		Global:       r.OrgID == accesscontrol.GlobalOrgID,
+		Users:        []int64{},
+		Teams:        []int64{},
+		BuiltInRoles: []string{},
+		Permissions:  permissions,
+	}
+	for _, a := range assignments {
+		// Global assignments apply to all the organizations
+		if a.OrgID != orgID && a.OrgID != accesscontrol.GlobalOrgID {
+			continue
+		}
+		switch {
+		case a.UserID != 0:
+			role.Users = append(role.Users, a.UserID)
+		case a.TeamID != 0:
+			role.Teams = append(role.Teams, a.TeamID)
+		case a.BuiltInRole != "":
+			role.BuiltInRoles = append(role.BuiltInRoles, a.BuiltInRole)
+		}
+	}
+	assigned := len(role.Users)+len(role.Teams)+len(role.BuiltInRoles) > 0
+	if r.OrgID != orgID && !(role.Global && assigned) {
+		return Role{}, false
+	}
+
+	sort.Slice(role.Users, func(i, j int) bool { return role.Users[i] < role.Users[j] })
+	sort.Slice(role.Teams, func(i, j int) bool { return role.Teams[i] < role.Teams[j] })
+	sort.Strings(role.BuiltInRoles)
+	if role.Permissions == nil {
+		role.Permissions = []Permission{}
+	}
+	return role, true
+}
+
+// fixedRoles returns the fixed roles with their built-in role grants, sorted by name
+func fixedRoles() []FixedRole {
+	grants := make(map[string][]string)
+	for builtInRole, names := range accesscontrol.FixedRoleGrants {
+		for _, name := range names {
+			grants[name] = append(grants[name], builtInRole)
+		}
+	}
+
+	result := make([]FixedRole, 0, len(accesscontrol.FixedRoles))
+	for name, role := range accesscontrol.FixedRoles {
+		builtInRoles := grants[name]
+		if builtInRoles == nil {
+			builtInRoles = []string{}
+		}
+		sort.Strings(builtInRoles)
+
+		permissions := make([]Permission, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			permissions = append(permissions, Permission{Action: p.Action, Scope: p.Scope, Deny: p.Deny})
+		}
+		sort.Slice(permissions, func(i, j int) bool {
+			if permissions[i].Action != permissions[j].Action {
+				return permissions[i].Action < permissions[j].Action
+			}
+			return permissions[i].Scope < permissions[j].Scope
+		})
+
+		result = append(result, FixedRole{Name: name, UID: accesscontrol.FixedRoleUID(name), BuiltInRoles: builtInRoles, Permissions: permissions})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,151 @@
+package permissionexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTestService(t *testing.T) (*Service, int64, int64) {
+	t.Helper()
+	ctx := context.Background()
+	sql := sqlstore.InitTestDB(t)
+
+	user, err := sql.CreateUser(ctx, models.CreateUserCommand{Login: "user", Email: "user@example.org", OrgId: 1})
+	require.NoError(t, err)
+	team, err := sql.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+	require.NoError(t, sql.AddTeamMember(user.Id, 1, team.Id, false, 0))
+
+	dash, err := sql.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Dashboard", "uid": "dash"}),
+	})
+	require.NoError(t, err)
+	require.NoError(t, sql.UpdateDashboardACLCtx(ctx, dash.Id, []*models.DashboardAcl{
+		{OrgID: 1, DashboardID: dash.Id, TeamID: team.Id, Permission: models.PERMISSION_EDIT, Created: time.Now(), Updated: time.Now()},
+	}))
+
+	store := database.ProvideService(sql)
+	_, err = store.SetUserResourcePermission(ctx, 1, user.Id, accesscontrol.SetResourcePermissionCommand{
+		Actions:           []string{"dashboards:read"},
+		Resource:          "dashboards",
+		ResourceID:        "dash",
+		ResourceAttribute: "uid",
+	})
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.PermissionExport = setting.PermissionExportSettings{
+		Format:     setting.PermissionExportFormatJSON,
+		Path:       t.TempDir(),
+		SigningKey: "secret",
+	}
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sql,
+		store:    store,
+		storage:  newArtifactStorage(cfg.PermissionExport),
+		log:      log.New("permissionexport"),
+		now:      func() time.Time { return time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC) },
+	}
+	return s, user.Id, team.Id
+}
+
+func TestCollect(t *testing.T) {
+	s, userID, teamID := setupTestService(t)
+
+	export, err := collect(context.Background(), s.sqlStore, s.now())
+	require.NoError(t, err)
+	require.Len(t, export.Orgs, 1)
+	org := export.Orgs[0]
+
+	var user *User
+	for i := range org.Users {
+		if org.Users[i].ID == userID {
+			user = &org.Users[i]
+		}
+	}
+	require.NotNil(t, user)
+	assert.Equal(t, "user", user.Login)
+	assert.Equal(t, "user@example.org", user.Email)
+
+	assert.Equal(t, []Team{{ID: teamID, Name: "team", Members: []int64{userID}}}, org.Teams)
+
+	roles := make(map[string]Role, len(org.Roles))
+	for _, r := range org.Roles {
+		roles[r.Name] = r
+	}
+	userRole, ok := roles[accesscontrol.ManagedUserRoleName(userID)]
+	require.True(t, ok)
+	assert.Equal(t, []int64{userID}, userRole.Users)
+	assert.Equal(t, []Permission{{Action: "dashboards:read", Scope: "dashboards:uid:dash"}}, userRole.Permissions)
+	// dashboard permissions are mirrored to the managed role of the team
+	teamRole, ok := roles[accesscontrol.ManagedTeamRoleName(teamID)]
+	require.True(t, ok)
+	assert.Equal(t, []int64{teamID}, teamRole.Teams)
+
+	assert.Equal(t, []DashboardACL{{DashboardUID: "dash", Title: "Dashboard", TeamID: teamID, Permission: "Edit"}}, org.DashboardACLs)
+}
+
+func TestService_Export(t *testing.T) {
+	s, userID, teamID := setupTestService(t)
+
+	t.Run("should sign the export", func(t *testing.T) {
+		artifact, err := s.Export(context.Background(), setting.PermissionExportFormatJSON)
+		require.NoError(t, err)
+		assert.Equal(t, "permissions-20211101T100000Z.json", artifact.Name)
+		assert.Equal(t, "application/json", artifact.ContentType)
+		assert.Equal(t, sign(artifact.Data, "secret"), artifact.Signature)
+		assert.NotEqual(t, sign(artifact.Data, "other"), artifact.Signature)
+	})
+
+	t.Run("should flatten the export to csv", func(t *testing.T) {
+		artifact, err := s.Export(context.Background(), setting.PermissionExportFormatCSV)
+		require.NoError(t, err)
+		assert.Equal(t, "text/csv", artifact.ContentType)
+
+		rows, err := csv.NewReader(bytes.NewReader(artifact.Data)).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, csvHeader, rows[0])
+
+		user, team := strconv.FormatInt(userID, 10), strconv.FormatInt(teamID, 10)
+		assert.Contains(t, rows, []string{"1", "user", user, "user", "team_membership", "team", "", "teams:id:" + team, "allow"})
+		assert.Contains(t, rows, []string{"1", "user", user, "user", "role", accesscontrol.ManagedUserRoleName(userID), "dashboards:read", "dashboards:uid:dash", "allow"})
+		assert.Contains(t, rows, []string{"1", "team", team, "team", "dashboard_acl", "Edit", "", "dashboards:uid:dash", "allow"})
+	})
+
+	t.Run("should fail on unknown formats", func(t *testing.T) {
+		_, err := s.Export(context.Background(), "xml")
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	})
+
+	t.Run("should save the export and its signature", func(t *testing.T) {
+		stored, err := s.ExportToStorage(context.Background(), setting.PermissionExportFormatJSON)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(s.cfg.PermissionExport.Path, "permissions-20211101T100000Z.json"), stored.Artifact)
+		assert.Equal(t, stored.Artifact+".sig", stored.Signature)
+
+		data, err := ioutil.ReadFile(stored.Artifact)
+		require.NoError(t, err)
+		signature, err := ioutil.ReadFile(stored.Signature)
+		require.NoError(t, err)
+		assert.Equal(t, sign(data, "secret")+"\n", string(signature))
+	})
+}
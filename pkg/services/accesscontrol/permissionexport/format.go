@@ -0,0 +1,162 @@
+package permissionexport
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// csvHeader lists the columns of the csv exports, with one row per grant
+var csvHeader = []string{"org_id", "subject_type", "subject_id", "subject_name", "grant_type", "grant", "action", "scope", "effect"}
+
+const (
+	subjectUser        = "user"
+	subjectTeam        = "team"
+	subjectBuiltInRole = "builtin_role"
+
+	grantOrgRole     = "org_role"
+	grantServerAdmin = "server_admin"
+	grantTeam        = "team_membership"
+	grantRole        = "role"
+	grantDashboard   = "dashboard_acl"
+	grantFolder      = "folder_acl"
+
+	effectAllow = "allow"
+	effectDeny  = "deny"
+)
+
+// encode returns the export in the given format along with its content type
+func encode(export *Export, format string) ([]byte, string, error) {
+	switch format {
+	case setting.PermissionExportFormatJSON:
+		b, err := json.MarshalIndent(export, "", "  ")
+		return b, "application/json", err
+	case setting.PermissionExportFormatCSV:
+		b, err := encodeCSV(export)
+		return b, "text/csv", err
+	case setting.PermissionExportFormatRego:
+		b, err := encodeRego(export)
+		return b, "text/plain; charset=utf-8", err
+	case setting.PermissionExportFormatCedar:
+		b, err := encodeCedar(export)
+		return b, "text/plain; charset=utf-8", err
+	}
+	return nil, "", fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+}
+
+// encodeCSV flattens the export into one row per grant: organization roles, team memberships, role permissions and
+// dashboard or folder permissions, for each user, team or built-in role they are granted to.
+func encodeCSV(export *Export) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	write := func(orgID int64, subjectType, subjectID, subjectName, grantType, grant, action, scope string, deny bool) error {
+		effect := effectAllow
+		if deny {
+			effect = effectDeny
+		}
+		return w.Write([]string{strconv.FormatInt(orgID, 10), subjectType, subjectID, subjectName, grantType, grant, action, scope, effect})
+	}
+	id := func(id int64) string {
+		return strconv.FormatInt(id, 10)
+	}
+
+	for _, role := range export.FixedRoles {
+		for _, builtInRole := range role.BuiltInRoles {
+			for _, p := range role.Permissions {
+				if err := write(0, subjectBuiltInRole, builtInRole, builtInRole, grantRole, role.Name, p.Action, p.Scope, p.Deny); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, org := range export.Orgs {
+		users := make(map[int64]string, len(org.Users))
+		for _, u := range org.Users {
+			users[u.ID] = u.Login
+			if err := write(org.ID, subjectUser, id(u.ID), u.Login, grantOrgRole, u.Role, "", "", false); err != nil {
+				return nil, err
+			}
+			if u.IsGrafanaAdmin {
+				if err := write(org.ID, subjectUser, id(u.ID), u.Login, grantServerAdmin, "Grafana Admin", "", "", false); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		teams := make(map[int64]string, len(org.Teams))
+		for _, t := range org.Teams {
+			teams[t.ID] = t.Name
+			for _, m := range t.Members {
+				if err := write(org.ID, subjectUser, id(m), users[m], grantTeam, t.Name, "", "teams:id:"+id(t.ID), false); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, role := range org.Roles {
+			type subject struct{ typ, id, name string }
+			subjects := make([]subject, 0, len(role.Users)+len(role.Teams)+len(role.BuiltInRoles))
+			for _, u := range role.Users {
+				subjects = append(subjects, subject{subjectUser, id(u), users[u]})
+			}
+			for _, t := range role.Teams {
+				subjects = append(subjects, subject{subjectTeam, id(t), teams[t]})
+			}
+			for _, b := range role.BuiltInRoles {
+				subjects = append(subjects, subject{subjectBuiltInRole, b, b})
+			}
+			for _, s := range subjects {
+				for _, p := range role.Permissions {
+					if err := write(org.ID, s.typ, s.id, s.name, grantRole, role.Name, p.Action, p.Scope, p.Deny); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		for _, acl := range org.DashboardACLs {
+			grantType, scope := grantDashboard, "dashboards:uid:"+acl.DashboardUID
+			if acl.IsFolder {
+				grantType, scope = grantFolder, "folders:uid:"+acl.DashboardUID
+			}
+			var err error
+			switch {
+			case acl.UserID != 0:
+				err = write(org.ID, subjectUser, id(acl.UserID), users[acl.UserID], grantType, acl.Permission, "", scope, false)
+			case acl.TeamID != 0:
+				err = write(org.ID, subjectTeam, id(acl.TeamID), teams[acl.TeamID], grantType, acl.Permission, "", scope, false)
+			default:
+				err = write(org.ID, subjectBuiltInRole, acl.Role, acl.Role, grantType, acl.Permission, "", scope, false)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the export, so that auditors holding the signing key can verify the
+// export has not been altered since it was generated.
+func sign(data []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,522 @@
+package permissionexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Grant is a permission a user is effectively granted in an organization, through their organization role, the
+// Grafana Admin flag, their team memberships or the roles assigned to them.
+type Grant struct {
+	OrgID  int64  `json:"orgId"`
+	UserID int64  `json:"userId"`
+	Login  string `json:"login,omitempty"`
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	Deny   bool   `json:"deny,omitempty"`
+	// Roles are the roles the permission is granted by, they are unknown for the grants read from a policy
+	Roles []string `json:"roles,omitempty"`
+}
+
+type grantKey struct {
+	orgID  int64
+	userID int64
+	action string
+	scope  string
+	deny   bool
+}
+
+func (g Grant) key() grantKey {
+	return grantKey{orgID: g.OrgID, userID: g.UserID, action: g.Action, scope: g.Scope, deny: g.Deny}
+}
+
+func (g Grant) effect() string {
+	if g.Deny {
+		return effectDeny
+	}
+	return effectAllow
+}
+
+// effectiveGrants resolves the export into the permissions of each user in each organization. Dashboard and folder
+// permissions are left out, as they are mirrored to the managed roles already.
+func effectiveGrants(export *Export) []Grant {
+	var grants []Grant
+	for _, org := range export.Orgs {
+		userTeams := make(map[int64][]int64)
+		for _, t := range org.Teams {
+			for _, m := range t.Members {
+				userTeams[m] = append(userTeams[m], t.ID)
+			}
+		}
+
+		for _, u := range org.Users {
+			builtInRoles := map[string]bool{u.Role: true}
+			for _, child := range models.RoleType(u.Role).Children() {
+				builtInRoles[string(child)] = true
+			}
+			if u.IsGrafanaAdmin {
+				builtInRoles[accesscontrol.RoleGrafanaAdmin] = true
+			}
+			teams := make(map[int64]bool, len(userTeams[u.ID]))
+			for _, t := range userTeams[u.ID] {
+				teams[t] = true
+			}
+
+			byKey := make(map[grantKey]*Grant)
+			add := func(role string, permissions []Permission) {
+				for _, p := range permissions {
+					g := Grant{OrgID: org.ID, UserID: u.ID, Login: u.Login, Action: p.Action, Scope: p.Scope, Deny: p.Deny}
+					if existing, ok := byKey[g.key()]; ok {
+						existing.Roles = append(existing.Roles, role)
+						continue
+					}
+					g.Roles = []string{role}
+					byKey[g.key()] = &g
+				}
+			}
+			for _, r := range export.FixedRoles {
+				if containsAny(r.BuiltInRoles, builtInRoles) {
+					add(r.Name, r.Permissions)
+				}
+			}
+			for _, r := range org.Roles {
+				if containsID(r.Users, u.ID) || containsAnyID(r.Teams, teams) || containsAny(r.BuiltInRoles, builtInRoles) {
+					add(r.Name, r.Permissions)
+				}
+			}
+
+			for _, g := range byKey {
+				grants = append(grants, *g)
+			}
+		}
+	}
+	sortGrants(grants)
+	return grants
+}
+
+func sortGrants(grants []Grant) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		switch {
+		case a.OrgID != b.OrgID:
+			return a.OrgID < b.OrgID
+		case a.UserID != b.UserID:
+			return a.UserID < b.UserID
+		case a.Action != b.Action:
+			return a.Action < b.Action
+		case a.Scope != b.Scope:
+			return a.Scope < b.Scope
+		}
+		return !a.Deny && b.Deny
+	})
+}
+
+func containsAny(values []string, set map[string]bool) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyID(values []int64, set map[int64]bool) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(values []int64, id int64) bool {
+	for _, v := range values {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// regoGrant is a grant as listed in the grants rule of the Rego policies
+type regoGrant struct {
+	OrgID  int64  `json:"org_id"`
+	UserID int64  `json:"user_id"`
+	Login  string `json:"login"`
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	Effect string `json:"effect"`
+}
+
+// regoGrantsRule starts the rule listing the grants, it is what the Rego policies are read from
+const regoGrantsRule = "grants := "
+
+// regoRules evaluate an input such as {"org_id": 1, "user_id": 2, "action": "dashboards:read", "scope": "dashboards:uid:abc"}
+// against the grants the way Grafana does: denies take precedence, permissions without scope apply to any scope and
+// scopes ending with * apply to any scope sharing their prefix.
+const regoRules = `package grafana.authz
+
+default allow = false
+
+allow {
+	some i
+	matches(grants[i], "allow")
+	not denied
+}
+
+denied {
+	some i
+	matches(grants[i], "deny")
+}
+
+matches(grant, effect) {
+	grant.effect == effect
+	grant.org_id == input.org_id
+	grant.user_id == input.user_id
+	grant.action == input.action
+	scope_matches(grant.scope, input.scope)
+}
+
+scope_matches(scope, _) {
+	scope == ""
+}
+
+scope_matches(scope, requested) {
+	scope == requested
+}
+
+scope_matches(scope, requested) {
+	endswith(scope, "*")
+	startswith(requested, trim_suffix(scope, "*"))
+}
+
+`
+
+// encodeRego writes the effective permissions as an Open Policy Agent policy, in the grafana.authz package
+func encodeRego(export *Export) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Effective permissions of the Grafana users, generated at %s\n", export.GeneratedAt.Format("2006-01-02T15:04:05Z07:00"))
+	buf.WriteString(regoRules)
+	buf.WriteString(regoGrantsRule + "[")
+	for i, g := range effectiveGrants(export) {
+		b, err := json.Marshal(regoGrant{OrgID: g.OrgID, UserID: g.UserID, Login: g.Login, Action: g.Action, Scope: g.Scope, Effect: g.effect()})
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n\t")
+		buf.Write(b)
+	}
+	buf.WriteString("\n]\n")
+	return buf.Bytes(), nil
+}
+
+// parseRego reads the grants rule of a Rego policy, the other rules are not evaluated
+func parseRego(policy []byte) ([]Grant, []string) {
+	i := bytes.Index(policy, []byte("\n"+regoGrantsRule))
+	if i < 0 {
+		return nil, []string{fmt.Sprintf("missing %q rule", strings.TrimSpace(regoGrantsRule))}
+	}
+
+	var parsed []regoGrant
+	if err := json.NewDecoder(bytes.NewReader(policy[i+1+len(regoGrantsRule):])).Decode(&parsed); err != nil {
+		return nil, []string{fmt.Sprintf("invalid grants: %s", err)}
+	}
+
+	grants := make([]Grant, 0, len(parsed))
+	var errs []string
+	for n, g := range parsed {
+		if g.Effect != effectAllow && g.Effect != effectDeny {
+			errs = append(errs, fmt.Sprintf("grant %d: invalid effect %q, expected %q or %q", n, g.Effect, effectAllow, effectDeny))
+			continue
+		}
+		if g.Action == "" {
+			errs = append(errs, fmt.Sprintf("grant %d: missing action", n))
+			continue
+		}
+		grants = append(grants, Grant{OrgID: g.OrgID, UserID: g.UserID, Login: g.Login, Action: g.Action, Scope: g.Scope, Deny: g.Effect == effectDeny})
+	}
+	return grants, errs
+}
+
+// encodeCedar writes the effective permissions as Cedar permit and forbid statements, one per line. The users are
+// the Grafana::User principals and the actions the Grafana::Action actions, the resources are expected to have the
+// org_id and scope attributes of the permission checks.
+func encodeCedar(export *Export) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Effective permissions of the Grafana users, generated at %s\n", export.GeneratedAt.Format("2006-01-02T15:04:05Z07:00"))
+
+	var last Grant
+	for _, g := range effectiveGrants(export) {
+		if g.OrgID != last.OrgID || g.UserID != last.UserID {
+			fmt.Fprintf(&buf, "\n// %s in organization %d\n", g.Login, g.OrgID)
+		}
+		last = g
+
+		statement := "permit"
+		if g.Deny {
+			statement = "forbid"
+		}
+		condition := fmt.Sprintf("resource.org_id == %d", g.OrgID)
+		if g.Scope != "" {
+			condition += " && resource.scope like " + strconv.Quote(g.Scope)
+		}
+		fmt.Fprintf(&buf, "%s (principal == Grafana::User::%s, action == Grafana::Action::%s, resource) when { %s };\n",
+			statement, strconv.Quote(strconv.FormatInt(g.UserID, 10)), strconv.Quote(g.Action), condition)
+	}
+	return buf.Bytes(), nil
+}
+
+const cedarString = `("(?:[^"\\]|\\.)*")`
+
+var cedarStatement = regexp.MustCompile(`^(permit|forbid)\s*\(\s*principal\s*==\s*Grafana::User::` + cedarString +
+	`\s*,\s*action\s*==\s*Grafana::Action::` + cedarString + `\s*,\s*resource\s*\)\s*when\s*\{\s*resource\.org_id\s*==\s*(\d+)` +
+	`(?:\s*&&\s*resource\.scope\s+like\s+` + cedarString + `)?\s*\}\s*;$`)
+
+// parseCedar reads the statements of a Cedar policy. Only the statements in the form of the exports are supported,
+// one per line.
+func parseCedar(policy []byte) ([]Grant, []string) {
+	var grants []Grant
+	var errs []string
+	for n, line := range strings.Split(string(policy), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		m := cedarStatement.FindStringSubmatch(line)
+		if m == nil {
+			errs = append(errs, fmt.Sprintf("line %d: unsupported statement", n+1))
+			continue
+		}
+		principal, err := strconv.Unquote(m[2])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: invalid principal: %s", n+1, err))
+			continue
+		}
+		userID, err := strconv.ParseInt(principal, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: invalid user id %q", n+1, principal))
+			continue
+		}
+		action, err := strconv.Unquote(m[3])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: invalid action: %s", n+1, err))
+			continue
+		}
+		orgID, err := strconv.ParseInt(m[4], 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: invalid organization id %q", n+1, m[4]))
+			continue
+		}
+		var scope string
+		if m[5] != "" {
+			if scope, err = strconv.Unquote(m[5]); err != nil {
+				errs = append(errs, fmt.Sprintf("line %d: invalid scope: %s", n+1, err))
+				continue
+			}
+		}
+		grants = append(grants, Grant{OrgID: orgID, UserID: userID, Action: action, Scope: scope, Deny: m[1] == "forbid"})
+	}
+	return grants, errs
+}
+
+// PolicyReport compares the grants of a policy with the effective permissions.
+type PolicyReport struct {
+	// Valid tells whether the policy could be read and only grants permissions to members of the organizations
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors"`
+	// InSync tells whether the policy grants exactly the effective permissions
+	InSync bool `json:"inSync"`
+	// Missing are the grants of the policy which are not effective in Grafana
+	Missing []Grant `json:"missing"`
+	// Extra are the permissions effective in Grafana which are not granted by the policy
+	Extra []Grant `json:"extra"`
+}
+
+// validatePolicy reads the policy in the format, rego or cedar, and compares its grants with the effective permissions
+// of the export.
+func validatePolicy(export *Export, format string, policy []byte) (*PolicyReport, error) {
+	var grants []Grant
+	var errs []string
+	switch format {
+	case setting.PermissionExportFormatRego:
+		grants, errs = parseRego(policy)
+	case setting.PermissionExportFormatCedar:
+		grants, errs = parseCedar(policy)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+
+	members := make(map[int64]map[int64]string, len(export.Orgs))
+	for _, org := range export.Orgs {
+		members[org.ID] = make(map[int64]string, len(org.Users))
+		for _, u := range org.Users {
+			members[org.ID][u.ID] = u.Login
+		}
+	}
+
+	granted := make(map[grantKey]bool, len(grants))
+	report := &PolicyReport{Errors: []string{}, Missing: []Grant{}, Extra: []Grant{}}
+	for _, g := range grants {
+		login, ok := members[g.OrgID][g.UserID]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("user %d is not a member of organization %d", g.UserID, g.OrgID))
+			continue
+		}
+		if granted[g.key()] {
+			continue
+		}
+		granted[g.key()] = true
+		g.Login = login
+		report.Missing = append(report.Missing, g)
+	}
+
+	effective := make(map[grantKey]bool)
+	for _, g := range effectiveGrants(export) {
+		effective[g.key()] = true
+		if !granted[g.key()] {
+			report.Extra = append(report.Extra, g)
+		}
+	}
+	missing := report.Missing[:0]
+	for _, g := range report.Missing {
+		if !effective[g.key()] {
+			missing = append(missing, g)
+		}
+	}
+	report.Missing = missing
+	sortGrants(report.Missing)
+
+	if errs != nil {
+		report.Errors = errs
+	}
+	report.Valid = len(report.Errors) == 0
+	report.InSync = report.Valid && len(report.Missing) == 0 && len(report.Extra) == 0
+	return report, nil
+}
+
+// ImportResult is the outcome of the import of a policy into the managed permissions of the users.
+type ImportResult struct {
+	PolicyReport
+	DryRun bool `json:"dryRun"`
+	// Added are the missing grants added to the managed permissions of the users
+	Added []Grant `json:"added"`
+	// Removed are the extra grants removed from the managed permissions of the users
+	Removed []Grant `json:"removed"`
+	// Skipped are the missing and extra grants which cannot be imported
+	Skipped []SkippedGrant `json:"skipped"`
+}
+
+type SkippedGrant struct {
+	Grant
+	Reason string `json:"reason"`
+}
+
+// managedScope is a resource the managed permissions of a user are set on
+type managedScope struct {
+	orgID     int64
+	userID    int64
+	resource  string
+	attribute string
+	id        string
+}
+
+func (m managedScope) scope() string {
+	return accesscontrol.Scope(m.resource, m.attribute, m.id)
+}
+
+// parseManagedScope splits a scope on a single resource, such as dashboards:uid:abc
+func parseManagedScope(g Grant) (managedScope, bool) {
+	parts := strings.SplitN(g.Scope, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" || strings.Contains(g.Scope, "*") {
+		return managedScope{}, false
+	}
+	return managedScope{orgID: g.OrgID, userID: g.UserID, resource: parts[0], attribute: parts[1], id: parts[2]}, true
+}
+
+// planImport works out the actions to set on each resource of the managed permissions of the users, so that the
+// effective permissions match the policy. Denies, permissions on several resources and permissions granted by other
+// roles than the managed role of the user cannot be imported and are skipped.
+func planImport(export *Export, report *PolicyReport) (*ImportResult, map[managedScope][]string, []managedScope) {
+	result := &ImportResult{PolicyReport: *report, Added: []Grant{}, Removed: []Grant{}, Skipped: []SkippedGrant{}}
+	plan := make(map[managedScope]map[string]bool)
+	var order []managedScope
+
+	current := func(m managedScope) map[string]bool {
+		if actions, ok := plan[m]; ok {
+			return actions
+		}
+		actions := make(map[string]bool)
+		for _, org := range export.Orgs {
+			if org.ID != m.orgID {
+				continue
+			}
+			for _, r := range org.Roles {
+				if r.Name != accesscontrol.ManagedUserRoleName(m.userID) {
+					continue
+				}
+				for _, p := range r.Permissions {
+					if !p.Deny && p.Scope == m.scope() {
+						actions[p.Action] = true
+					}
+				}
+			}
+		}
+		plan[m] = actions
+		order = append(order, m)
+		return actions
+	}
+
+	skip := func(g Grant, reason string) {
+		result.Skipped = append(result.Skipped, SkippedGrant{Grant: g, Reason: reason})
+	}
+	for _, g := range report.Missing {
+		m, ok := parseManagedScope(g)
+		switch {
+		case g.Deny:
+			skip(g, "denies are not imported")
+		case !ok:
+			skip(g, "only permissions on a single resource are imported")
+		default:
+			current(m)[g.Action] = true
+			result.Added = append(result.Added, g)
+		}
+	}
+	for _, g := range report.Extra {
+		m, ok := parseManagedScope(g)
+		switch {
+		case g.Deny:
+			skip(g, "denies are not imported")
+		case len(g.Roles) != 1 || g.Roles[0] != accesscontrol.ManagedUserRoleName(g.UserID):
+			skip(g, "granted by the roles "+strings.Join(g.Roles, ", "))
+		case !ok:
+			skip(g, "only permissions on a single resource are imported")
+		default:
+			delete(current(m), g.Action)
+			result.Removed = append(result.Removed, g)
+		}
+	}
+
+	actions := make(map[managedScope][]string, len(plan))
+	for m, set := range plan {
+		list := make([]string, 0, len(set))
+		for a := range set {
+			list = append(list, a)
+		}
+		sort.Strings(list)
+		actions[m] = list
+	}
+	return result, actions, order
+}
@@ -0,0 +1,115 @@
+package permissionexport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_Policy(t *testing.T) {
+	ctx := context.Background()
+	s, userID, _ := setupTestService(t)
+
+	for _, format := range []string{setting.PermissionExportFormatRego, setting.PermissionExportFormatCedar} {
+		t.Run(fmt.Sprintf("should validate the %s export against Grafana", format), func(t *testing.T) {
+			artifact, err := s.Export(ctx, format)
+			require.NoError(t, err)
+			assert.Equal(t, "permissions-20211101T100000Z."+format, artifact.Name)
+
+			report, err := s.ValidatePolicy(ctx, format, artifact.Data)
+			require.NoError(t, err)
+			assert.True(t, report.Valid, report.Errors)
+			assert.True(t, report.InSync)
+			assert.Empty(t, report.Missing)
+			assert.Empty(t, report.Extra)
+		})
+	}
+
+	t.Run("should grant the effective permissions in the rego export", func(t *testing.T) {
+		artifact, err := s.Export(ctx, setting.PermissionExportFormatRego)
+		require.NoError(t, err)
+		assert.Contains(t, string(artifact.Data), "package grafana.authz\n")
+		assert.Contains(t, string(artifact.Data), fmt.Sprintf(`{"org_id":1,"user_id":%d,"login":"user","action":"dashboards:read","scope":"dashboards:uid:dash","effect":"allow"}`, userID))
+	})
+
+	t.Run("should report the differences with the effective permissions", func(t *testing.T) {
+		artifact, err := s.Export(ctx, setting.PermissionExportFormatCedar)
+		require.NoError(t, err)
+		read := fmt.Sprintf(`Grafana::User::"%d", action == Grafana::Action::"dashboards:read", resource) when { resource.org_id == 1 && resource.scope like "dashboards:uid:dash" }`, userID)
+		require.Contains(t, string(artifact.Data), read)
+		policy := strings.Replace(string(artifact.Data), read, strings.Replace(read, "dashboards:read", "dashboards.permissions:write", 1), 1)
+
+		report, err := s.ValidatePolicy(ctx, setting.PermissionExportFormatCedar, []byte(policy))
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.False(t, report.InSync)
+		require.Len(t, report.Missing, 1)
+		assert.Equal(t, Grant{OrgID: 1, UserID: userID, Login: "user", Action: "dashboards.permissions:write", Scope: "dashboards:uid:dash"}, report.Missing[0])
+		require.Len(t, report.Extra, 1)
+		assert.Equal(t, "dashboards:read", report.Extra[0].Action)
+	})
+
+	t.Run("should refuse the policies granting permissions to unknown users", func(t *testing.T) {
+		policy := `permit (principal == Grafana::User::"1000", action == Grafana::Action::"dashboards:read", resource) when { resource.org_id == 1 };
+permit (principal, action, resource);`
+
+		report, err := s.ValidatePolicy(ctx, setting.PermissionExportFormatCedar, []byte(policy))
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, []string{"line 2: unsupported statement", "user 1000 is not a member of organization 1"}, report.Errors)
+
+		_, err = s.ImportPolicy(ctx, setting.PermissionExportFormatCedar, []byte(policy), false)
+		require.ErrorIs(t, err, ErrInvalidPolicy)
+
+		_, err = s.ValidatePolicy(ctx, setting.PermissionExportFormatCSV, []byte(policy))
+		require.ErrorIs(t, err, ErrInvalidFormat)
+	})
+
+	t.Run("should import the policy into the managed permissions of the users", func(t *testing.T) {
+		_, err := s.store.SetUserResourcePermission(ctx, 1, userID, accesscontrol.SetResourcePermissionCommand{
+			Actions:           []string{"folders:read"},
+			Resource:          "folders",
+			ResourceID:        "folder",
+			ResourceAttribute: "uid",
+		})
+		require.NoError(t, err)
+
+		artifact, err := s.Export(ctx, setting.PermissionExportFormatRego)
+		require.NoError(t, err)
+		read := fmt.Sprintf(`{"org_id":1,"user_id":%d,"login":"user","action":"folders:read","scope":"folders:uid:folder","effect":"allow"}`, userID)
+		write := fmt.Sprintf(`{"org_id":1,"user_id":%d,"login":"user","action":"dashboards.permissions:write","scope":"dashboards:uid:dash","effect":"allow"}`, userID)
+		wildcard := fmt.Sprintf(`{"org_id":1,"user_id":%d,"login":"user","action":"reports:create","scope":"reports:*","effect":"allow"}`, userID)
+		require.Contains(t, string(artifact.Data), read)
+		policy := strings.Replace(string(artifact.Data), read, write+",\n\t"+wildcard, 1)
+
+		result, err := s.ImportPolicy(ctx, setting.PermissionExportFormatRego, []byte(policy), true)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		require.Len(t, result.Added, 1)
+		assert.Equal(t, "dashboards.permissions:write", result.Added[0].Action)
+		require.Len(t, result.Removed, 1)
+		assert.Equal(t, "folders:read", result.Removed[0].Action)
+		require.Len(t, result.Skipped, 1)
+		assert.Equal(t, "reports:create", result.Skipped[0].Action)
+
+		report, err := s.ValidatePolicy(ctx, setting.PermissionExportFormatRego, []byte(policy))
+		require.NoError(t, err)
+		assert.Len(t, report.Missing, 2, "a dry run should not change the permissions")
+
+		_, err = s.ImportPolicy(ctx, setting.PermissionExportFormatRego, []byte(policy), false)
+		require.NoError(t, err)
+
+		report, err = s.ValidatePolicy(ctx, setting.PermissionExportFormatRego, []byte(policy))
+		require.NoError(t, err)
+		require.Len(t, report.Missing, 1)
+		assert.Equal(t, "reports:create", report.Missing[0].Action)
+		assert.Empty(t, report.Extra)
+	})
+}
@@ -0,0 +1,175 @@
+package permissionexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid permission export format")
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+// Artifact is a signed export of the permission model.
+type Artifact struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	// Signature is the hex encoded HMAC-SHA256 of the data, computed with the signing key
+	Signature string
+}
+
+// StoredArtifact tells where an export and its signature have been saved.
+type StoredArtifact struct {
+	Artifact  string `json:"artifact"`
+	Signature string `json:"signature"`
+}
+
+// Service periodically exports the permission model of all the organizations (users, teams, roles, and dashboard
+// and folder permissions) as signed artifacts kept as audit evidence. Exports can be requested on demand as well
+// through the /api/admin/permissions/export endpoints. The rego and cedar exports are policies granting the effective
+// permissions of the users, they can be validated against Grafana and imported back through the
+// /api/admin/permissions/policy endpoints.
+type Service struct {
+	cfg      *setting.Cfg
+	sqlStore *sqlstore.SQLStore
+	store    accesscontrol.ResourcePermissionsStore
+	storage  artifactStorage
+	log      log.Logger
+	now      func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, store accesscontrol.ResourcePermissionsStore,
+	routeRegister routing.RouteRegister, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sqlStore,
+		store:    store,
+		storage:  newArtifactStorage(cfg.PermissionExport),
+		log:      log.New("permissionexport"),
+		now:      time.Now,
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	// Only one Grafana instance exports the permissions when running in a cluster
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "permission-export",
+		Description: "Saves a signed export of the permission model to the configured storage.",
+		Cron:        "@every " + cfg.PermissionExport.Interval.String(),
+		Enabled:     cfg.PermissionExport.Enabled,
+		Exclusive:   true,
+		Run:         s.exportToStorage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// exportToStorage saves an export in the configured format, it is run by the scheduler
+func (s *Service) exportToStorage(ctx context.Context) error {
+	stored, err := s.ExportToStorage(ctx, s.cfg.PermissionExport.Format)
+	if err != nil {
+		return err
+	}
+	s.log.Info("Exported permissions", "artifact", stored.Artifact, "signature", stored.Signature)
+	return nil
+}
+
+// Export returns a signed export of the permission model in the format, json, csv, rego or cedar.
+func (s *Service) Export(ctx context.Context, format string) (*Artifact, error) {
+	now := s.now().UTC()
+	export, err := collect(ctx, s.sqlStore, now)
+	if err != nil {
+		return nil, err
+	}
+
+	data, contentType, err := encode(export, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Artifact{
+		Name:        fmt.Sprintf("permissions-%s.%s", now.Format("20060102T150405Z"), format),
+		ContentType: contentType,
+		Data:        data,
+		Signature:   sign(data, s.cfg.PermissionExport.SigningKey),
+	}, nil
+}
+
+// ExportToStorage saves a signed export of the permission model, in the format, to the configured storage.
+// The signature is saved next to the export, with the .sig extension.
+func (s *Service) ExportToStorage(ctx context.Context, format string) (*StoredArtifact, error) {
+	artifact, err := s.Export(ctx, format)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := s.storage.Save(ctx, artifact.Name, artifact.Data, artifact.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save permission export: %w", err)
+	}
+	signatureLocation, err := s.storage.Save(ctx, artifact.Name+".sig", []byte(artifact.Signature+"\n"), "text/plain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save permission export signature: %w", err)
+	}
+
+	return &StoredArtifact{Artifact: location, Signature: signatureLocation}, nil
+}
+
+// ValidatePolicy compares the grants of a policy, in the rego or cedar format, with the effective permissions of the
+// users.
+func (s *Service) ValidatePolicy(ctx context.Context, format string, policy []byte) (*PolicyReport, error) {
+	export, err := collect(ctx, s.sqlStore, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return validatePolicy(export, format, policy)
+}
+
+// ImportPolicy changes the managed permissions of the users so that their effective permissions match the grants of
+// the policy, in the rego or cedar format. Only the permissions on a single resource can be granted or revoked this
+// way, the other differences are reported as skipped. Nothing is changed with dryRun, or when the policy is invalid,
+// in which case ErrInvalidPolicy is returned along with the report.
+func (s *Service) ImportPolicy(ctx context.Context, format string, policy []byte, dryRun bool) (*ImportResult, error) {
+	export, err := collect(ctx, s.sqlStore, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	report, err := validatePolicy(export, format, policy)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return &ImportResult{PolicyReport: *report, DryRun: dryRun, Added: []Grant{}, Removed: []Grant{}, Skipped: []SkippedGrant{}}, ErrInvalidPolicy
+	}
+
+	result, actions, order := planImport(export, report)
+	result.DryRun = dryRun
+	if dryRun {
+		return result, nil
+	}
+
+	for _, m := range order {
+		_, err := s.store.SetUserResourcePermission(ctx, m.orgID, m.userID, accesscontrol.SetResourcePermissionCommand{
+			Actions:           actions[m],
+			Resource:          m.resource,
+			ResourceID:        m.id,
+			ResourceAttribute: m.attribute,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set the permissions of user %d on %s: %w", m.userID, m.scope(), err)
+		}
+	}
+	s.log.Info("Imported permission policy", "format", format, "added", len(result.Added), "removed", len(result.Removed), "skipped", len(result.Skipped))
+	return result, nil
+}
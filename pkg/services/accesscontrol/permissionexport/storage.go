@@ -0,0 +1,87 @@
+package permissionexport
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// artifactStorage saves the exports and their signatures, and returns where they have been saved
+type artifactStorage interface {
+	Save(ctx context.Context, name string, data []byte, contentType string) (string, error)
+}
+
+func newArtifactStorage(cfg setting.PermissionExportSettings) artifactStorage {
+	if cfg.Storage == setting.PermissionExportStorageS3 {
+		return &s3Storage{cfg: cfg.S3}
+	}
+	return &localStorage{dir: cfg.Path}
+}
+
+// localStorage writes the exports to a directory of the Grafana server
+type localStorage struct {
+	dir string
+}
+
+func (s *localStorage) Save(_ context.Context, name string, data []byte, _ string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// s3Storage uploads the exports to an S3 bucket. The objects are private, access to the exports is left to the bucket
+// policy.
+type s3Storage struct {
+	cfg setting.PermissionExportS3Settings
+}
+
+func (s *s3Storage) Save(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+	awsCfg := &aws.Config{
+		Region:           aws.String(s.cfg.Region),
+		S3ForcePathStyle: aws.Bool(s.cfg.PathStyleAccess),
+	}
+	if s.cfg.Endpoint != "" {
+		awsCfg.Endpoint = aws.String(s.cfg.Endpoint)
+	}
+	// Without static credentials the default credential chain of the AWS SDK is used
+	if s.cfg.AccessKey != "" && s.cfg.SecretKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(s.cfg.AccessKey, s.cfg.SecretKey, "")
+	}
+
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return "", err
+	}
+
+	key := name
+	if s.cfg.Path != "" {
+		key = strings.TrimSuffix(s.cfg.Path, "/") + "/" + name
+	}
+
+	result, err := s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(key),
+		ACL:         aws.String("private"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Location, nil
+}
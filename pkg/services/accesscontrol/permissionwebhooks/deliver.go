@@ -0,0 +1,88 @@
+package permissionwebhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+const (
+	SignatureHeader = "X-Grafana-Signature"
+	EventHeader     = "X-Grafana-Event"
+	DeliveryHeader  = "X-Grafana-Delivery"
+	AttemptHeader   = "X-Grafana-Delivery-Attempt"
+)
+
+// deliver posts the payload to the url, and retries up to max_retries times while the delivery fails with a
+// network error, a server error or a too many requests response
+func (s *Service) deliver(ctx context.Context, url string, payload *Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	signature := "sha256=" + sign(body, s.cfg.PermissionWebhooks.SigningKey)
+
+	backoff := s.cfg.PermissionWebhooks.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, url, payload, body, signature, attempt)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt > s.cfg.PermissionWebhooks.MaxRetries {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		s.log.Warn("Permission webhook delivery failed, retrying", "url", url, "id", payload.ID, "attempt", attempt, "backoff", backoff, "error", err)
+		if !s.sleep(ctx, backoff) {
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single delivery attempt, it returns whether the delivery should be retried when it fails
+func (s *Service) post(ctx context.Context, url string, payload *Payload, body []byte, signature string, attempt int) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(EventHeader, payload.Type)
+	req.Header.Set(DeliveryHeader, payload.ID)
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected response status %s", resp.Status)
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the payload, so that the receivers holding the signing key can verify
+// the webhook was sent by Grafana
+func sign(data []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,117 @@
+package permissionwebhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Payload is the JSON body of the webhooks.
+type Payload struct {
+	// ID identifies the change, it is the same for all the delivery attempts and URLs
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"orgId"`
+	User      *User     `json:"user,omitempty"`
+	Team      *Team     `json:"team,omitempty"`
+	// OrgRole is the role of the user in the organization, for the organization membership changes
+	OrgRole string `json:"orgRole,omitempty"`
+	Role    *Role  `json:"role,omitempty"`
+	// Removed is true when the user left the organization or the team, or when the role was unassigned
+	Removed bool `json:"removed"`
+}
+
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type Team struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type Role struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+}
+
+func newOrgMembershipPayload(e *events.OrgUserRoleChanged) *Payload {
+	return &Payload{
+		ID:        util.GenerateShortUID(),
+		Type:      EventOrgMembershipChanged,
+		Timestamp: e.Timestamp,
+		OrgID:     e.OrgID,
+		User:      &User{ID: e.UserID},
+		OrgRole:   e.Role,
+		Removed:   e.Role == "",
+	}
+}
+
+// newTeamMembershipPayload returns a payload without user when all the members left the team, as when it is deleted
+func newTeamMembershipPayload(e *events.TeamMembershipChanged) *Payload {
+	p := &Payload{
+		ID:        util.GenerateShortUID(),
+		Type:      EventTeamMembershipChanged,
+		Timestamp: e.Timestamp,
+		OrgID:     e.OrgID,
+		Team:      &Team{ID: e.TeamID},
+		Removed:   e.Removed,
+	}
+	if e.UserID != 0 {
+		p.User = &User{ID: e.UserID}
+	}
+	return p
+}
+
+func newRoleAssignmentPayload(e *events.RoleAssignmentChanged) *Payload {
+	p := &Payload{
+		ID:        util.GenerateShortUID(),
+		Type:      EventRoleAssignmentChanged,
+		Timestamp: e.Timestamp,
+		OrgID:     e.OrgID,
+		Role:      &Role{UID: e.RoleUID, Name: e.RoleName},
+		Removed:   e.Removed,
+	}
+	if e.UserID != 0 {
+		p.User = &User{ID: e.UserID}
+	}
+	if e.TeamID != 0 {
+		p.Team = &Team{ID: e.TeamID}
+	}
+	return p
+}
+
+// enrich adds the user login and email, the team name and the role name to the payload. The changes are published
+// with identifiers only, and the user, team or role may be gone already: enriching is best effort.
+func (s *Service) enrich(ctx context.Context, p *Payload) {
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if p.User != nil {
+			user := models.User{}
+			if _, err := sess.Where("id = ?", p.User.ID).Cols("login", "email").Get(&user); err != nil {
+				return err
+			}
+			p.User.Login, p.User.Email = user.Login, user.Email
+		}
+		if p.Team != nil {
+			if _, err := sess.Table("team").Cols("name").Where("id = ? AND org_id = ?", p.Team.ID, p.OrgID).Get(&p.Team.Name); err != nil {
+				return err
+			}
+		}
+		if p.Role != nil && p.Role.Name == "" {
+			if _, err := sess.Table("role").Cols("name").Where("uid = ?", p.Role.UID).Get(&p.Role.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Warn("Failed to enrich permission webhook", "id", p.ID, "type", p.Type, "error", err)
+	}
+}
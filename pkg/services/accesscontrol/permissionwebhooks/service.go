@@ -0,0 +1,107 @@
+package permissionwebhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	EventOrgMembershipChanged  = "org_membership_changed"
+	EventTeamMembershipChanged = "team_membership_changed"
+	EventRoleAssignmentChanged = "role_assignment_changed"
+)
+
+var errQueueFull = errors.New("permission webhooks queue is full")
+
+// Service sends a signed webhook to the configured URLs whenever a user joins or leaves an organization or is given
+// another role in it, joins or leaves a team, or is assigned or unassigned an access control role, directly or through
+// a team. Changes are delivered in order, failed deliveries are retried with an exponential backoff.
+type Service struct {
+	cfg      *setting.Cfg
+	sqlStore *sqlstore.SQLStore
+	client   *http.Client
+	queue    chan *Payload
+	log      log.Logger
+	// sleep waits before retrying a delivery, it returns false when the context is done first
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, b bus.Bus) *Service {
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sqlStore,
+		client:   &http.Client{Timeout: cfg.PermissionWebhooks.Timeout},
+		queue:    make(chan *Payload, cfg.PermissionWebhooks.QueueSize),
+		log:      log.New("permissionwebhooks"),
+		sleep:    sleep,
+	}
+	if !s.IsDisabled() {
+		s.registerEventListeners(b)
+	}
+	return s
+}
+
+// IsDisabled returns true when the webhooks are disabled or no URL is configured.
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.PermissionWebhooks.Enabled || len(s.cfg.PermissionWebhooks.URLs) == 0
+}
+
+// Run delivers the queued changes to the webhook URLs until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		select {
+		case payload := <-s.queue:
+			s.enrich(ctx, payload)
+			for _, url := range s.cfg.PermissionWebhooks.URLs {
+				if err := s.deliver(ctx, url, payload); err != nil {
+					s.log.Error("Failed to deliver permission webhook", "url", url, "id", payload.ID, "type", payload.Type, "error", err)
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) registerEventListeners(b bus.Bus) {
+	b.AddEventListener(func(ctx context.Context, e *events.OrgUserRoleChanged) error {
+		s.enqueue(newOrgMembershipPayload(e))
+		return nil
+	})
+	b.AddEventListener(func(ctx context.Context, e *events.TeamMembershipChanged) error {
+		s.enqueue(newTeamMembershipPayload(e))
+		return nil
+	})
+	b.AddEventListener(func(ctx context.Context, e *events.RoleAssignmentChanged) error {
+		s.enqueue(newRoleAssignmentPayload(e))
+		return nil
+	})
+}
+
+// enqueue never blocks the change being published, the change is dropped when too many deliveries are pending
+func (s *Service) enqueue(payload *Payload) {
+	select {
+	case s.queue <- payload:
+	default:
+		s.log.Error("Dropping permission webhook", "id", payload.ID, "type", payload.Type, "orgId", payload.OrgID, "error", errQueueFull)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
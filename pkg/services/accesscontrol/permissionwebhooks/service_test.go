@@ -0,0 +1,138 @@
+package permissionwebhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type delivery struct {
+	headers http.Header
+	body    []byte
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	sql := sqlstore.InitTestDB(t)
+	user, err := sql.CreateUser(ctx, models.CreateUserCommand{Login: "user", Email: "user@example.org", OrgId: 1})
+	require.NoError(t, err)
+	team, err := sql.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	deliveries := make([]delivery, 0)
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries = append(deliveries, delivery{headers: r.Header, body: body})
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get(EventHeader) == EventOrgMembershipChanged {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := setting.NewCfg()
+	cfg.PermissionWebhooks = setting.PermissionWebhooksSettings{
+		Enabled:      true,
+		URLs:         []string{server.URL},
+		SigningKey:   "secret",
+		Timeout:      time.Second,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		QueueSize:    10,
+	}
+	b := bus.New()
+	s := ProvideService(cfg, sql, b)
+	s.sleep = func(context.Context, time.Duration) bool { return true }
+
+	require.NoError(t, b.Publish(ctx, &events.RoleAssignmentChanged{OrgID: 1, RoleUID: "custom_editor", RoleName: "custom:editor", TeamID: team.Id}))
+	require.NoError(t, b.Publish(ctx, &events.TeamMembershipChanged{OrgID: 1, TeamID: team.Id, UserID: user.Id, Removed: true}))
+	require.NoError(t, b.Publish(ctx, &events.OrgUserRoleChanged{OrgID: 1, UserID: user.Id}))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(runCtx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deliveries) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	t.Run("should retry failed deliveries", func(t *testing.T) {
+		assert.Equal(t, "1", deliveries[0].headers.Get(AttemptHeader))
+		assert.Equal(t, "2", deliveries[1].headers.Get(AttemptHeader))
+		assert.Equal(t, deliveries[0].headers.Get(DeliveryHeader), deliveries[1].headers.Get(DeliveryHeader))
+		assert.Equal(t, deliveries[0].body, deliveries[1].body)
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		assert.Equal(t, EventOrgMembershipChanged, deliveries[3].headers.Get(EventHeader))
+		assert.Equal(t, "1", deliveries[3].headers.Get(AttemptHeader))
+	})
+
+	t.Run("should sign the payloads", func(t *testing.T) {
+		for _, d := range deliveries {
+			assert.Equal(t, "sha256="+sign(d.body, "secret"), d.headers.Get(SignatureHeader))
+		}
+	})
+
+	t.Run("should enrich the payloads", func(t *testing.T) {
+		var role Payload
+		require.NoError(t, json.Unmarshal(deliveries[1].body, &role))
+		assert.Equal(t, EventRoleAssignmentChanged, role.Type)
+		assert.Equal(t, &Role{UID: "custom_editor", Name: "custom:editor"}, role.Role)
+		assert.Equal(t, &Team{ID: team.Id, Name: "team"}, role.Team)
+		assert.Nil(t, role.User)
+		assert.False(t, role.Removed)
+
+		var membership Payload
+		require.NoError(t, json.Unmarshal(deliveries[2].body, &membership))
+		assert.Equal(t, EventTeamMembershipChanged, membership.Type)
+		assert.Equal(t, &User{ID: user.Id, Login: "user", Email: "user@example.org"}, membership.User)
+		assert.True(t, membership.Removed)
+
+		var org Payload
+		require.NoError(t, json.Unmarshal(deliveries[3].body, &org))
+		assert.Equal(t, EventOrgMembershipChanged, org.Type)
+		assert.True(t, org.Removed)
+	})
+}
+
+func TestService_IsDisabled(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PermissionWebhooks = setting.PermissionWebhooksSettings{Enabled: true, QueueSize: 1}
+	assert.True(t, ProvideService(cfg, nil, bus.New()).IsDisabled())
+
+	cfg.PermissionWebhooks.URLs = []string{"http://localhost"}
+	assert.False(t, ProvideService(cfg, nil, bus.New()).IsDisabled())
+}
@@ -9,6 +9,9 @@ type ResourceValidator func(ctx context.Context, orgID int64, resourceID string)
 type Options struct {
 	// Resource is the action and scope prefix that is generated
 	Resource string
+	// ResourceAttribute is the attribute the resource is identified by in scopes, e.g. "uid" for "datasources:uid:<uid>".
+	// Defaults to "id"
+	ResourceAttribute string
 	// OnlyManaged will tell the service to return all permissions if set to false and only managed permissions if set to true
 	OnlyManaged bool
 	// ResourceValidator is a validator function that will be called before each assignment.
@@ -27,6 +27,10 @@ func New(options Options, router routing.RouteRegister, ac accesscontrol.AccessC
 		return len(options.PermissionsToActions[permissions[i]]) > len(options.PermissionsToActions[permissions[j]])
 	})
 
+	if options.ResourceAttribute == "" {
+		options.ResourceAttribute = "id"
+	}
+
 	actions := make([]string, 0, len(validActions))
 	for action := range validActions {
 		actions = append(actions, action)
@@ -66,10 +70,11 @@ type Service struct {
 
 func (s *Service) GetPermissions(ctx context.Context, orgID int64, resourceID string) ([]accesscontrol.ResourcePermission, error) {
 	return s.store.GetResourcesPermissions(ctx, orgID, accesscontrol.GetResourcesPermissionsQuery{
-		Actions:     s.actions,
-		Resource:    s.options.Resource,
-		ResourceIDs: []string{resourceID},
-		OnlyManaged: s.options.OnlyManaged,
+		Actions:           s.actions,
+		Resource:          s.options.Resource,
+		ResourceIDs:       []string{resourceID},
+		OnlyManaged:       s.options.OnlyManaged,
+		ResourceAttribute: s.options.ResourceAttribute,
 	})
 }
 
@@ -91,9 +96,10 @@ func (s *Service) SetUserPermission(ctx context.Context, orgID, userID int64, re
 	}
 
 	permission, err := s.store.SetUserResourcePermission(ctx, orgID, userID, accesscontrol.SetResourcePermissionCommand{
-		Actions:    actions,
-		ResourceID: resourceID,
-		Resource:   s.options.Resource,
+		Actions:           actions,
+		ResourceID:        resourceID,
+		Resource:          s.options.Resource,
+		ResourceAttribute: s.options.ResourceAttribute,
 	})
 	if err != nil {
 		return nil, err
@@ -124,9 +130,10 @@ func (s *Service) SetTeamPermission(ctx context.Context, orgID, teamID int64, re
 	}
 
 	permission, err := s.store.SetTeamResourcePermission(ctx, orgID, teamID, accesscontrol.SetResourcePermissionCommand{
-		Actions:    actions,
-		ResourceID: resourceID,
-		Resource:   s.options.Resource,
+		Actions:           actions,
+		ResourceID:        resourceID,
+		Resource:          s.options.Resource,
+		ResourceAttribute: s.options.ResourceAttribute,
 	})
 	if err != nil {
 		return nil, err
@@ -158,9 +165,10 @@ func (s *Service) SetBuiltInRolePermission(ctx context.Context, orgID int64, bui
 	}
 
 	permission, err := s.store.SetBuiltInResourcePermission(ctx, orgID, builtInRole, accesscontrol.SetResourcePermissionCommand{
-		Actions:    actions,
-		ResourceID: resourceID,
-		Resource:   s.options.Resource,
+		Actions:           actions,
+		ResourceID:        resourceID,
+		Resource:          s.options.Resource,
+		ResourceAttribute: s.options.ResourceAttribute,
 	})
 	if err != nil {
 		return nil, err
@@ -1,6 +1,8 @@
 package accesscontrol
 
 import (
+	"crypto/sha1"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"sync"
@@ -28,6 +30,24 @@ var (
 		},
 	}
 
+	dashboardsReaderRole = RoleDTO{
+		Version:     1,
+		Name:        dashboardsReader,
+		DisplayName: "Dashboards reader",
+		Description: "List all dashboards and folders. Dashboard and folder permissions still apply.",
+		Group:       "Dashboards",
+		Permissions: []Permission{
+			{
+				Action: ActionDashboardsRead,
+				Scope:  ScopeDashboardsAll,
+			},
+			{
+				Action: ActionDashboardsRead,
+				Scope:  ScopeFoldersAll,
+			},
+		},
+	}
+
 	ldapReaderRole = RoleDTO{
 		Name:        ldapReader,
 		DisplayName: "LDAP reader",
@@ -63,9 +83,9 @@ var (
 	orgUsersWriterRole = RoleDTO{
 		Name:        orgUsersWriter,
 		DisplayName: "Organization user writer",
-		Description: "Within a single organization, add a user, invite a user, read information about a user and their role, remove a user from that organization, or change the role of a user.",
+		Description: "Within a single organization, add a user, invite a user, read information about a user and their role, remove a user from that organization, change the role of a user, or revoke the sessions of a user.",
 		Group:       "User administration (organizational)",
-		Version:     3,
+		Version:     4,
 		Permissions: ConcatPermissions(orgUsersReaderRole.Permissions, []Permission{
 			{
 				Action: ActionOrgUsersAdd,
@@ -79,6 +99,10 @@ var (
 				Action: ActionOrgUsersRemove,
 				Scope:  ScopeUsersAll,
 			},
+			{
+				Action: ActionOrgUsersLogout,
+				Scope:  ScopeUsersAll,
+			},
 		}),
 	}
 
@@ -110,6 +134,72 @@ var (
 		},
 	}
 
+	groupMappingsReaderRole = RoleDTO{
+		Name:        groupMappingsReader,
+		DisplayName: "Group mappings reader",
+		Description: "List the roles assigned to the groups of external identity providers.",
+		Group:       "User administration (organizational)",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionGroupMappingsRead,
+			},
+		},
+	}
+
+	groupMappingsWriterRole = RoleDTO{
+		Name:        groupMappingsWriter,
+		DisplayName: "Group mappings writer",
+		Description: "List, add and remove the roles assigned to the groups of external identity providers on login. Only roles with permissions the user already holds can be mapped or unmapped.",
+		Group:       "User administration (organizational)",
+		Version:     1,
+		Permissions: ConcatPermissions(groupMappingsReaderRole.Permissions, []Permission{
+			{
+				Action: ActionGroupMappingsWrite,
+				Scope:  ScopePermissionsDelegate,
+			},
+		}),
+	}
+
+	rolesExporterRole = RoleDTO{
+		Name:        rolesExporter,
+		DisplayName: "Roles exporter",
+		Description: "Export the custom roles and their assignments as YAML provisioning files.",
+		Group:       "Permissions",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionRolesExport,
+			},
+		},
+	}
+
+	permissionsDeniesReaderRole = RoleDTO{
+		Name:        permissionsDeniesReader,
+		DisplayName: "Deny permissions reader",
+		Description: "List the actions denied to users, teams and built-in roles.",
+		Group:       "Permissions",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionPermissionsDeniesRead,
+			},
+		},
+	}
+
+	permissionsDeniesWriterRole = RoleDTO{
+		Name:        permissionsDeniesWriter,
+		DisplayName: "Deny permissions writer",
+		Description: "List, add and remove the actions denied to users, teams and built-in roles, overriding the permissions granting them.",
+		Group:       "Permissions",
+		Version:     1,
+		Permissions: ConcatPermissions(permissionsDeniesReaderRole.Permissions, []Permission{
+			{
+				Action: ActionPermissionsDeniesWrite,
+			},
+		}),
+	}
+
 	statsReaderRole = RoleDTO{
 		Version:     3,
 		Name:        statsReader,
@@ -123,12 +213,90 @@ var (
 		},
 	}
 
+	teamsRolesReaderRole = RoleDTO{
+		Name:        teamsRolesReader,
+		DisplayName: "Team roles reader",
+		Description: "List the roles assigned to teams.",
+		Group:       "Teams",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionTeamsRolesList,
+				Scope:  ScopeTeamsAll,
+			},
+		},
+	}
+
+	teamsRolesWriterRole = RoleDTO{
+		Name:        teamsRolesWriter,
+		DisplayName: "Team roles writer",
+		Description: "List, assign and unassign the roles of teams. Only roles with permissions the user already holds can be assigned or unassigned.",
+		Group:       "Teams",
+		Version:     1,
+		Permissions: ConcatPermissions(teamsRolesReaderRole.Permissions, []Permission{
+			{
+				Action: ActionTeamsRolesAdd,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionTeamsRolesRemove,
+				Scope:  ScopePermissionsDelegate,
+			},
+		}),
+	}
+
+	usersRolesReaderRole = RoleDTO{
+		Name:        usersRolesReader,
+		DisplayName: "User roles reader",
+		Description: "List the roles assigned directly to users within a single organization.",
+		Group:       "User administration (organizational)",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionUsersRolesList,
+				Scope:  ScopeUsersAll,
+			},
+		},
+	}
+
+	usersRolesWriterRole = RoleDTO{
+		Name:        usersRolesWriter,
+		DisplayName: "User roles writer",
+		Description: "List, assign and unassign the roles of users within a single organization, with an optional expiry. Only roles with permissions the user already holds can be assigned or unassigned.",
+		Group:       "User administration (organizational)",
+		Version:     1,
+		Permissions: ConcatPermissions(usersRolesReaderRole.Permissions, []Permission{
+			{
+				Action: ActionUsersRolesAdd,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionUsersRolesRemove,
+				Scope:  ScopePermissionsDelegate,
+			},
+		}),
+	}
+
+	usersPermissionsReaderRole = RoleDTO{
+		Name:        usersPermissionsReader,
+		DisplayName: "User permissions reader",
+		Description: "Check the permissions of the users within a single organization.",
+		Group:       "User administration (organizational)",
+		Version:     1,
+		Permissions: []Permission{
+			{
+				Action: ActionUsersPermissionsList,
+				Scope:  ScopeUsersAll,
+			},
+		},
+	}
+
 	usersReaderRole = RoleDTO{
 		Name:        usersReader,
 		DisplayName: "User reader",
-		Description: "Read all users and their information, such as team memberships, authentication tokens, and quotas.",
+		Description: "Read all users and their information, such as team memberships, authentication tokens, quotas, and login lockouts.",
 		Group:       "User administration (global)",
-		Version:     3,
+		Version:     4,
 		Permissions: []Permission{
 			{
 				Action: ActionUsersRead,
@@ -146,15 +314,19 @@ var (
 				Action: ActionUsersQuotasList,
 				Scope:  ScopeGlobalUsersAll,
 			},
+			{
+				Action: ActionUsersLockoutRead,
+				Scope:  ScopeGlobalUsersAll,
+			},
 		},
 	}
 
 	usersWriterRole = RoleDTO{
 		Name:        usersWriter,
 		DisplayName: "User writer",
-		Description: "Read and update all attributes and settings for all users in Grafana: update user information, read user information, create or enable or disable a user, make a user a Grafana administrator, sign out a user, update a user’s authentication token, or update quotas for all users.",
+		Description: "Read and update all attributes and settings for all users in Grafana: update user information, read user information, create or enable or disable a user, make a user a Grafana administrator, sign out a user, update a user’s authentication token, update quotas, unlock the login, reset the two-factor authentication, or restore a deleted user for all users.",
 		Group:       "User administration (global)",
-		Version:     3,
+		Version:     6,
 		Permissions: ConcatPermissions(usersReaderRole.Permissions, []Permission{
 			{
 				Action: ActionUsersPasswordUpdate,
@@ -195,21 +367,44 @@ var (
 				Action: ActionUsersQuotasUpdate,
 				Scope:  ScopeGlobalUsersAll,
 			},
+			{
+				Action: ActionUsersUnlock,
+				Scope:  ScopeGlobalUsersAll,
+			},
+			{
+				Action: ActionUsersTwoFactorDelete,
+				Scope:  ScopeGlobalUsersAll,
+			},
+			{
+				Action: ActionUsersRestore,
+				Scope:  ScopeGlobalUsersAll,
+			},
 		}),
 	}
 )
 
 // Role names definitions
 const (
-	datasourcesExplorer = "fixed:datasources:explorer"
-	ldapReader          = "fixed:ldap:reader"
-	ldapWriter          = "fixed:ldap:writer"
-	orgUsersReader      = "fixed:org.users:reader"
-	orgUsersWriter      = "fixed:org.users:writer"
-	settingsReader      = "fixed:settings:reader"
-	statsReader         = "fixed:stats:reader"
-	usersReader         = "fixed:users:reader"
-	usersWriter         = "fixed:users:writer"
+	dashboardsReader        = "fixed:dashboards:reader"
+	datasourcesExplorer     = "fixed:datasources:explorer"
+	groupMappingsReader     = "fixed:groupmappings:reader"
+	groupMappingsWriter     = "fixed:groupmappings:writer"
+	ldapReader              = "fixed:ldap:reader"
+	ldapWriter              = "fixed:ldap:writer"
+	orgUsersReader          = "fixed:org.users:reader"
+	orgUsersWriter          = "fixed:org.users:writer"
+	permissionsDeniesReader = "fixed:permissions.denies:reader"
+	permissionsDeniesWriter = "fixed:permissions.denies:writer"
+	rolesExporter           = "fixed:roles:exporter"
+	settingsReader          = "fixed:settings:reader"
+	statsReader             = "fixed:stats:reader"
+	teamsRolesReader        = "fixed:teams.roles:reader"
+	teamsRolesWriter        = "fixed:teams.roles:writer"
+	usersPermissionsReader  = "fixed:users.permissions:reader"
+	usersReader             = "fixed:users:reader"
+	usersRolesReader        = "fixed:users.roles:reader"
+	usersRolesWriter        = "fixed:users.roles:writer"
+	usersWriter             = "fixed:users:writer"
 )
 
 var (
@@ -220,15 +415,26 @@ var (
 	// resource. FixedRoleGrants lists which built-in roles are
 	// assigned which fixed roles in this list.
 	FixedRoles = map[string]RoleDTO{
-		datasourcesExplorer: datasourcesExplorerRole,
-		ldapReader:          ldapReaderRole,
-		ldapWriter:          ldapWriterRole,
-		orgUsersReader:      orgUsersReaderRole,
-		orgUsersWriter:      orgUsersWriterRole,
-		settingsReader:      settingsReaderRole,
-		statsReader:         statsReaderRole,
-		usersReader:         usersReaderRole,
-		usersWriter:         usersWriterRole,
+		dashboardsReader:        dashboardsReaderRole,
+		datasourcesExplorer:     datasourcesExplorerRole,
+		groupMappingsReader:     groupMappingsReaderRole,
+		groupMappingsWriter:     groupMappingsWriterRole,
+		ldapReader:              ldapReaderRole,
+		ldapWriter:              ldapWriterRole,
+		orgUsersReader:          orgUsersReaderRole,
+		orgUsersWriter:          orgUsersWriterRole,
+		permissionsDeniesReader: permissionsDeniesReaderRole,
+		permissionsDeniesWriter: permissionsDeniesWriterRole,
+		rolesExporter:           rolesExporterRole,
+		settingsReader:          settingsReaderRole,
+		statsReader:             statsReaderRole,
+		teamsRolesReader:        teamsRolesReaderRole,
+		teamsRolesWriter:        teamsRolesWriterRole,
+		usersPermissionsReader:  usersPermissionsReaderRole,
+		usersReader:             usersReaderRole,
+		usersRolesReader:        usersRolesReaderRole,
+		usersRolesWriter:        usersRolesWriterRole,
+		usersWriter:             usersWriterRole,
 	}
 
 	// FixedRoleGrants specifies which built-in roles are assigned
@@ -245,12 +451,25 @@ var (
 			usersWriter,
 		},
 		string(models.ROLE_ADMIN): {
+			groupMappingsReader,
+			groupMappingsWriter,
 			orgUsersReader,
 			orgUsersWriter,
+			permissionsDeniesReader,
+			permissionsDeniesWriter,
+			rolesExporter,
+			teamsRolesReader,
+			teamsRolesWriter,
+			usersPermissionsReader,
+			usersRolesReader,
+			usersRolesWriter,
 		},
 		string(models.ROLE_EDITOR): {
 			datasourcesExplorer,
 		},
+		string(models.ROLE_VIEWER): {
+			dashboardsReader,
+		},
 	}
 )
 
@@ -267,6 +486,14 @@ func ConcatPermissions(permissions ...[]Permission) []Permission {
 	return perms
 }
 
+// FixedRoleUID returns a stable uid for a fixed role based on its name
+// e.g. fixed:users:reader -> fixed_<base64 encoded sha1 of the name>
+func FixedRoleUID(roleName string) string {
+	h := sha1.New()
+	_, _ = h.Write([]byte(roleName))
+	return strings.TrimSuffix(FixedRolePrefix, ":") + "_" + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
 // ValidateFixedRole errors when a fixed role does not match expected pattern
 func ValidateFixedRole(role RoleDTO) error {
 	if !strings.HasPrefix(role.Name, FixedRolePrefix) {
@@ -275,6 +502,52 @@ func ValidateFixedRole(role RoleDTO) error {
 	return nil
 }
 
+// ResolveRoleIncludes returns the permissions of the role along with the permissions of the roles it includes,
+// recursively, without duplicates. The included roles are looked up by name in roles.
+func ResolveRoleIncludes(role RoleDTO, roles map[string]RoleDTO) ([]Permission, error) {
+	permissions := make([]Permission, 0, len(role.Permissions))
+	seen := make(map[Permission]struct{})
+	resolved := make(map[string]bool)
+
+	var resolve func(role RoleDTO, path []string) error
+	resolve = func(role RoleDTO, path []string) error {
+		for _, name := range path {
+			if name == role.Name {
+				return fmt.Errorf("%s: %w", strings.Join(append(path, role.Name), " -> "), ErrRoleIncludeCycle)
+			}
+		}
+		if resolved[role.Name] {
+			return nil
+		}
+		resolved[role.Name] = true
+
+		for _, p := range role.Permissions {
+			key := Permission{Action: p.Action, Scope: p.Scope, Deny: p.Deny}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			permissions = append(permissions, key)
+		}
+
+		for _, name := range role.Includes {
+			included, ok := roles[name]
+			if !ok {
+				return fmt.Errorf("role '%s' included by '%s': %w", name, role.Name, ErrRoleNotFound)
+			}
+			if err := resolve(included, append(path, role.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := resolve(role, nil); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
 // ValidateBuiltInRoles errors when a built-in role does not match expected pattern
 func ValidateBuiltInRoles(builtInRoles []string) error {
 	for _, br := range builtInRoles {
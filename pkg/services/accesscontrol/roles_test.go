@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFixedRoles(t *testing.T) {
@@ -72,3 +73,30 @@ func TestConcatPermissions(t *testing.T) {
 	perms := ConcatPermissions(perms1, perms2)
 	assert.ElementsMatch(t, perms, expected)
 }
+
+func TestResolveRoleIncludes(t *testing.T) {
+	roles := map[string]RoleDTO{
+		"fixed:a": {Name: "fixed:a", Permissions: []Permission{{Action: "a", Scope: "*"}}, Includes: []string{"fixed:b", "fixed:c"}},
+		"fixed:b": {Name: "fixed:b", Permissions: []Permission{{Action: "b", Scope: "*"}}, Includes: []string{"fixed:c"}},
+		"fixed:c": {Name: "fixed:c", Permissions: []Permission{{Action: "c", Scope: "*"}, {Action: "a", Scope: "*"}}},
+		"fixed:d": {Name: "fixed:d", Includes: []string{"fixed:e"}},
+		"fixed:e": {Name: "fixed:e", Includes: []string{"fixed:d"}},
+		"fixed:f": {Name: "fixed:f", Includes: []string{"fixed:unknown"}},
+	}
+
+	t.Run("should include the permissions of the included roles once", func(t *testing.T) {
+		permissions, err := ResolveRoleIncludes(roles["fixed:a"], roles)
+		require.NoError(t, err)
+		assert.Equal(t, []Permission{{Action: "a", Scope: "*"}, {Action: "b", Scope: "*"}, {Action: "c", Scope: "*"}}, permissions)
+	})
+
+	t.Run("should fail on cycles", func(t *testing.T) {
+		_, err := ResolveRoleIncludes(roles["fixed:d"], roles)
+		assert.ErrorIs(t, err, ErrRoleIncludeCycle)
+	})
+
+	t.Run("should fail on unknown roles", func(t *testing.T) {
+		_, err := ResolveRoleIncludes(roles["fixed:f"], roles)
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+	})
+}
@@ -19,6 +19,21 @@ func GetResourceAllIDScope(resource string) string {
 	return Scope(resource, "id", "*")
 }
 
+// GetResourceAttributeScope returns the scope of a resource identified by the given attribute,
+// e.g. GetResourceAttributeScope("datasources", "uid", "abc") returns "datasources:uid:abc".
+// The resource id is used when no attribute is given.
+func GetResourceAttributeScope(resource, attribute, resourceID string) string {
+	if attribute == "" {
+		attribute = "id"
+	}
+	return Scope(resource, attribute, resourceID)
+}
+
+// GetResourceAllAttributeScope returns the scope covering all resources identified by the given attribute
+func GetResourceAllAttributeScope(resource, attribute string) string {
+	return GetResourceAttributeScope(resource, attribute, "*")
+}
+
 // Scope builds scope from parts
 // e.g. Scope("users", "*") return "users:*"
 func Scope(parts ...string) string {
@@ -98,6 +98,17 @@ type notificationService struct {
 }
 
 func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
+	if muted, err := n.isMutedByCalendar(evalCtx); err != nil {
+		n.log.Error("Failed to get mute calendar events", "ruleId", evalCtx.Rule.ID, "error", err)
+	} else if muted {
+		return nil
+	}
+	if muted, err := n.isMutedBySchedule(evalCtx); err != nil {
+		n.log.Error("Failed to get mute schedule occurrences", "ruleId", evalCtx.Rule.ID, "error", err)
+	} else if muted {
+		return nil
+	}
+
 	notifierStates, err := n.getNeededNotifiers(evalCtx.Rule.OrgID, evalCtx.Rule.Notifications, evalCtx)
 	if err != nil {
 		n.log.Error("Failed to get alert notifiers", "error", err)
@@ -127,6 +138,56 @@ func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
 	return n.sendNotifications(evalCtx, notifierStates)
 }
 
+// isMutedByCalendar reports whether an event of one of the mute calendars of the rule is active,
+// in which case no notifications are sent.
+func (n *notificationService) isMutedByCalendar(evalCtx *EvalContext) (bool, error) {
+	if len(evalCtx.Rule.MuteCalendars) == 0 {
+		return false, nil
+	}
+
+	query := &models.GetCalendarOccurrencesQuery{
+		OrgId:        evalCtx.Rule.OrgID,
+		CalendarUids: evalCtx.Rule.MuteCalendars,
+		From:         evalCtx.StartTime,
+		To:           evalCtx.StartTime,
+	}
+	if err := bus.Dispatch(evalCtx.Ctx, query); err != nil {
+		return false, err
+	}
+	if len(query.Result) == 0 {
+		return false, nil
+	}
+
+	n.log.Info("Skipping notifications during calendar event", "ruleId", evalCtx.Rule.ID, "calendarUid", query.Result[0].CalendarUid, "event", query.Result[0].Title)
+	return true, nil
+}
+
+// isMutedBySchedule reports whether one of the mute schedules of the rule is active, in which case
+// no notifications are sent.
+func (n *notificationService) isMutedBySchedule(evalCtx *EvalContext) (bool, error) {
+	if len(evalCtx.Rule.MuteSchedules) == 0 {
+		return false, nil
+	}
+
+	query := &models.GetScheduleOccurrencesQuery{
+		OrgId:        evalCtx.Rule.OrgID,
+		ScheduleUids: evalCtx.Rule.MuteSchedules,
+		From:         evalCtx.StartTime,
+		To:           evalCtx.StartTime,
+	}
+	if err := bus.Dispatch(evalCtx.Ctx, query); err != nil {
+		return false, err
+	}
+	for _, occurrence := range query.Result {
+		// Schedules without a duration are never active
+		if occurrence.End.After(occurrence.Start) {
+			n.log.Info("Skipping notifications during schedule", "ruleId", evalCtx.Rule.ID, "scheduleUid", occurrence.ScheduleUid, "schedule", occurrence.Name)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (n *notificationService) sendAndMarkAsComplete(evalContext *EvalContext, notifierState *notifierState) error {
 	notifier := notifierState.notifier
 
@@ -66,6 +66,56 @@ func TestNotificationService(t *testing.T) {
 			require.Truef(sc.t, evalCtx.Ctx.Value(notificationSent{}).(bool), "expected notification to be sent, but wasn't")
 		})
 
+	mutedRule := &Rule{Name: "Test", Message: "Something is bad", MuteCalendars: []string{"holidays"}}
+	mutedEvalCtx := NewEvalContext(context.Background(), mutedRule, &validations.OSSPluginRequestValidator{})
+	notificationServiceScenario(
+		t, "Given alert rule with mute calendars should not send notification during calendar events",
+		mutedEvalCtx, false, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetCalendarOccurrencesQuery) error {
+				require.Equal(sc.t, []string{"holidays"}, query.CalendarUids)
+				require.Equal(sc.t, mutedEvalCtx.StartTime, query.From)
+				query.Result = []*models.CalendarOccurrence{{CalendarUid: "holidays", Title: "Christmas Day"}}
+				return nil
+			})
+
+			err := sc.notificationService.SendIfNeeded(mutedEvalCtx)
+			require.NoError(sc.t, err)
+			require.Nilf(sc.t, mutedEvalCtx.Ctx.Value(notificationSent{}), "expected notification not to be sent, but it was")
+
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetCalendarOccurrencesQuery) error {
+				return nil
+			})
+			err = sc.notificationService.SendIfNeeded(mutedEvalCtx)
+			require.NoError(sc.t, err)
+			require.Truef(sc.t, mutedEvalCtx.Ctx.Value(notificationSent{}).(bool), "expected notification to be sent, but wasn't")
+		})
+
+	scheduledRule := &Rule{Name: "Test", Message: "Something is bad", MuteSchedules: []string{"nights"}}
+	scheduledEvalCtx := NewEvalContext(context.Background(), scheduledRule, &validations.OSSPluginRequestValidator{})
+	notificationServiceScenario(
+		t, "Given alert rule with mute schedules should not send notification while a schedule is active",
+		scheduledEvalCtx, false, func(sc *scenarioContext) {
+			start := scheduledEvalCtx.StartTime.Add(-time.Hour)
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetScheduleOccurrencesQuery) error {
+				require.Equal(sc.t, []string{"nights"}, query.ScheduleUids)
+				require.Equal(sc.t, scheduledEvalCtx.StartTime, query.To)
+				query.Result = []*models.ScheduleOccurrence{{ScheduleUid: "nights", Name: "Nights", Start: start, End: start.Add(8 * time.Hour)}}
+				return nil
+			})
+
+			err := sc.notificationService.SendIfNeeded(scheduledEvalCtx)
+			require.NoError(sc.t, err)
+			require.Nilf(sc.t, scheduledEvalCtx.Ctx.Value(notificationSent{}), "expected notification not to be sent, but it was")
+
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetScheduleOccurrencesQuery) error {
+				query.Result = []*models.ScheduleOccurrence{{ScheduleUid: "nights", Name: "Nights", Start: start, End: start}}
+				return nil
+			})
+			err = sc.notificationService.SendIfNeeded(scheduledEvalCtx)
+			require.NoError(sc.t, err)
+			require.Truef(sc.t, scheduledEvalCtx.Ctx.Value(notificationSent{}).(bool), "expected notification to be sent, but wasn't")
+		})
+
 	notificationServiceScenario(t, "Given alert rule with upload image enabled and render times out should send notification",
 		evalCtx, true, func(sc *scenarioContext) {
 			setting.AlertingNotificationTimeout = 200 * time.Millisecond
@@ -377,6 +427,10 @@ func (s *testRenderService) GetRenderUser(ctx context.Context, key string) (*ren
 	return nil, false
 }
 
+func (s *testRenderService) QueueLength() int {
+	return 0
+}
+
 func (s *testRenderService) Version() string {
 	return ""
 }
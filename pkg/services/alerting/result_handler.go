@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
@@ -97,6 +98,17 @@ func (handler *defaultResultHandler) handle(evalContext *EvalContext) error {
 		if err := annotationRepo.Save(&item); err != nil {
 			handler.log.Error("Failed to save annotation for new alert state", "error", err)
 		}
+
+		if evalContext.Rule.State == models.AlertStateAlerting {
+			if err := bus.Publish(evalContext.Ctx, &events.AlertFiring{
+				Timestamp:  time.Now(),
+				OrgID:      evalContext.Rule.OrgID,
+				AlertID:    evalContext.Rule.ID,
+				AlertTitle: evalContext.Rule.Name,
+			}); err != nil {
+				handler.log.Error("Failed to publish alert firing event", "error", err)
+			}
+		}
 	}
 
 	if err := handler.notifier.SendIfNeeded(evalContext); err != nil {
@@ -54,6 +54,8 @@ type Rule struct {
 	State               models.AlertStateType
 	Conditions          []Condition
 	Notifications       []string
+	MuteCalendars       []string
+	MuteSchedules       []string
 	AlertRuleTags       []*models.Tag
 
 	StateChanges int64
@@ -188,6 +190,8 @@ func NewRuleFromDBAlert(ctx context.Context, ruleDef *models.Alert, logTranslati
 			return nil, ValidationError{Reason: "Neither id nor uid is specified in 'notifications' block, " + err.Error(), DashboardID: model.DashboardID, AlertID: model.ID, PanelID: model.PanelID}
 		}
 	}
+	model.MuteCalendars = ruleDef.Settings.Get("muteCalendars").MustStringArray()
+	model.MuteSchedules = ruleDef.Settings.Get("muteSchedules").MustStringArray()
 	model.AlertRuleTags = ruleDef.GetTagsFromSettings()
 
 	for index, condition := range ruleDef.Settings.Get("conditions").MustArray() {
@@ -114,7 +114,9 @@ func TestAlertRuleModel(t *testing.T) {
 					"notifications": [
 						{"id": 1},
 						{"uid": "notifier2"}
-					]
+					],
+					"muteCalendars": ["holidays"],
+					"muteSchedules": ["nights"]
 				}
 				`
 
@@ -138,6 +140,8 @@ func TestAlertRuleModel(t *testing.T) {
 
 		require.Contains(t, alertRule.Notifications, "notifier2")
 		require.Contains(t, alertRule.Notifications, "notifier1")
+		require.Equal(t, []string{"holidays"}, alertRule.MuteCalendars)
+		require.Equal(t, []string{"nights"}, alertRule.MuteSchedules)
 	})
 
 	t.Run("Testing alert rule with non existing notification id", func(t *testing.T) {
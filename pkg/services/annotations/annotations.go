@@ -14,8 +14,12 @@ var (
 
 type Repository interface {
 	Save(item *Item) error
+	// SaveMany saves the items with batched inserts, unlike Save it does not set the ids of the items without tags
+	SaveMany(items []*Item) error
 	Update(item *Item) error
 	Find(query *ItemQuery) ([]*ItemDTO, error)
+	// Stream calls fn with each of the items matching the query as they are read, without a default limit
+	Stream(ctx context.Context, query *ItemQuery, fn func(item *ItemDTO) error) error
 	Delete(params *DeleteParams) error
 	FindTags(query *TagsQuery) (FindTagsResult, error)
 }
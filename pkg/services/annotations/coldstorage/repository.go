@@ -0,0 +1,81 @@
+package coldstorage
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// Repository is the repository of the annotations of the database, whose queries return the archived annotations as
+// well. The annotations are only saved, updated and deleted in the database.
+type Repository struct {
+	annotations.Repository
+	service *Service
+}
+
+// Find returns the annotations of the database and of the archived files matching the query, sorted and limited
+// like the ones of the database
+func (r *Repository) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	items, err := r.Repository.Find(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	archives, err := r.service.findArchives(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return items, nil
+	}
+	// the files aren't read when the annotations of the database are all more recent than the archived ones
+	if query.Limit > 0 && int64(len(items)) >= query.Limit && items[len(items)-1].TimeEnd > archives[0].MaxEpochEnd {
+		return items, nil
+	}
+
+	archived, err := r.service.findArchived(ctx, query, archives)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, archived...)
+	sortItems(items)
+	if query.Limit > 0 && int64(len(items)) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+// Stream calls fn with the annotations of the database matching the query, then with the archived ones
+func (r *Repository) Stream(ctx context.Context, query *annotations.ItemQuery, fn func(item *annotations.ItemDTO) error) error {
+	var count int64
+	err := r.Repository.Stream(ctx, query, func(item *annotations.ItemDTO) error {
+		count++
+		return fn(item)
+	})
+	if err != nil {
+		return err
+	}
+	if query.Limit > 0 && count >= query.Limit {
+		return nil
+	}
+
+	archives, err := r.service.findArchives(ctx, query)
+	if err != nil || len(archives) == 0 {
+		return err
+	}
+	archivedQuery := *query
+	if query.Limit > 0 {
+		archivedQuery.Limit = query.Limit - count
+	}
+	archived, err := r.service.findArchived(ctx, &archivedQuery, archives)
+	if err != nil {
+		return err
+	}
+	for _, item := range archived {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
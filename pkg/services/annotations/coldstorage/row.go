@@ -0,0 +1,170 @@
+package coldstorage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/parquet"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// row is an archived annotation, with the login and email of its user and the name of its alert at the time it was
+// archived. The tags and data are kept as their JSON.
+type row struct {
+	Id          int64
+	OrgId       int64
+	AlertId     int64
+	AlertName   string
+	DashboardId int64
+	PanelId     int64
+	UserId      int64
+	Login       string
+	Email       string
+	Epoch       int64
+	EpochEnd    int64
+	PrevState   string
+	NewState    string
+	Text        string
+	Tags        string
+	Data        string
+	Created     int64
+	Updated     int64
+}
+
+// columns are the columns of the files, in the order of the values of a row. The organization is the one of the file.
+var columns = []parquet.Column{
+	{Name: "id", Type: parquet.Int64},
+	{Name: "alert_id", Type: parquet.Int64},
+	{Name: "alert_name", Type: parquet.String},
+	{Name: "dashboard_id", Type: parquet.Int64},
+	{Name: "panel_id", Type: parquet.Int64},
+	{Name: "user_id", Type: parquet.Int64},
+	{Name: "login", Type: parquet.String},
+	{Name: "email", Type: parquet.String},
+	{Name: "epoch", Type: parquet.Int64},
+	{Name: "epoch_end", Type: parquet.Int64},
+	{Name: "prev_state", Type: parquet.String},
+	{Name: "new_state", Type: parquet.String},
+	{Name: "text", Type: parquet.String},
+	{Name: "tags", Type: parquet.String},
+	{Name: "data", Type: parquet.String},
+	{Name: "created", Type: parquet.Int64},
+	{Name: "updated", Type: parquet.Int64},
+}
+
+func (r *row) values() []interface{} {
+	return []interface{}{
+		r.Id, r.AlertId, r.AlertName, r.DashboardId, r.PanelId, r.UserId, r.Login, r.Email, r.Epoch, r.EpochEnd,
+		r.PrevState, r.NewState, r.Text, r.Tags, r.Data, r.Created, r.Updated,
+	}
+}
+
+// rowFromValues returns the row of the values of a file, whose columns are matched by name
+func rowFromValues(fileColumns []parquet.Column, values []interface{}) (*row, error) {
+	r := &row{}
+	fields := map[string]interface{}{
+		"id": &r.Id, "alert_id": &r.AlertId, "alert_name": &r.AlertName, "dashboard_id": &r.DashboardId,
+		"panel_id": &r.PanelId, "user_id": &r.UserId, "login": &r.Login, "email": &r.Email, "epoch": &r.Epoch,
+		"epoch_end": &r.EpochEnd, "prev_state": &r.PrevState, "new_state": &r.NewState, "text": &r.Text,
+		"tags": &r.Tags, "data": &r.Data, "created": &r.Created, "updated": &r.Updated,
+	}
+	for i, column := range fileColumns {
+		switch field := fields[column.Name].(type) {
+		case *int64:
+			v, ok := values[i].(int64)
+			if !ok {
+				return nil, fmt.Errorf("column %s is not an integer", column.Name)
+			}
+			*field = v
+		case *string:
+			v, ok := values[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("column %s is not a string", column.Name)
+			}
+			*field = v
+		}
+	}
+	return r, nil
+}
+
+// matches tells whether the annotation matches the query, like the filters of the queries of the database
+func (r *row) matches(query *annotations.ItemQuery) bool {
+	switch {
+	case query.AnnotationId != 0 && r.Id != query.AnnotationId,
+		query.AlertId != 0 && r.AlertId != query.AlertId,
+		query.DashboardId != 0 && r.DashboardId != query.DashboardId,
+		query.PanelId != 0 && r.PanelId != query.PanelId,
+		query.UserId != 0 && r.UserId != query.UserId,
+		query.From > 0 && query.To > 0 && (r.Epoch > query.To || r.EpochEnd < query.From),
+		query.Type == "alert" && r.AlertId <= 0,
+		query.Type == "annotation" && r.AlertId != 0:
+		return false
+	}
+
+	tags := models.ParseTagPairs(query.Tags)
+	if len(tags) == 0 {
+		return true
+	}
+	itemTags := models.ParseTagPairs(r.tags())
+	matched := 0
+	for _, tag := range tags {
+		for _, itemTag := range itemTags {
+			if itemTag.Key == tag.Key && (tag.Value == "" || itemTag.Value == tag.Value) {
+				matched++
+				break
+			}
+		}
+	}
+	if query.MatchAny {
+		return matched > 0
+	}
+	return matched == len(tags)
+}
+
+func (r *row) tags() []string {
+	var tags []string
+	if r.Tags != "" {
+		// the tags which can't be decoded are left out, like the database does
+		_ = json.Unmarshal([]byte(r.Tags), &tags)
+	}
+	return tags
+}
+
+func (r *row) toDTO() *annotations.ItemDTO {
+	data, err := simplejson.NewJson([]byte(r.Data))
+	if err != nil {
+		data = simplejson.New()
+	}
+	return &annotations.ItemDTO{
+		Id:          r.Id,
+		AlertId:     r.AlertId,
+		AlertName:   r.AlertName,
+		DashboardId: r.DashboardId,
+		PanelId:     r.PanelId,
+		UserId:      r.UserId,
+		NewState:    r.NewState,
+		PrevState:   r.PrevState,
+		Created:     r.Created,
+		Updated:     r.Updated,
+		Time:        r.Epoch,
+		TimeEnd:     r.EpochEnd,
+		Text:        r.Text,
+		Tags:        r.tags(),
+		Login:       r.Login,
+		Email:       r.Email,
+		Data:        data,
+	}
+}
+
+// sortItems sorts the annotations like the queries of the database, by end then start, the most recent first
+func sortItems(items []*annotations.ItemDTO) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].TimeEnd != items[j].TimeEnd {
+			return items[i].TimeEnd > items[j].TimeEnd
+		}
+		return items[i].Time > items[j].Time
+	})
+}
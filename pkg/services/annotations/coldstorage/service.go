@@ -0,0 +1,336 @@
+package coldstorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/parquet"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// KindAnnotation are the annotations of the dashboards and of the API
+	KindAnnotation = "annotation"
+	// KindAlert are the annotations of the alerts, their history of states
+	KindAlert = "alert"
+)
+
+// deleteChunkSize is the number of annotations deleted by a statement, which bounds its number of arguments
+const deleteChunkSize = 500
+
+var ErrDisabled = errors.New("annotation cold storage is disabled")
+
+// archive is an entry of the index of the archived files, which tells the queries which files may have matching
+// annotations
+type archive struct {
+	Id          int64
+	OrgId       int64
+	Kind        string
+	Name        string
+	RowCount    int64
+	MinId       int64
+	MaxId       int64
+	MinEpoch    int64
+	MaxEpochEnd int64
+	Created     time.Time
+}
+
+func (archive) TableName() string {
+	return "annotation_archive"
+}
+
+// Service moves the annotations and the alert history older than the configured ages from the database to Parquet
+// files, one per organization and batch, kept in the local or S3 storage. The archived annotations are still
+// returned by the queries of the annotations repository, which is replaced by one merging the rows of the database
+// and of the files. They can't be updated or deleted anymore.
+type Service struct {
+	cfg      setting.AnnotationColdStorageSettings
+	sqlStore *sqlstore.SQLStore
+	storage  fileStorage
+	log      log.Logger
+	now      func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:      cfg.AnnotationColdStorage,
+		sqlStore: sqlStore,
+		storage:  newFileStorage(cfg.AnnotationColdStorage),
+		log:      log.New("annotations.coldstorage"),
+		now:      time.Now,
+	}
+	if s.cfg.Enabled {
+		annotations.SetRepository(&Repository{Repository: annotations.GetRepository(), service: s})
+	}
+
+	// Only one Grafana instance moves the annotations when running in a cluster
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "annotation-cold-storage",
+		Description: "Moves the old annotations and alert history from the database to the cold storage.",
+		Cron:        "@hourly",
+		Enabled:     s.cfg.Enabled,
+		Exclusive:   true,
+		Run:         s.Archive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Archive moves the annotations and the alert history older than their configured ages to the cold storage
+func (s *Service) Archive(ctx context.Context) error {
+	if !s.cfg.Enabled {
+		return ErrDisabled
+	}
+	now := s.now()
+	for _, tier := range []struct {
+		kind      string
+		olderThan time.Duration
+	}{
+		{kind: KindAnnotation, olderThan: s.cfg.AnnotationsOlderThan},
+		{kind: KindAlert, olderThan: s.cfg.AlertHistoryOlderThan},
+	} {
+		if tier.olderThan == 0 {
+			continue
+		}
+		cutoff := now.Add(-tier.olderThan).UnixNano() / int64(time.Millisecond)
+		count, err := s.archiveKind(ctx, tier.kind, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s annotations: %w", tier.kind, err)
+		}
+		if count > 0 {
+			s.log.Info("Moved annotations to the cold storage", "kind", tier.kind, "count", count)
+		}
+	}
+	return nil
+}
+
+// archiveKind moves the annotations of the kind which ended before the cutoff, in milliseconds, by batches
+func (s *Service) archiveKind(ctx context.Context, kind string, cutoff int64) (int, error) {
+	var count int
+	for {
+		rows, err := s.selectRows(ctx, kind, cutoff)
+		if err != nil {
+			return count, err
+		}
+
+		// the rows are sorted by organization, each organization has files of its own
+		for start := 0; start < len(rows); {
+			end := start + 1
+			for end < len(rows) && rows[end].OrgId == rows[start].OrgId {
+				end++
+			}
+			if err := s.archiveRows(ctx, kind, rows[start:end]); err != nil {
+				return count, err
+			}
+			count += end - start
+			start = end
+		}
+
+		if len(rows) < s.cfg.BatchSize {
+			return count, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+	}
+}
+
+// selectRows returns the next batch of annotations of the kind which ended before the cutoff, sorted by organization
+// and id
+func (s *Service) selectRows(ctx context.Context, kind string, cutoff int64) ([]*row, error) {
+	condition := "annotation.alert_id = 0"
+	if kind == KindAlert {
+		condition = "annotation.alert_id > 0"
+	}
+	sql := `
+		SELECT
+			annotation.id,
+			annotation.org_id,
+			annotation.alert_id,
+			alert.name AS alert_name,
+			annotation.dashboard_id,
+			annotation.panel_id,
+			annotation.user_id,
+			usr.login,
+			usr.email,
+			annotation.epoch,
+			annotation.epoch_end,
+			annotation.prev_state,
+			annotation.new_state,
+			annotation.text,
+			annotation.tags,
+			annotation.data,
+			annotation.created,
+			annotation.updated
+		FROM annotation
+		LEFT OUTER JOIN ` + s.sqlStore.Dialect.Quote("user") + ` AS usr ON usr.id = annotation.user_id
+		LEFT OUTER JOIN alert ON alert.id = annotation.alert_id
+		WHERE annotation.epoch_end < ? AND ` + condition + `
+		ORDER BY annotation.org_id, annotation.id` + s.sqlStore.Dialect.Limit(int64(s.cfg.BatchSize))
+
+	rows := make([]*row, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(sql, cutoff).Find(&rows)
+	})
+	return rows, err
+}
+
+// archiveRows writes the annotations of an organization to a file, then adds it to the index and deletes the
+// annotations from the database. A file written again after a failure replaces the previous one, its name is given
+// by the ids of its annotations.
+func (s *Service) archiveRows(ctx context.Context, kind string, rows []*row) error {
+	a := &archive{
+		OrgId:       rows[0].OrgId,
+		Kind:        kind,
+		Name:        fmt.Sprintf("org-%d/%s-%d-%d.parquet", rows[0].OrgId, kind, rows[0].Id, rows[len(rows)-1].Id),
+		RowCount:    int64(len(rows)),
+		MinId:       rows[0].Id,
+		MaxId:       rows[len(rows)-1].Id,
+		MinEpoch:    rows[0].Epoch,
+		MaxEpochEnd: rows[0].EpochEnd,
+		Created:     s.now(),
+	}
+	for _, r := range rows {
+		if r.Epoch < a.MinEpoch {
+			a.MinEpoch = r.Epoch
+		}
+		if r.EpochEnd > a.MaxEpochEnd {
+			a.MaxEpochEnd = r.EpochEnd
+		}
+	}
+
+	data, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.Put(ctx, a.Name, data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", a.Name, err)
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Insert(a); err != nil {
+			return err
+		}
+		for start := 0; start < len(rows); start += deleteChunkSize {
+			end := start + deleteChunkSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			ids := make([]interface{}, 0, end-start)
+			for _, r := range rows[start:end] {
+				ids = append(ids, r.Id)
+			}
+			in := "(?" + strings.Repeat(",?", len(ids)-1) + ")"
+			if _, err := sess.Exec(append([]interface{}{"DELETE FROM annotation_tag WHERE annotation_id IN " + in}, ids...)...); err != nil {
+				return err
+			}
+			if _, err := sess.Exec(append([]interface{}{"DELETE FROM annotation WHERE id IN " + in}, ids...)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findArchives returns the archived files of the organization of the query which may have matching annotations,
+// the most recent first
+func (s *Service) findArchives(ctx context.Context, query *annotations.ItemQuery) ([]*archive, error) {
+	archives := make([]*archive, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sess.Where("org_id = ?", query.OrgId)
+		if query.Type == "alert" || query.AlertId != 0 {
+			sess.And("kind = ?", KindAlert)
+		} else if query.Type == "annotation" {
+			sess.And("kind = ?", KindAnnotation)
+		}
+		if query.From > 0 && query.To > 0 {
+			sess.And("min_epoch <= ? AND max_epoch_end >= ?", query.To, query.From)
+		}
+		if query.AnnotationId != 0 {
+			sess.And("min_id <= ? AND max_id >= ?", query.AnnotationId, query.AnnotationId)
+		}
+		return sess.Desc("max_epoch_end").Find(&archives)
+	})
+	return archives, err
+}
+
+// findArchived returns the archived annotations matching the query, sorted like the ones of the database. The files
+// are read from the most recent, until the next ones can't have annotations within the limit.
+func (s *Service) findArchived(ctx context.Context, query *annotations.ItemQuery, archives []*archive) ([]*annotations.ItemDTO, error) {
+	items := make([]*annotations.ItemDTO, 0)
+	for _, a := range archives {
+		if query.Limit > 0 && int64(len(items)) >= query.Limit {
+			sortItems(items)
+			items = items[:query.Limit]
+			if items[len(items)-1].TimeEnd > a.MaxEpochEnd {
+				break
+			}
+		}
+
+		data, err := s.storage.Get(ctx, a.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", a.Name, err)
+		}
+		rows, err := decodeRows(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", a.Name, err)
+		}
+		for _, r := range rows {
+			if r.matches(query) {
+				items = append(items, r.toDTO())
+			}
+		}
+	}
+
+	sortItems(items)
+	if query.Limit > 0 && int64(len(items)) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+// encodeRows writes the annotations to a Parquet file
+func encodeRows(rows []*row) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if err := w.Write(r.values()...); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeRows reads the annotations of a Parquet file
+func decodeRows(data []byte) ([]*row, error) {
+	r, err := parquet.NewReader(data)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*row, 0, r.NumRows())
+	err = r.Read(func(values []interface{}) error {
+		row, err := rowFromValues(r.Columns(), values)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
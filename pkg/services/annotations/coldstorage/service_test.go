@@ -0,0 +1,130 @@
+package coldstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	sql := sqlstore.InitTestDB(t)
+	hot := &sqlstore.SQLAnnotationRepo{}
+	now := time.Now()
+	old := now.Add(-48*time.Hour).UnixNano() / int64(time.Millisecond)
+
+	items := []*annotations.Item{
+		{OrgId: 1, Text: "outage", Epoch: old, EpochEnd: old + 1000, Tags: []string{"outage", "env:prod"}},
+		{OrgId: 1, Text: "deploy", Epoch: old + 10, Tags: []string{"deploy", "env:dev"}},
+		{OrgId: 1, Text: "alerting", AlertId: 5, NewState: "alerting", Epoch: old + 20},
+		{OrgId: 2, Text: "other org", Epoch: old},
+		{OrgId: 1, Text: "recent", Epoch: now.UnixNano() / int64(time.Millisecond), Tags: []string{"env:prod"}},
+	}
+	for _, item := range items {
+		require.NoError(t, hot.Save(item))
+	}
+
+	s := &Service{
+		cfg: setting.AnnotationColdStorageSettings{
+			Enabled:               true,
+			AnnotationsOlderThan:  24 * time.Hour,
+			AlertHistoryOlderThan: 24 * time.Hour,
+			BatchSize:             2,
+		},
+		sqlStore: sql,
+		storage:  &localStorage{dir: t.TempDir()},
+		log:      log.New("test"),
+		now:      func() time.Time { return now },
+	}
+	repo := &Repository{Repository: hot, service: s}
+
+	require.NoError(t, s.Archive(ctx))
+
+	t.Run("should move the old annotations out of the database", func(t *testing.T) {
+		remaining, err := hot.Find(&annotations.ItemQuery{OrgId: 1})
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+		assert.Equal(t, "recent", remaining[0].Text)
+
+		var tags int64
+		require.NoError(t, sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.SQL("SELECT COUNT(*) FROM annotation_tag WHERE annotation_id = ?", items[0].Id).Get(&tags)
+			return err
+		}))
+		assert.Zero(t, tags)
+
+		archives, err := s.findArchives(ctx, &annotations.ItemQuery{OrgId: 1})
+		require.NoError(t, err)
+		require.Len(t, archives, 2)
+		assert.Equal(t, KindAnnotation, archives[0].Kind)
+		assert.Equal(t, int64(2), archives[0].RowCount)
+		assert.Equal(t, KindAlert, archives[1].Kind)
+	})
+
+	t.Run("should find the archived annotations", func(t *testing.T) {
+		found, err := repo.Find(&annotations.ItemQuery{OrgId: 1})
+		require.NoError(t, err)
+		texts := make([]string, 0, len(found))
+		for _, item := range found {
+			texts = append(texts, item.Text)
+		}
+		assert.Equal(t, []string{"recent", "outage", "alerting", "deploy"}, texts)
+		assert.Equal(t, []string{"outage", "env:prod"}, found[1].Tags)
+		assert.Equal(t, items[0].Id, found[1].Id)
+	})
+
+	t.Run("should filter the archived annotations", func(t *testing.T) {
+		found, err := repo.Find(&annotations.ItemQuery{OrgId: 1, Tags: []string{"env:prod"}})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "outage", found[1].Text)
+
+		found, err = repo.Find(&annotations.ItemQuery{OrgId: 1, Tags: []string{"outage", "deploy"}, MatchAny: true})
+		require.NoError(t, err)
+		assert.Len(t, found, 2)
+
+		found, err = repo.Find(&annotations.ItemQuery{OrgId: 1, Type: "alert"})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "alerting", found[0].NewState)
+
+		found, err = repo.Find(&annotations.ItemQuery{OrgId: 1, From: old + 5, To: old + 15})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+
+		found, err = repo.Find(&annotations.ItemQuery{OrgId: 2})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "other org", found[0].Text)
+	})
+
+	t.Run("should limit the annotations", func(t *testing.T) {
+		found, err := repo.Find(&annotations.ItemQuery{OrgId: 1, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "outage", found[1].Text)
+	})
+
+	t.Run("should stream the archived annotations after the others", func(t *testing.T) {
+		var texts []string
+		require.NoError(t, repo.Stream(ctx, &annotations.ItemQuery{OrgId: 1}, func(item *annotations.ItemDTO) error {
+			texts = append(texts, item.Text)
+			return nil
+		}))
+		assert.Equal(t, []string{"recent", "outage", "alerting", "deploy"}, texts)
+	})
+
+	t.Run("should not archive when disabled", func(t *testing.T) {
+		disabled := *s
+		disabled.cfg.Enabled = false
+		require.ErrorIs(t, disabled.Archive(ctx), ErrDisabled)
+	})
+}
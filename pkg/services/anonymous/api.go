@@ -0,0 +1,72 @@
+package anonymous
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/admin/anonymous-access/orgs/:orgId", func(anonymousRoute routing.RouteRegister) {
+		anonymousRoute.Get("/", routing.Wrap(s.getRestrictionsHandler))
+		anonymousRoute.Put("/", routing.Wrap(s.setRestrictionsHandler))
+		anonymousRoute.Delete("/", routing.Wrap(s.resetRestrictionsHandler))
+	}, middleware.ReqGrafanaAdmin)
+}
+
+// GET /api/admin/anonymous-access/orgs/:orgId
+func (s *Service) getRestrictionsHandler(c *models.ReqContext) response.Response {
+	orgID, err := strconv.ParseInt(web.Params(c.Req)[":orgId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
+	}
+
+	restrictions, err := s.GetRestrictions(c.Req.Context(), orgID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get anonymous access restrictions", err)
+	}
+	return response.JSON(http.StatusOK, restrictions)
+}
+
+// PUT /api/admin/anonymous-access/orgs/:orgId
+func (s *Service) setRestrictionsHandler(c *models.ReqContext) response.Response {
+	orgID, err := strconv.ParseInt(web.Params(c.Req)[":orgId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
+	}
+	restrictions := Restrictions{}
+	if err := web.Bind(c.Req, &restrictions); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	result, err := s.SetRestrictions(c.Req.Context(), orgID, restrictions)
+	switch {
+	case errors.Is(err, ErrInvalidPath):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, models.ErrOrgNotFound):
+		return response.Error(http.StatusNotFound, "Organization not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to set anonymous access restrictions", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// DELETE /api/admin/anonymous-access/orgs/:orgId
+func (s *Service) resetRestrictionsHandler(c *models.ReqContext) response.Response {
+	orgID, err := strconv.ParseInt(web.Params(c.Req)[":orgId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
+	}
+
+	if err := s.ResetRestrictions(c.Req.Context(), orgID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reset anonymous access restrictions", err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"message": "Anonymous access restrictions reset"})
+}
@@ -0,0 +1,291 @@
+package anonymous
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+// queryPaths are the endpoints the dashboards query their data sources with, anonymous users can only query the data
+// sources of the dashboards they are allowed to view through them
+var queryPaths = []string{
+	"/api/ds/query",
+	"/api/tsdb/query",
+}
+
+// proxyPath matches the data source proxy calls, anonymous users can only call the data sources of the dashboards
+// they are allowed to view through it
+var proxyPath = regexp.MustCompile(`^/api/datasources/proxy/([^/]+)(?:/|$)`)
+
+// maxQueryBodySize is the size of the largest query request of anonymous users, the larger ones are refused
+const maxQueryBodySize = 10 << 20
+
+// dataSources are the data sources anonymous users can query in an organization
+type dataSources struct {
+	uids map[string]bool
+	ids  map[int64]bool
+}
+
+// isQueryAllowed tells whether all the queries of the request go to the data sources of the allowed dashboards. The
+// body of the request is restored for the handler.
+func (s *Service) isQueryAllowed(req *http.Request, orgID int64, restrictions Restrictions) (bool, error) {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return false, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxQueryBodySize+1))
+	if err != nil {
+		return false, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) > maxQueryBodySize {
+		return false, nil
+	}
+
+	request, err := simplejson.NewJson(body)
+	if err != nil {
+		// The handler rejects the invalid requests
+		return true, nil
+	}
+	// The environments send the queries to other data sources than the ones of the dashboards
+	if request.Get("environment").MustString() != "" {
+		return false, nil
+	}
+
+	allowed, err := s.cachedDataSources(req.Context(), orgID, restrictions)
+	if err != nil {
+		return false, err
+	}
+	for _, q := range request.Get("queries").MustArray() {
+		query := simplejson.NewFromAny(q)
+		uid := query.Get("datasource").Get("uid").MustString()
+		if uid == "" {
+			uid = query.Get("datasource").MustString()
+		}
+		if expr.IsDataSource(uid) {
+			continue
+		}
+		if id := query.Get("datasourceId").MustInt64(0); id > 0 && uid != grafanads.DatasourceUID {
+			if !allowed.ids[id] {
+				return false, nil
+			}
+			continue
+		}
+		if uid == "" || !allowed.uids[uid] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isProxyAllowed tells whether the data source called through the proxy is one of the data sources of the allowed
+// dashboards
+func (s *Service) isProxyAllowed(req *http.Request, orgID int64, dataSourceID string, restrictions Restrictions) (bool, error) {
+	id, err := strconv.ParseInt(dataSourceID, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	allowed, err := s.cachedDataSources(req.Context(), orgID, restrictions)
+	if err != nil {
+		return false, err
+	}
+	return allowed.ids[id], nil
+}
+
+func (s *Service) cachedDataSources(ctx context.Context, orgID int64, restrictions Restrictions) (*dataSources, error) {
+	key := dataSourcesCacheKey(orgID)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*dataSources), nil
+	}
+
+	allowed, err := s.dashboardDataSources(ctx, orgID, restrictions)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, allowed, 0)
+	return allowed, nil
+}
+
+// dashboardDataSources returns the data sources referenced by the allowed dashboards and by the dashboards of the
+// allowed folders, including their library panels. The data sources set by a data source variable can be any data
+// source of the type of the variable.
+func (s *Service) dashboardDataSources(ctx context.Context, orgID int64, restrictions Restrictions) (*dataSources, error) {
+	allowed := &dataSources{uids: map[string]bool{}, ids: map[int64]bool{}}
+	if len(restrictions.Dashboards)+len(restrictions.Folders) == 0 {
+		return allowed, nil
+	}
+
+	dashboards := make([]*models.Dashboard, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if len(restrictions.Dashboards) > 0 {
+			if err := sess.Where("org_id = ? AND is_folder = ?", orgID, s.sqlStore.Dialect.BooleanStr(false)).
+				In("uid", restrictions.Dashboards).Find(&dashboards); err != nil {
+				return err
+			}
+		}
+		if len(restrictions.Folders) == 0 {
+			return nil
+		}
+
+		folderIDs := make([]int64, 0)
+		if err := sess.Table("dashboard").Cols("id").Where("org_id = ? AND is_folder = ?", orgID, s.sqlStore.Dialect.BooleanStr(true)).
+			In("uid", restrictions.Folders).Find(&folderIDs); err != nil {
+			return err
+		}
+		if len(folderIDs) == 0 {
+			return nil
+		}
+		inFolders := make([]*models.Dashboard, 0)
+		if err := sess.Where("org_id = ? AND is_folder = ?", orgID, s.sqlStore.Dialect.BooleanStr(false)).
+			In("folder_id", folderIDs).Find(&inFolders); err != nil {
+			return err
+		}
+		dashboards = append(dashboards, inFolders...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &dataSourceRefs{names: map[string]bool{}, types: map[string]bool{}}
+	for _, dash := range dashboards {
+		if err := s.collectDataSourceRefs(ctx, orgID, dash.Data, refs); err != nil {
+			return nil, err
+		}
+	}
+
+	query := &models.GetDataSourcesQuery{OrgId: orgID}
+	if err := s.sqlStore.GetDataSources(ctx, query); err != nil {
+		return nil, err
+	}
+	for _, ds := range query.Result {
+		if refs.names[ds.Uid] || refs.names[ds.Name] || refs.types[ds.Type] || (refs.defaultDataSource && ds.IsDefault) {
+			allowed.uids[ds.Uid] = true
+			allowed.ids[ds.Id] = true
+		}
+	}
+	if refs.names[grafanads.DatasourceUID] || refs.names[grafanads.DatasourceName] {
+		allowed.uids[grafanads.DatasourceUID] = true
+	}
+	return allowed, nil
+}
+
+// dataSourceRefs are the data sources referenced by dashboards, by uid or name, and the types of their data source
+// variables
+type dataSourceRefs struct {
+	names             map[string]bool
+	types             map[string]bool
+	defaultDataSource bool
+}
+
+// collectDataSourceRefs adds the data sources referenced by the panels, the queries, the annotations and the query
+// variables of the dashboard. The library panels are loaded to add the data sources of their models.
+func (s *Service) collectDataSourceRefs(ctx context.Context, orgID int64, data *simplejson.Json, refs *dataSourceRefs) error {
+	variables := data.GetPath("templating", "list").MustArray()
+	variableTypes := map[string]string{}
+	for _, v := range variables {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("type").MustString() == "datasource" {
+			variableTypes[variable.Get("name").MustString()] = variable.Get("query").MustString()
+		}
+	}
+	for _, v := range variables {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("type").MustString() == "query" {
+			refs.add(variable.Get("datasource"), variableTypes)
+		}
+	}
+	for _, a := range data.GetPath("annotations", "list").MustArray() {
+		annotation := simplejson.NewFromAny(a)
+		if _, ok := annotation.CheckGet("datasource"); ok {
+			refs.add(annotation.Get("datasource"), variableTypes)
+		}
+	}
+
+	var visitPanels func(panels []interface{}) error
+	visitPanels = func(panels []interface{}) error {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			if err := visitPanels(panel.Get("panels").MustArray()); err != nil {
+				return err
+			}
+			if panel.Get("type").MustString() == "row" {
+				continue
+			}
+			if uid := panel.GetPath("libraryPanel", "uid").MustString(); uid != "" {
+				model, err := s.libraryPanelModel(ctx, orgID, uid)
+				if err != nil {
+					return err
+				}
+				if model == nil {
+					continue
+				}
+				panel = model
+			}
+
+			refs.add(panel.Get("datasource"), variableTypes)
+			for _, t := range panel.Get("targets").MustArray() {
+				target := simplejson.NewFromAny(t)
+				if _, ok := target.CheckGet("datasource"); ok {
+					refs.add(target.Get("datasource"), variableTypes)
+				}
+			}
+		}
+		return nil
+	}
+	return visitPanels(data.Get("panels").MustArray())
+}
+
+// add adds the data source referenced by name, by uid or through a data source variable
+func (r *dataSourceRefs) add(datasource *simplejson.Json, variableTypes map[string]string) {
+	ref := datasource.Get("uid").MustString()
+	if name, err := datasource.String(); err == nil {
+		ref = name
+	}
+	switch {
+	case datasource.Interface() == nil || (ref == "" && datasource.Get("type").MustString() == ""):
+		r.defaultDataSource = true
+	case strings.HasPrefix(ref, "$"):
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(ref, "$"), "{"), "}")
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+		if dsType := variableTypes[name]; dsType != "" {
+			r.types[dsType] = true
+		}
+	case ref != "":
+		r.names[ref] = true
+	}
+}
+
+// libraryPanelModel returns the model of the library panel, or nil when it does not exist
+func (s *Service) libraryPanelModel(ctx context.Context, orgID int64, uid string) (*simplejson.Json, error) {
+	var element struct {
+		Model []byte
+	}
+	var has bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		has, err = sess.Table("library_element").Cols("model").Where("org_id = ? AND uid = ?", orgID, uid).Get(&element)
+		return err
+	})
+	if err != nil || !has {
+		return nil, err
+	}
+	return simplejson.NewJson(element.Model)
+}
+
+func dataSourcesCacheKey(orgID int64) string {
+	return fmt.Sprintf("%d-datasources", orgID)
+}
@@ -0,0 +1,30 @@
+package anonymous
+
+import "errors"
+
+var ErrInvalidPath = errors.New("allowed paths must start with /")
+
+// Restrictions limit anonymous access to some dashboards, folders and URL prefixes of an organization. Anonymous
+// users can access the whole organization, with the configured role, when there are no restrictions.
+type Restrictions struct {
+	// Dashboards are the UIDs of the dashboards anonymous users can view
+	Dashboards []string `json:"dashboards"`
+	// Folders are the UIDs of the folders anonymous users can view, along with the dashboards they contain
+	Folders []string `json:"folders"`
+	// Paths are the URL prefixes anonymous users can access, such as /api/health
+	Paths []string `json:"paths"`
+}
+
+// Restricted tells whether anonymous access is limited to some dashboards, folders or URL prefixes
+func (r Restrictions) Restricted() bool {
+	return len(r.Dashboards)+len(r.Folders)+len(r.Paths) > 0
+}
+
+// OrgRestrictions are the restrictions of anonymous access to an organization
+type OrgRestrictions struct {
+	OrgID int64 `json:"orgId"`
+	Restrictions
+	// FromSettings is true when the restrictions are the ones of the [auth.anonymous] section, the organization has
+	// no restrictions set through the API
+	FromSettings bool `json:"fromSettings"`
+}
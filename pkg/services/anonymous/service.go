@@ -0,0 +1,318 @@
+package anonymous
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	kvNamespace = "anonymous_access"
+	kvKey       = "restrictions"
+
+	// cacheTTL is how long the restrictions and the folders of the dashboards are cached, the changes made through the
+	// other Grafana instances of a cluster apply after it
+	cacheTTL = time.Minute
+)
+
+var (
+	dashboardPath = regexp.MustCompile(`^/(?:d|d-solo|api/dashboards/uid)/([^/]+)`)
+	folderPath    = regexp.MustCompile(`^/(?:dashboards/f|api/folders)/([^/]+)`)
+)
+
+// alwaysAllowedPaths are the static files and the endpoints the frontend needs to load, anonymous users can access
+// them whatever the restrictions. The data sources are queried through the queryPaths and the proxyPath. Grafana Live
+// is not allowed, the channels it streams are not checked against the allowed dashboards.
+var alwaysAllowedPaths = []string{
+	"/public/",
+	"/api/frontend/settings",
+	"/api/frontend-metrics",
+	"/avatar/",
+}
+
+// annotationsPath is the endpoint the dashboards list their annotations with, anonymous users can only list the
+// annotations of the dashboards they are allowed to view through it
+const annotationsPath = "/api/annotations"
+
+// Service restricts anonymous access to some dashboards, folders or URL prefixes of the organizations. The
+// restrictions of the [auth.anonymous] section apply to the organizations without restrictions set through the
+// /api/admin/anonymous-access endpoints, and are enforced by the context handler.
+type Service struct {
+	cfg      *setting.Cfg
+	sqlStore *sqlstore.SQLStore
+	kv       kvstore.KVStore
+	cache    *localcache.CacheService
+	log      log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sqlStore,
+		kv:       kvStore,
+		cache:    localcache.New(cacheTTL, 2*cacheTTL),
+		log:      log.New("anonymous"),
+	}
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// GetRestrictions returns the restrictions of anonymous access to the organization
+func (s *Service) GetRestrictions(ctx context.Context, orgID int64) (*OrgRestrictions, error) {
+	value, ok, err := s.kv.Get(ctx, orgID, kvNamespace, kvKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &OrgRestrictions{
+			OrgID: orgID,
+			Restrictions: Restrictions{
+				Dashboards: nonNil(s.cfg.AnonymousAllowedDashboards),
+				Folders:    nonNil(s.cfg.AnonymousAllowedFolders),
+				Paths:      nonNil(s.cfg.AnonymousAllowedPaths),
+			},
+			FromSettings: true,
+		}, nil
+	}
+
+	restrictions := &OrgRestrictions{OrgID: orgID}
+	if err := json.Unmarshal([]byte(value), &restrictions.Restrictions); err != nil {
+		return nil, fmt.Errorf("invalid anonymous access restrictions of organization %d: %w", orgID, err)
+	}
+	return restrictions, nil
+}
+
+// SetRestrictions replaces the restrictions of anonymous access to the organization. Empty restrictions give access
+// to the whole organization, regardless of the [auth.anonymous] section.
+func (s *Service) SetRestrictions(ctx context.Context, orgID int64, restrictions Restrictions) (*OrgRestrictions, error) {
+	restrictions = Restrictions{
+		Dashboards: clean(restrictions.Dashboards),
+		Folders:    clean(restrictions.Folders),
+		Paths:      clean(restrictions.Paths),
+	}
+	for _, p := range restrictions.Paths {
+		if !strings.HasPrefix(p, "/") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, p)
+		}
+	}
+	if err := bus.Dispatch(ctx, &models.GetOrgByIdQuery{Id: orgID}); err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(restrictions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.kv.Set(ctx, orgID, kvNamespace, kvKey, string(value)); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(restrictionsCacheKey(orgID))
+	s.cache.Delete(dataSourcesCacheKey(orgID))
+	return &OrgRestrictions{OrgID: orgID, Restrictions: restrictions}, nil
+}
+
+// ResetRestrictions removes the restrictions set for the organization, the ones of the [auth.anonymous] section
+// apply again.
+func (s *Service) ResetRestrictions(ctx context.Context, orgID int64) error {
+	if err := s.kv.Del(ctx, orgID, kvNamespace, kvKey); err != nil {
+		return err
+	}
+	s.cache.Delete(restrictionsCacheKey(orgID))
+	s.cache.Delete(dataSourcesCacheKey(orgID))
+	return nil
+}
+
+// IsAllowed tells whether anonymous users can make the request to the path, relative to the root URL, in the
+// organization. The queries are only allowed for the data sources of the dashboards anonymous users can view.
+func (s *Service) IsAllowed(req *http.Request, orgID int64, path string) (bool, error) {
+	ctx := req.Context()
+	restrictions, err := s.cachedRestrictions(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	if !restrictions.Restricted() {
+		return true, nil
+	}
+
+	if hasPathPrefix(path, alwaysAllowedPaths) || hasPathPrefix(path, restrictions.Paths) {
+		return true, nil
+	}
+	if hasPathPrefix(path, queryPaths) {
+		return s.isQueryAllowed(req, orgID, restrictions)
+	}
+	if m := proxyPath.FindStringSubmatch(path); m != nil {
+		return s.isProxyAllowed(req, orgID, m[1], restrictions)
+	}
+	if path == annotationsPath {
+		return s.isAnnotationsAllowed(req, orgID, restrictions)
+	}
+	if m := folderPath.FindStringSubmatch(path); m != nil {
+		return contains(restrictions.Folders, m[1]), nil
+	}
+	if m := dashboardPath.FindStringSubmatch(path); m != nil {
+		return s.isDashboardAllowed(orgID, m[1], restrictions)
+	}
+	return false, nil
+}
+
+// isDashboardAllowed tells whether the dashboard is one of the allowed dashboards or in one of the allowed folders
+func (s *Service) isDashboardAllowed(orgID int64, dashboardUID string, restrictions Restrictions) (bool, error) {
+	if contains(restrictions.Dashboards, dashboardUID) {
+		return true, nil
+	}
+	if len(restrictions.Folders) == 0 {
+		return false, nil
+	}
+	folderUID, err := s.dashboardFolder(orgID, dashboardUID)
+	if err != nil {
+		return false, err
+	}
+	return folderUID != "" && contains(restrictions.Folders, folderUID), nil
+}
+
+// isAnnotationsAllowed tells whether the request lists the annotations of an allowed dashboard. The annotations of
+// the whole organization, like the ones matched by tags only, are refused.
+func (s *Service) isAnnotationsAllowed(req *http.Request, orgID int64, restrictions Restrictions) (bool, error) {
+	if req.Method != http.MethodGet {
+		return false, nil
+	}
+	query := req.URL.Query()
+	if _, ok := query["calendarUid"]; ok {
+		return false, nil
+	}
+	dashboardID, err := strconv.ParseInt(query.Get("dashboardId"), 10, 64)
+	if err != nil || dashboardID <= 0 {
+		return false, nil
+	}
+
+	dashboardUID, err := s.dashboardUID(orgID, dashboardID)
+	if err != nil || dashboardUID == "" {
+		return false, err
+	}
+	return s.isDashboardAllowed(orgID, dashboardUID, restrictions)
+}
+
+func (s *Service) cachedRestrictions(ctx context.Context, orgID int64) (Restrictions, error) {
+	key := restrictionsCacheKey(orgID)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(Restrictions), nil
+	}
+
+	restrictions, err := s.GetRestrictions(ctx, orgID)
+	if err != nil {
+		return Restrictions{}, err
+	}
+	s.cache.Set(key, restrictions.Restrictions, 0)
+	return restrictions.Restrictions, nil
+}
+
+// dashboardFolder returns the UID of the folder of the dashboard, empty for the dashboards of the General folder and
+// the unknown dashboards
+func (s *Service) dashboardFolder(orgID int64, dashboardUID string) (string, error) {
+	key := fmt.Sprintf("%d-folder-%s", orgID, dashboardUID)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(string), nil
+	}
+
+	var folderUID string
+	dashboard, err := s.sqlStore.GetDashboard(0, orgID, dashboardUID, "")
+	switch {
+	case errors.Is(err, models.ErrDashboardNotFound):
+	case err != nil:
+		return "", err
+	case dashboard.FolderId != 0:
+		folder, err := s.sqlStore.GetDashboard(dashboard.FolderId, orgID, "", "")
+		if err != nil && !errors.Is(err, models.ErrDashboardNotFound) {
+			return "", err
+		}
+		if err == nil {
+			folderUID = folder.Uid
+		}
+	}
+	s.cache.Set(key, folderUID, 0)
+	return folderUID, nil
+}
+
+// dashboardUID returns the UID of the dashboard, empty for the unknown dashboards and the folders
+func (s *Service) dashboardUID(orgID int64, dashboardID int64) (string, error) {
+	key := fmt.Sprintf("%d-uid-%d", orgID, dashboardID)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(string), nil
+	}
+
+	var dashboardUID string
+	dashboard, err := s.sqlStore.GetDashboard(dashboardID, orgID, "", "")
+	switch {
+	case errors.Is(err, models.ErrDashboardNotFound):
+	case err != nil:
+		return "", err
+	case !dashboard.IsFolder:
+		dashboardUID = dashboard.Uid
+	}
+	s.cache.Set(key, dashboardUID, 0)
+	return dashboardUID, nil
+}
+
+func restrictionsCacheKey(orgID int64) string {
+	return fmt.Sprintf("%d-restrictions", orgID)
+}
+
+// hasPathPrefix tells whether the path is one of the prefixes or under one of them. The prefixes match whole
+// segments of the path, /api/health does not match /api/healthz. The prefixes ending with / match the paths under
+// them only.
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(path, p) {
+				return true
+			}
+			continue
+		}
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// clean trims the values and drops the empty ones
+func clean(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,285 @@
+package anonymous
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_IsAllowed(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	s := ProvideService(cfg, store, kvstore.ProvideService(store), routing.NewRouteRegister())
+	org, err := store.CreateOrgWithMember("Main Org.", 0)
+	require.NoError(t, err)
+	orgID := org.Id
+
+	folder, err := store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     orgID,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Public", "uid": "public"}),
+	})
+	require.NoError(t, err)
+	_, err = store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     orgID,
+		FolderId:  folder.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Status", "uid": "status"}),
+	})
+	require.NoError(t, err)
+	_, err = store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     orgID,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Internal", "uid": "internal"}),
+	})
+	require.NoError(t, err)
+
+	t.Run("should allow everything without restrictions", func(t *testing.T) {
+		allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, "/d/internal/internal", nil), orgID, "/d/internal/internal")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("should apply the restrictions of the settings", func(t *testing.T) {
+		cfg.AnonymousAllowedDashboards = []string{"internal"}
+		cfg.AnonymousAllowedPaths = []string{"/api/health", "/api/plugins/"}
+		s.cache.Flush()
+		t.Cleanup(func() {
+			cfg.AnonymousAllowedDashboards = nil
+			cfg.AnonymousAllowedPaths = nil
+			s.cache.Flush()
+		})
+
+		restrictions, err := s.GetRestrictions(ctx, orgID)
+		require.NoError(t, err)
+		assert.True(t, restrictions.FromSettings)
+
+		paths := map[string]bool{
+			"/d/internal/internal":          true,
+			"/d-solo/internal/internal":     true,
+			"/api/dashboards/uid/internal":  true,
+			"/api/health":                   true,
+			"/api/health/ready":             true,
+			"/api/plugins/text/settings":    true,
+			"/public/build/app.js":          true,
+			"/api/healthz":                  false,
+			"/api/plugins":                  false,
+			"/api/ds/query":                 false,
+			"/api/datasources/proxy/1/api":  false,
+			"/api/annotations":              false,
+			"/api/live/ws":                  false,
+			"/d/status/status":              false,
+			"/dashboards/f/public/public":   false,
+			"/":                             false,
+			"/api/search":                   false,
+			"/api/dashboards/uid/internals": false,
+		}
+		for path, expected := range paths {
+			allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, path, nil), orgID, path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, allowed, path)
+		}
+	})
+
+	t.Run("should apply the restrictions set for the organization", func(t *testing.T) {
+		_, err := s.SetRestrictions(ctx, orgID, Restrictions{Paths: []string{"api/health"}})
+		require.ErrorIs(t, err, ErrInvalidPath)
+		_, err = s.SetRestrictions(ctx, 1000, Restrictions{})
+		require.ErrorIs(t, err, models.ErrOrgNotFound)
+
+		restrictions, err := s.SetRestrictions(ctx, orgID, Restrictions{Folders: []string{"public", " "}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"public"}, restrictions.Folders)
+		assert.False(t, restrictions.FromSettings)
+
+		paths := map[string]bool{
+			"/dashboards/f/public/public": true,
+			"/api/folders/public":         true,
+			"/d/status/status":            true,
+			"/d/internal/internal":        false,
+			"/d/unknown/unknown":          false,
+		}
+		for path, expected := range paths {
+			allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, path, nil), orgID, path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, allowed, path)
+		}
+		allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, "/d/internal/internal", nil), orgID+1, "/d/internal/internal")
+		require.NoError(t, err)
+		assert.True(t, allowed, "the restrictions should only apply to the organization")
+
+		require.NoError(t, s.ResetRestrictions(ctx, orgID))
+		allowed, err = s.IsAllowed(httptest.NewRequest(http.MethodGet, "/d/internal/internal", nil), orgID, "/d/internal/internal")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+}
+
+func TestService_IsAllowed_Queries(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(setting.NewCfg(), store, kvstore.ProvideService(store), routing.NewRouteRegister())
+	org, err := store.CreateOrgWithMember("Main Org.", 0)
+	require.NoError(t, err)
+	orgID := org.Id
+
+	dataSources := map[string]int64{}
+	for _, ds := range []models.AddDataSourceCommand{
+		{Name: "Prometheus", Uid: "prom", Type: "prometheus", IsDefault: true},
+		{Name: "Loki", Uid: "loki", Type: "loki"},
+		{Name: "Tempo", Uid: "tempo", Type: "tempo"},
+		{Name: "Influx", Uid: "influx", Type: "influxdb"},
+		{Name: "Elastic", Uid: "elastic", Type: "elasticsearch"},
+		{Name: "Secrets", Uid: "secrets", Type: "postgres"},
+	} {
+		ds.OrgId = orgID
+		ds.Access = models.DS_ACCESS_PROXY
+		require.NoError(t, store.AddDataSource(ctx, &ds))
+		dataSources[ds.Uid] = ds.Result.Id
+	}
+
+	require.NoError(t, store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&libraryelements.LibraryElement{
+			OrgID: orgID, UID: "logs-panel", Name: "Logs", Kind: 1, Type: "logs",
+			Model:   []byte(`{"type": "logs", "datasource": {"uid": "elastic"}}`),
+			Created: time.Now(), Updated: time.Now(),
+		})
+		return err
+	}))
+
+	folder, err := store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     orgID,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Public", "uid": "public"}),
+	})
+	require.NoError(t, err)
+	dashboards := map[string]int64{}
+	for _, cmd := range []models.SaveDashboardCommand{
+		{FolderId: folder.Id, Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "Status", "uid": "status",
+			"panels": []interface{}{
+				map[string]interface{}{"type": "timeseries", "datasource": nil},
+				map[string]interface{}{"type": "row", "collapsed": true, "panels": []interface{}{
+					map[string]interface{}{"type": "logs", "datasource": "Loki"},
+				}},
+				map[string]interface{}{"libraryPanel": map[string]interface{}{"uid": "logs-panel"}},
+			},
+		})},
+		{Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "Traces", "uid": "traces",
+			"templating": map[string]interface{}{"list": []interface{}{
+				map[string]interface{}{"name": "ds", "type": "datasource", "query": "tempo"},
+			}},
+			"panels": []interface{}{
+				map[string]interface{}{"type": "traces", "datasource": "-- Mixed --", "targets": []interface{}{
+					map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "${ds}"}},
+					map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "-- Grafana --"}},
+				}},
+			},
+		})},
+		{Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "Internal", "uid": "internal",
+			"panels": []interface{}{map[string]interface{}{"type": "table", "datasource": map[string]interface{}{"uid": "secrets"}}},
+		})},
+	} {
+		cmd.OrgId = orgID
+		dash, err := store.SaveDashboard(cmd)
+		require.NoError(t, err)
+		dashboards[dash.Uid] = dash.Id
+	}
+
+	_, err = s.SetRestrictions(ctx, orgID, Restrictions{Dashboards: []string{"traces"}, Folders: []string{"public"}})
+	require.NoError(t, err)
+
+	query := func(t *testing.T, path, body string) bool {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		allowed, err := s.IsAllowed(req, orgID, path)
+		require.NoError(t, err)
+		restored, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(restored), "the body should be restored")
+		return allowed
+	}
+
+	t.Run("should allow the queries of the data sources of the allowed dashboards", func(t *testing.T) {
+		for _, uid := range []string{"prom", "loki", "elastic", "tempo", "grafana"} {
+			assert.True(t, query(t, "/api/ds/query", `{"queries": [{"refId": "A", "datasource": {"uid": "`+uid+`"}}]}`), uid)
+		}
+		assert.True(t, query(t, "/api/ds/query", `{"queries": [
+			{"refId": "A", "datasource": {"uid": "prom"}},
+			{"refId": "B", "datasource": {"uid": "__expr__"}, "expression": "$A"}
+		]}`))
+		assert.True(t, query(t, "/api/tsdb/query", fmt.Sprintf(`{"queries": [{"refId": "A", "datasourceId": %d}]}`, dataSources["loki"])))
+		assert.False(t, query(t, "/api/tsdb/query", fmt.Sprintf(`{"queries": [{"refId": "A", "datasourceId": %d}]}`, dataSources["secrets"])))
+	})
+
+	t.Run("should refuse the queries of the other data sources", func(t *testing.T) {
+		assert.False(t, query(t, "/api/ds/query", `{"queries": [{"refId": "A", "datasource": {"uid": "secrets"}}]}`))
+		assert.False(t, query(t, "/api/ds/query", `{"queries": [{"refId": "A", "datasource": {"uid": "influx"}}]}`))
+		assert.False(t, query(t, "/api/ds/query", `{"queries": [
+			{"refId": "A", "datasource": {"uid": "prom"}},
+			{"refId": "B", "datasource": {"uid": "secrets"}}
+		]}`))
+		assert.False(t, query(t, "/api/ds/query", `{"queries": [{"refId": "A", "datasource": {"uid": "prom"}}], "environment": "staging"}`))
+		assert.False(t, query(t, "/api/ds/query", `{"queries": [{"refId": "A"}]}`))
+
+		allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, "/api/ds/query", nil), orgID, "/api/ds/query")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("should only allow the data source proxy calls to the data sources of the allowed dashboards", func(t *testing.T) {
+		paths := map[string]bool{
+			fmt.Sprintf("/api/datasources/proxy/%d/api/v1/query", dataSources["prom"]):    true,
+			fmt.Sprintf("/api/datasources/proxy/%d", dataSources["loki"]):                 true,
+			fmt.Sprintf("/api/datasources/proxy/%d/api/v1/query", dataSources["secrets"]): false,
+			fmt.Sprintf("/api/datasources/proxy/%d/", dataSources["influx"]):              false,
+			"/api/datasources/proxy/prom/api/v1/query":                                    false,
+		}
+		for path, expected := range paths {
+			allowed, err := s.IsAllowed(httptest.NewRequest(http.MethodGet, path, nil), orgID, path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, allowed, path)
+		}
+	})
+
+	t.Run("should only allow listing the annotations of the allowed dashboards", func(t *testing.T) {
+		urls := map[string]bool{
+			fmt.Sprintf("/api/annotations?dashboardId=%d&from=1&to=2", dashboards["status"]):      true,
+			fmt.Sprintf("/api/annotations?dashboardId=%d", dashboards["traces"]):                  true,
+			fmt.Sprintf("/api/annotations?dashboardId=%d", dashboards["internal"]):                false,
+			fmt.Sprintf("/api/annotations?dashboardId=%d", folder.Id):                             false,
+			fmt.Sprintf("/api/annotations?dashboardId=%d&calendarUid=team", dashboards["status"]): false,
+			"/api/annotations?tags=deploy":                                                        false,
+			"/api/annotations?dashboardId=1000":                                                   false,
+		}
+		for url, expected := range urls {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			allowed, err := s.IsAllowed(req, orgID, req.URL.Path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, allowed, url)
+		}
+
+		path := fmt.Sprintf("/api/annotations?dashboardId=%d", dashboards["status"])
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"text": "deploy"}`))
+		allowed, err := s.IsAllowed(req, orgID, req.URL.Path)
+		require.NoError(t, err)
+		assert.False(t, allowed, "annotations should not be created")
+	})
+}
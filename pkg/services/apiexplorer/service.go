@@ -0,0 +1,254 @@
+package apiexplorer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// TokenHeader is the header of the requests sent by the explorer, the context handler signs in the user the token
+// was issued for
+const TokenHeader = "X-Grafana-API-Explorer-Token"
+
+// alertingSpec is the name of the specification of the alerting API, which is always explored
+const alertingSpec = "alerting"
+
+var (
+	ErrDisabled          = errors.New("the API explorer is disabled")
+	ErrOperationNotFound = errors.New("no operation of the OpenAPI specifications matches the request")
+	ErrPathNotAllowed    = errors.New("the request path is not allowed")
+	ErrBodyTooLarge      = errors.New("the request body is too large")
+	ErrAPIKeyNotAllowed  = errors.New("the API explorer cannot be used with API keys or tokens")
+)
+
+// Service lists the operations of the OpenAPI specifications of the HTTP API and sends them to the instance on
+// behalf of the signed in user. The requests are served by the HTTP server in process, authenticated with a token
+// which is only valid for the duration of the request and grants the permissions of the user, so the explorer
+// cannot be used to do more than the user could do with their session.
+type Service struct {
+	cfg        *setting.Cfg
+	specs      []*Spec
+	operations []*Operation
+	tokens     *tokenStore
+	log        log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg) (*Service, error) {
+	s := &Service{
+		cfg:    cfg,
+		tokens: newTokenStore(),
+		log:    log.New("apiexplorer"),
+	}
+	if !cfg.APIExplorer.Enabled {
+		return s, nil
+	}
+
+	if err := s.addSpec(alertingSpec, tooling.Spec); err != nil {
+		return nil, err
+	}
+	for _, path := range cfg.APIExplorer.SpecPaths {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path comes from the configuration
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OpenAPI specification: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := s.addSpec(name, raw); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Service) addSpec(name string, raw []byte) error {
+	for _, spec := range s.specs {
+		if spec.Name == name {
+			return fmt.Errorf("duplicate OpenAPI specification %s", name)
+		}
+	}
+	spec, operations, err := parseSpec(name, raw)
+	if err != nil {
+		return err
+	}
+	s.specs = append(s.specs, spec)
+	s.operations = append(s.operations, operations...)
+	return nil
+}
+
+// IsEnabled returns true when the API explorer is enabled in the configuration.
+func (s *Service) IsEnabled() bool {
+	return s.cfg.APIExplorer.Enabled
+}
+
+// Specs returns the explored OpenAPI specifications.
+func (s *Service) Specs() []*Spec {
+	return s.specs
+}
+
+// Spec returns the OpenAPI specification with the name, or nil.
+func (s *Service) Spec(name string) *Spec {
+	for _, spec := range s.specs {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+// Operations returns the operations of the explored OpenAPI specifications, sorted by path.
+func (s *Service) Operations() []*Operation {
+	return s.operations
+}
+
+// FindOperation returns the operation of the request, the operation whose path template has the most literal
+// segments when several ones match.
+func (s *Service) FindOperation(method, path string) *Operation {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var found *Operation
+	best := -1
+	for _, op := range s.operations {
+		if literals := op.matches(strings.ToUpper(method), segments); literals > best {
+			found, best = op, literals
+		}
+	}
+	return found
+}
+
+// ValidateToken returns the user and the organization the token was issued for, or false when the token is unknown
+// or expired.
+func (s *Service) ValidateToken(token string) (userID int64, orgID int64, ok bool) {
+	return s.tokens.validate(token)
+}
+
+// ExecuteCommand is a request sent by the explorer. The path may have a query.
+type ExecuteCommand struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// ExecuteResult is the response to a request sent by the explorer, its body is cut at the maximum body size.
+type ExecuteResult struct {
+	OperationId string      `json:"operationId"`
+	Status      int         `json:"status"`
+	Headers     http.Header `json:"headers"`
+	Body        string      `json:"body"`
+	Truncated   bool        `json:"truncated"`
+	DurationMs  int64       `json:"durationMs"`
+}
+
+// Execute sends the request to the handler on behalf of the user and returns the response. Only the operations of
+// the explored specifications can be sent.
+func (s *Service) Execute(ctx context.Context, handler http.Handler, user *models.SignedInUser, cmd *ExecuteCommand) (*ExecuteResult, error) {
+	if !s.IsEnabled() {
+		return nil, ErrDisabled
+	}
+	// The tokens of the explorer sign in the user with their own permissions, which would escalate the scoped API keys
+	// and tokens to the full permissions of their owner
+	if user.ApiKeyId != 0 {
+		return nil, ErrAPIKeyNotAllowed
+	}
+
+	method := strings.ToUpper(cmd.Method)
+	target, err := validatePath(cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+	op := s.FindOperation(method, target.Path)
+	if op == nil {
+		return nil, ErrOperationNotFound
+	}
+	if int64(len(cmd.Body)) > s.cfg.APIExplorer.MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	token, err := s.tokens.issue(user.UserId, user.OrgId, s.cfg.APIExplorer.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer s.tokens.revoke(token)
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.APIExplorer.Timeout)
+	defer cancel()
+
+	if s.cfg.ServeFromSubPath && s.cfg.AppSubURL != "" {
+		target.Path = s.cfg.AppSubURL + target.Path
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(cmd.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+	req.Header.Set(TokenHeader, token)
+	req.Header.Set("X-Grafana-Org-Id", fmt.Sprint(user.OrgId))
+	req.Header.Set("Accept", "application/json")
+	if len(cmd.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := &limitedRecorder{ResponseRecorder: httptest.NewRecorder(), limit: s.cfg.APIExplorer.MaxBodySize}
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+
+	return &ExecuteResult{
+		OperationId: op.Id,
+		Status:      rec.Code,
+		Headers:     rec.Header(),
+		Body:        rec.Body.String(),
+		Truncated:   rec.truncated,
+		DurationMs:  time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// validatePath returns the path and query of the request. The requests can only reach the HTTP API of the instance,
+// not the explorer itself nor the live streams.
+func validatePath(path string) (*url.URL, error) {
+	target, err := url.Parse(path)
+	if err != nil {
+		return nil, ErrPathNotAllowed
+	}
+	if target.Scheme != "" || target.Host != "" || target.User != nil || target.Fragment != "" {
+		return nil, ErrPathNotAllowed
+	}
+	p := target.Path
+	if !strings.HasPrefix(p, "/api/") || strings.Contains(p, "..") || strings.Contains(p, "//") {
+		return nil, ErrPathNotAllowed
+	}
+	if p == "/api/explorer" || strings.HasPrefix(p, "/api/explorer/") || strings.HasPrefix(p, "/api/live/") {
+		return nil, ErrPathNotAllowed
+	}
+	return &url.URL{Path: p, RawQuery: target.RawQuery}, nil
+}
+
+// limitedRecorder records the response body up to the limit
+type limitedRecorder struct {
+	*httptest.ResponseRecorder
+	limit     int64
+	truncated bool
+}
+
+func (r *limitedRecorder) Write(b []byte) (int, error) {
+	if remaining := r.limit - int64(r.Body.Len()); int64(len(b)) > remaining {
+		r.truncated = true
+		if remaining > 0 {
+			_, _ = r.ResponseRecorder.Write(b[:remaining])
+		}
+		return len(b), nil
+	}
+	return r.ResponseRecorder.Write(b)
+}
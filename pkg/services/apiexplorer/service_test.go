@@ -0,0 +1,218 @@
+package apiexplorer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testSpec = `{
+	"openapi": "3.0.0",
+	"info": {"title": "Dashboards"},
+	"paths": {
+		"/api/dashboards/uid/{uid}": {
+			"parameters": [{"name": "uid", "in": "path", "required": true}],
+			"get": {"operationId": "getDashboardByUID", "tags": ["dashboards"]}
+		},
+		"/api/dashboards/uid/home": {
+			"get": {"operationId": "getHomeDashboard"}
+		},
+		"/api/dashboards/db": {
+			"post": {
+				"operationId": "postDashboard",
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SaveDashboardCommand"}}}}
+			}
+		},
+		"/public/dashboards": {
+			"get": {"operationId": "publicDashboards"}
+		}
+	}
+}`
+
+func setupTestService(t *testing.T) *Service {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dashboards.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testSpec), 0600))
+
+	cfg := setting.NewCfg()
+	cfg.APIExplorer = setting.APIExplorerSettings{
+		Enabled:     true,
+		SpecPaths:   []string{path},
+		Timeout:     time.Second,
+		MaxBodySize: 64,
+	}
+	s, err := ProvideService(cfg)
+	require.NoError(t, err)
+	return s
+}
+
+func TestService_Operations(t *testing.T) {
+	s := setupTestService(t)
+
+	require.Len(t, s.Specs(), 2)
+	assert.Equal(t, "alerting", s.Specs()[0].Name)
+	assert.Equal(t, "Dashboards", s.Spec("dashboards").Title)
+	assert.Nil(t, s.Spec("unknown"))
+
+	t.Run("should parse the operations of the specifications", func(t *testing.T) {
+		op := s.FindOperation(http.MethodGet, "/api/alertmanager/grafana/api/v2/silence/abc")
+		require.NotNil(t, op)
+		assert.Equal(t, "RouteGetSilence", op.Id)
+		assert.Equal(t, "alerting", op.Spec)
+
+		op = s.FindOperation(http.MethodPost, "/api/dashboards/db")
+		require.NotNil(t, op)
+		require.Len(t, op.Parameters, 1)
+		assert.Equal(t, "body", op.Parameters[0].In)
+		assert.True(t, op.Parameters[0].Required)
+		assert.JSONEq(t, `{"$ref": "#/components/schemas/SaveDashboardCommand"}`, string(op.Parameters[0].Schema))
+
+		op = s.FindOperation(http.MethodGet, "/api/dashboards/uid/abc")
+		require.NotNil(t, op)
+		assert.Equal(t, "getDashboardByUID", op.Id)
+		require.Len(t, op.Parameters, 1)
+		assert.Equal(t, "uid", op.Parameters[0].Name)
+	})
+
+	t.Run("should prefer the operations with the most literal segments", func(t *testing.T) {
+		op := s.FindOperation(http.MethodGet, "/api/dashboards/uid/home")
+		require.NotNil(t, op)
+		assert.Equal(t, "getHomeDashboard", op.Id)
+	})
+
+	t.Run("should not find the operations outside of the specifications", func(t *testing.T) {
+		assert.Nil(t, s.FindOperation(http.MethodDelete, "/api/dashboards/uid/abc"))
+		assert.Nil(t, s.FindOperation(http.MethodGet, "/api/dashboards/uid"))
+		assert.Nil(t, s.FindOperation(http.MethodGet, "/public/dashboards"))
+	})
+
+	t.Run("should fail on duplicate specifications", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.APIExplorer = s.cfg.APIExplorer
+		cfg.APIExplorer.SpecPaths = []string{s.cfg.APIExplorer.SpecPaths[0], s.cfg.APIExplorer.SpecPaths[0]}
+		_, err := ProvideService(cfg)
+		require.Error(t, err)
+	})
+}
+
+func TestService_Execute(t *testing.T) {
+	s := setupTestService(t)
+	user := &models.SignedInUser{UserId: 2, OrgId: 3}
+	ctx := context.Background()
+
+	var received *http.Request
+	var validToken bool
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = req
+		userID, orgID, ok := s.ValidateToken(req.Header.Get(TokenHeader))
+		validToken = ok && userID == user.UserId && orgID == user.OrgId
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		if req.URL.Query().Get("large") != "" {
+			_, _ = rw.Write([]byte(strings.Repeat("a", 100)))
+			return
+		}
+		_, _ = rw.Write([]byte(`{"uid":"abc"}`))
+	})
+
+	t.Run("should send the request on behalf of the user", func(t *testing.T) {
+		result, err := s.Execute(ctx, handler, user, &ExecuteCommand{Method: "get", Path: "/api/dashboards/uid/abc?a=1"})
+		require.NoError(t, err)
+		assert.Equal(t, "getDashboardByUID", result.OperationId)
+		assert.Equal(t, http.StatusOK, result.Status)
+		assert.Equal(t, `{"uid":"abc"}`, result.Body)
+		assert.False(t, result.Truncated)
+		assert.Equal(t, "application/json", result.Headers.Get("Content-Type"))
+
+		assert.True(t, validToken)
+		assert.Equal(t, "3", received.Header.Get("X-Grafana-Org-Id"))
+		assert.Equal(t, "1", received.URL.Query().Get("a"))
+
+		_, _, ok := s.ValidateToken(received.Header.Get(TokenHeader))
+		assert.False(t, ok, "the token is revoked after the request")
+	})
+
+	t.Run("should send the body", func(t *testing.T) {
+		_, err := s.Execute(ctx, handler, user, &ExecuteCommand{Method: "POST", Path: "/api/dashboards/db", Body: []byte(`{"dashboard":{}}`)})
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(received.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"dashboard":{}}`, string(body))
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	})
+
+	t.Run("should truncate large responses", func(t *testing.T) {
+		result, err := s.Execute(ctx, handler, user, &ExecuteCommand{Method: "GET", Path: "/api/dashboards/uid/abc?large=1"})
+		require.NoError(t, err)
+		assert.True(t, result.Truncated)
+		assert.Len(t, result.Body, 64)
+	})
+
+	t.Run("should reject the requests outside of the specifications", func(t *testing.T) {
+		for _, tc := range []struct {
+			cmd ExecuteCommand
+			err error
+		}{
+			{ExecuteCommand{Method: "DELETE", Path: "/api/dashboards/uid/abc"}, ErrOperationNotFound},
+			{ExecuteCommand{Method: "GET", Path: "/public/dashboards"}, ErrPathNotAllowed},
+			{ExecuteCommand{Method: "GET", Path: "http://example.com/api/dashboards/uid/abc"}, ErrPathNotAllowed},
+			{ExecuteCommand{Method: "GET", Path: "/api/dashboards/uid/../../admin/settings"}, ErrPathNotAllowed},
+			{ExecuteCommand{Method: "GET", Path: "/api/explorer/operations"}, ErrPathNotAllowed},
+			{ExecuteCommand{Method: "GET", Path: "/api/live/ws"}, ErrPathNotAllowed},
+			{ExecuteCommand{Method: "POST", Path: "/api/dashboards/db", Body: []byte(strings.Repeat("a", 65))}, ErrBodyTooLarge},
+		} {
+			received = nil
+			_, err := s.Execute(ctx, handler, user, &tc.cmd)
+			assert.ErrorIs(t, err, tc.err, tc.cmd.Path)
+			assert.Nil(t, received)
+		}
+	})
+
+	t.Run("should reject the requests of API keys and tokens", func(t *testing.T) {
+		received = nil
+		keyUser := &models.SignedInUser{UserId: 2, OrgId: 3, ApiKeyId: 5}
+		_, err := s.Execute(ctx, handler, keyUser, &ExecuteCommand{Method: "GET", Path: "/api/dashboards/uid/abc"})
+		assert.ErrorIs(t, err, ErrAPIKeyNotAllowed)
+		assert.Nil(t, received)
+	})
+
+	t.Run("should not send requests when disabled", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		disabled, err := ProvideService(cfg)
+		require.NoError(t, err)
+		_, err = disabled.Execute(ctx, handler, user, &ExecuteCommand{Method: "GET", Path: "/api/dashboards/uid/abc"})
+		assert.ErrorIs(t, err, ErrDisabled)
+		assert.Empty(t, disabled.Operations())
+	})
+}
+
+func TestTokenStore(t *testing.T) {
+	now := time.Now()
+	store := newTokenStore()
+	store.now = func() time.Time { return now }
+
+	token, err := store.issue(1, 2, time.Minute)
+	require.NoError(t, err)
+
+	userID, orgID, ok := store.validate(token)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), userID)
+	assert.Equal(t, int64(2), orgID)
+
+	_, _, ok = store.validate("unknown")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, _, ok = store.validate(token)
+	assert.False(t, ok, "expired tokens are not valid")
+}
@@ -0,0 +1,190 @@
+package apiexplorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// methods are the HTTP methods of the operations of the specifications, in their order in the catalog
+var methods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// Spec is an OpenAPI specification of the HTTP API, Swagger 2.0 or OpenAPI 3
+type Spec struct {
+	Name  string          `json:"name"`
+	Title string          `json:"title"`
+	Raw   json.RawMessage `json:"-"`
+}
+
+// Operation is an operation of a specification, the requests matching its method and path template are sent by the
+// explorer
+type Operation struct {
+	Id          string       `json:"id"`
+	Spec        string       `json:"spec"`
+	Method      string       `json:"method"`
+	Path        string       `json:"path"`
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Parameters  []*Parameter `json:"parameters"`
+
+	// segments are the segments of the path, the parameters are the segments in braces
+	segments []string
+}
+
+// Parameter is a parameter of an operation, the body of the requests is the parameter in body. The schema is the one
+// of the specification, its references are resolved with the definitions of the specification.
+type Parameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Required    bool            `json:"required"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+type specDocument struct {
+	Info struct {
+		Title string `json:"title"`
+	} `json:"info"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type specOperation struct {
+	OperationId string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *struct {
+		Description string                     `json:"description"`
+		Required    bool                       `json:"required"`
+		Content     map[string]json.RawMessage `json:"content"`
+	} `json:"requestBody"`
+}
+
+// parseSpec returns the specification and its operations. The paths are expected to be absolute, the operations
+// outside of /api/ are left out since they cannot be sent by the explorer.
+func parseSpec(name string, raw []byte) (*Spec, []*Operation, error) {
+	var doc specDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI specification %s: %w", name, err)
+	}
+
+	spec := &Spec{Name: name, Title: doc.Info.Title, Raw: raw}
+	if spec.Title == "" {
+		spec.Title = name
+	}
+
+	operations := make([]*Operation, 0)
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+
+		var shared []*Parameter
+		if params, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(params, &shared); err != nil {
+				return nil, nil, fmt.Errorf("invalid parameters of %s in OpenAPI specification %s: %w", path, name, err)
+			}
+		}
+
+		for _, method := range methods {
+			rawOperation, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op specOperation
+			if err := json.Unmarshal(rawOperation, &op); err != nil {
+				return nil, nil, fmt.Errorf("invalid operation %s %s in OpenAPI specification %s: %w", method, path, name, err)
+			}
+
+			operation := &Operation{
+				Id:          op.OperationId,
+				Spec:        name,
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				Summary:     op.Summary,
+				Description: op.Description,
+				Tags:        op.Tags,
+				Parameters:  mergeParameters(shared, op.Parameters),
+				segments:    strings.Split(strings.Trim(path, "/"), "/"),
+			}
+			if operation.Id == "" {
+				operation.Id = operation.Method + " " + path
+			}
+			// OpenAPI 3 describes the body apart from the parameters
+			if op.RequestBody != nil {
+				body := &Parameter{Name: "body", In: "body", Description: op.RequestBody.Description, Required: op.RequestBody.Required}
+				if content, ok := op.RequestBody.Content["application/json"]; ok {
+					var media struct {
+						Schema json.RawMessage `json:"schema"`
+					}
+					if err := json.Unmarshal(content, &media); err == nil {
+						body.Schema = media.Schema
+					}
+				}
+				operation.Parameters = append(operation.Parameters, body)
+			}
+			operations = append(operations, operation)
+		}
+	}
+
+	sortOperations(operations)
+	return spec, operations, nil
+}
+
+// mergeParameters returns the parameters of the path overridden by the ones of the operation with the same name and
+// location
+func mergeParameters(shared, own []*Parameter) []*Parameter {
+	parameters := make([]*Parameter, 0, len(shared)+len(own))
+	for _, p := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			parameters = append(parameters, p)
+		}
+	}
+	return append(parameters, own...)
+}
+
+func sortOperations(operations []*Operation) {
+	order := make(map[string]int, len(methods))
+	for i, method := range methods {
+		order[strings.ToUpper(method)] = i
+	}
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return order[operations[i].Method] < order[operations[j].Method]
+	})
+}
+
+// matches returns the number of segments of the path matching literal segments of the operation, or -1 when the
+// path does not match the template of the operation
+func (o *Operation) matches(method string, segments []string) int {
+	if o.Method != method || len(o.segments) != len(segments) {
+		return -1
+	}
+	literals := 0
+	for i, segment := range o.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return -1
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return -1
+		}
+		literals++
+	}
+	return literals
+}
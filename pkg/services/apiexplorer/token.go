@@ -0,0 +1,60 @@
+package apiexplorer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// sandboxToken is the token of a request sent by the explorer, it grants the permissions of the user until it is
+// revoked at the end of the request or expires
+type sandboxToken struct {
+	userID  int64
+	orgID   int64
+	expires time.Time
+}
+
+// tokenStore keeps the tokens in memory, they never outlive the request they were issued for
+type tokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]sandboxToken
+	now    func() time.Time
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{tokens: make(map[string]sandboxToken), now: time.Now}
+}
+
+func (s *tokenStore) issue(userID, orgID int64, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = sandboxToken{userID: userID, orgID: orgID, expires: s.now().Add(ttl)}
+	return token, nil
+}
+
+func (s *tokenStore) validate(token string) (int64, int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[token]
+	if !ok {
+		return 0, 0, false
+	}
+	if !s.now().Before(t.expires) {
+		delete(s.tokens, token)
+		return 0, 0, false
+	}
+	return t.userID, t.orgID, true
+}
+
+func (s *tokenStore) revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+}
@@ -0,0 +1,220 @@
+package apireplay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var errRecordingNotFound = errors.New("recording not found")
+
+// strippedHeaders are removed from the recorded responses, they are set again when the responses are replayed
+var strippedHeaders = []string{"Content-Length", "Content-Encoding", "Date", "Vary"}
+
+// recording is a recorded API response, saved as a JSON file. JSON responses are saved as such so that recordings can
+// be read and edited by hand, other responses are base64 encoded.
+type recording struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Query    string          `json:"query,omitempty"`
+	Status   int             `json:"status"`
+	Header   http.Header     `json:"header,omitempty"`
+	JSON     json.RawMessage `json:"json,omitempty"`
+	Body     []byte          `json:"body,omitempty"`
+	Recorded time.Time       `json:"recorded"`
+}
+
+// newRecording returns the recording of a response with the values of the query parameters, headers and JSON fields
+// matching the redacted keys stripped.
+func newRecording(method, path string, query url.Values, status int, header http.Header, body []byte, redactKeys []string) *recording {
+	r := &recording{
+		Method:   method,
+		Path:     path,
+		Query:    canonicalQuery(query, redactKeys),
+		Status:   status,
+		Header:   http.Header{},
+		Recorded: time.Now().UTC(),
+	}
+
+	for name, values := range header {
+		if redacted(name, redactKeys) {
+			continue
+		}
+		r.Header[name] = append([]string(nil), values...)
+	}
+	for _, name := range strippedHeaders {
+		r.Header.Del(name)
+	}
+
+	var value interface{}
+	if len(body) > 0 && strings.Contains(header.Get("Content-Type"), "json") && json.Unmarshal(body, &value) == nil {
+		if b, err := json.Marshal(redactJSON(value, redactKeys)); err == nil {
+			r.JSON = b
+			return r
+		}
+	}
+	r.Body = body
+	return r
+}
+
+// data returns the body of the recorded response
+func (r *recording) data() []byte {
+	if r.JSON != nil {
+		return r.JSON
+	}
+	return r.Body
+}
+
+// canonicalQuery encodes the query parameters sorted by key, without the redacted ones, so that requests differing
+// only in the order of their parameters share their recording.
+func canonicalQuery(query url.Values, redactKeys []string) string {
+	values := url.Values{}
+	for key, v := range query {
+		if !redacted(key, redactKeys) {
+			values[key] = v
+		}
+	}
+	return values.Encode()
+}
+
+// redacted returns true when the key contains one of the redacted keys, ignoring case
+func redacted(key string, redactKeys []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range redactKeys {
+		if strings.Contains(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON replaces the values of the redacted fields, and the credentials of URLs, in a decoded JSON document
+func redactJSON(value interface{}, redactKeys []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if !redacted(key, redactKeys) {
+				v[key] = redactJSON(field, redactKeys)
+				continue
+			}
+			if _, ok := field.(string); ok {
+				v[key] = setting.RedactedPassword
+			} else {
+				v[key] = nil
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i], redactKeys)
+		}
+	case string:
+		if u, err := url.Parse(v); err == nil && u.User != nil && u.Host != "" {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+// recordingStore saves the recordings of each endpoint, that is each method, path and query, in their own directory
+// with one file per request body. Requests with a body that has not been recorded, such as queries over a relative
+// time range, are replayed with the latest recording of the endpoint.
+type recordingStore struct {
+	dir string
+}
+
+func (s *recordingStore) Save(r *recording, requestBody []byte) error {
+	dir := s.endpointDir(r.Method, r.Path, r.Query)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written to a temporary file first so that a recording being replaced is never replayed partially written
+	tmp, err := ioutil.TempFile(dir, ".recording-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, hash(string(requestBody))+".json"))
+}
+
+func (s *recordingStore) Find(method, path, query string, requestBody []byte) (*recording, error) {
+	dir := s.endpointDir(method, path, query)
+	file := filepath.Join(dir, hash(string(requestBody))+".json")
+	if _, err := os.Stat(file); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if file, err = latestRecording(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the file name is a hash computed by the store.
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var r recording
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", file, err)
+	}
+	return &r, nil
+}
+
+func (s *recordingStore) endpointDir(method, path, query string) string {
+	return filepath.Join(s.dir, hash(method+" "+path+"?"+query))
+}
+
+func latestRecording(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errRecordingNotFound
+		}
+		return "", err
+	}
+
+	var latest os.FileInfo
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		if latest == nil || f.ModTime().After(latest.ModTime()) {
+			latest = f
+		}
+	}
+	if latest == nil {
+		return "", errRecordingNotFound
+	}
+	return filepath.Join(dir, latest.Name()), nil
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,164 @@
+package apireplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxRecordedBodySize is the size above which responses are served but not recorded
+const maxRecordedBodySize = 10 << 20
+
+// Service records the responses of the HTTP API, with their secrets stripped, and serves them back in replay mode.
+// In replay mode the API requests never reach the API handlers: the recorded responses are served without
+// authentication and without reading from or writing to the database, so the frontend and plugins can be developed
+// against realistic data without access to the Grafana instance it was recorded from.
+type Service struct {
+	cfg   *setting.Cfg
+	store *recordingStore
+	log   log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	s := &Service{
+		cfg:   cfg,
+		store: &recordingStore{dir: cfg.APIReplay.Path},
+		log:   log.New("apireplay"),
+	}
+	switch cfg.APIReplay.Mode {
+	case setting.APIReplayModeRecord:
+		s.log.Warn("Recording API responses, the recordings may contain sensitive data that isn't redacted", "path", cfg.APIReplay.Path)
+	case setting.APIReplayModeReplay:
+		s.log.Warn("Replaying recorded API responses, API requests are not authenticated", "path", cfg.APIReplay.Path)
+	}
+	return s
+}
+
+// IsEnabled returns true when the API responses are either recorded or replayed.
+func (s *Service) IsEnabled() bool {
+	return s.cfg.APIReplay.Mode != ""
+}
+
+// Middleware records or replays the API requests, depending on the mode. It must run before the context handler so
+// that replayed requests are neither authenticated nor looked up in the database.
+func (s *Service) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			path := req.URL.Path
+			if !isRecorded(path) {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			switch s.cfg.APIReplay.Mode {
+			case setting.APIReplayModeRecord:
+				s.record(next, rw, req, path)
+			case setting.APIReplayModeReplay:
+				s.replay(rw, req, path)
+			default:
+				next.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
+
+// isRecorded returns true for the API requests, which are recorded or replayed. Live requests are left out, as they
+// are streamed over websockets.
+func isRecorded(path string) bool {
+	return strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/live/")
+}
+
+func (s *Service) record(next http.Handler, rw http.ResponseWriter, req *http.Request, path string) {
+	body, err := readBody(req)
+	if err != nil {
+		s.log.Warn("Failed to read request body, the response isn't recorded", "path", path, "error", err)
+		next.ServeHTTP(rw, req)
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: rw.(web.ResponseWriter)}
+	next.ServeHTTP(rec, req)
+
+	if rec.Status() == 0 || rec.overflow {
+		return
+	}
+
+	r := newRecording(req.Method, path, req.URL.Query(), rec.Status(), rec.Header(), rec.body.Bytes(), s.cfg.APIReplay.RedactKeys)
+	if err := s.store.Save(r, body); err != nil {
+		s.log.Error("Failed to save API recording", "method", req.Method, "path", path, "error", err)
+	}
+}
+
+func (s *Service) replay(rw http.ResponseWriter, req *http.Request, path string) {
+	body, err := readBody(req)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	r, err := s.store.Find(req.Method, path, canonicalQuery(req.URL.Query(), s.cfg.APIReplay.RedactKeys), body)
+	if err != nil {
+		if err == errRecordingNotFound {
+			writeError(rw, http.StatusNotFound, "No recorded response for "+req.Method+" "+path)
+			return
+		}
+		s.log.Error("Failed to read API recording", "method", req.Method, "path", path, "error", err)
+		writeError(rw, http.StatusInternalServerError, "Failed to read API recording")
+		return
+	}
+
+	for name, values := range r.Header {
+		for _, v := range values {
+			rw.Header().Add(name, v)
+		}
+	}
+	rw.WriteHeader(r.Status)
+	if req.Method != http.MethodHead {
+		_, _ = rw.Write(r.data())
+	}
+}
+
+// readBody reads the request body and puts it back for the next handlers.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func writeError(rw http.ResponseWriter, status int, message string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(map[string]string{"message": message})
+}
+
+// responseRecorder keeps a copy of the response body written to the client
+type responseRecorder struct {
+	web.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxRecordedBodySize {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
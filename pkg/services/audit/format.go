@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// formatEvent returns the event as JSON, or in the Common Event Format
+func formatEvent(format string, event *Event) ([]byte, error) {
+	if format == setting.AuthAuditFormatCEF {
+		return []byte(cefEvent(event)), nil
+	}
+	return json.Marshal(event)
+}
+
+// cefEvent returns the event in the ArcSight Common Event Format: the action is the event class, the actor is the
+// source user and the user the event is about the destination user. The data of the event is the message.
+func cefEvent(event *Event) string {
+	severity := "3"
+	if event.Action == ActionLoginFailed {
+		severity = "5"
+	}
+	header := []string{"CEF:0", "Grafana Labs", "Grafana", setting.BuildVersion, event.Action,
+		strings.ReplaceAll(event.Action, "_", " "), severity}
+	for i := 1; i < len(header); i++ {
+		header[i] = cefHeaderEscaper.Replace(header[i])
+	}
+
+	extensions := []string{
+		"rt=" + strconv.FormatInt(event.Created.UnixMilli(), 10),
+		"cat=" + cefExtensionEscaper.Replace(string(event.Type)),
+		"act=" + cefExtensionEscaper.Replace(event.Action),
+		"externalId=" + strconv.FormatInt(event.Id, 10),
+	}
+	if event.ActorId != 0 {
+		extensions = append(extensions, "suid="+strconv.FormatInt(event.ActorId, 10))
+	}
+	if event.ActorLogin != "" {
+		extensions = append(extensions, "suser="+cefExtensionEscaper.Replace(event.ActorLogin))
+	}
+	if event.UserId != 0 {
+		extensions = append(extensions, "duid="+strconv.FormatInt(event.UserId, 10))
+	}
+	if event.OrgId != 0 {
+		extensions = append(extensions, "cs1Label=orgId", "cs1="+strconv.FormatInt(event.OrgId, 10))
+	}
+	if event.Data != nil {
+		if ip := event.Data.Get("ipAddress").MustString(); ip != "" {
+			extensions = append(extensions, "src="+cefExtensionEscaper.Replace(ip))
+		}
+		if data, err := event.Data.Encode(); err == nil {
+			extensions = append(extensions, "msg="+cefExtensionEscaper.Replace(string(data)))
+		}
+	}
+
+	return strings.Join(header, "|") + "|" + strings.Join(extensions, " ")
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
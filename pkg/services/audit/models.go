@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// EventType groups the audit events
+type EventType string
+
+const (
+	// EventTypeRole events are changes of the organization role or of the access control roles of a user
+	EventTypeRole EventType = "role"
+	// EventTypeTeam events are changes of the team memberships of a user
+	EventTypeTeam EventType = "team"
+	// EventTypeAuth events are the sign in attempts and the sessions of a user, which are not scoped to an
+	// organization, and the issuance and the use of the API keys and service account tokens
+	EventTypeAuth EventType = "auth"
+	// EventTypeAPIExplorer events are the requests sent by a user with the API explorer
+	EventTypeAPIExplorer EventType = "api_explorer"
+)
+
+const (
+	ActionOrgUserAdded       = "org_user_added"
+	ActionOrgRoleChanged     = "org_role_changed"
+	ActionOrgUserRemoved     = "org_user_removed"
+	ActionRoleAssigned       = "role_assigned"
+	ActionRoleUnassigned     = "role_unassigned"
+	ActionTeamMemberAdded    = "team_member_added"
+	ActionTeamMemberRemoved  = "team_member_removed"
+	ActionLoginSucceeded     = "login_succeeded"
+	ActionLoginFailed        = "login_failed"
+	ActionSessionCreated     = "session_created"
+	ActionSessionRevoked     = "session_revoked"
+	ActionAPIKeyCreated      = "api_key_created"
+	ActionAPIKeyUsed         = "api_key_used"
+	ActionAPIExplorerRequest = "api_explorer_request"
+)
+
+// ErrInvalidExportFormat is returned when exporting the events in a format other than json or cef
+var ErrInvalidExportFormat = errors.New("invalid export format, expected json or cef")
+
+// maxActorLoginLength is the length of the actor_login column
+const maxActorLoginLength = 190
+
+// Event is a recorded change. OrgId is zero for the events not scoped to an organization, ActorId is zero when the
+// change was not made by a signed in user, for example when it was provisioned.
+type Event struct {
+	Id         int64            `json:"id"`
+	OrgId      int64            `json:"orgId"`
+	UserId     int64            `json:"userId"`
+	ActorId    int64            `json:"actorId"`
+	ActorLogin string           `json:"actorLogin"`
+	Type       EventType        `json:"type"`
+	Action     string           `json:"action"`
+	Data       *simplejson.Json `json:"data"`
+	Created    time.Time        `json:"created"`
+}
+
+func (e *Event) TableName() string {
+	return "audit_event"
+}
+
+// UserHistoryQuery returns the events of a user in an organization, and the events of the user not scoped to an
+// organization, newest first. All the types are returned when Types is empty.
+type UserHistoryQuery struct {
+	OrgId   int64
+	UserId  int64
+	Types   []EventType
+	Page    int
+	PerPage int
+}
+
+type UserHistoryResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
+
+// AuthEventsQuery returns the authentication events, newest first. The events are filtered on the fields which are
+// set: OrgId, UserId, Login of the actor, which is the attempted login of the failed sign ins of unknown users, and
+// the time range.
+type AuthEventsQuery struct {
+	OrgId   int64
+	UserId  int64
+	Login   string
+	Actions []string
+	From    time.Time
+	To      time.Time
+	Page    int
+	PerPage int
+}
+
+type AuthEventsResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
+
+// EventsQuery returns the events of all the types, newest first. The events are filtered on the fields which are
+// set: OrgId, UserId of the user the event is about, who is the resource of the role, team and auth events, the
+// actor, the types, the actions and the time range.
+type EventsQuery struct {
+	OrgId      int64
+	UserId     int64
+	ActorId    int64
+	ActorLogin string
+	Types      []EventType
+	Actions    []string
+	From       time.Time
+	To         time.Time
+	Page       int
+	PerPage    int
+}
+
+type EventsResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
@@ -0,0 +1,425 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// deleteBatchSize is the number of expired events deleted at once
+const deleteBatchSize = 1000
+
+// exportBatchSize is the number of events read at once by the exports
+const exportBatchSize = 1000
+
+// Service records the changes of the roles and of the team memberships of the users, and their authentications, so
+// that the administrators can tell when a user was given a role and by whom. The actor of a change is the signed in
+// user of the request that made it. The events of the configured types, authentications by default, are also sent
+// to the configured sinks. The events older than the retention are deleted every hour.
+type Service struct {
+	sqlStore  *sqlstore.SQLStore
+	log       log.Logger
+	now       func() time.Time
+	retention time.Duration
+	// signedInUser returns the signed in user of the request of the context, or nil
+	signedInUser func(ctx context.Context) *models.SignedInUser
+	sinks        map[string]Sink
+	sinkTypes    map[EventType]bool
+	queue        chan *Event
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, b bus.Bus, hooksService *hooks.HooksService,
+	schedulerService *scheduler.Service) (*Service, error) {
+	sinks, err := newSinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{
+		sqlStore:     sqlStore,
+		log:          log.New("audit"),
+		now:          time.Now,
+		retention:    cfg.AuthAudit.Retention,
+		signedInUser: signedInUser,
+		sinks:        sinks,
+		sinkTypes:    map[EventType]bool{},
+		queue:        make(chan *Event, cfg.AuthAudit.QueueSize),
+	}
+	for _, eventType := range cfg.AuthAudit.SinkEventTypes {
+		s.sinkTypes[EventType(eventType)] = true
+	}
+	if len(s.sinkTypes) == 0 {
+		s.sinkTypes[EventTypeAuth] = true
+	}
+	s.registerEventListeners(b)
+	hooksService.AddLoginHook(s.loginHook)
+
+	// Only one Grafana instance deletes the expired events when running in a cluster
+	err = schedulerService.Register(scheduler.Task{
+		Name:        "audit-retention",
+		Description: "Deletes the audit events older than the retention.",
+		Cron:        "@hourly",
+		Enabled:     s.retention > 0,
+		Exclusive:   true,
+		Run:         s.DeleteExpiredEvents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddSink sends the authentication events to the sink as well, it must be added before the server starts
+func (s *Service) AddSink(name string, sink Sink) {
+	s.sinks[name] = sink
+}
+
+// IsDisabled returns true when there is no sink, the events are still recorded to the database
+func (s *Service) IsDisabled() bool {
+	return len(s.sinks) == 0
+}
+
+// Run sends the recorded events of the types sent to the sinks until the context is done
+func (s *Service) Run(ctx context.Context) error {
+	defer s.closeSinks()
+	for {
+		select {
+		case event := <-s.queue:
+			for name, sink := range s.sinks {
+				if err := sink.Send(ctx, event); err != nil {
+					s.log.Error("Failed to send audit event", "sink", name, "id", event.Id, "action", event.Action, "error", err)
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Record stores the event, the actor is set from the signed in user of the request of the context
+func (s *Service) Record(ctx context.Context, event *Event) error {
+	if user := s.signedInUser(ctx); user != nil {
+		event.ActorId = user.UserId
+		event.ActorLogin = user.Login
+	}
+	if event.Data == nil {
+		event.Data = simplejson.New()
+	}
+	if event.Created.IsZero() {
+		event.Created = s.now()
+	}
+	return s.save(ctx, event)
+}
+
+// GetAuthEvents returns the authentication events of all the organizations and users matching the query, newest
+// first
+func (s *Service) GetAuthEvents(ctx context.Context, query *AuthEventsQuery) (*AuthEventsResult, error) {
+	result, err := s.GetEvents(ctx, &EventsQuery{
+		OrgId:      query.OrgId,
+		UserId:     query.UserId,
+		ActorLogin: query.Login,
+		Types:      []EventType{EventTypeAuth},
+		Actions:    query.Actions,
+		From:       query.From,
+		To:         query.To,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AuthEventsResult{TotalCount: result.TotalCount, Events: result.Events, Page: result.Page, PerPage: result.PerPage}, nil
+}
+
+// GetEvents returns the events of all the organizations and users matching the query, newest first
+func (s *Service) GetEvents(ctx context.Context, query *EventsQuery) (*EventsResult, error) {
+	if query.PerPage <= 0 {
+		query.PerPage = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.getEvents(ctx, query, false)
+}
+
+// Export writes all the events matching the query to the writer, oldest first, one per line as JSON or in the
+// Common Event Format. The page of the query is ignored.
+func (s *Service) Export(ctx context.Context, query *EventsQuery, format string, w io.Writer) error {
+	if format != setting.AuthAuditFormatJSON && format != setting.AuthAuditFormatCEF {
+		return ErrInvalidExportFormat
+	}
+
+	// The events recorded during the export are after the pages already read
+	page := *query
+	page.PerPage = exportBatchSize
+	for page.Page = 1; ; page.Page++ {
+		result, err := s.getEvents(ctx, &page, true)
+		if err != nil {
+			return err
+		}
+		for _, event := range result.Events {
+			line, err := formatEvent(format, event)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+		if len(result.Events) < page.PerPage {
+			return nil
+		}
+	}
+}
+
+// DeleteExpiredEvents deletes the events older than the retention
+func (s *Service) DeleteExpiredEvents(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	deleted, err := s.deleteEventsBefore(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	s.log.Debug("Deleted expired audit events", "deleted", deleted)
+	return nil
+}
+
+// save stores the event, and queues the events of the types sent to the sinks
+func (s *Service) save(ctx context.Context, event *Event) error {
+	if err := s.insertEvent(ctx, event); err != nil {
+		return err
+	}
+	if s.sinkTypes[event.Type] && len(s.sinks) > 0 {
+		select {
+		case s.queue <- event:
+		default:
+			s.log.Error("Dropping audit event, too many events are waiting to be sent", "id", event.Id, "action", event.Action)
+		}
+	}
+	return nil
+}
+
+func (s *Service) closeSinks() {
+	for name, sink := range s.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				s.log.Warn("Failed to close auth audit sink", "sink", name, "error", err)
+			}
+		}
+	}
+}
+
+// GetUserHistory returns the events of the user in the organization, newest first
+func (s *Service) GetUserHistory(ctx context.Context, query *UserHistoryQuery) (*UserHistoryResult, error) {
+	if query.PerPage <= 0 {
+		query.PerPage = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.getUserHistory(ctx, query)
+}
+
+func signedInUser(ctx context.Context) *models.SignedInUser {
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil {
+		return reqCtx.SignedInUser
+	}
+	return nil
+}
+
+func (s *Service) registerEventListeners(b bus.Bus) {
+	b.AddEventListener(s.onOrgUserRoleChanged)
+	b.AddEventListener(s.onTeamMembershipChanged)
+	b.AddEventListener(s.onRoleAssignmentChanged)
+	b.AddEventListener(s.onUserTokenCreated)
+	b.AddEventListener(s.onUserTokensRevoked)
+	b.AddEventListener(s.onAPIKeyCreated)
+	b.AddEventListener(s.onAPIKeyUsed)
+}
+
+// onOrgUserRoleChanged records the previous role of the user with the new one. The event does not tell whether the
+// user was added, so the user is considered added when the previous recorded change is a removal, or when there is
+// none and the membership was never updated.
+func (s *Service) onOrgUserRoleChanged(ctx context.Context, e *events.OrgUserRoleChanged) error {
+	event := &Event{OrgId: e.OrgID, UserId: e.UserID, Type: EventTypeRole, Created: e.Timestamp, Data: simplejson.New()}
+
+	last, err := s.getLastRoleEvent(ctx, e.OrgID, e.UserID)
+	if err != nil {
+		return s.logError(err, event)
+	}
+	if last != nil && last.Action != ActionOrgUserRemoved {
+		event.Data.Set("previousRole", last.Data.Get("role").MustString())
+	}
+
+	switch {
+	case e.Role == "":
+		event.Action = ActionOrgUserRemoved
+	case last != nil:
+		event.Action = ActionOrgRoleChanged
+		if last.Action == ActionOrgUserRemoved {
+			event.Action = ActionOrgUserAdded
+		}
+	default:
+		isNew, err := s.isNewOrgUser(ctx, e.OrgID, e.UserID)
+		if err != nil {
+			return s.logError(err, event)
+		}
+		event.Action = ActionOrgRoleChanged
+		if isNew {
+			event.Action = ActionOrgUserAdded
+		}
+	}
+	if e.Role != "" {
+		event.Data.Set("role", e.Role)
+	}
+
+	return s.logError(s.Record(ctx, event), event)
+}
+
+// onTeamMembershipChanged records the changes of the members one by one, the deletion of a team is not recorded
+func (s *Service) onTeamMembershipChanged(ctx context.Context, e *events.TeamMembershipChanged) error {
+	if e.UserID == 0 {
+		return nil
+	}
+
+	event := &Event{OrgId: e.OrgID, UserId: e.UserID, Type: EventTypeTeam, Action: ActionTeamMemberAdded, Created: e.Timestamp, Data: simplejson.New()}
+	if e.Removed {
+		event.Action = ActionTeamMemberRemoved
+	}
+	name, err := s.getTeamName(ctx, e.OrgID, e.TeamID)
+	if err != nil {
+		return s.logError(err, event)
+	}
+	event.Data.Set("teamId", e.TeamID)
+	event.Data.Set("teamName", name)
+
+	return s.logError(s.Record(ctx, event), event)
+}
+
+// onRoleAssignmentChanged records the access control roles assigned to users, the roles assigned to teams are not
+// recorded
+func (s *Service) onRoleAssignmentChanged(ctx context.Context, e *events.RoleAssignmentChanged) error {
+	if e.UserID == 0 {
+		return nil
+	}
+
+	event := &Event{OrgId: e.OrgID, UserId: e.UserID, Type: EventTypeRole, Action: ActionRoleAssigned, Created: e.Timestamp, Data: simplejson.New()}
+	if e.Removed {
+		event.Action = ActionRoleUnassigned
+	}
+	event.Data.Set("roleUid", e.RoleUID)
+	if e.RoleName != "" {
+		event.Data.Set("roleName", e.RoleName)
+	}
+
+	return s.logError(s.Record(ctx, event), event)
+}
+
+// onUserTokenCreated records the sessions of the users signing in, the actor of which is the user
+func (s *Service) onUserTokenCreated(ctx context.Context, e *events.UserTokenCreated) error {
+	event := &Event{UserId: e.UserID, Type: EventTypeAuth, Action: ActionSessionCreated, Created: e.Timestamp, Data: simplejson.New()}
+	event.Data.Set("tokenId", e.TokenID)
+	event.Data.Set("ipAddress", e.ClientIP)
+	event.Data.Set("userAgent", e.UserAgent)
+
+	login, err := s.getUserLogin(ctx, e.UserID)
+	if err != nil {
+		return s.logError(err, event)
+	}
+	event.ActorId = e.UserID
+	event.ActorLogin = login
+	return s.logError(s.save(ctx, event), event)
+}
+
+// onUserTokensRevoked records the sessions revoked by the users signing out, or by the administrators
+func (s *Service) onUserTokensRevoked(ctx context.Context, e *events.UserTokensRevoked) error {
+	event := &Event{UserId: e.UserID, Type: EventTypeAuth, Action: ActionSessionRevoked, Created: e.Timestamp, Data: simplejson.New()}
+	if e.TokenID != 0 {
+		event.Data.Set("tokenId", e.TokenID)
+	} else {
+		event.Data.Set("allSessions", true)
+	}
+	return s.logError(s.Record(ctx, event), event)
+}
+
+// onAPIKeyCreated records the API keys added, and the tokens added to the service accounts as events of the service
+// account
+func (s *Service) onAPIKeyCreated(ctx context.Context, e *events.APIKeyCreated) error {
+	event := &Event{OrgId: e.OrgID, UserId: e.ServiceAccountID, Type: EventTypeAuth, Action: ActionAPIKeyCreated, Created: e.Timestamp, Data: simplejson.New()}
+	event.Data.Set("apiKeyId", e.ID)
+	event.Data.Set("apiKeyName", e.Name)
+	return s.logError(s.Record(ctx, event), event)
+}
+
+// onAPIKeyUsed records the uses of the API keys, there is no actor as the request is not signed in yet
+func (s *Service) onAPIKeyUsed(ctx context.Context, e *events.APIKeyUsed) error {
+	event := &Event{OrgId: e.OrgID, UserId: e.ServiceAccountID, Type: EventTypeAuth, Action: ActionAPIKeyUsed, Created: e.Timestamp, Data: simplejson.New()}
+	event.Data.Set("apiKeyId", e.ID)
+	event.Data.Set("apiKeyName", e.Name)
+	event.Data.Set("ipAddress", e.ClientIP)
+	return s.logError(s.save(ctx, event), event)
+}
+
+// loginHook records the sign in attempts. The attempts of unknown users are recorded with their attempted login as
+// the actor.
+func (s *Service) loginHook(info *models.LoginInfo, c *models.ReqContext) {
+	user := info.User
+	if user == nil && info.LoginUsername != "" {
+		query := &models.GetUserByLoginQuery{LoginOrEmail: info.LoginUsername}
+		if err := bus.Dispatch(c.Req.Context(), query); err != nil {
+			if !errors.Is(err, models.ErrUserNotFound) {
+				s.log.Warn("Failed to get user of sign in attempt", "login", info.LoginUsername, "error", err)
+				return
+			}
+		} else {
+			user = query.Result
+		}
+	}
+	if user == nil && info.LoginUsername == "" {
+		return
+	}
+
+	event := &Event{Type: EventTypeAuth, Action: ActionLoginSucceeded, Data: simplejson.New()}
+	if info.Error != nil || info.HTTPStatus >= 400 {
+		event.Action = ActionLoginFailed
+	}
+	event.Data.Set("authModule", info.AuthModule)
+	event.Data.Set("status", info.HTTPStatus)
+	event.Data.Set("ipAddress", c.RemoteAddr())
+
+	// The actor of a sign in is the user, who is not signed in yet
+	if user != nil {
+		event.UserId = user.Id
+		event.ActorId = user.Id
+		event.ActorLogin = user.Login
+	} else {
+		// The attempted login is truncated to the length of the column
+		login := []rune(info.LoginUsername)
+		if len(login) > maxActorLoginLength {
+			login = login[:maxActorLoginLength]
+		}
+		event.ActorLogin = string(login)
+	}
+	event.Created = s.now()
+	_ = s.logError(s.save(c.Req.Context(), event), event)
+}
+
+// logError logs the failures to record an event, the changes are made already and are not failed
+func (s *Service) logError(err error, event *Event) error {
+	if err != nil {
+		s.log.Error("Failed to record audit event", "orgId", event.OrgId, "userId", event.UserId, "action", event.Action, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestService_GetUserHistory(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	b := bus.New()
+	hooksService := hooks.ProvideService()
+	s, err := ProvideService(setting.NewCfg(), store, b, hooksService, newScheduler(store))
+	require.NoError(t, err)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "admin", OrgName: "tenant"})
+	require.NoError(t, err)
+	user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", SkipOrgSetup: true})
+	require.NoError(t, err)
+	team, err := store.CreateTeam("backend", "", admin.OrgId)
+	require.NoError(t, err)
+	orgID := admin.OrgId
+
+	var actor *models.SignedInUser
+	s.signedInUser = func(context.Context) *models.SignedInUser { return actor }
+
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+	publish := func(e interface{}) {
+		t.Helper()
+		require.NoError(t, b.Publish(ctx, e))
+	}
+
+	actor = &models.SignedInUser{UserId: admin.Id, Login: admin.Login}
+	require.NoError(t, store.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: models.ROLE_VIEWER}))
+	publish(&events.OrgUserRoleChanged{Timestamp: at(1), OrgID: orgID, UserID: user.Id, Role: string(models.ROLE_VIEWER)})
+	publish(&events.TeamMembershipChanged{Timestamp: at(2), OrgID: orgID, TeamID: team.Id, UserID: user.Id})
+	publish(&events.OrgUserRoleChanged{Timestamp: at(3), OrgID: orgID, UserID: user.Id, Role: string(models.ROLE_ADMIN)})
+	publish(&events.RoleAssignmentChanged{Timestamp: at(4), OrgID: orgID, RoleUID: "reporter", RoleName: "custom:reporter", UserID: user.Id})
+	publish(&events.RoleAssignmentChanged{Timestamp: at(4), OrgID: orgID, RoleUID: "reporter", TeamID: team.Id})
+	actor = nil
+	publish(&events.TeamMembershipChanged{Timestamp: at(5), OrgID: orgID, TeamID: team.Id, UserID: user.Id, Removed: true})
+	publish(&events.TeamMembershipChanged{Timestamp: at(5), OrgID: orgID, TeamID: team.Id, Removed: true})
+	publish(&events.OrgUserRoleChanged{Timestamp: at(6), OrgID: orgID + 1, UserID: user.Id, Role: string(models.ROLE_EDITOR)})
+
+	result, err := s.GetUserHistory(ctx, &UserHistoryQuery{OrgId: orgID, UserId: user.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.TotalCount)
+	require.Len(t, result.Events, 5)
+
+	removed := result.Events[0]
+	assert.Equal(t, ActionTeamMemberRemoved, removed.Action)
+	assert.Equal(t, EventTypeTeam, removed.Type)
+	assert.Equal(t, "backend", removed.Data.Get("teamName").MustString())
+	assert.Zero(t, removed.ActorId)
+
+	assigned := result.Events[1]
+	assert.Equal(t, ActionRoleAssigned, assigned.Action)
+	assert.Equal(t, "custom:reporter", assigned.Data.Get("roleName").MustString())
+
+	promoted := result.Events[2]
+	assert.Equal(t, ActionOrgRoleChanged, promoted.Action)
+	assert.Equal(t, "Viewer", promoted.Data.Get("previousRole").MustString())
+	assert.Equal(t, "Admin", promoted.Data.Get("role").MustString())
+	assert.Equal(t, admin.Id, promoted.ActorId)
+	assert.Equal(t, "admin", promoted.ActorLogin)
+	assert.Equal(t, at(3), promoted.Created.UTC())
+
+	assert.Equal(t, ActionTeamMemberAdded, result.Events[3].Action)
+
+	added := result.Events[4]
+	assert.Equal(t, ActionOrgUserAdded, added.Action)
+	assert.Equal(t, "Viewer", added.Data.Get("role").MustString())
+
+	t.Run("should filter the events by type and page them", func(t *testing.T) {
+		result, err := s.GetUserHistory(ctx, &UserHistoryQuery{OrgId: orgID, UserId: user.Id, Types: []EventType{EventTypeRole}, Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, ActionOrgUserAdded, result.Events[0].Action)
+	})
+
+	t.Run("should record the user removed and added again", func(t *testing.T) {
+		publish(&events.OrgUserRoleChanged{Timestamp: at(7), OrgID: orgID, UserID: user.Id})
+		publish(&events.OrgUserRoleChanged{Timestamp: at(8), OrgID: orgID, UserID: user.Id, Role: string(models.ROLE_EDITOR)})
+
+		result, err := s.GetUserHistory(ctx, &UserHistoryQuery{OrgId: orgID, UserId: user.Id, Types: []EventType{EventTypeRole}, PerPage: 2})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 2)
+		assert.Equal(t, ActionOrgUserAdded, result.Events[0].Action)
+		assert.Empty(t, result.Events[0].Data.Get("previousRole").MustString())
+		assert.Equal(t, ActionOrgUserRemoved, result.Events[1].Action)
+		assert.Equal(t, "Admin", result.Events[1].Data.Get("previousRole").MustString())
+	})
+
+	t.Run("should record the sign in attempts in all the organizations of the user", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/login", nil)
+		require.NoError(t, err)
+		req.RemoteAddr = "10.0.0.1:4000"
+		c := &models.ReqContext{Context: &web.Context{Req: req}}
+
+		hooksService.RunLoginHook(&models.LoginInfo{AuthModule: "ldap", User: user, HTTPStatus: http.StatusOK}, c)
+		hooksService.RunLoginHook(&models.LoginInfo{LoginUsername: "viewer", HTTPStatus: http.StatusUnauthorized, Error: errors.New("invalid password")}, c)
+		hooksService.RunLoginHook(&models.LoginInfo{LoginUsername: "unknown", HTTPStatus: http.StatusUnauthorized}, c)
+
+		for _, id := range []int64{orgID, orgID + 1} {
+			result, err := s.GetUserHistory(ctx, &UserHistoryQuery{OrgId: id, UserId: user.Id, Types: []EventType{EventTypeAuth}})
+			require.NoError(t, err)
+			require.Len(t, result.Events, 2)
+			assert.Equal(t, ActionLoginFailed, result.Events[0].Action)
+			assert.Equal(t, ActionLoginSucceeded, result.Events[1].Action)
+			assert.Equal(t, "ldap", result.Events[1].Data.Get("authModule").MustString())
+			assert.Equal(t, "10.0.0.1", result.Events[1].Data.Get("ipAddress").MustString())
+			assert.Equal(t, user.Id, result.Events[1].ActorId)
+		}
+	})
+}
+
+type fakeSink struct {
+	events chan *Event
+}
+
+func (s *fakeSink) Send(_ context.Context, event *Event) error {
+	s.events <- event
+	return nil
+}
+
+func TestService_GetAuthEvents(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	b := bus.New()
+	hooksService := hooks.ProvideService()
+	s, err := ProvideService(setting.NewCfg(), store, b, hooksService, newScheduler(store))
+	require.NoError(t, err)
+	sink := &fakeSink{events: make(chan *Event, 10)}
+	s.AddSink("fake", sink)
+	s.queue = make(chan *Event, 10)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", OrgName: "tenant"})
+	require.NoError(t, err)
+	admin := &models.SignedInUser{UserId: 100, Login: "admin"}
+	var actor *models.SignedInUser
+	s.signedInUser = func(context.Context) *models.SignedInUser { return actor }
+	publish := func(e interface{}) {
+		t.Helper()
+		require.NoError(t, b.Publish(ctx, e))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/login", nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:4000"
+	c := &models.ReqContext{Context: &web.Context{Req: req}}
+	hooksService.RunLoginHook(&models.LoginInfo{LoginUsername: "unknown", HTTPStatus: http.StatusUnauthorized}, c)
+	publish(&events.UserTokenCreated{Timestamp: at(1), UserID: user.Id, TokenID: 7, ClientIP: "10.0.0.1", UserAgent: "curl"})
+	actor = admin
+	publish(&events.UserTokensRevoked{Timestamp: at(2), UserID: user.Id})
+	publish(&events.APIKeyCreated{Timestamp: at(3), OrgID: user.OrgId, ID: 3, Name: "ci"})
+	actor = nil
+	publish(&events.APIKeyUsed{Timestamp: at(4), OrgID: user.OrgId, ID: 3, Name: "ci", ClientIP: "10.0.0.2"})
+
+	result, err := s.GetAuthEvents(ctx, &AuthEventsQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.TotalCount)
+	require.Len(t, result.Events, 5)
+
+	used := result.Events[0]
+	assert.Equal(t, ActionAPIKeyUsed, used.Action)
+	assert.Equal(t, user.OrgId, used.OrgId)
+	assert.Equal(t, "ci", used.Data.Get("apiKeyName").MustString())
+	assert.Equal(t, "10.0.0.2", used.Data.Get("ipAddress").MustString())
+
+	created := result.Events[1]
+	assert.Equal(t, ActionAPIKeyCreated, created.Action)
+	assert.Equal(t, admin.UserId, created.ActorId)
+
+	revoked := result.Events[2]
+	assert.Equal(t, ActionSessionRevoked, revoked.Action)
+	assert.True(t, revoked.Data.Get("allSessions").MustBool())
+	assert.Equal(t, "admin", revoked.ActorLogin)
+
+	session := result.Events[3]
+	assert.Equal(t, ActionSessionCreated, session.Action)
+	assert.Equal(t, user.Id, session.UserId)
+	assert.Equal(t, "viewer", session.ActorLogin)
+	assert.Equal(t, "curl", session.Data.Get("userAgent").MustString())
+
+	failed := result.Events[4]
+	assert.Equal(t, ActionLoginFailed, failed.Action)
+	assert.Zero(t, failed.UserId)
+	assert.Equal(t, "unknown", failed.ActorLogin)
+
+	t.Run("should filter the events", func(t *testing.T) {
+		result, err := s.GetAuthEvents(ctx, &AuthEventsQuery{UserId: user.Id, Actions: []string{ActionSessionCreated, ActionSessionRevoked}, To: at(1)})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, ActionSessionCreated, result.Events[0].Action)
+
+		result, err = s.GetAuthEvents(ctx, &AuthEventsQuery{Login: "unknown"})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+
+		result, err = s.GetAuthEvents(ctx, &AuthEventsQuery{OrgId: user.OrgId, From: at(3).Add(30 * time.Second)})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, ActionAPIKeyUsed, result.Events[0].Action)
+	})
+
+	t.Run("should send the events to the sinks in order", func(t *testing.T) {
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			_ = s.Run(runCtx)
+		}()
+
+		actions := make([]string, 0, 5)
+		for i := 0; i < 5; i++ {
+			actions = append(actions, (<-sink.events).Action)
+		}
+		assert.Equal(t, []string{ActionLoginFailed, ActionSessionCreated, ActionSessionRevoked, ActionAPIKeyCreated, ActionAPIKeyUsed}, actions)
+	})
+}
+
+func TestService_GetEvents(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.AuthAudit.Retention = 24 * time.Hour
+	s, err := ProvideService(cfg, store, bus.New(), hooks.ProvideService(), newScheduler(store))
+	require.NoError(t, err)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin := &models.SignedInUser{UserId: 1, Login: "admin"}
+	var actor *models.SignedInUser
+	s.signedInUser = func(context.Context) *models.SignedInUser { return actor }
+	record := func(event *Event) {
+		t.Helper()
+		require.NoError(t, s.Record(ctx, event))
+	}
+
+	record(&Event{UserId: 2, Type: EventTypeAuth, Action: ActionLoginSucceeded, Created: now.Add(-48 * time.Hour)})
+	actor = admin
+	record(&Event{OrgId: 1, UserId: 2, Type: EventTypeRole, Action: ActionOrgUserAdded, Created: now.Add(-2 * time.Hour)})
+	record(&Event{OrgId: 1, UserId: 2, Type: EventTypeTeam, Action: ActionTeamMemberAdded, Created: now.Add(-time.Hour)})
+	actor = nil
+	record(&Event{OrgId: 1, UserId: 3, Type: EventTypeRole, Action: ActionOrgRoleChanged, Created: now})
+
+	t.Run("should filter the events of all the types", func(t *testing.T) {
+		result, err := s.GetEvents(ctx, &EventsQuery{ActorLogin: "admin"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Events, 2)
+		assert.Equal(t, ActionTeamMemberAdded, result.Events[0].Action)
+
+		result, err = s.GetEvents(ctx, &EventsQuery{UserId: 2, Types: []EventType{EventTypeRole, EventTypeAuth}})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 2)
+		assert.Equal(t, ActionOrgUserAdded, result.Events[0].Action)
+		assert.Equal(t, ActionLoginSucceeded, result.Events[1].Action)
+
+		result, err = s.GetEvents(ctx, &EventsQuery{OrgId: 1, ActorId: admin.UserId, From: now.Add(-90 * time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, ActionTeamMemberAdded, result.Events[0].Action)
+	})
+
+	t.Run("should export the events oldest first", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, s.Export(ctx, &EventsQuery{OrgId: 1}, setting.AuthAuditFormatJSON, &out))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], `"action":"org_user_added"`)
+		assert.Contains(t, lines[2], `"action":"org_role_changed"`)
+
+		out.Reset()
+		require.NoError(t, s.Export(ctx, &EventsQuery{ActorLogin: "admin"}, setting.AuthAuditFormatCEF, &out))
+		lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "CEF:0|Grafana Labs|Grafana|"), lines[0])
+		assert.Contains(t, lines[0], "|org_user_added|org user added|3|")
+
+		assert.ErrorIs(t, s.Export(ctx, &EventsQuery{}, "xml", &out), ErrInvalidExportFormat)
+	})
+
+	t.Run("should delete the events older than the retention", func(t *testing.T) {
+		require.NoError(t, s.DeleteExpiredEvents(ctx))
+		result, err := s.GetEvents(ctx, &EventsQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		for _, event := range result.Events {
+			assert.NotEqual(t, ActionLoginSucceeded, event.Action)
+		}
+	})
+}
+
+func newScheduler(store *sqlstore.SQLStore) *scheduler.Service {
+	return scheduler.ProvideService(nil, kvstore.ProvideService(store), accesscontrolmock.New(), routing.NewRouteRegister())
+}
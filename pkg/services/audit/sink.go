@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Sink receives the events of the types sent to the sinks once they are recorded. The events are sent to the sinks one at a time,
+// in the order they are recorded, the sinks implementing io.Closer are closed when the server stops.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// newSinks returns the sinks enabled by the configuration, by name
+func newSinks(cfg *setting.Cfg) (map[string]Sink, error) {
+	client := &http.Client{Timeout: cfg.AuthAudit.Timeout}
+	sinks := make(map[string]Sink, len(cfg.AuthAudit.Sinks))
+	for _, name := range cfg.AuthAudit.Sinks {
+		var sink Sink
+		var err error
+		switch name {
+		case setting.AuthAuditSinkFile:
+			sink, err = newFileSink(cfg.AuthAudit.FilePath, cfg.AuthAudit.Format)
+		case setting.AuthAuditSinkLoki:
+			sink = &lokiSink{client: client, url: cfg.AuthAudit.LokiURL}
+		case setting.AuthAuditSinkSyslog:
+			sink, err = newSyslogSink(cfg.AuthAudit.SyslogNetwork, cfg.AuthAudit.SyslogAddress, cfg.AuthAudit.SyslogTag, cfg.AuthAudit.Format)
+		case setting.AuthAuditSinkWebhook:
+			sink = &webhookSink{client: client, url: cfg.AuthAudit.WebhookURL}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s auth audit sink: %w", name, err)
+		}
+		sinks[name] = sink
+	}
+	return sinks, nil
+}
+
+// fileSink appends the events to a file, one per line as JSON or in the Common Event Format
+type fileSink struct {
+	mu     sync.Mutex
+	file   *os.File
+	format string
+}
+
+func newFileSink(path, format string) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	// nolint:gosec
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file, format: format}, nil
+}
+
+func (s *fileSink) Send(_ context.Context, event *Event) error {
+	line, err := formatEvent(s.format, event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// lokiSink pushes the events to Loki, labeled with their action
+type lokiSink struct {
+	client *http.Client
+	url    string
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *lokiSink) Send(ctx context.Context, event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(lokiPush{Streams: []lokiStream{{
+		Stream: map[string]string{"job": "grafana-auth-audit", "action": event.Action},
+		Values: [][2]string{{strconv.FormatInt(event.Created.UnixNano(), 10), string(line)}},
+	}}})
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.client, s.url, body)
+}
+
+// webhookSink posts each event as JSON
+type webhookSink struct {
+	client *http.Client
+	url    string
+}
+
+func (s *webhookSink) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.client, s.url, body)
+}
+
+func post(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
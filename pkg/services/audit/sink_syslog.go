@@ -0,0 +1,39 @@
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package audit
+
+import (
+	"context"
+	"log/syslog"
+)
+
+// syslogSink writes the events as JSON or in the Common Event Format to syslog, with the auth facility
+type syslogSink struct {
+	writer *syslog.Writer
+	format string
+}
+
+// newSyslogSink connects to the syslog daemon at the address, or to the local one when the network is empty
+func newSyslogSink(network, address, tag, format string) (Sink, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer, format: format}, nil
+}
+
+func (s *syslogSink) Send(_ context.Context, event *Event) error {
+	line, err := formatEvent(s.format, event)
+	if err != nil {
+		return err
+	}
+	if event.Action == ActionLoginFailed {
+		return s.writer.Warning(string(line))
+	}
+	return s.writer.Info(string(line))
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
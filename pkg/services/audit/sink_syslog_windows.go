@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package audit
+
+import "errors"
+
+func newSyslogSink(network, address, tag, format string) (Sink, error) {
+	return nil, errors.New("syslog is not supported on Windows")
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "auth.log")
+	sink, err := newFileSink(path, setting.AuthAuditFormatJSON)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(context.Background(), &Event{Id: 1, Action: ActionLoginSucceeded, Data: simplejson.New()}))
+	require.NoError(t, sink.Send(context.Background(), &Event{Id: 2, Action: ActionLoginFailed, Data: simplejson.New()}))
+	require.NoError(t, sink.Close())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	event := &Event{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), event))
+	assert.Equal(t, int64(2), event.Id)
+	assert.Equal(t, ActionLoginFailed, event.Action)
+}
+
+func TestLokiSink(t *testing.T) {
+	var push lokiPush
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := &lokiSink{client: server.Client(), url: server.URL}
+	created := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, sink.Send(context.Background(), &Event{Id: 1, Action: ActionAPIKeyUsed, Created: created, Data: simplejson.New()}))
+
+	require.Len(t, push.Streams, 1)
+	assert.Equal(t, map[string]string{"job": "grafana-auth-audit", "action": ActionAPIKeyUsed}, push.Streams[0].Stream)
+	require.Len(t, push.Streams[0].Values, 1)
+	assert.Equal(t, "1636538400000000000", push.Streams[0].Values[0][0])
+	assert.Contains(t, push.Streams[0].Values[0][1], `"action":"api_key_used"`)
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := &webhookSink{client: server.Client(), url: server.URL}
+	require.NoError(t, sink.Send(context.Background(), &Event{Id: 1, Data: simplejson.New()}))
+
+	status = http.StatusBadGateway
+	require.Error(t, sink.Send(context.Background(), &Event{Id: 2, Data: simplejson.New()}))
+}
+
+func TestCEFEvent(t *testing.T) {
+	data := simplejson.New()
+	data.Set("ipAddress", "10.0.0.1")
+	data.Set("authModule", "ldap")
+	event := &Event{
+		Id:         7,
+		UserId:     2,
+		ActorLogin: `a=b\c`,
+		Type:       EventTypeAuth,
+		Action:     ActionLoginFailed,
+		Data:       data,
+		Created:    time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC),
+	}
+
+	line, err := formatEvent(setting.AuthAuditFormatCEF, event)
+	require.NoError(t, err)
+	assert.Equal(t, "CEF:0|Grafana Labs|Grafana|"+setting.BuildVersion+"|login_failed|login failed|5|"+
+		`rt=1636538400000 cat=auth act=login_failed externalId=7 suser=a\=b\\c duid=2 src=10.0.0.1 `+
+		`msg={"authModule":"ldap","ipAddress":"10.0.0.1"}`, string(line))
+}
@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) insertEvent(ctx context.Context, event *Event) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(event)
+		return err
+	})
+}
+
+func (s *Service) getUserHistory(ctx context.Context, query *UserHistoryQuery) (*UserHistoryResult, error) {
+	result := &UserHistoryResult{Events: make([]*Event, 0), Page: query.Page, PerPage: query.PerPage}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// The conditions are reset after each query
+		filter := func() *xorm.Session {
+			q := sess.Where("user_id = ?", query.UserId).In("org_id", query.OrgId, 0)
+			if len(query.Types) > 0 {
+				q = q.In("type", query.Types)
+			}
+			return q
+		}
+
+		count, err := filter().Count(&Event{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		offset := (query.Page - 1) * query.PerPage
+		return filter().Desc("created").Desc("id").Limit(query.PerPage, offset).Find(&result.Events)
+	})
+	return result, err
+}
+
+// getLastRoleEvent returns the last recorded change of the organization role of the user, or nil
+func (s *Service) getLastRoleEvent(ctx context.Context, orgID, userID int64) (*Event, error) {
+	var event *Event
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		e := &Event{}
+		has, err := sess.Where("org_id = ? AND user_id = ?", orgID, userID).
+			In("action", ActionOrgUserAdded, ActionOrgRoleChanged, ActionOrgUserRemoved).
+			Desc("created").Desc("id").Limit(1).Get(e)
+		if has {
+			event = e
+		}
+		return err
+	})
+	return event, err
+}
+
+// isNewOrgUser returns whether the membership of the user in the organization was never updated since it was added
+func (s *Service) isNewOrgUser(ctx context.Context, orgID, userID int64) (bool, error) {
+	var count int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.SQL("SELECT COUNT(*) FROM org_user WHERE org_id = ? AND user_id = ? AND created = updated", orgID, userID).Get(&count)
+		return err
+	})
+	return count > 0, err
+}
+
+func (s *Service) getTeamName(ctx context.Context, orgID, teamID int64) (string, error) {
+	var name string
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.SQL("SELECT name FROM team WHERE org_id = ? AND id = ?", orgID, teamID).Get(&name)
+		return err
+	})
+	return name, err
+}
+
+// getEvents returns a page of the events matching the query, newest first, or oldest first when ascending
+func (s *Service) getEvents(ctx context.Context, query *EventsQuery, ascending bool) (*EventsResult, error) {
+	result := &EventsResult{Events: make([]*Event, 0), Page: query.Page, PerPage: query.PerPage}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// The conditions are reset after each query
+		filter := func() *xorm.Session {
+			q := sess.Session
+			if query.OrgId != 0 {
+				q = q.And("org_id = ?", query.OrgId)
+			}
+			if query.UserId != 0 {
+				q = q.And("user_id = ?", query.UserId)
+			}
+			if query.ActorId != 0 {
+				q = q.And("actor_id = ?", query.ActorId)
+			}
+			if query.ActorLogin != "" {
+				q = q.And("actor_login = ?", query.ActorLogin)
+			}
+			if !query.From.IsZero() {
+				q = q.And("created >= ?", query.From)
+			}
+			if !query.To.IsZero() {
+				q = q.And("created <= ?", query.To)
+			}
+			if len(query.Types) > 0 {
+				q = q.In("type", query.Types)
+			}
+			if len(query.Actions) > 0 {
+				q = q.In("action", query.Actions)
+			}
+			return q
+		}
+
+		count, err := filter().Count(&Event{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		q := filter()
+		if ascending {
+			q = q.Asc("created").Asc("id")
+		} else {
+			q = q.Desc("created").Desc("id")
+		}
+		offset := (query.Page - 1) * query.PerPage
+		return q.Limit(query.PerPage, offset).Find(&result.Events)
+	})
+	return result, err
+}
+
+// deleteEventsBefore deletes the events created before the time, in batches so that the table is not locked for long
+func (s *Service) deleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for {
+		var affected int64
+		err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			var ids []int64
+			if err := sess.Table("audit_event").Cols("id").Where("created < ?", before).Limit(deleteBatchSize).Find(&ids); err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			res, err := sess.In("id", ids).Delete(&Event{})
+			affected = res
+			return err
+		})
+		deleted += affected
+		if err != nil || affected == 0 {
+			return deleted, err
+		}
+	}
+}
+
+func (s *Service) getUserLogin(ctx context.Context, userID int64) (string, error) {
+	var login string
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Table("user").Cols("login").Where("id = ?", userID).Get(&login)
+		return err
+	})
+	return login, err
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -98,6 +99,14 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *models.Use
 
 	s.log.Debug("user auth token created", "tokenId", userAuthToken.Id, "userId", userAuthToken.UserId, "clientIP", userAuthToken.ClientIp, "userAgent", userAuthToken.UserAgent, "authToken", userAuthToken.AuthToken)
 
+	s.publish(ctx, &events.UserTokenCreated{
+		Timestamp: time.Unix(now, 0),
+		UserID:    userAuthToken.UserId,
+		TokenID:   userAuthToken.Id,
+		ClientIP:  userAuthToken.ClientIp,
+		UserAgent: userAuthToken.UserAgent,
+	})
+
 	var userToken models.UserToken
 	err = userAuthToken.toUserToken(&userToken)
 
@@ -318,19 +327,21 @@ func (s *UserAuthTokenService) RevokeToken(ctx context.Context, token *models.Us
 	}
 
 	s.log.Debug("user auth token revoked", "tokenId", model.Id, "userId", model.UserId, "clientIP", model.ClientIp, "userAgent", model.UserAgent, "soft", soft)
+	s.publish(ctx, &events.UserTokensRevoked{Timestamp: getTime(), UserID: model.UserId, TokenID: model.Id})
 
 	return nil
 }
 
 func (s *UserAuthTokenService) RevokeAllUserTokens(ctx context.Context, userId int64) error {
-	return s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
 		sql := `DELETE from user_auth_token WHERE user_id = ?`
 		res, err := dbSession.Exec(sql, userId)
 		if err != nil {
 			return err
 		}
 
-		affected, err := res.RowsAffected()
+		affected, err = res.RowsAffected()
 		if err != nil {
 			return err
 		}
@@ -339,10 +350,14 @@ func (s *UserAuthTokenService) RevokeAllUserTokens(ctx context.Context, userId i
 
 		return err
 	})
+	if err == nil && affected > 0 {
+		s.publish(ctx, &events.UserTokensRevoked{Timestamp: getTime(), UserID: userId})
+	}
+	return err
 }
 
 func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, userIds []int64) error {
-	return s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
 		if len(userIds) == 0 {
 			return nil
 		}
@@ -369,6 +384,12 @@ func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, use
 
 		return err
 	})
+	if err == nil {
+		for _, userID := range userIds {
+			s.publish(ctx, &events.UserTokensRevoked{Timestamp: getTime(), UserID: userID})
+		}
+	}
+	return err
 }
 
 func (s *UserAuthTokenService) GetUserToken(ctx context.Context, userId, userTokenId int64) (*models.UserToken, error) {
@@ -448,6 +469,16 @@ func (s *UserAuthTokenService) rotatedAfterParam() int64 {
 	return getTime().Add(-s.Cfg.LoginMaxInactiveLifetime).Unix()
 }
 
+// publish notifies the issuance or the revocation of session tokens, failing to publish it is only logged
+func (s *UserAuthTokenService) publish(ctx context.Context, event interface{}) {
+	if s.SQLStore.Bus == nil {
+		return
+	}
+	if err := s.SQLStore.Bus.Publish(ctx, event); err != nil {
+		s.log.Error("Failed to publish user auth token event", "error", err)
+	}
+}
+
 func hashToken(token string) string {
 	hashBytes := sha256.Sum256([]byte(token + setting.SecretKey))
 	return hex.EncodeToString(hashBytes[:])
@@ -0,0 +1,224 @@
+// Package clientcert authenticates the users presenting a client certificate, either on the TLS connection or
+// forwarded by a TLS terminating proxy.
+package clientcert
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// The attributes of the certificates the login of the users can be mapped from
+const (
+	AttributeCommonName = "common_name"
+	AttributeEmail      = "email"
+	AttributeDNS        = "dns"
+	AttributeURI        = "uri"
+)
+
+var (
+	ErrInvalidCertificate = errors.New("invalid client certificate")
+	ErrMissingLogin       = errors.New("client certificate has no login attribute")
+	ErrUntrustedProxy     = errors.New("client certificate header is not from an allowed proxy")
+)
+
+// oidEmailAddress is the emailAddress attribute of the subjects, deprecated for the email SAN but still common
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+type Service struct {
+	cfg     *setting.Cfg
+	roots   *x509.CertPool
+	proxies []*net.IPNet
+	log     log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg) (*Service, error) {
+	s := &Service{cfg: cfg, log: log.New("auth.clientcert")}
+	if !cfg.ClientCertAuthEnabled {
+		return s, nil
+	}
+
+	switch cfg.ClientCertAuthLoginAttribute {
+	case AttributeCommonName, AttributeEmail, AttributeDNS, AttributeURI:
+	default:
+		return nil, fmt.Errorf("invalid client certificate login_attribute %q", cfg.ClientCertAuthLoginAttribute)
+	}
+
+	if cfg.ClientCertAuthCAFile != "" {
+		roots, err := LoadCertPool(cfg.ClientCertAuthCAFile)
+		if err != nil {
+			return nil, err
+		}
+		s.roots = roots
+	}
+
+	if cfg.ClientCertAuthHeaderName != "" {
+		if len(cfg.ClientCertAuthAllowedProxies) == 0 {
+			return nil, errors.New("client certificate allowed_proxies must be set with header_name")
+		}
+		for _, proxy := range cfg.ClientCertAuthAllowedProxies {
+			network, err := parseNetwork(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate allowed proxy %q: %w", proxy, err)
+			}
+			s.proxies = append(s.proxies, network)
+		}
+	} else if s.roots == nil {
+		return nil, errors.New("client certificate ca_file must be set when the certificates are not forwarded by a proxy")
+	}
+
+	return s, nil
+}
+
+// LoadCertPool reads the PEM bundle of certificate authorities
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `path` comes from the configuration file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("client certificate ca_file %q has no PEM certificate", path)
+	}
+	return pool, nil
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.cfg.ClientCertAuthEnabled
+}
+
+// Certificate returns the verified client certificate of the request, or nil when there is none. The certificate of
+// the TLS connection is verified by the server already. The certificate forwarded by a proxy is only accepted from an
+// allowed proxy, and is verified again when a ca_file is configured.
+func (s *Service) Certificate(req *http.Request) (*x509.Certificate, error) {
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		return req.TLS.VerifiedChains[0][0], nil
+	}
+
+	if s.cfg.ClientCertAuthHeaderName == "" {
+		return nil, nil
+	}
+	header := req.Header.Get(s.cfg.ClientCertAuthHeaderName)
+	if header == "" {
+		return nil, nil
+	}
+	if !s.isAllowedProxy(req.RemoteAddr) {
+		return nil, ErrUntrustedProxy
+	}
+
+	cert, err := parseHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)
+	}
+	if s.roots != nil {
+		opts := x509.VerifyOptions{Roots: s.roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+		if _, err := cert.Verify(opts); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)
+		}
+	}
+	return cert, nil
+}
+
+// ExternalUser maps the certificate to a user, the login is the configured attribute and the email is the first
+// email SAN or the email address of the subject
+func (s *Service) ExternalUser(cert *x509.Certificate) (*models.ExternalUserInfo, error) {
+	user := &models.ExternalUserInfo{
+		AuthModule: models.AuthModuleClientCert,
+		AuthId:     cert.Subject.String(),
+		Name:       cert.Subject.CommonName,
+		Email:      certificateEmail(cert),
+	}
+
+	switch s.cfg.ClientCertAuthLoginAttribute {
+	case AttributeCommonName:
+		user.Login = cert.Subject.CommonName
+	case AttributeEmail:
+		user.Login = user.Email
+	case AttributeDNS:
+		if len(cert.DNSNames) > 0 {
+			user.Login = cert.DNSNames[0]
+		}
+	case AttributeURI:
+		if len(cert.URIs) > 0 {
+			user.Login = cert.URIs[0].String()
+		}
+	}
+	if user.Login == "" {
+		return nil, ErrMissingLogin
+	}
+	return user, nil
+}
+
+func (s *Service) isAllowedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHeader decodes a certificate forwarded as URL encoded PEM, as by NGINX, or as base64 DER
+func parseHeader(header string) (*x509.Certificate, error) {
+	// The plus signs of base64 are not spaces
+	value, err := url.PathUnescape(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(value)); block != nil {
+		der = block.Bytes
+	} else if der, err = base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err != nil {
+		return nil, errors.New("header is neither PEM nor base64 DER")
+	}
+	return x509.ParseCertificate(der)
+}
+
+func parseNetwork(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		if strings.Contains(value, ":") {
+			value += "/128"
+		} else {
+			value += "/32"
+		}
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
+
+func certificateEmail(cert *x509.Certificate) string {
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	for _, name := range cert.Subject.Names {
+		if name.Type.Equal(oidEmailAddress) {
+			if email, ok := name.Value.(string); ok {
+				return email
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+package clientcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestProvideService(t *testing.T) {
+	ca, _ := newTestCertificate(t, nil, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}, IsCA: true})
+	caFile := writeTestCA(t, ca)
+
+	tests := []struct {
+		name        string
+		configure   func(cfg *setting.Cfg)
+		expectedErr string
+	}{
+		{
+			name:      "disabled",
+			configure: func(cfg *setting.Cfg) { cfg.ClientCertAuthEnabled = false },
+		},
+		{
+			name:      "certificate authorities",
+			configure: func(cfg *setting.Cfg) { cfg.ClientCertAuthCAFile = caFile },
+		},
+		{
+			name: "proxy header",
+			configure: func(cfg *setting.Cfg) {
+				cfg.ClientCertAuthHeaderName = "X-Client-Cert"
+				cfg.ClientCertAuthAllowedProxies = []string{"10.0.0.1", "192.168.0.0/16"}
+			},
+		},
+		{
+			name:        "no certificate authorities nor proxy",
+			configure:   func(cfg *setting.Cfg) {},
+			expectedErr: "client certificate ca_file must be set when the certificates are not forwarded by a proxy",
+		},
+		{
+			name:        "proxy header without allowed proxies",
+			configure:   func(cfg *setting.Cfg) { cfg.ClientCertAuthHeaderName = "X-Client-Cert" },
+			expectedErr: "client certificate allowed_proxies must be set with header_name",
+		},
+		{
+			name: "invalid login attribute",
+			configure: func(cfg *setting.Cfg) {
+				cfg.ClientCertAuthCAFile = caFile
+				cfg.ClientCertAuthLoginAttribute = "serial"
+			},
+			expectedErr: `invalid client certificate login_attribute "serial"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.ClientCertAuthEnabled = true
+			cfg.ClientCertAuthLoginAttribute = AttributeCommonName
+			tc.configure(cfg)
+
+			_, err := ProvideService(cfg)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Certificate(t *testing.T) {
+	ca, caKey := newTestCertificate(t, nil, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}, IsCA: true})
+	otherCA, otherCAKey := newTestCertificate(t, nil, &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, IsCA: true})
+	cert, _ := newTestCertificate(t, &testIssuer{ca, caKey}, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}})
+	untrusted, _ := newTestCertificate(t, &testIssuer{otherCA, otherCAKey}, &x509.Certificate{Subject: pkix.Name{CommonName: "mallory"}})
+
+	cfg := setting.NewCfg()
+	cfg.ClientCertAuthEnabled = true
+	cfg.ClientCertAuthLoginAttribute = AttributeCommonName
+	cfg.ClientCertAuthCAFile = writeTestCA(t, ca)
+	cfg.ClientCertAuthHeaderName = "X-Client-Cert"
+	cfg.ClientCertAuthAllowedProxies = []string{"10.0.0.1"}
+	s, err := ProvideService(cfg)
+	require.NoError(t, err)
+
+	request := func(remoteAddr, header string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.RemoteAddr = remoteAddr
+		if header != "" {
+			req.Header.Set("X-Client-Cert", header)
+		}
+		return req
+	}
+	escapedPEM := url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+
+	t.Run("should return the verified certificate of the connection", func(t *testing.T) {
+		req := request("10.0.0.2:4000", "")
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert, ca}}}
+		result, err := s.Certificate(req)
+		require.NoError(t, err)
+		assert.Equal(t, cert, result)
+	})
+
+	t.Run("should return no certificate without a verified certificate", func(t *testing.T) {
+		req := request("10.0.0.2:4000", "")
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrusted}}
+		result, err := s.Certificate(req)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("should return the certificate forwarded by an allowed proxy", func(t *testing.T) {
+		for _, header := range []string{escapedPEM, base64.StdEncoding.EncodeToString(cert.Raw)} {
+			result, err := s.Certificate(request("10.0.0.1:4000", header))
+			require.NoError(t, err)
+			assert.Equal(t, "alice", result.Subject.CommonName)
+		}
+	})
+
+	t.Run("should refuse the certificate forwarded by another address", func(t *testing.T) {
+		_, err := s.Certificate(request("10.0.0.2:4000", escapedPEM))
+		assert.ErrorIs(t, err, ErrUntrustedProxy)
+	})
+
+	t.Run("should refuse the forwarded certificate of another authority", func(t *testing.T) {
+		_, err := s.Certificate(request("10.0.0.1:4000", base64.StdEncoding.EncodeToString(untrusted.Raw)))
+		assert.ErrorIs(t, err, ErrInvalidCertificate)
+		_, err = s.Certificate(request("10.0.0.1:4000", "not a certificate"))
+		assert.ErrorIs(t, err, ErrInvalidCertificate)
+	})
+}
+
+func TestService_ExternalUser(t *testing.T) {
+	uri, err := url.Parse("spiffe://example.org/ns/monitoring/sa/alice")
+	require.NoError(t, err)
+	cert, _ := newTestCertificate(t, nil, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "Alice", Organization: []string{"Example"}},
+		EmailAddresses: []string{"alice@example.org"},
+		DNSNames:       []string{"alice.example.org"},
+		URIs:           []*url.URL{uri},
+	})
+
+	tests := []struct {
+		attribute     string
+		expectedLogin string
+	}{
+		{attribute: AttributeCommonName, expectedLogin: "Alice"},
+		{attribute: AttributeEmail, expectedLogin: "alice@example.org"},
+		{attribute: AttributeDNS, expectedLogin: "alice.example.org"},
+		{attribute: AttributeURI, expectedLogin: "spiffe://example.org/ns/monitoring/sa/alice"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.attribute, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.ClientCertAuthLoginAttribute = tc.attribute
+			s := &Service{cfg: cfg}
+
+			user, err := s.ExternalUser(cert)
+			require.NoError(t, err)
+			assert.Equal(t, &models.ExternalUserInfo{
+				AuthModule: models.AuthModuleClientCert,
+				AuthId:     "CN=Alice,O=Example",
+				Login:      tc.expectedLogin,
+				Name:       "Alice",
+				Email:      "alice@example.org",
+			}, user)
+		})
+	}
+
+	t.Run("should use the email address of the subject", func(t *testing.T) {
+		cert, _ := newTestCertificate(t, nil, &x509.Certificate{Subject: pkix.Name{
+			CommonName: "Bob",
+			ExtraNames: []pkix.AttributeTypeAndValue{{Type: oidEmailAddress, Value: "bob@example.org"}},
+		}})
+		cfg := setting.NewCfg()
+		cfg.ClientCertAuthLoginAttribute = AttributeEmail
+		s := &Service{cfg: cfg}
+
+		user, err := s.ExternalUser(cert)
+		require.NoError(t, err)
+		assert.Equal(t, "bob@example.org", user.Login)
+
+		cfg.ClientCertAuthLoginAttribute = AttributeDNS
+		_, err = s.ExternalUser(cert)
+		assert.ErrorIs(t, err, ErrMissingLogin)
+	})
+}
+
+type testIssuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCertificate creates a certificate for client authentication from the template, self-signed when there is no
+// issuer
+func newTestCertificate(t *testing.T, issuer *testIssuer, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	if template.IsCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+		template.BasicConstraintsValid = true
+	}
+
+	parent, parentKey := template, key
+	if issuer != nil {
+		parent, parentKey = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeTestCA(t *testing.T, ca *x509.Certificate) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600)
+	require.NoError(t, err)
+	return path
+}
@@ -7,16 +7,18 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 const ServiceName = "AuthService"
 
-func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) (*AuthService, error) {
-	s := newService(cfg, remoteCache)
+func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache, sqlStore *sqlstore.SQLStore) (*AuthService, error) {
+	s := newService(cfg, remoteCache, sqlStore)
 	if err := s.init(); err != nil {
 		return nil, err
 	}
@@ -24,10 +26,11 @@ func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) (*Au
 	return s, nil
 }
 
-func newService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) *AuthService {
+func newService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache, sqlStore *sqlstore.SQLStore) *AuthService {
 	return &AuthService{
 		Cfg:         cfg,
 		RemoteCache: remoteCache,
+		SQLStore:    sqlStore,
 		log:         log.New("auth.jwt"),
 	}
 }
@@ -43,6 +46,9 @@ func (s *AuthService) init() error {
 	if err := s.initKeySet(); err != nil {
 		return err
 	}
+	if err := s.initClaimMapping(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -50,11 +56,13 @@ func (s *AuthService) init() error {
 type AuthService struct {
 	Cfg         *setting.Cfg
 	RemoteCache *remotecache.RemoteCache
+	SQLStore    *sqlstore.SQLStore
 
 	keySet           keySet
 	log              log.Logger
 	expect           map[string]interface{}
 	expectRegistered jwt.Expected
+	claimMapping     []*claimMappingRule
 }
 
 // Sanitize JWT base64 strings to remove paddings everywhere
@@ -66,20 +74,27 @@ func sanitizeJWT(jwtToken string) string {
 }
 
 func (s *AuthService) Verify(ctx context.Context, strToken string) (models.JWTClaims, error) {
+	claims, result, err := s.verify(ctx, strToken)
+	metrics.MJWTVerificationTotal.WithLabelValues(result).Inc()
+	return claims, err
+}
+
+// verify verifies the token and returns the result used by the metrics
+func (s *AuthService) verify(ctx context.Context, strToken string) (models.JWTClaims, string, error) {
 	s.log.Debug("Parsing JSON Web Token")
 
 	strToken = sanitizeJWT(strToken)
 	token, err := jwt.ParseSigned(strToken)
 	if err != nil {
-		return nil, err
+		return nil, "invalid_token", err
 	}
 
 	keys, err := s.keySet.Key(ctx, token.Headers[0].KeyID)
 	if err != nil {
-		return nil, err
+		return nil, "key_set_error", err
 	}
 	if len(keys) == 0 {
-		return nil, errors.New("no keys found")
+		return nil, "unknown_key", errors.New("no keys found")
 	}
 
 	s.log.Debug("Trying to verify JSON Web Token using a key")
@@ -91,14 +106,14 @@ func (s *AuthService) Verify(ctx context.Context, strToken string) (models.JWTCl
 		}
 	}
 	if err != nil {
-		return nil, err
+		return nil, "invalid_signature", err
 	}
 
 	s.log.Debug("Validating JSON Web Token claims")
 
 	if err = s.validateClaims(claims); err != nil {
-		return nil, err
+		return nil, "invalid_claims", err
 	}
 
-	return claims, nil
+	return claims, "success", nil
 }
@@ -99,6 +99,13 @@ func TestVerifyUsingJWKSetFile(t *testing.T) {
 		_, err := sc.authJWTSvc.Verify(sc.ctx, token)
 		require.Error(t, err)
 	}, configure)
+
+	scenario(t, "verifies a token without key ID using the keys from the set", func(t *testing.T, sc scenarioContext) {
+		token := sign(t, rsaKeys[1], jwt.Claims{Subject: subject})
+		verifiedClaims, err := sc.authJWTSvc.Verify(sc.ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, verifiedClaims["sub"], subject)
+	}, configure)
 }
 
 func TestVerifyUsingJWKSetURL(t *testing.T) {
@@ -163,6 +170,33 @@ func TestCachingJWKHTTPResponse(t *testing.T) {
 	}, func(t *testing.T, cfg *setting.Cfg) {
 		// Arbitrary high value, several times what the test should take.
 		cfg.JWTAuthCacheTTL = time.Minute
+		cfg.JWTAuthJWKSetMinRefreshInterval = time.Minute
+	})
+
+	jwkCachingScenario(t, "gets the key set again when the token is signed with an unknown key", func(t *testing.T, sc cachingScenarioContext) {
+		keySet := sc.authJWTSvc.keySet.(*keySetHTTP)
+		now := time.Now()
+		keySet.now = func() time.Time { return now }
+
+		_, err := sc.authJWTSvc.Verify(sc.ctx, sign(t, &jwKeys[0], jwt.Claims{Subject: subject}))
+		require.NoError(t, err)
+
+		// The key set has just been fetched
+		_, err = sc.authJWTSvc.Verify(sc.ctx, sign(t, &jwKeys[1], jwt.Claims{Subject: subject}))
+		require.Error(t, err)
+		assert.Equal(t, 1, *sc.reqCount)
+
+		now = now.Add(2 * time.Minute)
+		_, err = sc.authJWTSvc.Verify(sc.ctx, sign(t, &jwKeys[1], jwt.Claims{Subject: subject}))
+		require.NoError(t, err)
+		assert.Equal(t, 2, *sc.reqCount)
+
+		// The key set has been rotated and is cached
+		_, err = sc.authJWTSvc.Verify(sc.ctx, sign(t, &jwKeys[1], jwt.Claims{Subject: subject}))
+		require.NoError(t, err)
+		assert.Equal(t, 2, *sc.reqCount)
+	}, func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthJWKSetMinRefreshInterval = time.Minute
 	})
 
 	jwkCachingScenario(t, "does not cache the response when TTL is zero", func(t *testing.T, sc cachingScenarioContext) {
@@ -381,7 +415,7 @@ func initAuthService(t *testing.T, cbs ...configureFunc) (*AuthService, error) {
 		cb(t, cfg)
 	}
 
-	service := newService(cfg, remotecache.NewFakeStore(t))
+	service := newService(cfg, remotecache.NewFakeStore(t), nil)
 	err := service.init()
 	return service, err
 }
@@ -13,9 +13,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	jose "gopkg.in/square/go-jose.v2"
 )
@@ -40,6 +42,13 @@ type keySetHTTP struct {
 	cache           *remotecache.RemoteCache
 	cacheKey        string
 	cacheExpiration time.Duration
+	// minRefreshInterval limits how often the key set is fetched again, bypassing the cache, when a token is
+	// signed with a key that is not in the cached set, usually because the keys of the provider have been rotated
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu        sync.Mutex
+	lastFetch time.Time
 }
 
 func (s *AuthService) checkKeySetConfiguration() error {
@@ -157,14 +166,23 @@ func (s *AuthService) initKeySet() error {
 			cacheKey:        fmt.Sprintf("auth-jwt:jwk-%s", urlStr),
 			cacheExpiration: s.Cfg.JWTAuthCacheTTL,
 			cache:           s.RemoteCache,
+
+			minRefreshInterval: s.Cfg.JWTAuthJWKSetMinRefreshInterval,
+			now:                time.Now,
 		}
 	}
 
 	return nil
 }
 
+// Key returns the keys of the set with the key ID of the token. When the token has no key ID, all the keys of the
+// set are tried.
 func (ks keySetJWKS) Key(ctx context.Context, keyID string) ([]jose.JSONWebKey, error) {
-	return ks.JSONWebKeySet.Key(keyID), nil
+	keys := ks.JSONWebKeySet.Key(keyID)
+	if len(keys) == 0 && keyID == "" {
+		return ks.Keys, nil
+	}
+	return keys, nil
 }
 
 func (ks *keySetHTTP) getJWKS(ctx context.Context) (keySetJWKS, error) {
@@ -172,10 +190,33 @@ func (ks *keySetHTTP) getJWKS(ctx context.Context) (keySetJWKS, error) {
 
 	if ks.cacheExpiration > 0 {
 		if val, err := ks.cache.Get(ctx, ks.cacheKey); err == nil {
+			metrics.MJWTKeySetCacheTotal.WithLabelValues("hit").Inc()
 			err := json.Unmarshal(val.([]byte), &jwks)
 			return jwks, err
 		}
+		metrics.MJWTKeySetCacheTotal.WithLabelValues("miss").Inc()
+	}
+
+	return ks.fetchJWKS(ctx, "expired")
+}
+
+// fetchJWKS gets the key set from the endpoint and caches it, the reason is used by the metrics
+func (ks *keySetHTTP) fetchJWKS(ctx context.Context, reason string) (keySetJWKS, error) {
+	ks.mu.Lock()
+	ks.lastFetch = ks.now()
+	ks.mu.Unlock()
+
+	jwks, err := ks.requestJWKS(ctx)
+	if err != nil {
+		metrics.MJWTKeySetFetchTotal.WithLabelValues(reason, "failure").Inc()
+		return jwks, err
 	}
+	metrics.MJWTKeySetFetchTotal.WithLabelValues(reason, "success").Inc()
+	return jwks, nil
+}
+
+func (ks *keySetHTTP) requestJWKS(ctx context.Context) (keySetJWKS, error) {
+	var jwks keySetJWKS
 
 	ks.log.Debug("Getting key set from endpoint", "url", ks.url)
 
@@ -194,6 +235,10 @@ func (ks *keySetHTTP) getJWKS(ctx context.Context) (keySetJWKS, error) {
 		}
 	}()
 
+	if resp.StatusCode != http.StatusOK {
+		return jwks, fmt.Errorf("failed to get key set: unexpected status %d", resp.StatusCode)
+	}
+
 	var jsonBuf bytes.Buffer
 	if err := json.NewDecoder(io.TeeReader(resp.Body, &jsonBuf)).Decode(&jwks); err != nil {
 		return jwks, err
@@ -205,10 +250,36 @@ func (ks *keySetHTTP) getJWKS(ctx context.Context) (keySetJWKS, error) {
 	return jwks, err
 }
 
-func (ks keySetHTTP) Key(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+// Key returns the keys of the set with the key ID of the token. When the cached set has no such key, the keys of the
+// provider may have been rotated and the set is fetched again, unless it was fetched less than the minimum refresh
+// interval ago so that tokens signed with unknown keys cannot flood the endpoint.
+func (ks *keySetHTTP) Key(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
 	jwks, err := ks.getJWKS(ctx)
 	if err != nil {
 		return nil, err
 	}
+	keys, err := jwks.Key(ctx, kid)
+	if err != nil || len(keys) > 0 || kid == "" || !ks.allowRefresh() {
+		return keys, err
+	}
+
+	ks.log.Debug("Key set has no key with the key ID of the token, getting key set again", "kid", kid)
+	if jwks, err = ks.fetchJWKS(ctx, "unknown_key"); err != nil {
+		return nil, err
+	}
 	return jwks.Key(ctx, kid)
 }
+
+// allowRefresh returns whether the key set can be fetched again for an unknown key. The fetch is recorded right away
+// so that concurrent requests with the same unknown key fetch the key set once.
+func (ks *keySetHTTP) allowRefresh() bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	now := ks.now()
+	if !ks.lastFetch.IsZero() && now.Sub(ks.lastFetch) < ks.minRefreshInterval {
+		return false
+	}
+	ks.lastFetch = now
+	return true
+}
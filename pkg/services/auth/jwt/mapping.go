@@ -0,0 +1,200 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// claimMappingRule maps the claims of a token to a role in an organization and to teams of the organization. The
+// fields are templates executed with the claims, a rule applies when both its org and its role are not empty.
+type claimMappingRule struct {
+	// Org is the ID or the name of the organization
+	Org  string `json:"org"`
+	Role string `json:"role"`
+	// Teams are the names of the teams of the organization, a template can produce several comma-separated names
+	Teams []string `json:"teams"`
+
+	org   *template.Template
+	role  *template.Template
+	teams []*template.Template
+}
+
+// claimMapping is the result of the rules for the claims of a token
+type claimMapping struct {
+	OrgRoles map[int64]models.RoleType
+	Teams    map[int64][]string
+}
+
+var claimTemplateFuncs = template.FuncMap{
+	"has":   claimHas,
+	"join":  claimJoin,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+func (s *AuthService) initClaimMapping() error {
+	if s.Cfg.JWTAuthClaimMapping == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(s.Cfg.JWTAuthClaimMapping), &s.claimMapping); err != nil {
+		return fmt.Errorf("invalid claim mapping: %w", err)
+	}
+
+	for i, rule := range s.claimMapping {
+		if rule.Org == "" || rule.Role == "" {
+			return fmt.Errorf("invalid claim mapping: rule %d must have an org and a role", i)
+		}
+
+		var err error
+		if rule.org, err = parseClaimTemplate(rule.Org); err != nil {
+			return fmt.Errorf("invalid claim mapping: org of rule %d: %w", i, err)
+		}
+		if rule.role, err = parseClaimTemplate(rule.Role); err != nil {
+			return fmt.Errorf("invalid claim mapping: role of rule %d: %w", i, err)
+		}
+		for _, team := range rule.Teams {
+			tmpl, err := parseClaimTemplate(team)
+			if err != nil {
+				return fmt.Errorf("invalid claim mapping: teams of rule %d: %w", i, err)
+			}
+			rule.teams = append(rule.teams, tmpl)
+		}
+	}
+
+	return nil
+}
+
+func parseClaimTemplate(text string) (*template.Template, error) {
+	return template.New("").Funcs(claimTemplateFuncs).Parse(text)
+}
+
+// mapClaims applies the claim mapping rules to the claims. When several rules map to the same organization, the
+// highest role is kept and the teams are merged. Rules with an unknown organization or an invalid role are skipped.
+func (s *AuthService) mapClaims(ctx context.Context, claims models.JWTClaims) (*claimMapping, error) {
+	result := &claimMapping{
+		OrgRoles: map[int64]models.RoleType{},
+		Teams:    map[int64][]string{},
+	}
+
+	for i, rule := range s.claimMapping {
+		org, err := executeClaimTemplate(rule.org, claims)
+		if err != nil {
+			return nil, err
+		}
+		role, err := executeClaimTemplate(rule.role, claims)
+		if err != nil {
+			return nil, err
+		}
+		if org == "" || role == "" {
+			continue
+		}
+
+		roleType := models.RoleType(role)
+		if !roleType.IsValid() {
+			s.log.Warn("Claim mapping rule has an invalid role", "rule", i, "role", role)
+			continue
+		}
+
+		orgID, err := getOrgID(ctx, org)
+		if err != nil {
+			if errors.Is(err, models.ErrOrgNotFound) {
+				s.log.Debug("Claim mapping rule has an unknown organization", "rule", i, "org", org)
+				continue
+			}
+			return nil, err
+		}
+
+		if current, ok := result.OrgRoles[orgID]; !ok || !current.Includes(roleType) {
+			result.OrgRoles[orgID] = roleType
+		}
+
+		for _, tmpl := range rule.teams {
+			teams, err := executeClaimTemplate(tmpl, claims)
+			if err != nil {
+				return nil, err
+			}
+			for _, team := range strings.Split(teams, ",") {
+				if team = strings.TrimSpace(team); team != "" {
+					result.Teams[orgID] = appendUnique(result.Teams[orgID], team)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func executeClaimTemplate(tmpl *template.Template, claims models.JWTClaims) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}(claims)); err != nil {
+		return "", fmt.Errorf("failed to execute claim mapping template: %w", err)
+	}
+	// Missing claims are printed as "<no value>"
+	return strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", "")), nil
+}
+
+func getOrgID(ctx context.Context, org string) (int64, error) {
+	if id, err := strconv.ParseInt(org, 10, 64); err == nil {
+		query := &models.GetOrgByIdQuery{Id: id}
+		if err := bus.Dispatch(ctx, query); err != nil {
+			return 0, err
+		}
+		return query.Result.Id, nil
+	}
+
+	query := &models.GetOrgByNameQuery{Name: org}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		return 0, err
+	}
+	return query.Result.Id, nil
+}
+
+// claimHas returns whether the claim is the value or, for an array claim, contains the value
+func claimHas(claim interface{}, value string) bool {
+	switch claim := claim.(type) {
+	case string:
+		return claim == value
+	case []interface{}:
+		for _, v := range claim {
+			if fmt.Sprint(v) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimJoin joins the values of an array claim
+func claimJoin(claim interface{}, sep string) string {
+	switch claim := claim.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, 0, len(claim))
+		for _, v := range claim {
+			values = append(values, fmt.Sprint(v))
+		}
+		return strings.Join(values, sep)
+	default:
+		return fmt.Sprint(claim)
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
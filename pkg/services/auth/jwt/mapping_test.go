@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestClaimMapping(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	orgs := map[string]int64{"Main Org.": 1, "tenant-a": 2}
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetOrgByNameQuery) error {
+		id, ok := orgs[query.Name]
+		if !ok {
+			return models.ErrOrgNotFound
+		}
+		query.Result = &models.Org{Id: id, Name: query.Name}
+		return nil
+	})
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetOrgByIdQuery) error {
+		for name, id := range orgs {
+			if id == query.Id {
+				query.Result = &models.Org{Id: id, Name: name}
+				return nil
+			}
+		}
+		return models.ErrOrgNotFound
+	})
+
+	mapClaims := func(t *testing.T, mapping string, claims models.JWTClaims) *claimMapping {
+		t.Helper()
+		s, err := initAuthService(t, configurePKIXPublicKeyFile, func(t *testing.T, cfg *setting.Cfg) {
+			cfg.JWTAuthClaimMapping = mapping
+		})
+		require.NoError(t, err)
+		result, err := s.mapClaims(context.Background(), claims)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should map the claims to organizations, roles and teams", func(t *testing.T) {
+		result := mapClaims(t, `[
+			{"org": "Main Org.", "role": "Viewer"},
+			{"org": "{{ .tenant }}", "role": "{{ if has .groups \"admins\" }}Admin{{ else }}Editor{{ end }}", "teams": ["{{ join .groups \",\" }}", "{{ lower .department }}"]}
+		]`, models.JWTClaims{"tenant": "tenant-a", "groups": []interface{}{"admins", "devs"}, "department": "SRE"})
+
+		assert.Equal(t, map[int64]models.RoleType{1: models.ROLE_VIEWER, 2: models.ROLE_ADMIN}, result.OrgRoles)
+		assert.Equal(t, map[int64][]string{2: {"admins", "devs", "sre"}}, result.Teams)
+	})
+
+	t.Run("should keep the highest role of an organization", func(t *testing.T) {
+		result := mapClaims(t, `[
+			{"org": "1", "role": "Editor", "teams": ["a"]},
+			{"org": "Main Org.", "role": "{{ .role }}", "teams": ["b"]},
+			{"org": "1", "role": "Viewer", "teams": ["a"]}
+		]`, models.JWTClaims{"role": "Admin"})
+
+		assert.Equal(t, map[int64]models.RoleType{1: models.ROLE_ADMIN}, result.OrgRoles)
+		assert.Equal(t, map[int64][]string{1: {"a", "b"}}, result.Teams)
+	})
+
+	t.Run("should skip the rules that do not apply", func(t *testing.T) {
+		result := mapClaims(t, `[
+			{"org": "{{ .tenant }}", "role": "Editor"},
+			{"org": "unknown", "role": "Editor"},
+			{"org": "Main Org.", "role": "Owner"},
+			{"org": "Main Org.", "role": "{{ if has .groups \"admins\" }}Admin{{ end }}"}
+		]`, models.JWTClaims{"groups": "devs"})
+
+		assert.Empty(t, result.OrgRoles)
+		assert.Empty(t, result.Teams)
+	})
+
+	t.Run("should refuse to start with an invalid claim mapping", func(t *testing.T) {
+		for _, mapping := range []string{
+			`{"org": "Main Org.", "role": "Viewer"}`,
+			`[{"org": "Main Org."}]`,
+			`[{"org": "{{ .tenant ", "role": "Viewer"}]`,
+			`[{"org": "Main Org.", "role": "Viewer", "teams": ["{{ unknown .groups }}"]}]`,
+		} {
+			_, err := initAuthService(t, configurePKIXPublicKeyFile, func(t *testing.T, cfg *setting.Cfg) {
+				cfg.JWTAuthClaimMapping = mapping
+			})
+			assert.Error(t, err, mapping)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var ErrMissingLoginClaim = errors.New("token has no login or email claim")
+
+// SyncUser creates the user of the claims when auto sign up is enabled, or updates it, with the organizations, roles
+// and teams mapped from the claims. The teams of the users are synced in the mapped organizations only, and only
+// the memberships added by the sync are removed.
+func (s *AuthService) SyncUser(ctx *models.ReqContext, claims models.JWTClaims) (int64, error) {
+	extUser := &models.ExternalUserInfo{AuthModule: models.AuthModuleJWT}
+	extUser.AuthId, _ = claims["sub"].(string)
+	if key := s.Cfg.JWTAuthUsernameClaim; key != "" {
+		extUser.Login, _ = claims[key].(string)
+	}
+	if key := s.Cfg.JWTAuthEmailClaim; key != "" {
+		extUser.Email, _ = claims[key].(string)
+	}
+	if key := s.Cfg.JWTAuthNameClaim; key != "" {
+		extUser.Name, _ = claims[key].(string)
+	}
+	if extUser.Login == "" && extUser.Email == "" {
+		return 0, ErrMissingLoginClaim
+	}
+	if extUser.Login == "" {
+		extUser.Login = extUser.Email
+	}
+
+	mapping, err := s.mapClaims(ctx.Req.Context(), claims)
+	if err != nil {
+		return 0, err
+	}
+	extUser.OrgRoles = mapping.OrgRoles
+	for _, teams := range mapping.Teams {
+		for _, team := range teams {
+			extUser.Groups = appendUnique(extUser.Groups, team)
+		}
+	}
+
+	upsert := &models.UpsertUserCommand{
+		ReqContext:    ctx,
+		SignupAllowed: s.Cfg.JWTAuthAutoSignUp,
+		ExternalUser:  extUser,
+	}
+	if err := bus.Dispatch(ctx.Req.Context(), upsert); err != nil {
+		return 0, err
+	}
+
+	if len(s.claimMapping) > 0 {
+		for orgID := range mapping.OrgRoles {
+			if err := s.syncTeams(ctx.Req.Context(), upsert.Result.Id, orgID, mapping.Teams[orgID]); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return upsert.Result.Id, nil
+}
+
+// syncTeams adds the user to the existing teams of the organization, and removes the user from the teams it was added
+// to by a previous sync but that are no longer mapped
+func (s *AuthService) syncTeams(ctx context.Context, userID, orgID int64, teams []string) error {
+	mapped := map[string]bool{}
+	for _, team := range teams {
+		mapped[team] = true
+	}
+
+	userTeams := &models.GetTeamsByUserQuery{OrgId: orgID, UserId: userID}
+	if err := bus.Dispatch(ctx, userTeams); err != nil {
+		return err
+	}
+
+	member := map[string]bool{}
+	for _, team := range userTeams.Result {
+		member[team.Name] = true
+		if mapped[team.Name] {
+			continue
+		}
+
+		members := &models.GetTeamMembersQuery{OrgId: orgID, TeamId: team.Id, UserId: userID, External: true}
+		if err := bus.Dispatch(ctx, members); err != nil {
+			return err
+		}
+		if len(members.Result) == 0 {
+			continue
+		}
+		s.log.Debug("Removing user from team no longer mapped from the claims", "userId", userID, "orgId", orgID, "team", team.Name)
+		if err := bus.Dispatch(ctx, &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: userID}); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range teams {
+		if member[name] {
+			continue
+		}
+
+		query := &models.SearchTeamsQuery{OrgId: orgID, Name: name, Limit: 1, Page: 1}
+		if err := bus.Dispatch(ctx, query); err != nil {
+			return err
+		}
+		if len(query.Result.Teams) == 0 {
+			s.log.Debug("Team mapped from the claims does not exist", "orgId", orgID, "team", name)
+			continue
+		}
+		s.log.Debug("Adding user to team mapped from the claims", "userId", userID, "orgId", orgID, "team", name)
+		err := s.SQLStore.AddTeamMember(userID, orgID, query.Result.Teams[0].Id, true, 0)
+		if err != nil && !errors.Is(err, models.ErrTeamMemberAlreadyAdded) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package pluginauth signs in the users with the authentication providers of the backend app plugins, for the single
+// sign-on systems Grafana has no built-in support for. The providers are discovered in the plugin.json files of the
+// installed plugins at startup.
+package pluginauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// resourcePath is the resource of the plugins handling the logins and the tokens of their providers
+const resourcePath = "auth/"
+
+var (
+	ErrProviderNotFound = errors.New("authentication provider not found")
+	ErrLoginFailed      = errors.New("authentication provider refused the login")
+	ErrInvalidToken     = errors.New("authentication provider refused the token")
+	ErrMissingLogin     = errors.New("authentication provider returned a user without login nor email")
+)
+
+// Provider is an authentication provider of a backend app plugin
+type Provider struct {
+	// Id is the ID of the plugin and the ID of the provider, separated by a slash
+	Id       string `json:"id"`
+	PluginId string `json:"pluginId"`
+	Name     string `json:"name"`
+
+	// resource is the path of the resources of the provider in the plugin
+	resource string
+}
+
+// UserInfo is the user a provider maps a login or a token to
+type UserInfo struct {
+	// AuthId is the ID of the user in the system of the provider
+	AuthId         string          `json:"authId"`
+	Login          string          `json:"login"`
+	Email          string          `json:"email"`
+	Name           string          `json:"name"`
+	Role           models.RoleType `json:"role"`
+	IsGrafanaAdmin *bool           `json:"isGrafanaAdmin"`
+	Groups         []string        `json:"groups"`
+}
+
+// LoginResult is the outcome of a login with a provider, either a redirection of the browser, for instance to the
+// single sign-on system or back from it, or the signed in user
+type LoginResult struct {
+	RedirectURL string
+	// Cookies are the Set-Cookie headers of the provider, which keeps the state of the login in its own cookies
+	Cookies []string
+	User    *models.ExternalUserInfo
+}
+
+type tokenRequest struct {
+	Token string `json:"token"`
+}
+
+type Service struct {
+	cfg          *setting.Cfg
+	pluginClient plugins.Client
+	providers    map[string]*Provider
+	log          log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, pluginStore plugins.Store, pluginClient plugins.Client) (*Service, error) {
+	s := &Service{
+		cfg:          cfg,
+		pluginClient: pluginClient,
+		providers:    map[string]*Provider{},
+		log:          log.New("auth.plugins"),
+	}
+	if !cfg.PluginAuthEnabled {
+		return s, nil
+	}
+
+	allowed := map[string]bool{}
+	for _, id := range cfg.PluginAuthAllowedProviders {
+		allowed[id] = true
+	}
+	for _, p := range pluginStore.Plugins(context.Background(), plugins.App) {
+		if !p.Backend {
+			continue
+		}
+		for _, provider := range p.AuthProviders {
+			id := p.ID + "/" + provider.ID
+			if len(allowed) > 0 && !allowed[id] {
+				continue
+			}
+			s.providers[id] = &Provider{Id: id, PluginId: p.ID, Name: provider.Name, resource: resourcePath + provider.ID}
+			s.log.Info("Registered authentication provider", "provider", id)
+		}
+	}
+	for id := range allowed {
+		if _, ok := s.providers[id]; !ok {
+			s.log.Warn("Allowed authentication provider is not registered by any plugin", "provider", id)
+		}
+	}
+
+	if cfg.PluginAuthTokenHeaderName != "" {
+		if _, ok := s.providers[cfg.PluginAuthTokenProvider]; !ok {
+			return nil, fmt.Errorf("plugin authentication token_provider %q is not a registered provider", cfg.PluginAuthTokenProvider)
+		}
+	}
+	return s, nil
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.cfg.PluginAuthEnabled
+}
+
+// IsTokenValidationEnabled returns true when the tokens of the token header are validated by a provider
+func (s *Service) IsTokenValidationEnabled() bool {
+	return s.cfg.PluginAuthEnabled && s.cfg.PluginAuthTokenHeaderName != ""
+}
+
+// Providers returns the registered authentication providers, sorted by ID
+func (s *Service) Providers() []*Provider {
+	result := make([]*Provider, 0, len(s.providers))
+	for _, p := range s.providers {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// Login calls the login resource of the provider with the query and the cookies of the login request, other than
+// the Grafana session cookie. The provider answers with a redirection or with the signed in user.
+func (s *Service) Login(ctx context.Context, id string, req *http.Request) (*LoginResult, error) {
+	provider, ok := s.providers[id]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	path := provider.resource + "/login"
+	url := path
+	if req.URL.RawQuery != "" {
+		url += "?" + req.URL.RawQuery
+	}
+	headers := map[string][]string{
+		"X-Grafana-Login-Url": {s.cfg.AppURL + "login/plugin/" + id},
+	}
+	if cookie := s.forwardedCookies(req); cookie != "" {
+		headers["Cookie"] = []string{cookie}
+	}
+
+	sender, err := s.callResource(ctx, provider, http.MethodGet, path, url, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch sender.status {
+	case http.StatusOK:
+		user, err := s.externalUser(provider, sender.body.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, Cookies: header(sender.headers, "Set-Cookie")}, nil
+	case http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect:
+		location := header(sender.headers, "Location")
+		if len(location) == 0 || location[0] == "" {
+			return nil, fmt.Errorf("%w: redirection without location", ErrLoginFailed)
+		}
+		return &LoginResult{RedirectURL: location[0], Cookies: header(sender.headers, "Set-Cookie")}, nil
+	default:
+		return nil, fmt.Errorf("%w: status %d: %s", ErrLoginFailed, sender.status, strings.TrimSpace(sender.body.String()))
+	}
+}
+
+// ValidateToken calls the token resource of the token provider, which maps a valid token to its user
+func (s *Service) ValidateToken(ctx context.Context, token string) (*models.ExternalUserInfo, error) {
+	provider, ok := s.providers[s.cfg.PluginAuthTokenProvider]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	body, err := json.Marshal(tokenRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	path := provider.resource + "/token"
+	headers := map[string][]string{"Content-Type": {"application/json"}}
+	sender, err := s.callResource(ctx, provider, http.MethodPost, path, path, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	if sender.status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrInvalidToken, sender.status)
+	}
+	return s.externalUser(provider, sender.body.Bytes())
+}
+
+func (s *Service) callResource(ctx context.Context, provider *Provider, method string, path string, url string,
+	headers map[string][]string, body []byte) (*responseSender, error) {
+	req := &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{PluginID: provider.PluginId},
+		Path:          path,
+		Method:        method,
+		URL:           url,
+		Headers:       headers,
+		Body:          body,
+	}
+	sender := &responseSender{}
+	if err := s.pluginClient.CallResource(ctx, req, sender); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
+
+// externalUser maps the user returned by a provider to an external user, with the role in the organization the new
+// users are assigned to
+func (s *Service) externalUser(provider *Provider, body []byte) (*models.ExternalUserInfo, error) {
+	var info UserInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("invalid user of authentication provider %s: %w", provider.Id, err)
+	}
+	if info.Login == "" {
+		info.Login = info.Email
+	}
+	if info.Login == "" {
+		return nil, ErrMissingLogin
+	}
+	if info.AuthId == "" {
+		info.AuthId = info.Login
+	}
+
+	user := &models.ExternalUserInfo{
+		AuthModule:     AuthModule(provider.Id),
+		AuthId:         info.AuthId,
+		Login:          info.Login,
+		Email:          info.Email,
+		Name:           info.Name,
+		IsGrafanaAdmin: info.IsGrafanaAdmin,
+		Groups:         info.Groups,
+		OrgRoles:       map[int64]models.RoleType{},
+	}
+	if info.Role != "" {
+		if !info.Role.IsValid() {
+			s.log.Warn("Ignored invalid role of authentication provider", "provider", provider.Id, "role", info.Role)
+		} else {
+			orgID := int64(1)
+			if s.cfg.AutoAssignOrg && s.cfg.AutoAssignOrgId > 0 {
+				orgID = int64(s.cfg.AutoAssignOrgId)
+			}
+			user.OrgRoles[orgID] = info.Role
+		}
+	}
+	return user, nil
+}
+
+// forwardedCookies returns the cookies of the request without the Grafana session cookie
+func (s *Service) forwardedCookies(req *http.Request) string {
+	cookies := make([]string, 0)
+	for _, c := range req.Cookies() {
+		if c.Name == s.cfg.LoginCookieName {
+			continue
+		}
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+	return strings.Join(cookies, "; ")
+}
+
+// AuthModule returns the auth module of the users signed in with a provider
+func AuthModule(providerID string) string {
+	return "plugin_" + providerID
+}
+
+// header returns the values of a header of a resource response, whose names are not canonicalized
+func header(headers map[string][]string, name string) []string {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// responseSender collects the response of a resource call
+type responseSender struct {
+	status  int
+	headers map[string][]string
+	body    bytes.Buffer
+}
+
+func (s *responseSender) Send(resp *backend.CallResourceResponse) error {
+	// The status and the headers are part of the first response only
+	if s.status == 0 {
+		s.status = resp.Status
+		s.headers = resp.Headers
+	}
+	_, err := s.body.Write(resp.Body)
+	return err
+}
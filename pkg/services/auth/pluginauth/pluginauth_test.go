@@ -0,0 +1,210 @@
+package pluginauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestProvideService(t *testing.T) {
+	t.Run("should register the providers of the backend app plugins", func(t *testing.T) {
+		s, err := ProvideService(newTestCfg(), newFakePluginStore(), &fakePluginClient{})
+		require.NoError(t, err)
+		assert.Equal(t, []*Provider{
+			{Id: "sso-app/corp", PluginId: "sso-app", Name: "Corp SSO", resource: "auth/corp"},
+			{Id: "sso-app/partners", PluginId: "sso-app", Name: "Partners", resource: "auth/partners"},
+		}, s.Providers())
+	})
+
+	t.Run("should only register the allowed providers", func(t *testing.T) {
+		cfg := newTestCfg()
+		cfg.PluginAuthAllowedProviders = []string{"sso-app/partners", "missing/provider"}
+		s, err := ProvideService(cfg, newFakePluginStore(), &fakePluginClient{})
+		require.NoError(t, err)
+		require.Len(t, s.Providers(), 1)
+		assert.Equal(t, "sso-app/partners", s.Providers()[0].Id)
+	})
+
+	t.Run("should not register providers when disabled", func(t *testing.T) {
+		cfg := newTestCfg()
+		cfg.PluginAuthEnabled = false
+		s, err := ProvideService(cfg, newFakePluginStore(), &fakePluginClient{})
+		require.NoError(t, err)
+		assert.Empty(t, s.Providers())
+	})
+
+	t.Run("should fail when the token provider is not registered", func(t *testing.T) {
+		cfg := newTestCfg()
+		cfg.PluginAuthTokenHeaderName = "X-Corp-Token"
+		cfg.PluginAuthTokenProvider = "sso-app/unknown"
+		_, err := ProvideService(cfg, newFakePluginStore(), &fakePluginClient{})
+		require.Error(t, err)
+	})
+}
+
+func TestService_Login(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/login/plugin/sso-app/corp?code=abc&state=xyz", nil)
+		req.AddCookie(&http.Cookie{Name: "grafana_session", Value: "secret"})
+		req.AddCookie(&http.Cookie{Name: "corp_state", Value: "xyz"})
+		return req
+	}
+
+	t.Run("should redirect to the location of the provider", func(t *testing.T) {
+		client := &fakePluginClient{response: &backend.CallResourceResponse{
+			Status:  http.StatusFound,
+			Headers: map[string][]string{"location": {"https://sso.corp.example/authorize"}, "Set-Cookie": {"corp_state=xyz"}},
+		}}
+		s, err := ProvideService(newTestCfg(), newFakePluginStore(), client)
+		require.NoError(t, err)
+
+		result, err := s.Login(context.Background(), "sso-app/corp", newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, "https://sso.corp.example/authorize", result.RedirectURL)
+		assert.Equal(t, []string{"corp_state=xyz"}, result.Cookies)
+		assert.Nil(t, result.User)
+
+		require.Len(t, client.requests, 1)
+		req := client.requests[0]
+		assert.Equal(t, "sso-app", req.PluginContext.PluginID)
+		assert.Equal(t, "auth/corp/login", req.Path)
+		assert.Equal(t, "auth/corp/login?code=abc&state=xyz", req.URL)
+		assert.Equal(t, []string{"corp_state=xyz"}, req.Headers["Cookie"])
+		assert.Equal(t, []string{"http://localhost:3000/login/plugin/sso-app/corp"}, req.Headers["X-Grafana-Login-Url"])
+	})
+
+	t.Run("should map the user of the provider", func(t *testing.T) {
+		client := &fakePluginClient{response: userResponse(t, UserInfo{
+			AuthId: "42",
+			Email:  "jane@corp.example",
+			Name:   "Jane",
+			Role:   models.ROLE_EDITOR,
+			Groups: []string{"sre"},
+		})}
+		s, err := ProvideService(newTestCfg(), newFakePluginStore(), client)
+		require.NoError(t, err)
+
+		result, err := s.Login(context.Background(), "sso-app/corp", newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, &models.ExternalUserInfo{
+			AuthModule: "plugin_sso-app/corp",
+			AuthId:     "42",
+			Login:      "jane@corp.example",
+			Email:      "jane@corp.example",
+			Name:       "Jane",
+			Groups:     []string{"sre"},
+			OrgRoles:   map[int64]models.RoleType{2: models.ROLE_EDITOR},
+		}, result.User)
+	})
+
+	t.Run("should fail when the provider refuses the login", func(t *testing.T) {
+		client := &fakePluginClient{response: &backend.CallResourceResponse{Status: http.StatusUnauthorized}}
+		s, err := ProvideService(newTestCfg(), newFakePluginStore(), client)
+		require.NoError(t, err)
+
+		_, err = s.Login(context.Background(), "sso-app/corp", newRequest())
+		assert.ErrorIs(t, err, ErrLoginFailed)
+
+		_, err = s.Login(context.Background(), "sso-app/unknown", newRequest())
+		assert.ErrorIs(t, err, ErrProviderNotFound)
+	})
+
+	t.Run("should fail when the user has no login", func(t *testing.T) {
+		client := &fakePluginClient{response: userResponse(t, UserInfo{Name: "Jane"})}
+		s, err := ProvideService(newTestCfg(), newFakePluginStore(), client)
+		require.NoError(t, err)
+
+		_, err = s.Login(context.Background(), "sso-app/corp", newRequest())
+		assert.ErrorIs(t, err, ErrMissingLogin)
+	})
+}
+
+func TestService_ValidateToken(t *testing.T) {
+	cfg := newTestCfg()
+	cfg.PluginAuthTokenHeaderName = "X-Corp-Token"
+	cfg.PluginAuthTokenProvider = "sso-app/corp"
+
+	t.Run("should map the user of a valid token", func(t *testing.T) {
+		client := &fakePluginClient{response: userResponse(t, UserInfo{Login: "jane"})}
+		s, err := ProvideService(cfg, newFakePluginStore(), client)
+		require.NoError(t, err)
+		require.True(t, s.IsTokenValidationEnabled())
+
+		user, err := s.ValidateToken(context.Background(), "t0ken")
+		require.NoError(t, err)
+		assert.Equal(t, "jane", user.Login)
+		assert.Equal(t, "jane", user.AuthId)
+
+		require.Len(t, client.requests, 1)
+		assert.Equal(t, "auth/corp/token", client.requests[0].Path)
+		assert.JSONEq(t, `{"token": "t0ken"}`, string(client.requests[0].Body))
+	})
+
+	t.Run("should fail when the provider refuses the token", func(t *testing.T) {
+		client := &fakePluginClient{response: &backend.CallResourceResponse{Status: http.StatusUnauthorized}}
+		s, err := ProvideService(cfg, newFakePluginStore(), client)
+		require.NoError(t, err)
+
+		_, err = s.ValidateToken(context.Background(), "t0ken")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func newTestCfg() *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.PluginAuthEnabled = true
+	cfg.AppURL = "http://localhost:3000/"
+	cfg.LoginCookieName = "grafana_session"
+	cfg.AutoAssignOrg = true
+	cfg.AutoAssignOrgId = 2
+	return cfg
+}
+
+func userResponse(t *testing.T, user UserInfo) *backend.CallResourceResponse {
+	t.Helper()
+	body, err := json.Marshal(user)
+	require.NoError(t, err)
+	return &backend.CallResourceResponse{Status: http.StatusOK, Body: body}
+}
+
+type fakePluginStore struct {
+	plugins.Store
+	plugins []plugins.PluginDTO
+}
+
+func newFakePluginStore() *fakePluginStore {
+	return &fakePluginStore{plugins: []plugins.PluginDTO{
+		{JSONData: plugins.JSONData{ID: "sso-app", Type: plugins.App, Backend: true, AuthProviders: []*plugins.AuthProvider{
+			{ID: "partners", Name: "Partners"},
+			{ID: "corp", Name: "Corp SSO"},
+		}}},
+		{JSONData: plugins.JSONData{ID: "frontend-app", Type: plugins.App, AuthProviders: []*plugins.AuthProvider{
+			{ID: "corp", Name: "Corp SSO"},
+		}}},
+	}}
+}
+
+func (s *fakePluginStore) Plugins(_ context.Context, _ ...plugins.Type) []plugins.PluginDTO {
+	return s.plugins
+}
+
+type fakePluginClient struct {
+	plugins.Client
+	requests []*backend.CallResourceRequest
+	response *backend.CallResourceResponse
+}
+
+func (c *fakePluginClient) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	c.requests = append(c.requests, req)
+	return sender.Send(c.response)
+}
@@ -0,0 +1,190 @@
+package calendar
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxImportSize is the maximum size of an imported iCalendar document.
+const maxImportSize = 10 << 20
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/calendars", func(calendarRoute routing.RouteRegister) {
+		calendarRoute.Get("/", routing.Wrap(s.getCalendarsHandler))
+		calendarRoute.Post("/", middleware.ReqEditorRole, routing.Wrap(s.createCalendarHandler))
+		calendarRoute.Get("/:uid", routing.Wrap(s.getCalendarHandler))
+		calendarRoute.Put("/:uid", middleware.ReqEditorRole, routing.Wrap(s.updateCalendarHandler))
+		calendarRoute.Delete("/:uid", middleware.ReqEditorRole, routing.Wrap(s.deleteCalendarHandler))
+		calendarRoute.Get("/:uid/occurrences", routing.Wrap(s.getOccurrencesHandler))
+		calendarRoute.Post("/:uid/import", middleware.ReqEditorRole, routing.Wrap(s.importHandler))
+		calendarRoute.Get("/:uid/events", routing.Wrap(s.getEventsHandler))
+		calendarRoute.Post("/:uid/events", middleware.ReqEditorRole, routing.Wrap(s.createEventHandler))
+		calendarRoute.Put("/:uid/events/:eventUid", middleware.ReqEditorRole, routing.Wrap(s.updateEventHandler))
+		calendarRoute.Delete("/:uid/events/:eventUid", middleware.ReqEditorRole, routing.Wrap(s.deleteEventHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// GET /api/calendars
+func (s *Service) getCalendarsHandler(c *models.ReqContext) response.Response {
+	calendars, err := s.GetCalendars(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get calendars")
+	}
+	return response.JSON(http.StatusOK, calendars)
+}
+
+// GET /api/calendars/:uid
+func (s *Service) getCalendarHandler(c *models.ReqContext) response.Response {
+	calendar, err := s.GetCalendar(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get calendar")
+	}
+	return response.JSON(http.StatusOK, calendar)
+}
+
+// POST /api/calendars
+func (s *Service) createCalendarHandler(c *models.ReqContext) response.Response {
+	cmd := CalendarCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	calendar, err := s.CreateCalendar(c.Req.Context(), c.OrgId, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create calendar")
+	}
+	return response.JSON(http.StatusOK, calendar)
+}
+
+// PUT /api/calendars/:uid
+func (s *Service) updateCalendarHandler(c *models.ReqContext) response.Response {
+	cmd := CalendarCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	calendar, err := s.UpdateCalendar(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update calendar")
+	}
+	return response.JSON(http.StatusOK, calendar)
+}
+
+// DELETE /api/calendars/:uid
+func (s *Service) deleteCalendarHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteCalendar(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err, "Failed to delete calendar")
+	}
+	return response.Success("Calendar deleted")
+}
+
+// GET /api/calendars/:uid/occurrences?from=&to=
+func (s *Service) getOccurrencesHandler(c *models.ReqContext) response.Response {
+	from := time.Now()
+	if ms := c.QueryInt64("from"); ms > 0 {
+		from = time.UnixMilli(ms)
+	}
+	to := from.AddDate(0, 0, 7)
+	if ms := c.QueryInt64("to"); ms > 0 {
+		to = time.UnixMilli(ms)
+	}
+	if to.Before(from) {
+		return response.Error(http.StatusBadRequest, "to cannot be before from", nil)
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if _, err := s.GetCalendar(c.Req.Context(), c.OrgId, uid); err != nil {
+		return errorResponse(err, "Failed to get calendar")
+	}
+	occurrences, err := s.GetOccurrences(c.Req.Context(), c.OrgId, []string{uid}, from, to)
+	if err != nil {
+		return errorResponse(err, "Failed to get calendar occurrences")
+	}
+	return response.JSON(http.StatusOK, occurrences)
+}
+
+// POST /api/calendars/:uid/import?replace=true
+func (s *Service) importHandler(c *models.ReqContext) response.Response {
+	data, err := io.ReadAll(io.LimitReader(c.Req.Body, maxImportSize+1))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to read iCalendar data", err)
+	}
+	if len(data) > maxImportSize {
+		return response.Error(http.StatusRequestEntityTooLarge, "iCalendar data is too large", nil)
+	}
+
+	result, err := s.ImportICal(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], data, c.QueryBool("replace"))
+	if err != nil {
+		return errorResponse(err, "Failed to import calendar events")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/calendars/:uid/events
+func (s *Service) getEventsHandler(c *models.ReqContext) response.Response {
+	events, err := s.GetEvents(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get calendar events")
+	}
+	return response.JSON(http.StatusOK, events)
+}
+
+// POST /api/calendars/:uid/events
+func (s *Service) createEventHandler(c *models.ReqContext) response.Response {
+	cmd := EventCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	event, err := s.CreateEvent(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create calendar event")
+	}
+	return response.JSON(http.StatusOK, event)
+}
+
+// PUT /api/calendars/:uid/events/:eventUid
+func (s *Service) updateEventHandler(c *models.ReqContext) response.Response {
+	cmd := EventCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	params := web.Params(c.Req)
+	event, err := s.UpdateEvent(c.Req.Context(), c.OrgId, params[":uid"], params[":eventUid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update calendar event")
+	}
+	return response.JSON(http.StatusOK, event)
+}
+
+// DELETE /api/calendars/:uid/events/:eventUid
+func (s *Service) deleteEventHandler(c *models.ReqContext) response.Response {
+	params := web.Params(c.Req)
+	if err := s.DeleteEvent(c.Req.Context(), c.OrgId, params[":uid"], params[":eventUid"]); err != nil {
+		return errorResponse(err, "Failed to delete calendar event")
+	}
+	return response.Success("Calendar event deleted")
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrCalendarNotFound), errors.Is(err, ErrCalendarEventNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrCalendarNameExists), errors.Is(err, ErrCalendarEventUidExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrCalendarNameEmpty), errors.Is(err, ErrInvalidTimezone),
+		errors.Is(err, ErrCalendarEventTitleEmpty), errors.Is(err, ErrInvalidEventTime),
+		errors.Is(err, ErrInvalidRecurrence), errors.Is(err, ErrInvalidICal):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,224 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseICal returns the events of an iCalendar (RFC 5545) document. Floating times, and times in
+// unknown timezones, are read in loc.
+func parseICal(data []byte, loc *time.Location) ([]*EventCommand, error) {
+	lines, err := unfoldLines(data)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*EventCommand, 0)
+	var event *EventCommand
+	var duration *time.Duration
+	// nested counts the components, like alarms, nested in the current event
+	nested := 0
+	for i, line := range lines {
+		name, params, value, err := parseContentLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidICal, i+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			if event != nil {
+				return nil, fmt.Errorf("%w: line %d: nested VEVENT", ErrInvalidICal, i+1)
+			}
+			event, duration, nested = &EventCommand{Tags: []string{}}, nil, 0
+			continue
+		case event == nil:
+			continue
+		case name == "BEGIN":
+			nested++
+			continue
+		case name == "END" && nested > 0:
+			nested--
+			continue
+		case nested > 0:
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("%w: line %d: event without DTSTART", ErrInvalidICal, i+1)
+			}
+			switch {
+			case !event.End.IsZero():
+			case duration != nil:
+				event.End = event.Start.Add(*duration)
+			case event.AllDay:
+				event.End = event.Start.AddDate(0, 0, 1)
+			default:
+				event.End = event.Start
+			}
+			if event.Uid == "" {
+				sum := sha1.Sum([]byte(event.Title + event.Start.String()))
+				event.Uid = hex.EncodeToString(sum[:])
+			}
+			events = append(events, event)
+			event = nil
+			continue
+		}
+
+		switch name {
+		case "UID":
+			event.Uid = value
+		case "SUMMARY":
+			event.Title = unescapeText(value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(value)
+		case "CATEGORIES":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(unescapeText(tag)); tag != "" {
+					event.Tags = append(event.Tags, tag)
+				}
+			}
+		case "DTSTART", "DTEND":
+			t, allDay, err := parseDateTime(value, paramLocation(params, loc))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidICal, i+1, err)
+			}
+			if name == "DTSTART" {
+				event.Start, event.AllDay = t, allDay
+			} else {
+				event.End = t
+			}
+		case "DURATION":
+			d, err := parseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidICal, i+1, err)
+			}
+			duration = &d
+		case "RRULE":
+			event.Recurrence = value
+		}
+	}
+
+	if event != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidICal)
+	}
+	return events, nil
+}
+
+// unfoldLines joins the lines folded over several lines, continuation lines starting with a space
+// or a tab.
+func unfoldLines(data []byte) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidICal, err)
+	}
+	return lines, nil
+}
+
+// parseContentLine splits a `NAME;PARAM=VALUE:value` content line. Parameter values can be quoted
+// and contain colons.
+func parseContentLine(line string) (string, map[string]string, string, error) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("missing value in %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+func paramLocation(params map[string]string, loc *time.Location) *time.Location {
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			return l
+		}
+	}
+	return loc
+}
+
+// parseDateTime parses an iCalendar DATE or DATE-TIME value. Dates are returned as midnight UTC,
+// along with true.
+func parseDateTime(value string, loc *time.Location) (time.Time, bool, error) {
+	switch {
+	case len(value) == 8:
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	default:
+		t, err := time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, err
+	}
+}
+
+// parseDuration parses an iCalendar DURATION value, like P1D or PT1H30M.
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var d time.Duration
+	set := false
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		d += time.Duration(n) * unit
+		set = true
+	}
+	if !set {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+var textReplacer = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(value string) string {
+	return textReplacer.Replace(value)
+}
@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testICal = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:christmas@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20211225\r\n" +
+	"DTEND;VALUE=DATE:20211226\r\n" +
+	"SUMMARY:Christmas Day\r\n" +
+	"CATEGORIES:holiday,public\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:freeze@example.com\r\n" +
+	"DTSTART;TZID=Europe/Paris:20211220T090000\r\n" +
+	"DURATION:PT8H30M\r\n" +
+	"SUMMARY:Deploy freeze\\, all \r\n" +
+	" teams\r\n" +
+	"DESCRIPTION:No deploys\\nduring the holidays\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20211231T230000Z\r\n" +
+	"SUMMARY:New Year's Eve\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICal(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	events, err := parseICal([]byte(testICal), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, &EventCommand{
+		Uid:        "christmas@example.com",
+		Title:      "Christmas Day",
+		Tags:       []string{"holiday", "public"},
+		Start:      time.Date(2021, 12, 25, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2021, 12, 26, 0, 0, 0, 0, time.UTC),
+		AllDay:     true,
+		Recurrence: "FREQ=YEARLY",
+	}, events[0])
+
+	assert.Equal(t, "Deploy freeze, all teams", events[1].Title)
+	assert.Equal(t, "No deploys\nduring the holidays", events[1].Description)
+	assert.True(t, events[1].Start.Equal(time.Date(2021, 12, 20, 9, 0, 0, 0, paris)))
+	assert.Equal(t, 8*time.Hour+30*time.Minute, events[1].End.Sub(events[1].Start))
+
+	assert.NotEmpty(t, events[2].Uid, "events without uid should get a stable uid")
+	assert.Equal(t, events[2].Start, events[2].End)
+
+	again, err := parseICal([]byte(testICal), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, events[2].Uid, again[2].Uid)
+
+	t.Run("should fail on invalid documents", func(t *testing.T) {
+		for _, data := range []string{
+			"BEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\n",
+			"BEGIN:VEVENT\nDTSTART:2021\nEND:VEVENT\n",
+			"BEGIN:VEVENT\nDTSTART:20211225T100000Z\n",
+			"BEGIN:VEVENT\nno colon\nEND:VEVENT\n",
+		} {
+			_, err := parseICal([]byte(data), time.UTC)
+			assert.ErrorIs(t, err, ErrInvalidICal, data)
+		}
+	})
+}
+
+func TestParseDuration(t *testing.T) {
+	tcs := []struct {
+		input  string
+		result time.Duration
+		err    bool
+	}{
+		{input: "P1D", result: 24 * time.Hour},
+		{input: "P1W", result: 7 * 24 * time.Hour},
+		{input: "PT1H30M", result: 90 * time.Minute},
+		{input: "P1DT12H", result: 36 * time.Hour},
+		{input: "-PT15M", result: -15 * time.Minute},
+		{input: "P", err: true},
+		{input: "PT", err: true},
+		{input: "1D", err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			d, err := parseDuration(tc.input)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, d)
+		})
+	}
+}
+
+func TestRRule_Occurrences(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	hour := func(t time.Time) time.Time { return t.Add(time.Hour) }
+
+	tcs := []struct {
+		desc     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			desc:  "daily events keep their local time across daylight saving time changes",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2021, 11, 5, 9, 0, 0, 0, ny),
+			from:  time.Date(2021, 11, 6, 0, 0, 0, 0, ny),
+			to:    time.Date(2021, 11, 8, 0, 0, 0, 0, ny),
+			expected: []time.Time{
+				time.Date(2021, 11, 6, 9, 0, 0, 0, ny),
+				time.Date(2021, 11, 7, 9, 0, 0, 0, ny),
+			},
+		},
+		{
+			desc:  "weekly events repeat on the days of the week",
+			rule:  "FREQ=WEEKLY;BYDAY=SU,SA",
+			start: time.Date(2021, 12, 4, 0, 0, 0, 0, time.UTC),
+			from:  time.Date(2021, 12, 5, 12, 0, 0, 0, time.UTC),
+			to:    time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 12, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			desc:  "monthly events skip the months without their day",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			start: time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC),
+			from:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC),
+				time.Date(2021, 3, 31, 10, 0, 0, 0, time.UTC),
+				time.Date(2021, 5, 31, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			desc:  "events stop repeating after until",
+			rule:  "FREQ=YEARLY;INTERVAL=2;UNTIL=20250101T000000Z",
+			start: time.Date(2020, 2, 29, 10, 0, 0, 0, time.UTC),
+			from:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2020, 2, 29, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			desc:     "occurrences active at a point in time",
+			rule:     "FREQ=DAILY",
+			start:    time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+			from:     time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC),
+			to:       time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC),
+			expected: []time.Time{time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := parseRRule(tc.rule, time.UTC)
+			require.NoError(t, err)
+			occurrences := r.occurrences(tc.start, hour, tc.from, tc.to)
+			require.Len(t, occurrences, len(tc.expected))
+			for i := range tc.expected {
+				assert.True(t, tc.expected[i].Equal(occurrences[i]), "expected %s, got %s", tc.expected[i], occurrences[i])
+			}
+		})
+	}
+
+	t.Run("should reject unsupported rules", func(t *testing.T) {
+		for _, rule := range []string{"", "FREQ=HOURLY", "FREQ=MONTHLY;BYDAY=1MO", "FREQ=DAILY;BYHOUR=9", "FREQ=DAILY;COUNT=0"} {
+			_, err := parseRRule(rule, time.UTC)
+			assert.ErrorIs(t, err, ErrInvalidRecurrence, rule)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrCalendarNotFound        = errors.New("calendar not found")
+	ErrCalendarNameEmpty       = errors.New("calendar name cannot be empty")
+	ErrCalendarNameExists      = errors.New("a calendar with the same name already exists")
+	ErrInvalidTimezone         = errors.New("invalid calendar timezone")
+	ErrCalendarEventNotFound   = errors.New("calendar event not found")
+	ErrCalendarEventTitleEmpty = errors.New("calendar event title cannot be empty")
+	ErrCalendarEventUidExists  = errors.New("a calendar event with the same uid already exists")
+	ErrInvalidEventTime        = errors.New("calendar event cannot end before it starts")
+	ErrInvalidRecurrence       = errors.New("invalid calendar event recurrence")
+	ErrInvalidICal             = errors.New("invalid iCalendar data")
+)
+
+// Calendar is a named set of events, such as business hours, public holidays or deploy freezes,
+// that panels and alert rules can reference.
+type Calendar struct {
+	Id          int64     `json:"id"`
+	Uid         string    `json:"uid"`
+	OrgId       int64     `json:"-"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Timezone    string    `json:"timezone"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Location returns the location in which the events of the calendar repeat.
+func (c *Calendar) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Tags are the tags of a calendar event.
+type Tags []string
+
+// FromDB is part of the xorm Conversion interface.
+func (t *Tags) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*t = Tags{}
+		return nil
+	}
+	return json.Unmarshal(data, t)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (t *Tags) ToDB() ([]byte, error) {
+	if *t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t)
+}
+
+// Event is an event of a calendar. All-day events start at midnight UTC of their first day and end
+// at midnight UTC of the day after their last day, their occurrences span the same days in the
+// timezone of the calendar. Recurrence is an iCalendar RRULE value, like FREQ=WEEKLY;BYDAY=SA,SU.
+type Event struct {
+	Id          int64     `json:"id"`
+	Uid         string    `json:"uid"`
+	OrgId       int64     `json:"-"`
+	CalendarId  int64     `json:"-"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Tags        Tags      `json:"tags"`
+	StartTime   time.Time `json:"start"`
+	EndTime     time.Time `json:"end"`
+	AllDay      bool      `json:"allDay"`
+	Recurrence  string    `json:"recurrence"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (e *Event) TableName() string {
+	return "calendar_event"
+}
+
+type CalendarCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Timezone    string `json:"timezone"`
+}
+
+type EventCommand struct {
+	Uid         string    `json:"uid"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"allDay"`
+	Recurrence  string    `json:"recurrence"`
+}
+
+// ImportResult reports the events created, updated and deleted by an iCalendar import. Skipped
+// lists the uids of the events using recurrence rules that are not supported.
+type ImportResult struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Deleted int      `json:"deleted"`
+	Skipped []string `json:"skipped"`
+}
@@ -0,0 +1,184 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxIterations bounds the expansion of a recurring event, so that a daily event that started a
+// long time ago cannot make a query arbitrarily slow.
+const maxIterations = 100000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// rrule is the subset of the iCalendar recurrence rules (RFC 5545, section 3.3.10) supported by the
+// calendars: FREQ, INTERVAL, COUNT, UNTIL, and BYDAY without ordinals for weekly events.
+type rrule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	r := &rrule{interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(value), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRecurrence, part)
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			switch val {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.freq = val
+			default:
+				return nil, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidRecurrence, val)
+			}
+		case "INTERVAL", "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: invalid %s %q", ErrInvalidRecurrence, key, val)
+			}
+			if key == "INTERVAL" {
+				r.interval = n
+			} else {
+				r.count = n
+			}
+		case "UNTIL":
+			until, isDate, err := parseDateTime(val, loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRecurrence, val)
+			}
+			if isDate {
+				// Dates include the whole day in the timezone of the calendar
+				until = time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, -1, loc)
+			}
+			r.until = until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY %q", ErrInvalidRecurrence, day)
+				}
+				r.byDay = append(r.byDay, weekday)
+			}
+		case "WKST":
+			// Weeks always start on Monday, the default week start
+		default:
+			return nil, fmt.Errorf("%w: unsupported rule part %q", ErrInvalidRecurrence, key)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if len(r.byDay) > 0 && r.freq != "WEEKLY" {
+		return nil, fmt.Errorf("%w: BYDAY is only supported for weekly events", ErrInvalidRecurrence)
+	}
+	sort.Slice(r.byDay, func(i, j int) bool {
+		return daysFromMonday(r.byDay[i]) < daysFromMonday(r.byDay[j])
+	})
+	return r, nil
+}
+
+func daysFromMonday(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// occurrences returns the start of the occurrences of an event starting at start, in the location
+// of start, that overlap the from - to range. end returns the end of an occurrence.
+func (r *rrule) occurrences(start time.Time, end func(time.Time) time.Time, from, to time.Time) []time.Time {
+	result := make([]time.Time, 0)
+	emitted := 0
+	// emit reports whether the expansion must go on
+	emit := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if t.After(to) || (!r.until.IsZero() && t.After(r.until)) {
+			return false
+		}
+		if r.count > 0 && emitted >= r.count {
+			return false
+		}
+		emitted++
+		if overlaps(t, end(t), from, to) {
+			result = append(result, t)
+		}
+		return true
+	}
+
+	y, m, d := start.Date()
+	hour, min, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, start.Nanosecond(), start.Location())
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		n := i * r.interval
+		switch r.freq {
+		case "DAILY":
+			if !emit(at(y, m, d+n)) {
+				return result
+			}
+		case "WEEKLY":
+			if len(r.byDay) == 0 {
+				if !emit(at(y, m, d+7*n)) {
+					return result
+				}
+				continue
+			}
+			monday := d - daysFromMonday(start.Weekday()) + 7*n
+			for _, day := range r.byDay {
+				if !emit(at(y, m, monday+daysFromMonday(day))) {
+					return result
+				}
+			}
+		case "MONTHLY":
+			// Months without the day of the month of the event are skipped
+			t := at(y, m+time.Month(n), d)
+			if t.Day() != d {
+				continue
+			}
+			if !emit(t) {
+				return result
+			}
+		case "YEARLY":
+			// Years without February 29th are skipped for events on February 29th
+			t := at(y+n, m, d)
+			if t.Day() != d {
+				continue
+			}
+			if !emit(t) {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// overlaps reports whether the start - end range overlaps the from - to range. Events without a
+// duration overlap the ranges they are within.
+func overlaps(start, end, from, to time.Time) bool {
+	if start.After(to) {
+		return false
+	}
+	return end.After(from) || (start.Equal(end) && !start.Before(from))
+}
@@ -0,0 +1,298 @@
+package calendar
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Service manages the calendars of the organizations. Panels show the occurrences of calendar
+// events as annotations, and alert rules can mute their notifications while an event of a calendar
+// is active. Other services get the occurrences with a models.GetCalendarOccurrencesQuery.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	bus           bus.Bus
+	routeRegister routing.RouteRegister
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		bus:           bus,
+		routeRegister: routeRegister,
+	}
+	s.registerAPIEndpoints()
+	s.bus.AddHandler(s.getOccurrencesQueryHandler)
+	return s
+}
+
+// GetCalendars returns the calendars of the organization, sorted by name.
+func (s *Service) GetCalendars(ctx context.Context, orgID int64) ([]*Calendar, error) {
+	return s.getCalendars(ctx, orgID)
+}
+
+// GetCalendar returns a calendar of the organization.
+func (s *Service) GetCalendar(ctx context.Context, orgID int64, uid string) (*Calendar, error) {
+	return s.getCalendar(ctx, orgID, uid)
+}
+
+// CreateCalendar creates a calendar. Calendars without a timezone use UTC.
+func (s *Service) CreateCalendar(ctx context.Context, orgID int64, cmd CalendarCommand) (*Calendar, error) {
+	if err := validateCalendar(&cmd); err != nil {
+		return nil, err
+	}
+	return s.createCalendar(ctx, orgID, cmd)
+}
+
+// UpdateCalendar replaces the name, description and timezone of a calendar.
+func (s *Service) UpdateCalendar(ctx context.Context, orgID int64, uid string, cmd CalendarCommand) (*Calendar, error) {
+	if err := validateCalendar(&cmd); err != nil {
+		return nil, err
+	}
+
+	calendar, err := s.getCalendar(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar.Name = cmd.Name
+	calendar.Description = cmd.Description
+	calendar.Timezone = cmd.Timezone
+	if err := s.updateCalendar(ctx, calendar); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// DeleteCalendar deletes a calendar and its events.
+func (s *Service) DeleteCalendar(ctx context.Context, orgID int64, uid string) error {
+	return s.deleteCalendar(ctx, orgID, uid)
+}
+
+// GetEvents returns the events of a calendar, sorted by start.
+func (s *Service) GetEvents(ctx context.Context, orgID int64, calendarUID string) ([]*Event, error) {
+	calendar, err := s.getCalendar(ctx, orgID, calendarUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getEvents(ctx, calendar.Id)
+}
+
+// CreateEvent adds an event to a calendar. Events without a uid get a generated one.
+func (s *Service) CreateEvent(ctx context.Context, orgID int64, calendarUID string, cmd EventCommand) (*Event, error) {
+	if err := validateEvent(&cmd); err != nil {
+		return nil, err
+	}
+
+	calendar, err := s.getCalendar(ctx, orgID, calendarUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.createEvent(ctx, calendar, cmd)
+}
+
+// UpdateEvent replaces an event of a calendar. The uid of the event cannot be changed.
+func (s *Service) UpdateEvent(ctx context.Context, orgID int64, calendarUID, uid string, cmd EventCommand) (*Event, error) {
+	if err := validateEvent(&cmd); err != nil {
+		return nil, err
+	}
+
+	calendar, err := s.getCalendar(ctx, orgID, calendarUID)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.getEvent(ctx, calendar.Id, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	setEvent(event, cmd)
+	if err := s.updateEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// DeleteEvent deletes an event of a calendar.
+func (s *Service) DeleteEvent(ctx context.Context, orgID int64, calendarUID, uid string) error {
+	calendar, err := s.getCalendar(ctx, orgID, calendarUID)
+	if err != nil {
+		return err
+	}
+	return s.deleteEvent(ctx, calendar.Id, uid)
+}
+
+// ImportICal adds the events of an iCalendar document to a calendar. Events are matched by uid, so
+// importing the same document again updates the events. When replace is true, the events of the
+// calendar that are not in the document are deleted.
+func (s *Service) ImportICal(ctx context.Context, orgID int64, calendarUID string, data []byte, replace bool) (*ImportResult, error) {
+	calendar, err := s.getCalendar(ctx, orgID, calendarUID)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := parseICal(data, calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Skipped: make([]string, 0)}
+	valid := make([]EventCommand, 0, len(commands))
+	for _, cmd := range commands {
+		if err := validateEvent(cmd); err != nil {
+			result.Skipped = append(result.Skipped, cmd.Uid)
+			continue
+		}
+		valid = append(valid, *cmd)
+	}
+
+	if err := s.importEvents(ctx, calendar, valid, replace, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetOccurrences returns the occurrences of the events of the calendars that overlap the from - to
+// range, sorted by start. Unknown calendars are ignored.
+func (s *Service) GetOccurrences(ctx context.Context, orgID int64, calendarUIDs []string, from, to time.Time) ([]*models.CalendarOccurrence, error) {
+	result := make([]*models.CalendarOccurrence, 0)
+	for _, uid := range calendarUIDs {
+		calendar, err := s.getCalendar(ctx, orgID, uid)
+		if err != nil {
+			if err == ErrCalendarNotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		events, err := s.getEventsBefore(ctx, calendar.Id, to)
+		if err != nil {
+			return nil, err
+		}
+		loc := calendar.Location()
+		for _, event := range events {
+			result = append(result, expandEvent(calendar, event, loc, from, to)...)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result, nil
+}
+
+func (s *Service) getOccurrencesQueryHandler(ctx context.Context, query *models.GetCalendarOccurrencesQuery) error {
+	result, err := s.GetOccurrences(ctx, query.OrgId, query.CalendarUids, query.From, query.To)
+	if err != nil {
+		return err
+	}
+	query.Result = result
+	return nil
+}
+
+// expandEvent returns the occurrences of an event that overlap the from - to range. Recurring
+// events repeat at the same local time in the timezone of the calendar.
+func expandEvent(calendar *Calendar, event *Event, loc *time.Location, from, to time.Time) []*models.CalendarOccurrence {
+	start := event.StartTime.In(loc)
+	end := func(t time.Time) time.Time {
+		return t.Add(event.EndTime.Sub(event.StartTime))
+	}
+	if event.AllDay {
+		s, e := event.StartTime.UTC(), event.EndTime.UTC()
+		days := int(e.Sub(s).Hours()+12) / 24
+		start = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+		end = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, days)
+		}
+	}
+
+	starts := make([]time.Time, 0, 1)
+	if event.Recurrence == "" {
+		if overlaps(start, end(start), from, to) {
+			starts = append(starts, start)
+		}
+	} else {
+		rule, err := parseRRule(event.Recurrence, loc)
+		if err != nil {
+			// Rules are validated when events are saved
+			return nil
+		}
+		starts = rule.occurrences(start, end, from, to)
+	}
+
+	result := make([]*models.CalendarOccurrence, 0, len(starts))
+	for _, t := range starts {
+		result = append(result, &models.CalendarOccurrence{
+			CalendarUid: calendar.Uid,
+			EventUid:    event.Uid,
+			Title:       event.Title,
+			Tags:        event.Tags,
+			Start:       t,
+			End:         end(t),
+			AllDay:      event.AllDay,
+		})
+	}
+	return result
+}
+
+func setEvent(event *Event, cmd EventCommand) {
+	event.Title = cmd.Title
+	event.Description = cmd.Description
+	event.Tags = cmd.Tags
+	event.StartTime = cmd.Start
+	event.EndTime = cmd.End
+	event.AllDay = cmd.AllDay
+	event.Recurrence = cmd.Recurrence
+}
+
+func validateCalendar(cmd *CalendarCommand) error {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if cmd.Name == "" {
+		return ErrCalendarNameEmpty
+	}
+	if cmd.Timezone == "" {
+		cmd.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(cmd.Timezone); err != nil || strings.EqualFold(cmd.Timezone, "local") {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+func validateEvent(cmd *EventCommand) error {
+	cmd.Title = strings.TrimSpace(cmd.Title)
+	if cmd.Title == "" {
+		return ErrCalendarEventTitleEmpty
+	}
+	if cmd.AllDay {
+		// Only the dates of all-day events matter
+		cmd.Start = truncateToDate(cmd.Start)
+		cmd.End = truncateToDate(cmd.End)
+		if !cmd.End.After(cmd.Start) {
+			cmd.End = cmd.Start.AddDate(0, 0, 1)
+		}
+	}
+	if cmd.End.Before(cmd.Start) {
+		return ErrInvalidEventTime
+	}
+	if cmd.Recurrence != "" {
+		cmd.Recurrence = strings.TrimPrefix(strings.TrimSpace(cmd.Recurrence), "RRULE:")
+		if _, err := parseRRule(cmd.Recurrence, time.UTC); err != nil {
+			return err
+		}
+	}
+	if cmd.Tags == nil {
+		cmd.Tags = []string{}
+	}
+	return nil
+}
+
+func truncateToDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
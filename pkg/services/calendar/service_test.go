@@ -0,0 +1,129 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Calendars(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, bus.New(), routing.NewRouteRegister())
+
+	calendar, err := s.CreateCalendar(ctx, 1, CalendarCommand{Name: " Holidays ", Timezone: "America/New_York"})
+	require.NoError(t, err)
+	assert.Equal(t, "Holidays", calendar.Name)
+
+	t.Run("should validate calendars", func(t *testing.T) {
+		_, err := s.CreateCalendar(ctx, 1, CalendarCommand{Name: " "})
+		assert.ErrorIs(t, err, ErrCalendarNameEmpty)
+		_, err = s.CreateCalendar(ctx, 1, CalendarCommand{Name: "Freezes", Timezone: "Mars/Olympus"})
+		assert.ErrorIs(t, err, ErrInvalidTimezone)
+		_, err = s.CreateCalendar(ctx, 1, CalendarCommand{Name: "Holidays"})
+		assert.ErrorIs(t, err, ErrCalendarNameExists)
+
+		other, err := s.CreateCalendar(ctx, 2, CalendarCommand{Name: "Holidays"})
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", other.Timezone)
+		_, err = s.GetCalendar(ctx, 1, other.Uid)
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+	})
+
+	t.Run("should validate events", func(t *testing.T) {
+		start := time.Date(2021, 12, 20, 9, 0, 0, 0, time.UTC)
+		_, err := s.CreateEvent(ctx, 1, calendar.Uid, EventCommand{Title: " ", Start: start, End: start})
+		assert.ErrorIs(t, err, ErrCalendarEventTitleEmpty)
+		_, err = s.CreateEvent(ctx, 1, calendar.Uid, EventCommand{Title: "Freeze", Start: start, End: start.Add(-time.Hour)})
+		assert.ErrorIs(t, err, ErrInvalidEventTime)
+		_, err = s.CreateEvent(ctx, 1, calendar.Uid, EventCommand{Title: "Freeze", Start: start, End: start, Recurrence: "FREQ=HOURLY"})
+		assert.ErrorIs(t, err, ErrInvalidRecurrence)
+		_, err = s.CreateEvent(ctx, 1, "unknown", EventCommand{Title: "Freeze", Start: start, End: start})
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+	})
+
+	t.Run("should import events and expand them in the calendar timezone", func(t *testing.T) {
+		result, err := s.ImportICal(ctx, 1, calendar.Uid, []byte(testICal), false)
+		require.NoError(t, err)
+		assert.Equal(t, &ImportResult{Created: 3, Skipped: []string{}}, result)
+
+		ny := calendar.Location()
+		occurrences, err := s.GetOccurrences(ctx, 1, []string{calendar.Uid, "unknown"},
+			time.Date(2022, 12, 1, 0, 0, 0, 0, ny), time.Date(2022, 12, 31, 0, 0, 0, 0, ny))
+		require.NoError(t, err)
+		require.Len(t, occurrences, 1)
+		assert.Equal(t, "Christmas Day", occurrences[0].Title)
+		assert.Equal(t, []string{"holiday", "public"}, occurrences[0].Tags)
+		assert.True(t, occurrences[0].Start.Equal(time.Date(2022, 12, 25, 0, 0, 0, 0, ny)))
+		assert.True(t, occurrences[0].End.Equal(time.Date(2022, 12, 26, 0, 0, 0, 0, ny)))
+
+		// All-day events span the days in the timezone of the calendar
+		occurrences, err = s.GetOccurrences(ctx, 1, []string{calendar.Uid},
+			time.Date(2022, 12, 25, 2, 0, 0, 0, time.UTC), time.Date(2022, 12, 25, 2, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Len(t, occurrences, 0)
+	})
+
+	t.Run("should update events when importing again", func(t *testing.T) {
+		data := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:christmas@example.com\nDTSTART;VALUE=DATE:20211225\n" +
+			"SUMMARY:Christmas\nRRULE:FREQ=YEARLY\nEND:VEVENT\nBEGIN:VEVENT\nUID:unsupported\nDTSTART;VALUE=DATE:20211225\n" +
+			"SUMMARY:Unsupported\nRRULE:FREQ=MONTHLY;BYDAY=-1FR\nEND:VEVENT\nEND:VCALENDAR\n"
+		result, err := s.ImportICal(ctx, 1, calendar.Uid, []byte(data), true)
+		require.NoError(t, err)
+		assert.Equal(t, &ImportResult{Updated: 1, Deleted: 2, Skipped: []string{"unsupported"}}, result)
+
+		events, err := s.GetEvents(ctx, 1, calendar.Uid)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Christmas", events[0].Title)
+	})
+
+	t.Run("should answer occurrence queries on the bus", func(t *testing.T) {
+		query := &models.GetCalendarOccurrencesQuery{
+			OrgId:        1,
+			CalendarUids: []string{calendar.Uid},
+			From:         time.Date(2030, 12, 25, 12, 0, 0, 0, calendar.Location()),
+			To:           time.Date(2030, 12, 25, 12, 0, 0, 0, calendar.Location()),
+		}
+		require.NoError(t, s.bus.Dispatch(ctx, query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "christmas@example.com", query.Result[0].EventUid)
+	})
+
+	t.Run("should update and delete events", func(t *testing.T) {
+		start := time.Date(2021, 12, 20, 9, 0, 0, 0, time.UTC)
+		event, err := s.CreateEvent(ctx, 1, calendar.Uid, EventCommand{Title: "Freeze", Start: start, End: start.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = s.CreateEvent(ctx, 1, calendar.Uid, EventCommand{Uid: event.Uid, Title: "Freeze", Start: start, End: start})
+		assert.ErrorIs(t, err, ErrCalendarEventUidExists)
+
+		updated, err := s.UpdateEvent(ctx, 1, calendar.Uid, event.Uid, EventCommand{Title: "Freeze", Tags: []string{"deploy"}, Start: start, End: start.Add(2 * time.Hour), Recurrence: "RRULE:FREQ=WEEKLY"})
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY", updated.Recurrence)
+
+		events, err := s.GetEvents(ctx, 1, calendar.Uid)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, Tags{"deploy"}, events[0].Tags)
+
+		require.NoError(t, s.DeleteEvent(ctx, 1, calendar.Uid, event.Uid))
+		assert.ErrorIs(t, s.DeleteEvent(ctx, 1, calendar.Uid, event.Uid), ErrCalendarEventNotFound)
+	})
+
+	t.Run("should delete calendars with their events", func(t *testing.T) {
+		require.NoError(t, s.DeleteCalendar(ctx, 1, calendar.Uid))
+		assert.ErrorIs(t, s.DeleteCalendar(ctx, 1, calendar.Uid), ErrCalendarNotFound)
+
+		count, err := store.NewSession(ctx).Table("calendar_event").Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
@@ -0,0 +1,236 @@
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getCalendar(ctx context.Context, orgID int64, uid string) (*Calendar, error) {
+	calendar := &Calendar{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(calendar)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrCalendarNotFound
+		}
+		return nil
+	})
+	return calendar, err
+}
+
+func (s *Service) getCalendars(ctx context.Context, orgID int64) ([]*Calendar, error) {
+	result := make([]*Calendar, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) createCalendar(ctx context.Context, orgID int64, cmd CalendarCommand) (*Calendar, error) {
+	now := time.Now()
+	calendar := &Calendar{
+		Uid:         util.GenerateShortUID(),
+		OrgId:       orgID,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Timezone:    cmd.Timezone,
+		Created:     now,
+		Updated:     now,
+	}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateUniqueName(sess, calendar); err != nil {
+			return err
+		}
+		_, err := sess.Insert(calendar)
+		return err
+	})
+	return calendar, err
+}
+
+func (s *Service) updateCalendar(ctx context.Context, calendar *Calendar) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateUniqueName(sess, calendar); err != nil {
+			return err
+		}
+
+		calendar.Updated = time.Now()
+		_, err := sess.ID(calendar.Id).Cols("name", "description", "timezone", "updated").Update(calendar)
+		return err
+	})
+}
+
+func (s *Service) deleteCalendar(ctx context.Context, orgID int64, uid string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		calendar := &Calendar{}
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(calendar)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrCalendarNotFound
+		}
+
+		if _, err := sess.Exec("DELETE FROM calendar_event WHERE calendar_id = ?", calendar.Id); err != nil {
+			return err
+		}
+		_, err = sess.Exec("DELETE FROM calendar WHERE id = ?", calendar.Id)
+		return err
+	})
+}
+
+func (s *Service) getEvent(ctx context.Context, calendarID int64, uid string) (*Event, error) {
+	event := &Event{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("calendar_id = ? AND uid = ?", calendarID, uid).Get(event)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrCalendarEventNotFound
+		}
+		return nil
+	})
+	return event, err
+}
+
+func (s *Service) getEvents(ctx context.Context, calendarID int64) ([]*Event, error) {
+	result := make([]*Event, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("calendar_id = ?", calendarID).Asc("start_time", "id").Find(&result)
+	})
+	return result, err
+}
+
+// getEventsBefore returns the events of the calendar starting before the time, including the
+// recurring events whose first occurrence is before the time.
+func (s *Service) getEventsBefore(ctx context.Context, calendarID int64, to time.Time) ([]*Event, error) {
+	result := make([]*Event, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// All-day events start at midnight UTC, which can be up to a day after their start in the
+		// timezone of the calendar
+		return sess.Where("calendar_id = ? AND start_time <= ?", calendarID, to.Add(24*time.Hour)).Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) createEvent(ctx context.Context, calendar *Calendar, cmd EventCommand) (*Event, error) {
+	now := time.Now()
+	event := &Event{
+		Uid:        cmd.Uid,
+		OrgId:      calendar.OrgId,
+		CalendarId: calendar.Id,
+		Created:    now,
+		Updated:    now,
+	}
+	if event.Uid == "" {
+		event.Uid = util.GenerateShortUID()
+	}
+	setEvent(event, cmd)
+
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Table("calendar_event").Where("calendar_id = ? AND uid = ?", calendar.Id, event.Uid).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrCalendarEventUidExists
+		}
+		_, err = sess.Insert(event)
+		return err
+	})
+	return event, err
+}
+
+func (s *Service) updateEvent(ctx context.Context, event *Event) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		event.Updated = time.Now()
+		_, err := sess.ID(event.Id).AllCols().Omit("id", "uid", "org_id", "calendar_id", "created").Update(event)
+		return err
+	})
+}
+
+func (s *Service) deleteEvent(ctx context.Context, calendarID int64, uid string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM calendar_event WHERE calendar_id = ? AND uid = ?", calendarID, uid)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return ErrCalendarEventNotFound
+		}
+		return nil
+	})
+}
+
+// importEvents creates or updates the events, matching them by uid. When replace is true, the
+// other events of the calendar are deleted.
+func (s *Service) importEvents(ctx context.Context, calendar *Calendar, commands []EventCommand, replace bool, result *ImportResult) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		existing := make([]*Event, 0)
+		if err := sess.Where("calendar_id = ?", calendar.Id).Find(&existing); err != nil {
+			return err
+		}
+		byUID := make(map[string]*Event, len(existing))
+		for _, event := range existing {
+			byUID[event.Uid] = event
+		}
+
+		now := time.Now()
+		imported := make(map[string]bool, len(commands))
+		for _, cmd := range commands {
+			if imported[cmd.Uid] {
+				continue
+			}
+			imported[cmd.Uid] = true
+
+			if event, ok := byUID[cmd.Uid]; ok {
+				setEvent(event, cmd)
+				event.Updated = now
+				if _, err := sess.ID(event.Id).AllCols().Omit("id", "uid", "org_id", "calendar_id", "created").Update(event); err != nil {
+					return err
+				}
+				result.Updated++
+				continue
+			}
+
+			event := &Event{Uid: cmd.Uid, OrgId: calendar.OrgId, CalendarId: calendar.Id, Created: now, Updated: now}
+			setEvent(event, cmd)
+			if _, err := sess.Insert(event); err != nil {
+				return err
+			}
+			result.Created++
+		}
+
+		if !replace {
+			return nil
+		}
+		for _, event := range existing {
+			if imported[event.Uid] {
+				continue
+			}
+			if _, err := sess.Exec("DELETE FROM calendar_event WHERE id = ?", event.Id); err != nil {
+				return err
+			}
+			result.Deleted++
+		}
+		return nil
+	})
+}
+
+func validateUniqueName(sess *sqlstore.DBSession, calendar *Calendar) error {
+	exists, err := sess.Table("calendar").
+		Where("org_id = ? AND name = ? AND id != ?", calendar.OrgId, calendar.Name, calendar.Id).
+		Exist()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCalendarNameExists
+	}
+	return nil
+}
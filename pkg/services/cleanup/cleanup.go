@@ -14,19 +14,62 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/scheduler"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+var (
+	deletedTotal    *prometheus.CounterVec
+	failuresTotal   *prometheus.CounterVec
+	durationSeconds *prometheus.HistogramVec
+)
+
+func init() {
+	deletedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "cleanup_deleted_total",
+		Help:      "Number of files and rows deleted, or user invites expired, by each cleanup task",
+	}, []string{"task"})
+	failuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "cleanup_failures_total",
+		Help:      "Number of failed runs of each cleanup task",
+	}, []string{"task"})
+	durationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "grafana",
+		Name:      "cleanup_duration_seconds",
+		Help:      "Duration of the runs of each cleanup task",
+		Buckets:   []float64{.01, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"task"})
+}
+
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
-	shortURLService shorturls.Service) *CleanUpService {
+	shortURLService shorturls.Service, userRoleStore accesscontrol.UserRoleStore,
+	schedulerService *scheduler.Service) (*CleanUpService, error) {
 	s := &CleanUpService{
 		Cfg:               cfg,
 		ServerLockService: serverLockService,
 		ShortURLService:   shortURLService,
+		UserRoleStore:     userRoleStore,
 		log:               log.New("cleanup"),
 	}
-	return s
+
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "cleanup",
+		Description: "Deletes the temporary files, the expired snapshots, dashboard versions, annotations, invites, short URLs, user roles, login attempts, deleted users, deleted dashboards and expired federated tokens.",
+		Cron:        "*/10 * * * *",
+		Enabled:     true,
+		Timeout:     time.Minute * 9,
+		Run:         s.cleanUp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 type CleanUpService struct {
@@ -34,67 +77,100 @@ type CleanUpService struct {
 	Cfg               *setting.Cfg
 	ServerLockService *serverlock.ServerLockService
 	ShortURLService   shorturls.Service
+	UserRoleStore     accesscontrol.UserRoleStore
+}
+
+// cleanupTask deletes a kind of expired data and returns the number of files or rows it deleted
+type cleanupTask struct {
+	name string
+	run  func(ctx context.Context) (int64, error)
+	// exclusive tasks are run by a single Grafana instance when running in a cluster
+	exclusive bool
+}
+
+func (srv *CleanUpService) tasks() []cleanupTask {
+	return []cleanupTask{
+		{name: "tmp-files", run: srv.cleanUpTmpFiles},
+		{name: "snapshots", run: srv.deleteExpiredSnapshots},
+		{name: "dashboard-versions", run: srv.deleteExpiredDashboardVersions},
+		{name: "annotations", run: srv.cleanUpOldAnnotations},
+		{name: "user-invites-expiry", run: srv.expireOldUserInvites},
+		{name: "user-invites", run: srv.deleteExpiredUserInvites},
+		{name: "short-urls", run: srv.deleteStaleShortURLs},
+		{name: "user-roles", run: srv.deleteExpiredUserRoles},
+		{name: "login-attempts", run: srv.deleteOldLoginAttempts, exclusive: true},
+		{name: "deleted-users", run: srv.deleteExpiredDeletedUsers, exclusive: true},
+		{name: "dashboard-trash", run: srv.deleteExpiredDashboardTrash},
+		{name: "federated-tokens", run: srv.deleteExpiredFederatedTokens},
+	}
 }
 
-func (srv *CleanUpService) Run(ctx context.Context) error {
-	srv.cleanUpTmpFiles()
-
-	ticker := time.NewTicker(time.Minute * 10)
-	for {
-		select {
-		case <-ticker.C:
-			ctxWithTimeout, cancelFn := context.WithTimeout(ctx, time.Minute*9)
-			defer cancelFn()
-
-			srv.cleanUpTmpFiles()
-			srv.deleteExpiredSnapshots(ctx)
-			srv.deleteExpiredDashboardVersions(ctx)
-			srv.cleanUpOldAnnotations(ctxWithTimeout)
-			srv.expireOldUserInvites(ctx)
-			srv.deleteStaleShortURLs(ctx)
-			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
-				time.Minute*10, func(context.Context) {
-					srv.deleteOldLoginAttempts(ctx)
-				})
-			if err != nil {
-				srv.log.Error("failed to lock and execute cleanup of old login attempts", "error", err)
-			}
-		case <-ctx.Done():
-			return ctx.Err()
+// cleanUp is run by every Grafana instance every 10 minutes, the exclusive tasks by a single one when running in a
+// cluster. A failing task does not stop the next ones.
+func (srv *CleanUpService) cleanUp(ctx context.Context) error {
+	for _, task := range srv.tasks() {
+		if ctx.Err() != nil {
+			srv.log.Warn("Cleanup timed out", "task", task.name)
+			break
+		}
+		if !task.exclusive {
+			srv.runTask(ctx, task)
+			continue
+		}
+
+		task := task
+		err := srv.ServerLockService.LockAndExecute(ctx, "cleanup "+task.name, time.Minute*10, func(ctx context.Context) {
+			srv.runTask(ctx, task)
+		})
+		if err != nil {
+			srv.log.Error("Failed to lock and execute cleanup task", "task", task.name, "error", err)
 		}
 	}
+	return nil
 }
 
-func (srv *CleanUpService) cleanUpOldAnnotations(ctx context.Context) {
+func (srv *CleanUpService) runTask(ctx context.Context, task cleanupTask) {
+	start := time.Now()
+	deleted, err := task.run(ctx)
+	durationSeconds.WithLabelValues(task.name).Observe(time.Since(start).Seconds())
+	deletedTotal.WithLabelValues(task.name).Add(float64(deleted))
+
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		failuresTotal.WithLabelValues(task.name).Inc()
+		srv.log.Error("Cleanup task failed", "task", task.name, "deleted", deleted, "error", err)
+		return
+	}
+	srv.log.Debug("Cleanup task done", "task", task.name, "deleted", deleted, "duration", time.Since(start))
+}
+
+func (srv *CleanUpService) cleanUpOldAnnotations(ctx context.Context) (int64, error) {
 	cleaner := annotations.GetAnnotationCleaner()
 	affected, affectedTags, err := cleaner.CleanAnnotations(ctx, srv.Cfg)
-	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
-		srv.log.Error("failed to clean up old annotations", "error", err)
-	} else {
-		srv.log.Debug("Deleted excess annotations", "annotations affected", affected, "annotation tags affected", affectedTags)
-	}
+	return affected + affectedTags, err
 }
 
-func (srv *CleanUpService) cleanUpTmpFiles() {
+func (srv *CleanUpService) cleanUpTmpFiles(_ context.Context) (int64, error) {
 	folders := []string{
 		srv.Cfg.ImagesDir,
 		srv.Cfg.CSVsDir,
 	}
 
+	var deleted int64
 	for _, f := range folders {
-		srv.cleanUpTmpFolder(f)
+		deleted += srv.cleanUpTmpFolder(f)
 	}
+	return deleted, nil
 }
 
-func (srv *CleanUpService) cleanUpTmpFolder(folder string) {
+func (srv *CleanUpService) cleanUpTmpFolder(folder string) int64 {
 	if _, err := os.Stat(folder); os.IsNotExist(err) {
-		return
+		return 0
 	}
 
 	files, err := ioutil.ReadDir(folder)
 	if err != nil {
 		srv.log.Error("Problem reading dir", "folder", folder, "error", err)
-		return
+		return 0
 	}
 
 	var toDelete []os.FileInfo
@@ -106,15 +182,19 @@ func (srv *CleanUpService) cleanUpTmpFolder(folder string) {
 		}
 	}
 
+	var deleted int64
 	for _, file := range toDelete {
 		fullPath := path.Join(folder, file.Name())
 		err := os.Remove(fullPath)
 		if err != nil {
 			srv.log.Error("Failed to delete temp file", "file", file.Name(), "error", err)
+			continue
 		}
+		deleted++
 	}
 
 	srv.log.Debug("Found old rendered file to delete", "folder", folder, "deleted", len(toDelete), "kept", len(files))
+	return deleted
 }
 
 func (srv *CleanUpService) shouldCleanupTempFile(filemtime time.Time, now time.Time) bool {
@@ -125,59 +205,113 @@ func (srv *CleanUpService) shouldCleanupTempFile(filemtime time.Time, now time.T
 	return filemtime.Add(srv.Cfg.TempDataLifetime).Before(now)
 }
 
-func (srv *CleanUpService) deleteExpiredSnapshots(ctx context.Context) {
-	cmd := models.DeleteExpiredSnapshotsCommand{}
-	if err := bus.Dispatch(ctx, &cmd); err != nil {
-		srv.log.Error("Failed to delete expired snapshots", "error", err.Error())
-	} else {
-		srv.log.Debug("Deleted expired snapshots", "rows affected", cmd.DeletedRows)
+func (srv *CleanUpService) deleteExpiredSnapshots(ctx context.Context) (int64, error) {
+	cmd := models.DeleteExpiredSnapshotsCommand{
+		ExpiredBefore: time.Now().Add(-srv.Cfg.Cleanup.ExpiredSnapshotsRetention),
+		BatchSize:     srv.Cfg.Cleanup.BatchSize,
 	}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
 }
 
-func (srv *CleanUpService) deleteExpiredDashboardVersions(ctx context.Context) {
-	cmd := models.DeleteExpiredVersionsCommand{}
-	if err := bus.Dispatch(ctx, &cmd); err != nil {
-		srv.log.Error("Failed to delete expired dashboard versions", "error", err.Error())
-	} else {
-		srv.log.Debug("Deleted old/expired dashboard versions", "rows affected", cmd.DeletedRows)
-	}
+func (srv *CleanUpService) deleteExpiredDashboardVersions(ctx context.Context) (int64, error) {
+	cmd := models.DeleteExpiredVersionsCommand{BatchSize: int(srv.Cfg.Cleanup.BatchSize)}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
 }
 
-func (srv *CleanUpService) deleteOldLoginAttempts(ctx context.Context) {
+func (srv *CleanUpService) deleteOldLoginAttempts(ctx context.Context) (int64, error) {
 	if srv.Cfg.DisableBruteForceLoginProtection {
-		return
+		return 0, nil
 	}
 
+	// The login attempts are kept as long as they can lock a user out
+	retention := srv.Cfg.BruteForceLoginWindow + srv.Cfg.BruteForceLoginCooldown
+	if retention < time.Minute*10 {
+		retention = time.Minute * 10
+	}
+	if srv.Cfg.Cleanup.LoginAttemptsRetention > retention {
+		retention = srv.Cfg.Cleanup.LoginAttemptsRetention
+	}
 	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+		OlderThan: time.Now().Add(-retention),
+		BatchSize: srv.Cfg.Cleanup.BatchSize,
 	}
-	if err := bus.Dispatch(ctx, &cmd); err != nil {
-		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
-	} else {
-		srv.log.Debug("Deleted expired login attempts", "rows affected", cmd.DeletedRows)
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
+}
+
+// deleteExpiredDeletedUsers purges the users removed from their last organization once the retention is over, all of
+// them when it is zero since the users are then deleted immediately
+func (srv *CleanUpService) deleteExpiredDeletedUsers(ctx context.Context) (int64, error) {
+	cmd := models.DeleteExpiredDeletedUsersCommand{
+		DeletedBefore: time.Now().Add(-srv.Cfg.Cleanup.DeletedUsersRetention),
+		BatchSize:     srv.Cfg.Cleanup.BatchSize,
+	}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
+}
+
+// deleteExpiredDashboardTrash deletes for good the dashboards deleted before the retention of the deleted dashboards,
+// all of them when it is zero since the dashboards are then deleted immediately
+func (srv *CleanUpService) deleteExpiredDashboardTrash(ctx context.Context) (int64, error) {
+	cmd := models.DeleteExpiredDashboardTrashCommand{
+		DeletedBefore: time.Now().Add(-srv.Cfg.Cleanup.DeletedDashboardsRetention),
+		BatchSize:     srv.Cfg.Cleanup.BatchSize,
 	}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
 }
 
-func (srv *CleanUpService) expireOldUserInvites(ctx context.Context) {
+// deleteExpiredFederatedTokens deletes the expired service account tokens exchanged for workload identity tokens
+func (srv *CleanUpService) deleteExpiredFederatedTokens(ctx context.Context) (int64, error) {
+	cmd := models.DeleteExpiredFederatedTokensCommand{
+		ExpiredBefore: time.Now(),
+		BatchSize:     srv.Cfg.Cleanup.BatchSize,
+	}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
+}
+
+func (srv *CleanUpService) expireOldUserInvites(ctx context.Context) (int64, error) {
 	maxInviteLifetime := srv.Cfg.UserInviteMaxLifetime
 
 	cmd := models.ExpireTempUsersCommand{
 		OlderThan: time.Now().Add(-maxInviteLifetime),
 	}
-	if err := bus.Dispatch(ctx, &cmd); err != nil {
-		srv.log.Error("Problem expiring user invites", "error", err.Error())
-	} else {
-		srv.log.Debug("Expired user invites", "rows affected", cmd.NumExpired)
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.NumExpired, err
+}
+
+// deleteExpiredUserInvites deletes the expired and revoked invites, they are kept forever when the retention is zero
+func (srv *CleanUpService) deleteExpiredUserInvites(ctx context.Context) (int64, error) {
+	if srv.Cfg.Cleanup.ExpiredUserInvitesRetention == 0 {
+		return 0, nil
+	}
+
+	cmd := models.DeleteExpiredTempUsersCommand{
+		ExpiredBefore: time.Now().Add(-srv.Cfg.Cleanup.ExpiredUserInvitesRetention),
+		BatchSize:     srv.Cfg.Cleanup.BatchSize,
 	}
+	err := bus.Dispatch(ctx, &cmd)
+	return cmd.DeletedRows, err
 }
 
-func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) {
+func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) (int64, error) {
 	cmd := models.DeleteShortUrlCommand{
-		OlderThan: time.Now().Add(-time.Hour * 24 * 7),
+		OlderThan: time.Now().Add(-srv.Cfg.Cleanup.ShortURLsRetention),
+		BatchSize: srv.Cfg.Cleanup.BatchSize,
 	}
-	if err := srv.ShortURLService.DeleteStaleShortURLs(ctx, &cmd); err != nil {
-		srv.log.Error("Problem deleting stale short urls", "error", err.Error())
-	} else {
-		srv.log.Debug("Deleted short urls", "rows affected", cmd.NumDeleted)
+	err := srv.ShortURLService.DeleteStaleShortURLs(ctx, &cmd)
+	return cmd.NumDeleted, err
+}
+
+// deleteExpiredUserRoles revokes the role assignments of users that expired. Expired assignments grant nothing
+// already, they are deleted so that they are not listed anymore.
+func (srv *CleanUpService) deleteExpiredUserRoles(ctx context.Context) (int64, error) {
+	if srv.UserRoleStore == nil {
+		return 0, nil
 	}
+
+	return srv.UserRoleStore.DeleteExpiredUserRoles(ctx, time.Now())
 }
@@ -1,10 +1,14 @@
 package cleanup
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +39,26 @@ func TestCleanUpTmpFiles(t *testing.T) {
 		require.False(t, service.shouldCleanupTempFile(weekAgo, now))
 	})
 }
+
+func TestRunTask(t *testing.T) {
+	service := CleanUpService{Cfg: &setting.Cfg{}, log: log.New("cleanup")}
+
+	t.Run("Should count the deleted rows", func(t *testing.T) {
+		service.runTask(context.Background(), cleanupTask{name: "test-deleted", run: func(context.Context) (int64, error) {
+			return 3, nil
+		}})
+		require.Equal(t, float64(3), testutil.ToFloat64(deletedTotal.WithLabelValues("test-deleted")))
+		require.Equal(t, float64(0), testutil.ToFloat64(failuresTotal.WithLabelValues("test-deleted")))
+	})
+
+	t.Run("Should count the failures but not the timeouts", func(t *testing.T) {
+		service.runTask(context.Background(), cleanupTask{name: "test-failed", run: func(context.Context) (int64, error) {
+			return 1, errors.New("database is locked")
+		}})
+		service.runTask(context.Background(), cleanupTask{name: "test-failed", run: func(context.Context) (int64, error) {
+			return 2, context.DeadlineExceeded
+		}})
+		require.Equal(t, float64(3), testutil.ToFloat64(deletedTotal.WithLabelValues("test-failed")))
+		require.Equal(t, float64(1), testutil.ToFloat64(failuresTotal.WithLabelValues("test-failed")))
+	})
+}
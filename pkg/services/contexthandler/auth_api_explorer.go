@@ -0,0 +1,40 @@
+package contexthandler
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
+)
+
+const InvalidAPIExplorerToken = "Invalid API explorer token"
+
+// initContextWithAPIExplorerToken signs in the user a request sent by the API explorer was sent for, in the
+// organization they sent it from.
+func (h *ContextHandler) initContextWithAPIExplorerToken(ctx *models.ReqContext) bool {
+	if h.APIExplorer == nil || !h.APIExplorer.IsEnabled() {
+		return false
+	}
+
+	token := ctx.Req.Header.Get(apiexplorer.TokenHeader)
+	if token == "" {
+		return false
+	}
+
+	userID, orgID, ok := h.APIExplorer.ValidateToken(token)
+	if !ok {
+		ctx.JsonApiErr(401, InvalidAPIExplorerToken, nil)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgID, UserId: userID}
+	if err := bus.Dispatch(ctx.Req.Context(), &query); err != nil {
+		ctx.Logger.Error("Failed to get signed in user of API explorer token", "userId", userID, "error", err)
+		ctx.JsonApiErr(401, InvalidAPIExplorerToken, err)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+
+	return true
+}
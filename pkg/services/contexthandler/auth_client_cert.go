@@ -0,0 +1,61 @@
+package contexthandler
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const InvalidClientCert = "Invalid client certificate"
+
+// initContextWithClientCert signs in the user of the verified client certificate of the request. The user is created
+// on its first request when auto sign up is enabled.
+func (h *ContextHandler) initContextWithClientCert(ctx *models.ReqContext, orgId int64) bool {
+	if !h.ClientCertService.IsEnabled() {
+		return false
+	}
+
+	cert, err := h.ClientCertService.Certificate(ctx.Req)
+	if err != nil {
+		ctx.Logger.Debug("Failed to get client certificate", "error", err)
+		ctx.JsonApiErr(401, InvalidClientCert, err)
+		return true
+	}
+	if cert == nil {
+		return false
+	}
+
+	extUser, err := h.ClientCertService.ExternalUser(cert)
+	if err != nil {
+		ctx.Logger.Debug("Failed to map client certificate to a user", "subject", cert.Subject.String(), "error", err)
+		ctx.JsonApiErr(401, InvalidClientCert, err)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgId, Login: extUser.Login}
+	err = bus.Dispatch(ctx.Req.Context(), &query)
+	if errors.Is(err, models.ErrUserNotFound) && h.Cfg.ClientCertAuthAutoSignUp {
+		upsert := &models.UpsertUserCommand{ReqContext: ctx, SignupAllowed: true, ExternalUser: extUser}
+		if err = bus.Dispatch(ctx.Req.Context(), upsert); err == nil {
+			query = models.GetSignedInUserQuery{OrgId: orgId, UserId: upsert.Result.Id}
+			err = bus.Dispatch(ctx.Req.Context(), &query)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			ctx.Logger.Debug("Failed to find user of client certificate", "subject", cert.Subject.String(), "login", extUser.Login)
+			err = login.ErrInvalidCredentials
+		} else {
+			ctx.Logger.Error("Failed to get signed in user of client certificate", "error", err)
+		}
+		ctx.JsonApiErr(401, InvalidClientCert, err)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+
+	return true
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth/jwt"
 )
 
 const InvalidJWT = "Invalid JWT"
@@ -27,6 +28,10 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		return true
 	}
 
+	if h.Cfg.JWTAuthAutoSignUp || h.Cfg.JWTAuthClaimMapping != "" {
+		return h.initContextWithJWTSync(ctx, orgId, claims)
+	}
+
 	query := models.GetSignedInUserQuery{OrgId: orgId}
 
 	if key := h.Cfg.JWTAuthUsernameClaim; key != "" {
@@ -62,3 +67,32 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 
 	return true
 }
+
+// initContextWithJWTSync creates or updates the user of the claims, with the organizations, roles and teams mapped
+// from the claims, before signing it in
+func (h *ContextHandler) initContextWithJWTSync(ctx *models.ReqContext, orgId int64, claims models.JWTClaims) bool {
+	userID, err := h.JWTAuthService.SyncUser(ctx, claims)
+	if err != nil {
+		if errors.Is(err, login.ErrInvalidCredentials) || errors.Is(err, models.ErrUserNotFound) ||
+			errors.Is(err, jwt.ErrMissingLoginClaim) {
+			ctx.Logger.Debug("Failed to sync user using JWT claims", "error", err)
+			err = login.ErrInvalidCredentials
+		} else {
+			ctx.Logger.Error("Failed to sync user using JWT claims", "error", err)
+		}
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgId, UserId: userID}
+	if err := bus.Dispatch(ctx.Req.Context(), &query); err != nil {
+		ctx.Logger.Error("Failed to get signed in user", "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+
+	return true
+}
@@ -0,0 +1,56 @@
+package contexthandler
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const InvalidPluginToken = "Invalid token"
+
+// initContextWithPluginToken signs in the user of the token header, validated by the token provider of an app
+// plugin. The user is created on its first request when auto sign up is enabled.
+func (h *ContextHandler) initContextWithPluginToken(ctx *models.ReqContext, orgId int64) bool {
+	if h.PluginAuthService == nil || !h.PluginAuthService.IsTokenValidationEnabled() {
+		return false
+	}
+
+	token := ctx.Req.Header.Get(h.Cfg.PluginAuthTokenHeaderName)
+	if token == "" {
+		return false
+	}
+
+	extUser, err := h.PluginAuthService.ValidateToken(ctx.Req.Context(), token)
+	if err != nil {
+		ctx.Logger.Debug("Failed to validate token with the authentication provider", "error", err)
+		ctx.JsonApiErr(401, InvalidPluginToken, err)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgId, Login: extUser.Login}
+	err = bus.Dispatch(ctx.Req.Context(), &query)
+	if errors.Is(err, models.ErrUserNotFound) && h.Cfg.PluginAuthAutoSignUp {
+		upsert := &models.UpsertUserCommand{ReqContext: ctx, SignupAllowed: true, ExternalUser: extUser}
+		if err = bus.Dispatch(ctx.Req.Context(), upsert); err == nil {
+			query = models.GetSignedInUserQuery{OrgId: orgId, UserId: upsert.Result.Id}
+			err = bus.Dispatch(ctx.Req.Context(), &query)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			ctx.Logger.Debug("Failed to find user of token", "login", extUser.Login)
+			err = login.ErrInvalidCredentials
+		} else {
+			ctx.Logger.Error("Failed to get signed in user of token", "error", err)
+		}
+		ctx.JsonApiErr(401, InvalidPluginToken, err)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+
+	return true
+}
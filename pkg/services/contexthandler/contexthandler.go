@@ -4,6 +4,7 @@ package contexthandler
 import (
 	"context"
 	"errors"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -11,15 +12,20 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/apiexplorer"
+	"github.com/grafana/grafana/pkg/services/auth/clientcert"
+	"github.com/grafana/grafana/pkg/services/auth/pluginauth"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -35,15 +41,30 @@ const (
 
 const ServiceName = "ContextHandler"
 
+// apiKeyLastUsedInterval is how often the last use of an API key is recorded
+const apiKeyLastUsedInterval = time.Minute
+
+// AnonymousAccess restricts the paths anonymous users can access in an organization
+type AnonymousAccess interface {
+	IsAllowed(req *http.Request, orgID int64, path string) (bool, error)
+}
+
 func ProvideService(cfg *setting.Cfg, tokenService models.UserTokenService, jwtService models.JWTService,
-	remoteCache *remotecache.RemoteCache, renderService rendering.Service, sqlStore *sqlstore.SQLStore) *ContextHandler {
+	remoteCache *remotecache.RemoteCache, renderService rendering.Service, sqlStore *sqlstore.SQLStore,
+	clientCertService *clientcert.Service, anonymousAccess AnonymousAccess, pluginAuthService *pluginauth.Service,
+	apiExplorer *apiexplorer.Service, twoFactorService *twofactor.Service) *ContextHandler {
 	return &ContextHandler{
-		Cfg:              cfg,
-		AuthTokenService: tokenService,
-		JWTAuthService:   jwtService,
-		RemoteCache:      remoteCache,
-		RenderService:    renderService,
-		SQLStore:         sqlStore,
+		Cfg:               cfg,
+		AuthTokenService:  tokenService,
+		JWTAuthService:    jwtService,
+		RemoteCache:       remoteCache,
+		RenderService:     renderService,
+		SQLStore:          sqlStore,
+		ClientCertService: clientCertService,
+		AnonymousAccess:   anonymousAccess,
+		PluginAuthService: pluginAuthService,
+		APIExplorer:       apiExplorer,
+		TwoFactor:         twoFactorService,
 	}
 }
 
@@ -55,6 +76,19 @@ type ContextHandler struct {
 	RemoteCache      *remotecache.RemoteCache
 	RenderService    rendering.Service
 	SQLStore         *sqlstore.SQLStore
+	// ClientCertService maps the client certificates to users
+	ClientCertService *clientcert.Service
+	// AnonymousAccess restricts anonymous access to some dashboards, folders or URL prefixes, anonymous users can
+	// access the whole organization when nil
+	AnonymousAccess AnonymousAccess
+	// PluginAuthService validates the tokens with the authentication providers of the app plugins, the tokens are
+	// not validated when nil
+	PluginAuthService *pluginauth.Service
+	// APIExplorer validates the tokens of the requests sent by the API explorer, the tokens are not validated when
+	// nil
+	APIExplorer *apiexplorer.Service
+	// TwoFactor refuses basic auth for the users with two-factor authentication, basic auth is not refused when nil
+	TwoFactor *twofactor.Service
 
 	// GetTime returns the current time.
 	// Stubbable by tests.
@@ -85,8 +119,10 @@ func (h *ContextHandler) Middleware(mContext *web.Context) {
 		Logger:         log.New("context"),
 	}
 
-	// Inject ReqContext into a request context and replace the request instance in the macaron context
-	mContext.Req = mContext.Req.WithContext(context.WithValue(mContext.Req.Context(), reqContextKey{}, reqContext))
+	// Inject ReqContext into a request context and replace the request instance in the macaron context.
+	// The writes of the request are tracked so that its following reads are made on the primary database.
+	ctx := sqlstore.TrackWrites(mContext.Req.Context())
+	mContext.Req = mContext.Req.WithContext(context.WithValue(ctx, reqContextKey{}, reqContext))
 	mContext.Map(mContext.Req)
 
 	traceID, exists := cw.ExtractTraceID(mContext.Req.Context())
@@ -126,11 +162,14 @@ func (h *ContextHandler) Middleware(mContext *web.Context) {
 	// then test if anonymous access is enabled
 	switch {
 	case h.initContextWithRenderAuth(reqContext):
+	case h.initContextWithAPIExplorerToken(reqContext):
 	case h.initContextWithAPIKey(reqContext):
 	case h.initContextWithBasicAuth(reqContext, orgID):
 	case h.initContextWithAuthProxy(reqContext, orgID):
 	case h.initContextWithToken(reqContext, orgID):
 	case h.initContextWithJWT(reqContext, orgID):
+	case h.initContextWithClientCert(reqContext, orgID):
+	case h.initContextWithPluginToken(reqContext, orgID):
 	case h.initContextWithAnonymousUser(reqContext):
 	}
 
@@ -166,6 +205,19 @@ func (h *ContextHandler) initContextWithAnonymousUser(reqContext *models.ReqCont
 		return false
 	}
 
+	if h.AnonymousAccess != nil {
+		path := strings.TrimPrefix(reqContext.Req.URL.Path, h.Cfg.AppSubURL)
+		allowed, err := h.AnonymousAccess.IsAllowed(reqContext.Req, org.Id, path)
+		if err != nil {
+			reqContext.Logger.Error("Failed to check anonymous access restrictions", "org_name", org.Name, "error", err)
+			return false
+		}
+		if !allowed {
+			reqContext.Logger.Debug("Anonymous access restricted", "org_name", org.Name, "path", path)
+			return false
+		}
+	}
+
 	reqContext.IsSignedIn = false
 	reqContext.AllowAnonymous = true
 	reqContext.SignedInUser = &models.SignedInUser{IsAnonymous: true}
@@ -175,6 +227,24 @@ func (h *ContextHandler) initContextWithAnonymousUser(reqContext *models.ReqCont
 	return true
 }
 
+// isValidPreviousAPIKey reports whether the key matches a previous key of the API key that is still
+// in the grace period of its rotation.
+func isValidPreviousAPIKey(ctx context.Context, decoded *apikeygen.ApiKeyJson, apikey *models.ApiKey, now time.Time) (bool, error) {
+	query := models.GetApiKeyRotationsQuery{ApiKeyId: apikey.Id, OrgId: apikey.OrgId}
+	if err := bus.Dispatch(ctx, &query); err != nil {
+		return false, err
+	}
+	for _, rotation := range query.Result {
+		if !rotation.PreviousKeyExpires.After(now) {
+			continue
+		}
+		if isValid, err := apikeygen.IsValid(decoded, rotation.PreviousKey); err != nil || isValid {
+			return isValid, err
+		}
+	}
+	return false, nil
+}
+
 func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bool {
 	header := reqContext.Req.Header.Get("Authorization")
 	parts := strings.SplitN(header, " ", 2)
@@ -211,27 +281,47 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bo
 
 	apikey := keyQuery.Result
 
+	getTime := h.GetTime
+	if getTime == nil {
+		getTime = time.Now
+	}
+
 	// validate api key
 	isValid, err := apikeygen.IsValid(decoded, apikey.Key)
 	if err != nil {
 		reqContext.JsonApiErr(500, "Validating API key failed", err)
 		return true
 	}
+	if !isValid {
+		// The key of a rotated API key remains valid during the grace period of the rotation
+		isValid, err = isValidPreviousAPIKey(reqContext.Req.Context(), decoded, apikey, getTime())
+		if err != nil {
+			reqContext.Logger.Warn("Failed to get the rotations of the API key", "id", apikey.Id, "err", err)
+		}
+	}
 	if !isValid {
 		reqContext.JsonApiErr(401, InvalidAPIKey, err)
 		return true
 	}
 
 	// check for expiration
-	getTime := h.GetTime
-	if getTime == nil {
-		getTime = time.Now
-	}
 	if apikey.Expires != nil && *apikey.Expires <= getTime().Unix() {
 		reqContext.JsonApiErr(401, "Expired API key", err)
 		return true
 	}
 
+	// The last use is recorded at most once per interval, not to write to the database on every request
+	if h.SQLStore != nil && (apikey.LastUsedAt == nil || getTime().Sub(*apikey.LastUsedAt) > apiKeyLastUsedInterval) {
+		if err := h.SQLStore.UpdateAPIKeyLastUsedDate(reqContext.Req.Context(), apikey.Id, reqContext.RemoteAddr()); err != nil {
+			reqContext.Logger.Warn("Failed to update the last use of the API key", "id", apikey.Id, "err", err)
+		}
+		h.publishAPIKeyUsed(reqContext, apikey, getTime())
+	}
+
+	if apikey.UserId > 0 {
+		return h.initContextWithUserAccessToken(reqContext, apikey)
+	}
+
 	if apikey.ServiceAccountId < 1 { //There is no service account attached to the apikey
 		//Use the old APIkey method.  This provides backwards compatibility.
 		reqContext.SignedInUser = &models.SignedInUser{}
@@ -239,6 +329,9 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bo
 		reqContext.ApiKeyId = apikey.Id
 		reqContext.OrgId = apikey.OrgId
 		reqContext.IsSignedIn = true
+		if err := restrictScopedAPIKey(reqContext, apikey); err != nil {
+			reqContext.JsonApiErr(500, "Failed to get the permissions of the API key", err)
+		}
 		return true
 	}
 
@@ -259,9 +352,82 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bo
 
 	reqContext.IsSignedIn = true
 	reqContext.SignedInUser = query.Result
+	// The API key is kept on the user to grant only its permissions when it is permission-scoped
+	reqContext.ApiKeyId = apikey.Id
+	if err := restrictScopedAPIKey(reqContext, apikey); err != nil {
+		reqContext.JsonApiErr(500, "Failed to get the permissions of the API key", err)
+	}
 	return true
 }
 
+// initContextWithUserAccessToken signs in the owner of a personal access token, in the organization of the token. The
+// token stops working when the user is disabled.
+func (h *ContextHandler) initContextWithUserAccessToken(reqContext *models.ReqContext, apikey *models.ApiKey) bool {
+	userQuery := models.GetUserByIdQuery{Id: apikey.UserId}
+	if err := bus.Dispatch(reqContext.Req.Context(), &userQuery); err != nil {
+		reqContext.JsonApiErr(401, InvalidAPIKey, err)
+		return true
+	}
+	if userQuery.Result.IsDisabled {
+		reqContext.JsonApiErr(401, "User is disabled", nil)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{UserId: apikey.UserId, OrgId: apikey.OrgId}
+	if err := bus.Dispatch(reqContext.Req.Context(), &query); err != nil {
+		reqContext.JsonApiErr(401, InvalidAPIKey, err)
+		return true
+	}
+	if query.Result.OrgId != apikey.OrgId {
+		reqContext.JsonApiErr(401, "User is not a member of the organization of the token", nil)
+		return true
+	}
+
+	reqContext.IsSignedIn = true
+	reqContext.SignedInUser = query.Result
+	// The token is kept on the user to grant only its permissions when it is permission-scoped
+	reqContext.ApiKeyId = apikey.Id
+	if err := restrictScopedAPIKey(reqContext, apikey); err != nil {
+		reqContext.JsonApiErr(500, "Failed to get the permissions of the token", err)
+	}
+	return true
+}
+
+// restrictScopedAPIKey downgrades the signed in user of a permission-scoped API key, service account token or personal
+// access token to the Viewer role, and removes their Grafana Admin permission. The permissions of the key only apply
+// to the routes evaluated with access control, the role keeps the other routes from granting more access.
+func restrictScopedAPIKey(reqContext *models.ReqContext, apikey *models.ApiKey) error {
+	query := models.GetApiKeyPermissionsQuery{OrgId: apikey.OrgId, ApiKeyIds: []int64{apikey.Id}}
+	if err := bus.Dispatch(reqContext.Req.Context(), &query); err != nil {
+		return err
+	}
+	if len(query.Result) == 0 {
+		return nil
+	}
+
+	reqContext.OrgRole = models.ROLE_VIEWER
+	reqContext.IsGrafanaAdmin = false
+	return nil
+}
+
+// publishAPIKeyUsed notifies that the request is authenticated with the API key, failing to publish it is only logged
+func (h *ContextHandler) publishAPIKeyUsed(reqContext *models.ReqContext, apikey *models.ApiKey, now time.Time) {
+	if h.SQLStore.Bus == nil {
+		return
+	}
+	event := &events.APIKeyUsed{
+		Timestamp:        now,
+		OrgID:            apikey.OrgId,
+		ID:               apikey.Id,
+		Name:             apikey.Name,
+		ServiceAccountID: apikey.ServiceAccountId,
+		ClientIP:         reqContext.RemoteAddr(),
+	}
+	if err := h.SQLStore.Bus.Publish(reqContext.Req.Context(), event); err != nil {
+		reqContext.Logger.Warn("Failed to publish the use of the API key", "id", apikey.Id, "err", err)
+	}
+}
+
 func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext, orgID int64) bool {
 	if !h.Cfg.BasicAuthEnabled {
 		return false
@@ -302,6 +468,20 @@ func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext,
 
 	user := authQuery.User
 
+	// Basic auth cannot verify a second factor, the users with two-factor authentication use API keys or tokens
+	if h.TwoFactor != nil {
+		enforced, err := h.TwoFactor.IsEnforced(ctx, user)
+		if err != nil {
+			reqContext.JsonApiErr(http.StatusInternalServerError, "Error while verifying two-factor authentication", err)
+			return true
+		}
+		if enforced {
+			reqContext.Logger.Debug("Basic auth refused for a user with two-factor authentication", "username", username)
+			reqContext.JsonApiErr(http.StatusUnauthorized, "Basic auth is not allowed for users with two-factor authentication", nil)
+			return true
+		}
+	}
+
 	query := models.GetSignedInUserQuery{UserId: user.Id, OrgId: orgID}
 	if err := bus.Dispatch(ctx, &query); err != nil {
 		reqContext.Logger.Error(
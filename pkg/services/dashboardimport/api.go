@@ -0,0 +1,107 @@
+package dashboardimport
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/dashboards/import", func(importRoute routing.RouteRegister) {
+		importRoute.Post("/archive", routing.Wrap(s.importArchiveHandler))
+		importRoute.Get("/jobs", routing.Wrap(s.getJobsHandler))
+		importRoute.Get("/jobs/:uid", routing.Wrap(s.getJobHandler))
+	}, middleware.ReqSignedIn, middleware.ReqEditorRole)
+}
+
+// POST /api/dashboards/import/archive?folderUid=&overwrite=
+//
+// The archive is the body of the request, or the archive file of a multipart form.
+func (s *Service) importArchiveHandler(c *models.ReqContext) response.Response {
+	limitReached, err := s.quotaService.QuotaReached(c, "dashboard")
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get quota", err)
+	}
+	if limitReached {
+		return response.Error(http.StatusForbidden, "Quota reached", nil)
+	}
+
+	archive, err := readArchiveBody(c)
+	if err != nil {
+		return errorResponse(err, "Failed to read dashboard archive")
+	}
+
+	job, err := s.ImportArchive(c.Req.Context(), c.SignedInUser, ImportArchiveCommand{
+		OrgId:     c.OrgId,
+		FolderUid: c.Query("folderUid"),
+		Overwrite: c.QueryBool("overwrite"),
+		Archive:   archive,
+	})
+	if err != nil {
+		return errorResponse(err, "Failed to import dashboard archive")
+	}
+	return response.JSON(http.StatusAccepted, job).SetHeader("Location", "/api/dashboards/import/jobs/"+job.Uid)
+}
+
+// GET /api/dashboards/import/jobs
+func (s *Service) getJobsHandler(c *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, s.GetJobs(c.OrgId))
+}
+
+// GET /api/dashboards/import/jobs/:uid
+func (s *Service) getJobHandler(c *models.ReqContext) response.Response {
+	job, err := s.GetJob(c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get import job")
+	}
+	return response.JSON(http.StatusOK, job)
+}
+
+func readArchiveBody(c *models.ReqContext) ([]byte, error) {
+	body := http.MaxBytesReader(c.Resp, c.Req.Body, MaxArchiveSize)
+	mediaType, _, _ := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, ErrArchiveTooLarge
+		}
+		return data, nil
+	}
+
+	c.Req.Body = body
+	file, _, err := c.Req.FormFile("archive")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, ErrInvalidArchive
+		}
+		return nil, ErrArchiveTooLarge
+	}
+	defer func() { _ = file.Close() }()
+	return io.ReadAll(file)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidArchive), errors.Is(err, ErrTooManyFiles):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrArchiveTooLarge):
+		return response.Error(http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, ErrTooManyJobs):
+		return response.Error(http.StatusTooManyRequests, err.Error(), nil)
+	case errors.Is(err, models.ErrFolderNotFound):
+		return response.Error(http.StatusBadRequest, "Folder not found", nil)
+	case errors.Is(err, models.ErrFolderAccessDenied):
+		return apierrors.ToFolderErrorResponse(err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
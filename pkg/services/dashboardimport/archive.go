@@ -0,0 +1,125 @@
+package dashboardimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	// MaxArchiveSize is the maximum size of an archive of dashboards
+	MaxArchiveSize = 100 << 20
+	// maxArchiveFiles is the maximum number of files of an archive of dashboards
+	maxArchiveFiles = 5000
+	// maxDashboardSize is the maximum uncompressed size of a dashboard of an archive
+	maxDashboardSize = 10 << 20
+)
+
+// archiveFile is a file of an archive of dashboards
+type archiveFile struct {
+	name string
+	// folder is the title of the folder of the dashboard, the directory of the file, empty at the root
+	folder string
+	file   *zip.File
+}
+
+// readArchive returns the files of an archive of dashboards sorted by name. The directories, and the hidden files
+// added by archivers, are left out.
+func readArchive(data []byte) ([]*archiveFile, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if len(reader.File) > maxArchiveFiles {
+		return nil, fmt.Errorf("%w: the maximum is %d", ErrTooManyFiles, maxArchiveFiles)
+	}
+
+	files := make([]*archiveFile, 0, len(reader.File))
+	for _, f := range reader.File {
+		name := path.Clean(strings.ReplaceAll(f.Name, "\\", "/"))
+		if f.FileInfo().IsDir() || isHidden(name) {
+			continue
+		}
+		folder := path.Dir(name)
+		if folder == "." || folder == "/" {
+			folder = ""
+		}
+		// Nested directories are imported to the folder of their top directory, folders cannot be nested
+		folder = strings.SplitN(strings.TrimPrefix(folder, "/"), "/", 2)[0]
+		files = append(files, &archiveFile{name: name, folder: folder, file: f})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// isHidden returns whether the file, or one of its directories, is hidden, like the __MACOSX directory or the
+// .DS_Store files of archives made on macOS
+func isHidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSON returns whether the file of the archive can be a dashboard
+func (f *archiveFile) isJSON() bool {
+	return strings.EqualFold(path.Ext(f.name), ".json")
+}
+
+// dashboard returns the dashboard JSON model of the file. Both the dashboard model and the dashboards returned by
+// the dashboard API, with the model in the dashboard property, are read.
+func (f *archiveFile) dashboard() (*simplejson.Json, error) {
+	if f.file.UncompressedSize64 > maxDashboardSize {
+		return nil, fmt.Errorf("dashboard is larger than %d bytes", maxDashboardSize)
+	}
+	rc, err := f.file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxDashboardSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if len(data) > maxDashboardSize {
+		return nil, fmt.Errorf("dashboard is larger than %d bytes", maxDashboardSize)
+	}
+
+	model, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotDashboardJSON, err)
+	}
+	if wrapped, ok := model.CheckGet("dashboard"); ok {
+		if _, isObject := wrapped.Interface().(map[string]interface{}); isObject {
+			model = wrapped
+		}
+	}
+	if _, isObject := model.Interface().(map[string]interface{}); !isObject {
+		return nil, ErrNotDashboardJSON
+	}
+	if !hasAnyKey(model, "panels", "rows", "schemaVersion") {
+		return nil, ErrNotDashboardJSON
+	}
+	if _, ok := model.CheckGet("__inputs"); ok && len(model.Get("__inputs").MustArray()) > 0 {
+		return nil, ErrDashboardInputs
+	}
+	return model, nil
+}
+
+func hasAnyKey(model *simplejson.Json, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := model.CheckGet(key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package dashboardimport
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// storeImporter saves the dashboards with the dashboard and folder services, which check the permissions of the user
+type storeImporter struct {
+	sqlStore            *sqlstore.SQLStore
+	libraryPanelService librarypanels.Service
+}
+
+func (i *storeImporter) getFolder(ctx context.Context, user *models.SignedInUser, uid string) (*models.Folder, error) {
+	return dashboards.NewFolderService(user.OrgId, user, i.sqlStore).GetFolderByUID(ctx, uid)
+}
+
+func (i *storeImporter) getOrCreateFolder(ctx context.Context, user *models.SignedInUser, title string) (*models.Folder, error) {
+	folders := dashboards.NewFolderService(user.OrgId, user, i.sqlStore)
+	folder, err := folders.GetFolderByTitle(ctx, title)
+	if errors.Is(err, models.ErrFolderNotFound) {
+		return folders.CreateFolder(ctx, title, "")
+	}
+	return folder, err
+}
+
+func (i *storeImporter) importDashboard(ctx context.Context, user *models.SignedInUser, model *simplejson.Json, folderID int64,
+	overwrite bool) (*models.Dashboard, error) {
+	// The ids of the dashboards are the ones of the instance they were exported from
+	model.Del("id")
+	saveCmd := models.SaveDashboardCommand{
+		Dashboard: model,
+		OrgId:     user.OrgId,
+		UserId:    user.UserId,
+		Overwrite: overwrite,
+		FolderId:  folderID,
+	}
+
+	saved, err := dashboards.NewService(i.sqlStore).ImportDashboard(ctx, &dashboards.SaveDashboardDTO{
+		OrgId:     user.OrgId,
+		Dashboard: saveCmd.GetDashboardModel(),
+		Overwrite: saveCmd.Overwrite,
+		User:      user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.libraryPanelService.ImportLibraryPanelsForDashboard(ctx, user, saved, folderID); err != nil {
+		return nil, err
+	}
+	if err := i.libraryPanelService.ConnectLibraryPanelsForDashboard(ctx, user, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
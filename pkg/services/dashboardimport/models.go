@@ -0,0 +1,89 @@
+package dashboardimport
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrJobNotFound      = errors.New("import job not found")
+	ErrInvalidArchive   = errors.New("invalid dashboard archive")
+	ErrArchiveTooLarge  = errors.New("dashboard archive is too large")
+	ErrTooManyFiles     = errors.New("dashboard archive has too many files")
+	ErrTooManyJobs      = errors.New("too many import jobs are running for the organization")
+	ErrDashboardInputs  = errors.New("dashboards exported for sharing externally cannot be imported from an archive, their inputs are not set")
+	ErrNotDashboardJSON = errors.New("file is not a dashboard JSON model")
+)
+
+// JobStatus is the status of an import job
+type JobStatus string
+
+const (
+	// JobPending jobs wait for other jobs to finish before they start
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	// JobSucceeded jobs imported all the dashboards of the archive
+	JobSucceeded JobStatus = "succeeded"
+	// JobPartiallyFailed jobs failed to import some of the dashboards of the archive
+	JobPartiallyFailed JobStatus = "partially_failed"
+	// JobFailed jobs failed to import all the dashboards of the archive
+	JobFailed JobStatus = "failed"
+)
+
+// FileStatus is the result of the import of a file of an archive
+type FileStatus string
+
+const (
+	FileImported FileStatus = "imported"
+	FileFailed   FileStatus = "failed"
+	// FileSkipped files are not dashboards, like the files that are not JSON
+	FileSkipped FileStatus = "skipped"
+)
+
+// Job is the import of the dashboards of an archive, processed in the background. Its progress and the per-file
+// results are polled until it is finished.
+type Job struct {
+	Uid       string        `json:"uid"`
+	OrgId     int64         `json:"orgId"`
+	CreatedBy int64         `json:"createdBy"`
+	Status    JobStatus     `json:"status"`
+	Total     int           `json:"total"`
+	Processed int           `json:"processed"`
+	Imported  int           `json:"imported"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	Created   time.Time     `json:"created"`
+	Started   *time.Time    `json:"started,omitempty"`
+	Finished  *time.Time    `json:"finished,omitempty"`
+	Results   []*FileResult `json:"results,omitempty"`
+	Overwrite bool          `json:"overwrite"`
+	FolderUid string        `json:"folderUid,omitempty"`
+}
+
+// IsFinished returns whether the job has processed all the files of the archive
+func (j *Job) IsFinished() bool {
+	return j.Status == JobSucceeded || j.Status == JobPartiallyFailed || j.Status == JobFailed
+}
+
+// FileResult is the result of the import of a file of an archive. The title, uid, url and folder are the ones of
+// the dashboard imported from the file, the error is the reason the file failed to import or was skipped.
+type FileResult struct {
+	File         string     `json:"file"`
+	Status       FileStatus `json:"status"`
+	Title        string     `json:"title,omitempty"`
+	DashboardUid string     `json:"dashboardUid,omitempty"`
+	Url          string     `json:"url,omitempty"`
+	FolderUid    string     `json:"folderUid,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// ImportArchiveCommand starts the import of an archive of dashboards
+type ImportArchiveCommand struct {
+	OrgId int64
+	// FolderUid is the folder the dashboards at the root of the archive are imported to, the General folder when empty.
+	// The dashboards in a directory of the archive are imported to the folder with the title of the directory.
+	FolderUid string
+	// Overwrite replaces the dashboards with the same uid or title
+	Overwrite bool
+	Archive   []byte
+}
@@ -0,0 +1,261 @@
+package dashboardimport
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// maxRunningJobs is the number of jobs importing dashboards at the same time, the other jobs are pending
+	maxRunningJobs = 2
+	// maxOrgJobs is the maximum number of pending and running jobs of an organization
+	maxOrgJobs = 5
+	// jobRetention is how long finished jobs can be polled
+	jobRetention = 24 * time.Hour
+)
+
+// Service imports archives of dashboards as background jobs, so that importing many dashboards does not block
+// a request. The jobs are kept in memory, their progress is polled from the instance that started them.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	quotaService  *quota.QuotaService
+	routeRegister routing.RouteRegister
+	log           log.Logger
+	importer      dashboardImporter
+	now           func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	// running limits the number of jobs importing dashboards at the same time
+	running chan struct{}
+}
+
+// dashboardImporter saves the dashboards of the archives and their folders on behalf of the user who started the job
+type dashboardImporter interface {
+	getFolder(ctx context.Context, user *models.SignedInUser, uid string) (*models.Folder, error)
+	getOrCreateFolder(ctx context.Context, user *models.SignedInUser, title string) (*models.Folder, error)
+	importDashboard(ctx context.Context, user *models.SignedInUser, model *simplejson.Json, folderID int64, overwrite bool) (*models.Dashboard, error)
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, quotaService *quota.QuotaService, libraryPanelService librarypanels.Service,
+	routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		quotaService:  quotaService,
+		routeRegister: routeRegister,
+		log:           log.New("dashboardimport"),
+		importer:      &storeImporter{sqlStore: sqlStore, libraryPanelService: libraryPanelService},
+		now:           time.Now,
+		jobs:          map[string]*Job{},
+		running:       make(chan struct{}, maxRunningJobs),
+	}
+	s.registerAPIEndpoints()
+	return s
+}
+
+// ImportArchive checks the archive and starts a job importing its dashboards on behalf of the user. The returned
+// job is pending, its progress is polled with GetJob.
+func (s *Service) ImportArchive(ctx context.Context, user *models.SignedInUser, cmd ImportArchiveCommand) (*Job, error) {
+	files, err := readArchive(cmd.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var rootFolder *models.Folder
+	if cmd.FolderUid != "" {
+		if rootFolder, err = s.importer.getFolder(ctx, user, cmd.FolderUid); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteExpiredJobs()
+	active := 0
+	for _, job := range s.jobs {
+		if job.OrgId == cmd.OrgId && !job.IsFinished() {
+			active++
+		}
+	}
+	if active >= maxOrgJobs {
+		return nil, ErrTooManyJobs
+	}
+
+	job := &Job{
+		Uid:       util.GenerateShortUID(),
+		OrgId:     cmd.OrgId,
+		CreatedBy: user.UserId,
+		Status:    JobPending,
+		Total:     len(files),
+		Created:   s.now(),
+		Results:   make([]*FileResult, 0, len(files)),
+		Overwrite: cmd.Overwrite,
+		FolderUid: cmd.FolderUid,
+	}
+	s.jobs[job.Uid] = job
+
+	// The job outlives the request, it runs on a copy of the user
+	jobUser := *user
+	jobUser.Permissions = nil
+	go s.run(job, &jobUser, files, rootFolder)
+
+	return job.snapshot(true), nil
+}
+
+// GetJob returns the progress and the results of an import job of the organization
+func (s *Service) GetJob(orgID int64, uid string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[uid]
+	if !ok || job.OrgId != orgID {
+		return nil, ErrJobNotFound
+	}
+	return job.snapshot(true), nil
+}
+
+// GetJobs returns the progress of the import jobs of the organization, the most recent first
+func (s *Service) GetJobs(orgID int64) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteExpiredJobs()
+	jobs := make([]*Job, 0)
+	for _, job := range s.jobs {
+		if job.OrgId == orgID {
+			jobs = append(jobs, job.snapshot(false))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.After(jobs[j].Created) })
+	return jobs
+}
+
+// run imports the dashboards of the archive, recording the result of each file so that the job can be polled
+func (s *Service) run(job *Job, user *models.SignedInUser, files []*archiveFile, rootFolder *models.Folder) {
+	s.running <- struct{}{}
+	defer func() { <-s.running }()
+
+	s.update(job, func() {
+		started := s.now()
+		job.Status = JobRunning
+		job.Started = &started
+	})
+	s.log.Info("Importing dashboard archive", "job", job.Uid, "orgId", job.OrgId, "files", len(files))
+
+	ctx := context.Background()
+	folders := map[string]*models.Folder{"": rootFolder}
+	for _, f := range files {
+		result := s.importFile(ctx, user, job, f, folders)
+		s.update(job, func() {
+			job.Results = append(job.Results, result)
+			job.Processed++
+			switch result.Status {
+			case FileImported:
+				job.Imported++
+			case FileFailed:
+				job.Failed++
+			case FileSkipped:
+				job.Skipped++
+			}
+		})
+	}
+
+	s.update(job, func() {
+		finished := s.now()
+		job.Finished = &finished
+		switch {
+		case job.Failed == 0:
+			job.Status = JobSucceeded
+		case job.Imported == 0:
+			job.Status = JobFailed
+		default:
+			job.Status = JobPartiallyFailed
+		}
+	})
+	s.log.Info("Imported dashboard archive", "job", job.Uid, "orgId", job.OrgId, "status", job.Status,
+		"imported", job.Imported, "failed", job.Failed, "skipped", job.Skipped)
+}
+
+func (s *Service) importFile(ctx context.Context, user *models.SignedInUser, job *Job, f *archiveFile, folders map[string]*models.Folder) *FileResult {
+	result := &FileResult{File: f.name}
+	if !f.isJSON() {
+		result.Status = FileSkipped
+		result.Error = "not a JSON file"
+		return result
+	}
+
+	model, err := f.dashboard()
+	if err != nil {
+		result.Status = FileFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	folder, ok := folders[f.folder]
+	if !ok {
+		if folder, err = s.importer.getOrCreateFolder(ctx, user, f.folder); err != nil {
+			result.Status = FileFailed
+			result.Error = "failed to create folder " + f.folder + ": " + err.Error()
+			return result
+		}
+		folders[f.folder] = folder
+	}
+
+	var folderID int64
+	if folder != nil {
+		folderID = folder.Id
+		result.FolderUid = folder.Uid
+	}
+
+	dash, err := s.importer.importDashboard(ctx, user, model, folderID, job.Overwrite)
+	if err != nil {
+		s.log.Debug("Failed to import dashboard of archive", "job", job.Uid, "file", f.name, "error", err)
+		result.Status = FileFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = FileImported
+	result.Title = dash.Title
+	result.DashboardUid = dash.Uid
+	result.Url = dash.GetUrl()
+	return result
+}
+
+func (s *Service) update(job *Job, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+// deleteExpiredJobs forgets the jobs that finished before the retention
+func (s *Service) deleteExpiredJobs() {
+	for uid, job := range s.jobs {
+		if job.Finished != nil && s.now().Sub(*job.Finished) > jobRetention {
+			delete(s.jobs, uid)
+		}
+	}
+}
+
+// snapshot returns a copy of the job, which can be read while the job runs
+func (j *Job) snapshot(withResults bool) *Job {
+	c := *j
+	c.Results = nil
+	if withResults {
+		c.Results = make([]*FileResult, len(j.Results))
+		copy(c.Results, j.Results)
+	}
+	return &c
+}
@@ -0,0 +1,274 @@
+package dashboardimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestReadArchive(t *testing.T) {
+	archive := newArchive(t, map[string]string{
+		"b.json":                     `{"title": "B", "panels": []}`,
+		"a.json":                     `{"dashboard": {"title": "A", "schemaVersion": 30}, "meta": {}}`,
+		"Team/c.json":                `{"title": "C", "panels": []}`,
+		"Team/nested/d.json":         `{"title": "D", "panels": []}`,
+		"README.md":                  "dashboards",
+		"__MACOSX/._a.json":          "",
+		"Team/.DS_Store":             "",
+		"inputs.json":                `{"title": "E", "panels": [], "__inputs": [{"name": "DS_PROMETHEUS"}]}`,
+		"not-dashboard.json":         `{"title": "F"}`,
+		"invalid.json":               `{`,
+		"Other Team/ignored/.hidden": "",
+	})
+
+	files, err := readArchive(archive)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	folders := map[string]string{}
+	for _, f := range files {
+		names = append(names, f.name)
+		folders[f.name] = f.folder
+	}
+	assert.Equal(t, []string{"README.md", "Team/c.json", "Team/nested/d.json", "a.json", "b.json", "inputs.json",
+		"invalid.json", "not-dashboard.json"}, names)
+	assert.Equal(t, "", folders["a.json"])
+	assert.Equal(t, "Team", folders["Team/c.json"])
+	assert.Equal(t, "Team", folders["Team/nested/d.json"])
+
+	byName := func(name string) *archiveFile {
+		for _, f := range files {
+			if f.name == name {
+				return f
+			}
+		}
+		t.Fatalf("file %s not found", name)
+		return nil
+	}
+
+	t.Run("should read dashboards and the dashboards returned by the API", func(t *testing.T) {
+		model, err := byName("a.json").dashboard()
+		require.NoError(t, err)
+		assert.Equal(t, "A", model.Get("title").MustString())
+
+		model, err = byName("b.json").dashboard()
+		require.NoError(t, err)
+		assert.Equal(t, "B", model.Get("title").MustString())
+	})
+
+	t.Run("should reject files that are not dashboards", func(t *testing.T) {
+		assert.False(t, byName("README.md").isJSON())
+		_, err := byName("inputs.json").dashboard()
+		assert.ErrorIs(t, err, ErrDashboardInputs)
+		_, err = byName("not-dashboard.json").dashboard()
+		assert.ErrorIs(t, err, ErrNotDashboardJSON)
+		_, err = byName("invalid.json").dashboard()
+		assert.ErrorIs(t, err, ErrNotDashboardJSON)
+	})
+
+	t.Run("should reject invalid archives", func(t *testing.T) {
+		_, err := readArchive([]byte("not a zip"))
+		assert.ErrorIs(t, err, ErrInvalidArchive)
+	})
+}
+
+func TestService_ImportArchive(t *testing.T) {
+	ctx := context.Background()
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+
+	t.Run("should import the dashboards and report the result of each file", func(t *testing.T) {
+		importer := newFakeImporter()
+		importer.failTitles["Broken"] = true
+		s := newTestService(importer)
+
+		job, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, FolderUid: "root", Archive: newArchive(t, map[string]string{
+			"a.json":       `{"title": "A", "panels": []}`,
+			"Team/b.json":  `{"title": "B", "panels": []}`,
+			"Team/c.json":  `{"title": "Broken", "panels": []}`,
+			"notes.txt":    "notes",
+			"invalid.json": `{"title": "Invalid"}`,
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, JobPending, job.Status)
+		assert.Equal(t, 5, job.Total)
+
+		job = waitForJob(t, s, 1, job.Uid)
+		assert.Equal(t, JobPartiallyFailed, job.Status)
+		assert.Equal(t, 5, job.Processed)
+		assert.Equal(t, 2, job.Imported)
+		assert.Equal(t, 2, job.Failed)
+		assert.Equal(t, 1, job.Skipped)
+		require.NotNil(t, job.Started)
+		require.NotNil(t, job.Finished)
+
+		results := map[string]*FileResult{}
+		for _, r := range job.Results {
+			results[r.File] = r
+		}
+		assert.Equal(t, FileImported, results["a.json"].Status)
+		assert.Equal(t, "root", results["a.json"].FolderUid)
+		assert.Equal(t, FileImported, results["Team/b.json"].Status)
+		assert.Equal(t, "folder-Team", results["Team/b.json"].FolderUid)
+		assert.Equal(t, "B", results["Team/b.json"].Title)
+		assert.NotEmpty(t, results["Team/b.json"].DashboardUid)
+		assert.Equal(t, FileFailed, results["Team/c.json"].Status)
+		assert.Equal(t, "import failed", results["Team/c.json"].Error)
+		assert.Equal(t, FileSkipped, results["notes.txt"].Status)
+		assert.Equal(t, FileFailed, results["invalid.json"].Status)
+		assert.Equal(t, 1, importer.createdFolders["Team"])
+	})
+
+	t.Run("should fail jobs that import no dashboards", func(t *testing.T) {
+		importer := newFakeImporter()
+		importer.failTitles["Broken"] = true
+		s := newTestService(importer)
+
+		job, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, Archive: newArchive(t, map[string]string{
+			"a.json": `{"title": "Broken", "panels": []}`,
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, JobFailed, waitForJob(t, s, 1, job.Uid).Status)
+	})
+
+	t.Run("should check the folder before starting the job", func(t *testing.T) {
+		s := newTestService(newFakeImporter())
+		_, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, FolderUid: "missing", Archive: newArchive(t, nil)})
+		assert.ErrorIs(t, err, models.ErrFolderNotFound)
+		assert.Empty(t, s.GetJobs(1))
+	})
+
+	t.Run("should return the jobs of the organization only", func(t *testing.T) {
+		s := newTestService(newFakeImporter())
+		job, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, Archive: newArchive(t, map[string]string{
+			"a.json": `{"title": "A", "panels": []}`,
+		})})
+		require.NoError(t, err)
+		waitForJob(t, s, 1, job.Uid)
+
+		_, err = s.GetJob(2, job.Uid)
+		assert.ErrorIs(t, err, ErrJobNotFound)
+		assert.Empty(t, s.GetJobs(2))
+		jobs := s.GetJobs(1)
+		require.Len(t, jobs, 1)
+		assert.Nil(t, jobs[0].Results)
+	})
+
+	t.Run("should limit the jobs of an organization", func(t *testing.T) {
+		importer := newFakeImporter()
+		importer.block = make(chan struct{})
+		s := newTestService(importer)
+		archive := newArchive(t, map[string]string{"a.json": `{"title": "A", "panels": []}`})
+
+		for i := 0; i < maxOrgJobs; i++ {
+			_, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, Archive: archive})
+			require.NoError(t, err)
+		}
+		_, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, Archive: archive})
+		assert.ErrorIs(t, err, ErrTooManyJobs)
+		_, err = s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 2, Archive: archive})
+		assert.NoError(t, err)
+		close(importer.block)
+	})
+
+	t.Run("should forget the jobs finished before the retention", func(t *testing.T) {
+		s := newTestService(newFakeImporter())
+		job, err := s.ImportArchive(ctx, user, ImportArchiveCommand{OrgId: 1, Archive: newArchive(t, nil)})
+		require.NoError(t, err)
+		waitForJob(t, s, 1, job.Uid)
+
+		s.now = func() time.Time { return time.Now().Add(jobRetention + time.Minute) }
+		assert.Empty(t, s.GetJobs(1))
+	})
+}
+
+func newTestService(importer dashboardImporter) *Service {
+	return &Service{
+		log:      log.New("dashboardimport.test"),
+		importer: importer,
+		now:      time.Now,
+		jobs:     map[string]*Job{},
+		running:  make(chan struct{}, maxRunningJobs),
+	}
+}
+
+func newArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func waitForJob(t *testing.T, s *Service, orgID int64, uid string) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = s.GetJob(orgID, uid)
+		require.NoError(t, err)
+		return job.IsFinished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+type fakeImporter struct {
+	mu             sync.Mutex
+	failTitles     map[string]bool
+	createdFolders map[string]int
+	block          chan struct{}
+	nextID         int64
+}
+
+func newFakeImporter() *fakeImporter {
+	return &fakeImporter{failTitles: map[string]bool{}, createdFolders: map[string]int{}}
+}
+
+func (i *fakeImporter) getFolder(_ context.Context, _ *models.SignedInUser, uid string) (*models.Folder, error) {
+	if uid == "missing" {
+		return nil, models.ErrFolderNotFound
+	}
+	return &models.Folder{Id: 100, Uid: uid, Title: uid}, nil
+}
+
+func (i *fakeImporter) getOrCreateFolder(_ context.Context, _ *models.SignedInUser, title string) (*models.Folder, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.createdFolders[title]++
+	return &models.Folder{Id: 200, Uid: "folder-" + title, Title: title}, nil
+}
+
+func (i *fakeImporter) importDashboard(_ context.Context, user *models.SignedInUser, model *simplejson.Json, folderID int64,
+	_ bool) (*models.Dashboard, error) {
+	if i.block != nil {
+		<-i.block
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.failTitles[model.Get("title").MustString()] {
+		return nil, errors.New("import failed")
+	}
+	i.nextID++
+	dash := models.NewDashboardFromJson(model)
+	dash.Id = i.nextID
+	dash.OrgId = user.OrgId
+	dash.FolderId = folderID
+	dash.SetUid(dash.Title + "-uid")
+	return dash, nil
+}
@@ -0,0 +1,389 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// The operations of a bulk update of dashboards
+const (
+	BulkOperationMove                = "move"
+	BulkOperationAddTags             = "addTags"
+	BulkOperationRemoveTags          = "removeTags"
+	BulkOperationChangeDataSourceUid = "changeDataSourceUid"
+)
+
+// MaxBulkUpdateDashboards is the maximum number of dashboards of a bulk update
+const MaxBulkUpdateDashboards = 1000
+
+// ErrInvalidBulkUpdate is returned when the dashboards or the operations of a bulk update are not valid
+var ErrInvalidBulkUpdate = errors.New("invalid bulk update")
+
+// BulkOperation is an operation of a bulk update. FolderUid is the folder the dashboards are moved to, the General
+// folder when empty. Tags are the tags added or removed. From and To are the uids of the data source replaced and of
+// the data source replacing it.
+type BulkOperation struct {
+	Type      string   `json:"type"`
+	FolderUid string   `json:"folderUid"`
+	Tags      []string `json:"tags"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+}
+
+// BulkUpdateDashboardsCommand applies operations to a set of dashboards, saved within a single transaction unless
+// DryRun is set
+type BulkUpdateDashboardsCommand struct {
+	DashboardUids []string        `json:"dashboardUids"`
+	Operations    []BulkOperation `json:"operations"`
+	DryRun        bool            `json:"dryRun"`
+	Message       string          `json:"message"`
+
+	OrgId int64                `json:"-"`
+	User  *models.SignedInUser `json:"-"`
+}
+
+// BulkUpdateResult lists the dashboards of a bulk update with their changes, the dashboards without changes are not
+// saved
+type BulkUpdateResult struct {
+	DryRun     bool                    `json:"dryRun"`
+	Dashboards []*BulkUpdatedDashboard `json:"dashboards"`
+}
+
+type BulkUpdatedDashboard struct {
+	Uid       string   `json:"uid"`
+	Title     string   `json:"title"`
+	FolderUid string   `json:"folderUid"`
+	Url       string   `json:"url"`
+	Version   int      `json:"version"`
+	Changes   []string `json:"changes"`
+}
+
+// BulkUpdateError is the error of a dashboard of a bulk update, none of the dashboards is saved then
+type BulkUpdateError struct {
+	Uid string
+	Err error
+}
+
+func (e BulkUpdateError) Error() string {
+	return fmt.Sprintf("dashboard %s: %s", e.Uid, e.Err)
+}
+
+func (e BulkUpdateError) Unwrap() error {
+	return e.Err
+}
+
+// bulkOperation is an operation whose folder or data source has been looked up
+type bulkOperation struct {
+	BulkOperation
+	folder     *models.Dashboard
+	dataSource *models.DataSource
+}
+
+// BulkUpdateDashboards applies the operations to the dashboards and saves them within a single transaction, none of
+// them is saved when one can't be. The dashboards are checked like the dashboards saved by the users, the user must
+// be able to save each of them, and the folder they are moved to. With DryRun, the dashboards are checked and their
+// changes returned without saving them.
+func (dr *dashboardServiceImpl) BulkUpdateDashboards(ctx context.Context, cmd *BulkUpdateDashboardsCommand) (*BulkUpdateResult, error) {
+	uids, err := validateBulkUpdate(cmd)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := lookUpBulkOperations(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkUpdateResult{DryRun: cmd.DryRun, Dashboards: make([]*BulkUpdatedDashboard, 0, len(uids))}
+	updated := make(map[string]*BulkUpdatedDashboard, len(uids))
+	folderUIDs := map[int64]string{0: ""}
+	for _, operation := range operations {
+		if operation.folder != nil {
+			folderUIDs[operation.folder.Id] = operation.folder.Uid
+		}
+	}
+	saveCmds := make([]*models.SaveDashboardCommand, 0, len(uids))
+	for _, uid := range uids {
+		query := models.GetDashboardQuery{Uid: uid, OrgId: cmd.OrgId}
+		if err := bus.Dispatch(ctx, &query); err != nil {
+			return nil, BulkUpdateError{Uid: uid, Err: err}
+		}
+		dash := query.Result
+		if dash.IsFolder {
+			return nil, BulkUpdateError{Uid: uid, Err: fmt.Errorf("%w: folders cannot be updated", ErrInvalidBulkUpdate)}
+		}
+
+		changes, err := applyBulkOperations(ctx, dash, operations)
+		if err != nil {
+			return nil, BulkUpdateError{Uid: uid, Err: err}
+		}
+		folderUID, err := lookUpFolderUID(ctx, folderUIDs, cmd.OrgId, dash.FolderId)
+		if err != nil {
+			return nil, BulkUpdateError{Uid: uid, Err: err}
+		}
+		entry := &BulkUpdatedDashboard{
+			Uid:       dash.Uid,
+			Title:     dash.Title,
+			FolderUid: folderUID,
+			Url:       models.GetDashboardUrl(dash.Uid, dash.Slug),
+			Version:   dash.Version,
+			Changes:   changes,
+		}
+		result.Dashboards = append(result.Dashboards, entry)
+		updated[dash.Uid] = entry
+		if len(changes) == 0 {
+			continue
+		}
+
+		if err := validateDashboardRefreshInterval(dash); err != nil {
+			dash.Data.Set("refresh", setting.MinRefreshInterval)
+		}
+		dash.Data.Set("id", dash.Id)
+		dash.Data.Set("uid", dash.Uid)
+		dash.Data.Set("version", dash.Version)
+		saveCmd, err := dr.buildSaveDashboardCommand(ctx, &SaveDashboardDTO{
+			OrgId:     cmd.OrgId,
+			User:      cmd.User,
+			Message:   cmd.Message,
+			Dashboard: dash,
+		}, true, true)
+		if err != nil {
+			return nil, BulkUpdateError{Uid: uid, Err: err}
+		}
+		saveCmds = append(saveCmds, saveCmd)
+	}
+
+	if cmd.DryRun || len(saveCmds) == 0 {
+		return result, nil
+	}
+
+	saved, err := dr.dashboardStore.SaveDashboards(ctx, saveCmds)
+	if err != nil {
+		return nil, fmt.Errorf("saving dashboards failed: %w", err)
+	}
+	for _, dash := range saved {
+		if err := UpdateAlerting(ctx, dr.dashboardStore, cmd.OrgId, dash, cmd.User); err != nil {
+			return nil, err
+		}
+		updated[dash.Uid].Version = dash.Version
+	}
+	return result, nil
+}
+
+// validateBulkUpdate checks the operations of the bulk update and returns its dashboards without duplicates
+func validateBulkUpdate(cmd *BulkUpdateDashboardsCommand) ([]string, error) {
+	if len(cmd.DashboardUids) == 0 {
+		return nil, fmt.Errorf("%w: no dashboards", ErrInvalidBulkUpdate)
+	}
+	if len(cmd.Operations) == 0 {
+		return nil, fmt.Errorf("%w: no operations", ErrInvalidBulkUpdate)
+	}
+
+	uids := make([]string, 0, len(cmd.DashboardUids))
+	seen := make(map[string]bool, len(cmd.DashboardUids))
+	for _, uid := range cmd.DashboardUids {
+		if !seen[uid] {
+			seen[uid] = true
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) > MaxBulkUpdateDashboards {
+		return nil, fmt.Errorf("%w: more than %d dashboards", ErrInvalidBulkUpdate, MaxBulkUpdateDashboards)
+	}
+
+	for _, operation := range cmd.Operations {
+		switch operation.Type {
+		case BulkOperationMove:
+		case BulkOperationAddTags, BulkOperationRemoveTags:
+			if len(operation.Tags) == 0 {
+				return nil, fmt.Errorf("%w: %s without tags", ErrInvalidBulkUpdate, operation.Type)
+			}
+		case BulkOperationChangeDataSourceUid:
+			if operation.From == "" || operation.To == "" || operation.From == operation.To {
+				return nil, fmt.Errorf("%w: %s requires different from and to data sources", ErrInvalidBulkUpdate, operation.Type)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidBulkUpdate, operation.Type)
+		}
+	}
+	return uids, nil
+}
+
+// lookUpBulkOperations looks up the folders the dashboards are moved to and the data sources replacing others
+func lookUpBulkOperations(ctx context.Context, cmd *BulkUpdateDashboardsCommand) ([]bulkOperation, error) {
+	operations := make([]bulkOperation, 0, len(cmd.Operations))
+	for _, op := range cmd.Operations {
+		operation := bulkOperation{BulkOperation: op}
+		switch op.Type {
+		case BulkOperationMove:
+			if op.FolderUid == "" {
+				break
+			}
+			query := models.GetDashboardQuery{Uid: op.FolderUid, OrgId: cmd.OrgId}
+			if err := bus.Dispatch(ctx, &query); err != nil {
+				if errors.Is(err, models.ErrDashboardNotFound) {
+					return nil, models.ErrFolderNotFound
+				}
+				return nil, err
+			}
+			if !query.Result.IsFolder {
+				return nil, models.ErrFolderNotFound
+			}
+			operation.folder = query.Result
+		case BulkOperationChangeDataSourceUid:
+			query := models.GetDataSourceQuery{Uid: op.To, OrgId: cmd.OrgId}
+			if err := bus.Dispatch(ctx, &query); err != nil {
+				if errors.Is(err, models.ErrDataSourceNotFound) {
+					return nil, fmt.Errorf("%w: data source %s not found", ErrInvalidBulkUpdate, op.To)
+				}
+				return nil, err
+			}
+			operation.dataSource = query.Result
+		}
+		operations = append(operations, operation)
+	}
+	return operations, nil
+}
+
+// applyBulkOperations applies the operations to the dashboard, in order, and describes the changes made
+func applyBulkOperations(ctx context.Context, dash *models.Dashboard, operations []bulkOperation) ([]string, error) {
+	changes := make([]string, 0)
+	for _, operation := range operations {
+		switch operation.Type {
+		case BulkOperationMove:
+			var folderID int64
+			folderTitle := models.RootFolderName
+			if operation.folder != nil {
+				folderID = operation.folder.Id
+				folderTitle = operation.folder.Title
+			}
+			if dash.FolderId != folderID {
+				dash.FolderId = folderID
+				changes = append(changes, fmt.Sprintf("moved to folder %s", folderTitle))
+			}
+		case BulkOperationAddTags:
+			if added := addDashboardTags(dash.Data, operation.Tags); len(added) > 0 {
+				changes = append(changes, fmt.Sprintf("added tags %s", strings.Join(added, ", ")))
+			}
+		case BulkOperationRemoveTags:
+			if removed := removeDashboardTags(dash.Data, operation.Tags); len(removed) > 0 {
+				changes = append(changes, fmt.Sprintf("removed tags %s", strings.Join(removed, ", ")))
+			}
+		case BulkOperationChangeDataSourceUid:
+			replaced, err := replaceDashboardDataSource(ctx, dash, operation.From, operation.dataSource)
+			if err != nil {
+				return nil, err
+			}
+			if replaced > 0 {
+				changes = append(changes, fmt.Sprintf("replaced data source %s with %s in %d places", operation.From,
+					operation.To, replaced))
+			}
+		}
+	}
+	return changes, nil
+}
+
+// lookUpFolderUID returns the uid of the folder, looked up once
+func lookUpFolderUID(ctx context.Context, folderUIDs map[int64]string, orgID, folderID int64) (string, error) {
+	if uid, ok := folderUIDs[folderID]; ok {
+		return uid, nil
+	}
+	query := models.GetDashboardQuery{Id: folderID, OrgId: orgID}
+	if err := bus.Dispatch(ctx, &query); err != nil {
+		return "", err
+	}
+	folderUIDs[folderID] = query.Result.Uid
+	return query.Result.Uid, nil
+}
+
+// addDashboardTags adds the tags the dashboard doesn't have yet and returns them
+func addDashboardTags(data *simplejson.Json, tags []string) []string {
+	existing := data.Get("tags").MustStringArray()
+	has := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		has[tag] = true
+	}
+
+	added := make([]string, 0)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || has[tag] {
+			continue
+		}
+		has[tag] = true
+		existing = append(existing, tag)
+		added = append(added, tag)
+	}
+	if len(added) > 0 {
+		data.Set("tags", existing)
+	}
+	return added
+}
+
+// removeDashboardTags removes the tags of the dashboard and returns the ones it had
+func removeDashboardTags(data *simplejson.Json, tags []string) []string {
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		remove[strings.TrimSpace(tag)] = true
+	}
+
+	existing := data.Get("tags").MustStringArray()
+	kept := make([]string, 0, len(existing))
+	removed := make([]string, 0)
+	for _, tag := range existing {
+		if remove[tag] {
+			removed = append(removed, tag)
+			continue
+		}
+		kept = append(kept, tag)
+	}
+	if len(removed) > 0 {
+		data.Set("tags", kept)
+	}
+	return removed
+}
+
+// replaceDashboardDataSource replaces the references to the data source with the uid from with references to the
+// data source target, keeping the format of the references, and returns the number of references replaced.
+// References to the default data source are kept as is, even when it is the replaced data source.
+func replaceDashboardDataSource(ctx context.Context, dash *models.Dashboard, from string, target *models.DataSource) (int, error) {
+	replaced := 0
+	names := map[string]string{}
+	var err error
+	visitDashboardDataSources(dash.Data, false, func(holder *simplejson.Json) {
+		ref, ok := parseDataSourceRef(holder.Get("datasource"))
+		if err != nil || !ok || (ref.uid == "" && ref.name == "") {
+			return
+		}
+
+		uid := ref.uid
+		if uid == "" {
+			resolved, seen := names[ref.name]
+			if !seen {
+				resolved, err = resolveDataSourceRef(ctx, dash.OrgId, ref)
+				if err != nil {
+					return
+				}
+				names[ref.name] = resolved
+			}
+			uid = resolved
+		}
+		if uid != from {
+			return
+		}
+
+		replaced++
+		if ref.name != "" {
+			holder.Set("datasource", target.Name)
+			return
+		}
+		holder.Set("datasource", map[string]interface{}{"uid": target.Uid, "type": target.Type})
+	})
+	return replaced, err
+}
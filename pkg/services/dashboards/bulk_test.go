@@ -0,0 +1,160 @@
+//go:build integration
+// +build integration
+
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestBulkUpdateDashboards(t *testing.T) {
+	origUpdateAlerting := UpdateAlerting
+	t.Cleanup(func() {
+		UpdateAlerting = origUpdateAlerting
+	})
+	UpdateAlerting = func(ctx context.Context, store dashboards.Store, orgID int64, dashboard *models.Dashboard, user *models.SignedInUser) error {
+		return nil
+	}
+	ctx := context.Background()
+	user := &models.SignedInUser{UserId: 1, OrgId: testOrgID, OrgRole: models.ROLE_EDITOR}
+
+	permissionScenario(t, "When moving and tagging dashboards", true, func(t *testing.T, sc *permissionScenarioContext) {
+		cmd := &BulkUpdateDashboardsCommand{
+			DashboardUids: []string{sc.savedDashInFolder.Uid, sc.savedDashInGeneralFolder.Uid},
+			Operations: []BulkOperation{
+				{Type: BulkOperationMove, FolderUid: sc.otherSavedFolder.Uid},
+				{Type: BulkOperationAddTags, Tags: []string{"prod", "team-a"}},
+			},
+			OrgId: testOrgID,
+			User:  user,
+		}
+
+		t.Run("dry run should return the changes without saving", func(t *testing.T) {
+			dryRun := *cmd
+			dryRun.DryRun = true
+			result, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, &dryRun)
+			require.NoError(t, err)
+			require.Len(t, result.Dashboards, 2)
+			assert.True(t, result.DryRun)
+			assert.Equal(t, []string{"moved to folder Other saved folder", "added tags prod, team-a"}, result.Dashboards[0].Changes)
+			assert.Equal(t, sc.otherSavedFolder.Uid, result.Dashboards[0].FolderUid)
+
+			dash, err := sc.sqlStore.GetDashboard(0, testOrgID, sc.savedDashInFolder.Uid, "")
+			require.NoError(t, err)
+			assert.Equal(t, sc.savedFolder.Id, dash.FolderId)
+			assert.Empty(t, dash.Data.Get("tags").MustStringArray())
+		})
+
+		t.Run("should save the dashboards", func(t *testing.T) {
+			result, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, cmd)
+			require.NoError(t, err)
+			require.Len(t, result.Dashboards, 2)
+			assert.Equal(t, 2, result.Dashboards[1].Version)
+
+			for _, uid := range cmd.DashboardUids {
+				dash, err := sc.sqlStore.GetDashboard(0, testOrgID, uid, "")
+				require.NoError(t, err)
+				assert.Equal(t, sc.otherSavedFolder.Id, dash.FolderId)
+				assert.Equal(t, []string{"prod", "team-a"}, dash.Data.Get("tags").MustStringArray())
+			}
+		})
+
+		t.Run("should not save the dashboards without changes", func(t *testing.T) {
+			result, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, cmd)
+			require.NoError(t, err)
+			assert.Empty(t, result.Dashboards[0].Changes)
+			assert.Equal(t, 2, result.Dashboards[0].Version)
+		})
+	})
+
+	permissionScenario(t, "When the user cannot save the dashboards", false, func(t *testing.T, sc *permissionScenarioContext) {
+		_, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, &BulkUpdateDashboardsCommand{
+			DashboardUids: []string{sc.savedDashInFolder.Uid},
+			Operations:    []BulkOperation{{Type: BulkOperationAddTags, Tags: []string{"prod"}}},
+			OrgId:         testOrgID,
+			User:          user,
+		})
+		require.ErrorIs(t, err, models.ErrDashboardUpdateAccessDenied)
+		var bulkErr BulkUpdateError
+		require.ErrorAs(t, err, &bulkErr)
+		assert.Equal(t, sc.savedDashInFolder.Uid, bulkErr.Uid)
+	})
+
+	permissionScenario(t, "When a dashboard conflicts with another one", true, func(t *testing.T, sc *permissionScenarioContext) {
+		conflicting := saveTestDashboard(t, "Saved dash in folder", testOrgID, sc.otherSavedFolder.Id, sc.sqlStore)
+		other := saveTestDashboard(t, "Untouched", testOrgID, 0, sc.sqlStore)
+
+		_, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, &BulkUpdateDashboardsCommand{
+			DashboardUids: []string{other.Uid, conflicting.Uid, sc.savedDashInFolder.Uid},
+			Operations:    []BulkOperation{{Type: BulkOperationMove, FolderUid: sc.savedFolder.Uid}},
+			OrgId:         testOrgID,
+			User:          user,
+		})
+		require.ErrorIs(t, err, models.ErrDashboardWithSameNameInFolderExists)
+
+		dash, err := sc.sqlStore.GetDashboard(0, testOrgID, other.Uid, "")
+		require.NoError(t, err)
+		assert.Zero(t, dash.FolderId)
+	})
+
+	permissionScenario(t, "When changing the data source of dashboards", true, func(t *testing.T, sc *permissionScenarioContext) {
+		bus.AddHandler("test", sc.sqlStore.GetDataSource)
+		for _, uid := range []string{"old-prom", "new-prom"} {
+			require.NoError(t, sc.sqlStore.AddDataSource(ctx, &models.AddDataSourceCommand{
+				OrgId: testOrgID, Name: uid, Uid: uid, Type: "prometheus", Access: models.DS_ACCESS_PROXY,
+			}))
+		}
+		saved, err := sc.sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId: testOrgID,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title": "Data sources",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"datasource": map[string]interface{}{"uid": "old-prom", "type": "prometheus"},
+						"targets":    []interface{}{map[string]interface{}{"datasource": "old-prom"}},
+					},
+					map[string]interface{}{"datasource": map[string]interface{}{"uid": "other"}},
+				},
+			}),
+		})
+		require.NoError(t, err)
+
+		result, err := NewService(sc.sqlStore).BulkUpdateDashboards(ctx, &BulkUpdateDashboardsCommand{
+			DashboardUids: []string{saved.Uid},
+			Operations:    []BulkOperation{{Type: BulkOperationChangeDataSourceUid, From: "old-prom", To: "new-prom"}},
+			OrgId:         testOrgID,
+			User:          user,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"replaced data source old-prom with new-prom in 2 places"}, result.Dashboards[0].Changes)
+
+		dash, err := sc.sqlStore.GetDashboard(0, testOrgID, saved.Uid, "")
+		require.NoError(t, err)
+		panels := dash.Data.Get("panels")
+		assert.Equal(t, "new-prom", panels.GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "new-prom", panels.GetIndex(0).Get("targets").GetIndex(0).Get("datasource").MustString())
+		assert.Equal(t, "other", panels.GetIndex(1).GetPath("datasource", "uid").MustString())
+	})
+
+	t.Run("Should reject invalid operations", func(t *testing.T) {
+		for _, cmd := range []*BulkUpdateDashboardsCommand{
+			{DashboardUids: []string{"a"}},
+			{Operations: []BulkOperation{{Type: BulkOperationMove}}},
+			{DashboardUids: []string{"a"}, Operations: []BulkOperation{{Type: "rename"}}},
+			{DashboardUids: []string{"a"}, Operations: []BulkOperation{{Type: BulkOperationAddTags}}},
+			{DashboardUids: []string{"a"}, Operations: []BulkOperation{{Type: BulkOperationChangeDataSourceUid, From: "a", To: "a"}}},
+		} {
+			_, err := validateBulkUpdate(cmd)
+			assert.ErrorIs(t, err, ErrInvalidBulkUpdate)
+		}
+	})
+}
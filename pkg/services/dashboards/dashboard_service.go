@@ -23,8 +23,13 @@ import (
 type DashboardService interface {
 	SaveDashboard(ctx context.Context, dto *SaveDashboardDTO, allowUiUpdate bool) (*models.Dashboard, error)
 	ImportDashboard(ctx context.Context, dto *SaveDashboardDTO) (*models.Dashboard, error)
-	DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64) error
+	// DeleteDashboard deletes a dashboard on behalf of the user deletedBy, it is moved to the trash of its organization
+	DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64, deletedBy int64) error
 	MakeUserAdmin(ctx context.Context, orgID int64, userID, dashboardID int64, setViewAndEditPermissions bool) error
+	// BulkUpdateDashboards applies operations to a set of dashboards saved within a single transaction
+	BulkUpdateDashboards(ctx context.Context, cmd *BulkUpdateDashboardsCommand) (*BulkUpdateResult, error)
+	// RestoreDashboard restores a dashboard or a folder from the trash of its organization on behalf of the user
+	RestoreDashboard(ctx context.Context, cmd *models.RestoreDashboardCommand) error
 }
 
 // DashboardProvisioningService is a service for operating on provisioned dashboards.
@@ -128,6 +133,10 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(ctx context.Context, d
 		return nil, err
 	}
 
+	if err := dr.validateFolderDataSources(ctx, dash); err != nil {
+		return nil, err
+	}
+
 	if isParentFolderChanged {
 		folderGuardian := guardian.New(ctx, dash.FolderId, dto.OrgId, dto.User)
 		if canSave, err := folderGuardian.CanSave(); err != nil || !canSave {
@@ -302,18 +311,21 @@ func (dr *dashboardServiceImpl) SaveDashboard(ctx context.Context, dto *SaveDash
 }
 
 // DeleteDashboard removes dashboard from the DB. Errors out if the dashboard was provisioned. Should be used for
-// operations by the user where we want to make sure user does not delete provisioned dashboard.
-func (dr *dashboardServiceImpl) DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64) error {
-	return dr.deleteDashboard(ctx, dashboardId, orgId, true)
+// operations by the user where we want to make sure user does not delete provisioned dashboard. The dashboard is
+// moved to the trash, from which it can be restored until the retention of the deleted dashboards is over.
+func (dr *dashboardServiceImpl) DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64, deletedBy int64) error {
+	return dr.deleteDashboard(ctx, dashboardId, orgId, true, deletedBy)
 }
 
 // DeleteProvisionedDashboard removes dashboard from the DB even if it is provisioned.
 func (dr *dashboardServiceImpl) DeleteProvisionedDashboard(ctx context.Context, dashboardId int64, orgId int64) error {
-	return dr.deleteDashboard(ctx, dashboardId, orgId, false)
+	return dr.deleteDashboard(ctx, dashboardId, orgId, false, 0)
 }
 
-func (dr *dashboardServiceImpl) deleteDashboard(ctx context.Context, dashboardId int64, orgId int64, validateProvisionedDashboard bool) error {
-	if validateProvisionedDashboard {
+// deleteDashboard moves the dashboard to the trash when it is deleted by a user, a provisioned dashboard would be
+// provisioned again anyway
+func (dr *dashboardServiceImpl) deleteDashboard(ctx context.Context, dashboardId int64, orgId int64, byUser bool, deletedBy int64) error {
+	if byUser {
 		provisionedData, err := dr.GetProvisionedDashboardDataByDashboardID(dashboardId)
 		if err != nil {
 			return errutil.Wrap("failed to check if dashboard is provisioned", err)
@@ -323,7 +335,7 @@ func (dr *dashboardServiceImpl) deleteDashboard(ctx context.Context, dashboardId
 			return models.ErrDashboardCannotDeleteProvisionedDashboard
 		}
 	}
-	cmd := &models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId}
+	cmd := &models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId, MoveToTrash: byUser, DeletedBy: deletedBy}
 	return bus.Dispatch(ctx, cmd)
 }
 
@@ -379,7 +391,7 @@ func (s *FakeDashboardService) ImportDashboard(ctx context.Context, dto *SaveDas
 	return s.SaveDashboard(ctx, dto, true)
 }
 
-func (s *FakeDashboardService) DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64) error {
+func (s *FakeDashboardService) DeleteDashboard(ctx context.Context, dashboardId int64, orgId int64, deletedBy int64) error {
 	for index, dash := range s.SavedDashboards {
 		if dash.Dashboard.Id == dashboardId && dash.OrgId == orgId {
 			s.SavedDashboards = append(s.SavedDashboards[:index], s.SavedDashboards[index+1:]...)
@@ -389,6 +401,10 @@ func (s *FakeDashboardService) DeleteDashboard(ctx context.Context, dashboardId
 	return nil
 }
 
+func (s *FakeDashboardService) BulkUpdateDashboards(ctx context.Context, cmd *BulkUpdateDashboardsCommand) (*BulkUpdateResult, error) {
+	return &BulkUpdateResult{DryRun: cmd.DryRun, Dashboards: []*BulkUpdatedDashboard{}}, nil
+}
+
 func (s *FakeDashboardService) GetProvisionedDashboardDataByDashboardID(id int64) (*models.DashboardProvisioning, error) {
 	return s.ProvisionedDashData, nil
 }
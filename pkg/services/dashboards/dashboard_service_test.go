@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
 
@@ -244,7 +245,7 @@ func TestDashboardService(t *testing.T) {
 
 			t.Run("DeleteDashboard should fail to delete it", func(t *testing.T) {
 				result := setupDeleteHandlers(t, &fakeStore, true)
-				err := service.DeleteDashboard(context.Background(), 1, 1)
+				err := service.DeleteDashboard(context.Background(), 1, 1, 1)
 				require.Equal(t, err, models.ErrDashboardCannotDeleteProvisionedDashboard)
 				require.False(t, result.deleteWasCalled)
 			})
@@ -260,7 +261,7 @@ func TestDashboardService(t *testing.T) {
 			})
 
 			t.Run("DeleteDashboard should delete it", func(t *testing.T) {
-				err := service.DeleteDashboard(context.Background(), 1, 1)
+				err := service.DeleteDashboard(context.Background(), 1, 1, 1)
 				require.NoError(t, err)
 				require.True(t, result.deleteWasCalled)
 			})
@@ -296,8 +297,24 @@ func setupDeleteHandlers(t *testing.T, fakeStore *fakeDashboardStore, provisione
 type fakeDashboardStore struct {
 	dashboards.Store
 
-	validationError error
-	provisionedData *models.DashboardProvisioning
+	validationError   error
+	provisionedData   *models.DashboardProvisioning
+	folderDataSources []string
+	libraryPanels     map[string]*simplejson.Json
+}
+
+func (s *fakeDashboardStore) GetFolderDataSourceUIDs(ctx context.Context, orgID, folderID int64) ([]string, error) {
+	return s.folderDataSources, nil
+}
+
+func (s *fakeDashboardStore) GetLibraryPanelModels(ctx context.Context, orgID int64, uids []string) (map[string]*simplejson.Json, error) {
+	result := make(map[string]*simplejson.Json)
+	for _, uid := range uids {
+		if model, ok := s.libraryPanels[uid]; ok {
+			result[uid] = model
+		}
+	}
+	return result, nil
 }
 
 func (s *fakeDashboardStore) ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (
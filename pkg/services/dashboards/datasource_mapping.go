@@ -0,0 +1,69 @@
+package dashboards
+
+import (
+	"context"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// DataSourceMapping returns the data source that replaces the data source with the given uid, or nil if there is none.
+type DataSourceMapping func(ctx context.Context, uid string) (*models.DataSource, error)
+
+// RemapDashboardDataSources replaces the references to the data sources of the source organization in the dashboard,
+// including the loaded library panels, with references to the data sources returned by the mapping. References to
+// the default data source, to variables and to built-in data sources are kept as is.
+//
+// The references that can't be mapped are returned sorted, the dashboard is left partially remapped in that case.
+func RemapDashboardDataSources(ctx context.Context, data *simplejson.Json, sourceOrgID int64, mapping DataSourceMapping) ([]string, error) {
+	resolved := map[dataSourceRef]*models.DataSource{}
+	unmapped := map[string]bool{}
+
+	var err error
+	visitDashboardDataSources(data, true, func(holder *simplejson.Json) {
+		ref, ok := parseDataSourceRef(holder.Get("datasource"))
+		if err != nil || !ok || (ref.uid == "" && ref.name == "") {
+			return
+		}
+
+		target, seen := resolved[ref]
+		if !seen {
+			target, err = mapDataSourceRef(ctx, sourceOrgID, ref, mapping)
+			if err != nil {
+				return
+			}
+			resolved[ref] = target
+		}
+
+		if target == nil {
+			unmapped[ref.String()] = true
+			return
+		}
+
+		// Keep the format of the reference, older dashboards reference data sources by name
+		if ref.name != "" {
+			holder.Set("datasource", target.Name)
+			return
+		}
+		holder.Set("datasource", map[string]interface{}{"uid": target.Uid, "type": target.Type})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(unmapped))
+	for name := range unmapped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func mapDataSourceRef(ctx context.Context, sourceOrgID int64, ref dataSourceRef, mapping DataSourceMapping) (*models.DataSource, error) {
+	uid, err := resolveDataSourceRef(ctx, sourceOrgID, ref)
+	if err != nil || uid == "" {
+		return nil, err
+	}
+	return mapping(ctx, uid)
+}
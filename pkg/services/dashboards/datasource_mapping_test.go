@@ -0,0 +1,84 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestRemapDashboardDataSources(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	sources := []*models.DataSource{
+		{OrgId: 1, Uid: "prom", Name: "Prometheus", Type: "prometheus"},
+		{OrgId: 1, Uid: "loki", Name: "Loki", Type: "loki"},
+	}
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetDataSourceQuery) error {
+		for _, ds := range sources {
+			if ds.OrgId == query.OrgId && (ds.Uid == query.Uid || (query.Uid == "" && ds.Name == query.Name)) {
+				query.Result = ds
+				return nil
+			}
+		}
+		return models.ErrDataSourceNotFound
+	})
+
+	target := &models.DataSource{OrgId: 2, Uid: "prom-2", Name: "Prometheus (org 2)", Type: "prometheus"}
+	mapping := func(ctx context.Context, uid string) (*models.DataSource, error) {
+		if uid == "prom" {
+			return target, nil
+		}
+		return nil, nil
+	}
+
+	data := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{"datasource": "Prometheus"},
+			map[string]interface{}{
+				"type":      "row",
+				"collapsed": true,
+				"panels": []interface{}{
+					map[string]interface{}{
+						"datasource": map[string]interface{}{"uid": "-- Mixed --"},
+						"targets": []interface{}{
+							map[string]interface{}{"datasource": map[string]interface{}{"uid": "prom", "type": "prometheus"}},
+							map[string]interface{}{"datasource": "$ds"},
+						},
+					},
+				},
+			},
+			map[string]interface{}{
+				"datasource":   map[string]interface{}{"uid": "prom"},
+				"libraryPanel": map[string]interface{}{"uid": "lib", "name": "lib"},
+			},
+			map[string]interface{}{},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"type": "query", "datasource": map[string]interface{}{"uid": "loki"}},
+			},
+		},
+	})
+
+	unmapped, err := RemapDashboardDataSources(context.Background(), data, 1, mapping)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loki"}, unmapped)
+
+	panels := data.Get("panels")
+	assert.Equal(t, "Prometheus (org 2)", panels.GetIndex(0).Get("datasource").MustString())
+	row := panels.GetIndex(1).Get("panels").GetIndex(0)
+	assert.Equal(t, "-- Mixed --", row.GetPath("datasource", "uid").MustString())
+	assert.Equal(t, map[string]interface{}{"uid": "prom-2", "type": "prometheus"}, row.Get("targets").GetIndex(0).Get("datasource").MustMap())
+	assert.Equal(t, "$ds", row.Get("targets").GetIndex(1).Get("datasource").MustString())
+	assert.Equal(t, "prom-2", panels.GetIndex(2).GetPath("datasource", "uid").MustString())
+	_, hasDataSource := panels.GetIndex(3).CheckGet("datasource")
+	assert.False(t, hasDataSource)
+	assert.Equal(t, "loki", data.GetPath("templating", "list").GetIndex(0).GetPath("datasource", "uid").MustString())
+}
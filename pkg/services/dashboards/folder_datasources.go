@@ -0,0 +1,230 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// builtInDataSources are the data sources provided by Grafana itself, they are always allowed
+var builtInDataSources = map[string]bool{
+	"grafana":         true,
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+// dataSourceRef is a reference to a data source in the dashboard model, either by uid or by name.
+// A reference without uid and name refers to the default data source.
+type dataSourceRef struct {
+	uid  string
+	name string
+}
+
+func (r dataSourceRef) String() string {
+	if r.uid != "" {
+		return r.uid
+	}
+	if r.name != "" {
+		return r.name
+	}
+	return "default"
+}
+
+// validateFolderDataSources makes sure the dashboard only references the data sources allowed in its folder
+func (dr *dashboardServiceImpl) validateFolderDataSources(ctx context.Context, dash *models.Dashboard) error {
+	if dash.IsFolder || dash.FolderId == 0 {
+		return nil
+	}
+
+	allowedUIDs, err := dr.dashboardStore.GetFolderDataSourceUIDs(ctx, dash.OrgId, dash.FolderId)
+	if err != nil {
+		return err
+	}
+	if len(allowedUIDs) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(allowedUIDs))
+	for _, uid := range allowedUIDs {
+		allowed[uid] = true
+	}
+
+	refs := getDashboardDataSourceRefs(dash.Data)
+	libraryRefs, err := dr.getLibraryPanelDataSourceRefs(ctx, dash.OrgId, dash.Data)
+	if err != nil {
+		return err
+	}
+	refs = append(refs, libraryRefs...)
+
+	notAllowed := map[string]bool{}
+	for _, ref := range refs {
+		uid, err := resolveDataSourceRef(ctx, dash.OrgId, ref)
+		if err != nil {
+			return err
+		}
+		if !allowed[uid] {
+			notAllowed[ref.String()] = true
+		}
+	}
+
+	if len(notAllowed) > 0 {
+		names := make([]string, 0, len(notAllowed))
+		for name := range notAllowed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return models.DashboardDataSourceNotAllowedError{DataSources: names}
+	}
+
+	return nil
+}
+
+// resolveDataSourceRef returns the uid of the referenced data source, or an empty string if it does not exist
+func resolveDataSourceRef(ctx context.Context, orgID int64, ref dataSourceRef) (string, error) {
+	if ref.uid == "" && ref.name == "" {
+		query := &models.GetDefaultDataSourceQuery{OrgId: orgID}
+		if err := bus.Dispatch(ctx, query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return "", nil
+			}
+			return "", err
+		}
+		return query.Result.Uid, nil
+	}
+
+	query := &models.GetDataSourceQuery{Uid: ref.uid, Name: ref.name, OrgId: orgID}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return query.Result.Uid, nil
+}
+
+// getDashboardDataSourceRefs returns the data sources referenced by the panels, queries, annotations and
+// query variables of the dashboard. References to variables and built-in data sources are skipped.
+func getDashboardDataSourceRefs(data *simplejson.Json) []dataSourceRef {
+	refs := make([]dataSourceRef, 0)
+	visitDashboardDataSources(data, false, func(holder *simplejson.Json) {
+		ref, ok := parseDataSourceRef(holder.Get("datasource"))
+		if ok {
+			refs = append(refs, ref)
+		}
+	})
+	return refs
+}
+
+// getLibraryPanelDataSourceRefs returns the data sources referenced by the stored models of the library panels of
+// the dashboard, the panels of the dashboard only hold a reference to them
+func (dr *dashboardServiceImpl) getLibraryPanelDataSourceRefs(ctx context.Context, orgID int64, data *simplejson.Json) ([]dataSourceRef, error) {
+	uids := make([]string, 0)
+	var visitPanels func(panels []interface{})
+	visitPanels = func(panels []interface{}) {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			visitPanels(panel.Get("panels").MustArray())
+			if uid := panel.GetPath("libraryPanel", "uid").MustString(); uid != "" {
+				uids = append(uids, uid)
+			}
+		}
+	}
+	visitPanels(data.Get("panels").MustArray())
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	libraryPanels, err := dr.dashboardStore.GetLibraryPanelModels(ctx, orgID, uids)
+	if err != nil {
+		return nil, err
+	}
+	panels := make([]interface{}, 0, len(libraryPanels))
+	for _, model := range libraryPanels {
+		panels = append(panels, model.Interface())
+	}
+
+	refs := make([]dataSourceRef, 0)
+	visitDashboardDataSources(simplejson.NewFromAny(map[string]interface{}{"panels": panels}), true, func(holder *simplejson.Json) {
+		if ref, ok := parseDataSourceRef(holder.Get("datasource")); ok {
+			refs = append(refs, ref)
+		}
+	})
+	return refs, nil
+}
+
+// visitDashboardDataSources calls visit with each panel, query, annotation and query variable of the dashboard
+// holding a data source reference in its datasource property. Library panels are only visited when their model
+// has been loaded into the dashboard and includeLibraryPanels is set.
+func visitDashboardDataSources(data *simplejson.Json, includeLibraryPanels bool, visit func(holder *simplejson.Json)) {
+	var visitPanels func(panels []interface{})
+	visitPanels = func(panels []interface{}) {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			// Collapsed rows contain their panels
+			visitPanels(panel.Get("panels").MustArray())
+
+			if panel.Get("type").MustString() == "row" || (panel.Get("libraryPanel").Interface() != nil && !includeLibraryPanels) {
+				continue
+			}
+
+			if !isMixedDataSource(panel.Get("datasource")) {
+				visit(panel)
+			}
+
+			for _, t := range panel.Get("targets").MustArray() {
+				target := simplejson.NewFromAny(t)
+				if _, ok := target.CheckGet("datasource"); ok {
+					visit(target)
+				}
+			}
+		}
+	}
+	visitPanels(data.Get("panels").MustArray())
+
+	for _, a := range data.GetPath("annotations", "list").MustArray() {
+		annotation := simplejson.NewFromAny(a)
+		if _, ok := annotation.CheckGet("datasource"); ok {
+			visit(annotation)
+		}
+	}
+
+	for _, v := range data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("type").MustString() != "query" {
+			continue
+		}
+		visit(variable)
+	}
+}
+
+func isMixedDataSource(datasource *simplejson.Json) bool {
+	ref, ok := parseDataSourceRef(datasource)
+	return !ok && (ref.uid == "-- Mixed --" || ref.name == "-- Mixed --")
+}
+
+// parseDataSourceRef parses a data source reference that is either a name or an object with an uid.
+// It returns false for references that should not be validated.
+func parseDataSourceRef(datasource *simplejson.Json) (dataSourceRef, bool) {
+	ref := dataSourceRef{}
+	if datasource == nil || datasource.Interface() == nil {
+		return ref, true
+	}
+
+	if name, err := datasource.String(); err == nil {
+		ref.name = name
+	} else {
+		ref.uid = datasource.Get("uid").MustString()
+	}
+
+	if key := ref.uid + ref.name; strings.Contains(key, "$") || builtInDataSources[key] {
+		return ref, false
+	}
+
+	return ref, true
+}
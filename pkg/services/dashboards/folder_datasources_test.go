@@ -0,0 +1,148 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestGetDashboardDataSourceRefs(t *testing.T) {
+	data := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{"datasource": "prometheus"},
+			map[string]interface{}{
+				"datasource": map[string]interface{}{"uid": "-- Mixed --"},
+				"targets": []interface{}{
+					map[string]interface{}{"datasource": map[string]interface{}{"uid": "loki-uid"}},
+					map[string]interface{}{"datasource": "$ds"},
+				},
+			},
+			map[string]interface{}{
+				"type":      "row",
+				"collapsed": true,
+				"panels": []interface{}{
+					map[string]interface{}{},
+				},
+			},
+			map[string]interface{}{"libraryPanel": map[string]interface{}{"uid": "lib"}},
+		},
+		"annotations": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"datasource": "-- Grafana --"},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"type": "query", "datasource": map[string]interface{}{"uid": "mysql-uid"}},
+				map[string]interface{}{"type": "datasource", "query": "prometheus"},
+			},
+		},
+	})
+
+	refs := getDashboardDataSourceRefs(data)
+	assert.ElementsMatch(t, []dataSourceRef{
+		{name: "prometheus"},
+		{uid: "loki-uid"},
+		{},
+		{uid: "mysql-uid"},
+	}, refs)
+}
+
+func TestValidateFolderDataSources(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	dataSources := map[string]*models.DataSource{
+		"prod":    {Uid: "prod", Name: "Prod", IsDefault: true},
+		"staging": {Uid: "staging", Name: "Staging"},
+	}
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetDataSourceQuery) error {
+		for _, ds := range dataSources {
+			if ds.Uid == query.Uid || (query.Uid == "" && ds.Name == query.Name) {
+				query.Result = ds
+				return nil
+			}
+		}
+		return models.ErrDataSourceNotFound
+	})
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetDefaultDataSourceQuery) error {
+		query.Result = dataSources["prod"]
+		return nil
+	})
+
+	fakeStore := &fakeDashboardStore{}
+	service := &dashboardServiceImpl{log: log.New("test.logger"), dashboardStore: fakeStore}
+
+	newDashboard := func(panels ...interface{}) *models.Dashboard {
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{"title": "Dash", "panels": panels}))
+		dash.OrgId = 1
+		dash.FolderId = 2
+		return dash
+	}
+
+	t.Run("should allow all data sources when the folder has no restrictions", func(t *testing.T) {
+		fakeStore.folderDataSources = nil
+		err := service.validateFolderDataSources(context.Background(), newDashboard(map[string]interface{}{"datasource": "Staging"}))
+		require.NoError(t, err)
+	})
+
+	t.Run("should allow referencing allowed data sources", func(t *testing.T) {
+		fakeStore.folderDataSources = []string{"prod"}
+		err := service.validateFolderDataSources(context.Background(), newDashboard(
+			map[string]interface{}{"datasource": "Prod"},
+			map[string]interface{}{},
+		))
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject data sources that are not allowed", func(t *testing.T) {
+		fakeStore.folderDataSources = []string{"prod"}
+		err := service.validateFolderDataSources(context.Background(), newDashboard(
+			map[string]interface{}{"datasource": map[string]interface{}{"uid": "staging"}},
+			map[string]interface{}{"datasource": "Prod"},
+		))
+
+		var notAllowedErr models.DashboardDataSourceNotAllowedError
+		require.ErrorAs(t, err, &notAllowedErr)
+		assert.Equal(t, []string{"staging"}, notAllowedErr.DataSources)
+	})
+
+	t.Run("should check the data sources of the library panels", func(t *testing.T) {
+		fakeStore.folderDataSources = []string{"prod"}
+		fakeStore.libraryPanels = map[string]*simplejson.Json{
+			"allowed": simplejson.NewFromAny(map[string]interface{}{"datasource": "Prod"}),
+			"not-allowed": simplejson.NewFromAny(map[string]interface{}{
+				"datasource": map[string]interface{}{"uid": "-- Mixed --"},
+				"targets": []interface{}{
+					map[string]interface{}{"datasource": map[string]interface{}{"uid": "staging"}},
+				},
+			}),
+		}
+		t.Cleanup(func() { fakeStore.libraryPanels = nil })
+
+		err := service.validateFolderDataSources(context.Background(), newDashboard(
+			map[string]interface{}{"libraryPanel": map[string]interface{}{"uid": "allowed"}},
+		))
+		require.NoError(t, err)
+
+		err = service.validateFolderDataSources(context.Background(), newDashboard(
+			map[string]interface{}{
+				"type":      "row",
+				"collapsed": true,
+				"panels": []interface{}{
+					map[string]interface{}{"libraryPanel": map[string]interface{}{"uid": "not-allowed"}},
+				},
+			},
+		))
+		var notAllowedErr models.DashboardDataSourceNotAllowedError
+		require.ErrorAs(t, err, &notAllowedErr)
+		assert.Equal(t, []string{"staging"}, notAllowedErr.DataSources)
+	})
+}
@@ -208,7 +208,13 @@ func (dr *dashboardServiceImpl) DeleteFolder(ctx context.Context, uid string, fo
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	deleteCmd := models.DeleteDashboardCommand{OrgId: dr.orgId, Id: dashFolder.Id, ForceDeleteFolderRules: forceDeleteRules}
+	deleteCmd := models.DeleteDashboardCommand{
+		OrgId:                  dr.orgId,
+		Id:                     dashFolder.Id,
+		ForceDeleteFolderRules: forceDeleteRules,
+		MoveToTrash:            true,
+		DeletedBy:              dr.user.UserId,
+	}
 	if err := bus.Dispatch(ctx, &deleteCmd); err != nil {
 		return nil, toFolderError(err)
 	}
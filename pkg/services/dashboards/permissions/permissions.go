@@ -0,0 +1,159 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// legacyPermissions maps the permission names to the legacy dashboard permissions
+var legacyPermissions = map[string]models.PermissionType{
+	models.PERMISSION_VIEW.String():  models.PERMISSION_VIEW,
+	models.PERMISSION_EDIT.String():  models.PERMISSION_EDIT,
+	models.PERMISSION_ADMIN.String(): models.PERMISSION_ADMIN,
+}
+
+// Service manages the permissions set on individual dashboards and folders through the
+// /api/access-control/dashboards/:uid and /api/access-control/folders/:uid endpoints.
+//
+// The permissions are kept in sync with the legacy dashboard acl: the permissions set through these endpoints are
+// written to the acl, and the acl updated through the legacy permissions API is written to the managed permissions,
+// so that both APIs read the same permissions while access control is being rolled out.
+type Service struct {
+	sqlStore   *sqlstore.SQLStore
+	dashboards *resourcepermissions.Service
+	folders    *resourcepermissions.Service
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, store accesscontrol.ResourcePermissionsStore,
+	router routing.RouteRegister) (*Service, error) {
+	s := &Service{sqlStore: sqlStore}
+
+	dashboards, err := resourcepermissions.New(s.options("dashboards", false, accesscontrol.DashboardPermissionsToActions,
+		"Dashboard permission reader", "Dashboard permission writer", "Dashboards"), router, ac, store)
+	if err != nil {
+		return nil, err
+	}
+	s.dashboards = dashboards
+
+	folders, err := resourcepermissions.New(s.options("folders", true, accesscontrol.FolderPermissionsToActions,
+		"Folder permission reader", "Folder permission writer", "Folders"), router, ac, store)
+	if err != nil {
+		return nil, err
+	}
+	s.folders = folders
+
+	return s, nil
+}
+
+func (s *Service) options(resource string, isFolder bool, permissionsToActions map[string][]string,
+	readerRoleName, writerRoleName, roleGroup string) resourcepermissions.Options {
+	return resourcepermissions.Options{
+		Resource:          resource,
+		ResourceAttribute: "uid",
+		OnlyManaged:       true,
+		ResourceValidator: func(ctx context.Context, orgID int64, resourceID string) error {
+			_, err := s.getDashboard(orgID, resourceID, isFolder)
+			return err
+		},
+		Assignments: resourcepermissions.Assignments{
+			Users:        true,
+			Teams:        true,
+			BuiltInRoles: true,
+		},
+		PermissionsToActions: permissionsToActions,
+		ReaderRoleName:       readerRoleName,
+		WriterRoleName:       writerRoleName,
+		RoleGroup:            roleGroup,
+		OnSetUser: func(ctx context.Context, orgID, userID int64, resourceID, permission string) error {
+			return s.setLegacyPermission(ctx, orgID, resourceID, isFolder, permission, models.DashboardAcl{UserID: userID})
+		},
+		OnSetTeam: func(ctx context.Context, orgID, teamID int64, resourceID, permission string) error {
+			return s.setLegacyPermission(ctx, orgID, resourceID, isFolder, permission, models.DashboardAcl{TeamID: teamID})
+		},
+		OnSetBuiltInRole: func(ctx context.Context, orgID int64, builtInRole, resourceID, permission string) error {
+			role := models.RoleType(builtInRole)
+			return s.setLegacyPermission(ctx, orgID, resourceID, isFolder, permission, models.DashboardAcl{Role: &role})
+		},
+	}
+}
+
+// setLegacyPermission writes a permission set through the access control API to the acl of the dashboard or folder,
+// replacing the permission of the same user, team or role. An empty permission removes it.
+//
+// Like with the legacy API, the default permissions of a dashboard without acl are kept once its acl is set.
+func (s *Service) setLegacyPermission(ctx context.Context, orgID int64, uid string, isFolder bool, permission string, item models.DashboardAcl) error {
+	dash, err := s.getDashboard(orgID, uid, isFolder)
+	if err != nil {
+		return err
+	}
+
+	query := models.GetDashboardAclInfoListQuery{OrgID: orgID, DashboardID: dash.Id}
+	if err := s.sqlStore.GetDashboardAclInfoList(ctx, &query); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	items := make([]*models.DashboardAcl, 0, len(query.Result)+1)
+	for _, current := range query.Result {
+		if current.Inherited || (current.DashboardId != dash.Id && current.DashboardId != -1) {
+			continue
+		}
+		if isSameAssignee(current, item) {
+			continue
+		}
+		items = append(items, &models.DashboardAcl{
+			OrgID:       orgID,
+			DashboardID: dash.Id,
+			UserID:      current.UserId,
+			TeamID:      current.TeamId,
+			Role:        current.Role,
+			Permission:  current.Permission,
+			Created:     current.Created,
+			Updated:     now,
+		})
+	}
+
+	if permission != "" {
+		item.OrgID = orgID
+		item.DashboardID = dash.Id
+		item.Permission = legacyPermissions[permission]
+		item.Created = now
+		item.Updated = now
+		items = append(items, &item)
+	}
+
+	return s.sqlStore.UpdateDashboardACLCtx(ctx, dash.Id, items)
+}
+
+func (s *Service) getDashboard(orgID int64, uid string, isFolder bool) (*models.Dashboard, error) {
+	dash, err := s.sqlStore.GetDashboard(0, orgID, uid, "")
+	if err == nil && dash.IsFolder != isFolder {
+		err = models.ErrDashboardNotFound
+	}
+	if err != nil {
+		if isFolder && errors.Is(err, models.ErrDashboardNotFound) {
+			return nil, models.ErrFolderNotFound
+		}
+		return nil, err
+	}
+	return dash, nil
+}
+
+func isSameAssignee(current *models.DashboardAclInfoDTO, item models.DashboardAcl) bool {
+	switch {
+	case item.UserID > 0:
+		return current.UserId == item.UserID
+	case item.TeamID > 0:
+		return current.TeamId == item.TeamID
+	case item.Role != nil:
+		return current.Role != nil && *current.Role == *item.Role
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package permissions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_SetPermission(t *testing.T) {
+	ctx := context.Background()
+	sql := sqlstore.InitTestDB(t)
+	service, err := ProvideService(sql, accesscontrolmock.New(), database.ProvideService(sql), routing.NewRouteRegister())
+	require.NoError(t, err)
+
+	folder, err := sql.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Folder", "uid": "folder"}),
+	})
+	require.NoError(t, err)
+	dash, err := sql.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Dashboard", "uid": "dash"}),
+	})
+	require.NoError(t, err)
+	user, err := sql.CreateUser(ctx, models.CreateUserCommand{Login: "user", OrgId: 1})
+	require.NoError(t, err)
+
+	getACL := func(t *testing.T, dashboardID int64) []*models.DashboardAclInfoDTO {
+		t.Helper()
+		query := models.GetDashboardAclInfoListQuery{OrgID: 1, DashboardID: dashboardID}
+		require.NoError(t, sql.GetDashboardAclInfoList(ctx, &query))
+		return query.Result
+	}
+
+	t.Run("should validate the dashboard or folder", func(t *testing.T) {
+		_, err := service.dashboards.SetUserPermission(ctx, 1, user.Id, "folder", service.dashboards.MapPermission("View"))
+		assert.ErrorIs(t, err, models.ErrDashboardNotFound)
+		_, err = service.folders.SetUserPermission(ctx, 1, user.Id, "dash", service.folders.MapPermission("View"))
+		assert.ErrorIs(t, err, models.ErrFolderNotFound)
+	})
+
+	t.Run("should write dashboard permissions to the acl and keep the default permissions", func(t *testing.T) {
+		permission, err := service.dashboards.SetUserPermission(ctx, 1, user.Id, "dash", service.dashboards.MapPermission("Edit"))
+		require.NoError(t, err)
+		assert.Equal(t, "dashboards:uid:dash", permission.Scope)
+
+		acl := getACL(t, dash.Id)
+		require.Len(t, acl, 3)
+		assert.Equal(t, models.ROLE_VIEWER, *acl[0].Role)
+		assert.Equal(t, models.ROLE_EDITOR, *acl[1].Role)
+		assert.Equal(t, user.Id, acl[2].UserId)
+		assert.Equal(t, models.PERMISSION_EDIT, acl[2].Permission)
+
+		permissions, err := service.dashboards.GetPermissions(ctx, 1, "dash")
+		require.NoError(t, err)
+		require.Len(t, permissions, 3)
+	})
+
+	t.Run("should remove dashboard permissions from the acl", func(t *testing.T) {
+		_, err := service.dashboards.SetBuiltInRolePermission(ctx, 1, "Viewer", "dash", nil)
+		require.NoError(t, err)
+
+		acl := getACL(t, dash.Id)
+		require.Len(t, acl, 2)
+		assert.Equal(t, models.ROLE_EDITOR, *acl[0].Role)
+		assert.Equal(t, user.Id, acl[1].UserId)
+	})
+
+	t.Run("should write folder permissions to the acl", func(t *testing.T) {
+		team, err := sql.CreateTeam("team", "", 1)
+		require.NoError(t, err)
+
+		permission, err := service.folders.SetTeamPermission(ctx, 1, team.Id, "folder", service.folders.MapPermission("Admin"))
+		require.NoError(t, err)
+		assert.Equal(t, "folders:uid:folder", permission.Scope)
+		assert.Equal(t, "Admin", service.folders.MapActions(*permission))
+
+		acl := getACL(t, folder.Id)
+		require.Len(t, acl, 3)
+		assert.Equal(t, team.Id, acl[2].TeamId)
+		assert.Equal(t, models.PERMISSION_ADMIN, acl[2].Permission)
+	})
+}
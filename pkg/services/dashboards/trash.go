@@ -0,0 +1,98 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// restoredMessage is the message of the versions of the dashboards restored from the trash
+const restoredMessage = "Restored from trash"
+
+// RestoreDashboard restores a deleted dashboard or folder as a new version saved by the user. It is validated like
+// any other save: the user must be allowed to save in the target folder, and the dashboard must only use the data
+// sources allowed in it. A dashboard whose folder was deleted too is restored in the folder, restored first, when
+// the user is allowed to restore it, in the General folder otherwise.
+func (dr *dashboardServiceImpl) RestoreDashboard(ctx context.Context, cmd *models.RestoreDashboardCommand) error {
+	entry, err := dr.dashboardStore.GetDashboardTrashEntry(ctx, cmd.OrgId, cmd.Id)
+	if err != nil {
+		return err
+	}
+	if cmd.DeletedBy != 0 && entry.DeletedBy != cmd.DeletedBy {
+		return models.ErrDashboardTrashEntryNotFound
+	}
+
+	var folderID int64
+	if !entry.IsFolder && entry.FolderUid != "" {
+		folderID, err = dr.restoreTrashEntryFolder(ctx, cmd, entry.FolderUid)
+		if err != nil {
+			return err
+		}
+	}
+
+	dash, err := dr.restoreTrashEntry(ctx, cmd, entry, folderID)
+	if err != nil {
+		return err
+	}
+	cmd.Result = dash
+	return nil
+}
+
+// restoreTrashEntryFolder returns the ID of the folder a dashboard is restored in, restoring the folder when it is in
+// the trash and the user is allowed to restore it, or 0 for the General folder when it is gone
+func (dr *dashboardServiceImpl) restoreTrashEntryFolder(ctx context.Context, cmd *models.RestoreDashboardCommand, uid string) (int64, error) {
+	folder, err := getFolder(ctx, models.GetDashboardQuery{OrgId: cmd.OrgId, Uid: uid})
+	if err == nil {
+		return folder.Id, nil
+	}
+	if !errors.Is(err, models.ErrFolderNotFound) {
+		return 0, err
+	}
+
+	entry, err := dr.dashboardStore.GetFolderTrashEntry(ctx, cmd.OrgId, uid)
+	if errors.Is(err, models.ErrDashboardTrashEntryNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if cmd.DeletedBy != 0 && entry.DeletedBy != cmd.DeletedBy {
+		return 0, nil
+	}
+
+	restored, err := dr.restoreTrashEntry(ctx, cmd, entry, 0)
+	if err != nil {
+		return 0, err
+	}
+	return restored.Id, nil
+}
+
+func (dr *dashboardServiceImpl) restoreTrashEntry(ctx context.Context, cmd *models.RestoreDashboardCommand, entry *models.DashboardTrashEntry,
+	folderID int64) (*models.Dashboard, error) {
+	entry.Data.Del("id")
+	dash := models.NewDashboardFromJson(entry.Data)
+	dash.IsFolder = entry.IsFolder
+	dash.FolderId = folderID
+
+	dto := &SaveDashboardDTO{
+		OrgId:     cmd.OrgId,
+		User:      cmd.User,
+		Message:   restoredMessage,
+		Dashboard: dash,
+	}
+	saveCmd, err := dr.buildSaveDashboardCommand(ctx, dto, !entry.IsFolder, false)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := dr.dashboardStore.RestoreDashboardTrashEntry(ctx, *saveCmd, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := UpdateAlerting(ctx, dr.dashboardStore, cmd.OrgId, restored, cmd.User); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -0,0 +1,132 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+func TestDashboardService_RestoreDashboard(t *testing.T) {
+	origNewDashboardGuardian := guardian.New
+	origValidateAlerts := validateAlerts
+	origUpdateAlerting := UpdateAlerting
+	t.Cleanup(func() {
+		guardian.New = origNewDashboardGuardian
+		validateAlerts = origValidateAlerts
+		UpdateAlerting = origUpdateAlerting
+		bus.ClearBusHandlers()
+	})
+	validateAlerts = func(ctx context.Context, dash *models.Dashboard, user *models.SignedInUser) error {
+		return nil
+	}
+	UpdateAlerting = func(ctx context.Context, store dashboards.Store, orgID int64, dashboard *models.Dashboard, user *models.SignedInUser) error {
+		return nil
+	}
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardQuery) error {
+		return models.ErrDashboardNotFound
+	})
+
+	user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+	newStore := func() *fakeTrashStore {
+		return &fakeTrashStore{entries: map[int64]*models.DashboardTrashEntry{
+			1: {Id: 1, OrgId: 1, Uid: "folder", Title: "Folder", IsFolder: true, DeletedBy: 2,
+				Data: simplejson.NewFromAny(map[string]interface{}{"id": 10, "uid": "folder", "title": "Folder"})},
+			2: {Id: 2, OrgId: 1, Uid: "dash", Title: "Dash", FolderUid: "folder", DeletedBy: 2,
+				Data: simplejson.NewFromAny(map[string]interface{}{"id": 11, "uid": "dash", "title": "Dash"})},
+		}}
+	}
+
+	t.Run("Should restore the deleted folder of the dashboard first", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: true})
+		store := newStore()
+		service := &dashboardServiceImpl{log: log.New("test.logger"), dashboardStore: store}
+
+		cmd := &models.RestoreDashboardCommand{Id: 2, OrgId: 1, User: user, DeletedBy: 2}
+		require.NoError(t, service.RestoreDashboard(context.Background(), cmd))
+		require.Len(t, store.restored, 2)
+		assert.True(t, store.restored[0].IsFolder)
+		assert.Equal(t, "folder", store.restored[0].Dashboard.Get("uid").MustString())
+		assert.False(t, store.restored[1].IsFolder)
+		assert.Equal(t, store.restoredID(0), store.restored[1].FolderId)
+		assert.Equal(t, restoredMessage, store.restored[1].Message)
+		assert.Equal(t, "dash", cmd.Result.Uid)
+	})
+
+	t.Run("Should restore the dashboard in the General folder when the folder was deleted by another user", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: true})
+		store := newStore()
+		store.entries[1].DeletedBy = 3
+		service := &dashboardServiceImpl{log: log.New("test.logger"), dashboardStore: store}
+
+		cmd := &models.RestoreDashboardCommand{Id: 2, OrgId: 1, User: user, DeletedBy: 2}
+		require.NoError(t, service.RestoreDashboard(context.Background(), cmd))
+		require.Len(t, store.restored, 1)
+		assert.Zero(t, store.restored[0].FolderId)
+	})
+
+	t.Run("Should not restore the dashboards the user is not allowed to save", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: false})
+		store := newStore()
+		service := &dashboardServiceImpl{log: log.New("test.logger"), dashboardStore: store}
+
+		cmd := &models.RestoreDashboardCommand{Id: 2, OrgId: 1, User: user}
+		require.ErrorIs(t, service.RestoreDashboard(context.Background(), cmd), models.ErrDashboardUpdateAccessDenied)
+		assert.Empty(t, store.restored)
+	})
+
+	t.Run("Should not restore the dashboards deleted by other users when restricted", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: true})
+		store := newStore()
+		service := &dashboardServiceImpl{log: log.New("test.logger"), dashboardStore: store}
+
+		cmd := &models.RestoreDashboardCommand{Id: 2, OrgId: 1, User: user, DeletedBy: 3}
+		require.ErrorIs(t, service.RestoreDashboard(context.Background(), cmd), models.ErrDashboardTrashEntryNotFound)
+		assert.Empty(t, store.restored)
+	})
+}
+
+type fakeTrashStore struct {
+	fakeDashboardStore
+
+	entries  map[int64]*models.DashboardTrashEntry
+	restored []models.SaveDashboardCommand
+}
+
+func (s *fakeTrashStore) GetDashboardTrashEntry(ctx context.Context, orgID, id int64) (*models.DashboardTrashEntry, error) {
+	entry, ok := s.entries[id]
+	if !ok || entry.OrgId != orgID {
+		return nil, models.ErrDashboardTrashEntryNotFound
+	}
+	return entry, nil
+}
+
+func (s *fakeTrashStore) GetFolderTrashEntry(ctx context.Context, orgID int64, uid string) (*models.DashboardTrashEntry, error) {
+	for _, entry := range s.entries {
+		if entry.OrgId == orgID && entry.IsFolder && entry.Uid == uid {
+			return entry, nil
+		}
+	}
+	return nil, models.ErrDashboardTrashEntryNotFound
+}
+
+func (s *fakeTrashStore) RestoreDashboardTrashEntry(ctx context.Context, cmd models.SaveDashboardCommand, entry *models.DashboardTrashEntry) (*models.Dashboard, error) {
+	delete(s.entries, entry.Id)
+	s.restored = append(s.restored, cmd)
+	dash := cmd.GetDashboardModel()
+	dash.Id = s.restoredID(len(s.restored) - 1)
+	return dash, nil
+}
+
+// restoredID is the ID of the i-th restored dashboard
+func (s *fakeTrashStore) restoredID(i int) int64 {
+	return int64(100 + i)
+}
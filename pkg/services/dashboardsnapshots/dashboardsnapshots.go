@@ -38,7 +38,7 @@ func (s *Service) CreateDashboardSnapshot(ctx context.Context, cmd *models.Creat
 		return err
 	}
 
-	encryptedDashboard, err := s.SecretsService.Encrypt(ctx, marshalledData, secrets.WithoutScope())
+	encryptedDashboard, err := s.SecretsService.Encrypt(ctx, marshalledData, secrets.WithOrgScope(cmd.OrgId))
 	if err != nil {
 		return err
 	}
@@ -79,6 +79,6 @@ func (s *Service) SearchDashboardSnapshots(_ context.Context, query *models.GetD
 	return s.SQLStore.SearchDashboardSnapshots(query)
 }
 
-func (s *Service) DeleteExpiredSnapshots(_ context.Context, cmd *models.DeleteExpiredSnapshotsCommand) error {
-	return s.SQLStore.DeleteExpiredSnapshots(cmd)
+func (s *Service) DeleteExpiredSnapshots(ctx context.Context, cmd *models.DeleteExpiredSnapshotsCommand) error {
+	return s.SQLStore.DeleteExpiredSnapshots(ctx, cmd)
 }
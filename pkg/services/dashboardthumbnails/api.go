@@ -0,0 +1,55 @@
+package dashboardthumbnails
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Get("/api/dashboards/uid/:uid/thumbnail", middleware.ReqSignedIn, routing.Wrap(s.getThumbnailHandler))
+}
+
+// GET /api/dashboards/uid/:uid/thumbnail
+func (s *Service) getThumbnailHandler(c *models.ReqContext) response.Response {
+	query := models.GetDashboardQuery{Uid: web.Params(c.Req)[":uid"], OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard", err)
+	}
+	if query.Result.IsFolder {
+		return response.Error(http.StatusNotFound, "Dashboard not found", nil)
+	}
+
+	g := guardian.New(c.Req.Context(), query.Result.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+	} else if !canView {
+		return response.Error(http.StatusForbidden, "Access denied to this dashboard", nil)
+	}
+
+	data, err := s.GetThumbnail(c.OrgId, query.Result.Uid)
+	if err != nil {
+		if errors.Is(err, ErrThumbnailNotFound) {
+			if !s.IsDisabled() {
+				s.enqueue(dashboardRef{OrgID: c.OrgId, UID: query.Result.Uid})
+			}
+			return response.Error(http.StatusNotFound, "Thumbnail not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get thumbnail", err)
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "image/png")
+	header.Set("Cache-Control", "private, max-age=60")
+	return response.CreateNormalResponse(header, data, http.StatusOK)
+}
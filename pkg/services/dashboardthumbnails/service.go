@@ -0,0 +1,264 @@
+package dashboardthumbnails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// viewportWidth is the width of the browser window the dashboards are rendered in, the screenshots are scaled
+	// down to the width of the thumbnails
+	viewportWidth = 1280
+	renderTimeout = 30 * time.Second
+	queueSize     = 100
+)
+
+var ErrThumbnailNotFound = errors.New("thumbnail not found")
+
+// Service renders small thumbnails of the dashboards with the image renderer when they are saved, renders the
+// missing and outdated ones on schedule, and caches them in the data directory of each Grafana instance.
+type Service struct {
+	cfg           *setting.Cfg
+	sqlStore      *sqlstore.SQLStore
+	renderService rendering.Service
+	root          string
+	queue         chan dashboardRef
+	log           log.Logger
+	now           func() time.Time
+}
+
+// dashboardRef identifies a dashboard whose thumbnail is rendered
+type dashboardRef struct {
+	OrgID int64
+	UID   string
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, renderService rendering.Service, b bus.Bus,
+	routeRegister routing.RouteRegister, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:           cfg,
+		sqlStore:      sqlStore,
+		renderService: renderService,
+		root:          filepath.Join(cfg.DataPath, "thumbnails"),
+		queue:         make(chan dashboardRef, queueSize),
+		log:           log.New("dashboardthumbnails"),
+		now:           time.Now,
+	}
+	s.registerAPIEndpoints(routeRegister)
+	if !s.IsDisabled() {
+		b.AddEventListener(s.onDashboardSaved)
+	}
+
+	// Every Grafana instance of a cluster keeps its own thumbnails
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "dashboard-thumbnails",
+		Description: "Renders the missing and outdated thumbnails of the dashboards.",
+		Cron:        "@every " + cfg.DashboardThumbnails.Interval.String(),
+		Enabled:     cfg.DashboardThumbnails.Enabled,
+		Run:         s.Refresh,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// IsDisabled returns true when the dashboard thumbnails are disabled.
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.DashboardThumbnails.Enabled
+}
+
+// Run renders the thumbnails of the saved dashboards until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		select {
+		case ref := <-s.queue:
+			if err := s.Render(ctx, ref.OrgID, ref.UID); err != nil {
+				s.log.Warn("Failed to render dashboard thumbnail", "orgId", ref.OrgID, "uid", ref.UID, "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) onDashboardSaved(_ context.Context, e *events.DashboardSaved) error {
+	s.enqueue(dashboardRef{OrgID: e.OrgID, UID: e.UID})
+	return nil
+}
+
+// enqueue never blocks, the thumbnail is rendered by the next scheduled refresh when too many are pending
+func (s *Service) enqueue(ref dashboardRef) {
+	select {
+	case s.queue <- ref:
+	default:
+		s.log.Debug("Dashboard thumbnails queue is full, rendering on the next refresh", "orgId", ref.OrgID, "uid", ref.UID)
+	}
+}
+
+// GetThumbnail returns the PNG thumbnail of the dashboard, or ErrThumbnailNotFound when it has not been rendered
+// yet. The permissions of the user on the dashboard must be checked by the caller.
+func (s *Service) GetThumbnail(orgID int64, uid string) ([]byte, error) {
+	// #nosec G304 - the UID is the one of an existing dashboard
+	data, err := ioutil.ReadFile(s.path(orgID, uid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrThumbnailNotFound
+	}
+	return data, err
+}
+
+// Render renders the thumbnail of the dashboard with the image renderer, as an org admin.
+func (s *Service) Render(ctx context.Context, orgID int64, uid string) error {
+	if !s.renderService.IsAvailable() {
+		return rendering.ErrRenderUnavailable
+	}
+
+	thumbnails := s.cfg.DashboardThumbnails
+	scale := float64(thumbnails.Width) / viewportWidth
+	result, err := s.renderService.Render(ctx, rendering.Opts{
+		Width:             viewportWidth,
+		Height:            int(float64(thumbnails.Height) / scale),
+		DeviceScaleFactor: scale,
+		Timeout:           renderTimeout,
+		OrgID:             orgID,
+		OrgRole:           models.ROLE_ADMIN,
+		Path:              fmt.Sprintf("d/%s?orgId=%d&kiosk", uid, orgID),
+		ConcurrentLimit:   s.cfg.RendererConcurrentRequestLimit,
+		Theme:             rendering.Theme(thumbnails.Theme),
+	})
+	if err != nil {
+		return err
+	}
+	return s.save(orgID, uid, result.FilePath)
+}
+
+// save moves the rendered image to the thumbnail of the dashboard, replacing the previous one atomically
+func (s *Service) save(orgID int64, uid string, renderedPath string) error {
+	defer func() {
+		if err := os.Remove(renderedPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Warn("Failed to remove rendered image", "path", renderedPath, "error", err)
+		}
+	}()
+
+	dst := s.path(orgID, uid)
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	// #nosec G304 - the path is the one of the image rendered by Grafana
+	src, err := os.Open(renderedPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), uid+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// Refresh renders the missing thumbnails, the ones older than their dashboard or than the maximum age, and removes
+// the thumbnails of the deleted dashboards. It is run by the scheduler.
+func (s *Service) Refresh(ctx context.Context) error {
+	if !s.renderService.IsAvailable() {
+		return rendering.ErrRenderUnavailable
+	}
+
+	var dashboards []struct {
+		OrgId   int64
+		Uid     string
+		Updated time.Time
+	}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("dashboard").Cols("org_id", "uid", "updated").Where("is_folder = ?", s.sqlStore.Dialect.BooleanStr(false)).Find(&dashboards)
+	})
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(dashboards))
+	rendered, failed := 0, 0
+	var lastErr error
+	for _, d := range dashboards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := s.path(d.OrgId, d.Uid)
+		existing[path] = true
+
+		info, err := os.Stat(path)
+		if err == nil && !info.ModTime().Before(d.Updated) && s.now().Sub(info.ModTime()) < s.cfg.DashboardThumbnails.MaxAge {
+			continue
+		}
+		if err := s.Render(ctx, d.OrgId, d.Uid); err != nil {
+			s.log.Warn("Failed to render dashboard thumbnail", "orgId", d.OrgId, "uid", d.Uid, "error", err)
+			failed++
+			lastErr = err
+			continue
+		}
+		rendered++
+	}
+
+	removed := s.removeDeleted(existing)
+	s.log.Info("Refreshed dashboard thumbnails", "rendered", rendered, "failed", failed, "removed", removed)
+	if failed > 0 {
+		return fmt.Errorf("failed to render %d of %d thumbnails: %w", failed, rendered+failed, lastErr)
+	}
+	return nil
+}
+
+// removeDeleted removes the thumbnails which are not of an existing dashboard and returns how many were removed
+func (s *Service) removeDeleted(existing map[string]bool) int {
+	removed := 0
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".png") || existing[path] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		s.log.Warn("Failed to remove the thumbnails of the deleted dashboards", "error", err)
+	}
+	return removed
+}
+
+func (s *Service) path(orgID int64, uid string) string {
+	return filepath.Join(s.root, strconv.FormatInt(orgID, 10), uid+".png")
+}
@@ -0,0 +1,183 @@
+package dashboardthumbnails
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeRenderer writes the rendered paths to PNG files
+type fakeRenderer struct {
+	rendering.Service
+	dir string
+
+	mu    sync.Mutex
+	paths []string
+	opts  rendering.Opts
+}
+
+func (r *fakeRenderer) IsAvailable() bool {
+	return true
+}
+
+func (r *fakeRenderer) Render(_ context.Context, opts rendering.Opts) (*rendering.RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = append(r.paths, opts.Path)
+	r.opts = opts
+
+	f, err := ioutil.TempFile(r.dir, "render-*.png")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = f.WriteString(opts.Path)
+	return &rendering.RenderResult{FilePath: f.Name()}, err
+}
+
+func (r *fakeRenderer) rendered() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths := r.paths
+	r.paths = nil
+	return paths
+}
+
+func TestService_Refresh(t *testing.T) {
+	ctx := context.Background()
+	s, renderer := setupTestService(t)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	saveDashboard := func(uid string, isFolder bool) *models.Dashboard {
+		t.Helper()
+		dash, err := s.sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			IsFolder:  isFolder,
+			Overwrite: true,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"uid": uid, "title": uid}),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+	saveDashboard("folder", true)
+	saveDashboard("first", false)
+	saveDashboard("second", false)
+
+	t.Run("should render the missing thumbnails", func(t *testing.T) {
+		_, err := s.GetThumbnail(1, "first")
+		require.ErrorIs(t, err, ErrThumbnailNotFound)
+
+		require.NoError(t, s.Refresh(ctx))
+		assert.ElementsMatch(t, []string{"d/first?orgId=1&kiosk", "d/second?orgId=1&kiosk"}, renderer.rendered())
+
+		data, err := s.GetThumbnail(1, "first")
+		require.NoError(t, err)
+		assert.Equal(t, "d/first?orgId=1&kiosk", string(data))
+
+		// The screenshots of the dashboards are scaled down to the size of the thumbnails
+		assert.Equal(t, viewportWidth, renderer.opts.Width)
+		assert.Equal(t, 960, renderer.opts.Height)
+		assert.Equal(t, 0.25, renderer.opts.DeviceScaleFactor)
+		assert.Equal(t, rendering.ThemeDark, renderer.opts.Theme)
+		assert.Equal(t, models.ROLE_ADMIN, renderer.opts.OrgRole)
+
+		// The rendered images are moved
+		files, err := ioutil.ReadDir(renderer.dir)
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("should render the thumbnails of the updated dashboards", func(t *testing.T) {
+		require.NoError(t, s.Refresh(ctx))
+		assert.Empty(t, renderer.rendered())
+
+		// The update time of the dashboards is stored with a precision of one second
+		future := time.Now().Add(2 * time.Second)
+		require.NoError(t, os.Chtimes(s.path(1, "first"), future, future))
+		require.NoError(t, os.Chtimes(s.path(1, "second"), now.Add(-time.Hour), now.Add(-time.Hour)))
+		require.NoError(t, s.Refresh(ctx))
+		assert.Equal(t, []string{"d/second?orgId=1&kiosk"}, renderer.rendered())
+	})
+
+	t.Run("should render the outdated thumbnails", func(t *testing.T) {
+		now = now.Add(25 * time.Hour)
+		t.Cleanup(func() { now = now.Add(-25 * time.Hour) })
+
+		require.NoError(t, s.Refresh(ctx))
+		assert.Len(t, renderer.rendered(), 2)
+	})
+
+	t.Run("should remove the thumbnails of the deleted dashboards", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(s.path(2, "deleted"), nil, 0600))
+		require.NoError(t, s.Refresh(ctx))
+
+		_, err := os.Stat(s.path(2, "deleted"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+		_, err = s.GetThumbnail(1, "second")
+		require.NoError(t, err)
+	})
+}
+
+func TestService_Run(t *testing.T) {
+	s, renderer := setupTestService(t)
+	b := bus.New()
+	b.AddEventListener(s.onDashboardSaved)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	require.NoError(t, b.Publish(ctx, &events.DashboardSaved{OrgID: 1, UID: "saved", Version: 1}))
+	require.Eventually(t, func() bool {
+		_, err := s.GetThumbnail(1, "saved")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"d/saved?orgId=1&kiosk"}, renderer.rendered())
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeRenderer) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.DashboardThumbnails = setting.DashboardThumbnailsSettings{
+		Enabled:  true,
+		Interval: time.Hour,
+		MaxAge:   24 * time.Hour,
+		Width:    320,
+		Height:   240,
+		Theme:    "dark",
+	}
+	store := sqlstore.InitTestDB(t)
+	renderer := &fakeRenderer{dir: filepath.Join(cfg.DataPath, "renders")}
+	require.NoError(t, os.MkdirAll(renderer.dir, 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.DataPath, "thumbnails", "2"), 0750))
+
+	schedulerService := scheduler.ProvideService(nil, kvstore.ProvideService(store), accesscontrolmock.New(), routing.NewRouteRegister())
+	s, err := ProvideService(cfg, store, renderer, bus.New(), routing.NewRouteRegister(), schedulerService)
+	require.NoError(t, err)
+	return s, renderer
+}
@@ -0,0 +1,48 @@
+package dashboardusage
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Get("/api/dashboards/uid/:uid/insights", middleware.ReqSignedIn, routing.Wrap(s.getInsightsHandler))
+}
+
+// GET /api/dashboards/uid/:uid/insights
+func (s *Service) getInsightsHandler(c *models.ReqContext) response.Response {
+	if s.IsDisabled() {
+		return response.Error(http.StatusNotFound, "Dashboard usage is disabled", nil)
+	}
+
+	dash, err := s.sqlStore.GetDashboard(0, c.OrgId, web.Params(c.Req)[":uid"], "")
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard", err)
+	}
+	if dash.IsFolder {
+		return response.Error(http.StatusNotFound, "Dashboard not found", nil)
+	}
+
+	g := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+	} else if !canView {
+		return response.Error(http.StatusForbidden, "Access denied to this dashboard", nil)
+	}
+
+	insights, err := s.GetInsights(c.Req.Context(), c.OrgId, dash.Uid, c.QueryInt("days"))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard insights", err)
+	}
+	return response.JSON(http.StatusOK, insights)
+}
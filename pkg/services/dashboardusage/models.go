@@ -0,0 +1,70 @@
+package dashboardusage
+
+import (
+	"time"
+)
+
+// dayFormat is the format of the days of the daily counts, in UTC
+const dayFormat = "2006-01-02"
+
+// dashboardUsage is the daily counts of a dashboard
+type dashboardUsage struct {
+	Id           int64
+	OrgId        int64
+	DashboardUid string
+	Day          string
+	Views        int64
+	Queries      int64
+	Errors       int64
+	LastViewed   time.Time `xorm:"last_viewed"`
+}
+
+func (dashboardUsage) TableName() string {
+	return "dashboard_usage"
+}
+
+// DailyUsage is the usage of a dashboard on a day
+type DailyUsage struct {
+	// Day is the UTC day, such as 2022-03-01
+	Day     string `json:"day"`
+	Views   int64  `json:"views"`
+	Queries int64  `json:"queries"`
+	Errors  int64  `json:"errors"`
+}
+
+// Insights are the usage of a dashboard over the last days, the days without usage are left out
+type Insights struct {
+	DashboardUID string `json:"dashboardUid"`
+	// From is the first day of the insights
+	From    string `json:"from"`
+	Views   int64  `json:"views"`
+	Queries int64  `json:"queries"`
+	Errors  int64  `json:"errors"`
+	// LastViewed is the last time the dashboard was viewed during the retention of the counts
+	LastViewed *time.Time    `json:"lastViewed"`
+	Days       []*DailyUsage `json:"days"`
+}
+
+// usageKey identifies the counts of a dashboard on a day
+type usageKey struct {
+	OrgID        int64
+	DashboardUID string
+	Day          string
+}
+
+// usageCounts are the counts not added to the database yet
+type usageCounts struct {
+	Views      int64
+	Queries    int64
+	Errors     int64
+	LastViewed time.Time
+}
+
+func (c *usageCounts) add(other *usageCounts) {
+	c.Views += other.Views
+	c.Queries += other.Queries
+	c.Errors += other.Errors
+	if other.LastViewed.After(c.LastViewed) {
+		c.LastViewed = other.LastViewed
+	}
+}
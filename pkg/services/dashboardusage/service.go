@@ -0,0 +1,171 @@
+package dashboardusage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Service counts the views, queries and query errors of the dashboards by day, to find the dashboards which are not
+// used anymore. The counts are kept in memory and added to the database at each flush interval, so that the views
+// and queries do not write to the database. They are returned by the insights API and sort the search results.
+type Service struct {
+	cfg      *setting.Cfg
+	sqlStore *sqlstore.SQLStore
+	log      log.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[usageKey]*usageCounts
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister,
+	searchService *search.SearchService, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sqlStore,
+		log:      log.New("dashboardusage"),
+		now:      time.Now,
+		pending:  make(map[usageKey]*usageCounts),
+	}
+	s.registerAPIEndpoints(routeRegister)
+	if !s.IsDisabled() {
+		for _, option := range sortOptions() {
+			searchService.RegisterSortOption(option)
+		}
+	}
+
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "dashboard-usage-retention",
+		Description: "Deletes the daily counts of the dashboard usage older than the retention.",
+		Cron:        "@every 1h",
+		Enabled:     cfg.DashboardUsage.Enabled,
+		Exclusive:   true,
+		Run:         s.DeleteExpired,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// IsDisabled returns true when the dashboard usage is disabled.
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.DashboardUsage.Enabled
+}
+
+// Run adds the counts to the database at each flush interval until the context is done, and once more then.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.DashboardUsage.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(ctx)
+		case <-ctx.Done():
+			// The counts are flushed after the context of the server is done
+			s.Flush(context.Background())
+			return ctx.Err()
+		}
+	}
+}
+
+// RecordView counts a view of the dashboard.
+func (s *Service) RecordView(orgID int64, dashboardUID string) {
+	if s.IsDisabled() {
+		return
+	}
+	now := s.now()
+	s.record(s.key(orgID, dashboardUID, now), &usageCounts{Views: 1, LastViewed: now})
+}
+
+// RecordQueries counts the queries run for a panel of the dashboard and the ones which failed.
+func (s *Service) RecordQueries(orgID int64, dashboardUID string, queries int, errors int) {
+	if s.IsDisabled() || queries == 0 {
+		return
+	}
+	s.record(s.key(orgID, dashboardUID, s.now()), &usageCounts{Queries: int64(queries), Errors: int64(errors)})
+}
+
+func (s *Service) key(orgID int64, dashboardUID string, t time.Time) usageKey {
+	return usageKey{OrgID: orgID, DashboardUID: dashboardUID, Day: t.UTC().Format(dayFormat)}
+}
+
+func (s *Service) record(key usageKey, counts *usageCounts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pending, ok := s.pending[key]; ok {
+		pending.add(counts)
+		return
+	}
+	s.pending[key] = counts
+}
+
+// Flush adds the pending counts to the database. The counts which could not be added are kept for the next flush.
+func (s *Service) Flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]*usageCounts)
+	s.mu.Unlock()
+
+	failed := 0
+	for key, counts := range pending {
+		if err := s.addCounts(ctx, key, counts); err != nil {
+			s.log.Warn("Failed to save dashboard usage", "orgId", key.OrgID, "uid", key.DashboardUID, "error", err)
+			s.record(key, counts)
+			failed++
+		}
+	}
+	if failed > 0 {
+		s.log.Error("Failed to save dashboard usage, retrying on the next flush", "failed", failed, "total", len(pending))
+	}
+}
+
+// DeleteExpired deletes the daily counts older than the retention. It is run by the scheduler.
+func (s *Service) DeleteExpired(ctx context.Context) error {
+	before := s.now().Add(-s.cfg.DashboardUsage.Retention).UTC().Format(dayFormat)
+	deleted, err := s.sqlStore.DeleteInBatches(ctx, "dashboard_usage", "day < ?", s.cfg.Cleanup.BatchSize, before)
+	if err != nil {
+		return err
+	}
+	s.log.Debug("Deleted expired dashboard usage", "deleted", deleted)
+	return nil
+}
+
+// GetInsights returns the usage of the dashboard over the last days, bounded by the retention. The permissions of
+// the user on the dashboard must be checked by the caller.
+func (s *Service) GetInsights(ctx context.Context, orgID int64, dashboardUID string, days int) (*Insights, error) {
+	maxDays := int(s.cfg.DashboardUsage.Retention / (24 * time.Hour))
+	if days <= 0 || days > maxDays {
+		days = maxDays
+	}
+	from := s.now().UTC().AddDate(0, 0, 1-days).Format(dayFormat)
+
+	rows, err := s.getUsage(ctx, orgID, dashboardUID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	insights := &Insights{DashboardUID: dashboardUID, From: from, Days: make([]*DailyUsage, 0, len(rows))}
+	for _, row := range rows {
+		insights.Views += row.Views
+		insights.Queries += row.Queries
+		insights.Errors += row.Errors
+		insights.Days = append(insights.Days, &DailyUsage{Day: row.Day, Views: row.Views, Queries: row.Queries, Errors: row.Errors})
+	}
+
+	// The last view is looked up over the whole retention
+	lastViewed, err := s.getLastViewed(ctx, orgID, dashboardUID)
+	if err != nil {
+		return nil, err
+	}
+	insights.LastViewed = lastViewed
+	return insights, nil
+}
@@ -0,0 +1,178 @@
+package dashboardusage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_Insights(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	// Two days ago
+	now = now.AddDate(0, 0, -2)
+	s.RecordView(1, "hosts")
+	s.RecordQueries(1, "hosts", 4, 1)
+	s.Flush(ctx)
+
+	// Today, flushed twice
+	now = now.AddDate(0, 0, 2)
+	s.RecordView(1, "hosts")
+	s.RecordView(1, "hosts")
+	s.RecordQueries(1, "hosts", 3, 0)
+	s.RecordView(2, "hosts")
+	s.Flush(ctx)
+	now = now.Add(time.Hour)
+	s.RecordView(1, "hosts")
+	s.RecordQueries(1, "hosts", 2, 2)
+	s.Flush(ctx)
+	assert.Empty(t, s.pending)
+
+	t.Run("should return the daily counts of the dashboard", func(t *testing.T) {
+		insights, err := s.GetInsights(ctx, 1, "hosts", 7)
+		require.NoError(t, err)
+		assert.Equal(t, "2022-03-04", insights.From)
+		assert.Equal(t, int64(4), insights.Views)
+		assert.Equal(t, int64(9), insights.Queries)
+		assert.Equal(t, int64(3), insights.Errors)
+		assert.Equal(t, []*DailyUsage{
+			{Day: "2022-03-08", Views: 1, Queries: 4, Errors: 1},
+			{Day: "2022-03-10", Views: 3, Queries: 5, Errors: 2},
+		}, insights.Days)
+		require.NotNil(t, insights.LastViewed)
+		assert.Equal(t, now, insights.LastViewed.UTC())
+	})
+
+	t.Run("should bound the insights to the days asked for", func(t *testing.T) {
+		insights, err := s.GetInsights(ctx, 1, "hosts", 2)
+		require.NoError(t, err)
+		assert.Equal(t, "2022-03-09", insights.From)
+		assert.Equal(t, int64(3), insights.Views)
+		assert.Len(t, insights.Days, 1)
+		// The last view is the one over the whole retention
+		assert.NotNil(t, insights.LastViewed)
+	})
+
+	t.Run("should return no usage for the dashboards which are not used", func(t *testing.T) {
+		insights, err := s.GetInsights(ctx, 1, "unused", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "2022-02-09", insights.From)
+		assert.Zero(t, insights.Views)
+		assert.Empty(t, insights.Days)
+		assert.Nil(t, insights.LastViewed)
+	})
+
+	t.Run("should delete the counts older than the retention", func(t *testing.T) {
+		now = now.AddDate(0, 0, 29)
+		require.NoError(t, s.DeleteExpired(ctx))
+
+		insights, err := s.GetInsights(ctx, 1, "hosts", 0)
+		require.NoError(t, err)
+		assert.Len(t, insights.Days, 1)
+		assert.Equal(t, "2022-03-10", insights.Days[0].Day)
+	})
+}
+
+func TestService_SortOptions(t *testing.T) {
+	ctx := context.Background()
+	s, searchService := setupTestService(t)
+
+	for _, uid := range []string{"busy", "quiet", "unused"} {
+		_, err := s.sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"uid": uid, "title": uid}),
+		})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 3; i++ {
+		s.RecordView(1, "busy")
+	}
+	s.RecordView(1, "quiet")
+	s.RecordQueries(1, "quiet", 5, 5)
+	s.Flush(ctx)
+
+	sortOptions := map[string]search.SortOption{}
+	for _, option := range searchService.SortOptions() {
+		sortOptions[option.Name] = option
+	}
+	require.Contains(t, sortOptions, "views-desc")
+
+	find := func(t *testing.T, sort string) ([]string, []int64) {
+		t.Helper()
+		query := &search.FindPersistedDashboardsQuery{
+			OrgId:        1,
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			Permission:   models.PERMISSION_VIEW,
+			Limit:        10,
+			Page:         1,
+			Sort:         sortOptions[sort],
+		}
+		require.NoError(t, s.sqlStore.SearchDashboards(ctx, query))
+		uids := make([]string, 0, len(query.Result))
+		metas := make([]int64, 0, len(query.Result))
+		for _, hit := range query.Result {
+			uids = append(uids, hit.UID)
+			metas = append(metas, hit.SortMeta)
+			assert.Equal(t, sortOptions[sort].MetaName, hit.SortMetaName)
+		}
+		return uids, metas
+	}
+
+	uids, metas := find(t, "views-desc")
+	assert.Equal(t, []string{"busy", "quiet", "unused"}, uids)
+	assert.Equal(t, []int64{3, 1, 0}, metas)
+
+	uids, _ = find(t, "views-asc")
+	assert.Equal(t, []string{"unused", "quiet", "busy"}, uids)
+
+	uids, metas = find(t, "errors-desc")
+	assert.Equal(t, []string{"quiet", "busy", "unused"}, uids)
+	assert.Equal(t, []int64{5, 0, 0}, metas)
+}
+
+func TestService_Disabled(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.DashboardUsage = setting.DashboardUsageSettings{FlushInterval: time.Minute, Retention: 30 * 24 * time.Hour}
+	s, searchService := provideTestService(t, cfg)
+
+	s.RecordView(1, "hosts")
+	s.RecordQueries(1, "hosts", 1, 0)
+	assert.Empty(t, s.pending)
+
+	// The sort options are only registered when the usage is enabled
+	assert.Len(t, searchService.SortOptions(), 2)
+}
+
+func setupTestService(t *testing.T) (*Service, *search.SearchService) {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.DashboardUsage = setting.DashboardUsageSettings{Enabled: true, FlushInterval: time.Minute, Retention: 30 * 24 * time.Hour}
+	return provideTestService(t, cfg)
+}
+
+func provideTestService(t *testing.T, cfg *setting.Cfg) (*Service, *search.SearchService) {
+	t.Helper()
+	store := sqlstore.InitTestDB(t)
+	searchService := search.ProvideService(cfg, bus.New(), nil)
+	schedulerService := scheduler.ProvideService(nil, kvstore.ProvideService(store), accesscontrolmock.New(), routing.NewRouteRegister())
+	s, err := ProvideService(cfg, store, routing.NewRouteRegister(), searchService, schedulerService)
+	require.NoError(t, err)
+	return s, searchService
+}
@@ -0,0 +1,68 @@
+package dashboardusage
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+// usageSums are the counts of the dashboards over the retention, joined to the dashboards of the search
+const usageSums = `(SELECT org_id, dashboard_uid, SUM(views) AS views, SUM(queries) AS queries, SUM(errors) AS errors
+		FROM dashboard_usage GROUP BY org_id, dashboard_uid) AS dashboard_usage_sums
+		ON dashboard_usage_sums.org_id = dashboard.org_id AND dashboard_usage_sums.dashboard_uid = dashboard.uid`
+
+// usageSorter sorts the search results by one of the counts of the dashboards, the dashboards without usage count
+// as zero. The count is returned as the sort meta of the hits.
+type usageSorter struct {
+	Column     string
+	Descending bool
+}
+
+func (s usageSorter) LeftJoin() string {
+	return usageSums
+}
+
+func (s usageSorter) OrderBy() string {
+	order := "ASC"
+	if s.Descending {
+		order = "DESC"
+	}
+	return fmt.Sprintf("COALESCE(dashboard_usage_sums.%s, 0) %s, dashboard.title ASC", s.Column, order)
+}
+
+func (s usageSorter) Select() string {
+	return fmt.Sprintf("COALESCE(dashboard_usage_sums.%s, 0) AS sort_meta", s.Column)
+}
+
+// sortOptions are the search sort options on the counts of the dashboards
+func sortOptions() []search.SortOption {
+	options := make([]search.SortOption, 0, 6)
+	for i, column := range []struct {
+		name  string
+		title string
+	}{
+		{"views", "Views"},
+		{"queries", "Queries"},
+		{"errors", "Errors"},
+	} {
+		options = append(options,
+			search.SortOption{
+				Name:        column.name + "-desc",
+				DisplayName: fmt.Sprintf("%s (most first)", column.title),
+				Description: fmt.Sprintf("Sort dashboards by their %s over the retention of the usage, most first", column.name),
+				Index:       i + 1,
+				MetaName:    column.name,
+				Filter:      []search.SortOptionFilter{usageSorter{Column: column.name, Descending: true}},
+			},
+			search.SortOption{
+				Name:        column.name + "-asc",
+				DisplayName: fmt.Sprintf("%s (fewest first)", column.title),
+				Description: fmt.Sprintf("Sort dashboards by their %s over the retention of the usage, fewest first", column.name),
+				Index:       i + 1,
+				MetaName:    column.name,
+				Filter:      []search.SortOptionFilter{usageSorter{Column: column.name}},
+			},
+		)
+	}
+	return options
+}
@@ -0,0 +1,61 @@
+package dashboardusage
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// addCounts adds the counts to the daily counts of the dashboard
+func (s *Service) addCounts(ctx context.Context, key usageKey, counts *usageCounts) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		usage := dashboardUsage{}
+		has, err := sess.Where("org_id = ? AND dashboard_uid = ? AND day = ?", key.OrgID, key.DashboardUID, key.Day).Get(&usage)
+		if err != nil {
+			return err
+		}
+		if !has {
+			_, err := sess.Insert(&dashboardUsage{
+				OrgId:        key.OrgID,
+				DashboardUid: key.DashboardUID,
+				Day:          key.Day,
+				Views:        counts.Views,
+				Queries:      counts.Queries,
+				Errors:       counts.Errors,
+				LastViewed:   counts.LastViewed,
+			})
+			return err
+		}
+
+		// The zero fields of the bean are not updated
+		bean := &dashboardUsage{}
+		if counts.LastViewed.After(usage.LastViewed) {
+			bean.LastViewed = counts.LastViewed
+		}
+		_, err = sess.ID(usage.Id).Incr("views", counts.Views).Incr("queries", counts.Queries).Incr("errors", counts.Errors).Update(bean)
+		return err
+	})
+}
+
+func (s *Service) getUsage(ctx context.Context, orgID int64, dashboardUID string, from string) ([]*dashboardUsage, error) {
+	result := make([]*dashboardUsage, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ? AND dashboard_uid = ? AND day >= ?", orgID, dashboardUID, from).Asc("day").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) getLastViewed(ctx context.Context, orgID int64, dashboardUID string) (*time.Time, error) {
+	usage := dashboardUsage{}
+	var has bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		has, err = sess.Where("org_id = ? AND dashboard_uid = ? AND views > 0", orgID, dashboardUID).Desc("last_viewed").Get(&usage)
+		return err
+	})
+	if err != nil || !has {
+		return nil, err
+	}
+	return &usage.LastViewed, nil
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
-func ProvideCacheService(cacheService *localcache.CacheService, sqlStore *sqlstore.SQLStore) *CacheServiceImpl {
+func ProvideCacheService(sqlStore *sqlstore.SQLStore, cacheRegistry *localcache.Registry) *CacheServiceImpl {
+	cacheService := localcache.NewNamed("datasources", 5*time.Second, 10*time.Minute)
+	cacheRegistry.Register(cacheService)
 	return &CacheServiceImpl{
 		CacheService: cacheService,
 		SQLStore:     sqlStore,
@@ -33,7 +35,7 @@ func (dc *CacheServiceImpl) GetDatasource(
 	user *models.SignedInUser,
 	skipCache bool,
 ) (*models.DataSource, error) {
-	cacheKey := idKey(datasourceID)
+	cacheKey := idKey(user.OrgId, datasourceID)
 
 	if !skipCache {
 		if cached, found := dc.CacheService.Get(cacheKey); found {
@@ -94,14 +96,14 @@ func (dc *CacheServiceImpl) GetDatasourceByUID(
 	ds := query.Result
 
 	dc.CacheService.Set(uidCacheKey, ds, time.Second*5)
-	dc.CacheService.Set(idKey(ds.Id), ds, time.Second*5)
+	dc.CacheService.Set(idKey(ds.OrgId, ds.Id), ds, time.Second*5)
 	return ds, nil
 }
 
-func idKey(id int64) string {
-	return fmt.Sprintf("ds-%d", id)
+func idKey(orgID, id int64) string {
+	return localcache.OrgKey(orgID, fmt.Sprintf("ds-id-%d", id))
 }
 
 func uidKey(orgID int64, uid string) string {
-	return fmt.Sprintf("ds-orgid-uid-%d-%s", orgID, uid)
+	return localcache.OrgKey(orgID, "ds-uid-"+uid)
 }
@@ -0,0 +1,13 @@
+package datasources
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// PermissionsService checks the permissions users are given on individual data sources
+type PermissionsService interface {
+	// CanQuery returns true if the user is allowed to query the data source
+	CanQuery(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) (bool, error)
+}
@@ -0,0 +1,199 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	resource          = "datasources"
+	resourceAttribute = "uid"
+
+	// restrictedCacheTTL bounds how long a data source is considered (un)restricted after a change made on another instance
+	restrictedCacheTTL = 30 * time.Second
+)
+
+var _ datasources.PermissionsService = new(Service)
+
+// Service manages the permissions set on individual data sources, through the
+// /api/access-control/datasources/:uid endpoints, and enforces them.
+//
+// A data source can be queried by everyone allowed to query data sources until query permissions are set on it.
+// From then on only the users, teams and built-in roles given permissions on the data source can query it.
+type Service struct {
+	ac          accesscontrol.AccessControl
+	store       accesscontrol.ResourcePermissionsStore
+	permissions *resourcepermissions.Service
+	restricted  *localcache.CacheService
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, store accesscontrol.ResourcePermissionsStore,
+	router routing.RouteRegister) (*Service, error) {
+	s := &Service{
+		ac:         ac,
+		store:      store,
+		restricted: localcache.New(restrictedCacheTTL, 2*restrictedCacheTTL),
+	}
+
+	permissions, err := resourcepermissions.New(resourcepermissions.Options{
+		Resource:          resource,
+		ResourceAttribute: resourceAttribute,
+		OnlyManaged:       true,
+		ResourceValidator: func(ctx context.Context, orgID int64, resourceID string) error {
+			return sqlStore.GetDataSource(ctx, &models.GetDataSourceQuery{Uid: resourceID, OrgId: orgID})
+		},
+		Assignments: resourcepermissions.Assignments{
+			Users:        true,
+			Teams:        true,
+			BuiltInRoles: true,
+		},
+		PermissionsToActions: map[string][]string{
+			"Query": {accesscontrol.ActionDatasourcesQuery},
+			"Read":  {accesscontrol.ActionDatasourcesQuery, accesscontrol.ActionDatasourcesRead},
+			"Edit":  {accesscontrol.ActionDatasourcesQuery, accesscontrol.ActionDatasourcesRead, accesscontrol.ActionDatasourcesWrite},
+			"Admin": {accesscontrol.ActionDatasourcesQuery, accesscontrol.ActionDatasourcesRead, accesscontrol.ActionDatasourcesWrite, accesscontrol.ActionDatasourcesDelete},
+		},
+		ReaderRoleName: "Data source permission reader",
+		WriterRoleName: "Data source permission writer",
+		RoleGroup:      "Data sources",
+		OnSetUser: func(ctx context.Context, orgID, userID int64, resourceID, permission string) error {
+			s.restricted.Delete(restrictedKey(orgID, resourceID))
+			return nil
+		},
+		OnSetTeam: func(ctx context.Context, orgID, teamID int64, resourceID, permission string) error {
+			s.restricted.Delete(restrictedKey(orgID, resourceID))
+			return nil
+		},
+		OnSetBuiltInRole: func(ctx context.Context, orgID int64, builtInRole, resourceID, permission string) error {
+			s.restricted.Delete(restrictedKey(orgID, resourceID))
+			return nil
+		},
+	}, router, ac, store)
+	if err != nil {
+		return nil, err
+	}
+	s.permissions = permissions
+
+	return s, nil
+}
+
+// CanQuery returns true if the user is allowed to query the data source.
+func (s *Service) CanQuery(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) (bool, error) {
+	if s.ac.IsDisabled() {
+		return true, nil
+	}
+	return s.evaluate(ctx, user, accesscontrol.ActionDatasourcesQuery, ds)
+}
+
+// Middleware returns a handler allowing the request if the user can perform the action on the data source
+// referenced by the :id or :uid URL parameter. The fallback is used when access control is disabled.
+func (s *Service) Middleware(fallback web.Handler, action string) web.Handler {
+	if s.ac.IsDisabled() {
+		return fallback
+	}
+
+	return func(c *models.ReqContext) {
+		// Users that can't perform the action on any data source are denied without looking the data source up
+		if ok, err := s.ac.Evaluate(c.Req.Context(), c.SignedInUser, accesscontrol.EvalPermission(action)); !ok || err != nil {
+			acmiddleware.Deny(c, accesscontrol.EvalPermission(action), err)
+			return
+		}
+
+		ds, err := s.getDataSource(c)
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			// Let the users allowed to perform the action on any data source be told that it does not exist
+			evaluator := accesscontrol.EvalPermission(action, accesscontrol.Scope(resource, "*"))
+			if ok, err := s.ac.Evaluate(c.Req.Context(), c.SignedInUser, evaluator); !ok || err != nil {
+				acmiddleware.Deny(c, evaluator, err)
+			}
+			return
+		}
+		if err != nil {
+			c.JsonApiErr(http.StatusInternalServerError, "Unable to load datasource meta data", err)
+			return
+		}
+
+		if ok, err := s.evaluate(c.Req.Context(), c.SignedInUser, action, ds); !ok || err != nil {
+			acmiddleware.Deny(c, dataSourceEvaluator(action, ds), err)
+		}
+	}
+}
+
+func (s *Service) evaluate(ctx context.Context, user *models.SignedInUser, action string, ds *models.DataSource) (bool, error) {
+	ok, err := s.ac.Evaluate(ctx, user, dataSourceEvaluator(action, ds))
+	if err != nil || ok || action != accesscontrol.ActionDatasourcesQuery {
+		return ok, err
+	}
+
+	restricted, err := s.isRestricted(ctx, ds)
+	if err != nil || restricted {
+		return false, err
+	}
+
+	return s.ac.Evaluate(ctx, user, accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery))
+}
+
+// isRestricted returns true if query permissions have been set on the data source
+func (s *Service) isRestricted(ctx context.Context, ds *models.DataSource) (bool, error) {
+	key := restrictedKey(ds.OrgId, ds.Uid)
+	if cached, ok := s.restricted.Get(key); ok {
+		return cached.(bool), nil
+	}
+
+	permissions, err := s.store.GetResourcesPermissions(ctx, ds.OrgId, accesscontrol.GetResourcesPermissionsQuery{
+		Actions:           []string{accesscontrol.ActionDatasourcesQuery},
+		Resource:          resource,
+		ResourceIDs:       []string{ds.Uid},
+		OnlyManaged:       true,
+		ResourceAttribute: resourceAttribute,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	restricted := len(permissions) > 0
+	s.restricted.Set(key, restricted, restrictedCacheTTL)
+	return restricted, nil
+}
+
+func (s *Service) getDataSource(c *models.ReqContext) (*models.DataSource, error) {
+	query := models.GetDataSourceQuery{Uid: web.Params(c.Req)[":uid"], OrgId: c.OrgId}
+	if query.Uid == "" {
+		query.Id = c.ParamsInt64(":id")
+		if query.Id == 0 {
+			return nil, models.ErrDataSourceNotFound
+		}
+	}
+
+	if err := bus.Dispatch(c.Req.Context(), &query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+// dataSourceEvaluator accepts permissions scoped to the id as well as to the uid of the data source
+func dataSourceEvaluator(action string, ds *models.DataSource) accesscontrol.Evaluator {
+	return accesscontrol.EvalAny(
+		accesscontrol.EvalPermission(action, accesscontrol.Scope(resource, "id", strconv.FormatInt(ds.Id, 10))),
+		accesscontrol.EvalPermission(action, accesscontrol.Scope(resource, resourceAttribute, ds.Uid)),
+	)
+}
+
+func restrictedKey(orgID int64, uid string) string {
+	return fmt.Sprintf("%d-%s", orgID, uid)
+}
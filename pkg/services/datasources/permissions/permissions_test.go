@@ -0,0 +1,97 @@
+package permissions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_CanQuery(t *testing.T) {
+	sql := sqlstore.InitTestDB(t)
+	ctx := context.Background()
+
+	addDS := &models.AddDataSourceCommand{OrgId: 1, Name: "test", Uid: "test-uid", Type: "prometheus", Access: models.DS_ACCESS_PROXY}
+	require.NoError(t, sql.AddDataSource(ctx, addDS))
+	ds := addDS.Result
+
+	user, err := sql.CreateUser(ctx, models.CreateUserCommand{Login: "user", OrgId: 1})
+	require.NoError(t, err)
+
+	anyQuery := []*accesscontrol.Permission{{Action: accesscontrol.ActionDatasourcesQuery}}
+	dsQuery := []*accesscontrol.Permission{{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:uid:test-uid"}}
+	service := setupTestService(t, sql, nil)
+
+	canQuery := func(t *testing.T, permissions []*accesscontrol.Permission) bool {
+		t.Helper()
+		service.ac = accesscontrolmock.New().WithPermissions(permissions)
+		ok, err := service.CanQuery(ctx, &models.SignedInUser{OrgId: 1, UserId: user.Id}, ds)
+		require.NoError(t, err)
+		return ok
+	}
+
+	t.Run("data source without permissions can be queried by users allowed to query data sources", func(t *testing.T) {
+		assert.True(t, canQuery(t, anyQuery))
+		assert.True(t, canQuery(t, dsQuery))
+		assert.False(t, canQuery(t, nil))
+	})
+
+	_, err = service.permissions.SetUserPermission(ctx, 1, user.Id, ds.Uid, []string{accesscontrol.ActionDatasourcesQuery})
+	require.NoError(t, err)
+
+	t.Run("data source with query permissions can only be queried by users given permissions on it", func(t *testing.T) {
+		assert.False(t, canQuery(t, anyQuery))
+		assert.True(t, canQuery(t, dsQuery))
+		assert.True(t, canQuery(t, []*accesscontrol.Permission{{Action: accesscontrol.ActionDatasourcesQuery, Scope: "datasources:*"}}))
+	})
+
+	t.Run("data source is open again once its permissions are removed", func(t *testing.T) {
+		_, err := service.permissions.SetUserPermission(ctx, 1, user.Id, ds.Uid, []string{})
+		require.NoError(t, err)
+		assert.True(t, canQuery(t, anyQuery))
+	})
+
+	t.Run("access control disabled allows every query", func(t *testing.T) {
+		service.ac = accesscontrolmock.New().WithDisabled()
+		ok, err := service.CanQuery(ctx, &models.SignedInUser{OrgId: 1, UserId: user.Id}, ds)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestService_SetPermission(t *testing.T) {
+	sql := sqlstore.InitTestDB(t)
+	ctx := context.Background()
+	service := setupTestService(t, sql, nil)
+
+	t.Run("should fail for unknown data source", func(t *testing.T) {
+		_, err := service.permissions.SetBuiltInRolePermission(ctx, 1, "Viewer", "unknown", []string{accesscontrol.ActionDatasourcesQuery})
+		assert.ErrorIs(t, err, models.ErrDataSourceNotFound)
+	})
+
+	t.Run("should store uid scoped permissions", func(t *testing.T) {
+		addDS := &models.AddDataSourceCommand{OrgId: 1, Name: "test", Uid: "test-uid", Type: "prometheus", Access: models.DS_ACCESS_PROXY}
+		require.NoError(t, sql.AddDataSource(ctx, addDS))
+
+		permission, err := service.permissions.SetBuiltInRolePermission(ctx, 1, "Viewer", "test-uid", service.permissions.MapPermission("Read"))
+		require.NoError(t, err)
+		assert.Equal(t, "datasources:uid:test-uid", permission.Scope)
+		assert.Equal(t, "Read", service.permissions.MapActions(*permission))
+	})
+}
+
+func setupTestService(t *testing.T, sql *sqlstore.SQLStore, permissions []*accesscontrol.Permission) *Service {
+	t.Helper()
+
+	service, err := ProvideService(sql, accesscontrolmock.New().WithPermissions(permissions), database.ProvideService(sql), routing.NewRouteRegister())
+	require.NoError(t, err)
+	return service
+}
@@ -0,0 +1,137 @@
+package entitystore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// entityRow is a version of an entity in the entity table
+type entityRow struct {
+	Id      int64
+	OrgId   int64
+	Kind    string
+	Uid     string
+	Version int64
+	Body    string
+	Created time.Time
+}
+
+func (r *entityRow) TableName() string {
+	return "entity"
+}
+
+func (r *entityRow) entity() *Entity {
+	return &Entity{Key: Key{OrgID: r.OrgId, Kind: Kind(r.Kind), UID: r.Uid}, Version: r.Version, Body: []byte(r.Body)}
+}
+
+// NewDatabaseStore returns the entity store keeping the versions of the entities in the entity table. The versions
+// written while a transaction is in the context, such as the transaction saving a dashboard, are written by that
+// transaction.
+func NewDatabaseStore(sqlStore *sqlstore.SQLStore) Store {
+	return &databaseStore{sqlStore: sqlStore}
+}
+
+type databaseStore struct {
+	sqlStore *sqlstore.SQLStore
+}
+
+// withSession runs the callback with the session of the transaction of the context, or in a new transaction
+func (s *databaseStore) withSession(ctx context.Context, callback func(sess *sqlstore.DBSession) error) error {
+	if sess, ok := ctx.Value(sqlstore.ContextSessionKey{}).(*sqlstore.DBSession); ok {
+		return callback(sess)
+	}
+	return s.sqlStore.WithTransactionalDbSession(ctx, callback)
+}
+
+func (s *databaseStore) Write(ctx context.Context, entity *Entity) error {
+	if err := entity.Key.Validate(); err != nil {
+		return err
+	}
+	if entity.Version < 1 {
+		return fmt.Errorf("invalid version %d of entity %s", entity.Version, entity.Key)
+	}
+
+	return s.withSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM entity WHERE org_id = ? AND kind = ? AND uid = ? AND version = ?",
+			entity.OrgID, string(entity.Kind), entity.UID, entity.Version); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&entityRow{
+			OrgId:   entity.OrgID,
+			Kind:    string(entity.Kind),
+			Uid:     entity.UID,
+			Version: entity.Version,
+			Body:    string(entity.Body),
+			Created: time.Now(),
+		})
+		return err
+	})
+}
+
+func (s *databaseStore) Read(ctx context.Context, key Key, version int64) (*Entity, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	row := &entityRow{}
+	err := s.withSession(ctx, func(sess *sqlstore.DBSession) error {
+		query := sess.Where("org_id = ? AND kind = ? AND uid = ?", key.OrgID, string(key.Kind), key.UID)
+		if version > 0 {
+			query = query.And("version = ?", version)
+		}
+		exists, err := query.Desc("version").Limit(1).Get(row)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrEntityNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row.entity(), nil
+}
+
+func (s *databaseStore) List(ctx context.Context, orgID int64, kind Kind) ([]*Entity, error) {
+	rows := make([]*entityRow, 0)
+	err := s.withSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(`SELECT * FROM entity WHERE org_id = ? AND kind = ? AND version = (
+			SELECT MAX(version) FROM entity latest
+			WHERE latest.org_id = entity.org_id AND latest.kind = entity.kind AND latest.uid = entity.uid
+		) ORDER BY uid`, orgID, string(kind)).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]*Entity, 0, len(rows))
+	for _, row := range rows {
+		entities = append(entities, row.entity())
+	}
+	return entities, nil
+}
+
+func (s *databaseStore) Delete(ctx context.Context, key Key, versions ...int64) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	return s.withSession(ctx, func(sess *sqlstore.DBSession) error {
+		sql := "DELETE FROM entity WHERE org_id = ? AND kind = ? AND uid = ?"
+		args := []interface{}{key.OrgID, string(key.Kind), key.UID}
+		if len(versions) > 0 {
+			sql += " AND version IN (?" + strings.Repeat(",?", len(versions)-1) + ")"
+			for _, v := range versions {
+				args = append(args, v)
+			}
+		}
+		_, err := sess.Exec(append([]interface{}{sql}, args...)...)
+		return err
+	})
+}
@@ -0,0 +1,71 @@
+// Package entitystore stores the documents of Grafana, the JSON models of the dashboards, folders and library
+// elements, in a pluggable backend such as the database, a directory or a key/value store like etcd. The relational
+// metadata of the entities, such as their titles, folders and permissions, stay in the database tables.
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FeatureToggle enables the entity store, the documents saved from then on are kept by the backend set by the
+// [entity_store] settings
+const FeatureToggle = "entityStore"
+
+var (
+	ErrEntityNotFound = errors.New("entity not found")
+	ErrInvalidKey     = errors.New("invalid entity key")
+)
+
+// Kind is the kind of the entities
+type Kind string
+
+const (
+	KindDashboard Kind = "dashboard"
+	KindFolder    Kind = "folder"
+	// KindLibraryElement entities are the models of the library panels and variables
+	KindLibraryElement Kind = "libraryelement"
+)
+
+// Key identifies an entity
+type Key struct {
+	OrgID int64
+	Kind  Kind
+	UID   string
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%d/%s/%s", k.OrgID, k.Kind, k.UID)
+}
+
+// Validate returns ErrInvalidKey when the key cannot be used as a path or a key of the backends
+func (k Key) Validate() error {
+	if k.Kind == "" || k.UID == "" || strings.ContainsAny(string(k.Kind)+k.UID, "/\\") || k.UID == "." || k.UID == ".." {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, k.String())
+	}
+	return nil
+}
+
+// Entity is a version of the document of an entity
+type Entity struct {
+	Key
+	Version int64
+	Body    []byte
+}
+
+// Store keeps the versions of the entities. The entities are versioned by their owners, such as the dashboards
+// service, writing a version which exists already replaces it.
+type Store interface {
+	// Write saves a version of an entity
+	Write(ctx context.Context, entity *Entity) error
+	// Read returns a version of an entity, or its latest version when version is 0. It returns ErrEntityNotFound
+	// when the version does not exist.
+	Read(ctx context.Context, key Key, version int64) (*Entity, error)
+	// List returns the latest versions of the entities of a kind of an organization, ordered by uid
+	List(ctx context.Context, orgID int64, kind Kind) ([]*Entity, error)
+	// Delete deletes versions of an entity, or all its versions when none is given. The missing versions are
+	// ignored.
+	Delete(ctx context.Context, key Key, versions ...int64) error
+}
@@ -0,0 +1,87 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewFileKV returns a key/value backend writing each key to a file of a directory, such as a volume shared by the
+// Grafana instances
+func NewFileKV(dir string) KV {
+	return &fileKV{dir: dir}
+}
+
+type fileKV struct {
+	dir string
+}
+
+func (s *fileKV) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *fileKV) Get(_ context.Context, key string) ([]byte, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the keys are made by the entity store of validated segments
+	data, err := ioutil.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrKeyNotFound
+	}
+	return data, err
+}
+
+func (s *fileKV) Put(_ context.Context, key string, value []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never get a partially written value
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, value, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *fileKV) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fileKV) Keys(_ context.Context, prefix string) ([]string, error) {
+	// walk the deepest directory of the prefix, then filter the keys by the prefix
+	root := s.dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = s.path(prefix[:i])
+	}
+
+	keys := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
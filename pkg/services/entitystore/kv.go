@@ -0,0 +1,158 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrKeyNotFound is returned by the KV backends for the missing keys
+var ErrKeyNotFound = errors.New("key not found")
+
+// KV is a key/value backend of the entity store, such as etcd or an object storage. The keys are made of slash
+// separated segments.
+type KV interface {
+	// Get returns the value of a key, or ErrKeyNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	// Delete deletes keys, the missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+	// Keys returns the keys starting with a prefix, in any order
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// NewKVStore returns an entity store keeping each version of the entities under its own key of a key/value backend:
+// <org id>/<kind>/<uid>/<version>.
+func NewKVStore(kv KV) Store {
+	return &kvStore{kv: kv}
+}
+
+type kvStore struct {
+	kv KV
+}
+
+// versionKey zero pads the versions so that the keys sort as their versions
+func versionKey(key Key, version int64) string {
+	return fmt.Sprintf("%s/%020d", key, version)
+}
+
+func (s *kvStore) Write(ctx context.Context, entity *Entity) error {
+	if err := entity.Key.Validate(); err != nil {
+		return err
+	}
+	if entity.Version < 1 {
+		return fmt.Errorf("invalid version %d of entity %s", entity.Version, entity.Key)
+	}
+	return s.kv.Put(ctx, versionKey(entity.Key, entity.Version), entity.Body)
+}
+
+func (s *kvStore) Read(ctx context.Context, key Key, version int64) (*Entity, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		versions, err := s.versions(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, ErrEntityNotFound
+		}
+		version = versions[len(versions)-1]
+	}
+
+	body, err := s.kv.Get(ctx, versionKey(key, version))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrEntityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Entity{Key: key, Version: version, Body: body}, nil
+}
+
+func (s *kvStore) List(ctx context.Context, orgID int64, kind Kind) ([]*Entity, error) {
+	prefix := fmt.Sprintf("%d/%s/", orgID, kind)
+	keys, err := s.kv.Keys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]int64{}
+	for _, k := range keys {
+		uid, version, ok := parseVersionKey(strings.TrimPrefix(k, prefix))
+		if ok && version > latest[uid] {
+			latest[uid] = version
+		}
+	}
+	uids := make([]string, 0, len(latest))
+	for uid := range latest {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	entities := make([]*Entity, 0, len(uids))
+	for _, uid := range uids {
+		entity, err := s.Read(ctx, Key{OrgID: orgID, Kind: kind, UID: uid}, latest[uid])
+		// the entity may have been deleted since the keys were listed
+		if errors.Is(err, ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+func (s *kvStore) Delete(ctx context.Context, key Key, versions ...int64) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		var err error
+		if versions, err = s.versions(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(versions))
+	for _, v := range versions {
+		keys = append(keys, versionKey(key, v))
+	}
+	return s.kv.Delete(ctx, keys...)
+}
+
+// versions returns the sorted versions of an entity
+func (s *kvStore) versions(ctx context.Context, key Key) ([]int64, error) {
+	keys, err := s.kv.Keys(ctx, key.String()+"/")
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(keys))
+	for _, k := range keys {
+		uid, version, ok := parseVersionKey(strings.TrimPrefix(k, fmt.Sprintf("%d/%s/", key.OrgID, key.Kind)))
+		if ok && uid == key.UID {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// parseVersionKey parses the <uid>/<version> end of the keys of the versions
+func parseVersionKey(k string) (string, int64, bool) {
+	i := strings.LastIndex(k, "/")
+	if i < 1 {
+		return "", 0, false
+	}
+	version, err := strconv.ParseInt(k[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return k[:i], version, true
+}
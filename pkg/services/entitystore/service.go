@@ -0,0 +1,118 @@
+package entitystore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// BackendFactory creates the store of a backend from the settings
+type BackendFactory func(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore) (Store, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{
+		"database": func(_ *setting.Cfg, sqlStore *sqlstore.SQLStore) (Store, error) {
+			return NewDatabaseStore(sqlStore), nil
+		},
+		"file": func(cfg *setting.Cfg, _ *sqlstore.SQLStore) (Store, error) {
+			return NewKVStore(NewFileKV(cfg.EntityStore.Path)), nil
+		},
+	}
+)
+
+// RegisterBackend makes a backend available to the backend setting of the [entity_store] section, it is called by
+// the extensions adding backends such as etcd before the services are created
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = factory
+}
+
+// Service keeps the JSON models of the dashboards, folders and library elements in the store of the configured
+// backend when the entity store is enabled
+type Service struct {
+	store Store
+	log   log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, libraryElements *libraryelements.LibraryElementService) (*Service, error) {
+	s := &Service{log: log.New("entitystore")}
+	if !cfg.FeatureToggles[FeatureToggle] {
+		return s, nil
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[cfg.EntityStore.Backend]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown entity store backend %q", cfg.EntityStore.Backend)
+	}
+	store, err := factory(cfg, sqlStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create entity store backend %q: %w", cfg.EntityStore.Backend, err)
+	}
+	s.store = store
+
+	sqlStore.RegisterDashboardEntities(dashboardEntities{store: store})
+	libraryElements.RegisterModelStore(modelStore{store: store})
+	s.log.Info("Entity store enabled", "backend", cfg.EntityStore.Backend)
+	return s, nil
+}
+
+// IsEnabled returns whether the entity store is enabled
+func (s *Service) IsEnabled() bool {
+	return s.store != nil
+}
+
+// Store returns the store of the backend, or nil when the entity store is disabled
+func (s *Service) Store() Store {
+	return s.store
+}
+
+// dashboardEntities keeps the JSON models of the dashboards and folders in the store
+type dashboardEntities struct {
+	store Store
+}
+
+func (d dashboardEntities) WriteDashboard(ctx context.Context, orgID int64, kind string, uid string, version int64, data []byte) error {
+	return d.store.Write(ctx, &Entity{Key: Key{OrgID: orgID, Kind: Kind(kind), UID: uid}, Version: version, Body: data})
+}
+
+func (d dashboardEntities) ReadDashboard(ctx context.Context, orgID int64, kind string, uid string, version int64) ([]byte, error) {
+	entity, err := d.store.Read(ctx, Key{OrgID: orgID, Kind: Kind(kind), UID: uid}, version)
+	if err != nil {
+		return nil, err
+	}
+	return entity.Body, nil
+}
+
+func (d dashboardEntities) DeleteDashboard(ctx context.Context, orgID int64, kind string, uid string, versions ...int64) error {
+	return d.store.Delete(ctx, Key{OrgID: orgID, Kind: Kind(kind), UID: uid}, versions...)
+}
+
+// modelStore keeps the models of the library elements in the store
+type modelStore struct {
+	store Store
+}
+
+func (m modelStore) WriteModel(ctx context.Context, orgID int64, uid string, version int64, model []byte) error {
+	return m.store.Write(ctx, &Entity{Key: Key{OrgID: orgID, Kind: KindLibraryElement, UID: uid}, Version: version, Body: model})
+}
+
+func (m modelStore) ReadModel(ctx context.Context, orgID int64, uid string, version int64) ([]byte, error) {
+	entity, err := m.store.Read(ctx, Key{OrgID: orgID, Kind: KindLibraryElement, UID: uid}, version)
+	if err != nil {
+		return nil, err
+	}
+	return entity.Body, nil
+}
+
+func (m modelStore) DeleteModel(ctx context.Context, orgID int64, uid string, versions ...int64) error {
+	return m.store.Delete(ctx, Key{OrgID: orgID, Kind: KindLibraryElement, UID: uid}, versions...)
+}
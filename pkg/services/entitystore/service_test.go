@@ -0,0 +1,59 @@
+package entitystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestProvideService(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	newService := func(backend string, enabled bool) (*Service, error) {
+		cfg := setting.NewCfg()
+		cfg.FeatureToggles = map[string]bool{FeatureToggle: enabled}
+		cfg.EntityStore.Backend = backend
+		return ProvideService(cfg, sqlStore, &libraryelements.LibraryElementService{Cfg: cfg, SQLStore: sqlStore})
+	}
+
+	t.Run("should be disabled without the feature toggle", func(t *testing.T) {
+		s, err := newService("unknown", false)
+		require.NoError(t, err)
+		assert.False(t, s.IsEnabled())
+		assert.Nil(t, s.Store())
+	})
+
+	t.Run("should refuse an unknown backend", func(t *testing.T) {
+		_, err := newService("unknown", true)
+		require.Error(t, err)
+	})
+
+	t.Run("should keep the dashboards in the entity store", func(t *testing.T) {
+		s, err := newService("database", true)
+		require.NoError(t, err)
+		require.True(t, s.IsEnabled())
+
+		dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Entity", "description": "in the entity store"}),
+		})
+		require.NoError(t, err)
+
+		entity, err := s.Store().Read(context.Background(), Key{OrgID: 1, Kind: KindDashboard, UID: dash.Uid}, 1)
+		require.NoError(t, err)
+		data, err := simplejson.NewJson(entity.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "in the entity store", data.Get("description").MustString())
+
+		loaded, err := sqlStore.GetDashboard(dash.Id, 1, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "in the entity store", loaded.Data.Get("description").MustString())
+	})
+}
@@ -0,0 +1,96 @@
+package entitystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"file": func(t *testing.T) Store {
+			return NewKVStore(NewFileKV(t.TempDir()))
+		},
+		"database": func(t *testing.T) Store {
+			return NewDatabaseStore(sqlstore.InitTestDB(t))
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, newStore(t))
+		})
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	key := Key{OrgID: 1, Kind: KindDashboard, UID: "abc"}
+	write := func(t *testing.T, key Key, version int64, body string) {
+		t.Helper()
+		require.NoError(t, store.Write(ctx, &Entity{Key: key, Version: version, Body: []byte(body)}))
+	}
+
+	write(t, key, 1, `{"title":"v1"}`)
+	write(t, key, 2, `{"title":"v2"}`)
+	write(t, Key{OrgID: 1, Kind: KindDashboard, UID: "other"}, 1, `{"title":"other"}`)
+	write(t, Key{OrgID: 1, Kind: KindFolder, UID: "folder"}, 1, `{"title":"folder"}`)
+	write(t, Key{OrgID: 2, Kind: KindDashboard, UID: "abc"}, 1, `{"title":"org 2"}`)
+
+	t.Run("should read the versions of an entity", func(t *testing.T) {
+		latest, err := store.Read(ctx, key, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), latest.Version)
+		assert.Equal(t, `{"title":"v2"}`, string(latest.Body))
+
+		first, err := store.Read(ctx, key, 1)
+		require.NoError(t, err)
+		assert.Equal(t, `{"title":"v1"}`, string(first.Body))
+		assert.Equal(t, key, first.Key)
+
+		_, err = store.Read(ctx, key, 3)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+		_, err = store.Read(ctx, Key{OrgID: 1, Kind: KindDashboard, UID: "missing"}, 0)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+	})
+
+	t.Run("should replace a version written again", func(t *testing.T) {
+		write(t, key, 2, `{"title":"v2 again"}`)
+		latest, err := store.Read(ctx, key, 2)
+		require.NoError(t, err)
+		assert.Equal(t, `{"title":"v2 again"}`, string(latest.Body))
+	})
+
+	t.Run("should list the latest versions of the entities of a kind", func(t *testing.T) {
+		entities, err := store.List(ctx, 1, KindDashboard)
+		require.NoError(t, err)
+		require.Len(t, entities, 2)
+		assert.Equal(t, "abc", entities[0].UID)
+		assert.Equal(t, int64(2), entities[0].Version)
+		assert.Equal(t, "other", entities[1].UID)
+	})
+
+	t.Run("should delete versions of an entity", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, key, 1, 5))
+		_, err := store.Read(ctx, key, 1)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+		_, err = store.Read(ctx, key, 2)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Delete(ctx, key))
+		_, err = store.Read(ctx, key, 0)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+		_, err = store.Read(ctx, Key{OrgID: 2, Kind: KindDashboard, UID: "abc"}, 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("should refuse the invalid keys", func(t *testing.T) {
+		err := store.Write(ctx, &Entity{Key: Key{OrgID: 1, Kind: KindDashboard, UID: "../abc"}, Version: 1})
+		assert.ErrorIs(t, err, ErrInvalidKey)
+		_, err = store.Read(ctx, Key{OrgID: 1, Kind: KindDashboard}, 0)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
@@ -0,0 +1,93 @@
+package environments
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite)
+
+	s.routeRegister.Group("/api/environments", func(environmentRoute routing.RouteRegister) {
+		environmentRoute.Get("/", middleware.ReqSignedIn, routing.Wrap(s.getEnvironmentsHandler))
+		environmentRoute.Post("/", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.createEnvironmentHandler))
+		environmentRoute.Delete("/:name", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.deleteEnvironmentHandler))
+		environmentRoute.Get("/datasources/:uid", middleware.ReqSignedIn, routing.Wrap(s.getDataSourceEnvironmentsHandler))
+		environmentRoute.Put("/datasources/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.setDataSourceEnvironmentsHandler))
+	})
+}
+
+// GET /api/environments
+func (s *Service) getEnvironmentsHandler(c *models.ReqContext) response.Response {
+	environments, err := s.GetEnvironments(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get environments")
+	}
+	return response.JSON(http.StatusOK, environments)
+}
+
+// POST /api/environments
+func (s *Service) createEnvironmentHandler(c *models.ReqContext) response.Response {
+	cmd := CreateEnvironmentCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	environment, err := s.CreateEnvironment(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create environment")
+	}
+	return response.JSON(http.StatusOK, environment)
+}
+
+// DELETE /api/environments/:name
+func (s *Service) deleteEnvironmentHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteEnvironment(c.Req.Context(), c.OrgId, web.Params(c.Req)[":name"]); err != nil {
+		return errorResponse(err, "Failed to delete environment")
+	}
+	return response.Success("Environment deleted")
+}
+
+// GET /api/environments/datasources/:uid
+func (s *Service) getDataSourceEnvironmentsHandler(c *models.ReqContext) response.Response {
+	environments, err := s.GetDataSourceEnvironments(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get data source environments")
+	}
+	return response.JSON(http.StatusOK, environments)
+}
+
+// PUT /api/environments/datasources/:uid
+func (s *Service) setDataSourceEnvironmentsHandler(c *models.ReqContext) response.Response {
+	cmd := SetDataSourceEnvironmentsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	environments, err := s.SetDataSourceEnvironments(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to set data source environments")
+	}
+	return response.JSON(http.StatusOK, environments)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrEnvironmentNotFound), errors.Is(err, models.ErrDataSourceNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidTarget):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrEnvironmentExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,62 @@
+package environments
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrEnvironmentNotFound = errors.New("environment not found")
+	ErrEnvironmentExists   = errors.New("an environment with the same name already exists")
+	ErrInvalidName         = errors.New("environment name must be 1 to 40 letters, digits, dashes or underscores")
+	ErrInvalidTarget       = errors.New("the data source of an environment must be of the same type")
+)
+
+const (
+	ActionWrite = "environments:write"
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,40}$`)
+
+// Environment is a deployment environment of an organization, such as dev, staging or prod. The dashboards are
+// viewed in an environment, their queries are then sent to the data sources of this environment.
+type Environment struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (e *Environment) TableName() string {
+	return "environment"
+}
+
+// DataSourceEnvironment is the data source which replaces a data source in an environment. The data sources without
+// one for an environment are used as they are.
+type DataSourceEnvironment struct {
+	Id            int64     `json:"id"`
+	OrgId         int64     `json:"orgId"`
+	DatasourceUid string    `json:"datasourceUid"`
+	Environment   string    `json:"environment"`
+	TargetUid     string    `json:"targetUid"`
+	UpdatedBy     int64     `json:"updatedBy"`
+	Updated       time.Time `json:"updated"`
+}
+
+func (d *DataSourceEnvironment) TableName() string {
+	return "data_source_environment"
+}
+
+type CreateEnvironmentCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SetDataSourceEnvironmentsCommand replaces the data sources of a data source by environment, the keys are the names
+// of the environments and the values the UIDs of the data sources
+type SetDataSourceEnvironmentsCommand struct {
+	Environments map[string]string `json:"environments"`
+}
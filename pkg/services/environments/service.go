@@ -0,0 +1,201 @@
+package environments
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// environmentCacheTTL is how long the data sources by environment are cached. Changes are applied to the queries
+// of the other Grafana instances of a cluster after this delay.
+const environmentCacheTTL = time.Minute
+
+// Service manages the environments of the organizations, such as dev, staging and prod, and the data sources which
+// replace a data source in each environment. A single dashboard is then viewed in every environment, instead of a
+// copy of the dashboard by environment.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	cache         *localcache.CacheService
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	cacheRegistry *localcache.Registry) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		cache:         localcache.NewNamed("environments", environmentCacheTTL, 2*environmentCacheTTL),
+		log:           log.New("environments"),
+		now:           time.Now,
+	}
+	cacheRegistry.Register(s.cache)
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s
+}
+
+// GetEnvironments returns the environments of the organization sorted by name
+func (s *Service) GetEnvironments(ctx context.Context, orgID int64) ([]*Environment, error) {
+	return s.getEnvironments(ctx, orgID)
+}
+
+// CreateEnvironment adds an environment to the organization of the user.
+func (s *Service) CreateEnvironment(ctx context.Context, user *models.SignedInUser, cmd CreateEnvironmentCommand) (*Environment, error) {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if !namePattern.MatchString(cmd.Name) {
+		return nil, ErrInvalidName
+	}
+
+	environment := &Environment{
+		OrgId:       user.OrgId,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Created:     s.now(),
+		Updated:     s.now(),
+	}
+	if err := s.insertEnvironment(ctx, environment); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Created environment", "orgId", user.OrgId, "name", environment.Name, "userId", user.UserId)
+	return environment, nil
+}
+
+// DeleteEnvironment removes the environment and the data sources of the environment, its dashboards are then
+// queried with their own data sources.
+func (s *Service) DeleteEnvironment(ctx context.Context, orgID int64, name string) error {
+	if err := s.deleteEnvironment(ctx, orgID, name); err != nil {
+		return err
+	}
+	// The data sources by environment are cached by data source
+	s.cache.Flush()
+	return nil
+}
+
+// GetDataSourceEnvironments returns the data sources replacing the data source in each environment
+func (s *Service) GetDataSourceEnvironments(ctx context.Context, orgID int64, uid string) ([]*DataSourceEnvironment, error) {
+	query := &models.GetDataSourceQuery{OrgId: orgID, Uid: uid}
+	if err := s.sqlStore.GetDataSource(ctx, query); err != nil {
+		return nil, err
+	}
+	return s.getDataSourceEnvironments(ctx, orgID, uid)
+}
+
+// SetDataSourceEnvironments replaces the data sources replacing the data source in each environment. The data
+// sources of an environment must be of the type of the data source they replace.
+func (s *Service) SetDataSourceEnvironments(ctx context.Context, user *models.SignedInUser, uid string, cmd SetDataSourceEnvironmentsCommand) ([]*DataSourceEnvironment, error) {
+	query := &models.GetDataSourceQuery{OrgId: user.OrgId, Uid: uid}
+	if err := s.sqlStore.GetDataSource(ctx, query); err != nil {
+		return nil, err
+	}
+	source := query.Result
+
+	names := make([]string, 0, len(cmd.Environments))
+	for name := range cmd.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	environments := make([]*DataSourceEnvironment, 0, len(names))
+	for _, name := range names {
+		targetUID := cmd.Environments[name]
+		if targetUID == "" || targetUID == uid {
+			continue
+		}
+		if _, err := s.getEnvironment(ctx, user.OrgId, name); err != nil {
+			return nil, fmt.Errorf("%q: %w", name, err)
+		}
+		target := &models.GetDataSourceQuery{OrgId: user.OrgId, Uid: targetUID}
+		if err := s.sqlStore.GetDataSource(ctx, target); err != nil {
+			return nil, fmt.Errorf("%q: %w", name, err)
+		}
+		if target.Result.Type != source.Type {
+			return nil, fmt.Errorf("%q: %w", name, ErrInvalidTarget)
+		}
+
+		environments = append(environments, &DataSourceEnvironment{
+			OrgId:         user.OrgId,
+			DatasourceUid: uid,
+			Environment:   name,
+			TargetUid:     targetUID,
+			UpdatedBy:     user.UserId,
+			Updated:       s.now(),
+		})
+	}
+
+	if err := s.replaceDataSourceEnvironments(ctx, user.OrgId, uid, environments); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(dataSourceKey(user.OrgId, uid))
+
+	s.log.Info("Set data source environments", "orgId", user.OrgId, "datasource", uid, "environments", len(environments), "userId", user.UserId)
+	return environments, nil
+}
+
+// Resolve returns the UID of the data source replacing the data source of the organization in the environment, or
+// the UID of the data source itself when there is none. The data sources by environment are cached.
+func (s *Service) Resolve(ctx context.Context, orgID int64, environment string, uid string) (string, error) {
+	if environment == "" {
+		return uid, nil
+	}
+
+	key := dataSourceKey(orgID, uid)
+	var targets map[string]string
+	if cached, ok := s.cache.Get(key); ok {
+		targets = cached.(map[string]string)
+	} else {
+		environments, err := s.getDataSourceEnvironments(ctx, orgID, uid)
+		if err != nil {
+			return "", err
+		}
+		targets = make(map[string]string, len(environments))
+		for _, e := range environments {
+			targets[e.Environment] = e.TargetUid
+		}
+		s.cache.Set(key, targets, 0)
+	}
+
+	if target, ok := targets[environment]; ok {
+		return target, nil
+	}
+	return uid, nil
+}
+
+func dataSourceKey(orgID int64, uid string) string {
+	return localcache.OrgKey(orgID, uid)
+}
+
+func (s *Service) registerRoles() error {
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:environments:writer",
+			DisplayName: "Environment writer",
+			Description: "Create and delete the environments of the organization and set the data sources of each environment.",
+			Group:       "Data sources",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionWrite},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(writer)
+}
@@ -0,0 +1,109 @@
+package environments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Environments(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, Login: "admin"}
+	for _, uid := range []string{"prom", "prom-staging", "prom-prod"} {
+		err := store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: uid, Type: "prometheus", Uid: uid, Access: models.DS_ACCESS_PROXY})
+		require.NoError(t, err)
+	}
+	err := store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: "loki", Type: "loki", Uid: "loki", Access: models.DS_ACCESS_PROXY})
+	require.NoError(t, err)
+
+	t.Run("should create the environments of the organization", func(t *testing.T) {
+		for _, name := range []string{"staging", "prod"} {
+			_, err := s.CreateEnvironment(ctx, admin, CreateEnvironmentCommand{Name: name})
+			require.NoError(t, err)
+		}
+
+		environments, err := s.GetEnvironments(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, environments, 2)
+		assert.Equal(t, "prod", environments[0].Name)
+		assert.Equal(t, "staging", environments[1].Name)
+
+		environments, err = s.GetEnvironments(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, environments)
+	})
+
+	t.Run("should refuse invalid and duplicated environments", func(t *testing.T) {
+		_, err := s.CreateEnvironment(ctx, admin, CreateEnvironmentCommand{Name: "prod"})
+		require.ErrorIs(t, err, ErrEnvironmentExists)
+		_, err = s.CreateEnvironment(ctx, admin, CreateEnvironmentCommand{Name: "pre prod"})
+		require.ErrorIs(t, err, ErrInvalidName)
+		_, err = s.CreateEnvironment(ctx, admin, CreateEnvironmentCommand{Name: ""})
+		require.ErrorIs(t, err, ErrInvalidName)
+	})
+
+	t.Run("should resolve the data sources of the environments", func(t *testing.T) {
+		uid, err := s.Resolve(ctx, 1, "prod", "prom")
+		require.NoError(t, err)
+		assert.Equal(t, "prom", uid)
+
+		environments, err := s.SetDataSourceEnvironments(ctx, admin, "prom", SetDataSourceEnvironmentsCommand{
+			Environments: map[string]string{"staging": "prom-staging", "prod": "prom-prod"},
+		})
+		require.NoError(t, err)
+		require.Len(t, environments, 2)
+
+		for environment, expected := range map[string]string{"": "prom", "staging": "prom-staging", "prod": "prom-prod", "dev": "prom"} {
+			uid, err := s.Resolve(ctx, 1, environment, "prom")
+			require.NoError(t, err)
+			assert.Equal(t, expected, uid, environment)
+		}
+		uid, err = s.Resolve(ctx, 1, "prod", "loki")
+		require.NoError(t, err)
+		assert.Equal(t, "loki", uid)
+	})
+
+	t.Run("should refuse the data sources of unknown environments and of another type", func(t *testing.T) {
+		_, err := s.SetDataSourceEnvironments(ctx, admin, "prom", SetDataSourceEnvironmentsCommand{Environments: map[string]string{"dev": "prom-staging"}})
+		require.ErrorIs(t, err, ErrEnvironmentNotFound)
+		_, err = s.SetDataSourceEnvironments(ctx, admin, "prom", SetDataSourceEnvironmentsCommand{Environments: map[string]string{"prod": "loki"}})
+		require.ErrorIs(t, err, ErrInvalidTarget)
+		_, err = s.SetDataSourceEnvironments(ctx, admin, "prom", SetDataSourceEnvironmentsCommand{Environments: map[string]string{"prod": "missing"}})
+		require.ErrorIs(t, err, models.ErrDataSourceNotFound)
+		_, err = s.SetDataSourceEnvironments(ctx, admin, "missing", SetDataSourceEnvironmentsCommand{})
+		require.ErrorIs(t, err, models.ErrDataSourceNotFound)
+
+		// The data sources of the environments are left unchanged
+		environments, err := s.GetDataSourceEnvironments(ctx, 1, "prom")
+		require.NoError(t, err)
+		assert.Len(t, environments, 2)
+	})
+
+	t.Run("should remove the data sources of the deleted environments", func(t *testing.T) {
+		require.NoError(t, s.DeleteEnvironment(ctx, 1, "prod"))
+		require.ErrorIs(t, s.DeleteEnvironment(ctx, 1, "prod"), ErrEnvironmentNotFound)
+
+		uid, err := s.Resolve(ctx, 1, "prod", "prom")
+		require.NoError(t, err)
+		assert.Equal(t, "prom", uid)
+
+		environments, err := s.GetDataSourceEnvironments(ctx, 1, "prom")
+		require.NoError(t, err)
+		require.Len(t, environments, 1)
+		assert.Equal(t, "staging", environments[0].Environment)
+	})
+}
@@ -0,0 +1,84 @@
+package environments
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getEnvironments(ctx context.Context, orgID int64) ([]*Environment, error) {
+	environments := make([]*Environment, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&environments)
+	})
+	return environments, err
+}
+
+func (s *Service) getEnvironment(ctx context.Context, orgID int64, name string) (*Environment, error) {
+	environment := &Environment{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND name = ?", orgID, name).Get(environment)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrEnvironmentNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+func (s *Service) insertEnvironment(ctx context.Context, environment *Environment) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND name = ?", environment.OrgId, environment.Name).Exist(&Environment{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrEnvironmentExists
+		}
+		_, err = sess.Insert(environment)
+		return err
+	})
+}
+
+// deleteEnvironment deletes the environment and the data sources of the environment
+func (s *Service) deleteEnvironment(ctx context.Context, orgID int64, name string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND name = ?", orgID, name).Delete(&Environment{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrEnvironmentNotFound
+		}
+		_, err = sess.Where("org_id = ? AND environment = ?", orgID, name).Delete(&DataSourceEnvironment{})
+		return err
+	})
+}
+
+func (s *Service) getDataSourceEnvironments(ctx context.Context, orgID int64, uid string) ([]*DataSourceEnvironment, error) {
+	environments := make([]*DataSourceEnvironment, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ? AND datasource_uid = ?", orgID, uid).Asc("environment").Find(&environments)
+	})
+	return environments, err
+}
+
+// replaceDataSourceEnvironments replaces the data sources by environment of the data source
+func (s *Service) replaceDataSourceEnvironments(ctx context.Context, orgID int64, uid string, environments []*DataSourceEnvironment) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Where("org_id = ? AND datasource_uid = ?", orgID, uid).Delete(&DataSourceEnvironment{}); err != nil {
+			return err
+		}
+		if len(environments) == 0 {
+			return nil
+		}
+		_, err := sess.InsertMulti(environments)
+		return err
+	})
+}
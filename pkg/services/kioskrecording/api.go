@@ -0,0 +1,138 @@
+package kioskrecording
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/kiosk-recordings", func(recordingRoute routing.RouteRegister) {
+		recordingRoute.Get("/", routing.Wrap(s.getRecordingsHandler))
+		recordingRoute.Get("/:uid", routing.Wrap(s.getRecordingHandler))
+		recordingRoute.Get("/:uid/frames/:index", routing.Wrap(s.getFrameHandler))
+		recordingRoute.Delete("/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.deleteRecordingHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// canView returns whether the user can view the dashboard of the recording. Only the org admins can view the
+// recordings of the deleted dashboards.
+func (s *Service) canView(ctx context.Context, c *models.ReqContext, recording *Recording) (bool, error) {
+	if c.OrgRole == models.ROLE_ADMIN {
+		return true, nil
+	}
+	dash, err := s.sqlStore.GetDashboard(0, c.OrgId, recording.DashboardUid, "")
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return guardian.New(ctx, dash.Id, c.OrgId, c.SignedInUser).CanView()
+}
+
+// getViewableRecording returns the recording of the request, or an error response unless the user can view it
+func (s *Service) getViewableRecording(c *models.ReqContext) (*RecordingDTO, response.Response) {
+	recording, err := s.GetRecording(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		if errors.Is(err, ErrRecordingNotFound) {
+			return nil, response.Error(http.StatusNotFound, "Kiosk recording not found", err)
+		}
+		return nil, response.Error(http.StatusInternalServerError, "Failed to get kiosk recording", err)
+	}
+	canView, err := s.canView(c.Req.Context(), c, recording.Recording)
+	if err != nil {
+		return nil, response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+	}
+	if !canView {
+		return nil, response.Error(http.StatusForbidden, "Access denied to this dashboard", nil)
+	}
+	return recording, nil
+}
+
+// GET /api/kiosk-recordings
+func (s *Service) getRecordingsHandler(c *models.ReqContext) response.Response {
+	recordings, err := s.GetRecordings(c.Req.Context(), GetRecordingsQuery{
+		OrgID:        c.OrgId,
+		DashboardUID: c.Query("dashboardUid"),
+		Limit:        c.QueryInt("limit"),
+	})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get kiosk recordings", err)
+	}
+
+	// The permissions are checked once per dashboard
+	viewable := make(map[string]bool)
+	result := make([]*RecordingDTO, 0, len(recordings))
+	for _, recording := range recordings {
+		canView, ok := viewable[recording.DashboardUid]
+		if !ok {
+			canView, err = s.canView(c.Req.Context(), c, recording.Recording)
+			if err != nil {
+				return response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+			}
+			viewable[recording.DashboardUid] = canView
+		}
+		if canView {
+			result = append(result, recording)
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/kiosk-recordings/:uid
+func (s *Service) getRecordingHandler(c *models.ReqContext) response.Response {
+	recording, rsp := s.getViewableRecording(c)
+	if rsp != nil {
+		return rsp
+	}
+	return response.JSON(http.StatusOK, recording)
+}
+
+// GET /api/kiosk-recordings/:uid/frames/:index
+func (s *Service) getFrameHandler(c *models.ReqContext) response.Response {
+	index, err := strconv.Atoi(web.Params(c.Req)[":index"])
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "index is invalid", err)
+	}
+	recording, rsp := s.getViewableRecording(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	data, err := s.GetFrame(c.Req.Context(), recording.Recording, index)
+	if err != nil {
+		if errors.Is(err, ErrFrameNotFound) {
+			return response.Error(http.StatusNotFound, "Kiosk recording frame not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get kiosk recording frame", err)
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "image/png")
+	header.Set("Cache-Control", "private, max-age=3600")
+	return response.CreateNormalResponse(header, data, http.StatusOK)
+}
+
+// DELETE /api/kiosk-recordings/:uid
+func (s *Service) deleteRecordingHandler(c *models.ReqContext) response.Response {
+	err := s.DeleteRecording(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRecordingNotFound):
+			return response.Error(http.StatusNotFound, "Kiosk recording not found", err)
+		case errors.Is(err, ErrRecordingInProgress):
+			return response.Error(http.StatusBadRequest, "Kiosk recording in progress", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete kiosk recording", err)
+	}
+	return response.Success("Kiosk recording deleted")
+}
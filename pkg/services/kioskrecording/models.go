@@ -0,0 +1,91 @@
+package kioskrecording
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRecordingNotFound   = errors.New("kiosk recording not found")
+	ErrRecordingInProgress = errors.New("kiosk recording in progress")
+	ErrFrameNotFound       = errors.New("kiosk recording frame not found")
+)
+
+// Recording is the sequence of frames of a dashboard displayed in kiosk mode around alert firings, from Before the
+// first firing until After the last one. The alerts firing while the dashboard is recorded extend the recording.
+type Recording struct {
+	Id           int64           `json:"-"`
+	Uid          string          `json:"uid"`
+	OrgId        int64           `json:"-"`
+	DashboardUid string          `json:"dashboardUid"`
+	Alerts       RecordingAlerts `json:"alerts"`
+	Frames       RecordingFrames `json:"frames"`
+	// Started and Ends are the time range of the recording, the frames are rendered until Ends
+	Started time.Time `json:"started"`
+	Ends    time.Time `json:"ends"`
+	Created time.Time `json:"created"`
+}
+
+func (r *Recording) TableName() string {
+	return "kiosk_recording"
+}
+
+// RecordingAlert is an alert whose firing is recorded
+type RecordingAlert struct {
+	ID int64 `json:"id"`
+	// UID is the UID of the alert rule, empty for the legacy alerts
+	UID     string    `json:"uid,omitempty"`
+	Title   string    `json:"title"`
+	FiredAt time.Time `json:"firedAt"`
+}
+
+type RecordingAlerts []RecordingAlert
+
+// FromDB is part of the xorm Conversion interface.
+func (a *RecordingAlerts) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*a = RecordingAlerts{}
+		return nil
+	}
+	return json.Unmarshal(data, a)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (a *RecordingAlerts) ToDB() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// RecordingFrame is a frame of a recording, a PNG image of the dashboard rendered at Time
+type RecordingFrame struct {
+	Time time.Time `json:"time"`
+}
+
+type RecordingFrames []RecordingFrame
+
+// FromDB is part of the xorm Conversion interface.
+func (f *RecordingFrames) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*f = RecordingFrames{}
+		return nil
+	}
+	return json.Unmarshal(data, f)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (f *RecordingFrames) ToDB() ([]byte, error) {
+	return json.Marshal(f)
+}
+
+// RecordingDTO is a recording and whether its frames are still rendered
+type RecordingDTO struct {
+	*Recording
+	InProgress bool `json:"inProgress"`
+}
+
+// GetRecordingsQuery filters the recordings of an organization, the most recent first
+type GetRecordingsQuery struct {
+	OrgID        int64
+	DashboardUID string
+	Limit        int
+}
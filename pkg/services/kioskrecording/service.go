@@ -0,0 +1,290 @@
+package kioskrecording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	renderTimeout = 30 * time.Second
+	queueSize     = 100
+)
+
+// Service records the dashboards displayed in kiosk mode, such as on the wall screens of an operations room, so
+// that the incident reviewers see what was displayed when the alerts fired. Their frames are rendered with the image
+// renderer at each interval and the ones of the last minutes are kept in memory. When an alert of the organization
+// of a dashboard fires, a recording is started with these frames and the next ones, until the end of the recording.
+// The recordings are indexed in the database and their frames are saved to the storage.
+type Service struct {
+	cfg           *setting.Cfg
+	sqlStore      *sqlstore.SQLStore
+	renderService rendering.Service
+	storage       fileStorage
+	firings       chan *events.AlertFiring
+	log           log.Logger
+	now           func() time.Time
+
+	// buffers are the frames rendered during the last Before of each dashboard, and recordings the recordings in
+	// progress. Both are only used by the Run loop.
+	buffers    map[setting.KioskRecordingDashboard][]frame
+	recordings map[setting.KioskRecordingDashboard]*Recording
+}
+
+// frame is a rendered frame, not saved yet
+type frame struct {
+	Time time.Time
+	Data []byte
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, renderService rendering.Service, b bus.Bus,
+	routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:           cfg,
+		sqlStore:      sqlStore,
+		renderService: renderService,
+		storage:       newFileStorage(cfg.KioskRecording),
+		firings:       make(chan *events.AlertFiring, queueSize),
+		log:           log.New("kioskrecording"),
+		now:           time.Now,
+		buffers:       make(map[setting.KioskRecordingDashboard][]frame),
+		recordings:    make(map[setting.KioskRecordingDashboard]*Recording),
+	}
+	s.registerAPIEndpoints(routeRegister)
+	if !s.IsDisabled() {
+		b.AddEventListener(s.onAlertFiring)
+	}
+	return s
+}
+
+// IsDisabled returns true when the kiosk recording is disabled or there is no dashboard to record.
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.KioskRecording.Enabled || len(s.cfg.KioskRecording.Dashboards) == 0
+}
+
+// Run renders the frames of the dashboards at each interval and records the alert firings until the context is
+// done.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.KioskRecording.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-s.firings:
+			s.record(ctx, e)
+		case <-ticker.C:
+			s.capture(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// onAlertFiring never blocks the alerting, the firing is not recorded when too many are pending
+func (s *Service) onAlertFiring(_ context.Context, e *events.AlertFiring) error {
+	select {
+	case s.firings <- e:
+	default:
+		s.log.Warn("Kiosk recording queue is full, the alert firing is not recorded", "orgId", e.OrgID, "alertId", e.AlertID)
+	}
+	return nil
+}
+
+// record starts the recordings of the dashboards of the organization of the alert, or extends the recordings in
+// progress
+func (s *Service) record(ctx context.Context, e *events.AlertFiring) {
+	cfg := s.cfg.KioskRecording
+	alert := RecordingAlert{ID: e.AlertID, UID: e.AlertUID, Title: e.AlertTitle, FiredAt: e.Timestamp}
+	for _, dashboard := range cfg.Dashboards {
+		if dashboard.OrgID != e.OrgID {
+			continue
+		}
+
+		ends := e.Timestamp.Add(cfg.After)
+		if recording, ok := s.recordings[dashboard]; ok && !e.Timestamp.After(recording.Ends) {
+			recording.Alerts = append(recording.Alerts, alert)
+			if ends.After(recording.Ends) {
+				recording.Ends = ends
+			}
+			if err := s.updateRecording(ctx, recording, "alerts", "ends"); err != nil {
+				s.log.Error("Failed to update kiosk recording", "uid", recording.Uid, "error", err)
+			}
+			continue
+		}
+
+		recording := &Recording{
+			Uid:          util.GenerateShortUID(),
+			OrgId:        dashboard.OrgID,
+			DashboardUid: dashboard.UID,
+			Alerts:       RecordingAlerts{alert},
+			Frames:       RecordingFrames{},
+			Started:      e.Timestamp.Add(-cfg.Before),
+			Ends:         ends,
+			Created:      s.now(),
+		}
+		for _, f := range s.buffers[dashboard] {
+			if f.Time.Before(recording.Started) {
+				continue
+			}
+			if err := s.storage.Put(ctx, framePath(recording, f.Time), f.Data); err != nil {
+				s.log.Error("Failed to save kiosk recording frame", "uid", recording.Uid, "error", err)
+				continue
+			}
+			recording.Frames = append(recording.Frames, RecordingFrame{Time: f.Time})
+		}
+		if err := s.insertRecording(ctx, recording); err != nil {
+			s.log.Error("Failed to save kiosk recording", "orgId", dashboard.OrgID, "dashboardUid", dashboard.UID, "error", err)
+			continue
+		}
+		s.recordings[dashboard] = recording
+		s.log.Info("Started kiosk recording", "uid", recording.Uid, "orgId", dashboard.OrgID, "dashboardUid", dashboard.UID, "alert", e.AlertTitle)
+	}
+}
+
+// capture renders a frame of each dashboard, saves it to the recording of the dashboard in progress and ends the
+// recordings which are over
+func (s *Service) capture(ctx context.Context) {
+	if !s.renderService.IsAvailable() {
+		s.log.Warn("Failed to render kiosk recording frames", "error", rendering.ErrRenderUnavailable)
+		return
+	}
+
+	now := s.now()
+	for _, dashboard := range s.cfg.KioskRecording.Dashboards {
+		data, err := s.render(ctx, dashboard)
+		if err != nil {
+			s.log.Warn("Failed to render kiosk recording frame", "orgId", dashboard.OrgID, "dashboardUid", dashboard.UID, "error", err)
+			continue
+		}
+		s.buffer(dashboard, frame{Time: now, Data: data})
+
+		recording, ok := s.recordings[dashboard]
+		if !ok || now.After(recording.Ends) {
+			continue
+		}
+		if err := s.storage.Put(ctx, framePath(recording, now), data); err != nil {
+			s.log.Error("Failed to save kiosk recording frame", "uid", recording.Uid, "error", err)
+			continue
+		}
+		recording.Frames = append(recording.Frames, RecordingFrame{Time: now})
+		if err := s.updateRecording(ctx, recording, "frames"); err != nil {
+			s.log.Error("Failed to update kiosk recording", "uid", recording.Uid, "error", err)
+		}
+	}
+
+	for dashboard, recording := range s.recordings {
+		if now.After(recording.Ends) {
+			delete(s.recordings, dashboard)
+			s.log.Info("Finished kiosk recording", "uid", recording.Uid, "frames", len(recording.Frames))
+		}
+	}
+}
+
+// buffer adds the frame to the frames of the dashboard and drops the ones older than Before
+func (s *Service) buffer(dashboard setting.KioskRecordingDashboard, f frame) {
+	since := f.Time.Add(-s.cfg.KioskRecording.Before)
+	frames := s.buffers[dashboard]
+	i := 0
+	for i < len(frames) && frames[i].Time.Before(since) {
+		// releases the image of the dropped frame, still referenced by the array of the slice
+		frames[i] = frame{}
+		i++
+	}
+	s.buffers[dashboard] = append(frames[i:], f)
+}
+
+// render renders the dashboard in kiosk mode with the image renderer, as an org admin
+func (s *Service) render(ctx context.Context, dashboard setting.KioskRecordingDashboard) ([]byte, error) {
+	cfg := s.cfg.KioskRecording
+	result, err := s.renderService.Render(ctx, rendering.Opts{
+		Width:             cfg.Width,
+		Height:            cfg.Height,
+		DeviceScaleFactor: 1,
+		Timeout:           renderTimeout,
+		OrgID:             dashboard.OrgID,
+		OrgRole:           models.ROLE_ADMIN,
+		Path:              fmt.Sprintf("d/%s?orgId=%d&kiosk", dashboard.UID, dashboard.OrgID),
+		ConcurrentLimit:   s.cfg.RendererConcurrentRequestLimit,
+		Theme:             rendering.Theme(cfg.Theme),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.Remove(result.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Warn("Failed to remove rendered image", "path", result.FilePath, "error", err)
+		}
+	}()
+	// #nosec G304 - the path is the one of the image rendered by Grafana
+	return ioutil.ReadFile(result.FilePath)
+}
+
+// GetRecordings returns the recordings of the organization, the most recent first. The permissions of the user on
+// the dashboards must be checked by the caller.
+func (s *Service) GetRecordings(ctx context.Context, query GetRecordingsQuery) ([]*RecordingDTO, error) {
+	recordings, err := s.getRecordings(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*RecordingDTO, 0, len(recordings))
+	for _, recording := range recordings {
+		result = append(result, s.toDTO(recording))
+	}
+	return result, nil
+}
+
+// GetRecording returns the recording, or ErrRecordingNotFound.
+func (s *Service) GetRecording(ctx context.Context, orgID int64, uid string) (*RecordingDTO, error) {
+	recording, err := s.getRecording(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	return s.toDTO(recording), nil
+}
+
+// GetFrame returns the PNG image of the frame of the recording at the index, or ErrFrameNotFound.
+func (s *Service) GetFrame(ctx context.Context, recording *Recording, index int) ([]byte, error) {
+	if index < 0 || index >= len(recording.Frames) {
+		return nil, ErrFrameNotFound
+	}
+	return s.storage.Get(ctx, framePath(recording, recording.Frames[index].Time))
+}
+
+// DeleteRecording deletes the recording and its frames, or returns ErrRecordingInProgress when its frames are still
+// rendered.
+func (s *Service) DeleteRecording(ctx context.Context, orgID int64, uid string) error {
+	recording, err := s.getRecording(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	if !s.now().After(recording.Ends) {
+		return ErrRecordingInProgress
+	}
+	for _, f := range recording.Frames {
+		if err := s.storage.Delete(ctx, framePath(recording, f.Time)); err != nil {
+			return err
+		}
+	}
+	return s.deleteRecording(ctx, recording)
+}
+
+func (s *Service) toDTO(recording *Recording) *RecordingDTO {
+	return &RecordingDTO{Recording: recording, InProgress: !s.now().After(recording.Ends)}
+}
+
+// framePath is the name of the frame of the recording rendered at the time in the storage
+func framePath(recording *Recording, t time.Time) string {
+	return fmt.Sprintf("%d/%s/%d.png", recording.OrgId, recording.Uid, t.UnixNano()/int64(time.Millisecond))
+}
@@ -0,0 +1,200 @@
+package kioskrecording
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeRenderer writes the rendered paths to PNG files
+type fakeRenderer struct {
+	rendering.Service
+	dir  string
+	opts rendering.Opts
+}
+
+func (r *fakeRenderer) IsAvailable() bool {
+	return true
+}
+
+func (r *fakeRenderer) Render(_ context.Context, opts rendering.Opts) (*rendering.RenderResult, error) {
+	r.opts = opts
+	f, err := ioutil.TempFile(r.dir, "render-*.png")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = f.WriteString(opts.Path)
+	return &rendering.RenderResult{FilePath: f.Name()}, err
+}
+
+func TestService_Record(t *testing.T) {
+	ctx := context.Background()
+	s, renderer := setupTestService(t)
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	s.now = func() time.Time { return now }
+
+	capture := func(at time.Duration) {
+		now = start.Add(at)
+		s.capture(ctx)
+	}
+	fire := func(at time.Duration, title string) {
+		s.record(ctx, &events.AlertFiring{Timestamp: start.Add(at), OrgID: 1, AlertID: 1, AlertUID: "rule", AlertTitle: title})
+	}
+	frameTimes := func(recording *RecordingDTO) []time.Time {
+		times := make([]time.Time, 0, len(recording.Frames))
+		for _, f := range recording.Frames {
+			times = append(times, f.Time.UTC())
+		}
+		return times
+	}
+
+	for _, at := range []time.Duration{0, 30 * time.Second, time.Minute, 90 * time.Second} {
+		capture(at)
+	}
+	recordings, err := s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1})
+	require.NoError(t, err)
+	require.Empty(t, recordings)
+
+	assert.Equal(t, "d/other?orgId=2&kiosk", renderer.opts.Path)
+	assert.Equal(t, 1920, renderer.opts.Width)
+	assert.Equal(t, 1080, renderer.opts.Height)
+	assert.Equal(t, models.ROLE_ADMIN, renderer.opts.OrgRole)
+	files, err := ioutil.ReadDir(renderer.dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	t.Run("should record the frames before the alert firing", func(t *testing.T) {
+		fire(90*time.Second, "High latency")
+
+		recordings, err := s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, recordings, 2)
+
+		recordings, err = s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1, DashboardUID: "wall"})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		recording := recordings[0]
+		assert.True(t, recording.InProgress)
+		assert.Equal(t, start.Add(30*time.Second), recording.Started.UTC())
+		assert.Equal(t, start.Add(150*time.Second), recording.Ends.UTC())
+		assert.Equal(t, []time.Time{start.Add(30 * time.Second), start.Add(time.Minute), start.Add(90 * time.Second)}, frameTimes(recording))
+
+		data, err := s.GetFrame(ctx, recording.Recording, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "d/wall?orgId=1&kiosk", string(data))
+		_, err = s.GetFrame(ctx, recording.Recording, 3)
+		require.ErrorIs(t, err, ErrFrameNotFound)
+
+		recordings, err = s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Empty(t, recordings)
+	})
+
+	t.Run("should extend the recording with the alerts firing during the recording", func(t *testing.T) {
+		capture(2 * time.Minute)
+		fire(2*time.Minute, "Error rate")
+		for _, at := range []time.Duration{150 * time.Second, 3 * time.Minute} {
+			capture(at)
+		}
+
+		recordings, err := s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1, DashboardUID: "wall"})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		recording := recordings[0]
+		require.Len(t, recording.Alerts, 2)
+		assert.Equal(t, "High latency", recording.Alerts[0].Title)
+		assert.Equal(t, "Error rate", recording.Alerts[1].Title)
+		assert.Equal(t, start.Add(3*time.Minute), recording.Ends.UTC())
+		assert.Len(t, recording.Frames, 6)
+
+		err = s.DeleteRecording(ctx, 1, recording.Uid)
+		require.ErrorIs(t, err, ErrRecordingInProgress)
+	})
+
+	t.Run("should end the recording after the last alert firing", func(t *testing.T) {
+		capture(210 * time.Second)
+		assert.Empty(t, s.recordings)
+
+		recordings, err := s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1, DashboardUID: "wall"})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		assert.False(t, recordings[0].InProgress)
+		assert.Len(t, recordings[0].Frames, 6)
+
+		// The next alert firing starts a new recording
+		fire(210*time.Second, "High latency")
+		recordings, err = s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1, DashboardUID: "wall"})
+		require.NoError(t, err)
+		require.Len(t, recordings, 2)
+		assert.Len(t, recordings[0].Frames, 3)
+	})
+
+	t.Run("should delete the recording and its frames", func(t *testing.T) {
+		now = start.Add(time.Hour)
+		recordings, err := s.GetRecordings(ctx, GetRecordingsQuery{OrgID: 1, DashboardUID: "wall", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		recording := recordings[0].Recording
+
+		require.NoError(t, s.DeleteRecording(ctx, 1, recording.Uid))
+		_, err = s.GetRecording(ctx, 1, recording.Uid)
+		require.ErrorIs(t, err, ErrRecordingNotFound)
+		_, err = s.storage.Get(ctx, framePath(recording, recording.Frames[0].Time))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestService_onAlertFiring(t *testing.T) {
+	s, _ := setupTestService(t)
+	b := bus.New()
+	b.AddEventListener(s.onAlertFiring)
+
+	require.NoError(t, b.Publish(context.Background(), &events.AlertFiring{OrgID: 1, AlertID: 1}))
+	require.Len(t, s.firings, 1)
+
+	// The alerting is not blocked when the queue is full
+	for i := 0; i < queueSize; i++ {
+		require.NoError(t, b.Publish(context.Background(), &events.AlertFiring{OrgID: 1, AlertID: 1}))
+	}
+	require.Len(t, s.firings, queueSize)
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeRenderer) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.KioskRecording = setting.KioskRecordingSettings{
+		Enabled: true,
+		Dashboards: []setting.KioskRecordingDashboard{
+			{OrgID: 1, UID: "wall"},
+			{OrgID: 1, UID: "noc"},
+			{OrgID: 2, UID: "other"},
+		},
+		Interval: 30 * time.Second,
+		Before:   time.Minute,
+		After:    time.Minute,
+		Width:    1920,
+		Height:   1080,
+		Theme:    "dark",
+		Storage:  setting.KioskRecordingLocal,
+		Path:     t.TempDir(),
+	}
+	renderer := &fakeRenderer{dir: t.TempDir()}
+	return ProvideService(cfg, sqlstore.InitTestDB(t), renderer, bus.New(), routing.NewRouteRegister()), renderer
+}
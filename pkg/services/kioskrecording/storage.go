@@ -0,0 +1,131 @@
+package kioskrecording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fileStorage keeps the frames of the recordings, by their name
+type fileStorage interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+}
+
+func newFileStorage(cfg setting.KioskRecordingSettings) fileStorage {
+	if cfg.Storage == setting.KioskRecordingS3 {
+		return &s3Storage{cfg: cfg.S3}
+	}
+	return &localStorage{dir: cfg.Path}
+}
+
+// localStorage writes the frames to a directory of the Grafana server
+type localStorage struct {
+	dir string
+}
+
+func (s *localStorage) Put(_ context.Context, name string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (s *localStorage) Get(_ context.Context, name string) ([]byte, error) {
+	// nolint:gosec
+	// the names are the ones of the frames of the recordings, written by the service
+	return ioutil.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+}
+
+func (s *localStorage) Delete(_ context.Context, name string) error {
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// s3Storage keeps the frames in an S3 bucket. The objects are private, access to the frames is left to the bucket
+// policy.
+type s3Storage struct {
+	cfg setting.KioskRecordingS3Settings
+}
+
+func (s *s3Storage) session() (*session.Session, error) {
+	awsCfg := &aws.Config{
+		Region:           aws.String(s.cfg.Region),
+		S3ForcePathStyle: aws.Bool(s.cfg.PathStyleAccess),
+	}
+	if s.cfg.Endpoint != "" {
+		awsCfg.Endpoint = aws.String(s.cfg.Endpoint)
+	}
+	// Without static credentials the default credential chain of the AWS SDK is used
+	if s.cfg.AccessKey != "" && s.cfg.SecretKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(s.cfg.AccessKey, s.cfg.SecretKey, "")
+	}
+	return session.NewSession(awsCfg)
+}
+
+func (s *s3Storage) key(name string) string {
+	if s.cfg.Path != "" {
+		return strings.TrimSuffix(s.cfg.Path, "/") + "/" + name
+	}
+	return name
+}
+
+func (s *s3Storage) Put(ctx context.Context, name string, data []byte) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	_, err = s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(s.key(name)),
+		ACL:         aws.String("private"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("image/png"),
+	})
+	return err
+}
+
+func (s *s3Storage) Get(ctx context.Context, name string) ([]byte, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	buf := aws.NewWriteAtBuffer(nil)
+	_, err = s3manager.NewDownloader(sess).DownloadWithContext(ctx, buf, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, name string) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	_, err = s3.New(sess).DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	return err
+}
@@ -0,0 +1,58 @@
+package kioskrecording
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getRecording(ctx context.Context, orgID int64, uid string) (*Recording, error) {
+	recording := &Recording{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(recording)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrRecordingNotFound
+		}
+		return nil
+	})
+	return recording, err
+}
+
+func (s *Service) getRecordings(ctx context.Context, query GetRecordingsQuery) ([]*Recording, error) {
+	result := make([]*Recording, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sess.Where("org_id = ?", query.OrgID)
+		if query.DashboardUID != "" {
+			sess.And("dashboard_uid = ?", query.DashboardUID)
+		}
+		if query.Limit > 0 {
+			sess.Limit(query.Limit)
+		}
+		return sess.Desc("started").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) insertRecording(ctx context.Context, recording *Recording) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(recording)
+		return err
+	})
+}
+
+func (s *Service) updateRecording(ctx context.Context, recording *Recording, cols ...string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.ID(recording.Id).Cols(cols...).Update(recording)
+		return err
+	})
+}
+
+func (s *Service) deleteRecording(ctx context.Context, recording *Recording) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.ID(recording.Id).Delete(&Recording{})
+		return err
+	})
+}
@@ -416,17 +416,15 @@ func (server *Server) buildGrafanaUser(user *ldap.Entry) (*models.ExternalUserIn
 		OrgRoles: map[int64]models.RoleType{},
 	}
 
-	for _, group := range server.Config.Groups {
+	for _, match := range MatchGroupMappings(server.Config.Groups, memberOf) {
 		// only use the first match for each org
-		if extUser.OrgRoles[group.OrgId] != "" {
+		if !match.Applied {
 			continue
 		}
 
-		if isMemberOf(memberOf, group.GroupDN) {
-			extUser.OrgRoles[group.OrgId] = group.OrgRole
-			if extUser.IsGrafanaAdmin == nil || !*extUser.IsGrafanaAdmin {
-				extUser.IsGrafanaAdmin = group.IsGrafanaAdmin
-			}
+		extUser.OrgRoles[match.OrgId] = match.OrgRole
+		if extUser.IsGrafanaAdmin == nil || !*extUser.IsGrafanaAdmin {
+			extUser.IsGrafanaAdmin = match.IsGrafanaAdmin
 		}
 	}
 
@@ -0,0 +1,82 @@
+package ldap
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GroupMappingMatch is a group mapping matching one of the groups of a user
+type GroupMappingMatch struct {
+	*GroupToOrgRole
+	// Applied is false when an earlier mapping of the same organization applies instead, only the first matching
+	// mapping with a role applies to each organization
+	Applied bool
+}
+
+// GroupMappingConflict is an organization for which a user matches several group mappings with different roles or
+// Grafana admin settings. Only the first of these mappings applies, which may not be what the configuration means.
+type GroupMappingConflict struct {
+	OrgId    int64
+	GroupDNs []string
+	Roles    []models.RoleType
+}
+
+// MatchGroupMappings returns the mappings matching the groups of a user, in the order of the configuration
+func MatchGroupMappings(mappings []*GroupToOrgRole, memberOf []string) []*GroupMappingMatch {
+	matches := []*GroupMappingMatch{}
+	mapped := map[int64]bool{}
+
+	for _, mapping := range mappings {
+		if !isMemberOf(memberOf, mapping.GroupDN) {
+			continue
+		}
+
+		match := &GroupMappingMatch{GroupToOrgRole: mapping, Applied: !mapped[mapping.OrgId]}
+		if match.Applied && mapping.OrgRole != "" {
+			mapped[mapping.OrgId] = true
+		}
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
+// FindGroupMappingConflicts returns the organizations for which the matching mappings disagree, in the order of the
+// configuration
+func FindGroupMappingConflicts(matches []*GroupMappingMatch) []*GroupMappingConflict {
+	byOrg := map[int64][]*GroupMappingMatch{}
+	orgIDs := []int64{}
+	for _, match := range matches {
+		if _, ok := byOrg[match.OrgId]; !ok {
+			orgIDs = append(orgIDs, match.OrgId)
+		}
+		byOrg[match.OrgId] = append(byOrg[match.OrgId], match)
+	}
+
+	conflicts := []*GroupMappingConflict{}
+	for _, orgID := range orgIDs {
+		orgMatches := byOrg[orgID]
+		conflicting := false
+		for _, match := range orgMatches[1:] {
+			if match.OrgRole != orgMatches[0].OrgRole || isGrafanaAdmin(match) != isGrafanaAdmin(orgMatches[0]) {
+				conflicting = true
+				break
+			}
+		}
+		if !conflicting {
+			continue
+		}
+
+		conflict := &GroupMappingConflict{OrgId: orgID}
+		for _, match := range orgMatches {
+			conflict.GroupDNs = append(conflict.GroupDNs, match.GroupDN)
+			conflict.Roles = append(conflict.Roles, match.OrgRole)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts
+}
+
+func isGrafanaAdmin(match *GroupMappingMatch) bool {
+	return match.IsGrafanaAdmin != nil && *match.IsGrafanaAdmin
+}
@@ -0,0 +1,45 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestMatchGroupMappings(t *testing.T) {
+	isAdmin := true
+	mappings := []*GroupToOrgRole{
+		{GroupDN: "cn=admins,dc=grafana,dc=org", OrgId: 1, OrgRole: models.ROLE_ADMIN, IsGrafanaAdmin: &isAdmin},
+		{GroupDN: "cn=editors,dc=grafana,dc=org", OrgId: 1, OrgRole: models.ROLE_EDITOR},
+		{GroupDN: "cn=admins,dc=grafana,dc=org", OrgId: 2, IsGrafanaAdmin: &isAdmin},
+		{GroupDN: "cn=viewers,dc=grafana,dc=org", OrgId: 2, OrgRole: models.ROLE_VIEWER},
+		{GroupDN: "cn=others,dc=grafana,dc=org", OrgId: 3, OrgRole: models.ROLE_VIEWER},
+		{GroupDN: "*", OrgId: 3, OrgRole: models.ROLE_VIEWER},
+		{GroupDN: "*", OrgId: 3, OrgRole: models.ROLE_VIEWER},
+	}
+
+	matches := MatchGroupMappings(mappings, []string{"CN=Admins,dc=grafana,dc=org", "cn=editors,dc=grafana,dc=org", "cn=viewers,dc=grafana,dc=org"})
+	require.Len(t, matches, 6)
+
+	applied := []bool{}
+	for _, match := range matches {
+		applied = append(applied, match.Applied)
+	}
+	// A mapping without role does not prevent the next mapping of the organization from applying
+	assert.Equal(t, []bool{true, false, true, true, true, false}, applied)
+	assert.Equal(t, mappings[3], matches[3].GroupToOrgRole)
+
+	conflicts := FindGroupMappingConflicts(matches)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, &GroupMappingConflict{
+		OrgId:    1,
+		GroupDNs: []string{"cn=admins,dc=grafana,dc=org", "cn=editors,dc=grafana,dc=org"},
+		Roles:    []models.RoleType{models.ROLE_ADMIN, models.ROLE_EDITOR},
+	}, conflicts[0])
+	assert.Equal(t, int64(2), conflicts[1].OrgId)
+
+	assert.Empty(t, MatchGroupMappings(mappings[:5], []string{"cn=unknown,dc=grafana,dc=org"}))
+}
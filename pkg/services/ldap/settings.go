@@ -88,6 +88,15 @@ func ReloadConfig() error {
 	return err
 }
 
+// ReadConfigFile reads the config from the disk without caching it, so that changes can be tested before reloading
+// the config.
+func ReadConfigFile() (*Config, error) {
+	loadingMutex.Lock()
+	defer loadingMutex.Unlock()
+
+	return readConfig(setting.LDAPConfigFile)
+}
+
 // We need to define in this space so `GetConfig` fn
 // could be defined as singleton
 var config *Config
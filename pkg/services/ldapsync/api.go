@@ -0,0 +1,49 @@
+package ldapsync
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister, ac accesscontrol.AccessControl) {
+	auth := acmiddleware.Middleware(ac)
+
+	routeRegister.Group("/api/admin/ldap-sync", func(syncRoute routing.RouteRegister) {
+		syncRoute.Get("/status", auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionLDAPStatusRead)), routing.Wrap(s.getStatusHandler))
+		syncRoute.Post("/run", auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionLDAPUsersSync)), routing.Wrap(s.startSyncHandler))
+	})
+}
+
+// GET /api/admin/ldap-sync/status
+func (s *Service) getStatusHandler(c *models.ReqContext) response.Response {
+	status, err := s.Status(c.Req.Context())
+	if err != nil {
+		return errorResponse(err, "Failed to get the LDAP synchronization status")
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// POST /api/admin/ldap-sync/run
+func (s *Service) startSyncHandler(c *models.ReqContext) response.Response {
+	if err := s.Start(TriggerManual); err != nil {
+		return errorResponse(err, "Failed to start the LDAP synchronization")
+	}
+	return response.JSON(http.StatusAccepted, map[string]interface{}{"message": "LDAP synchronization started"})
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrLDAPDisabled):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrSyncRunning):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,77 @@
+package ldapsync
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrLDAPDisabled = errors.New("LDAP is not enabled")
+	ErrSyncRunning  = errors.New("an LDAP synchronization is running already")
+)
+
+// Trigger is what started a synchronization
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// maxRunFailures is the number of failed users kept in the report of a synchronization
+const maxRunFailures = 100
+
+// Run is the report of a synchronization of the LDAP users. Users is the number of LDAP users checked, Updated the
+// number of users found in LDAP whose roles, teams and profile were synced, and Disabled the number of users disabled
+// because they are no longer in LDAP or no longer match a group mapping.
+type Run struct {
+	Trigger  Trigger        `json:"trigger"`
+	Status   RunStatus      `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Started  time.Time      `json:"started"`
+	Finished *time.Time     `json:"finished,omitempty"`
+	Users    int            `json:"users"`
+	Updated  int            `json:"updated"`
+	Disabled int            `json:"disabled"`
+	Failed   int            `json:"failed"`
+	Failures []*UserFailure `json:"failures"`
+}
+
+// UserFailure is a user who could not be synced
+type UserFailure struct {
+	UserId int64  `json:"userId"`
+	Login  string `json:"login"`
+	Error  string `json:"error"`
+}
+
+// Status is the state of the scheduled synchronization. LastRun is the last synchronization of any Grafana instance,
+// Running tells whether this instance is running a synchronization.
+type Status struct {
+	Enabled  bool       `json:"enabled"`
+	Schedule string     `json:"schedule"`
+	NextRun  *time.Time `json:"nextRun,omitempty"`
+	Running  bool       `json:"running"`
+	LastRun  *Run       `json:"lastRun,omitempty"`
+}
+
+func (r *Run) addFailure(userID int64, login string, err error) {
+	r.Failed++
+	if len(r.Failures) < maxRunFailures {
+		r.Failures = append(r.Failures, &UserFailure{UserId: userID, Login: login, Error: err.Error()})
+	}
+}
+
+// ldapUser is a Grafana user authenticated by LDAP
+type ldapUser struct {
+	Id         int64
+	Login      string
+	IsDisabled bool
+}
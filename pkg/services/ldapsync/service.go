@@ -0,0 +1,199 @@
+package ldapsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	kvNamespace = "ldapsync"
+	lastRunKey  = "last_run"
+	taskName    = "ldap-sync"
+)
+
+// Service periodically synchronizes all the users authenticated by LDAP, and not only when they log in: their
+// profile, organization roles, teams and group roles are updated, and the users no longer found in LDAP are disabled
+// and logged out. The report of the last synchronization is available through the /api/admin/ldap-sync endpoints.
+type Service struct {
+	cfg              *setting.Cfg
+	sqlStore         *sqlstore.SQLStore
+	scheduler        *scheduler.Service
+	kv               *kvstore.NamespacedKVStore
+	authTokenService models.UserTokenService
+	log              log.Logger
+	now              func() time.Time
+
+	getConfig func(cfg *setting.Cfg) (*ldap.Config, error)
+	newLDAP   func(configs []*ldap.ServerConfig) multildap.IMultiLDAP
+
+	mu      sync.Mutex
+	running bool
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore,
+	authTokenService models.UserTokenService, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:              cfg,
+		sqlStore:         sqlStore,
+		scheduler:        schedulerService,
+		kv:               kvstore.WithNamespace(kvStore, 0, kvNamespace),
+		authTokenService: authTokenService,
+		log:              log.New("ldapsync"),
+		now:              time.Now,
+		getConfig:        multildap.GetConfig,
+		newLDAP:          multildap.New,
+	}
+	s.registerAPIEndpoints(routeRegister, ac)
+
+	// Only one Grafana instance synchronizes the users when running in a cluster
+	err := schedulerService.Register(scheduler.Task{
+		Name:        taskName,
+		Description: "Synchronizes the users authenticated by LDAP, and disables the ones no longer found.",
+		Cron:        cfg.LDAPSyncCron,
+		Enabled:     cfg.LDAPEnabled && cfg.LDAPActiveSyncEnabled,
+		Exclusive:   true,
+		Run: func(ctx context.Context) error {
+			_, err := s.Sync(ctx, TriggerSchedule)
+			return err
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP sync_cron: %w", err)
+	}
+	return s, nil
+}
+
+// Status returns the schedule and the report of the last synchronization.
+func (s *Service) Status(ctx context.Context) (*Status, error) {
+	task, err := s.scheduler.GetTask(ctx, taskName)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{
+		Enabled:  task.Enabled,
+		Schedule: task.Cron,
+		NextRun:  task.NextRun,
+		Running:  s.isRunning(),
+	}
+
+	lastRun, err := s.lastRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.LastRun = lastRun
+	return status, nil
+}
+
+// Start starts a synchronization in the background, and returns ErrSyncRunning when one is running already.
+func (s *Service) Start(trigger Trigger) error {
+	if !s.cfg.LDAPEnabled {
+		return ErrLDAPDisabled
+	}
+	if !s.setRunning() {
+		return ErrSyncRunning
+	}
+
+	go func() {
+		defer s.clearRunning()
+		if _, err := s.sync(context.Background(), trigger); err != nil {
+			s.log.Error("Failed to synchronize the LDAP users", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Sync synchronizes all the LDAP users and returns the report of the synchronization, which is saved as the
+// last run. The report is returned with the error when the synchronization failed.
+func (s *Service) Sync(ctx context.Context, trigger Trigger) (*Run, error) {
+	if !s.cfg.LDAPEnabled {
+		return nil, ErrLDAPDisabled
+	}
+	if !s.setRunning() {
+		return nil, ErrSyncRunning
+	}
+	defer s.clearRunning()
+
+	return s.sync(ctx, trigger)
+}
+
+func (s *Service) sync(ctx context.Context, trigger Trigger) (*Run, error) {
+	run := &Run{Trigger: trigger, Status: RunStatusRunning, Started: s.now(), Failures: []*UserFailure{}}
+	s.saveRun(ctx, run)
+
+	err := s.syncUsers(ctx, run)
+	finished := s.now()
+	run.Finished = &finished
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = RunStatusSucceeded
+	}
+	s.saveRun(ctx, run)
+
+	s.log.Info("Synchronized the LDAP users", "trigger", trigger, "status", run.Status, "users", run.Users,
+		"updated", run.Updated, "disabled", run.Disabled, "failed", run.Failed, "duration", finished.Sub(run.Started))
+	return run, err
+}
+
+func (s *Service) isRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// setRunning returns false when a synchronization is running already
+func (s *Service) setRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *Service) clearRunning() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+func (s *Service) lastRun(ctx context.Context) (*Run, error) {
+	value, ok, err := s.kv.Get(ctx, lastRunKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	run := &Run{}
+	if err := json.Unmarshal([]byte(value), run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// saveRun saves the report as the last run, a synchronization is not failed because its report cannot be saved
+func (s *Service) saveRun(ctx context.Context, run *Run) {
+	value, err := json.Marshal(run)
+	if err == nil {
+		err = s.kv.Set(ctx, lastRunKey, string(value))
+	}
+	if err != nil {
+		s.log.Warn("Failed to save the LDAP synchronization report", "error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_Sync(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.LDAPEnabled = true
+	cfg.LDAPActiveSyncEnabled = true
+	cfg.LDAPSyncCron = "0 0 1 * * *"
+	cfg.AdminUser = "admin"
+
+	tokens := auth.NewFakeUserAuthTokenService()
+	var revoked []int64
+	tokens.RevokeAllUserTokensProvider = func(_ context.Context, userID int64) error {
+		revoked = append(revoked, userID)
+		return nil
+	}
+
+	kv := kvstore.ProvideService(store)
+	s, err := ProvideService(cfg, store, kv, tokens, accesscontrolmock.New(), routing.NewRouteRegister(),
+		scheduler.ProvideService(nil, kv, accesscontrolmock.New(), routing.NewRouteRegister()))
+	require.NoError(t, err)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	server := &fakeMultiLDAP{}
+	s.getConfig = func(*setting.Cfg) (*ldap.Config, error) { return &ldap.Config{}, nil }
+	s.newLDAP = func([]*ldap.ServerConfig) multildap.IMultiLDAP { return server }
+
+	var upserted []string
+	bus.AddHandler("test", func(_ context.Context, cmd *models.UpsertUserCommand) error {
+		if cmd.ExternalUser.Login == "broken" {
+			return errors.New("upsert failed")
+		}
+		upserted = append(upserted, cmd.ExternalUser.Login)
+		return nil
+	})
+	t.Cleanup(bus.ClearBusHandlers)
+
+	createUser := func(login string, authModule string, disabled bool) *models.User {
+		user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: login, IsDisabled: disabled, SkipOrgSetup: true})
+		require.NoError(t, err)
+		if authModule != "" {
+			err = store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				_, err := sess.Insert(&models.UserAuth{UserId: user.Id, AuthModule: authModule, AuthId: login, Created: now})
+				return err
+			})
+			require.NoError(t, err)
+		}
+		return user
+	}
+	createUser("admin", models.AuthModuleLDAP, false)
+	createUser("local", "", false)
+	createUser("oauth", "oauth_github", false)
+	createUser("found", models.AuthModuleLDAP, true)
+	removed := createUser("removed", models.AuthModuleLDAP, false)
+	unmapped := createUser("unmapped", models.AuthModuleLDAP, false)
+	createUser("gone", models.AuthModuleLDAP, true)
+	createUser("broken", models.AuthModuleLDAP, false)
+
+	server.users = []*models.ExternalUserInfo{
+		{AuthModule: models.AuthModuleLDAP, Login: "found"},
+		{AuthModule: models.AuthModuleLDAP, Login: "unmapped", IsDisabled: true},
+		{AuthModule: models.AuthModuleLDAP, Login: "broken"},
+	}
+
+	t.Run("should sync the LDAP users and disable the users no longer in LDAP", func(t *testing.T) {
+		run, err := s.Sync(ctx, TriggerManual)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"admin", "found", "removed", "unmapped", "gone", "broken"}, server.logins)
+		assert.Equal(t, []string{"found"}, upserted)
+		assert.ElementsMatch(t, []int64{removed.Id, unmapped.Id}, revoked)
+		for _, id := range []int64{removed.Id, unmapped.Id} {
+			user := &models.User{Id: id}
+			err := store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				_, err := sess.Get(user)
+				return err
+			})
+			require.NoError(t, err)
+			assert.True(t, user.IsDisabled)
+		}
+
+		assert.Equal(t, RunStatusSucceeded, run.Status)
+		assert.Equal(t, TriggerManual, run.Trigger)
+		assert.Equal(t, 6, run.Users)
+		assert.Equal(t, 1, run.Updated)
+		assert.Equal(t, 2, run.Disabled)
+		assert.Equal(t, 2, run.Failed)
+		require.Len(t, run.Failures, 2)
+		assert.Equal(t, "admin", run.Failures[0].Login)
+		assert.Equal(t, "broken", run.Failures[1].Login)
+		assert.Equal(t, "upsert failed", run.Failures[1].Error)
+
+		status, err := s.Status(ctx)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.False(t, status.Running)
+		assert.Equal(t, "0 0 1 * * *", status.Schedule)
+		require.NotNil(t, status.NextRun)
+		assert.Equal(t, 1, status.NextRun.Local().Hour())
+		require.NotNil(t, status.LastRun)
+		assert.Equal(t, RunStatusSucceeded, status.LastRun.Status)
+		assert.Equal(t, 2, status.LastRun.Disabled)
+	})
+
+	t.Run("should not disable any user when an LDAP server is unavailable", func(t *testing.T) {
+		server.logins, upserted, revoked = nil, nil, nil
+		server.unavailable = true
+		t.Cleanup(func() { server.unavailable = false })
+
+		run, err := s.Sync(ctx, TriggerSchedule)
+		require.Error(t, err)
+		assert.Equal(t, RunStatusFailed, run.Status)
+		assert.Contains(t, run.Error, "is unavailable")
+		assert.Empty(t, server.logins)
+		assert.Empty(t, revoked)
+
+		status, err := s.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusFailed, status.LastRun.Status)
+		assert.Equal(t, TriggerSchedule, status.LastRun.Trigger)
+	})
+
+	t.Run("should refuse to start a sync when one is running", func(t *testing.T) {
+		require.True(t, s.setRunning())
+		t.Cleanup(s.clearRunning)
+
+		_, err := s.Sync(ctx, TriggerManual)
+		require.ErrorIs(t, err, ErrSyncRunning)
+		require.ErrorIs(t, s.Start(TriggerManual), ErrSyncRunning)
+	})
+
+	t.Run("should refuse to sync when LDAP is disabled", func(t *testing.T) {
+		cfg.LDAPEnabled = false
+		t.Cleanup(func() { cfg.LDAPEnabled = true })
+
+		_, err := s.Sync(ctx, TriggerManual)
+		require.ErrorIs(t, err, ErrLDAPDisabled)
+	})
+}
+
+type fakeMultiLDAP struct {
+	multildap.IMultiLDAP
+	users       []*models.ExternalUserInfo
+	logins      []string
+	unavailable bool
+}
+
+func (m *fakeMultiLDAP) Ping() ([]*multildap.ServerStatus, error) {
+	return []*multildap.ServerStatus{
+		{Host: "ldap-1", Port: 389, Available: true},
+		{Host: "ldap-2", Port: 389, Available: !m.unavailable},
+	}, nil
+}
+
+func (m *fakeMultiLDAP) Users(logins []string) ([]*models.ExternalUserInfo, error) {
+	m.logins = append(m.logins, logins...)
+	var result []*models.ExternalUserInfo
+	for _, user := range m.users {
+		for _, login := range logins {
+			if user.Login == login {
+				result = append(result, user)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// syncBatchSize is the number of users searched in LDAP at once
+const syncBatchSize = 100
+
+// syncUsers synchronizes the LDAP users in batches and counts them in the run. The synchronization is aborted when
+// an LDAP server is unavailable, since the users of that server would be disabled otherwise.
+func (s *Service) syncUsers(ctx context.Context, run *Run) error {
+	config, err := s.getConfig(s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get the LDAP configuration: %w", err)
+	}
+	server := s.newLDAP(config.Servers)
+
+	statuses, err := server.Ping()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the LDAP servers: %w", err)
+	}
+	for _, status := range statuses {
+		if !status.Available {
+			return fmt.Errorf("LDAP server %s:%d is unavailable: %v", status.Host, status.Port, status.Error)
+		}
+	}
+
+	users, err := s.getLDAPUsers(ctx)
+	if err != nil {
+		return err
+	}
+	run.Users = len(users)
+
+	for start := 0; start < len(users); start += syncBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + syncBatchSize
+		if end > len(users) {
+			end = len(users)
+		}
+		if err := s.syncBatch(ctx, server, users[start:end], run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) syncBatch(ctx context.Context, server multildap.IMultiLDAP, users []*ldapUser, run *Run) error {
+	logins := make([]string, 0, len(users))
+	for _, user := range users {
+		logins = append(logins, user.Login)
+	}
+
+	extUsers, err := server.Users(logins)
+	if err != nil {
+		return fmt.Errorf("failed to search the users in LDAP: %w", err)
+	}
+	byLogin := make(map[string]*models.ExternalUserInfo, len(extUsers))
+	for _, extUser := range extUsers {
+		// The first server finding a user wins, like at login
+		if _, ok := byLogin[extUser.Login]; !ok {
+			byLogin[extUser.Login] = extUser
+		}
+	}
+
+	for _, user := range users {
+		extUser, ok := byLogin[user.Login]
+		if !ok || extUser.IsDisabled {
+			if err := s.disableUser(ctx, user, run); err != nil {
+				run.addFailure(user.Id, user.Login, err)
+			}
+			continue
+		}
+
+		upsert := &models.UpsertUserCommand{
+			ReqContext:    &models.ReqContext{Logger: s.log},
+			ExternalUser:  extUser,
+			SignupAllowed: false,
+		}
+		if err := bus.Dispatch(ctx, upsert); err != nil {
+			s.log.Debug("Failed to synchronize the LDAP user", "login", user.Login, "error", err)
+			run.addFailure(user.Id, user.Login, err)
+			continue
+		}
+		run.Updated++
+	}
+	return nil
+}
+
+// disableUser disables the user no longer found in LDAP and revokes its sessions. The Grafana server admin is
+// never disabled.
+func (s *Service) disableUser(ctx context.Context, user *ldapUser, run *Run) error {
+	if user.Login == s.cfg.AdminUser {
+		return errors.New("refusing to disable the Grafana server admin, who is not found in LDAP")
+	}
+	if user.IsDisabled {
+		return nil
+	}
+
+	s.log.Debug("Disabling the user no longer found in LDAP", "login", user.Login)
+	if err := bus.Dispatch(ctx, &models.DisableUserCommand{UserId: user.Id, IsDisabled: true}); err != nil {
+		return err
+	}
+	if err := s.authTokenService.RevokeAllUserTokens(ctx, user.Id); err != nil {
+		return err
+	}
+	run.Disabled++
+	return nil
+}
+
+// getLDAPUsers returns the users authenticated by LDAP, ordered by ID
+func (s *Service) getLDAPUsers(ctx context.Context) ([]*ldapUser, error) {
+	var users []*ldapUser
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		user := s.sqlStore.Dialect.Quote("user")
+		return sess.SQL(`SELECT DISTINCT u.id, u.login, u.is_disabled FROM `+user+` AS u
+			INNER JOIN user_auth AS ua ON ua.user_id = u.id
+			WHERE ua.auth_module = ? ORDER BY u.id`, models.AuthModuleLDAP).Find(&users)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the LDAP users: %w", err)
+	}
+	return users, nil
+}
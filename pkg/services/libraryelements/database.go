@@ -128,12 +128,19 @@ func (l *LibraryElementService) createLibraryElement(c context.Context, signedIn
 		if err := l.requirePermissionsOnFolder(c, signedInUser, cmd.FolderID); err != nil {
 			return err
 		}
-		if _, err := session.Insert(&element); err != nil {
+		row := element
+		model, err := l.encodeModel(c, session, element)
+		if err != nil {
+			return err
+		}
+		row.Model = model
+		if _, err := session.Insert(&row); err != nil {
 			if l.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
 				return errLibraryElementAlreadyExists
 			}
 			return err
 		}
+		element.ID = row.ID
 		return nil
 	})
 
@@ -202,6 +209,9 @@ func (l *LibraryElementService) deleteLibraryElement(c context.Context, signedIn
 		elementID = element.ID
 		return nil
 	})
+	if err == nil {
+		l.deleteModels(c, signedInUser.OrgId, uid)
+	}
 	return elementID, err
 }
 
@@ -281,6 +291,9 @@ func (l *LibraryElementService) getLibraryElementByUid(c context.Context, signed
 	if err != nil {
 		return LibraryElementDTO{}, err
 	}
+	if err := l.decodeDTOModels(c, libraryElements); err != nil {
+		return LibraryElementDTO{}, err
+	}
 	if len(libraryElements) > 1 {
 		return LibraryElementDTO{}, fmt.Errorf("found %d elements, while expecting at most one", len(libraryElements))
 	}
@@ -290,7 +303,14 @@ func (l *LibraryElementService) getLibraryElementByUid(c context.Context, signed
 
 // getLibraryElementByName gets a Library Element by name.
 func (l *LibraryElementService) getLibraryElementsByName(c context.Context, signedInUser *models.SignedInUser, name string) ([]LibraryElementDTO, error) {
-	return getLibraryElements(c, l.SQLStore, signedInUser, []Pair{{"org_id", signedInUser.OrgId}, {"name", name}})
+	libraryElements, err := getLibraryElements(c, l.SQLStore, signedInUser, []Pair{{"org_id", signedInUser.OrgId}, {"name", name}})
+	if err != nil {
+		return nil, err
+	}
+	if err := l.decodeDTOModels(c, libraryElements); err != nil {
+		return nil, err
+	}
+	return libraryElements, nil
 }
 
 // getAllLibraryElements gets all Library Elements.
@@ -408,7 +428,11 @@ func (l *LibraryElementService) getAllLibraryElements(c context.Context, signedI
 
 		return nil
 	})
+	if err != nil {
+		return result, err
+	}
 
+	err = l.decodeDTOModels(c, result.Elements)
 	return result, err
 }
 
@@ -438,6 +462,8 @@ func (l *LibraryElementService) handleFolderIDPatches(ctx context.Context, eleme
 // patchLibraryElement updates a Library Element.
 func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInUser *models.SignedInUser, cmd patchLibraryElementCommand, uid string) (LibraryElementDTO, error) {
 	var dto LibraryElementDTO
+	var previousUID string
+	var previousVersion int64
 	if err := l.requireSupportedElementKind(cmd.Kind); err != nil {
 		return LibraryElementDTO{}, err
 	}
@@ -486,7 +512,12 @@ func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInU
 			libraryElement.Name = elementInDB.Name
 		}
 		if cmd.Model == nil {
-			libraryElement.Model = elementInDB.Model
+			ctx := context.WithValue(c, sqlstore.ContextSessionKey{}, session)
+			model, err := l.decodeModel(ctx, elementInDB.OrgID, elementInDB.UID, elementInDB.Model)
+			if err != nil {
+				return err
+			}
+			libraryElement.Model = model
 		}
 		if err := l.handleFolderIDPatches(c, &libraryElement, elementInDB.FolderID, cmd.FolderID, signedInUser); err != nil {
 			return err
@@ -494,7 +525,13 @@ func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInU
 		if err := syncFieldsWithModel(&libraryElement); err != nil {
 			return err
 		}
-		if rowsAffected, err := session.ID(elementInDB.ID).Update(&libraryElement); err != nil {
+		row := libraryElement
+		model, err := l.encodeModel(c, session, libraryElement)
+		if err != nil {
+			return err
+		}
+		row.Model = model
+		if rowsAffected, err := session.ID(elementInDB.ID).Update(&row); err != nil {
 			if l.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
 				return errLibraryElementAlreadyExists
 			}
@@ -531,10 +568,20 @@ func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInU
 			},
 		}
 
+		previousUID, previousVersion = elementInDB.UID, elementInDB.Version
 		return nil
 	})
+	if err != nil {
+		return dto, err
+	}
 
-	return dto, err
+	// the model store keeps the latest version of the models only
+	if previousUID != dto.UID {
+		l.deleteModels(c, signedInUser.OrgId, previousUID)
+	} else {
+		l.deleteModels(c, signedInUser.OrgId, previousUID, previousVersion)
+	}
+	return dto, nil
 }
 
 // getConnections gets all connections for a Library Element.
@@ -598,6 +645,11 @@ func (l *LibraryElementService) getElementsForDashboardID(c context.Context, das
 		}
 
 		for _, element := range libraryElements {
+			ctx := context.WithValue(c, sqlstore.ContextSessionKey{}, session)
+			model, err := l.decodeModel(ctx, element.OrgID, element.UID, element.Model)
+			if err != nil {
+				return err
+			}
 			libraryElementMap[element.UID] = LibraryElementDTO{
 				ID:          element.ID,
 				OrgID:       element.OrgID,
@@ -607,7 +659,7 @@ func (l *LibraryElementService) getElementsForDashboardID(c context.Context, das
 				Kind:        element.Kind,
 				Type:        element.Type,
 				Description: element.Description,
-				Model:       element.Model,
+				Model:       model,
 				Version:     element.Version,
 				Meta: LibraryElementDTOMeta{
 					FolderName:          element.FolderName,
@@ -684,7 +736,8 @@ func (l *LibraryElementService) disconnectElementsFromDashboardID(c context.Cont
 
 // deleteLibraryElementsInFolderUID deletes all Library Elements in a folder.
 func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Context, signedInUser *models.SignedInUser, folderUID string) error {
-	return l.SQLStore.WithTransactionalDbSession(c, func(session *sqlstore.DBSession) error {
+	var deletedUIDs []string
+	err := l.SQLStore.WithTransactionalDbSession(c, func(session *sqlstore.DBSession) error {
 		var folderUIDs []struct {
 			ID int64 `xorm:"id"`
 		}
@@ -721,9 +774,10 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 		}
 
 		var elementIDs []struct {
-			ID int64 `xorm:"id"`
+			ID  int64  `xorm:"id"`
+			UID string `xorm:"uid"`
 		}
-		err = session.SQL("SELECT id from library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgId).Find(&elementIDs)
+		err = session.SQL("SELECT id, uid from library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgId).Find(&elementIDs)
 		if err != nil {
 			return err
 		}
@@ -737,6 +791,17 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 			return err
 		}
 
+		for _, element := range elementIDs {
+			deletedUIDs = append(deletedUIDs, element.UID)
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range deletedUIDs {
+		l.deleteModels(c, signedInUser.OrgId, uid)
+	}
+	return nil
 }
@@ -37,6 +37,7 @@ type LibraryElementService struct {
 	SQLStore      *sqlstore.SQLStore
 	RouteRegister routing.RouteRegister
 	log           log.Logger
+	modelStore    ModelStore
 }
 
 // CreateElement creates a Library Element.
@@ -0,0 +1,95 @@
+package libraryelements
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// fakeModelStore keeps the models in a map
+type fakeModelStore struct {
+	models map[string][]byte
+}
+
+func (f *fakeModelStore) WriteModel(_ context.Context, orgID int64, uid string, version int64, model []byte) error {
+	f.models[fmt.Sprintf("%d/%s/%d", orgID, uid, version)] = model
+	return nil
+}
+
+func (f *fakeModelStore) ReadModel(_ context.Context, orgID int64, uid string, version int64) ([]byte, error) {
+	model, ok := f.models[fmt.Sprintf("%d/%s/%d", orgID, uid, version)]
+	if !ok {
+		return nil, fmt.Errorf("model %s not found", uid)
+	}
+	return model, nil
+}
+
+func (f *fakeModelStore) DeleteModel(_ context.Context, orgID int64, uid string, versions ...int64) error {
+	prefix := fmt.Sprintf("%d/%s/", orgID, uid)
+	if len(versions) == 0 {
+		for key := range f.models {
+			if strings.HasPrefix(key, prefix) {
+				delete(f.models, key)
+			}
+		}
+	}
+	for _, version := range versions {
+		delete(f.models, fmt.Sprintf("%s%d", prefix, version))
+	}
+	return nil
+}
+
+func TestLibraryElementModelStore(t *testing.T) {
+	testScenario(t, "When the model store is registered, the models should be kept by the model store",
+		func(t *testing.T, sc scenarioContext) {
+			ctx := context.Background()
+			store := &fakeModelStore{models: map[string][]byte{}}
+			sc.service.RegisterModelStore(store)
+
+			created, err := sc.service.createLibraryElement(ctx, &sc.user, getCreatePanelCommand(sc.folder.Id, "Text - Library Panel"))
+			require.NoError(t, err)
+			require.Contains(t, store.models, "1/"+created.UID+"/1")
+
+			storedModel := func(uid string) string {
+				var model string
+				err := sc.sqlStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+					_, err := session.Table("library_element").Where("uid = ?", uid).Cols("model").Get(&model)
+					return err
+				})
+				require.NoError(t, err)
+				return model
+			}
+			assert.JSONEq(t, `{"__entity":"libraryelement","version":1}`, storedModel(created.UID))
+
+			element, err := sc.service.getLibraryElementByUid(ctx, &sc.user, created.UID)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(created.Model), string(element.Model))
+
+			patched, err := sc.service.patchLibraryElement(ctx, &sc.user, patchLibraryElementCommand{
+				FolderID: sc.folder.Id,
+				Name:     "Renamed",
+				Kind:     int64(models.PanelElement),
+				Version:  1,
+			}, created.UID)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(created.Model), string(patched.Model))
+			assert.Contains(t, store.models, "1/"+created.UID+"/2")
+			assert.NotContains(t, store.models, "1/"+created.UID+"/1")
+
+			result, err := sc.service.getAllLibraryElements(ctx, &sc.user, searchLibraryElementsQuery{perPage: 100, page: 1})
+			require.NoError(t, err)
+			require.Len(t, result.Elements, 1)
+			assert.JSONEq(t, string(created.Model), string(result.Elements[0].Model))
+
+			_, err = sc.service.deleteLibraryElement(ctx, &sc.user, created.UID)
+			require.NoError(t, err)
+			assert.Empty(t, store.models)
+		})
+}
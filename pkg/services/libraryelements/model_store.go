@@ -0,0 +1,85 @@
+package libraryelements
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// modelEntityKey is the field of the stub kept in the model column in place of the models kept by the model store
+const modelEntityKey = "__entity"
+
+// ModelStore keeps the models of the library elements, and of each of their versions, outside of the
+// library_element table. The context of WriteModel holds the transaction saving the library element.
+type ModelStore interface {
+	WriteModel(ctx context.Context, orgID int64, uid string, version int64, model []byte) error
+	ReadModel(ctx context.Context, orgID int64, uid string, version int64) ([]byte, error)
+	// DeleteModel deletes versions of the model of a library element, or all of them when none is given
+	DeleteModel(ctx context.Context, orgID int64, uid string, versions ...int64) error
+}
+
+// RegisterModelStore keeps the models of the library elements saved from now on in the model store, a stub
+// referencing the model is kept in the model column. The models saved before are still read from the model column.
+func (l *LibraryElementService) RegisterModelStore(store ModelStore) {
+	l.modelStore = store
+}
+
+type modelStub struct {
+	Entity  string `json:"__entity"`
+	Version int64  `json:"version"`
+}
+
+// encodeModel writes the model of a library element to the model store, and returns the stub to keep in the model
+// column. The model is returned as it is when there is no model store.
+func (l *LibraryElementService) encodeModel(ctx context.Context, session *sqlstore.DBSession, element LibraryElement) (json.RawMessage, error) {
+	if l.modelStore == nil {
+		return element.Model, nil
+	}
+
+	ctx = context.WithValue(ctx, sqlstore.ContextSessionKey{}, session)
+	if err := l.modelStore.WriteModel(ctx, element.OrgID, element.UID, element.Version, element.Model); err != nil {
+		return nil, fmt.Errorf("failed to write library element model: %w", err)
+	}
+	return json.Marshal(modelStub{Entity: "libraryelement", Version: element.Version})
+}
+
+// decodeModel returns the model of a library element from what is kept in the model column
+func (l *LibraryElementService) decodeModel(ctx context.Context, orgID int64, uid string, model json.RawMessage) (json.RawMessage, error) {
+	if l.modelStore == nil || len(model) == 0 {
+		return model, nil
+	}
+	var stub modelStub
+	if err := json.Unmarshal(model, &stub); err != nil || stub.Entity == "" {
+		return model, nil
+	}
+
+	decoded, err := l.modelStore.ReadModel(ctx, orgID, uid, stub.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the model of library element %s: %w", uid, err)
+	}
+	return decoded, nil
+}
+
+func (l *LibraryElementService) decodeDTOModels(ctx context.Context, dtos []LibraryElementDTO) error {
+	for i := range dtos {
+		model, err := l.decodeModel(ctx, dtos[i].OrgID, dtos[i].UID, dtos[i].Model)
+		if err != nil {
+			return err
+		}
+		dtos[i].Model = model
+	}
+	return nil
+}
+
+// deleteModels deletes versions of the models of a library element once the transaction deleting or updating it is
+// committed. Failing to delete them is only logged.
+func (l *LibraryElementService) deleteModels(ctx context.Context, orgID int64, uid string, versions ...int64) {
+	if l.modelStore == nil {
+		return
+	}
+	if err := l.modelStore.DeleteModel(ctx, orgID, uid, versions...); err != nil {
+		l.log.Warn("Failed to delete library element model", "orgId", orgID, "uid", uid, "error", err)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
 )
@@ -16,10 +18,11 @@ import (
 type actionType string
 
 const (
-	ActionSaved    actionType = "saved"
-	ActionDeleted  actionType = "deleted"
-	EditingStarted actionType = "editing-started"
-	//EditingFinished actionType = "editing-finished"
+	ActionSaved      actionType = "saved"
+	ActionDeleted    actionType = "deleted"
+	EditingStarted   actionType = "editing-started"
+	EditingFinished  actionType = "editing-finished"
+	EditingTakenOver actionType = "editing-taken-over"
 
 	GitopsChannel = "grafana/dashboard/gitops"
 )
@@ -33,12 +36,21 @@ type dashboardEvent struct {
 	Message   string                 `json:"message,omitempty"`
 	Dashboard *models.Dashboard      `json:"dashboard,omitempty"`
 	Error     string                 `json:"error,omitempty"`
+	// Editing lists the users editing the dashboard, sent along with the editing events
+	Editing *models.DashboardEditingState `json:"editing,omitempty"`
 }
 
 // DashboardHandler manages all the `grafana/dashboard/*` channels
 type DashboardHandler struct {
 	Publisher   models.ChannelPublisher
 	ClientCount models.ChannelClientCount
+	// Cache stores who is editing the dashboards, editing presence is disabled when nil
+	Cache remotecache.CacheStorage
+	// ExclusiveEditing lets the first editor of a dashboard lock it until they finish editing or another editor
+	// takes over
+	ExclusiveEditing bool
+
+	editingMu sync.Mutex
 }
 
 // GetHandlerForPath called on init
@@ -104,7 +116,7 @@ func (h *DashboardHandler) OnPublish(ctx context.Context, user *models.SignedInU
 		if err != nil || event.UID != parts[1] {
 			return models.PublishReply{}, backend.PublishStreamStatusNotFound, fmt.Errorf("bad request")
 		}
-		if event.Action != EditingStarted {
+		if event.Action != EditingStarted && event.Action != EditingFinished {
 			// just ignore the event
 			return models.PublishReply{}, backend.PublishStreamStatusNotFound, fmt.Errorf("ignore???")
 		}
@@ -127,6 +139,15 @@ func (h *DashboardHandler) OnPublish(ctx context.Context, user *models.SignedInU
 
 		// Tell everyone who is editing
 		event.User = user.ToUserDisplayDTO()
+		if event.Action == EditingStarted {
+			event.Editing, err = h.EditingStarted(ctx, user.OrgId, event.UID, event.User, event.SessionID)
+		} else {
+			event.Editing, err = h.EditingFinished(ctx, user.OrgId, event.UID, user.UserId, event.SessionID)
+		}
+		if err != nil {
+			logger.Error("Failed to update dashboard editing state", "uid", event.UID, "error", err)
+			return models.PublishReply{}, backend.PublishStreamStatusNotFound, fmt.Errorf("internal error")
+		}
 
 		msg, err := json.Marshal(event)
 		if err != nil {
@@ -180,6 +201,7 @@ func (h *DashboardHandler) DashboardSaved(orgID int64, user *models.UserDisplayD
 
 // DashboardDeleted will broadcast to all connected dashboards
 func (h *DashboardHandler) DashboardDeleted(orgID int64, user *models.UserDisplayDTO, uid string) error {
+	h.clearEditingState(context.Background(), orgID, uid)
 	return h.publish(orgID, dashboardEvent{
 		UID:    uid,
 		Action: ActionDeleted,
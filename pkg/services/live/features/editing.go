@@ -0,0 +1,203 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// EditingTimeout is the time after which an editor who has not published an `editing-started` event again is no
+// longer considered editing the dashboard. Clients repeat the event while editing to stay present.
+const EditingTimeout = time.Minute
+
+func init() {
+	remotecache.Register(models.DashboardEditingState{})
+}
+
+func editingCacheKey(orgID int64, uid string) string {
+	return fmt.Sprintf("dashboard-editing-%d-%s", orgID, uid)
+}
+
+// GetEditingState returns the users currently editing the dashboard
+func (h *DashboardHandler) GetEditingState(ctx context.Context, orgID int64, uid string) (*models.DashboardEditingState, error) {
+	state := &models.DashboardEditingState{UID: uid, Editors: make([]*models.DashboardEditor, 0)}
+	if h.Cache == nil {
+		return state, nil
+	}
+
+	value, err := h.Cache.Get(ctx, editingCacheKey(orgID, uid))
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return state, nil
+		}
+		return nil, err
+	}
+	stored, ok := value.(models.DashboardEditingState)
+	if !ok {
+		return state, nil
+	}
+
+	// Drop the editors who left without publishing an `editing-finished` event
+	now := time.Now()
+	for _, e := range stored.Editors {
+		if now.Sub(e.LastSeen) < EditingTimeout {
+			state.Editors = append(state.Editors, e)
+		}
+	}
+	if stored.LockedBy != nil && now.Sub(stored.LockedBy.LastSeen) < EditingTimeout {
+		state.LockedBy = stored.LockedBy
+	}
+	return state, nil
+}
+
+func (h *DashboardHandler) saveEditingState(ctx context.Context, orgID int64, state *models.DashboardEditingState) error {
+	if len(state.Editors) == 0 && state.LockedBy == nil {
+		err := h.Cache.Delete(ctx, editingCacheKey(orgID, state.UID))
+		if err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return err
+		}
+		return nil
+	}
+	return h.Cache.Set(ctx, editingCacheKey(orgID, state.UID), *state, EditingTimeout)
+}
+
+// EditingStarted records the user as editing the dashboard, or refreshes its presence. In exclusive editing mode,
+// the user acquires the lock unless another user holds it.
+func (h *DashboardHandler) EditingStarted(ctx context.Context, orgID int64, uid string, user *models.UserDisplayDTO, sessionID string) (*models.DashboardEditingState, error) {
+	if h.Cache == nil {
+		return &models.DashboardEditingState{UID: uid, Editors: make([]*models.DashboardEditor, 0)}, nil
+	}
+
+	h.editingMu.Lock()
+	defer h.editingMu.Unlock()
+
+	state, err := h.GetEditingState(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	editor := &models.DashboardEditor{User: user, SessionID: sessionID, Since: now, LastSeen: now}
+	for i, e := range state.Editors {
+		if e.SessionID == sessionID && e.User != nil && e.User.Id == user.Id {
+			editor.Since = e.Since
+			state.Editors = append(state.Editors[:i], state.Editors[i+1:]...)
+			break
+		}
+	}
+	state.Editors = append(state.Editors, editor)
+
+	if h.ExclusiveEditing && !state.LockedByOther(user.Id) {
+		if state.LockedBy == nil || state.LockedBy.SessionID == sessionID {
+			state.LockedBy = editor
+		} else {
+			// Another session of the same user holds the lock, keep it alive
+			state.LockedBy.LastSeen = now
+		}
+	}
+
+	return state, h.saveEditingState(ctx, orgID, state)
+}
+
+// EditingFinished removes the session from the editors of the dashboard, and releases the lock it holds
+func (h *DashboardHandler) EditingFinished(ctx context.Context, orgID int64, uid string, userID int64, sessionID string) (*models.DashboardEditingState, error) {
+	if h.Cache == nil {
+		return &models.DashboardEditingState{UID: uid, Editors: make([]*models.DashboardEditor, 0)}, nil
+	}
+
+	h.editingMu.Lock()
+	defer h.editingMu.Unlock()
+
+	state, err := h.GetEditingState(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	editors := make([]*models.DashboardEditor, 0, len(state.Editors))
+	for _, e := range state.Editors {
+		if e.SessionID != sessionID || e.User == nil || e.User.Id != userID {
+			editors = append(editors, e)
+		}
+	}
+	state.Editors = editors
+	if state.LockedBy != nil && state.LockedBy.SessionID == sessionID && !state.LockedByOther(userID) {
+		state.LockedBy = nil
+	}
+
+	return state, h.saveEditingState(ctx, orgID, state)
+}
+
+// TakeOverEditing gives the exclusive editing lock to the session, and tells the previous holder it lost it
+func (h *DashboardHandler) TakeOverEditing(ctx context.Context, orgID int64, uid string, user *models.UserDisplayDTO, sessionID string) (*models.DashboardEditingState, error) {
+	if h.Cache == nil {
+		return &models.DashboardEditingState{UID: uid, Editors: make([]*models.DashboardEditor, 0)}, nil
+	}
+
+	h.editingMu.Lock()
+	state, err := h.GetEditingState(ctx, orgID, uid)
+	if err != nil {
+		h.editingMu.Unlock()
+		return nil, err
+	}
+
+	now := time.Now()
+	editor := &models.DashboardEditor{User: user, SessionID: sessionID, Since: now, LastSeen: now}
+	editors := []*models.DashboardEditor{editor}
+	for _, e := range state.Editors {
+		if e.SessionID == sessionID && e.User != nil && e.User.Id == user.Id {
+			editor.Since = e.Since
+			continue
+		}
+		editors = append(editors, e)
+	}
+	state.Editors = editors
+	state.LockedBy = editor
+
+	err = h.saveEditingState(ctx, orgID, state)
+	h.editingMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.publish(orgID, dashboardEvent{
+		UID:       uid,
+		Action:    EditingTakenOver,
+		User:      user,
+		SessionID: sessionID,
+		Editing:   state,
+	}); err != nil {
+		logger.Warn("Failed to broadcast editing takeover", "uid", uid, "error", err)
+	}
+	return state, nil
+}
+
+// CheckEditingLock returns ErrDashboardEditingLocked, along with the editing state, when the dashboard cannot be
+// saved by the user because another user holds the exclusive editing lock
+func (h *DashboardHandler) CheckEditingLock(ctx context.Context, orgID int64, uid string, userID int64) (*models.DashboardEditingState, error) {
+	if !h.ExclusiveEditing || h.Cache == nil || uid == "" {
+		return nil, nil
+	}
+
+	state, err := h.GetEditingState(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if state.LockedByOther(userID) {
+		return state, models.ErrDashboardEditingLocked
+	}
+	return state, nil
+}
+
+// clearEditingState forgets the editors of a deleted dashboard
+func (h *DashboardHandler) clearEditingState(ctx context.Context, orgID int64, uid string) {
+	if h.Cache == nil {
+		return
+	}
+	if err := h.Cache.Delete(ctx, editingCacheKey(orgID, uid)); err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+		logger.Warn("Failed to clear dashboard editing state", "uid", uid, "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package features
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDashboardHandler_Editing(t *testing.T) {
+	published := 0
+	h := &DashboardHandler{
+		Publisher: func(orgID int64, channel string, data []byte) error {
+			if channel != GitopsChannel {
+				published++
+			}
+			return nil
+		},
+		Cache:            remotecache.NewFakeStore(t),
+		ExclusiveEditing: true,
+	}
+	ctx := context.Background()
+	alice := &models.UserDisplayDTO{Id: 1, Login: "alice"}
+	bob := &models.UserDisplayDTO{Id: 2, Login: "bob"}
+
+	t.Run("should track the editors and lock the dashboard for the first one", func(t *testing.T) {
+		state, err := h.EditingStarted(ctx, 1, "abc", alice, "s1")
+		require.NoError(t, err)
+		require.Len(t, state.Editors, 1)
+		require.NotNil(t, state.LockedBy)
+		assert.Equal(t, "s1", state.LockedBy.SessionID)
+
+		state, err = h.EditingStarted(ctx, 1, "abc", bob, "s2")
+		require.NoError(t, err)
+		assert.Len(t, state.Editors, 2)
+		assert.Equal(t, alice.Id, state.LockedBy.User.Id)
+
+		// Heartbeats don't duplicate editors
+		state, err = h.EditingStarted(ctx, 1, "abc", alice, "s1")
+		require.NoError(t, err)
+		assert.Len(t, state.Editors, 2)
+
+		state, err = h.GetEditingState(ctx, 2, "abc")
+		require.NoError(t, err)
+		assert.Len(t, state.Editors, 0)
+	})
+
+	t.Run("should prevent other users from saving", func(t *testing.T) {
+		_, err := h.CheckEditingLock(ctx, 1, "abc", alice.Id)
+		require.NoError(t, err)
+
+		state, err := h.CheckEditingLock(ctx, 1, "abc", bob.Id)
+		require.ErrorIs(t, err, models.ErrDashboardEditingLocked)
+		assert.Equal(t, "alice", state.LockedBy.User.Login)
+	})
+
+	t.Run("should give the lock on takeover", func(t *testing.T) {
+		state, err := h.TakeOverEditing(ctx, 1, "abc", bob, "s2")
+		require.NoError(t, err)
+		assert.Equal(t, bob.Id, state.LockedBy.User.Id)
+		assert.Len(t, state.Editors, 2)
+		assert.Equal(t, 1, published)
+
+		_, err = h.CheckEditingLock(ctx, 1, "abc", alice.Id)
+		require.ErrorIs(t, err, models.ErrDashboardEditingLocked)
+		_, err = h.CheckEditingLock(ctx, 1, "abc", bob.Id)
+		require.NoError(t, err)
+	})
+
+	t.Run("should release the lock when editing is finished", func(t *testing.T) {
+		state, err := h.EditingFinished(ctx, 1, "abc", bob.Id, "s2")
+		require.NoError(t, err)
+		assert.Nil(t, state.LockedBy)
+		require.Len(t, state.Editors, 1)
+		assert.Equal(t, alice.Id, state.Editors[0].User.Id)
+
+		_, err = h.CheckEditingLock(ctx, 1, "abc", alice.Id)
+		require.NoError(t, err)
+	})
+
+	t.Run("should drop the editors that are gone", func(t *testing.T) {
+		state, err := h.GetEditingState(ctx, 1, "abc")
+		require.NoError(t, err)
+		state.Editors[0].LastSeen = time.Now().Add(-2 * EditingTimeout)
+		require.NoError(t, h.saveEditingState(ctx, 1, state))
+
+		state, err = h.GetEditingState(ctx, 1, "abc")
+		require.NoError(t, err)
+		assert.Len(t, state.Editors, 0)
+	})
+
+	t.Run("should not lock without exclusive editing", func(t *testing.T) {
+		h := &DashboardHandler{Cache: remotecache.NewFakeStore(t)}
+		state, err := h.EditingStarted(ctx, 1, "abc", alice, "s1")
+		require.NoError(t, err)
+		assert.Nil(t, state.LockedBy)
+
+		_, err = h.CheckEditingLock(ctx, 1, "abc", bob.Id)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,34 @@
+package features
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// QueryProgressHandler manages the `grafana/query/<user id>/<query id>` channels, on which the progress of the
+// queries started with a query ID is published
+type QueryProgressHandler struct{}
+
+// GetHandlerForPath called on init
+func (h *QueryProgressHandler) GetHandlerForPath(_ string) (models.ChannelHandler, error) {
+	return h, nil // all queries share the same handler
+}
+
+// OnSubscribe lets the users follow the progress of their own queries
+func (h *QueryProgressHandler) OnSubscribe(_ context.Context, user *models.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	parts := strings.Split(e.Path, "/")
+	if len(parts) != 2 || parts[1] == "" || parts[0] != strconv.FormatInt(user.UserId, 10) {
+		return models.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+	}
+	return models.SubscribeReply{}, backend.SubscribeStreamStatusOK, nil
+}
+
+// OnPublish is not allowed, the progress is published by the server
+func (h *QueryProgressHandler) OnPublish(_ context.Context, _ *models.SignedInUser, _ models.PublishEvent) (models.PublishReply, backend.PublishStreamStatus, error) {
+	return models.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
@@ -0,0 +1,33 @@
+package features
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryProgressHandler_OnSubscribe(t *testing.T) {
+	h := &QueryProgressHandler{}
+	user := &models.SignedInUser{OrgId: 1, UserId: 2}
+
+	for path, expected := range map[string]backend.SubscribeStreamStatus{
+		"2/q1":   backend.SubscribeStreamStatusOK,
+		"3/q1":   backend.SubscribeStreamStatusPermissionDenied,
+		"2":      backend.SubscribeStreamStatusPermissionDenied,
+		"2/":     backend.SubscribeStreamStatusPermissionDenied,
+		"2/q1/x": backend.SubscribeStreamStatusPermissionDenied,
+	} {
+		handler, err := h.GetHandlerForPath(path)
+		require.NoError(t, err)
+		_, status, err := handler.OnSubscribe(context.Background(), user, models.SubscribeEvent{Channel: "grafana/query/" + path, Path: path})
+		require.NoError(t, err)
+		require.Equal(t, expected, status, path)
+	}
+
+	_, status, err := h.OnPublish(context.Background(), user, models.PublishEvent{Channel: "grafana/query/2/q1", Path: "2/q1"})
+	require.NoError(t, err)
+	require.Equal(t, backend.PublishStreamStatusPermissionDenied, status)
+}
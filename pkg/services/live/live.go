@@ -27,6 +27,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
@@ -68,7 +69,7 @@ type CoreGrafanaScope struct {
 func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, routeRegister routing.RouteRegister,
 	logsService *cloudwatch.LogsService, pluginStore plugins.Store, cacheService *localcache.CacheService,
 	dataSourceCache datasources.CacheService, sqlStore *sqlstore.SQLStore, secretsService secrets.Service,
-	usageStatsService usagestats.Service, queryDataService *query.Service) (*GrafanaLive, error) {
+	usageStatsService usagestats.Service, queryDataService *query.Service, remoteCache *remotecache.RemoteCache) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		PluginContextProvider: plugCtxProvider,
@@ -230,13 +231,21 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	// Initialize the main features
 	dash := &features.DashboardHandler{
-		Publisher:   g.Publish,
-		ClientCount: g.ClientCount,
+		Publisher:        g.Publish,
+		ClientCount:      g.ClientCount,
+		ExclusiveEditing: g.Cfg.LiveExclusiveDashboardEditing,
+	}
+	if remoteCache != nil {
+		dash.Cache = remoteCache
 	}
 	g.storage = database.NewStorage(g.SQLStore, g.CacheService)
 	g.GrafanaScope.Dashboards = dash
 	g.GrafanaScope.Features["dashboard"] = dash
 	g.GrafanaScope.Features["broadcast"] = features.NewBroadcastRunner(g.storage)
+	g.GrafanaScope.Features["query"] = &features.QueryProgressHandler{}
+	if g.queryDataService != nil {
+		g.queryDataService.SetProgressPublisher(g.Publish)
+	}
 
 	g.surveyCaller = survey.NewCaller(managedStreamRunner, node)
 	err = g.surveyCaller.SetupHandlers()
@@ -943,6 +952,11 @@ func (g *GrafanaLive) Publish(orgID int64, channel string, data []byte) error {
 	return err
 }
 
+// NumConnections returns the number of Live connections to this Grafana instance.
+func (g *GrafanaLive) NumConnections() int {
+	return g.node.Hub().NumClients()
+}
+
 // ClientCount returns the number of clients.
 func (g *GrafanaLive) ClientCount(orgID int64, channel string) (int, error) {
 	p, err := g.node.Presence(orgchannel.PrependOrgID(orgID, channel))
@@ -59,6 +59,16 @@ func (s *Implementation) GetAuthInfo(ctx context.Context, query *models.GetAuthI
 		return models.ErrUserNotFound
 	}
 
+	if err := s.decryptTokens(userAuth); err != nil {
+		return err
+	}
+
+	query.Result = userAuth
+	return nil
+}
+
+// decryptTokens decrypts the OAuth tokens of the user auth read from the database
+func (s *Implementation) decryptTokens(userAuth *models.UserAuth) error {
 	secretAccessToken, err := s.decodeAndDecrypt(userAuth.OAuthAccessToken)
 	if err != nil {
 		return err
@@ -79,8 +89,35 @@ func (s *Implementation) GetAuthInfo(ctx context.Context, query *models.GetAuthI
 	userAuth.OAuthRefreshToken = secretRefreshToken
 	userAuth.OAuthTokenType = secretTokenType
 	userAuth.OAuthIdToken = secretIdToken
+	return nil
+}
 
-	query.Result = userAuth
+func (s *Implementation) GetExpiringOAuthTokens(ctx context.Context, query *models.GetExpiringOAuthTokensQuery) error {
+	userAuths := make([]*models.UserAuth, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("user_auth").
+			Where("user_auth.o_auth_access_token != ? AND user_auth.o_auth_expiry > ? AND user_auth.o_auth_expiry < ?",
+				"", time.Unix(0, 0), query.ExpiresBefore).
+			Where("EXISTS (SELECT 1 FROM user_auth_token WHERE user_auth_token.user_id = user_auth.user_id AND user_auth_token.rotated_at >= ?)",
+				query.ActiveSince.Unix()).
+			Asc("user_auth.o_auth_expiry").
+			Limit(query.Limit).
+			Find(&userAuths)
+	})
+	if err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.UserAuth, 0, len(userAuths))
+	for _, userAuth := range userAuths {
+		if err := s.decryptTokens(userAuth); err != nil {
+			return err
+		}
+		// Tokens without a refresh token cannot be refreshed
+		if userAuth.OAuthRefreshToken != "" {
+			query.Result = append(query.Result, userAuth)
+		}
+	}
 	return nil
 }
 
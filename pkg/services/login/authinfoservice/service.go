@@ -34,6 +34,7 @@ func ProvideAuthInfoService(bus bus.Bus, store *sqlstore.SQLStore, userProtectio
 
 	s.Bus.AddHandler(s.GetExternalUserInfoByLogin)
 	s.Bus.AddHandler(s.GetAuthInfo)
+	s.Bus.AddHandler(s.GetExpiringOAuthTokens)
 	s.Bus.AddHandler(s.SetAuthInfo)
 	s.Bus.AddHandler(s.UpdateAuthInfo)
 	s.Bus.AddHandler(s.DeleteAuthInfo)
@@ -255,5 +255,47 @@ func TestUserAuth(t *testing.T) {
 			require.NotNil(t, err)
 			require.Nil(t, user)
 		})
+
+		t.Run("Can find the oauth tokens about to expire of the users with a session", func(t *testing.T) {
+			now := time.Now()
+			tokens := map[string]*oauth2.Token{
+				"loginuser2": {AccessToken: "expiring", RefreshToken: "refresh", Expiry: now.Add(time.Minute)},
+				"loginuser3": {AccessToken: "no-session", RefreshToken: "refresh", Expiry: now.Add(time.Minute)},
+				"loginuser4": {AccessToken: "valid", RefreshToken: "refresh", Expiry: now.Add(time.Hour)},
+			}
+			userIDs := map[string]int64{}
+			for login, token := range tokens {
+				_, user, err := srv.LookupByOneOf(0, "", login)
+				require.NoError(t, err)
+				userIDs[login] = user.Id
+				err = srv.SetAuthInfo(context.Background(), &models.SetAuthInfoCommand{
+					UserId: user.Id, AuthModule: "oauth_refresh", AuthId: login, OAuthToken: token,
+				})
+				require.NoError(t, err)
+			}
+			err := srv.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+				for _, login := range []string{"loginuser2", "loginuser4"} {
+					_, err := sess.Exec("INSERT INTO user_auth_token (user_id, auth_token, prev_auth_token, user_agent, client_ip, auth_token_seen, seen_at, rotated_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+						userIDs[login], "token-"+login, "prev-"+login, "", "", false, now.Unix(), now.Unix(), now.Unix(), now.Unix())
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err)
+
+			query := &models.GetExpiringOAuthTokensQuery{
+				ExpiresBefore: now.Add(5 * time.Minute),
+				ActiveSince:   now.Add(-time.Hour),
+				Limit:         10,
+			}
+			err = srv.GetExpiringOAuthTokens(context.Background(), query)
+			require.NoError(t, err)
+			require.Len(t, query.Result, 1)
+			require.Equal(t, userIDs["loginuser2"], query.Result[0].UserId)
+			require.Equal(t, "expiring", query.Result[0].OAuthAccessToken)
+			require.Equal(t, "refresh", query.Result[0].OAuthRefreshToken)
+		})
 	})
 }
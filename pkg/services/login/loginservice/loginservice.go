@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -16,12 +17,14 @@ var (
 	logger = log.New("login.ext_user")
 )
 
-func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, quotaService *quota.QuotaService, authInfoService login.AuthInfoService) *Implementation {
+func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, quotaService *quota.QuotaService, authInfoService login.AuthInfoService,
+	groupMappings accesscontrol.GroupMappingStore) *Implementation {
 	s := &Implementation{
 		SQLStore:        sqlStore,
 		Bus:             bus,
 		QuotaService:    quotaService,
 		AuthInfoService: authInfoService,
+		GroupMappings:   groupMappings,
 	}
 	bus.AddHandler(s.UpsertUser)
 	return s
@@ -33,6 +36,8 @@ type Implementation struct {
 	AuthInfoService login.AuthInfoService
 	QuotaService    *quota.QuotaService
 	TeamSync        login.TeamSyncFunc
+	// GroupMappings syncs the roles mapped to the groups of the external users
+	GroupMappings accesscontrol.GroupMappingStore
 }
 
 // CreateUser creates inserts a new one.
@@ -127,6 +132,12 @@ func (ls *Implementation) UpsertUser(ctx context.Context, cmd *models.UpsertUser
 		}
 	}
 
+	if ls.GroupMappings != nil && extUser.AuthModule != "" {
+		if err := ls.GroupMappings.SyncUserGroupRoles(ctx, cmd.Result.Id, extUser.AuthModule, extUser.Groups); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/quota"
 	log "github.com/inconshreveable/log15"
 	"github.com/stretchr/testify/assert"
@@ -198,3 +199,46 @@ func createResponseWithOneErrLastOrgAdminItem() []struct {
 	}
 	return remResp
 }
+
+type groupMappingStoreMock struct {
+	accesscontrol.GroupMappingStore
+	userID     int64
+	authModule string
+	groups     []string
+}
+
+func (m *groupMappingStoreMock) SyncUserGroupRoles(ctx context.Context, userID int64, authModule string, groups []string) error {
+	m.userID, m.authModule, m.groups = userID, authModule, groups
+	return nil
+}
+
+func Test_groupSync(t *testing.T) {
+	authInfoMock := &authInfoServiceMock{user: &models.User{Id: 1, Login: "test_user"}}
+	groupMappings := &groupMappingStoreMock{}
+	login := Implementation{
+		Bus:             bus.New(),
+		QuotaService:    &quota.QuotaService{},
+		AuthInfoService: authInfoMock,
+		GroupMappings:   groupMappings,
+	}
+	bus.ClearBusHandlers()
+	t.Cleanup(func() { bus.ClearBusHandlers() })
+
+	t.Run("should not sync the groups of users without authentication module", func(t *testing.T) {
+		err := login.UpsertUser(context.Background(), &models.UpsertUserCommand{ExternalUser: &models.ExternalUserInfo{Login: "test_user"}})
+		require.NoError(t, err)
+		assert.Zero(t, groupMappings.userID)
+	})
+
+	t.Run("should sync the groups of external users", func(t *testing.T) {
+		err := login.UpsertUser(context.Background(), &models.UpsertUserCommand{ExternalUser: &models.ExternalUserInfo{
+			Login:      "test_user",
+			AuthModule: "oauth_generic_oauth",
+			Groups:     []string{"admins"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), groupMappings.userID)
+		assert.Equal(t, "oauth_generic_oauth", groupMappings.authModule)
+		assert.Equal(t, []string{"admins"}, groupMappings.groups)
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -28,6 +29,7 @@ var timeNow = time.Now
 type Scheduler interface {
 	AlertmanagersFor(orgID int64) []*url.URL
 	DroppedAlertmanagersFor(orgID int64) []*url.URL
+	EvaluationSamples(key models.AlertRuleKey) []apimodels.EvaluationSample
 }
 
 type Alertmanager interface {
@@ -70,6 +72,8 @@ type API struct {
 	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 	StateManager         *state.Manager
 	SecretsService       secrets.Service
+	// ResourceLabels filters the alert rules with label selectors, it is nil in the tests
+	ResourceLabels *resourcelabels.Service
 }
 
 // RegisterAPIEndpoints registers API handlers
@@ -95,7 +99,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 	api.RegisterRulerApiEndpoints(NewForkedRuler(
 		api.DatasourceCache,
 		NewLotexRuler(proxy, logger),
-		RulerSrv{DatasourceCache: api.DatasourceCache, QuotaService: api.QuotaService, manager: api.StateManager, store: api.RuleStore, log: logger},
+		RulerSrv{DatasourceCache: api.DatasourceCache, QuotaService: api.QuotaService, manager: api.StateManager, store: api.RuleStore, resourceLabels: api.ResourceLabels, log: logger},
 	), m)
 	api.RegisterTestingApiEndpoints(NewForkedTestingApi(
 		TestingApiSrv{
@@ -108,6 +112,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 	api.RegisterConfigurationApiEndpoints(NewForkedConfiguration(
 		AdminSrv{
 			store:     api.AdminConfigStore,
+			ruleStore: api.RuleStore,
 			log:       logger,
 			scheduler: api.Schedule,
 		},
@@ -11,6 +11,7 @@ import (
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
 
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
@@ -18,6 +19,7 @@ import (
 type AdminSrv struct {
 	scheduler Scheduler
 	store     store.AdminConfigurationStore
+	ruleStore store.RuleStore
 	log       log.Logger
 }
 
@@ -38,6 +40,26 @@ func (srv AdminSrv) RouteGetAlertmanagers(c *models.ReqContext) response.Respons
 	})
 }
 
+func (srv AdminSrv) RouteGetEvaluationSamples(c *models.ReqContext) response.Response {
+	if c.OrgRole != models.ROLE_ADMIN {
+		return accessForbiddenResp()
+	}
+
+	q := ngmodels.GetAlertRuleByUIDQuery{OrgID: c.OrgId, UID: web.Params(c.Req)[":RuleUID"]}
+	if err := srv.ruleStore.GetAlertRuleByUID(&q); err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to fetch alert rule")
+	}
+
+	samples := srv.scheduler.EvaluationSamples(q.Result.GetKey())
+	if samples == nil {
+		samples = []apimodels.EvaluationSample{}
+	}
+	return response.JSON(http.StatusOK, apimodels.EvaluationSamples(samples))
+}
+
 func (srv AdminSrv) RouteGetNGalertConfig(c *models.ReqContext) response.Response {
 	if c.OrgRole != models.ROLE_ADMIN {
 		return accessForbiddenResp()
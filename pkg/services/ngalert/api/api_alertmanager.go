@@ -355,7 +355,11 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
 
-	if err := body.ProcessConfig(srv.secrets.Encrypt); err != nil {
+	// the secure settings are encrypted with the data keys of the organization
+	encrypt := func(ctx context.Context, payload []byte, _ secrets.EncryptionOptions) ([]byte, error) {
+		return srv.secrets.Encrypt(ctx, payload, secrets.WithOrgScope(c.OrgId))
+	}
+	if err := body.ProcessConfig(encrypt); err != nil {
 		return ErrResp(http.StatusInternalServerError, err, "failed to post process Alertmanager configuration")
 	}
 
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/prometheus/common/model"
@@ -26,6 +27,7 @@ type RulerSrv struct {
 	DatasourceCache datasources.CacheService
 	QuotaService    *quota.QuotaService
 	manager         *state.Manager
+	resourceLabels  *resourcelabels.Service
 	log             log.Logger
 }
 
@@ -183,8 +185,20 @@ func (srv RulerSrv) RouteGetRulesConfig(c *models.ReqContext) response.Response
 		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
 	}
 
+	// the resource labels of the rules, unlike the labels of their alerts
+	var labelMatch *resourcelabels.Match
+	if srv.resourceLabels != nil {
+		labelMatch, err = srv.resourceLabels.MatchSelector(c.Req.Context(), c.OrgId, c.Query("labelSelector"), resourcelabels.ResourceTypeAlertRule)
+		if err != nil {
+			return resourcelabels.SelectorErrorResponse(err)
+		}
+	}
+
 	configs := make(map[string]map[string]apimodels.GettableRuleGroupConfig)
 	for _, r := range q.Result {
+		if labelMatch != nil && !labelMatch.Matches(r.UID) {
+			continue
+		}
 		folder, ok := namespaceMap[r.NamespaceUID]
 		if !ok {
 			srv.log.Error("namespace not visible to the user", "user", c.SignedInUser.UserId, "namespace", r.NamespaceUID, "rule", r.UID)
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/expr"
@@ -15,8 +16,18 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// maxPreviewEvaluations bounds the evaluations of a rule preview, the interval is widened to cover the time range
+	maxPreviewEvaluations  = 100
+	defaultPreviewInterval = time.Minute
+	defaultPreviewRange    = time.Hour
 )
 
 type TestingApiSrv struct {
@@ -94,3 +105,146 @@ func (srv TestingApiSrv) RouteEvalQueries(c *models.ReqContext, cmd apimodels.Ev
 
 	return response.JSONStreaming(http.StatusOK, evalResults)
 }
+
+func (srv TestingApiSrv) RoutePreviewRule(c *models.ReqContext, body apimodels.PreviewRulePayload) response.Response {
+	r := body.Rule
+	if r.GrafanaManagedAlert == nil {
+		return ErrResp(http.StatusBadRequest, errors.New("unexpected payload"), "only Grafana managed rules can be previewed")
+	}
+
+	rule := ngmodels.AlertRule{
+		OrgID:        c.SignedInUser.OrgId,
+		Title:        r.GrafanaManagedAlert.Title,
+		Condition:    r.GrafanaManagedAlert.Condition,
+		Data:         r.GrafanaManagedAlert.Data,
+		UID:          r.GrafanaManagedAlert.UID,
+		NoDataState:  ngmodels.NoDataState(r.GrafanaManagedAlert.NoDataState),
+		ExecErrState: ngmodels.ExecutionErrorState(r.GrafanaManagedAlert.ExecErrState),
+		Annotations:  map[string]string{},
+	}
+	if rule.UID == "" {
+		rule.UID = "preview"
+	}
+	if rule.NoDataState == "" {
+		rule.NoDataState = ngmodels.NoData
+	}
+	if rule.ExecErrState == "" {
+		rule.ExecErrState = ngmodels.AlertingErrState
+	}
+	if r.ApiRuleNode != nil {
+		rule.For = time.Duration(r.ApiRuleNode.For)
+		rule.Labels = r.ApiRuleNode.Labels
+		for k, v := range r.ApiRuleNode.Annotations {
+			rule.Annotations[k] = v
+		}
+	}
+	if body.DashboardUID != "" {
+		rule.Annotations[ngmodels.DashboardUIDAnnotation] = body.DashboardUID
+		rule.Annotations[ngmodels.PanelIDAnnotation] = strconv.FormatInt(body.PanelID, 10)
+	}
+
+	cond := ngmodels.Condition{
+		Condition: rule.Condition,
+		OrgID:     rule.OrgID,
+		Data:      rule.Data,
+	}
+	if err := validateCondition(c.Req.Context(), cond, c.SignedInUser, c.SkipCache, srv.DatasourceCache); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to validate alert rule %q", rule.Title)
+	}
+
+	to, from := body.To, body.From
+	if to.IsZero() {
+		to = timeNow()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultPreviewRange)
+	}
+	if !from.Before(to) {
+		return ErrResp(http.StatusBadRequest, errors.New("from must be before to"), "")
+	}
+	interval := time.Duration(body.Interval)
+	if interval < 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("interval must not be negative"), "")
+	}
+	if interval == 0 {
+		interval = defaultPreviewInterval
+	}
+	if min := to.Sub(from) / (maxPreviewEvaluations - 1); interval < min {
+		interval = min.Truncate(time.Second)
+		if interval < min {
+			interval += time.Second
+		}
+	}
+	rule.IntervalSeconds = int64(interval.Seconds())
+
+	// the rule is evaluated at the end of the time range and at each interval before it, oldest first
+	n := int(to.Sub(from) / interval)
+	times := make([]time.Time, 0, n+1)
+	for i := n; i >= 0; i-- {
+		times = append(times, to.Add(-time.Duration(i)*interval))
+	}
+
+	evaluator := eval.Evaluator{Cfg: srv.Cfg, Log: srv.log, DataSourceCache: srv.DatasourceCache}
+	evaluations := make([]eval.Results, 0, len(times))
+	for _, t := range times {
+		results, err := evaluator.ConditionEval(&cond, t, srv.ExpressionService)
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "Failed to evaluate conditions")
+		}
+		evaluations = append(evaluations, results)
+	}
+
+	externalURL, err := url.Parse(srv.Cfg.AppURL)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to parse the application URL")
+	}
+	states, transitions := state.Replay(c.Req.Context(), srv.log, externalURL, &rule, evaluations)
+
+	return response.JSON(http.StatusOK, previewRuleResponse(&rule, interval, times, states, transitions))
+}
+
+// previewRuleResponse returns the alert instances of a rule preview with the changes of their states, each change
+// lasting until the next change of the instance or the last evaluation.
+func previewRuleResponse(rule *ngmodels.AlertRule, interval time.Duration, times []time.Time, states []*state.State, transitions []state.Transition) apimodels.PreviewRuleResponse {
+	changes := make(map[string][]apimodels.PreviewStateChange, len(states))
+	for _, t := range transitions {
+		instanceChanges := changes[t.CacheId]
+		if n := len(instanceChanges); n > 0 {
+			instanceChanges[n-1].TimeEnd = t.EvaluatedAt
+		}
+		changes[t.CacheId] = append(instanceChanges, apimodels.PreviewStateChange{
+			Time:      t.EvaluatedAt,
+			PrevState: t.OldState.String(),
+			NewState:  t.NewState.String(),
+			Text:      fmt.Sprintf("%s {%s} - %s", rule.Title, t.Instance.String(), t.NewState.String()),
+		})
+	}
+
+	res := apimodels.PreviewRuleResponse{
+		Interval:    model.Duration(interval),
+		Evaluations: len(times),
+		Instances:   make([]apimodels.PreviewAlertInstance, 0, len(states)),
+	}
+	for _, s := range states {
+		instanceChanges := changes[s.CacheId]
+		if n := len(instanceChanges); n > 0 {
+			instanceChanges[n-1].TimeEnd = s.LastEvaluationTime
+		} else {
+			instanceChanges = []apimodels.PreviewStateChange{}
+		}
+		instance := apimodels.PreviewAlertInstance{
+			Labels:       s.Labels,
+			Annotations:  s.Annotations,
+			State:        s.State.String(),
+			StateChanges: instanceChanges,
+		}
+		if n := len(s.Results); n > 0 {
+			instance.Value = s.Results[n-1].EvaluationString
+		}
+		if s.Error != nil {
+			instance.Error = s.Error.Error()
+		}
+		res.Instances = append(res.Instances, instance)
+	}
+	return res
+}
@@ -22,6 +22,10 @@ func (f *ForkedConfigurationApi) forkRouteGetAlertmanagers(c *models.ReqContext)
 	return f.grafana.RouteGetAlertmanagers(c)
 }
 
+func (f *ForkedConfigurationApi) forkRouteGetEvaluationSamples(c *models.ReqContext) response.Response {
+	return f.grafana.RouteGetEvaluationSamples(c)
+}
+
 func (f *ForkedConfigurationApi) forkRouteGetNGalertConfig(c *models.ReqContext) response.Response {
 	return f.grafana.RouteGetNGalertConfig(c)
 }
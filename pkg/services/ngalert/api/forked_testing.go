@@ -25,3 +25,7 @@ func (f *ForkedTestingApi) forkRouteTestRuleConfig(c *models.ReqContext, body ap
 func (f *ForkedTestingApi) forkRouteEvalQueries(c *models.ReqContext, body apimodels.EvalQueriesPayload) response.Response {
 	return f.grafana.RouteEvalQueries(c, body)
 }
+
+func (f *ForkedTestingApi) forkRoutePreviewRule(c *models.ReqContext, body apimodels.PreviewRulePayload) response.Response {
+	return f.grafana.RoutePreviewRule(c, body)
+}
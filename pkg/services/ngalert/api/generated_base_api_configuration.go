@@ -22,6 +22,7 @@ import (
 type ConfigurationApiForkingService interface {
 	RouteDeleteNGalertConfig(*models.ReqContext) response.Response
 	RouteGetAlertmanagers(*models.ReqContext) response.Response
+	RouteGetEvaluationSamples(*models.ReqContext) response.Response
 	RouteGetNGalertConfig(*models.ReqContext) response.Response
 	RoutePostNGalertConfig(*models.ReqContext) response.Response
 }
@@ -29,6 +30,7 @@ type ConfigurationApiForkingService interface {
 type ConfigurationApiService interface {
 	RouteDeleteNGalertConfig(*models.ReqContext) response.Response
 	RouteGetAlertmanagers(*models.ReqContext) response.Response
+	RouteGetEvaluationSamples(*models.ReqContext) response.Response
 	RouteGetNGalertConfig(*models.ReqContext) response.Response
 	RoutePostNGalertConfig(*models.ReqContext, apimodels.PostableNGalertConfig) response.Response
 }
@@ -41,6 +43,10 @@ func (f *ForkedConfigurationApi) RouteGetAlertmanagers(ctx *models.ReqContext) r
 	return f.forkRouteGetAlertmanagers(ctx)
 }
 
+func (f *ForkedConfigurationApi) RouteGetEvaluationSamples(ctx *models.ReqContext) response.Response {
+	return f.forkRouteGetEvaluationSamples(ctx)
+}
+
 func (f *ForkedConfigurationApi) RouteGetNGalertConfig(ctx *models.ReqContext) response.Response {
 	return f.forkRouteGetNGalertConfig(ctx)
 }
@@ -73,6 +79,15 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApiForkingSer
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/rules/{RuleUID}/evaluations"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/rules/{RuleUID}/evaluations",
+				srv.RouteGetEvaluationSamples,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/ngalert/admin_config"),
 			metrics.Instrument(
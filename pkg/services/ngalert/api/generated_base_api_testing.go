@@ -21,11 +21,13 @@ import (
 
 type TestingApiForkingService interface {
 	RouteEvalQueries(*models.ReqContext) response.Response
+	RoutePreviewRule(*models.ReqContext) response.Response
 	RouteTestRuleConfig(*models.ReqContext) response.Response
 }
 
 type TestingApiService interface {
 	RouteEvalQueries(*models.ReqContext, apimodels.EvalQueriesPayload) response.Response
+	RoutePreviewRule(*models.ReqContext, apimodels.PreviewRulePayload) response.Response
 	RouteTestRuleConfig(*models.ReqContext, apimodels.TestRulePayload) response.Response
 }
 
@@ -37,6 +39,14 @@ func (f *ForkedTestingApi) RouteEvalQueries(ctx *models.ReqContext) response.Res
 	return f.forkRouteEvalQueries(ctx, conf)
 }
 
+func (f *ForkedTestingApi) RoutePreviewRule(ctx *models.ReqContext) response.Response {
+	conf := apimodels.PreviewRulePayload{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.forkRoutePreviewRule(ctx, conf)
+}
+
 func (f *ForkedTestingApi) RouteTestRuleConfig(ctx *models.ReqContext) response.Response {
 	conf := apimodels.TestRulePayload{}
 	if err := web.Bind(ctx.Req, &conf); err != nil {
@@ -56,6 +66,15 @@ func (api *API) RegisterTestingApiEndpoints(srv TestingApiForkingService, m *met
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/rule/preview"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/rule/preview",
+				srv.RoutePreviewRule,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/rule/test/{Recipient}"),
 			metrics.Instrument(
@@ -1,6 +1,11 @@
 package definitions
 
-import v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+import (
+	"encoding/json"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
 
 // swagger:route GET /api/v1/ngalert/alertmanagers configuration RouteGetAlertmanagers
 //
@@ -46,6 +51,23 @@ import v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 //       200: Ack
 //       500: Failure
 
+// swagger:route GET /api/v1/ngalert/rules/{RuleUID}/evaluations configuration RouteGetEvaluationSamples
+//
+// Get the recent evaluations of an alert rule of the user's organization, the most recent first.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: EvaluationSamples
+//       404: NotFound
+
+// swagger:parameters RouteGetEvaluationSamples
+type RuleUIDParam struct {
+	// in:path
+	RuleUID string
+}
+
 // swagger:parameters RoutePostNGalertConfig
 type NGalertConfig struct {
 	// in:body
@@ -67,3 +89,34 @@ type GettableAlertmanagers struct {
 	Status string                 `json:"status"`
 	Data   v1.AlertManagersResult `json:"data"`
 }
+
+// swagger:model
+type EvaluationSamples []EvaluationSample
+
+// EvaluationSample is an evaluation of an alert rule, with the time ranges of the queries it ran and the
+// values and states it produced.
+type EvaluationSample struct {
+	EvaluatedAt time.Time                `json:"evaluatedAt"`
+	RuleVersion int64                    `json:"ruleVersion"`
+	Attempt     int64                    `json:"attempt"`
+	Duration    string                   `json:"duration"`
+	Error       string                   `json:"error,omitempty"`
+	Queries     []EvaluationSampleQuery  `json:"queries"`
+	Results     []EvaluationSampleResult `json:"results"`
+}
+
+type EvaluationSampleQuery struct {
+	RefID         string          `json:"refId"`
+	DatasourceUID string          `json:"datasourceUid"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	Model         json.RawMessage `json:"model"`
+}
+
+type EvaluationSampleResult struct {
+	Labels           map[string]string   `json:"labels"`
+	State            string              `json:"state"`
+	Error            string              `json:"error,omitempty"`
+	EvaluationString string              `json:"evaluationString,omitempty"`
+	Values           map[string]*float64 `json:"values,omitempty"`
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/promql"
 )
 
@@ -38,6 +39,21 @@ import (
 //     Responses:
 //       200: EvalQueriesResponse
 
+// swagger:route Post /api/v1/rule/preview testing RoutePreviewRule
+//
+// Preview the alert instances a draft Grafana managed rule would have produced over a time range, such as the time
+// range of the panel it is created from, with the changes of their states to render as annotations.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: PreviewRuleResponse
+//       400: ValidationError
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -70,6 +86,56 @@ type EvalQueriesPayload struct {
 	Now  time.Time           `json:"now"`
 }
 
+// swagger:parameters RoutePreviewRule
+type PreviewRuleRequest struct {
+	// in:body
+	Body PreviewRulePayload
+}
+
+// swagger:model
+type PreviewRulePayload struct {
+	// Rule is the draft rule, its grafana_alert is required
+	Rule PostableExtendedRuleNode `json:"rule"`
+	// Interval is the evaluation interval of the rule group, one minute by default
+	Interval model.Duration `json:"interval,omitempty"`
+	// DashboardUID and PanelID link the rule to the panel it is created from
+	DashboardUID string `json:"dashboardUid,omitempty"`
+	PanelID      int64  `json:"panelId,omitempty"`
+	// From and To are the time range of the evaluations, the last hour by default
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to,omitempty"`
+}
+
+// swagger:model
+type PreviewRuleResponse struct {
+	// Interval is the time between two evaluations, the interval of the rule widened so that the time range is
+	// covered by at most 100 evaluations
+	Interval    model.Duration         `json:"interval"`
+	Evaluations int                    `json:"evaluations"`
+	Instances   []PreviewAlertInstance `json:"instances"`
+}
+
+// PreviewAlertInstance is an alert instance the rule would have produced, with its state and annotations after the
+// last evaluation.
+type PreviewAlertInstance struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	State       string            `json:"state"`
+	Value       string            `json:"value,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	// StateChanges are the changes of the state of the instance, in the format of the state history annotations.
+	// TimeEnd is the time of the next change, or of the last evaluation.
+	StateChanges []PreviewStateChange `json:"stateChanges"`
+}
+
+type PreviewStateChange struct {
+	Time      time.Time `json:"time"`
+	TimeEnd   time.Time `json:"timeEnd"`
+	PrevState string    `json:"prevState"`
+	NewState  string    `json:"newState"`
+	Text      string    `json:"text"`
+}
+
 func (p *TestRulePayload) UnmarshalJSON(b []byte) error {
 	type plain TestRulePayload
 	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
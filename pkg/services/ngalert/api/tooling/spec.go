@@ -0,0 +1,11 @@
+// Package tooling embeds the OpenAPI specification of the alerting API generated from its definitions.
+package tooling
+
+import (
+	_ "embed"
+)
+
+// Spec is the OpenAPI specification of the alerting API, post.json generated by the Makefile
+//
+//go:embed post.json
+var Spec []byte
@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/resourcelabels"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -40,7 +41,8 @@ const (
 
 func ProvideService(cfg *setting.Cfg, dataSourceCache datasources.CacheService, routeRegister routing.RouteRegister,
 	sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore, expressionService *expr.Service, dataProxy *datasourceproxy.DataSourceProxyService,
-	quotaService *quota.QuotaService, secretsService secrets.Service, m *metrics.NGAlert) (*AlertNG, error) {
+	quotaService *quota.QuotaService, secretsService secrets.Service, m *metrics.NGAlert,
+	resourceLabels *resourcelabels.Service) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:               cfg,
 		DataSourceCache:   dataSourceCache,
@@ -52,6 +54,7 @@ func ProvideService(cfg *setting.Cfg, dataSourceCache datasources.CacheService,
 		QuotaService:      quotaService,
 		SecretsService:    secretsService,
 		Metrics:           m,
+		ResourceLabels:    resourceLabels,
 		Log:               log.New("ngalert"),
 	}
 
@@ -78,6 +81,7 @@ type AlertNG struct {
 	QuotaService      *quota.QuotaService
 	SecretsService    secrets.Service
 	Metrics           *metrics.NGAlert
+	ResourceLabels    *resourcelabels.Service
 	Log               log.Logger
 	schedule          schedule.ScheduleService
 	stateManager      *state.Manager
@@ -129,6 +133,7 @@ func (ng *AlertNG) init() error {
 		AdminConfigPollInterval: ng.Cfg.UnifiedAlerting.AdminConfigPollInterval,
 		DisabledOrgs:            ng.Cfg.UnifiedAlerting.DisabledOrgs,
 		MinRuleInterval:         ng.getRuleMinInterval(),
+		EvaluationDebugBuffer:   ng.Cfg.UnifiedAlerting.EvaluationDebugBufferSize,
 	}
 
 	appUrl, err := url.Parse(ng.Cfg.AppURL)
@@ -157,6 +162,7 @@ func (ng *AlertNG) init() error {
 		AdminConfigStore:     store,
 		MultiOrgAlertmanager: ng.MultiOrgAlertmanager,
 		StateManager:         ng.stateManager,
+		ResourceLabels:       ng.ResourceLabels,
 	}
 	api.RegisterAPIEndpoints(ng.Metrics.GetAPIMetrics())
 
@@ -189,6 +195,14 @@ func (ng *AlertNG) IsDisabled() bool {
 	return !ng.Cfg.UnifiedAlerting.IsEnabled()
 }
 
+// SchedulerLag returns how late the alert rule scheduler is in evaluating the rules, zero when it is not running.
+func (ng *AlertNG) SchedulerLag() time.Duration {
+	if ng.schedule == nil {
+		return 0
+	}
+	return ng.schedule.Lag()
+}
+
 // getRuleDefaultIntervalSeconds returns the default rule interval if the interval is not set.
 // If this constant (1 minute) is lower than the configured minimum evaluation interval then
 // this configuration is returned.
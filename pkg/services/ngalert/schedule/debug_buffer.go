@@ -0,0 +1,132 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// evaluationDebugBuffer keeps the most recent evaluations of each alert rule in memory, so that what a rule saw and
+// decided at a given time can be inspected after the fact without running its queries again.
+// A nil buffer is disabled and records nothing.
+type evaluationDebugBuffer struct {
+	mtx     sync.Mutex
+	size    int
+	samples map[models.AlertRuleKey]*sampleRing
+}
+
+// sampleRing is a fixed size ring of evaluation samples, next is the position of the next sample to write.
+type sampleRing struct {
+	samples []apimodels.EvaluationSample
+	next    int
+}
+
+func newEvaluationDebugBuffer(size int) *evaluationDebugBuffer {
+	if size <= 0 {
+		return nil
+	}
+	return &evaluationDebugBuffer{
+		size:    size,
+		samples: make(map[models.AlertRuleKey]*sampleRing),
+	}
+}
+
+// add records an evaluation of the alert rule, overwriting its oldest one once the buffer is full.
+func (b *evaluationDebugBuffer) add(key models.AlertRuleKey, sample apimodels.EvaluationSample) {
+	if b == nil {
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	r, ok := b.samples[key]
+	if !ok {
+		r = &sampleRing{samples: make([]apimodels.EvaluationSample, 0, b.size)}
+		b.samples[key] = r
+	}
+	if len(r.samples) < b.size {
+		r.samples = append(r.samples, sample)
+	} else {
+		r.samples[r.next] = sample
+	}
+	r.next = (r.next + 1) % b.size
+}
+
+// get returns the recorded evaluations of the alert rule, the most recent first.
+func (b *evaluationDebugBuffer) get(key models.AlertRuleKey) []apimodels.EvaluationSample {
+	if b == nil {
+		return nil
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	r, ok := b.samples[key]
+	if !ok {
+		return nil
+	}
+	result := make([]apimodels.EvaluationSample, 0, len(r.samples))
+	for i := 1; i <= len(r.samples); i++ {
+		result = append(result, r.samples[(r.next-i+len(r.samples))%len(r.samples)])
+	}
+	return result
+}
+
+// del drops the recorded evaluations of a deleted alert rule.
+func (b *evaluationDebugBuffer) del(key models.AlertRuleKey) {
+	if b == nil {
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	delete(b.samples, key)
+}
+
+// newEvaluationSample captures the queries of the alert rule as evaluated at the given time and the results of the
+// evaluation, including the values of its reduce and math expressions.
+func newEvaluationSample(rule *models.AlertRule, attempt int64, now time.Time, dur time.Duration, results eval.Results, err error) apimodels.EvaluationSample {
+	sample := apimodels.EvaluationSample{
+		EvaluatedAt: now,
+		RuleVersion: rule.Version,
+		Attempt:     attempt,
+		Duration:    dur.String(),
+		Queries:     make([]apimodels.EvaluationSampleQuery, 0, len(rule.Data)),
+		Results:     make([]apimodels.EvaluationSampleResult, 0, len(results)),
+	}
+	if err != nil {
+		sample.Error = err.Error()
+	}
+
+	for _, q := range rule.Data {
+		timeRange := q.RelativeTimeRange.ToTimeRange(now)
+		sample.Queries = append(sample.Queries, apimodels.EvaluationSampleQuery{
+			RefID:         q.RefID,
+			DatasourceUID: q.DatasourceUID,
+			From:          timeRange.From,
+			To:            timeRange.To,
+			Model:         q.Model,
+		})
+	}
+
+	for _, r := range results {
+		result := apimodels.EvaluationSampleResult{
+			Labels:           r.Instance,
+			State:            r.State.String(),
+			EvaluationString: r.EvaluationString,
+		}
+		if r.Error != nil {
+			result.Error = r.Error.Error()
+		}
+		if len(r.Values) > 0 {
+			result.Values = make(map[string]*float64, len(r.Values))
+			for refID, v := range r.Values {
+				result.Values[refID] = v.Value
+			}
+		}
+		sample.Results = append(sample.Results, result)
+	}
+
+	return sample
+}
@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestEvaluationDebugBuffer(t *testing.T) {
+	key := models.AlertRuleKey{OrgID: 1, UID: "rule"}
+	sample := func(version int64) apimodels.EvaluationSample {
+		return apimodels.EvaluationSample{RuleVersion: version}
+	}
+	versions := func(samples []apimodels.EvaluationSample) []int64 {
+		result := make([]int64, 0, len(samples))
+		for _, s := range samples {
+			result = append(result, s.RuleVersion)
+		}
+		return result
+	}
+
+	t.Run("should record nothing when disabled", func(t *testing.T) {
+		b := newEvaluationDebugBuffer(0)
+		require.Nil(t, b)
+		b.add(key, sample(1))
+		require.Nil(t, b.get(key))
+		b.del(key)
+	})
+
+	t.Run("should return the most recent evaluations first and drop the oldest ones", func(t *testing.T) {
+		b := newEvaluationDebugBuffer(3)
+		b.add(key, sample(1))
+		b.add(key, sample(2))
+		require.Equal(t, []int64{2, 1}, versions(b.get(key)))
+
+		b.add(key, sample(3))
+		b.add(key, sample(4))
+		b.add(key, sample(5))
+		require.Equal(t, []int64{5, 4, 3}, versions(b.get(key)))
+
+		other := models.AlertRuleKey{OrgID: 2, UID: "rule"}
+		require.Nil(t, b.get(other))
+
+		b.del(key)
+		require.Nil(t, b.get(key))
+	})
+}
+
+func TestNewEvaluationSample(t *testing.T) {
+	now := time.Date(2021, 11, 1, 14, 2, 0, 0, time.UTC)
+	value := 42.0
+	rule := &models.AlertRule{
+		Version: 3,
+		Data: []models.AlertQuery{{
+			RefID:             "A",
+			DatasourceUID:     "prometheus",
+			RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(time.Hour)},
+			Model:             []byte(`{"expr":"up"}`),
+		}},
+	}
+	results := eval.Results{{
+		Instance:         data.Labels{"job": "api"},
+		State:            eval.Alerting,
+		EvaluationString: "[ var='B' labels={job=api} value=42 ]",
+		Values:           map[string]eval.NumberValueCapture{"B": {Var: "B", Value: &value}},
+	}}
+
+	sample := newEvaluationSample(rule, 1, now, time.Second, results, nil)
+	require.Equal(t, now, sample.EvaluatedAt)
+	require.Equal(t, int64(3), sample.RuleVersion)
+	require.Equal(t, int64(1), sample.Attempt)
+	require.Equal(t, "1s", sample.Duration)
+	require.Empty(t, sample.Error)
+	require.Equal(t, []apimodels.EvaluationSampleQuery{{
+		RefID:         "A",
+		DatasourceUID: "prometheus",
+		From:          now.Add(-time.Hour),
+		To:            now,
+		Model:         []byte(`{"expr":"up"}`),
+	}}, sample.Queries)
+	require.Equal(t, []apimodels.EvaluationSampleResult{{
+		Labels:           map[string]string{"job": "api"},
+		State:            "Alerting",
+		EvaluationString: "[ var='B' labels={job=api} value=42 ]",
+		Values:           map[string]*float64{"B": &value},
+	}}, sample.Results)
+
+	sample = newEvaluationSample(rule, 0, now, time.Second, nil, errors.New("failed"))
+	require.Equal(t, "failed", sample.Error)
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -40,6 +42,14 @@ type ScheduleService interface {
 	// organization.
 	DroppedAlertmanagersFor(orgID int64) []*url.URL
 
+	// EvaluationSamples returns the recent evaluations of the alert rule, the
+	// most recent first.
+	EvaluationSamples(key models.AlertRuleKey) []apimodels.EvaluationSample
+
+	// Lag returns how late the scheduler is in processing the ticks of the
+	// base interval, zero when it is not running.
+	Lag() time.Duration
+
 	// the following are used by tests only used for tests
 	evalApplied(models.AlertRuleKey, time.Time)
 	stopApplied(models.AlertRuleKey)
@@ -47,6 +57,10 @@ type ScheduleService interface {
 }
 
 type schedule struct {
+	// lastTick is the last tick processed in unix nanoseconds, zero when the scheduler is not running. It is accessed
+	// atomically and kept first for its alignment on 32-bit platforms.
+	lastTick int64
+
 	// base tick rate (fastest possible configured check)
 	baseInterval time.Duration
 
@@ -93,6 +107,9 @@ type schedule struct {
 	adminConfigPollInterval time.Duration
 	disabledOrgs            map[int64]struct{}
 	minRuleInterval         time.Duration
+
+	// debugBuffer keeps the recent evaluations of each alert rule, it is nil when disabled
+	debugBuffer *evaluationDebugBuffer
 }
 
 // SchedulerCfg is the scheduler configuration.
@@ -113,6 +130,7 @@ type SchedulerCfg struct {
 	AdminConfigPollInterval time.Duration
 	DisabledOrgs            map[int64]struct{}
 	MinRuleInterval         time.Duration
+	EvaluationDebugBuffer   int
 }
 
 // NewScheduler returns a new schedule.
@@ -143,6 +161,7 @@ func NewScheduler(cfg SchedulerCfg, expressionService *expr.Service, appURL *url
 		adminConfigPollInterval: cfg.AdminConfigPollInterval,
 		disabledOrgs:            cfg.DisabledOrgs,
 		minRuleInterval:         cfg.MinRuleInterval,
+		debugBuffer:             newEvaluationDebugBuffer(cfg.EvaluationDebugBuffer),
 	}
 	return &sch
 }
@@ -152,6 +171,7 @@ func (sch *schedule) Pause() error {
 		return fmt.Errorf("scheduler is not initialised")
 	}
 	sch.heartbeat.Pause()
+	atomic.StoreInt64(&sch.lastTick, 0)
 	sch.log.Info("alert rule scheduler paused", "now", sch.clock.Now())
 	return nil
 }
@@ -307,6 +327,25 @@ func (sch *schedule) DroppedAlertmanagersFor(orgID int64) []*url.URL {
 	return s.DroppedAlertmanagers()
 }
 
+// EvaluationSamples returns the recent evaluations of an alert rule, the most recent first.
+func (sch *schedule) EvaluationSamples(key models.AlertRuleKey) []apimodels.EvaluationSample {
+	return sch.debugBuffer.get(key)
+}
+
+// Lag returns how late the scheduler is in processing the ticks of the base interval. The ticks are never dropped, so
+// the lag grows while the evaluation loop takes longer than the base interval to process them.
+func (sch *schedule) Lag() time.Duration {
+	last := atomic.LoadInt64(&sch.lastTick)
+	if last == 0 {
+		return 0
+	}
+	lag := sch.clock.Now().Sub(time.Unix(0, last).Add(sch.baseInterval))
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
 func (sch *schedule) adminConfigSync(ctx context.Context) error {
 	for {
 		select {
@@ -333,6 +372,7 @@ func (sch *schedule) ruleEvaluationLoop(ctx context.Context) error {
 	for {
 		select {
 		case tick := <-sch.heartbeat.C:
+			atomic.StoreInt64(&sch.lastTick, tick.UnixNano())
 			tickNum := tick.Unix() / int64(sch.baseInterval.Seconds())
 			disabledOrgs := make([]int64, 0, len(sch.disabledOrgs))
 			for disabledOrg := range sch.disabledOrgs {
@@ -413,8 +453,10 @@ func (sch *schedule) ruleEvaluationLoop(ctx context.Context) error {
 					continue
 				}
 				ruleInfo.stop()
+				sch.debugBuffer.del(key)
 			}
 		case <-ctx.Done():
+			atomic.StoreInt64(&sch.lastTick, 0)
 			waitErr := dispatcherGroup.Wait()
 
 			orgIds, err := sch.instanceStore.FetchOrgIds()
@@ -464,6 +506,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 		dur := sch.clock.Now().Sub(start)
 		evalTotal.Inc()
 		evalDuration.Observe(dur.Seconds())
+		sch.debugBuffer.add(key, newEvaluationSample(alertRule, attempt, ctx.now, dur, results, err))
 		if err != nil {
 			evalTotalFailures.Inc()
 			// consider saving alert instance on error
@@ -21,6 +21,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
@@ -342,6 +343,14 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 				require.Equal(t, evalState.String(), string(cmd.State))
 				require.Equal(t, s.Labels, data.Labels(cmd.Labels))
 			})
+			t.Run("it should record the evaluation in the debug buffer", func(t *testing.T) {
+				samples := sch.EvaluationSamples(rule.GetKey())
+				require.Len(t, samples, 1)
+				require.Equal(t, expectedTime, samples[0].EvaluatedAt)
+				require.Equal(t, rule.Version, samples[0].RuleVersion)
+				require.Len(t, samples[0].Queries, len(rule.Data))
+				require.Len(t, samples[0].Results, 1)
+			})
 			t.Run("it reports metrics", func(t *testing.T) {
 				// duration metric has 0 values because of mocked clock that do not advance
 				expectedMetric := fmt.Sprintf(
@@ -664,6 +673,7 @@ func setupScheduler(t *testing.T, rs store.RuleStore, is store.InstanceStore, ac
 		Logger:                  logger,
 		Metrics:                 m.GetSchedulerMetrics(),
 		AdminConfigPollInterval: 10 * time.Minute, // do not poll in unit tests.
+		EvaluationDebugBuffer:   10,
 	}
 	st := state.NewManager(schedCfg.Logger, m.GetStateMetrics(), nil, rs, is)
 	appUrl := &url.URL{
@@ -760,3 +770,23 @@ func CreateTestAlertRule(t *testing.T, dbstore *fakeRuleStore, intervalSeconds i
 	t.Logf("alert definition: %v with interval: %d created", rule.GetKey(), rule.IntervalSeconds)
 	return rule
 }
+
+func TestSchedule_Lag(t *testing.T) {
+	mockedClock := clock.NewMock()
+	mockedClock.Set(time.Date(2022, 1, 12, 9, 30, 0, 0, time.UTC))
+	sch := &schedule{clock: mockedClock, baseInterval: 10 * time.Second}
+
+	require.Zero(t, sch.Lag(), "the lag should be zero when the scheduler is not running")
+
+	sch.lastTick = mockedClock.Now().UnixNano()
+	mockedClock.Add(5 * time.Second)
+	require.Zero(t, sch.Lag(), "the lag should be zero before the next tick is due")
+
+	mockedClock.Add(20 * time.Second)
+	require.Equal(t, 15*time.Second, sch.Lag())
+
+	sch.heartbeat = &alerting.Ticker{}
+	sch.log = log.New("ngalert.scheduler.test")
+	require.NoError(t, sch.Pause())
+	require.Zero(t, sch.Lag(), "the lag should be zero when the scheduler is paused")
+}
@@ -410,6 +410,15 @@ func (repo *FakeAnnotationsRepo) Save(item *annotations.Item) error {
 
 	return nil
 }
+
+func (repo *FakeAnnotationsRepo) SaveMany(items []*annotations.Item) error {
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+	repo.items = append(repo.items, items...)
+
+	return nil
+}
+
 func (repo *FakeAnnotationsRepo) Update(item *annotations.Item) error {
 	return nil
 }
@@ -419,6 +428,10 @@ func (repo *FakeAnnotationsRepo) Find(query *annotations.ItemQuery) ([]*annotati
 	return annotations, nil
 }
 
+func (repo *FakeAnnotationsRepo) Stream(_ context.Context, _ *annotations.ItemQuery, fn func(item *annotations.ItemDTO) error) error {
+	return fn(&annotations.ItemDTO{Id: 1})
+}
+
 func (repo *FakeAnnotationsRepo) FindTags(query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
 	result := annotations.FindTagsResult{
 		Tags: []*annotations.TagsDTO{},
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -141,18 +143,38 @@ func (st *Manager) RemoveByRuleUID(orgID int64, ruleUID string) {
 func (st *Manager) ProcessEvalResults(ctx context.Context, alertRule *ngModels.AlertRule, results eval.Results) []*State {
 	st.log.Debug("state manager processing evaluation results", "uid", alertRule.UID, "resultCount", len(results))
 	var states []*State
+	var transitions []stateTransition
 	processedResults := make(map[string]*State, len(results))
 	for _, result := range results {
-		s := st.setNextState(ctx, alertRule, result)
+		s, oldState := st.setNextState(ctx, alertRule, result)
 		states = append(states, s)
 		processedResults[s.CacheId] = s
+		if oldState != s.State {
+			transitions = append(transitions, stateTransition{result: result, oldState: oldState, newState: s.State})
+		}
+	}
+	if len(transitions) > 0 {
+		go st.createAlertAnnotations(ctx, alertRule, transitions)
+	}
+	for _, t := range transitions {
+		if t.newState == eval.Alerting {
+			st.publishAlertFiring(ctx, alertRule, t.result.EvaluatedAt)
+			break
+		}
 	}
 	st.staleResultsHandler(alertRule, processedResults)
 	return states
 }
 
-// Set the current state based on evaluation results
-func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result) *State {
+// stateTransition is a change of the state of an alert instance, recorded as an annotation
+type stateTransition struct {
+	result   eval.Result
+	oldState eval.State
+	newState eval.State
+}
+
+// Set the current state based on evaluation results, and return it with the previous state
+func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result) (*State, eval.State) {
 	currentState := st.getOrCreate(ctx, alertRule, result)
 
 	currentState.LastEvaluationTime = result.EvaluatedAt
@@ -184,10 +206,7 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 	currentState.Resolved = oldState == eval.Alerting && currentState.State == eval.Normal
 
 	st.set(currentState)
-	if oldState != currentState.State {
-		go st.createAlertAnnotation(ctx, currentState.State, alertRule, result, oldState)
-	}
-	return currentState
+	return currentState, oldState
 }
 
 func (st *Manager) GetAll(orgID int64) []*State {
@@ -233,25 +252,18 @@ func translateInstanceState(state ngModels.InstanceStateType) eval.State {
 	}
 }
 
-func (st *Manager) createAlertAnnotation(ctx context.Context, new eval.State, alertRule *ngModels.AlertRule, result eval.Result, oldState eval.State) {
-	st.log.Debug("alert state changed creating annotation", "alertRuleUID", alertRule.UID, "newState", new.String(), "oldState", oldState.String())
-
-	annotationText := fmt.Sprintf("%s {%s} - %s", alertRule.Title, result.Instance.String(), new.String())
-
-	item := &annotations.Item{
-		AlertId:   alertRule.ID,
-		OrgId:     alertRule.OrgID,
-		PrevState: oldState.String(),
-		NewState:  new.String(),
-		Text:      annotationText,
-		Epoch:     result.EvaluatedAt.UnixNano() / int64(time.Millisecond),
-	}
+// createAlertAnnotations records the state transitions of the alert instances of an evaluation as annotations,
+// saved together with batched inserts
+func (st *Manager) createAlertAnnotations(ctx context.Context, alertRule *ngModels.AlertRule, transitions []stateTransition) {
+	st.log.Debug("alert states changed creating annotations", "alertRuleUID", alertRule.UID, "count", len(transitions))
 
+	var dashboardId, panelId int64
 	dashUid, ok := alertRule.Annotations[ngModels.DashboardUIDAnnotation]
 	if ok {
 		panelUid := alertRule.Annotations[ngModels.PanelIDAnnotation]
 
-		panelId, err := strconv.ParseInt(panelUid, 10, 64)
+		var err error
+		panelId, err = strconv.ParseInt(panelUid, 10, 64)
 		if err != nil {
 			st.log.Error("error parsing panelUID for alert annotation", "panelUID", panelUid, "alertRuleUID", alertRule.UID, "error", err.Error())
 			return
@@ -267,18 +279,44 @@ func (st *Manager) createAlertAnnotation(ctx context.Context, new eval.State, al
 			st.log.Error("error getting dashboard for alert annotation", "dashboardUID", dashUid, "alertRuleUID", alertRule.UID, "error", err.Error())
 			return
 		}
+		dashboardId = query.Result.Id
+	}
 
-		item.PanelId = panelId
-		item.DashboardId = query.Result.Id
+	items := make([]*annotations.Item, 0, len(transitions))
+	for _, t := range transitions {
+		items = append(items, &annotations.Item{
+			AlertId:     alertRule.ID,
+			OrgId:       alertRule.OrgID,
+			DashboardId: dashboardId,
+			PanelId:     panelId,
+			PrevState:   t.oldState.String(),
+			NewState:    t.newState.String(),
+			Text:        fmt.Sprintf("%s {%s} - %s", alertRule.Title, t.result.Instance.String(), t.newState.String()),
+			Epoch:       t.result.EvaluatedAt.UnixNano() / int64(time.Millisecond),
+		})
 	}
 
 	annotationRepo := annotations.GetRepository()
-	if err := annotationRepo.Save(item); err != nil {
-		st.log.Error("error saving alert annotation", "alertRuleUID", alertRule.UID, "error", err.Error())
+	if err := annotationRepo.SaveMany(items); err != nil {
+		st.log.Error("error saving alert annotations", "alertRuleUID", alertRule.UID, "error", err.Error())
 		return
 	}
 }
 
+// publishAlertFiring publishes that the rule is firing, once per evaluation whatever the number of its alert
+// instances which started firing
+func (st *Manager) publishAlertFiring(ctx context.Context, alertRule *ngModels.AlertRule, evaluatedAt time.Time) {
+	if err := bus.Publish(ctx, &events.AlertFiring{
+		Timestamp:  evaluatedAt,
+		OrgID:      alertRule.OrgID,
+		AlertID:    alertRule.ID,
+		AlertUID:   alertRule.UID,
+		AlertTitle: alertRule.Title,
+	}); err != nil {
+		st.log.Error("error publishing alert firing event", "alertRuleUID", alertRule.UID, "error", err.Error())
+	}
+}
+
 func (st *Manager) staleResultsHandler(alertRule *ngModels.AlertRule, states map[string]*State) {
 	allStates := st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID)
 	for _, s := range allStates {
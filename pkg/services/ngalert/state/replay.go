@@ -0,0 +1,56 @@
+package state
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// Transition is a change of the state of an alert instance, identified by its cache id. Instance holds the labels
+// of the evaluation result, as in the text of the state history annotations.
+type Transition struct {
+	CacheId     string
+	Instance    data.Labels
+	EvaluatedAt time.Time
+	OldState    eval.State
+	NewState    eval.State
+}
+
+// Replay runs the results of consecutive evaluations of an alert rule, oldest first, through the state changes of its
+// alert instances starting from an empty cache. Nothing is saved, sent or annotated: it returns the states of the
+// alert instances after the last evaluation, sorted by cache id, and the transitions of their states.
+func Replay(ctx context.Context, logger log.Logger, externalURL *url.URL, alertRule *ngModels.AlertRule, evaluations []eval.Results) ([]*State, []Transition) {
+	st := &Manager{
+		cache: newCache(logger, nil, externalURL),
+		log:   logger,
+	}
+
+	var transitions []Transition
+	for _, results := range evaluations {
+		for _, result := range results {
+			s, oldState := st.setNextState(ctx, alertRule, result)
+			if oldState != s.State {
+				transitions = append(transitions, Transition{
+					CacheId:     s.CacheId,
+					Instance:    result.Instance,
+					EvaluatedAt: result.EvaluatedAt,
+					OldState:    oldState,
+					NewState:    s.State,
+				})
+			}
+		}
+	}
+
+	states := st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID)
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].CacheId < states[j].CacheId
+	})
+	return states, transitions
+}
@@ -0,0 +1,83 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	rule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		For:             30 * time.Second,
+		IntervalSeconds: 60,
+		Labels:          map[string]string{"severity": "critical"},
+		Annotations:     map[string]string{"summary": "{{ $labels.instance }} is down"},
+		NoDataState:     models.NoData,
+		ExecErrState:    models.AlertingErrState,
+	}
+
+	results := func(i int, instances map[string]eval.State) eval.Results {
+		res := eval.Results{}
+		for instance, s := range instances {
+			res = append(res, eval.Result{
+				Instance:    data.Labels{"instance": instance},
+				State:       s,
+				EvaluatedAt: start.Add(time.Duration(i) * time.Minute),
+			})
+		}
+		return res
+	}
+	evaluations := []eval.Results{
+		results(0, map[string]eval.State{"a": eval.Normal, "b": eval.Alerting}),
+		results(1, map[string]eval.State{"a": eval.Alerting, "b": eval.Alerting}),
+		results(2, map[string]eval.State{"a": eval.Alerting, "b": eval.Normal}),
+		results(3, map[string]eval.State{"a": eval.Normal}),
+	}
+
+	states, transitions := state.Replay(context.Background(), log.New("test"), nil, rule, evaluations)
+
+	require.Len(t, states, 2)
+	assert.Equal(t, "a", states[0].Labels["instance"])
+	assert.Equal(t, "critical", states[0].Labels["severity"])
+	assert.Equal(t, "a is down", states[0].Annotations["summary"])
+	assert.Equal(t, eval.Normal, states[0].State)
+	assert.Equal(t, "b", states[1].Labels["instance"])
+	assert.Equal(t, eval.Normal, states[1].State)
+
+	type change struct {
+		instance string
+		minute   int
+		old, new eval.State
+	}
+	var changes []change
+	for _, tr := range transitions {
+		changes = append(changes, change{
+			instance: tr.Instance["instance"],
+			minute:   int(tr.EvaluatedAt.Sub(start) / time.Minute),
+			old:      tr.OldState,
+			new:      tr.NewState,
+		})
+	}
+	// the instances are pending for the duration of the rule before alerting
+	assert.ElementsMatch(t, []change{
+		{instance: "b", minute: 0, old: eval.Normal, new: eval.Pending},
+		{instance: "a", minute: 1, old: eval.Normal, new: eval.Pending},
+		{instance: "b", minute: 1, old: eval.Pending, new: eval.Alerting},
+		{instance: "a", minute: 2, old: eval.Pending, new: eval.Alerting},
+		{instance: "b", minute: 2, old: eval.Alerting, new: eval.Normal},
+		{instance: "a", minute: 3, old: eval.Alerting, new: eval.Normal},
+	}, changes)
+}
@@ -0,0 +1,212 @@
+package oauthtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+	// logoutTokenMaxAge is how long after they are issued the logout tokens are accepted
+	logoutTokenMaxAge = 5 * time.Minute
+	// keySetExpiration is how long the key sets of the providers are cached
+	keySetExpiration = 10 * time.Minute
+	// keySetMinRefreshInterval limits how often a key set is fetched again when a logout token is signed with an
+	// unknown key, usually because the provider rotated its keys
+	keySetMinRefreshInterval = time.Minute
+)
+
+var errInvalidLogoutToken = errors.New("invalid logout token")
+
+type logoutClaims struct {
+	jwt.Claims
+	SessionId string                     `json:"sid"`
+	Events    map[string]json.RawMessage `json:"events"`
+	Nonce     *string                    `json:"nonce"`
+}
+
+type cachedKeySet struct {
+	keys    jose.JSONWebKeySet
+	fetched time.Time
+}
+
+func (s *LogoutService) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Post("/login/:name/backchannel-logout", routing.Wrap(s.backchannelLogoutHandler))
+}
+
+// POST /login/:name/backchannel-logout
+//
+// Ends the Grafana sessions of the single sign-on session logged out by the provider, as specified by OpenID Connect
+// Back-Channel Logout.
+func (s *LogoutService) backchannelLogoutHandler(c *models.ReqContext) response.Response {
+	name := web.Params(c.Req)[":name"]
+	info := s.socialService.GetOAuthInfoProvider(name)
+	if info == nil || !info.BackchannelLogout {
+		return response.Error(http.StatusNotFound, "Back-channel logout is not enabled", nil).SetHeader("Cache-Control", "no-store")
+	}
+
+	count, err := s.BackchannelLogout(c.Req.Context(), name, c.Req.FormValue("logout_token"))
+	if err != nil {
+		if errors.Is(err, errInvalidLogoutToken) {
+			return response.Error(http.StatusBadRequest, "Invalid logout token", err).SetHeader("Cache-Control", "no-store")
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to end the sessions", err).SetHeader("Cache-Control", "no-store")
+	}
+	s.log.Info("Back-channel logout", "provider", name, "sessions", count)
+	return response.Empty(http.StatusOK).SetHeader("Cache-Control", "no-store")
+}
+
+// BackchannelLogout revokes the Grafana sessions of the single sign-on session of the logout token sent by the
+// provider name, and returns the number of revoked sessions
+func (s *LogoutService) BackchannelLogout(ctx context.Context, name string, logoutToken string) (int, error) {
+	info := s.socialService.GetOAuthInfoProvider(name)
+	if info == nil || !info.BackchannelLogout {
+		return 0, fmt.Errorf("back-channel logout is not enabled for %q", name)
+	}
+
+	claims, err := s.verifyLogoutToken(ctx, name, info, logoutToken)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidLogoutToken, err)
+	}
+
+	sessions, err := s.findSessions(ctx, "oauth_"+name, claims.Issuer, claims.Subject, claims.SessionId)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		err := s.userTokenService.RevokeToken(ctx, &models.UserToken{Id: session.TokenId, UserId: session.UserId}, false)
+		if err != nil && !errors.Is(err, models.ErrUserTokenNotFound) {
+			return 0, err
+		}
+	}
+	// The provider ended the single sign-on session already
+	if err := s.deleteSessions(ctx, sessions); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// verifyLogoutToken verifies the signature of the logout token with the key set of the provider, and validates its
+// claims
+func (s *LogoutService) verifyLogoutToken(ctx context.Context, name string, info *social.OAuthInfo, raw string) (*logoutClaims, error) {
+	if raw == "" {
+		return nil, errors.New("missing logout_token")
+	}
+	parsed, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Headers) != 1 {
+		return nil, errors.New("unexpected number of signatures")
+	}
+
+	keys, err := s.getKeys(ctx, name, info, parsed.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+	claims := &logoutClaims{}
+	verified := false
+	for _, key := range keys {
+		if err := parsed.Claims(key, claims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("signature verification failed")
+	}
+
+	now := s.now()
+	if err := claims.ValidateWithLeeway(jwt.Expected{Audience: jwt.Audience{info.ClientId}, Time: now}, jwt.DefaultLeeway); err != nil {
+		return nil, err
+	}
+	switch {
+	case claims.Issuer == "":
+		return nil, errors.New("missing iss claim")
+	case claims.IssuedAt == nil || now.Sub(claims.IssuedAt.Time()) > logoutTokenMaxAge:
+		return nil, errors.New("missing or expired iat claim")
+	case claims.Subject == "" && claims.SessionId == "":
+		return nil, errors.New("missing sub or sid claim")
+	case claims.Nonce != nil:
+		return nil, errors.New("unexpected nonce claim")
+	}
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return nil, errors.New("missing back-channel logout event")
+	}
+	return claims, nil
+}
+
+// getKeys returns the keys of the key set of the provider with the key ID, all the keys when it is empty. The key
+// set is fetched again when it has expired, or when no key has this ID.
+func (s *LogoutService) getKeys(ctx context.Context, name string, info *social.OAuthInfo, kid string) ([]jose.JSONWebKey, error) {
+	if info.JwkSetUrl == "" {
+		return nil, errors.New("jwk_set_url is not configured")
+	}
+
+	s.keySetsMu.Lock()
+	defer s.keySetsMu.Unlock()
+
+	now := s.now()
+	cached, ok := s.keySets[name]
+	if ok && now.Sub(cached.fetched) < keySetExpiration {
+		if keys := keysWithID(cached.keys, kid); len(keys) > 0 || now.Sub(cached.fetched) < keySetMinRefreshInterval {
+			return keys, nil
+		}
+	}
+
+	keySet, err := s.fetchKeySet(ctx, name, info.JwkSetUrl)
+	if err != nil {
+		return nil, err
+	}
+	s.keySets[name] = &cachedKeySet{keys: *keySet, fetched: now}
+	return keysWithID(*keySet, kid), nil
+}
+
+func (s *LogoutService) fetchKeySet(ctx context.Context, name string, keySetURL string) (*jose.JSONWebKeySet, error) {
+	client, err := s.socialService.GetOAuthHttpClient(name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, keySetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key set endpoint returned status %d", resp.StatusCode)
+	}
+
+	keySet := &jose.JSONWebKeySet{}
+	if err := json.NewDecoder(resp.Body).Decode(keySet); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+func keysWithID(keySet jose.JSONWebKeySet, kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return keySet.Keys
+	}
+	return keySet.Key(kid)
+}
@@ -0,0 +1,313 @@
+package oauthtoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// logoutInterval is how often the end of the Grafana sessions is propagated to the providers
+	logoutInterval = time.Minute
+	// logoutBatchSize is the maximum number of ended sessions propagated at each interval
+	logoutBatchSize = 500
+)
+
+// LogoutService terminates the Grafana sessions together with the single sign-on sessions of the OAuth providers.
+// When a Grafana session ends, because the user logs out, the session is revoked or expires, the user is redirected
+// to the end_session_url of the provider on logout and the OAuth token of the user is revoked at the revocation_url
+// once they have no other session. Providers with back-channel logout enabled can end the Grafana sessions of the
+// single sign-on sessions they log out.
+type LogoutService struct {
+	cfg              *setting.Cfg
+	sqlStore         *sqlstore.SQLStore
+	socialService    social.Service
+	userTokenService models.UserTokenService
+	serverLock       *serverlock.ServerLockService
+	log              log.Logger
+	now              func() time.Time
+
+	keySetsMu sync.Mutex
+	keySets   map[string]*cachedKeySet
+}
+
+func ProvideLogoutService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, socialService social.Service,
+	userTokenService models.UserTokenService, serverLock *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister) *LogoutService {
+	s := &LogoutService{
+		cfg:              cfg,
+		sqlStore:         sqlStore,
+		socialService:    socialService,
+		userTokenService: userTokenService,
+		serverLock:       serverLock,
+		log:              log.New("oauthtoken.logout"),
+		now:              time.Now,
+		keySets:          map[string]*cachedKeySet{},
+	}
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// IsDisabled returns true when no provider has single logout enabled
+func (s *LogoutService) IsDisabled() bool {
+	for _, info := range s.socialService.GetOAuthInfoProviders() {
+		if info.SingleLogoutEnabled() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *LogoutService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(logoutInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Only one instance propagates the ended sessions at each interval
+			err := s.serverLock.LockAndExecute(ctx, "oauth single logout", logoutInterval/2, s.endSessions)
+			if err != nil {
+				s.log.Error("Failed to propagate the ended sessions", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SessionCreated records the single sign-on session of the Grafana session of a user who logged in with the
+// provider of the external user, when the provider has single logout enabled
+func (s *LogoutService) SessionCreated(ctx context.Context, token *models.UserToken, extUser *models.ExternalUserInfo) error {
+	info := s.socialService.GetOAuthInfoProvider(strings.TrimPrefix(extUser.AuthModule, "oauth_"))
+	if info == nil || !info.SingleLogoutEnabled() || token == nil {
+		return nil
+	}
+
+	session := &oauthSession{
+		UserId:     token.UserId,
+		TokenId:    token.Id,
+		AuthModule: extUser.AuthModule,
+		Created:    s.now(),
+	}
+	if claims, ok := idTokenClaims(extUser.OAuthToken); ok {
+		session.Issuer = claims.Issuer
+		session.Subject = claims.Subject
+		session.SessionId = claims.SessionId
+	}
+	return s.createSession(ctx, session)
+}
+
+// Logout ends the single sign-on session of the Grafana session the user is logging out of, and returns the URL of
+// the provider the user is redirected to, if any
+func (s *LogoutService) Logout(ctx context.Context, token *models.UserToken) string {
+	if token == nil {
+		return ""
+	}
+	session, ok, err := s.getSessionByToken(ctx, token.Id)
+	if err != nil {
+		s.log.Error("Failed to get the OAuth session", "tokenId", token.Id, "error", err)
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	if err := s.deleteSessions(ctx, []*oauthSession{session}); err != nil {
+		s.log.Error("Failed to delete the OAuth session", "tokenId", token.Id, "error", err)
+	}
+
+	info := s.socialService.GetOAuthInfoProvider(strings.TrimPrefix(session.AuthModule, "oauth_"))
+	if info == nil {
+		return ""
+	}
+
+	authInfo, err := s.getAuthInfo(ctx, session)
+	if err != nil {
+		s.log.Error("Failed to get the OAuth token of the user", "userId", session.UserId, "error", err)
+		return ""
+	}
+	s.revokeIfLastSession(ctx, info, session, authInfo, token.Id)
+
+	if info.EndSessionUrl == "" {
+		return ""
+	}
+	return s.endSessionURL(info, authInfo)
+}
+
+// endSessions propagates the end of the Grafana sessions revoked, deleted or expired since the last interval
+func (s *LogoutService) endSessions(ctx context.Context) {
+	sessions, err := s.getEndedSessions(ctx, s.now(), logoutBatchSize)
+	if err != nil {
+		s.log.Error("Failed to get the ended OAuth sessions", "error", err)
+		return
+	}
+	if err := s.deleteSessions(ctx, sessions); err != nil {
+		s.log.Error("Failed to delete the ended OAuth sessions", "error", err)
+		return
+	}
+
+	// The OAuth token is shared by the sessions of a user, it is revoked once for them all
+	revoked := map[string]bool{}
+	for _, session := range sessions {
+		key := fmt.Sprintf("%s/%d", session.AuthModule, session.UserId)
+		if revoked[key] {
+			continue
+		}
+		revoked[key] = true
+
+		info := s.socialService.GetOAuthInfoProvider(strings.TrimPrefix(session.AuthModule, "oauth_"))
+		if info == nil || info.RevocationUrl == "" {
+			continue
+		}
+		authInfo, err := s.getAuthInfo(ctx, session)
+		if err != nil {
+			s.log.Error("Failed to get the OAuth token of the user", "userId", session.UserId, "error", err)
+			continue
+		}
+		s.revokeIfLastSession(ctx, info, session, authInfo, 0)
+	}
+}
+
+// revokeIfLastSession revokes the OAuth token of the user at the provider, unless the user has another active
+// session with the provider than the one of the Grafana session excludedTokenID
+func (s *LogoutService) revokeIfLastSession(ctx context.Context, info *social.OAuthInfo, session *oauthSession,
+	authInfo *models.UserAuth, excludedTokenID int64) {
+	if info.RevocationUrl == "" {
+		return
+	}
+	active, err := s.hasActiveSession(ctx, session.UserId, session.AuthModule, excludedTokenID, s.now())
+	if err != nil {
+		s.log.Error("Failed to get the OAuth sessions of the user", "userId", session.UserId, "error", err)
+		return
+	}
+	if active {
+		return
+	}
+	if err := s.revokeToken(ctx, info, session.AuthModule, authInfo); err != nil {
+		s.log.Error("Failed to revoke the OAuth token of the user", "provider", session.AuthModule, "userId", session.UserId, "error", err)
+		return
+	}
+	s.log.Debug("Revoked the OAuth token of the user", "provider", session.AuthModule, "userId", session.UserId)
+}
+
+// revokeToken revokes the refresh token, or the access token when there is none, as specified by RFC 7009
+func (s *LogoutService) revokeToken(ctx context.Context, info *social.OAuthInfo, authModule string, authInfo *models.UserAuth) error {
+	token, hint := authInfo.OAuthRefreshToken, "refresh_token"
+	if token == "" {
+		token, hint = authInfo.OAuthAccessToken, "access_token"
+	}
+	if token == "" {
+		return nil
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {hint}}
+	if info.ClientSecret == "" {
+		form.Set("client_id", info.ClientId)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, info.RevocationUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if info.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(info.ClientId), url.QueryEscape(info.ClientSecret))
+	}
+
+	client, err := s.socialService.GetOAuthHttpClient(authModule)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("revocation endpoint returned status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// endSessionURL returns the URL of the provider ending the single sign-on session, as specified by OpenID Connect
+// RP-Initiated Logout. The user is redirected back to the login page, or to the signout_redirect_url.
+func (s *LogoutService) endSessionURL(info *social.OAuthInfo, authInfo *models.UserAuth) string {
+	endSessionURL, err := url.Parse(info.EndSessionUrl)
+	if err != nil {
+		s.log.Error("Invalid end_session_url", "url", info.EndSessionUrl, "error", err)
+		return ""
+	}
+
+	redirectURL := setting.SignoutRedirectUrl
+	if redirectURL == "" {
+		redirectURL = strings.TrimSuffix(s.cfg.AppURL, "/") + "/login"
+	}
+	query := endSessionURL.Query()
+	query.Set("client_id", info.ClientId)
+	query.Set("post_logout_redirect_uri", redirectURL)
+	if authInfo.OAuthIdToken != "" {
+		query.Set("id_token_hint", authInfo.OAuthIdToken)
+	}
+	endSessionURL.RawQuery = query.Encode()
+	return endSessionURL.String()
+}
+
+func (s *LogoutService) getAuthInfo(ctx context.Context, session *oauthSession) (*models.UserAuth, error) {
+	query := &models.GetAuthInfoQuery{UserId: session.UserId, AuthModule: session.AuthModule}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return &models.UserAuth{UserId: session.UserId, AuthModule: session.AuthModule}, nil
+		}
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+type ssoClaims struct {
+	jwt.Claims
+	SessionId string `json:"sid"`
+}
+
+// idTokenClaims returns the claims of the ID token of the OAuth token. The ID token is not verified, it was
+// received from the token endpoint of the provider.
+func idTokenClaims(token *oauth2.Token) (*ssoClaims, bool) {
+	if token == nil {
+		return nil, false
+	}
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return nil, false
+	}
+	parsed, err := jwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, false
+	}
+	claims := &ssoClaims{}
+	if err := parsed.UnsafeClaimsWithoutVerification(claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
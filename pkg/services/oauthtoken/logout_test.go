@@ -0,0 +1,205 @@
+package oauthtoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testIssuer = "https://idp.example.org"
+
+func TestLogoutService(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	ctx := context.Background()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var revocations []url.Values
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/revoke":
+			require.NoError(t, r.ParseForm())
+			form := r.PostForm
+			if user, password, ok := r.BasicAuth(); ok {
+				form.Set("basic_auth", user+":"+password)
+			}
+			revocations = append(revocations, form)
+		case "/jwks":
+			keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "key-1", Algorithm: "RS256", Use: "sig"}}}
+			_ = json.NewEncoder(w).Encode(keySet)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(provider.Close)
+
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://grafana.example.org/"
+	cfg.LoginMaxLifetime = 30 * 24 * time.Hour
+	cfg.LoginMaxInactiveLifetime = 7 * 24 * time.Hour
+	store := sqlstore.InitTestDB(t)
+	userTokens := auth.ProvideUserAuthTokenService(store, nil, cfg)
+	info := &social.OAuthInfo{
+		ClientId:          "grafana",
+		ClientSecret:      "secret",
+		EndSessionUrl:     provider.URL + "/logout?ui_locales=en",
+		RevocationUrl:     provider.URL + "/revoke",
+		BackchannelLogout: true,
+		JwkSetUrl:         provider.URL + "/jwks",
+	}
+	s := ProvideLogoutService(cfg, store, &fakeSocialService{info: info}, userTokens, nil, routing.NewRouteRegister())
+	require.False(t, s.IsDisabled())
+
+	user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "alice"})
+	require.NoError(t, err)
+	idToken := signToken(t, key, map[string]interface{}{"iss": testIssuer, "sub": "alice", "aud": "grafana", "sid": "sso-1"})
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetAuthInfoQuery) error {
+		query.Result = &models.UserAuth{UserId: query.UserId, AuthModule: query.AuthModule, OAuthAccessToken: "access", OAuthRefreshToken: "refresh", OAuthIdToken: idToken}
+		return nil
+	})
+
+	login := func(sid string) *models.UserToken {
+		token, err := userTokens.CreateToken(ctx, user, nil, "")
+		require.NoError(t, err)
+		oauthToken := (&oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}).WithExtra(map[string]interface{}{
+			"id_token": signToken(t, key, map[string]interface{}{"iss": testIssuer, "sub": "alice", "aud": "grafana", "sid": sid}),
+		})
+		err = s.SessionCreated(ctx, token, &models.ExternalUserInfo{AuthModule: "oauth_generic_oauth", OAuthToken: oauthToken})
+		require.NoError(t, err)
+		return token
+	}
+	isActive := func(token *models.UserToken) bool {
+		_, err := userTokens.GetUserToken(ctx, token.UserId, token.Id)
+		return err == nil
+	}
+
+	t.Run("should redirect to the end session endpoint on logout", func(t *testing.T) {
+		first, second := login("sso-1"), login("sso-2")
+
+		endSessionURL, err := url.Parse(s.Logout(ctx, first))
+		require.NoError(t, err)
+		assert.Equal(t, "/logout", endSessionURL.Path)
+		assert.Equal(t, url.Values{
+			"ui_locales":               {"en"},
+			"client_id":                {"grafana"},
+			"post_logout_redirect_uri": {"http://grafana.example.org/login"},
+			"id_token_hint":            {idToken},
+		}, endSessionURL.Query())
+
+		// The token is shared with the other session
+		assert.Empty(t, revocations)
+		_, ok, err := s.getSessionByToken(ctx, first.Id)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		t.Run("should revoke the OAuth token once the last session ended", func(t *testing.T) {
+			require.NoError(t, userTokens.RevokeToken(ctx, second, false))
+			s.endSessions(ctx)
+			require.Len(t, revocations, 1)
+			assert.Equal(t, "refresh", revocations[0].Get("token"))
+			assert.Equal(t, "refresh_token", revocations[0].Get("token_type_hint"))
+			assert.Equal(t, "grafana:secret", revocations[0].Get("basic_auth"))
+
+			_, ok, err := s.getSessionByToken(ctx, second.Id)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			s.endSessions(ctx)
+			assert.Len(t, revocations, 1)
+		})
+	})
+
+	t.Run("should end the sessions logged out by the provider", func(t *testing.T) {
+		first, second := login("sso-3"), login("sso-4")
+		now := time.Now()
+
+		count, err := s.BackchannelLogout(ctx, "generic_oauth", signToken(t, key, logoutTokenClaims(now, "", "sso-3")))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.False(t, isActive(first))
+		assert.True(t, isActive(second))
+
+		count, err = s.BackchannelLogout(ctx, "generic_oauth", signToken(t, key, logoutTokenClaims(now, "alice", "")))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.False(t, isActive(second))
+	})
+
+	t.Run("should refuse invalid logout tokens", func(t *testing.T) {
+		token := login("sso-5")
+		now := time.Now()
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		wrongAudience := logoutTokenClaims(now, "alice", "sso-5")
+		wrongAudience["aud"] = "other"
+		withNonce := logoutTokenClaims(now, "alice", "sso-5")
+		withNonce["nonce"] = "nonce"
+		withoutEvent := logoutTokenClaims(now, "alice", "sso-5")
+		delete(withoutEvent, "events")
+
+		for name, logoutToken := range map[string]string{
+			"empty":           "",
+			"malformed":       "logout",
+			"wrong signature": signToken(t, otherKey, logoutTokenClaims(now, "alice", "sso-5")),
+			"wrong audience":  signToken(t, key, wrongAudience),
+			"nonce":           signToken(t, key, withNonce),
+			"no event":        signToken(t, key, withoutEvent),
+			"no subject":      signToken(t, key, logoutTokenClaims(now, "", "")),
+			"issued too long": signToken(t, key, logoutTokenClaims(now.Add(-time.Hour), "alice", "sso-5")),
+		} {
+			_, err := s.BackchannelLogout(ctx, "generic_oauth", logoutToken)
+			assert.ErrorIs(t, err, errInvalidLogoutToken, name)
+		}
+		assert.True(t, isActive(token))
+	})
+}
+
+func logoutTokenClaims(issuedAt time.Time, subject, sessionID string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":    testIssuer,
+		"aud":    "grafana",
+		"iat":    issuedAt.Unix(),
+		"jti":    "logout",
+		"events": map[string]interface{}{backchannelLogoutEvent: map[string]interface{}{}},
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+	return claims
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims interface{}) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key-1"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
@@ -3,6 +3,8 @@ package oauthtoken
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -48,53 +50,68 @@ func (o *Service) GetCurrentOAuthToken(ctx context.Context, user *models.SignedI
 		return nil
 	}
 
-	authProvider := authInfoQuery.Result.AuthModule
-	connect, err := o.SocialService.GetConnector(authProvider)
+	token, err := o.refreshToken(ctx, authInfoQuery.Result, false)
 	if err != nil {
-		logger.Error("failed to get OAuth connector", "provider", authProvider, "error", err)
+		logger.Error("failed to retrieve OAuth access token", "provider", authInfoQuery.Result.AuthModule, "userId", user.UserId, "username", user.Login, "error", err)
 		return nil
 	}
+	return token
+}
+
+// refreshToken returns the OAuth token of the auth info, refreshed if it has expired or when forced, and stores the
+// refreshed token
+func (o *Service) refreshToken(ctx context.Context, authInfo *models.UserAuth, force bool) (*oauth2.Token, error) {
+	authProvider := authInfo.AuthModule
+	connect, err := o.SocialService.GetConnector(authProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth connector: %w", err)
+	}
 
 	client, err := o.SocialService.GetOAuthHttpClient(authProvider)
 	if err != nil {
-		logger.Error("failed to get OAuth http client", "provider", authProvider, "error", err)
-		return nil
+		return nil, fmt.Errorf("failed to get OAuth http client: %w", err)
 	}
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
 
 	persistedToken := &oauth2.Token{
-		AccessToken:  authInfoQuery.Result.OAuthAccessToken,
-		Expiry:       authInfoQuery.Result.OAuthExpiry,
-		RefreshToken: authInfoQuery.Result.OAuthRefreshToken,
-		TokenType:    authInfoQuery.Result.OAuthTokenType,
+		AccessToken:  authInfo.OAuthAccessToken,
+		Expiry:       authInfo.OAuthExpiry,
+		RefreshToken: authInfo.OAuthRefreshToken,
+		TokenType:    authInfo.OAuthTokenType,
+	}
+
+	if authInfo.OAuthIdToken != "" {
+		persistedToken = persistedToken.WithExtra(map[string]interface{}{"id_token": authInfo.OAuthIdToken})
 	}
 
-	if authInfoQuery.Result.OAuthIdToken != "" {
-		persistedToken = persistedToken.WithExtra(map[string]interface{}{"id_token": authInfoQuery.Result.OAuthIdToken})
+	sourceToken := persistedToken
+	if force {
+		// The token source refreshes the tokens that have expired only
+		expired := *persistedToken
+		expired.Expiry = time.Unix(1, 0)
+		sourceToken = &expired
 	}
 
 	// TokenSource handles refreshing the token if it has expired
-	token, err := connect.TokenSource(ctx, persistedToken).Token()
+	token, err := connect.TokenSource(ctx, sourceToken).Token()
 	if err != nil {
-		logger.Error("failed to retrieve OAuth access token", "provider", authInfoQuery.Result.AuthModule, "userId", user.UserId, "username", user.Login, "error", err)
-		return nil
+		return nil, err
 	}
 
 	// If the tokens are not the same, update the entry in the DB
 	if !tokensEq(persistedToken, token) {
 		updateAuthCommand := &models.UpdateAuthInfoCommand{
-			UserId:     authInfoQuery.Result.UserId,
-			AuthModule: authInfoQuery.Result.AuthModule,
-			AuthId:     authInfoQuery.Result.AuthId,
+			UserId:     authInfo.UserId,
+			AuthModule: authInfo.AuthModule,
+			AuthId:     authInfo.AuthId,
 			OAuthToken: token,
 		}
 		if err := bus.Dispatch(ctx, updateAuthCommand); err != nil {
-			logger.Error("failed to update auth info during token refresh", "userId", user.UserId, "username", user.Login, "error", err)
-			return nil
+			return nil, fmt.Errorf("failed to update auth info during token refresh: %w", err)
 		}
-		logger.Debug("updated OAuth info for user", "userId", user.UserId, "username", user.Login)
+		logger.Debug("updated OAuth info for user", "userId", authInfo.UserId)
 	}
-	return token
+	return token, nil
 }
 
 // IsOAuthPassThruEnabled returns true if Forward OAuth Identity (oauthPassThru) is enabled for the provided data source.
@@ -0,0 +1,158 @@
+package oauthtoken
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// refreshBatchSize is the maximum number of tokens refreshed at each interval, the other tokens are refreshed at the
+// next intervals
+const refreshBatchSize = 500
+
+// RefreshService refreshes the OAuth tokens of the users with a session before they expire, so that the data
+// sources forwarding the OAuth identity get tokens that are valid instead of refreshing them in the request path.
+// When the provider refuses to refresh a token, the sessions of the user are revoked so that they log in again.
+type RefreshService struct {
+	cfg              *setting.Cfg
+	tokenService     *Service
+	userTokenService models.UserTokenService
+	serverLock       *serverlock.ServerLockService
+	log              log.Logger
+	now              func() time.Time
+}
+
+func ProvideRefreshService(cfg *setting.Cfg, tokenService *Service, userTokenService models.UserTokenService,
+	serverLock *serverlock.ServerLockService) *RefreshService {
+	return &RefreshService{
+		cfg:              cfg,
+		tokenService:     tokenService,
+		userTokenService: userTokenService,
+		serverLock:       serverLock,
+		log:              log.New("oauthtoken.refresh"),
+		now:              time.Now,
+	}
+}
+
+func (s *RefreshService) IsDisabled() bool {
+	return !s.cfg.OAuthTokenRefreshEnabled
+}
+
+func (s *RefreshService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.OAuthTokenRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Only one instance refreshes the tokens at each interval
+			err := s.serverLock.LockAndExecute(ctx, "refresh oauth tokens", s.cfg.OAuthTokenRefreshInterval/2, s.refreshExpiringTokens)
+			if err != nil {
+				s.log.Error("Failed to refresh OAuth tokens", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *RefreshService) refreshExpiringTokens(ctx context.Context) {
+	now := s.now()
+	query := &models.GetExpiringOAuthTokensQuery{
+		ExpiresBefore: now.Add(s.cfg.OAuthTokenRefreshAhead + s.maxJitter()),
+		ActiveSince:   now.Add(-s.cfg.LoginMaxInactiveLifetime),
+		Limit:         refreshBatchSize,
+	}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		s.log.Error("Failed to get the OAuth tokens about to expire", "error", err)
+		return
+	}
+
+	for _, authInfo := range query.Result {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Before(s.refreshTime(authInfo)) {
+			continue
+		}
+		s.refresh(ctx, authInfo)
+	}
+}
+
+func (s *RefreshService) refresh(ctx context.Context, authInfo *models.UserAuth) {
+	provider := strings.TrimPrefix(authInfo.AuthModule, "oauth_")
+	_, err := s.tokenService.refreshToken(ctx, authInfo, true)
+	if err == nil {
+		metrics.MOAuthTokenRefreshTotal.WithLabelValues(provider, "success").Inc()
+		return
+	}
+
+	if !isReauthRequired(err) {
+		metrics.MOAuthTokenRefreshTotal.WithLabelValues(provider, "failure").Inc()
+		s.log.Error("Failed to refresh OAuth token", "provider", provider, "userId", authInfo.UserId, "error", err)
+		return
+	}
+
+	metrics.MOAuthTokenRefreshTotal.WithLabelValues(provider, "reauth").Inc()
+	s.log.Warn("OAuth token cannot be refreshed, revoking the sessions of the user", "provider", provider,
+		"userId", authInfo.UserId, "error", err)
+	if err := s.userTokenService.RevokeAllUserTokens(ctx, authInfo.UserId); err != nil {
+		s.log.Error("Failed to revoke the sessions of the user", "userId", authInfo.UserId, "error", err)
+	}
+}
+
+// refreshTime returns when the token is refreshed: ahead of its expiry, and earlier by up to the jitter of the
+// provider so that the tokens obtained at the same time are not all refreshed at once. A token is refreshed at the
+// same time on every interval.
+func (s *RefreshService) refreshTime(authInfo *models.UserAuth) time.Time {
+	refreshTime := authInfo.OAuthExpiry.Add(-s.cfg.OAuthTokenRefreshAhead)
+	jitter := s.jitter(authInfo.AuthModule)
+	if jitter <= 0 {
+		return refreshTime
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(authInfo.AuthModule + "/" + strconv.FormatInt(authInfo.UserId, 10)))
+	return refreshTime.Add(-time.Duration(h.Sum64() % uint64(jitter)))
+}
+
+func (s *RefreshService) jitter(authModule string) time.Duration {
+	info := s.tokenService.SocialService.GetOAuthInfoProvider(strings.TrimPrefix(authModule, "oauth_"))
+	if info == nil {
+		return 0
+	}
+	return info.TokenRefreshJitter
+}
+
+func (s *RefreshService) maxJitter() time.Duration {
+	var max time.Duration
+	for _, info := range s.tokenService.SocialService.GetOAuthInfoProviders() {
+		if info.TokenRefreshJitter > max {
+			max = info.TokenRefreshJitter
+		}
+	}
+	return max
+}
+
+// isReauthRequired returns whether the provider refused to refresh the token, the refresh token has expired or has
+// been revoked, and the user must log in again
+func isReauthRequired(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.Response == nil {
+		return false
+	}
+	return retrieveErr.Response.StatusCode == http.StatusBadRequest || retrieveErr.Response.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,157 @@
+package oauthtoken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRefreshService(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	status := http.StatusOK
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "refreshed", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	now := time.Now()
+	authInfo := &models.UserAuth{
+		UserId:            1,
+		AuthModule:        "oauth_generic_oauth",
+		OAuthAccessToken:  "access",
+		OAuthRefreshToken: "refresh",
+		OAuthTokenType:    "Bearer",
+		OAuthExpiry:       now.Add(2 * time.Minute),
+	}
+	var query *models.GetExpiringOAuthTokensQuery
+	bus.AddHandler("test", func(ctx context.Context, q *models.GetExpiringOAuthTokensQuery) error {
+		query = q
+		q.Result = []*models.UserAuth{authInfo}
+		return nil
+	})
+	var updated *models.UpdateAuthInfoCommand
+	bus.AddHandler("test", func(ctx context.Context, cmd *models.UpdateAuthInfoCommand) error {
+		updated = cmd
+		return nil
+	})
+
+	revoked := int64(0)
+	userTokenService := auth.NewFakeUserAuthTokenService()
+	userTokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userId int64) error {
+		revoked = userId
+		return nil
+	}
+
+	socialService := &fakeSocialService{
+		connector: &fakeConnector{config: &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL}}},
+		info:      &social.OAuthInfo{TokenRefreshJitter: time.Minute},
+	}
+	cfg := setting.NewCfg()
+	cfg.OAuthTokenRefreshAhead = 5 * time.Minute
+	cfg.LoginMaxInactiveLifetime = 7 * 24 * time.Hour
+	s := ProvideRefreshService(cfg, ProvideService(socialService), userTokenService, nil)
+	s.now = func() time.Time { return now }
+
+	t.Run("should refresh the tokens about to expire of the users with a session", func(t *testing.T) {
+		s.refreshExpiringTokens(context.Background())
+
+		require.NotNil(t, query)
+		assert.Equal(t, now.Add(6*time.Minute), query.ExpiresBefore)
+		assert.Equal(t, now.Add(-7*24*time.Hour), query.ActiveSince)
+		require.NotNil(t, updated)
+		assert.Equal(t, "refreshed", updated.OAuthToken.AccessToken)
+		assert.Equal(t, int64(0), revoked)
+	})
+
+	t.Run("should not refresh the tokens before their refresh time", func(t *testing.T) {
+		updated = nil
+		authInfo.OAuthExpiry = now.Add(10 * time.Minute)
+		s.refreshExpiringTokens(context.Background())
+		assert.Nil(t, updated)
+	})
+
+	t.Run("should revoke the sessions of the users whose token cannot be refreshed", func(t *testing.T) {
+		updated = nil
+		status = http.StatusBadRequest
+		authInfo.OAuthExpiry = now.Add(time.Minute)
+		s.refreshExpiringTokens(context.Background())
+		assert.Nil(t, updated)
+		assert.Equal(t, int64(1), revoked)
+	})
+
+	t.Run("should not revoke the sessions when the provider fails", func(t *testing.T) {
+		revoked = 0
+		status = http.StatusInternalServerError
+		s.refreshExpiringTokens(context.Background())
+		assert.Equal(t, int64(0), revoked)
+	})
+}
+
+func TestRefreshService_refreshTime(t *testing.T) {
+	socialService := &fakeSocialService{info: &social.OAuthInfo{TokenRefreshJitter: time.Minute}}
+	cfg := setting.NewCfg()
+	cfg.OAuthTokenRefreshAhead = 5 * time.Minute
+	s := ProvideRefreshService(cfg, ProvideService(socialService), nil, nil)
+
+	expiry := time.Now().Add(time.Hour)
+	refreshTime := s.refreshTime(&models.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth", OAuthExpiry: expiry})
+	assert.Equal(t, refreshTime, s.refreshTime(&models.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth", OAuthExpiry: expiry}))
+	assert.False(t, refreshTime.After(expiry.Add(-5*time.Minute)))
+	assert.True(t, refreshTime.After(expiry.Add(-6*time.Minute)))
+
+	socialService.info.TokenRefreshJitter = 0
+	assert.Equal(t, expiry.Add(-5*time.Minute), s.refreshTime(&models.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth", OAuthExpiry: expiry}))
+}
+
+type fakeSocialService struct {
+	connector social.SocialConnector
+	info      *social.OAuthInfo
+}
+
+func (s *fakeSocialService) GetOAuthProviders() map[string]bool {
+	return map[string]bool{"generic_oauth": true}
+}
+
+func (s *fakeSocialService) GetOAuthHttpClient(string) (*http.Client, error) {
+	return http.DefaultClient, nil
+}
+
+func (s *fakeSocialService) GetConnector(string) (social.SocialConnector, error) {
+	return s.connector, nil
+}
+
+func (s *fakeSocialService) GetOAuthInfoProvider(string) *social.OAuthInfo {
+	return s.info
+}
+
+func (s *fakeSocialService) GetOAuthInfoProviders() map[string]*social.OAuthInfo {
+	return map[string]*social.OAuthInfo{"generic_oauth": s.info}
+}
+
+type fakeConnector struct {
+	social.SocialConnector
+	config *oauth2.Config
+}
+
+func (c *fakeConnector) TokenSource(ctx context.Context, t *oauth2.Token) oauth2.TokenSource {
+	return c.config.TokenSource(ctx, t)
+}
@@ -0,0 +1,107 @@
+package oauthtoken
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// oauthSession links a Grafana session to the single sign-on session of the provider the user logged in with
+type oauthSession struct {
+	Id         int64
+	UserId     int64
+	TokenId    int64
+	AuthModule string
+	Issuer     string
+	Subject    string
+	SessionId  string
+	Created    time.Time
+}
+
+func (s *oauthSession) TableName() string {
+	return "oauth_session"
+}
+
+// activeTokenCondition selects the sessions whose Grafana session has not been revoked and has not expired
+const activeTokenCondition = "t.revoked_at = 0 AND t.created_at > ? AND t.rotated_at > ?"
+
+func (s *LogoutService) createSession(ctx context.Context, session *oauthSession) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(session)
+		return err
+	})
+}
+
+func (s *LogoutService) getSessionByToken(ctx context.Context, tokenID int64) (*oauthSession, bool, error) {
+	session := &oauthSession{}
+	var has bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		has, err = sess.Where("token_id = ?", tokenID).Get(session)
+		return err
+	})
+	return session, has, err
+}
+
+// findSessions returns the sessions of the provider with the subject and the session ID, when set
+func (s *LogoutService) findSessions(ctx context.Context, authModule, issuer, subject, sessionID string) ([]*oauthSession, error) {
+	result := make([]*oauthSession, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := sess.Where("auth_module = ? AND issuer = ?", authModule, issuer)
+		if subject != "" {
+			q = q.And("subject = ?", subject)
+		}
+		if sessionID != "" {
+			q = q.And("session_id = ?", sessionID)
+		}
+		return q.Find(&result)
+	})
+	return result, err
+}
+
+// getEndedSessions returns the sessions whose Grafana session has been revoked, deleted or has expired
+func (s *LogoutService) getEndedSessions(ctx context.Context, now time.Time, limit int) ([]*oauthSession, error) {
+	result := make([]*oauthSession, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(`SELECT s.* FROM oauth_session s LEFT JOIN user_auth_token t ON t.id = s.token_id
+			WHERE t.id IS NULL OR NOT (`+activeTokenCondition+`) ORDER BY s.id LIMIT ?`,
+			s.createdAfter(now), s.rotatedAfter(now), limit).Find(&result)
+	})
+	return result, err
+}
+
+// hasActiveSession returns whether the user has another active session with the provider than the one of the
+// Grafana session excludedTokenID
+func (s *LogoutService) hasActiveSession(ctx context.Context, userID int64, authModule string, excludedTokenID int64, now time.Time) (bool, error) {
+	var count int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.SQL(`SELECT COUNT(*) FROM oauth_session s INNER JOIN user_auth_token t ON t.id = s.token_id
+			WHERE s.user_id = ? AND s.auth_module = ? AND s.token_id <> ? AND `+activeTokenCondition,
+			userID, authModule, excludedTokenID, s.createdAfter(now), s.rotatedAfter(now)).Get(&count)
+		return err
+	})
+	return count > 0, err
+}
+
+func (s *LogoutService) deleteSessions(ctx context.Context, sessions []*oauthSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.Id)
+	}
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.In("id", ids).Delete(&oauthSession{})
+		return err
+	})
+}
+
+func (s *LogoutService) createdAfter(now time.Time) int64 {
+	return now.Add(-s.cfg.LoginMaxLifetime).Unix()
+}
+
+func (s *LogoutService) rotatedAfter(now time.Time) int64 {
+	return now.Add(-s.cfg.LoginMaxInactiveLifetime).Unix()
+}
@@ -0,0 +1,44 @@
+package onboarding
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/org/onboarding", func(onboardingRoute routing.RouteRegister) {
+		onboardingRoute.Get("/", routing.Wrap(s.getStateHandler))
+		onboardingRoute.Post("/steps/:step/complete", middleware.ReqOrgAdmin, routing.Wrap(s.completeStepHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// GET /api/org/onboarding
+func (s *Service) getStateHandler(c *models.ReqContext) response.Response {
+	state, err := s.GetState(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get onboarding state")
+	}
+	return response.JSON(http.StatusOK, state)
+}
+
+// POST /api/org/onboarding/steps/:step/complete
+func (s *Service) completeStepHandler(c *models.ReqContext) response.Response {
+	state, err := s.CompleteStep(c.Req.Context(), c.OrgId, c.UserId, StepID(web.Params(c.Req)[":step"]))
+	if err != nil {
+		return errorResponse(err, "Failed to complete onboarding step")
+	}
+	return response.JSON(http.StatusOK, state)
+}
+
+func errorResponse(err error, message string) response.Response {
+	if errors.Is(err, ErrUnknownStep) {
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
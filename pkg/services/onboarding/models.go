@@ -0,0 +1,69 @@
+package onboarding
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownStep = errors.New("unknown onboarding step")
+
+// StepID identifies a step of the guided setup of an organization
+type StepID string
+
+const (
+	// StepDataSource is completed once a data source is added
+	StepDataSource StepID = "datasource"
+	// StepDashboard is completed once a dashboard is created
+	StepDashboard StepID = "dashboard"
+	// StepAlert is completed once an alert rule is created
+	StepAlert StepID = "alert"
+	// StepInvite is completed once a user is invited or added to the organization
+	StepInvite StepID = "invite"
+)
+
+// Steps are the steps of the guided setup, in the order they are suggested
+var Steps = []StepID{StepDataSource, StepDashboard, StepAlert, StepInvite}
+
+// StepSource is how a step was completed
+type StepSource string
+
+const (
+	// SourceDetected steps were completed by the organization, like adding a data source
+	SourceDetected StepSource = "detected"
+	// SourceMarked steps were marked complete through the API, for example by the provisioning tools
+	SourceMarked StepSource = "marked"
+)
+
+// State is the progress of the guided setup of an organization
+type State struct {
+	Completed bool    `json:"completed"`
+	Steps     []*Step `json:"steps"`
+}
+
+// Step is a step of the guided setup. CompletedBy is the user who marked the step complete.
+type Step struct {
+	Id          StepID     `json:"id"`
+	Completed   bool       `json:"completed"`
+	Source      StepSource `json:"source,omitempty"`
+	CompletedBy int64      `json:"completedBy,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// OrgOnboardingStep is a completed step of an organization
+type OrgOnboardingStep struct {
+	Id          int64
+	OrgId       int64
+	Step        StepID
+	Source      StepSource
+	CompletedBy int64
+	CompletedAt time.Time
+}
+
+func isStep(id StepID) bool {
+	for _, step := range Steps {
+		if step == id {
+			return true
+		}
+	}
+	return false
+}
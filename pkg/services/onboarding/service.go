@@ -0,0 +1,89 @@
+package onboarding
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Service tracks the guided setup of the organizations. The steps are detected from what the organization has done,
+// like adding a data source, or are marked complete through the API, for example by the tools provisioning tenants.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	routeRegister routing.RouteRegister
+	now           func() time.Time
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		routeRegister: routeRegister,
+		now:           time.Now,
+	}
+	s.registerAPIEndpoints()
+	return s
+}
+
+// GetState returns the progress of the guided setup of the organization. The steps the organization has done since
+// they were last read are recorded as completed, so that a step stays completed when, for example, the data source is
+// deleted.
+func (s *Service) GetState(ctx context.Context, orgID int64) (*State, error) {
+	completed, err := s.getCompletedSteps(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range Steps {
+		if _, ok := completed[id]; ok {
+			continue
+		}
+		done, err := s.detectStep(ctx, orgID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			continue
+		}
+		step := &OrgOnboardingStep{OrgId: orgID, Step: id, Source: SourceDetected, CompletedAt: s.now()}
+		if err := s.completeStep(ctx, step); err != nil {
+			return nil, err
+		}
+		completed[id] = step
+	}
+
+	return newState(completed), nil
+}
+
+// CompleteStep marks a step of the guided setup of the organization complete on behalf of the user. Marking a
+// completed step does nothing.
+func (s *Service) CompleteStep(ctx context.Context, orgID int64, userID int64, id StepID) (*State, error) {
+	if !isStep(id) {
+		return nil, ErrUnknownStep
+	}
+
+	step := &OrgOnboardingStep{OrgId: orgID, Step: id, Source: SourceMarked, CompletedBy: userID, CompletedAt: s.now()}
+	if err := s.completeStep(ctx, step); err != nil {
+		return nil, err
+	}
+	return s.GetState(ctx, orgID)
+}
+
+func newState(completed map[StepID]*OrgOnboardingStep) *State {
+	state := &State{Completed: true, Steps: make([]*Step, 0, len(Steps))}
+	for _, id := range Steps {
+		step := &Step{Id: id}
+		if c, ok := completed[id]; ok {
+			completedAt := c.CompletedAt
+			step.Completed = true
+			step.Source = c.Source
+			step.CompletedBy = c.CompletedBy
+			step.CompletedAt = &completedAt
+		} else {
+			state.Completed = false
+		}
+		state.Steps = append(state.Steps, step)
+	}
+	return state
+}
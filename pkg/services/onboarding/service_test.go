@@ -0,0 +1,105 @@
+package onboarding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_GetState(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, routing.NewRouteRegister())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "admin", OrgName: "tenant"})
+	require.NoError(t, err)
+	orgID := admin.OrgId
+
+	state, err := s.GetState(ctx, orgID)
+	require.NoError(t, err)
+	assert.False(t, state.Completed)
+	require.Len(t, state.Steps, len(Steps))
+	for i, step := range state.Steps {
+		assert.Equal(t, Steps[i], step.Id)
+		assert.False(t, step.Completed)
+	}
+
+	t.Run("should detect the steps the organization has done", func(t *testing.T) {
+		err := store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: orgID, Name: "prometheus", Type: "prometheus", Access: models.DS_ACCESS_PROXY})
+		require.NoError(t, err)
+		_, err = store.SaveDashboard(models.SaveDashboardCommand{OrgId: orgID, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Overview"})})
+		require.NoError(t, err)
+
+		state, err := s.GetState(ctx, orgID)
+		require.NoError(t, err)
+		assert.False(t, state.Completed)
+		assert.True(t, stepByID(state, StepDataSource).Completed)
+		assert.Equal(t, SourceDetected, stepByID(state, StepDataSource).Source)
+		assert.Equal(t, now, *stepByID(state, StepDataSource).CompletedAt)
+		assert.True(t, stepByID(state, StepDashboard).Completed)
+		assert.False(t, stepByID(state, StepAlert).Completed)
+		assert.False(t, stepByID(state, StepInvite).Completed)
+	})
+
+	t.Run("should keep the detected steps completed", func(t *testing.T) {
+		err := store.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{OrgID: orgID, Name: "prometheus"})
+		require.NoError(t, err)
+
+		state, err := s.GetState(ctx, orgID)
+		require.NoError(t, err)
+		assert.True(t, stepByID(state, StepDataSource).Completed)
+	})
+
+	t.Run("should detect the users added to the organization", func(t *testing.T) {
+		user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", SkipOrgSetup: true})
+		require.NoError(t, err)
+		err = store.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: models.ROLE_VIEWER})
+		require.NoError(t, err)
+
+		state, err := s.GetState(ctx, orgID)
+		require.NoError(t, err)
+		assert.True(t, stepByID(state, StepInvite).Completed)
+	})
+
+	t.Run("should mark steps complete", func(t *testing.T) {
+		state, err := s.CompleteStep(ctx, orgID, admin.Id, StepAlert)
+		require.NoError(t, err)
+		assert.True(t, state.Completed)
+		assert.Equal(t, SourceMarked, stepByID(state, StepAlert).Source)
+		assert.Equal(t, admin.Id, stepByID(state, StepAlert).CompletedBy)
+
+		state, err = s.CompleteStep(ctx, orgID, 0, StepDataSource)
+		require.NoError(t, err)
+		assert.Equal(t, SourceDetected, stepByID(state, StepDataSource).Source)
+
+		_, err = s.CompleteStep(ctx, orgID, admin.Id, "unknown")
+		assert.ErrorIs(t, err, ErrUnknownStep)
+	})
+
+	t.Run("should return the state of the organization only", func(t *testing.T) {
+		state, err := s.GetState(ctx, orgID+1)
+		require.NoError(t, err)
+		for _, step := range state.Steps {
+			assert.False(t, step.Completed)
+		}
+	})
+}
+
+func stepByID(state *State, id StepID) *Step {
+	for _, step := range state.Steps {
+		if step.Id == id {
+			return step
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package onboarding
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// detectQueries count what the organization has done for each step. The invite step counts the invites and the
+// users of the organization other than the one who created it.
+var detectQueries = map[StepID]string{
+	StepDataSource: "SELECT COUNT(*) FROM data_source WHERE org_id = ?",
+	StepDashboard:  "SELECT COUNT(*) FROM dashboard WHERE org_id = ? AND is_folder = ?",
+	StepAlert:      "SELECT (SELECT COUNT(*) FROM alert WHERE org_id = ?) + (SELECT COUNT(*) FROM alert_rule WHERE org_id = ?)",
+	StepInvite:     "SELECT (SELECT COUNT(*) FROM temp_user WHERE org_id = ?) + (SELECT COUNT(*) FROM org_user WHERE org_id = ?) - 1",
+}
+
+func (s *Service) getCompletedSteps(ctx context.Context, orgID int64) (map[StepID]*OrgOnboardingStep, error) {
+	steps := make([]*OrgOnboardingStep, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Find(&steps)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[StepID]*OrgOnboardingStep, len(steps))
+	for _, step := range steps {
+		completed[step.Step] = step
+	}
+	return completed, nil
+}
+
+// detectStep returns whether the organization has done what the step is about
+func (s *Service) detectStep(ctx context.Context, orgID int64, id StepID) (bool, error) {
+	args := []interface{}{orgID}
+	switch id {
+	case StepDashboard:
+		args = append(args, s.sqlStore.Dialect.BooleanStr(false))
+	case StepAlert, StepInvite:
+		args = append(args, orgID)
+	}
+
+	var count int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.SQL(detectQueries[id], args...).Get(&count)
+		return err
+	})
+	return count > 0, err
+}
+
+// completeStep records the completion of a step, a step completed already is left as it is
+func (s *Service) completeStep(ctx context.Context, step *OrgOnboardingStep) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND step = ?", step.OrgId, step.Step).Exist(&OrgOnboardingStep{})
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(step)
+		return err
+	})
+}
@@ -0,0 +1,65 @@
+package orgreadonly
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Get("/api/org/read-only", middleware.ReqSignedIn, routing.Wrap(s.getOrgStateHandler))
+	routeRegister.Group("/api/admin/read-only/orgs/:orgId", func(readOnlyRoute routing.RouteRegister) {
+		readOnlyRoute.Get("/", routing.Wrap(s.getStateHandler))
+		readOnlyRoute.Put("/", routing.Wrap(s.setStateHandler))
+	}, middleware.ReqGrafanaAdmin)
+}
+
+// GET /api/org/read-only
+func (s *Service) getOrgStateHandler(c *models.ReqContext) response.Response {
+	state, err := s.GetState(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the read-only state of the organization", err)
+	}
+	return response.JSON(http.StatusOK, state)
+}
+
+// GET /api/admin/read-only/orgs/:orgId
+func (s *Service) getStateHandler(c *models.ReqContext) response.Response {
+	orgID, err := strconv.ParseInt(web.Params(c.Req)[":orgId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
+	}
+
+	state, err := s.GetState(c.Req.Context(), orgID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the read-only state of the organization", err)
+	}
+	return response.JSON(http.StatusOK, state)
+}
+
+// PUT /api/admin/read-only/orgs/:orgId
+func (s *Service) setStateHandler(c *models.ReqContext) response.Response {
+	orgID, err := strconv.ParseInt(web.Params(c.Req)[":orgId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
+	}
+	cmd := SetStateCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	state, err := s.SetState(c.Req.Context(), c.SignedInUser, orgID, cmd)
+	switch {
+	case errors.Is(err, models.ErrOrgNotFound):
+		return response.Error(http.StatusNotFound, "Organization not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to set the read-only state of the organization", err)
+	}
+	return response.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,80 @@
+package orgreadonly
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// allowedPaths are the API paths which do not change the organization, the data source queries sent with POST
+// requests and the changes the users make to their own profile, preferences and sessions. The paths ending with a /
+// match the paths under them, the other ones only the path itself. The requests to the data source
+// proxy are not allowed, the data sources can be changed through it.
+var allowedPaths = []string{
+	"/api/ds/query",
+	"/api/tsdb/query",
+	"/api/dashboards/calculate-diff",
+	"/api/v1/eval",
+	"/api/v1/rule/test/",
+	"/api/frontend-metrics",
+	"/api/user",
+	"/api/user/using/",
+	"/api/user/preferences",
+	"/api/user/password",
+	"/api/user/helpflags/",
+	"/api/user/stars/",
+	"/api/user/auth-tokens",
+	"/api/user/revoke-auth-token",
+	"/api/user/2fa/",
+	"/api/login/",
+}
+
+// Middleware denies the API requests changing a read-only organization, the requests other than GET, HEAD and
+// OPTIONS, with the status 403. The requests of the server admins are never denied.
+func (s *Service) Middleware(c *models.ReqContext) {
+	if !c.IsSignedIn && !c.IsAnonymous || c.OrgId == 0 || c.IsGrafanaAdmin {
+		return
+	}
+	if !isMutation(c.Req) {
+		return
+	}
+
+	var readOnlyErr ReadOnlyError
+	err := s.CheckWritable(c.Req.Context(), c.OrgId)
+	switch {
+	case err == nil:
+		return
+	case errors.As(err, &readOnlyErr):
+		c.JSON(http.StatusForbidden, readOnlyErr.Response())
+	default:
+		// The organization may be read-only, the change is refused until the state can be checked again
+		s.log.Error("Failed to check the read-only state of the organization", "orgId", c.OrgId, "error", err)
+		c.JsonApiErr(http.StatusServiceUnavailable, "Failed to check the read-only state of the organization", nil)
+	}
+}
+
+func isMutation(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if !strings.HasPrefix(req.URL.Path, "/api/") {
+		return false
+	}
+	for _, allowed := range allowedPaths {
+		if matchesPath(req.URL.Path, allowed) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPath tells whether the path is under the allowed path ending with a /, or is the allowed path
+func matchesPath(path, allowed string) bool {
+	if strings.HasSuffix(allowed, "/") {
+		return strings.HasPrefix(path, allowed)
+	}
+	return path == allowed
+}
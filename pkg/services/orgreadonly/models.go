@@ -0,0 +1,47 @@
+package orgreadonly
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOrgReadOnly is matched by the errors returned for changes to read-only organizations
+var ErrOrgReadOnly = errors.New("the organization is read-only")
+
+// ReadOnlyError is returned by CheckWritable for read-only organizations, with the reason they are read-only
+type ReadOnlyError struct {
+	OrgID  int64
+	Reason string
+}
+
+func (e ReadOnlyError) Error() string {
+	return ErrOrgReadOnly.Error()
+}
+
+func (e ReadOnlyError) Is(err error) bool {
+	return err == ErrOrgReadOnly
+}
+
+// Response returns the body of the responses denying the changes
+func (e ReadOnlyError) Response() map[string]interface{} {
+	return map[string]interface{}{
+		"message": "The organization is read-only",
+		"reason":  e.Reason,
+	}
+}
+
+// State is the read-only state of an organization
+type State struct {
+	OrgID    int64 `json:"orgId"`
+	ReadOnly bool  `json:"readOnly"`
+	// Reason is shown to the users whose changes are denied, such as "Frozen for the incident 42 investigation"
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+	Updated   *time.Time `json:"updated,omitempty"`
+}
+
+// SetStateCommand turns the read-only mode of an organization on or off
+type SetStateCommand struct {
+	ReadOnly bool   `json:"readOnly"`
+	Reason   string `json:"reason"`
+}
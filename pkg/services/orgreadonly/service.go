@@ -0,0 +1,110 @@
+package orgreadonly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	kvNamespace = "org_read_only"
+	kvKey       = "state"
+
+	// cacheTTL is how long the read-only state of the organizations is cached, the changes made through the other
+	// Grafana instances of a cluster apply after it
+	cacheTTL = time.Minute
+)
+
+// Service manages the read-only mode of the organizations. The changes to a read-only organization are denied by
+// Middleware, except the ones of the server admins, so that the organization can be kept as a reference or frozen
+// for an investigation.
+type Service struct {
+	kv    kvstore.KVStore
+	cache *localcache.CacheService
+	log   log.Logger
+	now   func() time.Time
+}
+
+func ProvideService(kvStore kvstore.KVStore, routeRegister routing.RouteRegister, cacheRegistry *localcache.Registry) *Service {
+	s := &Service{
+		kv:    kvStore,
+		cache: localcache.NewNamed("org-read-only", cacheTTL, 2*cacheTTL),
+		log:   log.New("orgreadonly"),
+		now:   time.Now,
+	}
+	cacheRegistry.Register(s.cache)
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// GetState returns the read-only state of the organization
+func (s *Service) GetState(ctx context.Context, orgID int64) (*State, error) {
+	cacheKey := strconv.FormatInt(orgID, 10)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		return cached.(*State), nil
+	}
+
+	state := &State{OrgID: orgID}
+	value, ok, err := s.kv.Get(ctx, orgID, kvNamespace, kvKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := json.Unmarshal([]byte(value), state); err != nil {
+			return nil, fmt.Errorf("invalid read-only state of organization %d: %w", orgID, err)
+		}
+		state.OrgID = orgID
+	}
+
+	s.cache.Set(cacheKey, state, 0)
+	return state, nil
+}
+
+// CheckWritable returns a ReadOnlyError when the organization is read-only. Middleware checks the organization the
+// requests are made in, the changes made from it to other organizations are checked with CheckWritable.
+func (s *Service) CheckWritable(ctx context.Context, orgID int64) error {
+	state, err := s.GetState(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if state.ReadOnly {
+		return ReadOnlyError{OrgID: orgID, Reason: state.Reason}
+	}
+	return nil
+}
+
+// SetState turns the read-only mode of the organization on or off
+func (s *Service) SetState(ctx context.Context, user *models.SignedInUser, orgID int64, cmd SetStateCommand) (*State, error) {
+	if err := bus.Dispatch(ctx, &models.GetOrgByIdQuery{Id: orgID}); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	state := &State{
+		OrgID:     orgID,
+		ReadOnly:  cmd.ReadOnly,
+		Reason:    cmd.Reason,
+		UpdatedBy: user.Login,
+		Updated:   &now,
+	}
+	value, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.kv.Set(ctx, orgID, kvNamespace, kvKey, string(value)); err != nil {
+		return nil, err
+	}
+
+	s.cache.Delete(strconv.FormatInt(orgID, 10))
+	s.log.Info("Organization read-only mode changed", "orgId", orgID, "readOnly", cmd.ReadOnly, "user", user.Login, "reason", cmd.Reason)
+	return state, nil
+}
@@ -0,0 +1,104 @@
+package orgreadonly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestService_State(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(kvstore.ProvideService(store), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	org, err := store.CreateOrgWithMember("Reference", 0)
+	require.NoError(t, err)
+	admin := &models.SignedInUser{UserId: 1, Login: "admin", IsGrafanaAdmin: true}
+
+	t.Run("should not be read-only by default", func(t *testing.T) {
+		state, err := s.GetState(ctx, org.Id)
+		require.NoError(t, err)
+		assert.Equal(t, &State{OrgID: org.Id}, state)
+	})
+
+	t.Run("should turn the read-only mode on and off", func(t *testing.T) {
+		state, err := s.SetState(ctx, admin, org.Id, SetStateCommand{ReadOnly: true, Reason: "Frozen"})
+		require.NoError(t, err)
+		assert.True(t, state.ReadOnly)
+
+		state, err = s.GetState(ctx, org.Id)
+		require.NoError(t, err)
+		assert.True(t, state.ReadOnly)
+		assert.Equal(t, "Frozen", state.Reason)
+
+		err = s.CheckWritable(ctx, org.Id)
+		require.ErrorIs(t, err, ErrOrgReadOnly)
+		assert.Equal(t, ReadOnlyError{OrgID: org.Id, Reason: "Frozen"}, err)
+		assert.Equal(t, "admin", state.UpdatedBy)
+		assert.Equal(t, now, *state.Updated)
+
+		_, err = s.SetState(ctx, admin, org.Id, SetStateCommand{ReadOnly: false})
+		require.NoError(t, err)
+		state, err = s.GetState(ctx, org.Id)
+		require.NoError(t, err)
+		assert.False(t, state.ReadOnly)
+		assert.NoError(t, s.CheckWritable(ctx, org.Id))
+	})
+
+	t.Run("should refuse unknown organizations", func(t *testing.T) {
+		_, err := s.SetState(ctx, admin, 42, SetStateCommand{ReadOnly: true})
+		require.ErrorIs(t, err, models.ErrOrgNotFound)
+	})
+}
+
+func TestService_Middleware(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(kvstore.ProvideService(store), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	org, err := store.CreateOrgWithMember("Reference", 0)
+	require.NoError(t, err)
+	_, err = s.SetState(ctx, &models.SignedInUser{Login: "admin"}, org.Id, SetStateCommand{ReadOnly: true})
+	require.NoError(t, err)
+
+	request := func(user *models.SignedInUser, method string, path string) int {
+		rec := httptest.NewRecorder()
+		c := &models.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(method, path, nil), Resp: web.NewResponseWriter(method, rec)},
+			SignedInUser: user,
+			IsSignedIn:   !user.IsAnonymous,
+		}
+		s.Middleware(c)
+		return rec.Code
+	}
+
+	editor := &models.SignedInUser{UserId: 2, OrgId: org.Id, OrgRole: models.ROLE_EDITOR}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		assert.Equal(t, http.StatusForbidden, request(editor, method, "/api/dashboards/db"), method)
+	}
+	assert.Equal(t, http.StatusForbidden, request(&models.SignedInUser{OrgId: org.Id, ApiKeyId: 1}, http.MethodPost, "/api/folders"))
+	assert.Equal(t, http.StatusForbidden, request(&models.SignedInUser{OrgId: org.Id, IsAnonymous: true}, http.MethodPost, "/api/annotations"))
+
+	for _, path := range []string{"/api/ds/query", "/api/user/using/1", "/api/user", "/api/user/preferences", "/api/user/2fa/totp", "/login"} {
+		assert.Equal(t, http.StatusOK, request(editor, http.MethodPost, path), path)
+	}
+	for _, path := range []string{"/api/datasources/proxy/1/api/v1/query", "/api/user/access-tokens", "/api/users/2", "/api/user/preferences-other"} {
+		assert.Equal(t, http.StatusForbidden, request(editor, http.MethodPost, path), path)
+	}
+	assert.Equal(t, http.StatusOK, request(editor, http.MethodGet, "/api/dashboards/uid/abc"))
+	assert.Equal(t, http.StatusOK, request(editor, http.MethodGet, "/api/datasources/proxy/1/api/v1/query"))
+	assert.Equal(t, http.StatusOK, request(&models.SignedInUser{UserId: 1, OrgId: org.Id, IsGrafanaAdmin: true}, http.MethodPost, "/api/dashboards/db"))
+	assert.Equal(t, http.StatusOK, request(&models.SignedInUser{UserId: 2, OrgId: org.Id + 1}, http.MethodPost, "/api/dashboards/db"))
+}
@@ -0,0 +1,111 @@
+package ownership
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	readEvaluator := accesscontrol.EvalPermission(ActionRead, ScopeAll)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite, ScopeAll)
+
+	s.routeRegister.Group("/api/ownership", func(ownershipRoute routing.RouteRegister) {
+		ownershipRoute.Get("/orphans", auth(middleware.ReqOrgAdmin, readEvaluator), routing.Wrap(s.getOrphansHandler))
+		ownershipRoute.Post("/reassign", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.reassignOwnerHandler))
+		ownershipRoute.Get("/:resourceType/:uid", auth(middleware.ReqOrgAdmin, readEvaluator), routing.Wrap(s.getOwnerHandler))
+		ownershipRoute.Put("/:resourceType/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.setOwnerHandler))
+		ownershipRoute.Delete("/:resourceType/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.deleteOwnerHandler))
+	})
+}
+
+// GET /api/ownership/:resourceType/:uid
+func (s *Service) getOwnerHandler(c *models.ReqContext) response.Response {
+	params := web.Params(c.Req)
+	owner, err := s.GetOwner(c.Req.Context(), c.OrgId, ResourceType(params[":resourceType"]), params[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get owner")
+	}
+	return response.JSON(http.StatusOK, owner)
+}
+
+// PUT /api/ownership/:resourceType/:uid
+func (s *Service) setOwnerHandler(c *models.ReqContext) response.Response {
+	cmd := SetOwnerCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	params := web.Params(c.Req)
+	cmd.OrgID = c.OrgId
+	cmd.ResourceType = ResourceType(params[":resourceType"])
+	cmd.ResourceUID = params[":uid"]
+	cmd.UpdatedBy = c.UserId
+
+	owner, err := s.SetOwner(c.Req.Context(), cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to set owner")
+	}
+	return response.JSON(http.StatusOK, owner)
+}
+
+// DELETE /api/ownership/:resourceType/:uid
+func (s *Service) deleteOwnerHandler(c *models.ReqContext) response.Response {
+	params := web.Params(c.Req)
+	if err := s.DeleteOwner(c.Req.Context(), c.OrgId, ResourceType(params[":resourceType"]), params[":uid"]); err != nil {
+		return errorResponse(err, "Failed to remove owner")
+	}
+	return response.Success("Owner removed")
+}
+
+// POST /api/ownership/reassign
+func (s *Service) reassignOwnerHandler(c *models.ReqContext) response.Response {
+	cmd := ReassignOwnerCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgId
+	cmd.UpdatedBy = c.UserId
+
+	count, err := s.ReassignOwner(c.Req.Context(), cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to reassign owner")
+	}
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "Owner reassigned",
+		"reassigned": count,
+	})
+}
+
+// GET /api/ownership/orphans
+func (s *Service) getOrphansHandler(c *models.ReqContext) response.Response {
+	query := GetOrphansQuery{OrgID: c.OrgId}
+	for _, rt := range c.QueryStrings("type") {
+		query.ResourceTypes = append(query.ResourceTypes, ResourceType(rt))
+	}
+
+	orphans, err := s.GetOrphans(c.Req.Context(), query)
+	if err != nil {
+		return errorResponse(err, "Failed to get orphaned resources")
+	}
+	return response.JSON(http.StatusOK, orphans)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrOwnerNotFound), errors.Is(err, ErrResourceNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidResourceType), errors.Is(err, ErrInvalidOwner), errors.Is(err, ErrInvalidReassignSource),
+		errors.Is(err, ErrOwnerUserNotInOrg), errors.Is(err, ErrOwnerTeamNotFound):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,18 @@
+package ownership
+
+// DashboardOwnerFilter is a dashboard search filter matching the dashboards and folders owned by a user or a team.
+type DashboardOwnerFilter struct {
+	UserID int64
+	TeamID int64
+}
+
+func (f DashboardOwnerFilter) Where() (string, []interface{}) {
+	column, ownerID := "owner_user_id", f.UserID
+	if f.TeamID != 0 {
+		column, ownerID = "owner_team_id", f.TeamID
+	}
+
+	return `dashboard.uid IN (SELECT ro.resource_uid FROM resource_owner AS ro
+		WHERE ro.org_id = dashboard.org_id AND ro.resource_type IN (?, ?) AND ro.` + column + ` = ?)`,
+		[]interface{}{ResourceTypeDashboard, ResourceTypeFolder, ownerID}
+}
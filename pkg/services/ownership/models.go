@@ -0,0 +1,92 @@
+package ownership
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrOwnerNotFound         = errors.New("owner not found")
+	ErrResourceNotFound      = errors.New("resource not found")
+	ErrInvalidResourceType   = errors.New("invalid resource type")
+	ErrInvalidOwner          = errors.New("exactly one of user or team must be set as owner")
+	ErrOwnerUserNotInOrg     = errors.New("owner user is not a member of the organization")
+	ErrOwnerTeamNotFound     = errors.New("owner team not found")
+	ErrInvalidReassignSource = errors.New("exactly one of fromUserId or fromTeamId must be set")
+)
+
+const (
+	ActionRead  = "ownership:read"
+	ActionWrite = "ownership:write"
+
+	ScopeAll = "ownership:*"
+)
+
+type ResourceType string
+
+const (
+	ResourceTypeDashboard  ResourceType = "dashboard"
+	ResourceTypeFolder     ResourceType = "folder"
+	ResourceTypeDataSource ResourceType = "datasource"
+	ResourceTypeAlertRule  ResourceType = "alert_rule"
+)
+
+var ResourceTypes = []ResourceType{ResourceTypeDashboard, ResourceTypeFolder, ResourceTypeDataSource, ResourceTypeAlertRule}
+
+func (t ResourceType) IsValid() bool {
+	for _, rt := range ResourceTypes {
+		if t == rt {
+			return true
+		}
+	}
+	return false
+}
+
+type OrphanReason string
+
+const (
+	OrphanReasonUserLeftOrg OrphanReason = "userLeftOrg"
+	OrphanReasonTeamDeleted OrphanReason = "teamDeleted"
+)
+
+// ResourceOwner records the user or team responsible for a resource.
+type ResourceOwner struct {
+	Id           int64        `json:"-"`
+	OrgId        int64        `json:"-"`
+	ResourceType ResourceType `json:"resourceType"`
+	ResourceUID  string       `json:"resourceUid" xorm:"resource_uid"`
+	OwnerUserId  int64        `json:"ownerUserId,omitempty"`
+	OwnerTeamId  int64        `json:"ownerTeamId,omitempty"`
+	Updated      time.Time    `json:"updated"`
+	UpdatedBy    int64        `json:"updatedBy"`
+}
+
+// Orphan is a resource whose owner has left the organization or has been deleted.
+type Orphan struct {
+	ResourceOwner
+	Reason OrphanReason `json:"reason"`
+}
+
+type SetOwnerCommand struct {
+	OrgID        int64        `json:"-"`
+	ResourceType ResourceType `json:"-"`
+	ResourceUID  string       `json:"-"`
+	UpdatedBy    int64        `json:"-"`
+	UserID       int64        `json:"userId"`
+	TeamID       int64        `json:"teamId"`
+}
+
+type ReassignOwnerCommand struct {
+	OrgID         int64          `json:"-"`
+	UpdatedBy     int64          `json:"-"`
+	FromUserID    int64          `json:"fromUserId"`
+	FromTeamID    int64          `json:"fromTeamId"`
+	ToUserID      int64          `json:"toUserId"`
+	ToTeamID      int64          `json:"toTeamId"`
+	ResourceTypes []ResourceType `json:"resourceTypes"`
+}
+
+type GetOrphansQuery struct {
+	OrgID         int64
+	ResourceTypes []ResourceType
+}
@@ -0,0 +1,115 @@
+package ownership
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Service keeps track of the user or team owning dashboards, folders, data sources and alert rules, so that
+// resources left behind by users leaving the organization can be found and handed over.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	log           log.Logger
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister) (*Service, error) {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		log:           log.New("ownership"),
+	}
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s, nil
+}
+
+// GetOwner returns the owner of a resource.
+func (s *Service) GetOwner(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) (*ResourceOwner, error) {
+	if !resourceType.IsValid() {
+		return nil, ErrInvalidResourceType
+	}
+	return s.getOwner(ctx, orgID, resourceType, resourceUID)
+}
+
+// SetOwner sets the user or team owning a resource, replacing the previous owner.
+func (s *Service) SetOwner(ctx context.Context, cmd SetOwnerCommand) (*ResourceOwner, error) {
+	if !cmd.ResourceType.IsValid() {
+		return nil, ErrInvalidResourceType
+	}
+	return s.setOwner(ctx, cmd)
+}
+
+// DeleteOwner removes the owner of a resource.
+func (s *Service) DeleteOwner(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) error {
+	if !resourceType.IsValid() {
+		return ErrInvalidResourceType
+	}
+	return s.deleteOwner(ctx, orgID, resourceType, resourceUID)
+}
+
+// ReassignOwner transfers all resources owned by a user or team to another user or team and returns
+// the number of resources that have been reassigned.
+func (s *Service) ReassignOwner(ctx context.Context, cmd ReassignOwnerCommand) (int64, error) {
+	if (cmd.FromUserID > 0) == (cmd.FromTeamID > 0) {
+		return 0, ErrInvalidReassignSource
+	}
+	for _, rt := range cmd.ResourceTypes {
+		if !rt.IsValid() {
+			return 0, ErrInvalidResourceType
+		}
+	}
+	return s.reassignOwner(ctx, cmd)
+}
+
+// GetOrphans returns the resources whose owner has left the organization or has been deleted.
+func (s *Service) GetOrphans(ctx context.Context, query GetOrphansQuery) ([]Orphan, error) {
+	for _, rt := range query.ResourceTypes {
+		if !rt.IsValid() {
+			return nil, ErrInvalidResourceType
+		}
+	}
+	return s.getOrphans(ctx, query)
+}
+
+func (s *Service) registerRoles() error {
+	reader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:ownership:reader",
+			DisplayName: "Ownership reader",
+			Description: "Read resource owners and list resources whose owner left the organization.",
+			Group:       "Ownership",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionRead, Scope: ScopeAll},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:ownership:writer",
+			DisplayName: "Ownership writer",
+			Description: "Read, set and reassign resource owners.",
+			Group:       "Ownership",
+			Permissions: accesscontrol.ConcatPermissions(reader.Role.Permissions, []accesscontrol.Permission{
+				{Action: ActionWrite, Scope: ScopeAll},
+			}),
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(reader, writer)
+}
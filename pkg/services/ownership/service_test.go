@@ -0,0 +1,148 @@
+package ownership
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Ownership(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s, err := ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister())
+	require.NoError(t, err)
+
+	admin, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "admin"})
+	require.NoError(t, err)
+	org, err := store.CreateOrgWithMember("test", admin.Id)
+	require.NoError(t, err)
+	editor, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "editor"})
+	require.NoError(t, err)
+	require.NoError(t, store.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: org.Id, UserId: editor.Id, Role: models.ROLE_EDITOR}))
+	team, err := store.CreateTeam("team", "", org.Id)
+	require.NoError(t, err)
+
+	dash, err := store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     org.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "dashboard", "uid": "dash"}),
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: org.Id, Name: "ds", Uid: "ds", Type: "prometheus", Access: models.DS_ACCESS_PROXY}))
+
+	t.Run("should fail to set owner of a resource that doesn't exist", func(t *testing.T) {
+		_, err := s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeFolder, ResourceUID: dash.Uid, UserID: editor.Id})
+		assert.ErrorIs(t, err, ErrResourceNotFound)
+	})
+
+	t.Run("should require exactly one owner", func(t *testing.T) {
+		_, err := s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid, UserID: editor.Id, TeamID: team.Id})
+		assert.ErrorIs(t, err, ErrInvalidOwner)
+		_, err = s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid})
+		assert.ErrorIs(t, err, ErrInvalidOwner)
+	})
+
+	t.Run("should fail to set owner from another org", func(t *testing.T) {
+		_, err := s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid, UserID: 1000})
+		assert.ErrorIs(t, err, ErrOwnerUserNotInOrg)
+		_, err = s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid, TeamID: 1000})
+		assert.ErrorIs(t, err, ErrOwnerTeamNotFound)
+	})
+
+	t.Run("should set and replace owner", func(t *testing.T) {
+		_, err := s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid, TeamID: team.Id})
+		require.NoError(t, err)
+		_, err = s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDashboard, ResourceUID: dash.Uid, UserID: editor.Id, UpdatedBy: admin.Id})
+		require.NoError(t, err)
+		_, err = s.SetOwner(ctx, SetOwnerCommand{OrgID: org.Id, ResourceType: ResourceTypeDataSource, ResourceUID: "ds", UserID: editor.Id})
+		require.NoError(t, err)
+
+		owner, err := s.GetOwner(ctx, org.Id, ResourceTypeDashboard, dash.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, editor.Id, owner.OwnerUserId)
+		assert.Zero(t, owner.OwnerTeamId)
+		assert.Equal(t, admin.Id, owner.UpdatedBy)
+	})
+
+	t.Run("should report resources of users that left the org", func(t *testing.T) {
+		orphans, err := s.GetOrphans(ctx, GetOrphansQuery{OrgID: org.Id})
+		require.NoError(t, err)
+		assert.Len(t, orphans, 0)
+
+		require.NoError(t, store.RemoveOrgUser(ctx, &models.RemoveOrgUserCommand{OrgId: org.Id, UserId: editor.Id}))
+
+		orphans, err = s.GetOrphans(ctx, GetOrphansQuery{OrgID: org.Id})
+		require.NoError(t, err)
+		require.Len(t, orphans, 2)
+		assert.Equal(t, ResourceTypeDashboard, orphans[0].ResourceType)
+		assert.Equal(t, OrphanReasonUserLeftOrg, orphans[0].Reason)
+		assert.Equal(t, ResourceTypeDataSource, orphans[1].ResourceType)
+
+		orphans, err = s.GetOrphans(ctx, GetOrphansQuery{OrgID: org.Id, ResourceTypes: []ResourceType{ResourceTypeDataSource}})
+		require.NoError(t, err)
+		require.Len(t, orphans, 1)
+		assert.Equal(t, "ds", orphans[0].ResourceUID)
+	})
+
+	t.Run("should reassign resources in bulk", func(t *testing.T) {
+		_, err := s.ReassignOwner(ctx, ReassignOwnerCommand{OrgID: org.Id, ToUserID: admin.Id})
+		assert.ErrorIs(t, err, ErrInvalidReassignSource)
+
+		count, err := s.ReassignOwner(ctx, ReassignOwnerCommand{OrgID: org.Id, FromUserID: editor.Id, ToTeamID: team.Id, ResourceTypes: []ResourceType{ResourceTypeDashboard}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = s.ReassignOwner(ctx, ReassignOwnerCommand{OrgID: org.Id, FromUserID: editor.Id, ToUserID: admin.Id})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		orphans, err := s.GetOrphans(ctx, GetOrphansQuery{OrgID: org.Id})
+		require.NoError(t, err)
+		assert.Len(t, orphans, 0)
+	})
+
+	t.Run("should filter dashboard search by owner", func(t *testing.T) {
+		findDashboards := func(t *testing.T, filter DashboardOwnerFilter) []string {
+			t.Helper()
+			query := &search.FindPersistedDashboardsQuery{
+				OrgId:        org.Id,
+				SignedInUser: &models.SignedInUser{OrgId: org.Id, UserId: admin.Id, OrgRole: models.ROLE_ADMIN},
+				Filters:      []interface{}{filter},
+			}
+			require.NoError(t, store.SearchDashboards(ctx, query))
+			uids := make([]string, 0, len(query.Result))
+			for _, hit := range query.Result {
+				uids = append(uids, hit.UID)
+			}
+			return uids
+		}
+
+		assert.Equal(t, []string{dash.Uid}, findDashboards(t, DashboardOwnerFilter{TeamID: team.Id}))
+		assert.Empty(t, findDashboards(t, DashboardOwnerFilter{UserID: admin.Id}))
+	})
+
+	t.Run("should report resources of deleted teams", func(t *testing.T) {
+		require.NoError(t, sqlstore.DeleteTeam(ctx, &models.DeleteTeamCommand{OrgId: org.Id, Id: team.Id}))
+
+		orphans, err := s.GetOrphans(ctx, GetOrphansQuery{OrgID: org.Id})
+		require.NoError(t, err)
+		require.Len(t, orphans, 1)
+		assert.Equal(t, dash.Uid, orphans[0].ResourceUID)
+		assert.Equal(t, OrphanReasonTeamDeleted, orphans[0].Reason)
+	})
+
+	t.Run("should remove owner", func(t *testing.T) {
+		require.NoError(t, s.DeleteOwner(ctx, org.Id, ResourceTypeDashboard, dash.Uid))
+		_, err := s.GetOwner(ctx, org.Id, ResourceTypeDashboard, dash.Uid)
+		assert.ErrorIs(t, err, ErrOwnerNotFound)
+		assert.ErrorIs(t, s.DeleteOwner(ctx, org.Id, ResourceTypeDashboard, dash.Uid), ErrOwnerNotFound)
+	})
+}
@@ -0,0 +1,208 @@
+package ownership
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getOwner(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) (*ResourceOwner, error) {
+	owner := &ResourceOwner{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND resource_type = ? AND resource_uid = ?", orgID, resourceType, resourceUID).Get(owner)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrOwnerNotFound
+		}
+		return nil
+	})
+	return owner, err
+}
+
+func (s *Service) setOwner(ctx context.Context, cmd SetOwnerCommand) (*ResourceOwner, error) {
+	owner := &ResourceOwner{}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := s.resourceExists(sess, cmd.OrgID, cmd.ResourceType, cmd.ResourceUID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrResourceNotFound
+		}
+
+		if err := validateOwner(sess, cmd.OrgID, cmd.UserID, cmd.TeamID); err != nil {
+			return err
+		}
+
+		has, err := sess.Where("org_id = ? AND resource_type = ? AND resource_uid = ?", cmd.OrgID, cmd.ResourceType, cmd.ResourceUID).Get(owner)
+		if err != nil {
+			return err
+		}
+
+		owner.OrgId = cmd.OrgID
+		owner.ResourceType = cmd.ResourceType
+		owner.ResourceUID = cmd.ResourceUID
+		owner.OwnerUserId = cmd.UserID
+		owner.OwnerTeamId = cmd.TeamID
+		owner.Updated = time.Now()
+		owner.UpdatedBy = cmd.UpdatedBy
+
+		if has {
+			_, err = sess.ID(owner.Id).Cols("owner_user_id", "owner_team_id", "updated", "updated_by").Update(owner)
+			return err
+		}
+		_, err = sess.Insert(owner)
+		return err
+	})
+	return owner, err
+}
+
+func (s *Service) deleteOwner(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM resource_owner WHERE org_id = ? AND resource_type = ? AND resource_uid = ?", orgID, resourceType, resourceUID)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return ErrOwnerNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Service) reassignOwner(ctx context.Context, cmd ReassignOwnerCommand) (int64, error) {
+	var affected int64
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateOwner(sess, cmd.OrgID, cmd.ToUserID, cmd.ToTeamID); err != nil {
+			return err
+		}
+
+		sql := strings.Builder{}
+		sql.WriteString("UPDATE resource_owner SET owner_user_id = ?, owner_team_id = ?, updated = ?, updated_by = ? WHERE org_id = ?")
+		params := []interface{}{cmd.ToUserID, cmd.ToTeamID, time.Now(), cmd.UpdatedBy, cmd.OrgID}
+
+		if cmd.FromUserID > 0 {
+			sql.WriteString(" AND owner_user_id = ?")
+			params = append(params, cmd.FromUserID)
+		} else {
+			sql.WriteString(" AND owner_team_id = ?")
+			params = append(params, cmd.FromTeamID)
+		}
+		params = appendResourceTypeFilter(&sql, params, "resource_type", cmd.ResourceTypes)
+
+		res, err := sess.Exec(append([]interface{}{sql.String()}, params...)...)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
+
+// getOrphans returns the owner records of existing resources whose owner user is no longer a member of the
+// organization or whose owner team has been deleted.
+func (s *Service) getOrphans(ctx context.Context, query GetOrphansQuery) ([]Orphan, error) {
+	var owners []ResourceOwner
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sql := strings.Builder{}
+		sql.WriteString(`SELECT ro.* FROM resource_owner AS ro WHERE ro.org_id = ? AND (
+			(ro.owner_user_id > 0 AND NOT EXISTS (SELECT 1 FROM org_user AS ou WHERE ou.org_id = ro.org_id AND ou.user_id = ro.owner_user_id)) OR
+			(ro.owner_team_id > 0 AND NOT EXISTS (SELECT 1 FROM team AS t WHERE t.org_id = ro.org_id AND t.id = ro.owner_team_id)))`)
+		params := []interface{}{query.OrgID}
+
+		sql.WriteString(" AND (" + s.resourceExistsFilter("ro") + ")")
+		params = appendResourceTypeFilter(&sql, params, "ro.resource_type", query.ResourceTypes)
+		sql.WriteString(" ORDER BY ro.resource_type, ro.resource_uid")
+
+		return sess.SQL(sql.String(), params...).Find(&owners)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]Orphan, 0, len(owners))
+	for _, owner := range owners {
+		reason := OrphanReasonUserLeftOrg
+		if owner.OwnerTeamId > 0 {
+			reason = OrphanReasonTeamDeleted
+		}
+		orphans = append(orphans, Orphan{ResourceOwner: owner, Reason: reason})
+	}
+	return orphans, nil
+}
+
+func (s *Service) resourceExists(sess *sqlstore.DBSession, orgID int64, resourceType ResourceType, resourceUID string) (bool, error) {
+	dialect := s.sqlStore.Dialect
+	switch resourceType {
+	case ResourceTypeDashboard:
+		return sess.SQL("SELECT 1 FROM dashboard WHERE org_id = ? AND uid = ? AND is_folder = "+dialect.BooleanStr(false), orgID, resourceUID).Exist()
+	case ResourceTypeFolder:
+		return sess.SQL("SELECT 1 FROM dashboard WHERE org_id = ? AND uid = ? AND is_folder = "+dialect.BooleanStr(true), orgID, resourceUID).Exist()
+	case ResourceTypeDataSource:
+		return sess.SQL("SELECT 1 FROM data_source WHERE org_id = ? AND uid = ?", orgID, resourceUID).Exist()
+	case ResourceTypeAlertRule:
+		return sess.SQL("SELECT 1 FROM alert_rule WHERE org_id = ? AND uid = ?", orgID, resourceUID).Exist()
+	}
+	return false, ErrInvalidResourceType
+}
+
+// resourceExistsFilter builds a condition matching the rows of the aliased resource_owner table
+// whose resource still exists.
+func (s *Service) resourceExistsFilter(alias string) string {
+	dialect := s.sqlStore.Dialect
+	cond := func(resourceType ResourceType, table, extra string) string {
+		return fmt.Sprintf("(%[1]s.resource_type = '%[2]s' AND EXISTS (SELECT 1 FROM %[3]s AS r WHERE r.org_id = %[1]s.org_id AND r.uid = %[1]s.resource_uid%[4]s))",
+			alias, resourceType, table, extra)
+	}
+
+	return strings.Join([]string{
+		cond(ResourceTypeDashboard, "dashboard", " AND r.is_folder = "+dialect.BooleanStr(false)),
+		cond(ResourceTypeFolder, "dashboard", " AND r.is_folder = "+dialect.BooleanStr(true)),
+		cond(ResourceTypeDataSource, "data_source", ""),
+		cond(ResourceTypeAlertRule, "alert_rule", ""),
+	}, " OR ")
+}
+
+func validateOwner(sess *sqlstore.DBSession, orgID, userID, teamID int64) error {
+	if (userID > 0) == (teamID > 0) {
+		return ErrInvalidOwner
+	}
+
+	if userID > 0 {
+		has, err := sess.SQL("SELECT 1 FROM org_user WHERE org_id = ? AND user_id = ?", orgID, userID).Exist()
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrOwnerUserNotInOrg
+		}
+		return nil
+	}
+
+	has, err := sess.SQL("SELECT 1 FROM team WHERE org_id = ? AND id = ?", orgID, teamID).Exist()
+	if err != nil {
+		return err
+	}
+	if !has {
+		return ErrOwnerTeamNotFound
+	}
+	return nil
+}
+
+func appendResourceTypeFilter(sql *strings.Builder, params []interface{}, column string, resourceTypes []ResourceType) []interface{} {
+	if len(resourceTypes) == 0 {
+		return params
+	}
+
+	sql.WriteString(" AND " + column + " IN (?" + strings.Repeat(",?", len(resourceTypes)-1) + ")")
+	for _, rt := range resourceTypes {
+		params = append(params, rt)
+	}
+	return params
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/dashboards"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -20,6 +21,8 @@ type DashboardProvisioner interface {
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 	CleanUpOrphanedDashboards(ctx context.Context)
+	Drift(ctx context.Context) ([]*drift.Resource, error)
+	Reconcile(ctx context.Context, ids []string) ([]*drift.Resource, error)
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
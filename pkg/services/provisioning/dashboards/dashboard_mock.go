@@ -1,6 +1,10 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -8,6 +12,8 @@ type calls struct {
 	PollChanges                 []interface{}
 	GetProvisionerResolvedPath  []interface{}
 	GetAllowUIUpdatesFromConfig []interface{}
+	Drift                       []interface{}
+	Reconcile                   []interface{}
 }
 
 // ProvisionerMock is a mock implementation of `Provisioner`
@@ -17,6 +23,8 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	DriftFunc                       func(ctx context.Context) ([]*drift.Resource, error)
+	ReconcileFunc                   func(ctx context.Context, ids []string) ([]*drift.Resource, error)
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -63,3 +71,21 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 
 // CleanUpOrphanedDashboards not implemented for mocks
 func (dpm *ProvisionerMock) CleanUpOrphanedDashboards(ctx context.Context) {}
+
+// Drift is a mock implementation of `Provisioner.Drift`
+func (dpm *ProvisionerMock) Drift(ctx context.Context) ([]*drift.Resource, error) {
+	dpm.Calls.Drift = append(dpm.Calls.Drift, nil)
+	if dpm.DriftFunc != nil {
+		return dpm.DriftFunc(ctx)
+	}
+	return nil, nil
+}
+
+// Reconcile is a mock implementation of `Provisioner.Reconcile`
+func (dpm *ProvisionerMock) Reconcile(ctx context.Context, ids []string) ([]*drift.Resource, error) {
+	dpm.Calls.Reconcile = append(dpm.Calls.Reconcile, ids)
+	if dpm.ReconcileFunc != nil {
+		return dpm.ReconcileFunc(ctx, ids)
+	}
+	return nil, nil
+}
@@ -0,0 +1,227 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+)
+
+// dashboardDrift is a dashboard whose database state differs from its file, together with what reconciling it
+// requires
+type dashboardDrift struct {
+	resource *drift.Resource
+	path     string
+	fileInfo os.FileInfo
+	ref      *models.DashboardProvisioning
+}
+
+// Drift compares the dashboards of the provisioning files with the database
+func (provider *Provisioner) Drift(ctx context.Context) ([]*drift.Resource, error) {
+	result := make([]*drift.Resource, 0)
+	for _, reader := range provider.fileReaders {
+		drifts, err := reader.drift(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range drifts {
+			result = append(result, d.resource)
+		}
+	}
+	return result, nil
+}
+
+// Reconcile saves the files of the drifted dashboards with the IDs, or of all of them when there is no ID, and
+// returns the reconciled dashboards. Extra dashboards are deleted, or unprovisioned when the provisioner disables
+// deletion.
+func (provider *Provisioner) Reconcile(ctx context.Context, ids []string) ([]*drift.Resource, error) {
+	set := drift.NewIDSet(ids)
+	result := make([]*drift.Resource, 0)
+	for _, reader := range provider.fileReaders {
+		drifts, err := reader.drift(ctx)
+		if err != nil {
+			return result, err
+		}
+		for _, d := range drifts {
+			if !set.Has(d.resource.ID) {
+				continue
+			}
+			if err := reader.reconcile(ctx, d); err != nil {
+				return result, err
+			}
+			result = append(result, d.resource)
+		}
+	}
+	return result, nil
+}
+
+func (fr *FileReader) drift(ctx context.Context) ([]*dashboardDrift, error) {
+	resolvedPath := fr.resolvedPath()
+	if _, err := os.Stat(resolvedPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	provisionedDashboardRefs, err := getProvisionedDashboardsByPath(fr.dashboardProvisioningService, fr.Cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	filesFoundOnDisk := map[string]os.FileInfo{}
+	if err := filepath.Walk(resolvedPath, createWalkFn(filesFoundOnDisk)); err != nil {
+		return nil, err
+	}
+
+	drifts := make([]*dashboardDrift, 0)
+	for path, fileInfo := range filesFoundOnDisk {
+		d, err := fr.fileDrift(ctx, path, resolvedPath, fileInfo, provisionedDashboardRefs[path])
+		if err != nil {
+			fr.log.Error("failed to compare dashboard", "file", path, "error", err)
+			continue
+		}
+		if d != nil {
+			drifts = append(drifts, d)
+		}
+	}
+
+	for path, ref := range provisionedDashboardRefs {
+		if _, existsOnDisk := filesFoundOnDisk[path]; existsOnDisk {
+			continue
+		}
+		resource := fr.resource(path, resolvedPath)
+		resource.Status = drift.StatusExtra
+		query := &models.GetDashboardQuery{Id: ref.DashboardId, OrgId: fr.Cfg.OrgID}
+		if err := bus.Dispatch(ctx, query); err == nil {
+			resource.UID = query.Result.Uid
+			resource.Name = query.Result.Title
+		}
+		drifts = append(drifts, &dashboardDrift{resource: resource, path: path, ref: ref})
+	}
+
+	return drifts, nil
+}
+
+// fileDrift compares the dashboard of the file with the database, and returns nil when they match
+func (fr *FileReader) fileDrift(ctx context.Context, path, resolvedPath string, fileInfo os.FileInfo,
+	ref *models.DashboardProvisioning) (*dashboardDrift, error) {
+	resolvedFileInfo, err := resolveSymlink(fileInfo, path)
+	if err != nil {
+		return nil, err
+	}
+	jsonFile, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := fr.resource(path, resolvedPath)
+	resource.UID = jsonFile.dashboard.Dashboard.Uid
+	resource.Name = jsonFile.dashboard.Dashboard.Title
+	d := &dashboardDrift{resource: resource, path: path, fileInfo: fileInfo, ref: ref}
+
+	if ref == nil {
+		resource.Status = drift.StatusDeleted
+		return d, nil
+	}
+	query := &models.GetDashboardQuery{Id: ref.DashboardId, OrgId: fr.Cfg.OrgID}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			resource.Status = drift.StatusDeleted
+			return d, nil
+		}
+		return nil, err
+	}
+	existing := query.Result
+	resource.UID = existing.Uid
+
+	withoutUID := jsonFile.dashboard.Dashboard.Uid == ""
+	expected := map[string]interface{}{"dashboard": dashboardContent(jsonFile.dashboard.Dashboard.Data, withoutUID)}
+	actual := map[string]interface{}{"dashboard": dashboardContent(existing.Data, withoutUID)}
+	folderID, ok, err := fr.lookupFolderID(ctx, fr.folderName(path, resolvedPath))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		folderID = -1
+	}
+	expected["folder"] = folderID
+	actual["folder"] = existing.FolderId
+
+	if resource.Fields = drift.Fields(expected, actual); len(resource.Fields) == 0 {
+		return nil, nil
+	}
+	resource.Status = drift.StatusModified
+	return d, nil
+}
+
+func (fr *FileReader) reconcile(ctx context.Context, d *dashboardDrift) error {
+	if d.resource.Status == drift.StatusExtra {
+		return fr.removeDashboard(ctx, d.ref.DashboardId)
+	}
+
+	folderID, err := getOrCreateFolderID(ctx, fr.Cfg, fr.dashboardProvisioningService, fr.folderName(d.path, fr.resolvedPath()))
+	if err != nil && !errors.Is(err, ErrFolderNameMissing) {
+		return err
+	}
+
+	refs := map[string]*models.DashboardProvisioning{}
+	if d.ref != nil && d.resource.Status == drift.StatusModified {
+		// The checksum of the file may not have changed, the dashboard is saved again anyway
+		ref := *d.ref
+		ref.CheckSum = ""
+		refs[d.path] = &ref
+	}
+	_, err = fr.saveDashboard(ctx, d.path, folderID, d.fileInfo, refs)
+	return err
+}
+
+// resource returns the drifted dashboard resource of the file, identified by its path relative to the provisioner
+func (fr *FileReader) resource(path, resolvedPath string) *drift.Resource {
+	relativePath, err := filepath.Rel(resolvedPath, path)
+	if err != nil {
+		relativePath = path
+	}
+	return &drift.Resource{
+		ID:          drift.ResourceID(drift.KindDashboard, fr.Cfg.OrgID, fr.Cfg.Name+"/"+filepath.ToSlash(relativePath)),
+		Kind:        drift.KindDashboard,
+		OrgID:       fr.Cfg.OrgID,
+		Provisioner: fr.Cfg.Name,
+		Path:        filepath.ToSlash(relativePath),
+	}
+}
+
+// lookupFolderID returns the ID of the folder, 0 when the name is empty, without creating it
+func (fr *FileReader) lookupFolderID(ctx context.Context, folderName string) (int64, bool, error) {
+	if folderName == "" {
+		return 0, true, nil
+	}
+	query := &models.GetDashboardQuery{Slug: models.SlugifyTitle(folderName), OrgId: fr.Cfg.OrgID}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return query.Result.Id, query.Result.IsFolder, nil
+}
+
+// dashboardContent returns the dashboard JSON without the fields set when saving it. The UID is removed as well
+// when the file does not set it.
+func dashboardContent(data *simplejson.Json, withoutUID bool) map[string]interface{} {
+	content := map[string]interface{}{}
+	for k, v := range data.MustMap() {
+		content[k] = v
+	}
+	delete(content, "id")
+	delete(content, "version")
+	if withoutUID {
+		delete(content, "uid")
+	}
+	return content
+}
@@ -162,12 +162,7 @@ func (fr *FileReader) storeDashboardsInFolder(ctx context.Context, filesFoundOnD
 func (fr *FileReader) storeDashboardsInFoldersFromFileStructure(ctx context.Context, filesFoundOnDisk map[string]os.FileInfo,
 	dashboardRefs map[string]*models.DashboardProvisioning, resolvedPath string, usageTracker *usageTracker) error {
 	for path, fileInfo := range filesFoundOnDisk {
-		folderName := ""
-
-		dashboardsFolder := filepath.Dir(path)
-		if dashboardsFolder != resolvedPath {
-			folderName = filepath.Base(dashboardsFolder)
-		}
+		folderName := fr.folderName(path, resolvedPath)
 
 		folderID, err := getOrCreateFolderID(ctx, fr.Cfg, fr.dashboardProvisioningService, folderName)
 		if err != nil && !errors.Is(err, ErrFolderNameMissing) {
@@ -195,26 +190,39 @@ func (fr *FileReader) handleMissingDashboardFiles(ctx context.Context, provision
 		}
 	}
 
+	for _, dashboardID := range dashboardsToDelete {
+		if err := fr.removeDashboard(ctx, dashboardID); err != nil {
+			fr.log.Error("failed to remove dashboard", "id", dashboardID, "error", err)
+		}
+	}
+}
+
+// removeDashboard deletes a provisioned dashboard whose file is missing, or only unprovisions it when deletion is
+// disabled for the provisioner.
+func (fr *FileReader) removeDashboard(ctx context.Context, dashboardID int64) error {
 	if fr.Cfg.DisableDeletion {
 		// If deletion is disabled for the provisioner we just remove provisioning metadata about the dashboard
 		// so afterwards the dashboard is considered unprovisioned.
-		for _, dashboardID := range dashboardsToDelete {
-			fr.log.Debug("unprovisioning provisioned dashboard. missing on disk", "id", dashboardID)
-			err := fr.dashboardProvisioningService.UnprovisionDashboard(ctx, dashboardID)
-			if err != nil {
-				fr.log.Error("failed to unprovision dashboard", "dashboard_id", dashboardID, "error", err)
-			}
-		}
-	} else {
-		// delete dashboards missing JSON file
-		for _, dashboardID := range dashboardsToDelete {
-			fr.log.Debug("deleting provisioned dashboard, missing on disk", "id", dashboardID)
-			err := fr.dashboardProvisioningService.DeleteProvisionedDashboard(ctx, dashboardID, fr.Cfg.OrgID)
-			if err != nil {
-				fr.log.Error("failed to delete dashboard", "id", dashboardID, "error", err)
-			}
-		}
+		fr.log.Debug("unprovisioning provisioned dashboard. missing on disk", "id", dashboardID)
+		return fr.dashboardProvisioningService.UnprovisionDashboard(ctx, dashboardID)
+	}
+
+	fr.log.Debug("deleting provisioned dashboard, missing on disk", "id", dashboardID)
+	return fr.dashboardProvisioningService.DeleteProvisionedDashboard(ctx, dashboardID, fr.Cfg.OrgID)
+}
+
+// folderName returns the name of the folder of the dashboard file, from the config or from the file system
+// structure.
+func (fr *FileReader) folderName(path, resolvedPath string) string {
+	if !fr.FoldersFromFilesStructure {
+		return fr.Cfg.Folder
+	}
+
+	dashboardsFolder := filepath.Dir(path)
+	if dashboardsFolder == resolvedPath {
+		return ""
 	}
+	return filepath.Base(dashboardsFolder)
 }
 
 // saveDashboard saves or updates the dashboard provisioning file at path.
@@ -0,0 +1,224 @@
+package datasources
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+)
+
+// datasourceDrift is a data source whose database state differs from the provisioning files, together with the
+// configuration reconciling it
+type datasourceDrift struct {
+	resource *drift.Resource
+	upsert   *upsertDataSourceFromConfig
+	existing *models.DataSource
+}
+
+// Drift compares the data sources of the provisioning files in the directory with the database. The secure fields
+// are not compared, they cannot be read back.
+func Drift(ctx context.Context, configDirectory string) ([]*drift.Resource, error) {
+	dc := newDatasourceProvisioner(log.New("provisioning.datasources"))
+	drifts, err := dc.drift(ctx, configDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return resources(drifts), nil
+}
+
+// Reconcile applies the provisioning files in the directory to the drifted data sources with the IDs, or to all of
+// them when there is no ID, and returns the reconciled data sources
+func Reconcile(ctx context.Context, configDirectory string, ids []string) ([]*drift.Resource, error) {
+	dc := newDatasourceProvisioner(log.New("provisioning.datasources"))
+	drifts, err := dc.drift(ctx, configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	set := drift.NewIDSet(ids)
+	reconciled := make([]*datasourceDrift, 0)
+	for _, d := range drifts {
+		if !set.Has(d.resource.ID) {
+			continue
+		}
+		if err := dc.reconcile(ctx, d); err != nil {
+			return resources(reconciled), err
+		}
+		reconciled = append(reconciled, d)
+	}
+	return resources(reconciled), nil
+}
+
+func (dc *DatasourceProvisioner) drift(ctx context.Context, configPath string) ([]*datasourceDrift, error) {
+	configs, err := dc.cfgProvider.readConfig(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]*datasourceDrift, 0)
+	provisioned := map[int64]map[string]bool{}
+	for _, cfg := range configs {
+		for _, ds := range cfg.Datasources {
+			if provisioned[ds.OrgID] == nil {
+				provisioned[ds.OrgID] = map[string]bool{}
+			}
+			provisioned[ds.OrgID][ds.Name] = true
+
+			query := &models.GetDataSourceQuery{OrgId: ds.OrgID, Name: ds.Name}
+			err := bus.Dispatch(ctx, query)
+			if err != nil && !errors.Is(err, models.ErrDataSourceNotFound) {
+				return nil, err
+			}
+
+			resource := &drift.Resource{
+				ID:    drift.ResourceID(drift.KindDatasource, ds.OrgID, ds.Name),
+				Kind:  drift.KindDatasource,
+				OrgID: ds.OrgID,
+				UID:   ds.UID,
+				Name:  ds.Name,
+			}
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				resource.Status = drift.StatusDeleted
+				drifts = append(drifts, &datasourceDrift{resource: resource, upsert: ds})
+				continue
+			}
+			if fields := modifiedFields(ds, query.Result); len(fields) > 0 {
+				resource.Status = drift.StatusModified
+				resource.UID = query.Result.Uid
+				resource.Fields = fields
+				drifts = append(drifts, &datasourceDrift{resource: resource, upsert: ds, existing: query.Result})
+			}
+		}
+	}
+
+	// The data sources deleted by the provisioning files are extra, unless they are provisioned again
+	deleted := map[string]bool{}
+	for _, cfg := range configs {
+		for _, ds := range cfg.DeleteDatasources {
+			if provisioned[ds.OrgID][ds.Name] {
+				continue
+			}
+			query := &models.GetDataSourceQuery{OrgId: ds.OrgID, Name: ds.Name}
+			if err := bus.Dispatch(ctx, query); err != nil {
+				if errors.Is(err, models.ErrDataSourceNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			if d := extraDatasource(query.Result); !deleted[d.resource.ID] {
+				deleted[d.resource.ID] = true
+				drifts = append(drifts, d)
+			}
+		}
+	}
+
+	// Only the provisioning makes data sources read-only, those no longer in the provisioning files are extra
+	orgs := &models.SearchOrgsQuery{}
+	if err := bus.Dispatch(ctx, orgs); err != nil {
+		return nil, err
+	}
+	for _, org := range orgs.Result {
+		query := &models.GetDataSourcesQuery{OrgId: org.Id}
+		if err := bus.Dispatch(ctx, query); err != nil {
+			return nil, err
+		}
+		for _, ds := range query.Result {
+			if !ds.ReadOnly || provisioned[ds.OrgId][ds.Name] {
+				continue
+			}
+			if d := extraDatasource(ds); !deleted[d.resource.ID] {
+				drifts = append(drifts, d)
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+func (dc *DatasourceProvisioner) reconcile(ctx context.Context, d *datasourceDrift) error {
+	if d.upsert == nil {
+		dc.log.Info("deleting datasource missing in configuration", "name", d.resource.Name, "orgId", d.resource.OrgID)
+		return bus.Dispatch(ctx, &models.DeleteDataSourceCommand{OrgID: d.resource.OrgID, Name: d.resource.Name})
+	}
+
+	if d.existing == nil {
+		insertCmd := createInsertCommand(d.upsert)
+		dc.log.Info("inserting datasource from configuration", "name", insertCmd.Name, "uid", insertCmd.Uid)
+		return bus.Dispatch(ctx, insertCmd)
+	}
+
+	updateCmd := createUpdateCommand(d.upsert, d.existing.Id)
+	dc.log.Info("updating datasource from configuration", "name", updateCmd.Name, "uid", updateCmd.Uid)
+	return bus.Dispatch(ctx, updateCmd)
+}
+
+func extraDatasource(ds *models.DataSource) *datasourceDrift {
+	return &datasourceDrift{
+		resource: &drift.Resource{
+			ID:     drift.ResourceID(drift.KindDatasource, ds.OrgId, ds.Name),
+			Kind:   drift.KindDatasource,
+			Status: drift.StatusExtra,
+			OrgID:  ds.OrgId,
+			UID:    ds.Uid,
+			Name:   ds.Name,
+		},
+		existing: ds,
+	}
+}
+
+// modifiedFields returns the fields of the data source which differ from its configuration
+func modifiedFields(ds *upsertDataSourceFromConfig, existing *models.DataSource) []string {
+	jsonData := map[string]interface{}{}
+	for k, v := range ds.JSONData {
+		jsonData[k] = v
+	}
+	existingJSONData := map[string]interface{}{}
+	if existing.JsonData != nil {
+		for k, v := range existing.JsonData.MustMap() {
+			existingJSONData[k] = v
+		}
+	}
+
+	expected := map[string]interface{}{
+		"type":            ds.Type,
+		"access":          ds.Access,
+		"url":             ds.URL,
+		"user":            ds.User,
+		"database":        ds.Database,
+		"basicAuth":       ds.BasicAuth,
+		"basicAuthUser":   ds.BasicAuthUser,
+		"withCredentials": ds.WithCredentials,
+		"isDefault":       ds.IsDefault,
+		"jsonData":        jsonData,
+		"readOnly":        !ds.Editable,
+	}
+	actual := map[string]interface{}{
+		"type":            existing.Type,
+		"access":          string(existing.Access),
+		"url":             existing.Url,
+		"user":            existing.User,
+		"database":        existing.Database,
+		"basicAuth":       existing.BasicAuth,
+		"basicAuthUser":   existing.BasicAuthUser,
+		"withCredentials": existing.WithCredentials,
+		"isDefault":       existing.IsDefault,
+		"jsonData":        existingJSONData,
+		"readOnly":        existing.ReadOnly,
+	}
+	if ds.UID != "" {
+		expected["uid"] = ds.UID
+		actual["uid"] = existing.Uid
+	}
+	return drift.Fields(expected, actual)
+}
+
+func resources(drifts []*datasourceDrift) []*drift.Resource {
+	result := make([]*drift.Resource, 0, len(drifts))
+	for _, d := range drifts {
+		result = append(result, d.resource)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasourceDrift(t *testing.T) {
+	setup := func(datasources ...*models.DataSource) {
+		fakeRepo = &fakeRepository{loadAll: datasources}
+		bus.ClearBusHandlers()
+		bus.AddHandler("test", mockDelete)
+		bus.AddHandler("test", mockInsert)
+		bus.AddHandler("test", mockUpdate)
+		bus.AddHandler("test", mockGet)
+		bus.AddHandler("test", mockGetOrg)
+		bus.AddHandler("test", func(ctx context.Context, query *models.SearchOrgsQuery) error {
+			query.Result = []*models.OrgDTO{{Id: 1}}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetDataSourcesQuery) error {
+			for _, ds := range fakeRepo.loadAll {
+				if ds.OrgId == query.OrgId {
+					query.Result = append(query.Result, ds)
+				}
+			}
+			return nil
+		})
+	}
+	t.Cleanup(bus.ClearBusHandlers)
+
+	prometheus := func(url string) *models.DataSource {
+		return &models.DataSource{Id: 1, OrgId: 1, Name: "Prometheus", Type: "prometheus", Access: "proxy", Url: url, ReadOnly: true}
+	}
+
+	t.Run("should not report the data sources matching the configuration", func(t *testing.T) {
+		setup(prometheus("http://localhost:9090"), &models.DataSource{Id: 2, OrgId: 1, Name: "Graphite", Type: "graphite",
+			Access: "proxy", Url: "http://localhost:8080", ReadOnly: true})
+
+		resources, err := Drift(context.Background(), twoDatasourcesConfigPurgeOthers)
+		require.NoError(t, err)
+		require.Empty(t, resources)
+	})
+
+	t.Run("should report the modified, deleted and extra data sources", func(t *testing.T) {
+		setup(
+			prometheus("http://prometheus:9090"),
+			&models.DataSource{Id: 3, OrgId: 1, Name: "old-graphite", Uid: "old", Type: "graphite"},
+			&models.DataSource{Id: 4, OrgId: 1, Name: "removed", Uid: "removed", Type: "loki", ReadOnly: true},
+			&models.DataSource{Id: 5, OrgId: 1, Name: "created in the UI", Type: "loki"},
+		)
+
+		resources, err := Drift(context.Background(), twoDatasourcesConfigPurgeOthers)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*drift.Resource{
+			{ID: "datasource/1/Prometheus", Kind: drift.KindDatasource, Status: drift.StatusModified, OrgID: 1, Name: "Prometheus", Fields: []string{"url"}},
+			{ID: "datasource/1/Graphite", Kind: drift.KindDatasource, Status: drift.StatusDeleted, OrgID: 1, Name: "Graphite"},
+			{ID: "datasource/1/old-graphite", Kind: drift.KindDatasource, Status: drift.StatusExtra, OrgID: 1, UID: "old", Name: "old-graphite"},
+			{ID: "datasource/1/removed", Kind: drift.KindDatasource, Status: drift.StatusExtra, OrgID: 1, UID: "removed", Name: "removed"},
+		}, resources)
+	})
+
+	t.Run("should only reconcile the selected data sources", func(t *testing.T) {
+		setup(
+			prometheus("http://prometheus:9090"),
+			&models.DataSource{Id: 4, OrgId: 1, Name: "removed", Type: "loki", ReadOnly: true},
+		)
+
+		resources, err := Reconcile(context.Background(), twoDatasourcesConfigPurgeOthers, []string{"datasource/1/Prometheus", "datasource/1/removed"})
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+
+		require.Len(t, fakeRepo.updated, 1)
+		require.Equal(t, int64(1), fakeRepo.updated[0].Id)
+		require.Equal(t, "http://localhost:9090", fakeRepo.updated[0].Url)
+		require.Len(t, fakeRepo.deleted, 1)
+		require.Equal(t, "removed", fakeRepo.deleted[0].Name)
+		require.Empty(t, fakeRepo.inserted)
+	})
+}
@@ -0,0 +1,99 @@
+// Package drift describes the differences between the provisioning files and the database state of the resources
+// they provision, such as changes made in the UI or through the API since the resources were provisioned.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Kinds of provisioned resources
+const (
+	KindDatasource   = "datasource"
+	KindDashboard    = "dashboard"
+	KindNotification = "notification"
+)
+
+type Status string
+
+const (
+	// StatusModified is a resource of the provisioning files which differs in the database
+	StatusModified Status = "modified"
+	// StatusDeleted is a resource of the provisioning files missing in the database
+	StatusDeleted Status = "deleted"
+	// StatusExtra is a provisioned resource of the database which is no longer in the provisioning files, or which
+	// the provisioning files delete
+	StatusExtra Status = "extra"
+)
+
+// Resource is a provisioned resource whose database state does not match the provisioning files
+type Resource struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Status Status `json:"status"`
+	OrgID  int64  `json:"orgId"`
+	UID    string `json:"uid,omitempty"`
+	Name   string `json:"name,omitempty"`
+	// Provisioner is the name of the dashboard provider of a dashboard
+	Provisioner string `json:"provisioner,omitempty"`
+	// Path is the file of a dashboard
+	Path string `json:"path,omitempty"`
+	// Fields are the fields of a modified resource whose value differs, when known
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ResourceID returns the ID identifying a resource across the drift detections, used to select the resources to
+// reconcile
+func ResourceID(kind string, orgID int64, key string) string {
+	return fmt.Sprintf("%s/%d/%s", kind, orgID, key)
+}
+
+// IDSet is a set of resource IDs. An empty set matches all the resources.
+type IDSet map[string]bool
+
+// NewIDSet returns the set of the resource IDs
+func NewIDSet(ids []string) IDSet {
+	set := IDSet{}
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// Has returns whether the set contains the resource ID, or is empty
+func (s IDSet) Has(id string) bool {
+	return len(s) == 0 || s[id]
+}
+
+// Fields compares the field values of a resource in the provisioning files and in the database, and returns the
+// names of the fields which differ in the order of the names
+func Fields(expected, actual map[string]interface{}) []string {
+	var fields []string
+	for name, value := range expected {
+		if !Equal(value, actual[name]) {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Equal returns whether the values are equal once encoded to JSON, so that the numbers and the maps decoded from
+// YAML compare equal to those stored in the database
+func Equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
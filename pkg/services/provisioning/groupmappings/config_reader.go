@@ -0,0 +1,96 @@
+package groupmappings
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(ctx context.Context, path string) ([]*groupMappingsAsConfig, error) {
+	var mappings []*groupMappingsAsConfig
+	cr.log.Debug("Looking for group mapping provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read group mapping provisioning files from directory", "path", path, "error", err)
+		return mappings, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing group mappings provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseGroupMappingsConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				mappings = append(mappings, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating group mappings")
+	if err := cr.validateGroupMappings(ctx, mappings); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+func (cr *configReader) parseGroupMappingsConfig(path string, file os.FileInfo) (*groupMappingsAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *groupMappingsAsConfigV1
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToGroupMappingsFromConfig(), nil
+}
+
+func (cr *configReader) validateGroupMappings(ctx context.Context, configs []*groupMappingsAsConfig) error {
+	for _, cfg := range configs {
+		for index, mapping := range cfg.GroupMappings {
+			if mapping.GroupID == "" {
+				return fmt.Errorf("group mapping item %d in configuration doesn't contain required field groupId", index+1)
+			}
+			if len(mapping.Roles) == 0 {
+				return fmt.Errorf("group mapping of %q doesn't contain any role", mapping.GroupID)
+			}
+			for _, role := range mapping.Roles {
+				if !strings.HasPrefix(role, accesscontrol.FixedRolePrefix) {
+					return fmt.Errorf("group mapping of %q: %q is not a fixed role", mapping.GroupID, role)
+				}
+			}
+
+			if mapping.OrgID < 1 {
+				mapping.OrgID = 1
+			} else if err := utils.CheckOrgExists(ctx, mapping.OrgID); err != nil {
+				return fmt.Errorf("failed to provision group mapping of %q: %w", mapping.GroupID, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package groupmappings
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// Provision replaces the provisioned group mappings with the ones of the configuration files
+func Provision(ctx context.Context, configDirectory string, store accesscontrol.GroupMappingStore) error {
+	logger := log.New("provisioning.groupmappings")
+	cr := &configReader{log: logger}
+
+	configs, err := cr.readConfig(ctx, configDirectory)
+	if err != nil {
+		return err
+	}
+
+	cmds := make([]accesscontrol.AddGroupMappingCommand, 0)
+	for _, cfg := range configs {
+		for _, mapping := range cfg.GroupMappings {
+			for _, name := range mapping.Roles {
+				cmds = append(cmds, accesscontrol.AddGroupMappingCommand{
+					OrgID:      mapping.OrgID,
+					AuthModule: mapping.AuthModule,
+					GroupID:    mapping.GroupID,
+					Role: accesscontrol.Role{
+						OrgID:   accesscontrol.GlobalOrgID,
+						UID:     accesscontrol.FixedRoleUID(name),
+						Name:    name,
+						Version: 1,
+					},
+				})
+			}
+		}
+	}
+
+	logger.Debug("Provisioning group mappings", "count", len(cmds))
+	return store.SetProvisionedGroupMappings(ctx, cmds)
+}
@@ -0,0 +1,73 @@
+package groupmappings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	invalidRole       = "./testdata/test-configs/invalid-role"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestProvision(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	bus.AddHandler("getOrg", func(ctx context.Context, q *models.GetOrgByIdQuery) error {
+		return sqlstore.GetOrgById(ctx, q)
+	})
+	require.NoError(t, sqlstore.CreateOrg(context.Background(), &models.CreateOrgCommand{Name: "Main Org."}))
+	store := database.ProvideService(sqlStore)
+
+	_, err := store.AddGroupMapping(context.Background(), accesscontrol.AddGroupMappingCommand{
+		OrgID:   1,
+		GroupID: "viewers",
+		Role:    accesscontrol.Role{UID: accesscontrol.FixedRoleUID("fixed:dashboards:reader"), Name: "fixed:dashboards:reader"},
+	})
+	require.NoError(t, err)
+
+	t.Run("should provision the group mappings", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), correctProperties, store))
+
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, mappings, 4)
+		assert.False(t, mappings[0].Provisioned)
+
+		assert.Equal(t, "ldap", mappings[1].AuthModule)
+		assert.Equal(t, "cn=admins,ou=groups,dc=grafana,dc=org", mappings[1].GroupID)
+		assert.Equal(t, "fixed:users.roles:writer", mappings[1].RoleName)
+		assert.Equal(t, accesscontrol.FixedRoleUID("fixed:users.roles:writer"), mappings[1].RoleUID)
+		assert.True(t, mappings[1].Provisioned)
+		assert.Equal(t, "fixed:teams.roles:writer", mappings[2].RoleName)
+		assert.Equal(t, "", mappings[3].AuthModule)
+		assert.Equal(t, "editors", mappings[3].GroupID)
+	})
+
+	t.Run("should fail on roles that are not fixed roles", func(t *testing.T) {
+		err := Provision(context.Background(), invalidRole, store)
+		require.Error(t, err)
+
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, mappings, 4)
+	})
+
+	t.Run("should remove the mappings no longer provisioned", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), emptyFolder, store))
+
+		mappings, err := store.GetGroupMappings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, mappings, 1)
+		assert.Equal(t, "viewers", mappings[0].GroupID)
+	})
+}
@@ -0,0 +1,49 @@
+package groupmappings
+
+import "github.com/grafana/grafana/pkg/services/provisioning/values"
+
+// groupMappingsAsConfig is normalized data object for group mappings config data. Any config version should be
+// mappable to this type.
+type groupMappingsAsConfig struct {
+	GroupMappings []*groupMappingFromConfig
+}
+
+type groupMappingFromConfig struct {
+	OrgID      int64
+	AuthModule string
+	GroupID    string
+	Roles      []string
+}
+
+// groupMappingsAsConfigV1 is mapping for version 1 configs. This is mapped to its normalised version.
+type groupMappingsAsConfigV1 struct {
+	GroupMappings []*groupMappingFromConfigV1 `json:"groupMappings" yaml:"groupMappings"`
+}
+
+type groupMappingFromConfigV1 struct {
+	OrgID      values.Int64Value    `json:"orgId" yaml:"orgId"`
+	AuthModule values.StringValue   `json:"authModule" yaml:"authModule"`
+	GroupID    values.StringValue   `json:"groupId" yaml:"groupId"`
+	Roles      []values.StringValue `json:"roles" yaml:"roles"`
+}
+
+func (cfg *groupMappingsAsConfigV1) mapToGroupMappingsFromConfig() *groupMappingsAsConfig {
+	r := &groupMappingsAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, m := range cfg.GroupMappings {
+		roles := make([]string, 0, len(m.Roles))
+		for _, role := range m.Roles {
+			roles = append(roles, role.Value())
+		}
+		r.GroupMappings = append(r.GroupMappings, &groupMappingFromConfig{
+			OrgID:      m.OrgID.Value(),
+			AuthModule: m.AuthModule.Value(),
+			GroupID:    m.GroupID.Value(),
+			Roles:      roles,
+		})
+	}
+	return r
+}
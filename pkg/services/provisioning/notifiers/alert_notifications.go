@@ -46,15 +46,11 @@ func (dc *NotificationProvisioner) deleteNotifications(ctx context.Context, noti
 	for _, notification := range notificationToDelete {
 		dc.log.Info("Deleting alert notification", "name", notification.Name, "uid", notification.UID)
 
-		if notification.OrgID == 0 && notification.OrgName != "" {
-			getOrg := &models.GetOrgByNameQuery{Name: notification.OrgName}
-			if err := bus.Dispatch(ctx, getOrg); err != nil {
-				return err
-			}
-			notification.OrgID = getOrg.Result.Id
-		} else if notification.OrgID < 0 {
-			notification.OrgID = 1
+		orgID, err := resolveOrgID(ctx, notification.OrgID, notification.OrgName)
+		if err != nil {
+			return err
 		}
+		notification.OrgID = orgID
 
 		getNotification := &models.GetAlertNotificationsWithUidQuery{Uid: notification.UID, OrgId: notification.OrgID}
 
@@ -75,62 +71,74 @@ func (dc *NotificationProvisioner) deleteNotifications(ctx context.Context, noti
 
 func (dc *NotificationProvisioner) mergeNotifications(ctx context.Context, notificationToMerge []*notificationFromConfig) error {
 	for _, notification := range notificationToMerge {
-		if notification.OrgID == 0 && notification.OrgName != "" {
-			getOrg := &models.GetOrgByNameQuery{Name: notification.OrgName}
-			if err := bus.Dispatch(ctx, getOrg); err != nil {
-				return err
-			}
-			notification.OrgID = getOrg.Result.Id
-		} else if notification.OrgID < 0 {
-			notification.OrgID = 1
+		orgID, err := resolveOrgID(ctx, notification.OrgID, notification.OrgName)
+		if err != nil {
+			return err
 		}
+		notification.OrgID = orgID
 
 		cmd := &models.GetAlertNotificationsWithUidQuery{OrgId: notification.OrgID, Uid: notification.UID}
-		err := bus.Dispatch(ctx, cmd)
-		if err != nil {
+		if err := bus.Dispatch(ctx, cmd); err != nil {
 			return err
 		}
 
-		if cmd.Result == nil {
-			dc.log.Debug("inserting alert notification from configuration", "name", notification.Name, "uid", notification.UID)
-			insertCmd := &models.CreateAlertNotificationCommand{
-				Uid:                   notification.UID,
-				Name:                  notification.Name,
-				Type:                  notification.Type,
-				IsDefault:             notification.IsDefault,
-				Settings:              notification.SettingsToJSON(),
-				SecureSettings:        notification.SecureSettings,
-				OrgId:                 notification.OrgID,
-				DisableResolveMessage: notification.DisableResolveMessage,
-				Frequency:             notification.Frequency,
-				SendReminder:          notification.SendReminder,
-			}
+		if err := dc.saveNotification(ctx, notification, cmd.Result != nil); err != nil {
+			return err
+		}
+	}
 
-			if err := bus.Dispatch(ctx, insertCmd); err != nil {
-				return err
-			}
-		} else {
-			dc.log.Debug("updating alert notification from configuration", "name", notification.Name)
-			updateCmd := &models.UpdateAlertNotificationWithUidCommand{
-				Uid:                   notification.UID,
-				Name:                  notification.Name,
-				Type:                  notification.Type,
-				IsDefault:             notification.IsDefault,
-				Settings:              notification.SettingsToJSON(),
-				SecureSettings:        notification.SecureSettings,
-				OrgId:                 notification.OrgID,
-				DisableResolveMessage: notification.DisableResolveMessage,
-				Frequency:             notification.Frequency,
-				SendReminder:          notification.SendReminder,
-			}
+	return nil
+}
 
-			if err := bus.Dispatch(ctx, updateCmd); err != nil {
-				return err
-			}
+func (dc *NotificationProvisioner) saveNotification(ctx context.Context, notification *notificationFromConfig, exists bool) error {
+	if !exists {
+		dc.log.Debug("inserting alert notification from configuration", "name", notification.Name, "uid", notification.UID)
+		insertCmd := &models.CreateAlertNotificationCommand{
+			Uid:                   notification.UID,
+			Name:                  notification.Name,
+			Type:                  notification.Type,
+			IsDefault:             notification.IsDefault,
+			Settings:              notification.SettingsToJSON(),
+			SecureSettings:        notification.SecureSettings,
+			OrgId:                 notification.OrgID,
+			DisableResolveMessage: notification.DisableResolveMessage,
+			Frequency:             notification.Frequency,
+			SendReminder:          notification.SendReminder,
 		}
+
+		return bus.Dispatch(ctx, insertCmd)
 	}
 
-	return nil
+	dc.log.Debug("updating alert notification from configuration", "name", notification.Name)
+	updateCmd := &models.UpdateAlertNotificationWithUidCommand{
+		Uid:                   notification.UID,
+		Name:                  notification.Name,
+		Type:                  notification.Type,
+		IsDefault:             notification.IsDefault,
+		Settings:              notification.SettingsToJSON(),
+		SecureSettings:        notification.SecureSettings,
+		OrgId:                 notification.OrgID,
+		DisableResolveMessage: notification.DisableResolveMessage,
+		Frequency:             notification.Frequency,
+		SendReminder:          notification.SendReminder,
+	}
+
+	return bus.Dispatch(ctx, updateCmd)
+}
+
+// resolveOrgID returns the ID of the organization of a notification, looked up by name when the ID is not set
+func resolveOrgID(ctx context.Context, orgID int64, orgName string) (int64, error) {
+	if orgID == 0 && orgName != "" {
+		getOrg := &models.GetOrgByNameQuery{Name: orgName}
+		if err := bus.Dispatch(ctx, getOrg); err != nil {
+			return 0, err
+		}
+		return getOrg.Result.Id, nil
+	}
+	if orgID < 0 {
+		return 1, nil
+	}
+	return orgID, nil
 }
 
 func (dc *NotificationProvisioner) applyChanges(ctx context.Context, configPath string) error {
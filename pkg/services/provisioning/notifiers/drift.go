@@ -0,0 +1,185 @@
+package notifiers
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+)
+
+// notificationDrift is an alert notifier whose database state differs from the provisioning files, together with
+// the configuration reconciling it
+type notificationDrift struct {
+	resource     *drift.Resource
+	notification *notificationFromConfig
+	exists       bool
+}
+
+// Drift compares the alert notifiers of the provisioning files in the directory with the database. The secure
+// settings are not compared.
+func Drift(ctx context.Context, configDirectory string, encryptionService encryption.Internal) ([]*drift.Resource, error) {
+	dc := newNotificationProvisioner(encryptionService, log.New("provisioning.notifiers"))
+	drifts, err := dc.drift(ctx, configDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return resources(drifts), nil
+}
+
+// Reconcile applies the provisioning files in the directory to the drifted alert notifiers with the IDs, or to all
+// of them when there is no ID, and returns the reconciled alert notifiers
+func Reconcile(ctx context.Context, configDirectory string, encryptionService encryption.Internal, ids []string) ([]*drift.Resource, error) {
+	dc := newNotificationProvisioner(encryptionService, log.New("provisioning.notifiers"))
+	drifts, err := dc.drift(ctx, configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	set := drift.NewIDSet(ids)
+	reconciled := make([]*notificationDrift, 0)
+	for _, d := range drifts {
+		if !set.Has(d.resource.ID) {
+			continue
+		}
+		if err := dc.reconcile(ctx, d); err != nil {
+			return resources(reconciled), err
+		}
+		reconciled = append(reconciled, d)
+	}
+	return resources(reconciled), nil
+}
+
+func (dc *NotificationProvisioner) drift(ctx context.Context, configPath string) ([]*notificationDrift, error) {
+	configs, err := dc.cfgProvider.readConfig(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]*notificationDrift, 0)
+	provisioned := map[string]bool{}
+	for _, cfg := range configs {
+		for _, notification := range cfg.Notifications {
+			orgID, err := resolveOrgID(ctx, notification.OrgID, notification.OrgName)
+			if err != nil {
+				return nil, err
+			}
+			notification.OrgID = orgID
+
+			resource := &drift.Resource{
+				ID:    drift.ResourceID(drift.KindNotification, orgID, notification.UID),
+				Kind:  drift.KindNotification,
+				OrgID: orgID,
+				UID:   notification.UID,
+				Name:  notification.Name,
+			}
+			provisioned[resource.ID] = true
+
+			query := &models.GetAlertNotificationsWithUidQuery{OrgId: orgID, Uid: notification.UID}
+			if err := bus.Dispatch(ctx, query); err != nil {
+				return nil, err
+			}
+			if query.Result == nil {
+				resource.Status = drift.StatusDeleted
+				drifts = append(drifts, &notificationDrift{resource: resource, notification: notification})
+				continue
+			}
+			if fields := modifiedFields(notification, query.Result); len(fields) > 0 {
+				resource.Status = drift.StatusModified
+				resource.Fields = fields
+				drifts = append(drifts, &notificationDrift{resource: resource, notification: notification, exists: true})
+			}
+		}
+	}
+
+	for _, cfg := range configs {
+		for _, notification := range cfg.DeleteNotifications {
+			orgID, err := resolveOrgID(ctx, notification.OrgID, notification.OrgName)
+			if err != nil {
+				return nil, err
+			}
+			id := drift.ResourceID(drift.KindNotification, orgID, notification.UID)
+			if provisioned[id] {
+				continue
+			}
+
+			query := &models.GetAlertNotificationsWithUidQuery{OrgId: orgID, Uid: notification.UID}
+			if err := bus.Dispatch(ctx, query); err != nil {
+				return nil, err
+			}
+			if query.Result == nil {
+				continue
+			}
+			provisioned[id] = true
+			drifts = append(drifts, &notificationDrift{
+				resource: &drift.Resource{
+					ID:     id,
+					Kind:   drift.KindNotification,
+					Status: drift.StatusExtra,
+					OrgID:  orgID,
+					UID:    query.Result.Uid,
+					Name:   query.Result.Name,
+				},
+				exists: true,
+			})
+		}
+	}
+
+	return drifts, nil
+}
+
+func (dc *NotificationProvisioner) reconcile(ctx context.Context, d *notificationDrift) error {
+	if d.notification == nil {
+		dc.log.Info("Deleting alert notification", "name", d.resource.Name, "uid", d.resource.UID)
+		return bus.Dispatch(ctx, &models.DeleteAlertNotificationWithUidCommand{Uid: d.resource.UID, OrgId: d.resource.OrgID})
+	}
+	return dc.saveNotification(ctx, d.notification, d.exists)
+}
+
+// modifiedFields returns the fields of the alert notifier which differ from its configuration
+func modifiedFields(notification *notificationFromConfig, existing *models.AlertNotification) []string {
+	settings := map[string]interface{}{}
+	for k, v := range notification.Settings {
+		settings[k] = v
+	}
+	existingSettings := map[string]interface{}{}
+	if existing.Settings != nil {
+		for k, v := range existing.Settings.MustMap() {
+			existingSettings[k] = v
+		}
+	}
+
+	expected := map[string]interface{}{
+		"name":                  notification.Name,
+		"type":                  notification.Type,
+		"isDefault":             notification.IsDefault,
+		"sendReminder":          notification.SendReminder,
+		"disableResolveMessage": notification.DisableResolveMessage,
+		"settings":              settings,
+	}
+	actual := map[string]interface{}{
+		"name":                  existing.Name,
+		"type":                  existing.Type,
+		"isDefault":             existing.IsDefault,
+		"sendReminder":          existing.SendReminder,
+		"disableResolveMessage": existing.DisableResolveMessage,
+		"settings":              existingSettings,
+	}
+	// The frequency is only saved along with the reminders
+	if frequency, err := time.ParseDuration(notification.Frequency); err == nil && notification.SendReminder {
+		expected["frequency"] = frequency.String()
+		actual["frequency"] = existing.Frequency.String()
+	}
+	return drift.Fields(expected, actual)
+}
+
+func resources(drifts []*notificationDrift) []*drift.Resource {
+	result := make([]*drift.Resource, 0, len(drifts))
+	for _, d := range drifts {
+		result = append(result, d.resource)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/alerting/notifiers"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationDrift(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	setupBusHandlers(sqlStore)
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{Type: "slack", Name: "slack", Factory: notifiers.NewSlackNotifier})
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{Type: "email", Name: "email", Factory: notifiers.NewEmailNotifier})
+	require.NoError(t, sqlstore.CreateOrg(ctx, &models.CreateOrgCommand{Name: "Main Org."}))
+	encryptionService := ossencryption.ProvideService()
+
+	require.NoError(t, Provision(ctx, twoNotificationsConfig, encryptionService))
+	resources, err := Drift(ctx, twoNotificationsConfig, encryptionService)
+	require.NoError(t, err)
+	require.Empty(t, resources)
+
+	err = sqlStore.UpdateAlertNotificationWithUid(ctx, &models.UpdateAlertNotificationWithUidCommand{
+		Uid:      "notifier2",
+		OrgId:    1,
+		Name:     "channel2",
+		Type:     "slack",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://slack.example.org"}),
+	})
+	require.NoError(t, err)
+	err = sqlStore.DeleteAlertNotificationWithUid(ctx, &models.DeleteAlertNotificationWithUidCommand{Uid: "notifier1", OrgId: 1})
+	require.NoError(t, err)
+
+	resources, err = Drift(ctx, twoNotificationsConfig, encryptionService)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*drift.Resource{
+		{ID: "notification/1/notifier1", Kind: drift.KindNotification, Status: drift.StatusDeleted, OrgID: 1, UID: "notifier1", Name: "channel1"},
+		{ID: "notification/1/notifier2", Kind: drift.KindNotification, Status: drift.StatusModified, OrgID: 1, UID: "notifier2", Name: "channel2", Fields: []string{"settings"}},
+	}, resources)
+
+	reconciled, err := Reconcile(ctx, twoNotificationsConfig, encryptionService, []string{"notification/1/notifier2"})
+	require.NoError(t, err)
+	require.Len(t, reconciled, 1)
+	query := &models.GetAlertNotificationsWithUidQuery{Uid: "notifier2", OrgId: 1}
+	require.NoError(t, sqlStore.GetAlertNotificationsWithUid(ctx, query))
+	require.Equal(t, "http://slack.com", query.Result.Settings.Get("url").MustString())
+
+	resources, err = Drift(ctx, twoNotificationsConfig, encryptionService)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, drift.StatusDeleted, resources[0].Status)
+}
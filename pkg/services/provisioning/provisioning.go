@@ -2,34 +2,52 @@ package provisioning
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	plugifaces "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+	"github.com/grafana/grafana/pkg/services/provisioning/groupmappings"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	"github.com/grafana/grafana/pkg/services/provisioning/rolebindings"
+	"github.com/grafana/grafana/pkg/services/provisioning/rolebundles"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
+// ErrUnknownDriftKind is returned when comparing a kind of resources which is not provisioned
+var ErrUnknownDriftKind = errors.New("unknown kind of provisioned resources")
+
 func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, pluginStore plugifaces.Store,
-	encryptionService encryption.Internal) (*ProvisioningServiceImpl, error) {
+	encryptionService encryption.Internal, groupMappings accesscontrol.GroupMappingStore,
+	roleBundles accesscontrol.RoleBundleStore, roleBindings accesscontrol.RoleBindingStore) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                     cfg,
 		SQLStore:                sqlStore,
 		pluginStore:             pluginStore,
 		EncryptionService:       encryptionService,
+		groupMappings:           groupMappings,
+		roleBundles:             roleBundles,
+		roleBindings:            roleBindings,
 		log:                     log.New("provisioning"),
 		newDashboardProvisioner: dashboards.New,
 		provisionNotifiers:      notifiers.Provision,
 		provisionDatasources:    datasources.Provision,
 		provisionPlugins:        plugins.Provision,
+		provisionGroupMappings:  groupmappings.Provision,
+		provisionRoleBundles:    rolebundles.Provision,
+		provisionRoleBindings:   rolebindings.Provision,
 	}
 	return s, nil
 }
@@ -40,9 +58,14 @@ type ProvisioningService interface {
 	ProvisionDatasources(ctx context.Context) error
 	ProvisionPlugins(ctx context.Context) error
 	ProvisionNotifications(ctx context.Context) error
+	ProvisionGroupMappings(ctx context.Context) error
+	ProvisionRoleBundles(ctx context.Context) error
+	ProvisionRoleBindings(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	GetDrift(ctx context.Context, kinds ...string) ([]*drift.Resource, error)
+	ReconcileDrift(ctx context.Context, ids []string) ([]*drift.Resource, error)
 }
 
 // Add a public constructor for overriding service to be able to instantiate OSS as fallback
@@ -77,6 +100,9 @@ type ProvisioningServiceImpl struct {
 	SQLStore                *sqlstore.SQLStore
 	pluginStore             plugifaces.Store
 	EncryptionService       encryption.Internal
+	groupMappings           accesscontrol.GroupMappingStore
+	roleBundles             accesscontrol.RoleBundleStore
+	roleBindings            accesscontrol.RoleBindingStore
 	log                     log.Logger
 	pollingCtxCancel        context.CancelFunc
 	newDashboardProvisioner dashboards.DashboardProvisionerFactory
@@ -84,6 +110,9 @@ type ProvisioningServiceImpl struct {
 	provisionNotifiers      func(context.Context, string, encryption.Internal) error
 	provisionDatasources    func(context.Context, string) error
 	provisionPlugins        func(context.Context, string, plugifaces.Store) error
+	provisionGroupMappings  func(context.Context, string, accesscontrol.GroupMappingStore) error
+	provisionRoleBundles    func(context.Context, string, accesscontrol.RoleBundleStore) error
+	provisionRoleBindings   func(context.Context, string, accesscontrol.RoleBindingStore) error
 	mutex                   sync.Mutex
 }
 
@@ -103,6 +132,21 @@ func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) erro
 		return err
 	}
 
+	err = ps.ProvisionRoleBundles(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionGroupMappings(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionRoleBindings(ctx)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -113,6 +157,10 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 		return err
 	}
 
+	if ps.provisionRoleBindings != nil && ps.roleBindings != nil && ps.Cfg.RoleBindingsReconcileInterval > 0 {
+		go ps.reconcileRoleBindings(ctx, ps.Cfg.RoleBindingsReconcileInterval)
+	}
+
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
 		ps.mutex.Lock()
@@ -165,6 +213,65 @@ func (ps *ProvisioningServiceImpl) ProvisionNotifications(ctx context.Context) e
 	return nil
 }
 
+func (ps *ProvisioningServiceImpl) ProvisionGroupMappings(ctx context.Context) error {
+	if ps.provisionGroupMappings == nil || ps.groupMappings == nil {
+		return nil
+	}
+
+	groupMappingsPath := filepath.Join(ps.Cfg.ProvisioningPath, "group-mappings")
+	if err := ps.provisionGroupMappings(ctx, groupMappingsPath, ps.groupMappings); err != nil {
+		err = errutil.Wrap("Group mapping provisioning error", err)
+		ps.log.Error("Failed to provision group mappings", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (ps *ProvisioningServiceImpl) ProvisionRoleBundles(ctx context.Context) error {
+	if ps.provisionRoleBundles == nil || ps.roleBundles == nil {
+		return nil
+	}
+
+	roleBundlesPath := filepath.Join(ps.Cfg.ProvisioningPath, "role-bundles")
+	if err := ps.provisionRoleBundles(ctx, roleBundlesPath, ps.roleBundles); err != nil {
+		err = errutil.Wrap("Role bundle provisioning error", err)
+		ps.log.Error("Failed to provision role bundles", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (ps *ProvisioningServiceImpl) ProvisionRoleBindings(ctx context.Context) error {
+	if ps.provisionRoleBindings == nil || ps.roleBindings == nil {
+		return nil
+	}
+
+	roleBindingsPath := filepath.Join(ps.Cfg.ProvisioningPath, "role-bindings")
+	if err := ps.provisionRoleBindings(ctx, roleBindingsPath, ps.roleBindings); err != nil {
+		err = errutil.Wrap("Role binding provisioning error", err)
+		ps.log.Error("Failed to provision role bindings", "error", err)
+		return err
+	}
+	return nil
+}
+
+// reconcileRoleBindings applies the role bindings provisioning files at every interval until the context is done,
+// so that the bindings removed from the files are unassigned without restarting Grafana
+func (ps *ProvisioningServiceImpl) reconcileRoleBindings(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// The error is logged already, the next tick tries again
+			_ = ps.ProvisionRoleBindings(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (ps *ProvisioningServiceImpl) ProvisionDashboards(ctx context.Context) error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
 	dashProvisioner, err := ps.newDashboardProvisioner(ctx, dashboardPath, ps.SQLStore)
@@ -196,6 +303,75 @@ func (ps *ProvisioningServiceImpl) GetAllowUIUpdatesFromConfig(name string) bool
 	return ps.dashboardProvisioner.GetAllowUIUpdatesFromConfig(name)
 }
 
+// GetDrift compares the resources of the provisioning files of the kinds, all of them when there is no kind, with
+// their database state and returns the drifted resources
+func (ps *ProvisioningServiceImpl) GetDrift(ctx context.Context, kinds ...string) ([]*drift.Resource, error) {
+	result := make([]*drift.Resource, 0)
+	for _, kind := range driftKinds(kinds) {
+		var resources []*drift.Resource
+		var err error
+		switch kind {
+		case drift.KindDatasource:
+			resources, err = datasources.Drift(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "datasources"))
+		case drift.KindNotification:
+			resources, err = notifiers.Drift(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "notifiers"), ps.EncryptionService)
+		case drift.KindDashboard:
+			resources, err = ps.dashboardDrift(ctx, nil, false)
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownDriftKind, kind)
+		}
+		if err != nil {
+			return nil, errutil.Wrapf(err, "Failed to compare the provisioned %ss", kind)
+		}
+		result = append(result, resources...)
+	}
+	return result, nil
+}
+
+// ReconcileDrift applies the provisioning files to the drifted resources with the IDs, all of them when there is
+// no ID, and returns the reconciled resources
+func (ps *ProvisioningServiceImpl) ReconcileDrift(ctx context.Context, ids []string) ([]*drift.Resource, error) {
+	result := make([]*drift.Resource, 0)
+	for _, kind := range driftKinds(nil) {
+		var resources []*drift.Resource
+		var err error
+		switch kind {
+		case drift.KindDatasource:
+			resources, err = datasources.Reconcile(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "datasources"), ids)
+		case drift.KindNotification:
+			resources, err = notifiers.Reconcile(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "notifiers"), ps.EncryptionService, ids)
+		case drift.KindDashboard:
+			resources, err = ps.dashboardDrift(ctx, ids, true)
+		}
+		result = append(result, resources...)
+		if err != nil {
+			return result, errutil.Wrapf(err, "Failed to reconcile the provisioned %ss", kind)
+		}
+	}
+	return result, nil
+}
+
+func (ps *ProvisioningServiceImpl) dashboardDrift(ctx context.Context, ids []string, reconcile bool) ([]*drift.Resource, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	// The dashboards are provisioned once the service runs
+	if ps.dashboardProvisioner == nil {
+		return nil, nil
+	}
+	if reconcile {
+		return ps.dashboardProvisioner.Reconcile(ctx, ids)
+	}
+	return ps.dashboardProvisioner.Drift(ctx)
+}
+
+func driftKinds(kinds []string) []string {
+	if len(kinds) == 0 {
+		return []string{drift.KindDatasource, drift.KindDashboard, drift.KindNotification}
+	}
+	return kinds
+}
+
 func (ps *ProvisioningServiceImpl) cancelPolling() {
 	if ps.pollingCtxCancel != nil {
 		ps.log.Debug("Stop polling for dashboard changes")
@@ -1,15 +1,24 @@
 package provisioning
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/drift"
+)
 
 type Calls struct {
 	RunInitProvisioners                 []interface{}
 	ProvisionDatasources                []interface{}
 	ProvisionPlugins                    []interface{}
 	ProvisionNotifications              []interface{}
+	ProvisionGroupMappings              []interface{}
+	ProvisionRoleBundles                []interface{}
+	ProvisionRoleBindings               []interface{}
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
+	GetDrift                            []interface{}
+	ReconcileDrift                      []interface{}
 	Run                                 []interface{}
 }
 
@@ -19,9 +28,14 @@ type ProvisioningServiceMock struct {
 	ProvisionDatasourcesFunc                func(ctx context.Context) error
 	ProvisionPluginsFunc                    func() error
 	ProvisionNotificationsFunc              func() error
+	ProvisionGroupMappingsFunc              func() error
+	ProvisionRoleBundlesFunc                func() error
+	ProvisionRoleBindingsFunc               func() error
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	GetDriftFunc                            func(ctx context.Context, kinds ...string) ([]*drift.Resource, error)
+	ReconcileDriftFunc                      func(ctx context.Context, ids []string) ([]*drift.Resource, error)
 	RunFunc                                 func(ctx context.Context) error
 }
 
@@ -63,6 +77,30 @@ func (mock *ProvisioningServiceMock) ProvisionNotifications(ctx context.Context)
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionGroupMappings(ctx context.Context) error {
+	mock.Calls.ProvisionGroupMappings = append(mock.Calls.ProvisionGroupMappings, nil)
+	if mock.ProvisionGroupMappingsFunc != nil {
+		return mock.ProvisionGroupMappingsFunc()
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) ProvisionRoleBundles(ctx context.Context) error {
+	mock.Calls.ProvisionRoleBundles = append(mock.Calls.ProvisionRoleBundles, nil)
+	if mock.ProvisionRoleBundlesFunc != nil {
+		return mock.ProvisionRoleBundlesFunc()
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) ProvisionRoleBindings(ctx context.Context) error {
+	mock.Calls.ProvisionRoleBindings = append(mock.Calls.ProvisionRoleBindings, nil)
+	if mock.ProvisionRoleBindingsFunc != nil {
+		return mock.ProvisionRoleBindingsFunc()
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionDashboards(ctx context.Context) error {
 	mock.Calls.ProvisionDashboards = append(mock.Calls.ProvisionDashboards, nil)
 	if mock.ProvisionDashboardsFunc != nil {
@@ -87,6 +125,22 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	return false
 }
 
+func (mock *ProvisioningServiceMock) GetDrift(ctx context.Context, kinds ...string) ([]*drift.Resource, error) {
+	mock.Calls.GetDrift = append(mock.Calls.GetDrift, kinds)
+	if mock.GetDriftFunc != nil {
+		return mock.GetDriftFunc(ctx, kinds...)
+	}
+	return nil, nil
+}
+
+func (mock *ProvisioningServiceMock) ReconcileDrift(ctx context.Context, ids []string) ([]*drift.Resource, error) {
+	mock.Calls.ReconcileDrift = append(mock.Calls.ReconcileDrift, ids)
+	if mock.ReconcileDriftFunc != nil {
+		return mock.ReconcileDriftFunc(ctx, ids)
+	}
+	return nil, nil
+}
+
 func (mock *ProvisioningServiceMock) Run(ctx context.Context) error {
 	mock.Calls.Run = append(mock.Calls.Run, nil)
 	if mock.RunFunc != nil {
@@ -3,10 +3,12 @@ package provisioning
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	dboards "github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -62,6 +64,38 @@ func TestProvisioningServiceImpl(t *testing.T) {
 		// Cancelling the root context and stopping the service
 		serviceTest.cancel()
 	})
+
+	t.Run("Role bindings are provisioned again at every interval", func(t *testing.T) {
+		serviceTest := setup()
+		serviceTest.service.Cfg.RoleBindingsReconcileInterval = 10 * time.Millisecond
+		serviceTest.service.roleBindings = fakeRoleBindingStore{}
+		provisioned := make(chan string)
+		serviceTest.service.provisionRoleBindings = func(ctx context.Context, path string, store accesscontrol.RoleBindingStore) error {
+			select {
+			case provisioned <- path:
+			case <-ctx.Done():
+			}
+			return nil
+		}
+
+		serviceTest.startService()
+		serviceTest.waitForPollChanges()
+		for i := 0; i < 2; i++ {
+			select {
+			case path := <-provisioned:
+				assert.Equal(t, filepath.Join(serviceTest.service.Cfg.ProvisioningPath, "role-bindings"), path)
+			case <-time.After(serviceTest.waitTimeout):
+				t.Fatal("Role bindings should have been provisioned")
+			}
+		}
+
+		serviceTest.cancel()
+		serviceTest.waitForStop()
+	})
+}
+
+type fakeRoleBindingStore struct {
+	accesscontrol.RoleBindingStore
 }
 
 type serviceTestStruct struct {
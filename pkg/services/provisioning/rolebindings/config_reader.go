@@ -0,0 +1,97 @@
+package rolebindings
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(ctx context.Context, path string) ([]*roleBindingsAsConfig, error) {
+	var bindings []*roleBindingsAsConfig
+	cr.log.Debug("Looking for role binding provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read role binding provisioning files from directory", "path", path, "error", err)
+		return bindings, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing role bindings provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseRoleBindingsConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				bindings = append(bindings, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating role bindings")
+	if err := cr.validateRoleBindings(ctx, bindings); err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+func (cr *configReader) parseRoleBindingsConfig(path string, file os.FileInfo) (*roleBindingsAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *roleBindingsAsConfigV1
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToRoleBindingsFromConfig(), nil
+}
+
+func (cr *configReader) validateRoleBindings(ctx context.Context, configs []*roleBindingsAsConfig) error {
+	for _, cfg := range configs {
+		for index, binding := range cfg.RoleBindings {
+			if binding.Role == "" {
+				return fmt.Errorf("role binding item %d in configuration doesn't contain required field role", index+1)
+			}
+			if (binding.User == "") == (binding.Team == "") {
+				return fmt.Errorf("role binding of %q should contain either a user or a team", binding.Role)
+			}
+			if strings.HasPrefix(binding.Role, accesscontrol.ManagedRolePrefix) || strings.HasPrefix(binding.Role, accesscontrol.RoleBindingRolePrefix) {
+				return fmt.Errorf("role binding of %q: managed roles cannot be bound", binding.Role)
+			}
+			if binding.Scope != "" && !accesscontrol.ValidateScope(binding.Scope) {
+				return fmt.Errorf("role binding of %q: invalid scope %q", binding.Role, binding.Scope)
+			}
+
+			if binding.OrgID < 1 {
+				binding.OrgID = 1
+			} else if err := utils.CheckOrgExists(ctx, binding.OrgID); err != nil {
+				return fmt.Errorf("failed to provision role binding of %q: %w", binding.Role, err)
+			}
+		}
+	}
+	return nil
+}
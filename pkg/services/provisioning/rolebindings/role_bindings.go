@@ -0,0 +1,36 @@
+package rolebindings
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// Provision reconciles the role bindings with the ones of the configuration files: the bindings missing from the
+// store are assigned and the ones removed from the files are unassigned
+func Provision(ctx context.Context, configDirectory string, store accesscontrol.RoleBindingStore) error {
+	logger := log.New("provisioning.rolebindings")
+	cr := &configReader{log: logger}
+
+	configs, err := cr.readConfig(ctx, configDirectory)
+	if err != nil {
+		return err
+	}
+
+	cmds := make([]accesscontrol.AddRoleBindingCommand, 0)
+	for _, cfg := range configs {
+		for _, binding := range cfg.RoleBindings {
+			cmds = append(cmds, accesscontrol.AddRoleBindingCommand{
+				OrgID: binding.OrgID,
+				User:  binding.User,
+				Team:  binding.Team,
+				Role:  binding.Role,
+				Scope: binding.Scope,
+			})
+		}
+	}
+
+	logger.Debug("Provisioning role bindings", "count", len(cmds))
+	return store.SetProvisionedRoleBindings(ctx, cmds)
+}
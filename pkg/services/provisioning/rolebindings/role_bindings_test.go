@@ -0,0 +1,85 @@
+package rolebindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	invalidSubject    = "./testdata/test-configs/invalid-subject"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestProvision(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	bus.AddHandler("getOrg", func(ctx context.Context, q *models.GetOrgByIdQuery) error {
+		return sqlstore.GetOrgById(ctx, q)
+	})
+	require.NoError(t, sqlstore.CreateOrg(context.Background(), &models.CreateOrgCommand{Name: "Main Org."}))
+	store := database.ProvideService(sqlStore)
+
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "user", OrgId: 1})
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddOrgUser(context.Background(), &models.AddOrgUserCommand{OrgId: 1, UserId: user.Id, Role: models.ROLE_VIEWER}))
+	team, err := sqlStore.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+	require.NoError(t, store.ImportRoleBundle(context.Background(), accesscontrol.RoleBundle{
+		APIVersion: accesscontrol.RoleBundleAPIVersion,
+		Roles: []accesscontrol.RoleBundleRole{{
+			UID:         "custom_editor",
+			Name:        "custom:editor",
+			OrgID:       1,
+			Version:     1,
+			Permissions: []accesscontrol.RoleBundlePermission{{Action: "dashboards:write", Scope: "dashboards:*"}},
+		}},
+	}))
+
+	t.Run("should provision the role bindings", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), correctProperties, store))
+
+		bindings, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, bindings, 2)
+		assert.Equal(t, user.Id, bindings[0].UserID)
+		assert.Equal(t, "fixed:users:reader", bindings[0].RoleName)
+		assert.Equal(t, team.Id, bindings[1].TeamID)
+		assert.Equal(t, "custom:editor", bindings[1].RoleName)
+		assert.Equal(t, "dashboards:uid:abc", bindings[1].Scope)
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "fixed:users:reader", roles[0].Name)
+	})
+
+	t.Run("should fail on bindings without a single subject", func(t *testing.T) {
+		err := Provision(context.Background(), invalidSubject, store)
+		require.Error(t, err)
+
+		bindings, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, bindings, 2)
+	})
+
+	t.Run("should unassign the bindings no longer provisioned", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), emptyFolder, store))
+
+		bindings, err := store.GetRoleBindings(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, bindings)
+
+		roles, err := store.GetUserRoles(context.Background(), 1, user.Id)
+		require.NoError(t, err)
+		assert.Empty(t, roles)
+	})
+}
@@ -0,0 +1,48 @@
+package rolebindings
+
+import "github.com/grafana/grafana/pkg/services/provisioning/values"
+
+// roleBindingsAsConfig is normalized data object for role bindings config data. Any config version should be mappable
+// to this type.
+type roleBindingsAsConfig struct {
+	RoleBindings []*roleBindingFromConfig
+}
+
+type roleBindingFromConfig struct {
+	OrgID int64
+	User  string
+	Team  string
+	Role  string
+	Scope string
+}
+
+// roleBindingsAsConfigV1 is mapping for version 1 configs. This is mapped to its normalised version.
+type roleBindingsAsConfigV1 struct {
+	RoleBindings []*roleBindingFromConfigV1 `json:"roleBindings" yaml:"roleBindings"`
+}
+
+type roleBindingFromConfigV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	User  values.StringValue `json:"user" yaml:"user"`
+	Team  values.StringValue `json:"team" yaml:"team"`
+	Role  values.StringValue `json:"role" yaml:"role"`
+	Scope values.StringValue `json:"scope" yaml:"scope"`
+}
+
+func (cfg *roleBindingsAsConfigV1) mapToRoleBindingsFromConfig() *roleBindingsAsConfig {
+	r := &roleBindingsAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, b := range cfg.RoleBindings {
+		r.RoleBindings = append(r.RoleBindings, &roleBindingFromConfig{
+			OrgID: b.OrgID.Value(),
+			User:  b.User.Value(),
+			Team:  b.Team.Value(),
+			Role:  b.Role.Value(),
+			Scope: b.Scope.Value(),
+		})
+	}
+	return r
+}
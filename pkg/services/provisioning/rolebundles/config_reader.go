@@ -0,0 +1,117 @@
+package rolebundles
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(ctx context.Context, path string) ([]*accesscontrol.RoleBundle, error) {
+	var bundles []*accesscontrol.RoleBundle
+	cr.log.Debug("Looking for role bundle provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read role bundle provisioning files from directory", "path", path, "error", err)
+		return bundles, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing role bundle provisioning file", "path", path, "file.Name", file.Name())
+			bundle, err := cr.parseRoleBundle(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if bundle != nil {
+				bundles = append(bundles, bundle)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating role bundles")
+	if err := cr.validateRoleBundles(ctx, bundles); err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+func (cr *configReader) parseRoleBundle(path string, file os.FileInfo) (*accesscontrol.RoleBundle, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *roleBundleAsConfigV1
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToRoleBundle(), nil
+}
+
+// validateRoleBundles checks the required fields and the organizations. Roles without organization are global,
+// assignments and deny permissions without organization belong to the main organization.
+func (cr *configReader) validateRoleBundles(ctx context.Context, bundles []*accesscontrol.RoleBundle) error {
+	for _, bundle := range bundles {
+		for index, role := range bundle.Roles {
+			if role.Name == "" {
+				return fmt.Errorf("role item %d in configuration doesn't contain required field name", index+1)
+			}
+			if role.OrgID < 0 {
+				return fmt.Errorf("role %q has an invalid orgId", role.Name)
+			} else if role.OrgID > 0 {
+				if err := utils.CheckOrgExists(ctx, role.OrgID); err != nil {
+					return fmt.Errorf("failed to provision role %q: %w", role.Name, err)
+				}
+			}
+		}
+
+		for index := range bundle.Assignments {
+			assignment := &bundle.Assignments[index]
+			if assignment.Role == "" {
+				return fmt.Errorf("assignment item %d in configuration doesn't contain required field role", index+1)
+			}
+
+			if assignment.OrgID < 1 {
+				assignment.OrgID = 1
+			} else if err := utils.CheckOrgExists(ctx, assignment.OrgID); err != nil {
+				return fmt.Errorf("failed to provision assignments of %q: %w", assignment.Role, err)
+			}
+		}
+
+		for index := range bundle.Denies {
+			deny := &bundle.Denies[index]
+			if deny.Action == "" {
+				return fmt.Errorf("deny item %d in configuration doesn't contain required field action", index+1)
+			}
+
+			if deny.OrgID < 1 {
+				deny.OrgID = 1
+			} else if err := utils.CheckOrgExists(ctx, deny.OrgID); err != nil {
+				return fmt.Errorf("failed to provision deny permissions of %q: %w", deny.Action, err)
+			}
+		}
+	}
+	return nil
+}
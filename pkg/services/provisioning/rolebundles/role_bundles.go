@@ -0,0 +1,29 @@
+package rolebundles
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// Provision imports the role bundles of the configuration files. Importing is idempotent: roles are created or
+// updated and missing assignments and deny permissions are added, but roles, assignments and deny permissions
+// removed from the files are kept.
+func Provision(ctx context.Context, configDirectory string, store accesscontrol.RoleBundleStore) error {
+	logger := log.New("provisioning.rolebundles")
+	cr := &configReader{log: logger}
+
+	bundles, err := cr.readConfig(ctx, configDirectory)
+	if err != nil {
+		return err
+	}
+
+	for _, bundle := range bundles {
+		logger.Debug("Provisioning role bundle", "roles", len(bundle.Roles), "assignments", len(bundle.Assignments), "denies", len(bundle.Denies))
+		if err := store.ImportRoleBundle(ctx, *bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
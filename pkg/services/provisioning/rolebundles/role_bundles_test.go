@@ -0,0 +1,84 @@
+package rolebundles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	invalidRole       = "./testdata/test-configs/invalid-role"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestProvision(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	bus.AddHandler("getOrg", func(ctx context.Context, q *models.GetOrgByIdQuery) error {
+		return sqlstore.GetOrgById(ctx, q)
+	})
+	require.NoError(t, sqlstore.CreateOrg(context.Background(), &models.CreateOrgCommand{Name: "Main Org."}))
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "user", OrgId: 1})
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddOrgUser(context.Background(), &models.AddOrgUserCommand{OrgId: 1, UserId: user.Id, Role: models.ROLE_VIEWER}))
+	_, err = sqlStore.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+	store := database.ProvideService(sqlStore)
+
+	t.Run("should provision the roles and the assignments", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), correctProperties, store))
+		// Provisioning again does not duplicate anything
+		require.NoError(t, Provision(context.Background(), correctProperties, store))
+
+		bundle, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, bundle.Roles, 2)
+		assert.Equal(t, "custom:reports:reader", bundle.Roles[0].Name)
+		assert.Equal(t, int64(accesscontrol.GlobalOrgID), bundle.Roles[0].OrgID)
+		assert.Equal(t, "custom_dashboards_editor", bundle.Roles[1].UID)
+		assert.Equal(t, "Dashboards editor", bundle.Roles[1].DisplayName)
+		assert.Equal(t, []accesscontrol.RoleBundlePermission{
+			{Action: "dashboards:delete", Scope: "dashboards:uid:production", Deny: true},
+			{Action: "dashboards:read", Scope: "dashboards:*"},
+			{Action: "dashboards:write", Scope: "dashboards:*"},
+		}, bundle.Roles[1].Permissions)
+
+		assert.Equal(t, []accesscontrol.RoleBundleAssignment{
+			{OrgID: 1, Role: "custom:dashboards:editor", Teams: []string{"team"}, BuiltInRoles: []string{"Editor"}},
+			{OrgID: 1, Role: "fixed:users:reader", Users: []string{user.Login}},
+		}, bundle.Assignments)
+
+		assert.Equal(t, []accesscontrol.RoleBundleDeny{
+			{OrgID: 1, Action: "datasources:query", Scope: "datasources:uid:secrets", Users: []string{user.Login}, Teams: []string{"team"}, BuiltInRoles: []string{"Viewer"}},
+		}, bundle.Denies)
+
+		denies, err := store.GetDenyPermissions(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, denies, 3, "the denies are stored in the managed roles of the subjects")
+	})
+
+	t.Run("should fail on fixed roles assigned to built-in roles", func(t *testing.T) {
+		err := Provision(context.Background(), invalidRole, store)
+		require.ErrorIs(t, err, accesscontrol.ErrInvalidRoleBundle)
+
+		bundle, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, bundle.Roles, 2)
+	})
+
+	t.Run("should keep the roles no longer provisioned", func(t *testing.T) {
+		require.NoError(t, Provision(context.Background(), emptyFolder, store))
+
+		bundle, err := store.ExportRoleBundle(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Len(t, bundle.Roles, 2)
+	})
+}
@@ -0,0 +1,102 @@
+package rolebundles
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// roleBundleAsConfigV1 is mapping for version 1 configs, the format of the bundles exported by
+// GET /api/access-control/export. This is mapped to the role bundle of the access control store.
+type roleBundleAsConfigV1 struct {
+	Roles       []*roleFromConfigV1       `json:"roles" yaml:"roles"`
+	Assignments []*assignmentFromConfigV1 `json:"assignments" yaml:"assignments"`
+	Denies      []*denyFromConfigV1       `json:"denies" yaml:"denies"`
+}
+
+type roleFromConfigV1 struct {
+	UID         values.StringValue    `json:"uid" yaml:"uid"`
+	Name        values.StringValue    `json:"name" yaml:"name"`
+	OrgID       values.Int64Value     `json:"orgId" yaml:"orgId"`
+	Version     values.Int64Value     `json:"version" yaml:"version"`
+	DisplayName values.StringValue    `json:"displayName" yaml:"displayName"`
+	Description values.StringValue    `json:"description" yaml:"description"`
+	Group       values.StringValue    `json:"group" yaml:"group"`
+	Permissions []*permissionConfigV1 `json:"permissions" yaml:"permissions"`
+}
+
+type permissionConfigV1 struct {
+	Action values.StringValue `json:"action" yaml:"action"`
+	Scope  values.StringValue `json:"scope" yaml:"scope"`
+	Deny   values.BoolValue   `json:"deny" yaml:"deny"`
+}
+
+type assignmentFromConfigV1 struct {
+	OrgID        values.Int64Value    `json:"orgId" yaml:"orgId"`
+	Role         values.StringValue   `json:"role" yaml:"role"`
+	Users        []values.StringValue `json:"users" yaml:"users"`
+	Teams        []values.StringValue `json:"teams" yaml:"teams"`
+	BuiltInRoles []values.StringValue `json:"builtInRoles" yaml:"builtInRoles"`
+}
+
+type denyFromConfigV1 struct {
+	OrgID        values.Int64Value    `json:"orgId" yaml:"orgId"`
+	Action       values.StringValue   `json:"action" yaml:"action"`
+	Scope        values.StringValue   `json:"scope" yaml:"scope"`
+	Users        []values.StringValue `json:"users" yaml:"users"`
+	Teams        []values.StringValue `json:"teams" yaml:"teams"`
+	BuiltInRoles []values.StringValue `json:"builtInRoles" yaml:"builtInRoles"`
+}
+
+func (cfg *roleBundleAsConfigV1) mapToRoleBundle() *accesscontrol.RoleBundle {
+	r := &accesscontrol.RoleBundle{APIVersion: accesscontrol.RoleBundleAPIVersion}
+	if cfg == nil {
+		return r
+	}
+
+	for _, role := range cfg.Roles {
+		permissions := make([]accesscontrol.RoleBundlePermission, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			permissions = append(permissions, accesscontrol.RoleBundlePermission{Action: p.Action.Value(), Scope: p.Scope.Value(), Deny: p.Deny.Value()})
+		}
+		r.Roles = append(r.Roles, accesscontrol.RoleBundleRole{
+			UID:         role.UID.Value(),
+			Name:        role.Name.Value(),
+			OrgID:       role.OrgID.Value(),
+			Version:     role.Version.Value(),
+			DisplayName: role.DisplayName.Value(),
+			Description: role.Description.Value(),
+			Group:       role.Group.Value(),
+			Permissions: permissions,
+		})
+	}
+
+	for _, a := range cfg.Assignments {
+		r.Assignments = append(r.Assignments, accesscontrol.RoleBundleAssignment{
+			OrgID:        a.OrgID.Value(),
+			Role:         a.Role.Value(),
+			Users:        stringValues(a.Users),
+			Teams:        stringValues(a.Teams),
+			BuiltInRoles: stringValues(a.BuiltInRoles),
+		})
+	}
+
+	for _, d := range cfg.Denies {
+		r.Denies = append(r.Denies, accesscontrol.RoleBundleDeny{
+			OrgID:        d.OrgID.Value(),
+			Action:       d.Action.Value(),
+			Scope:        d.Scope.Value(),
+			Users:        stringValues(d.Users),
+			Teams:        stringValues(d.Teams),
+			BuiltInRoles: stringValues(d.BuiltInRoles),
+		})
+	}
+	return r
+}
+
+func stringValues(v []values.StringValue) []string {
+	r := make([]string, 0, len(v))
+	for _, s := range v {
+		r = append(r, s.Value())
+	}
+	return r
+}
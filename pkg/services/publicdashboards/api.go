@@ -0,0 +1,188 @@
+package publicdashboards
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/dashboards/uid/:uid/public", func(publicRoute routing.RouteRegister) {
+		publicRoute.Get("/", routing.Wrap(s.getPublicDashboardHandler))
+		publicRoute.Put("/", routing.Wrap(s.savePublicDashboardHandler))
+		publicRoute.Delete("/", routing.Wrap(s.deletePublicDashboardHandler))
+		publicRoute.Post("/rotate-token", routing.Wrap(s.rotateAccessTokenHandler))
+	}, middleware.ReqSignedIn)
+	routeRegister.Get("/api/public-dashboards", middleware.ReqOrgAdmin, routing.Wrap(s.getPublicDashboardsHandler))
+
+	// The public dashboards are viewed without being signed in
+	routeRegister.Group("/api/public/dashboards/:accessToken", func(viewRoute routing.RouteRegister) {
+		viewRoute.Get("/", routing.Wrap(s.viewPublicDashboardHandler))
+		viewRoute.Post("/panels/:panelId/query", routing.Wrap(s.queryPanelHandler))
+	})
+}
+
+// canAdminDashboard returns an error response unless the user can administrate the dashboard of the request
+func (s *Service) canAdminDashboard(c *models.ReqContext) response.Response {
+	dash, err := s.sqlStore.GetDashboard(0, c.OrgId, web.Params(c.Req)[":uid"], "")
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard", err)
+	}
+
+	g := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	canAdmin, err := g.CanAdmin()
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+	}
+	if !canAdmin {
+		return response.Error(http.StatusForbidden, "Access denied to this dashboard", nil)
+	}
+	return nil
+}
+
+// GET /api/dashboards/uid/:uid/public
+func (s *Service) getPublicDashboardHandler(c *models.ReqContext) response.Response {
+	if rsp := s.canAdminDashboard(c); rsp != nil {
+		return rsp
+	}
+	pd, err := s.GetPublicDashboard(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get public dashboard")
+	}
+	return response.JSON(http.StatusOK, pd)
+}
+
+// PUT /api/dashboards/uid/:uid/public
+func (s *Service) savePublicDashboardHandler(c *models.ReqContext) response.Response {
+	cmd := SavePublicDashboardCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if rsp := s.canAdminDashboard(c); rsp != nil {
+		return rsp
+	}
+	pd, err := s.SavePublicDashboard(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if errors.Is(err, ErrDisabled) {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	if err != nil {
+		return errorResponse(err, "Failed to save public dashboard")
+	}
+	return response.JSON(http.StatusOK, pd)
+}
+
+// DELETE /api/dashboards/uid/:uid/public
+func (s *Service) deletePublicDashboardHandler(c *models.ReqContext) response.Response {
+	if rsp := s.canAdminDashboard(c); rsp != nil {
+		return rsp
+	}
+	if err := s.DeletePublicDashboard(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err, "Failed to delete public dashboard")
+	}
+	return response.Success("Public dashboard deleted")
+}
+
+// POST /api/dashboards/uid/:uid/public/rotate-token
+func (s *Service) rotateAccessTokenHandler(c *models.ReqContext) response.Response {
+	if rsp := s.canAdminDashboard(c); rsp != nil {
+		return rsp
+	}
+	pd, err := s.RotateAccessToken(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to rotate access token")
+	}
+	return response.JSON(http.StatusOK, pd)
+}
+
+// GET /api/public-dashboards
+func (s *Service) getPublicDashboardsHandler(c *models.ReqContext) response.Response {
+	pds, err := s.GetPublicDashboards(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get public dashboards")
+	}
+	return response.JSON(http.StatusOK, pds)
+}
+
+// GET /api/public/dashboards/:accessToken
+func (s *Service) viewPublicDashboardHandler(c *models.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	pd, dash, err := s.GetDashboard(c.Req.Context(), accessToken)
+	observe(metricsUID(accessToken, err), "dashboard", err)
+	if err != nil {
+		return errorResponse(err, "Failed to get public dashboard")
+	}
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"dashboard": dash.Data,
+		"meta": map[string]interface{}{
+			"publicDashboardUid": pd.Uid,
+			"title":              dash.Title,
+			"updated":            dash.Updated,
+		},
+	})
+}
+
+// POST /api/public/dashboards/:accessToken/panels/:panelId/query
+func (s *Service) queryPanelHandler(c *models.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	panelID, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "panelId is invalid", err)
+	}
+	cmd := PanelQueryCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	resp, err := s.QueryPanel(c.Req.Context(), accessToken, panelID, cmd)
+	observe(metricsUID(accessToken, err), "query", err)
+	if err != nil {
+		return errorResponse(err, "Failed to query panel")
+	}
+	statusCode := http.StatusOK
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			statusCode = http.StatusBadRequest
+		}
+	}
+	return response.JSONStreaming(statusCode, resp)
+}
+
+// metricsUID returns the uid of the public dashboard of the access token for the access metrics. The requests with
+// an invalid access token are counted together so that they cannot add labels.
+func metricsUID(accessToken string, err error) string {
+	if isHidden(err) {
+		return "unknown"
+	}
+	uid, _ := parseAccessToken(accessToken)
+	return uid
+}
+
+func isHidden(err error) bool {
+	return errors.Is(err, ErrDisabled) ||
+		errors.Is(err, ErrPublicDashboardNotFound) ||
+		errors.Is(err, ErrInvalidAccessToken) ||
+		errors.Is(err, models.ErrDashboardNotFound)
+}
+
+// errorResponse hides the existence of the public dashboards which are disabled or whose access token is revoked
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case isHidden(err):
+		return response.Error(http.StatusNotFound, ErrPublicDashboardNotFound.Error(), nil)
+	case errors.Is(err, ErrPanelNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidTimeRange), errors.Is(err, models.ErrDataSourceNotFound):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
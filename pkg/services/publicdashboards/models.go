@@ -0,0 +1,81 @@
+package publicdashboards
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDisabled                = errors.New("public dashboards are disabled")
+	ErrPublicDashboardNotFound = errors.New("public dashboard not found")
+	ErrInvalidAccessToken      = errors.New("invalid access token")
+	ErrPanelNotFound           = errors.New("panel not found")
+	ErrInvalidTimeRange        = errors.New("invalid time range")
+)
+
+// Variables are the values of the template variables of a public dashboard, by name
+type Variables map[string]string
+
+// FromDB is part of the xorm Conversion interface.
+func (v *Variables) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*v = Variables{}
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (v *Variables) ToDB() ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// PublicDashboard publishes a dashboard at an unauthenticated URL, signed with the secret key of the instance. The
+// viewers of the public dashboard can only run the queries of its panels, over the time range and with the values
+// of the template variables chosen by the publisher.
+type PublicDashboard struct {
+	Id           int64  `json:"-"`
+	Uid          string `json:"uid"`
+	OrgId        int64  `json:"-"`
+	DashboardUid string `json:"dashboardUid"`
+	Enabled      bool   `json:"enabled"`
+	// TimeFrom and TimeTo are the time range of the queries, such as now-6h and now. The viewers can zoom in but not
+	// out of it.
+	TimeFrom string `json:"timeFrom"`
+	TimeTo   string `json:"timeTo"`
+	// Variables are the values of the template variables, the other variables have their current value saved in the
+	// dashboard
+	Variables Variables `json:"variables"`
+	// TokenVersion is signed in the access token, changing it revokes the access tokens signed before
+	TokenVersion int       `json:"-"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+	UpdatedBy    int64     `json:"updatedBy"`
+}
+
+// PublicDashboardDTO is a public dashboard with its access token
+type PublicDashboardDTO struct {
+	*PublicDashboard
+	AccessToken string `json:"accessToken"`
+	// URL is the path of the model of the public dashboard in the API, relative to the root URL of Grafana
+	URL string `json:"url"`
+}
+
+// SavePublicDashboardCommand publishes a dashboard or changes its public dashboard
+type SavePublicDashboardCommand struct {
+	Enabled   bool      `json:"enabled"`
+	TimeFrom  string    `json:"timeFrom"`
+	TimeTo    string    `json:"timeTo"`
+	Variables Variables `json:"variables"`
+}
+
+// PanelQueryCommand runs the queries of a panel of a public dashboard
+type PanelQueryCommand struct {
+	// From and To narrow the time range of the public dashboard down, they are bounded by it
+	From string `json:"from"`
+	To   string `json:"to"`
+	// MaxDataPoints and IntervalMs are the resolution of the panel in the browser of the viewer
+	MaxDataPoints int64 `json:"maxDataPoints"`
+	IntervalMs    int64 `json:"intervalMs"`
+}
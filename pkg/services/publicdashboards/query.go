@@ -0,0 +1,250 @@
+package publicdashboards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+const (
+	mixedDataSource = "-- Mixed --"
+	// defaultMaxDataPoints is the resolution of the queries when the viewer does not send the one of the panel
+	defaultMaxDataPoints = 1000
+)
+
+// variablePattern matches the references to the template variables: $name, ${name}, ${name:format} and [[name]]
+var variablePattern = regexp.MustCompile(`\$(\w+)|\$\{(\w+)(?::[^}]*)?\}|\[\[(\w+)(?::[^\]]*)?\]\]`)
+
+// timeRange returns the time range of the queries of the public dashboard, narrowed down by the time range sent by
+// the viewer. The viewers cannot query outside of the time range of the public dashboard.
+func timeRange(pd *PublicDashboard, from, to string, now time.Time) (time.Time, time.Time, error) {
+	published := legacydata.DataTimeRange{From: pd.TimeFrom, To: pd.TimeTo, Now: now}
+	start, err := published.ParseFrom()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimeRange, err)
+	}
+	end, err := published.ParseTo()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimeRange, err)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s is not before %s", ErrInvalidTimeRange, pd.TimeFrom, pd.TimeTo)
+	}
+
+	if from == "" && to == "" {
+		return start, end, nil
+	}
+	requested := legacydata.DataTimeRange{From: from, To: to, Now: now}
+	if from != "" {
+		t, err := requested.ParseFrom()
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimeRange, err)
+		}
+		if t.After(start) {
+			start = t
+		}
+	}
+	if to != "" {
+		t, err := requested.ParseTo()
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimeRange, err)
+		}
+		if t.Before(end) {
+			end = t
+		}
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: the time range is outside of the one of the public dashboard", ErrInvalidTimeRange)
+	}
+	return start, end, nil
+}
+
+// variableValues returns the values of the template variables of the dashboard: the ones chosen by the publisher,
+// or their current value saved in the dashboard. The multiple values are formatted as a glob, {a,b}.
+func variableValues(model *simplejson.Json, overrides Variables) map[string]string {
+	values := make(map[string]string)
+	for _, v := range model.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(v)
+		name := variable.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+		if value, ok := overrides[name]; ok {
+			values[name] = value
+			continue
+		}
+
+		current := variable.GetPath("current", "value").Interface()
+		var selected []string
+		switch value := current.(type) {
+		case string:
+			selected = []string{value}
+		case []interface{}:
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					selected = append(selected, s)
+				}
+			}
+		}
+		if len(selected) == 1 && selected[0] == "$__all" {
+			if allValue := variable.Get("allValue").MustString(); allValue != "" {
+				values[name] = allValue
+				continue
+			}
+			selected = nil
+			for _, option := range variable.Get("options").MustArray() {
+				if value, ok := simplejson.NewFromAny(option).Get("value").Interface().(string); ok && value != "$__all" {
+					selected = append(selected, value)
+				}
+			}
+		}
+
+		switch len(selected) {
+		case 0:
+			values[name] = ""
+		case 1:
+			values[name] = selected[0]
+		default:
+			values[name] = "{" + strings.Join(selected, ",") + "}"
+		}
+	}
+	return values
+}
+
+// interpolate replaces the references to the template variables in the strings of the value, the references to
+// unknown variables such as the global $__interval are left as they are
+func interpolate(value interface{}, values map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return variablePattern.ReplaceAllStringFunc(v, func(ref string) string {
+			groups := variablePattern.FindStringSubmatch(ref)
+			for _, name := range groups[1:] {
+				if value, ok := values[name]; ok && name != "" {
+					return value
+				}
+			}
+			return ref
+		})
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = interpolate(item, values)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = interpolate(item, values)
+		}
+		return result
+	}
+	return value
+}
+
+// findPanel returns the panel of the dashboard, including the panels of the collapsed rows
+func findPanel(model *simplejson.Json, panelID int64) *simplejson.Json {
+	for _, p := range model.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(p)
+		if panel.Get("id").MustInt64() == panelID {
+			return panel
+		}
+		for _, nested := range panel.Get("panels").MustArray() {
+			nestedPanel := simplejson.NewFromAny(nested)
+			if nestedPanel.Get("id").MustInt64() == panelID {
+				return nestedPanel
+			}
+		}
+	}
+	return nil
+}
+
+// dataSourceUID returns the uid of the data source referenced by a panel or a query, by uid or by name, once the
+// template variables are interpolated. The default data source is used when it is not set.
+func (s *Service) dataSourceUID(ctx context.Context, orgID int64, ref interface{}, values map[string]string) (string, error) {
+	var name string
+	switch r := interpolate(ref, values).(type) {
+	case string:
+		name = r
+	case map[string]interface{}:
+		name, _ = r["uid"].(string)
+	}
+
+	switch name {
+	case "-- Grafana --", grafanads.DatasourceUID:
+		return grafanads.DatasourceUID, nil
+	case expr.DatasourceUID, expr.OldDatasourceUID:
+		return expr.DatasourceUID, nil
+	case "", "default":
+		query := models.GetDefaultDataSourceQuery{OrgId: orgID}
+		if err := s.sqlStore.GetDefaultDataSource(ctx, &query); err != nil {
+			return "", err
+		}
+		return query.Result.Uid, nil
+	}
+
+	// The dashboards saved before the references by uid reference the data sources by name
+	query := models.GetDataSourceQuery{OrgId: orgID, Uid: name}
+	err := s.sqlStore.GetDataSource(ctx, &query)
+	if errors.Is(err, models.ErrDataSourceNotFound) {
+		query = models.GetDataSourceQuery{OrgId: orgID, Name: name}
+		err = s.sqlStore.GetDataSource(ctx, &query)
+	}
+	if err != nil {
+		return "", err
+	}
+	return query.Result.Uid, nil
+}
+
+// panelQueries returns the queries of the panel by data source uid, with the template variables interpolated. The
+// queries of a panel using expressions are all sent together.
+func (s *Service) panelQueries(ctx context.Context, orgID int64, panel *simplejson.Json, values map[string]string, maxDataPoints, intervalMs int64) (map[string][]*simplejson.Json, error) {
+	panelDataSource := panel.Get("datasource").Interface()
+	mixed := interpolate(panelDataSource, values) == mixedDataSource
+	if ref, ok := panelDataSource.(map[string]interface{}); ok && ref["uid"] == mixedDataSource {
+		mixed = true
+	}
+
+	queries := make(map[string][]*simplejson.Json)
+	hasExpression := false
+	for _, t := range panel.Get("targets").MustArray() {
+		target, ok := interpolate(t, values).(map[string]interface{})
+		if !ok || target["hide"] == true {
+			continue
+		}
+
+		ref := target["datasource"]
+		if ref == nil && !mixed {
+			ref = panelDataSource
+		}
+		uid, err := s.dataSourceUID(ctx, orgID, ref, values)
+		if err != nil {
+			return nil, err
+		}
+		if uid == expr.DatasourceUID {
+			hasExpression = true
+		}
+
+		target["datasource"] = map[string]interface{}{"uid": uid}
+		target["maxDataPoints"] = maxDataPoints
+		target["intervalMs"] = intervalMs
+		queries[uid] = append(queries[uid], simplejson.NewFromAny(target))
+	}
+
+	if hasExpression {
+		all := make([]*simplejson.Json, 0)
+		for _, q := range queries {
+			all = append(all, q...)
+		}
+		return map[string][]*simplejson.Json{expr.DatasourceUID: all}, nil
+	}
+	return queries, nil
+}
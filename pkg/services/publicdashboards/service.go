@@ -0,0 +1,286 @@
+package publicdashboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var requestsTotal *prometheus.CounterVec
+
+func init() {
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "public_dashboards_requests_total",
+		Help:      "Number of requests to the public dashboards, by public dashboard, type of request and status",
+	}, []string{"public_dashboard_uid", "type", "status"})
+}
+
+// queryDataService runs the queries of the panels
+type queryDataService interface {
+	QueryData(ctx context.Context, user *models.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, handleExpressions bool) (*backend.QueryDataResponse, error)
+}
+
+// Service publishes dashboards at unauthenticated URLs. The viewers of a public dashboard get its model and run the
+// queries of its panels with an access token signed with the secret key of the instance, without being signed in.
+// The queries are taken from the saved dashboard rather than from the requests, and run with the Viewer role of the
+// organization of the dashboard, over the time range and with the values of the template variables chosen by the
+// publisher.
+type Service struct {
+	cfg                 *setting.Cfg
+	sqlStore            *sqlstore.SQLStore
+	queryService        queryDataService
+	libraryPanelService librarypanels.Service
+	log                 log.Logger
+	now                 func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister,
+	queryService *query.Service, libraryPanelService librarypanels.Service) *Service {
+	s := &Service{
+		cfg:                 cfg,
+		sqlStore:            sqlStore,
+		queryService:        queryService,
+		libraryPanelService: libraryPanelService,
+		log:                 log.New("publicdashboards"),
+		now:                 time.Now,
+	}
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// IsDisabled returns true when the public dashboards are disabled
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.PublicDashboards.Enabled
+}
+
+// GetPublicDashboard returns the public dashboard of the dashboard. The permissions of the user on the dashboard
+// must be checked by the caller.
+func (s *Service) GetPublicDashboard(ctx context.Context, orgID int64, dashboardUID string) (*PublicDashboardDTO, error) {
+	pd, err := s.getPublicDashboard(ctx, "org_id = ? AND dashboard_uid = ?", orgID, dashboardUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toDTO(pd), nil
+}
+
+// GetPublicDashboards returns the public dashboards of the organization
+func (s *Service) GetPublicDashboards(ctx context.Context, orgID int64) ([]*PublicDashboardDTO, error) {
+	pds, err := s.getPublicDashboards(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*PublicDashboardDTO, 0, len(pds))
+	for _, pd := range pds {
+		result = append(result, s.toDTO(pd))
+	}
+	return result, nil
+}
+
+// SavePublicDashboard publishes the dashboard or changes its public dashboard. The permissions of the user on the
+// dashboard must be checked by the caller.
+func (s *Service) SavePublicDashboard(ctx context.Context, user *models.SignedInUser, dashboardUID string, cmd SavePublicDashboardCommand) (*PublicDashboardDTO, error) {
+	if s.IsDisabled() {
+		return nil, ErrDisabled
+	}
+	if cmd.TimeFrom == "" {
+		cmd.TimeFrom = "now-6h"
+	}
+	if cmd.TimeTo == "" {
+		cmd.TimeTo = "now"
+	}
+	if _, _, err := timeRange(&PublicDashboard{TimeFrom: cmd.TimeFrom, TimeTo: cmd.TimeTo}, "", "", s.now()); err != nil {
+		return nil, err
+	}
+	if cmd.Variables == nil {
+		cmd.Variables = Variables{}
+	}
+
+	pd, err := s.savePublicDashboard(ctx, user.OrgId, dashboardUID, user.UserId, cmd)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("Public dashboard saved", "orgId", user.OrgId, "dashboardUid", dashboardUID, "uid", pd.Uid, "enabled", pd.Enabled, "user", user.Login)
+	return s.toDTO(pd), nil
+}
+
+// RotateAccessToken signs a new access token for the public dashboard, the previous one is revoked
+func (s *Service) RotateAccessToken(ctx context.Context, user *models.SignedInUser, dashboardUID string) (*PublicDashboardDTO, error) {
+	pd, err := s.getPublicDashboard(ctx, "org_id = ? AND dashboard_uid = ?", user.OrgId, dashboardUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.incrementTokenVersion(ctx, pd, user.UserId); err != nil {
+		return nil, err
+	}
+	s.log.Info("Public dashboard access token rotated", "orgId", user.OrgId, "dashboardUid", dashboardUID, "uid", pd.Uid, "user", user.Login)
+	return s.toDTO(pd), nil
+}
+
+// DeletePublicDashboard unpublishes the dashboard, its access tokens are revoked
+func (s *Service) DeletePublicDashboard(ctx context.Context, user *models.SignedInUser, dashboardUID string) error {
+	if err := s.deletePublicDashboard(ctx, user.OrgId, dashboardUID); err != nil {
+		return err
+	}
+	s.log.Info("Public dashboard deleted", "orgId", user.OrgId, "dashboardUid", dashboardUID, "user", user.Login)
+	return nil
+}
+
+// GetDashboard returns the public dashboard of the access token, with the model of its dashboard as seen by the
+// viewers: the time range and the values of the template variables are the ones of the public dashboard.
+func (s *Service) GetDashboard(ctx context.Context, accessToken string) (*PublicDashboard, *models.Dashboard, error) {
+	pd, dash, err := s.resolve(ctx, accessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	model := dash.Data
+	model.Set("id", nil)
+	model.Set("editable", false)
+	model.Set("time", map[string]interface{}{"from": pd.TimeFrom, "to": pd.TimeTo})
+	values := variableValues(model, pd.Variables)
+	for _, v := range model.GetPath("templating", "list").MustArray() {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		if value, ok := values[name]; ok {
+			current := map[string]interface{}{"text": value, "value": value}
+			variable["current"] = current
+			variable["options"] = []interface{}{current}
+		}
+	}
+	return pd, dash, nil
+}
+
+// QueryPanel runs the queries of the panel of the public dashboard of the access token with the Viewer role of the
+// organization of the dashboard. The responses are merged by refId.
+func (s *Service) QueryPanel(ctx context.Context, accessToken string, panelID int64, cmd PanelQueryCommand) (*backend.QueryDataResponse, error) {
+	pd, dash, err := s.resolve(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	panel := findPanel(dash.Data, panelID)
+	if panel == nil {
+		return nil, ErrPanelNotFound
+	}
+
+	from, to, err := timeRange(pd, cmd.From, cmd.To, s.now())
+	if err != nil {
+		return nil, err
+	}
+	maxDataPoints := cmd.MaxDataPoints
+	if maxDataPoints <= 0 || maxDataPoints > defaultMaxDataPoints {
+		maxDataPoints = defaultMaxDataPoints
+	}
+	intervalMs := cmd.IntervalMs
+	if minIntervalMs := to.Sub(from).Milliseconds() / maxDataPoints; intervalMs < minIntervalMs {
+		intervalMs = minIntervalMs
+	}
+	if intervalMs <= 0 {
+		intervalMs = 1
+	}
+
+	queries, err := s.panelQueries(ctx, pd.OrgId, panel, variableValues(dash.Data, pd.Variables), maxDataPoints, intervalMs)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(queries))
+	for uid := range queries {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	user := viewerIdentity(pd)
+	result := backend.NewQueryDataResponse()
+	for _, uid := range uids {
+		resp, err := s.queryService.QueryData(ctx, user, false, dtos.MetricRequest{
+			From:    strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10),
+			To:      strconv.FormatInt(to.UnixNano()/int64(time.Millisecond), 10),
+			Queries: queries[uid],
+		}, true)
+		if err != nil {
+			return nil, err
+		}
+		for refID, r := range resp.Responses {
+			result.Responses[refID] = r
+		}
+	}
+	return result, nil
+}
+
+// resolve returns the enabled public dashboard of the access token and its dashboard, with its library panels
+func (s *Service) resolve(ctx context.Context, accessToken string) (*PublicDashboard, *models.Dashboard, error) {
+	if s.IsDisabled() {
+		return nil, nil, ErrDisabled
+	}
+	uid, err := parseAccessToken(accessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	pd, err := s.getPublicDashboard(ctx, "uid = ?", uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := verifyAccessToken(s.cfg.SecretKey, pd, accessToken); err != nil {
+		return nil, nil, err
+	}
+	if !pd.Enabled {
+		return nil, nil, ErrPublicDashboardNotFound
+	}
+
+	dash, err := s.sqlStore.GetDashboard(0, pd.OrgId, pd.DashboardUid, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.libraryPanelService.LoadLibraryPanelsForDashboard(ctx, dash); err != nil {
+		return nil, nil, fmt.Errorf("failed to load library panels: %w", err)
+	}
+	return pd, dash, nil
+}
+
+func (s *Service) toDTO(pd *PublicDashboard) *PublicDashboardDTO {
+	token := signAccessToken(s.cfg.SecretKey, pd)
+	return &PublicDashboardDTO{PublicDashboard: pd, AccessToken: token, URL: "/api/public/dashboards/" + token}
+}
+
+// viewerIdentity returns the identity the queries of the public dashboard are run with: a user of the organization
+// of the dashboard with the Viewer role, and with no permissions of its own
+func viewerIdentity(pd *PublicDashboard) *models.SignedInUser {
+	return &models.SignedInUser{
+		OrgId:   pd.OrgId,
+		OrgRole: models.ROLE_VIEWER,
+		Login:   "public-dashboard:" + pd.Uid,
+		Name:    "Public dashboard",
+	}
+}
+
+// observe counts a request to a public dashboard in the access metrics
+func observe(uid, requestType string, err error) {
+	status := "success"
+	switch {
+	case isHidden(err):
+		status = "not_found"
+	case err != nil:
+		status = "error"
+	}
+	requestsTotal.WithLabelValues(uid, requestType, status).Inc()
+}
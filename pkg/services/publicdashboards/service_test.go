@@ -0,0 +1,247 @@
+package publicdashboards
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeQueryDataService struct {
+	users    []*models.SignedInUser
+	requests []dtos.MetricRequest
+}
+
+func (f *fakeQueryDataService) QueryData(_ context.Context, user *models.SignedInUser, _ bool, reqDTO dtos.MetricRequest, _ bool) (*backend.QueryDataResponse, error) {
+	f.users = append(f.users, user)
+	f.requests = append(f.requests, reqDTO)
+	resp := backend.NewQueryDataResponse()
+	for _, q := range reqDTO.Queries {
+		resp.Responses[q.Get("refId").MustString()] = backend.DataResponse{}
+	}
+	return resp, nil
+}
+
+type fakeLibraryPanelService struct {
+	librarypanels.Service
+}
+
+func (f *fakeLibraryPanelService) LoadLibraryPanelsForDashboard(context.Context, *models.Dashboard) error {
+	return nil
+}
+
+func setupTestService(t *testing.T) (*Service, *sqlstore.SQLStore, *fakeQueryDataService) {
+	t.Helper()
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.SecretKey = "secret"
+	cfg.PublicDashboards.Enabled = true
+	queryService := &fakeQueryDataService{}
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &Service{
+		cfg:                 cfg,
+		sqlStore:            store,
+		queryService:        queryService,
+		libraryPanelService: &fakeLibraryPanelService{},
+		log:                 log.New("publicdashboards"),
+		now:                 func() time.Time { return now },
+	}
+	return s, store, queryService
+}
+
+func TestAccessToken(t *testing.T) {
+	pd := &PublicDashboard{Uid: "abc", TokenVersion: 1}
+	token := signAccessToken("secret", pd)
+
+	uid, err := parseAccessToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", uid)
+	assert.NoError(t, verifyAccessToken("secret", pd, token))
+	assert.ErrorIs(t, verifyAccessToken("other secret", pd, token), ErrInvalidAccessToken)
+	assert.ErrorIs(t, verifyAccessToken("secret", pd, "abc.forged"), ErrInvalidAccessToken)
+
+	pd.TokenVersion++
+	assert.ErrorIs(t, verifyAccessToken("secret", pd, token), ErrInvalidAccessToken)
+
+	for _, invalid := range []string{"", "abc", ".abc", "abc."} {
+		_, err := parseAccessToken(invalid)
+		assert.ErrorIs(t, err, ErrInvalidAccessToken, invalid)
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	pd := &PublicDashboard{TimeFrom: "now-6h", TimeTo: "now"}
+
+	from, to, err := timeRange(pd, "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-6*time.Hour), from)
+	assert.Equal(t, now, to)
+
+	t.Run("should narrow the time range down", func(t *testing.T) {
+		from, to, err := timeRange(pd, "now-1h", "now-30m", now)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-time.Hour), from)
+		assert.Equal(t, now.Add(-30*time.Minute), to)
+	})
+
+	t.Run("should not query outside of the time range", func(t *testing.T) {
+		from, to, err := timeRange(pd, "now-7d", "now+1d", now)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-6*time.Hour), from)
+		assert.Equal(t, now, to)
+
+		_, _, err = timeRange(pd, "now-7d", "now-6d", now)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	})
+
+	t.Run("should return an error for the invalid time ranges", func(t *testing.T) {
+		_, _, err := timeRange(&PublicDashboard{TimeFrom: "now", TimeTo: "now-1h"}, "", "", now)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+		_, _, err = timeRange(pd, "yesterday", "", now)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	})
+}
+
+func TestVariables(t *testing.T) {
+	model := simplejson.NewFromAny(map[string]interface{}{
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "env", "current": map[string]interface{}{"value": "prod"}},
+				map[string]interface{}{"name": "host", "current": map[string]interface{}{"value": []interface{}{"a", "b"}}},
+				map[string]interface{}{"name": "region", "current": map[string]interface{}{"value": "$__all"}, "options": []interface{}{
+					map[string]interface{}{"value": "$__all"},
+					map[string]interface{}{"value": "eu"},
+					map[string]interface{}{"value": "us"},
+				}},
+				map[string]interface{}{"name": "dc", "current": map[string]interface{}{"value": []interface{}{"$__all"}}, "allValue": ".*"},
+			},
+		},
+	})
+
+	values := variableValues(model, Variables{"env": "dev"})
+	assert.Equal(t, map[string]string{"env": "dev", "host": "{a,b}", "region": "{eu,us}", "dc": ".*"}, values)
+
+	assert.Equal(t, map[string]interface{}{
+		"expr":  `up{env="dev",host=~"{a,b}",dc=~".*"}[$__interval]`,
+		"other": []interface{}{"dev", 1.0},
+	}, interpolate(map[string]interface{}{
+		"expr":  `up{env="$env",host=~"${host:regex}",dc=~"[[dc]]"}[$__interval]`,
+		"other": []interface{}{"$env", 1.0},
+	}, values))
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s, store, queryService := setupTestService(t)
+	user := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+
+	require.NoError(t, store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: "Prometheus", Uid: "prom", Type: "prometheus", Access: models.DS_ACCESS_PROXY}))
+	require.NoError(t, store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: "Loki", Uid: "loki", Type: "loki", Access: models.DS_ACCESS_PROXY}))
+	dash, err := store.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{
+		"title": "Servers",
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "env", "current": map[string]interface{}{"value": "prod"}},
+			},
+		},
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "datasource": "Prometheus", "targets": []interface{}{
+				map[string]interface{}{"refId": "A", "expr": `up{env="$env"}`},
+				map[string]interface{}{"refId": "B", "expr": "hidden", "hide": true},
+			}},
+			map[string]interface{}{"id": 2, "type": "row", "panels": []interface{}{
+				map[string]interface{}{"id": 3, "datasource": map[string]interface{}{"uid": "-- Mixed --"}, "targets": []interface{}{
+					map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}},
+					map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "loki"}},
+				}},
+			}},
+		},
+	})})
+	require.NoError(t, err)
+
+	pd, err := s.SavePublicDashboard(ctx, user, dash.Uid, SavePublicDashboardCommand{Enabled: true, Variables: Variables{"env": "staging"}})
+	require.NoError(t, err)
+	assert.Equal(t, "now-6h", pd.TimeFrom)
+	assert.Equal(t, "/api/public/dashboards/"+pd.AccessToken, pd.URL)
+
+	t.Run("should return the dashboard with the time range and variables of the public dashboard", func(t *testing.T) {
+		_, viewed, err := s.GetDashboard(ctx, pd.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "now-6h", viewed.Data.GetPath("time", "from").MustString())
+		assert.False(t, viewed.Data.Get("editable").MustBool(true))
+		assert.Equal(t, "staging", viewed.Data.GetPath("templating", "list").GetIndex(0).GetPath("current", "value").MustString())
+	})
+
+	t.Run("should run the queries of the panel as a viewer", func(t *testing.T) {
+		queryService.users, queryService.requests = nil, nil
+		resp, err := s.QueryPanel(ctx, pd.AccessToken, 1, PanelQueryCommand{From: "now-1h", To: "now"})
+		require.NoError(t, err)
+		assert.Len(t, resp.Responses, 1)
+
+		require.Len(t, queryService.requests, 1)
+		assert.Equal(t, models.ROLE_VIEWER, queryService.users[0].OrgRole)
+		assert.Equal(t, int64(1), queryService.users[0].OrgId)
+		assert.Zero(t, queryService.users[0].UserId)
+		req := queryService.requests[0]
+		assert.Equal(t, "1646132400000", req.From)
+		assert.Equal(t, "1646136000000", req.To)
+		require.Len(t, req.Queries, 1)
+		assert.Equal(t, `up{env="staging"}`, req.Queries[0].Get("expr").MustString())
+		assert.Equal(t, "prom", req.Queries[0].GetPath("datasource", "uid").MustString())
+	})
+
+	t.Run("should run the queries of the mixed panels by data source", func(t *testing.T) {
+		queryService.requests = nil
+		resp, err := s.QueryPanel(ctx, pd.AccessToken, 3, PanelQueryCommand{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Responses, 2)
+		assert.Len(t, queryService.requests, 2)
+
+		_, err = s.QueryPanel(ctx, pd.AccessToken, 4, PanelQueryCommand{})
+		assert.ErrorIs(t, err, ErrPanelNotFound)
+	})
+
+	t.Run("should revoke the access token when rotated", func(t *testing.T) {
+		rotated, err := s.RotateAccessToken(ctx, user, dash.Uid)
+		require.NoError(t, err)
+		assert.NotEqual(t, pd.AccessToken, rotated.AccessToken)
+
+		_, _, err = s.GetDashboard(ctx, pd.AccessToken)
+		assert.ErrorIs(t, err, ErrInvalidAccessToken)
+		_, _, err = s.GetDashboard(ctx, rotated.AccessToken)
+		assert.NoError(t, err)
+		pd = rotated
+	})
+
+	t.Run("should not be viewed when disabled", func(t *testing.T) {
+		_, err := s.SavePublicDashboard(ctx, user, dash.Uid, SavePublicDashboardCommand{Enabled: false})
+		require.NoError(t, err)
+		_, _, err = s.GetDashboard(ctx, pd.AccessToken)
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+
+		_, err = s.SavePublicDashboard(ctx, user, dash.Uid, SavePublicDashboardCommand{Enabled: true})
+		require.NoError(t, err)
+		s.cfg.PublicDashboards.Enabled = false
+		_, _, err = s.GetDashboard(ctx, pd.AccessToken)
+		assert.ErrorIs(t, err, ErrDisabled)
+		s.cfg.PublicDashboards.Enabled = true
+	})
+
+	t.Run("should be deleted with the dashboard", func(t *testing.T) {
+		require.NoError(t, sqlstore.DeleteDashboard(ctx, &models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+		_, err := s.GetPublicDashboard(ctx, 1, dash.Uid)
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+}
@@ -0,0 +1,86 @@
+package publicdashboards
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getPublicDashboard(ctx context.Context, where string, args ...interface{}) (*PublicDashboard, error) {
+	pd := &PublicDashboard{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where(where, args...).Get(pd)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrPublicDashboardNotFound
+		}
+		return nil
+	})
+	return pd, err
+}
+
+func (s *Service) getPublicDashboards(ctx context.Context, orgID int64) ([]*PublicDashboard, error) {
+	result := make([]*PublicDashboard, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("dashboard_uid").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) savePublicDashboard(ctx context.Context, orgID int64, dashboardUID string, userID int64, cmd SavePublicDashboardCommand) (*PublicDashboard, error) {
+	pd := &PublicDashboard{}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND dashboard_uid = ?", orgID, dashboardUID).Get(pd)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		pd.Enabled = cmd.Enabled
+		pd.TimeFrom = cmd.TimeFrom
+		pd.TimeTo = cmd.TimeTo
+		pd.Variables = cmd.Variables
+		pd.Updated = now
+		pd.UpdatedBy = userID
+		if has {
+			_, err := sess.ID(pd.Id).Cols("enabled", "time_from", "time_to", "variables", "updated", "updated_by").Update(pd)
+			return err
+		}
+
+		pd.Uid = util.GenerateShortUID()
+		pd.OrgId = orgID
+		pd.DashboardUid = dashboardUID
+		pd.TokenVersion = 1
+		pd.Created = now
+		_, err = sess.Insert(pd)
+		return err
+	})
+	return pd, err
+}
+
+func (s *Service) incrementTokenVersion(ctx context.Context, pd *PublicDashboard, userID int64) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		pd.TokenVersion++
+		pd.Updated = time.Now()
+		pd.UpdatedBy = userID
+		_, err := sess.ID(pd.Id).Cols("token_version", "updated", "updated_by").Update(pd)
+		return err
+	})
+}
+
+func (s *Service) deletePublicDashboard(ctx context.Context, orgID int64, dashboardUID string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		n, err := sess.Where("org_id = ? AND dashboard_uid = ?", orgID, dashboardUID).Delete(&PublicDashboard{})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrPublicDashboardNotFound
+		}
+		return nil
+	})
+}
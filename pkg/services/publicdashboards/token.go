@@ -0,0 +1,41 @@
+package publicdashboards
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// signAccessToken returns the access token of the public dashboard: its uid and the signature of the uid and token
+// version with the secret key of the instance, so that the tokens cannot be forged and are revoked by changing the
+// token version or the secret key.
+func signAccessToken(secretKey string, pd *PublicDashboard) string {
+	return pd.Uid + "." + signature(secretKey, pd.Uid, pd.TokenVersion)
+}
+
+// parseAccessToken returns the uid of the public dashboard of the access token
+func parseAccessToken(token string) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if i <= 0 || i == len(token)-1 {
+		return "", ErrInvalidAccessToken
+	}
+	return token[:i], nil
+}
+
+// verifyAccessToken returns ErrInvalidAccessToken when the access token is not signed for the current token version
+// of the public dashboard
+func verifyAccessToken(secretKey string, pd *PublicDashboard, token string) error {
+	expected := signAccessToken(secretKey, pd)
+	if !hmac.Equal([]byte(expected), []byte(token)) {
+		return ErrInvalidAccessToken
+	}
+	return nil
+}
+
+func signature(secretKey string, uid string, version int) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte("public-dashboard:" + uid + ":" + strconv.Itoa(version)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
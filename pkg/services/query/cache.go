@@ -0,0 +1,125 @@
+package query
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// cachePolicy holds how long the query results of a data source can be cached, configured through the
+// `queryCacheTTL` and `queryCacheMaxTTL` json data settings.
+// Panels can declare their own TTL through the `cacheTTL` hint, which overrides the default TTL of the data source
+// but is bounded by its maximum TTL. The maximum TTL defaults to the default TTL, so results are only cached for the
+// data sources that opt in.
+type cachePolicy struct {
+	ttl    time.Duration
+	maxTTL time.Duration
+}
+
+func cachePolicyFromDataSource(ds *models.DataSource) (cachePolicy, error) {
+	policy := cachePolicy{}
+	if ds == nil || ds.JsonData == nil {
+		return policy, nil
+	}
+
+	var err error
+	if v := ds.JsonData.Get("queryCacheTTL").MustString(""); v != "" {
+		if policy.ttl, err = gtime.ParseDuration(v); err != nil {
+			return policy, fmt.Errorf("invalid queryCacheTTL %q: %w", v, err)
+		}
+	}
+
+	policy.maxTTL = policy.ttl
+	if v := ds.JsonData.Get("queryCacheMaxTTL").MustString(""); v != "" {
+		if policy.maxTTL, err = gtime.ParseDuration(v); err != nil {
+			return policy, fmt.Errorf("invalid queryCacheMaxTTL %q: %w", v, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// effectiveTTL returns how long the results can be cached given the TTL hint of the panel, zero means they are not cached.
+func (p cachePolicy) effectiveTTL(hint time.Duration) time.Duration {
+	ttl := p.ttl
+	if hint > 0 {
+		ttl = hint
+	}
+	if ttl > p.maxTTL {
+		ttl = p.maxTTL
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+type cacheKeyQuery struct {
+	RefID         string          `json:"refId"`
+	QueryType     string          `json:"queryType"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	Interval      time.Duration   `json:"interval"`
+	JSON          json.RawMessage `json:"json"`
+}
+
+type cacheKey struct {
+	OrgID             int64           `json:"orgId"`
+	DataSourceUID     string          `json:"dataSourceUid"`
+	DataSourceVersion int             `json:"dataSourceVersion"`
+	From              int64           `json:"from"`
+	To                int64           `json:"to"`
+	Queries           []cacheKeyQuery `json:"queries"`
+}
+
+// queryCacheKey returns the key the results of the queries are cached under. The time ranges are truncated to the TTL,
+// so that refreshes of a relative time range within the same TTL window are served from the cache.
+func queryCacheKey(orgID int64, ds *models.DataSource, queries []parsedQuery, ttl time.Duration) (string, error) {
+	key := cacheKey{
+		OrgID:             orgID,
+		DataSourceUID:     ds.Uid,
+		DataSourceVersion: ds.Version,
+		Queries:           make([]cacheKeyQuery, 0, len(queries)),
+	}
+	for i, pq := range queries {
+		if i == 0 {
+			key.From = pq.query.TimeRange.From.Truncate(ttl).UnixNano()
+			key.To = pq.query.TimeRange.To.Truncate(ttl).UnixNano()
+		}
+		key.Queries = append(key.Queries, cacheKeyQuery{
+			RefID:         pq.query.RefID,
+			QueryType:     pq.query.QueryType,
+			MaxDataPoints: pq.query.MaxDataPoints,
+			Interval:      pq.query.Interval,
+			JSON:          pq.query.JSON,
+		})
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return localcache.OrgKey(orgID, hex.EncodeToString(sum[:])), nil
+}
+
+// cacheable returns true when none of the queries failed, errors are not cached so failed queries are retried
+// on the next refresh.
+func cacheable(resp *backend.QueryDataResponse) bool {
+	if resp == nil {
+		return false
+	}
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			return false
+		}
+	}
+	return true
+}
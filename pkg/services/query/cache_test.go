@@ -0,0 +1,186 @@
+package query
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+)
+
+func TestCachePolicyFromDataSource(t *testing.T) {
+	t.Run("should not cache without settings", func(t *testing.T) {
+		policy, err := cachePolicyFromDataSource(&models.DataSource{JsonData: simplejson.New()})
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), policy.effectiveTTL(time.Hour))
+	})
+
+	t.Run("should bound the panel hint by the default TTL when there is no maximum", func(t *testing.T) {
+		policy, err := cachePolicyFromDataSource(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCacheTTL": "5m",
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, policy.effectiveTTL(0))
+		assert.Equal(t, time.Minute, policy.effectiveTTL(time.Minute))
+		assert.Equal(t, 5*time.Minute, policy.effectiveTTL(time.Hour))
+	})
+
+	t.Run("should bound the panel hint by the maximum TTL", func(t *testing.T) {
+		policy, err := cachePolicyFromDataSource(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCacheMaxTTL": "1h",
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), policy.effectiveTTL(0))
+		assert.Equal(t, 30*time.Minute, policy.effectiveTTL(30*time.Minute))
+		assert.Equal(t, time.Hour, policy.effectiveTTL(24*time.Hour))
+	})
+
+	t.Run("should fail on invalid settings", func(t *testing.T) {
+		_, err := cachePolicyFromDataSource(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCacheMaxTTL": "one hour",
+		})})
+		require.Error(t, err)
+	})
+}
+
+func TestQueryCacheKey(t *testing.T) {
+	ds := &models.DataSource{Uid: "abc", Version: 1}
+	query := func(from, to time.Time, expr string) []parsedQuery {
+		return []parsedQuery{{
+			datasource: ds,
+			query: backend.DataQuery{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      []byte(`{"expr":"` + expr + `"}`),
+			},
+		}}
+	}
+
+	start := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	key, err := queryCacheKey(1, ds, query(start, start.Add(time.Hour), "up"), time.Hour)
+	require.NoError(t, err)
+
+	t.Run("should be the same within the TTL window", func(t *testing.T) {
+		other, err := queryCacheKey(1, ds, query(start.Add(10*time.Minute), start.Add(70*time.Minute), "up"), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, key, other)
+	})
+
+	t.Run("should differ for another TTL window, query, organization or data source version", func(t *testing.T) {
+		for _, other := range []func() (string, error){
+			func() (string, error) {
+				return queryCacheKey(1, ds, query(start.Add(time.Hour), start.Add(2*time.Hour), "up"), time.Hour)
+			},
+			func() (string, error) {
+				return queryCacheKey(1, ds, query(start, start.Add(time.Hour), "down"), time.Hour)
+			},
+			func() (string, error) {
+				return queryCacheKey(2, ds, query(start, start.Add(time.Hour), "up"), time.Hour)
+			},
+			func() (string, error) {
+				updated := *ds
+				updated.Version = 2
+				return queryCacheKey(1, &updated, query(start, start.Add(time.Hour), "up"), time.Hour)
+			},
+		} {
+			k, err := other()
+			require.NoError(t, err)
+			assert.NotEqual(t, key, k)
+		}
+	})
+}
+
+type fakePluginRequestValidator struct{}
+
+func (fakePluginRequestValidator) Validate(string, *http.Request) error {
+	return nil
+}
+
+type fakePluginClient struct {
+	plugins.Client
+	calls int
+	err   error
+}
+
+func (c *fakePluginClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	c.calls++
+	resp := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		resp.Responses[q.RefID] = backend.DataResponse{Error: c.err}
+	}
+	return resp, nil
+}
+
+func TestService_HandleCachedQueryData(t *testing.T) {
+	client := &fakePluginClient{}
+	s := &Service{
+		pluginRequestValidator: fakePluginRequestValidator{},
+		secretsService:         fakes.NewFakeSecretsService(),
+		pluginClient:           client,
+		oAuthTokenService:      oauthtoken.ProvideService(nil),
+		queryCache:             localcache.New(time.Minute, time.Minute),
+		log:                    log.New("test"),
+	}
+	user := &models.SignedInUser{OrgId: 1}
+	request := func(uid string, jsonData map[string]interface{}, cacheTTL time.Duration) *parsedRequest {
+		return &parsedRequest{
+			cacheTTL: cacheTTL,
+			parsedQueries: []parsedQuery{{
+				datasource: &models.DataSource{Uid: uid, Type: "test", JsonData: simplejson.NewFromAny(jsonData)},
+				query:      backend.DataQuery{RefID: "A", JSON: []byte(`{}`)},
+			}},
+		}
+	}
+	run := func(req *parsedRequest, skipCache bool) {
+		_, err := s.handleCachedQueryData(context.Background(), user, skipCache, req)
+		require.NoError(t, err)
+	}
+
+	t.Run("should not cache without cache policy", func(t *testing.T) {
+		client.calls = 0
+		run(request("none", map[string]interface{}{}, time.Hour), false)
+		run(request("none", map[string]interface{}{}, time.Hour), false)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("should serve the queries from the cache with the panel hint", func(t *testing.T) {
+		client.calls = 0
+		run(request("cached", map[string]interface{}{"queryCacheMaxTTL": "1h"}, time.Hour), false)
+		run(request("cached", map[string]interface{}{"queryCacheMaxTTL": "1h"}, time.Hour), false)
+		assert.Equal(t, 1, client.calls)
+
+		// Skipping the cache executes the queries again
+		run(request("cached", map[string]interface{}{"queryCacheMaxTTL": "1h"}, time.Hour), true)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("should not cache the data sources forwarding the OAuth identity", func(t *testing.T) {
+		client.calls = 0
+		jsonData := map[string]interface{}{"queryCacheTTL": "1h", "oauthPassThru": true}
+		run(request("oauth", jsonData, 0), false)
+		run(request("oauth", jsonData, 0), false)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("should not cache failed queries", func(t *testing.T) {
+		client.calls = 0
+		client.err = assert.AnError
+		t.Cleanup(func() { client.err = nil })
+
+		jsonData := map[string]interface{}{"queryCacheTTL": "1h"}
+		run(request("failed", jsonData, 0), false)
+		run(request("failed", jsonData, 0), false)
+		assert.Equal(t, 2, client.calls)
+	})
+}
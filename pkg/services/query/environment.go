@@ -0,0 +1,35 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// resolveEnvironment returns the data source replacing the data source in the environment the dashboard is viewed
+// in, or the data source itself when there is none. The data sources of an environment which have been deleted are
+// ignored.
+func (s *Service) resolveEnvironment(ctx context.Context, user *models.SignedInUser, skipCache bool, environment string, ds *models.DataSource) (*models.DataSource, error) {
+	if s.environmentsService == nil || environment == "" {
+		return ds, nil
+	}
+
+	uid, err := s.environmentsService.Resolve(ctx, user.OrgId, environment, ds.Uid)
+	if err != nil {
+		return nil, err
+	}
+	if uid == ds.Uid {
+		return ds, nil
+	}
+
+	target, err := s.dataSourceCache.GetDatasourceByUID(ctx, uid, user, skipCache)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			s.log.Warn("Ignoring the missing data source of the environment", "datasource", ds.Uid, "environment", environment, "target", uid)
+			return ds, nil
+		}
+		return nil, err
+	}
+	return target, nil
+}
@@ -0,0 +1,72 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/environments"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_ParseMetricRequest_Environment(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	environmentsService := environments.ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	s := &Service{
+		dataSourceCache:     datasources.ProvideCacheService(store, localcache.ProvideRegistry()),
+		environmentsService: environmentsService,
+		log:                 log.New("test"),
+	}
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+	for _, uid := range []string{"prom", "prom-prod", "deleted"} {
+		err := store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: uid, Type: "prometheus", Uid: uid, Access: models.DS_ACCESS_PROXY})
+		require.NoError(t, err)
+	}
+	for _, name := range []string{"prod", "staging"} {
+		_, err := environmentsService.CreateEnvironment(ctx, admin, environments.CreateEnvironmentCommand{Name: name})
+		require.NoError(t, err)
+	}
+	_, err := environmentsService.SetDataSourceEnvironments(ctx, admin, "prom", environments.SetDataSourceEnvironmentsCommand{
+		Environments: map[string]string{"prod": "prom-prod", "staging": "deleted"},
+	})
+	require.NoError(t, err)
+	err = store.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{OrgID: 1, UID: "deleted"})
+	require.NoError(t, err)
+
+	datasourceOf := func(environment string) string {
+		t.Helper()
+		req, err := s.parseMetricRequest(ctx, admin, false, dtos.MetricRequest{
+			Environment: environment,
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}}),
+			},
+		})
+		require.NoError(t, err)
+		return req.parsedQueries[0].datasource.Uid
+	}
+
+	t.Run("should query the data source of the environment", func(t *testing.T) {
+		assert.Equal(t, "prom-prod", datasourceOf("prod"))
+	})
+
+	t.Run("should query the data source itself without environment", func(t *testing.T) {
+		assert.Equal(t, "prom", datasourceOf(""))
+		assert.Equal(t, "prom", datasourceOf("dev"))
+	})
+
+	t.Run("should ignore the deleted data sources of the environments", func(t *testing.T) {
+		assert.Equal(t, "prom", datasourceOf("staging"))
+	})
+}
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/querypipelines"
+)
+
+// pipelineQueryFields are the fields of a query referencing a query pipeline which are copied to the queries of the
+// pipeline, so that the pipeline follows the time settings of the panel
+var pipelineQueryFields = []string{"maxDataPoints", "intervalMs"}
+
+// expandPipeline replaces a query referencing a query pipeline with the queries of the pipeline, and prepends the
+// transformations of the pipeline to the transformations of the request. A pipeline reference must be the only
+// query of the request.
+func (s *Service) expandPipeline(ctx context.Context, user *models.SignedInUser, reqDTO dtos.MetricRequest) (dtos.MetricRequest, error) {
+	if s.queryPipelinesService == nil {
+		return reqDTO, nil
+	}
+
+	var ref *simplejson.Json
+	uid := ""
+	for _, query := range reqDTO.Queries {
+		if pipelineUID, ok := querypipelines.PipelineUID(query); ok {
+			ref, uid = query, pipelineUID
+		}
+	}
+	if ref == nil {
+		return reqDTO, nil
+	}
+	if len(reqDTO.Queries) > 1 {
+		return reqDTO, NewErrBadQuery("a query pipeline must be the only query of the request")
+	}
+
+	pipeline, err := s.queryPipelinesService.GetCachedPipeline(ctx, user.OrgId, uid)
+	if err != nil {
+		if errors.Is(err, querypipelines.ErrPipelineNotFound) {
+			return reqDTO, NewErrBadQuery(fmt.Sprintf("query pipeline %q not found", uid))
+		}
+		return reqDTO, err
+	}
+
+	// the queries of the cached pipeline are shared, they are copied before being changed
+	queries := make([]*simplejson.Json, 0, len(pipeline.Steps.Queries))
+	for _, q := range pipeline.Steps.Queries {
+		data, err := q.MarshalJSON()
+		if err != nil {
+			return reqDTO, err
+		}
+		query, err := simplejson.NewJson(data)
+		if err != nil {
+			return reqDTO, err
+		}
+		for _, field := range pipelineQueryFields {
+			if value, ok := ref.CheckGet(field); ok {
+				query.Set(field, value.Interface())
+			}
+		}
+		queries = append(queries, query)
+	}
+
+	reqDTO.Queries = queries
+	reqDTO.Transformations = append(append([]*dtos.MetricRequestTransformation{}, pipeline.Steps.Transformations...),
+		reqDTO.Transformations...)
+	return reqDTO, nil
+}
@@ -0,0 +1,90 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/querypipelines"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_ExpandPipeline(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	pipelinesService := querypipelines.ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry(), bus.New())
+	s := &Service{
+		queryPipelinesService: pipelinesService,
+		log:                   log.New("test"),
+	}
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+	_, err := pipelinesService.CreatePipeline(ctx, admin, querypipelines.SavePipelineCommand{
+		Uid:  "error-ratio",
+		Name: "Error ratio",
+		Steps: &querypipelines.Steps{
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}, "maxDataPoints": 10}),
+				simplejson.NewFromAny(map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "__expr__"}, "type": "math"}),
+			},
+			Transformations: []*dtos.MetricRequestTransformation{{Id: "grafana-ratio-app/ratio"}},
+		},
+	})
+	require.NoError(t, err)
+
+	pipelineRef := func(uid string) *simplejson.Json {
+		return simplejson.NewFromAny(map[string]interface{}{
+			"refId":         "A",
+			"datasource":    map[string]interface{}{"uid": querypipelines.DatasourceUID},
+			"pipelineUid":   uid,
+			"maxDataPoints": 500,
+		})
+	}
+
+	t.Run("should replace the reference with the queries and the transformations of the pipeline", func(t *testing.T) {
+		req, err := s.expandPipeline(ctx, admin, dtos.MetricRequest{
+			Queries:         []*simplejson.Json{pipelineRef("error-ratio")},
+			Transformations: []*dtos.MetricRequestTransformation{{Id: "grafana-panel-app/rename"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, req.Queries, 2)
+		assert.Equal(t, "prom", req.Queries[0].Get("datasource").Get("uid").MustString())
+		assert.Equal(t, int64(500), req.Queries[0].Get("maxDataPoints").MustInt64())
+		assert.Equal(t, "__expr__", req.Queries[1].Get("datasource").Get("uid").MustString())
+		require.Len(t, req.Transformations, 2)
+		assert.Equal(t, "grafana-ratio-app/ratio", req.Transformations[0].Id)
+		assert.Equal(t, "grafana-panel-app/rename", req.Transformations[1].Id)
+
+		// the cached pipeline is left unchanged
+		pipeline, err := pipelinesService.GetCachedPipeline(ctx, 1, "error-ratio")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), pipeline.Steps.Queries[0].Get("maxDataPoints").MustInt64())
+	})
+
+	t.Run("should leave the requests without reference unchanged", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}})
+		req, err := s.expandPipeline(ctx, admin, dtos.MetricRequest{Queries: []*simplejson.Json{query}})
+		require.NoError(t, err)
+		assert.Equal(t, []*simplejson.Json{query}, req.Queries)
+	})
+
+	t.Run("should refuse missing pipelines and references mixed with other queries", func(t *testing.T) {
+		_, err := s.expandPipeline(ctx, admin, dtos.MetricRequest{Queries: []*simplejson.Json{pipelineRef("missing")}})
+		var badQuery *ErrBadQuery
+		require.ErrorAs(t, err, &badQuery)
+
+		query := simplejson.NewFromAny(map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "prom"}})
+		_, err = s.expandPipeline(ctx, admin, dtos.MetricRequest{Queries: []*simplejson.Json{pipelineRef("error-ratio"), query}})
+		require.ErrorAs(t, err, &badQuery)
+	})
+}
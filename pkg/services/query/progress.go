@@ -0,0 +1,162 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/queryprogress"
+)
+
+var (
+	// ErrQueryNotFound is returned when canceling a query which is not running
+	ErrQueryNotFound = errors.New("query not found")
+	// ErrQueryCanceled is returned by the queries canceled with CancelQuery
+	ErrQueryCanceled = errors.New("query canceled")
+
+	// queryIDPattern validates the query IDs, which are part of the Live channels of the query progress
+	queryIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+)
+
+// ProgressState is the state of a query in its progress events
+type ProgressState string
+
+const (
+	ProgressStateRunning  ProgressState = "running"
+	ProgressStateDone     ProgressState = "done"
+	ProgressStateError    ProgressState = "error"
+	ProgressStateCanceled ProgressState = "canceled"
+)
+
+// ProgressEvent is published on the Live channel of a query when it starts, when its data source reports its
+// progress, and when it finishes
+type ProgressEvent struct {
+	QueryID      string        `json:"queryId"`
+	State        ProgressState `json:"state"`
+	ElapsedMs    int64         `json:"elapsedMs"`
+	RefID        string        `json:"refId,omitempty"`
+	BytesScanned int64         `json:"bytesScanned,omitempty"`
+	RowsScanned  int64         `json:"rowsScanned,omitempty"`
+	// Frames are the partial results of the query RefID
+	Frames data.Frames `json:"frames,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ProgressChannel returns the Grafana Live channel on which the progress of a query of the user is published
+func ProgressChannel(userID int64, queryID string) string {
+	return fmt.Sprintf("grafana/query/%d/%s", userID, queryID)
+}
+
+// runningQuery is a query started with a query ID, which can be canceled
+type runningQuery struct {
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// SetProgressPublisher sets the publisher of the progress events of the queries, which are not published when unset
+func (s *Service) SetProgressPublisher(publisher models.ChannelPublisher) {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+	s.progressPublisher = publisher
+}
+
+// CancelQuery cancels a running query of the user, the cancellation is propagated to its data source
+func (s *Service) CancelQuery(user *models.SignedInUser, queryID string) error {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	q, ok := s.running[runningQueryKey(user, queryID)]
+	if !ok {
+		return ErrQueryNotFound
+	}
+	q.canceled = true
+	q.cancel()
+	return nil
+}
+
+// trackQuery registers a query started with a query ID so that it can be canceled, and makes its data source report
+// its progress to the query channel. The returned function must be called with the error of the query when it
+// finishes, it returns ErrQueryCanceled when the query has been canceled.
+func (s *Service) trackQuery(ctx context.Context, user *models.SignedInUser, queryID string) (context.Context, func(error) error, error) {
+	if !queryIDPattern.MatchString(queryID) {
+		return nil, nil, NewErrBadQuery("invalid query ID")
+	}
+
+	key := runningQueryKey(user, queryID)
+	ctx, cancel := context.WithCancel(ctx)
+	q := &runningQuery{cancel: cancel}
+
+	s.runningMu.Lock()
+	if _, ok := s.running[key]; ok {
+		s.runningMu.Unlock()
+		cancel()
+		return nil, nil, NewErrBadQuery("query ID already in use")
+	}
+	s.running[key] = q
+	s.runningMu.Unlock()
+
+	started := time.Now()
+	publish := func(e ProgressEvent) {
+		e.QueryID = queryID
+		e.ElapsedMs = time.Since(started).Milliseconds()
+		s.publishProgress(user, e)
+	}
+	publish(ProgressEvent{State: ProgressStateRunning})
+	ctx = queryprogress.WithReporter(ctx, func(p queryprogress.Progress) {
+		publish(ProgressEvent{
+			State:        ProgressStateRunning,
+			RefID:        p.RefID,
+			BytesScanned: p.BytesScanned,
+			RowsScanned:  p.RowsScanned,
+			Frames:       p.Frames,
+		})
+	})
+
+	finish := func(err error) error {
+		s.runningMu.Lock()
+		delete(s.running, key)
+		canceled := q.canceled
+		s.runningMu.Unlock()
+		cancel()
+
+		switch {
+		case canceled:
+			publish(ProgressEvent{State: ProgressStateCanceled})
+			return ErrQueryCanceled
+		case err != nil:
+			publish(ProgressEvent{State: ProgressStateError, Error: err.Error()})
+		default:
+			publish(ProgressEvent{State: ProgressStateDone})
+		}
+		return err
+	}
+	return ctx, finish, nil
+}
+
+func (s *Service) publishProgress(user *models.SignedInUser, e ProgressEvent) {
+	s.runningMu.Lock()
+	publisher := s.progressPublisher
+	s.runningMu.Unlock()
+	if publisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		s.log.Error("Failed to encode the query progress", "queryId", e.QueryID, "error", err)
+		return
+	}
+	if err := publisher(user.OrgId, ProgressChannel(user.UserId, e.QueryID), data); err != nil {
+		s.log.Warn("Failed to publish the query progress", "queryId", e.QueryID, "error", err)
+	}
+}
+
+func runningQueryKey(user *models.SignedInUser, queryID string) string {
+	return fmt.Sprintf("%d/%d/%s", user.OrgId, user.UserId, queryID)
+}
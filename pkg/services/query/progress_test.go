@@ -0,0 +1,132 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/tsdb/queryprogress"
+)
+
+// blockingPluginClient reports the progress of the queries and waits for them to be canceled
+type blockingPluginClient struct {
+	plugins.Client
+	started chan struct{}
+}
+
+func (c *blockingPluginClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	queryprogress.Report(ctx, queryprogress.Progress{
+		RefID:        "A",
+		BytesScanned: 1024,
+		Frames:       data.Frames{data.NewFrame("partial", data.NewField("value", nil, []int64{1, 2}))},
+	})
+	close(c.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type progressEvents struct {
+	mu     sync.Mutex
+	events map[string][]ProgressEvent
+}
+
+func (p *progressEvents) publish(orgID int64, channel string, data []byte) error {
+	var e ProgressEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events[channel] = append(p.events[channel], e)
+	return nil
+}
+
+func (p *progressEvents) states(channel string) []ProgressState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var states []ProgressState
+	for _, e := range p.events[channel] {
+		states = append(states, e.State)
+	}
+	return states
+}
+
+func TestService_QueryProgress(t *testing.T) {
+	ctx := context.Background()
+	client := &blockingPluginClient{started: make(chan struct{})}
+	events := &progressEvents{events: map[string][]ProgressEvent{}}
+	s := &Service{
+		pluginRequestValidator: fakePluginRequestValidator{},
+		secretsService:         fakes.NewFakeSecretsService(),
+		pluginClient:           client,
+		oAuthTokenService:      oauthtoken.ProvideService(nil),
+		running:                map[string]*runningQuery{},
+		log:                    log.New("test"),
+	}
+	s.SetProgressPublisher(events.publish)
+	user := &models.SignedInUser{OrgId: 1, UserId: 2}
+	req := &parsedRequest{parsedQueries: []parsedQuery{{
+		datasource: &models.DataSource{Uid: "clickhouse", Type: "test"},
+		query:      backend.DataQuery{RefID: "A", JSON: []byte(`{}`)},
+	}}}
+
+	t.Run("should refuse invalid query IDs", func(t *testing.T) {
+		var badQuery *ErrBadQuery
+		_, _, err := s.trackQuery(ctx, user, "../other")
+		require.ErrorAs(t, err, &badQuery)
+	})
+
+	t.Run("should publish the progress and cancel the query", func(t *testing.T) {
+		queryCtx, finish, err := s.trackQuery(ctx, user, "q1")
+		require.NoError(t, err)
+		done := make(chan error)
+		go func() {
+			_, err := s.handleQueryData(queryCtx, user, req)
+			done <- finish(err)
+		}()
+		<-client.started
+
+		var badQuery *ErrBadQuery
+		_, _, err = s.trackQuery(ctx, user, "q1")
+		require.ErrorAs(t, err, &badQuery)
+		require.ErrorIs(t, s.CancelQuery(&models.SignedInUser{OrgId: 1, UserId: 3}, "q1"), ErrQueryNotFound)
+
+		require.NoError(t, s.CancelQuery(user, "q1"))
+		require.ErrorIs(t, <-done, ErrQueryCanceled)
+		require.ErrorIs(t, s.CancelQuery(user, "q1"), ErrQueryNotFound)
+
+		channel := ProgressChannel(user.UserId, "q1")
+		assert.Equal(t, "grafana/query/2/q1", channel)
+		assert.Equal(t, []ProgressState{ProgressStateRunning, ProgressStateRunning, ProgressStateCanceled}, events.states(channel))
+		progress := events.events[channel][1]
+		assert.Equal(t, "q1", progress.QueryID)
+		assert.Equal(t, "A", progress.RefID)
+		assert.Equal(t, int64(1024), progress.BytesScanned)
+		require.Len(t, progress.Frames, 1)
+		assert.Equal(t, 2, progress.Frames[0].Rows())
+	})
+
+	t.Run("should publish the end of the query", func(t *testing.T) {
+		_, finish, err := s.trackQuery(ctx, user, "q2")
+		require.NoError(t, err)
+		require.NoError(t, finish(nil))
+
+		_, finish, err = s.trackQuery(ctx, user, "q3")
+		require.NoError(t, err)
+		require.ErrorIs(t, finish(assert.AnError), assert.AnError)
+
+		assert.Equal(t, []ProgressState{ProgressStateRunning, ProgressStateDone}, events.states(ProgressChannel(2, "q2")))
+		assert.Equal(t, []ProgressState{ProgressStateRunning, ProgressStateError}, events.states(ProgressChannel(2, "q3")))
+	})
+}
@@ -3,52 +3,87 @@ package query
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/environments"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/querypipelines"
+	"github.com/grafana/grafana/pkg/services/retention"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 )
 
 func ProvideService(cfg *setting.Cfg, dataSourceCache datasources.CacheService, expressionService *expr.Service,
 	pluginRequestValidator models.PluginRequestValidator, SecretsService secrets.Service,
-	pluginClient plugins.Client, OAuthTokenService oauthtoken.OAuthTokenService) *Service {
+	pluginClient plugins.Client, OAuthTokenService oauthtoken.OAuthTokenService,
+	dataSourcePermissions datasources.PermissionsService, cacheRegistry *localcache.Registry, pluginStore plugins.Store,
+	retentionService *retention.Service, environmentsService *environments.Service,
+	queryPipelinesService *querypipelines.Service) *Service {
 	g := &Service{
 		cfg:                    cfg,
 		dataSourceCache:        dataSourceCache,
+		dataSourcePermissions:  dataSourcePermissions,
 		expressionService:      expressionService,
 		pluginRequestValidator: pluginRequestValidator,
 		secretsService:         SecretsService,
 		pluginClient:           pluginClient,
+		pluginStore:            pluginStore,
 		oAuthTokenService:      OAuthTokenService,
+		retentionService:       retentionService,
+		environmentsService:    environmentsService,
+		queryPipelinesService:  queryPipelinesService,
+		queryCache:             localcache.NewNamed("query", 5*time.Minute, 10*time.Minute),
+		running:                map[string]*runningQuery{},
 		log:                    log.New("query_data"),
 	}
+	cacheRegistry.Register(g.queryCache)
 	g.log.Info("Query Service initialization")
 	return g
 }
 
 // Gateway receives data and translates it to Grafana Live publications.
 type Service struct {
+	// inFlight is the number of queries being processed. It is accessed atomically and kept first for its alignment
+	// on 32-bit platforms.
+	inFlight int64
+
 	cfg                    *setting.Cfg
 	dataSourceCache        datasources.CacheService
+	dataSourcePermissions  datasources.PermissionsService
 	expressionService      *expr.Service
 	pluginRequestValidator models.PluginRequestValidator
 	secretsService         secrets.Service
 	pluginClient           plugins.Client
+	pluginStore            plugins.Store
 	oAuthTokenService      oauthtoken.OAuthTokenService
-	log                    log.Logger
+	retentionService       *retention.Service
+	environmentsService    *environments.Service
+	queryPipelinesService  *querypipelines.Service
+	// queryCache holds the query responses of the data sources with a cache policy
+	queryCache *localcache.CacheService
+	log        log.Logger
+
+	runningMu sync.Mutex
+	// running are the queries started with a query ID, by org, user and query ID
+	running map[string]*runningQuery
+	// progressPublisher publishes the progress of the running queries to Grafana Live
+	progressPublisher models.ChannelPublisher
 }
 
 // Run Service.
@@ -57,16 +92,100 @@ func (s *Service) Run(ctx context.Context) error {
 	return ctx.Err()
 }
 
-// QueryData can process queries and return query responses.
+// QueryData can process queries and return query responses. The queries started with a query ID can be canceled
+// with CancelQuery, and their progress is published to Grafana Live.
 func (s *Service) QueryData(ctx context.Context, user *models.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, handleExpressions bool) (*backend.QueryDataResponse, error) {
+	atomic.AddInt64(&s.inFlight, 1)
+	defer atomic.AddInt64(&s.inFlight, -1)
+
+	if reqDTO.QueryID == "" {
+		return s.queryData(ctx, user, skipCache, reqDTO, handleExpressions)
+	}
+
+	ctx, finish, err := s.trackQuery(ctx, user, reqDTO.QueryID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.queryData(ctx, user, skipCache, reqDTO, handleExpressions)
+	if err := finish(err); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// QueriesInFlight returns the number of queries being processed by this Grafana instance.
+func (s *Service) QueriesInFlight() int64 {
+	return atomic.LoadInt64(&s.inFlight)
+}
+
+func (s *Service) queryData(ctx context.Context, user *models.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, handleExpressions bool) (*backend.QueryDataResponse, error) {
+	reqDTO, err := s.expandPipeline(ctx, user, reqDTO)
+	if err != nil {
+		return nil, err
+	}
 	parsedReq, err := s.parseMetricRequest(ctx, user, skipCache, reqDTO)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkTransformations(ctx, reqDTO.Transformations); err != nil {
+		return nil, err
+	}
+
+	var resp *backend.QueryDataResponse
 	if handleExpressions && parsedReq.hasExpression {
-		return s.handleExpressions(ctx, user, parsedReq)
+		resp, err = s.handleExpressions(ctx, user, parsedReq)
+	} else {
+		resp, err = s.handleCachedQueryData(ctx, user, skipCache, parsedReq)
 	}
-	return s.handleQueryData(ctx, user, parsedReq)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyTransformations(ctx, user, reqDTO.Transformations, resp), nil
+}
+
+// handleCachedQueryData serves the queries from the query cache when the data source has a cache policy, unless
+// skipCache is set in which case the queries are executed and the cache refreshed.
+func (s *Service) handleCachedQueryData(ctx context.Context, user *models.SignedInUser, skipCache bool, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
+	ds := parsedReq.parsedQueries[0].datasource
+	key := ""
+	ttl := s.queryCacheTTL(ds, parsedReq.cacheTTL)
+	if ttl > 0 {
+		var err error
+		if key, err = queryCacheKey(user.OrgId, ds, parsedReq.parsedQueries, ttl); err != nil {
+			return nil, err
+		}
+		if cached, ok := s.queryCache.Get(key); ok && !skipCache {
+			s.log.Debug("Serving queries from the query cache", "datasource", ds.Uid, "ttl", ttl)
+			return cached.(*backend.QueryDataResponse), nil
+		}
+	}
+
+	resp, err := s.handleQueryData(ctx, user, parsedReq)
+	if err != nil {
+		return nil, err
+	}
+	addAlignmentNotices(resp, parsedReq.parsedQueries)
+	addRetentionNotices(resp, parsedReq.parsedQueries)
+
+	if key != "" && cacheable(resp) {
+		s.queryCache.Set(key, resp, ttl)
+	}
+	return resp, nil
+}
+
+// queryCacheTTL returns how long the responses of the data source can be cached given the TTL hint of the panel.
+// The responses of data sources forwarding the OAuth identity of the user are never cached.
+func (s *Service) queryCacheTTL(ds *models.DataSource, hint time.Duration) time.Duration {
+	if s.queryCache == nil || s.oAuthTokenService.IsOAuthPassThruEnabled(ds) {
+		return 0
+	}
+
+	policy, err := cachePolicyFromDataSource(ds)
+	if err != nil {
+		s.log.Warn("Ignoring data source query cache settings", "datasource", ds.Uid, "error", err)
+		return 0
+	}
+	return policy.effectiveTTL(hint)
 }
 
 // handleExpressions handles POST /api/ds/query when there is an expression.
@@ -136,8 +255,15 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 	}
 
 	for _, q := range parsedReq.parsedQueries {
+		// The data source has no data for the queries older than its retention
+		if q.clamped != nil && q.clamped.unavailable {
+			continue
+		}
 		req.Queries = append(req.Queries, q.query)
 	}
+	if len(req.Queries) == 0 {
+		return backend.NewQueryDataResponse(), nil
+	}
 
 	return s.pluginClient.QueryData(ctx, req)
 }
@@ -145,11 +271,17 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 type parsedQuery struct {
 	datasource *models.DataSource
 	query      backend.DataQuery
+	// aligned is set when the query time range or interval has been adjusted by the data source alignment policy
+	aligned *alignedQuery
+	// clamped is set when the query time range has been clamped to the retention of the data source
+	clamped *clampedQuery
 }
 
 type parsedRequest struct {
 	hasExpression bool
 	parsedQueries []parsedQuery
+	// cacheTTL is the cache TTL hint of the panel the queries come from
+	cacheTTL time.Duration
 }
 
 func (s *Service) parseMetricRequest(ctx context.Context, user *models.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest) (*parsedRequest, error) {
@@ -163,10 +295,18 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *models.SignedInU
 		parsedQueries: []parsedQuery{},
 	}
 
+	if reqDTO.CacheTTL != "" {
+		cacheTTL, err := gtime.ParseDuration(reqDTO.CacheTTL)
+		if err != nil {
+			return nil, NewErrBadQuery(fmt.Sprintf("invalid cacheTTL %q", reqDTO.CacheTTL))
+		}
+		req.cacheTTL = cacheTTL
+	}
+
 	// Parse the queries
 	datasourcesByUid := map[string]*models.DataSource{}
 	for _, query := range reqDTO.Queries {
-		ds, err := s.getDataSourceFromQuery(ctx, user, skipCache, query, datasourcesByUid)
+		ds, err := s.getDataSourceFromQuery(ctx, user, skipCache, query, datasourcesByUid, reqDTO.Environment)
 		if err != nil {
 			return nil, err
 		}
@@ -186,7 +326,7 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *models.SignedInU
 			return nil, err
 		}
 
-		req.parsedQueries = append(req.parsedQueries, parsedQuery{
+		pq := parsedQuery{
 			datasource: ds,
 			query: backend.DataQuery{
 				TimeRange: backend.TimeRange{
@@ -199,7 +339,17 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *models.SignedInU
 				QueryType:     query.Get("queryType").MustString(""),
 				JSON:          modelJSON,
 			},
-		})
+		}
+
+		alignment, err := timeAlignmentFromDataSource(ds)
+		if err != nil {
+			s.log.Warn("Ignoring data source time alignment settings", "datasource", ds.Uid, "error", err)
+		} else if alignment.enabled() {
+			pq.aligned = alignment.align(&pq.query)
+		}
+		pq.clamped = s.clampToRetention(ctx, user.OrgId, ds, &pq.query)
+
+		req.parsedQueries = append(req.parsedQueries, pq)
 	}
 
 	if !req.hasExpression {
@@ -212,7 +362,7 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *models.SignedInU
 	return req, nil
 }
 
-func (s *Service) getDataSourceFromQuery(ctx context.Context, user *models.SignedInUser, skipCache bool, query *simplejson.Json, history map[string]*models.DataSource) (*models.DataSource, error) {
+func (s *Service) getDataSourceFromQuery(ctx context.Context, user *models.SignedInUser, skipCache bool, query *simplejson.Json, history map[string]*models.DataSource, environment string) (*models.DataSource, error) {
 	var err error
 	uid := query.Get("datasource").Get("uid").MustString()
 
@@ -239,21 +389,40 @@ func (s *Service) getDataSourceFromQuery(ctx context.Context, user *models.Signe
 	id := query.Get("datasourceId").MustInt64(0)
 	if id > 0 {
 		ds, err = s.dataSourceCache.GetDatasource(ctx, id, user, skipCache)
-		if err != nil {
-			return nil, err
-		}
-		return ds, nil
+	} else if uid != "" {
+		ds, err = s.dataSourceCache.GetDatasourceByUID(ctx, uid, user, skipCache)
+	} else {
+		return nil, NewErrBadQuery("missing data source ID/UID")
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	if uid != "" {
-		ds, err = s.dataSourceCache.GetDatasourceByUID(ctx, uid, user, skipCache)
-		if err != nil {
-			return nil, err
-		}
-		return ds, nil
+	ds, err = s.resolveEnvironment(ctx, user, skipCache, environment, ds)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, NewErrBadQuery("missing data source ID/UID")
+	if err := s.checkQueryPermission(ctx, user, ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// checkQueryPermission returns ErrDataSourceAccessDenied if the user is not allowed to query the data source
+func (s *Service) checkQueryPermission(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) error {
+	if s.dataSourcePermissions == nil {
+		return nil
+	}
+
+	ok, err := s.dataSourcePermissions.CanQuery(ctx, user, ds)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrDataSourceAccessDenied
+	}
+	return nil
 }
 
 func (s *Service) decryptSecureJsonDataFn(ctx context.Context) func(map[string][]byte) map[string]string {
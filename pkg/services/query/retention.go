@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+// clampedQuery records the original time range of a query whose start has been moved to the retention cutoff of its
+// data source, declared through the retention API.
+type clampedQuery struct {
+	originalTimeRange backend.TimeRange
+	retention         string
+	// unavailable is set when the whole time range is older than the retention, the query is then not sent to the
+	// data source
+	unavailable bool
+}
+
+// clampToRetention clamps the query time range to the retention of the data source. It returns nil when the data
+// source has no retention or the query is within it.
+func (s *Service) clampToRetention(ctx context.Context, orgID int64, ds *models.DataSource, q *backend.DataQuery) *clampedQuery {
+	if s.retentionService == nil || expr.IsDataSource(ds.Uid) || ds.Uid == grafanads.DatasourceUID {
+		return nil
+	}
+
+	cutoff, retention, err := s.retentionService.Cutoff(ctx, orgID, ds.Uid)
+	if err != nil {
+		s.log.Warn("Ignoring data source retention", "datasource", ds.Uid, "error", err)
+		return nil
+	}
+	if retention == nil {
+		return nil
+	}
+	return clampTimeRange(q, cutoff, retention.Retention)
+}
+
+// clampTimeRange moves the start of the query time range to the cutoff. A time range ending before the cutoff is
+// emptied, it starts and ends at its end.
+func clampTimeRange(q *backend.DataQuery, cutoff time.Time, retention string) *clampedQuery {
+	if !q.TimeRange.From.Before(cutoff) {
+		return nil
+	}
+
+	clamped := &clampedQuery{originalTimeRange: q.TimeRange, retention: retention}
+	if q.TimeRange.To.Before(cutoff) {
+		clamped.unavailable = true
+		q.TimeRange.From = q.TimeRange.To
+	} else {
+		q.TimeRange.From = cutoff
+	}
+	return clamped
+}
+
+// notice tells the panel why the data of the query is missing or starts later than requested.
+func (cq *clampedQuery) notice(q backend.DataQuery) data.Notice {
+	text := fmt.Sprintf(
+		"Data older than %s is unavailable, the query time range was clamped from %s - %s to %s - %s",
+		cq.retention, cq.originalTimeRange.From.Format(time.RFC3339), cq.originalTimeRange.To.Format(time.RFC3339),
+		q.TimeRange.From.Format(time.RFC3339), q.TimeRange.To.Format(time.RFC3339),
+	)
+	if cq.unavailable {
+		text = fmt.Sprintf(
+			"Data older than %s is unavailable, there is no data for the query time range %s - %s",
+			cq.retention, cq.originalTimeRange.From.Format(time.RFC3339), cq.originalTimeRange.To.Format(time.RFC3339),
+		)
+	}
+	return data.Notice{Severity: data.NoticeSeverityWarning, Text: text}
+}
+
+// addRetentionNotices adds a notice to every frame of the responses of queries that have been clamped. The responses
+// without frames are given an empty frame holding the notice, so that the panels show it instead of no data.
+func addRetentionNotices(resp *backend.QueryDataResponse, queries []parsedQuery) {
+	if resp == nil {
+		return
+	}
+
+	for _, pq := range queries {
+		if pq.clamped == nil {
+			continue
+		}
+
+		res := resp.Responses[pq.query.RefID]
+		if res.Error != nil {
+			continue
+		}
+		if len(res.Frames) == 0 {
+			res.Frames = data.Frames{data.NewFrame("")}
+		}
+
+		notice := pq.clamped.notice(pq.query)
+		for _, frame := range res.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Notices = append(frame.Meta.Notices, notice)
+		}
+		resp.Responses[pq.query.RefID] = res
+	}
+}
@@ -0,0 +1,108 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+)
+
+func TestClampTimeRange(t *testing.T) {
+	cutoff := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should leave the queries within the retention unchanged", func(t *testing.T) {
+		q := backend.DataQuery{TimeRange: backend.TimeRange{From: cutoff, To: cutoff.Add(time.Hour)}}
+		assert.Nil(t, clampTimeRange(&q, cutoff, "30d"))
+		assert.Equal(t, cutoff, q.TimeRange.From)
+	})
+
+	t.Run("should move the start of the time range to the cutoff", func(t *testing.T) {
+		from := cutoff.Add(-time.Hour)
+		q := backend.DataQuery{TimeRange: backend.TimeRange{From: from, To: cutoff.Add(time.Hour)}}
+		clamped := clampTimeRange(&q, cutoff, "30d")
+
+		require.NotNil(t, clamped)
+		assert.False(t, clamped.unavailable)
+		assert.Equal(t, from, clamped.originalTimeRange.From)
+		assert.Equal(t, cutoff, q.TimeRange.From)
+		assert.Equal(t, cutoff.Add(time.Hour), q.TimeRange.To)
+	})
+
+	t.Run("should empty the time ranges older than the retention", func(t *testing.T) {
+		to := cutoff.Add(-time.Hour)
+		q := backend.DataQuery{TimeRange: backend.TimeRange{From: cutoff.Add(-2 * time.Hour), To: to}}
+		clamped := clampTimeRange(&q, cutoff, "30d")
+
+		require.NotNil(t, clamped)
+		assert.True(t, clamped.unavailable)
+		assert.Equal(t, to, q.TimeRange.From)
+		assert.Equal(t, to, q.TimeRange.To)
+	})
+}
+
+func TestAddRetentionNotices(t *testing.T) {
+	cutoff := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	a := backend.DataQuery{RefID: "A", TimeRange: backend.TimeRange{From: cutoff.Add(-time.Hour), To: cutoff.Add(time.Hour)}}
+	clampedA := clampTimeRange(&a, cutoff, "30d")
+	b := backend.DataQuery{RefID: "B", TimeRange: backend.TimeRange{From: cutoff.Add(-2 * time.Hour), To: cutoff.Add(-time.Hour)}}
+	clampedB := clampTimeRange(&b, cutoff, "30d")
+
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("a")}},
+		"C": backend.DataResponse{Frames: data.Frames{data.NewFrame("c")}},
+	}}
+	addRetentionNotices(resp, []parsedQuery{{query: a, clamped: clampedA}, {query: b, clamped: clampedB}, {query: backend.DataQuery{RefID: "C"}}})
+
+	require.NotNil(t, resp.Responses["A"].Frames[0].Meta)
+	require.Len(t, resp.Responses["A"].Frames[0].Meta.Notices, 1)
+	assert.Equal(t, data.NoticeSeverityWarning, resp.Responses["A"].Frames[0].Meta.Notices[0].Severity)
+	assert.Contains(t, resp.Responses["A"].Frames[0].Meta.Notices[0].Text, "Data older than 30d is unavailable")
+
+	// The queries without data are given a frame holding the notice
+	require.Len(t, resp.Responses["B"].Frames, 1)
+	require.Len(t, resp.Responses["B"].Frames[0].Meta.Notices, 1)
+	assert.Contains(t, resp.Responses["B"].Frames[0].Meta.Notices[0].Text, "there is no data")
+
+	assert.Nil(t, resp.Responses["C"].Frames[0].Meta)
+}
+
+func TestService_HandleQueryData_Retention(t *testing.T) {
+	client := &fakePluginClient{}
+	s := &Service{
+		pluginRequestValidator: fakePluginRequestValidator{},
+		secretsService:         fakes.NewFakeSecretsService(),
+		pluginClient:           client,
+		oAuthTokenService:      oauthtoken.ProvideService(nil),
+		log:                    log.New("test"),
+	}
+	ds := &models.DataSource{Uid: "prom", Type: "test", JsonData: simplejson.New()}
+	user := &models.SignedInUser{OrgId: 1}
+
+	t.Run("should not send the queries older than the retention to the data source", func(t *testing.T) {
+		resp, err := s.handleQueryData(context.Background(), user, &parsedRequest{parsedQueries: []parsedQuery{
+			{datasource: ds, query: backend.DataQuery{RefID: "A"}, clamped: &clampedQuery{retention: "30d", unavailable: true}},
+		}})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Responses)
+		assert.Equal(t, 0, client.calls)
+
+		resp, err = s.handleQueryData(context.Background(), user, &parsedRequest{parsedQueries: []parsedQuery{
+			{datasource: ds, query: backend.DataQuery{RefID: "A"}, clamped: &clampedQuery{retention: "30d", unavailable: true}},
+			{datasource: ds, query: backend.DataQuery{RefID: "B"}, clamped: &clampedQuery{retention: "30d"}},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, client.calls)
+		assert.Contains(t, resp.Responses, "B")
+		assert.NotContains(t, resp.Responses, "A")
+	})
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// timeAlignment holds the boundaries a data source wants its query time ranges and intervals snapped to,
+// configured through the `timeRangeAlignment` and `intervalAlignment` json data settings.
+// Aligned requests are identical for refreshes happening within the same boundary which improves
+// cache hit rates of caches sitting between Grafana and the data source.
+type timeAlignment struct {
+	timeRange time.Duration
+	interval  time.Duration
+}
+
+func (a timeAlignment) enabled() bool {
+	return a.timeRange > 0 || a.interval > 0
+}
+
+// alignedQuery records the original values of a query that has been adjusted.
+type alignedQuery struct {
+	originalTimeRange backend.TimeRange
+	originalInterval  time.Duration
+}
+
+func timeAlignmentFromDataSource(ds *models.DataSource) (timeAlignment, error) {
+	alignment := timeAlignment{}
+	if ds == nil || ds.JsonData == nil {
+		return alignment, nil
+	}
+
+	var err error
+	if v := ds.JsonData.Get("timeRangeAlignment").MustString(""); v != "" {
+		if alignment.timeRange, err = gtime.ParseDuration(v); err != nil {
+			return alignment, fmt.Errorf("invalid timeRangeAlignment %q: %w", v, err)
+		}
+	}
+
+	if v := ds.JsonData.Get("intervalAlignment").MustString(""); v != "" {
+		if alignment.interval, err = gtime.ParseDuration(v); err != nil {
+			return alignment, fmt.Errorf("invalid intervalAlignment %q: %w", v, err)
+		}
+	}
+
+	return alignment, nil
+}
+
+// align snaps the query time range outwards to the time range boundary, so that the requested range is always covered,
+// and rounds the interval up to a multiple of the interval boundary. It returns nil when the query is left unchanged.
+func (a timeAlignment) align(q *backend.DataQuery) *alignedQuery {
+	original := alignedQuery{
+		originalTimeRange: q.TimeRange,
+		originalInterval:  q.Interval,
+	}
+
+	if a.timeRange > 0 {
+		q.TimeRange.From = q.TimeRange.From.Truncate(a.timeRange)
+		if to := q.TimeRange.To.Truncate(a.timeRange); to.Before(q.TimeRange.To) {
+			q.TimeRange.To = to.Add(a.timeRange)
+		}
+	}
+
+	if a.interval > 0 && q.Interval > 0 {
+		if remainder := q.Interval % a.interval; remainder != 0 {
+			q.Interval += a.interval - remainder
+		}
+	}
+
+	if q.TimeRange.From.Equal(original.originalTimeRange.From) && q.TimeRange.To.Equal(original.originalTimeRange.To) &&
+		q.Interval == original.originalInterval {
+		return nil
+	}
+
+	return &original
+}
+
+// notice describes the adjustment made to the query so it can be surfaced next to the query results.
+func (aq *alignedQuery) notice(q backend.DataQuery) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text: fmt.Sprintf(
+			"Query time range adjusted by the data source alignment policy from %s - %s (interval %s) to %s - %s (interval %s)",
+			aq.originalTimeRange.From.Format(time.RFC3339), aq.originalTimeRange.To.Format(time.RFC3339), aq.originalInterval,
+			q.TimeRange.From.Format(time.RFC3339), q.TimeRange.To.Format(time.RFC3339), q.Interval,
+		),
+	}
+}
+
+// addAlignmentNotices adds a notice to every frame of the responses of queries that have been aligned.
+func addAlignmentNotices(resp *backend.QueryDataResponse, queries []parsedQuery) {
+	if resp == nil {
+		return
+	}
+
+	for _, pq := range queries {
+		if pq.aligned == nil {
+			continue
+		}
+
+		res, ok := resp.Responses[pq.query.RefID]
+		if !ok {
+			continue
+		}
+
+		notice := pq.aligned.notice(pq.query)
+		for _, frame := range res.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Notices = append(frame.Meta.Notices, notice)
+		}
+	}
+}
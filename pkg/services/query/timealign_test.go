@@ -0,0 +1,83 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestTimeAlignmentFromDataSource(t *testing.T) {
+	t.Run("should be disabled without settings", func(t *testing.T) {
+		alignment, err := timeAlignmentFromDataSource(&models.DataSource{JsonData: simplejson.New()})
+		require.NoError(t, err)
+		assert.False(t, alignment.enabled())
+	})
+
+	t.Run("should parse settings", func(t *testing.T) {
+		alignment, err := timeAlignmentFromDataSource(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"timeRangeAlignment": "1m",
+			"intervalAlignment":  "15s",
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, alignment.timeRange)
+		assert.Equal(t, 15*time.Second, alignment.interval)
+	})
+
+	t.Run("should fail on invalid settings", func(t *testing.T) {
+		_, err := timeAlignmentFromDataSource(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"timeRangeAlignment": "one minute",
+		})})
+		require.Error(t, err)
+	})
+}
+
+func TestTimeAlignment_align(t *testing.T) {
+	from := time.Date(2021, 12, 1, 10, 0, 12, 0, time.UTC)
+	to := time.Date(2021, 12, 1, 11, 0, 42, 0, time.UTC)
+
+	t.Run("should snap time range outwards and round interval up", func(t *testing.T) {
+		q := backend.DataQuery{TimeRange: backend.TimeRange{From: from, To: to}, Interval: 20 * time.Second}
+		aligned := timeAlignment{timeRange: time.Minute, interval: 15 * time.Second}.align(&q)
+
+		require.NotNil(t, aligned)
+		assert.Equal(t, time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC), q.TimeRange.From)
+		assert.Equal(t, time.Date(2021, 12, 1, 11, 1, 0, 0, time.UTC), q.TimeRange.To)
+		assert.Equal(t, 30*time.Second, q.Interval)
+		assert.Equal(t, from, aligned.originalTimeRange.From)
+		assert.Equal(t, to, aligned.originalTimeRange.To)
+		assert.Equal(t, 20*time.Second, aligned.originalInterval)
+	})
+
+	t.Run("should not report already aligned queries", func(t *testing.T) {
+		q := backend.DataQuery{
+			TimeRange: backend.TimeRange{From: from.Truncate(time.Minute), To: to.Truncate(time.Minute)},
+			Interval:  30 * time.Second,
+		}
+		aligned := timeAlignment{timeRange: time.Minute, interval: 15 * time.Second}.align(&q)
+		assert.Nil(t, aligned)
+	})
+}
+
+func TestAddAlignmentNotices(t *testing.T) {
+	q := backend.DataQuery{RefID: "A", TimeRange: backend.TimeRange{From: time.Unix(61, 0), To: time.Unix(119, 0)}}
+	aligned := timeAlignment{timeRange: time.Minute}.align(&q)
+	require.NotNil(t, aligned)
+
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("a")}},
+		"B": backend.DataResponse{Frames: data.Frames{data.NewFrame("b")}},
+	}}
+	addAlignmentNotices(resp, []parsedQuery{{query: q, aligned: aligned}, {query: backend.DataQuery{RefID: "B"}}})
+
+	require.NotNil(t, resp.Responses["A"].Frames[0].Meta)
+	require.Len(t, resp.Responses["A"].Frames[0].Meta.Notices, 1)
+	assert.Equal(t, data.NoticeSeverityInfo, resp.Responses["A"].Frames[0].Meta.Notices[0].Severity)
+	assert.Nil(t, resp.Responses["B"].Frames[0].Meta)
+}
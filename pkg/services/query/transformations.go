@@ -0,0 +1,179 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
+)
+
+// transformationsResourcePath is the resource of the backend plugins executing their transformations
+const transformationsResourcePath = "transformations/"
+
+// Transformation is a server-side transformation registered by a backend plugin
+type Transformation struct {
+	// Id is the ID of the plugin and the ID of the transformation, separated by a slash
+	Id          string `json:"id"`
+	PluginId    string `json:"pluginId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// transformationRequest is the body of the transformation resource calls, the frames are Arrow encoded
+type transformationRequest struct {
+	Options json.RawMessage `json:"options,omitempty"`
+	Frames  [][]byte        `json:"frames"`
+}
+
+type transformationResponse struct {
+	Frames [][]byte `json:"frames"`
+}
+
+// Transformations returns the server-side transformations registered by the backend plugins, sorted by ID
+func (s *Service) Transformations(ctx context.Context) []*Transformation {
+	result := make([]*Transformation, 0)
+	if s.pluginStore == nil {
+		return result
+	}
+
+	for _, p := range s.pluginStore.Plugins(ctx) {
+		if !p.Backend {
+			continue
+		}
+		for _, t := range p.Transformations {
+			result = append(result, &Transformation{
+				Id:          p.ID + "/" + t.ID,
+				PluginId:    p.ID,
+				Name:        t.Name,
+				Description: t.Description,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// checkTransformations returns an ErrBadQuery if a transformation of the request is not registered
+func (s *Service) checkTransformations(ctx context.Context, transformations []*dtos.MetricRequestTransformation) error {
+	if len(transformations) == 0 {
+		return nil
+	}
+
+	registered := map[string]bool{}
+	for _, t := range s.Transformations(ctx) {
+		registered[t.Id] = true
+	}
+	for _, t := range transformations {
+		if !registered[t.Id] {
+			return NewErrBadQuery(fmt.Sprintf("unknown transformation %q", t.Id))
+		}
+	}
+	return nil
+}
+
+// applyTransformations applies the transformations in order to the frames of each query response. The responses
+// may be cached, the transformed frames are set on a copy. A failing transformation sets the error of the response.
+func (s *Service) applyTransformations(ctx context.Context, user *models.SignedInUser,
+	transformations []*dtos.MetricRequestTransformation, resp *backend.QueryDataResponse) *backend.QueryDataResponse {
+	if len(transformations) == 0 {
+		return resp
+	}
+
+	result := backend.NewQueryDataResponse()
+	for refID, res := range resp.Responses {
+		for _, t := range transformations {
+			if res.Error != nil {
+				break
+			}
+			frames, err := s.transform(ctx, user, t, res.Frames)
+			if err != nil {
+				s.log.Debug("Failed to apply transformation", "transformation", t.Id, "refId", refID, "error", err)
+				res = backend.DataResponse{Error: fmt.Errorf("transformation %s failed: %w", t.Id, err)}
+				break
+			}
+			res.Frames = frames
+		}
+		result.Responses[refID] = res
+	}
+	return result
+}
+
+// transform calls the transformation resource of the plugin with the frames and returns the transformed frames
+func (s *Service) transform(ctx context.Context, user *models.SignedInUser, t *dtos.MetricRequestTransformation,
+	frames data.Frames) (data.Frames, error) {
+	pluginID, id := splitTransformationID(t.Id)
+
+	encoded, err := frames.MarshalArrow()
+	if err != nil {
+		return nil, err
+	}
+	body := transformationRequest{Frames: encoded}
+	if t.Options != nil {
+		if body.Options, err = t.Options.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:    user.OrgId,
+			PluginID: pluginID,
+			User:     adapters.BackendUserFromSignedInUser(user),
+		},
+		Path:    transformationsResourcePath + id,
+		Method:  http.MethodPost,
+		URL:     transformationsResourcePath + id,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    reqBody,
+	}
+	sender := &transformationResponseSender{}
+	if err := s.pluginClient.CallResource(ctx, req, sender); err != nil {
+		return nil, err
+	}
+	if sender.status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", sender.status, strings.TrimSpace(sender.body.String()))
+	}
+
+	var res transformationResponse
+	if err := json.Unmarshal(sender.body.Bytes(), &res); err != nil {
+		return nil, fmt.Errorf("invalid transformation response: %w", err)
+	}
+	return data.UnmarshalArrowFrames(res.Frames)
+}
+
+func splitTransformationID(id string) (string, string) {
+	i := strings.Index(id, "/")
+	if i < 0 {
+		return id, ""
+	}
+	return id[:i], id[i+1:]
+}
+
+// transformationResponseSender collects the response of a transformation resource call
+type transformationResponseSender struct {
+	status int
+	body   bytes.Buffer
+}
+
+func (s *transformationResponseSender) Send(resp *backend.CallResourceResponse) error {
+	// The status is part of the first response only
+	if s.status == 0 {
+		s.status = resp.Status
+	}
+	_, err := s.body.Write(resp.Body)
+	return err
+}
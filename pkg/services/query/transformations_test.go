@@ -0,0 +1,144 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+func TestService_Transformations(t *testing.T) {
+	s := &Service{pluginStore: newFakeTransformationsPluginStore(), log: log.New("test")}
+
+	assert.Equal(t, []*Transformation{
+		{Id: "calendar/business-fill", PluginId: "calendar", Name: "Business calendar fill", Description: "Fills the business days"},
+		{Id: "calendar/downsample", PluginId: "calendar", Name: "Downsample"},
+	}, s.Transformations(context.Background()))
+
+	t.Run("should refuse unknown transformations", func(t *testing.T) {
+		err := s.checkTransformations(context.Background(), []*dtos.MetricRequestTransformation{
+			{Id: "calendar/downsample"}, {Id: "frontend/downsample"},
+		})
+		var badQuery *ErrBadQuery
+		require.True(t, errors.As(err, &badQuery))
+	})
+}
+
+func TestService_ApplyTransformations(t *testing.T) {
+	client := &fakeTransformationsPluginClient{}
+	s := &Service{pluginStore: newFakeTransformationsPluginStore(), pluginClient: client, log: log.New("test")}
+	user := &models.SignedInUser{OrgId: 2, Login: "viewer"}
+
+	resp := backend.NewQueryDataResponse()
+	resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("A", data.NewField("value", nil, []float64{1, 2}))}}
+	resp.Responses["B"] = backend.DataResponse{Error: errors.New("query failed")}
+
+	t.Run("should apply the transformations in order to the frames of the responses", func(t *testing.T) {
+		result := s.applyTransformations(context.Background(), user, []*dtos.MetricRequestTransformation{
+			{Id: "calendar/downsample", Options: simplejson.NewFromAny(map[string]interface{}{"interval": "1h"})},
+			{Id: "calendar/business-fill"},
+		}, resp)
+
+		require.Len(t, client.requests, 2)
+		assert.Equal(t, "calendar", client.requests[0].PluginContext.PluginID)
+		assert.Equal(t, int64(2), client.requests[0].PluginContext.OrgID)
+		assert.Equal(t, "transformations/downsample", client.requests[0].Path)
+		assert.Equal(t, http.MethodPost, client.requests[0].Method)
+		assert.JSONEq(t, `{"interval": "1h"}`, string(client.options[0]))
+		assert.Equal(t, "transformations/business-fill", client.requests[1].Path)
+
+		require.Len(t, result.Responses["A"].Frames, 1)
+		assert.Equal(t, "A transformations/downsample transformations/business-fill", result.Responses["A"].Frames[0].Name)
+		assert.EqualError(t, result.Responses["B"].Error, "query failed")
+
+		// The responses, which may be cached, are not changed
+		assert.Equal(t, "A", resp.Responses["A"].Frames[0].Name)
+	})
+
+	t.Run("should set the error of the responses whose transformation fails", func(t *testing.T) {
+		client.requests = nil
+		client.status = http.StatusBadRequest
+		t.Cleanup(func() { client.status = 0 })
+
+		result := s.applyTransformations(context.Background(), user, []*dtos.MetricRequestTransformation{
+			{Id: "calendar/downsample"},
+			{Id: "calendar/business-fill"},
+		}, resp)
+
+		require.Len(t, client.requests, 1)
+		require.Error(t, result.Responses["A"].Error)
+		assert.Contains(t, result.Responses["A"].Error.Error(), "transformation calendar/downsample failed")
+		assert.Empty(t, result.Responses["A"].Frames)
+	})
+}
+
+type fakeTransformationsPluginStore struct {
+	plugins.Store
+	plugins []plugins.PluginDTO
+}
+
+func newFakeTransformationsPluginStore() *fakeTransformationsPluginStore {
+	return &fakeTransformationsPluginStore{plugins: []plugins.PluginDTO{
+		{JSONData: plugins.JSONData{ID: "calendar", Backend: true, Transformations: []*plugins.Transformation{
+			{ID: "downsample", Name: "Downsample"},
+			{ID: "business-fill", Name: "Business calendar fill", Description: "Fills the business days"},
+		}}},
+		{JSONData: plugins.JSONData{ID: "frontend", Transformations: []*plugins.Transformation{
+			{ID: "downsample", Name: "Downsample"},
+		}}},
+	}}
+}
+
+func (s *fakeTransformationsPluginStore) Plugins(_ context.Context, _ ...plugins.Type) []plugins.PluginDTO {
+	return s.plugins
+}
+
+// fakeTransformationsPluginClient renames the frames with the path of the transformation resource
+type fakeTransformationsPluginClient struct {
+	plugins.Client
+	requests []*backend.CallResourceRequest
+	options  []json.RawMessage
+	status   int
+}
+
+func (c *fakeTransformationsPluginClient) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	c.requests = append(c.requests, req)
+	if c.status != 0 {
+		return sender.Send(&backend.CallResourceResponse{Status: c.status, Body: []byte(`{"message": "invalid options"}`)})
+	}
+
+	var body transformationRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return err
+	}
+	c.options = append(c.options, body.Options)
+
+	frames, err := data.UnmarshalArrowFrames(body.Frames)
+	if err != nil {
+		return err
+	}
+	for _, frame := range frames {
+		frame.Name += " " + req.Path
+	}
+	encoded, err := frames.MarshalArrow()
+	if err != nil {
+		return err
+	}
+	resBody, err := json.Marshal(transformationResponse{Frames: encoded})
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{Status: http.StatusOK, Body: resBody})
+}
@@ -0,0 +1,106 @@
+package querypipelines
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite)
+
+	s.routeRegister.Group("/api/query-pipelines", func(pipelineRoute routing.RouteRegister) {
+		pipelineRoute.Get("/", middleware.ReqSignedIn, routing.Wrap(s.getPipelinesHandler))
+		pipelineRoute.Post("/", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.createPipelineHandler))
+		pipelineRoute.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(s.getPipelineHandler))
+		pipelineRoute.Put("/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.updatePipelineHandler))
+		pipelineRoute.Delete("/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.deletePipelineHandler))
+		pipelineRoute.Get("/:uid/usage", middleware.ReqSignedIn, routing.Wrap(s.getUsageHandler))
+	})
+}
+
+// GET /api/query-pipelines
+func (s *Service) getPipelinesHandler(c *models.ReqContext) response.Response {
+	pipelines, err := s.GetPipelines(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get query pipelines")
+	}
+	return response.JSON(http.StatusOK, pipelines)
+}
+
+// POST /api/query-pipelines
+func (s *Service) createPipelineHandler(c *models.ReqContext) response.Response {
+	cmd := SavePipelineCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	pipeline, err := s.CreatePipeline(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create query pipeline")
+	}
+	return response.JSON(http.StatusOK, pipeline)
+}
+
+// GET /api/query-pipelines/:uid
+func (s *Service) getPipelineHandler(c *models.ReqContext) response.Response {
+	pipeline, err := s.GetPipeline(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get query pipeline")
+	}
+	return response.JSON(http.StatusOK, pipeline)
+}
+
+// PUT /api/query-pipelines/:uid
+func (s *Service) updatePipelineHandler(c *models.ReqContext) response.Response {
+	cmd := SavePipelineCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	pipeline, err := s.UpdatePipeline(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update query pipeline")
+	}
+	return response.JSON(http.StatusOK, pipeline)
+}
+
+// DELETE /api/query-pipelines/:uid
+func (s *Service) deletePipelineHandler(c *models.ReqContext) response.Response {
+	force := c.QueryBool("force")
+	if err := s.DeletePipeline(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], force); err != nil {
+		return errorResponse(err, "Failed to delete query pipeline")
+	}
+	return response.Success("Query pipeline deleted")
+}
+
+// GET /api/query-pipelines/:uid/usage
+func (s *Service) getUsageHandler(c *models.ReqContext) response.Response {
+	usage, err := s.GetUsage(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get query pipeline usage")
+	}
+	return response.JSON(http.StatusOK, usage)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrPipelineNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidPipeline):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrPipelineExists), errors.Is(err, ErrPipelineInUse):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrPipelineVersionMismatch):
+		return response.Error(http.StatusPreconditionFailed, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
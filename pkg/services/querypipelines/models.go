@@ -0,0 +1,106 @@
+package querypipelines
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	ErrPipelineNotFound        = errors.New("query pipeline not found")
+	ErrPipelineExists          = errors.New("a query pipeline with the same name or uid already exists")
+	ErrPipelineVersionMismatch = errors.New("the query pipeline has been changed by someone else")
+	ErrPipelineInUse           = errors.New("the query pipeline is used by dashboards or alert rules")
+	ErrInvalidPipeline         = errors.New("invalid query pipeline")
+)
+
+const (
+	ActionWrite = "querypipelines:write"
+
+	// DatasourceUID is the UID of the data source of the queries referencing a query pipeline, the UID of the
+	// pipeline is then set in the pipelineUid field of the query
+	DatasourceUID = "-- Pipeline --"
+)
+
+// Steps are the steps of a query pipeline: the data queries and the expressions, followed by the server-side
+// transformations applied to the frames of their responses.
+type Steps struct {
+	Queries         []*simplejson.Json                  `json:"queries"`
+	Transformations []*dtos.MetricRequestTransformation `json:"transformations,omitempty"`
+}
+
+// FromDB is part of the xorm Conversion interface.
+func (s *Steps) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*s = Steps{}
+		return nil
+	}
+	return json.Unmarshal(data, s)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (s *Steps) ToDB() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// Pipeline is a named chain of queries, expressions and transformations of an organization, defined once and
+// referenced by the queries of panels and alert rules.
+type Pipeline struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	Uid         string    `json:"uid"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Steps       *Steps    `json:"steps"`
+	Version     int       `json:"version"`
+	CreatedBy   int64     `json:"createdBy"`
+	UpdatedBy   int64     `json:"updatedBy"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (p *Pipeline) TableName() string {
+	return "query_pipeline"
+}
+
+// DashboardUsage records that a panel of the dashboard references the query pipeline. It is kept up to date when
+// the dashboards are saved.
+type DashboardUsage struct {
+	Id           int64  `json:"-"`
+	OrgId        int64  `json:"-"`
+	PipelineUid  string `json:"-"`
+	DashboardUid string `json:"-"`
+}
+
+func (u *DashboardUsage) TableName() string {
+	return "query_pipeline_dashboard"
+}
+
+// SavePipelineCommand creates a query pipeline, or updates it when Version is the current version of the pipeline.
+type SavePipelineCommand struct {
+	Uid         string `json:"uid"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Steps       *Steps `json:"steps"`
+	Version     int    `json:"version"`
+}
+
+// UsageRef is a dashboard or an alert rule referencing a query pipeline
+type UsageRef struct {
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+// Usage lists the dashboards and the alert rules referencing a query pipeline
+type Usage struct {
+	Dashboards []UsageRef `json:"dashboards"`
+	AlertRules []UsageRef `json:"alertRules"`
+}
+
+// InUse returns true when a dashboard or an alert rule references the query pipeline
+func (u *Usage) InUse() bool {
+	return len(u.Dashboards) > 0 || len(u.AlertRules) > 0
+}
@@ -0,0 +1,270 @@
+package querypipelines
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// pipelineCacheTTL is how long the pipelines are cached for the queries. Changes are applied to the queries of the
+// other Grafana instances of a cluster after this delay.
+const pipelineCacheTTL = time.Minute
+
+const maxNameLength = 190
+
+// Service manages the query pipelines of the organizations: chains of queries, expressions and transformations
+// saved once and referenced by the queries of panels and alert rules, so that common derived metrics are defined
+// in a single place.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	cache         *localcache.CacheService
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	cacheRegistry *localcache.Registry, b bus.Bus) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		cache:         localcache.NewNamed("querypipelines", pipelineCacheTTL, 2*pipelineCacheTTL),
+		log:           log.New("querypipelines"),
+		now:           time.Now,
+	}
+	cacheRegistry.Register(s.cache)
+	b.AddEventListener(s.onDashboardSaved)
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s
+}
+
+// PipelineUID returns the UID of the query pipeline the query references, or false when the query is not a
+// reference to a pipeline.
+func PipelineUID(query *simplejson.Json) (string, bool) {
+	if query.Get("datasource").Get("uid").MustString() != DatasourceUID {
+		return "", false
+	}
+	return query.Get("pipelineUid").MustString(), true
+}
+
+// GetPipelines returns the query pipelines of the organization sorted by name
+func (s *Service) GetPipelines(ctx context.Context, orgID int64) ([]*Pipeline, error) {
+	return s.getPipelines(ctx, orgID)
+}
+
+// GetPipeline returns the query pipeline of the organization
+func (s *Service) GetPipeline(ctx context.Context, orgID int64, uid string) (*Pipeline, error) {
+	return s.getPipeline(ctx, orgID, uid)
+}
+
+// GetCachedPipeline returns the query pipeline of the organization from the cache, it is used to run the queries
+// referencing the pipeline.
+func (s *Service) GetCachedPipeline(ctx context.Context, orgID int64, uid string) (*Pipeline, error) {
+	key := localcache.OrgKey(orgID, uid)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*Pipeline), nil
+	}
+
+	pipeline, err := s.getPipeline(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, pipeline, 0)
+	return pipeline, nil
+}
+
+// CreatePipeline adds a query pipeline to the organization of the user.
+func (s *Service) CreatePipeline(ctx context.Context, user *models.SignedInUser, cmd SavePipelineCommand) (*Pipeline, error) {
+	if cmd.Uid == "" {
+		cmd.Uid = util.GenerateShortUID()
+	}
+	if err := validate(&cmd); err != nil {
+		return nil, err
+	}
+
+	pipeline := &Pipeline{
+		OrgId:       user.OrgId,
+		Uid:         cmd.Uid,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Steps:       cmd.Steps,
+		Version:     1,
+		CreatedBy:   user.UserId,
+		UpdatedBy:   user.UserId,
+		Created:     s.now(),
+		Updated:     s.now(),
+	}
+	if err := s.insertPipeline(ctx, pipeline); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Created query pipeline", "orgId", user.OrgId, "uid", pipeline.Uid, "userId", user.UserId)
+	return pipeline, nil
+}
+
+// UpdatePipeline replaces the name, the description and the steps of the query pipeline. The version of the
+// command must be the current version of the pipeline, so that concurrent changes are not overwritten.
+func (s *Service) UpdatePipeline(ctx context.Context, user *models.SignedInUser, uid string, cmd SavePipelineCommand) (*Pipeline, error) {
+	cmd.Uid = uid
+	if err := validate(&cmd); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := s.updatePipeline(ctx, user.OrgId, cmd, func(p *Pipeline) {
+		p.Name = cmd.Name
+		p.Description = cmd.Description
+		p.Steps = cmd.Steps
+		p.Version++
+		p.UpdatedBy = user.UserId
+		p.Updated = s.now()
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Delete(localcache.OrgKey(user.OrgId, uid))
+
+	s.log.Info("Updated query pipeline", "orgId", user.OrgId, "uid", uid, "version", pipeline.Version, "userId", user.UserId)
+	return pipeline, nil
+}
+
+// DeletePipeline removes the query pipeline. A pipeline referenced by dashboards or alert rules is only removed
+// when force is set, their queries referencing the pipeline then fail.
+func (s *Service) DeletePipeline(ctx context.Context, orgID int64, uid string, force bool) error {
+	if _, err := s.getPipeline(ctx, orgID, uid); err != nil {
+		return err
+	}
+	if !force {
+		usage, err := s.getUsage(ctx, orgID, uid)
+		if err != nil {
+			return err
+		}
+		if usage.InUse() {
+			return ErrPipelineInUse
+		}
+	}
+
+	if err := s.deletePipeline(ctx, orgID, uid); err != nil {
+		return err
+	}
+	s.cache.Delete(localcache.OrgKey(orgID, uid))
+	return nil
+}
+
+// GetUsage returns the dashboards and the alert rules referencing the query pipeline
+func (s *Service) GetUsage(ctx context.Context, orgID int64, uid string) (*Usage, error) {
+	if _, err := s.getPipeline(ctx, orgID, uid); err != nil {
+		return nil, err
+	}
+	return s.getUsage(ctx, orgID, uid)
+}
+
+// onDashboardSaved records the query pipelines referenced by the panels of the saved dashboard
+func (s *Service) onDashboardSaved(ctx context.Context, e *events.DashboardSaved) error {
+	dashboard, err := s.sqlStore.GetDashboard(0, e.OrgID, e.UID, "")
+	if err != nil {
+		return err
+	}
+	return s.replaceDashboardUsage(ctx, e.OrgID, e.UID, dashboardPipelines(dashboard.Data))
+}
+
+// dashboardPipelines returns the UIDs of the query pipelines referenced by the panels of the dashboard, including
+// the panels of the collapsed rows.
+func dashboardPipelines(data *simplejson.Json) []string {
+	seen := map[string]bool{}
+	uids := make([]string, 0)
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for i := range panels {
+			panel := simplejson.NewFromAny(panels[i])
+			for _, target := range panel.Get("targets").MustArray() {
+				query := simplejson.NewFromAny(target)
+				// the queries without data source use the data source of the panel
+				datasource, ok := query.CheckGet("datasource")
+				if !ok {
+					datasource = panel.Get("datasource")
+				}
+				if datasource.Get("uid").MustString() != DatasourceUID {
+					continue
+				}
+				if uid := query.Get("pipelineUid").MustString(); uid != "" && !seen[uid] {
+					seen[uid] = true
+					uids = append(uids, uid)
+				}
+			}
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+	walk(data.Get("panels").MustArray())
+	return uids
+}
+
+func validate(cmd *SavePipelineCommand) error {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if cmd.Name == "" || len(cmd.Name) > maxNameLength {
+		return fmt.Errorf("%w: the name must be 1 to %d characters", ErrInvalidPipeline, maxNameLength)
+	}
+	if !util.IsValidShortUID(cmd.Uid) || util.IsShortUIDTooLong(cmd.Uid) {
+		return fmt.Errorf("%w: invalid uid %q", ErrInvalidPipeline, cmd.Uid)
+	}
+	if cmd.Steps == nil || len(cmd.Steps.Queries) == 0 {
+		return fmt.Errorf("%w: at least one query is required", ErrInvalidPipeline)
+	}
+
+	refIDs := map[string]bool{}
+	for _, query := range cmd.Steps.Queries {
+		refID := query.Get("refId").MustString()
+		if refID == "" {
+			return fmt.Errorf("%w: the queries must have a refId", ErrInvalidPipeline)
+		}
+		if refIDs[refID] {
+			return fmt.Errorf("%w: duplicate refId %q", ErrInvalidPipeline, refID)
+		}
+		refIDs[refID] = true
+		if _, ok := PipelineUID(query); ok {
+			return fmt.Errorf("%w: query %q references another pipeline", ErrInvalidPipeline, refID)
+		}
+	}
+	for _, t := range cmd.Steps.Transformations {
+		if t == nil || t.Id == "" {
+			return fmt.Errorf("%w: the transformations must have an id", ErrInvalidPipeline)
+		}
+	}
+	return nil
+}
+
+func (s *Service) registerRoles() error {
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:querypipelines:writer",
+			DisplayName: "Query pipeline writer",
+			Description: "Create, update and delete the query pipelines of the organization.",
+			Group:       "Data sources",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionWrite},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(writer)
+}
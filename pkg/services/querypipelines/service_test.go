@@ -0,0 +1,189 @@
+package querypipelines
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Pipelines(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry(), bus.New())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, Login: "admin"}
+	steps := &Steps{
+		Queries: []*simplejson.Json{
+			simplejson.NewFromAny(map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}, "expr": "errors"}),
+			simplejson.NewFromAny(map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "prom"}, "expr": "requests"}),
+			simplejson.NewFromAny(map[string]interface{}{"refId": "C", "datasource": map[string]interface{}{"uid": "__expr__"}, "type": "math", "expression": "$A / $B"}),
+		},
+		Transformations: []*dtos.MetricRequestTransformation{{Id: "grafana-ratio-app/ratio"}},
+	}
+	pipelineRef := func(uid string) map[string]interface{} {
+		return map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": DatasourceUID}, "pipelineUid": uid}
+	}
+
+	t.Run("should create the pipelines of the organization", func(t *testing.T) {
+		pipeline, err := s.CreatePipeline(ctx, admin, SavePipelineCommand{Uid: "error-ratio", Name: " Error ratio ", Steps: steps})
+		require.NoError(t, err)
+		assert.Equal(t, "Error ratio", pipeline.Name)
+		assert.Equal(t, 1, pipeline.Version)
+
+		pipeline, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "Availability", Steps: steps})
+		require.NoError(t, err)
+		assert.NotEmpty(t, pipeline.Uid)
+
+		pipelines, err := s.GetPipelines(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, pipelines, 2)
+		assert.Equal(t, "Availability", pipelines[0].Name)
+		assert.Equal(t, "Error ratio", pipelines[1].Name)
+		require.Len(t, pipelines[1].Steps.Queries, 3)
+		assert.Equal(t, "$A / $B", pipelines[1].Steps.Queries[2].Get("expression").MustString())
+		assert.Equal(t, "grafana-ratio-app/ratio", pipelines[1].Steps.Transformations[0].Id)
+
+		pipelines, err = s.GetPipelines(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, pipelines)
+	})
+
+	t.Run("should refuse invalid and duplicated pipelines", func(t *testing.T) {
+		_, err := s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "Error ratio", Steps: steps})
+		require.ErrorIs(t, err, ErrPipelineExists)
+		_, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Uid: "error-ratio", Name: "Other", Steps: steps})
+		require.ErrorIs(t, err, ErrPipelineExists)
+		_, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "", Steps: steps})
+		require.ErrorIs(t, err, ErrInvalidPipeline)
+		_, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "Empty", Steps: &Steps{}})
+		require.ErrorIs(t, err, ErrInvalidPipeline)
+
+		duplicated := &Steps{Queries: []*simplejson.Json{steps.Queries[0], steps.Queries[0]}}
+		_, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "Duplicated", Steps: duplicated})
+		require.ErrorIs(t, err, ErrInvalidPipeline)
+
+		nested := &Steps{Queries: []*simplejson.Json{simplejson.NewFromAny(pipelineRef("error-ratio"))}}
+		_, err = s.CreatePipeline(ctx, admin, SavePipelineCommand{Name: "Nested", Steps: nested})
+		require.ErrorIs(t, err, ErrInvalidPipeline)
+	})
+
+	t.Run("should update the pipeline of the current version", func(t *testing.T) {
+		updated := &Steps{Queries: steps.Queries[:1]}
+		pipeline, err := s.UpdatePipeline(ctx, admin, "error-ratio", SavePipelineCommand{Name: "Errors", Steps: updated, Version: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, pipeline.Version)
+
+		_, err = s.UpdatePipeline(ctx, admin, "error-ratio", SavePipelineCommand{Name: "Errors", Steps: updated, Version: 1})
+		require.ErrorIs(t, err, ErrPipelineVersionMismatch)
+		_, err = s.UpdatePipeline(ctx, admin, "error-ratio", SavePipelineCommand{Name: "Availability", Steps: updated, Version: 2})
+		require.ErrorIs(t, err, ErrPipelineExists)
+		_, err = s.UpdatePipeline(ctx, admin, "missing", SavePipelineCommand{Name: "Missing", Steps: updated})
+		require.ErrorIs(t, err, ErrPipelineNotFound)
+
+		pipeline, err = s.GetCachedPipeline(ctx, 1, "error-ratio")
+		require.NoError(t, err)
+		assert.Equal(t, "Errors", pipeline.Name)
+		assert.Len(t, pipeline.Steps.Queries, 1)
+	})
+
+	t.Run("should track the dashboards and the alert rules using the pipeline", func(t *testing.T) {
+		dashboard, err := store.SaveDashboard(models.SaveDashboardCommand{
+			OrgId: 1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title": "Service",
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "targets": []interface{}{pipelineRef("error-ratio")}},
+					map[string]interface{}{"id": 2, "type": "row", "panels": []interface{}{
+						map[string]interface{}{
+							"id":         3,
+							"datasource": map[string]interface{}{"uid": DatasourceUID},
+							"targets":    []interface{}{map[string]interface{}{"refId": "A", "pipelineUid": "error-ratio"}},
+						},
+					}},
+				},
+			}),
+		})
+		require.NoError(t, err)
+		err = s.onDashboardSaved(ctx, &events.DashboardSaved{OrgID: 1, UID: dashboard.Uid})
+		require.NoError(t, err)
+
+		err = store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&ngmodels.AlertRule{
+				OrgID: 1,
+				Title: "High error ratio",
+				UID:   "high-error-ratio",
+				Data: []ngmodels.AlertQuery{{
+					RefID:         "A",
+					DatasourceUID: DatasourceUID,
+					Model:         json.RawMessage(`{"refId": "A", "pipelineUid": "error-ratio"}`),
+				}},
+				NamespaceUID: "folder",
+				RuleGroup:    "group",
+				Updated:      now,
+			})
+			return err
+		})
+		require.NoError(t, err)
+
+		usage, err := s.GetUsage(ctx, 1, "error-ratio")
+		require.NoError(t, err)
+		assert.Equal(t, []UsageRef{{Uid: dashboard.Uid, Title: "Service"}}, usage.Dashboards)
+		assert.Equal(t, []UsageRef{{Uid: "high-error-ratio", Title: "High error ratio"}}, usage.AlertRules)
+
+		err = s.DeletePipeline(ctx, 1, "error-ratio", false)
+		require.ErrorIs(t, err, ErrPipelineInUse)
+	})
+
+	t.Run("should forget the dashboards which no longer use the pipeline", func(t *testing.T) {
+		dashboard, err := store.SaveDashboard(models.SaveDashboardCommand{
+			OrgId: 1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title":  "Other",
+				"panels": []interface{}{map[string]interface{}{"id": 1, "targets": []interface{}{pipelineRef("error-ratio")}}},
+			}),
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.onDashboardSaved(ctx, &events.DashboardSaved{OrgID: 1, UID: dashboard.Uid}))
+
+		dashboard.Data.Set("id", dashboard.Id)
+		dashboard.Data.Set("panels", []interface{}{})
+		_, err = store.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Dashboard: dashboard.Data, Overwrite: true})
+		require.NoError(t, err)
+		require.NoError(t, s.onDashboardSaved(ctx, &events.DashboardSaved{OrgID: 1, UID: dashboard.Uid}))
+
+		usage, err := s.GetUsage(ctx, 1, "error-ratio")
+		require.NoError(t, err)
+		require.Len(t, usage.Dashboards, 1)
+		assert.Equal(t, "Service", usage.Dashboards[0].Title)
+	})
+
+	t.Run("should delete the pipelines", func(t *testing.T) {
+		pipelines, err := s.GetPipelines(ctx, 1)
+		require.NoError(t, err)
+		for _, pipeline := range pipelines {
+			require.NoError(t, s.DeletePipeline(ctx, 1, pipeline.Uid, true))
+		}
+
+		_, err = s.GetCachedPipeline(ctx, 1, "error-ratio")
+		require.ErrorIs(t, err, ErrPipelineNotFound)
+		err = s.DeletePipeline(ctx, 1, "error-ratio", true)
+		require.ErrorIs(t, err, ErrPipelineNotFound)
+	})
+}
@@ -0,0 +1,155 @@
+package querypipelines
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getPipelines(ctx context.Context, orgID int64) ([]*Pipeline, error) {
+	pipelines := make([]*Pipeline, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&pipelines)
+	})
+	return pipelines, err
+}
+
+func (s *Service) getPipeline(ctx context.Context, orgID int64, uid string) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return getPipeline(sess, orgID, uid, pipeline)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+func getPipeline(sess *sqlstore.DBSession, orgID int64, uid string, pipeline *Pipeline) error {
+	exists, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(pipeline)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrPipelineNotFound
+	}
+	return nil
+}
+
+func (s *Service) insertPipeline(ctx context.Context, pipeline *Pipeline) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND (uid = ? OR name = ?)", pipeline.OrgId, pipeline.Uid, pipeline.Name).Exist(&Pipeline{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrPipelineExists
+		}
+		_, err = sess.Insert(pipeline)
+		return err
+	})
+}
+
+// updatePipeline applies the update to the pipeline when the version of the command is its current version
+func (s *Service) updatePipeline(ctx context.Context, orgID int64, cmd SavePipelineCommand, update func(*Pipeline)) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := getPipeline(sess, orgID, cmd.Uid, pipeline); err != nil {
+			return err
+		}
+		if pipeline.Version != cmd.Version {
+			return ErrPipelineVersionMismatch
+		}
+		exists, err := sess.Where("org_id = ? AND name = ? AND uid <> ?", orgID, cmd.Name, cmd.Uid).Exist(&Pipeline{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrPipelineExists
+		}
+
+		update(pipeline)
+		affected, err := sess.ID(pipeline.Id).Where("version = ?", cmd.Version).AllCols().Update(pipeline)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrPipelineVersionMismatch
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// deletePipeline deletes the pipeline and its recorded usage
+func (s *Service) deletePipeline(ctx context.Context, orgID int64, uid string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Delete(&Pipeline{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrPipelineNotFound
+		}
+		_, err = sess.Where("org_id = ? AND pipeline_uid = ?", orgID, uid).Delete(&DashboardUsage{})
+		return err
+	})
+}
+
+// replaceDashboardUsage replaces the pipelines recorded as referenced by the dashboard
+func (s *Service) replaceDashboardUsage(ctx context.Context, orgID int64, dashboardUID string, pipelineUIDs []string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Where("org_id = ? AND dashboard_uid = ?", orgID, dashboardUID).Delete(&DashboardUsage{}); err != nil {
+			return err
+		}
+		if len(pipelineUIDs) == 0 {
+			return nil
+		}
+		usage := make([]*DashboardUsage, 0, len(pipelineUIDs))
+		for _, uid := range pipelineUIDs {
+			usage = append(usage, &DashboardUsage{OrgId: orgID, PipelineUid: uid, DashboardUid: dashboardUID})
+		}
+		_, err := sess.InsertBatch("query_pipeline_dashboard", usage)
+		return err
+	})
+}
+
+// getUsage returns the existing dashboards recorded as referencing the pipeline, and the alert rules whose queries
+// reference the pipeline.
+func (s *Service) getUsage(ctx context.Context, orgID int64, uid string) (*Usage, error) {
+	usage := &Usage{Dashboards: make([]UsageRef, 0), AlertRules: make([]UsageRef, 0)}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// the usage of the deleted dashboards is left behind, they are skipped by the join
+		err := sess.SQL(`SELECT d.uid, d.title FROM query_pipeline_dashboard u
+			INNER JOIN dashboard d ON d.org_id = u.org_id AND d.uid = u.dashboard_uid
+			WHERE u.org_id = ? AND u.pipeline_uid = ? ORDER BY d.title`, orgID, uid).Find(&usage.Dashboards)
+		if err != nil {
+			return err
+		}
+
+		// the queries of the alert rules are stored as JSON in the data column, the LIKE pattern only narrows the
+		// rules down since the UID may contain the _ wildcard
+		reference := `"pipelineUid":"` + uid + `"`
+		rules := make([]struct {
+			Uid   string
+			Title string
+			Data  string
+		}, 0)
+		err = sess.SQL(`SELECT uid, title, data FROM alert_rule WHERE org_id = ? AND data LIKE ? ORDER BY title`,
+			orgID, "%"+reference+"%").Find(&rules)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if strings.Contains(rule.Data, reference) {
+				usage.AlertRules = append(usage.AlertRules, UsageRef{Uid: rule.Uid, Title: rule.Title})
+			}
+		}
+		return nil
+	})
+	return usage, err
+}
@@ -2,8 +2,10 @@ package quota
 
 import (
 	"errors"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -92,6 +94,7 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 			if query.Result.Used >= query.Result.Limit {
 				return true, nil
 			}
+			qs.warnNearLimit(c, scope.Target, query.Result.Used, query.Result.Limit)
 		case "user":
 			if !c.IsSignedIn || c.UserId == 0 {
 				continue
@@ -116,6 +119,27 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 	return false, nil
 }
 
+// warnNearLimit publishes a QuotaWarning event when the resource about to be created brings the usage of the
+// organization quota to the warning threshold or above it, so that the organization administrators are warned before
+// the quota is enforced
+func (qs *QuotaService) warnNearLimit(c *models.ReqContext, target string, used int64, limit int64) {
+	threshold := qs.Cfg.Quota.WarningThreshold
+	if threshold <= 0 || (used+1)*100 < limit*threshold {
+		return
+	}
+
+	c.Logger.Debug("Quota warning threshold reached", "target", target, "used", used+1, "limit", limit)
+	if err := bus.Publish(c.Req.Context(), &events.QuotaWarning{
+		Timestamp: time.Now(),
+		OrgID:     c.OrgId,
+		Target:    target,
+		Used:      used + 1,
+		Limit:     limit,
+	}); err != nil {
+		c.Logger.Warn("Failed to publish the quota warning", "target", target, "error", err)
+	}
+}
+
 func (qs *QuotaService) getQuotaScopes(target string) ([]models.QuotaScope, error) {
 	scopes := make([]models.QuotaScope, 0)
 	switch target {
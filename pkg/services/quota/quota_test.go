@@ -0,0 +1,81 @@
+package quota
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestQuotaService_Warnings(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	cfg := setting.NewCfg()
+	cfg.Quota = setting.QuotaSettings{
+		Enabled:          true,
+		Org:              &setting.OrgQuota{Dashboard: 10},
+		Global:           &setting.GlobalQuota{Dashboard: -1},
+		WarningThreshold: 80,
+	}
+	qs := ProvideService(cfg, nil)
+
+	used := int64(0)
+	bus.AddHandler("test", func(_ context.Context, query *models.GetOrgQuotaByTargetQuery) error {
+		query.Result = &models.OrgQuotaDTO{OrgId: query.OrgId, Target: query.Target, Limit: query.Default, Used: used}
+		return nil
+	})
+	var warnings []*events.QuotaWarning
+	bus.AddEventListener(func(_ context.Context, e *events.QuotaWarning) error {
+		warnings = append(warnings, e)
+		return nil
+	})
+
+	c := &models.ReqContext{
+		Context:      &web.Context{Req: httptest.NewRequest("POST", "/api/dashboards/db", nil)},
+		SignedInUser: &models.SignedInUser{OrgId: 2},
+		IsSignedIn:   true,
+		Logger:       log.New("test"),
+	}
+	check := func(t *testing.T, usage int64) bool {
+		t.Helper()
+		used = usage
+		reached, err := qs.QuotaReached(c, "dashboard")
+		require.NoError(t, err)
+		return reached
+	}
+
+	t.Run("should not warn below the threshold", func(t *testing.T) {
+		assert.False(t, check(t, 6))
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("should warn when the new resource reaches the threshold", func(t *testing.T) {
+		assert.False(t, check(t, 7))
+		require.Len(t, warnings, 1)
+		assert.Equal(t, int64(2), warnings[0].OrgID)
+		assert.Equal(t, "dashboard", warnings[0].Target)
+		assert.Equal(t, int64(8), warnings[0].Used)
+		assert.Equal(t, int64(10), warnings[0].Limit)
+	})
+
+	t.Run("should not warn when the quota is reached", func(t *testing.T) {
+		assert.True(t, check(t, 10))
+		assert.Len(t, warnings, 1)
+	})
+
+	t.Run("should not warn when the warnings are disabled", func(t *testing.T) {
+		cfg.Quota.WarningThreshold = 0
+		assert.False(t, check(t, 9))
+		assert.Len(t, warnings, 1)
+	})
+}
@@ -0,0 +1,82 @@
+package quotawarnings
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+)
+
+const (
+	SignatureHeader = "X-Grafana-Signature"
+	EventHeader     = "X-Grafana-Event"
+	EventType       = "quota_warning"
+)
+
+// Payload is the JSON body of the webhooks.
+type Payload struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"orgId"`
+	Target    string    `json:"target"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+}
+
+// post sends the warning to the webhook url, signed with the secret key. A failed delivery is not retried, the
+// warning is sent again after the warning interval while the usage stays above the threshold.
+func (s *Service) post(ctx context.Context, url string, e *events.QuotaWarning) error {
+	body, err := json.Marshal(Payload{
+		Type:      EventType,
+		Timestamp: e.Timestamp,
+		OrgID:     e.OrgID,
+		Target:    e.Target,
+		Used:      e.Used,
+		Limit:     e.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set(SignatureHeader, "sha256="+sign(body, s.cfg.SecretKey))
+	req.Header.Set(EventHeader, EventType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the payload, so that the receivers holding the secret key can verify
+// the webhook was sent by Grafana
+func sign(data []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
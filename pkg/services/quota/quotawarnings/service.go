@@ -0,0 +1,165 @@
+// Package quotawarnings warns the organization administrators when the usage of an organization quota reaches the
+// warning threshold, so that they are not surprised when the quota is enforced.
+package quotawarnings
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	tmplQuotaWarning = "quota_warning"
+	kvNamespace      = "quota_warnings"
+	queueSize        = 100
+)
+
+var errQueueFull = errors.New("quota warnings queue is full")
+
+// resourceNames are the names of the quota targets in the warnings
+var resourceNames = map[string]string{
+	"org_user":    "users",
+	"dashboard":   "dashboards",
+	"data_source": "data sources",
+	"api_key":     "API keys",
+	"alert_rule":  "alert rules",
+}
+
+// Service listens to the QuotaWarning events published by the quota service, and emails the administrators of the
+// organization and posts the warning to the webhook URLs. The warning of a quota is sent at most once per warning
+// interval.
+type Service struct {
+	cfg     *setting.Cfg
+	kvStore kvstore.KVStore
+	client  *http.Client
+	queue   chan *events.QuotaWarning
+	log     log.Logger
+	now     func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, b bus.Bus, kvStore kvstore.KVStore) *Service {
+	s := &Service{
+		cfg:     cfg,
+		kvStore: kvStore,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan *events.QuotaWarning, queueSize),
+		log:     log.New("quotawarnings"),
+		now:     time.Now,
+	}
+	b.AddEventListener(s.onQuotaWarning)
+	return s
+}
+
+// Run sends the queued warnings until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		select {
+		case warning := <-s.queue:
+			s.notify(ctx, warning)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// onQuotaWarning queues the warning unless it was sent less than a warning interval ago. It never blocks the
+// request creating the resource, the warning is dropped when too many are pending.
+func (s *Service) onQuotaWarning(ctx context.Context, e *events.QuotaWarning) error {
+	due, err := s.due(ctx, e)
+	if err != nil {
+		s.log.Error("Failed to check the last quota warning", "orgId", e.OrgID, "target", e.Target, "error", err)
+		return nil
+	}
+	if !due {
+		return nil
+	}
+
+	select {
+	case s.queue <- e:
+	default:
+		s.log.Error("Dropping quota warning", "orgId", e.OrgID, "target", e.Target, "error", errQueueFull)
+	}
+	return nil
+}
+
+// due records the time of the warning and returns true when the previous warning of the quota is older than the
+// warning interval
+func (s *Service) due(ctx context.Context, e *events.QuotaWarning) (bool, error) {
+	store := kvstore.WithNamespace(s.kvStore, e.OrgID, kvNamespace)
+	last, ok, err := store.Get(ctx, e.Target)
+	if err != nil {
+		return false, err
+	}
+	now := s.now()
+	if ok {
+		if lastWarning, err := time.Parse(time.RFC3339, last); err == nil && now.Sub(lastWarning) < s.cfg.Quota.WarningInterval {
+			return false, nil
+		}
+	}
+	return true, store.Set(ctx, e.Target, now.UTC().Format(time.RFC3339))
+}
+
+func (s *Service) notify(ctx context.Context, e *events.QuotaWarning) {
+	if err := s.email(ctx, e); err != nil {
+		s.log.Error("Failed to email the quota warning", "orgId", e.OrgID, "target", e.Target, "error", err)
+	}
+	for _, url := range s.cfg.Quota.WarningWebhookURLs {
+		if err := s.post(ctx, url, e); err != nil {
+			s.log.Error("Failed to post the quota warning", "url", url, "orgId", e.OrgID, "target", e.Target, "error", err)
+		}
+	}
+}
+
+// email sends the warning to the administrators of the organization
+func (s *Service) email(ctx context.Context, e *events.QuotaWarning) error {
+	if !s.cfg.Smtp.Enabled {
+		s.log.Debug("SMTP is disabled, the organization administrators are not emailed the quota warning", "orgId", e.OrgID)
+		return nil
+	}
+
+	org := &models.GetOrgByIdQuery{Id: e.OrgID}
+	if err := bus.Dispatch(ctx, org); err != nil {
+		return err
+	}
+	users := &models.GetOrgUsersQuery{OrgId: e.OrgID}
+	if err := bus.Dispatch(ctx, users); err != nil {
+		return err
+	}
+	to := make([]string, 0)
+	for _, user := range users.Result {
+		if user.Role == string(models.ROLE_ADMIN) && user.Email != "" {
+			to = append(to, user.Email)
+		}
+	}
+	if len(to) == 0 {
+		s.log.Debug("No organization administrator to email the quota warning", "orgId", e.OrgID)
+		return nil
+	}
+
+	return bus.Dispatch(ctx, &models.SendEmailCommand{
+		To:       to,
+		Template: tmplQuotaWarning,
+		Data: map[string]interface{}{
+			"OrgName":  org.Result.Name,
+			"Resource": resourceName(e.Target),
+			"Used":     e.Used,
+			"Limit":    e.Limit,
+			"Percent":  e.Used * 100 / e.Limit,
+		},
+	})
+}
+
+func resourceName(target string) string {
+	if name, ok := resourceNames[target]; ok {
+		return name
+	}
+	return target
+}
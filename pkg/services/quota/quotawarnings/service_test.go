@@ -0,0 +1,93 @@
+package quotawarnings
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_QuotaWarnings(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+
+	var payloads []Payload
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, EventType, r.Header.Get(EventHeader))
+		assert.Equal(t, "sha256="+sign(body, "secret"), r.Header.Get(SignatureHeader))
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payloads = append(payloads, payload)
+	}))
+	t.Cleanup(webhook.Close)
+
+	cfg := setting.NewCfg()
+	cfg.SecretKey = "secret"
+	cfg.Smtp.Enabled = true
+	cfg.Quota.WarningInterval = 24 * time.Hour
+	cfg.Quota.WarningWebhookURLs = []string{webhook.URL}
+	b := bus.New()
+	s := ProvideService(cfg, b, kvstore.ProvideService(store))
+	now := time.Date(2022, 2, 14, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "admin", Email: "admin@example.org", OrgName: "Tenant"})
+	require.NoError(t, err)
+	_, err = store.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", Email: "viewer@example.org", OrgId: admin.OrgId, DefaultOrgRole: string(models.ROLE_VIEWER)})
+	require.NoError(t, err)
+
+	var emails []*models.SendEmailCommand
+	bus.AddHandler("test", func(_ context.Context, cmd *models.SendEmailCommand) error {
+		emails = append(emails, cmd)
+		return nil
+	})
+
+	warn := func(t *testing.T) {
+		t.Helper()
+		err := b.Publish(ctx, &events.QuotaWarning{Timestamp: now, OrgID: admin.OrgId, Target: "dashboard", Used: 8, Limit: 10})
+		require.NoError(t, err)
+	}
+
+	t.Run("should warn the organization administrators", func(t *testing.T) {
+		warn(t)
+		require.Len(t, s.queue, 1)
+		s.notify(ctx, <-s.queue)
+
+		require.Len(t, emails, 1)
+		assert.Equal(t, []string{"admin@example.org"}, emails[0].To)
+		assert.Equal(t, tmplQuotaWarning, emails[0].Template)
+		assert.Equal(t, "Tenant", emails[0].Data["OrgName"])
+		assert.Equal(t, "dashboards", emails[0].Data["Resource"])
+		assert.Equal(t, int64(80), emails[0].Data["Percent"])
+
+		require.Len(t, payloads, 1)
+		assert.Equal(t, Payload{Type: EventType, Timestamp: now, OrgID: admin.OrgId, Target: "dashboard", Used: 8, Limit: 10}, payloads[0])
+	})
+
+	t.Run("should not repeat the warning before the warning interval", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		warn(t)
+		assert.Empty(t, s.queue)
+	})
+
+	t.Run("should repeat the warning after the warning interval", func(t *testing.T) {
+		now = now.Add(24 * time.Hour)
+		warn(t)
+		assert.Len(t, s.queue, 1)
+	})
+}
@@ -73,4 +73,6 @@ type Service interface {
 	RenderCSV(ctx context.Context, opts CSVOpts) (*RenderCSVResult, error)
 	RenderErrorImage(theme Theme, error error) (*RenderResult, error)
 	GetRenderUser(ctx context.Context, key string) (*RenderUser, bool)
+	// QueueLength returns the number of renders and CSV exports in progress
+	QueueLength() int
 }
@@ -166,6 +166,10 @@ func (rs *RenderingService) Version() string {
 	return rs.version
 }
 
+func (rs *RenderingService) QueueLength() int {
+	return int(atomic.LoadInt32(&rs.inProgressCount))
+}
+
 func (rs *RenderingService) RenderErrorImage(theme Theme, err error) (*RenderResult, error) {
 	if theme == "" {
 		theme = ThemeDark
@@ -0,0 +1,85 @@
+package resourcelabels
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	readEvaluator := accesscontrol.EvalPermission(ActionRead, ScopeAll)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite, ScopeAll)
+
+	s.routeRegister.Group("/api/resource-labels", func(labelsRoute routing.RouteRegister) {
+		labelsRoute.Get("/:resourceType", auth(middleware.ReqSignedIn, readEvaluator), routing.Wrap(s.findResourcesHandler))
+		labelsRoute.Get("/:resourceType/:uid", auth(middleware.ReqSignedIn, readEvaluator), routing.Wrap(s.getLabelsHandler))
+		labelsRoute.Put("/:resourceType/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.setLabelsHandler))
+	})
+}
+
+// GET /api/resource-labels/:resourceType
+func (s *Service) findResourcesHandler(c *models.ReqContext) response.Response {
+	selector, err := ParseSelector(c.Query("labelSelector"))
+	if err != nil {
+		return errorResponse(err, "Failed to find resources")
+	}
+
+	resources, err := s.FindResources(c.Req.Context(), c.OrgId, ResourceType(web.Params(c.Req)[":resourceType"]), selector)
+	if err != nil {
+		return errorResponse(err, "Failed to find resources")
+	}
+	return response.JSON(http.StatusOK, resources)
+}
+
+// GET /api/resource-labels/:resourceType/:uid
+func (s *Service) getLabelsHandler(c *models.ReqContext) response.Response {
+	params := web.Params(c.Req)
+	metadata, err := s.GetLabels(c.Req.Context(), c.OrgId, ResourceType(params[":resourceType"]), params[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get labels")
+	}
+	return response.JSON(http.StatusOK, metadata)
+}
+
+// PUT /api/resource-labels/:resourceType/:uid
+func (s *Service) setLabelsHandler(c *models.ReqContext) response.Response {
+	cmd := SetLabelsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	params := web.Params(c.Req)
+	cmd.OrgID = c.OrgId
+	cmd.ResourceType = ResourceType(params[":resourceType"])
+	cmd.ResourceUID = params[":uid"]
+
+	metadata, err := s.SetLabels(c.Req.Context(), cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to set labels")
+	}
+	return response.JSON(http.StatusOK, metadata)
+}
+
+// SelectorErrorResponse returns the response of the list endpoints of the resources to the errors of their label
+// selector.
+func SelectorErrorResponse(err error) response.Response {
+	return errorResponse(err, "Failed to match the label selector")
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrResourceNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidResourceType), errors.Is(err, ErrInvalidLabel), errors.Is(err, ErrInvalidSelector):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,71 @@
+package resourcelabels
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match are the resources matching a label selector. It holds the resources matching the selector when it
+// requires the resources to have labels, and otherwise the labelled resources not matching it.
+type Match struct {
+	uids    map[string]bool
+	include bool
+}
+
+// Matches returns true when the resource matches the selector
+func (m *Match) Matches(uid string) bool {
+	return m.uids[uid] == m.include
+}
+
+// Filter returns a search filter matching the rows whose uid column matches the selector
+func (m *Match) Filter(column string) Filter {
+	values := make([]interface{}, 0, len(m.uids))
+	for _, uid := range m.sortedUIDs() {
+		values = append(values, uid)
+	}
+	return Filter{column: column, values: values, include: m.include}
+}
+
+// IDFilter returns a search filter matching the rows whose id column matches the selector, for the resources
+// identified by their id such as the teams
+func (m *Match) IDFilter(column string) Filter {
+	values := make([]interface{}, 0, len(m.uids))
+	for _, uid := range m.sortedUIDs() {
+		if id, err := strconv.ParseInt(uid, 10, 64); err == nil {
+			values = append(values, id)
+		}
+	}
+	return Filter{column: column, values: values, include: m.include}
+}
+
+func (m *Match) sortedUIDs() []string {
+	uids := make([]string, 0, len(m.uids))
+	for uid := range m.uids {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// Filter is a search filter matching the resources selected by labels, see searchstore.FilterWhere
+type Filter struct {
+	column  string
+	values  []interface{}
+	include bool
+}
+
+func (f Filter) Where() (string, []interface{}) {
+	if len(f.values) == 0 {
+		if f.include {
+			return "1 = 0", nil
+		}
+		return "1 = 1", nil
+	}
+
+	operator := " IN "
+	if !f.include {
+		operator = " NOT IN "
+	}
+	return f.column + operator + "(?" + strings.Repeat(",?", len(f.values)-1) + ")", f.values
+}
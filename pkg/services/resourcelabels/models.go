@@ -0,0 +1,79 @@
+package resourcelabels
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidResourceType = errors.New("invalid resource type")
+	ErrResourceNotFound    = errors.New("resource not found")
+	ErrInvalidLabel        = errors.New("invalid label")
+	ErrInvalidSelector     = errors.New("invalid label selector")
+)
+
+const (
+	ActionRead  = "resourcelabels:read"
+	ActionWrite = "resourcelabels:write"
+
+	ScopeAll = "resourcelabels:*"
+
+	// MaxLabels is the maximum number of labels, and of annotations, of a resource
+	MaxLabels = 64
+	// maxAnnotationValueLength is the maximum length of the values of the annotations, the values of the labels are
+	// limited to the length of their names
+	maxAnnotationValueLength = 4096
+)
+
+type ResourceType string
+
+const (
+	ResourceTypeDashboard  ResourceType = "dashboard"
+	ResourceTypeFolder     ResourceType = "folder"
+	ResourceTypeDataSource ResourceType = "datasource"
+	ResourceTypeAlertRule  ResourceType = "alert_rule"
+	// ResourceTypeTeam resources are identified by the id of the teams, which have no uid
+	ResourceTypeTeam ResourceType = "team"
+)
+
+var ResourceTypes = []ResourceType{ResourceTypeDashboard, ResourceTypeFolder, ResourceTypeDataSource, ResourceTypeAlertRule, ResourceTypeTeam}
+
+func (t ResourceType) IsValid() bool {
+	for _, rt := range ResourceTypes {
+		if t == rt {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels are key/value pairs attached to a resource
+type Labels map[string]string
+
+// ResourceLabel is a label or an annotation of a resource. Labels identify the resources and are matched by the
+// label selectors, annotations hold any other metadata and are not selectable.
+type ResourceLabel struct {
+	Id           int64
+	OrgId        int64
+	ResourceType ResourceType
+	ResourceUID  string `xorm:"resource_uid"`
+	// Name is the key of the label
+	Name       string
+	Value      string
+	Annotation bool
+}
+
+// ResourceMetadata are the labels and the annotations of a resource
+type ResourceMetadata struct {
+	ResourceType ResourceType `json:"resourceType"`
+	ResourceUID  string       `json:"resourceUid"`
+	Labels       Labels       `json:"labels"`
+	Annotations  Labels       `json:"annotations"`
+}
+
+type SetLabelsCommand struct {
+	OrgID        int64        `json:"-"`
+	ResourceType ResourceType `json:"-"`
+	ResourceUID  string       `json:"-"`
+	Labels       Labels       `json:"labels"`
+	Annotations  Labels       `json:"annotations"`
+}
@@ -0,0 +1,203 @@
+package resourcelabels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Operator is the operator of a requirement of a label selector
+type Operator string
+
+const (
+	OperatorEquals       Operator = "="
+	OperatorNotEquals    Operator = "!="
+	OperatorIn           Operator = "in"
+	OperatorNotIn        Operator = "notin"
+	OperatorExists       Operator = "exists"
+	OperatorDoesNotExist Operator = "!"
+)
+
+// Requirement is a condition on a label of the resources
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Selector selects the resources whose labels match all of its requirements. The resources without a label match
+// the != and notin requirements on the label, as with Kubernetes.
+type Selector []Requirement
+
+var (
+	labelNameRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	labelPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	setRequirement    = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// ParseSelector parses a label selector with the syntax of Kubernetes, made of comma separated requirements:
+// env=prod, env!=prod, env in (prod,staging), env notin (dev), env (the label exists) and !env (it does not exist).
+func ParseSelector(selector string) (Selector, error) {
+	var requirements Selector
+	for _, term := range splitRequirements(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("%w: empty requirement in %q", ErrInvalidSelector, selector)
+		}
+
+		var r Requirement
+		switch {
+		case setRequirement.MatchString(term):
+			match := setRequirement.FindStringSubmatch(term)
+			r = Requirement{Key: match[1], Operator: Operator(match[2])}
+			for _, value := range strings.Split(match[3], ",") {
+				r.Values = append(r.Values, strings.TrimSpace(value))
+			}
+		case strings.HasPrefix(term, "!"):
+			r = Requirement{Key: strings.TrimSpace(term[1:]), Operator: OperatorDoesNotExist}
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			r = Requirement{Key: strings.TrimSpace(parts[0]), Operator: OperatorNotEquals, Values: []string{strings.TrimSpace(parts[1])}}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(strings.Replace(term, "==", "=", 1), "=", 2)
+			r = Requirement{Key: strings.TrimSpace(parts[0]), Operator: OperatorEquals, Values: []string{strings.TrimSpace(parts[1])}}
+		default:
+			r = Requirement{Key: term, Operator: OperatorExists}
+		}
+
+		if err := validateLabelKey(r.Key); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSelector, err)
+		}
+		for _, value := range r.Values {
+			if err := validateLabelValue(value); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidSelector, err)
+			}
+		}
+		requirements = append(requirements, r)
+	}
+	return requirements, nil
+}
+
+// splitRequirements splits a selector on the commas which are not in the values of a set requirement
+func splitRequirements(selector string) []string {
+	if strings.TrimSpace(selector) == "" {
+		return nil
+	}
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+// Matches returns true when the labels match all the requirements of the selector
+func (s Selector) Matches(labels Labels) bool {
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Requirement) matches(labels Labels) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case OperatorExists:
+		return exists
+	case OperatorDoesNotExist:
+		return !exists
+	case OperatorEquals, OperatorIn:
+		return exists && contains(r.Values, value)
+	case OperatorNotEquals, OperatorNotIn:
+		return !exists || !contains(r.Values, value)
+	}
+	return false
+}
+
+// requiresLabels returns true when the resources must have a label to match the selector. The resources matching
+// the other selectors are the ones without any of their labels.
+func (s Selector) requiresLabels() bool {
+	for _, r := range s {
+		switch r.Operator {
+		case OperatorExists, OperatorEquals, OperatorIn:
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the keys of the labels of the requirements
+func (s Selector) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for _, r := range s {
+		if !contains(keys, r.Key) {
+			keys = append(keys, r.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, r := range s {
+		switch r.Operator {
+		case OperatorExists:
+			terms = append(terms, r.Key)
+		case OperatorDoesNotExist:
+			terms = append(terms, "!"+r.Key)
+		case OperatorIn, OperatorNotIn:
+			terms = append(terms, fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ",")))
+		default:
+			terms = append(terms, r.Key+string(r.Operator)+r.Values[0])
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// validateLabelKey checks that a key is a name of at most 63 characters, optionally prefixed by a DNS subdomain
+// and a slash such as example.com/env
+func validateLabelKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) > 253 || !labelPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("invalid prefix of label key %q", key)
+		}
+	}
+	if len(name) > 63 || !labelNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid label key %q: it must be at most 63 alphanumeric characters, '-', '_' or '.'", key)
+	}
+	return nil
+}
+
+// validateLabelValue checks that a value is empty or a name of at most 63 characters
+func validateLabelValue(value string) error {
+	if value != "" && (len(value) > 63 || !labelNameRegexp.MatchString(value)) {
+		return fmt.Errorf("invalid label value %q: it must be empty or at most 63 alphanumeric characters, '-', '_' or '.'", value)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
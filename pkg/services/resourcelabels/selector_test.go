@@ -0,0 +1,56 @@
+package resourcelabels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	selector, err := ParseSelector("env=prod, tier != frontend,team in (a, b),region notin (eu),example.com/owner,!deprecated")
+	require.NoError(t, err)
+	assert.Equal(t, Selector{
+		{Key: "env", Operator: OperatorEquals, Values: []string{"prod"}},
+		{Key: "tier", Operator: OperatorNotEquals, Values: []string{"frontend"}},
+		{Key: "team", Operator: OperatorIn, Values: []string{"a", "b"}},
+		{Key: "region", Operator: OperatorNotIn, Values: []string{"eu"}},
+		{Key: "example.com/owner", Operator: OperatorExists},
+		{Key: "deprecated", Operator: OperatorDoesNotExist},
+	}, selector)
+	assert.Equal(t, "env=prod,tier!=frontend,team in (a,b),region notin (eu),example.com/owner,!deprecated", selector.String())
+
+	selector, err = ParseSelector("env==prod")
+	require.NoError(t, err)
+	assert.Equal(t, Selector{{Key: "env", Operator: OperatorEquals, Values: []string{"prod"}}}, selector)
+
+	for _, invalid := range []string{"env=prod,", "env=pr od", "-env", "env in (a,b c)", "Example.com/env=prod"} {
+		_, err := ParseSelector(invalid)
+		assert.ErrorIs(t, err, ErrInvalidSelector, invalid)
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := Labels{"env": "prod", "team": "a"}
+
+	tests := map[string]bool{
+		"env=prod":             true,
+		"env=dev":              false,
+		"env!=dev":             true,
+		"tier!=frontend":       true,
+		"team in (a,b)":        true,
+		"team notin (a,b)":     false,
+		"region notin (eu)":    true,
+		"env":                  true,
+		"region":               false,
+		"!region":              true,
+		"!env":                 false,
+		"env=prod,team=b":      false,
+		"env=prod,team in (a)": true,
+	}
+	for s, expected := range tests {
+		selector, err := ParseSelector(s)
+		require.NoError(t, err)
+		assert.Equal(t, expected, selector.Matches(labels), s)
+	}
+}
@@ -0,0 +1,150 @@
+package resourcelabels
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Service keeps the labels and annotations of dashboards, folders, data sources, alert rules and teams. The list
+// endpoints of these resources filter them with label selectors.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	log           log.Logger
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister) (*Service, error) {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		log:           log.New("resourcelabels"),
+	}
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s, nil
+}
+
+// GetLabels returns the labels and the annotations of a resource.
+func (s *Service) GetLabels(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) (*ResourceMetadata, error) {
+	if !resourceType.IsValid() {
+		return nil, ErrInvalidResourceType
+	}
+	return s.getLabels(ctx, orgID, resourceType, resourceUID)
+}
+
+// SetLabels replaces the labels and the annotations of a resource.
+func (s *Service) SetLabels(ctx context.Context, cmd SetLabelsCommand) (*ResourceMetadata, error) {
+	if !cmd.ResourceType.IsValid() {
+		return nil, ErrInvalidResourceType
+	}
+	if err := validateLabels(cmd.Labels, cmd.Annotations); err != nil {
+		return nil, err
+	}
+	return s.setLabels(ctx, cmd)
+}
+
+// FindResources returns the labelled resources of a type matching a selector, with their labels and annotations.
+func (s *Service) FindResources(ctx context.Context, orgID int64, resourceType ResourceType, selector Selector) ([]ResourceMetadata, error) {
+	if !resourceType.IsValid() {
+		return nil, ErrInvalidResourceType
+	}
+	return s.findResources(ctx, orgID, resourceType, selector)
+}
+
+// Match returns the resources of the types matching a selector, to filter the lists of resources.
+func (s *Service) Match(ctx context.Context, orgID int64, selector Selector, resourceTypes ...ResourceType) (*Match, error) {
+	for _, rt := range resourceTypes {
+		if !rt.IsValid() {
+			return nil, ErrInvalidResourceType
+		}
+	}
+
+	labels, err := s.getSelectedLabels(ctx, orgID, selector, resourceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Match{uids: map[string]bool{}, include: selector.requiresLabels()}
+	for uid, resourceLabels := range labels {
+		if selector.Matches(resourceLabels) == m.include {
+			m.uids[uid] = true
+		}
+	}
+	return m, nil
+}
+
+// MatchSelector parses a selector and returns the resources of the types matching it, it returns nil when the
+// selector is empty.
+func (s *Service) MatchSelector(ctx context.Context, orgID int64, selector string, resourceTypes ...ResourceType) (*Match, error) {
+	parsed, err := ParseSelector(selector)
+	if err != nil || len(parsed) == 0 {
+		return nil, err
+	}
+	return s.Match(ctx, orgID, parsed, resourceTypes...)
+}
+
+func validateLabels(labels, annotations Labels) error {
+	if len(labels) > MaxLabels || len(annotations) > MaxLabels {
+		return fmt.Errorf("%w: a resource has at most %d labels and %d annotations", ErrInvalidLabel, MaxLabels, MaxLabels)
+	}
+	for key, value := range labels {
+		if err := validateLabelKey(key); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidLabel, err)
+		}
+		if err := validateLabelValue(value); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidLabel, err)
+		}
+	}
+	for key, value := range annotations {
+		if err := validateLabelKey(key); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidLabel, err)
+		}
+		if len(value) > maxAnnotationValueLength {
+			return fmt.Errorf("%w: the value of annotation %q is longer than %d characters", ErrInvalidLabel, key, maxAnnotationValueLength)
+		}
+	}
+	return nil
+}
+
+func (s *Service) registerRoles() error {
+	reader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:resourcelabels:reader",
+			DisplayName: "Resource labels reader",
+			Description: "Read the labels and annotations of dashboards, folders, data sources, alert rules and teams.",
+			Group:       "Resource labels",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionRead, Scope: ScopeAll},
+			},
+		},
+		Grants: []string{"Viewer"},
+	}
+
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:resourcelabels:writer",
+			DisplayName: "Resource labels writer",
+			Description: "Read and set the labels and annotations of dashboards, folders, data sources, alert rules and teams.",
+			Group:       "Resource labels",
+			Permissions: accesscontrol.ConcatPermissions(reader.Role.Permissions, []accesscontrol.Permission{
+				{Action: ActionWrite, Scope: ScopeAll},
+			}),
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(reader, writer)
+}
@@ -0,0 +1,167 @@
+package resourcelabels
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Labels(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s, err := ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister())
+	require.NoError(t, err)
+
+	saveDashboard := func(t *testing.T, uid string, isFolder bool) *models.Dashboard {
+		t.Helper()
+		dash, err := store.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			IsFolder:  isFolder,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": uid, "uid": uid}),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+	saveDashboard(t, "prod", false)
+	saveDashboard(t, "dev", false)
+	saveDashboard(t, "unlabelled", false)
+	saveDashboard(t, "folder", true)
+	teamA, err := store.CreateTeam("a", "", 1)
+	require.NoError(t, err)
+	teamB, err := store.CreateTeam("b", "", 1)
+	require.NoError(t, err)
+
+	setLabels := func(t *testing.T, resourceType ResourceType, uid string, labels Labels) {
+		t.Helper()
+		_, err := s.SetLabels(ctx, SetLabelsCommand{OrgID: 1, ResourceType: resourceType, ResourceUID: uid, Labels: labels})
+		require.NoError(t, err)
+	}
+
+	t.Run("should set and replace the labels and annotations", func(t *testing.T) {
+		_, err := s.SetLabels(ctx, SetLabelsCommand{
+			OrgID:        1,
+			ResourceType: ResourceTypeDashboard,
+			ResourceUID:  "prod",
+			Labels:       Labels{"env": "dev"},
+			Annotations:  Labels{"example.com/runbook": "https://example.com/runbooks/prod"},
+		})
+		require.NoError(t, err)
+		setLabels(t, ResourceTypeDashboard, "prod", Labels{"env": "prod", "team": "a"})
+
+		metadata, err := s.GetLabels(ctx, 1, ResourceTypeDashboard, "prod")
+		require.NoError(t, err)
+		assert.Equal(t, Labels{"env": "prod", "team": "a"}, metadata.Labels)
+		assert.Empty(t, metadata.Annotations)
+	})
+
+	t.Run("should refuse invalid labels and missing resources", func(t *testing.T) {
+		_, err := s.SetLabels(ctx, SetLabelsCommand{OrgID: 1, ResourceType: ResourceTypeDashboard, ResourceUID: "prod", Labels: Labels{"env": "pr od"}})
+		assert.ErrorIs(t, err, ErrInvalidLabel)
+		_, err = s.SetLabels(ctx, SetLabelsCommand{OrgID: 1, ResourceType: "panel", ResourceUID: "prod"})
+		assert.ErrorIs(t, err, ErrInvalidResourceType)
+		_, err = s.SetLabels(ctx, SetLabelsCommand{OrgID: 1, ResourceType: ResourceTypeFolder, ResourceUID: "prod"})
+		assert.ErrorIs(t, err, ErrResourceNotFound)
+		_, err = s.GetLabels(ctx, 2, ResourceTypeDashboard, "prod")
+		assert.ErrorIs(t, err, ErrResourceNotFound)
+	})
+
+	setLabels(t, ResourceTypeDashboard, "dev", Labels{"env": "dev", "team": "a"})
+	setLabels(t, ResourceTypeFolder, "folder", Labels{"env": "prod"})
+	setLabels(t, ResourceTypeTeam, strconv.FormatInt(teamA.Id, 10), Labels{"oncall": ""})
+
+	t.Run("should filter the dashboard search with label selectors", func(t *testing.T) {
+		findDashboards := func(t *testing.T, selector string) []string {
+			t.Helper()
+			match, err := s.MatchSelector(ctx, 1, selector, ResourceTypeDashboard, ResourceTypeFolder)
+			require.NoError(t, err)
+			query := &search.FindPersistedDashboardsQuery{
+				OrgId:        1,
+				SignedInUser: &models.SignedInUser{OrgId: 1, UserId: 1, OrgRole: models.ROLE_ADMIN},
+				Filters:      []interface{}{match.Filter("dashboard.uid")},
+			}
+			require.NoError(t, store.SearchDashboards(ctx, query))
+			uids := make([]string, 0, len(query.Result))
+			for _, hit := range query.Result {
+				uids = append(uids, hit.UID)
+			}
+			return uids
+		}
+
+		assert.Equal(t, []string{"folder", "prod"}, findDashboards(t, "env=prod"))
+		assert.Equal(t, []string{"dev", "prod"}, findDashboards(t, "team=a"))
+		assert.Equal(t, []string{"dev"}, findDashboards(t, "team,env notin (prod)"))
+		// the resources without the label match the negative requirements
+		assert.Equal(t, []string{"dev", "unlabelled"}, findDashboards(t, "env!=prod"))
+		assert.Equal(t, []string{"folder", "unlabelled"}, findDashboards(t, "!team"))
+		assert.Empty(t, findDashboards(t, "env=staging"))
+	})
+
+	t.Run("should filter the team search with label selectors", func(t *testing.T) {
+		findTeams := func(t *testing.T, selector string) []string {
+			t.Helper()
+			match, err := s.MatchSelector(ctx, 1, selector, ResourceTypeTeam)
+			require.NoError(t, err)
+			query := &models.SearchTeamsQuery{OrgId: 1, Filters: []interface{}{match.IDFilter("team.id")}}
+			require.NoError(t, sqlstore.SearchTeams(ctx, query))
+			names := make([]string, 0, len(query.Result.Teams))
+			for _, team := range query.Result.Teams {
+				names = append(names, team.Name)
+			}
+			assert.Equal(t, int64(len(names)), query.Result.TotalCount)
+			return names
+		}
+
+		assert.Equal(t, []string{teamA.Name}, findTeams(t, "oncall"))
+		assert.Equal(t, []string{teamB.Name}, findTeams(t, "!oncall"))
+	})
+
+	t.Run("should find the labelled resources of a type", func(t *testing.T) {
+		selector, err := ParseSelector("env")
+		require.NoError(t, err)
+		resources, err := s.FindResources(ctx, 1, ResourceTypeDashboard, selector)
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		assert.Equal(t, "dev", resources[0].ResourceUID)
+		assert.Equal(t, "prod", resources[1].ResourceUID)
+
+		// the labels of the deleted resources are not listed
+		require.NoError(t, sqlstore.DeleteDashboard(ctx, &models.DeleteDashboardCommand{OrgId: 1, Id: mustDashboardID(t, store, "dev")}))
+		resources, err = s.FindResources(ctx, 1, ResourceTypeDashboard, selector)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "prod", resources[0].ResourceUID)
+	})
+
+	t.Run("should match the resources filtered in memory", func(t *testing.T) {
+		match, err := s.MatchSelector(ctx, 1, "env=prod", ResourceTypeDashboard)
+		require.NoError(t, err)
+		assert.True(t, match.Matches("prod"))
+		assert.False(t, match.Matches("unlabelled"))
+
+		match, err = s.MatchSelector(ctx, 1, "", ResourceTypeDashboard)
+		require.NoError(t, err)
+		assert.Nil(t, match)
+
+		_, err = s.MatchSelector(ctx, 1, "env=", ResourceTypeDashboard)
+		require.NoError(t, err)
+		_, err = s.MatchSelector(ctx, 1, "env in prod", ResourceTypeDashboard)
+		assert.ErrorIs(t, err, ErrInvalidSelector)
+	})
+}
+
+func mustDashboardID(t *testing.T, store *sqlstore.SQLStore, uid string) int64 {
+	t.Helper()
+	dash, err := store.GetDashboard(0, 1, uid, "")
+	require.NoError(t, err)
+	return dash.Id
+}
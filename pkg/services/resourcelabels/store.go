@@ -0,0 +1,214 @@
+package resourcelabels
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getLabels(ctx context.Context, orgID int64, resourceType ResourceType, resourceUID string) (*ResourceMetadata, error) {
+	var metadata *ResourceMetadata
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := s.resourceExists(sess, orgID, resourceType, resourceUID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrResourceNotFound
+		}
+
+		var rows []ResourceLabel
+		if err := sess.Where("org_id = ? AND resource_type = ? AND resource_uid = ?", orgID, resourceType, resourceUID).Find(&rows); err != nil {
+			return err
+		}
+		metadata = toMetadata(resourceType, resourceUID, rows)
+		return nil
+	})
+	return metadata, err
+}
+
+func (s *Service) setLabels(ctx context.Context, cmd SetLabelsCommand) (*ResourceMetadata, error) {
+	rows := make([]ResourceLabel, 0, len(cmd.Labels)+len(cmd.Annotations))
+	for name, value := range cmd.Labels {
+		rows = append(rows, ResourceLabel{OrgId: cmd.OrgID, ResourceType: cmd.ResourceType, ResourceUID: cmd.ResourceUID, Name: name, Value: value})
+	}
+	for name, value := range cmd.Annotations {
+		rows = append(rows, ResourceLabel{OrgId: cmd.OrgID, ResourceType: cmd.ResourceType, ResourceUID: cmd.ResourceUID, Name: name, Value: value, Annotation: true})
+	}
+
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := s.resourceExists(sess, cmd.OrgID, cmd.ResourceType, cmd.ResourceUID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrResourceNotFound
+		}
+
+		if _, err := sess.Exec("DELETE FROM resource_label WHERE org_id = ? AND resource_type = ? AND resource_uid = ?",
+			cmd.OrgID, cmd.ResourceType, cmd.ResourceUID); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		_, err = sess.InsertBatch("resource_label", rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toMetadata(cmd.ResourceType, cmd.ResourceUID, rows), nil
+}
+
+// getSelectedLabels returns the labels of the resources of the types with the keys of the selector, by resource uid
+func (s *Service) getSelectedLabels(ctx context.Context, orgID int64, selector Selector, resourceTypes []ResourceType) (map[string]Labels, error) {
+	var rows []ResourceLabel
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sql := strings.Builder{}
+		sql.WriteString("SELECT resource_uid, name, value FROM resource_label WHERE org_id = ? AND annotation = " + s.sqlStore.Dialect.BooleanStr(false))
+		types := make([]interface{}, 0, len(resourceTypes))
+		for _, rt := range resourceTypes {
+			types = append(types, rt)
+		}
+		params := appendInFilter(&sql, []interface{}{orgID}, "resource_type", types)
+
+		keys := make([]interface{}, 0, len(selector))
+		for _, key := range selector.Keys() {
+			keys = append(keys, key)
+		}
+		params = appendInFilter(&sql, params, "name", keys)
+		return sess.SQL(sql.String(), params...).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]Labels{}
+	for _, row := range rows {
+		if labels[row.ResourceUID] == nil {
+			labels[row.ResourceUID] = Labels{}
+		}
+		labels[row.ResourceUID][row.Name] = row.Value
+	}
+	return labels, nil
+}
+
+// findResources returns the existing resources with labels matching the selector, sorted by uid
+func (s *Service) findResources(ctx context.Context, orgID int64, resourceType ResourceType, selector Selector) ([]ResourceMetadata, error) {
+	result := make([]ResourceMetadata, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var rows []ResourceLabel
+		if err := sess.Where("org_id = ? AND resource_type = ?", orgID, resourceType).Asc("resource_uid").Find(&rows); err != nil {
+			return err
+		}
+
+		byUID := map[string][]ResourceLabel{}
+		uids := make([]string, 0)
+		for _, row := range rows {
+			if _, ok := byUID[row.ResourceUID]; !ok {
+				uids = append(uids, row.ResourceUID)
+			}
+			byUID[row.ResourceUID] = append(byUID[row.ResourceUID], row)
+		}
+
+		var matching []string
+		for _, uid := range uids {
+			metadata := toMetadata(resourceType, uid, byUID[uid])
+			if len(metadata.Labels) > 0 && selector.Matches(metadata.Labels) {
+				matching = append(matching, uid)
+			}
+		}
+
+		// the labels of the deleted resources are left behind
+		existing, err := s.existingResources(sess, orgID, resourceType, matching)
+		if err != nil {
+			return err
+		}
+		for _, uid := range matching {
+			if existing[uid] {
+				result = append(result, *toMetadata(resourceType, uid, byUID[uid]))
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) resourceExists(sess *sqlstore.DBSession, orgID int64, resourceType ResourceType, resourceUID string) (bool, error) {
+	existing, err := s.existingResources(sess, orgID, resourceType, []string{resourceUID})
+	return existing[resourceUID], err
+}
+
+// existingResources returns the uids of the resources of a type which exist
+func (s *Service) existingResources(sess *sqlstore.DBSession, orgID int64, resourceType ResourceType, uids []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(uids) == 0 {
+		return existing, nil
+	}
+
+	dialect := s.sqlStore.Dialect
+	var table, column, extra string
+	switch resourceType {
+	case ResourceTypeDashboard:
+		table, column, extra = "dashboard", "uid", " AND is_folder = "+dialect.BooleanStr(false)
+	case ResourceTypeFolder:
+		table, column, extra = "dashboard", "uid", " AND is_folder = "+dialect.BooleanStr(true)
+	case ResourceTypeDataSource:
+		table, column = "data_source", "uid"
+	case ResourceTypeAlertRule:
+		table, column = "alert_rule", "uid"
+	case ResourceTypeTeam:
+		table, column = "team", "id"
+	default:
+		return nil, ErrInvalidResourceType
+	}
+
+	values := make([]interface{}, 0, len(uids))
+	for _, uid := range uids {
+		if resourceType != ResourceTypeTeam {
+			values = append(values, uid)
+		} else if id, err := strconv.ParseInt(uid, 10, 64); err == nil {
+			values = append(values, id)
+		}
+	}
+	if len(values) == 0 {
+		return existing, nil
+	}
+
+	sql := strings.Builder{}
+	sql.WriteString("SELECT " + column + " FROM " + table + " WHERE org_id = ?" + extra)
+	params := appendInFilter(&sql, []interface{}{orgID}, column, values)
+
+	results, err := sess.SQL(sql.String(), params...).QueryString()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range results {
+		existing[row[column]] = true
+	}
+	return existing, nil
+}
+
+func toMetadata(resourceType ResourceType, resourceUID string, rows []ResourceLabel) *ResourceMetadata {
+	metadata := &ResourceMetadata{ResourceType: resourceType, ResourceUID: resourceUID, Labels: Labels{}, Annotations: Labels{}}
+	for _, row := range rows {
+		if row.Annotation {
+			metadata.Annotations[row.Name] = row.Value
+		} else {
+			metadata.Labels[row.Name] = row.Value
+		}
+	}
+	return metadata
+}
+
+func appendInFilter(sql *strings.Builder, params []interface{}, column string, values []interface{}) []interface{} {
+	if len(values) == 0 {
+		return params
+	}
+
+	sql.WriteString(" AND " + column + " IN (?" + strings.Repeat(",?", len(values)-1) + ")")
+	return append(params, values...)
+}
@@ -0,0 +1,76 @@
+package retention
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite)
+
+	s.routeRegister.Group("/api/org/retention", func(retentionRoute routing.RouteRegister) {
+		retentionRoute.Get("/", middleware.ReqSignedIn, routing.Wrap(s.getRetentionsHandler))
+		retentionRoute.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(s.getRetentionHandler))
+		retentionRoute.Put("/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.setRetentionHandler))
+		retentionRoute.Delete("/:uid", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.deleteRetentionHandler))
+	})
+}
+
+// GET /api/org/retention
+func (s *Service) getRetentionsHandler(c *models.ReqContext) response.Response {
+	retentions, err := s.GetRetentions(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get data source retentions")
+	}
+	return response.JSON(http.StatusOK, retentions)
+}
+
+// GET /api/org/retention/:uid
+func (s *Service) getRetentionHandler(c *models.ReqContext) response.Response {
+	retention, err := s.GetRetention(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get data source retention")
+	}
+	return response.JSON(http.StatusOK, retention)
+}
+
+// PUT /api/org/retention/:uid
+func (s *Service) setRetentionHandler(c *models.ReqContext) response.Response {
+	cmd := SetRetentionCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	retention, err := s.SetRetention(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to set data source retention")
+	}
+	return response.JSON(http.StatusOK, retention)
+}
+
+// DELETE /api/org/retention/:uid
+func (s *Service) deleteRetentionHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteRetention(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err, "Failed to delete data source retention")
+	}
+	return response.Success("Data source retention deleted")
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrRetentionNotFound), errors.Is(err, models.ErrDataSourceNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidRetention):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
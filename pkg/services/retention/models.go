@@ -0,0 +1,46 @@
+package retention
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRetentionNotFound = errors.New("data source has no retention")
+	ErrInvalidRetention  = errors.New("retention must be a positive duration, like 30d")
+)
+
+const (
+	ActionWrite = "datasources.retention:write"
+)
+
+// Retention is how long a data source of an organization keeps its data, as declared by the administrators. The
+// queries of older data are clamped to the retention window by the query service.
+type Retention struct {
+	Id            int64  `json:"id"`
+	OrgId         int64  `json:"orgId"`
+	DatasourceUid string `json:"datasourceUid"`
+	// Retention is a duration such as 30d
+	Retention   string    `json:"retention"`
+	Description string    `json:"description"`
+	UpdatedBy   int64     `json:"updatedBy"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (r *Retention) TableName() string {
+	return "data_source_retention"
+}
+
+// Window returns the retention as a duration
+func (r *Retention) Window() time.Duration {
+	window, err := parseWindow(r.Retention)
+	if err != nil {
+		return 0
+	}
+	return window
+}
+
+type SetRetentionCommand struct {
+	Retention   string `json:"retention"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,153 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// retentionCacheTTL is how long the retentions of the data sources are cached. Changes are applied to the queries
+// of the other Grafana instances of a cluster after this delay.
+const retentionCacheTTL = time.Minute
+
+// Service manages the retentions the administrators declare for the data sources of their organization, so that the
+// queries of data older than the retention are clamped and the panels told why there is no data.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	cache         *localcache.CacheService
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	cacheRegistry *localcache.Registry) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		cache:         localcache.NewNamed("retention", retentionCacheTTL, 2*retentionCacheTTL),
+		log:           log.New("retention"),
+		now:           time.Now,
+	}
+	cacheRegistry.Register(s.cache)
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s
+}
+
+// GetRetentions returns the retentions of the data sources of the organization
+func (s *Service) GetRetentions(ctx context.Context, orgID int64) ([]*Retention, error) {
+	return s.getRetentions(ctx, orgID)
+}
+
+// GetRetention returns the retention of the data source of the organization, ErrRetentionNotFound when it has none
+func (s *Service) GetRetention(ctx context.Context, orgID int64, uid string) (*Retention, error) {
+	return s.getRetention(ctx, orgID, uid)
+}
+
+// SetRetention declares how long the data source keeps its data, replacing its previous retention.
+func (s *Service) SetRetention(ctx context.Context, user *models.SignedInUser, uid string, cmd SetRetentionCommand) (*Retention, error) {
+	cmd.Retention = strings.TrimSpace(cmd.Retention)
+	if _, err := parseWindow(cmd.Retention); err != nil {
+		return nil, err
+	}
+
+	query := &models.GetDataSourceQuery{OrgId: user.OrgId, Uid: uid}
+	if err := s.sqlStore.GetDataSource(ctx, query); err != nil {
+		return nil, err
+	}
+
+	retention := &Retention{
+		OrgId:         user.OrgId,
+		DatasourceUid: uid,
+		Retention:     cmd.Retention,
+		Description:   cmd.Description,
+		UpdatedBy:     user.UserId,
+		Updated:       s.now(),
+	}
+	if err := s.saveRetention(ctx, retention); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(retentionKey(user.OrgId, uid))
+
+	s.log.Info("Set data source retention", "orgId", user.OrgId, "datasource", uid, "retention", retention.Retention, "userId", user.UserId)
+	return retention, nil
+}
+
+// DeleteRetention removes the retention of the data source, its queries are no longer clamped.
+func (s *Service) DeleteRetention(ctx context.Context, orgID int64, uid string) error {
+	if err := s.deleteRetention(ctx, orgID, uid); err != nil {
+		return err
+	}
+	s.cache.Delete(retentionKey(orgID, uid))
+	return nil
+}
+
+// Cutoff returns the time before which the data source of the organization has no data, the zero time when it has
+// no retention. The retentions are cached.
+func (s *Service) Cutoff(ctx context.Context, orgID int64, uid string) (time.Time, *Retention, error) {
+	key := retentionKey(orgID, uid)
+	var retention *Retention
+	if cached, ok := s.cache.Get(key); ok {
+		retention = cached.(*Retention)
+	} else {
+		var err error
+		retention, err = s.getRetention(ctx, orgID, uid)
+		if err != nil && !errors.Is(err, ErrRetentionNotFound) {
+			return time.Time{}, nil, err
+		}
+		s.cache.Set(key, retention, 0)
+	}
+
+	if retention == nil || retention.Window() <= 0 {
+		return time.Time{}, nil, nil
+	}
+	return s.now().Add(-retention.Window()), retention, nil
+}
+
+// parseWindow parses a retention such as 30d, which must be positive
+func parseWindow(retention string) (time.Duration, error) {
+	window, err := gtime.ParseDuration(retention)
+	if err != nil || window <= 0 {
+		return 0, ErrInvalidRetention
+	}
+	return window, nil
+}
+
+func retentionKey(orgID int64, uid string) string {
+	return localcache.OrgKey(orgID, uid)
+}
+
+func (s *Service) registerRoles() error {
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:datasources.retention:writer",
+			DisplayName: "Data source retention writer",
+			Description: "Declare how long the data sources of the organization keep their data.",
+			Group:       "Data sources",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionWrite},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(writer)
+}
@@ -0,0 +1,83 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Retention(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, Login: "admin"}
+	err := store.AddDataSource(ctx, &models.AddDataSourceCommand{OrgId: 1, Name: "prometheus", Type: "prometheus", Uid: "prom", Access: models.DS_ACCESS_PROXY})
+	require.NoError(t, err)
+
+	t.Run("should not clamp the data sources without retention", func(t *testing.T) {
+		_, err := s.GetRetention(ctx, 1, "prom")
+		require.ErrorIs(t, err, ErrRetentionNotFound)
+
+		cutoff, retention, err := s.Cutoff(ctx, 1, "prom")
+		require.NoError(t, err)
+		assert.True(t, cutoff.IsZero())
+		assert.Nil(t, retention)
+	})
+
+	t.Run("should refuse invalid retentions and unknown data sources", func(t *testing.T) {
+		_, err := s.SetRetention(ctx, admin, "prom", SetRetentionCommand{Retention: "a month"})
+		require.ErrorIs(t, err, ErrInvalidRetention)
+		_, err = s.SetRetention(ctx, admin, "prom", SetRetentionCommand{Retention: "0d"})
+		require.ErrorIs(t, err, ErrInvalidRetention)
+		_, err = s.SetRetention(ctx, admin, "missing", SetRetentionCommand{Retention: "30d"})
+		require.ErrorIs(t, err, models.ErrDataSourceNotFound)
+	})
+
+	t.Run("should set and replace the retention of a data source", func(t *testing.T) {
+		retention, err := s.SetRetention(ctx, admin, "prom", SetRetentionCommand{Retention: "30d", Description: "Metrics are kept 30 days"})
+		require.NoError(t, err)
+		assert.Equal(t, 30*24*time.Hour, retention.Window())
+
+		cutoff, _, err := s.Cutoff(ctx, 1, "prom")
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-30*24*time.Hour), cutoff)
+
+		_, err = s.SetRetention(ctx, admin, "prom", SetRetentionCommand{Retention: "7d"})
+		require.NoError(t, err)
+		cutoff, retention, err = s.Cutoff(ctx, 1, "prom")
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-7*24*time.Hour), cutoff)
+		assert.Equal(t, "7d", retention.Retention)
+
+		retentions, err := s.GetRetentions(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, retentions, 1)
+		assert.Equal(t, "prom", retentions[0].DatasourceUid)
+		assert.Equal(t, admin.UserId, retentions[0].UpdatedBy)
+
+		retentions, err = s.GetRetentions(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, retentions)
+	})
+
+	t.Run("should delete the retention of a data source", func(t *testing.T) {
+		require.NoError(t, s.DeleteRetention(ctx, 1, "prom"))
+		require.ErrorIs(t, s.DeleteRetention(ctx, 1, "prom"), ErrRetentionNotFound)
+
+		cutoff, _, err := s.Cutoff(ctx, 1, "prom")
+		require.NoError(t, err)
+		assert.True(t, cutoff.IsZero())
+	})
+}
@@ -0,0 +1,64 @@
+package retention
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getRetentions(ctx context.Context, orgID int64) ([]*Retention, error) {
+	retentions := make([]*Retention, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("datasource_uid").Find(&retentions)
+	})
+	return retentions, err
+}
+
+func (s *Service) getRetention(ctx context.Context, orgID int64, uid string) (*Retention, error) {
+	retention := &Retention{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND datasource_uid = ?", orgID, uid).Get(retention)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrRetentionNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+// saveRetention inserts the retention of the data source, or replaces the existing one
+func (s *Service) saveRetention(ctx context.Context, retention *Retention) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		existing := &Retention{}
+		exists, err := sess.Where("org_id = ? AND datasource_uid = ?", retention.OrgId, retention.DatasourceUid).Get(existing)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			_, err = sess.Insert(retention)
+			return err
+		}
+		retention.Id = existing.Id
+		_, err = sess.ID(existing.Id).AllCols().Update(retention)
+		return err
+	})
+}
+
+func (s *Service) deleteRetention(ctx context.Context, orgID int64, uid string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND datasource_uid = ?", orgID, uid).Delete(&Retention{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrRetentionNotFound
+		}
+		return nil
+	})
+}
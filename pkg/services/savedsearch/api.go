@@ -0,0 +1,90 @@
+package savedsearch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/saved-searches", func(savedSearchRoute routing.RouteRegister) {
+		savedSearchRoute.Get("/", routing.Wrap(s.getSavedSearchesHandler))
+		savedSearchRoute.Post("/", routing.Wrap(s.createSavedSearchHandler))
+		savedSearchRoute.Get("/:uid", routing.Wrap(s.getSavedSearchHandler))
+		savedSearchRoute.Put("/:uid", routing.Wrap(s.updateSavedSearchHandler))
+		savedSearchRoute.Delete("/:uid", routing.Wrap(s.deleteSavedSearchHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+}
+
+// GET /api/saved-searches
+func (s *Service) getSavedSearchesHandler(c *models.ReqContext) response.Response {
+	savedSearches, err := s.GetSavedSearches(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return errorResponse(err, "Failed to get saved searches")
+	}
+	return response.JSON(http.StatusOK, savedSearches)
+}
+
+// GET /api/saved-searches/:uid
+func (s *Service) getSavedSearchHandler(c *models.ReqContext) response.Response {
+	savedSearch, err := s.GetSavedSearch(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get saved search")
+	}
+	return response.JSON(http.StatusOK, savedSearch)
+}
+
+// POST /api/saved-searches
+func (s *Service) createSavedSearchHandler(c *models.ReqContext) response.Response {
+	cmd := CreateSavedSearchCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	savedSearch, err := s.CreateSavedSearch(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create saved search")
+	}
+	return response.JSON(http.StatusOK, savedSearch)
+}
+
+// PUT /api/saved-searches/:uid
+func (s *Service) updateSavedSearchHandler(c *models.ReqContext) response.Response {
+	cmd := UpdateSavedSearchCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	savedSearch, err := s.UpdateSavedSearch(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update saved search")
+	}
+	return response.JSON(http.StatusOK, savedSearch)
+}
+
+// DELETE /api/saved-searches/:uid
+func (s *Service) deleteSavedSearchHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteSavedSearch(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err, "Failed to delete saved search")
+	}
+	return response.Success("Saved search deleted")
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrSavedSearchNotFound), errors.Is(err, models.ErrTeamNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrSavedSearchForbidden), errors.Is(err, models.ErrNotAllowedToUpdateTeamInDifferentOrg):
+		return response.Error(http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, ErrSavedSearchNameExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrSavedSearchNameEmpty), errors.Is(err, ErrInvalidOwner):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
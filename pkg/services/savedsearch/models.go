@@ -0,0 +1,108 @@
+package savedsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ownership"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	ErrSavedSearchNotFound   = errors.New("saved search not found")
+	ErrSavedSearchNameEmpty  = errors.New("saved search name cannot be empty")
+	ErrSavedSearchNameExists = errors.New("a saved search with the same name already exists")
+	ErrSavedSearchForbidden  = errors.New("not allowed to change the saved search")
+	ErrInvalidOwner          = errors.New("only one of ownerUserId or ownerTeamId can be set")
+)
+
+// Filters are the search parameters stored in a saved search.
+type Filters struct {
+	Query       string   `json:"query,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Starred     bool     `json:"starred,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	FolderIds   []int64  `json:"folderIds,omitempty"`
+	Sort        string   `json:"sort,omitempty"`
+	OwnerUserId int64    `json:"ownerUserId,omitempty"`
+	OwnerTeamId int64    `json:"ownerTeamId,omitempty"`
+}
+
+// FromDB is part of the xorm Conversion interface.
+func (f *Filters) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*f = Filters{}
+		return nil
+	}
+	return json.Unmarshal(data, f)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (f *Filters) ToDB() ([]byte, error) {
+	return json.Marshal(f)
+}
+
+// SavedSearch is a named set of search filters. A saved search belongs either to a single user or,
+// when TeamId is set, is shared with all the members of the team.
+type SavedSearch struct {
+	Id        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	OrgId     int64     `json:"-"`
+	UserId    int64     `json:"userId,omitempty"`
+	TeamId    int64     `json:"teamId,omitempty"`
+	Name      string    `json:"name"`
+	Filters   Filters   `json:"filters"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+	CreatedBy int64     `json:"createdBy"`
+}
+
+// Apply sets the filters of the saved search on a search query. Parameters already set on the
+// query narrow the saved search down further.
+func (s *SavedSearch) Apply(query *search.Query) {
+	f := s.Filters
+	if query.Title == "" {
+		query.Title = f.Query
+	}
+	query.Tags = append(query.Tags, f.Tags...)
+	query.IsStarred = query.IsStarred || f.Starred
+	if query.Type == "" {
+		query.Type = f.Type
+	}
+	if len(query.FolderIds) == 0 {
+		query.FolderIds = f.FolderIds
+	}
+	if query.Sort == "" {
+		query.Sort = f.Sort
+	}
+	if f.OwnerUserId != 0 || f.OwnerTeamId != 0 {
+		query.Filters = append(query.Filters, ownership.DashboardOwnerFilter{UserID: f.OwnerUserId, TeamID: f.OwnerTeamId})
+	}
+}
+
+// Hit returns the saved search as a search hit, so that it can be listed next to the folders. The hit is identified
+// by its type and uid, its id is left at 0 as saved search ids would collide with dashboard ids.
+func (s *SavedSearch) Hit() *search.Hit {
+	return &search.Hit{
+		UID:   s.Uid,
+		Title: s.Name,
+		URL:   setting.AppSubUrl + "/dashboards?savedSearchUid=" + s.Uid,
+		Type:  search.DashHitSavedSearch,
+		Tags:  []string{},
+	}
+}
+
+type CreateSavedSearchCommand struct {
+	OrgID     int64   `json:"-"`
+	CreatedBy int64   `json:"-"`
+	TeamID    int64   `json:"teamId"`
+	Name      string  `json:"name"`
+	Filters   Filters `json:"filters"`
+}
+
+type UpdateSavedSearchCommand struct {
+	Name    string  `json:"name"`
+	Filters Filters `json:"filters"`
+}
@@ -0,0 +1,141 @@
+package savedsearch
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/teamguardian"
+)
+
+// Service stores named search filters for users and teams. Saved searches are listed as virtual
+// folders at the root of the dashboard search, opening one runs the search with its filters.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	bus           bus.Bus
+	routeRegister routing.RouteRegister
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		bus:           bus,
+		routeRegister: routeRegister,
+	}
+	s.registerAPIEndpoints()
+	return s
+}
+
+// GetSavedSearch returns a saved search visible to the user.
+func (s *Service) GetSavedSearch(ctx context.Context, user *models.SignedInUser, uid string) (*SavedSearch, error) {
+	savedSearch, err := s.getSavedSearch(ctx, user.OrgId, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !canView(user, savedSearch) {
+		return nil, ErrSavedSearchNotFound
+	}
+	return savedSearch, nil
+}
+
+// GetSavedSearches returns the saved searches of the user and of the user's teams, sorted by name.
+// Organization administrators also get the saved searches of every team.
+func (s *Service) GetSavedSearches(ctx context.Context, user *models.SignedInUser) ([]*SavedSearch, error) {
+	return s.getSavedSearches(ctx, user)
+}
+
+// CreateSavedSearch creates a saved search for the user or, when a team is set, for the team.
+func (s *Service) CreateSavedSearch(ctx context.Context, user *models.SignedInUser, cmd CreateSavedSearchCommand) (*SavedSearch, error) {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if err := validate(cmd.Name, cmd.Filters); err != nil {
+		return nil, err
+	}
+	if cmd.TeamID != 0 {
+		if err := s.canAdminTeam(ctx, user, cmd.TeamID); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd.OrgID = user.OrgId
+	cmd.CreatedBy = user.UserId
+	return s.createSavedSearch(ctx, cmd)
+}
+
+// UpdateSavedSearch replaces the name and filters of a saved search.
+func (s *Service) UpdateSavedSearch(ctx context.Context, user *models.SignedInUser, uid string, cmd UpdateSavedSearchCommand) (*SavedSearch, error) {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if err := validate(cmd.Name, cmd.Filters); err != nil {
+		return nil, err
+	}
+
+	savedSearch, err := s.getEditableSavedSearch(ctx, user, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	savedSearch.Name = cmd.Name
+	savedSearch.Filters = cmd.Filters
+	if err := s.updateSavedSearch(ctx, savedSearch); err != nil {
+		return nil, err
+	}
+	return savedSearch, nil
+}
+
+// DeleteSavedSearch deletes a saved search.
+func (s *Service) DeleteSavedSearch(ctx context.Context, user *models.SignedInUser, uid string) error {
+	if _, err := s.getEditableSavedSearch(ctx, user, uid); err != nil {
+		return err
+	}
+	return s.deleteSavedSearch(ctx, user.OrgId, uid)
+}
+
+// getEditableSavedSearch returns the saved search if the user can change it. Personal saved searches
+// can only be changed by their owner, team saved searches by the team administrators.
+func (s *Service) getEditableSavedSearch(ctx context.Context, user *models.SignedInUser, uid string) (*SavedSearch, error) {
+	savedSearch, err := s.GetSavedSearch(ctx, user, uid)
+	if err != nil {
+		return nil, err
+	}
+	if savedSearch.TeamId != 0 {
+		if err := s.canAdminTeam(ctx, user, savedSearch.TeamId); err != nil {
+			return nil, err
+		}
+	}
+	return savedSearch, nil
+}
+
+func (s *Service) canAdminTeam(ctx context.Context, user *models.SignedInUser, teamID int64) error {
+	err := teamguardian.CanAdmin(ctx, s.bus, user.OrgId, teamID, user)
+	if err == models.ErrNotAllowedToUpdateTeam {
+		return ErrSavedSearchForbidden
+	}
+	return err
+}
+
+func canView(user *models.SignedInUser, savedSearch *SavedSearch) bool {
+	if savedSearch.TeamId == 0 {
+		return savedSearch.UserId == user.UserId
+	}
+	if user.OrgRole == models.ROLE_ADMIN {
+		return true
+	}
+	for _, teamID := range user.Teams {
+		if teamID == savedSearch.TeamId {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(name string, filters Filters) error {
+	if name == "" {
+		return ErrSavedSearchNameEmpty
+	}
+	if filters.OwnerUserId != 0 && filters.OwnerTeamId != 0 {
+		return ErrInvalidOwner
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package savedsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ownership"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_SavedSearches(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, bus.GetBus(), routing.NewRouteRegister())
+
+	team, err := store.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+	otherTeam, err := store.CreateTeam("other", "", 1)
+	require.NoError(t, err)
+
+	users := make([]*models.User, 3)
+	for i, login := range []string{"team-admin", "team-member", "org-admin"} {
+		users[i], err = store.CreateUser(ctx, models.CreateUserCommand{Login: login, OrgId: 1})
+		require.NoError(t, err)
+	}
+
+	teamAdmin := &models.SignedInUser{UserId: users[0].Id, OrgId: 1, OrgRole: models.ROLE_EDITOR, Teams: []int64{team.Id}}
+	teamMember := &models.SignedInUser{UserId: users[1].Id, OrgId: 1, OrgRole: models.ROLE_VIEWER, Teams: []int64{team.Id}}
+	orgAdmin := &models.SignedInUser{UserId: users[2].Id, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+	require.NoError(t, store.AddTeamMember(teamAdmin.UserId, 1, team.Id, false, models.PERMISSION_ADMIN))
+	require.NoError(t, store.AddTeamMember(teamMember.UserId, 1, team.Id, false, 0))
+
+	filters := Filters{Tags: []string{"prod"}, OwnerTeamId: team.Id}
+
+	personal, err := s.CreateSavedSearch(ctx, teamMember, CreateSavedSearchCommand{Name: " mine ", Filters: filters})
+	require.NoError(t, err)
+	assert.Equal(t, "mine", personal.Name)
+	assert.Equal(t, teamMember.UserId, personal.UserId)
+
+	shared, err := s.CreateSavedSearch(ctx, teamAdmin, CreateSavedSearchCommand{Name: "prod", TeamID: team.Id, Filters: filters})
+	require.NoError(t, err)
+
+	t.Run("should validate saved searches", func(t *testing.T) {
+		_, err := s.CreateSavedSearch(ctx, teamMember, CreateSavedSearchCommand{Name: " "})
+		assert.ErrorIs(t, err, ErrSavedSearchNameEmpty)
+		_, err = s.CreateSavedSearch(ctx, teamMember, CreateSavedSearchCommand{Name: "both", Filters: Filters{OwnerUserId: 1, OwnerTeamId: 1}})
+		assert.ErrorIs(t, err, ErrInvalidOwner)
+		_, err = s.CreateSavedSearch(ctx, teamMember, CreateSavedSearchCommand{Name: "mine"})
+		assert.ErrorIs(t, err, ErrSavedSearchNameExists)
+		_, err = s.CreateSavedSearch(ctx, orgAdmin, CreateSavedSearchCommand{Name: "unknown", TeamID: 1000})
+		assert.ErrorIs(t, err, models.ErrTeamNotFound)
+	})
+
+	t.Run("should only let team administrators create team saved searches", func(t *testing.T) {
+		_, err := s.CreateSavedSearch(ctx, teamMember, CreateSavedSearchCommand{Name: "team", TeamID: team.Id})
+		assert.ErrorIs(t, err, ErrSavedSearchForbidden)
+	})
+
+	t.Run("should list saved searches visible to the user", func(t *testing.T) {
+		searches, err := s.GetSavedSearches(ctx, teamMember)
+		require.NoError(t, err)
+		require.Len(t, searches, 2)
+		assert.Equal(t, "mine", searches[0].Name)
+		assert.Equal(t, filters, searches[0].Filters)
+		assert.Equal(t, "prod", searches[1].Name)
+
+		searches, err = s.GetSavedSearches(ctx, &models.SignedInUser{UserId: 1000, OrgId: 1, OrgRole: models.ROLE_EDITOR, Teams: []int64{otherTeam.Id}})
+		require.NoError(t, err)
+		assert.Len(t, searches, 0)
+
+		searches, err = s.GetSavedSearches(ctx, orgAdmin)
+		require.NoError(t, err)
+		require.Len(t, searches, 1)
+		assert.Equal(t, shared.Uid, searches[0].Uid)
+	})
+
+	t.Run("should hide personal saved searches from other users", func(t *testing.T) {
+		_, err := s.GetSavedSearch(ctx, orgAdmin, personal.Uid)
+		assert.ErrorIs(t, err, ErrSavedSearchNotFound)
+		_, err = s.GetSavedSearch(ctx, teamAdmin, personal.Uid)
+		assert.ErrorIs(t, err, ErrSavedSearchNotFound)
+	})
+
+	t.Run("should only let team administrators change team saved searches", func(t *testing.T) {
+		_, err := s.UpdateSavedSearch(ctx, teamMember, shared.Uid, UpdateSavedSearchCommand{Name: "renamed"})
+		assert.ErrorIs(t, err, ErrSavedSearchForbidden)
+		assert.ErrorIs(t, s.DeleteSavedSearch(ctx, teamMember, shared.Uid), ErrSavedSearchForbidden)
+
+		updated, err := s.UpdateSavedSearch(ctx, teamAdmin, shared.Uid, UpdateSavedSearchCommand{Name: "renamed", Filters: Filters{Query: "api"}})
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", updated.Name)
+
+		fetched, err := s.GetSavedSearch(ctx, teamMember, shared.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", fetched.Name)
+		assert.Equal(t, Filters{Query: "api"}, fetched.Filters)
+	})
+
+	t.Run("should delete saved searches", func(t *testing.T) {
+		require.NoError(t, s.DeleteSavedSearch(ctx, orgAdmin, shared.Uid))
+		require.NoError(t, s.DeleteSavedSearch(ctx, teamMember, personal.Uid))
+		assert.ErrorIs(t, s.DeleteSavedSearch(ctx, teamMember, personal.Uid), ErrSavedSearchNotFound)
+	})
+}
+
+func TestSavedSearch_Apply(t *testing.T) {
+	savedSearch := &SavedSearch{Filters: Filters{Query: "api", Tags: []string{"prod"}, Type: "dash-db", FolderIds: []int64{1}, OwnerTeamId: 2}}
+
+	query := search.Query{Tags: []string{"team-a"}, Sort: "alpha-asc"}
+	savedSearch.Apply(&query)
+
+	assert.Equal(t, "api", query.Title)
+	assert.Equal(t, []string{"team-a", "prod"}, query.Tags)
+	assert.Equal(t, "dash-db", query.Type)
+	assert.Equal(t, []int64{1}, query.FolderIds)
+	assert.Equal(t, "alpha-asc", query.Sort)
+	assert.Equal(t, []interface{}{ownership.DashboardOwnerFilter{TeamID: 2}}, query.Filters)
+}
+
+func TestSavedSearch_Hit(t *testing.T) {
+	hit := (&SavedSearch{Id: 1, Uid: "errors", Name: "Errors"}).Hit()
+	assert.Equal(t, int64(0), hit.ID)
+	assert.Equal(t, "errors", hit.UID)
+	assert.Equal(t, search.DashHitSavedSearch, hit.Type)
+	assert.Equal(t, "Errors", hit.Title)
+}
@@ -0,0 +1,122 @@
+package savedsearch
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getSavedSearch(ctx context.Context, orgID int64, uid string) (*SavedSearch, error) {
+	savedSearch := &SavedSearch{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(savedSearch)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrSavedSearchNotFound
+		}
+		return nil
+	})
+	return savedSearch, err
+}
+
+func (s *Service) getSavedSearches(ctx context.Context, user *models.SignedInUser) ([]*SavedSearch, error) {
+	result := make([]*SavedSearch, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sess.Where("org_id = ?", user.OrgId)
+		switch {
+		case user.OrgRole == models.ROLE_ADMIN:
+			sess.And("(user_id = ? OR team_id != 0)", user.UserId)
+		case len(user.Teams) > 0:
+			params := []interface{}{user.UserId}
+			for _, teamID := range user.Teams {
+				params = append(params, teamID)
+			}
+			sess.And("(user_id = ? OR team_id IN (?"+strings.Repeat(",?", len(user.Teams)-1)+"))", params...)
+		default:
+			sess.And("user_id = ?", user.UserId)
+		}
+		return sess.Asc("name").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) createSavedSearch(ctx context.Context, cmd CreateSavedSearchCommand) (*SavedSearch, error) {
+	savedSearch := &SavedSearch{}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if cmd.TeamID != 0 {
+			exists, err := sess.Table("team").Where("org_id = ? AND id = ?", cmd.OrgID, cmd.TeamID).Exist()
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return models.ErrTeamNotFound
+			}
+		}
+
+		now := time.Now()
+		savedSearch.Uid = util.GenerateShortUID()
+		savedSearch.OrgId = cmd.OrgID
+		savedSearch.TeamId = cmd.TeamID
+		if cmd.TeamID == 0 {
+			savedSearch.UserId = cmd.CreatedBy
+		}
+		savedSearch.Name = cmd.Name
+		savedSearch.Filters = cmd.Filters
+		savedSearch.Created = now
+		savedSearch.Updated = now
+		savedSearch.CreatedBy = cmd.CreatedBy
+
+		if err := validateUniqueName(sess, savedSearch); err != nil {
+			return err
+		}
+
+		_, err := sess.Insert(savedSearch)
+		return err
+	})
+	return savedSearch, err
+}
+
+func (s *Service) updateSavedSearch(ctx context.Context, savedSearch *SavedSearch) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateUniqueName(sess, savedSearch); err != nil {
+			return err
+		}
+
+		savedSearch.Updated = time.Now()
+		_, err := sess.ID(savedSearch.Id).Cols("name", "filters", "updated").Update(savedSearch)
+		return err
+	})
+}
+
+func (s *Service) deleteSavedSearch(ctx context.Context, orgID int64, uid string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM saved_search WHERE org_id = ? AND uid = ?", orgID, uid)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return ErrSavedSearchNotFound
+		}
+		return nil
+	})
+}
+
+func validateUniqueName(sess *sqlstore.DBSession, savedSearch *SavedSearch) error {
+	exists, err := sess.Table("saved_search").
+		Where("org_id = ? AND user_id = ? AND team_id = ? AND name = ? AND id != ?",
+			savedSearch.OrgId, savedSearch.UserId, savedSearch.TeamId, savedSearch.Name, savedSearch.Id).
+		Exist()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrSavedSearchNameExists
+	}
+	return nil
+}
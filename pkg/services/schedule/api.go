@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/schedules", func(scheduleRoute routing.RouteRegister) {
+		scheduleRoute.Get("/", routing.Wrap(s.getSchedulesHandler))
+		scheduleRoute.Post("/", middleware.ReqEditorRole, routing.Wrap(s.createScheduleHandler))
+		scheduleRoute.Get("/:uid", routing.Wrap(s.getScheduleHandler))
+		scheduleRoute.Put("/:uid", middleware.ReqEditorRole, routing.Wrap(s.updateScheduleHandler))
+		scheduleRoute.Delete("/:uid", middleware.ReqEditorRole, routing.Wrap(s.deleteScheduleHandler))
+		scheduleRoute.Get("/:uid/occurrences", routing.Wrap(s.getOccurrencesHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// GET /api/schedules
+func (s *Service) getSchedulesHandler(c *models.ReqContext) response.Response {
+	schedules, err := s.GetSchedules(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get schedules")
+	}
+	return response.JSON(http.StatusOK, schedules)
+}
+
+// GET /api/schedules/:uid
+func (s *Service) getScheduleHandler(c *models.ReqContext) response.Response {
+	schedule, err := s.GetSchedule(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to get schedule")
+	}
+	return response.JSON(http.StatusOK, schedule)
+}
+
+// POST /api/schedules
+func (s *Service) createScheduleHandler(c *models.ReqContext) response.Response {
+	cmd := ScheduleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	schedule, err := s.CreateSchedule(c.Req.Context(), c.OrgId, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to create schedule")
+	}
+	return response.JSON(http.StatusOK, schedule)
+}
+
+// PUT /api/schedules/:uid
+func (s *Service) updateScheduleHandler(c *models.ReqContext) response.Response {
+	cmd := ScheduleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	schedule, err := s.UpdateSchedule(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update schedule")
+	}
+	return response.JSON(http.StatusOK, schedule)
+}
+
+// DELETE /api/schedules/:uid
+func (s *Service) deleteScheduleHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteSchedule(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err, "Failed to delete schedule")
+	}
+	return response.Success("Schedule deleted")
+}
+
+// GET /api/schedules/:uid/occurrences?from=&to=
+func (s *Service) getOccurrencesHandler(c *models.ReqContext) response.Response {
+	from := time.Now()
+	if ms := c.QueryInt64("from"); ms > 0 {
+		from = time.UnixMilli(ms)
+	}
+	to := from.AddDate(0, 0, 7)
+	if ms := c.QueryInt64("to"); ms > 0 {
+		to = time.UnixMilli(ms)
+	}
+	if to.Before(from) {
+		return response.Error(http.StatusBadRequest, "to cannot be before from", nil)
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if _, err := s.GetSchedule(c.Req.Context(), c.OrgId, uid); err != nil {
+		return errorResponse(err, "Failed to get schedule")
+	}
+	occurrences, err := s.GetOccurrences(c.Req.Context(), c.OrgId, []string{uid}, from, to)
+	if err != nil {
+		return errorResponse(err, "Failed to get schedule occurrences")
+	}
+	return response.JSON(http.StatusOK, occurrences)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrScheduleNameExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrScheduleNameEmpty), errors.Is(err, ErrInvalidTimezone),
+		errors.Is(err, ErrInvalidCron), errors.Is(err, ErrInvalidDuration):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// maxSearchDays bounds the search of the next activation of a cron expression, expressions like
+// 0 0 30 2 * never match
+const maxSearchDays = 5 * 366
+
+// starBit is set by the cron parser on the day fields given as *
+const starBit = 1 << 63
+
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Cron is a cron expression evaluated in the wall clock of a location. The expression has five
+// fields, or six with the seconds first, or is a descriptor like @daily. Activations falling into
+// the gap of a daylight saving time change happen when the clocks jump, the ones falling into the
+// repeated hour happen only once, at the first of the two times.
+type Cron struct {
+	spec *cron.SpecSchedule
+	loc  *time.Location
+}
+
+// ParseCron parses a cron expression evaluated in the location, @every descriptors are refused
+// since they do not depend on the wall clock.
+func ParseCron(expr string, loc *time.Location) (*Cron, error) {
+	if strings.HasPrefix(strings.TrimSpace(expr), "@every") {
+		return nil, fmt.Errorf("%w: @every is not supported", ErrInvalidCron)
+	}
+	parsed, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCron, err)
+	}
+	spec, ok := parsed.(*cron.SpecSchedule)
+	if !ok {
+		return nil, ErrInvalidCron
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Cron{spec: spec, loc: loc}, nil
+}
+
+// Next returns the first activation after the time, in the location of the time, or the zero time
+// when the expression does not match in the next five years.
+func (c *Cron) Next(after time.Time) time.Time {
+	origLoc := after.Location()
+	after = after.In(c.loc)
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxSearchDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if !c.dayMatches(day) {
+			continue
+		}
+		if next, ok := c.nextInDay(day, after); ok {
+			return next.In(origLoc)
+		}
+	}
+	return time.Time{}
+}
+
+// nextInDay returns the first activation after the time in the day, whose date is given in UTC.
+// The wall clock times are walked in order, and the times of a gap are moved to its end, so the
+// activations are in order as well.
+func (c *Cron) nextInDay(day time.Time, after time.Time) (time.Time, bool) {
+	for hour := 0; hour < 24; hour++ {
+		if c.spec.Hour&(1<<uint(hour)) == 0 {
+			continue
+		}
+		for minute := 0; minute < 60; minute++ {
+			if c.spec.Minute&(1<<uint(minute)) == 0 {
+				continue
+			}
+			for second := 0; second < 60; second++ {
+				if c.spec.Second&(1<<uint(second)) == 0 {
+					continue
+				}
+				t := c.wallClock(day.Year(), day.Month(), day.Day(), hour, minute, second)
+				if t.After(after) {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// wallClock returns the time of the wall clock in the location. Wall clock times skipped by a
+// daylight saving time change return the time of the change, and the repeated ones the first time.
+func (c *Cron) wallClock(year int, month time.Month, day, hour, minute, second int) time.Time {
+	t := time.Date(year, month, day, hour, minute, second, 0, c.loc)
+	wall := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+
+	// The wall clock exists with the offsets before or after a change, time.Date picks either
+	var first time.Time
+	for _, probe := range []time.Time{t.Add(-12 * time.Hour), t, t.Add(12 * time.Hour)} {
+		_, offset := probe.Zone()
+		candidate := wall.Add(-time.Duration(offset) * time.Second).In(c.loc)
+		if sameWallClock(candidate, wall) && (first.IsZero() || candidate.Before(first)) {
+			first = candidate
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+
+	// The wall clock is in a gap, the change is the first instant with the offset that follows it
+	low, high := t.Add(-6*time.Hour), t.Add(6*time.Hour)
+	_, offset := high.Zone()
+	for high.Sub(low) > time.Second {
+		mid := low.Add(high.Sub(low) / 2)
+		if _, o := mid.Zone(); o == offset {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return high.Truncate(time.Second)
+}
+
+func sameWallClock(t time.Time, wall time.Time) bool {
+	return t.Year() == wall.Year() && t.YearDay() == wall.YearDay() &&
+		t.Hour() == wall.Hour() && t.Minute() == wall.Minute() && t.Second() == wall.Second()
+}
+
+// dayMatches reproduces the cron rule for the days: when both the day of the month and the day of
+// the week are restricted, a day matching either of them matches.
+func (c *Cron) dayMatches(day time.Time) bool {
+	if c.spec.Month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domMatch := c.spec.Dom&(1<<uint(day.Day())) > 0
+	dowMatch := c.spec.Dow&(1<<uint(day.Weekday())) > 0
+	if c.spec.Dom&starBit > 0 || c.spec.Dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron_Next(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	next := func(t *testing.T, expr string, loc *time.Location, after time.Time) time.Time {
+		t.Helper()
+		c, err := ParseCron(expr, loc)
+		require.NoError(t, err)
+		return c.Next(after)
+	}
+
+	t.Run("should evaluate the expression in the wall clock of the location", func(t *testing.T) {
+		after := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2022, 1, 10, 14, 0, 0, 0, time.UTC), next(t, "0 9 * * *", ny, after))
+		assert.Equal(t, time.Date(2022, 1, 11, 8, 0, 0, 0, time.UTC), next(t, "0 9 * * *", paris, after))
+		assert.Equal(t, time.Date(2022, 1, 10, 12, 0, 30, 0, time.UTC), next(t, "30 * * * * *", time.UTC, after))
+		assert.Equal(t, time.Date(2022, 1, 11, 5, 0, 0, 0, time.UTC), next(t, "@daily", ny, after))
+		assert.Equal(t, time.UTC, next(t, "0 9 * * *", ny, after).Location())
+	})
+
+	t.Run("should run the activations skipped by a daylight saving time change when the clocks jump", func(t *testing.T) {
+		after := time.Date(2022, 3, 13, 0, 0, 0, 0, ny)
+		first := next(t, "30 2 * * *", ny, after)
+		assert.Equal(t, time.Date(2022, 3, 13, 3, 0, 0, 0, ny), first)
+		assert.Equal(t, time.Date(2022, 3, 14, 2, 30, 0, 0, ny), next(t, "30 2 * * *", ny, first))
+
+		// The activations of the gap happen once
+		after = time.Date(2022, 3, 13, 1, 50, 0, 0, ny)
+		first = next(t, "*/15 * * * *", ny, after)
+		assert.Equal(t, time.Date(2022, 3, 13, 3, 0, 0, 0, ny), first)
+		assert.Equal(t, time.Date(2022, 3, 13, 3, 15, 0, 0, ny), next(t, "*/15 * * * *", ny, first))
+	})
+
+	t.Run("should run the activations repeated by a daylight saving time change once", func(t *testing.T) {
+		after := time.Date(2022, 11, 6, 0, 0, 0, 0, ny)
+		first := next(t, "30 1 * * *", ny, after)
+		assert.Equal(t, time.Date(2022, 11, 6, 5, 30, 0, 0, time.UTC), first.UTC())
+		assert.Equal(t, time.Date(2022, 11, 7, 6, 30, 0, 0, time.UTC), next(t, "30 1 * * *", ny, first).UTC())
+
+		after = time.Date(2022, 10, 30, 0, 0, 0, 0, paris)
+		first = next(t, "30 2 * * *", paris, after)
+		assert.Equal(t, time.Date(2022, 10, 30, 0, 30, 0, 0, time.UTC), first.UTC())
+		assert.Equal(t, time.Date(2022, 10, 31, 1, 30, 0, 0, time.UTC), next(t, "30 2 * * *", paris, first).UTC())
+	})
+
+	t.Run("should match either the day of the month or the day of the week when both are restricted", func(t *testing.T) {
+		after := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), next(t, "0 0 1 * MON", time.UTC, after))
+		assert.Equal(t, time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+			next(t, "0 0 1 * MON", time.UTC, time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), next(t, "0 0 1 * MON", time.UTC, time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), next(t, "0 0 * * MON", time.UTC, time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("should return the zero time for expressions that never match", func(t *testing.T) {
+		assert.True(t, next(t, "0 0 30 2 *", time.UTC, time.Now()).IsZero())
+	})
+
+	t.Run("should refuse invalid expressions", func(t *testing.T) {
+		for _, expr := range []string{"", "0 9 * *", "@every 1h", "61 * * * *"} {
+			_, err := ParseCron(expr, time.UTC)
+			assert.ErrorIs(t, err, ErrInvalidCron, expr)
+		}
+	})
+}
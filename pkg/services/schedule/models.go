@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrScheduleNotFound   = errors.New("schedule not found")
+	ErrScheduleNameEmpty  = errors.New("schedule name cannot be empty")
+	ErrScheduleNameExists = errors.New("a schedule with the same name already exists")
+	ErrInvalidTimezone    = errors.New("invalid schedule timezone")
+	ErrInvalidCron        = errors.New("invalid schedule cron expression")
+	ErrInvalidDuration    = errors.New("invalid schedule duration")
+)
+
+// maxDuration is the maximum duration of the activations of a schedule.
+const maxDuration = 31 * 24 * time.Hour
+
+// Schedule is a named cron expression evaluated in a timezone, such as the business hours or the
+// maintenance windows of an organization. A schedule with a duration is active from each of its
+// activations until the wall clock of its timezone has moved by the duration, so a schedule
+// activated at 22:00 for 8h is active until 06:00 on the nights of daylight saving time changes
+// too. Alert rules mute their notifications while one of their schedules is active, and playlists
+// play during their schedule.
+type Schedule struct {
+	Id          int64     `json:"id"`
+	Uid         string    `json:"uid"`
+	OrgId       int64     `json:"-"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Cron        string    `json:"cron"`
+	Timezone    string    `json:"timezone"`
+	Duration    string    `json:"duration"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Location returns the location in which the cron expression of the schedule is evaluated.
+func (s *Schedule) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type ScheduleCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Cron        string `json:"cron"`
+	Timezone    string `json:"timezone"`
+	Duration    string `json:"duration"`
+}
@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// maxOccurrences is the maximum number of occurrences of a schedule returned for a time range.
+const maxOccurrences = 1000
+
+// Service manages the named schedules of the organizations, the shared definition of the times at
+// which alert notifications are muted or playlists play. Other services get the occurrences of the
+// schedules with a models.GetScheduleOccurrencesQuery, or evaluate a cron expression of their
+// settings with ParseCron.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	bus           bus.Bus
+	routeRegister routing.RouteRegister
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		bus:           bus,
+		routeRegister: routeRegister,
+	}
+	s.registerAPIEndpoints()
+	s.bus.AddHandler(s.getOccurrencesQueryHandler)
+	return s
+}
+
+// GetSchedules returns the schedules of the organization, sorted by name.
+func (s *Service) GetSchedules(ctx context.Context, orgID int64) ([]*Schedule, error) {
+	return s.getSchedules(ctx, orgID)
+}
+
+// GetSchedule returns a schedule of the organization.
+func (s *Service) GetSchedule(ctx context.Context, orgID int64, uid string) (*Schedule, error) {
+	return s.getSchedule(ctx, orgID, uid)
+}
+
+// CreateSchedule creates a schedule. Schedules without a timezone use UTC, and schedules without
+// a duration are activated at instants.
+func (s *Service) CreateSchedule(ctx context.Context, orgID int64, cmd ScheduleCommand) (*Schedule, error) {
+	if err := validateSchedule(&cmd); err != nil {
+		return nil, err
+	}
+	return s.createSchedule(ctx, orgID, cmd)
+}
+
+// UpdateSchedule replaces a schedule. The uid of the schedule cannot be changed.
+func (s *Service) UpdateSchedule(ctx context.Context, orgID int64, uid string, cmd ScheduleCommand) (*Schedule, error) {
+	if err := validateSchedule(&cmd); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.getSchedule(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	setSchedule(schedule, cmd)
+	if err := s.updateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule deletes a schedule.
+func (s *Service) DeleteSchedule(ctx context.Context, orgID int64, uid string) error {
+	return s.deleteSchedule(ctx, orgID, uid)
+}
+
+// GetOccurrences returns the occurrences of the schedules that overlap the from - to range, sorted
+// by start. Unknown schedules are ignored.
+func (s *Service) GetOccurrences(ctx context.Context, orgID int64, scheduleUIDs []string, from, to time.Time) ([]*models.ScheduleOccurrence, error) {
+	result := make([]*models.ScheduleOccurrence, 0)
+	for _, uid := range scheduleUIDs {
+		schedule, err := s.getSchedule(ctx, orgID, uid)
+		if err != nil {
+			if err == ErrScheduleNotFound {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, expandSchedule(schedule, from, to)...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result, nil
+}
+
+func (s *Service) getOccurrencesQueryHandler(ctx context.Context, query *models.GetScheduleOccurrencesQuery) error {
+	result, err := s.GetOccurrences(ctx, query.OrgId, query.ScheduleUids, query.From, query.To)
+	if err != nil {
+		return err
+	}
+	query.Result = result
+	return nil
+}
+
+// expandSchedule returns the occurrences of a schedule that overlap the from - to range. The end
+// of an occurrence is its start moved by the duration on the wall clock of the timezone.
+func expandSchedule(schedule *Schedule, from, to time.Time) []*models.ScheduleOccurrence {
+	result := make([]*models.ScheduleOccurrence, 0)
+	loc := schedule.Location()
+	c, err := ParseCron(schedule.Cron, loc)
+	if err != nil {
+		// Schedules are validated when they are saved
+		return result
+	}
+	duration, _ := parseDuration(schedule.Duration)
+
+	// An occurrence starting before the range can overlap it, daylight saving time changes make it
+	// last up to a few hours more than its duration
+	t := from.Add(-duration - 3*time.Hour - time.Second)
+	for len(result) < maxOccurrences {
+		t = c.Next(t)
+		if t.IsZero() || t.After(to) {
+			break
+		}
+		end := addWallClock(t, duration, loc)
+		if end.Before(from) || (duration > 0 && end.Equal(from)) {
+			continue
+		}
+		result = append(result, &models.ScheduleOccurrence{
+			ScheduleUid: schedule.Uid,
+			Name:        schedule.Name,
+			Start:       t.In(loc),
+			End:         end,
+		})
+	}
+	return result
+}
+
+// addWallClock moves the time by the duration on the wall clock of the location.
+func addWallClock(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	if d == 0 {
+		return t
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+int(d/time.Second), 0, loc)
+}
+
+func setSchedule(schedule *Schedule, cmd ScheduleCommand) {
+	schedule.Name = cmd.Name
+	schedule.Description = cmd.Description
+	schedule.Cron = cmd.Cron
+	schedule.Timezone = cmd.Timezone
+	schedule.Duration = cmd.Duration
+}
+
+func validateSchedule(cmd *ScheduleCommand) error {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if cmd.Name == "" {
+		return ErrScheduleNameEmpty
+	}
+	if cmd.Timezone == "" {
+		cmd.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(cmd.Timezone)
+	if err != nil || strings.EqualFold(cmd.Timezone, "local") {
+		return ErrInvalidTimezone
+	}
+	cmd.Cron = strings.TrimSpace(cmd.Cron)
+	if _, err := ParseCron(cmd.Cron, loc); err != nil {
+		return err
+	}
+	if _, err := parseDuration(cmd.Duration); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d > maxDuration || d%time.Second != 0 {
+		return 0, ErrInvalidDuration
+	}
+	return d, nil
+}
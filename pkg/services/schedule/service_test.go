@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Schedules(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	b := bus.New()
+	s := ProvideService(store, b, routing.NewRouteRegister())
+
+	nights, err := s.CreateSchedule(ctx, 1, ScheduleCommand{
+		Name: " Nights ", Cron: "0 22 * * *", Timezone: "America/New_York", Duration: "8h",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Nights", nights.Name)
+
+	t.Run("should validate schedules", func(t *testing.T) {
+		_, err := s.CreateSchedule(ctx, 1, ScheduleCommand{Name: " ", Cron: "0 22 * * *"})
+		assert.ErrorIs(t, err, ErrScheduleNameEmpty)
+		_, err = s.CreateSchedule(ctx, 1, ScheduleCommand{Name: "Reports", Cron: "0 22 * * *", Timezone: "Mars/Olympus"})
+		assert.ErrorIs(t, err, ErrInvalidTimezone)
+		_, err = s.CreateSchedule(ctx, 1, ScheduleCommand{Name: "Reports", Cron: "@every 1h"})
+		assert.ErrorIs(t, err, ErrInvalidCron)
+		_, err = s.CreateSchedule(ctx, 1, ScheduleCommand{Name: "Reports", Cron: "0 22 * * *", Duration: "-1h"})
+		assert.ErrorIs(t, err, ErrInvalidDuration)
+		_, err = s.CreateSchedule(ctx, 1, ScheduleCommand{Name: "Nights", Cron: "0 22 * * *"})
+		assert.ErrorIs(t, err, ErrScheduleNameExists)
+
+		other, err := s.CreateSchedule(ctx, 2, ScheduleCommand{Name: "Nights", Cron: "0 22 * * *"})
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", other.Timezone)
+		_, err = s.GetSchedule(ctx, 1, other.Uid)
+		assert.ErrorIs(t, err, ErrScheduleNotFound)
+	})
+
+	t.Run("should end the occurrences on the wall clock across daylight saving time changes", func(t *testing.T) {
+		ny := nights.Location()
+		occurrences, err := s.GetOccurrences(ctx, 1, []string{nights.Uid, "unknown"},
+			time.Date(2022, 3, 12, 12, 0, 0, 0, ny), time.Date(2022, 3, 14, 0, 0, 0, 0, ny))
+		require.NoError(t, err)
+		require.Len(t, occurrences, 2)
+		assert.Equal(t, nights.Uid, occurrences[0].ScheduleUid)
+		assert.True(t, time.Date(2022, 3, 12, 22, 0, 0, 0, ny).Equal(occurrences[0].Start))
+		assert.True(t, time.Date(2022, 3, 13, 6, 0, 0, 0, ny).Equal(occurrences[0].End))
+		assert.Equal(t, 7*time.Hour, occurrences[0].End.Sub(occurrences[0].Start))
+		assert.Equal(t, 8*time.Hour, occurrences[1].End.Sub(occurrences[1].Start))
+	})
+
+	t.Run("should return the occurrences active at a point in time", func(t *testing.T) {
+		query := &models.GetScheduleOccurrencesQuery{OrgId: 1, ScheduleUids: []string{nights.Uid}}
+		query.From = time.Date(2022, 3, 13, 5, 0, 0, 0, nights.Location())
+		query.To = query.From
+		require.NoError(t, b.Dispatch(ctx, query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Nights", query.Result[0].Name)
+
+		query.From = time.Date(2022, 3, 13, 6, 0, 0, 0, nights.Location())
+		query.To = query.From
+		require.NoError(t, b.Dispatch(ctx, query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("should update and delete schedules", func(t *testing.T) {
+		updated, err := s.UpdateSchedule(ctx, 1, nights.Uid, ScheduleCommand{Name: "Weeknights", Cron: "0 22 * * MON-FRI", Timezone: "Europe/Paris"})
+		require.NoError(t, err)
+		assert.Equal(t, nights.Uid, updated.Uid)
+
+		schedules, err := s.GetSchedules(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, "Weeknights", schedules[0].Name)
+		assert.Equal(t, "", schedules[0].Duration)
+
+		require.NoError(t, s.DeleteSchedule(ctx, 1, nights.Uid))
+		assert.ErrorIs(t, s.DeleteSchedule(ctx, 1, nights.Uid), ErrScheduleNotFound)
+	})
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getSchedule(ctx context.Context, orgID int64, uid string) (*Schedule, error) {
+	schedule := &Schedule{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(schedule)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrScheduleNotFound
+		}
+		return nil
+	})
+	return schedule, err
+}
+
+func (s *Service) getSchedules(ctx context.Context, orgID int64) ([]*Schedule, error) {
+	result := make([]*Schedule, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&result)
+	})
+	return result, err
+}
+
+func (s *Service) createSchedule(ctx context.Context, orgID int64, cmd ScheduleCommand) (*Schedule, error) {
+	now := time.Now()
+	schedule := &Schedule{
+		Uid:     util.GenerateShortUID(),
+		OrgId:   orgID,
+		Created: now,
+		Updated: now,
+	}
+	setSchedule(schedule, cmd)
+
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateUniqueName(sess, schedule); err != nil {
+			return err
+		}
+		_, err := sess.Insert(schedule)
+		return err
+	})
+	return schedule, err
+}
+
+func (s *Service) updateSchedule(ctx context.Context, schedule *Schedule) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := validateUniqueName(sess, schedule); err != nil {
+			return err
+		}
+
+		schedule.Updated = time.Now()
+		_, err := sess.ID(schedule.Id).Cols("name", "description", "cron", "timezone", "duration", "updated").Update(schedule)
+		return err
+	})
+}
+
+func (s *Service) deleteSchedule(ctx context.Context, orgID int64, uid string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Delete(&Schedule{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrScheduleNotFound
+		}
+		return nil
+	})
+}
+
+func validateUniqueName(sess *sqlstore.DBSession, schedule *Schedule) error {
+	exists, err := sess.Table("schedule").
+		Where("org_id = ? AND name = ? AND id != ?", schedule.OrgId, schedule.Name, schedule.Id).
+		Exist()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrScheduleNameExists
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	auth := acmiddleware.Middleware(s.accessControl)
+	readEvaluator := accesscontrol.EvalPermission(ActionTasksRead)
+	writeEvaluator := accesscontrol.EvalPermission(ActionTasksWrite)
+
+	routeRegister.Group("/api/admin/scheduler", func(schedulerRoute routing.RouteRegister) {
+		schedulerRoute.Get("/", auth(middleware.ReqGrafanaAdmin, readEvaluator), routing.Wrap(s.getTasksHandler))
+		schedulerRoute.Get("/:name", auth(middleware.ReqGrafanaAdmin, readEvaluator), routing.Wrap(s.getTaskHandler))
+		schedulerRoute.Patch("/:name", auth(middleware.ReqGrafanaAdmin, writeEvaluator), routing.Wrap(s.updateTaskHandler))
+		schedulerRoute.Post("/:name/run", auth(middleware.ReqGrafanaAdmin, writeEvaluator), routing.Wrap(s.runTaskHandler))
+	})
+}
+
+// GET /api/admin/scheduler
+func (s *Service) getTasksHandler(c *models.ReqContext) response.Response {
+	tasks, err := s.GetTasks(c.Req.Context())
+	if err != nil {
+		return errorResponse(err, "Failed to get scheduled tasks")
+	}
+	return response.JSON(http.StatusOK, tasks)
+}
+
+// GET /api/admin/scheduler/:name
+func (s *Service) getTaskHandler(c *models.ReqContext) response.Response {
+	task, err := s.GetTask(c.Req.Context(), web.Params(c.Req)[":name"])
+	if err != nil {
+		return errorResponse(err, "Failed to get scheduled task")
+	}
+	return response.JSON(http.StatusOK, task)
+}
+
+// PATCH /api/admin/scheduler/:name
+func (s *Service) updateTaskHandler(c *models.ReqContext) response.Response {
+	cmd := UpdateTaskCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	task, err := s.UpdateTask(c.Req.Context(), c.UserId, web.Params(c.Req)[":name"], cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to update scheduled task")
+	}
+	return response.JSON(http.StatusOK, task)
+}
+
+// POST /api/admin/scheduler/:name/run
+func (s *Service) runTaskHandler(c *models.ReqContext) response.Response {
+	if err := s.Trigger(web.Params(c.Req)[":name"]); err != nil {
+		return errorResponse(err, "Failed to run scheduled task")
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "Scheduled task started"})
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidCron):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrTaskRunning):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
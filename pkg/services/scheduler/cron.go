@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/services/schedule"
+)
+
+// activations returns the next activation of a schedule after a time, or the zero time when there is none
+type activations interface {
+	Next(after time.Time) time.Time
+}
+
+// every activates a task at a constant interval, regardless of the wall clock
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// parseCron parses the cron expression of a task, evaluated in the local timezone, or an @every descriptor
+func parseCron(expr string) (activations, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every") {
+		interval, err := gtime.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every")))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%w %q", ErrInvalidCron, expr)
+		}
+		return every(interval), nil
+	}
+
+	c, err := schedule.ParseCron(expr, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrInvalidCron, expr)
+	}
+	return c, nil
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTaskNotFound = errors.New("scheduled task not found")
+	ErrTaskExists   = errors.New("a scheduled task with the same name is registered already")
+	ErrTaskRunning  = errors.New("the scheduled task is running already")
+	ErrInvalidCron  = errors.New("invalid scheduled task cron expression")
+)
+
+const (
+	ActionTasksRead  = "scheduler.tasks:read"
+	ActionTasksWrite = "scheduler.tasks:write"
+)
+
+// Trigger is what started a run of a task
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// Task is a job run by the scheduler, like a cleanup or a synchronization. Cron and Enabled are the defaults of the
+// schedule of the task, the administrators can change them through the API.
+type Task struct {
+	// Name identifies the task, such as ldap-sync
+	Name        string
+	Description string
+	// Cron is a cron expression evaluated in the local timezone, or an @every descriptor such as @every 1h
+	Cron    string
+	Enabled bool
+	// Exclusive tasks are run by a single Grafana instance of a cluster at each activation, the others by every
+	// instance, like the cleanup of their temporary files
+	Exclusive bool
+	// Timeout bounds the runs of the task, they are not bounded when it is zero
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Settings are the schedule of a task set by the administrators, which replaces its default schedule. The default
+// cron expression is used when Cron is empty.
+type Settings struct {
+	Cron      string    `json:"cron"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedBy int64     `json:"updatedBy"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Run is the report of a run of a task
+type Run struct {
+	Trigger  Trigger    `json:"trigger"`
+	Status   RunStatus  `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// TaskStatus is the schedule and the state of a task. LastRun is the last run of any Grafana instance, Running tells
+// whether this instance is running the task.
+type TaskStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Cron        string     `json:"cron"`
+	DefaultCron string     `json:"defaultCron"`
+	Enabled     bool       `json:"enabled"`
+	Exclusive   bool       `json:"exclusive"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"nextRun,omitempty"`
+	LastRun     *Run       `json:"lastRun,omitempty"`
+}
+
+// UpdateTaskCommand changes the schedule of a task, the fields which are not set are left as they are. An empty cron
+// expression restores the default one.
+type UpdateTaskCommand struct {
+	Cron    *string `json:"cron"`
+	Enabled *bool   `json:"enabled"`
+}
@@ -0,0 +1,435 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	kvNamespace = "scheduler"
+
+	// reloadInterval is how often the schedules are reloaded, so that the changes made through the other Grafana
+	// instances of a cluster are applied
+	reloadInterval = time.Minute
+)
+
+// Service runs the tasks registered by the other services, like the cleanups, the synchronizations and the exports,
+// on their cron schedule. The administrators can inspect the tasks, change their schedule, disable them and run them
+// on demand through the /api/admin/scheduler endpoints.
+type Service struct {
+	serverLock    *serverlock.ServerLockService
+	kv            *kvstore.NamespacedKVStore
+	accessControl accesscontrol.AccessControl
+	log           log.Logger
+	now           func() time.Time
+
+	mu      sync.Mutex
+	tasks   map[string]*Task
+	running map[string]bool
+	// changed is signaled when a task is registered or its schedule changed
+	changed chan struct{}
+}
+
+func ProvideService(serverLockService *serverlock.ServerLockService, kvStore kvstore.KVStore,
+	ac accesscontrol.AccessControl, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		serverLock:    serverLockService,
+		kv:            kvstore.WithNamespace(kvStore, 0, kvNamespace),
+		accessControl: ac,
+		log:           log.New("scheduler"),
+		now:           time.Now,
+		tasks:         make(map[string]*Task),
+		running:       make(map[string]bool),
+		changed:       make(chan struct{}, 1),
+	}
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s
+}
+
+// Register adds a task to run on its schedule. It fails when the name is taken or the cron expression is invalid.
+func (s *Service) Register(task Task) error {
+	if _, err := parseCron(task.Cron); err != nil {
+		return fmt.Errorf("task %s: %w", task.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[task.Name]; ok {
+		return fmt.Errorf("task %s: %w", task.Name, ErrTaskExists)
+	}
+	s.tasks[task.Name] = &task
+	s.notifyChanged()
+	return nil
+}
+
+// Run runs the enabled tasks on their schedule. The exclusive tasks are run by a single Grafana instance when running
+// in a cluster.
+func (s *Service) Run(ctx context.Context) error {
+	// next holds the next activation of each enabled task, for the cron expression it was computed from
+	type activation struct {
+		cron string
+		next time.Time
+	}
+	next := make(map[string]activation)
+
+	for {
+		now := s.now()
+		wait := reloadInterval
+		for _, task := range s.registeredTasks() {
+			cron, enabled := s.schedule(ctx, task)
+			if !enabled {
+				delete(next, task.Name)
+				continue
+			}
+			schedule, err := parseCron(cron)
+			if err != nil {
+				s.log.Error("Invalid schedule of task", "task", task.Name, "cron", cron, "error", err)
+				continue
+			}
+
+			a, ok := next[task.Name]
+			if !ok || a.cron != cron {
+				a = activation{cron: cron, next: schedule.Next(now)}
+			}
+			if !a.next.IsZero() && !a.next.After(now) {
+				s.startScheduled(ctx, task, a.next, schedule)
+				a.next = schedule.Next(now)
+			}
+			next[task.Name] = a
+
+			if !a.next.IsZero() && a.next.Sub(now) < wait {
+				wait = a.next.Sub(now)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// GetTasks returns the status of all the tasks, sorted by name
+func (s *Service) GetTasks(ctx context.Context) ([]*TaskStatus, error) {
+	tasks := s.registeredTasks()
+	result := make([]*TaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		status, err := s.status(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// GetTask returns the status of the task
+func (s *Service) GetTask(ctx context.Context, name string) (*TaskStatus, error) {
+	task, err := s.getTask(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(ctx, task)
+}
+
+// UpdateTask changes the schedule of the task, or enables or disables it, on behalf of the user
+func (s *Service) UpdateTask(ctx context.Context, userID int64, name string, cmd UpdateTaskCommand) (*TaskStatus, error) {
+	task, err := s.getTask(name)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settings(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Cron != nil {
+		cron := strings.TrimSpace(*cmd.Cron)
+		if cron != "" {
+			if _, err := parseCron(cron); err != nil {
+				return nil, err
+			}
+		}
+		settings.Cron = cron
+	}
+	if cmd.Enabled != nil {
+		settings.Enabled = *cmd.Enabled
+	}
+	settings.UpdatedBy = userID
+	settings.Updated = s.now()
+	if err := s.saveJSON(ctx, settingsKey(name), settings); err != nil {
+		return nil, err
+	}
+	s.notifyChanged()
+
+	s.log.Info("Updated the schedule of task", "task", name, "cron", settings.Cron, "enabled", settings.Enabled, "userId", userID)
+	return s.status(ctx, task)
+}
+
+// Trigger starts a run of the task in the background, whether it is enabled or not, and returns ErrTaskRunning when
+// this instance is running it already.
+func (s *Service) Trigger(name string) error {
+	task, err := s.getTask(name)
+	if err != nil {
+		return err
+	}
+	if !s.setRunning(name) {
+		return ErrTaskRunning
+	}
+
+	go func() {
+		defer s.clearRunning(name)
+		s.execute(context.Background(), task, TriggerManual)
+	}()
+	return nil
+}
+
+// startScheduled starts the run of the task activated at the time, unless this instance is running it already. The
+// exclusive tasks are locked so that the other instances skip the activation.
+func (s *Service) startScheduled(ctx context.Context, task *Task, activation time.Time, schedule activations) {
+	if !s.setRunning(task.Name) {
+		s.log.Warn("Skipping the activation of task, it is running already", "task", task.Name)
+		return
+	}
+
+	go func() {
+		defer s.clearRunning(task.Name)
+		if !task.Exclusive || s.serverLock == nil {
+			s.execute(ctx, task, TriggerSchedule)
+			return
+		}
+
+		// The other instances skip the activation if it has been run within half the interval to the next one
+		maxInterval := schedule.Next(activation).Sub(activation) / 2
+		err := s.serverLock.LockAndExecute(ctx, "scheduler "+task.Name, maxInterval, func(ctx context.Context) {
+			s.execute(ctx, task, TriggerSchedule)
+		})
+		if err != nil {
+			s.log.Error("Failed to lock and execute task", "task", task.Name, "error", err)
+		}
+	}()
+}
+
+// execute runs the task and saves its report as the last run
+func (s *Service) execute(ctx context.Context, task *Task, trigger Trigger) {
+	run := &Run{Trigger: trigger, Status: RunStatusRunning, Started: s.now()}
+	s.saveRun(ctx, task.Name, run)
+
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+	err := task.Run(ctx)
+
+	finished := s.now()
+	run.Finished = &finished
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+		s.log.Error("Task failed", "task", task.Name, "trigger", trigger, "error", err, "duration", finished.Sub(run.Started))
+	} else {
+		run.Status = RunStatusSucceeded
+		s.log.Debug("Task succeeded", "task", task.Name, "trigger", trigger, "duration", finished.Sub(run.Started))
+	}
+	s.saveRun(ctx, task.Name, run)
+}
+
+func (s *Service) status(ctx context.Context, task *Task) (*TaskStatus, error) {
+	settings, err := s.settings(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TaskStatus{
+		Name:        task.Name,
+		Description: task.Description,
+		Cron:        task.Cron,
+		DefaultCron: task.Cron,
+		Enabled:     settings.Enabled,
+		Exclusive:   task.Exclusive,
+		Running:     s.isRunning(task.Name),
+	}
+	if settings.Cron != "" {
+		status.Cron = settings.Cron
+	}
+	if status.Enabled {
+		if schedule, err := parseCron(status.Cron); err == nil {
+			if next := schedule.Next(s.now()); !next.IsZero() {
+				status.NextRun = &next
+			}
+		}
+	}
+
+	run := &Run{}
+	ok, err := s.getJSON(ctx, runKey(task.Name), run)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		status.LastRun = run
+	}
+	return status, nil
+}
+
+// schedule returns the cron expression of the task and whether it is enabled, the defaults of the task are used when
+// its settings cannot be read
+func (s *Service) schedule(ctx context.Context, task *Task) (string, bool) {
+	settings, err := s.settings(ctx, task)
+	if err != nil {
+		s.log.Warn("Failed to get the schedule of task, using its default schedule", "task", task.Name, "error", err)
+		return task.Cron, task.Enabled
+	}
+	if settings.Cron == "" {
+		return task.Cron, settings.Enabled
+	}
+	return settings.Cron, settings.Enabled
+}
+
+// settings returns the settings of the task, its defaults when the administrators have not changed them
+func (s *Service) settings(ctx context.Context, task *Task) (*Settings, error) {
+	settings := &Settings{Enabled: task.Enabled}
+	if _, err := s.getJSON(ctx, settingsKey(task.Name), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *Service) getTask(name string) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (s *Service) registeredTasks() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks
+}
+
+func (s *Service) notifyChanged() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) isRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[name]
+}
+
+// setRunning returns false when the task is running already
+func (s *Service) setRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Service) clearRunning(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+// saveRun saves the report as the last run of the task, a run is not failed because its report cannot be saved
+func (s *Service) saveRun(ctx context.Context, name string, run *Run) {
+	if err := s.saveJSON(ctx, runKey(name), run); err != nil {
+		s.log.Warn("Failed to save the report of task", "task", name, "error", err)
+	}
+}
+
+func (s *Service) getJSON(ctx context.Context, key string, v interface{}) (bool, error) {
+	value, ok, err := s.kv.Get(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Service) saveJSON(ctx context.Context, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.kv.Set(ctx, key, string(value))
+}
+
+func settingsKey(name string) string {
+	return name + "/settings"
+}
+
+func runKey(name string) string {
+	return name + "/last_run"
+}
+
+func (s *Service) registerRoles() error {
+	reader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:scheduler.tasks:reader",
+			DisplayName: "Scheduled tasks reader",
+			Description: "List the scheduled tasks of the server with the report of their last run.",
+			Group:       "Settings",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionTasksRead},
+			},
+		},
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:scheduler.tasks:writer",
+			DisplayName: "Scheduled tasks writer",
+			Description: "List, reschedule, enable, disable and run the scheduled tasks of the server.",
+			Group:       "Settings",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionTasksRead},
+				{Action: ActionTasksWrite},
+			},
+		},
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	return s.accessControl.DeclareFixedRoles(reader, writer)
+}
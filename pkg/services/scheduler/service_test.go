@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Tasks(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestService(t)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	var runs int32
+	err := s.Register(Task{Name: "report", Description: "Sends the reports", Cron: "0 6 * * *", Enabled: true,
+		Run: func(context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}})
+	require.NoError(t, err)
+	err = s.Register(Task{Name: "export", Cron: "@every 24h", Exclusive: true, Run: func(context.Context) error {
+		return errors.New("storage unavailable")
+	}})
+	require.NoError(t, err)
+
+	t.Run("should refuse duplicated names and invalid cron expressions", func(t *testing.T) {
+		err := s.Register(Task{Name: "report", Cron: "@hourly"})
+		require.ErrorIs(t, err, ErrTaskExists)
+		err = s.Register(Task{Name: "invalid", Cron: "every day"})
+		require.ErrorIs(t, err, ErrInvalidCron)
+		err = s.Register(Task{Name: "invalid", Cron: "@every 0s"})
+		require.ErrorIs(t, err, ErrInvalidCron)
+	})
+
+	t.Run("should list the tasks with their default schedule", func(t *testing.T) {
+		tasks, err := s.GetTasks(ctx)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+
+		assert.Equal(t, "export", tasks[0].Name)
+		assert.False(t, tasks[0].Enabled)
+		assert.True(t, tasks[0].Exclusive)
+		assert.Nil(t, tasks[0].NextRun)
+
+		assert.Equal(t, "report", tasks[1].Name)
+		assert.Equal(t, "0 6 * * *", tasks[1].Cron)
+		assert.True(t, tasks[1].Enabled)
+		require.NotNil(t, tasks[1].NextRun)
+		assert.Nil(t, tasks[1].LastRun)
+
+		_, err = s.GetTask(ctx, "unknown")
+		require.ErrorIs(t, err, ErrTaskNotFound)
+	})
+
+	t.Run("should change the schedule of a task", func(t *testing.T) {
+		cron, enabled := "@every 1h", true
+		_, err := s.UpdateTask(ctx, 1, "export", UpdateTaskCommand{Cron: stringPtr("every hour")})
+		require.ErrorIs(t, err, ErrInvalidCron)
+
+		task, err := s.UpdateTask(ctx, 1, "export", UpdateTaskCommand{Cron: &cron, Enabled: &enabled})
+		require.NoError(t, err)
+		assert.Equal(t, "@every 1h", task.Cron)
+		assert.Equal(t, "@every 24h", task.DefaultCron)
+		assert.True(t, task.Enabled)
+		require.NotNil(t, task.NextRun)
+		assert.Equal(t, now.Add(time.Hour), *task.NextRun)
+
+		task, err = s.UpdateTask(ctx, 1, "export", UpdateTaskCommand{Cron: stringPtr("")})
+		require.NoError(t, err)
+		assert.Equal(t, "@every 24h", task.Cron)
+		assert.True(t, task.Enabled)
+	})
+
+	t.Run("should run a task on demand and save the report of the run", func(t *testing.T) {
+		require.NoError(t, s.Trigger("report"))
+		task := waitForRun(t, s, "report")
+		assert.Equal(t, TriggerManual, task.LastRun.Trigger)
+		assert.Equal(t, RunStatusSucceeded, task.LastRun.Status)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+		require.NoError(t, s.Trigger("export"))
+		task = waitForRun(t, s, "export")
+		assert.Equal(t, RunStatusFailed, task.LastRun.Status)
+		assert.Equal(t, "storage unavailable", task.LastRun.Error)
+
+		require.ErrorIs(t, s.Trigger("unknown"), ErrTaskNotFound)
+	})
+}
+
+func TestService_Run(t *testing.T) {
+	s := setupTestService(t)
+
+	ran := make(chan struct{}, 1)
+	err := s.Register(Task{Name: "sync", Cron: "@every 200ms", Enabled: true, Run: func(context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the task has not been run on its schedule")
+	}
+	task := waitForRun(t, s, "sync")
+	assert.Equal(t, TriggerSchedule, task.LastRun.Trigger)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func setupTestService(t *testing.T) *Service {
+	t.Helper()
+
+	store := sqlstore.InitTestDB(t)
+	return ProvideService(nil, kvstore.ProvideService(store), accesscontrolmock.New(), routing.NewRouteRegister())
+}
+
+// waitForRun waits for the run of the task to be finished and returns its status
+func waitForRun(t *testing.T, s *Service, name string) *TaskStatus {
+	t.Helper()
+
+	var task *TaskStatus
+	require.Eventually(t, func() bool {
+		var err error
+		task, err = s.GetTask(context.Background(), name)
+		require.NoError(t, err)
+		return !task.Running && task.LastRun != nil && task.LastRun.Finished != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return task
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
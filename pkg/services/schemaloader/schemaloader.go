@@ -3,6 +3,10 @@ package schemaloader
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
+	errs "cuelang.org/go/cue/errors"
 
 	"github.com/grafana/grafana"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -89,6 +93,80 @@ func (rs *SchemaLoaderService) DashboardTrimDefaults(input simplejson.Json) (sim
 	return *output, nil
 }
 
+// SchemaError is a mismatch between a dashboard and the dashboard schema
+type SchemaError struct {
+	// Path is the path of the mismatched value in the dashboard, such as panels[0].gridPos.w
+	Path    string
+	Message string
+}
+
+// maxSchemaErrorLength is the length the messages of the schema errors are truncated to, the messages about
+// incomplete values print the whole value
+const maxSchemaErrorLength = 200
+
+// DashboardValidate returns the mismatches between the dashboard and the latest dashboard schema, one per path. The
+// errors of the alternatives of the disjunctions, such as the panel types, are not reported on their own.
+func (rs *SchemaLoaderService) DashboardValidate(input *simplejson.Json) ([]SchemaError, error) {
+	val, err := input.Map()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(removeNils(val))
+	if err != nil {
+		return nil, err
+	}
+
+	dsSchema := schema.Find(rs.DashFamily, schema.Latest())
+	validationErr := dsSchema.Validate(schema.Resource{Value: string(data)})
+	if validationErr == nil {
+		return nil, nil
+	}
+
+	result := []SchemaError{}
+	byPath := map[string]int{}
+	for _, e := range errs.Errors(validationErr) {
+		format, args := e.Msg()
+		message := fmt.Sprintf(format, args...)
+		if strings.HasSuffix(message, "errors in empty disjunction:") {
+			continue
+		}
+		if len(message) > maxSchemaErrorLength {
+			message = message[:maxSchemaErrorLength] + "..."
+		}
+
+		path := schemaErrorPath(e.Path())
+		if i, ok := byPath[path]; ok {
+			if !strings.Contains(result[i].Message, message) {
+				result[i].Message += "; " + message
+			}
+			continue
+		}
+		byPath[path] = len(result)
+		result = append(result, SchemaError{Path: path, Message: message})
+	}
+	return result, nil
+}
+
+// schemaErrorPath returns the path of a value in the dashboard from its path in the schema family, which starts with
+// the lineage and the version of the schema
+func schemaErrorPath(selectors []string) string {
+	if len(selectors) >= 4 && selectors[0] == "Family" && selectors[1] == "lineages" {
+		selectors = selectors[4:]
+	}
+	var b strings.Builder
+	for _, selector := range selectors {
+		if _, err := strconv.Atoi(selector); err == nil {
+			b.WriteString("[" + selector + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(selector)
+	}
+	return b.String()
+}
+
 func removeNils(initialMap map[string]interface{}) map[string]interface{} {
 	withoutNils := map[string]interface{}{}
 	for key, value := range initialMap {
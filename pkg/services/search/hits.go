@@ -8,6 +8,8 @@ const (
 	DashHitDB     HitType = "dash-db"
 	DashHitHome   HitType = "dash-home"
 	DashHitFolder HitType = "dash-folder"
+	// DashHitSavedSearch is a saved search listed as a virtual folder
+	DashHitSavedSearch HitType = "dash-saved-search"
 )
 
 type Hit struct {
@@ -10,10 +10,11 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 )
 
-func ProvideService(cfg *setting.Cfg, bus bus.Bus) *SearchService {
+func ProvideService(cfg *setting.Cfg, bus bus.Bus, titleAnalyzer TitleAnalyzer) *SearchService {
 	s := &SearchService{
-		Cfg: cfg,
-		Bus: bus,
+		Cfg:           cfg,
+		Bus:           bus,
+		titleAnalyzer: titleAnalyzer,
 		sortOptions: map[string]SortOption{
 			SortAlphaAsc.Name:  SortAlphaAsc,
 			SortAlphaDesc.Name: SortAlphaDesc,
@@ -36,6 +37,8 @@ type Query struct {
 	FolderIds    []int64
 	Permission   models.PermissionType
 	Sort         string
+	// Filters are added to the filters used to search for dashboards
+	Filters []interface{}
 
 	Result HitList
 }
@@ -55,15 +58,25 @@ type FindPersistedDashboardsQuery struct {
 	Sort         SortOption
 
 	Filters []interface{}
+	// TitleTerms are the terms of the title analyzed by the search analyzer of the organization, the dashboards are
+	// matched by the terms of their analyzed titles as well
+	TitleTerms []string
 
 	Result HitList
 }
 
+// TitleAnalyzer analyzes the titles searched for with the search analyzer of the organization
+type TitleAnalyzer interface {
+	// TitleTerms returns the analyzed terms of the title, or none when the organization has no search analyzer
+	TitleTerms(ctx context.Context, orgID int64, title string) ([]string, error)
+}
+
 type SearchService struct {
 	Bus bus.Bus
 	Cfg *setting.Cfg
 
-	sortOptions map[string]SortOption
+	sortOptions   map[string]SortOption
+	titleAnalyzer TitleAnalyzer
 }
 
 func (s *SearchService) searchHandler(ctx context.Context, query *Query) error {
@@ -78,6 +91,15 @@ func (s *SearchService) searchHandler(ctx context.Context, query *Query) error {
 		Limit:        query.Limit,
 		Page:         query.Page,
 		Permission:   query.Permission,
+		Filters:      query.Filters,
+	}
+
+	if query.Title != "" && s.titleAnalyzer != nil {
+		terms, err := s.titleAnalyzer.TitleTerms(ctx, query.SignedInUser.OrgId, query.Title)
+		if err != nil {
+			return err
+		}
+		dashboardQuery.TitleTerms = terms
 	}
 
 	if sortOpt, exists := s.sortOptions[query.Sort]; exists {
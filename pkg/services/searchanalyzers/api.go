@@ -0,0 +1,46 @@
+package searchanalyzers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/textanalysis"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/org/search/analyzer", func(analyzerRoute routing.RouteRegister) {
+		analyzerRoute.Get("/", routing.Wrap(s.getSettingsHandler))
+		analyzerRoute.Put("/", routing.Wrap(s.setSettingsHandler))
+	}, middleware.ReqOrgAdmin)
+}
+
+// GET /api/org/search/analyzer
+func (s *Service) getSettingsHandler(c *models.ReqContext) response.Response {
+	settings, err := s.GetSettings(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the search analyzer settings", err)
+	}
+	return response.JSON(http.StatusOK, settings)
+}
+
+// PUT /api/org/search/analyzer
+func (s *Service) setSettingsHandler(c *models.ReqContext) response.Response {
+	cmd := Settings{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	settings, err := s.SetSettings(c.Req.Context(), c.OrgId, cmd)
+	switch {
+	case errors.Is(err, textanalysis.ErrUnknownLanguage):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to set the search analyzer settings", err)
+	}
+	return response.JSON(http.StatusOK, settings)
+}
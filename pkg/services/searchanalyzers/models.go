@@ -0,0 +1,18 @@
+package searchanalyzers
+
+import "github.com/grafana/grafana/pkg/components/textanalysis"
+
+// Settings are the search analyzer settings of an organization
+type Settings struct {
+	// Enabled matches the dashboards by the terms of their analyzed titles, in addition to the dashboards whose title
+	// contains the title searched for
+	Enabled bool `json:"enabled"`
+	// Language of the stemmer, such as "english", the words are not stemmed when empty
+	Language string `json:"language,omitempty"`
+	// FoldDiacritics removes the diacritics, so that "resume" matches "résumé"
+	FoldDiacritics bool `json:"foldDiacritics"`
+}
+
+func (s Settings) analyzer() textanalysis.Analyzer {
+	return textanalysis.Analyzer{Language: s.Language, FoldDiacritics: s.FoldDiacritics}
+}
@@ -0,0 +1,121 @@
+package searchanalyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	kvNamespace = "search_analyzer"
+	kvKey       = "settings"
+
+	// cacheTTL is how long the settings of the organizations are cached, the changes made through the other Grafana
+	// instances of a cluster apply after it
+	cacheTTL = time.Minute
+)
+
+// Service analyzes the titles of the dashboards with the search analyzer of their organization, so that the titles
+// in languages the LIKE queries do not match well can be searched: the words are matched regardless of the case of
+// their letters and, depending on the settings, of their diacritics and inflections, and the Chinese, Japanese and
+// Korean titles are matched by their bigrams. The analyzed terms of the titles are indexed when the dashboards are
+// searched, the dashboards changed since the last search are indexed again.
+type Service struct {
+	sqlStore *sqlstore.SQLStore
+	kv       kvstore.KVStore
+	cache    *localcache.CacheService
+	log      log.Logger
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore, routeRegister routing.RouteRegister, cacheRegistry *localcache.Registry) *Service {
+	s := &Service{
+		sqlStore: sqlStore,
+		kv:       kvStore,
+		cache:    localcache.NewNamed("search-analyzers", cacheTTL, 2*cacheTTL),
+		log:      log.New("searchanalyzers"),
+	}
+	cacheRegistry.Register(s.cache)
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// GetSettings returns the search analyzer settings of the organization
+func (s *Service) GetSettings(ctx context.Context, orgID int64) (*Settings, error) {
+	cacheKey := strconv.FormatInt(orgID, 10)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		return cached.(*Settings), nil
+	}
+
+	settings := &Settings{}
+	value, ok, err := s.kv.Get(ctx, orgID, kvNamespace, kvKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := json.Unmarshal([]byte(value), settings); err != nil {
+			return nil, fmt.Errorf("invalid search analyzer settings of organization %d: %w", orgID, err)
+		}
+	}
+
+	s.cache.Set(cacheKey, settings, 0)
+	return settings, nil
+}
+
+// SetSettings changes the search analyzer settings of the organization. The titles of its dashboards are analyzed
+// again with the new settings at the next search.
+func (s *Service) SetSettings(ctx context.Context, orgID int64, settings Settings) (*Settings, error) {
+	if err := settings.analyzer().Validate(); err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.kv.Set(ctx, orgID, kvNamespace, kvKey, string(value)); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(strconv.FormatInt(orgID, 10))
+
+	if !settings.Enabled {
+		if err := s.deleteIndex(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.log.Info("Search analyzer settings changed", "orgId", orgID, "enabled", settings.Enabled, "analyzer", settings.analyzer())
+	return &settings, nil
+}
+
+// TitleTerms returns the analyzed terms of the title searched for in the organization, once the titles of its
+// dashboards are indexed. It returns none when the search analyzer of the organization is disabled.
+func (s *Service) TitleTerms(ctx context.Context, orgID int64, title string) ([]string, error) {
+	settings, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	analyzer := settings.analyzer()
+	terms := analyzer.Analyze(title)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if err := s.syncIndex(ctx, orgID, analyzer); err != nil {
+		// The dashboards whose title contains the title searched for are still found
+		s.log.Warn("Failed to index the titles of the dashboards", "orgId", orgID, "error", err)
+		return nil, nil
+	}
+	return terms, nil
+}
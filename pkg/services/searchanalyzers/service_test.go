@@ -0,0 +1,92 @@
+package searchanalyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/textanalysis"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_TitleTerms(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	s := ProvideService(store, kvstore.ProvideService(store), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	user := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+
+	save := func(t *testing.T, title string) *models.Dashboard {
+		t.Helper()
+		dash, err := store.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Overwrite: true, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title})})
+		require.NoError(t, err)
+		return dash
+	}
+	searchTitles := func(t *testing.T, title string) []string {
+		t.Helper()
+		terms, err := s.TitleTerms(ctx, 1, title)
+		require.NoError(t, err)
+		query := &search.FindPersistedDashboardsQuery{Title: title, TitleTerms: terms, SignedInUser: user}
+		require.NoError(t, store.SearchDashboards(ctx, query))
+		titles := make([]string, 0, len(query.Result))
+		for _, hit := range query.Result {
+			titles = append(titles, hit.Title)
+		}
+		return titles
+	}
+
+	save(t, "Résumé des réseaux")
+	save(t, "服务器监控")
+	monitoring := save(t, "Monitoring Servers")
+
+	t.Run("should only match the titles containing the title searched for by default", func(t *testing.T) {
+		terms, err := s.TitleTerms(ctx, 1, "resume")
+		require.NoError(t, err)
+		assert.Empty(t, terms)
+		assert.Empty(t, searchTitles(t, "resume"))
+		assert.Equal(t, []string{"服务器监控"}, searchTitles(t, "监控"))
+	})
+
+	t.Run("should match the analyzed titles", func(t *testing.T) {
+		_, err := s.SetSettings(ctx, 1, Settings{Enabled: true, Language: textanalysis.LanguageEnglish, FoldDiacritics: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Résumé des réseaux"}, searchTitles(t, "resume"))
+		assert.Equal(t, []string{"服务器监控"}, searchTitles(t, "服务器 监控"))
+		assert.Equal(t, []string{"Monitoring Servers"}, searchTitles(t, "server monitored"))
+		assert.Empty(t, searchTitles(t, "server resume"))
+	})
+
+	t.Run("should index the changed dashboards again", func(t *testing.T) {
+		monitoring.Data.Set("id", monitoring.Id)
+		monitoring.Data.Set("uid", monitoring.Uid)
+		monitoring.Data.Set("title", "Surveillance des serveurs")
+		_, err := store.SaveDashboard(models.SaveDashboardCommand{OrgId: 1, Overwrite: true, Dashboard: monitoring.Data})
+		require.NoError(t, err)
+
+		assert.Empty(t, searchTitles(t, "monitoring"))
+		assert.Equal(t, []string{"Surveillance des serveurs"}, searchTitles(t, "serveur"))
+	})
+
+	t.Run("should delete the index when disabled", func(t *testing.T) {
+		_, err := s.SetSettings(ctx, 1, Settings{})
+		require.NoError(t, err)
+
+		count, err := store.NewSession(ctx).Table("dashboard_search_term").Where("org_id = ?", 1).Count()
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Empty(t, searchTitles(t, "resume"))
+	})
+
+	t.Run("should refuse unknown languages", func(t *testing.T) {
+		_, err := s.SetSettings(ctx, 1, Settings{Enabled: true, Language: "klingon"})
+		assert.ErrorIs(t, err, textanalysis.ErrUnknownLanguage)
+	})
+}
@@ -0,0 +1,92 @@
+package searchanalyzers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/textanalysis"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// deleteBatchSize bounds the number of dashboards deleted from the index by a statement
+const deleteBatchSize = 500
+
+type dashboardSearchIndex struct {
+	OrgId       int64
+	DashboardId int64
+	Version     int
+	Analyzer    string
+}
+
+type dashboardSearchTerm struct {
+	OrgId       int64
+	DashboardId int64
+	Term        string
+}
+
+type staleDashboard struct {
+	Id      int64
+	Title   string
+	Version int
+}
+
+// syncIndex indexes the terms of the titles of the dashboards of the organization which were not indexed with the
+// analyzer at their current version
+func (s *Service) syncIndex(ctx context.Context, orgID int64, analyzer textanalysis.Analyzer) error {
+	var stale []staleDashboard
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(`SELECT dashboard.id, dashboard.title, dashboard.version FROM dashboard
+			LEFT OUTER JOIN dashboard_search_index ON dashboard_search_index.dashboard_id = dashboard.id
+				AND dashboard_search_index.version = dashboard.version AND dashboard_search_index.analyzer = ?
+			WHERE dashboard.org_id = ? AND dashboard_search_index.dashboard_id IS NULL`, analyzer.String(), orgID).Find(&stale)
+	})
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+
+	indexes := make([]dashboardSearchIndex, 0, len(stale))
+	terms := make([]dashboardSearchTerm, 0)
+	ids := make([]interface{}, 0, len(stale))
+	for _, dash := range stale {
+		ids = append(ids, dash.Id)
+		indexes = append(indexes, dashboardSearchIndex{OrgId: orgID, DashboardId: dash.Id, Version: dash.Version, Analyzer: analyzer.String()})
+		for _, term := range analyzer.Analyze(dash.Title) {
+			terms = append(terms, dashboardSearchTerm{OrgId: orgID, DashboardId: dash.Id, Term: term})
+		}
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for start := 0; start < len(ids); start += deleteBatchSize {
+			end := start + deleteBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			batch := ids[start:end]
+			in := "(?" + strings.Repeat(",?", len(batch)-1) + ")"
+			for _, table := range []string{"dashboard_search_index", "dashboard_search_term"} {
+				args := append([]interface{}{"DELETE FROM " + table + " WHERE dashboard_id IN " + in}, batch...)
+				if _, err := sess.Exec(args...); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := sess.InsertBatch("dashboard_search_index", indexes); err != nil {
+			return err
+		}
+		_, err := sess.InsertBatch("dashboard_search_term", terms)
+		return err
+	})
+}
+
+// deleteIndex deletes the terms of the titles of the dashboards of the organization
+func (s *Service) deleteIndex(ctx context.Context, orgID int64) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, table := range []string{"dashboard_search_index", "dashboard_search_term"} {
+			if _, err := sess.Exec("DELETE FROM "+table+" WHERE org_id = ?", orgID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -1,6 +1,8 @@
 package searchusers
 
 import (
+	"strings"
+
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
@@ -59,7 +61,7 @@ func (s *OSSService) SearchUser(c *models.ReqContext) (*models.SearchUsersQuery,
 		}
 	}
 
-	query := &models.SearchUsersQuery{Query: searchQuery, Filters: filters, Page: page, Limit: perPage}
+	query := &models.SearchUsersQuery{Query: searchQuery, Filters: filters, Page: page, Limit: perPage, IsDeleted: c.QueryBool("deleted")}
 	if err := s.bus.Dispatch(c.Req.Context(), query); err != nil {
 		return nil, err
 	}
@@ -81,6 +83,11 @@ func (s *OSSService) SearchUser(c *models.ReqContext) (*models.SearchUsersQuery,
 }
 
 func GetAuthProviderLabel(authModule string) string {
+	// the authentication providers of the app plugins
+	if strings.HasPrefix(authModule, "plugin_") {
+		return "Plugin"
+	}
+
 	switch authModule {
 	case "oauth_github":
 		return "GitHub"
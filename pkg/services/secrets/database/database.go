@@ -32,7 +32,7 @@ func (ss *SecretsStoreImpl) GetDataKey(ctx context.Context, name string) (*secre
 	err := ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
 		var err error
 		exists, err = sess.Table(dataKeysTable).
-			Where("name = ? AND active = ?", name, ss.sqlStore.Dialect.BooleanStr(true)).
+			Where("name = ?", name).
 			Get(dataKey)
 		return err
 	})
@@ -49,6 +49,32 @@ func (ss *SecretsStoreImpl) GetDataKey(ctx context.Context, name string) (*secre
 	return dataKey, nil
 }
 
+// GetCurrentDataKey returns the last active data key of the label
+func (ss *SecretsStoreImpl) GetCurrentDataKey(ctx context.Context, label string) (*secrets.DataKey, error) {
+	dataKey := &secrets.DataKey{}
+	var exists bool
+
+	err := ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.Table(dataKeysTable).
+			Where("label = ? AND active = ?", label, ss.sqlStore.Dialect.BooleanStr(true)).
+			Desc("created").
+			Get(dataKey)
+		return err
+	})
+
+	if err != nil {
+		ss.log.Error("Failed to get current data key", "err", err, "label", label)
+		return nil, fmt.Errorf("failed getting current data key: %w", err)
+	}
+
+	if !exists {
+		return nil, secrets.ErrDataKeyNotFound
+	}
+
+	return dataKey, nil
+}
+
 func (ss *SecretsStoreImpl) GetAllDataKeys(ctx context.Context) ([]*secrets.DataKey, error) {
 	result := make([]*secrets.DataKey, 0)
 	err := ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
@@ -87,3 +113,22 @@ func (ss *SecretsStoreImpl) DeleteDataKey(ctx context.Context, name string) erro
 		return err
 	})
 }
+
+// DisableDataKeys deactivates all the data keys, they are still used to decrypt the secrets but no longer to
+// encrypt them
+func (ss *SecretsStoreImpl) DisableDataKeys(ctx context.Context) error {
+	return ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("UPDATE "+dataKeysTable+" SET active = ?, updated = ? WHERE active = ?",
+			ss.sqlStore.Dialect.BooleanStr(false), time.Now(), ss.sqlStore.Dialect.BooleanStr(true))
+		return err
+	})
+}
+
+// DisableScopeDataKeys deactivates the data keys of a scope
+func (ss *SecretsStoreImpl) DisableScopeDataKeys(ctx context.Context, scope string) error {
+	return ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("UPDATE "+dataKeysTable+" SET active = ?, updated = ? WHERE active = ? AND scope = ?",
+			ss.sqlStore.Dialect.BooleanStr(false), time.Now(), ss.sqlStore.Dialect.BooleanStr(true), scope)
+		return err
+	})
+}
@@ -2,6 +2,7 @@ package fakes
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"xorm.io/xorm"
@@ -23,6 +24,19 @@ func (f FakeSecretsStore) GetDataKey(_ context.Context, name string) (*secrets.D
 	return key, nil
 }
 
+func (f FakeSecretsStore) GetCurrentDataKey(_ context.Context, label string) (*secrets.DataKey, error) {
+	var current *secrets.DataKey
+	for _, key := range f.store {
+		if key.Label == label && key.Active && (current == nil || key.Created.After(current.Created)) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, secrets.ErrDataKeyNotFound
+	}
+	return current, nil
+}
+
 func (f FakeSecretsStore) GetAllDataKeys(_ context.Context) ([]*secrets.DataKey, error) {
 	result := make([]*secrets.DataKey, 0)
 	for _, key := range f.store {
@@ -32,16 +46,32 @@ func (f FakeSecretsStore) GetAllDataKeys(_ context.Context) ([]*secrets.DataKey,
 }
 
 func (f FakeSecretsStore) CreateDataKey(_ context.Context, dataKey secrets.DataKey) error {
+	dataKey.Created = time.Now()
 	f.store[dataKey.Name] = &dataKey
 	return nil
 }
 
-func (f FakeSecretsStore) CreateDataKeyWithDBSession(_ context.Context, dataKey secrets.DataKey, _ *xorm.Session) error {
-	f.store[dataKey.Name] = &dataKey
-	return nil
+func (f FakeSecretsStore) CreateDataKeyWithDBSession(ctx context.Context, dataKey secrets.DataKey, _ *xorm.Session) error {
+	return f.CreateDataKey(ctx, dataKey)
 }
 
 func (f FakeSecretsStore) DeleteDataKey(_ context.Context, name string) error {
 	delete(f.store, name)
 	return nil
 }
+
+func (f FakeSecretsStore) DisableDataKeys(_ context.Context) error {
+	for _, key := range f.store {
+		key.Active = false
+	}
+	return nil
+}
+
+func (f FakeSecretsStore) DisableScopeDataKeys(_ context.Context, scope string) error {
+	for _, key := range f.store {
+		if key.Scope == scope {
+			key.Active = false
+		}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -15,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/kmsproviders"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	"xorm.io/xorm"
 )
 
@@ -26,7 +29,10 @@ type SecretsService struct {
 
 	currentProvider string
 	providers       map[string]secrets.Provider
+	scopedProviders secrets.ScopedProviders
 	dataKeyCache    map[string]dataKeyCacheItem
+	currentDataKeys map[string]currentDataKeyItem
+	mtx             sync.RWMutex
 	log             log.Logger
 }
 
@@ -64,6 +70,7 @@ func ProvideSecretsService(
 		providers:       providers,
 		currentProvider: currentProvider,
 		dataKeyCache:    make(map[string]dataKeyCacheItem),
+		currentDataKeys: make(map[string]currentDataKeyItem),
 		log:             logger,
 	}
 
@@ -85,8 +92,16 @@ func (s *SecretsService) registerUsageMetrics() {
 }
 
 type dataKeyCacheItem struct {
-	expiry  time.Time
-	dataKey []byte
+	expiry   time.Time
+	provider string
+	dataKey  []byte
+}
+
+// currentDataKeyItem is the name of the data key encrypting the secrets of a label. Its expiry is not extended on
+// use, so that the data keys rotated by another instance stop being used.
+type currentDataKeyItem struct {
+	expiry time.Time
+	name   string
 }
 
 var b64 = base64.RawStdEncoding
@@ -103,18 +118,13 @@ func (s *SecretsService) EncryptWithDBSession(ctx context.Context, payload []byt
 
 	// If encryption secrets.EnvelopeEncryptionFeatureToggle toggle is on, use envelope encryption
 	scope := opt()
-	keyName := s.keyName(scope)
-
-	dataKey, err := s.dataKey(ctx, keyName)
+	providerName, provider, err := s.scopeProvider(ctx, scope)
 	if err != nil {
-		if errors.Is(err, secrets.ErrDataKeyNotFound) {
-			dataKey, err = s.newDataKey(ctx, keyName, scope, sess)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, err
-		}
+		return nil, err
+	}
+	keyName, dataKey, err := s.currentDataKey(ctx, s.keyLabel(scope, providerName), scope, providerName, provider, sess)
+	if err != nil {
+		return nil, err
 	}
 
 	encrypted, err := s.enc.Encrypt(ctx, payload, string(dataKey))
@@ -134,8 +144,59 @@ func (s *SecretsService) EncryptWithDBSession(ctx context.Context, payload []byt
 	return blob, nil
 }
 
-func (s *SecretsService) keyName(scope string) string {
-	return fmt.Sprintf("%s/%s@%s", now().Format("2006-01-02"), scope, s.currentProvider)
+func (s *SecretsService) keyLabel(scope string, provider string) string {
+	return fmt.Sprintf("%s/%s@%s", now().Format("2006-01-02"), scope, provider)
+}
+
+// RegisterScopedProviders sets the key encryption keys of the scopes whose data keys are not encrypted with the
+// current provider.
+func (s *SecretsService) RegisterScopedProviders(scopedProviders secrets.ScopedProviders) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.scopedProviders = scopedProviders
+}
+
+// scopeProvider returns the name and the provider of the key encryption key of the data keys of a scope
+func (s *SecretsService) scopeProvider(ctx context.Context, scope string) (string, secrets.Provider, error) {
+	s.mtx.RLock()
+	scopedProviders := s.scopedProviders
+	s.mtx.RUnlock()
+
+	if scopedProviders != nil {
+		name, provider, err := scopedProviders.ScopeProvider(ctx, scope)
+		if err != nil || name != "" {
+			return name, provider, err
+		}
+	}
+
+	provider, exists := s.providers[s.currentProvider]
+	if !exists {
+		return "", nil, fmt.Errorf("could not find encryption provider '%s'", s.currentProvider)
+	}
+	return s.currentProvider, provider, nil
+}
+
+// provider returns a key encryption key provider by name, the scoped providers return an error for the keys which
+// must no longer be used, such as revoked keys
+func (s *SecretsService) provider(ctx context.Context, name string) (secrets.Provider, error) {
+	if provider, exists := s.providers[name]; exists {
+		return provider, nil
+	}
+
+	s.mtx.RLock()
+	scopedProviders := s.scopedProviders
+	s.mtx.RUnlock()
+
+	if scopedProviders != nil {
+		provider, exists, err := scopedProviders.Provider(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find encryption provider '%s'", name)
 }
 
 func (s *SecretsService) Decrypt(ctx context.Context, payload []byte) ([]byte, error) {
@@ -145,37 +206,138 @@ func (s *SecretsService) Decrypt(ctx context.Context, payload []byte) ([]byte, e
 	}
 
 	// If encryption secrets.EnvelopeEncryptionFeatureToggle toggle is on, use envelope encryption
-	if len(payload) == 0 {
-		return nil, fmt.Errorf("unable to decrypt empty payload")
+	keyName, payload, err := keyNameFromPayload(payload)
+	if err != nil {
+		return nil, err
 	}
 
 	var dataKey []byte
 
-	if payload[0] != '#' {
+	if keyName == "" {
 		secretKey := s.settings.KeyValue("security", "secret_key").Value()
 		dataKey = []byte(secretKey)
 	} else {
-		payload = payload[1:]
-		endOfKey := bytes.Index(payload, []byte{'#'})
-		if endOfKey == -1 {
-			return nil, fmt.Errorf("could not find valid key in encrypted payload")
-		}
-		b64Key := payload[:endOfKey]
-		payload = payload[endOfKey+1:]
-		key := make([]byte, b64.DecodedLen(len(b64Key)))
-		_, err := b64.Decode(key, b64Key)
+		dataKey, err = s.dataKey(ctx, keyName)
 		if err != nil {
+			s.log.Error("Failed to lookup data key", "name", keyName, "error", err)
 			return nil, err
 		}
+	}
+
+	return s.enc.Decrypt(ctx, payload, string(dataKey))
+}
+
+// keyNameFromPayload splits an envelope encrypted payload into the name of its data key and its encrypted value.
+// The name is empty when the payload was encrypted with the secret key.
+func keyNameFromPayload(payload []byte) (string, []byte, error) {
+	if len(payload) == 0 {
+		return "", nil, fmt.Errorf("unable to decrypt empty payload")
+	}
 
-		dataKey, err = s.dataKey(ctx, string(key))
+	if payload[0] != '#' {
+		return "", payload, nil
+	}
+
+	payload = payload[1:]
+	endOfKey := bytes.Index(payload, []byte{'#'})
+	if endOfKey == -1 {
+		return "", nil, fmt.Errorf("could not find valid key in encrypted payload")
+	}
+	b64Key := payload[:endOfKey]
+	key := make([]byte, b64.DecodedLen(len(b64Key)))
+	if _, err := b64.Decode(key, b64Key); err != nil {
+		return "", nil, err
+	}
+
+	return string(key), payload[endOfKey+1:], nil
+}
+
+// ReEncrypt encrypts again a payload encrypted with a deactivated data key, or with the secret key, with the
+// current data key of its scope. The payloads already encrypted with an active data key are returned unchanged,
+// with false.
+func (s *SecretsService) ReEncrypt(ctx context.Context, payload []byte) ([]byte, bool, error) {
+	return s.ReEncryptWithScope(ctx, payload, nil)
+}
+
+// ReEncryptWithScope is ReEncrypt moving the payload to the scope of opt, the payloads encrypted with an active data
+// key of another scope are re-encrypted too. The payloads keep their scope when opt is nil.
+func (s *SecretsService) ReEncryptWithScope(ctx context.Context, payload []byte, opt secrets.EncryptionOptions) ([]byte, bool, error) {
+	if !s.settings.IsFeatureToggleEnabled(secrets.EnvelopeEncryptionFeatureToggle) {
+		return nil, false, secrets.ErrEnvelopeEncryptionNotEnabled
+	}
+
+	keyName, _, err := keyNameFromPayload(payload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	scope := secrets.WithoutScope()
+	if keyName != "" {
+		dataKey, err := s.store.GetDataKey(ctx, keyName)
 		if err != nil {
-			s.log.Error("Failed to lookup data key", "name", string(key), "error", err)
-			return nil, err
+			return nil, false, err
+		}
+		if dataKey.Active && (opt == nil || opt() == dataKey.Scope) {
+			return payload, false, nil
 		}
+		scope = secrets.WithScope(dataKey.Scope)
+	}
+	if opt != nil {
+		scope = opt
 	}
 
-	return s.enc.Decrypt(ctx, payload, string(dataKey))
+	decrypted, err := s.Decrypt(ctx, payload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	encrypted, err := s.Encrypt(ctx, decrypted, scope)
+	if err != nil {
+		return nil, false, err
+	}
+	return encrypted, true, nil
+}
+
+// RotateDataKeys deactivates all the data keys, so that new data keys are created to encrypt the secrets. The
+// deactivated data keys still decrypt the secrets until these are re-encrypted.
+func (s *SecretsService) RotateDataKeys(ctx context.Context) error {
+	if !s.settings.IsFeatureToggleEnabled(secrets.EnvelopeEncryptionFeatureToggle) {
+		return secrets.ErrEnvelopeEncryptionNotEnabled
+	}
+
+	if err := s.store.DisableDataKeys(ctx); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	s.currentDataKeys = make(map[string]currentDataKeyItem)
+	s.mtx.Unlock()
+
+	s.log.Info("Data keys rotated")
+	return nil
+}
+
+// RotateScopeDataKeys deactivates the data keys of a scope, such as the scope of an organization whose key
+// encryption key changed, so that new data keys are created to encrypt its secrets.
+func (s *SecretsService) RotateScopeDataKeys(ctx context.Context, scope string) error {
+	if !s.settings.IsFeatureToggleEnabled(secrets.EnvelopeEncryptionFeatureToggle) {
+		return secrets.ErrEnvelopeEncryptionNotEnabled
+	}
+
+	if err := s.store.DisableScopeDataKeys(ctx, scope); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	for label := range s.currentDataKeys {
+		if strings.Contains(label, "/"+scope+"@") {
+			delete(s.currentDataKeys, label)
+		}
+	}
+	s.mtx.Unlock()
+
+	s.log.Info("Data keys rotated", "scope", scope)
+	return nil
 }
 
 func (s *SecretsService) EncryptJsonData(ctx context.Context, kv map[string]string, opt secrets.EncryptionOptions) (map[string][]byte, error) {
@@ -230,17 +392,55 @@ func newRandomDataKey() ([]byte, error) {
 	return rawDataKey, nil
 }
 
-// newDataKey creates a new random DEK, caches it and returns its value
-func (s *SecretsService) newDataKey(ctx context.Context, name string, scope string, sess *xorm.Session) ([]byte, error) {
+// currentDataKey returns the name and the value of the data key encrypting the secrets of the label, and creates
+// it with the provider when the label has no active data key
+func (s *SecretsService) currentDataKey(ctx context.Context, label string, scope string, providerName string, provider secrets.Provider, sess *xorm.Session) (string, []byte, error) {
+	s.mtx.RLock()
+	item, exists := s.currentDataKeys[label]
+	s.mtx.RUnlock()
+
+	if exists && item.expiry.After(now()) {
+		dataKey, err := s.dataKey(ctx, item.name)
+		if err != nil {
+			return "", nil, err
+		}
+		return item.name, dataKey, nil
+	}
+
+	var (
+		name    string
+		dataKey []byte
+	)
+	current, err := s.store.GetCurrentDataKey(ctx, label)
+	switch {
+	case err == nil:
+		name = current.Name
+		dataKey, err = s.dataKey(ctx, name)
+	case errors.Is(err, secrets.ErrDataKeyNotFound):
+		name = util.GenerateShortUID()
+		dataKey, err = s.newDataKey(ctx, name, label, scope, providerName, provider, sess)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mtx.Lock()
+	s.currentDataKeys[label] = currentDataKeyItem{
+		expiry: now().Add(dekTTL),
+		name:   name,
+	}
+	s.mtx.Unlock()
+
+	return name, dataKey, nil
+}
+
+// newDataKey creates a new random DEK encrypted with the provider, caches it and returns its value
+func (s *SecretsService) newDataKey(ctx context.Context, name string, label string, scope string, providerName string, provider secrets.Provider, sess *xorm.Session) ([]byte, error) {
 	// 1. Create new DEK
 	dataKey, err := newRandomDataKey()
 	if err != nil {
 		return nil, err
 	}
-	provider, exists := s.providers[s.currentProvider]
-	if !exists {
-		return nil, fmt.Errorf("could not find encryption provider '%s'", s.currentProvider)
-	}
 
 	// 2. Encrypt it
 	encrypted, err := provider.Encrypt(ctx, dataKey)
@@ -250,9 +450,10 @@ func (s *SecretsService) newDataKey(ctx context.Context, name string, scope stri
 
 	// 3. Store its encrypted value in db
 	dek := secrets.DataKey{
-		Active:        true, // TODO: right now we never mark a key as deactivated
+		Active:        true,
 		Name:          name,
-		Provider:      s.currentProvider,
+		Label:         label,
+		Provider:      providerName,
 		EncryptedData: encrypted,
 		Scope:         scope,
 	}
@@ -268,19 +469,32 @@ func (s *SecretsService) newDataKey(ctx context.Context, name string, scope stri
 	}
 
 	// 4. Cache its unencrypted value and return it
+	s.mtx.Lock()
 	s.dataKeyCache[name] = dataKeyCacheItem{
-		expiry:  now().Add(dekTTL),
-		dataKey: dataKey,
+		expiry:   now().Add(dekTTL),
+		provider: providerName,
+		dataKey:  dataKey,
 	}
+	s.mtx.Unlock()
 
 	return dataKey, nil
 }
 
 // dataKey looks up DEK in cache or database, and decrypts it
 func (s *SecretsService) dataKey(ctx context.Context, name string) ([]byte, error) {
-	if item, exists := s.dataKeyCache[name]; exists {
+	s.mtx.Lock()
+	item, exists := s.dataKeyCache[name]
+	if exists {
 		item.expiry = now().Add(dekTTL)
 		s.dataKeyCache[name] = item
+	}
+	s.mtx.Unlock()
+
+	if exists {
+		// the cached data keys of a scoped provider are no longer used once its key encryption key is revoked
+		if _, err := s.provider(ctx, item.provider); err != nil {
+			return nil, err
+		}
 		return item.dataKey, nil
 	}
 
@@ -291,9 +505,9 @@ func (s *SecretsService) dataKey(ctx context.Context, name string) ([]byte, erro
 	}
 
 	// 2. decrypt data key
-	provider, exists := s.providers[dataKey.Provider]
-	if !exists {
-		return nil, fmt.Errorf("could not find encryption provider '%s'", dataKey.Provider)
+	provider, err := s.provider(ctx, dataKey.Provider)
+	if err != nil {
+		return nil, err
 	}
 
 	decrypted, err := provider.Decrypt(ctx, dataKey.EncryptedData)
@@ -302,10 +516,13 @@ func (s *SecretsService) dataKey(ctx context.Context, name string) ([]byte, erro
 	}
 
 	// 3. cache data key
+	s.mtx.Lock()
 	s.dataKeyCache[name] = dataKeyCacheItem{
-		expiry:  now().Add(dekTTL),
-		dataKey: decrypted,
+		expiry:   now().Add(dekTTL),
+		provider: dataKey.Provider,
+		dataKey:  decrypted,
 	}
+	s.mtx.Unlock()
 
 	return decrypted, nil
 }
@@ -341,9 +558,17 @@ func (s *SecretsService) Run(ctx context.Context) error {
 }
 
 func (s *SecretsService) removeExpiredItems() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	for id, dek := range s.dataKeyCache {
 		if dek.expiry.Before(now()) {
 			delete(s.dataKeyCache, id)
 		}
 	}
+	for label, current := range s.currentDataKeys {
+		if current.expiry.Before(now()) {
+			delete(s.currentDataKeys, label)
+		}
+	}
 }
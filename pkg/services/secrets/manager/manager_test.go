@@ -2,6 +2,7 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -156,6 +157,164 @@ func TestSecretsService_DataKeys(t *testing.T) {
 	})
 }
 
+func TestSecretsService_RotateDataKeys(t *testing.T) {
+	store := database.ProvideSecretsStore(sqlstore.InitTestDB(t))
+	svc := SetupTestService(t, store)
+	ctx := context.Background()
+
+	plaintext := []byte("very secret string")
+	encrypted, err := svc.Encrypt(ctx, plaintext, secrets.WithScope("user:100"))
+	require.NoError(t, err)
+	oldKeyName, _, err := keyNameFromPayload(encrypted)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RotateDataKeys(ctx))
+
+	t.Run("rotated data keys should still decrypt the secrets", func(t *testing.T) {
+		decrypted, err := svc.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("re-encrypting should use a new data key of the same scope", func(t *testing.T) {
+		reencrypted, changed, err := svc.ReEncrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.True(t, changed)
+
+		keyName, _, err := keyNameFromPayload(reencrypted)
+		require.NoError(t, err)
+		assert.NotEqual(t, oldKeyName, keyName)
+		dataKey, err := store.GetDataKey(ctx, keyName)
+		require.NoError(t, err)
+		assert.True(t, dataKey.Active)
+		assert.Equal(t, "user:100", dataKey.Scope)
+		assert.Equal(t, svc.keyLabel("user:100", svc.currentProvider), dataKey.Label)
+
+		decrypted, err := svc.Decrypt(ctx, reencrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+
+		_, changed, err = svc.ReEncrypt(ctx, reencrypted)
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("re-encrypting should move the secrets encrypted with the secret key to a data key", func(t *testing.T) {
+		legacy, err := svc.enc.Encrypt(ctx, plaintext, svc.settings.KeyValue("security", "secret_key").Value())
+		require.NoError(t, err)
+
+		reencrypted, changed, err := svc.ReEncrypt(ctx, legacy)
+		require.NoError(t, err)
+		require.True(t, changed)
+		keyName, _, err := keyNameFromPayload(reencrypted)
+		require.NoError(t, err)
+		assert.NotEmpty(t, keyName)
+	})
+}
+
+func TestSecretsService_ScopedProviders(t *testing.T) {
+	store := database.ProvideSecretsStore(sqlstore.InitTestDB(t))
+	svc := SetupTestService(t, store)
+	ctx := context.Background()
+
+	scoped := &fakeScopedProviders{scope: secrets.OrgScope(2), name: "org-key:2/a", provider: svc.providers[svc.currentProvider]}
+	svc.RegisterScopedProviders(scoped)
+
+	plaintext := []byte("very secret string")
+	encrypted, err := svc.Encrypt(ctx, plaintext, secrets.WithOrgScope(2))
+	require.NoError(t, err)
+
+	t.Run("the data keys of the scope should be encrypted with its provider", func(t *testing.T) {
+		keyName, _, err := keyNameFromPayload(encrypted)
+		require.NoError(t, err)
+		dataKey, err := store.GetDataKey(ctx, keyName)
+		require.NoError(t, err)
+		assert.Equal(t, "org-key:2/a", dataKey.Provider)
+		assert.Equal(t, svc.keyLabel("org:2", "org-key:2/a"), dataKey.Label)
+
+		decrypted, err := svc.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("the other scopes should use the current provider", func(t *testing.T) {
+		other, err := svc.Encrypt(ctx, plaintext, secrets.WithOrgScope(3))
+		require.NoError(t, err)
+		keyName, _, err := keyNameFromPayload(other)
+		require.NoError(t, err)
+		dataKey, err := store.GetDataKey(ctx, keyName)
+		require.NoError(t, err)
+		assert.Equal(t, svc.currentProvider, dataKey.Provider)
+	})
+
+	t.Run("re-encrypting with a scope should move the secrets of other scopes", func(t *testing.T) {
+		root, err := svc.Encrypt(ctx, plaintext, secrets.WithoutScope())
+		require.NoError(t, err)
+		reencrypted, changed, err := svc.ReEncryptWithScope(ctx, root, secrets.WithOrgScope(2))
+		require.NoError(t, err)
+		require.True(t, changed)
+		assert.Equal(t, keyNameOf(t, encrypted), keyNameOf(t, reencrypted))
+
+		_, changed, err = svc.ReEncryptWithScope(ctx, reencrypted, secrets.WithOrgScope(2))
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("rotating the data keys of the scope should only deactivate its data keys", func(t *testing.T) {
+		require.NoError(t, svc.RotateScopeDataKeys(ctx, secrets.OrgScope(2)))
+		keys, err := store.GetAllDataKeys(ctx)
+		require.NoError(t, err)
+		for _, key := range keys {
+			assert.Equal(t, key.Scope != "org:2", key.Active, key.Scope)
+		}
+
+		reencrypted, changed, err := svc.ReEncrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.True(t, changed)
+		assert.NotEqual(t, keyNameOf(t, encrypted), keyNameOf(t, reencrypted))
+	})
+
+	t.Run("the cached data keys should not decrypt once their provider is revoked", func(t *testing.T) {
+		scoped.revoked = true
+		_, err := svc.Decrypt(ctx, encrypted)
+		assert.ErrorIs(t, err, errRevoked)
+	})
+}
+
+var errRevoked = errors.New("revoked")
+
+// fakeScopedProviders encrypts the data keys of a scope with a named provider, which can be revoked
+type fakeScopedProviders struct {
+	scope    string
+	name     string
+	provider secrets.Provider
+	revoked  bool
+}
+
+func (f *fakeScopedProviders) ScopeProvider(_ context.Context, scope string) (string, secrets.Provider, error) {
+	if scope != f.scope || f.revoked {
+		return "", nil, nil
+	}
+	return f.name, f.provider, nil
+}
+
+func (f *fakeScopedProviders) Provider(_ context.Context, name string) (secrets.Provider, bool, error) {
+	if name != f.name {
+		return nil, false, nil
+	}
+	if f.revoked {
+		return nil, true, errRevoked
+	}
+	return f.provider, true, nil
+}
+
+func keyNameOf(t *testing.T, payload []byte) string {
+	t.Helper()
+	keyName, _, err := keyNameFromPayload(payload)
+	require.NoError(t, err)
+	return keyName
+}
+
 func TestSecretsService_UseCurrentProvider(t *testing.T) {
 	t.Run("When encryption_provider is not specified explicitly, should use 'secretKey' as a current provider", func(t *testing.T) {
 		svc := SetupTestService(t, database.ProvideSecretsStore(sqlstore.InitTestDB(t)))
@@ -309,7 +468,7 @@ func TestSecretsService_Run(t *testing.T) {
 		_, err = svc.Encrypt(ctx, []byte("grafana"), withoutScope)
 		require.NoError(t, err)
 
-		dataKeyID := svc.keyName(withoutScope())
+		dataKeyID := svc.currentDataKeys[svc.keyLabel(withoutScope(), svc.currentProvider)].name
 		assert.True(t, svc.dataKeyCache[dataKeyID].expiry.After(time.Now().Add(dekTTL)))
 	})
 }
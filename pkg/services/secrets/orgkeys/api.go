@@ -0,0 +1,77 @@
+package orgkeys
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/rotation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/org/encryption", func(encryptionRoute routing.RouteRegister) {
+		encryptionRoute.Get("/keys", routing.Wrap(s.getKeysHandler))
+		encryptionRoute.Post("/keys", routing.Wrap(s.registerKeyHandler))
+		encryptionRoute.Post("/keys/:uid/revoke", routing.Wrap(s.revokeKeyHandler))
+		encryptionRoute.Post("/rotate-data-keys", routing.Wrap(s.rotateDataKeysHandler))
+	}, middleware.ReqOrgAdmin)
+}
+
+// GET /api/org/encryption/keys
+func (s *Service) getKeysHandler(c *models.ReqContext) response.Response {
+	keys, err := s.GetKeys(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return errorResponse(err, "Failed to get the encryption keys")
+	}
+	return response.JSON(http.StatusOK, keys)
+}
+
+// POST /api/org/encryption/keys
+func (s *Service) registerKeyHandler(c *models.ReqContext) response.Response {
+	cmd := RegisterKeyCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgId
+
+	key, err := s.RegisterKey(c.Req.Context(), cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to register the encryption key")
+	}
+	return response.JSON(http.StatusOK, key)
+}
+
+// POST /api/org/encryption/keys/:uid/revoke
+func (s *Service) revokeKeyHandler(c *models.ReqContext) response.Response {
+	key, err := s.RevokeKey(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err, "Failed to revoke the encryption key")
+	}
+	return response.JSON(http.StatusOK, key)
+}
+
+// POST /api/org/encryption/rotate-data-keys
+func (s *Service) rotateDataKeysHandler(c *models.ReqContext) response.Response {
+	if err := s.RotateDataKeys(c.Req.Context(), c.OrgId); err != nil {
+		return errorResponse(err, "Failed to rotate the data keys")
+	}
+	return response.JSON(http.StatusAccepted, map[string]interface{}{"message": "Data keys rotated, secrets re-encryption started"})
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrNotEnabled), errors.Is(err, ErrProviderNotAllowed), errors.Is(err, ErrInvalidKey),
+		errors.Is(err, ErrKeyRevoked), errors.Is(err, secrets.ErrEnvelopeEncryptionNotEnabled):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, rotation.ErrRotationRunning):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
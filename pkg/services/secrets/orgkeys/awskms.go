@@ -0,0 +1,67 @@
+package orgkeys
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+)
+
+// awsKMSProvider encrypts the data keys of an organization with an AWS KMS key. The id of the organization is the
+// encryption context of the data keys, so that they cannot be decrypted on behalf of another organization.
+type awsKMSProvider struct {
+	client  *kms.KMS
+	keyID   string
+	context map[string]*string
+}
+
+func newAWSKMSProvider(key *OrgKey) (secrets.Provider, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(key.Region)})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &aws.Config{}
+	if key.AssumeRoleArn != "" {
+		config.Credentials = stscreds.NewCredentials(sess, key.AssumeRoleArn, func(p *stscreds.AssumeRoleProvider) {
+			if key.ExternalId != "" {
+				p.ExternalID = aws.String(key.ExternalId)
+			}
+		})
+	}
+
+	return &awsKMSProvider{
+		client:  kms.New(sess, config),
+		keyID:   key.KeyId,
+		context: map[string]*string{"grafana_org_id": aws.String(strconv.FormatInt(key.OrgId, 10))},
+	}, nil
+}
+
+func (p *awsKMSProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	out, err := p.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(p.keyID),
+		Plaintext:         blob,
+		EncryptionContext: p.context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (p *awsKMSProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	out, err := p.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(p.keyID),
+		CiphertextBlob:    blob,
+		EncryptionContext: p.context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,66 @@
+package orgkeys
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotEnabled         = errors.New("organization encryption keys are not enabled")
+	ErrProviderNotAllowed = errors.New("encryption key provider not allowed")
+	ErrInvalidKey         = errors.New("invalid encryption key")
+	ErrKeyNotFound        = errors.New("encryption key not found")
+	ErrKeyRevoked         = errors.New("encryption key revoked")
+)
+
+// ProviderAWSKMS keys are AWS KMS symmetric keys, used by Grafana with its AWS credentials or by assuming a role of
+// the account of the organization
+const ProviderAWSKMS = "awskms"
+
+// KeyState is the state of a key encryption key of an organization
+type KeyState string
+
+const (
+	// KeyStateActive keys encrypt the data keys of the organization, an organization has at most one active key
+	KeyStateActive KeyState = "active"
+	// KeyStateRetired keys have been replaced by a newer key, they still decrypt the data keys which have not been
+	// re-encrypted yet
+	KeyStateRetired KeyState = "retired"
+	// KeyStateRevoked keys are no longer used, the secrets encrypted with them cannot be decrypted anymore
+	KeyStateRevoked KeyState = "revoked"
+)
+
+// OrgKey is a key encryption key registered by an organization. The data keys of the organization, which encrypt its
+// secrets, are encrypted with its active key instead of the key encryption key of the instance.
+type OrgKey struct {
+	Id       int64  `json:"-"`
+	OrgId    int64  `json:"orgId"`
+	Uid      string `json:"uid"`
+	Provider string `json:"provider"`
+	// KeyId identifies the key in its key management service, such as the ARN of an AWS KMS key
+	KeyId         string `json:"keyId"`
+	Region        string `json:"region,omitempty"`
+	AssumeRoleArn string `json:"assumeRoleArn,omitempty"`
+	ExternalId    string `json:"externalId,omitempty"`
+	// EncryptDashboards encrypts the JSON models of the dashboards of the organization, when the key is active
+	EncryptDashboards bool       `json:"encryptDashboards"`
+	State             KeyState   `json:"state"`
+	Created           time.Time  `json:"created"`
+	Updated           time.Time  `json:"updated"`
+	Revoked           *time.Time `json:"revoked,omitempty"`
+}
+
+func (k *OrgKey) TableName() string {
+	return "org_encryption_key"
+}
+
+// RegisterKeyCommand registers the key encryption key of an organization, replacing its active key.
+type RegisterKeyCommand struct {
+	OrgID             int64  `json:"-"`
+	Provider          string `json:"provider"`
+	KeyID             string `json:"keyId"`
+	Region            string `json:"region"`
+	AssumeRoleARN     string `json:"assumeRoleArn"`
+	ExternalID        string `json:"externalId"`
+	EncryptDashboards bool   `json:"encryptDashboards"`
+}
@@ -0,0 +1,324 @@
+package orgkeys
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/secrets/rotation"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"xorm.io/xorm"
+)
+
+// providerPrefix prefixes the names of the providers of the organization keys, as kept in the data keys
+const providerPrefix = "org-key:"
+
+// keysCacheTTL is how long the keys of an organization are cached, the keys revoked by another Grafana instance
+// are no longer used after at most this long
+const keysCacheTTL = time.Minute
+
+// Service lets the organizations bring their own key encryption key for their secrets and, optionally, the JSON
+// models of their dashboards. The data keys of the scope of an organization are encrypted with its active key, the
+// keys replaced by a newer key keep decrypting the secrets until these are re-encrypted, and the revoked keys are no
+// longer used at all. The allowed key providers are set by org_encryption_key_providers in the security section.
+type Service struct {
+	sqlStore  *sqlstore.SQLStore
+	secrets   *manager.SecretsService
+	rotation  *rotation.Service
+	settings  setting.Provider
+	log       log.Logger
+	now       func() time.Time
+	providers map[string]func(key *OrgKey) (secrets.Provider, error)
+
+	mtx          sync.Mutex
+	keys         map[int64]cachedKeys
+	keyProviders map[string]secrets.Provider
+}
+
+type cachedKeys struct {
+	expiry time.Time
+	keys   []*OrgKey
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, secretsService *manager.SecretsService, rotationService *rotation.Service,
+	settings setting.Provider, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		sqlStore:     sqlStore,
+		secrets:      secretsService,
+		rotation:     rotationService,
+		settings:     settings,
+		log:          log.New("secrets.orgkeys"),
+		now:          time.Now,
+		providers:    map[string]func(key *OrgKey) (secrets.Provider, error){ProviderAWSKMS: newAWSKMSProvider},
+		keys:         make(map[int64]cachedKeys),
+		keyProviders: make(map[string]secrets.Provider),
+	}
+
+	// the keys registered while the feature was enabled keep decrypting the secrets when it is disabled
+	secretsService.RegisterScopedProviders(s)
+	sqlStore.RegisterDashboardEncryption(s)
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// allowedProviders returns the key providers the organizations may register keys of
+func (s *Service) allowedProviders() map[string]bool {
+	allowed := map[string]bool{}
+	for _, provider := range strings.Fields(s.settings.KeyValue("security", "org_encryption_key_providers").Value()) {
+		allowed[provider] = true
+	}
+	return allowed
+}
+
+// GetKeys returns the keys of an organization, the active key first and then the newest keys first.
+func (s *Service) GetKeys(ctx context.Context, orgID int64) ([]*OrgKey, error) {
+	return s.getKeys(ctx, orgID)
+}
+
+// RegisterKey makes a key the active key of an organization. The key must encrypt and decrypt a test value. The
+// previous active key is retired, and the data keys of the organization are rotated so that its secrets are
+// re-encrypted with the new key in the background.
+func (s *Service) RegisterKey(ctx context.Context, cmd RegisterKeyCommand) (*OrgKey, error) {
+	if !s.settings.IsFeatureToggleEnabled(secrets.EnvelopeEncryptionFeatureToggle) {
+		return nil, secrets.ErrEnvelopeEncryptionNotEnabled
+	}
+	allowed := s.allowedProviders()
+	if len(allowed) == 0 {
+		return nil, ErrNotEnabled
+	}
+	newProvider, ok := s.providers[cmd.Provider]
+	if !allowed[cmd.Provider] || !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProviderNotAllowed, cmd.Provider)
+	}
+	if cmd.KeyID == "" {
+		return nil, fmt.Errorf("%w: missing key id", ErrInvalidKey)
+	}
+
+	key := &OrgKey{
+		OrgId:             cmd.OrgID,
+		Provider:          cmd.Provider,
+		KeyId:             cmd.KeyID,
+		Region:            cmd.Region,
+		AssumeRoleArn:     cmd.AssumeRoleARN,
+		ExternalId:        cmd.ExternalID,
+		EncryptDashboards: cmd.EncryptDashboards,
+		State:             KeyStateActive,
+	}
+	provider, err := newProvider(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+	if err := checkProvider(ctx, provider); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	if err := s.insertKey(ctx, key); err != nil {
+		return nil, err
+	}
+	s.mtx.Lock()
+	s.keyProviders[key.Uid] = provider
+	s.mtx.Unlock()
+	s.forgetKeys(cmd.OrgID)
+	s.log.Info("Organization encryption key registered", "orgId", key.OrgId, "uid", key.Uid, "provider", key.Provider)
+
+	if err := s.RotateDataKeys(ctx, cmd.OrgID); err != nil {
+		s.log.Warn("Failed to re-encrypt the secrets with the new organization encryption key", "orgId", cmd.OrgID, "error", err)
+	}
+	return key, nil
+}
+
+// RevokeKey stops using a key of an organization. The secrets and the dashboards encrypted with the data keys it
+// encrypts cannot be decrypted anymore, when it was the active key the data keys of the organization are encrypted
+// with the key encryption key of the instance from then on.
+func (s *Service) RevokeKey(ctx context.Context, orgID int64, uid string) (*OrgKey, error) {
+	key, err := s.revokeKey(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	s.mtx.Lock()
+	delete(s.keyProviders, uid)
+	s.mtx.Unlock()
+	s.forgetKeys(orgID)
+	s.log.Info("Organization encryption key revoked", "orgId", orgID, "uid", uid)
+	return key, nil
+}
+
+// RotateDataKeys deactivates the data keys of an organization and re-encrypts its secrets in the background, with
+// new data keys encrypted with its active key. It returns rotation.ErrRotationRunning when the secrets are being
+// re-encrypted already, the secrets of the organization are then re-encrypted by the next rotation.
+func (s *Service) RotateDataKeys(ctx context.Context, orgID int64) error {
+	if err := s.secrets.RotateScopeDataKeys(ctx, secrets.OrgScope(orgID)); err != nil {
+		return err
+	}
+	return s.rotation.StartReEncryption(ctx, rotation.TriggerOrgKey)
+}
+
+// ScopeProvider returns the active key of the organization of the scope, see secrets.ScopedProviders.
+func (s *Service) ScopeProvider(ctx context.Context, scope string) (string, secrets.Provider, error) {
+	if !strings.HasPrefix(scope, "org:") {
+		return "", nil, nil
+	}
+	orgID, err := strconv.ParseInt(strings.TrimPrefix(scope, "org:"), 10, 64)
+	if err != nil {
+		return "", nil, nil
+	}
+
+	key, err := s.activeKey(ctx, orgID)
+	if err != nil || key == nil {
+		return "", nil, err
+	}
+	provider, err := s.keyProvider(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return providerName(key), provider, nil
+}
+
+// Provider returns a key of an organization by its provider name, see secrets.ScopedProviders. It returns
+// ErrKeyRevoked for the revoked keys.
+func (s *Service) Provider(ctx context.Context, name string) (secrets.Provider, bool, error) {
+	if !strings.HasPrefix(name, providerPrefix) {
+		return nil, false, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(name, providerPrefix), "/", 2)
+	if len(parts) != 2 {
+		return nil, true, ErrKeyNotFound
+	}
+	orgID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, true, ErrKeyNotFound
+	}
+
+	keys, err := s.cachedKeys(ctx, orgID)
+	if err != nil {
+		return nil, true, err
+	}
+	for _, key := range keys {
+		if key.Uid != parts[1] {
+			continue
+		}
+		if key.State == KeyStateRevoked {
+			return nil, true, ErrKeyRevoked
+		}
+		provider, err := s.keyProvider(key)
+		return provider, true, err
+	}
+	return nil, true, ErrKeyNotFound
+}
+
+// EncryptDashboard encrypts the dashboards of the organizations whose active key encrypts the dashboards, see
+// sqlstore.DashboardEncryption.
+func (s *Service) EncryptDashboard(ctx context.Context, sess *xorm.Session, orgID int64, data []byte) ([]byte, bool, error) {
+	key, err := s.activeKey(ctx, orgID)
+	if err != nil {
+		return nil, false, err
+	}
+	if key == nil || !key.EncryptDashboards {
+		return nil, false, nil
+	}
+
+	encrypted, err := s.secrets.EncryptWithDBSession(ctx, data, secrets.WithOrgScope(orgID), sess)
+	if err != nil {
+		return nil, false, err
+	}
+	return encrypted, true, nil
+}
+
+// DecryptDashboard decrypts the JSON model of a dashboard, see sqlstore.DashboardEncryption.
+func (s *Service) DecryptDashboard(ctx context.Context, data []byte) ([]byte, error) {
+	return s.secrets.Decrypt(ctx, data)
+}
+
+func providerName(key *OrgKey) string {
+	return fmt.Sprintf("%s%d/%s", providerPrefix, key.OrgId, key.Uid)
+}
+
+// checkProvider encrypts and decrypts a random value with the provider of a new key
+func checkProvider(ctx context.Context, provider secrets.Provider) error {
+	value := make([]byte, 16)
+	if _, err := rand.Read(value); err != nil {
+		return err
+	}
+	encrypted, err := provider.Encrypt(ctx, value)
+	if err != nil {
+		return err
+	}
+	decrypted, err := provider.Decrypt(ctx, encrypted)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(value, decrypted) {
+		return errors.New("the decrypted value does not match the encrypted value")
+	}
+	return nil
+}
+
+// activeKey returns the active key of an organization, or nil when it has none
+func (s *Service) activeKey(ctx context.Context, orgID int64) (*OrgKey, error) {
+	keys, err := s.cachedKeys(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.State == KeyStateActive {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+// keyProvider returns the provider of a key, created on first use
+func (s *Service) keyProvider(key *OrgKey) (secrets.Provider, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if provider, ok := s.keyProviders[key.Uid]; ok {
+		return provider, nil
+	}
+
+	newProvider, ok := s.providers[key.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProviderNotAllowed, key.Provider)
+	}
+	provider, err := newProvider(key)
+	if err != nil {
+		return nil, err
+	}
+	s.keyProviders[key.Uid] = provider
+	return provider, nil
+}
+
+// cachedKeys returns the keys of an organization, cached for keysCacheTTL
+func (s *Service) cachedKeys(ctx context.Context, orgID int64) ([]*OrgKey, error) {
+	s.mtx.Lock()
+	cached, ok := s.keys[orgID]
+	s.mtx.Unlock()
+	if ok && cached.expiry.After(s.now()) {
+		return cached.keys, nil
+	}
+
+	keys, err := s.getKeys(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	s.mtx.Lock()
+	s.keys[orgID] = cachedKeys{expiry: s.now().Add(keysCacheTTL), keys: keys}
+	s.mtx.Unlock()
+	return keys, nil
+}
+
+func (s *Service) forgetKeys(orgID int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.keys, orgID)
+}
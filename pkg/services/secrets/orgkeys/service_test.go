@@ -0,0 +1,209 @@
+package orgkeys
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/osskmsproviders"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/secrets/rotation"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeProvider encrypts with a XOR of its key id, and fails when its key id is "invalid"
+type fakeProvider struct {
+	keyID string
+}
+
+func (p fakeProvider) Encrypt(_ context.Context, blob []byte) ([]byte, error) {
+	if p.keyID == "invalid" {
+		return nil, errors.New("access denied")
+	}
+	encrypted := make([]byte, len(blob))
+	for i, b := range blob {
+		encrypted[i] = b ^ p.keyID[i%len(p.keyID)]
+	}
+	return encrypted, nil
+}
+
+func (p fakeProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	return p.Encrypt(ctx, blob)
+}
+
+func setupTestService(t *testing.T) (*Service, *sqlstore.SQLStore, *database.SecretsStoreImpl) {
+	t.Helper()
+	raw, err := ini.Load([]byte(`
+		[security]
+		secret_key = SdlklWklckeLS
+		org_encryption_key_providers = awskms`))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{
+		Raw:            raw,
+		FeatureToggles: map[string]bool{secrets.EnvelopeEncryptionFeatureToggle: true},
+	}}
+
+	store := sqlstore.InitTestDB(t)
+	secretsStore := database.ProvideSecretsStore(store)
+	encryption := ossencryption.ProvideService()
+	secretsService, err := manager.ProvideSecretsService(secretsStore, osskmsproviders.ProvideService(encryption, settings),
+		encryption, settings, &usagestats.UsageStatsMock{T: t})
+	require.NoError(t, err)
+
+	kv := kvstore.ProvideService(store)
+	rotationService, err := rotation.ProvideService(store, secretsService, kv, routing.NewRouteRegister(),
+		scheduler.ProvideService(nil, kv, accesscontrolmock.New(), routing.NewRouteRegister()))
+	require.NoError(t, err)
+
+	s := ProvideService(store, secretsService, rotationService, settings, routing.NewRouteRegister())
+	s.providers = map[string]func(key *OrgKey) (secrets.Provider, error){
+		ProviderAWSKMS: func(key *OrgKey) (secrets.Provider, error) {
+			return fakeProvider{keyID: key.KeyId}, nil
+		},
+	}
+	return s, store, secretsStore
+}
+
+func TestService_RegisterKey(t *testing.T) {
+	ctx := context.Background()
+	s, _, secretsStore := setupTestService(t)
+
+	t.Run("should refuse the keys of providers not allowed and the invalid keys", func(t *testing.T) {
+		_, err := s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 2, Provider: "azurekv", KeyID: "key"})
+		assert.ErrorIs(t, err, ErrProviderNotAllowed)
+		_, err = s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 2, Provider: ProviderAWSKMS})
+		assert.ErrorIs(t, err, ErrInvalidKey)
+		_, err = s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 2, Provider: ProviderAWSKMS, KeyID: "invalid"})
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	first, err := s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 2, Provider: ProviderAWSKMS, KeyID: "first"})
+	require.NoError(t, err)
+
+	encrypted, err := s.secrets.Encrypt(ctx, []byte("secret"), secrets.WithOrgScope(2))
+	require.NoError(t, err)
+
+	dataKeyProvider := func(t *testing.T, payload []byte) string {
+		t.Helper()
+		_, err := s.secrets.Decrypt(ctx, payload)
+		require.NoError(t, err)
+		keys, err := secretsStore.GetAllDataKeys(ctx)
+		require.NoError(t, err)
+		for _, key := range keys {
+			if key.Active && key.Scope == "org:2" {
+				return key.Provider
+			}
+		}
+		return ""
+	}
+
+	t.Run("should encrypt the data keys of the organization with its key", func(t *testing.T) {
+		assert.Equal(t, "org-key:2/"+first.Uid, dataKeyProvider(t, encrypted))
+
+		other, err := s.secrets.Encrypt(ctx, []byte("secret"), secrets.WithOrgScope(3))
+		require.NoError(t, err)
+		decrypted, err := s.secrets.Decrypt(ctx, other)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), decrypted)
+	})
+
+	second, err := s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 2, Provider: ProviderAWSKMS, KeyID: "second"})
+	require.NoError(t, err)
+
+	t.Run("should retire the previous key and rotate the data keys of the organization", func(t *testing.T) {
+		keys, err := s.GetKeys(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, second.Uid, keys[0].Uid)
+		assert.Equal(t, KeyStateActive, keys[0].State)
+		assert.Equal(t, first.Uid, keys[1].Uid)
+		assert.Equal(t, KeyStateRetired, keys[1].State)
+
+		// the retired key still decrypts the secrets which have not been re-encrypted
+		decrypted, err := s.secrets.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), decrypted)
+
+		reencrypted, changed, err := s.secrets.ReEncrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.True(t, changed)
+		assert.Equal(t, "org-key:2/"+second.Uid, dataKeyProvider(t, reencrypted))
+	})
+
+	t.Run("should no longer decrypt with a revoked key", func(t *testing.T) {
+		_, err := s.RevokeKey(ctx, 2, first.Uid)
+		require.NoError(t, err)
+		_, err = s.secrets.Decrypt(ctx, encrypted)
+		assert.ErrorIs(t, err, ErrKeyRevoked)
+
+		_, err = s.RevokeKey(ctx, 2, first.Uid)
+		assert.ErrorIs(t, err, ErrKeyRevoked)
+		_, err = s.RevokeKey(ctx, 3, second.Uid)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("should use the key of the instance once the active key is revoked", func(t *testing.T) {
+		_, err := s.RevokeKey(ctx, 2, second.Uid)
+		require.NoError(t, err)
+		name, _, err := s.ScopeProvider(ctx, secrets.OrgScope(2))
+		require.NoError(t, err)
+		assert.Empty(t, name)
+	})
+}
+
+func TestService_EncryptDashboards(t *testing.T) {
+	ctx := context.Background()
+	s, store, _ := setupTestService(t)
+	// wait for the re-encryption started by the registration, which would lock the test database
+	waitReEncryption := func(t *testing.T) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			status, err := s.rotation.Status(ctx)
+			return err == nil && !status.Running
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	key, err := s.RegisterKey(ctx, RegisterKeyCommand{OrgID: 1, Provider: ProviderAWSKMS, KeyID: "key", EncryptDashboards: true})
+	require.NoError(t, err)
+	waitReEncryption(t)
+
+	dash, err := store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Secret", "description": "confidential"}),
+	})
+	require.NoError(t, err)
+
+	var stored models.Dashboard
+	err = store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.ID(dash.Id).Get(&stored)
+		return err
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.Data.Get(sqlstore.EncryptedDashboardKey).MustString())
+	assert.Empty(t, stored.Data.Get("description").MustString())
+
+	loaded, err := store.GetDashboard(dash.Id, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "confidential", loaded.Data.Get("description").MustString())
+
+	_, err = s.RevokeKey(ctx, 1, key.Uid)
+	require.NoError(t, err)
+	_, err = store.GetDashboard(dash.Id, 1, "", "")
+	assert.ErrorIs(t, err, ErrKeyRevoked)
+}
@@ -0,0 +1,71 @@
+package orgkeys
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getKeys(ctx context.Context, orgID int64) ([]*OrgKey, error) {
+	keys := make([]*OrgKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Desc("id").Find(&keys)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the active key first
+	for i, key := range keys {
+		if key.State == KeyStateActive && i > 0 {
+			copy(keys[1:i+1], keys[:i])
+			keys[0] = key
+			break
+		}
+	}
+	return keys, nil
+}
+
+// insertKey saves a new active key and retires the previous active key of the organization
+func (s *Service) insertKey(ctx context.Context, key *OrgKey) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		key.Uid = util.GenerateShortUID()
+		key.Created = s.now()
+		key.Updated = key.Created
+
+		if _, err := sess.Exec("UPDATE org_encryption_key SET state = ?, updated = ? WHERE org_id = ? AND state = ?",
+			KeyStateRetired, key.Updated, key.OrgId, KeyStateActive); err != nil {
+			return err
+		}
+		_, err := sess.Insert(key)
+		return err
+	})
+}
+
+func (s *Service) revokeKey(ctx context.Context, orgID int64, uid string) (*OrgKey, error) {
+	key := &OrgKey{}
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrKeyNotFound
+		}
+		if key.State == KeyStateRevoked {
+			return ErrKeyRevoked
+		}
+
+		now := s.now()
+		key.State = KeyStateRevoked
+		key.Updated = now
+		key.Revoked = &now
+		_, err = sess.ID(key.Id).Cols("state", "updated", "revoked").Update(key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,46 @@
+package rotation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/admin/encryption", func(encryptionRoute routing.RouteRegister) {
+		encryptionRoute.Get("/rotate-data-keys", middleware.ReqGrafanaAdmin, routing.Wrap(s.getStatusHandler))
+		encryptionRoute.Post("/rotate-data-keys", middleware.ReqGrafanaAdmin, routing.Wrap(s.startRotationHandler))
+	})
+}
+
+// GET /api/admin/encryption/rotate-data-keys
+func (s *Service) getStatusHandler(c *models.ReqContext) response.Response {
+	status, err := s.Status(c.Req.Context())
+	if err != nil {
+		return errorResponse(err, "Failed to get the data keys rotation status")
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// POST /api/admin/encryption/rotate-data-keys
+func (s *Service) startRotationHandler(c *models.ReqContext) response.Response {
+	if err := s.Start(c.Req.Context(), TriggerManual); err != nil {
+		return errorResponse(err, "Failed to rotate the data keys")
+	}
+	return response.JSON(http.StatusAccepted, map[string]interface{}{"message": "Data keys rotated, secrets re-encryption started"})
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, secrets.ErrEnvelopeEncryptionNotEnabled):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrRotationRunning):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,63 @@
+package rotation
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrRotationRunning = errors.New("a data keys rotation is running already")
+
+// Trigger is what started a rotation
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+	// TriggerOrgKey re-encryptions follow the rotation of the data keys of an organization, whose key encryption
+	// key changed
+	TriggerOrgKey Trigger = "org-key"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// Run is the report of a rotation of the data keys, with the progress of the re-encryption of each column of
+// secrets.
+type Run struct {
+	Trigger  Trigger     `json:"trigger"`
+	Status   RunStatus   `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Started  time.Time   `json:"started"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Secrets  []*Progress `json:"secrets"`
+}
+
+// Progress is the progress of the re-encryption of a column of secrets. Total is the number of rows with secrets
+// when the re-encryption started, Processed the number of rows checked so far, ReEncrypted the number of rows whose
+// secrets were encrypted with a rotated data key and have been re-encrypted, Skipped the number of rows changed
+// while they were re-encrypted, which are encrypted with a current data key already, and Failed the number of rows
+// whose secrets could not be re-encrypted.
+type Progress struct {
+	Table       string `json:"table"`
+	Column      string `json:"column"`
+	Total       int64  `json:"total"`
+	Processed   int64  `json:"processed"`
+	ReEncrypted int64  `json:"reencrypted"`
+	Skipped     int64  `json:"skipped"`
+	Failed      int64  `json:"failed"`
+}
+
+// Status is the state of the scheduled rotation. LastRun is the last rotation of any Grafana instance, Running tells
+// whether this instance is running a rotation.
+type Status struct {
+	Enabled  bool       `json:"enabled"`
+	Schedule string     `json:"schedule"`
+	NextRun  *time.Time `json:"nextRun,omitempty"`
+	Running  bool       `json:"running"`
+	LastRun  *Run       `json:"lastRun,omitempty"`
+}
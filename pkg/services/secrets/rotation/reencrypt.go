@@ -0,0 +1,286 @@
+package rotation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// reencryptBatchSize is the number of rows re-encrypted between two saves of the progress
+const reencryptBatchSize = 100
+
+// encoding is how the encrypted secrets of a column are stored
+type encoding int
+
+const (
+	// encodingRaw is an encrypted secret
+	encodingRaw encoding = iota
+	// encodingBase64 is a base64 encoded encrypted secret
+	encodingBase64
+	// encodingJSON is a JSON object of encrypted secrets, such as the secure json data of the data sources
+	encodingJSON
+	// encodingAlertmanager is an alertmanager configuration, the secure settings of its receivers are base64 encoded
+	// encrypted secrets
+	encodingAlertmanager
+	// encodingDashboard is the data of a dashboard, which is a base64 encoded encrypted JSON model when the dashboard
+	// is encrypted
+	encodingDashboard
+)
+
+// secretColumn is a column of secrets encrypted by the secrets service, in a table with an id primary key. The
+// secrets of the tables with an orgColumn are re-encrypted with the data keys of their organization.
+type secretColumn struct {
+	table     string
+	column    string
+	encoding  encoding
+	orgColumn string
+}
+
+var secretColumns = []secretColumn{
+	{table: "data_source", column: "secure_json_data", encoding: encodingJSON, orgColumn: "org_id"},
+	{table: "plugin_setting", column: "secure_json_data", encoding: encodingJSON, orgColumn: "org_id"},
+	{table: "user_auth", column: "o_auth_access_token", encoding: encodingBase64},
+	{table: "user_auth", column: "o_auth_refresh_token", encoding: encodingBase64},
+	{table: "user_auth", column: "o_auth_token_type", encoding: encodingBase64},
+	{table: "user_auth", column: "o_auth_id_token", encoding: encodingBase64},
+	{table: "user_totp", column: "secret", encoding: encodingBase64},
+	{table: "dashboard_snapshot", column: "dashboard_encrypted", encoding: encodingRaw, orgColumn: "org_id"},
+	{table: "alert_configuration", column: "alertmanager_configuration", encoding: encodingAlertmanager, orgColumn: "org_id"},
+	{table: "dashboard", column: "data", encoding: encodingDashboard, orgColumn: "org_id"},
+	{table: "dashboard_version", column: "data", encoding: encodingDashboard},
+}
+
+// secretRow is the secrets of a row of a secrets column
+type secretRow struct {
+	id    int64
+	orgID int64
+	value []byte
+}
+
+// scope returns the scope to re-encrypt the secrets of the row with, nil to keep their scope
+func (c secretColumn) scope(row secretRow) secrets.EncryptionOptions {
+	if c.orgColumn == "" {
+		return nil
+	}
+	return secrets.WithOrgScope(row.orgID)
+}
+
+// reencryptColumn re-encrypts the secrets of the column in batches, outside of transactions. The rows are updated
+// only if their secrets did not change meanwhile, the secrets changed meanwhile are encrypted with a current data
+// key already.
+func (s *Service) reencryptColumn(ctx context.Context, column secretColumn, progress *Progress, saveProgress func()) error {
+	total, err := s.countSecrets(ctx, column)
+	if err != nil {
+		return err
+	}
+	progress.Total = total
+	saveProgress()
+
+	var lastID int64
+	for {
+		rows, err := s.nextSecrets(ctx, column, lastID)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			lastID = row.id
+			progress.Processed++
+			if len(row.value) == 0 {
+				continue
+			}
+
+			reencrypted, changed, err := column.reencrypt(ctx, s.secrets, row.value, column.scope(row))
+			if err != nil {
+				progress.Failed++
+				s.log.Warn("Failed to re-encrypt secret", "table", column.table, "column", column.column, "id", row.id, "error", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			updated, err := s.updateSecret(ctx, column, row, reencrypted)
+			switch {
+			case err != nil:
+				progress.Failed++
+				s.log.Warn("Failed to save re-encrypted secret", "table", column.table, "column", column.column, "id", row.id, "error", err)
+			case updated:
+				progress.ReEncrypted++
+			default:
+				progress.Skipped++
+			}
+		}
+		saveProgress()
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Service) countSecrets(ctx context.Context, column secretColumn) (int64, error) {
+	var total int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.SQL(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NOT NULL", column.table, column.column)).Get(&total)
+		return err
+	})
+	return total, err
+}
+
+// nextSecrets returns the next batch of secrets of the column, after the row id
+func (s *Service) nextSecrets(ctx context.Context, column secretColumn, afterID int64) ([]secretRow, error) {
+	var rows []secretRow
+	orgColumn := "0"
+	if column.orgColumn != "" {
+		orgColumn = column.orgColumn
+	}
+
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		results, err := sess.SQL(fmt.Sprintf("SELECT id, %s AS org_id, %s AS secret FROM %s WHERE id > ? AND %s IS NOT NULL ORDER BY id %s",
+			orgColumn, column.column, column.table, column.column, s.sqlStore.Dialect.Limit(reencryptBatchSize)), afterID).Query()
+		if err != nil {
+			return err
+		}
+
+		rows = make([]secretRow, 0, len(results))
+		for _, result := range results {
+			id, err := strconv.ParseInt(string(result["id"]), 10, 64)
+			if err != nil {
+				return err
+			}
+			orgID, err := strconv.ParseInt(string(result["org_id"]), 10, 64)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, secretRow{id: id, orgID: orgID, value: result["secret"]})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// updateSecret saves the re-encrypted secrets of the row, and returns false when the secrets changed meanwhile
+func (s *Service) updateSecret(ctx context.Context, column secretColumn, row secretRow, reencrypted []byte) (bool, error) {
+	// the text columns are compared with strings, and the blob columns with bytes
+	arg := func(value []byte) interface{} {
+		if column.encoding == encodingRaw {
+			return value
+		}
+		return string(value)
+	}
+
+	var updated bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		result, err := sess.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ? AND %s = ?", column.table, column.column, column.column),
+			arg(reencrypted), row.id, arg(row.value))
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		updated = affected > 0
+		return err
+	})
+	return updated, err
+}
+
+// reencrypt re-encrypts the secrets of a row of the column encrypted with a rotated data key, or with a data key of
+// another scope than the scope of the row, and returns false when they are all encrypted with a current data key of
+// the scope already
+func (c secretColumn) reencrypt(ctx context.Context, secretsService *manager.SecretsService, value []byte, scope secrets.EncryptionOptions) ([]byte, bool, error) {
+	switch c.encoding {
+	case encodingBase64:
+		reencrypted, changed, err := reencryptBase64(ctx, secretsService, string(value), scope)
+		return []byte(reencrypted), changed, err
+
+	case encodingDashboard:
+		data, err := simplejson.NewJson(value)
+		if err != nil {
+			return nil, false, err
+		}
+		encrypted := data.Get(sqlstore.EncryptedDashboardKey).MustString()
+		if encrypted == "" {
+			return value, false, nil
+		}
+		reencrypted, changed, err := reencryptBase64(ctx, secretsService, encrypted, scope)
+		if err != nil || !changed {
+			return value, false, err
+		}
+		data.Set(sqlstore.EncryptedDashboardKey, reencrypted)
+		encoded, err := data.Encode()
+		return encoded, true, err
+
+	case encodingJSON:
+		secureJSONData := map[string][]byte{}
+		if err := json.Unmarshal(value, &secureJSONData); err != nil {
+			return nil, false, err
+		}
+		changed := false
+		for key, secret := range secureJSONData {
+			reencrypted, secretChanged, err := secretsService.ReEncryptWithScope(ctx, secret, scope)
+			if err != nil {
+				return nil, false, err
+			}
+			if secretChanged {
+				secureJSONData[key] = reencrypted
+				changed = true
+			}
+		}
+		if !changed {
+			return value, false, nil
+		}
+		encoded, err := json.Marshal(secureJSONData)
+		return encoded, true, err
+
+	case encodingAlertmanager:
+		config, err := notifier.Load(value)
+		if err != nil {
+			return nil, false, err
+		}
+		changed := false
+		for _, receiver := range config.AlertmanagerConfig.Receivers {
+			for _, gmr := range receiver.GrafanaManagedReceivers {
+				for key, secret := range gmr.SecureSettings {
+					reencrypted, secretChanged, err := reencryptBase64(ctx, secretsService, secret, scope)
+					if err != nil {
+						return nil, false, err
+					}
+					if secretChanged {
+						gmr.SecureSettings[key] = reencrypted
+						changed = true
+					}
+				}
+			}
+		}
+		if !changed {
+			return value, false, nil
+		}
+		encoded, err := json.Marshal(config)
+		return encoded, true, err
+	}
+
+	return secretsService.ReEncryptWithScope(ctx, value, scope)
+}
+
+func reencryptBase64(ctx context.Context, secretsService *manager.SecretsService, value string, scope secrets.EncryptionOptions) (string, bool, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", false, err
+	}
+	reencrypted, changed, err := secretsService.ReEncryptWithScope(ctx, decoded, scope)
+	if err != nil || !changed {
+		return value, false, err
+	}
+	return base64.StdEncoding.EncodeToString(reencrypted), true, nil
+}
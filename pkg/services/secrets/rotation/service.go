@@ -0,0 +1,232 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	kvNamespace = "secrets.rotation"
+	lastRunKey  = "last_run"
+	taskName    = "data-keys-rotation"
+)
+
+// Service rotates the data encryption keys of the envelope encryption: the data keys are deactivated, so that new
+// ones encrypt the secrets from then on, and the stored secrets are re-encrypted with the new data keys in background
+// batches. The deactivated data keys are kept to decrypt the secrets which could not be re-encrypted. Rotations run
+// on the schedule of the data-keys-rotation task, disabled by default, or through the /api/admin/encryption
+// endpoints, which report the progress of the last rotation.
+type Service struct {
+	sqlStore  *sqlstore.SQLStore
+	secrets   *manager.SecretsService
+	scheduler *scheduler.Service
+	kv        *kvstore.NamespacedKVStore
+	log       log.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	running bool
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, secretsService *manager.SecretsService, kvStore kvstore.KVStore,
+	routeRegister routing.RouteRegister, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		sqlStore:  sqlStore,
+		secrets:   secretsService,
+		scheduler: schedulerService,
+		kv:        kvstore.WithNamespace(kvStore, 0, kvNamespace),
+		log:       log.New("secrets.rotation"),
+		now:       time.Now,
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	// Only one Grafana instance rotates the data keys when running in a cluster
+	err := schedulerService.Register(scheduler.Task{
+		Name:        taskName,
+		Description: "Rotates the data encryption keys and re-encrypts the secrets with the new data keys.",
+		Cron:        "@monthly",
+		Exclusive:   true,
+		Run: func(ctx context.Context) error {
+			_, err := s.Rotate(ctx, TriggerSchedule)
+			return err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Status returns the schedule and the report of the last rotation.
+func (s *Service) Status(ctx context.Context) (*Status, error) {
+	task, err := s.scheduler.GetTask(ctx, taskName)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{
+		Enabled:  task.Enabled,
+		Schedule: task.Cron,
+		NextRun:  task.NextRun,
+		Running:  s.isRunning(),
+	}
+
+	lastRun, err := s.lastRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.LastRun = lastRun
+	return status, nil
+}
+
+// Start rotates the data keys and re-encrypts the secrets in the background. It returns ErrRotationRunning when a
+// rotation is running already, and secrets.ErrEnvelopeEncryptionNotEnabled when the data keys cannot be rotated.
+func (s *Service) Start(ctx context.Context, trigger Trigger) error {
+	if !s.setRunning() {
+		return ErrRotationRunning
+	}
+
+	if err := s.secrets.RotateDataKeys(ctx); err != nil {
+		s.clearRunning()
+		return err
+	}
+	s.startReEncryption(ctx, s.newRun(trigger))
+	return nil
+}
+
+// StartReEncryption re-encrypts the secrets in the background without rotating all the data keys, such as after
+// the data keys of an organization have been rotated. It returns ErrRotationRunning when a rotation is running
+// already.
+func (s *Service) StartReEncryption(ctx context.Context, trigger Trigger) error {
+	if !s.setRunning() {
+		return ErrRotationRunning
+	}
+	s.startReEncryption(ctx, s.newRun(trigger))
+	return nil
+}
+
+// startReEncryption saves the run and re-encrypts the secrets in the background, the caller must have set the
+// rotation running
+func (s *Service) startReEncryption(ctx context.Context, run *Run) {
+	s.saveRun(ctx, run)
+
+	go func() {
+		defer s.clearRunning()
+		if err := s.reencrypt(context.Background(), run); err != nil {
+			s.log.Error("Failed to re-encrypt the secrets", "error", err)
+		}
+	}()
+}
+
+// Rotate rotates the data keys, re-encrypts the secrets and returns the report of the rotation, which is saved as the
+// last run. The report is returned with the error when the re-encryption failed.
+func (s *Service) Rotate(ctx context.Context, trigger Trigger) (*Run, error) {
+	if !s.setRunning() {
+		return nil, ErrRotationRunning
+	}
+	defer s.clearRunning()
+
+	run := s.newRun(trigger)
+	if err := s.secrets.RotateDataKeys(ctx); err != nil {
+		return nil, err
+	}
+	s.saveRun(ctx, run)
+
+	err := s.reencrypt(ctx, run)
+	return run, err
+}
+
+func (s *Service) newRun(trigger Trigger) *Run {
+	run := &Run{Trigger: trigger, Status: RunStatusRunning, Started: s.now(), Secrets: make([]*Progress, 0, len(secretColumns))}
+	for _, column := range secretColumns {
+		run.Secrets = append(run.Secrets, &Progress{Table: column.table, Column: column.column})
+	}
+	return run
+}
+
+// reencrypt re-encrypts all the secrets columns, the progress is saved after each batch so that any instance can
+// report it
+func (s *Service) reencrypt(ctx context.Context, run *Run) error {
+	var err error
+	for i, column := range secretColumns {
+		if err = s.reencryptColumn(ctx, column, run.Secrets[i], func() { s.saveRun(ctx, run) }); err != nil {
+			err = fmt.Errorf("failed to re-encrypt %s.%s: %w", column.table, column.column, err)
+			break
+		}
+	}
+
+	finished := s.now()
+	run.Finished = &finished
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = RunStatusSucceeded
+	}
+	s.saveRun(ctx, run)
+
+	var reencrypted, failed int64
+	for _, progress := range run.Secrets {
+		reencrypted += progress.ReEncrypted
+		failed += progress.Failed
+	}
+	s.log.Info("Rotated the data keys", "trigger", run.Trigger, "status", run.Status, "reencrypted", reencrypted,
+		"failed", failed, "duration", finished.Sub(run.Started))
+	return err
+}
+
+func (s *Service) isRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// setRunning returns false when a rotation is running already
+func (s *Service) setRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *Service) clearRunning() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+func (s *Service) lastRun(ctx context.Context) (*Run, error) {
+	value, ok, err := s.kv.Get(ctx, lastRunKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	run := &Run{}
+	if err := json.Unmarshal([]byte(value), run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// saveRun saves the report as the last run, a rotation is not failed because its report cannot be saved
+func (s *Service) saveRun(ctx context.Context, run *Run) {
+	value, err := json.Marshal(run)
+	if err == nil {
+		err = s.kv.Set(ctx, lastRunKey, string(value))
+	}
+	if err != nil {
+		s.log.Warn("Failed to save the data keys rotation report", "error", err)
+	}
+}
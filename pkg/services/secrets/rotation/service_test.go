@@ -0,0 +1,113 @@
+package rotation
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestService_Rotate(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	secretsStore := database.ProvideSecretsStore(store)
+	secretsService := manager.SetupTestService(t, secretsStore)
+
+	kv := kvstore.ProvideService(store)
+	s, err := ProvideService(store, secretsService, kv, routing.NewRouteRegister(),
+		scheduler.ProvideService(nil, kv, accesscontrolmock.New(), routing.NewRouteRegister()))
+	require.NoError(t, err)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	secureJSONData, err := secretsService.EncryptJsonData(ctx, map[string]string{"password": "secret"}, secrets.WithoutScope())
+	require.NoError(t, err)
+	require.NoError(t, store.AddDataSource(ctx, &models.AddDataSourceCommand{
+		OrgId: 1, Name: "prometheus", Type: "prometheus", Access: models.DS_ACCESS_PROXY, EncryptedSecureJsonData: secureJSONData,
+	}))
+	totpSecret, err := secretsService.Encrypt(ctx, []byte("totp"), secrets.WithScope("user:1"))
+	require.NoError(t, err)
+	err = store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("INSERT INTO user_totp (user_id, secret, enabled, last_used_step, created, updated) VALUES (?, ?, ?, ?, ?, ?)",
+			1, base64.StdEncoding.EncodeToString(totpSecret), true, 0, now, now)
+		return err
+	})
+	require.NoError(t, err)
+
+	progress := func(run *Run, table, column string) *Progress {
+		for _, p := range run.Secrets {
+			if p.Table == table && p.Column == column {
+				return p
+			}
+		}
+		t.Fatalf("no progress of %s.%s", table, column)
+		return nil
+	}
+
+	t.Run("should rotate the data keys and re-encrypt the secrets", func(t *testing.T) {
+		oldKeys, err := secretsStore.GetAllDataKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, oldKeys, 2)
+
+		run, err := s.Rotate(ctx, TriggerManual)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusSucceeded, run.Status)
+		assert.Equal(t, &Progress{Table: "data_source", Column: "secure_json_data", Total: 1, Processed: 1, ReEncrypted: 1}, progress(run, "data_source", "secure_json_data"))
+		assert.Equal(t, &Progress{Table: "user_totp", Column: "secret", Total: 1, Processed: 1, ReEncrypted: 1}, progress(run, "user_totp", "secret"))
+		assert.Zero(t, progress(run, "dashboard_snapshot", "dashboard_encrypted").Total)
+
+		keys, err := secretsStore.GetAllDataKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 4)
+		for _, key := range keys {
+			rotated := key.Name == oldKeys[0].Name || key.Name == oldKeys[1].Name
+			assert.Equal(t, !rotated, key.Active, key.Name)
+		}
+
+		query := &models.GetDataSourceQuery{OrgId: 1, Name: "prometheus"}
+		require.NoError(t, store.GetDataSource(ctx, query))
+		assert.NotEqual(t, secureJSONData["password"], query.Result.SecureJsonData["password"])
+		// the secrets of the organizations are moved to the data keys of their organization
+		reencrypted, _, err := secretsService.ReEncryptWithScope(ctx, query.Result.SecureJsonData["password"], secrets.WithOrgScope(1))
+		require.NoError(t, err)
+		assert.Equal(t, query.Result.SecureJsonData["password"], reencrypted)
+		decrypted, err := secretsService.DecryptJsonData(ctx, query.Result.SecureJsonData)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "secret"}, decrypted)
+	})
+
+	t.Run("should skip the secrets encrypted with the current data keys", func(t *testing.T) {
+		run := s.newRun(TriggerManual)
+		require.NoError(t, s.reencrypt(ctx, run))
+		assert.Equal(t, &Progress{Table: "data_source", Column: "secure_json_data", Total: 1, Processed: 1}, progress(run, "data_source", "secure_json_data"))
+	})
+
+	t.Run("should report the last rotation", func(t *testing.T) {
+		status, err := s.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+		assert.False(t, status.Running)
+		require.NotNil(t, status.LastRun)
+		assert.Equal(t, RunStatusSucceeded, status.LastRun.Status)
+		assert.Equal(t, int64(0), progress(status.LastRun, "data_source", "secure_json_data").ReEncrypted)
+	})
+
+	t.Run("should refuse to start a rotation when one is running", func(t *testing.T) {
+		require.True(t, s.setRunning())
+		defer s.clearRunning()
+		assert.ErrorIs(t, s.Start(ctx, TriggerManual), ErrRotationRunning)
+	})
+}
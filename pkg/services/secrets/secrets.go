@@ -31,10 +31,13 @@ type Service interface {
 // Store defines methods to interact with secrets storage
 type Store interface {
 	GetDataKey(ctx context.Context, name string) (*DataKey, error)
+	GetCurrentDataKey(ctx context.Context, label string) (*DataKey, error)
 	GetAllDataKeys(ctx context.Context) ([]*DataKey, error)
 	CreateDataKey(ctx context.Context, dataKey DataKey) error
 	CreateDataKeyWithDBSession(ctx context.Context, dataKey DataKey, sess *xorm.Session) error
 	DeleteDataKey(ctx context.Context, name string) error
+	DisableDataKeys(ctx context.Context) error
+	DisableScopeDataKeys(ctx context.Context, scope string) error
 }
 
 // Provider is a key encryption key provider for envelope encryption
@@ -42,3 +45,13 @@ type Provider interface {
 	Encrypt(ctx context.Context, blob []byte) ([]byte, error)
 	Decrypt(ctx context.Context, blob []byte) ([]byte, error)
 }
+
+// ScopedProviders resolves the key encryption keys of the scopes whose data keys are not encrypted with the current
+// provider, such as the keys registered by the organizations for their secrets.
+type ScopedProviders interface {
+	// ScopeProvider returns the name and the provider of the key encryption key of a scope, or an empty name when
+	// the data keys of the scope are encrypted with the current provider.
+	ScopeProvider(ctx context.Context, scope string) (string, Provider, error)
+	// Provider returns a key encryption key by its name, ok is false when the name is not one of its keys.
+	Provider(ctx context.Context, name string) (provider Provider, ok bool, err error)
+}
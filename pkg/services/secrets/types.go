@@ -2,14 +2,21 @@ package secrets
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
-var ErrDataKeyNotFound = errors.New("data key not found")
+var (
+	ErrDataKeyNotFound              = errors.New("data key not found")
+	ErrEnvelopeEncryptionNotEnabled = errors.New("envelope encryption is not enabled")
+)
 
 type DataKey struct {
-	Active        bool
-	Name          string
+	Active bool
+	Name   string
+	// Label groups the data keys of a day, scope and provider, only the last active data key of a label is used
+	// for encryption.
+	Label         string
 	Scope         string
 	Provider      string
 	EncryptedData []byte
@@ -34,3 +41,14 @@ func WithScope(scope string) EncryptionOptions {
 		return scope
 	}
 }
+
+// WithOrgScope uses a data key for encryption bound to an organization, encrypted with the key registered by the
+// organization if any.
+func WithOrgScope(orgID int64) EncryptionOptions {
+	return WithScope(OrgScope(orgID))
+}
+
+// OrgScope returns the scope of the data keys of an organization.
+func OrgScope(orgID int64) string {
+	return fmt.Sprintf("org:%d", orgID)
+}
@@ -11,28 +11,34 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/federation"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
 type ServiceAccountsAPI struct {
+	cfg            *setting.Cfg
 	service        serviceaccounts.Service
 	accesscontrol  accesscontrol.AccessControl
 	RouterRegister routing.RouteRegister
 	store          serviceaccounts.Store
+	verifier       tokenVerifier
 }
 
 func NewServiceAccountsAPI(
+	cfg *setting.Cfg,
 	service serviceaccounts.Service,
 	accesscontrol accesscontrol.AccessControl,
 	routerRegister routing.RouteRegister,
 	store serviceaccounts.Store,
 ) *ServiceAccountsAPI {
 	return &ServiceAccountsAPI{
+		cfg:            cfg,
 		service:        service,
 		accesscontrol:  accesscontrol,
 		RouterRegister: routerRegister,
 		store:          store,
+		verifier:       federation.NewVerifier(cfg),
 	}
 }
 
@@ -44,9 +50,21 @@ func (api *ServiceAccountsAPI) RegisterAPIEndpoints(
 	}
 	auth := acmiddleware.Middleware(api.accesscontrol)
 	api.RouterRegister.Group("/api/serviceaccounts", func(serviceAccountsRoute routing.RouteRegister) {
+		serviceAccountsRoute.Get("/", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionRead, serviceaccounts.ScopeAll)), routing.Wrap(api.ListServiceAccounts))
+		serviceAccountsRoute.Get("/:serviceAccountId", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionRead, serviceaccounts.ScopeID)), routing.Wrap(api.RetrieveServiceAccount))
 		serviceAccountsRoute.Delete("/:serviceAccountId", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionDelete, serviceaccounts.ScopeID)), routing.Wrap(api.DeleteServiceAccount))
+		serviceAccountsRoute.Get("/:serviceAccountId/tokens", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionRead, serviceaccounts.ScopeID)), routing.Wrap(api.ListTokens))
+		serviceAccountsRoute.Post("/:serviceAccountId/tokens", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.CreateToken))
+		serviceAccountsRoute.Delete("/:serviceAccountId/tokens/:tokenId", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.DeleteToken))
+		serviceAccountsRoute.Get("/:serviceAccountId/federated-credentials", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionRead, serviceaccounts.ScopeID)), routing.Wrap(api.ListFederatedCredentials))
+		serviceAccountsRoute.Post("/:serviceAccountId/federated-credentials", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.AddFederatedCredential))
+		serviceAccountsRoute.Delete("/:serviceAccountId/federated-credentials/:uid", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.DeleteFederatedCredential))
 		serviceAccountsRoute.Get("/upgrade", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionCreate, serviceaccounts.ScopeID)), routing.Wrap(api.UpgradeServiceAccounts))
 		serviceAccountsRoute.Post("/", auth(middleware.ReqOrgAdmin, accesscontrol.EvalPermission(serviceaccounts.ActionCreate, serviceaccounts.ScopeID)), routing.Wrap(api.CreateServiceAccount))
+		if cfg.WorkloadIdentityEnabled {
+			// workloads authenticate with their token, the route is not signed in
+			serviceAccountsRoute.Post("/token-exchange", routing.Wrap(api.ExchangeToken))
+		}
 	})
 }
 
@@ -56,10 +74,16 @@ func (api *ServiceAccountsAPI) CreateServiceAccount(c *models.ReqContext) respon
 	if err := web.Bind(c.Req, &cmd); err != nil {
 		return response.Error(http.StatusBadRequest, "Bad request data", err)
 	}
+	if cmd.Role != "" && !cmd.Role.IsValid() {
+		return response.Error(http.StatusBadRequest, "Invalid role specified", nil)
+	}
+	cmd.OrgID = c.OrgId
 	user, err := api.service.CreateServiceAccount(c.Req.Context(), &cmd)
 	switch {
 	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
 		return response.Error(http.StatusBadRequest, "Failed to create role with the provided name", err)
+	case errors.Is(err, serviceaccounts.ErrServiceAccountAlreadyExists):
+		return response.Error(http.StatusConflict, "Service account with the provided name already exists", err)
 	case err != nil:
 		return response.Error(http.StatusInternalServerError, "Failed to create service account", err)
 	}
@@ -67,6 +91,27 @@ func (api *ServiceAccountsAPI) CreateServiceAccount(c *models.ReqContext) respon
 	return response.JSON(http.StatusCreated, user)
 }
 
+// GET /api/serviceaccounts
+func (api *ServiceAccountsAPI) ListServiceAccounts(c *models.ReqContext) response.Response {
+	serviceAccounts, err := api.store.ListServiceAccounts(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list service accounts", err)
+	}
+	return response.JSON(http.StatusOK, serviceAccounts)
+}
+
+// GET /api/serviceaccounts/:serviceAccountId
+func (api *ServiceAccountsAPI) RetrieveServiceAccount(c *models.ReqContext) response.Response {
+	serviceAccount, err := api.store.RetrieveServiceAccount(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"))
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Service account not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to retrieve service account", err)
+	}
+	return response.JSON(http.StatusOK, serviceAccount)
+}
+
 func (api *ServiceAccountsAPI) DeleteServiceAccount(ctx *models.ReqContext) response.Response {
 	scopeID := ctx.ParamsInt64(":serviceAccountId")
 	err := api.service.DeleteServiceAccount(ctx.Req.Context(), ctx.OrgId, scopeID)
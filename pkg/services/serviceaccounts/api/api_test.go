@@ -2,21 +2,29 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/federation"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
@@ -96,7 +104,9 @@ func serviceAccountDeletionScenario(t *testing.T, httpMethod string, endpoint st
 
 func setupTestServer(t *testing.T, svc *tests.ServiceAccountMock, routerRegister routing.RouteRegister, acmock *accesscontrolmock.Mock) *web.Mux {
 	store := sqlstore.InitTestDB(t)
-	a := NewServiceAccountsAPI(svc, acmock, routerRegister, database.NewServiceAccountsStore(store))
+	cfg := setting.NewCfg()
+	cfg.ApiKeyMaxSecondsToLive = -1
+	a := NewServiceAccountsAPI(cfg, svc, acmock, routerRegister, database.NewServiceAccountsStore(store))
 	a.RegisterAPIEndpoints(&setting.Cfg{FeatureToggles: map[string]bool{"service-accounts": true}})
 
 	m := web.New()
@@ -117,3 +127,202 @@ func setupTestServer(t *testing.T, svc *tests.ServiceAccountMock, routerRegister
 	a.RouterRegister.Register(m.Router)
 	return m
 }
+
+func TestServiceAccountsAPI_Tokens(t *testing.T) {
+	svcmock := tests.ServiceAccountMock{}
+	acmock := tests.SetupMockAccesscontrol(
+		t,
+		func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+			return []*accesscontrol.Permission{
+				{Action: serviceaccounts.ActionRead, Scope: serviceaccounts.ScopeAll},
+				{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll},
+			}, nil
+		},
+		false,
+	)
+	server := setupTestServer(t, &svcmock, routing.NewRouteRegister(), acmock)
+	// the server and the test share the test database
+	store := sqlstore.InitTestDB(t)
+	tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "admin"})
+	sa, err := database.NewServiceAccountsStore(store).CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "bot"})
+	require.NoError(t, err)
+	tokensPath := fmt.Sprintf("/api/serviceaccounts/%d/tokens", sa.Id)
+
+	var request = func(httpMethod, requestpath, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(httpMethod, requestpath, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("should create a token and return its key once", func(t *testing.T) {
+		recorder := request(http.MethodPost, tokensPath, `{"name": "ci", "secondsToLive": 3600}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		result := dtos.NewApiKeyResult{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+		assert.Equal(t, "ci", result.Name)
+		assert.NotEmpty(t, result.Key)
+
+		recorder = request(http.MethodPost, tokensPath, `{"name": "ci"}`)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	t.Run("should list the tokens without their key", func(t *testing.T) {
+		recorder := request(http.MethodGet, tokensPath, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.NotContains(t, recorder.Body.String(), `"key"`)
+
+		tokens := make([]*serviceaccounts.TokenDTO, 0)
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+		require.Len(t, tokens, 1)
+		assert.Equal(t, "ci", tokens[0].Name)
+		assert.NotNil(t, tokens[0].Expiration)
+		assert.False(t, tokens[0].HasExpired)
+		assert.Nil(t, tokens[0].LastUsedAt)
+
+		recorder = request(http.MethodDelete, fmt.Sprintf("%s/%d", tokensPath, tokens[0].Id), "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("should not find the tokens of unknown service accounts", func(t *testing.T) {
+		recorder := request(http.MethodGet, "/api/serviceaccounts/999/tokens", "")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+type fakeVerifier struct {
+	claims *federation.Claims
+	err    error
+}
+
+func (v *fakeVerifier) IsAllowedIssuer(issuer string) bool {
+	return issuer == "https://token.actions.githubusercontent.com"
+}
+
+func (v *fakeVerifier) Verify(ctx context.Context, rawToken string) (*federation.Claims, error) {
+	return v.claims, v.err
+}
+
+func TestServiceAccountsAPI_FederatedCredentials(t *testing.T) {
+	acmock := tests.SetupMockAccesscontrol(
+		t,
+		func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+			return []*accesscontrol.Permission{
+				{Action: serviceaccounts.ActionRead, Scope: serviceaccounts.ScopeAll},
+				{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll},
+			}, nil
+		},
+		false,
+	)
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/"
+	cfg.ApiKeyMaxSecondsToLive = -1
+	cfg.WorkloadIdentityEnabled = true
+	cfg.WorkloadIdentityTokenTTL = time.Hour
+	cfg.FeatureToggles = map[string]bool{"service-accounts": true}
+	verifier := &fakeVerifier{}
+	a := NewServiceAccountsAPI(cfg, &tests.ServiceAccountMock{}, acmock, routing.NewRouteRegister(), database.NewServiceAccountsStore(store))
+	a.verifier = verifier
+	a.RegisterAPIEndpoints(cfg)
+	server := web.New()
+	server.Use(func(c *web.Context) {
+		c.Map(&models.ReqContext{
+			Context:      c,
+			IsSignedIn:   true,
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			Logger:       log.New("serviceaccounts-test"),
+		})
+	})
+	a.RouterRegister.Register(server.Router)
+
+	tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "admin"})
+	sa, err := database.NewServiceAccountsStore(store).CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "ci", Role: models.ROLE_EDITOR})
+	require.NoError(t, err)
+	credentialsPath := fmt.Sprintf("/api/serviceaccounts/%d/federated-credentials", sa.Id)
+
+	var request = func(httpMethod, requestpath, contentType, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(httpMethod, requestpath, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+	var exchange = func(token string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+			"subject_token_type": {"urn:ietf:params:oauth:token-type:jwt"},
+			"subject_token":      {token},
+		}
+		return request(http.MethodPost, "/api/serviceaccounts/token-exchange", "application/x-www-form-urlencoded", form.Encode())
+	}
+
+	credential := serviceaccounts.FederatedCredential{}
+	t.Run("should add federated credentials of allowed issuers", func(t *testing.T) {
+		recorder := request(http.MethodPost, credentialsPath, "application/json",
+			`{"issuer": "https://token.actions.githubusercontent.com", "subject": "repo:grafana/grafana:*"}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &credential))
+		assert.Equal(t, "https://grafana.example.com", credential.Audience)
+
+		recorder = request(http.MethodPost, credentialsPath, "application/json",
+			`{"issuer": "https://evil.example.com", "subject": "repo:grafana/grafana:*"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		recorder = request(http.MethodGet, credentialsPath, "", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), credential.Uid)
+	})
+
+	t.Run("should exchange matching tokens for service account tokens", func(t *testing.T) {
+		verifier.claims = &federation.Claims{
+			Issuer:   "https://token.actions.githubusercontent.com",
+			Subject:  "repo:grafana/grafana:ref:refs/heads/main",
+			Audience: []string{"https://grafana.example.com"},
+		}
+		recorder := exchange("token")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+		result := tokenExchangeResponse{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+		assert.Equal(t, "Bearer", result.TokenType)
+		assert.Equal(t, int64(3600), result.ExpiresIn)
+
+		decoded, err := apikeygen.Decode(result.AccessToken)
+		require.NoError(t, err)
+		key := models.GetApiKeyByNameQuery{OrgId: 1, KeyName: decoded.Name}
+		require.NoError(t, store.GetApiKeyByName(context.Background(), &key))
+		assert.Equal(t, sa.Id, key.Result.ServiceAccountId)
+		assert.NotNil(t, key.Result.Expires)
+	})
+
+	t.Run("should reject tokens without matching credentials", func(t *testing.T) {
+		verifier.claims = &federation.Claims{
+			Issuer:   "https://token.actions.githubusercontent.com",
+			Subject:  "repo:grafana/loki:ref:refs/heads/main",
+			Audience: []string{"https://grafana.example.com"},
+		}
+		recorder := exchange("token")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"error":"invalid_grant"`)
+
+		verifier.claims, verifier.err = nil, federation.ErrInvalidToken
+		recorder = exchange("token")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		recorder = request(http.MethodPost, "/api/serviceaccounts/token-exchange", "application/x-www-form-urlencoded", "grant_type=password")
+		assert.Contains(t, recorder.Body.String(), `"error":"unsupported_grant_type"`)
+	})
+
+	t.Run("should delete federated credentials", func(t *testing.T) {
+		recorder := request(http.MethodDelete, credentialsPath+"/"+credential.Uid, "", "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		recorder = request(http.MethodDelete, credentialsPath+"/"+credential.Uid, "", "")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
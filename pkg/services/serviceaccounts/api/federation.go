@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/federation"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// Token exchange identifiers, as defined in RFC 8693
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeIDToken       = "urn:ietf:params:oauth:token-type:id_token"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+var errSeveralServiceAccounts = errors.New("the subject token matches several service accounts, set service_account_id to pick one")
+
+type tokenVerifier interface {
+	IsAllowedIssuer(issuer string) bool
+	Verify(ctx context.Context, rawToken string) (*federation.Claims, error)
+}
+
+type tokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+}
+
+// GET /api/serviceaccounts/:serviceAccountId/federated-credentials
+func (api *ServiceAccountsAPI) ListFederatedCredentials(c *models.ReqContext) response.Response {
+	credentials, err := api.store.ListFederatedCredentials(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"))
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Service account not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to list federated credentials", err)
+	}
+	return response.JSON(http.StatusOK, credentials)
+}
+
+// POST /api/serviceaccounts/:serviceAccountId/federated-credentials
+func (api *ServiceAccountsAPI) AddFederatedCredential(c *models.ReqContext) response.Response {
+	cmd := serviceaccounts.AddFederatedCredentialCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	credential := &serviceaccounts.FederatedCredential{
+		OrgId:            c.OrgId,
+		ServiceAccountId: c.ParamsInt64(":serviceAccountId"),
+		Issuer:           strings.TrimSpace(cmd.Issuer),
+		Subject:          strings.TrimSpace(cmd.Subject),
+		Audience:         strings.TrimSpace(cmd.Audience),
+		Created:          time.Now(),
+	}
+	if credential.Audience == "" {
+		credential.Audience = strings.TrimSuffix(api.cfg.AppURL, "/")
+	}
+	if !api.verifier.IsAllowedIssuer(credential.Issuer) {
+		return response.Error(http.StatusBadRequest, serviceaccounts.ErrIssuerNotAllowed.Error(), nil)
+	}
+	if err := federation.ValidateSubject(credential.Subject); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	err := api.store.AddFederatedCredential(c.Req.Context(), credential)
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Service account not found", err)
+	case errors.Is(err, serviceaccounts.ErrFederatedCredentialExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to add federated credential", err)
+	}
+	return response.JSON(http.StatusOK, credential)
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId/federated-credentials/:uid
+func (api *ServiceAccountsAPI) DeleteFederatedCredential(c *models.ReqContext) response.Response {
+	err := api.store.DeleteFederatedCredential(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"), web.Params(c.Req)[":uid"])
+	switch {
+	case errors.Is(err, serviceaccounts.ErrFederatedCredentialNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to delete federated credential", err)
+	}
+	return response.Success("Federated credential deleted")
+}
+
+// POST /api/serviceaccounts/token-exchange
+//
+// ExchangeToken exchanges the OpenID Connect token of a workload for a short-lived token of the
+// service account with a matching federated credential. The request and the response follow the
+// OAuth 2.0 token exchange specification (RFC 8693).
+func (api *ServiceAccountsAPI) ExchangeToken(c *models.ReqContext) response.Response {
+	if c.Req.PostFormValue("grant_type") != grantTypeTokenExchange {
+		return tokenExchangeError(c, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be "+grantTypeTokenExchange, nil)
+	}
+	if tokenType := c.Req.PostFormValue("subject_token_type"); tokenType != tokenTypeJWT && tokenType != tokenTypeIDToken {
+		return tokenExchangeError(c, http.StatusBadRequest, "invalid_request", "subject_token_type must be "+tokenTypeJWT+" or "+tokenTypeIDToken, nil)
+	}
+	subjectToken := c.Req.PostFormValue("subject_token")
+	if subjectToken == "" {
+		return tokenExchangeError(c, http.StatusBadRequest, "invalid_request", "subject_token is required", nil)
+	}
+	var serviceAccountID int64
+	if value := c.Req.PostFormValue("service_account_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return tokenExchangeError(c, http.StatusBadRequest, "invalid_request", "service_account_id must be a number", nil)
+		}
+		serviceAccountID = id
+	}
+
+	ctx := c.Req.Context()
+	claims, err := api.verifier.Verify(ctx, subjectToken)
+	switch {
+	case errors.Is(err, federation.ErrInvalidToken), errors.Is(err, serviceaccounts.ErrIssuerNotAllowed):
+		return tokenExchangeError(c, http.StatusBadRequest, "invalid_grant", err.Error(), nil)
+	case err != nil:
+		return tokenExchangeError(c, http.StatusInternalServerError, "server_error", "Failed to verify the subject token", err)
+	}
+
+	credential, err := api.matchFederatedCredential(ctx, claims, serviceAccountID)
+	switch {
+	case errors.Is(err, errSeveralServiceAccounts):
+		return tokenExchangeError(c, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+	case err != nil:
+		return tokenExchangeError(c, http.StatusInternalServerError, "server_error", "Failed to find federated credentials", err)
+	case credential == nil:
+		c.Logger.Info("No federated credential matches the workload identity token", "issuer", claims.Issuer, "subject", claims.Subject)
+		return tokenExchangeError(c, http.StatusBadRequest, "invalid_grant", "no service account can be used with the subject token", nil)
+	}
+
+	serviceAccount, err := api.store.RetrieveServiceAccount(ctx, credential.OrgId, credential.ServiceAccountId)
+	if err != nil {
+		return tokenExchangeError(c, http.StatusInternalServerError, "server_error", "Failed to retrieve service account", err)
+	}
+
+	secondsToLive := int64(api.cfg.WorkloadIdentityTokenTTL.Seconds())
+	if api.cfg.ApiKeyMaxSecondsToLive > 0 && secondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
+		secondsToLive = api.cfg.ApiKeyMaxSecondsToLive
+	}
+
+	name := fmt.Sprintf("%s%s-%s", models.FederatedTokenNamePrefix, credential.Uid, util.GenerateShortUID())
+	newKeyInfo, err := apikeygen.New(credential.OrgId, name)
+	if err != nil {
+		return tokenExchangeError(c, http.StatusInternalServerError, "server_error", "Generating service account token failed", err)
+	}
+	addCmd := models.AddApiKeyCommand{
+		Name:          name,
+		Role:          serviceAccount.Role,
+		OrgId:         credential.OrgId,
+		Key:           newKeyInfo.HashedKey,
+		SecondsToLive: secondsToLive,
+	}
+	if err := api.store.AddServiceAccountToken(ctx, serviceAccount.Id, &addCmd); err != nil {
+		return tokenExchangeError(c, http.StatusInternalServerError, "server_error", "Failed to add service account token", err)
+	}
+
+	c.Logger.Info("Exchanged workload identity token for a service account token", "issuer", claims.Issuer,
+		"subject", claims.Subject, "serviceAccountId", serviceAccount.Id, "tokenId", addCmd.Result.Id)
+	return response.JSON(http.StatusOK, tokenExchangeResponse{
+		AccessToken:     newKeyInfo.ClientSecret,
+		IssuedTokenType: tokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       secondsToLive,
+	}).SetHeader("Cache-Control", "no-store")
+}
+
+// matchFederatedCredential returns the federated credential matching the claims, or nil when no
+// credential matches. The service account ID, when set, picks the service account among the ones
+// with matching credentials.
+func (api *ServiceAccountsAPI) matchFederatedCredential(ctx context.Context, claims *federation.Claims, serviceAccountID int64) (*serviceaccounts.FederatedCredential, error) {
+	credentials, err := api.store.FindFederatedCredentials(ctx, claims.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *serviceaccounts.FederatedCredential
+	for _, credential := range credentials {
+		if serviceAccountID != 0 && credential.ServiceAccountId != serviceAccountID {
+			continue
+		}
+		if !federation.Matches(credential, claims) {
+			continue
+		}
+		if match != nil && match.ServiceAccountId != credential.ServiceAccountId {
+			return nil, errSeveralServiceAccounts
+		}
+		match = credential
+	}
+	return match, nil
+}
+
+func tokenExchangeError(c *models.ReqContext, status int, code, description string, err error) response.Response {
+	if err != nil {
+		c.Logger.Error(description, "error", err)
+	}
+	return response.JSON(status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/serviceaccounts/:serviceAccountId/tokens
+func (api *ServiceAccountsAPI) ListTokens(c *models.ReqContext) response.Response {
+	tokens, err := api.store.ListTokens(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"))
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Service account not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to list service account tokens", err)
+	}
+
+	now := time.Now()
+	result := make([]*serviceaccounts.TokenDTO, len(tokens))
+	for i, t := range tokens {
+		var expiration *time.Time
+		if t.Expires != nil {
+			v := time.Unix(*t.Expires, 0)
+			expiration = &v
+		}
+		result[i] = &serviceaccounts.TokenDTO{
+			Id:         t.Id,
+			Name:       t.Name,
+			Created:    t.Created,
+			Expiration: expiration,
+			HasExpired: expiration != nil && !expiration.After(now),
+			LastUsedAt: t.LastUsedAt,
+		}
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// POST /api/serviceaccounts/:serviceAccountId/tokens
+func (api *ServiceAccountsAPI) CreateToken(c *models.ReqContext) response.Response {
+	cmd := serviceaccounts.AddServiceAccountTokenCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
+		if cmd.SecondsToLive == 0 {
+			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
+		}
+		if cmd.SecondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
+			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
+		}
+	}
+
+	serviceAccount, err := api.store.RetrieveServiceAccount(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"))
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Service account not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to retrieve service account", err)
+	}
+
+	newKeyInfo, err := apikeygen.New(c.OrgId, cmd.Name)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
+	}
+
+	// The role of the key is not used, requests are signed in as the service account
+	addCmd := models.AddApiKeyCommand{
+		Name:          cmd.Name,
+		Role:          serviceAccount.Role,
+		OrgId:         c.OrgId,
+		Key:           newKeyInfo.HashedKey,
+		SecondsToLive: cmd.SecondsToLive,
+	}
+	if err := api.store.AddServiceAccountToken(c.Req.Context(), serviceAccount.Id, &addCmd); err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidApiKeyExpiration):
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, models.ErrDuplicateApiKey):
+			return response.Error(http.StatusConflict, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add service account token", err)
+	}
+
+	return response.JSON(http.StatusOK, &dtos.NewApiKeyResult{
+		ID:   addCmd.Result.Id,
+		Name: addCmd.Result.Name,
+		Key:  newKeyInfo.ClientSecret,
+	})
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId/tokens/:tokenId
+func (api *ServiceAccountsAPI) DeleteToken(c *models.ReqContext) response.Response {
+	err := api.store.DeleteServiceAccountToken(c.Req.Context(), c.OrgId, c.ParamsInt64(":serviceAccountId"), c.ParamsInt64(":tokenId"))
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountTokenNotFound):
+		return response.Error(http.StatusNotFound, "Service account token not found", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to delete service account token", err)
+	}
+	return response.Success("Service account token deleted")
+}
@@ -2,9 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
@@ -19,22 +19,71 @@ type ServiceAccountsStoreImpl struct {
 func NewServiceAccountsStore(store *sqlstore.SQLStore) *ServiceAccountsStoreImpl {
 	return &ServiceAccountsStoreImpl{
 		sqlStore: store,
+		log:      log.New("serviceaccounts.store"),
 	}
 }
 
 func (s *ServiceAccountsStoreImpl) CreateServiceAccount(ctx context.Context, sa *serviceaccounts.CreateServiceaccountForm) (user *models.User, err error) {
-	// create a new service account - "user" with empty permissions
-	cmd := models.CreateUserCommand{
-		Login:            "Service-Account-" + uuid.New().String(),
-		Name:             sa.Name + "-Service-Account-" + uuid.New().String(),
-		OrgId:            sa.OrgID,
-		IsServiceAccount: true,
+	role := sa.Role
+	if role == "" {
+		role = models.ROLE_VIEWER
 	}
-	newuser, err := s.sqlStore.CreateUser(ctx, cmd)
+
+	err = s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		// the service account is a "user" of the organization only, with the permissions of its role
+		user, err = s.sqlStore.CreateUser(ctx, models.CreateUserCommand{
+			Login:            fmt.Sprintf("sa-%d-%s", sa.OrgID, models.SlugifyTitle(sa.Name)),
+			Name:             sa.Name,
+			OrgId:            sa.OrgID,
+			IsServiceAccount: true,
+			SkipOrgSetup:     true,
+		})
+		if err != nil {
+			if errors.Is(err, models.ErrUserAlreadyExists) {
+				return serviceaccounts.ErrServiceAccountAlreadyExists
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		return s.sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: sa.OrgID, UserId: user.Id, Role: role})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.OrgId = sa.OrgID
+	return user, nil
+}
+
+func (s *ServiceAccountsStoreImpl) ListServiceAccounts(ctx context.Context, orgID int64) ([]*serviceaccounts.ServiceAccountDTO, error) {
+	result := make([]*serviceaccounts.ServiceAccountDTO, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(s.serviceAccountsQuery()+" ORDER BY u.login", orgID, true).Find(&result)
+	})
+	return result, err
+}
+
+func (s *ServiceAccountsStoreImpl) RetrieveServiceAccount(ctx context.Context, orgID, serviceAccountID int64) (*serviceaccounts.ServiceAccountDTO, error) {
+	result := make([]*serviceaccounts.ServiceAccountDTO, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(s.serviceAccountsQuery()+" AND u.id = ?", orgID, true, serviceAccountID).Find(&result)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, serviceaccounts.ErrServiceAccountNotFound
 	}
-	return newuser, nil
+	return result[0], nil
+}
+
+// serviceAccountsQuery selects the service accounts of an organization along with their role and number of tokens
+func (s *ServiceAccountsStoreImpl) serviceAccountsQuery() string {
+	return `SELECT u.id, org_user.org_id, u.name, u.login, org_user.role,
+		(SELECT COUNT(*) FROM api_key WHERE api_key.service_account_id = u.id) AS tokens
+		FROM org_user
+		INNER JOIN ` + s.sqlStore.Dialect.Quote("user") + ` AS u ON u.id = org_user.user_id
+		WHERE org_user.org_id = ? AND u.is_service_account = ?`
 }
 
 func (s *ServiceAccountsStoreImpl) DeleteServiceAccount(ctx context.Context, orgID, serviceaccountID int64) error {
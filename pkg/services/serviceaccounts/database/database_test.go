@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +50,145 @@ func setupTestDatabase(t *testing.T) (*sqlstore.SQLStore, *ServiceAccountsStoreI
 	db := sqlstore.InitTestDB(t)
 	return db, NewServiceAccountsStore(db)
 }
+
+func TestStore_CreateServiceAccount(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	// creates the main organization
+	tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "admin"})
+
+	sa, err := store.CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "Deploy bot", Role: models.ROLE_EDITOR})
+	require.NoError(t, err)
+	assert.True(t, sa.IsServiceAccount)
+	assert.Equal(t, "sa-1-deploy-bot", sa.Login)
+
+	t.Run("should be a member of the organization only", func(t *testing.T) {
+		retrieved, err := store.RetrieveServiceAccount(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+		assert.Equal(t, &serviceaccounts.ServiceAccountDTO{Id: sa.Id, OrgId: 1, Name: "Deploy bot", Login: sa.Login, Role: models.ROLE_EDITOR}, retrieved)
+
+		_, err = store.RetrieveServiceAccount(context.Background(), 2, sa.Id)
+		assert.ErrorIs(t, err, serviceaccounts.ErrServiceAccountNotFound)
+	})
+
+	t.Run("should not create service accounts with the same name", func(t *testing.T) {
+		_, err := store.CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "Deploy bot"})
+		assert.ErrorIs(t, err, serviceaccounts.ErrServiceAccountAlreadyExists)
+	})
+
+	t.Run("should list the service accounts but not the users", func(t *testing.T) {
+		_, err := store.CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "Alerts bot"})
+		require.NoError(t, err)
+
+		listed, err := store.ListServiceAccounts(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "Alerts bot", listed[0].Name)
+		assert.Equal(t, models.ROLE_VIEWER, listed[0].Role)
+		assert.Equal(t, "Deploy bot", listed[1].Name)
+	})
+}
+
+func TestStore_ServiceAccountTokens(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "admin"})
+	sa, err := store.CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "Token bot"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"ci", "cd"} {
+		cmd := models.AddApiKeyCommand{OrgId: 1, Name: name, Key: name + "-key", Role: models.ROLE_VIEWER, SecondsToLive: 3600}
+		require.NoError(t, store.AddServiceAccountToken(context.Background(), sa.Id, &cmd))
+		assert.Equal(t, sa.Id, cmd.Result.ServiceAccountId)
+	}
+
+	t.Run("should list the tokens of the service account", func(t *testing.T) {
+		tokens, err := store.ListTokens(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		assert.Equal(t, "cd", tokens[0].Name)
+		assert.NotNil(t, tokens[0].Expires)
+
+		retrieved, err := store.RetrieveServiceAccount(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), retrieved.Tokens)
+	})
+
+	t.Run("should not add tokens to unknown service accounts", func(t *testing.T) {
+		cmd := models.AddApiKeyCommand{OrgId: 2, Name: "other", Key: "other-key", Role: models.ROLE_VIEWER}
+		assert.ErrorIs(t, store.AddServiceAccountToken(context.Background(), sa.Id, &cmd), serviceaccounts.ErrServiceAccountNotFound)
+
+		_, err := store.ListTokens(context.Background(), 2, sa.Id)
+		assert.ErrorIs(t, err, serviceaccounts.ErrServiceAccountNotFound)
+	})
+
+	t.Run("should delete the tokens of the service account", func(t *testing.T) {
+		tokens, err := store.ListTokens(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteServiceAccountToken(context.Background(), 1, sa.Id, tokens[0].Id))
+		err = store.DeleteServiceAccountToken(context.Background(), 1, sa.Id, tokens[0].Id)
+		assert.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+
+		tokens, err = store.ListTokens(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+		assert.Len(t, tokens, 1)
+	})
+}
+
+func TestStore_FederatedCredentials(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "admin"})
+	sa, err := store.CreateServiceAccount(context.Background(), &serviceaccounts.CreateServiceaccountForm{OrgID: 1, Name: "CI bot"})
+	require.NoError(t, err)
+
+	credential := &serviceaccounts.FederatedCredential{
+		OrgId:            1,
+		ServiceAccountId: sa.Id,
+		Issuer:           "https://token.actions.githubusercontent.com",
+		Subject:          "repo:grafana/grafana:*",
+		Audience:         "https://grafana.example.com",
+		Created:          time.Now(),
+	}
+	require.NoError(t, store.AddFederatedCredential(context.Background(), credential))
+	assert.NotEmpty(t, credential.Uid)
+
+	t.Run("should not add the same credential twice", func(t *testing.T) {
+		duplicate := *credential
+		duplicate.Id = 0
+		assert.ErrorIs(t, store.AddFederatedCredential(context.Background(), &duplicate), serviceaccounts.ErrFederatedCredentialExists)
+
+		duplicate.OrgId = 2
+		assert.ErrorIs(t, store.AddFederatedCredential(context.Background(), &duplicate), serviceaccounts.ErrServiceAccountNotFound)
+	})
+
+	t.Run("should list and find the credentials", func(t *testing.T) {
+		listed, err := store.ListFederatedCredentials(context.Background(), 1, sa.Id)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, credential.Uid, listed[0].Uid)
+
+		found, err := store.FindFederatedCredentials(context.Background(), credential.Issuer)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, sa.Id, found[0].ServiceAccountId)
+
+		found, err = store.FindFederatedCredentials(context.Background(), "https://accounts.google.com")
+		require.NoError(t, err)
+		assert.Len(t, found, 0)
+	})
+
+	t.Run("should delete the credentials", func(t *testing.T) {
+		require.NoError(t, store.DeleteFederatedCredential(context.Background(), 1, sa.Id, credential.Uid))
+		err := store.DeleteFederatedCredential(context.Background(), 1, sa.Id, credential.Uid)
+		assert.ErrorIs(t, err, serviceaccounts.ErrFederatedCredentialNotFound)
+	})
+
+	t.Run("should delete the credentials with the service account", func(t *testing.T) {
+		credential.Id = 0
+		require.NoError(t, store.AddFederatedCredential(context.Background(), credential))
+		require.NoError(t, store.DeleteServiceAccount(context.Background(), 1, sa.Id))
+
+		found, err := store.FindFederatedCredentials(context.Background(), credential.Issuer)
+		require.NoError(t, err)
+		assert.Len(t, found, 0)
+	})
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *ServiceAccountsStoreImpl) ListFederatedCredentials(ctx context.Context, orgID, serviceAccountID int64) ([]*serviceaccounts.FederatedCredential, error) {
+	result := make([]*serviceaccounts.FederatedCredential, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := s.serviceAccountExists(sess, orgID, serviceAccountID); err != nil {
+			return err
+		}
+		return sess.Where("org_id = ? AND service_account_id = ?", orgID, serviceAccountID).Asc("issuer", "subject").Find(&result)
+	})
+	return result, err
+}
+
+func (s *ServiceAccountsStoreImpl) AddFederatedCredential(ctx context.Context, credential *serviceaccounts.FederatedCredential) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := s.serviceAccountExists(sess, credential.OrgId, credential.ServiceAccountId); err != nil {
+			return err
+		}
+
+		exists, err := sess.Table("service_account_federated_credential").
+			Where("service_account_id = ? AND issuer = ? AND subject = ? AND audience = ?",
+				credential.ServiceAccountId, credential.Issuer, credential.Subject, credential.Audience).
+			Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return serviceaccounts.ErrFederatedCredentialExists
+		}
+
+		credential.Uid = util.GenerateShortUID()
+		_, err = sess.Insert(credential)
+		return err
+	})
+}
+
+func (s *ServiceAccountsStoreImpl) DeleteFederatedCredential(ctx context.Context, orgID, serviceAccountID int64, uid string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM service_account_federated_credential WHERE org_id = ? AND service_account_id = ? AND uid = ?", orgID, serviceAccountID, uid)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return serviceaccounts.ErrFederatedCredentialNotFound
+		}
+		return nil
+	})
+}
+
+func (s *ServiceAccountsStoreImpl) FindFederatedCredentials(ctx context.Context, issuer string) ([]*serviceaccounts.FederatedCredential, error) {
+	result := make([]*serviceaccounts.FederatedCredential, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("issuer = ?", issuer).Asc("id").Find(&result)
+	})
+	return result, err
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *ServiceAccountsStoreImpl) ListTokens(ctx context.Context, orgID, serviceAccountID int64) ([]*models.ApiKey, error) {
+	result := make([]*models.ApiKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := s.serviceAccountExists(sess, orgID, serviceAccountID); err != nil {
+			return err
+		}
+		return sess.Where("org_id = ? AND service_account_id = ?", orgID, serviceAccountID).Asc("name").Find(&result)
+	})
+	return result, err
+}
+
+// AddServiceAccountToken adds an API key owned by the service account, requests authenticated with the key are signed
+// in as the service account
+func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *models.AddApiKeyCommand) error {
+	return s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return s.serviceAccountExists(sess, cmd.OrgId, serviceAccountID)
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.ServiceAccountId = serviceAccountID
+		return s.sqlStore.AddAPIKey(ctx, cmd)
+	})
+}
+
+func (s *ServiceAccountsStoreImpl) DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM api_key WHERE id = ? AND org_id = ? AND service_account_id = ?", tokenID, orgID, serviceAccountID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return serviceaccounts.ErrServiceAccountTokenNotFound
+		}
+		return nil
+	})
+}
+
+func (s *ServiceAccountsStoreImpl) serviceAccountExists(sess *sqlstore.DBSession, orgID, serviceAccountID int64) error {
+	q := `SELECT 1 FROM org_user
+		INNER JOIN ` + s.sqlStore.Dialect.Quote("user") + ` AS u ON u.id = org_user.user_id
+		WHERE org_user.org_id = ? AND u.id = ? AND u.is_service_account = ?`
+	res, err := sess.Query(q, orgID, serviceAccountID, true)
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return serviceaccounts.ErrServiceAccountNotFound
+	}
+	return nil
+}
@@ -3,5 +3,12 @@ package serviceaccounts
 import "errors"
 
 var (
-	ErrServiceAccountNotFound = errors.New("Service account not found")
+	ErrServiceAccountNotFound      = errors.New("Service account not found")
+	ErrServiceAccountAlreadyExists = errors.New("Service account with that name already exists")
+	ErrServiceAccountTokenNotFound = errors.New("Service account token not found")
+
+	ErrFederatedCredentialNotFound = errors.New("Federated credential not found")
+	ErrFederatedCredentialExists   = errors.New("Federated credential with the same issuer, subject, and audience already exists")
+	ErrIssuerNotAllowed            = errors.New("Issuer is not allowed for workload identity federation")
+	ErrInvalidSubjectPattern       = errors.New("Invalid subject pattern")
 )
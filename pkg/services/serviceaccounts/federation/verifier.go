@@ -0,0 +1,269 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var ErrInvalidToken = errors.New("invalid workload identity token")
+
+const (
+	// maxDocumentSize is the maximum size of the OpenID configuration and key set of an issuer
+	maxDocumentSize = 1 << 20
+	// minRefreshInterval limits how often the keys of an issuer are fetched again when a token is
+	// signed with an unknown key
+	minRefreshInterval = time.Minute
+	// minExactSegments is the number of exact segments required before the first wildcard of a subject pattern
+	minExactSegments = 2
+	// globSpecialChars are the characters with a special meaning in subject patterns
+	globSpecialChars = `*?[]{}\`
+)
+
+// Claims are the claims of a verified workload identity token used to match federated credentials
+type Claims struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Expiry   time.Time
+}
+
+// Verifier verifies the signature and the claims of the OpenID Connect tokens of the allowed issuers,
+// using the keys published in their OpenID configuration.
+type Verifier struct {
+	cfg    *setting.Cfg
+	client *http.Client
+	log    log.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keySets map[string]*cachedKeySet
+}
+
+type cachedKeySet struct {
+	keys    jose.JSONWebKeySet
+	fetched time.Time
+}
+
+type openIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+func NewVerifier(cfg *setting.Cfg) *Verifier {
+	return &Verifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		log:     log.New("serviceaccounts.federation"),
+		now:     time.Now,
+		keySets: map[string]*cachedKeySet{},
+	}
+}
+
+// IsAllowedIssuer returns whether the tokens of the issuer can be exchanged
+func (v *Verifier) IsAllowedIssuer(issuer string) bool {
+	for _, allowed := range v.cfg.WorkloadIdentityAllowedIssuers {
+		if issuer == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (*Claims, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if len(token.Headers) != 1 {
+		return nil, fmt.Errorf("%w: tokens must have a single signature", ErrInvalidToken)
+	}
+
+	unverified := jwt.Claims{}
+	if err := token.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if !v.IsAllowedIssuer(unverified.Issuer) {
+		return nil, serviceaccounts.ErrIssuerNotAllowed
+	}
+
+	keys, err := v.keys(ctx, unverified.Issuer, token.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.Claims{}
+	verified := false
+	for _, key := range keys {
+		// symmetric keys would let anyone who knows them sign tokens
+		if !key.IsPublic() {
+			continue
+		}
+		if err := token.Claims(key, &claims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: failed to verify the signature", ErrInvalidToken)
+	}
+
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if err := claims.ValidateWithLeeway(jwt.Expected{Issuer: unverified.Issuer, Time: v.now()}, jwt.DefaultLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	return &Claims{
+		Issuer:   claims.Issuer,
+		Subject:  claims.Subject,
+		Audience: claims.Audience,
+		Expiry:   claims.Expiry.Time(),
+	}, nil
+}
+
+// keys returns the keys of the issuer for the key ID, fetching them again when they have
+// expired from the cache or when the key ID is unknown, in case the issuer rotated its keys.
+func (v *Verifier) keys(ctx context.Context, issuer, keyID string) ([]jose.JSONWebKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	cached, ok := v.keySets[issuer]
+	if ok && now.Sub(cached.fetched) < v.cfg.WorkloadIdentityCacheTTL {
+		keys := cached.keys.Key(keyID)
+		if len(keys) > 0 || now.Sub(cached.fetched) < minRefreshInterval {
+			return keys, nil
+		}
+	}
+
+	keySet, err := v.fetchKeySet(ctx, issuer)
+	if err != nil {
+		v.log.Warn("Failed to fetch the keys of the issuer", "issuer", issuer, "error", err)
+		if ok {
+			// keep verifying tokens with the previous keys while the issuer is unavailable
+			return cached.keys.Key(keyID), nil
+		}
+		return nil, err
+	}
+	v.keySets[issuer] = &cachedKeySet{keys: keySet, fetched: now}
+	return keySet.Key(keyID), nil
+}
+
+func (v *Verifier) fetchKeySet(ctx context.Context, issuer string) (jose.JSONWebKeySet, error) {
+	keySet := jose.JSONWebKeySet{}
+
+	config := openIDConfiguration{}
+	if err := v.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &config); err != nil {
+		return keySet, fmt.Errorf("failed to get OpenID configuration: %w", err)
+	}
+	if config.Issuer != issuer {
+		return keySet, fmt.Errorf("OpenID configuration is for issuer %q", config.Issuer)
+	}
+	if config.JWKSURI == "" {
+		return keySet, errors.New("OpenID configuration has no jwks_uri")
+	}
+
+	v.log.Debug("Getting key set of issuer", "issuer", issuer, "url", config.JWKSURI)
+	if err := v.getJSON(ctx, config.JWKSURI, &keySet); err != nil {
+		return keySet, fmt.Errorf("failed to get key set: %w", err)
+	}
+	return keySet, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			v.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(dest)
+}
+
+// ValidateSubject validates the subject pattern of a federated credential. A pattern with wildcards must start with
+// at least two exact segments, separated by : or /, such as the owner in repo:my-org/*, so that a credential cannot
+// match the tokens of any workload of the issuer.
+func ValidateSubject(subject string) error {
+	if subject == "" {
+		return fmt.Errorf("%w: the subject is required", serviceaccounts.ErrInvalidSubjectPattern)
+	}
+	if _, err := glob.Compile(subject); err != nil {
+		return fmt.Errorf("%w: %s", serviceaccounts.ErrInvalidSubjectPattern, err)
+	}
+
+	i := strings.IndexAny(subject, globSpecialChars)
+	if i < 0 {
+		return nil
+	}
+	if exactSegments(subject[:i]) < minExactSegments {
+		return fmt.Errorf("%w: the subject must start with an exact owner or repository, such as repo:my-org/, before any wildcard",
+			serviceaccounts.ErrInvalidSubjectPattern)
+	}
+	return nil
+}
+
+// exactSegments returns the number of complete segments of the literal prefix of a subject pattern, the last segment
+// is only complete when it is followed by a separator
+func exactSegments(prefix string) int {
+	count := 0
+	for _, segment := range strings.FieldsFunc(prefix+"*", isSubjectSeparator) {
+		if segment != "*" && !strings.HasSuffix(segment, "*") {
+			count++
+		}
+	}
+	return count
+}
+
+func isSubjectSeparator(r rune) bool {
+	return r == ':' || r == '/'
+}
+
+// Matches returns whether the claims of a verified token match the federated credential. The
+// subject of the credential can contain * wildcards, and the audience of the credential must be
+// one of the audiences of the token.
+func Matches(credential *serviceaccounts.FederatedCredential, claims *Claims) bool {
+	if credential.Issuer != claims.Issuer {
+		return false
+	}
+
+	subject, err := glob.Compile(credential.Subject)
+	if err != nil || !subject.Match(claims.Subject) {
+		return false
+	}
+
+	for _, audience := range claims.Audience {
+		if audience == credential.Audience {
+			return true
+		}
+	}
+	return false
+}
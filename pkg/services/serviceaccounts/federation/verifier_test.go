@@ -0,0 +1,160 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type testIssuer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	keyID    string
+	requests int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{key: key, keyID: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.requests++
+		_ = json.NewEncoder(w).Encode(openIDConfiguration{Issuer: issuer.URL, JWKSURI: issuer.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &issuer.key.PublicKey, KeyID: issuer.keyID, Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: i.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", i.keyID))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	cfg := setting.NewCfg()
+	cfg.WorkloadIdentityAllowedIssuers = []string{issuer.URL}
+	cfg.WorkloadIdentityCacheTTL = time.Hour
+	verifier := NewVerifier(cfg)
+	now := time.Now()
+
+	validClaims := jwt.Claims{
+		Issuer:   issuer.URL,
+		Subject:  "repo:grafana/grafana:ref:refs/heads/main",
+		Audience: jwt.Audience{"https://grafana.example.com"},
+		Expiry:   jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		IssuedAt: jwt.NewNumericDate(now),
+	}
+
+	t.Run("should verify tokens signed by the issuer", func(t *testing.T) {
+		claims, err := verifier.Verify(context.Background(), issuer.sign(t, validClaims))
+		require.NoError(t, err)
+		assert.Equal(t, validClaims.Subject, claims.Subject)
+		assert.Equal(t, []string{"https://grafana.example.com"}, claims.Audience)
+
+		_, err = verifier.Verify(context.Background(), issuer.sign(t, validClaims))
+		require.NoError(t, err)
+		assert.Equal(t, 1, issuer.requests, "the keys of the issuer should be cached")
+	})
+
+	t.Run("should reject invalid tokens", func(t *testing.T) {
+		expired := validClaims
+		expired.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+		noExpiry := validClaims
+		noExpiry.Expiry = nil
+
+		for desc, token := range map[string]string{
+			"malformed": "not-a-token",
+			"expired":   issuer.sign(t, expired),
+			"no expiry": issuer.sign(t, noExpiry),
+		} {
+			_, err := verifier.Verify(context.Background(), token)
+			assert.ErrorIs(t, err, ErrInvalidToken, desc)
+		}
+	})
+
+	t.Run("should reject tokens signed with other keys", func(t *testing.T) {
+		other := newTestIssuer(t)
+		_, err := verifier.Verify(context.Background(), other.sign(t, validClaims))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject tokens of issuers that are not allowed", func(t *testing.T) {
+		other := newTestIssuer(t)
+		claims := validClaims
+		claims.Issuer = other.URL
+		_, err := verifier.Verify(context.Background(), other.sign(t, claims))
+		assert.ErrorIs(t, err, serviceaccounts.ErrIssuerNotAllowed)
+		assert.Equal(t, 0, other.requests)
+	})
+}
+
+func TestMatches(t *testing.T) {
+	credential := &serviceaccounts.FederatedCredential{
+		Issuer:   "https://token.actions.githubusercontent.com",
+		Subject:  "repo:grafana/grafana:*",
+		Audience: "https://grafana.example.com",
+	}
+	claims := func(subject string, audience ...string) *Claims {
+		return &Claims{Issuer: credential.Issuer, Subject: subject, Audience: audience}
+	}
+
+	assert.True(t, Matches(credential, claims("repo:grafana/grafana:ref:refs/heads/main", "https://grafana.example.com")))
+	assert.True(t, Matches(credential, claims("repo:grafana/grafana:environment:production", "other", "https://grafana.example.com")))
+	assert.False(t, Matches(credential, claims("repo:grafana/loki:ref:refs/heads/main", "https://grafana.example.com")))
+	assert.False(t, Matches(credential, claims("repo:grafana/grafana:ref:refs/heads/main", "https://other.example.com")))
+	assert.False(t, Matches(credential, &Claims{Issuer: "https://accounts.google.com", Subject: "repo:grafana/grafana:x", Audience: []string{"https://grafana.example.com"}}))
+}
+
+func TestValidateSubject(t *testing.T) {
+	for _, subject := range []string{
+		"repo:grafana/grafana:ref:refs/heads/main",
+		"repo:grafana/grafana:*",
+		"repo:grafana/*",
+		"repo:grafana/graf*",
+		"system:serviceaccount:ci:*",
+		"1234567890",
+	} {
+		assert.NoError(t, ValidateSubject(subject), subject)
+	}
+
+	for _, subject := range []string{
+		"",
+		"*",
+		"**",
+		"?*",
+		"repo:*",
+		"repo:graf*",
+		"repo:{grafana,loki}/*",
+		"repo:[",
+	} {
+		assert.ErrorIs(t, ValidateSubject(subject), serviceaccounts.ErrInvalidSubjectPattern, subject)
+	}
+}
@@ -24,16 +24,20 @@ var (
 func RegisterRoles(ac accesscontrol.AccessControl) error {
 	role := accesscontrol.RoleRegistration{
 		Role: accesscontrol.RoleDTO{
-			Version:     3,
+			Version:     4,
 			Name:        "fixed:serviceaccounts:writer",
 			DisplayName: "Service accounts writer",
-			Description: "Create, delete, read, or query service accounts.",
+			Description: "Create, delete, read, or query service accounts, and manage their tokens.",
 			Group:       "Service accounts",
 			Permissions: []accesscontrol.Permission{
 				{
 					Action: serviceaccounts.ActionRead,
 					Scope:  serviceaccounts.ScopeAll,
 				},
+				{
+					Action: serviceaccounts.ActionWrite,
+					Scope:  serviceaccounts.ScopeAll,
+				},
 				{
 					Action: serviceaccounts.ActionCreate,
 				},
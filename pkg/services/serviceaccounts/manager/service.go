@@ -40,7 +40,7 @@ func ProvideServiceAccountsService(
 		s.log.Error("Failed to register roles", "error", err)
 	}
 
-	serviceaccountsAPI := api.NewServiceAccountsAPI(s, ac, routeRegister, s.store)
+	serviceaccountsAPI := api.NewServiceAccountsAPI(cfg, s, ac, routeRegister, s.store)
 	serviceaccountsAPI.RegisterAPIEndpoints(cfg)
 
 	return s, nil
@@ -1,10 +1,15 @@
 package serviceaccounts
 
-import "github.com/grafana/grafana/pkg/services/accesscontrol"
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
 
 var (
 	ScopeAll = "serviceaccounts:*"
-	ScopeID  = accesscontrol.Scope("serviceaccounts", "id", accesscontrol.Parameter(":serviceaccountId"))
+	ScopeID  = accesscontrol.Scope("serviceaccounts", "id", accesscontrol.Parameter(":serviceAccountId"))
 )
 
 const (
@@ -23,4 +28,53 @@ type CreateServiceaccountForm struct {
 	Name        string `json:"name" binding:"Required"`
 	DisplayName string `json:"displayName"`
 	Description string `json:"description"`
+	// Role is the organization role of the service account, Viewer by default
+	Role models.RoleType `json:"role"`
+}
+
+type ServiceAccountDTO struct {
+	Id     int64           `json:"id"`
+	OrgId  int64           `json:"orgId"`
+	Name   string          `json:"name"`
+	Login  string          `json:"login"`
+	Role   models.RoleType `json:"role"`
+	Tokens int64           `json:"tokens"`
+}
+
+type AddServiceAccountTokenCommand struct {
+	Name          string `json:"name" binding:"Required"`
+	SecondsToLive int64  `json:"secondsToLive"`
+}
+
+type TokenDTO struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Created    time.Time  `json:"created"`
+	Expiration *time.Time `json:"expiration"`
+	HasExpired bool       `json:"hasExpired"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// FederatedCredential lets the workloads of an external identity provider sign in as the service
+// account, by exchanging the OpenID Connect tokens of the provider for service account tokens. The
+// subject is matched against the sub claim of the tokens, and can contain * wildcards.
+type FederatedCredential struct {
+	Id               int64     `json:"id"`
+	Uid              string    `json:"uid"`
+	OrgId            int64     `json:"orgId"`
+	ServiceAccountId int64     `json:"serviceAccountId"`
+	Issuer           string    `json:"issuer"`
+	Subject          string    `json:"subject"`
+	Audience         string    `json:"audience"`
+	Created          time.Time `json:"created"`
+}
+
+func (c FederatedCredential) TableName() string {
+	return "service_account_federated_credential"
+}
+
+type AddFederatedCredentialCommand struct {
+	Issuer   string `json:"issuer" binding:"Required"`
+	Subject  string `json:"subject" binding:"Required"`
+	Audience string `json:"audience"`
 }
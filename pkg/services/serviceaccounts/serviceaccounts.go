@@ -14,6 +14,16 @@ type Service interface {
 
 type Store interface {
 	CreateServiceAccount(ctx context.Context, saForm *CreateServiceaccountForm) (*models.User, error)
+	ListServiceAccounts(ctx context.Context, orgID int64) ([]*ServiceAccountDTO, error)
+	RetrieveServiceAccount(ctx context.Context, orgID, serviceAccountID int64) (*ServiceAccountDTO, error)
 	DeleteServiceAccount(ctx context.Context, orgID, serviceAccountID int64) error
 	UpgradeServiceAccounts(ctx context.Context) error
+	ListTokens(ctx context.Context, orgID, serviceAccountID int64) ([]*models.ApiKey, error)
+	AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *models.AddApiKeyCommand) error
+	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
+	ListFederatedCredentials(ctx context.Context, orgID, serviceAccountID int64) ([]*FederatedCredential, error)
+	AddFederatedCredential(ctx context.Context, credential *FederatedCredential) error
+	DeleteFederatedCredential(ctx context.Context, orgID, serviceAccountID int64, uid string) error
+	// FindFederatedCredentials returns the federated credentials of all organizations for the issuer
+	FindFederatedCredentials(ctx context.Context, issuer string) ([]*FederatedCredential, error)
 }
@@ -56,9 +56,18 @@ func SetupMockAccesscontrol(t *testing.T, userpermissionsfunc func(c context.Con
 var _ serviceaccounts.Store = new(ServiceAccountsStoreMock)
 
 type Calls struct {
-	CreateServiceAccount   []interface{}
-	DeleteServiceAccount   []interface{}
-	UpgradeServiceAccounts []interface{}
+	CreateServiceAccount      []interface{}
+	ListServiceAccounts       []interface{}
+	RetrieveServiceAccount    []interface{}
+	DeleteServiceAccount      []interface{}
+	UpgradeServiceAccounts    []interface{}
+	ListTokens                []interface{}
+	AddServiceAccountToken    []interface{}
+	DeleteServiceAccountToken []interface{}
+	ListFederatedCredentials  []interface{}
+	AddFederatedCredential    []interface{}
+	DeleteFederatedCredential []interface{}
+	FindFederatedCredentials  []interface{}
 }
 
 type ServiceAccountsStoreMock struct {
@@ -81,3 +90,48 @@ func (s *ServiceAccountsStoreMock) UpgradeServiceAccounts(ctx context.Context) e
 	s.Calls.DeleteServiceAccount = append(s.Calls.UpgradeServiceAccounts, []interface{}{ctx})
 	return nil
 }
+
+func (s *ServiceAccountsStoreMock) ListServiceAccounts(ctx context.Context, orgID int64) ([]*serviceaccounts.ServiceAccountDTO, error) {
+	s.Calls.ListServiceAccounts = append(s.Calls.ListServiceAccounts, []interface{}{ctx, orgID})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) RetrieveServiceAccount(ctx context.Context, orgID, serviceAccountID int64) (*serviceaccounts.ServiceAccountDTO, error) {
+	s.Calls.RetrieveServiceAccount = append(s.Calls.RetrieveServiceAccount, []interface{}{ctx, orgID, serviceAccountID})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) ListTokens(ctx context.Context, orgID, serviceAccountID int64) ([]*models.ApiKey, error) {
+	s.Calls.ListTokens = append(s.Calls.ListTokens, []interface{}{ctx, orgID, serviceAccountID})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *models.AddApiKeyCommand) error {
+	s.Calls.AddServiceAccountToken = append(s.Calls.AddServiceAccountToken, []interface{}{ctx, serviceAccountID, cmd})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error {
+	s.Calls.DeleteServiceAccountToken = append(s.Calls.DeleteServiceAccountToken, []interface{}{ctx, orgID, serviceAccountID, tokenID})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) ListFederatedCredentials(ctx context.Context, orgID, serviceAccountID int64) ([]*serviceaccounts.FederatedCredential, error) {
+	s.Calls.ListFederatedCredentials = append(s.Calls.ListFederatedCredentials, []interface{}{ctx, orgID, serviceAccountID})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) AddFederatedCredential(ctx context.Context, credential *serviceaccounts.FederatedCredential) error {
+	s.Calls.AddFederatedCredential = append(s.Calls.AddFederatedCredential, []interface{}{ctx, credential})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) DeleteFederatedCredential(ctx context.Context, orgID, serviceAccountID int64, uid string) error {
+	s.Calls.DeleteFederatedCredential = append(s.Calls.DeleteFederatedCredential, []interface{}{ctx, orgID, serviceAccountID, uid})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) FindFederatedCredentials(ctx context.Context, issuer string) ([]*serviceaccounts.FederatedCredential, error) {
+	s.Calls.FindFederatedCredentials = append(s.Calls.FindFederatedCredentials, []interface{}{ctx, issuer})
+	return nil, nil
+}
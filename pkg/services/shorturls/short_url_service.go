@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/util"
@@ -11,9 +12,15 @@ import (
 
 var getTime = time.Now
 
-func ProvideService(sqlStore *sqlstore.SQLStore) *ShortURLService {
+// shortURLCacheTTL is how long the short URLs are cached, they cannot be changed
+const shortURLCacheTTL = 5 * time.Minute
+
+func ProvideService(sqlStore *sqlstore.SQLStore, cacheRegistry *localcache.Registry) *ShortURLService {
+	cache := localcache.NewNamed("shorturls", shortURLCacheTTL, 2*shortURLCacheTTL)
+	cacheRegistry.Register(cache)
 	return &ShortURLService{
 		SQLStore: sqlStore,
+		cache:    cache,
 	}
 }
 
@@ -26,9 +33,19 @@ type Service interface {
 
 type ShortURLService struct {
 	SQLStore *sqlstore.SQLStore
+	// cache holds the short URLs by organization and uid, it is optional
+	cache *localcache.CacheService
 }
 
 func (s ShortURLService) GetShortURLByUID(ctx context.Context, user *models.SignedInUser, uid string) (*models.ShortUrl, error) {
+	cacheKey := localcache.OrgKey(user.OrgId, uid)
+	if s.cache != nil {
+		if cached, ok := s.cache.Get(cacheKey); ok {
+			shortURL := cached.(models.ShortUrl)
+			return &shortURL, nil
+		}
+	}
+
 	var shortURL models.ShortUrl
 	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
 		exists, err := dbSession.Where("org_id=? AND uid=?", user.OrgId, uid).Get(&shortURL)
@@ -45,6 +62,9 @@ func (s ShortURLService) GetShortURLByUID(ctx context.Context, user *models.Sign
 		return nil, err
 	}
 
+	if s.cache != nil {
+		s.cache.Set(cacheKey, shortURL, shortURLCacheTTL)
+	}
 	return &shortURL, nil
 }
 
@@ -82,16 +102,13 @@ func (s ShortURLService) CreateShortURL(ctx context.Context, user *models.Signed
 }
 
 func (s ShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *models.DeleteShortUrlCommand) error {
-	return s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
-		var rawSql = "DELETE FROM short_url WHERE created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)"
-
-		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix()); err != nil {
-			return err
-		} else if cmd.NumDeleted, err = result.RowsAffected(); err != nil {
-			return err
-		}
-		return nil
-	})
+	deleted, err := s.SQLStore.DeleteInBatches(ctx, "short_url", "created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)",
+		cmd.BatchSize, cmd.OlderThan.Unix())
+	cmd.NumDeleted = deleted
+	if deleted > 0 && s.cache != nil {
+		s.cache.Flush()
+	}
+	return err
 }
 
 var _ Service = &ShortURLService{}
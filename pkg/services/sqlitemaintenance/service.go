@@ -0,0 +1,95 @@
+// Package sqlitemaintenance checkpoints the WAL file of the SQLite database and vacuums it periodically, so that
+// the files do not keep growing on the small installations running on SQLite.
+package sqlitemaintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const (
+	checkpointTaskName = "sqlite-checkpoint"
+	vacuumTaskName     = "sqlite-vacuum"
+)
+
+// Service registers the checkpoint and the vacuum of the SQLite database as scheduled tasks, enabled when their
+// interval is set in the [database] section. Nothing is registered for the other databases.
+type Service struct {
+	sqlStore *sqlstore.SQLStore
+	log      log.Logger
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		sqlStore: sqlStore,
+		log:      log.New("sqlitemaintenance"),
+	}
+	if sqlStore.Dialect.DriverName() != migrator.SQLite {
+		return s, nil
+	}
+
+	checkpointInterval, vacuumInterval := sqlStore.SQLiteMaintenanceIntervals()
+	// Every Grafana instance has its own SQLite database
+	tasks := []scheduler.Task{
+		{
+			Name:        checkpointTaskName,
+			Description: "Writes the WAL file of the SQLite database to the database and truncates it.",
+			Cron:        every(checkpointInterval, time.Hour),
+			Enabled:     checkpointInterval > 0,
+			Timeout:     10 * time.Minute,
+			Run:         s.Checkpoint,
+		},
+		{
+			Name:        vacuumTaskName,
+			Description: "Rebuilds the SQLite database file to release the space of the deleted rows.",
+			Cron:        every(vacuumInterval, 7*24*time.Hour),
+			Enabled:     vacuumInterval > 0,
+			Run:         s.Vacuum,
+		},
+	}
+	for _, task := range tasks {
+		if err := schedulerService.Register(task); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// every returns the cron descriptor of the interval, or of the default interval the administrators can enable the
+// task with when the interval is zero
+func every(interval time.Duration, defaultInterval time.Duration) string {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return "@every " + interval.String()
+}
+
+// Checkpoint writes the WAL file to the database and truncates it
+func (s *Service) Checkpoint(ctx context.Context) error {
+	result, err := s.sqlStore.CheckpointSQLite(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Busy {
+		s.log.Warn("SQLite checkpoint could not complete because of concurrent queries", "logPages", result.LogPages,
+			"checkpointedPages", result.CheckpointedPages)
+		return nil
+	}
+	s.log.Debug("SQLite checkpoint completed", "logPages", result.LogPages, "checkpointedPages", result.CheckpointedPages)
+	return nil
+}
+
+// Vacuum rebuilds the database file
+func (s *Service) Vacuum(ctx context.Context) error {
+	start := time.Now()
+	if err := s.sqlStore.VacuumSQLite(ctx); err != nil {
+		return err
+	}
+	s.log.Info("SQLite database vacuumed", "duration", time.Since(start))
+	return nil
+}
@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -33,14 +34,8 @@ type SQLAnnotationRepo struct {
 
 func (r *SQLAnnotationRepo) Save(item *annotations.Item) error {
 	return inTransaction(func(sess *DBSession) error {
-		tags := models.ParseTagPairs(item.Tags)
-		item.Tags = models.JoinTagPairs(tags)
-		item.Created = timeNow().UnixNano() / int64(time.Millisecond)
-		item.Updated = item.Created
-		if item.Epoch == 0 {
-			item.Epoch = item.Created
-		}
-		if err := validateTimeRange(item); err != nil {
+		tags, err := prepareAnnotation(item)
+		if err != nil {
 			return err
 		}
 
@@ -64,6 +59,63 @@ func (r *SQLAnnotationRepo) Save(item *annotations.Item) error {
 	})
 }
 
+// annotationTag is a row of the annotation_tag table
+type annotationTag struct {
+	AnnotationId int64
+	TagId        int64
+}
+
+func (r *SQLAnnotationRepo) SaveMany(items []*annotations.Item) error {
+	return inTransaction(func(sess *DBSession) error {
+		untagged := make([]*annotations.Item, 0, len(items))
+		var tagged []*annotations.Item
+		for _, item := range items {
+			if _, err := prepareAnnotation(item); err != nil {
+				return err
+			}
+			if len(item.Tags) > 0 {
+				tagged = append(tagged, item)
+			} else {
+				untagged = append(untagged, item)
+			}
+		}
+
+		if _, err := sess.InsertBatch("annotation", untagged); err != nil {
+			return err
+		}
+
+		// The ids of the tagged annotations are needed to insert their tags, so they are inserted one by one
+		var annotationTags []annotationTag
+		for _, item := range tagged {
+			if _, err := sess.Table("annotation").Insert(item); err != nil {
+				return err
+			}
+			tags, err := EnsureTagsExist(sess, models.ParseTagPairs(item.Tags))
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				annotationTags = append(annotationTags, annotationTag{AnnotationId: item.Id, TagId: tag.Id})
+			}
+		}
+		_, err := sess.InsertBatch("annotation_tag", annotationTags)
+		return err
+	})
+}
+
+// prepareAnnotation sets the creation time and the time range of the annotation before it is inserted, and returns
+// its parsed tags
+func prepareAnnotation(item *annotations.Item) ([]*models.Tag, error) {
+	tags := models.ParseTagPairs(item.Tags)
+	item.Tags = models.JoinTagPairs(tags)
+	item.Created = timeNow().UnixNano() / int64(time.Millisecond)
+	item.Updated = item.Created
+	if item.Epoch == 0 {
+		item.Epoch = item.Created
+	}
+	return tags, validateTimeRange(item)
+}
+
 func (r *SQLAnnotationRepo) Update(item *annotations.Item) error {
 	return inTransaction(func(sess *DBSession) error {
 		var (
@@ -118,6 +170,34 @@ func (r *SQLAnnotationRepo) Update(item *annotations.Item) error {
 }
 
 func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	sql, params := annotationsSQL(query)
+	items := make([]*annotations.ItemDTO, 0)
+
+	if err := x.SQL(sql, params...).Find(&items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Stream calls fn with each of the annotations matching the query, in the order of Find, as they are read from the
+// database. Unlike Find, there is no limit by default.
+func (r *SQLAnnotationRepo) Stream(ctx context.Context, query *annotations.ItemQuery, fn func(item *annotations.ItemDTO) error) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		sql, params := annotationsSQL(query)
+		sess.SQL(sql, params...)
+		return sess.StreamRows(ctx, &annotations.ItemDTO{}, func(row interface{}) error {
+			return fn(row.(*annotations.ItemDTO))
+		})
+	})
+}
+
+// annotationsSQL returns the statement selecting the annotations matching the query, and its arguments
+func annotationsSQL(query *annotations.ItemQuery) (string, []interface{}) {
 	var sql bytes.Buffer
 	params := make([]interface{}, 0)
 
@@ -218,20 +298,14 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 		}
 	}
 
-	if query.Limit == 0 {
-		query.Limit = 100
-	}
-
 	// order of ORDER BY arguments match the order of a sql index for performance
-	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + dialect.Limit(query.Limit) + " ) dt on dt.id = annotation.id")
-
-	items := make([]*annotations.ItemDTO, 0)
-
-	if err := x.SQL(sql.String(), params...).Find(&items); err != nil {
-		return nil, err
+	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC")
+	if query.Limit > 0 {
+		sql.WriteString(dialect.Limit(query.Limit))
 	}
+	sql.WriteString(" ) dt on dt.id = annotation.id")
 
-	return items, nil
+	return sql.String(), params
 }
 
 func (r *SQLAnnotationRepo) Delete(params *annotations.DeleteParams) error {
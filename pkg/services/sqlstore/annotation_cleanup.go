@@ -60,7 +60,7 @@ func (acs *AnnotationCleanupService) cleanAnnotations(ctx context.Context, cfg s
 		deleteQuery := `DELETE FROM annotation WHERE id IN (SELECT id FROM (SELECT id FROM annotation WHERE %s AND created < %v ORDER BY id DESC %s) a)`
 		sql := fmt.Sprintf(deleteQuery, annotationType, cutoffDate, dialect.Limit(acs.batchSize))
 
-		affected, err := acs.executeUntilDoneOrCancelled(ctx, sql)
+		affected, err := executeUntilDoneOrCancelled(ctx, sql)
 		totalAffected += affected
 		if err != nil {
 			return totalAffected, err
@@ -70,7 +70,7 @@ func (acs *AnnotationCleanupService) cleanAnnotations(ctx context.Context, cfg s
 	if cfg.MaxCount > 0 {
 		deleteQuery := `DELETE FROM annotation WHERE id IN (SELECT id FROM (SELECT id FROM annotation WHERE %s ORDER BY id DESC %s) a)`
 		sql := fmt.Sprintf(deleteQuery, annotationType, dialect.LimitOffset(acs.batchSize, cfg.MaxCount))
-		affected, err := acs.executeUntilDoneOrCancelled(ctx, sql)
+		affected, err := executeUntilDoneOrCancelled(ctx, sql)
 		totalAffected += affected
 		return totalAffected, err
 	}
@@ -81,35 +81,5 @@ func (acs *AnnotationCleanupService) cleanAnnotations(ctx context.Context, cfg s
 func (acs *AnnotationCleanupService) cleanOrphanedAnnotationTags(ctx context.Context) (int64, error) {
 	deleteQuery := `DELETE FROM annotation_tag WHERE id IN ( SELECT id FROM (SELECT id FROM annotation_tag WHERE NOT EXISTS (SELECT 1 FROM annotation a WHERE annotation_id = a.id) %s) a)`
 	sql := fmt.Sprintf(deleteQuery, dialect.Limit(acs.batchSize))
-	return acs.executeUntilDoneOrCancelled(ctx, sql)
-}
-
-func (acs *AnnotationCleanupService) executeUntilDoneOrCancelled(ctx context.Context, sql string) (int64, error) {
-	var totalAffected int64
-	for {
-		select {
-		case <-ctx.Done():
-			return totalAffected, ctx.Err()
-		default:
-			var affected int64
-			err := withDbSession(ctx, x, func(session *DBSession) error {
-				res, err := session.Exec(sql)
-				if err != nil {
-					return err
-				}
-
-				affected, err = res.RowsAffected()
-				totalAffected += affected
-
-				return err
-			})
-			if err != nil {
-				return totalAffected, err
-			}
-
-			if affected == 0 {
-				return totalAffected, nil
-			}
-		}
-	}
+	return executeUntilDoneOrCancelled(ctx, sql)
 }
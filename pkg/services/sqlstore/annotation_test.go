@@ -325,4 +325,41 @@ func TestAnnotations(t *testing.T) {
 			require.Len(t, result.Tags, 0)
 		})
 	})
+
+	t.Run("Testing annotations saved in batches", func(t *testing.T) {
+		t.Cleanup(func() {
+			_, err := x.Exec("DELETE FROM annotation WHERE 1=1")
+			assert.NoError(t, err)
+			_, err = x.Exec("DELETE FROM annotation_tag WHERE 1=1")
+			assert.NoError(t, err)
+		})
+
+		items := make([]*annotations.Item, 0, 500)
+		for i := 0; i < cap(items); i++ {
+			items = append(items, &annotations.Item{
+				OrgId:     1,
+				AlertId:   1,
+				PrevState: "Normal",
+				NewState:  "Alerting",
+				Epoch:     int64(i + 1),
+			})
+		}
+		tagged := &annotations.Item{OrgId: 1, Text: "deploy", Epoch: 1000, EpochEnd: 900, Tags: []string{"deploy", "server:server-1"}}
+		items = append(items, tagged)
+
+		err := repo.SaveMany(items)
+		require.NoError(t, err)
+		assert.Greater(t, tagged.Id, int64(0))
+		assert.Equal(t, int64(900), tagged.Epoch)
+
+		found, err := repo.Find(&annotations.ItemQuery{OrgId: 1, AlertId: 1, Limit: 1000})
+		require.NoError(t, err)
+		assert.Len(t, found, 500)
+		assert.Equal(t, "Alerting", found[0].NewState)
+
+		found, err = repo.Find(&annotations.ItemQuery{OrgId: 1, Tags: []string{"server:server-1"}})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, tagged.Id, found[0].Id)
+	})
 }
@@ -7,30 +7,40 @@ import (
 	"xorm.io/xorm"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 )
 
+// notUserAccessToken is the condition of the API keys which are not personal access tokens
+const notUserAccessToken = "(user_id IS NULL OR user_id = 0)"
+
 func (ss *SQLStore) addAPIKeysQueryAndCommandHandlers() {
 	bus.AddHandler("sql", ss.GetAPIKeys)
 	bus.AddHandler("sql", ss.GetApiKeyById)
 	bus.AddHandler("sql", ss.GetApiKeyByName)
 	bus.AddHandler("sql", ss.DeleteApiKey)
 	bus.AddHandler("sql", ss.AddAPIKey)
+	bus.AddHandler("sql", ss.RotateAPIKey)
+	bus.AddHandler("sql", ss.GetAPIKeyRotations)
+	bus.AddHandler("sql", ss.GetAPIKeyPermissions)
+	bus.AddHandler("sql", ss.GetUserAccessTokens)
+	bus.AddHandler("sql", ss.DeleteUserAccessToken)
+	bus.AddHandler("sql", ss.DeleteExpiredFederatedTokens)
 }
 
 // GetAPIKeys queries the database based
-// on input on GetApiKeysQuery
+// on input on GetApiKeysQuery, the personal access tokens of the users are not API keys of the organization
 func (ss *SQLStore) GetAPIKeys(ctx context.Context, query *models.GetApiKeysQuery) error {
 	return ss.WithDbSession(ctx, func(dbSession *DBSession) error {
 		var sess *xorm.Session
 
 		if query.IncludeExpired {
 			sess = dbSession.Limit(100, 0).
-				Where("org_id=?", query.OrgId).
+				Where("org_id=? and "+notUserAccessToken, query.OrgId).
 				Asc("name")
 		} else {
 			sess = dbSession.Limit(100, 0).
-				Where("org_id=? and ( expires IS NULL or expires >= ?)", query.OrgId, timeNow().Unix()).
+				Where("org_id=? and ( expires IS NULL or expires >= ?) and "+notUserAccessToken, query.OrgId, timeNow().Unix()).
 				Asc("name")
 		}
 
@@ -45,7 +55,7 @@ func (ss *SQLStore) GetNonServiceAccountAPIKeys(ctx context.Context) []*models.A
 	result := make([]*models.ApiKey, 0)
 	err := ss.WithDbSession(ctx, func(dbSession *DBSession) error {
 		sess := dbSession. //CHECK how many API keys do our clients have?  Can we load them all?
-					Where("(expires IS NULL OR expires >= ?) AND service_account_id < 1 ", timeNow().Unix()).Asc("name")
+					Where("(expires IS NULL OR expires >= ?) AND service_account_id < 1 AND "+notUserAccessToken, timeNow().Unix()).Asc("name")
 		return sess.Find(&result)
 	})
 	if err != nil {
@@ -72,7 +82,11 @@ func deleteAPIKey(sess *DBSession, id, orgID int64) error {
 	} else if n == 0 {
 		return models.ErrApiKeyNotFound
 	}
-	return nil
+	if _, err = sess.Exec("DELETE FROM api_key_rotation WHERE api_key_id=? and org_id=?", id, orgID); err != nil {
+		return err
+	}
+	_, err = sess.Exec("DELETE FROM api_key_permission WHERE api_key_id=? and org_id=?", id, orgID)
+	return err
 }
 
 // AddAPIKey adds the API key to the database.
@@ -102,16 +116,103 @@ func (ss *SQLStore) AddAPIKey(ctx context.Context, cmd *models.AddApiKeyCommand)
 			Updated:          updated,
 			Expires:          expires,
 			ServiceAccountId: cmd.ServiceAccountId,
+			UserId:           cmd.UserId,
 		}
 
 		if _, err := sess.Insert(&t); err != nil {
 			return err
 		}
+		for _, p := range cmd.Permissions {
+			permission := models.ApiKeyPermission{OrgId: t.OrgId, ApiKeyId: t.Id, Action: p.Action, Scope: p.Scope}
+			if _, err := sess.Insert(&permission); err != nil {
+				return err
+			}
+		}
 		cmd.Result = &t
+		sess.publishAfterCommit(&events.APIKeyCreated{
+			Timestamp:        t.Created,
+			OrgID:            t.OrgId,
+			ID:               t.Id,
+			Name:             t.Name,
+			ServiceAccountID: t.ServiceAccountId,
+		})
+		return nil
+	})
+}
+
+// GetAPIKeyPermissions returns the permissions of the API keys of the query, none for the API keys
+// that are not permission-scoped.
+func (ss *SQLStore) GetAPIKeyPermissions(ctx context.Context, query *models.GetApiKeyPermissionsQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		query.Result = make([]*models.ApiKeyPermission, 0)
+		if len(query.ApiKeyIds) == 0 {
+			return nil
+		}
+		return sess.Where("org_id=?", query.OrgId).In("api_key_id", query.ApiKeyIds).
+			Asc("api_key_id").Asc("id").
+			Find(&query.Result)
+	})
+}
+
+// RotateAPIKey replaces the key of an API key and records the rotation. The previous key remains
+// valid for the grace period of the command.
+func (ss *SQLStore) RotateAPIKey(ctx context.Context, cmd *models.RotateApiKeyCommand) error {
+	if cmd.SecondsToLive < 0 || cmd.GracePeriod < 0 {
+		return models.ErrInvalidApiKeyExpiration
+	}
+
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		var key models.ApiKey
+		has, err := sess.Where("id=? AND org_id=?", cmd.Id, cmd.OrgId).Get(&key)
+		if err != nil {
+			return err
+		} else if !has {
+			return models.ErrApiKeyNotFound
+		}
+
+		now := timeNow()
+		rotation := models.ApiKeyRotation{
+			OrgId:              key.OrgId,
+			ApiKeyId:           key.Id,
+			PreviousKey:        key.Key,
+			PreviousKeyExpires: now.Add(cmd.GracePeriod),
+			RotatedBy:          cmd.RotatedBy,
+			RotatedAt:          now,
+		}
+		// The previous key cannot outlive the API key
+		if key.Expires != nil && time.Unix(*key.Expires, 0).Before(rotation.PreviousKeyExpires) {
+			rotation.PreviousKeyExpires = time.Unix(*key.Expires, 0)
+		}
+		if _, err := sess.Insert(&rotation); err != nil {
+			return err
+		}
+
+		key.Key = cmd.Key
+		key.Updated = now
+		if cmd.SecondsToLive > 0 {
+			v := now.Add(time.Second * time.Duration(cmd.SecondsToLive)).Unix()
+			key.Expires = &v
+		}
+		if _, err := sess.ID(key.Id).Cols("key", "updated", "expires").Update(&key); err != nil {
+			return err
+		}
+
+		cmd.Result = &key
+		cmd.Rotation = &rotation
 		return nil
 	})
 }
 
+// GetAPIKeyRotations returns the rotations of an API key, the most recent first.
+func (ss *SQLStore) GetAPIKeyRotations(ctx context.Context, query *models.GetApiKeyRotationsQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		query.Result = make([]*models.ApiKeyRotation, 0)
+		return sess.Where("api_key_id=? AND org_id=?", query.ApiKeyId, query.OrgId).
+			Desc("rotated_at").Desc("id").
+			Find(&query.Result)
+	})
+}
+
 // UpdateApikeyServiceAccount sets a service account for an existing API key
 func (ss *SQLStore) UpdateApikeyServiceAccount(ctx context.Context, apikeyId int64, saccountId int64) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
@@ -167,3 +268,77 @@ func (ss *SQLStore) GetApiKeyByName(ctx context.Context, query *models.GetApiKey
 		return nil
 	})
 }
+
+// UpdateAPIKeyLastUsedDate records that the API key was just used to authenticate a request from the client IP
+func (ss *SQLStore) UpdateAPIKeyLastUsedDate(ctx context.Context, apikeyId int64, clientIP string) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("UPDATE api_key SET last_used_at = ?, last_used_ip = ? WHERE id = ?", timeNow(), clientIP, apikeyId)
+		return err
+	})
+}
+
+// GetUserAccessTokens returns the personal access tokens of the user in the organization
+func (ss *SQLStore) GetUserAccessTokens(ctx context.Context, query *models.GetUserAccessTokensQuery) error {
+	return ss.WithDbSession(ctx, func(dbSession *DBSession) error {
+		sess := dbSession.Where("org_id=? AND user_id=?", query.OrgId, query.UserId)
+		if !query.IncludeExpired {
+			sess = sess.And("(expires IS NULL OR expires >= ?)", timeNow().Unix())
+		}
+
+		query.Result = make([]*models.ApiKey, 0)
+		return sess.Asc("name").Find(&query.Result)
+	})
+}
+
+// DeleteUserAccessToken revokes a personal access token, the tokens of the other users are not found
+func (ss *SQLStore) DeleteUserAccessToken(ctx context.Context, cmd *models.DeleteUserAccessTokenCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		var key models.ApiKey
+		has, err := sess.Where("id=? AND user_id=?", cmd.Id, cmd.UserId).Get(&key)
+		if err != nil {
+			return err
+		} else if !has {
+			return models.ErrUserAccessTokenNotFound
+		}
+		return deleteAPIKey(sess, key.Id, key.OrgId)
+	})
+}
+
+// DeleteExpiredFederatedTokens deletes the expired service account tokens exchanged for workload identity tokens, by
+// batches of at most cmd.BatchSize tokens. A new token is added by each exchange, they are not kept once expired.
+func (ss *SQLStore) DeleteExpiredFederatedTokens(ctx context.Context, cmd *models.DeleteExpiredFederatedTokensCommand) error {
+	batchSize := cmd.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultDeleteBatchSize
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		deleted := 0
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			var keys []*models.ApiKey
+			err := sess.Where("service_account_id IS NOT NULL AND service_account_id > 0 AND name LIKE ? AND expires IS NOT NULL AND expires < ?",
+				models.FederatedTokenNamePrefix+"%", cmd.ExpiredBefore.Unix()).
+				Cols("id", "org_id").Asc("id").Limit(int(batchSize)).Find(&keys)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := deleteAPIKey(sess, key.Id, key.OrgId); err != nil {
+					return err
+				}
+			}
+			deleted = len(keys)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		cmd.DeletedRows += int64(deleted)
+		if deleted < int(batchSize) {
+			return nil
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestApiKeyDataAccess(t *testing.T) {
@@ -31,6 +32,20 @@ func TestApiKeyDataAccess(t *testing.T) {
 				assert.Nil(t, err)
 				assert.NotNil(t, query.Result)
 			})
+
+			t.Run("Should record when the key was last used", func(t *testing.T) {
+				query := models.GetApiKeyByNameQuery{KeyName: "hello", OrgId: 1}
+				err = ss.GetApiKeyByName(context.Background(), &query)
+				assert.Nil(t, err)
+				assert.Nil(t, query.Result.LastUsedAt)
+
+				err = ss.UpdateAPIKeyLastUsedDate(context.Background(), query.Result.Id, "10.0.0.1")
+				assert.Nil(t, err)
+				err = ss.GetApiKeyByName(context.Background(), &query)
+				assert.Nil(t, err)
+				assert.NotNil(t, query.Result.LastUsedAt)
+				assert.Equal(t, "10.0.0.1", query.Result.LastUsedIp)
+			})
 		})
 
 		t.Run("Add non expiring key", func(t *testing.T) {
@@ -120,6 +135,177 @@ func TestApiKeyDataAccess(t *testing.T) {
 	})
 }
 
+func TestApiKeyRotation(t *testing.T) {
+	mockTimeNow()
+	defer resetTimeNow()
+
+	ss := InitTestDB(t)
+	cmd := models.AddApiKeyCommand{OrgId: 1, Name: "rotated", Key: "key-v1", SecondsToLive: 3600}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+	apikey := cmd.Result
+
+	t.Run("Should replace the key and record the rotation", func(t *testing.T) {
+		rotate := models.RotateApiKeyCommand{Id: apikey.Id, OrgId: 1, Key: "key-v2", GracePeriod: time.Minute, RotatedBy: 7}
+		require.NoError(t, ss.RotateAPIKey(context.Background(), &rotate))
+		assert.Equal(t, "key-v2", rotate.Result.Key)
+		assert.Equal(t, apikey.Expires, rotate.Result.Expires)
+		assert.Equal(t, "key-v1", rotate.Rotation.PreviousKey)
+		assert.Equal(t, rotate.Rotation.RotatedAt.Add(time.Minute), rotate.Rotation.PreviousKeyExpires)
+
+		query := models.GetApiKeyByNameQuery{KeyName: "rotated", OrgId: 1}
+		require.NoError(t, ss.GetApiKeyByName(context.Background(), &query))
+		assert.Equal(t, "key-v2", query.Result.Key)
+	})
+
+	t.Run("Should not keep the previous key valid after the API key expires", func(t *testing.T) {
+		rotate := models.RotateApiKeyCommand{Id: apikey.Id, OrgId: 1, Key: "key-v3", GracePeriod: 48 * time.Hour, SecondsToLive: 7200}
+		require.NoError(t, ss.RotateAPIKey(context.Background(), &rotate))
+		assert.Equal(t, time.Unix(*apikey.Expires, 0), rotate.Rotation.PreviousKeyExpires)
+		assert.Greater(t, *rotate.Result.Expires, *apikey.Expires)
+	})
+
+	t.Run("Should list the rotations, the most recent first", func(t *testing.T) {
+		query := models.GetApiKeyRotationsQuery{ApiKeyId: apikey.Id, OrgId: 1}
+		require.NoError(t, ss.GetAPIKeyRotations(context.Background(), &query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "key-v2", query.Result[0].PreviousKey)
+		assert.Equal(t, int64(7), query.Result[1].RotatedBy)
+
+		query = models.GetApiKeyRotationsQuery{ApiKeyId: apikey.Id, OrgId: 2}
+		require.NoError(t, ss.GetAPIKeyRotations(context.Background(), &query))
+		assert.Len(t, query.Result, 0)
+	})
+
+	t.Run("Should not rotate unknown keys", func(t *testing.T) {
+		rotate := models.RotateApiKeyCommand{Id: apikey.Id, OrgId: 2, Key: "key-v4"}
+		assert.ErrorIs(t, ss.RotateAPIKey(context.Background(), &rotate), models.ErrApiKeyNotFound)
+
+		rotate = models.RotateApiKeyCommand{Id: apikey.Id, OrgId: 1, Key: "key-v4", GracePeriod: -time.Minute}
+		assert.ErrorIs(t, ss.RotateAPIKey(context.Background(), &rotate), models.ErrInvalidApiKeyExpiration)
+	})
+
+	t.Run("Should delete the rotations with the key", func(t *testing.T) {
+		require.NoError(t, ss.DeleteApiKey(context.Background(), &models.DeleteApiKeyCommand{Id: apikey.Id, OrgId: 1}))
+
+		query := models.GetApiKeyRotationsQuery{ApiKeyId: apikey.Id, OrgId: 1}
+		require.NoError(t, ss.GetAPIKeyRotations(context.Background(), &query))
+		assert.Len(t, query.Result, 0)
+	})
+}
+
+func TestApiKeyPermissions(t *testing.T) {
+	mockTimeNow()
+	defer resetTimeNow()
+
+	ss := InitTestDB(t)
+	cmd := models.AddApiKeyCommand{OrgId: 1, Name: "scoped", Key: "scoped-key", Role: models.ROLE_VIEWER, Permissions: []models.ApiKeyPermissionDTO{
+		{Action: "dashboards:read", Scope: "dashboards:uid:abc"},
+		{Action: "annotations:read", Scope: "annotations:*"},
+	}}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+	scoped := cmd.Result
+	cmd = models.AddApiKeyCommand{OrgId: 1, Name: "unscoped", Key: "unscoped-key", Role: models.ROLE_VIEWER}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+	unscoped := cmd.Result
+
+	t.Run("Should store the permissions of the key", func(t *testing.T) {
+		query := models.GetApiKeyPermissionsQuery{OrgId: 1, ApiKeyIds: []int64{scoped.Id, unscoped.Id}}
+		require.NoError(t, ss.GetAPIKeyPermissions(context.Background(), &query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, scoped.Id, query.Result[0].ApiKeyId)
+		assert.Equal(t, "dashboards:read", query.Result[0].Action)
+		assert.Equal(t, "annotations:*", query.Result[1].Scope)
+
+		query = models.GetApiKeyPermissionsQuery{OrgId: 2, ApiKeyIds: []int64{scoped.Id}}
+		require.NoError(t, ss.GetAPIKeyPermissions(context.Background(), &query))
+		assert.Len(t, query.Result, 0)
+	})
+
+	t.Run("Should delete the permissions with the key", func(t *testing.T) {
+		require.NoError(t, ss.DeleteApiKey(context.Background(), &models.DeleteApiKeyCommand{Id: scoped.Id, OrgId: 1}))
+
+		query := models.GetApiKeyPermissionsQuery{OrgId: 1, ApiKeyIds: []int64{scoped.Id}}
+		require.NoError(t, ss.GetAPIKeyPermissions(context.Background(), &query))
+		assert.Len(t, query.Result, 0)
+	})
+}
+
+func TestUserAccessTokens(t *testing.T) {
+	mockTimeNow()
+	defer resetTimeNow()
+
+	ss := InitTestDB(t)
+	cmd := models.AddApiKeyCommand{OrgId: 1, Name: "org key", Key: "org-key", Role: models.ROLE_VIEWER}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+	cmd = models.AddApiKeyCommand{OrgId: 1, Name: "ci", Key: "ci-key", Role: models.ROLE_EDITOR, UserId: 10, Permissions: []models.ApiKeyPermissionDTO{
+		{Action: "dashboards:read", Scope: "dashboards:*"},
+	}}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+	token := cmd.Result
+	cmd = models.AddApiKeyCommand{OrgId: 1, Name: "other", Key: "other-key", Role: models.ROLE_EDITOR, UserId: 11}
+	require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+
+	t.Run("Should list the tokens of the user", func(t *testing.T) {
+		query := models.GetUserAccessTokensQuery{OrgId: 1, UserId: 10}
+		require.NoError(t, ss.GetUserAccessTokens(context.Background(), &query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "ci", query.Result[0].Name)
+		assert.Equal(t, int64(10), query.Result[0].UserId)
+	})
+
+	t.Run("Should not list the tokens with the API keys of the organization", func(t *testing.T) {
+		query := models.GetApiKeysQuery{OrgId: 1}
+		require.NoError(t, ss.GetAPIKeys(context.Background(), &query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "org key", query.Result[0].Name)
+	})
+
+	t.Run("Should not revoke the tokens of other users", func(t *testing.T) {
+		err := ss.DeleteUserAccessToken(context.Background(), &models.DeleteUserAccessTokenCommand{Id: token.Id, UserId: 11})
+		assert.ErrorIs(t, err, models.ErrUserAccessTokenNotFound)
+	})
+
+	t.Run("Should revoke the token with its permissions", func(t *testing.T) {
+		require.NoError(t, ss.DeleteUserAccessToken(context.Background(), &models.DeleteUserAccessTokenCommand{Id: token.Id, UserId: 10}))
+
+		query := models.GetUserAccessTokensQuery{OrgId: 1, UserId: 10}
+		require.NoError(t, ss.GetUserAccessTokens(context.Background(), &query))
+		assert.Len(t, query.Result, 0)
+		permissionsQuery := models.GetApiKeyPermissionsQuery{OrgId: 1, ApiKeyIds: []int64{token.Id}}
+		require.NoError(t, ss.GetAPIKeyPermissions(context.Background(), &permissionsQuery))
+		assert.Len(t, permissionsQuery.Result, 0)
+	})
+}
+
+func TestDeleteExpiredFederatedTokens(t *testing.T) {
+	mockTimeNow()
+	defer resetTimeNow()
+
+	ss := InitTestDB(t)
+	add := func(name string, serviceAccountID, secondsToLive int64) *models.ApiKey {
+		cmd := models.AddApiKeyCommand{OrgId: 1, Name: name, Key: name, Role: models.ROLE_VIEWER, ServiceAccountId: serviceAccountID, SecondsToLive: secondsToLive}
+		require.NoError(t, ss.AddAPIKey(context.Background(), &cmd))
+		return cmd.Result
+	}
+	add("federated-a-1", 5, 60)
+	add("federated-a-2", 5, 60)
+	add("federated-a-3", 5, 7200)
+	add("federated-key", 0, 60)
+	add("ci", 5, 60)
+
+	cmd := models.DeleteExpiredFederatedTokensCommand{ExpiredBefore: timeNow().Add(time.Hour), BatchSize: 1}
+	require.NoError(t, ss.DeleteExpiredFederatedTokens(context.Background(), &cmd))
+	assert.Equal(t, int64(2), cmd.DeletedRows)
+
+	query := models.GetApiKeysQuery{OrgId: 1, IncludeExpired: true}
+	require.NoError(t, ss.GetAPIKeys(context.Background(), &query))
+	names := make([]string, 0, len(query.Result))
+	for _, key := range query.Result {
+		names = append(names, key.Name)
+	}
+	assert.ElementsMatch(t, []string{"federated-a-3", "federated-key", "ci"}, names)
+}
+
 func TestApiKeyErrors(t *testing.T) {
 	mockTimeNow()
 	defer resetTimeNow()
@@ -0,0 +1,54 @@
+package sqlstore
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// maxBatchRows bounds the number of rows of a multi-value insert, to keep the size of the statements reasonable
+const maxBatchRows = 1000
+
+// InsertBatch inserts the rows, a slice of beans or of pointers to beans, with multi-value inserts of as many rows
+// as the dialect allows bind parameters in a statement. Unlike Insert, it does not set the ids of the inserted rows.
+// It returns the number of inserted rows.
+func (sess *DBSession) InsertBatch(table string, rows interface{}) (int64, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("batch insert needs a slice of rows, got %T", rows)
+	}
+	if v.Len() == 0 {
+		return 0, nil
+	}
+
+	size := batchSize(dialect, len(x.TableInfo(v.Index(0).Interface()).Columns()))
+	var inserted int64
+	for start := 0; start < v.Len(); start += size {
+		end := start + size
+		if end > v.Len() {
+			end = v.Len()
+		}
+		n, err := sess.Table(table).InsertMulti(v.Slice(start, end).Interface())
+		if err != nil {
+			return inserted, err
+		}
+		inserted += n
+	}
+	return inserted, nil
+}
+
+// batchSize returns how many rows of the given number of columns fit in a multi-value insert
+func batchSize(d migrator.Dialect, columns int) int {
+	if columns <= 0 {
+		return maxBatchRows
+	}
+	size := d.MaxBindParams() / columns
+	if size > maxBatchRows {
+		return maxBatchRows
+	}
+	if size < 1 {
+		return 1
+	}
+	return size
+}
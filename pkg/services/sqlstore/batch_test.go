@@ -0,0 +1,79 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+type batchRow struct {
+	Id    int64
+	Name  string
+	Value int64
+}
+
+func TestInsertBatch(t *testing.T) {
+	ss := InitTestDB(t)
+	_, err := ss.engine.Exec("CREATE TABLE batch_row (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, value INTEGER)")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := ss.engine.Exec("DROP TABLE batch_row")
+		require.NoError(t, err)
+	})
+
+	t.Run("should insert the rows in batches", func(t *testing.T) {
+		// SQLite allows 999 bind parameters, so the rows are inserted 333 at a time
+		rows := make([]*batchRow, 0, 700)
+		for i := 0; i < cap(rows); i++ {
+			rows = append(rows, &batchRow{Name: "row", Value: int64(i)})
+		}
+
+		err := ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			inserted, err := sess.InsertBatch("batch_row", rows)
+			assert.Equal(t, int64(700), inserted)
+			return err
+		})
+		require.NoError(t, err)
+
+		var result struct {
+			Count int64
+			Sum   int64
+		}
+		_, err = ss.engine.SQL("SELECT COUNT(*) AS count, SUM(value) AS sum FROM batch_row").Get(&result)
+		require.NoError(t, err)
+		assert.Equal(t, int64(700), result.Count)
+		assert.Equal(t, int64(699*700/2), result.Sum)
+	})
+
+	t.Run("should do nothing without rows", func(t *testing.T) {
+		err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+			inserted, err := sess.InsertBatch("batch_row", []batchRow{})
+			assert.Zero(t, inserted)
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail when the rows are not a slice", func(t *testing.T) {
+		err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+			_, err := sess.InsertBatch("batch_row", batchRow{})
+			return err
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestBatchSize(t *testing.T) {
+	sqlite := migrator.NewSQLite3Dialect(nil)
+	assert.Equal(t, 333, batchSize(sqlite, 3))
+	assert.Equal(t, 1, batchSize(sqlite, 2000))
+	assert.Equal(t, maxBatchRows, batchSize(migrator.NewPostgresDialect(nil), 3))
+	assert.Equal(t, maxBatchRows, batchSize(sqlite, 0))
+}
@@ -0,0 +1,478 @@
+package sqlstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+	"xorm.io/core"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const (
+	// copyBatchSize is the maximum number of rows inserted by a statement
+	copyBatchSize = 500
+	// copyBatchBytes is the approximate maximum size of the values inserted by a statement, which stays under the
+	// default max_allowed_packet of MySQL
+	copyBatchBytes = 1 << 20
+)
+
+// copyFirstTables are copied before the other tables, which reference them. The schema has no foreign keys, but the
+// databases whose administrators added some can still be copied.
+var copyFirstTables = []string{"org", "user", "team", "dashboard", "data_source"}
+
+// copySkippedTables are the bookkeeping of the migrations, which the migrations of the target database create
+var copySkippedTables = map[string]bool{
+	"migration_log":  true,
+	"migration_lock": true,
+}
+
+// TableCopy is the outcome of the copy of a table. The checksum of the rows is the same in both databases.
+type TableCopy struct {
+	Name     string
+	Rows     int64
+	Checksum string
+}
+
+// DatabaseCopy copies the data of the database of a store to another database, for instance to graduate off SQLite.
+// The schema of the target database is created by the migrations of the store.
+type DatabaseCopy struct {
+	source *SQLStore
+	target *SQLStore
+	log    log.Logger
+}
+
+// NewDatabaseCopy connects to the database of the URL, which must be empty, and creates its schema
+func NewDatabaseCopy(source *SQLStore, targetURL string) (*DatabaseCopy, error) {
+	raw := ini.Empty()
+	sec, err := raw.NewSection("database")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sec.NewKey("url", targetURL); err != nil {
+		return nil, err
+	}
+	return newDatabaseCopy(source, raw)
+}
+
+// newDatabaseCopy connects to the database of the [database] section of the configuration
+func newDatabaseCopy(source *SQLStore, raw *ini.File) (*DatabaseCopy, error) {
+	cfg := *source.Cfg
+	cfg.Raw = raw
+
+	// the target is only used through its engine, the package keeps working with the source
+	defer source.restoreGlobals()
+	target, err := newSQLStore(&cfg, nil, bus.New(), nil, source.migrations, InitTestDBOpt{EnsureDefaultOrgAndUser: false})
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := target.engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) > 0 {
+		return nil, fmt.Errorf("the target database is not empty, it has %d tables", len(tables))
+	}
+	if err := target.Migrate(); err != nil {
+		return nil, fmt.Errorf("failed to create the schema of the target database: %w", err)
+	}
+
+	return &DatabaseCopy{source: source, target: target, log: log.New("sqlstore.copy")}, nil
+}
+
+// restoreGlobals points the globals of the package back to the store
+func (ss *SQLStore) restoreGlobals() {
+	x = ss.engine
+	dialect = ss.Dialect
+	readReplica = ss.replica
+	slowQueryThreshold = ss.dbCfg.SlowQueryThreshold
+	transactionRetries = retryPolicy{MaxRetries: ss.dbCfg.TransactionRetries, Backoff: ss.dbCfg.TransactionRetryBackoff}
+}
+
+// Run copies the tables, parents first, and verifies the number of rows and the checksum of every table. The
+// sequences of the target database are moved past the copied IDs. The progress is called after each table.
+func (c *DatabaseCopy) Run(ctx context.Context, progress func(TableCopy)) ([]TableCopy, error) {
+	sourceTables, err := c.source.engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	targetTables, err := c.target.engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]*core.Table, len(targetTables))
+	for _, table := range targetTables {
+		targets[table.Name] = table
+	}
+
+	tables := make([]*core.Table, 0, len(sourceTables))
+	for _, table := range sourceTables {
+		if copySkippedTables[table.Name] {
+			continue
+		}
+		if _, ok := targets[table.Name]; !ok {
+			return nil, fmt.Errorf("the table %s is not in the schema of the target database", table.Name)
+		}
+		tables = append(tables, table)
+	}
+	sortTablesForCopy(tables)
+
+	result := make([]TableCopy, 0, len(tables))
+	for _, table := range tables {
+		copied, err := c.copyTable(ctx, table, targets[table.Name])
+		if err != nil {
+			return result, fmt.Errorf("failed to copy table %s: %w", table.Name, err)
+		}
+		result = append(result, copied)
+		if progress != nil {
+			progress(copied)
+		}
+	}
+	return result, nil
+}
+
+// sortTablesForCopy sorts the tables referenced by the others first, and the others by name
+func sortTablesForCopy(tables []*core.Table) {
+	rank := func(name string) int {
+		for i, first := range copyFirstTables {
+			if first == name {
+				return i
+			}
+		}
+		return len(copyFirstTables)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		ri, rj := rank(tables[i].Name), rank(tables[j].Name)
+		if ri != rj {
+			return ri < rj
+		}
+		return tables[i].Name < tables[j].Name
+	})
+}
+
+func (c *DatabaseCopy) copyTable(ctx context.Context, source *core.Table, target *core.Table) (TableCopy, error) {
+	columns := make([]*core.Column, 0, len(source.Columns()))
+	for _, col := range source.Columns() {
+		targetCol := target.GetColumn(col.Name)
+		if targetCol == nil {
+			return TableCopy{}, fmt.Errorf("the column %s is not in the schema of the target database", col.Name)
+		}
+		columns = append(columns, targetCol)
+	}
+	sourceChecksum := newTableChecksum(source.Name)
+	if len(columns) == 0 {
+		return sourceChecksum.result(), nil
+	}
+
+	sourceDialect := c.source.Dialect
+	targetDialect := c.target.Dialect
+	targetType := targetDialect.DriverName()
+
+	err := withDbSession(ctx, c.source.engine, func(sourceSess *DBSession) error {
+		rows, err := sourceSess.DB().QueryContext(ctx, selectColumnsSQL(sourceDialect, source.Name, columns))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				c.log.Warn("Failed to close the rows", "table", source.Name, "error", err)
+			}
+		}()
+
+		return c.target.WithTransactionalDbSession(ctx, func(targetSess *DBSession) error {
+			// the rows inserted by the migrations, such as the default dashboard permissions, are also in the source
+			if _, err := targetSess.Exec("DELETE FROM " + targetDialect.Quote(target.Name)); err != nil {
+				return err
+			}
+
+			insert := &batchInsert{sess: targetSess, dialect: targetDialect, table: source.Name, columns: columns}
+			for rows.Next() {
+				values, err := scanRow(rows.Rows, len(columns))
+				if err != nil {
+					return err
+				}
+				sourceChecksum.add(columns, values)
+
+				for i, col := range columns {
+					values[i] = copyValue(col, values[i], targetType)
+				}
+				if err := insert.add(values); err != nil {
+					return err
+				}
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if err := insert.flush(); err != nil {
+				return err
+			}
+			return c.fixSequence(targetSess, target)
+		})
+	})
+	if err != nil {
+		return TableCopy{}, err
+	}
+
+	copied := sourceChecksum.result()
+	verified, err := c.checksum(ctx, c.target, target.Name, columns)
+	if err != nil {
+		return copied, fmt.Errorf("failed to verify the copy: %w", err)
+	}
+	if verified != copied {
+		return copied, fmt.Errorf("the copy does not match, %d rows with checksum %s were copied to %d rows with checksum %s",
+			copied.Rows, copied.Checksum, verified.Rows, verified.Checksum)
+	}
+	return copied, nil
+}
+
+// checksum reads the rows of a table of a store to compute its checksum
+func (c *DatabaseCopy) checksum(ctx context.Context, ss *SQLStore, table string, columns []*core.Column) (TableCopy, error) {
+	checksum := newTableChecksum(table)
+	err := withDbSession(ctx, ss.engine, func(sess *DBSession) error {
+		rows, err := sess.DB().QueryContext(ctx, selectColumnsSQL(ss.Dialect, table, columns))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				c.log.Warn("Failed to close the rows", "table", table, "error", err)
+			}
+		}()
+
+		for rows.Next() {
+			values, err := scanRow(rows.Rows, len(columns))
+			if err != nil {
+				return err
+			}
+			checksum.add(columns, values)
+		}
+		return rows.Err()
+	})
+	return checksum.result(), err
+}
+
+// fixSequence moves the sequence of the auto incremented column of a Postgres table past the copied IDs. MySQL moves
+// the auto increment counter itself when IDs are inserted.
+func (c *DatabaseCopy) fixSequence(sess *DBSession, table *core.Table) error {
+	if c.target.Dialect.DriverName() != migrator.Postgres || table.AutoIncrement == "" {
+		return nil
+	}
+	quotedTable := c.target.Dialect.Quote(table.Name)
+	quotedColumn := c.target.Dialect.Quote(table.AutoIncrement)
+	rawSQL := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		quotedTable, table.AutoIncrement, quotedColumn, quotedTable)
+	_, err := sess.Exec(rawSQL)
+	return err
+}
+
+func selectColumnsSQL(d migrator.Dialect, table string, columns []*core.Column) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = d.Quote(col.Name)
+	}
+	return "SELECT " + strings.Join(names, ", ") + " FROM " + d.Quote(table)
+}
+
+func scanRow(rows *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	dest := make([]interface{}, n)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// copyValue converts a value read from the source database to the type of the column of the target database, the
+// booleans of SQLite are integers and its texts can be read as bytes
+func copyValue(col *core.Column, value interface{}, targetType string) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch {
+	case isBoolColumn(col):
+		switch v := value.(type) {
+		case int64:
+			return v != 0
+		case []byte:
+			b, _ := strconv.ParseBool(string(v))
+			return b
+		case string:
+			b, _ := strconv.ParseBool(v)
+			return b
+		}
+	case col.SQLType.IsTime():
+		if t, ok := value.(time.Time); ok && targetType == migrator.MySQL {
+			// MySQL rounds the fractional seconds the DATETIME columns do not have
+			return t.UTC().Truncate(time.Second)
+		}
+	case col.SQLType.IsBlob():
+		if v, ok := value.(string); ok {
+			return []byte(v)
+		}
+	default:
+		if v, ok := value.([]byte); ok {
+			return string(v)
+		}
+	}
+	return value
+}
+
+func isBoolColumn(col *core.Column) bool {
+	name := strings.ToUpper(col.SQLType.Name)
+	return name == core.Bool || name == core.Boolean
+}
+
+// batchInsert inserts the rows of a table with multi-row INSERT statements
+type batchInsert struct {
+	sess    *DBSession
+	dialect migrator.Dialect
+	table   string
+	columns []*core.Column
+
+	args  []interface{}
+	rows  int
+	bytes int
+}
+
+func (b *batchInsert) add(values []interface{}) error {
+	b.args = append(b.args, values...)
+	b.rows++
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			b.bytes += len(v)
+		case []byte:
+			b.bytes += len(v)
+		default:
+			b.bytes += 8
+		}
+	}
+	// Postgres binds at most 65535 parameters
+	if b.rows >= copyBatchSize || b.bytes >= copyBatchBytes || len(b.args)+len(b.columns) > 65535 {
+		return b.flush()
+	}
+	return nil
+}
+
+func (b *batchInsert) flush() error {
+	if b.rows == 0 {
+		return nil
+	}
+	names := make([]string, len(b.columns))
+	for i, col := range b.columns {
+		names[i] = b.dialect.Quote(col.Name)
+	}
+	row := "(" + strings.TrimSuffix(strings.Repeat("?,", len(b.columns)), ",") + ")"
+	rawSQL := "INSERT INTO " + b.dialect.Quote(b.table) + " (" + strings.Join(names, ", ") + ") VALUES " +
+		strings.TrimSuffix(strings.Repeat(row+",", b.rows), ",")
+
+	if _, err := b.sess.Exec(append([]interface{}{rawSQL}, b.args...)...); err != nil {
+		return err
+	}
+	b.args = b.args[:0]
+	b.rows = 0
+	b.bytes = 0
+	return nil
+}
+
+// tableChecksum is a checksum of the rows of a table which does not depend on their order, nor on the types the
+// database drivers read the values as
+type tableChecksum struct {
+	name string
+	rows int64
+	sum  uint64
+}
+
+func newTableChecksum(name string) *tableChecksum {
+	return &tableChecksum{name: name}
+}
+
+func (c *tableChecksum) add(columns []*core.Column, values []interface{}) {
+	h := sha256.New()
+	for i, v := range values {
+		_, _ = h.Write([]byte(normalizeValue(columns[i], v)))
+		_, _ = h.Write([]byte{0x1f})
+	}
+	c.sum += binary.BigEndian.Uint64(h.Sum(nil))
+	c.rows++
+}
+
+func (c *tableChecksum) result() TableCopy {
+	sum := make([]byte, 8)
+	binary.BigEndian.PutUint64(sum, c.sum)
+	return TableCopy{Name: c.name, Rows: c.rows, Checksum: hex.EncodeToString(sum)}
+}
+
+// timeLayouts are the layouts of the dates read as texts, MySQL reads its DATETIME columns as texts
+var timeLayouts = []string{"2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, "2006-01-02"}
+
+// normalizeValue formats a value the same way whatever the database it was read from
+func normalizeValue(col *core.Column, value interface{}) string {
+	if value == nil {
+		return "\x00NULL"
+	}
+	if b, ok := value.([]byte); ok {
+		if col.SQLType.IsBlob() {
+			return string(b)
+		}
+		value = string(b)
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return formatCopyTime(v)
+	case string:
+		if col.SQLType.IsTime() {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return formatCopyTime(t)
+				}
+			}
+		}
+		if isBoolColumn(col) {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return normalizeValue(col, b)
+			}
+		}
+		if col.SQLType.IsNumeric() {
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return strconv.FormatInt(i, 10)
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatCopyTime formats the times to the second, which is the precision of the DATETIME columns of MySQL
+func formatCopyTime(t time.Time) string {
+	return t.UTC().Truncate(time.Second).Format("2006-01-02 15:04:05")
+}
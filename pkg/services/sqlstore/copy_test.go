@@ -0,0 +1,81 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDatabaseCopy(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := InitTestDB(t)
+	org, err := sqlStore.CreateOrgWithMember("Copied", 0)
+	require.NoError(t, err)
+	user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "copied", Email: "copied@example.org", OrgId: org.Id, IsAdmin: true})
+	require.NoError(t, err)
+
+	newTarget := func(t *testing.T, path string) (*DatabaseCopy, error) {
+		raw := ini.Empty()
+		sec, err := raw.NewSection("database")
+		require.NoError(t, err)
+		_, err = sec.NewKey("type", "sqlite3")
+		require.NoError(t, err)
+		_, err = sec.NewKey("path", path)
+		require.NoError(t, err)
+		return newDatabaseCopy(sqlStore, raw)
+	}
+
+	path := filepath.Join(t.TempDir(), "copy.db")
+	dbCopy, err := newTarget(t, path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = dbCopy.target.engine.Close()
+	})
+	require.Equal(t, sqlStore.engine, x, "the package should keep using the source database")
+
+	var progress []string
+	tables, err := dbCopy.Run(ctx, func(table TableCopy) {
+		progress = append(progress, table.Name)
+	})
+	require.NoError(t, err)
+	require.Len(t, progress, len(tables))
+
+	t.Run("should copy the referenced tables first", func(t *testing.T) {
+		assert.Equal(t, []string{"org", "user", "team", "dashboard", "data_source"}, progress[:5])
+		assert.NotContains(t, progress, "migration_log")
+	})
+
+	t.Run("should copy the rows", func(t *testing.T) {
+		for _, table := range tables {
+			if table.Name != "user" {
+				continue
+			}
+			count, err := sqlStore.engine.Table("user").Count()
+			require.NoError(t, err)
+			assert.Equal(t, count, table.Rows)
+		}
+
+		copied := models.User{}
+		exists, err := dbCopy.target.engine.ID(user.Id).Get(&copied)
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, user.Login, copied.Login)
+		assert.Equal(t, user.Email, copied.Email)
+		assert.True(t, copied.IsAdmin)
+		assert.Equal(t, user.Created.Unix(), copied.Created.Unix())
+	})
+
+	t.Run("should refuse a target database which is not empty", func(t *testing.T) {
+		_, err := newTarget(t, path)
+		require.Error(t, err)
+	})
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
@@ -55,6 +57,26 @@ func (ss *SQLStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dash
 	return cmd.Result, err
 }
 
+// SaveDashboards saves several dashboards within a single transaction, none of them is saved when one fails. The
+// dashboards are checked for title conflicts with the ones saved before them.
+func (ss *SQLStore) SaveDashboards(ctx context.Context, cmds []*models.SaveDashboardCommand) ([]*models.Dashboard, error) {
+	result := make([]*models.Dashboard, 0, len(cmds))
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		result = result[:0]
+		for _, cmd := range cmds {
+			if _, err := getExistingDashboardByTitleAndFolder(sess, cmd.GetDashboardModel(), cmd.Overwrite, false); err != nil {
+				return err
+			}
+			if err := saveDashboard(sess, cmd); err != nil {
+				return err
+			}
+			result = append(result, cmd.Result)
+		}
+		return nil
+	})
+	return result, err
+}
+
 func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 	dash := cmd.GetDashboardModel()
 
@@ -64,8 +86,8 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		userId = -1
 	}
 
+	var existing models.Dashboard
 	if dash.Id > 0 {
-		var existing models.Dashboard
 		dashWithIdExists, err := sess.Where("id=? AND org_id=?", dash.Id, dash.OrgId).Get(&existing)
 		if err != nil {
 			return err
@@ -107,8 +129,6 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		dash.CreatedBy = userId
 		dash.Updated = time.Now()
 		dash.UpdatedBy = userId
-		metrics.MApiDashboardInsert.Inc()
-		affectedRows, err = sess.Insert(dash)
 	} else {
 		dash.SetVersion(dash.Version + 1)
 
@@ -119,8 +139,22 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 
 		dash.UpdatedBy = userId
+	}
 
-		affectedRows, err = sess.MustCols("folder_id").ID(dash.Id).Update(dash)
+	// The stored row holds what the dashboard storage keeps in the data column, the result keeps the JSON model
+	data, err := dashboardStorage.encode(context.Background(), sess, dash)
+	if err != nil {
+		return err
+	}
+	row := *dash
+	row.Data = data
+
+	if dash.Id == 0 {
+		metrics.MApiDashboardInsert.Inc()
+		affectedRows, err = sess.Insert(&row)
+		dash.Id = row.Id
+	} else {
+		affectedRows, err = sess.MustCols("folder_id").ID(dash.Id).Update(&row)
 	}
 
 	if err != nil {
@@ -139,7 +173,7 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		Created:       time.Now(),
 		CreatedBy:     dash.UpdatedBy,
 		Message:       cmd.Message,
-		Data:          dash.Data,
+		Data:          data,
 	}
 
 	// insert version entry
@@ -149,6 +183,12 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		return models.ErrDashboardNotFound
 	}
 
+	if recordDashboardEvents && !dash.IsFolder {
+		if err := insertDashboardEvents(sess, dash, existing.Data); err != nil {
+			return err
+		}
+	}
+
 	// delete existing tags
 	_, err = sess.Exec("DELETE FROM dashboard_tag WHERE dashboard_id=?", dash.Id)
 	if err != nil {
@@ -165,6 +205,15 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 	}
 
+	if !dash.IsFolder {
+		sess.publishAfterCommit(&events.DashboardSaved{
+			Timestamp: dash.Updated,
+			OrgID:     dash.OrgId,
+			UID:       dash.Uid,
+			Version:   dash.Version,
+		})
+	}
+
 	cmd.Result = dash
 
 	return nil
@@ -200,6 +249,9 @@ func (ss *SQLStore) GetDashboard(id, orgID int64, uid, slug string) (*models.Das
 	} else if !has {
 		return nil, models.ErrDashboardNotFound
 	}
+	if err := decodeDashboards(context.Background(), &dashboard); err != nil {
+		return nil, err
+	}
 
 	dashboard.SetId(dashboard.Id)
 	dashboard.SetUid(dashboard.Uid)
@@ -228,7 +280,7 @@ func (ss *SQLStore) GetFolderByTitle(orgID int64, title string) (*models.Dashboa
 }
 
 func GetDashboard(ctx context.Context, query *models.GetDashboardQuery) error {
-	return withDbSession(ctx, x, func(dbSession *DBSession) error {
+	return withReadDbSession(withPreparedStatement(ctx), x, readReplica, func(dbSession *DBSession) error {
 		if query.Id == 0 && len(query.Slug) == 0 && len(query.Uid) == 0 {
 			return models.ErrDashboardIdentifierNotSet
 		}
@@ -241,6 +293,9 @@ func GetDashboard(ctx context.Context, query *models.GetDashboardQuery) error {
 		} else if !has {
 			return models.ErrDashboardNotFound
 		}
+		if err := decodeDashboards(ctx, &dashboard); err != nil {
+			return err
+		}
 
 		dashboard.SetId(dashboard.Id)
 		dashboard.SetUid(dashboard.Uid)
@@ -278,6 +333,8 @@ func (ss *SQLStore) findDashboards(ctx context.Context, query *search.FindPersis
 		filters = append(filters, filter)
 	}
 
+	filters = append(filters, query.Filters...)
+
 	if query.OrgId != 0 {
 		filters = append(filters, searchstore.OrgFilter{OrgId: query.OrgId})
 	} else if query.SignedInUser.OrgId != 0 {
@@ -296,7 +353,13 @@ func (ss *SQLStore) findDashboards(ctx context.Context, query *search.FindPersis
 		filters = append(filters, searchstore.StarredFilter{UserId: query.SignedInUser.UserId})
 	}
 
-	if len(query.Title) > 0 {
+	if len(query.Title) > 0 && len(query.TitleTerms) > 0 {
+		orgID := query.OrgId
+		if orgID == 0 {
+			orgID = query.SignedInUser.OrgId
+		}
+		filters = append(filters, searchstore.TitleTermsFilter{Dialect: dialect, OrgId: orgID, Title: query.Title, Terms: query.TitleTerms})
+	} else if len(query.Title) > 0 {
 		filters = append(filters, searchstore.TitleFilter{Dialect: dialect, Title: query.Title})
 	}
 
@@ -323,7 +386,7 @@ func (ss *SQLStore) findDashboards(ctx context.Context, query *search.FindPersis
 
 	sql, params := sb.ToSQL(limit, page)
 
-	err := ss.WithDbSession(ctx, func(dbSession *DBSession) error {
+	err := ss.WithReadDbSession(ctx, func(dbSession *DBSession) error {
 		return dbSession.SQL(sql, params...).Find(&res)
 	})
 
@@ -411,9 +474,23 @@ func GetDashboardTags(ctx context.Context, query *models.GetDashboardTagsQuery)
 }
 
 func DeleteDashboard(ctx context.Context, cmd *models.DeleteDashboardCommand) error {
-	return inTransaction(func(sess *DBSession) error {
+	var released []*simplejson.Json
+	err := inTransaction(func(sess *DBSession) error {
+		var err error
+		// The versions of the dashboards of a folder are deleted along with the folder
+		released, err = externalDashboardVersionsData(sess,
+			"dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND (id = ? OR folder_id = ?))", cmd.OrgId, cmd.Id, cmd.Id)
+		if err != nil {
+			return err
+		}
 		return deleteDashboard(cmd, sess)
 	})
+	if err != nil {
+		return err
+	}
+
+	releaseDashboardData(ctx, released)
+	return nil
 }
 
 func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error {
@@ -425,19 +502,30 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 		return models.ErrDashboardNotFound
 	}
 
+	if cmd.MoveToTrash && trashDeletedDashboards {
+		if err := moveDashboardsToTrash(sess, &dashboard, cmd.DeletedBy); err != nil {
+			return err
+		}
+	}
+
 	deletes := []string{
 		"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
 		"DELETE FROM star WHERE dashboard_id = ? ",
+		"DELETE FROM public_dashboard WHERE EXISTS (SELECT 1 FROM dashboard WHERE dashboard.id = ? AND dashboard.org_id = public_dashboard.org_id AND dashboard.uid = public_dashboard.dashboard_uid)",
+		"DELETE FROM dashboard_usage WHERE EXISTS (SELECT 1 FROM dashboard WHERE dashboard.id = ? AND dashboard.org_id = dashboard_usage.org_id AND dashboard.uid = dashboard_usage.dashboard_uid)",
 		"DELETE FROM dashboard WHERE id = ?",
 		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
 		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_event WHERE dashboard_id = ?",
 		"DELETE FROM annotation WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_acl WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_search_index WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_search_term WHERE dashboard_id = ?",
 	}
 
 	if dashboard.IsFolder {
-		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?", "DELETE FROM folder_datasource WHERE folder_id = ?")
 
 		dashIds := []struct {
 			Id int64
@@ -458,9 +546,14 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 				"DELETE FROM dashboard_tag WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM star WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_version WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_event WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM annotation WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_provisioning WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_acl WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_search_index WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_search_term WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM public_dashboard WHERE EXISTS (SELECT 1 FROM dashboard WHERE dashboard.org_id = ? AND dashboard.folder_id = ? AND dashboard.org_id = public_dashboard.org_id AND dashboard.uid = public_dashboard.dashboard_uid)",
+				"DELETE FROM dashboard_usage WHERE EXISTS (SELECT 1 FROM dashboard WHERE dashboard.org_id = ? AND dashboard.folder_id = ? AND dashboard.org_id = dashboard_usage.org_id AND dashboard.uid = dashboard_usage.dashboard_uid)",
 			}
 			for _, sql := range childrenDeletes {
 				_, err := sess.Exec(sql, dashboard.OrgId, dashboard.Id)
@@ -506,7 +599,7 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 		}
 	}
 
-	return nil
+	return deleteManagedDashboardPermissions(sess, &dashboard)
 }
 
 func GetDashboards(ctx context.Context, query *models.GetDashboardsQuery) error {
@@ -516,9 +609,29 @@ func GetDashboards(ctx context.Context, query *models.GetDashboardsQuery) error
 
 	var dashboards = make([]*models.Dashboard, 0)
 
-	err := x.In("id", query.DashboardIds).Find(&dashboards)
+	if err := x.In("id", query.DashboardIds).Find(&dashboards); err != nil {
+		return err
+	}
+	if err := decodeDashboards(ctx, dashboards...); err != nil {
+		return err
+	}
 	query.Result = dashboards
-	return err
+	return nil
+}
+
+// StreamDashboards calls fn with each of the dashboards of the organization, folders excluded, by order of id as they
+// are read from the database.
+func (ss *SQLStore) StreamDashboards(ctx context.Context, orgID int64, fn func(dash *models.Dashboard) error) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		sess.Where("org_id=? AND is_folder="+dialect.BooleanStr(false), orgID).Asc("id")
+		return sess.StreamRows(ctx, &models.Dashboard{}, func(row interface{}) error {
+			dash := row.(*models.Dashboard)
+			if err := decodeDashboards(ctx, dash); err != nil {
+				return err
+			}
+			return fn(dash)
+		})
+	})
 }
 
 // GetDashboardPermissionsForUser returns the maximum permission the specified user has for a dashboard(s)
@@ -595,9 +708,14 @@ func GetDashboardsByPluginId(ctx context.Context, query *models.GetDashboardsByP
 	var dashboards = make([]*models.Dashboard, 0)
 	whereExpr := "org_id=? AND plugin_id=? AND is_folder=" + dialect.BooleanStr(false)
 
-	err := x.Where(whereExpr, query.OrgId, query.PluginId).Find(&dashboards)
+	if err := x.Where(whereExpr, query.OrgId, query.PluginId).Find(&dashboards); err != nil {
+		return err
+	}
+	if err := decodeDashboards(ctx, dashboards...); err != nil {
+		return err
+	}
 	query.Result = dashboards
-	return err
+	return nil
 }
 
 type DashboardSlugDTO struct {
@@ -626,6 +744,9 @@ func GetDashboardsBySlug(ctx context.Context, query *models.GetDashboardsBySlugQ
 	if err := x.Where("org_id=? AND slug=?", query.OrgId, query.Slug).Find(&dashboards); err != nil {
 		return err
 	}
+	if err := decodeDashboards(ctx, dashboards...); err != nil {
+		return err
+	}
 
 	query.Result = dashboards
 	return nil
@@ -3,9 +3,12 @@ package sqlstore
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func (ss *SQLStore) addDashboardACLQueryAndCommandHandlers() {
@@ -41,8 +44,11 @@ func (ss *SQLStore) UpdateDashboardACLCtx(ctx context.Context, dashboardID int64
 
 		// Update dashboard HasAcl flag
 		dashboard := models.Dashboard{HasAcl: true}
-		_, err = sess.Cols("has_acl").Where("id=?", dashboardID).Update(&dashboard)
-		return err
+		if _, err := sess.Cols("has_acl").Where("id=?", dashboardID).Update(&dashboard); err != nil {
+			return err
+		}
+
+		return syncManagedDashboardPermissions(sess, dashboardID, items)
 	})
 }
 
@@ -132,3 +138,108 @@ func (ss *SQLStore) GetDashboardAclInfoList(ctx context.Context, query *models.G
 
 	return nil
 }
+
+// syncManagedDashboardPermissions mirrors the acl of a dashboard or folder to the access control managed permissions
+// scoped to it, so that the permissions set through the legacy API are the same with access control enabled.
+func syncManagedDashboardPermissions(sess *DBSession, dashboardID int64, items []*models.DashboardAcl) error {
+	dashboard := models.Dashboard{Id: dashboardID}
+	has, err := sess.Get(&dashboard)
+	if err != nil || !has {
+		return err
+	}
+
+	if err := deleteManagedDashboardPermissions(sess, &dashboard); err != nil {
+		return err
+	}
+
+	scope := managedDashboardPermissionsScope(&dashboard)
+	permissionsToActions := accesscontrol.DashboardPermissionsToActions
+	if dashboard.IsFolder {
+		permissionsToActions = accesscontrol.FolderPermissionsToActions
+	}
+
+	type grant struct {
+		roleID int64
+		action string
+	}
+	granted := map[grant]bool{}
+	for _, item := range items {
+		roleID, err := getOrCreateManagedDashboardRole(sess, dashboard.OrgId, item)
+		if err != nil {
+			return err
+		}
+
+		for _, action := range permissionsToActions[item.Permission.String()] {
+			if granted[grant{roleID, action}] {
+				continue
+			}
+			granted[grant{roleID, action}] = true
+
+			permission := accesscontrol.Permission{RoleID: roleID, Action: action, Scope: scope, Created: time.Now(), Updated: time.Now()}
+			if _, err := sess.Insert(&permission); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getOrCreateManagedDashboardRole returns the id of the role holding the managed permissions of the user, team or
+// built-in role the acl item is set for, the role is created and assigned when it does not exist yet.
+func getOrCreateManagedDashboardRole(sess *DBSession, orgID int64, item *models.DashboardAcl) (int64, error) {
+	var name string
+	var assignment interface{}
+	switch {
+	case item.UserID > 0:
+		name = accesscontrol.ManagedUserRoleName(item.UserID)
+		assignment = &accesscontrol.UserRole{OrgID: orgID, UserID: item.UserID, Created: time.Now()}
+	case item.TeamID > 0:
+		name = accesscontrol.ManagedTeamRoleName(item.TeamID)
+		assignment = &accesscontrol.TeamRole{OrgID: orgID, TeamID: item.TeamID, Created: time.Now()}
+	case item.Role != nil:
+		name = accesscontrol.ManagedBuiltInRoleName(string(*item.Role))
+		assignment = &accesscontrol.BuiltinRole{OrgID: orgID, Role: string(*item.Role), Created: time.Now(), Updated: time.Now()}
+	default:
+		return 0, models.ErrDashboardAclInfoMissing
+	}
+
+	role := accesscontrol.Role{}
+	has, err := sess.Where("org_id = ? AND name = ?", orgID, name).Get(&role)
+	if err != nil || has {
+		return role.ID, err
+	}
+
+	role = accesscontrol.Role{OrgID: orgID, Name: name, UID: util.GenerateShortUID(), Created: time.Now(), Updated: time.Now()}
+	if _, err := sess.Insert(&role); err != nil {
+		return 0, err
+	}
+
+	switch a := assignment.(type) {
+	case *accesscontrol.UserRole:
+		a.RoleID = role.ID
+	case *accesscontrol.TeamRole:
+		a.RoleID = role.ID
+	case *accesscontrol.BuiltinRole:
+		a.RoleID = role.ID
+	}
+	if _, err := sess.Insert(assignment); err != nil {
+		return 0, err
+	}
+
+	return role.ID, nil
+}
+
+// deleteManagedDashboardPermissions deletes the managed permissions scoped to a dashboard or folder
+func deleteManagedDashboardPermissions(sess *DBSession, dashboard *models.Dashboard) error {
+	_, err := sess.Exec("DELETE FROM permission WHERE scope = ? AND role_id IN (SELECT id FROM role WHERE org_id = ? AND name LIKE ?)",
+		managedDashboardPermissionsScope(dashboard), dashboard.OrgId, "managed:%")
+	return err
+}
+
+func managedDashboardPermissionsScope(dashboard *models.Dashboard) string {
+	if dashboard.IsFolder {
+		return accesscontrol.GetResourceAttributeScope("folders", "uid", dashboard.Uid)
+	}
+	return accesscontrol.GetResourceAttributeScope("dashboards", "uid", dashboard.Uid)
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 
 	"github.com/stretchr/testify/require"
 )
@@ -245,4 +246,40 @@ func TestDashboardAclDataAccess(t *testing.T) {
 		require.Equal(t, models.ROLE_EDITOR, *query.Result[1].Role)
 		require.False(t, query.Result[1].Inherited)
 	})
+
+	t.Run("Updating dashboard acl should sync the managed permissions of the dashboard", func(t *testing.T) {
+		setup(t)
+		viewer := models.ROLE_VIEWER
+		err := testHelperUpdateDashboardAcl(t, sqlStore, childDash.Id,
+			models.DashboardAcl{OrgID: 1, DashboardID: childDash.Id, UserID: currentUser.Id, Permission: models.PERMISSION_ADMIN},
+			models.DashboardAcl{OrgID: 1, DashboardID: childDash.Id, Role: &viewer, Permission: models.PERMISSION_VIEW},
+		)
+		require.Nil(t, err)
+
+		getManagedPermissions := func(roleName string) []string {
+			var actions []string
+			err := sqlStore.WithDbSession(context.Background(), func(sess *DBSession) error {
+				return sess.Table("permission").Join("INNER", "role", "role.id = permission.role_id").
+					Where("role.name = ? AND permission.scope = ?", roleName, "dashboards:uid:"+childDash.Uid).
+					Asc("permission.action").Cols("permission.action").Find(&actions)
+			})
+			require.NoError(t, err)
+			return actions
+		}
+
+		require.Equal(t, []string{"dashboards.permissions:read", "dashboards.permissions:write", "dashboards:delete", "dashboards:read", "dashboards:write"},
+			getManagedPermissions(accesscontrol.ManagedUserRoleName(currentUser.Id)))
+		require.Equal(t, []string{"dashboards:read"}, getManagedPermissions(accesscontrol.ManagedBuiltInRoleName("Viewer")))
+
+		err = testHelperUpdateDashboardAcl(t, sqlStore, childDash.Id,
+			models.DashboardAcl{OrgID: 1, DashboardID: childDash.Id, UserID: currentUser.Id, Permission: models.PERMISSION_VIEW},
+		)
+		require.Nil(t, err)
+		require.Equal(t, []string{"dashboards:read"}, getManagedPermissions(accesscontrol.ManagedUserRoleName(currentUser.Id)))
+		require.Empty(t, getManagedPermissions(accesscontrol.ManagedBuiltInRoleName("Viewer")))
+
+		err = DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: childDash.Id, OrgId: 1})
+		require.Nil(t, err)
+		require.Empty(t, getManagedPermissions(accesscontrol.ManagedUserRoleName(currentUser.Id)))
+	})
 }
@@ -0,0 +1,91 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashevents"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// recordDashboardEvents is temporarily a global var like the dashboard storage, as the dashboards are still saved
+// by bus handlers without access to the SQL store
+var recordDashboardEvents = false
+
+func (ss *SQLStore) addDashboardEventQueryHandlers() {
+	bus.AddHandler("sql", ss.GetDashboardEvents)
+	bus.AddHandler("sql", ss.GetDashboardEvent)
+}
+
+// GetDashboardEvents gets the events of the given dashboard ID, latest first.
+func (ss *SQLStore) GetDashboardEvents(ctx context.Context, query *models.GetDashboardEventsQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		if query.Limit == 0 {
+			query.Limit = 1000
+		}
+
+		query.Result = make([]*models.DashboardEventDTO, 0)
+		return sess.Table("dashboard_event").
+			Select(`dashboard_event.*, `+dialect.Quote("user")+`.login as created_by_login`).
+			Join("LEFT", dialect.Quote("user"), `dashboard_event.created_by = `+dialect.Quote("user")+`.id`).
+			Where("dashboard_event.dashboard_id=? AND dashboard_event.org_id=?", query.DashboardId, query.OrgId).
+			OrderBy("dashboard_event.id DESC").
+			Limit(query.Limit, query.Start).
+			Find(&query.Result)
+	})
+}
+
+// GetDashboardEvent gets the dashboard event with the given ID.
+func (ss *SQLStore) GetDashboardEvent(ctx context.Context, query *models.GetDashboardEventQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		event := models.DashboardEvent{}
+		has, err := sess.Where("id=? AND dashboard_id=? AND org_id=?", query.Id, query.DashboardId, query.OrgId).Get(&event)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardEventNotFound
+		}
+
+		query.Result = &event
+		return nil
+	})
+}
+
+// insertDashboardEvents records the changes from the previous data of the dashboard to the saved one, or the
+// creation of the dashboard when there is no previous data
+func insertDashboardEvents(sess *DBSession, dash *models.Dashboard, previous *simplejson.Json) error {
+	var events []*models.DashboardEvent
+	if previous == nil {
+		events = []*models.DashboardEvent{{Type: models.DashboardEventCreated}}
+	} else {
+		base, err := dashboardStorage.decode(context.WithValue(context.Background(), ContextSessionKey{}, sess), previous)
+		if err != nil {
+			return err
+		}
+		events = dashevents.Compute(base, dash.Data)
+	}
+
+	for _, event := range events {
+		event.OrgId = dash.OrgId
+		event.DashboardId = dash.Id
+		event.Version = dash.Version
+		event.Created = time.Now()
+		event.CreatedBy = dash.UpdatedBy
+
+		// Missing values are kept as NULL, xorm would store nil JSON values as empty strings, read back as empty objects
+		var omit []string
+		if event.OldValue == nil {
+			omit = append(omit, "old_value")
+		}
+		if event.NewValue == nil {
+			omit = append(omit, "new_value")
+		}
+		if _, err := sess.Omit(omit...).Insert(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
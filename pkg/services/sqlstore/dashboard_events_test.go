@@ -0,0 +1,87 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDashboardEvents(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	previous := recordDashboardEvents
+	recordDashboardEvents = true
+	t.Cleanup(func() {
+		recordDashboardEvents = previous
+	})
+
+	dash := insertTestDashboard(t, sqlStore, "Events", 1, 0, false)
+	updateTestDashboard(t, sqlStore, dash, map[string]interface{}{
+		"uid":    dash.Uid,
+		"title":  "Events",
+		"panels": []interface{}{map[string]interface{}{"id": 1, "title": "CPU"}},
+	})
+	updateTestDashboard(t, sqlStore, dash, map[string]interface{}{
+		"uid":    dash.Uid,
+		"title":  "Renamed",
+		"panels": []interface{}{map[string]interface{}{"id": 1, "title": "CPU", "gridPos": map[string]interface{}{"x": 12}}},
+	})
+
+	t.Run("should record the events of each save", func(t *testing.T) {
+		query := models.GetDashboardEventsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardEvents(context.Background(), &query))
+
+		types := make([]string, 0, len(query.Result))
+		for _, e := range query.Result {
+			types = append(types, e.Type)
+		}
+		assert.Equal(t, []string{
+			models.DashboardEventPanelMoved,
+			models.DashboardEventPropertyChanged,
+			models.DashboardEventPanelAdded,
+			models.DashboardEventCreated,
+		}, types)
+		assert.Equal(t, 3, query.Result[0].Version)
+		assert.Equal(t, "Events", query.Result[1].OldValue.MustString())
+		assert.Equal(t, "Renamed", query.Result[1].NewValue.MustString())
+		assert.Nil(t, query.Result[3].OldValue)
+
+		event := models.GetDashboardEventQuery{Id: query.Result[2].Id, DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardEvent(context.Background(), &event))
+		assert.Equal(t, int64(1), event.Result.PanelId)
+		assert.Equal(t, "CPU", event.Result.NewValue.Get("title").MustString())
+
+		event = models.GetDashboardEventQuery{Id: query.Result[2].Id, DashboardId: dash.Id, OrgId: 2}
+		assert.ErrorIs(t, sqlStore.GetDashboardEvent(context.Background(), &event), models.ErrDashboardEventNotFound)
+	})
+
+	t.Run("should delete the events of expired versions", func(t *testing.T) {
+		versionsToKeep := setting.DashboardVersionsToKeep
+		setting.DashboardVersionsToKeep = 1
+		t.Cleanup(func() {
+			setting.DashboardVersionsToKeep = versionsToKeep
+		})
+
+		require.NoError(t, sqlStore.DeleteExpiredVersions(context.Background(), &models.DeleteExpiredVersionsCommand{}))
+
+		query := models.GetDashboardEventsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardEvents(context.Background(), &query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, 3, query.Result[1].Version)
+	})
+
+	t.Run("should delete the events of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+
+		query := models.GetDashboardEventsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardEvents(context.Background(), &query))
+		assert.Empty(t, query.Result)
+	})
+}
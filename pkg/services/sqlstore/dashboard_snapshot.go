@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -11,22 +12,19 @@ import (
 // DeleteExpiredSnapshots removes snapshots with old expiry dates.
 // SnapShotRemoveExpired is deprecated and should be removed in the future.
 // Snapshot expiry is decided by the user when they share the snapshot.
-func (ss *SQLStore) DeleteExpiredSnapshots(cmd *models.DeleteExpiredSnapshotsCommand) error {
-	return inTransaction(func(sess *DBSession) error {
-		if !setting.SnapShotRemoveExpired {
-			sqlog.Warn("[Deprecated] The snapshot_remove_expired setting is outdated. Please remove from your config.")
-			return nil
-		}
-
-		deleteExpiredSQL := "DELETE FROM dashboard_snapshot WHERE expires < ?"
-		expiredResponse, err := sess.Exec(deleteExpiredSQL, time.Now())
-		if err != nil {
-			return err
-		}
-		cmd.DeletedRows, _ = expiredResponse.RowsAffected()
-
+func (ss *SQLStore) DeleteExpiredSnapshots(ctx context.Context, cmd *models.DeleteExpiredSnapshotsCommand) error {
+	if !setting.SnapShotRemoveExpired {
+		sqlog.Warn("[Deprecated] The snapshot_remove_expired setting is outdated. Please remove from your config.")
 		return nil
-	})
+	}
+
+	expiredBefore := cmd.ExpiredBefore
+	if expiredBefore.IsZero() {
+		expiredBefore = time.Now()
+	}
+	deleted, err := deleteInBatches(ctx, "dashboard_snapshot", "expires < ?", cmd.BatchSize, expiredBefore)
+	cmd.DeletedRows = deleted
+	return err
 }
 
 func (ss *SQLStore) CreateDashboardSnapshot(cmd *models.CreateDashboardSnapshotCommand) error {
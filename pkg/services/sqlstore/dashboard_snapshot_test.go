@@ -154,7 +154,7 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 		createTestSnapshot(t, sqlstore, "key2", -1200)
 		createTestSnapshot(t, sqlstore, "key3", -1200)
 
-		err := sqlstore.DeleteExpiredSnapshots(&models.DeleteExpiredSnapshotsCommand{})
+		err := sqlstore.DeleteExpiredSnapshots(context.Background(), &models.DeleteExpiredSnapshotsCommand{})
 		require.NoError(t, err)
 
 		query := models.GetDashboardSnapshotsQuery{
@@ -167,7 +167,7 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 		assert.Len(t, query.Result, 1)
 		assert.Equal(t, nonExpiredSnapshot.Key, query.Result[0].Key)
 
-		err = sqlstore.DeleteExpiredSnapshots(&models.DeleteExpiredSnapshotsCommand{})
+		err = sqlstore.DeleteExpiredSnapshots(context.Background(), &models.DeleteExpiredSnapshotsCommand{})
 		require.NoError(t, err)
 
 		query = models.GetDashboardSnapshotsQuery{
@@ -180,6 +180,28 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 		require.Len(t, query.Result, 1)
 		require.Equal(t, nonExpiredSnapshot.Key, query.Result[0].Key)
 	})
+
+	t.Run("Testing dashboard snapshots clean up in batches with a retention", func(t *testing.T) {
+		setting.SnapShotRemoveExpired = true
+
+		createTestSnapshot(t, sqlstore, "key4", -1200)
+		createTestSnapshot(t, sqlstore, "key5", -1200)
+		createTestSnapshot(t, sqlstore, "key6", -7200)
+		createTestSnapshot(t, sqlstore, "key7", -7200)
+
+		cmd := models.DeleteExpiredSnapshotsCommand{ExpiredBefore: time.Now().Add(-time.Hour), BatchSize: 1}
+		err := sqlstore.DeleteExpiredSnapshots(context.Background(), &cmd)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), cmd.DeletedRows)
+
+		query := models.GetDashboardSnapshotsQuery{
+			OrgId:        1,
+			SignedInUser: &models.SignedInUser{OrgRole: models.ROLE_ADMIN},
+		}
+		err = sqlstore.SearchDashboardSnapshots(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 3)
+	})
 }
 
 func createTestSnapshot(t *testing.T, sqlstore *SQLStore, key string, expires int64) *models.DashboardSnapshot {
@@ -0,0 +1,376 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/dashboardblob"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+	"xorm.io/xorm"
+)
+
+// dashboardBlobKey is the field of the stub kept in the data column in place of the JSON models offloaded
+// to the blob storage, it holds the key of the blob
+const dashboardBlobKey = "__blob"
+
+// EncryptedDashboardKey is the field of the stub kept in the data column in place of the encrypted JSON models, it
+// holds the base64 encoded encrypted JSON model
+const EncryptedDashboardKey = "__encrypted"
+
+// dashboardDataStorage persists the JSON models of the dashboards and their versions. What is returned by encode is
+// kept in the data column of the dashboard and dashboard_version tables.
+type dashboardDataStorage interface {
+	// encode returns what to keep in the data column for the current version of the dashboard, the session is the
+	// transaction saving the dashboard
+	encode(ctx context.Context, sess *DBSession, dash *models.Dashboard) (*simplejson.Json, error)
+	// decode returns the JSON model of a dashboard from what has been kept in the data column
+	decode(ctx context.Context, data *simplejson.Json) (*simplejson.Json, error)
+	// external returns true when JSON models can be stored outside of the data columns
+	external() bool
+	// release deletes what has been stored outside of the data columns, once the rows they were kept in are deleted
+	release(ctx context.Context, data ...*simplejson.Json) error
+}
+
+// dashboardStorage is temporarily a global var, as the dashboard queries are still bus handlers without access
+// to the SQL store
+var dashboardStorage dashboardDataStorage = columnDashboardStorage{}
+
+func newDashboardDataStorage(cfg setting.DashboardStorageSettings, d migrator.Dialect) (dashboardDataStorage, error) {
+	if cfg.Type == setting.DashboardStorageJSONB && d.DriverName() != migrator.Postgres {
+		return nil, fmt.Errorf("dashboard storage type %q requires PostgreSQL, got %s", cfg.Type, d.DriverName())
+	}
+	if !cfg.BlobStorageEnabled() {
+		return columnDashboardStorage{}, nil
+	}
+	return &blobDashboardStorage{threshold: cfg.BlobThreshold, blobs: dashboardblob.New(cfg)}, nil
+}
+
+// columnDashboardStorage keeps the JSON models in the data column, as text, or as JSONB when the column has
+// been converted on PostgreSQL
+type columnDashboardStorage struct{}
+
+func (columnDashboardStorage) encode(_ context.Context, _ *DBSession, dash *models.Dashboard) (*simplejson.Json, error) {
+	return dash.Data, nil
+}
+
+func (columnDashboardStorage) decode(_ context.Context, data *simplejson.Json) (*simplejson.Json, error) {
+	return data, nil
+}
+
+func (columnDashboardStorage) external() bool {
+	return false
+}
+
+func (columnDashboardStorage) release(context.Context, ...*simplejson.Json) error {
+	return nil
+}
+
+// blobDashboardStorage offloads the JSON models larger than the threshold to the blob storage, a stub referencing
+// the blob is kept in the data column. Each version is stored in its own blob so that the blobs are deleted along
+// with the dashboard versions they belong to.
+type blobDashboardStorage struct {
+	threshold int64
+	blobs     dashboardblob.Storage
+}
+
+func (s *blobDashboardStorage) encode(ctx context.Context, _ *DBSession, dash *models.Dashboard) (*simplejson.Json, error) {
+	if dash.IsFolder {
+		return dash.Data, nil
+	}
+
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) <= s.threshold {
+		return dash.Data, nil
+	}
+
+	key := fmt.Sprintf("%d/%s/%d.json", dash.OrgId, dash.Uid, dash.Version)
+	if err := s.blobs.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("failed to offload dashboard to blob storage: %w", err)
+	}
+
+	stub := simplejson.NewFromAny(map[string]interface{}{
+		dashboardBlobKey: key,
+		"uid":            dash.Uid,
+		"title":          dash.Title,
+		"version":        dash.Version,
+	})
+	return stub, nil
+}
+
+func (s *blobDashboardStorage) decode(ctx context.Context, data *simplejson.Json) (*simplejson.Json, error) {
+	key := blobKey(data)
+	if key == "" {
+		return data, nil
+	}
+
+	blob, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboard %q from blob storage: %w", key, err)
+	}
+	return simplejson.NewJson(blob)
+}
+
+func (s *blobDashboardStorage) external() bool {
+	return true
+}
+
+func (s *blobDashboardStorage) release(ctx context.Context, data ...*simplejson.Json) error {
+	keys := make([]string, 0, len(data))
+	for _, d := range data {
+		if key := blobKey(d); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return s.blobs.Delete(ctx, keys...)
+}
+
+// DashboardEncryption encrypts the JSON models of the dashboards of the organizations requiring it.
+type DashboardEncryption interface {
+	// EncryptDashboard returns the encrypted JSON model of a dashboard of the organization, or false when the
+	// dashboards of the organization are not encrypted. The session is the transaction saving the dashboard.
+	EncryptDashboard(ctx context.Context, sess *xorm.Session, orgID int64, data []byte) ([]byte, bool, error)
+	DecryptDashboard(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// RegisterDashboardEncryption encrypts the JSON models of the dashboards saved from now on, for the organizations
+// requiring it. The encrypted JSON models are kept in the data column, whatever the dashboard storage.
+func (ss *SQLStore) RegisterDashboardEncryption(encryption DashboardEncryption) {
+	storage := dashboardStorage
+	if encrypted, ok := storage.(*encryptedDashboardStorage); ok {
+		storage = encrypted.dashboardDataStorage
+	}
+	dashboardStorage = &encryptedDashboardStorage{dashboardDataStorage: storage, encryption: encryption}
+}
+
+// encryptedDashboardStorage keeps a stub holding the encrypted JSON model in the data column of the dashboards
+// which are encrypted, the other dashboards are kept by the wrapped storage
+type encryptedDashboardStorage struct {
+	dashboardDataStorage
+	encryption DashboardEncryption
+}
+
+func (s *encryptedDashboardStorage) encode(ctx context.Context, sess *DBSession, dash *models.Dashboard) (*simplejson.Json, error) {
+	if dash.IsFolder {
+		return s.dashboardDataStorage.encode(ctx, sess, dash)
+	}
+
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, ok, err := s.encryption.EncryptDashboard(ctx, sess.Session, dash.OrgId, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt dashboard: %w", err)
+	}
+	if !ok {
+		return s.dashboardDataStorage.encode(ctx, sess, dash)
+	}
+
+	stub := simplejson.NewFromAny(map[string]interface{}{
+		EncryptedDashboardKey: base64.StdEncoding.EncodeToString(encrypted),
+		"uid":                 dash.Uid,
+		"version":             dash.Version,
+	})
+	return stub, nil
+}
+
+func (s *encryptedDashboardStorage) decode(ctx context.Context, data *simplejson.Json) (*simplejson.Json, error) {
+	if data == nil {
+		return s.dashboardDataStorage.decode(ctx, data)
+	}
+	encoded := data.Get(EncryptedDashboardKey).MustString()
+	if encoded == "" {
+		return s.dashboardDataStorage.decode(ctx, data)
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := s.encryption.DecryptDashboard(ctx, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt dashboard: %w", err)
+	}
+	return simplejson.NewJson(decrypted)
+}
+
+// dashboardEntityKey is the field of the stub kept in the data column in place of the JSON models kept by the entity
+// store, it holds the kind of the entity
+const dashboardEntityKey = "__entity"
+
+// DashboardEntities keeps the JSON models of the dashboards and folders, and of each of their versions, outside of
+// the database tables. The kind is dashboard or folder. The context of WriteDashboard holds the transaction saving
+// the dashboard.
+type DashboardEntities interface {
+	WriteDashboard(ctx context.Context, orgID int64, kind string, uid string, version int64, data []byte) error
+	ReadDashboard(ctx context.Context, orgID int64, kind string, uid string, version int64) ([]byte, error)
+	DeleteDashboard(ctx context.Context, orgID int64, kind string, uid string, versions ...int64) error
+}
+
+// RegisterDashboardEntities keeps the JSON models of the dashboards and folders saved from now on by the entity
+// store, the JSON models saved before are still read from the dashboard storage. The encrypted JSON models are kept
+// in the data column.
+func (ss *SQLStore) RegisterDashboardEntities(entities DashboardEntities) {
+	encrypted, isEncrypted := dashboardStorage.(*encryptedDashboardStorage)
+	storage := dashboardStorage
+	if isEncrypted {
+		storage = encrypted.dashboardDataStorage
+	}
+	if existing, ok := storage.(*entityDashboardStorage); ok {
+		storage = existing.fallback
+	}
+
+	storage = &entityDashboardStorage{entities: entities, fallback: storage}
+	if isEncrypted {
+		encrypted.dashboardDataStorage = storage
+		return
+	}
+	dashboardStorage = storage
+}
+
+// entityDashboardStorage keeps a stub referencing the entity in the data column of the dashboards and folders, the
+// stubs it does not know are decoded and released by the fallback storage
+type entityDashboardStorage struct {
+	entities DashboardEntities
+	fallback dashboardDataStorage
+}
+
+type dashboardEntityRef struct {
+	orgID   int64
+	kind    string
+	uid     string
+	version int64
+}
+
+func entityRef(data *simplejson.Json) (dashboardEntityRef, bool) {
+	if data == nil {
+		return dashboardEntityRef{}, false
+	}
+	kind := data.Get(dashboardEntityKey).MustString()
+	if kind == "" {
+		return dashboardEntityRef{}, false
+	}
+	return dashboardEntityRef{
+		orgID:   data.Get("orgId").MustInt64(),
+		kind:    kind,
+		uid:     data.Get("uid").MustString(),
+		version: data.Get("version").MustInt64(),
+	}, true
+}
+
+func (s *entityDashboardStorage) encode(ctx context.Context, sess *DBSession, dash *models.Dashboard) (*simplejson.Json, error) {
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	kind := "dashboard"
+	if dash.IsFolder {
+		kind = "folder"
+	}
+
+	ctx = context.WithValue(ctx, ContextSessionKey{}, sess)
+	if err := s.entities.WriteDashboard(ctx, dash.OrgId, kind, dash.Uid, int64(dash.Version), data); err != nil {
+		return nil, fmt.Errorf("failed to write dashboard to the entity store: %w", err)
+	}
+
+	stub := simplejson.NewFromAny(map[string]interface{}{
+		dashboardEntityKey: kind,
+		"orgId":            dash.OrgId,
+		"uid":              dash.Uid,
+		"title":            dash.Title,
+		"version":          dash.Version,
+	})
+	return stub, nil
+}
+
+func (s *entityDashboardStorage) decode(ctx context.Context, data *simplejson.Json) (*simplejson.Json, error) {
+	ref, ok := entityRef(data)
+	if !ok {
+		return s.fallback.decode(ctx, data)
+	}
+
+	body, err := s.entities.ReadDashboard(ctx, ref.orgID, ref.kind, ref.uid, ref.version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dashboard %s from the entity store: %w", ref.uid, err)
+	}
+	return simplejson.NewJson(body)
+}
+
+func (s *entityDashboardStorage) external() bool {
+	return true
+}
+
+func (s *entityDashboardStorage) release(ctx context.Context, data ...*simplejson.Json) error {
+	others := make([]*simplejson.Json, 0)
+	for _, d := range data {
+		ref, ok := entityRef(d)
+		if !ok {
+			others = append(others, d)
+			continue
+		}
+		if err := s.entities.DeleteDashboard(ctx, ref.orgID, ref.kind, ref.uid, ref.version); err != nil {
+			return err
+		}
+	}
+	if len(others) == 0 || !s.fallback.external() {
+		return nil
+	}
+	return s.fallback.release(ctx, others...)
+}
+
+// blobKey returns the key of the blob the data refers to, or an empty string for data kept in the data column
+func blobKey(data *simplejson.Json) string {
+	if data == nil {
+		return ""
+	}
+	return data.Get(dashboardBlobKey).MustString()
+}
+
+// decodeDashboards replaces the data of the dashboards loaded from the database with their JSON models
+func decodeDashboards(ctx context.Context, dashboards ...*models.Dashboard) error {
+	for _, dash := range dashboards {
+		data, err := dashboardStorage.decode(ctx, dash.Data)
+		if err != nil {
+			return err
+		}
+		dash.Data = data
+	}
+	return nil
+}
+
+// externalDashboardVersionsData returns the data of the versions matching the condition, so that what they store
+// outside of the data columns can be released once they are deleted. Nothing is returned when the dashboard storage
+// keeps everything in the data columns.
+func externalDashboardVersionsData(sess *DBSession, query string, args ...interface{}) ([]*simplejson.Json, error) {
+	if !dashboardStorage.external() {
+		return nil, nil
+	}
+
+	versions := make([]*models.DashboardVersion, 0)
+	if err := sess.Table("dashboard_version").Cols("data").Where(query, args...).Find(&versions); err != nil {
+		return nil, err
+	}
+	data := make([]*simplejson.Json, 0, len(versions))
+	for _, v := range versions {
+		data = append(data, v.Data)
+	}
+	return data, nil
+}
+
+// releaseDashboardData deletes what the deleted dashboard versions stored outside of the data columns. The versions
+// are deleted already, failing to release their data is only logged.
+func releaseDashboardData(ctx context.Context, data []*simplejson.Json) {
+	if len(data) == 0 {
+		return
+	}
+	if err := dashboardStorage.release(ctx, data...); err != nil {
+		sqlog.Warn("Failed to release the data of deleted dashboard versions", "error", err)
+	}
+}
@@ -0,0 +1,277 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/dashboardblob"
+	"github.com/grafana/grafana/pkg/setting"
+	"xorm.io/xorm"
+)
+
+func TestDashboardBlobStorage(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	blobs := dashboardblob.New(setting.DashboardStorageSettings{BlobStorage: setting.DashboardBlobStorageLocal, BlobPath: t.TempDir()})
+	previous := dashboardStorage
+	dashboardStorage = &blobDashboardStorage{threshold: 1024, blobs: blobs}
+	t.Cleanup(func() {
+		dashboardStorage = previous
+	})
+
+	large := strings.Repeat("a", 2048)
+	cmd := models.SaveDashboardCommand{
+		OrgId: 1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title":       "Large",
+			"description": large,
+		}),
+	}
+	dash, err := sqlStore.SaveDashboard(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, large, dash.Data.Get("description").MustString())
+
+	small, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Small"}),
+	})
+	require.NoError(t, err)
+
+	storedData := func(id int64) *simplejson.Json {
+		var stored models.Dashboard
+		_, err := sqlStore.engine.ID(id).Get(&stored)
+		require.NoError(t, err)
+		return stored.Data
+	}
+
+	t.Run("should offload large dashboards to the blob storage", func(t *testing.T) {
+		stored := storedData(dash.Id)
+		key := stored.Get(dashboardBlobKey).MustString()
+		assert.Equal(t, "1/"+dash.Uid+"/1.json", key)
+		assert.Equal(t, "Large", stored.Get("title").MustString())
+		_, err := blobs.Get(context.Background(), key)
+		require.NoError(t, err)
+
+		assert.Empty(t, storedData(small.Id).Get(dashboardBlobKey).MustString())
+	})
+
+	t.Run("should load offloaded dashboards and versions", func(t *testing.T) {
+		query := models.GetDashboardQuery{OrgId: 1, Uid: dash.Uid}
+		require.NoError(t, GetDashboard(context.Background(), &query))
+		assert.Equal(t, large, query.Result.Data.Get("description").MustString())
+
+		loaded, err := sqlStore.GetDashboard(dash.Id, 1, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, large, loaded.Data.Get("description").MustString())
+
+		dashboards := models.GetDashboardsQuery{DashboardIds: []int64{dash.Id, small.Id}}
+		require.NoError(t, GetDashboards(context.Background(), &dashboards))
+		require.Len(t, dashboards.Result, 2)
+		for _, d := range dashboards.Result {
+			assert.NotEmpty(t, d.Data.Get("title").MustString())
+			assert.Empty(t, d.Data.Get(dashboardBlobKey).MustString())
+		}
+
+		version := models.GetDashboardVersionQuery{OrgId: 1, DashboardId: dash.Id, Version: 1}
+		require.NoError(t, sqlStore.GetDashboardVersion(context.Background(), &version))
+		assert.Equal(t, large, version.Result.Data.Get("description").MustString())
+	})
+
+	t.Run("should store each version in its own blob", func(t *testing.T) {
+		dash.Data.Set("id", dash.Id)
+		cmd.Dashboard = dash.Data
+		updated, err := sqlStore.SaveDashboard(cmd)
+		require.NoError(t, err)
+		assert.Equal(t, "1/"+dash.Uid+"/2.json", storedData(updated.Id).Get(dashboardBlobKey).MustString())
+
+		_, err = blobs.Get(context.Background(), "1/"+dash.Uid+"/1.json")
+		require.NoError(t, err)
+	})
+
+	t.Run("should release the blobs of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+
+		for _, key := range []string{"1/" + dash.Uid + "/1.json", "1/" + dash.Uid + "/2.json"} {
+			_, err := blobs.Get(context.Background(), key)
+			assert.ErrorIs(t, err, dashboardblob.ErrBlobNotFound)
+		}
+	})
+}
+
+// fakeDashboardEncryption encrypts the dashboards of org 2 by reversing their JSON models
+type fakeDashboardEncryption struct{}
+
+func (fakeDashboardEncryption) EncryptDashboard(_ context.Context, _ *xorm.Session, orgID int64, data []byte) ([]byte, bool, error) {
+	if orgID != 2 {
+		return nil, false, nil
+	}
+	return reverse(data), true, nil
+}
+
+func (fakeDashboardEncryption) DecryptDashboard(_ context.Context, data []byte) ([]byte, error) {
+	return reverse(data), nil
+}
+
+func reverse(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+	return reversed
+}
+
+func TestDashboardEncryption(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	previous := dashboardStorage
+	sqlStore.RegisterDashboardEncryption(fakeDashboardEncryption{})
+	t.Cleanup(func() {
+		dashboardStorage = previous
+	})
+
+	save := func(t *testing.T, orgID int64) *models.Dashboard {
+		t.Helper()
+		dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     orgID,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Secret", "description": "confidential"}),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+	storedData := func(id int64) *simplejson.Json {
+		var stored models.Dashboard
+		_, err := sqlStore.engine.ID(id).Get(&stored)
+		require.NoError(t, err)
+		return stored.Data
+	}
+
+	encrypted := save(t, 2)
+	plain := save(t, 1)
+
+	t.Run("should keep the encrypted JSON models of the organizations requiring it", func(t *testing.T) {
+		stored := storedData(encrypted.Id)
+		assert.NotEmpty(t, stored.Get(EncryptedDashboardKey).MustString())
+		assert.Empty(t, stored.Get("description").MustString())
+		assert.Equal(t, "confidential", encrypted.Data.Get("description").MustString())
+
+		assert.Equal(t, "confidential", storedData(plain.Id).Get("description").MustString())
+	})
+
+	t.Run("should decrypt the dashboards and versions", func(t *testing.T) {
+		query := models.GetDashboardQuery{OrgId: 2, Uid: encrypted.Uid}
+		require.NoError(t, GetDashboard(context.Background(), &query))
+		assert.Equal(t, "confidential", query.Result.Data.Get("description").MustString())
+
+		version := models.GetDashboardVersionQuery{OrgId: 2, DashboardId: encrypted.Id, Version: 1}
+		require.NoError(t, sqlStore.GetDashboardVersion(context.Background(), &version))
+		assert.Equal(t, "confidential", version.Result.Data.Get("description").MustString())
+	})
+}
+
+// fakeDashboardEntities keeps the JSON models in a map, and checks that the writes are in the saving transaction
+type fakeDashboardEntities struct {
+	bodies map[string][]byte
+}
+
+func (f *fakeDashboardEntities) key(orgID int64, kind string, uid string, version int64) string {
+	return fmt.Sprintf("%d/%s/%s/%d", orgID, kind, uid, version)
+}
+
+func (f *fakeDashboardEntities) WriteDashboard(ctx context.Context, orgID int64, kind string, uid string, version int64, data []byte) error {
+	if _, ok := ctx.Value(ContextSessionKey{}).(*DBSession); !ok {
+		return errors.New("no session in the context")
+	}
+	f.bodies[f.key(orgID, kind, uid, version)] = data
+	return nil
+}
+
+func (f *fakeDashboardEntities) ReadDashboard(_ context.Context, orgID int64, kind string, uid string, version int64) ([]byte, error) {
+	body, ok := f.bodies[f.key(orgID, kind, uid, version)]
+	if !ok {
+		return nil, errors.New("entity not found")
+	}
+	return body, nil
+}
+
+func (f *fakeDashboardEntities) DeleteDashboard(_ context.Context, orgID int64, kind string, uid string, versions ...int64) error {
+	for _, version := range versions {
+		delete(f.bodies, f.key(orgID, kind, uid, version))
+	}
+	return nil
+}
+
+func TestDashboardEntityStorage(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	previous := dashboardStorage
+	t.Cleanup(func() {
+		dashboardStorage = previous
+	})
+
+	legacy, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Legacy", "description": "in the data column"}),
+	})
+	require.NoError(t, err)
+
+	entities := &fakeDashboardEntities{bodies: map[string][]byte{}}
+	sqlStore.RegisterDashboardEntities(entities)
+
+	cmd := models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Entity", "description": "in the entity store"}),
+	}
+	dash, err := sqlStore.SaveDashboard(cmd)
+	require.NoError(t, err)
+	folder, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Folder"}),
+	})
+	require.NoError(t, err)
+
+	t.Run("should keep a stub in the data column", func(t *testing.T) {
+		var stored models.Dashboard
+		_, err := sqlStore.engine.ID(dash.Id).Get(&stored)
+		require.NoError(t, err)
+		assert.Equal(t, "dashboard", stored.Data.Get(dashboardEntityKey).MustString())
+		assert.Equal(t, "Entity", stored.Data.Get("title").MustString())
+		assert.Empty(t, stored.Data.Get("description").MustString())
+		assert.Contains(t, entities.bodies, "1/dashboard/"+dash.Uid+"/1")
+		assert.Contains(t, entities.bodies, "1/folder/"+folder.Uid+"/1")
+	})
+
+	t.Run("should load the dashboards from the entity store and the data column", func(t *testing.T) {
+		query := models.GetDashboardQuery{OrgId: 1, Uid: dash.Uid}
+		require.NoError(t, GetDashboard(context.Background(), &query))
+		assert.Equal(t, "in the entity store", query.Result.Data.Get("description").MustString())
+
+		query = models.GetDashboardQuery{OrgId: 1, Uid: legacy.Uid}
+		require.NoError(t, GetDashboard(context.Background(), &query))
+		assert.Equal(t, "in the data column", query.Result.Data.Get("description").MustString())
+
+		version := models.GetDashboardVersionQuery{OrgId: 1, DashboardId: dash.Id, Version: 1}
+		require.NoError(t, sqlStore.GetDashboardVersion(context.Background(), &version))
+		assert.Equal(t, "in the entity store", version.Result.Data.Get("description").MustString())
+	})
+
+	t.Run("should delete the entities of deleted dashboards", func(t *testing.T) {
+		dash.Data.Set("id", dash.Id)
+		cmd.Dashboard = dash.Data
+		_, err := sqlStore.SaveDashboard(cmd)
+		require.NoError(t, err)
+		assert.Contains(t, entities.bodies, "1/dashboard/"+dash.Uid+"/2")
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+		assert.NotContains(t, entities.bodies, "1/dashboard/"+dash.Uid+"/1")
+		assert.NotContains(t, entities.bodies, "1/dashboard/"+dash.Uid+"/2")
+	})
+}
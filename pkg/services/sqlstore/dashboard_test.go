@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -315,6 +317,33 @@ func TestDashboardDataAccess(t *testing.T) {
 		require.Equal(t, hit.FolderTitle, "")
 	})
 
+	t.Run("Should be able to filter search by access control permissions", func(t *testing.T) {
+		setup()
+		user := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR, Permissions: map[int64]map[string][]string{1: {
+			accesscontrol.ActionDashboardsRead: {fmt.Sprintf("folders:id:%d", savedFolder.Id), fmt.Sprintf("dashboards:id:%d", savedDash2.Id)},
+		}}}
+		query := search.FindPersistedDashboardsQuery{
+			OrgId:        1,
+			SignedInUser: user,
+			Filters:      []interface{}{permissions.AccessControlDashboardFilter{User: user}},
+		}
+
+		err := sqlStore.SearchDashboards(context.Background(), &query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 4)
+
+		user.Permissions[1][accesscontrol.ActionDashboardsRead] = []string{fmt.Sprintf("dashboards:id:%d", savedDash.Id)}
+		err = sqlStore.SearchDashboards(context.Background(), &query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		require.Equal(t, savedDash.Id, query.Result[0].ID)
+
+		user.Permissions[1][accesscontrol.ActionDashboardsRead] = nil
+		err = sqlStore.SearchDashboards(context.Background(), &query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 0)
+	})
+
 	t.Run("Should be able to limit search", func(t *testing.T) {
 		setup()
 		query := search.FindPersistedDashboardsQuery{
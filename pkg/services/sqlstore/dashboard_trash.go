@@ -0,0 +1,230 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// trashDeletedDashboards is temporarily a global var like the dashboard storage, as the dashboards are still deleted
+// by bus handlers without access to the SQL store
+var trashDeletedDashboards = false
+
+func (ss *SQLStore) addDashboardTrashQueryAndCommandHandlers() {
+	bus.AddHandler("sql", ss.DeleteExpiredDashboardTrash)
+}
+
+// moveDashboardsToTrash keeps a copy of the dashboard being deleted with its permissions in the trash of its
+// organization. A folder being deleted is kept with its permissions and data sources, along with each of its
+// dashboards.
+func moveDashboardsToTrash(sess *DBSession, dashboard *models.Dashboard, deletedBy int64) error {
+	dashboards := []*models.Dashboard{dashboard}
+	folder := &models.Dashboard{}
+	var datasourceUIDs []string
+	if dashboard.IsFolder {
+		if err := sess.Table("folder_datasource").Where("org_id = ? AND folder_id = ?", dashboard.OrgId, dashboard.Id).
+			Asc("datasource_uid").Cols("datasource_uid").Find(&datasourceUIDs); err != nil {
+			return err
+		}
+		if err := sess.Where("org_id = ? AND folder_id = ?", dashboard.OrgId, dashboard.Id).Find(&dashboards); err != nil {
+			return err
+		}
+	} else if dashboard.FolderId > 0 {
+		if _, err := sess.Where("org_id = ? AND id = ?", dashboard.OrgId, dashboard.FolderId).Get(folder); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+	deleted := time.Now()
+	for _, dash := range dashboards {
+		data, err := dashboardStorage.decode(ctx, dash.Data)
+		if err != nil {
+			return err
+		}
+
+		acl := make([]*models.DashboardAcl, 0)
+		if err := sess.Where("dashboard_id = ?", dash.Id).Find(&acl); err != nil {
+			return err
+		}
+
+		entry := &models.DashboardTrashEntry{
+			OrgId:       dash.OrgId,
+			DashboardId: dash.Id,
+			Uid:         dash.Uid,
+			Title:       dash.Title,
+			IsFolder:    dash.IsFolder,
+			Data:        data,
+			HasAcl:      dash.HasAcl,
+			Acl:         make([]*models.DashboardTrashPermission, 0, len(acl)),
+			DeletedBy:   deletedBy,
+			Deleted:     deleted,
+		}
+		if dash.IsFolder {
+			entry.DatasourceUids = datasourceUIDs
+		} else if dashboard.IsFolder {
+			entry.FolderUid, entry.FolderTitle = dashboard.Uid, dashboard.Title
+		} else {
+			entry.FolderUid, entry.FolderTitle = folder.Uid, folder.Title
+		}
+		for _, item := range acl {
+			entry.Acl = append(entry.Acl, &models.DashboardTrashPermission{
+				UserID:     item.UserID,
+				TeamID:     item.TeamID,
+				Role:       item.Role,
+				Permission: item.Permission,
+			})
+		}
+		if _, err := sess.Insert(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDashboardTrash lists the deleted dashboards of an organization which can still be restored
+func (ss *SQLStore) GetDashboardTrash(ctx context.Context, query *models.GetDashboardTrashQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		query.Result = make([]*models.DashboardTrashEntryDTO, 0)
+		sess.Table("dashboard_trash").
+			Select(`dashboard_trash.id, dashboard_trash.dashboard_id, dashboard_trash.uid, dashboard_trash.title,
+				dashboard_trash.is_folder, dashboard_trash.folder_uid, dashboard_trash.folder_title, dashboard_trash.deleted_by,
+				dashboard_trash.deleted, usr.login AS deleted_by_login`).
+			Join("LEFT", dialect.Quote("user")+" AS usr", "usr.id = dashboard_trash.deleted_by").
+			Where("dashboard_trash.org_id = ?", query.OrgId)
+		if query.DeletedBy != 0 {
+			sess.And("dashboard_trash.deleted_by = ?", query.DeletedBy)
+		}
+		return sess.OrderBy("dashboard_trash.deleted DESC, dashboard_trash.id DESC").Find(&query.Result)
+	})
+}
+
+// GetDashboardTrashEntry returns a deleted dashboard or folder of the organization
+func (ss *SQLStore) GetDashboardTrashEntry(ctx context.Context, orgID, id int64) (*models.DashboardTrashEntry, error) {
+	entry := &models.DashboardTrashEntry{}
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		has, err := sess.Where("id = ? AND org_id = ?", id, orgID).Get(entry)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardTrashEntryNotFound
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// GetFolderTrashEntry returns the most recently deleted folder of the organization with the uid
+func (ss *SQLStore) GetFolderTrashEntry(ctx context.Context, orgID int64, uid string) (*models.DashboardTrashEntry, error) {
+	entry := &models.DashboardTrashEntry{}
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		has, err := sess.Where("org_id = ? AND uid = ? AND is_folder = "+dialect.BooleanStr(true), orgID, uid).
+			Desc("deleted", "id").Get(entry)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardTrashEntryNotFound
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// RestoreDashboardTrashEntry saves a deleted dashboard or folder again with cmd, validated by the dashboard service,
+// and restores its permissions, except the ones of the users and teams deleted since, and the data sources of a
+// folder. The entry is removed from the trash within the same transaction.
+func (ss *SQLStore) RestoreDashboardTrashEntry(ctx context.Context, cmd models.SaveDashboardCommand, entry *models.DashboardTrashEntry) (*models.Dashboard, error) {
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		// the entry may have been restored or deleted since it was read
+		affected, err := sess.Where("id = ? AND org_id = ?", entry.Id, entry.OrgId).Delete(&models.DashboardTrashEntry{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return models.ErrDashboardTrashEntryNotFound
+		}
+
+		if err := saveDashboard(sess, &cmd); err != nil {
+			return err
+		}
+		dash := cmd.Result
+
+		for _, uid := range entry.DatasourceUids {
+			item := models.FolderDataSource{OrgId: dash.OrgId, FolderId: dash.Id, DatasourceUid: uid, Created: time.Now()}
+			if _, err := sess.Table("folder_datasource").Insert(&item); err != nil {
+				return err
+			}
+		}
+
+		if !entry.HasAcl {
+			return nil
+		}
+		items := make([]*models.DashboardAcl, 0, len(entry.Acl))
+		for _, p := range entry.Acl {
+			if p.UserID > 0 {
+				if exists, err := sess.Table("user").Where("id = ?", p.UserID).Exist(); err != nil || !exists {
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if p.TeamID > 0 {
+				if exists, err := sess.Table("team").Where("id = ? AND org_id = ?", p.TeamID, dash.OrgId).Exist(); err != nil || !exists {
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			item := &models.DashboardAcl{
+				OrgID:       dash.OrgId,
+				DashboardID: dash.Id,
+				UserID:      p.UserID,
+				TeamID:      p.TeamID,
+				Role:        p.Role,
+				Permission:  p.Permission,
+				Created:     time.Now(),
+				Updated:     time.Now(),
+			}
+			sess.Nullable("user_id", "team_id")
+			if _, err := sess.Insert(item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+
+		if _, err := sess.Cols("has_acl").Where("id = ?", dash.Id).Update(&models.Dashboard{HasAcl: true}); err != nil {
+			return err
+		}
+		dash.HasAcl = true
+		return syncManagedDashboardPermissions(sess, dash.Id, items)
+	})
+	return cmd.Result, err
+}
+
+// DeleteDashboardTrashEntry deletes a deleted dashboard for good
+func (ss *SQLStore) DeleteDashboardTrashEntry(ctx context.Context, cmd *models.DeleteDashboardTrashEntryCommand) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		affected, err := sess.Where("id = ? AND org_id = ?", cmd.Id, cmd.OrgId).Delete(&models.DashboardTrashEntry{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return models.ErrDashboardTrashEntryNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteExpiredDashboardTrash deletes for good the dashboards deleted before cmd.DeletedBefore
+func (ss *SQLStore) DeleteExpiredDashboardTrash(ctx context.Context, cmd *models.DeleteExpiredDashboardTrashCommand) error {
+	deleted, err := deleteInBatches(ctx, "dashboard_trash", "deleted <= ?", cmd.BatchSize, cmd.DeletedBefore)
+	cmd.DeletedRows = deleted
+	return err
+}
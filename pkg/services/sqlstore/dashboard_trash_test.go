@@ -0,0 +1,189 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDashboardTrash(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := InitTestDB(t)
+	previous := trashDeletedDashboards
+	trashDeletedDashboards = true
+	t.Cleanup(func() {
+		trashDeletedDashboards = previous
+	})
+
+	editor := createUser(t, sqlStore, "editor", "Editor", false)
+	viewer := createUser(t, sqlStore, "viewer", "Viewer", false)
+	team, err := sqlStore.CreateTeam("team", "", 1)
+	require.NoError(t, err)
+
+	folder := insertTestDashboard(t, sqlStore, "Folder", 1, 0, true)
+	dash := insertTestDashboard(t, sqlStore, "Trashed", 1, folder.Id, false, "prod")
+	require.NoError(t, testHelperUpdateDashboardAcl(t, sqlStore, dash.Id,
+		models.DashboardAcl{OrgID: 1, DashboardID: dash.Id, UserID: viewer.Id, Permission: models.PERMISSION_EDIT},
+		models.DashboardAcl{OrgID: 1, DashboardID: dash.Id, TeamID: team.Id, Permission: models.PERMISSION_VIEW},
+	))
+	other := insertTestDashboard(t, sqlStore, "Other", 1, 0, false)
+
+	require.NoError(t, DeleteDashboard(ctx, &models.DeleteDashboardCommand{
+		Id: dash.Id, OrgId: 1, MoveToTrash: true, DeletedBy: editor.Id,
+	}))
+	require.NoError(t, DeleteDashboard(ctx, &models.DeleteDashboardCommand{Id: other.Id, OrgId: 1}))
+
+	t.Run("should list the dashboards deleted by the users", func(t *testing.T) {
+		query := models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardTrash(ctx, &query))
+		require.Len(t, query.Result, 1)
+		entry := query.Result[0]
+		assert.Equal(t, dash.Uid, entry.Uid)
+		assert.Equal(t, "Trashed", entry.Title)
+		assert.Equal(t, folder.Uid, entry.FolderUid)
+		assert.Equal(t, "Folder", entry.FolderTitle)
+		assert.Equal(t, "editor", entry.DeletedByLogin)
+
+		query = models.GetDashboardTrashQuery{OrgId: 1, DeletedBy: viewer.Id}
+		require.NoError(t, sqlStore.GetDashboardTrash(ctx, &query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("should not restore a dashboard whose uid was taken", func(t *testing.T) {
+		taken := insertTestDashboard(t, sqlStore, "Taken", 1, 0, false)
+		require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.Exec("UPDATE dashboard SET uid = ? WHERE id = ?", dash.Uid, taken.Id)
+			return err
+		}))
+		t.Cleanup(func() {
+			require.NoError(t, DeleteDashboard(ctx, &models.DeleteDashboardCommand{Id: taken.Id, OrgId: 1}))
+		})
+
+		entry := trashEntry(t, sqlStore, dash.Uid)
+		_, err := sqlStore.RestoreDashboardTrashEntry(ctx, restoreCommand(entry, editor.Id, folder.Id), entry)
+		require.Error(t, err)
+		trashEntry(t, sqlStore, dash.Uid)
+	})
+
+	t.Run("should restore the dashboard in its folder with its permissions", func(t *testing.T) {
+		require.NoError(t, DeleteTeam(ctx, &models.DeleteTeamCommand{OrgId: 1, Id: team.Id}))
+
+		entry := trashEntry(t, sqlStore, dash.Uid)
+		restored, err := sqlStore.RestoreDashboardTrashEntry(ctx, restoreCommand(entry, editor.Id, folder.Id), entry)
+		require.NoError(t, err)
+		assert.Equal(t, dash.Uid, restored.Uid)
+		assert.Equal(t, folder.Id, restored.FolderId)
+		assert.True(t, restored.HasAcl)
+		assert.Equal(t, []interface{}{"prod"}, restored.Data.Get("tags").MustArray())
+		assert.Equal(t, []*models.DashboardAclInfoDTO{{UserId: viewer.Id, Permission: models.PERMISSION_EDIT}}, ownAcl(t, sqlStore, restored.Id))
+
+		query := models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardTrash(ctx, &query))
+		assert.Empty(t, query.Result)
+
+		_, err = sqlStore.RestoreDashboardTrashEntry(ctx, restoreCommand(entry, editor.Id, folder.Id), entry)
+		assert.ErrorIs(t, err, models.ErrDashboardTrashEntryNotFound)
+	})
+
+	t.Run("should move a deleted folder to the trash with its permissions, data sources and dashboards", func(t *testing.T) {
+		require.NoError(t, testHelperUpdateDashboardAcl(t, sqlStore, folder.Id,
+			models.DashboardAcl{OrgID: 1, DashboardID: folder.Id, UserID: viewer.Id, Permission: models.PERMISSION_VIEW},
+		))
+		require.NoError(t, sqlStore.SetFolderDataSourceUIDs(ctx, 1, folder.Id, []string{"prometheus"}))
+		require.NoError(t, DeleteDashboard(ctx, &models.DeleteDashboardCommand{
+			Id: folder.Id, OrgId: 1, ForceDeleteFolderRules: true, MoveToTrash: true, DeletedBy: editor.Id,
+		}))
+
+		query := models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, sqlStore.GetDashboardTrash(ctx, &query))
+		require.Len(t, query.Result, 2)
+
+		folderEntry, err := sqlStore.GetFolderTrashEntry(ctx, 1, folder.Uid)
+		require.NoError(t, err)
+		assert.True(t, folderEntry.IsFolder)
+		assert.Empty(t, folderEntry.FolderUid)
+		dashEntry := trashEntry(t, sqlStore, dash.Uid)
+		assert.Equal(t, folder.Uid, dashEntry.FolderUid)
+		assert.Equal(t, folderEntry.Deleted, dashEntry.Deleted)
+
+		restoredFolder, err := sqlStore.RestoreDashboardTrashEntry(ctx, restoreCommand(folderEntry, editor.Id, 0), folderEntry)
+		require.NoError(t, err)
+		assert.True(t, restoredFolder.IsFolder)
+		assert.Equal(t, folder.Uid, restoredFolder.Uid)
+		assert.Equal(t, []*models.DashboardAclInfoDTO{{UserId: viewer.Id, Permission: models.PERMISSION_VIEW}}, ownAcl(t, sqlStore, restoredFolder.Id))
+		uids, err := sqlStore.GetFolderDataSourceUIDs(ctx, 1, restoredFolder.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"prometheus"}, uids)
+
+		restored, err := sqlStore.RestoreDashboardTrashEntry(ctx, restoreCommand(dashEntry, editor.Id, restoredFolder.Id), dashEntry)
+		require.NoError(t, err)
+		assert.Equal(t, restoredFolder.Id, restored.FolderId)
+	})
+
+	t.Run("should delete the expired dashboards", func(t *testing.T) {
+		expired := insertTestDashboard(t, sqlStore, "Expired", 1, 0, false)
+		require.NoError(t, DeleteDashboard(ctx, &models.DeleteDashboardCommand{
+			Id: expired.Id, OrgId: 1, MoveToTrash: true, DeletedBy: editor.Id,
+		}))
+
+		cmd := models.DeleteExpiredDashboardTrashCommand{DeletedBefore: time.Now().Add(-time.Hour)}
+		require.NoError(t, sqlStore.DeleteExpiredDashboardTrash(ctx, &cmd))
+		assert.Zero(t, cmd.DeletedRows)
+
+		cmd = models.DeleteExpiredDashboardTrashCommand{DeletedBefore: time.Now().Add(time.Second)}
+		require.NoError(t, sqlStore.DeleteExpiredDashboardTrash(ctx, &cmd))
+		assert.Equal(t, int64(1), cmd.DeletedRows)
+	})
+}
+
+func trashEntry(t *testing.T, sqlStore *SQLStore, uid string) *models.DashboardTrashEntry {
+	t.Helper()
+	query := models.GetDashboardTrashQuery{OrgId: 1}
+	require.NoError(t, sqlStore.GetDashboardTrash(context.Background(), &query))
+	for _, item := range query.Result {
+		if item.Uid == uid && !item.IsFolder {
+			entry, err := sqlStore.GetDashboardTrashEntry(context.Background(), 1, item.Id)
+			require.NoError(t, err)
+			return entry
+		}
+	}
+	t.Fatalf("dashboard %s not in the trash", uid)
+	return nil
+}
+
+// restoreCommand is the command the dashboard service saves a deleted dashboard with
+func restoreCommand(entry *models.DashboardTrashEntry, userID, folderID int64) models.SaveDashboardCommand {
+	data := simplejson.NewFromAny(entry.Data.MustMap())
+	data.Del("id")
+	return models.SaveDashboardCommand{
+		Dashboard: data,
+		OrgId:     entry.OrgId,
+		UserId:    userID,
+		FolderId:  folderID,
+		IsFolder:  entry.IsFolder,
+		Message:   "Restored from trash",
+	}
+}
+
+// ownAcl returns the permissions of the dashboard, without the ones inherited from its folder or the default ones
+func ownAcl(t *testing.T, sqlStore *SQLStore, dashboardID int64) []*models.DashboardAclInfoDTO {
+	t.Helper()
+	query := models.GetDashboardAclInfoListQuery{DashboardID: dashboardID, OrgID: 1}
+	require.NoError(t, sqlStore.GetDashboardAclInfoList(context.Background(), &query))
+	own := make([]*models.DashboardAclInfoDTO, 0)
+	for _, item := range query.Result {
+		if item.DashboardId == dashboardID {
+			own = append(own, &models.DashboardAclInfoDTO{UserId: item.UserId, Permission: item.Permission})
+		}
+	}
+	return own
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -31,6 +32,11 @@ func (ss *SQLStore) GetDashboardVersion(ctx context.Context, query *models.GetDa
 			return models.ErrDashboardVersionNotFound
 		}
 
+		data, err := dashboardStorage.decode(ctx, version.Data)
+		if err != nil {
+			return err
+		}
+		version.Data = data
 		version.Data.Set("id", version.DashboardId)
 		query.Result = &version
 		return nil
@@ -76,7 +82,11 @@ const MAX_VERSIONS_TO_DELETE_PER_BATCH = 100
 const MAX_VERSION_DELETION_BATCHES = 50
 
 func (ss *SQLStore) DeleteExpiredVersions(ctx context.Context, cmd *models.DeleteExpiredVersionsCommand) error {
-	return ss.deleteExpiredVersions(ctx, cmd, MAX_VERSIONS_TO_DELETE_PER_BATCH, MAX_VERSION_DELETION_BATCHES)
+	perBatch := MAX_VERSIONS_TO_DELETE_PER_BATCH
+	if cmd.BatchSize > 0 {
+		perBatch = cmd.BatchSize
+	}
+	return ss.deleteExpiredVersions(ctx, cmd, perBatch, MAX_VERSION_DELETION_BATCHES)
 }
 
 func (ss *SQLStore) deleteExpiredVersions(ctx context.Context, cmd *models.DeleteExpiredVersionsCommand, perBatch int, maxBatches int) error {
@@ -87,6 +97,7 @@ func (ss *SQLStore) deleteExpiredVersions(ctx context.Context, cmd *models.Delet
 
 	for batch := 0; batch < maxBatches; batch++ {
 		deleted := int64(0)
+		var released []*simplejson.Json
 
 		batchErr := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
 			// Idea of this query is finding version IDs to delete based on formula:
@@ -113,6 +124,20 @@ func (ss *SQLStore) deleteExpiredVersions(ctx context.Context, cmd *models.Delet
 				return nil
 			}
 
+			released, err = externalDashboardVersionsData(sess, "id IN (?"+strings.Repeat(",?", len(versionIdsToDelete)-1)+")", versionIdsToDelete...)
+			if err != nil {
+				return err
+			}
+
+			deleteEventsSQL := `DELETE FROM dashboard_event WHERE EXISTS (
+				SELECT 1 FROM dashboard_version
+				WHERE dashboard_version.dashboard_id = dashboard_event.dashboard_id
+				AND dashboard_version.version = dashboard_event.version
+				AND dashboard_version.id IN (?` + strings.Repeat(",?", len(versionIdsToDelete)-1) + `))`
+			if _, err := sess.Exec(append([]interface{}{deleteEventsSQL}, versionIdsToDelete...)...); err != nil {
+				return err
+			}
+
 			deleteExpiredSQL := `DELETE FROM dashboard_version WHERE id IN (?` + strings.Repeat(",?", len(versionIdsToDelete)-1) + `)`
 			sqlOrArgs := append([]interface{}{deleteExpiredSQL}, versionIdsToDelete...)
 			expiredResponse, err := sess.Exec(sqlOrArgs...)
@@ -127,6 +152,7 @@ func (ss *SQLStore) deleteExpiredVersions(ctx context.Context, cmd *models.Delet
 		if batchErr != nil {
 			return batchErr
 		}
+		releaseDashboardData(ctx, released)
 
 		cmd.DeletedRows += deleted
 
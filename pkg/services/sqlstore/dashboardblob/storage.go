@@ -0,0 +1,163 @@
+// Package dashboardblob stores the JSON models of large dashboards outside of the database.
+package dashboardblob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var ErrBlobNotFound = errors.New("dashboard blob not found")
+
+// Storage saves the JSON models of the dashboards under keys made of slash separated segments.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blobs, keys of blobs that do not exist are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns the blob storage configured by the [dashboard_storage] settings
+func New(cfg setting.DashboardStorageSettings) Storage {
+	if cfg.BlobStorage == setting.DashboardBlobStorageS3 {
+		return &s3Storage{cfg: cfg.S3}
+	}
+	return &localStorage{dir: cfg.BlobPath}
+}
+
+// localStorage writes the blobs to a directory of the Grafana server
+type localStorage struct {
+	dir string
+}
+
+func (s *localStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *localStorage) Put(_ context.Context, key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never get a partially written blob
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *localStorage) Get(_ context.Context, key string) ([]byte, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the keys are generated by Grafana
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+func (s *localStorage) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3Storage keeps the blobs in an S3 bucket. The objects are private, access is left to the bucket policy.
+type s3Storage struct {
+	cfg setting.DashboardBlobS3Settings
+}
+
+func (s *s3Storage) session() (*session.Session, error) {
+	awsCfg := &aws.Config{
+		Region:           aws.String(s.cfg.Region),
+		S3ForcePathStyle: aws.Bool(s.cfg.PathStyleAccess),
+	}
+	if s.cfg.Endpoint != "" {
+		awsCfg.Endpoint = aws.String(s.cfg.Endpoint)
+	}
+	// Without static credentials the default credential chain of the AWS SDK is used
+	if s.cfg.AccessKey != "" && s.cfg.SecretKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(s.cfg.AccessKey, s.cfg.SecretKey, "")
+	}
+	return session.NewSession(awsCfg)
+}
+
+func (s *s3Storage) key(key string) string {
+	if s.cfg.Path == "" {
+		return key
+	}
+	return strings.TrimSuffix(s.cfg.Path, "/") + "/" + key
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	_, err = s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(s.key(key)),
+		ACL:         aws.String("private"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	buf := aws.NewWriteAtBuffer([]byte{})
+	_, err = s3manager.NewDownloader(sess).DownloadWithContext(ctx, buf, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	client := s3.New(sess)
+	// Deleting objects that do not exist succeeds
+	for _, key := range keys {
+		if _, err := client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.cfg.Bucket),
+			Key:    aws.String(s.key(key)),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
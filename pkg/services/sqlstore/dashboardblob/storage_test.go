@@ -0,0 +1,29 @@
+package dashboardblob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storage := New(setting.DashboardStorageSettings{BlobStorage: setting.DashboardBlobStorageLocal, BlobPath: t.TempDir()})
+	ctx := context.Background()
+
+	_, err := storage.Get(ctx, "1/abc/1.json")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	require.NoError(t, storage.Put(ctx, "1/abc/1.json", []byte(`{"title":"first"}`)))
+	require.NoError(t, storage.Put(ctx, "1/abc/1.json", []byte(`{"title":"second"}`)))
+	data, err := storage.Get(ctx, "1/abc/1.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"second"}`, string(data))
+
+	require.NoError(t, storage.Delete(ctx, "1/abc/1.json", "1/abc/2.json"))
+	_, err = storage.Get(ctx, "1/abc/1.json")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+}
@@ -0,0 +1,212 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// DatabaseHealth are the diagnostics of the database. The diagnostics which failed have an error, so that the others
+// are still reported.
+type DatabaseHealth struct {
+	Type string
+	// Latency is the round trip of a query to the primary database
+	Latency time.Duration
+	// Error is the error of the query to the primary database, the database is down when it is set
+	Error string
+	// Replica is nil when no read replica is configured
+	Replica *ReplicaHealth
+	// Migrations is nil when the migrations have never been run with locking
+	Migrations      *migrator.MigrationStatus
+	MigrationsError string
+	// Tables are the largest tables, the sizes in bytes are only known for MySQL and Postgres
+	Tables      []TableSize
+	TablesError string
+	// LockWaits is nil when the database does not report them
+	LockWaits      *LockWaits
+	LockWaitsError string
+}
+
+// ReplicaHealth is the state of the read replica
+type ReplicaHealth struct {
+	// Healthy tells whether the reads are sent to the replica
+	Healthy bool
+	Latency time.Duration
+	Lag     time.Duration
+	Error   string
+}
+
+// TableSize is the number of rows and the size of a table, data and indexes, as estimated by the database
+type TableSize struct {
+	Name  string
+	Rows  int64
+	Bytes int64
+}
+
+// LockWaits are the transactions waiting for a lock
+type LockWaits struct {
+	Waiting     int64
+	LongestWait time.Duration
+}
+
+// GetDatabaseHealth returns the diagnostics of the database, with the maxTables largest tables
+func (ss *SQLStore) GetDatabaseHealth(ctx context.Context, maxTables int) *DatabaseHealth {
+	dbType := ss.Dialect.DriverName()
+	health := &DatabaseHealth{Type: dbType}
+
+	latency, err := pingLatency(ctx, ss.engine)
+	health.Latency = latency
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+
+	if ss.replica != nil {
+		health.Replica = ss.replicaHealth(ctx)
+	}
+
+	status, err := ss.GetMigrationStatus(ctx)
+	health.Migrations = status
+	if err != nil {
+		health.MigrationsError = err.Error()
+	}
+
+	err = ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		health.Tables, err = largestTables(ctx, sess, dbType, maxTables)
+		return err
+	})
+	if err != nil {
+		health.TablesError = err.Error()
+	}
+
+	err = ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		health.LockWaits, err = lockWaits(ctx, sess, dbType)
+		return err
+	})
+	if err != nil {
+		health.LockWaitsError = err.Error()
+	}
+
+	return health
+}
+
+func (ss *SQLStore) replicaHealth(ctx context.Context) *ReplicaHealth {
+	ss.replica.mu.Lock()
+	result := &ReplicaHealth{Healthy: ss.replica.healthy}
+	ss.replica.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+
+	latency, err := pingLatency(ctx, ss.replica.engine)
+	result.Latency = latency
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	lag, err := ss.replica.lagFn(ctx)
+	result.Lag = lag
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// pingLatency returns the round trip of a query to the database
+func pingLatency(ctx context.Context, engine *xorm.Engine) (time.Duration, error) {
+	start := time.Now()
+	err := withDbSession(ctx, engine, func(sess *DBSession) error {
+		_, err := sess.Exec("SELECT 1")
+		return err
+	})
+	return time.Since(start), err
+}
+
+// largestTables returns the largest tables by size, or by number of rows for SQLite which does not report the sizes
+// of its tables
+func largestTables(ctx context.Context, sess *DBSession, dbType string, maxTables int) ([]TableSize, error) {
+	var rawSQL string
+	switch dbType {
+	case migrator.MySQL:
+		rawSQL = `SELECT table_name, COALESCE(table_rows, 0), COALESCE(data_length + index_length, 0) AS size
+			FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+			ORDER BY size DESC ` + dialect.Limit(int64(maxTables))
+	case migrator.Postgres:
+		rawSQL = `SELECT relname, n_live_tup, pg_total_relation_size(relid) AS size
+			FROM pg_stat_user_tables WHERE schemaname = current_schema()
+			ORDER BY size DESC ` + dialect.Limit(int64(maxTables))
+	default:
+		return countTableRows(ctx, sess, maxTables)
+	}
+
+	rows, err := sess.DB().QueryContext(ctx, rawSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	tables := make([]TableSize, 0, maxTables)
+	for rows.Next() {
+		var table TableSize
+		if err := rows.Scan(&table.Name, &table.Rows, &table.Bytes); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// countTableRows returns the tables of a SQLite database with the most rows
+func countTableRows(ctx context.Context, sess *DBSession, maxTables int) ([]TableSize, error) {
+	names, err := sess.QueryString("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]TableSize, 0, len(names))
+	for _, name := range names {
+		table := TableSize{Name: name["name"]}
+		row := sess.DB().QueryRowContext(ctx, "SELECT COUNT(*) FROM "+dialect.Quote(table.Name))
+		if err := row.Scan(&table.Rows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Rows > tables[j].Rows })
+	if len(tables) > maxTables {
+		tables = tables[:maxTables]
+	}
+	return tables, nil
+}
+
+// lockWaits returns the transactions waiting for a lock, SQLite does not report them
+func lockWaits(ctx context.Context, sess *DBSession, dbType string) (*LockWaits, error) {
+	var rawSQL string
+	switch dbType {
+	case migrator.MySQL:
+		rawSQL = `SELECT COUNT(*), COALESCE(MAX(TIMESTAMPDIFF(MICROSECOND, trx_wait_started, NOW())), 0) / 1000000
+			FROM information_schema.innodb_trx WHERE trx_state = 'LOCK WAIT'`
+	case migrator.Postgres:
+		rawSQL = `SELECT COUNT(*), COALESCE(MAX(EXTRACT(EPOCH FROM now() - state_change)), 0)
+			FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND datname = current_database()`
+	default:
+		return nil, nil
+	}
+
+	var waiting int64
+	var seconds sql.NullFloat64
+	if err := sess.DB().QueryRowContext(ctx, rawSQL).Scan(&waiting, &seconds); err != nil {
+		return nil, err
+	}
+	return &LockWaits{Waiting: waiting, LongestWait: time.Duration(seconds.Float64 * float64(time.Second))}, nil
+}
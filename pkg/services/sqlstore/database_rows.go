@@ -0,0 +1,175 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// rowsCountingDriver wraps a database driver to count the rows returned and affected by the statements in the
+// store call of their context, see addQueryRows.
+type rowsCountingDriver struct {
+	driver.Driver
+}
+
+func (d *rowsCountingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsCountingConn{Conn: conn}, nil
+}
+
+// rowsCountingConn implements the context interfaces of the connections of the SQLite, MySQL and PostgreSQL
+// drivers, and returns driver.ErrSkip when the wrapped connection does not so that database/sql falls back to
+// prepared statements.
+type rowsCountingConn struct {
+	driver.Conn
+}
+
+func (c *rowsCountingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if conn, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return conn.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("the database driver does not support transaction options")
+	}
+	return c.Conn.Begin() // nolint:staticcheck
+}
+
+func (c *rowsCountingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if conn, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = conn.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rowsCountingStmt{Stmt: stmt}, nil
+}
+
+func (c *rowsCountingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := conn.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsCountingRows{Rows: rows, ctx: ctx}, nil
+}
+
+func (c *rowsCountingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := conn.ExecContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	countAffectedRows(ctx, result)
+	return result, nil
+}
+
+func (c *rowsCountingConn) Ping(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.Pinger); ok {
+		return conn.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *rowsCountingConn) ResetSession(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.SessionResetter); ok {
+		return conn.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *rowsCountingConn) CheckNamedValue(value *driver.NamedValue) error {
+	if conn, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return conn.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type rowsCountingStmt struct {
+	driver.Stmt
+}
+
+func (s *rowsCountingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	var err error
+	if stmt, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = stmt.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(values) // nolint:staticcheck
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rowsCountingRows{Rows: rows, ctx: ctx}, nil
+}
+
+func (s *rowsCountingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var result driver.Result
+	var err error
+	if stmt, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = stmt.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			result, err = s.Stmt.Exec(values) // nolint:staticcheck
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	countAffectedRows(ctx, result)
+	return result, nil
+}
+
+func (s *rowsCountingStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if stmt, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return stmt.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type rowsCountingRows struct {
+	driver.Rows
+	ctx context.Context
+}
+
+func (r *rowsCountingRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+	addQueryRows(r.ctx, 1)
+	return nil
+}
+
+func countAffectedRows(ctx context.Context, result driver.Result) {
+	// the drivers return an error when the number of rows is not known
+	if n, err := result.RowsAffected(); err == nil {
+		addQueryRows(ctx, n)
+	}
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("the database driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
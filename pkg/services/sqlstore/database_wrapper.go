@@ -38,7 +38,8 @@ func init() {
 
 // WrapDatabaseDriverWithHooks creates a fake database driver that
 // executes pre and post functions which we use to gather metrics about
-// database queries. It also registers the metrics.
+// database queries. It also registers the metrics, and counts the rows
+// of the store calls traced by startQuery.
 func WrapDatabaseDriverWithHooks(dbType string) string {
 	drivers := map[string]driver.Driver{
 		migrator.SQLite:   &sqlite3.SQLiteDriver{},
@@ -48,12 +49,18 @@ func WrapDatabaseDriverWithHooks(dbType string) string {
 
 	d, exist := drivers[dbType]
 	if !exist {
-		return dbType
+		// the driver caching the prepared statements is wrapped in turn
+		wrapped, ok := registeredStatementCacheDrivers.Load(dbType)
+		if !ok {
+			return dbType
+		}
+		d = wrapped.(driver.Driver)
 	}
 
 	driverWithHooks := dbType + "WithHooks"
-	sql.Register(driverWithHooks, sqlhooks.Wrap(d, &databaseQueryWrapper{log: log.New("sqlstore.metrics")}))
+	sql.Register(driverWithHooks, sqlhooks.Wrap(&rowsCountingDriver{Driver: d}, &databaseQueryWrapper{log: log.New("sqlstore.metrics")}))
 	core.RegisterDriver(driverWithHooks, &databaseQueryWrapperDriver{dbType: dbType})
+	countRows = true
 	return driverWithHooks
 }
 
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"xorm.io/xorm"
 )
@@ -49,6 +50,14 @@ func (ss *SQLStore) GetDataSources(ctx context.Context, query *models.GetDataSou
 			sess = dbSess.Limit(query.DataSourceLimit, 0).Where("org_id=?", query.OrgId).Asc("name")
 		}
 
+		if query.User != nil {
+			acFilter, err := accesscontrol.Filter(query.User, "data_source.id", "datasources", accesscontrol.ActionDatasourcesRead)
+			if err != nil {
+				return err
+			}
+			sess.And(acFilter.Where, acFilter.Args...)
+		}
+
 		query.Result = make([]*models.DataSource, 0)
 		return sess.Find(&query.Result)
 	})
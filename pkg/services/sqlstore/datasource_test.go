@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -342,6 +343,34 @@ func TestDataAccess(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, numberOfDatasource, len(query.Result))
 		})
+
+		t.Run("Only data sources the user can read should be returned", func(t *testing.T) {
+			sqlStore := InitTestDB(t)
+			ids := []int64{}
+			for i := 0; i < 3; i++ {
+				cmd := &models.AddDataSourceCommand{
+					OrgId:  10,
+					Name:   "laban" + strconv.Itoa(i),
+					Type:   models.DS_GRAPHITE,
+					Access: models.DS_ACCESS_DIRECT,
+					Url:    "http://test",
+				}
+				err := sqlStore.AddDataSource(context.Background(), cmd)
+				require.NoError(t, err)
+				ids = append(ids, cmd.Result.Id)
+			}
+
+			user := &models.SignedInUser{OrgId: 10, Permissions: map[int64]map[string][]string{10: {
+				accesscontrol.ActionDatasourcesRead: {"datasources:id:" + strconv.FormatInt(ids[1], 10), "datasources:uid:other"},
+			}}}
+			query := models.GetDataSourcesQuery{OrgId: 10, DataSourceLimit: 2, User: user}
+
+			err := sqlStore.GetDataSources(context.Background(), &query)
+
+			require.NoError(t, err)
+			require.Len(t, query.Result, 1)
+			require.Equal(t, ids[1], query.Result[0].Id)
+		})
 	})
 
 	t.Run("GetDataSourcesByType", func(t *testing.T) {
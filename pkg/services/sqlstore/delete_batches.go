@@ -0,0 +1,58 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultDeleteBatchSize is the size of the batches of DeleteInBatches when none is given
+const DefaultDeleteBatchSize = 1000
+
+// DeleteInBatches deletes the rows of a table matching a condition, by batches of at most batchSize rows deleted by
+// a statement each, so that no statement holds its locks for long. It stops when no row matches anymore or the
+// context is done, and returns the number of rows deleted so far. The table must have an id primary key.
+func (ss *SQLStore) DeleteInBatches(ctx context.Context, table string, condition string, batchSize int64, args ...interface{}) (int64, error) {
+	return deleteInBatches(ctx, table, condition, batchSize, args...)
+}
+
+func deleteInBatches(ctx context.Context, table string, condition string, batchSize int64, args ...interface{}) (int64, error) {
+	if batchSize < 1 {
+		batchSize = DefaultDeleteBatchSize
+	}
+	// the nested select lets MySQL delete from the table it selects from
+	sql := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM (SELECT id FROM %s WHERE %s ORDER BY id %s) a)",
+		table, table, condition, dialect.Limit(batchSize))
+	return executeUntilDoneOrCancelled(ctx, sql, args...)
+}
+
+// executeUntilDoneOrCancelled executes a statement until it affects no row or the context is done, and returns the
+// number of rows it affected
+func executeUntilDoneOrCancelled(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	var totalAffected int64
+	for {
+		select {
+		case <-ctx.Done():
+			return totalAffected, ctx.Err()
+		default:
+			var affected int64
+			err := withDbSession(ctx, x, func(session *DBSession) error {
+				res, err := session.Exec(append([]interface{}{sql}, args...)...)
+				if err != nil {
+					return err
+				}
+
+				affected, err = res.RowsAffected()
+				totalAffected += affected
+
+				return err
+			})
+			if err != nil {
+				return totalAffected, err
+			}
+
+			if affected == 0 {
+				return totalAffected, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GetFolderDataSourceUIDs returns the uids of the data sources dashboards in the folder are allowed to use.
+// An empty list means all data sources are allowed.
+func (ss *SQLStore) GetFolderDataSourceUIDs(ctx context.Context, orgID, folderID int64) ([]string, error) {
+	uids := make([]string, 0)
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.Table("folder_datasource").
+			Where("org_id = ? AND folder_id = ?", orgID, folderID).
+			Asc("datasource_uid").
+			Cols("datasource_uid").
+			Find(&uids)
+	})
+	return uids, err
+}
+
+// SetFolderDataSourceUIDs replaces the data sources dashboards in the folder are allowed to use.
+func (ss *SQLStore) SetFolderDataSourceUIDs(ctx context.Context, orgID, folderID int64, uids []string) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM folder_datasource WHERE org_id = ? AND folder_id = ?", orgID, folderID); err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(uids))
+		for _, uid := range uids {
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+
+			entry := models.FolderDataSource{
+				OrgId:         orgID,
+				FolderId:      folderID,
+				DatasourceUid: uid,
+				Created:       time.Now(),
+			}
+			if _, err := sess.Table("folder_datasource").Insert(&entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLibraryPanelModels returns the models of the library panels of the organization with the uids, keyed by uid.
+// The library panels that do not exist are left out.
+func (ss *SQLStore) GetLibraryPanelModels(ctx context.Context, orgID int64, uids []string) (map[string]*simplejson.Json, error) {
+	result := make(map[string]*simplejson.Json, len(uids))
+	if len(uids) == 0 {
+		return result, nil
+	}
+
+	var elements []struct {
+		UID   string `xorm:"uid"`
+		Model []byte `xorm:"model"`
+	}
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.Table("library_element").Cols("uid", "model").
+			Where("org_id = ? AND kind = ?", orgID, models.PanelElement).
+			In("uid", uids).
+			Find(&elements)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, element := range elements {
+		model, err := simplejson.NewJson(element.Model)
+		if err != nil {
+			return nil, err
+		}
+		result[element.UID] = model
+	}
+	return result, nil
+}
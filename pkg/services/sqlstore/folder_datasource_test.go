@@ -0,0 +1,63 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestFolderDataSources(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	folder := insertTestDashboard(t, sqlStore, "Folder", 1, 0, true)
+
+	t.Run("should allow all data sources by default", func(t *testing.T) {
+		uids, err := sqlStore.GetFolderDataSourceUIDs(context.Background(), 1, folder.Id)
+		require.NoError(t, err)
+		require.Empty(t, uids)
+	})
+
+	t.Run("should replace the allowed data sources", func(t *testing.T) {
+		require.NoError(t, sqlStore.SetFolderDataSourceUIDs(context.Background(), 1, folder.Id, []string{"b", "a", "b"}))
+		uids, err := sqlStore.GetFolderDataSourceUIDs(context.Background(), 1, folder.Id)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, uids)
+
+		require.NoError(t, sqlStore.SetFolderDataSourceUIDs(context.Background(), 1, folder.Id, []string{"c"}))
+		uids, err = sqlStore.GetFolderDataSourceUIDs(context.Background(), 1, folder.Id)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c"}, uids)
+	})
+
+	t.Run("should remove the allowed data sources with the folder", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+		uids, err := sqlStore.GetFolderDataSourceUIDs(context.Background(), 1, folder.Id)
+		require.NoError(t, err)
+		require.Empty(t, uids)
+	})
+}
+
+func TestGetLibraryPanelModels(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	err := sqlStore.WithDbSession(context.Background(), func(sess *DBSession) error {
+		_, err := sess.Exec(`INSERT INTO library_element (org_id, folder_id, uid, name, kind, type, description, model, created, created_by, updated, updated_by, version)
+			VALUES (1, 0, 'lib', 'Lib', ?, 'graph', '', ?, ?, 1, ?, 1, 1)`, models.PanelElement, `{"datasource":"Prod"}`, time.Now(), time.Now())
+		return err
+	})
+	require.NoError(t, err)
+
+	panels, err := sqlStore.GetLibraryPanelModels(context.Background(), 1, []string{"lib", "missing"})
+	require.NoError(t, err)
+	require.Len(t, panels, 1)
+	require.Equal(t, "Prod", panels["lib"].Get("datasource").MustString())
+
+	panels, err = sqlStore.GetLibraryPanelModels(context.Background(), 2, []string{"lib"})
+	require.NoError(t, err)
+	require.Empty(t, panels)
+}
@@ -18,3 +18,19 @@ func TestGetDBHealthQuery(t *testing.T) {
 	err := GetDBHealthQuery(context.Background(), &query)
 	require.NoError(t, err)
 }
+
+func TestGetDatabaseHealth(t *testing.T) {
+	ss := InitTestDB(t)
+	_, err := ss.CreateUser(context.Background(), models.CreateUserCommand{Login: "health"})
+	require.NoError(t, err)
+
+	health := ss.GetDatabaseHealth(context.Background(), 3)
+	require.Empty(t, health.Error)
+	require.Positive(t, health.Latency)
+	require.Empty(t, health.TablesError)
+	require.Len(t, health.Tables, 3)
+	for i := 1; i < len(health.Tables); i++ {
+		require.GreaterOrEqual(t, health.Tables[i-1].Rows, health.Tables[i].Rows)
+	}
+	require.Empty(t, health.LockWaitsError)
+}
@@ -2,7 +2,6 @@ package sqlstore
 
 import (
 	"context"
-	"strconv"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -15,6 +14,8 @@ func init() {
 	bus.AddHandler("sql", CreateLoginAttempt)
 	bus.AddHandler("sql", DeleteOldLoginAttempts)
 	bus.AddHandler("sql", GetUserLoginAttemptCount)
+	bus.AddHandler("sql", GetLoginAttempts)
+	bus.AddHandler("sql", DeleteLoginAttempts)
 }
 
 func CreateLoginAttempt(ctx context.Context, cmd *models.CreateLoginAttemptCommand) error {
@@ -36,33 +37,9 @@ func CreateLoginAttempt(ctx context.Context, cmd *models.CreateLoginAttemptComma
 }
 
 func DeleteOldLoginAttempts(ctx context.Context, cmd *models.DeleteOldLoginAttemptsCommand) error {
-	return inTransaction(func(sess *DBSession) error {
-		var maxId int64
-		sql := "SELECT max(id) as id FROM login_attempt WHERE created < ?"
-		result, err := sess.Query(sql, cmd.OlderThan.Unix())
-		if err != nil {
-			return err
-		}
-		if len(result) == 0 || result[0] == nil {
-			return nil
-		}
-
-		maxId = toInt64(result[0]["id"])
-
-		if maxId == 0 {
-			return nil
-		}
-
-		sql = "DELETE FROM login_attempt WHERE id <= ?"
-
-		if result, err := sess.Exec(sql, maxId); err != nil {
-			return err
-		} else if cmd.DeletedRows, err = result.RowsAffected(); err != nil {
-			return err
-		}
-
-		return nil
-	})
+	deleted, err := deleteInBatches(ctx, "login_attempt", "created < ?", cmd.BatchSize, cmd.OlderThan.Unix())
+	cmd.DeletedRows = deleted
+	return err
 }
 
 func GetUserLoginAttemptCount(ctx context.Context, query *models.GetUserLoginAttemptCountQuery) error {
@@ -80,15 +57,29 @@ func GetUserLoginAttemptCount(ctx context.Context, query *models.GetUserLoginAtt
 	return nil
 }
 
-func toInt64(i interface{}) int64 {
-	switch i := i.(type) {
-	case []byte:
-		n, _ := strconv.ParseInt(string(i), 10, 64)
-		return n
-	case int:
-		return int64(i)
-	case int64:
-		return i
+func GetLoginAttempts(ctx context.Context, query *models.GetLoginAttemptsQuery) error {
+	sess := x.Where("created >= ?", query.Since.Unix())
+	if query.Username != "" {
+		sess = sess.And("username = ?", query.Username)
+	} else {
+		sess = sess.And("ip_address = ?", query.IpAddress)
+	}
+
+	query.Result = make([]*models.LoginAttempt, 0)
+	return sess.Asc("created", "id").Find(&query.Result)
+}
+
+func DeleteLoginAttempts(ctx context.Context, cmd *models.DeleteLoginAttemptsCommand) error {
+	if len(cmd.Usernames) == 0 {
+		return nil
 	}
-	return 0
+
+	return inTransaction(func(sess *DBSession) error {
+		deleted, err := sess.In("username", cmd.Usernames).Delete(&models.LoginAttempt{})
+		if err != nil {
+			return err
+		}
+		cmd.DeletedRows = deleted
+		return nil
+	})
 }
@@ -131,4 +131,66 @@ func TestLoginAttempts(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, int64(3), cmd.DeletedRows)
 	})
+
+	t.Run("Should delete the old rows in batches", func(t *testing.T) {
+		setup(t)
+		cmd := models.DeleteOldLoginAttemptsCommand{
+			OlderThan: timePlusTwoMinutes.Add(time.Second * 1),
+			BatchSize: 2,
+		}
+		err := DeleteOldLoginAttempts(context.Background(), &cmd)
+
+		require.Nil(t, err)
+		require.Equal(t, int64(3), cmd.DeletedRows)
+	})
+
+	t.Run("Should return the login attempts of the username since beginning of time + 1min", func(t *testing.T) {
+		setup(t)
+		query := models.GetLoginAttemptsQuery{
+			Username: user,
+			Since:    timePlusOneMinute,
+		}
+		err := GetLoginAttempts(context.Background(), &query)
+		require.Nil(t, err)
+		require.Len(t, query.Result, 2)
+		require.Equal(t, timePlusOneMinute.Unix(), query.Result[0].Created)
+		require.Equal(t, timePlusTwoMinutes.Unix(), query.Result[1].Created)
+	})
+
+	t.Run("Should return the login attempts of the IP address", func(t *testing.T) {
+		setup(t)
+		query := models.GetLoginAttemptsQuery{
+			IpAddress: "192.168.0.1",
+			Since:     beginningOfTime,
+		}
+		err := GetLoginAttempts(context.Background(), &query)
+		require.Nil(t, err)
+		require.Len(t, query.Result, 3)
+
+		query = models.GetLoginAttemptsQuery{
+			IpAddress: "192.168.0.2",
+			Since:     beginningOfTime,
+		}
+		err = GetLoginAttempts(context.Background(), &query)
+		require.Nil(t, err)
+		require.Len(t, query.Result, 0)
+	})
+
+	t.Run("Should delete the login attempts of the usernames", func(t *testing.T) {
+		setup(t)
+		cmd := models.DeleteLoginAttemptsCommand{
+			Usernames: []string{user, "other"},
+		}
+		err := DeleteLoginAttempts(context.Background(), &cmd)
+		require.Nil(t, err)
+		require.Equal(t, int64(3), cmd.DeletedRows)
+
+		query := models.GetUserLoginAttemptCountQuery{
+			Username: user,
+			Since:    beginningOfTime,
+		}
+		err = GetUserLoginAttemptCount(context.Background(), &query)
+		require.Nil(t, err)
+		require.Equal(t, int64(0), query.Result)
+	})
 }
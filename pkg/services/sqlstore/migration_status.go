@@ -0,0 +1,25 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// MigrationLocking returns true when the instances sharing the database take a lock before running the migrations
+func (ss *SQLStore) MigrationLocking() bool {
+	lockSQL, _ := ss.Dialect.TryLockSQL("")
+	return ss.dbCfg.MigrationLocking && lockSQL != ""
+}
+
+// GetMigrationStatus returns the instance which last held the migration lock and the progress of its migrations, or
+// nil when the migrations have never been run with locking.
+func (ss *SQLStore) GetMigrationStatus(ctx context.Context) (*migrator.MigrationStatus, error) {
+	var status *migrator.MigrationStatus
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		status, err = migrator.GetMigrationStatus(sess.Session)
+		return err
+	})
+	return status, err
+}
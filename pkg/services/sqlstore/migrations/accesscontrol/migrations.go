@@ -162,4 +162,72 @@ func AddMigration(mg *migrator.Migrator) {
 
 	//-------  indexes ------------------
 	mg.AddMigration("add unique index builtin_role_role_name", migrator.NewAddIndexMigration(seedAssignmentV1, seedAssignmentV1.Indices[0]))
+
+	mg.AddMigration("add column deny to permission", migrator.NewAddColumnMigration(permissionV1, &migrator.Column{
+		Name: "deny", Type: migrator.DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add column expires_at to user_role", migrator.NewAddColumnMigration(userRoleV1, &migrator.Column{
+		Name: "expires_at", Type: migrator.DB_DateTime, Nullable: true,
+	}))
+	mg.AddMigration("add index user_role.expires_at", migrator.NewAddIndexMigration(userRoleV1, &migrator.Index{
+		Cols: []string{"expires_at"},
+	}))
+
+	mg.AddMigration("add column auth_module to user_role", migrator.NewAddColumnMigration(userRoleV1, &migrator.Column{
+		Name: "auth_module", Type: migrator.DB_NVarchar, Length: 190, Nullable: false, Default: "''",
+	}))
+
+	groupMappingV1 := migrator.Table{
+		Name: "group_mapping",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt},
+			{Name: "auth_module", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "group_id", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "role_id", Type: migrator.DB_BigInt},
+			{Name: "provisioned", Type: migrator.DB_Bool, Nullable: false, Default: "0"},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"org_id", "auth_module", "group_id", "role_id"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"role_id"}},
+		},
+	}
+
+	mg.AddMigration("create group mapping table", migrator.NewAddTableMigration(groupMappingV1))
+
+	//-------  indexes ------------------
+	mg.AddMigration("add index group_mapping.org_id", migrator.NewAddIndexMigration(groupMappingV1, groupMappingV1.Indices[0]))
+	mg.AddMigration("add unique index group_mapping_org_id_auth_module_group_id_role_id", migrator.NewAddIndexMigration(groupMappingV1, groupMappingV1.Indices[1]))
+	mg.AddMigration("add index group_mapping.role_id", migrator.NewAddIndexMigration(groupMappingV1, groupMappingV1.Indices[2]))
+
+	roleBindingV1 := migrator.Table{
+		Name: "role_binding",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt},
+			{Name: "user_id", Type: migrator.DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "team_id", Type: migrator.DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "role_id", Type: migrator.DB_BigInt},
+			{Name: "role_name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "scope", Type: migrator.DB_NVarchar, Length: 190, Nullable: false, Default: "''"},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"org_id", "user_id", "team_id", "role_id"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"role_id"}},
+		},
+	}
+
+	mg.AddMigration("create role binding table", migrator.NewAddTableMigration(roleBindingV1))
+
+	//-------  indexes ------------------
+	mg.AddMigration("add index role_binding.org_id", migrator.NewAddIndexMigration(roleBindingV1, roleBindingV1.Indices[0]))
+	mg.AddMigration("add unique index role_binding_org_id_user_id_team_id_role_id", migrator.NewAddIndexMigration(roleBindingV1, roleBindingV1.Indices[1]))
+	mg.AddMigration("add index role_binding.role_id", migrator.NewAddIndexMigration(roleBindingV1, roleBindingV1.Indices[2]))
 }
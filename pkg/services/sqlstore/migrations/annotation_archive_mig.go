@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAnnotationArchiveMigrations(mg *Migrator) {
+	annotationArchiveV1 := Table{
+		Name: "annotation_archive",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "row_count", Type: DB_BigInt, Nullable: false},
+			{Name: "min_id", Type: DB_BigInt, Nullable: false},
+			{Name: "max_id", Type: DB_BigInt, Nullable: false},
+			{Name: "min_epoch", Type: DB_BigInt, Nullable: false},
+			{Name: "max_epoch_end", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "max_epoch_end"}},
+			{Cols: []string{"name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create annotation_archive table v1", NewAddTableMigration(annotationArchiveV1))
+	mg.AddMigration("add index annotation_archive.org_id_max_epoch_end", NewAddIndexMigration(annotationArchiveV1, annotationArchiveV1.Indices[0]))
+	mg.AddMigration("add unique index annotation_archive.name", NewAddIndexMigration(annotationArchiveV1, annotationArchiveV1.Indices[1]))
+}
@@ -86,4 +86,55 @@ func addApiKeyMigrations(mg *Migrator) {
 	mg.AddMigration("Add service account foreign key", NewAddColumnMigration(apiKeyV2, &Column{
 		Name: "service_account_id", Type: DB_BigInt, Nullable: true,
 	}))
+
+	mg.AddMigration("Add last_used_at to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "last_used_at", Type: DB_DateTime, Nullable: true,
+	}))
+
+	apiKeyRotationV1 := Table{
+		Name: "api_key_rotation",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "api_key_id", Type: DB_BigInt, Nullable: false},
+			{Name: "previous_key", Type: DB_Varchar, Length: 190, Nullable: false},
+			{Name: "previous_key_expires", Type: DB_DateTime, Nullable: false},
+			{Name: "rotated_by", Type: DB_BigInt, Nullable: false},
+			{Name: "rotated_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"api_key_id"}},
+		},
+	}
+
+	mg.AddMigration("create api_key_rotation table", NewAddTableMigration(apiKeyRotationV1))
+	mg.AddMigration("add index api_key_rotation.api_key_id", NewAddIndexMigration(apiKeyRotationV1, apiKeyRotationV1.Indices[0]))
+
+	apiKeyPermissionV1 := Table{
+		Name: "api_key_permission",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "api_key_id", Type: DB_BigInt, Nullable: false},
+			{Name: "action", Type: DB_Varchar, Length: 190, Nullable: false},
+			{Name: "scope", Type: DB_Varchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"api_key_id"}},
+		},
+	}
+
+	mg.AddMigration("create api_key_permission table", NewAddTableMigration(apiKeyPermissionV1))
+	mg.AddMigration("add index api_key_permission.api_key_id", NewAddIndexMigration(apiKeyPermissionV1, apiKeyPermissionV1.Indices[0]))
+
+	// the personal access tokens are the API keys owned by a user
+	mg.AddMigration("Add user_id to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "user_id", Type: DB_BigInt, Nullable: true,
+	}))
+	mg.AddMigration("Add last_used_ip to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "last_used_ip", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("add index api_key.user_id", NewAddIndexMigration(apiKeyV2, &Index{
+		Cols: []string{"user_id"},
+	}))
 }
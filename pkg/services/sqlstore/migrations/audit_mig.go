@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAuditMigrations(mg *Migrator) {
+	auditEventV1 := Table{
+		Name: "audit_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "actor_id", Type: DB_BigInt, Nullable: false},
+			{Name: "actor_login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "data", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "user_id", "created"}},
+		},
+	}
+
+	mg.AddMigration("create audit_event table v1", NewAddTableMigration(auditEventV1))
+	mg.AddMigration("add index audit_event.org_id_user_id_created", NewAddIndexMigration(auditEventV1, auditEventV1.Indices[0]))
+
+	// The authentication events are listed across the organizations and the users
+	mg.AddMigration("add index audit_event.type_created", NewAddIndexMigration(auditEventV1, &Index{
+		Cols: []string{"type", "created"},
+	}))
+}
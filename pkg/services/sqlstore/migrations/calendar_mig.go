@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addCalendarMigrations(mg *Migrator) {
+	calendarV1 := Table{
+		Name: "calendar",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "timezone", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create calendar table v1", NewAddTableMigration(calendarV1))
+	mg.AddMigration("add unique index calendar.org_id_uid", NewAddIndexMigration(calendarV1, calendarV1.Indices[0]))
+	mg.AddMigration("add unique index calendar.org_id_name", NewAddIndexMigration(calendarV1, calendarV1.Indices[1]))
+
+	calendarEventV1 := Table{
+		Name: "calendar_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "calendar_id", Type: DB_BigInt, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "tags", Type: DB_Text, Nullable: true},
+			{Name: "start_time", Type: DB_DateTime, Nullable: false},
+			{Name: "end_time", Type: DB_DateTime, Nullable: false},
+			{Name: "all_day", Type: DB_Bool, Nullable: false, Default: "0"},
+			{Name: "recurrence", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"calendar_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id"}},
+		},
+	}
+
+	mg.AddMigration("create calendar_event table v1", NewAddTableMigration(calendarEventV1))
+	mg.AddMigration("add unique index calendar_event.calendar_id_uid", NewAddIndexMigration(calendarEventV1, calendarEventV1.Indices[0]))
+	mg.AddMigration("add index calendar_event.org_id", NewAddIndexMigration(calendarEventV1, calendarEventV1.Indices[1]))
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDashboardEventMigrations(mg *Migrator) {
+	dashboardEventV1 := Table{
+		Name: "dashboard_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "panel_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "variable", Type: DB_NVarchar, Length: 190, Nullable: false, Default: "''"},
+			{Name: "path", Type: DB_NVarchar, Length: 190, Nullable: false, Default: "''"},
+			{Name: "old_value", Type: DB_Text, Nullable: true},
+			{Name: "new_value", Type: DB_Text, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "version"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_event table v1", NewAddTableMigration(dashboardEventV1))
+	mg.AddMigration("add index dashboard_event.dashboard_id_version", NewAddIndexMigration(dashboardEventV1, dashboardEventV1.Indices[0]))
+
+	mg.AddMigration("alter dashboard_event.old_value and new_value to mediumtext v1", NewRawSQLMigration("").
+		Mysql("ALTER TABLE dashboard_event MODIFY old_value MEDIUMTEXT, MODIFY new_value MEDIUMTEXT;"))
+}
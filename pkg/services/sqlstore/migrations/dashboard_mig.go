@@ -231,3 +231,13 @@ func addDashboardMigration(mg *Migrator) {
 		Type: IndexType,
 	}))
 }
+
+// addDashboardJSONBMigrations stores the JSON models of the dashboards as JSONB on PostgreSQL, indexed so that the
+// panels of the dashboards can be queried. The migrations do nothing on the other databases.
+func addDashboardJSONBMigrations(mg *Migrator) {
+	mg.AddMigration("alter dashboard.data to jsonb", NewRawSQLMigration("").
+		Postgres("ALTER TABLE dashboard ALTER COLUMN data TYPE jsonb USING data::jsonb;"))
+
+	mg.AddMigration("add gin index for dashboard.data", NewRawSQLMigration("").
+		Postgres("CREATE INDEX IF NOT EXISTS IDX_dashboard_data_gin ON dashboard USING GIN (data jsonb_path_ops);"))
+}
@@ -0,0 +1,182 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func addDashboardPermissionsMigrations(mg *Migrator) {
+	mg.AddMigration("migrate dashboard and folder permissions to managed permissions", &dashboardPermissionsMigration{})
+}
+
+// dashboardPermissionsMigration converts the dashboard and folder acl into the access control managed permissions
+// of the users, teams and built-in roles the acl is set for. The default permissions of the dashboards without acl
+// are not converted, they are granted by the fixed roles of the built-in roles.
+type dashboardPermissionsMigration struct {
+	MigrationBase
+}
+
+func (m *dashboardPermissionsMigration) SQL(dialect Dialect) string {
+	return "code migration"
+}
+
+type dashboardACLPermission struct {
+	OrgID      int64   `xorm:"org_id"`
+	UserID     int64   `xorm:"user_id"`
+	TeamID     int64   `xorm:"team_id"`
+	Role       *string `xorm:"role"`
+	Permission int64   `xorm:"permission"`
+	UID        string  `xorm:"uid"`
+	IsFolder   bool    `xorm:"is_folder"`
+}
+
+func (m *dashboardPermissionsMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	var acl []dashboardACLPermission
+	if err := sess.SQL(`SELECT acl.org_id, acl.user_id, acl.team_id, acl.role, acl.permission, d.uid, d.is_folder
+		FROM dashboard_acl AS acl
+		INNER JOIN dashboard AS d ON d.id = acl.dashboard_id
+		WHERE acl.dashboard_id > 0`).Find(&acl); err != nil {
+		return err
+	}
+
+	roleIDs := map[string]int64{}
+	for _, p := range acl {
+		roleID, err := m.getOrCreateManagedRole(sess, p, roleIDs)
+		if err != nil {
+			return err
+		}
+		if roleID == 0 {
+			mg.Logger.Warn("skipping dashboard permission without user, team or role", "orgId", p.OrgID, "uid", p.UID)
+			continue
+		}
+
+		scope := "dashboards:uid:" + p.UID
+		if p.IsFolder {
+			scope = "folders:uid:" + p.UID
+		}
+
+		for _, action := range dashboardPermissionActions(p.IsFolder, p.Permission) {
+			exists, err := sess.SQL("SELECT 1 FROM permission WHERE role_id = ? AND action = ? AND scope = ?", roleID, action, scope).Exist()
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			now := time.Now()
+			if _, err := sess.Exec("INSERT INTO permission (role_id, action, scope, created, updated) VALUES (?, ?, ?, ?, ?)",
+				roleID, action, scope, now, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getOrCreateManagedRole returns the id of the managed role of the user, team or built-in role the permission is set
+// for, creating and assigning the role if needed. It returns 0 if the permission is not set for any of them.
+func (m *dashboardPermissionsMigration) getOrCreateManagedRole(sess *xorm.Session, p dashboardACLPermission, roleIDs map[string]int64) (int64, error) {
+	var name string
+	var assign func(roleID int64, now time.Time) error
+	switch {
+	case p.UserID > 0:
+		name = fmt.Sprintf("managed:users:%d:permissions", p.UserID)
+		assign = func(roleID int64, now time.Time) error {
+			_, err := sess.Exec("INSERT INTO user_role (org_id, user_id, role_id, created) VALUES (?, ?, ?, ?)", p.OrgID, p.UserID, roleID, now)
+			return err
+		}
+	case p.TeamID > 0:
+		name = fmt.Sprintf("managed:teams:%d:permissions", p.TeamID)
+		assign = func(roleID int64, now time.Time) error {
+			_, err := sess.Exec("INSERT INTO team_role (org_id, team_id, role_id, created) VALUES (?, ?, ?, ?)", p.OrgID, p.TeamID, roleID, now)
+			return err
+		}
+	case p.Role != nil && *p.Role != "":
+		name = fmt.Sprintf("managed:builtins:%s:permissions", strings.ToLower(*p.Role))
+		assign = func(roleID int64, now time.Time) error {
+			_, err := sess.Exec("INSERT INTO builtin_role (org_id, role, role_id, created, updated) VALUES (?, ?, ?, ?, ?)", p.OrgID, *p.Role, roleID, now, now)
+			return err
+		}
+	default:
+		return 0, nil
+	}
+
+	key := fmt.Sprintf("%d:%s", p.OrgID, name)
+	if id, ok := roleIDs[key]; ok {
+		return id, nil
+	}
+
+	findRole := func() ([]int64, error) {
+		var ids []int64
+		err := sess.SQL("SELECT id FROM role WHERE org_id = ? AND name = ?", p.OrgID, name).Find(&ids)
+		return ids, err
+	}
+
+	ids, err := findRole()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		now := time.Now()
+		if _, err := sess.Exec("INSERT INTO role (org_id, uid, name, version, created, updated) VALUES (?, ?, ?, 0, ?, ?)",
+			p.OrgID, util.GenerateShortUID(), name, now, now); err != nil {
+			return 0, err
+		}
+		if ids, err = findRole(); err != nil {
+			return 0, err
+		}
+		if len(ids) == 0 {
+			return 0, fmt.Errorf("failed to create managed role %s", name)
+		}
+		if err := assign(ids[0], now); err != nil {
+			return 0, err
+		}
+	}
+
+	roleIDs[key] = ids[0]
+	return ids[0], nil
+}
+
+// dashboardPermissionActions returns the actions granted by the legacy permission on a dashboard or folder,
+// a folder permission grants the dashboard actions on the dashboards of the folder.
+func dashboardPermissionActions(isFolder bool, permission int64) []string {
+	const (
+		view  = 1
+		edit  = 2
+		admin = 4
+	)
+
+	var actions []string
+	if isFolder {
+		if permission >= view {
+			actions = append(actions, "folders:read", "dashboards:read")
+		}
+		if permission >= edit {
+			actions = append(actions, "folders:write", "folders:delete", "dashboards:write", "dashboards:delete", "dashboards:create")
+		}
+		if permission >= admin {
+			actions = append(actions, "folders.permissions:read", "folders.permissions:write", "dashboards.permissions:read", "dashboards.permissions:write")
+		}
+		return actions
+	}
+
+	if permission >= view {
+		actions = append(actions, "dashboards:read")
+	}
+	if permission >= edit {
+		actions = append(actions, "dashboards:write", "dashboards:delete")
+	}
+	if permission >= admin {
+		actions = append(actions, "dashboards.permissions:read", "dashboards.permissions:write")
+	}
+	return actions
+}
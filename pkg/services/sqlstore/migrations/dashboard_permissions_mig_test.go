@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDashboardPermissionsMigration(t *testing.T) {
+	testDB := sqlutil.SQLite3TestDB()
+	x, err := xorm.NewEngine(testDB.DriverName, testDB.ConnStr)
+	require.NoError(t, err)
+	// The in-memory database is shared between the connections of the engine and dropped once they are closed
+	t.Cleanup(func() { require.NoError(t, x.Close()) })
+	require.NoError(t, NewDialect(x).CleanDB())
+
+	mg := NewMigrator(x, &setting.Cfg{})
+	migrations := &OSSMigrations{}
+	migrations.AddMigration(mg)
+	require.NoError(t, mg.Start())
+
+	now := time.Now()
+	for _, stmt := range [][]interface{}{
+		{"INSERT INTO dashboard (id, version, slug, title, data, org_id, created, updated, uid, is_folder) VALUES (1, 1, 'folder', 'Folder', '{}', 1, ?, ?, 'folder', ?)", now, now, true},
+		{"INSERT INTO dashboard (id, version, slug, title, data, org_id, created, updated, uid, is_folder, folder_id) VALUES (2, 1, 'dash', 'Dash', '{}', 1, ?, ?, 'dash', ?, 1)", now, now, false},
+		{"INSERT INTO dashboard_acl (org_id, dashboard_id, user_id, team_id, permission, created, updated) VALUES (1, 1, NULL, 3, 2, ?, ?)", now, now},
+		{"INSERT INTO dashboard_acl (org_id, dashboard_id, user_id, team_id, permission, created, updated) VALUES (1, 2, 5, NULL, 4, ?, ?)", now, now},
+		{"INSERT INTO dashboard_acl (org_id, dashboard_id, user_id, team_id, role, permission, created, updated) VALUES (1, 2, NULL, NULL, 'Viewer', 1, ?, ?)", now, now},
+	} {
+		_, err := x.Exec(stmt...)
+		require.NoError(t, err)
+	}
+
+	migrate := func() {
+		sess := x.NewSession()
+		defer sess.Close()
+		require.NoError(t, (&dashboardPermissionsMigration{}).Exec(sess, &Migrator{Logger: log.New("test")}))
+	}
+	// Running the migration again must not duplicate the permissions
+	migrate()
+	migrate()
+
+	type permission struct {
+		Name   string
+		Action string
+		Scope  string
+	}
+	var permissions []permission
+	require.NoError(t, x.SQL(`SELECT r.name, p.action, p.scope FROM permission AS p INNER JOIN role AS r ON r.id = p.role_id
+		WHERE r.name LIKE 'managed:%' ORDER BY r.name, p.action`).Find(&permissions))
+
+	require.Equal(t, []permission{
+		{"managed:builtins:viewer:permissions", "dashboards:read", "dashboards:uid:dash"},
+		{"managed:teams:3:permissions", "dashboards:create", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "dashboards:delete", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "dashboards:read", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "dashboards:write", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "folders:delete", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "folders:read", "folders:uid:folder"},
+		{"managed:teams:3:permissions", "folders:write", "folders:uid:folder"},
+		{"managed:users:5:permissions", "dashboards.permissions:read", "dashboards:uid:dash"},
+		{"managed:users:5:permissions", "dashboards.permissions:write", "dashboards:uid:dash"},
+		{"managed:users:5:permissions", "dashboards:delete", "dashboards:uid:dash"},
+		{"managed:users:5:permissions", "dashboards:read", "dashboards:uid:dash"},
+		{"managed:users:5:permissions", "dashboards:write", "dashboards:uid:dash"},
+	}, permissions)
+
+	for table, count := range map[string]int64{"user_role": 1, "team_role": 1, "builtin_role": 1} {
+		assigned, err := x.Table(table).Count()
+		require.NoError(t, err)
+		require.Equal(t, count, assigned, table)
+	}
+}
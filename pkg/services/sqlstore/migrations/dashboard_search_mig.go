@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDashboardSearchMigrations(mg *Migrator) {
+	dashboardSearchIndexV1 := Table{
+		Name: "dashboard_search_index",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "analyzer", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}, Type: UniqueIndex},
+			{Cols: []string{"org_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_search_index table v1", NewAddTableMigration(dashboardSearchIndexV1))
+
+	mg.AddMigration("add unique index dashboard_search_index.dashboard_id", NewAddIndexMigration(dashboardSearchIndexV1, dashboardSearchIndexV1.Indices[0]))
+	mg.AddMigration("add index dashboard_search_index.org_id", NewAddIndexMigration(dashboardSearchIndexV1, dashboardSearchIndexV1.Indices[1]))
+
+	dashboardSearchTermV1 := Table{
+		Name: "dashboard_search_term",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "term", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "term"}},
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_search_term table v1", NewAddTableMigration(dashboardSearchTermV1))
+
+	mg.AddMigration("add index dashboard_search_term.org_id_term", NewAddIndexMigration(dashboardSearchTermV1, dashboardSearchTermV1.Indices[0]))
+	mg.AddMigration("add index dashboard_search_term.dashboard_id", NewAddIndexMigration(dashboardSearchTermV1, dashboardSearchTermV1.Indices[1]))
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDashboardUsageMigrations(mg *Migrator) {
+	dashboardUsageV1 := Table{
+		Name: "dashboard_usage",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "views", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "queries", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "errors", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "last_viewed", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "dashboard_uid", "day"}, Type: UniqueIndex},
+			{Cols: []string{"day"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_usage table v1", NewAddTableMigration(dashboardUsageV1))
+
+	mg.AddMigration("add unique index dashboard_usage.org_id_dashboard_uid_day", NewAddIndexMigration(dashboardUsageV1, dashboardUsageV1.Indices[0]))
+	mg.AddMigration("add index dashboard_usage.day", NewAddIndexMigration(dashboardUsageV1, dashboardUsageV1.Indices[1]))
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addEntityMigrations(mg *Migrator) {
+	entityV1 := Table{
+		Name: "entity",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "body", Type: DB_MediumText, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "kind", "uid", "version"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create entity table v1", NewAddTableMigration(entityV1))
+	mg.AddMigration("add unique index entity.org_id_kind_uid_version", NewAddIndexMigration(entityV1, entityV1.Indices[0]))
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addEnvironmentMigrations(mg *Migrator) {
+	environmentV1 := Table{
+		Name: "environment",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "description", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create environment table v1", NewAddTableMigration(environmentV1))
+	mg.AddMigration("add unique index environment.org_id_name", NewAddIndexMigration(environmentV1, environmentV1.Indices[0]))
+
+	dataSourceEnvironmentV1 := Table{
+		Name: "data_source_environment",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "environment", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "target_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "datasource_uid", "environment"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create data_source_environment table v1", NewAddTableMigration(dataSourceEnvironmentV1))
+	mg.AddMigration("add unique index data_source_environment.org_id_datasource_uid_environment", NewAddIndexMigration(dataSourceEnvironmentV1, dataSourceEnvironmentV1.Indices[0]))
+}
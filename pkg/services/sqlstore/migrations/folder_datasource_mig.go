@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addFolderDataSourceMigrations(mg *Migrator) {
+	folderDataSourceV1 := Table{
+		Name: "folder_datasource",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "folder_id", "datasource_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create folder_datasource table v1", NewAddTableMigration(folderDataSourceV1))
+
+	mg.AddMigration("add unique index folder_datasource.org_id_folder_id_datasource_uid", NewAddIndexMigration(folderDataSourceV1, folderDataSourceV1.Indices[0]))
+}
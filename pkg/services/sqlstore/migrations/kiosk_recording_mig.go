@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addKioskRecordingMigrations(mg *Migrator) {
+	kioskRecordingV1 := Table{
+		Name: "kiosk_recording",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "alerts", Type: DB_MediumText, Nullable: false},
+			{Name: "frames", Type: DB_MediumText, Nullable: false},
+			{Name: "started", Type: DB_DateTime, Nullable: false},
+			{Name: "ends", Type: DB_DateTime, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "dashboard_uid", "started"}},
+		},
+	}
+
+	mg.AddMigration("create kiosk_recording table v1", NewAddTableMigration(kioskRecordingV1))
+
+	mg.AddMigration("add unique index kiosk_recording.org_id_uid", NewAddIndexMigration(kioskRecordingV1, kioskRecordingV1.Indices[0]))
+	mg.AddMigration("add index kiosk_recording.org_id_dashboard_uid_started", NewAddIndexMigration(kioskRecordingV1, kioskRecordingV1.Indices[1]))
+}
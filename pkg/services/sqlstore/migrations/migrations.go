@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
 	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // --- Migration Guide line ---
@@ -67,6 +68,36 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	addKVStoreMigrations(mg)
 	ualert.AddDashboardUIDPanelIDMigration(mg)
 	accesscontrol.AddMigration(mg)
+	addFolderDataSourceMigrations(mg)
+	addResourceOwnerMigrations(mg)
+	addSavedSearchMigrations(mg)
+	addCalendarMigrations(mg)
+	addServiceAccountMigrations(mg)
+	addDashboardPermissionsMigrations(mg)
+	addTestFixtureMigrations(mg)
+	addDashboardEventMigrations(mg)
+	addOnboardingMigrations(mg)
+	addAuditMigrations(mg)
+	addTermsMigrations(mg)
+	addScheduleMigrations(mg)
+	addOAuthSessionMigrations(mg)
+	addTOTPMigrations(mg)
+	addDataSourceRetentionMigrations(mg)
+	addUserDevicesMigrations(mg)
+	addEnvironmentMigrations(mg)
+	addQueryPipelineMigrations(mg)
+	addResourceLabelMigrations(mg)
+	addOrgEncryptionKeyMigrations(mg)
+	addEntityMigrations(mg)
+	addAnnotationArchiveMigrations(mg)
+	addDashboardTrashMigrations(mg)
+	addDashboardSearchMigrations(mg)
+	addPublicDashboardMigrations(mg)
+	addKioskRecordingMigrations(mg)
+	addDashboardUsageMigrations(mg)
+	if mg.Cfg != nil && mg.Cfg.DashboardStorage.Type == setting.DashboardStorageJSONB {
+		addDashboardJSONBMigrations(mg)
+	}
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
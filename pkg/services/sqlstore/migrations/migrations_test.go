@@ -2,8 +2,10 @@ package migrations
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"xorm.io/xorm"
@@ -53,6 +55,49 @@ func TestMigrations(t *testing.T) {
 	checkStepsAndDatabaseMatch(t, mg, expectedMigrations)
 }
 
+// TestMigrationLocking runs the migrations of two instances sharing a MySQL or PostgreSQL database at once:
+// GRAFANA_TEST_DB=postgres go test -v ./pkg/services/sqlstore/migrations -run TestMigrationLocking
+func TestMigrationLocking(t *testing.T) {
+	var testDB sqlutil.TestDB
+	switch os.Getenv("GRAFANA_TEST_DB") {
+	case Postgres:
+		testDB = sqlutil.PostgresTestDB()
+	case MySQL:
+		testDB = sqlutil.MySQLTestDB()
+	default:
+		t.Skip("migration locking requires MySQL or PostgreSQL")
+	}
+
+	engines := make([]*xorm.Engine, 2)
+	for i := range engines {
+		x, err := xorm.NewEngine(testDB.DriverName, testDB.ConnStr)
+		require.NoError(t, err)
+		engines[i] = x
+	}
+	require.NoError(t, NewDialect(engines[0]).CleanDB())
+
+	migrators := make([]*Migrator, len(engines))
+	errs := make(chan error, len(engines))
+	for i, x := range engines {
+		mg := NewMigrator(x, &setting.Cfg{})
+		mg.LockTimeout = time.Minute
+		(&OSSMigrations{}).AddMigration(mg)
+		migrators[i] = mg
+		go func() { errs <- mg.Start() }()
+	}
+	for range engines {
+		require.NoError(t, <-errs)
+	}
+	checkStepsAndDatabaseMatch(t, migrators[0], migrators[0].GetMigrationIDs(true))
+
+	status, err := GetMigrationStatus(engines[0])
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	require.Equal(t, MigrationStateCompleted, status.State)
+	// the instance which waited for the lock has nothing left to migrate
+	require.Equal(t, 0, status.Pending)
+}
+
 func checkStepsAndDatabaseMatch(t *testing.T, mg *Migrator, expected []string) {
 	t.Helper()
 	log, err := mg.GetMigrationLog()
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addOAuthSessionMigrations(mg *Migrator) {
+	oauthSessionV1 := Table{
+		Name: "oauth_session",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "token_id", Type: DB_BigInt, Nullable: false},
+			{Name: "auth_module", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "issuer", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "subject", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "session_id", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"token_id"}, Type: UniqueIndex},
+			{Cols: []string{"user_id"}},
+			{Cols: []string{"auth_module", "subject"}},
+			{Cols: []string{"auth_module", "session_id"}},
+		},
+	}
+
+	mg.AddMigration("create oauth_session table v1", NewAddTableMigration(oauthSessionV1))
+	mg.AddMigration("add unique index oauth_session.token_id", NewAddIndexMigration(oauthSessionV1, oauthSessionV1.Indices[0]))
+	mg.AddMigration("add index oauth_session.user_id", NewAddIndexMigration(oauthSessionV1, oauthSessionV1.Indices[1]))
+	mg.AddMigration("add index oauth_session.auth_module_subject", NewAddIndexMigration(oauthSessionV1, oauthSessionV1.Indices[2]))
+	mg.AddMigration("add index oauth_session.auth_module_session_id", NewAddIndexMigration(oauthSessionV1, oauthSessionV1.Indices[3]))
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addOnboardingMigrations(mg *Migrator) {
+	onboardingStepV1 := Table{
+		Name: "org_onboarding_step",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "step", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "source", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "completed_by", Type: DB_BigInt, Nullable: false},
+			{Name: "completed_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "step"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create org_onboarding_step table v1", NewAddTableMigration(onboardingStepV1))
+	mg.AddMigration("add unique index org_onboarding_step.org_id_step", NewAddIndexMigration(onboardingStepV1, onboardingStepV1.Indices[0]))
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addOrgEncryptionKeyMigrations(mg *Migrator) {
+	orgEncryptionKeyV1 := Table{
+		Name: "org_encryption_key",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "provider", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "key_id", Type: DB_NVarchar, Length: 2048, Nullable: false},
+			{Name: "region", Type: DB_NVarchar, Length: 100, Nullable: true},
+			{Name: "assume_role_arn", Type: DB_NVarchar, Length: 2048, Nullable: true},
+			{Name: "external_id", Type: DB_NVarchar, Length: 1224, Nullable: true},
+			{Name: "encrypt_dashboards", Type: DB_Bool, Nullable: false},
+			{Name: "state", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "revoked", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "state"}},
+		},
+	}
+
+	mg.AddMigration("create org_encryption_key table v1", NewAddTableMigration(orgEncryptionKeyV1))
+	mg.AddMigration("add unique index org_encryption_key.uid", NewAddIndexMigration(orgEncryptionKeyV1, orgEncryptionKeyV1.Indices[0]))
+	mg.AddMigration("add index org_encryption_key.org_id_state", NewAddIndexMigration(orgEncryptionKeyV1, orgEncryptionKeyV1.Indices[1]))
+}
@@ -43,4 +43,8 @@ func addPlaylistMigrations(mg *Migrator) {
 		{Name: "value", Type: DB_Text, Nullable: false},
 		{Name: "title", Type: DB_Text, Nullable: false},
 	}))
+
+	mg.AddMigration("Add column schedule_uid to playlist", NewAddColumnMigration(playlistV2, &Column{
+		Name: "schedule_uid", Type: DB_NVarchar, Length: 40, Nullable: true,
+	}))
 }
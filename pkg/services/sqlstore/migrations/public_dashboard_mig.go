@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addPublicDashboardMigrations(mg *Migrator) {
+	publicDashboardV1 := Table{
+		Name: "public_dashboard",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "time_from", Type: DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "time_to", Type: DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "variables", Type: DB_Text, Nullable: true},
+			{Name: "token_version", Type: DB_Int, Nullable: false, Default: "1"},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "dashboard_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create public_dashboard table v1", NewAddTableMigration(publicDashboardV1))
+
+	mg.AddMigration("add unique index public_dashboard.uid", NewAddIndexMigration(publicDashboardV1, publicDashboardV1.Indices[0]))
+	mg.AddMigration("add unique index public_dashboard.org_id_dashboard_uid", NewAddIndexMigration(publicDashboardV1, publicDashboardV1.Indices[1]))
+}
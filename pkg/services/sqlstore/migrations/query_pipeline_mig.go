@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addQueryPipelineMigrations(mg *Migrator) {
+	queryPipelineV1 := Table{
+		Name: "query_pipeline",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: false},
+			{Name: "steps", Type: DB_MediumText, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create query_pipeline table v1", NewAddTableMigration(queryPipelineV1))
+	mg.AddMigration("add unique index query_pipeline.org_id_uid", NewAddIndexMigration(queryPipelineV1, queryPipelineV1.Indices[0]))
+	mg.AddMigration("add unique index query_pipeline.org_id_name", NewAddIndexMigration(queryPipelineV1, queryPipelineV1.Indices[1]))
+
+	queryPipelineDashboardV1 := Table{
+		Name: "query_pipeline_dashboard",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "pipeline_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "pipeline_uid"}},
+			{Cols: []string{"org_id", "dashboard_uid"}},
+		},
+	}
+
+	mg.AddMigration("create query_pipeline_dashboard table v1", NewAddTableMigration(queryPipelineDashboardV1))
+	mg.AddMigration("add index query_pipeline_dashboard.org_id_pipeline_uid", NewAddIndexMigration(queryPipelineDashboardV1, queryPipelineDashboardV1.Indices[0]))
+	mg.AddMigration("add index query_pipeline_dashboard.org_id_dashboard_uid", NewAddIndexMigration(queryPipelineDashboardV1, queryPipelineDashboardV1.Indices[1]))
+}
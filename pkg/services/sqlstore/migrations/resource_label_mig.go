@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addResourceLabelMigrations(mg *Migrator) {
+	resourceLabelV1 := Table{
+		Name: "resource_label",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "resource_type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "resource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 320, Nullable: false},
+			{Name: "value", Type: DB_Text, Nullable: false},
+			{Name: "annotation", Type: DB_Bool, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "resource_type", "resource_uid", "annotation", "name"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "resource_type", "name"}},
+		},
+	}
+
+	mg.AddMigration("create resource_label table v1", NewAddTableMigration(resourceLabelV1))
+	mg.AddMigration("add unique index resource_label.org_id_resource_type_resource_uid_annotation_name", NewAddIndexMigration(resourceLabelV1, resourceLabelV1.Indices[0]))
+	mg.AddMigration("add index resource_label.org_id_resource_type_name", NewAddIndexMigration(resourceLabelV1, resourceLabelV1.Indices[1]))
+}
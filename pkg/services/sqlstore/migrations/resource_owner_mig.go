@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addResourceOwnerMigrations(mg *Migrator) {
+	resourceOwnerV1 := Table{
+		Name: "resource_owner",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "resource_type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "resource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "owner_user_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "owner_team_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "resource_type", "resource_uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "owner_user_id"}},
+			{Cols: []string{"org_id", "owner_team_id"}},
+		},
+	}
+
+	mg.AddMigration("create resource_owner table v1", NewAddTableMigration(resourceOwnerV1))
+
+	mg.AddMigration("add unique index resource_owner.org_id_resource_type_resource_uid", NewAddIndexMigration(resourceOwnerV1, resourceOwnerV1.Indices[0]))
+	mg.AddMigration("add index resource_owner.org_id_owner_user_id", NewAddIndexMigration(resourceOwnerV1, resourceOwnerV1.Indices[1]))
+	mg.AddMigration("add index resource_owner.org_id_owner_team_id", NewAddIndexMigration(resourceOwnerV1, resourceOwnerV1.Indices[2]))
+}
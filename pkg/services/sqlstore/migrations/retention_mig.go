@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDataSourceRetentionMigrations(mg *Migrator) {
+	retentionV1 := Table{
+		Name: "data_source_retention",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "retention", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "description", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "datasource_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create data_source_retention table v1", NewAddTableMigration(retentionV1))
+	mg.AddMigration("add unique index data_source_retention.org_id_datasource_uid", NewAddIndexMigration(retentionV1, retentionV1.Indices[0]))
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addSavedSearchMigrations(mg *Migrator) {
+	savedSearchV1 := Table{
+		Name: "saved_search",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "team_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "filters", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "user_id", "team_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create saved_search table v1", NewAddTableMigration(savedSearchV1))
+
+	mg.AddMigration("add unique index saved_search.org_id_uid", NewAddIndexMigration(savedSearchV1, savedSearchV1.Indices[0]))
+	mg.AddMigration("add unique index saved_search.org_id_user_id_team_id_name", NewAddIndexMigration(savedSearchV1, savedSearchV1.Indices[1]))
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addScheduleMigrations(mg *Migrator) {
+	scheduleV1 := Table{
+		Name: "schedule",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "cron", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "timezone", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "duration", Type: DB_NVarchar, Length: 32, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create schedule table v1", NewAddTableMigration(scheduleV1))
+	mg.AddMigration("add unique index schedule.org_id_uid", NewAddIndexMigration(scheduleV1, scheduleV1.Indices[0]))
+	mg.AddMigration("add unique index schedule.org_id_name", NewAddIndexMigration(scheduleV1, scheduleV1.Indices[1]))
+}
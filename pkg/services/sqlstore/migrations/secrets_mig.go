@@ -18,4 +18,10 @@ func addSecretsMigration(mg *migrator.Migrator) {
 	}
 
 	mg.AddMigration("create data_keys table", migrator.NewAddTableMigration(dataKeysV1))
+
+	// the data keys were named after their day, scope and provider, which is now their label so they can be rotated
+	mg.AddMigration("add label column to data_keys", migrator.NewAddColumnMigration(dataKeysV1, &migrator.Column{
+		Name: "label", Type: migrator.DB_NVarchar, Length: 100, Nullable: false, Default: "''",
+	}))
+	mg.AddMigration("set label of existing data_keys", migrator.NewRawSQLMigration("UPDATE data_keys SET label = name"))
 }
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addServiceAccountMigrations(mg *Migrator) {
+	federatedCredentialV1 := Table{
+		Name: "service_account_federated_credential",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "service_account_id", Type: DB_BigInt, Nullable: false},
+			{Name: "issuer", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "subject", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "audience", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"issuer"}},
+			{Cols: []string{"service_account_id"}},
+		},
+	}
+
+	mg.AddMigration("create service_account_federated_credential table v1", NewAddTableMigration(federatedCredentialV1))
+	mg.AddMigration("add unique index service_account_federated_credential.org_id_uid", NewAddIndexMigration(federatedCredentialV1, federatedCredentialV1.Indices[0]))
+	mg.AddMigration("add index service_account_federated_credential.issuer", NewAddIndexMigration(federatedCredentialV1, federatedCredentialV1.Indices[1]))
+	mg.AddMigration("add index service_account_federated_credential.service_account_id", NewAddIndexMigration(federatedCredentialV1, federatedCredentialV1.Indices[2]))
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addTermsMigrations(mg *Migrator) {
+	termsV1 := Table{
+		Name: "org_terms",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "content", Type: DB_MediumText, Nullable: false},
+			{Name: "retired", Type: DB_Bool, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "version"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create org_terms table v1", NewAddTableMigration(termsV1))
+	mg.AddMigration("add unique index org_terms.org_id_version", NewAddIndexMigration(termsV1, termsV1.Indices[0]))
+
+	acceptanceV1 := Table{
+		Name: "org_terms_acceptance",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "email", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "ip_address", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "accepted", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "user_id", "version"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "version", "accepted"}},
+		},
+	}
+
+	mg.AddMigration("create org_terms_acceptance table v1", NewAddTableMigration(acceptanceV1))
+	mg.AddMigration("add unique index org_terms_acceptance.org_id_user_id_version", NewAddIndexMigration(acceptanceV1, acceptanceV1.Indices[0]))
+	mg.AddMigration("add index org_terms_acceptance.org_id_version_accepted", NewAddIndexMigration(acceptanceV1, acceptanceV1.Indices[1]))
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addTestFixtureMigrations(mg *Migrator) {
+	testFixtureV1 := Table{
+		Name: "test_fixture",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "data", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create test_fixture table v1", NewAddTableMigration(testFixtureV1))
+
+	mg.AddMigration("add unique index test_fixture.uid", NewAddIndexMigration(testFixtureV1, testFixtureV1.Indices[0]))
+}
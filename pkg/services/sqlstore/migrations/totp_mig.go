@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addTOTPMigrations(mg *Migrator) {
+	userTOTPV1 := Table{
+		Name: "user_totp",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "secret", Type: DB_Text, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "last_used_step", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_totp table v1", NewAddTableMigration(userTOTPV1))
+	mg.AddMigration("add unique index user_totp.user_id", NewAddIndexMigration(userTOTPV1, userTOTPV1.Indices[0]))
+	mg.AddMigration("add enrollment_token column to user_totp", NewAddColumnMigration(userTOTPV1, &Column{
+		Name: "enrollment_token", Type: DB_NVarchar, Length: 100, Nullable: true,
+	}))
+	mg.AddMigration("add enrollment_expires column to user_totp", NewAddColumnMigration(userTOTPV1, &Column{
+		Name: "enrollment_expires", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	recoveryCodeV1 := Table{
+		Name: "user_totp_recovery_code",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "code", Type: DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "salt", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create user_totp_recovery_code table v1", NewAddTableMigration(recoveryCodeV1))
+	mg.AddMigration("add index user_totp_recovery_code.user_id", NewAddIndexMigration(recoveryCodeV1, recoveryCodeV1.Indices[0]))
+}
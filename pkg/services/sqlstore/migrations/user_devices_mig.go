@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addUserDevicesMigrations(mg *Migrator) {
+	userDevicesV1 := Table{
+		Name: "user_devices",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "fingerprint", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "user_agent", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "client_ip", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "last_seen", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id", "fingerprint"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_devices table v1", NewAddTableMigration(userDevicesV1))
+	mg.AddMigration("add unique index user_devices.user_id_fingerprint", NewAddIndexMigration(userDevicesV1, userDevicesV1.Indices[0]))
+}
@@ -130,6 +130,12 @@ func addUserMigrations(mg *Migrator) {
 	mg.AddMigration("Add is_service_account column to user", NewAddColumnMigration(userV2, &Column{
 		Name: "is_service_account", Type: DB_Bool, Nullable: false, Default: "0",
 	}))
+
+	// deleted is when the user was removed from their last organization, the deleted users are disabled and purged
+	// by the cleanup service once the retention is over, they can be restored until then
+	mg.AddMigration("Add deleted column to user", NewAddColumnMigration(userV2, &Column{
+		Name: "deleted", Type: DB_DateTime, Nullable: true,
+	}))
 }
 
 type AddMissingUserSaltAndRandsMigration struct {
@@ -42,6 +42,13 @@ type Dialect interface {
 
 	Limit(limit int64) string
 	LimitOffset(limit int64, offset int64) string
+	// MaxBindParams returns the maximum number of bind parameters of a statement
+	MaxBindParams() int
+	// TryLockSQL returns the statement taking the named lock of the database session without waiting, it returns
+	// true when the lock is taken. The statement is empty when the database does not support locks.
+	TryLockSQL(name string) (string, []interface{})
+	// UnlockSQL returns the statement releasing the named lock of the database session
+	UnlockSQL(name string) (string, []interface{})
 
 	PreInsertId(table string, sess *xorm.Session) error
 	PostInsertId(table string, sess *xorm.Session) error
@@ -53,7 +60,21 @@ type Dialect interface {
 	IsUniqueConstraintViolation(err error) bool
 	ErrorMessage(err error) string
 	IsDeadlock(err error) bool
-}
+	// RetryReason returns the reason of the transient errors, such as deadlocks, on which a transaction can be rolled
+	// back and retried. It is empty for the other errors.
+	RetryReason(err error) string
+	// SupportsPreparedStatements tells whether the prepared statements can be kept open on the connections and
+	// reused by the next queries
+	SupportsPreparedStatements() bool
+}
+
+// The reasons of the transient errors on which the transactions are retried
+const (
+	RetryReasonDeadlock             = "deadlock"
+	RetryReasonLockWaitTimeout      = "lock_wait_timeout"
+	RetryReasonSerializationFailure = "serialization_failure"
+	RetryReasonDatabaseLocked       = "database_locked"
+)
 
 type dialectFunc func(*xorm.Engine) Dialect
 
@@ -64,6 +85,11 @@ var supportedDialects = map[string]dialectFunc{
 	MySQL + "WithHooks":    NewMysqlDialect,
 	SQLite + "WithHooks":   NewSQLite3Dialect,
 	Postgres + "WithHooks": NewPostgresDialect,
+
+	MySQL + "WithStatementCache":             NewMysqlDialect,
+	Postgres + "WithStatementCache":          NewPostgresDialect,
+	MySQL + "WithStatementCacheWithHooks":    NewMysqlDialect,
+	Postgres + "WithStatementCacheWithHooks": NewPostgresDialect,
 }
 
 func NewDialect(engine *xorm.Engine) Dialect {
@@ -260,6 +286,28 @@ func (b *BaseDialect) Limit(limit int64) string {
 	return fmt.Sprintf(" LIMIT %d", limit)
 }
 
+// MaxBindParams returns the limit of both MySQL and Postgres, whose protocols encode the number of parameters on 16 bits
+func (b *BaseDialect) MaxBindParams() int {
+	return 65535
+}
+
+// TryLockSQL returns an empty statement, the databases of the dialects without locks are used by a single instance
+func (b *BaseDialect) TryLockSQL(name string) (string, []interface{}) {
+	return "", nil
+}
+
+func (b *BaseDialect) UnlockSQL(name string) (string, []interface{}) {
+	return "", nil
+}
+
+func (b *BaseDialect) RetryReason(err error) string {
+	return ""
+}
+
+func (b *BaseDialect) SupportsPreparedStatements() bool {
+	return false
+}
+
 func (b *BaseDialect) LimitOffset(limit int64, offset int64) string {
 	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
 }
@@ -284,7 +332,7 @@ func (b *BaseDialect) TruncateDBTables() error {
 	return nil
 }
 
-//UpsertSQL returns empty string
+// UpsertSQL returns empty string
 func (b *BaseDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	return ""
 }
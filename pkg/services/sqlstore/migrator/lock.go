@@ -0,0 +1,160 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"xorm.io/xorm"
+)
+
+const (
+	migrationLockName = "grafana_migrations"
+	// migrationLockRetryInterval is how often an instance tries to take the migration lock held by another instance
+	migrationLockRetryInterval = time.Second
+)
+
+// Migration states of MigrationStatus
+const (
+	MigrationStateRunning   = "running"
+	MigrationStateCompleted = "completed"
+	MigrationStateFailed    = "failed"
+)
+
+// MigrationStatus is the progress of the migrations of the last Grafana instance which held the migration lock. It
+// is kept in the migration_lock table, so that the other instances of a cluster report it.
+type MigrationStatus struct {
+	Id               int64     `xorm:"pk 'id'" json:"-"`
+	Holder           string    `json:"holder"`
+	State            string    `json:"state"`
+	Pending          int       `json:"pending"`
+	Performed        int       `json:"performed"`
+	CurrentMigration string    `json:"currentMigration"`
+	Error            string    `json:"error"`
+	Started          time.Time `json:"started"`
+	Updated          time.Time `json:"updated"`
+}
+
+func (s *MigrationStatus) TableName() string {
+	return "migration_lock"
+}
+
+// statusReader is implemented by the xorm engine and sessions
+type statusReader interface {
+	IsTableExist(beanOrTableName interface{}) (bool, error)
+	ID(id interface{}) *xorm.Session
+}
+
+// GetMigrationStatus returns the status of the migrations of the last instance which held the migration lock, or
+// nil when the migrations have never been run with locking.
+func GetMigrationStatus(sess statusReader) (*MigrationStatus, error) {
+	exists, err := sess.IsTableExist(new(MigrationStatus))
+	if err != nil || !exists {
+		return nil, err
+	}
+	status := &MigrationStatus{}
+	has, err := sess.ID(1).Get(status)
+	if err != nil || !has {
+		return nil, err
+	}
+	return status, nil
+}
+
+// lock takes the migration lock of the database, waiting up to the lock timeout for the instance holding it to
+// complete its migrations. The returned function releases the lock, it is nil when the locking is disabled or the
+// database does not support locks.
+func (mg *Migrator) lock() (func(), error) {
+	lockSQL, lockArgs := mg.Dialect.TryLockSQL(migrationLockName)
+	if mg.LockTimeout <= 0 || lockSQL == "" {
+		return nil, nil
+	}
+	if mg.DBEngine.DB().Stats().MaxOpenConnections == 1 {
+		mg.Logger.Warn("Running the migrations without locking, the lock requires a database connection of its own")
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mg.LockTimeout)
+	defer cancel()
+
+	// the locks belong to the database sessions, the lock is held by a connection of its own
+	conn, err := mg.DBEngine.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	for {
+		var locked sql.NullBool
+		if err := conn.QueryRowContext(ctx, lockSQL, lockArgs...).Scan(&locked); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if locked.Bool {
+			break
+		}
+
+		holder := ""
+		if status, err := GetMigrationStatus(mg.DBEngine); err == nil && status != nil {
+			holder = status.Holder
+		}
+		mg.Logger.Info("Waiting for the migration lock", "holder", holder, "waited", time.Since(start).Round(time.Second))
+
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for the migration lock held by %q", mg.LockTimeout, holder)
+		case <-time.After(migrationLockRetryInterval):
+		}
+	}
+	mg.Logger.Info("Took the migration lock", "waited", time.Since(start).Round(time.Second))
+
+	return func() {
+		unlockSQL, unlockArgs := mg.Dialect.UnlockSQL(migrationLockName)
+		if _, err := conn.ExecContext(context.Background(), unlockSQL, unlockArgs...); err != nil {
+			mg.Logger.Error("Failed to release the migration lock, closing its connection", "error", err)
+			// the lock is released with the session, the connection must not go back to the pool
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		if err := conn.Close(); err != nil {
+			mg.Logger.Warn("Failed to close the connection of the migration lock", "error", err)
+		}
+	}, nil
+}
+
+// maxStatusErrorLength is the size of the error column of the migration status
+const maxStatusErrorLength = 255
+
+// setStatus records the progress of the migrations of the instance holding the lock. Failing to record it does not
+// fail the migrations.
+func (mg *Migrator) setStatus(update func(*MigrationStatus)) {
+	if mg.status == nil {
+		return
+	}
+	update(mg.status)
+	mg.status.Updated = time.Now()
+
+	affected, err := mg.DBEngine.ID(1).AllCols().Update(mg.status)
+	if err == nil && affected == 0 {
+		_, err = mg.DBEngine.Insert(mg.status)
+	}
+	if err != nil {
+		mg.Logger.Warn("Failed to record the migration status", "error", err)
+	}
+}
+
+// startStatus creates the table of the migration status, it is called while holding the migration lock
+func (mg *Migrator) startStatus(pending int) {
+	if err := mg.DBEngine.Sync2(new(MigrationStatus)); err != nil {
+		mg.Logger.Warn("Failed to create the migration status table", "error", err)
+		return
+	}
+	mg.status = &MigrationStatus{Id: 1, Started: time.Now()}
+	mg.setStatus(func(s *MigrationStatus) {
+		s.Holder = setting.InstanceName
+		s.State = MigrationStateRunning
+		s.Pending = pending
+	})
+}
@@ -20,6 +20,11 @@ type Migrator struct {
 	migrations []Migration
 	Logger     log.Logger
 	Cfg        *setting.Cfg
+	// LockTimeout is how long to wait for the migration lock held by another instance, the migrations are run
+	// without locking when zero
+	LockTimeout time.Duration
+
+	status *MigrationStatus
 }
 
 type MigrationLog struct {
@@ -90,11 +95,29 @@ func (mg *Migrator) GetMigrationLog() (map[string]MigrationLog, error) {
 func (mg *Migrator) Start() error {
 	mg.Logger.Info("Starting DB migrations")
 
+	unlock, err := mg.lock()
+	if err != nil {
+		return err
+	}
+	if unlock != nil {
+		defer unlock()
+	}
+
 	logMap, err := mg.GetMigrationLog()
 	if err != nil {
 		return err
 	}
 
+	if unlock != nil {
+		pending := 0
+		for _, m := range mg.migrations {
+			if _, exists := logMap[m.Id()]; !exists {
+				pending++
+			}
+		}
+		mg.startStatus(pending)
+	}
+
 	migrationsPerformed := 0
 	migrationsSkipped := 0
 	start := time.Now()
@@ -108,6 +131,7 @@ func (mg *Migrator) Start() error {
 		}
 
 		sql := m.SQL(mg.Dialect)
+		mg.setStatus(func(s *MigrationStatus) { s.CurrentMigration = m.Id() })
 
 		record := MigrationLog{
 			MigrationID: m.Id(),
@@ -137,12 +161,26 @@ func (mg *Migrator) Start() error {
 			return err
 		})
 		if err != nil {
-			return errutil.Wrap(fmt.Sprintf("migration failed (id = %s)", m.Id()), err)
+			err = errutil.Wrap(fmt.Sprintf("migration failed (id = %s)", m.Id()), err)
+			mg.setStatus(func(s *MigrationStatus) {
+				s.State = MigrationStateFailed
+				s.Error = err.Error()
+				if len(s.Error) > maxStatusErrorLength {
+					s.Error = s.Error[:maxStatusErrorLength]
+				}
+			})
+			return err
 		}
+		mg.setStatus(func(s *MigrationStatus) { s.Performed++ })
 	}
 
 	mg.Logger.Info("migrations completed", "performed", migrationsPerformed, "skipped", migrationsSkipped, "duration", time.Since(start))
 
+	mg.setStatus(func(s *MigrationStatus) {
+		s.State = MigrationStateCompleted
+		s.CurrentMigration = ""
+	})
+
 	// Make sure migrations are synced
 	return mg.DBEngine.Sync2()
 }
@@ -201,6 +201,16 @@ func (db *MySQLDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, mysqlerr.ER_LOCK_DEADLOCK)
 }
 
+func (db *MySQLDialect) RetryReason(err error) string {
+	switch {
+	case db.IsDeadlock(err):
+		return RetryReasonDeadlock
+	case db.isThisError(err, mysqlerr.ER_LOCK_WAIT_TIMEOUT):
+		return RetryReasonLockWaitTimeout
+	}
+	return ""
+}
+
 // UpsertSQL returns the upsert sql statement for PostgreSQL dialect
 func (db *MySQLDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	columnsStr := strings.Builder{}
@@ -225,3 +235,18 @@ func (db *MySQLDialect) UpsertSQL(tableName string, keyCols, updateCols []string
 	)
 	return s
 }
+
+// TryLockSQL takes the lock with GET_LOCK, its name is prefixed with the name of the database since the MySQL locks
+// are shared by the databases of the server
+func (db *MySQLDialect) TryLockSQL(name string) (string, []interface{}) {
+	return "SELECT GET_LOCK(CONCAT(DATABASE(), ':', ?), 0)", []interface{}{name}
+}
+
+func (db *MySQLDialect) UnlockSQL(name string) (string, []interface{}) {
+	return "SELECT RELEASE_LOCK(CONCAT(DATABASE(), ':', ?))", []interface{}{name}
+}
+
+// SupportsPreparedStatements returns true, the prepared statements are kept by the server until the end of the session
+func (db *MySQLDialect) SupportsPreparedStatements() bool {
+	return true
+}
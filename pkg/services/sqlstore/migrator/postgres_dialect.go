@@ -3,6 +3,7 @@ package migrator
 import (
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"strconv"
 	"strings"
 
@@ -209,6 +210,18 @@ func (db *PostgresDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, "40P01")
 }
 
+func (db *PostgresDialect) RetryReason(err error) string {
+	switch {
+	case db.IsDeadlock(err):
+		return RetryReasonDeadlock
+	case db.isThisError(err, "40001"):
+		return RetryReasonSerializationFailure
+	case db.isThisError(err, "55P03"):
+		return RetryReasonLockWaitTimeout
+	}
+	return ""
+}
+
 func (db *PostgresDialect) PostInsertId(table string, sess *xorm.Session) error {
 	if table != "org" {
 		return nil
@@ -257,3 +270,17 @@ func (db *PostgresDialect) UpsertSQL(tableName string, keyCols, updateCols []str
 	)
 	return s
 }
+
+// TryLockSQL takes a session level advisory lock, whose key is the checksum of the name of the lock
+func (db *PostgresDialect) TryLockSQL(name string) (string, []interface{}) {
+	return "SELECT pg_try_advisory_lock($1)", []interface{}{int64(crc32.ChecksumIEEE([]byte(name)))}
+}
+
+func (db *PostgresDialect) UnlockSQL(name string) (string, []interface{}) {
+	return "SELECT pg_advisory_unlock($1)", []interface{}{int64(crc32.ChecksumIEEE([]byte(name)))}
+}
+
+// SupportsPreparedStatements returns true, the prepared statements are kept by the server until the end of the session
+func (db *PostgresDialect) SupportsPreparedStatements() bool {
+	return true
+}
@@ -146,10 +146,25 @@ func (db *SQLite3) IsUniqueConstraintViolation(err error) bool {
 	return db.isThisError(err, int(sqlite3.ErrConstraintUnique))
 }
 
+// MaxBindParams returns the default limit of the SQLite versions older than 3.32.0
+func (db *SQLite3) MaxBindParams() int {
+	return 999
+}
+
 func (db *SQLite3) IsDeadlock(err error) bool {
 	return false // No deadlock
 }
 
+// RetryReason returns the reason of the errors of the writes concurrent to other writes, SQLite locks the whole
+// database file
+func (db *SQLite3) RetryReason(err error) string {
+	var driverErr sqlite3.Error
+	if errors.As(err, &driverErr) && (driverErr.Code == sqlite3.ErrLocked || driverErr.Code == sqlite3.ErrBusy) {
+		return RetryReasonDatabaseLocked
+	}
+	return ""
+}
+
 // UpsertSQL returns the upsert sql statement for SQLite dialect
 func (db *SQLite3) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	columnsStr := strings.Builder{}
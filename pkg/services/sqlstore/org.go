@@ -226,43 +226,63 @@ func UpdateOrgAddress(ctx context.Context, cmd *models.UpdateOrgAddressCommand)
 
 func DeleteOrg(ctx context.Context, cmd *models.DeleteOrgCommand) error {
 	return inTransaction(func(sess *DBSession) error {
-		if res, err := sess.Query("SELECT 1 from org WHERE id=?", cmd.Id); err != nil {
-			return err
-		} else if len(res) != 1 {
-			return models.ErrOrgNotFound
-		}
+		return deleteOrgInTransaction(sess, cmd.Id)
+	})
+}
 
-		deletes := []string{
-			"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
-			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
-			"DELETE FROM dashboard WHERE org_id = ?",
-			"DELETE FROM api_key WHERE org_id = ?",
-			"DELETE FROM data_source WHERE org_id = ?",
-			"DELETE FROM org_user WHERE org_id = ?",
-			"DELETE FROM org WHERE id = ?",
-			"DELETE FROM temp_user WHERE org_id = ?",
-			"DELETE FROM ngalert_configuration WHERE org_id = ?",
-			"DELETE FROM alert_configuration WHERE org_id = ?",
-			"DELETE FROM alert_instance WHERE rule_org_id = ?",
-			"DELETE FROM alert_notification WHERE org_id = ?",
-			"DELETE FROM alert_notification_state WHERE org_id = ?",
-			"DELETE FROM alert_rule WHERE org_id = ?",
-			"DELETE FROM alert_rule_tag WHERE EXISTS (SELECT 1 FROM alert WHERE alert.org_id = ? AND alert.id = alert_rule_tag.alert_id)",
-			"DELETE FROM alert_rule_version WHERE rule_org_id = ?",
-			"DELETE FROM alert WHERE org_id = ?",
-			"DELETE FROM annotation WHERE org_id = ?",
-			"DELETE FROM kv_store WHERE org_id = ?",
-		}
+func deleteOrgInTransaction(sess *DBSession, orgID int64) error {
+	if res, err := sess.Query("SELECT 1 from org WHERE id=?", orgID); err != nil {
+		return err
+	} else if len(res) != 1 {
+		return models.ErrOrgNotFound
+	}
 
-		for _, sql := range deletes {
-			_, err := sess.Exec(sql, cmd.Id)
-			if err != nil {
-				return err
-			}
+	deletes := []string{
+		"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
+		"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
+		"DELETE FROM dashboard_search_index WHERE org_id = ?",
+		"DELETE FROM dashboard_search_term WHERE org_id = ?",
+		"DELETE FROM public_dashboard WHERE org_id = ?",
+		"DELETE FROM dashboard_usage WHERE org_id = ?",
+		"DELETE FROM dashboard WHERE org_id = ?",
+		"DELETE FROM api_key WHERE org_id = ?",
+		"DELETE FROM api_key_permission WHERE org_id = ?",
+		"DELETE FROM data_source WHERE org_id = ?",
+		"DELETE FROM data_source_retention WHERE org_id = ?",
+		"DELETE FROM environment WHERE org_id = ?",
+		"DELETE FROM data_source_environment WHERE org_id = ?",
+		"DELETE FROM query_pipeline WHERE org_id = ?",
+		"DELETE FROM query_pipeline_dashboard WHERE org_id = ?",
+		"DELETE FROM resource_label WHERE org_id = ?",
+		"DELETE FROM org_user WHERE org_id = ?",
+		"DELETE FROM org WHERE id = ?",
+		"DELETE FROM temp_user WHERE org_id = ?",
+		"DELETE FROM org_onboarding_step WHERE org_id = ?",
+		"DELETE FROM audit_event WHERE org_id = ?",
+		"DELETE FROM org_terms WHERE org_id = ?",
+		"DELETE FROM org_terms_acceptance WHERE org_id = ?",
+		"DELETE FROM schedule WHERE org_id = ?",
+		"DELETE FROM ngalert_configuration WHERE org_id = ?",
+		"DELETE FROM alert_configuration WHERE org_id = ?",
+		"DELETE FROM alert_instance WHERE rule_org_id = ?",
+		"DELETE FROM alert_notification WHERE org_id = ?",
+		"DELETE FROM alert_notification_state WHERE org_id = ?",
+		"DELETE FROM alert_rule WHERE org_id = ?",
+		"DELETE FROM alert_rule_tag WHERE EXISTS (SELECT 1 FROM alert WHERE alert.org_id = ? AND alert.id = alert_rule_tag.alert_id)",
+		"DELETE FROM alert_rule_version WHERE rule_org_id = ?",
+		"DELETE FROM alert WHERE org_id = ?",
+		"DELETE FROM annotation WHERE org_id = ?",
+		"DELETE FROM kv_store WHERE org_id = ?",
+	}
+
+	for _, sql := range deletes {
+		_, err := sess.Exec(sql, orgID)
+		if err != nil {
+			return err
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
 func verifyExistingOrg(sess *DBSession, orgId int64) error {
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/require"
 )
@@ -133,6 +134,33 @@ func TestAccountDataAccess(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, len(query.Result.OrgUsers), 1)
 			})
+
+			t.Run("Can get organization users filtered by access control permissions", func(t *testing.T) {
+				user := &models.SignedInUser{OrgId: ac1.OrgId, Permissions: map[int64]map[string][]string{
+					ac1.OrgId: {accesscontrol.ActionOrgUsersRead: {fmt.Sprintf("users:id:%d", ac1.Id)}},
+				}}
+
+				query := models.SearchOrgUsersQuery{
+					OrgID: ac1.OrgId,
+					Page:  1,
+					User:  user,
+				}
+				err = sqlStore.SearchOrgUsers(context.Background(), &query)
+				require.NoError(t, err)
+				require.Len(t, query.Result.OrgUsers, 1)
+				require.Equal(t, ac1.Id, query.Result.OrgUsers[0].UserId)
+				require.Equal(t, int64(1), query.Result.TotalCount)
+
+				getQuery := models.GetOrgUsersQuery{OrgId: ac1.OrgId, User: user}
+				err = sqlStore.GetOrgUsers(context.Background(), &getQuery)
+				require.NoError(t, err)
+				require.Len(t, getQuery.Result, 1)
+
+				user.Permissions[ac1.OrgId][accesscontrol.ActionOrgUsersRead] = []string{accesscontrol.ScopeUsersAll}
+				err = sqlStore.GetOrgUsers(context.Background(), &getQuery)
+				require.NoError(t, err)
+				require.Len(t, getQuery.Result, 2)
+			})
 		})
 
 		t.Run("Given two saved users", func(t *testing.T) {
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -28,6 +30,11 @@ func (ss *SQLStore) AddOrgUser(ctx context.Context, cmd *models.AddOrgUserComman
 			return models.ErrUserNotFound
 		}
 
+		// adding a deleted user back to an organization restores them
+		if _, err := restoreUserInTransaction(sess, user.Id); err != nil {
+			return err
+		}
+
 		if res, err := sess.Query("SELECT 1 from org_user WHERE org_id=? and user_id=?", cmd.OrgId, user.Id); err != nil {
 			return err
 		} else if len(res) == 1 {
@@ -50,9 +57,20 @@ func (ss *SQLStore) AddOrgUser(ctx context.Context, cmd *models.AddOrgUserComman
 
 		_, err := sess.Insert(&entity)
 		if err != nil {
+			// the user was added by a concurrent request
+			if dialect.IsUniqueConstraintViolation(err) {
+				return models.ErrOrgUserAlreadyAdded
+			}
 			return err
 		}
 
+		sess.publishAfterCommit(&events.OrgUserRoleChanged{
+			Timestamp: entity.Created,
+			OrgID:     cmd.OrgId,
+			UserID:    cmd.UserId,
+			Role:      string(cmd.Role),
+		})
+
 		var userOrgs []*models.UserOrgDTO
 		sess.Table("org_user")
 		sess.Join("INNER", "org", "org_user.org_id=org.id")
@@ -91,14 +109,59 @@ func (ss *SQLStore) UpdateOrgUser(ctx context.Context, cmd *models.UpdateOrgUser
 			return err
 		}
 
-		return validateOneAdminLeftInOrg(cmd.OrgId, sess)
+		if err := validateOneAdminLeftInOrg(cmd.OrgId, sess); err != nil {
+			return err
+		}
+
+		sess.publishAfterCommit(&events.OrgUserRoleChanged{
+			Timestamp: orgUser.Updated,
+			OrgID:     cmd.OrgId,
+			UserID:    cmd.UserId,
+			Role:      string(cmd.Role),
+		})
+		return nil
 	})
 }
 
 func (ss *SQLStore) GetOrgUsers(ctx context.Context, query *models.GetOrgUsersQuery) error {
 	query.Result = make([]*models.OrgUserDTO, 0)
 
-	sess := x.Table("org_user")
+	return ss.WithReadDbSession(withPreparedStatement(ctx), func(dbSession *DBSession) error {
+		if err := orgUsersQuery(dbSession, query); err != nil {
+			return err
+		}
+
+		if err := dbSession.Find(&query.Result); err != nil {
+			return err
+		}
+
+		for _, user := range query.Result {
+			user.LastSeenAtAge = util.GetAgeString(user.LastSeenAt)
+		}
+
+		return nil
+	})
+}
+
+// StreamOrgUsers calls fn with each of the users matching the query, in the order of GetOrgUsers, as they are read
+// from the database. The result of the query is left empty.
+func (ss *SQLStore) StreamOrgUsers(ctx context.Context, query *models.GetOrgUsersQuery, fn func(user *models.OrgUserDTO) error) error {
+	return ss.WithDbSession(ctx, func(dbSession *DBSession) error {
+		if err := orgUsersQuery(dbSession, query); err != nil {
+			return err
+		}
+
+		return dbSession.StreamRows(ctx, &models.OrgUserDTO{}, func(row interface{}) error {
+			user := row.(*models.OrgUserDTO)
+			user.LastSeenAtAge = util.GetAgeString(user.LastSeenAt)
+			return fn(user)
+		})
+	})
+}
+
+// orgUsersQuery prepares the query of the users of an organization in the session
+func orgUsersQuery(dbSession *DBSession, query *models.GetOrgUsersQuery) error {
+	sess := dbSession.Table("org_user")
 	sess.Join("INNER", x.Dialect().Quote("user"), fmt.Sprintf("org_user.user_id=%s.id", x.Dialect().Quote("user")))
 
 	whereConditions := make([]string, 0)
@@ -117,6 +180,15 @@ func (ss *SQLStore) GetOrgUsers(ctx context.Context, query *models.GetOrgUsersQu
 		whereParams = append(whereParams, queryWithWildcards, queryWithWildcards, queryWithWildcards)
 	}
 
+	if query.User != nil {
+		acFilter, err := accesscontrol.Filter(query.User, "org_user.user_id", "users", accesscontrol.ActionOrgUsersRead)
+		if err != nil {
+			return err
+		}
+		whereConditions = append(whereConditions, acFilter.Where)
+		whereParams = append(whereParams, acFilter.Args...)
+	}
+
 	if len(whereConditions) > 0 {
 		sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
 	}
@@ -135,15 +207,6 @@ func (ss *SQLStore) GetOrgUsers(ctx context.Context, query *models.GetOrgUsersQu
 		"user.last_seen_at",
 	)
 	sess.Asc("user.email", "user.login")
-
-	if err := sess.Find(&query.Result); err != nil {
-		return err
-	}
-
-	for _, user := range query.Result {
-		user.LastSeenAtAge = util.GetAgeString(user.LastSeenAt)
-	}
-
 	return nil
 }
 
@@ -152,69 +215,80 @@ func (ss *SQLStore) SearchOrgUsers(ctx context.Context, query *models.SearchOrgU
 		OrgUsers: make([]*models.OrgUserDTO, 0),
 	}
 
-	sess := x.Table("org_user")
-	sess.Join("INNER", x.Dialect().Quote("user"), fmt.Sprintf("org_user.user_id=%s.id", x.Dialect().Quote("user")))
+	return ss.WithReadDbSession(ctx, func(dbSession *DBSession) error {
+		sess := dbSession.Table("org_user")
+		sess.Join("INNER", x.Dialect().Quote("user"), fmt.Sprintf("org_user.user_id=%s.id", x.Dialect().Quote("user")))
 
-	whereConditions := make([]string, 0)
-	whereParams := make([]interface{}, 0)
+		whereConditions := make([]string, 0)
+		whereParams := make([]interface{}, 0)
 
-	whereConditions = append(whereConditions, "org_user.org_id = ?")
-	whereParams = append(whereParams, query.OrgID)
+		whereConditions = append(whereConditions, "org_user.org_id = ?")
+		whereParams = append(whereParams, query.OrgID)
 
-	// TODO: add to chore, for cleaning up after we have created
-	// service accounts table in the modelling
-	whereConditions = append(whereConditions, fmt.Sprintf("%s.is_service_account = false", x.Dialect().Quote("user")))
+		// TODO: add to chore, for cleaning up after we have created
+		// service accounts table in the modelling
+		whereConditions = append(whereConditions, fmt.Sprintf("%s.is_service_account = false", x.Dialect().Quote("user")))
 
-	if query.Query != "" {
-		queryWithWildcards := "%" + query.Query + "%"
-		whereConditions = append(whereConditions, "(email "+dialect.LikeStr()+" ? OR name "+dialect.LikeStr()+" ? OR login "+dialect.LikeStr()+" ?)")
-		whereParams = append(whereParams, queryWithWildcards, queryWithWildcards, queryWithWildcards)
-	}
+		if query.Query != "" {
+			queryWithWildcards := "%" + query.Query + "%"
+			whereConditions = append(whereConditions, "(email "+dialect.LikeStr()+" ? OR name "+dialect.LikeStr()+" ? OR login "+dialect.LikeStr()+" ?)")
+			whereParams = append(whereParams, queryWithWildcards, queryWithWildcards, queryWithWildcards)
+		}
 
-	if len(whereConditions) > 0 {
-		sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
-	}
+		if query.User != nil {
+			acFilter, err := accesscontrol.Filter(query.User, "org_user.user_id", "users", accesscontrol.ActionOrgUsersRead)
+			if err != nil {
+				return err
+			}
+			whereConditions = append(whereConditions, acFilter.Where)
+			whereParams = append(whereParams, acFilter.Args...)
+		}
 
-	if query.Limit > 0 {
-		offset := query.Limit * (query.Page - 1)
-		sess.Limit(query.Limit, offset)
-	}
+		if len(whereConditions) > 0 {
+			sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+		}
 
-	sess.Cols(
-		"org_user.org_id",
-		"org_user.user_id",
-		"user.email",
-		"user.name",
-		"user.login",
-		"org_user.role",
-		"user.last_seen_at",
-	)
-	sess.Asc("user.email", "user.login")
+		if query.Limit > 0 {
+			offset := query.Limit * (query.Page - 1)
+			sess.Limit(query.Limit, offset)
+		}
 
-	if err := sess.Find(&query.Result.OrgUsers); err != nil {
-		return err
-	}
+		sess.Cols(
+			"org_user.org_id",
+			"org_user.user_id",
+			"user.email",
+			"user.name",
+			"user.login",
+			"org_user.role",
+			"user.last_seen_at",
+		)
+		sess.Asc("user.email", "user.login")
+
+		if err := sess.Find(&query.Result.OrgUsers); err != nil {
+			return err
+		}
 
-	// get total count
-	orgUser := models.OrgUser{}
-	countSess := x.Table("org_user").
-		Join("INNER", x.Dialect().Quote("user"), fmt.Sprintf("org_user.user_id=%s.id", x.Dialect().Quote("user")))
+		// get total count
+		orgUser := models.OrgUser{}
+		countSess := dbSession.Table("org_user").
+			Join("INNER", x.Dialect().Quote("user"), fmt.Sprintf("org_user.user_id=%s.id", x.Dialect().Quote("user")))
 
-	if len(whereConditions) > 0 {
-		countSess.Where(strings.Join(whereConditions, " AND "), whereParams...)
-	}
+		if len(whereConditions) > 0 {
+			countSess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+		}
 
-	count, err := countSess.Count(&orgUser)
-	if err != nil {
-		return err
-	}
-	query.Result.TotalCount = count
+		count, err := countSess.Count(&orgUser)
+		if err != nil {
+			return err
+		}
+		query.Result.TotalCount = count
 
-	for _, user := range query.Result.OrgUsers {
-		user.LastSeenAtAge = util.GetAgeString(user.LastSeenAt)
-	}
+		for _, user := range query.Result.OrgUsers {
+			user.LastSeenAtAge = util.GetAgeString(user.LastSeenAt)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (ss *SQLStore) RemoveOrgUser(ctx context.Context, cmd *models.RemoveOrgUserCommand) error {
@@ -231,6 +305,8 @@ func (ss *SQLStore) RemoveOrgUser(ctx context.Context, cmd *models.RemoveOrgUser
 			"DELETE FROM org_user WHERE org_id=? and user_id=?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and user_id = ?",
 			"DELETE FROM team_member WHERE org_id=? and user_id = ?",
+			"DELETE FROM api_key_permission WHERE org_id=? and api_key_id IN (SELECT id FROM api_key WHERE user_id = ?)",
+			"DELETE FROM api_key WHERE org_id=? and user_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -245,6 +321,12 @@ func (ss *SQLStore) RemoveOrgUser(ctx context.Context, cmd *models.RemoveOrgUser
 			return err
 		}
 
+		sess.publishAfterCommit(&events.OrgUserRoleChanged{
+			Timestamp: time.Now(),
+			OrgID:     cmd.OrgId,
+			UserID:    cmd.UserId,
+		})
+
 		// check user other orgs and update user current org
 		var userOrgs []*models.UserOrgDTO
 		sess.Table("org_user")
@@ -273,8 +355,13 @@ func (ss *SQLStore) RemoveOrgUser(ctx context.Context, cmd *models.RemoveOrgUser
 				}
 			}
 		} else if cmd.ShouldDeleteOrphanedUser {
-			// no other orgs, delete the full user
-			if err := deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: user.Id}); err != nil {
+			// no other orgs, delete the full user, or keep it deleted until the retention of the deleted users is over
+			if ss.Cfg.Cleanup.DeletedUsersRetention > 0 {
+				err = softDeleteUserInTransaction(sess, user.Id, cmd.OrgId)
+			} else {
+				err = deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: user.Id})
+			}
+			if err != nil {
 				return err
 			}
 
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
@@ -73,3 +74,20 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 	params = append(params, okRoles...)
 	return sql, params
 }
+
+// AccessControlDashboardFilter restricts the dashboards and folders to the ones the user can read according to
+// their access control permissions. Scopes on folders grant access to the folder and to the dashboards in it.
+// Dashboard permissions still apply and must be filtered with a DashboardPermissionFilter.
+type AccessControlDashboardFilter struct {
+	User *models.SignedInUser
+}
+
+func (f AccessControlDashboardFilter) Where() (string, []interface{}) {
+	// Filter returns a condition matching no rows when it fails, which is what we want here.
+	dashboards, _ := accesscontrol.Filter(f.User, "dashboard.id", "dashboards", accesscontrol.ActionDashboardsRead)
+	folders, _ := accesscontrol.Filter(f.User, "dashboard.id", "folders", accesscontrol.ActionDashboardsRead)
+	folderDashboards, _ := accesscontrol.Filter(f.User, "dashboard.folder_id", "folders", accesscontrol.ActionDashboardsRead)
+
+	filter := accesscontrol.FilterAny(dashboards, folders, folderDashboards)
+	return filter.Where, filter.Args
+}
@@ -19,9 +19,10 @@ func (ss *SQLStore) addPlaylistQueryAndCommandHandlers() {
 func (ss *SQLStore) CreatePlaylist(ctx context.Context, cmd *models.CreatePlaylistCommand) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
 		playlist := models.Playlist{
-			Name:     cmd.Name,
-			Interval: cmd.Interval,
-			OrgId:    cmd.OrgId,
+			Name:        cmd.Name,
+			Interval:    cmd.Interval,
+			OrgId:       cmd.OrgId,
+			ScheduleUid: cmd.ScheduleUid,
 		}
 
 		_, err := sess.Insert(&playlist)
@@ -50,10 +51,11 @@ func (ss *SQLStore) CreatePlaylist(ctx context.Context, cmd *models.CreatePlayli
 func (ss *SQLStore) UpdatePlaylist(ctx context.Context, cmd *models.UpdatePlaylistCommand) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
 		playlist := models.Playlist{
-			Id:       cmd.Id,
-			OrgId:    cmd.OrgId,
-			Name:     cmd.Name,
-			Interval: cmd.Interval,
+			Id:          cmd.Id,
+			OrgId:       cmd.OrgId,
+			Name:        cmd.Name,
+			Interval:    cmd.Interval,
+			ScheduleUid: cmd.ScheduleUid,
 		}
 
 		existingPlaylist := sess.Where("id = ? AND org_id = ?", cmd.Id, cmd.OrgId).Find(models.Playlist{})
@@ -63,13 +65,14 @@ func (ss *SQLStore) UpdatePlaylist(ctx context.Context, cmd *models.UpdatePlayli
 		}
 
 		cmd.Result = &models.PlaylistDTO{
-			Id:       playlist.Id,
-			OrgId:    playlist.OrgId,
-			Name:     playlist.Name,
-			Interval: playlist.Interval,
+			Id:          playlist.Id,
+			OrgId:       playlist.OrgId,
+			Name:        playlist.Name,
+			Interval:    playlist.Interval,
+			ScheduleUid: playlist.ScheduleUid,
 		}
 
-		_, err := sess.ID(cmd.Id).Cols("name", "interval").Update(&playlist)
+		_, err := sess.ID(cmd.Id).Cols("name", "interval", "schedule_uid").Update(&playlist)
 
 		if err != nil {
 			return err
@@ -19,10 +19,17 @@ func TestPlaylistDataAccess(t *testing.T) {
 			{Title: "graphite", Value: "graphite", Type: "dashboard_by_tag"},
 			{Title: "Backend response times", Value: "3", Type: "dashboard_by_id"},
 		}
-		cmd := models.CreatePlaylistCommand{Name: "NYC office", Interval: "10m", OrgId: 1, Items: items}
+		cmd := models.CreatePlaylistCommand{Name: "NYC office", Interval: "10m", ScheduleUid: "office-hours", OrgId: 1, Items: items}
 		err := ss.CreatePlaylist(context.Background(), &cmd)
 		require.NoError(t, err)
 
+		t.Run("Can get playlist with its schedule", func(t *testing.T) {
+			getQuery := models.GetPlaylistByIdQuery{Id: cmd.Result.Id}
+			err := ss.GetPlaylist(context.Background(), &getQuery)
+			require.NoError(t, err)
+			require.Equal(t, "office-hours", getQuery.Result.ScheduleUid)
+		})
+
 		t.Run("Can update playlist", func(t *testing.T) {
 			items := []models.PlaylistItemDTO{
 				{Title: "influxdb", Value: "influxdb", Type: "dashboard_by_tag"},
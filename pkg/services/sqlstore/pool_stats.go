@@ -0,0 +1,119 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"xorm.io/xorm"
+)
+
+const (
+	PoolPrimary = "primary"
+	PoolReplica = "replica"
+)
+
+// PoolStats are the settings and the statistics of a connection pool of the database
+type PoolStats struct {
+	Name string
+	// Healthy tells whether the reads are sent to the read replica, it is always true for the primary database
+	Healthy          bool
+	MaxIdleConn      int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration
+	sql.DBStats
+}
+
+// GetPoolStats returns the statistics of the connection pool of the primary database, and of the read replica when
+// one is configured.
+func (ss *SQLStore) GetPoolStats() []PoolStats {
+	stats := []PoolStats{{
+		Name:             PoolPrimary,
+		Healthy:          true,
+		MaxIdleConn:      ss.dbCfg.MaxIdleConn,
+		ConnMaxLifetime:  time.Duration(ss.dbCfg.ConnMaxLifetime) * time.Second,
+		StatementTimeout: ss.dbCfg.StatementTimeout,
+		DBStats:          ss.engine.DB().Stats(),
+	}}
+	if ss.replica != nil {
+		ss.replica.mu.Lock()
+		healthy := ss.replica.healthy
+		ss.replica.mu.Unlock()
+
+		stats = append(stats, PoolStats{
+			Name:             PoolReplica,
+			Healthy:          healthy,
+			MaxIdleConn:      ss.replicaCfg.MaxIdleConn,
+			ConnMaxLifetime:  time.Duration(ss.replicaCfg.ConnMaxLifetime) * time.Second,
+			StatementTimeout: ss.replicaCfg.StatementTimeout,
+			DBStats:          ss.replica.engine.DB().Stats(),
+		})
+	}
+	return stats
+}
+
+// poolCollector exposes the statistics of the connection pools to Prometheus, labeled with the name of the pool
+type poolCollector struct {
+	engines func() map[string]*xorm.Engine
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newPoolCollector(ss *SQLStore) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("grafana", "database", name), help, []string{"pool"}, nil)
+	}
+	return &poolCollector{
+		engines: func() map[string]*xorm.Engine {
+			engines := map[string]*xorm.Engine{PoolPrimary: ss.engine}
+			if ss.replica != nil {
+				engines[PoolReplica] = ss.replica.engine
+			}
+			return engines
+		},
+		maxOpen:           desc("conn_max_open", "Maximum number of open connections to the database"),
+		open:              desc("conn_open", "The number of established connections both in use and idle"),
+		inUse:             desc("conn_in_use", "The number of connections currently in use"),
+		idle:              desc("conn_idle", "The number of idle connections"),
+		waitCount:         desc("conn_wait_count_total", "The total number of connections waited for"),
+		waitDuration:      desc("conn_wait_duration_seconds_total", "The total time blocked waiting for a new connection"),
+		maxIdleClosed:     desc("conn_max_idle_closed_total", "The total number of connections closed due to the max idle connections"),
+		maxIdleTimeClosed: desc("conn_max_idle_time_closed_total", "The total number of connections closed due to the max idle time"),
+		maxLifetimeClosed: desc("conn_max_lifetime_closed_total", "The total number of connections closed due to the max lifetime"),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for pool, engine := range c.engines() {
+		stats := engine.DB().Stats()
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse), pool)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle), pool)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount), pool)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), pool)
+		ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed), pool)
+		ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed), pool)
+		ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), pool)
+	}
+}
@@ -0,0 +1,143 @@
+package sqlstore
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"xorm.io/xorm"
+)
+
+var (
+	tracer = otel.Tracer("github.com/grafana/grafana/pkg/services/sqlstore")
+
+	// slowQueryThreshold is the duration above which the store calls are logged, they are not logged when zero
+	slowQueryThreshold time.Duration
+	// countRows is true when the database driver counts the rows returned and affected by the statements
+	countRows bool
+)
+
+// sessionHelpers are the functions of the package opening the sessions, the store calls are named after the
+// function calling them
+var sessionHelpers = map[string]bool{
+	"WithDbSession":              true,
+	"withDbSession":              true,
+	"WithReadDbSession":          true,
+	"withReadDbSession":          true,
+	"WithTransactionalDbSession": true,
+	"InTransaction":              true,
+	"inTransactionWithRetry":     true,
+	"inTransactionWithRetryCtx":  true,
+	"inTransactionAttempt":       true,
+	"inTransaction":              true,
+	"inTransactionCtx":           true,
+	"startQuery":                 true,
+}
+
+// queryStatsKey is the context key of the statistics of the running store call
+type queryStatsKey struct{}
+
+// queryStats are the statistics of a store call, the rows are also counted in the calls it is nested in
+type queryStats struct {
+	rows   int64
+	parent *queryStats
+}
+
+func (s *queryStats) addRows(n int64) {
+	for ; s != nil; s = s.parent {
+		atomic.AddInt64(&s.rows, n)
+	}
+}
+
+// addQueryRows counts rows returned or affected by a statement in the store call of the context
+func addQueryRows(ctx context.Context, n int64) {
+	if stats, ok := ctx.Value(queryStatsKey{}).(*queryStats); ok {
+		stats.addRows(n)
+	}
+}
+
+// startQuery starts the span of a store call, named after the function which opened the session such as
+// GetOrgUsers. The returned function ends the span, and logs the call when it is slower than the slow query
+// threshold.
+func startQuery(ctx context.Context, engine *xorm.Engine) (context.Context, func(error)) {
+	name := queryName()
+	parent, _ := ctx.Value(queryStatsKey{}).(*queryStats)
+	stats := &queryStats{parent: parent}
+	ctx = context.WithValue(ctx, queryStatsKey{}, stats)
+	ctx, span := tracer.Start(ctx, "sqlstore."+name)
+	start := time.Now()
+
+	return ctx, func(err error) {
+		elapsed := time.Since(start)
+		rows := atomic.LoadInt64(&stats.rows)
+
+		attributes := []attribute.KeyValue{
+			attribute.String("db.system", engine.DriverName()),
+			attribute.String("db.query_name", name),
+			attribute.Int64("db.duration_ms", elapsed.Milliseconds()),
+		}
+		if countRows {
+			attributes = append(attributes, attribute.Int64("db.rows", rows))
+		}
+		span.SetAttributes(attributes...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		if slowQueryThreshold > 0 && elapsed >= slowQueryThreshold {
+			logArgs := []interface{}{"name", name, "duration", elapsed}
+			if countRows {
+				logArgs = append(logArgs, "rows", rows)
+			}
+			if err != nil {
+				logArgs = append(logArgs, "error", err)
+			}
+			sqlog.Warn("Slow database query", logArgs...)
+		}
+	}
+}
+
+// queryName returns the name of the function which called the session helpers, without the package when it is a
+// function of this package.
+func queryName() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		pkg, name := splitFunctionName(frame.Function)
+		if pkg != "sqlstore" || !sessionHelpers[name] {
+			if pkg == "sqlstore" {
+				return name
+			}
+			return pkg + "." + name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// splitFunctionName returns the package and the name of a function from its fully qualified name, skipping the
+// receiver and the closures: github.com/grafana/grafana/pkg/services/sqlstore.(*SQLStore).GetOrgUsers.func1 is the
+// GetOrgUsers function of the sqlstore package.
+func splitFunctionName(function string) (string, string) {
+	function = function[strings.LastIndex(function, "/")+1:]
+	parts := strings.Split(function, ".")
+	name := ""
+	for _, part := range parts[1:] {
+		closure := strings.HasPrefix(part, "func") && strings.Trim(part[len("func"):], "0123456789") == ""
+		if strings.HasPrefix(part, "(") || closure || strings.Trim(part, "0123456789") == "" {
+			continue
+		}
+		name = part
+	}
+	return parts[0], name
+}
@@ -0,0 +1,99 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestSplitFunctionName(t *testing.T) {
+	tests := map[string][2]string{
+		"github.com/grafana/grafana/pkg/services/sqlstore.(*SQLStore).GetOrgUsers":              {"sqlstore", "GetOrgUsers"},
+		"github.com/grafana/grafana/pkg/services/sqlstore.(*SQLStore).GetOrgUsers.func1":        {"sqlstore", "GetOrgUsers"},
+		"github.com/grafana/grafana/pkg/services/sqlstore.UpdateOrg.func1.2":                    {"sqlstore", "UpdateOrg"},
+		"github.com/grafana/grafana/pkg/services/environments.(*Service).getEnvironments.func1": {"environments", "getEnvironments"},
+		"github.com/grafana/grafana/pkg/services/sqlstore.SQLStore.GetOrgUsers":                 {"sqlstore", "GetOrgUsers"},
+	}
+	for function, expected := range tests {
+		pkg, name := splitFunctionName(function)
+		assert.Equal(t, expected, [2]string{pkg, name}, function)
+	}
+}
+
+func TestQueryTracing(t *testing.T) {
+	ctx := context.Background()
+	ss := InitTestDB(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		values := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			values[kv.Key] = kv.Value
+		}
+		return values
+	}
+
+	t.Run("should trace the store calls with their name", func(t *testing.T) {
+		err := ss.GetOrgUsers(ctx, &models.GetOrgUsersQuery{OrgId: 1})
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.NotEmpty(t, spans)
+		span := spans[len(spans)-1]
+		assert.Equal(t, "sqlstore.GetOrgUsers", span.Name())
+		assert.Equal(t, "GetOrgUsers", attributes(span)["db.query_name"].AsString())
+	})
+
+	t.Run("should name the store calls after the function opening the session", func(t *testing.T) {
+		err := ss.WithDbSession(ctx, func(sess *DBSession) error { return nil })
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		assert.Equal(t, "sqlstore.TestQueryTracing", spans[len(spans)-1].Name())
+	})
+
+	t.Run("should count the rows of the store calls", func(t *testing.T) {
+		engine, err := xorm.NewEngine(WrapDatabaseDriverWithHooks(migrator.SQLite), filepath.Join(t.TempDir(), "rows.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			countRows = false
+			require.NoError(t, engine.Close())
+		})
+
+		err = inTransactionWithRetryCtx(ctx, engine, func(sess *DBSession) error {
+			if _, err := sess.Exec("CREATE TABLE marker (name TEXT)"); err != nil {
+				return err
+			}
+			_, err := sess.Exec("INSERT INTO marker (name) VALUES ('a'), ('b'), ('c')")
+			return err
+		}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), attributes(recorder.Ended()[len(recorder.Ended())-1])["db.rows"].AsInt64())
+
+		var names []string
+		err = withDbSession(ctx, engine, func(sess *DBSession) error {
+			return sess.SQL("SELECT name FROM marker WHERE name <> 'c'").Find(&names)
+		})
+		require.NoError(t, err)
+		assert.Len(t, names, 2)
+		assert.Equal(t, int64(2), attributes(recorder.Ended()[len(recorder.Ended())-1])["db.rows"].AsInt64())
+	})
+}
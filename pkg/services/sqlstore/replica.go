@@ -0,0 +1,276 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// replicaPingTimeout bounds the health checks of the read replica
+const replicaPingTimeout = 2 * time.Second
+
+// ReplicaConfig configures the read replica of the database. The connection settings left empty are the ones of the
+// primary database.
+type ReplicaConfig struct {
+	URL         string
+	Host        string
+	Name        string
+	User        string
+	Pwd         string
+	MaxOpenConn int
+	MaxIdleConn int
+	// ConnMaxLifetime is the maximum lifetime of the connections in seconds
+	ConnMaxLifetime  int
+	StatementTimeout time.Duration
+	// MaxLag is the replication lag above which the reads are sent to the primary database
+	MaxLag time.Duration
+	// HealthCheckInterval is how often the availability and the lag of the replica are checked
+	HealthCheckInterval time.Duration
+}
+
+// replica sends the reads to a read replica of the database while it is up and its replication lag is under the
+// tolerance. Its health is checked in the background, at most once per interval, when reads are routed.
+type replica struct {
+	engine   *xorm.Engine
+	dbType   string
+	maxLag   time.Duration
+	interval time.Duration
+	log      log.Logger
+	now      func() time.Time
+	// lagFn returns the replication lag, it is stubbable by tests
+	lagFn func(ctx context.Context) (time.Duration, error)
+
+	mu        sync.Mutex
+	healthy   bool
+	lastCheck time.Time
+	checking  bool
+}
+
+func newReplica(engine *xorm.Engine, dbType string, maxLag, interval time.Duration) *replica {
+	r := &replica{
+		engine:   engine,
+		dbType:   dbType,
+		maxLag:   maxLag,
+		interval: interval,
+		log:      log.New("sqlstore.replica"),
+		now:      time.Now,
+	}
+	r.lagFn = r.lag
+	return r
+}
+
+// available tells whether the reads can be sent to the replica, and starts a health check when the last one is older
+// than the interval
+func (r *replica) available() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.checking && r.now().Sub(r.lastCheck) >= r.interval {
+		r.checking = true
+		go r.check(context.Background())
+	}
+	return r.healthy
+}
+
+// check pings the replica and measures its replication lag
+func (r *replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+
+	healthy := true
+	if err := r.engine.PingContext(ctx); err != nil {
+		r.log.Warn("Read replica is down, reading from the primary database", "error", err)
+		healthy = false
+	} else if lag, err := r.lagFn(ctx); err != nil {
+		r.log.Warn("Failed to get the replication lag, reading from the primary database", "error", err)
+		healthy = false
+	} else if lag > r.maxLag {
+		r.log.Warn("Read replica is lagging, reading from the primary database", "lag", lag, "max_lag", r.maxLag)
+		healthy = false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if healthy && !r.healthy {
+		r.log.Info("Reading from the read replica")
+	}
+	r.healthy = healthy
+	r.lastCheck = r.now()
+	r.checking = false
+}
+
+// markDown stops sending the reads to the replica until the next health check
+func (r *replica) markDown(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.healthy {
+		r.log.Warn("Read replica is down, reading from the primary database", "error", err)
+	}
+	r.healthy = false
+	r.lastCheck = r.now()
+}
+
+// lag returns the replication lag of the replica
+func (r *replica) lag(ctx context.Context) (time.Duration, error) {
+	sess := r.engine.NewSession().Context(ctx)
+	defer sess.Close()
+
+	switch r.dbType {
+	case migrator.Postgres:
+		// the lag is unknown until a transaction has been replayed, and zero on a primary database
+		var seconds float64
+		_, err := sess.SQL(`SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)`).Get(&seconds)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	case migrator.MySQL:
+		rows, err := sess.QueryString("SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+		if len(rows) == 0 {
+			return 0, nil
+		}
+		seconds, err := strconv.ParseInt(rows[0]["Seconds_Behind_Master"], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, nil
+}
+
+type contextPrimaryKey struct{}
+
+// writeTracker tells whether a write has been made with a context, so that the following reads go to the primary
+// database
+type writeTracker struct {
+	wrote int32
+}
+
+// WithPrimary returns a context whose reads are made on the primary database, for the reads which must see the
+// writes made just before them.
+func WithPrimary(ctx context.Context) context.Context {
+	tracker := &writeTracker{wrote: 1}
+	return context.WithValue(ctx, contextPrimaryKey{}, tracker)
+}
+
+// TrackWrites returns a context whose reads are made on the read replica until a transaction is committed with it,
+// the reads are made on the primary database after. The context handler tracks the writes of each request this way.
+func TrackWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextPrimaryKey{}).(*writeTracker); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextPrimaryKey{}, &writeTracker{})
+}
+
+func markWrite(ctx context.Context) {
+	if tracker, ok := ctx.Value(contextPrimaryKey{}).(*writeTracker); ok {
+		atomic.StoreInt32(&tracker.wrote, 1)
+	}
+}
+
+// readFromPrimary tells whether the reads made with the context must go to the primary database: within
+// transactions, after a write, or when requested with WithPrimary
+func readFromPrimary(ctx context.Context) bool {
+	if _, ok := ctx.Value(ContextSessionKey{}).(*DBSession); ok {
+		return true
+	}
+	if tracker, ok := ctx.Value(contextPrimaryKey{}).(*writeTracker); ok {
+		return atomic.LoadInt32(&tracker.wrote) == 1
+	}
+	return false
+}
+
+// WithReadDbSession calls the callback with a session on the read replica when one is configured and available, or
+// on the primary database otherwise. The callback must only read, and is retried on the primary database when the
+// replica fails.
+func (ss *SQLStore) WithReadDbSession(ctx context.Context, callback dbTransactionFunc) error {
+	return withReadDbSession(ctx, ss.engine, ss.replica, callback)
+}
+
+func withReadDbSession(ctx context.Context, primary *xorm.Engine, r *replica, callback dbTransactionFunc) error {
+	if r == nil || readFromPrimary(ctx) || !r.available() {
+		return withDbSession(ctx, primary, callback)
+	}
+
+	err := withDbSession(ctx, r.engine, callback)
+	if err == nil {
+		return nil
+	}
+	// the errors of the callback, like not found errors, are returned as is while the replica is up
+	pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+	if pingErr := r.engine.PingContext(pingCtx); pingErr != nil {
+		r.markDown(pingErr)
+		return withDbSession(ctx, primary, callback)
+	}
+	return err
+}
+
+// initReplica connects to the read replica, when one is configured. The replicas of SQLite databases are not
+// supported.
+func (ss *SQLStore) initReplica() error {
+	cfg := ss.replicaCfg
+	if cfg.URL == "" && cfg.Host == "" {
+		return nil
+	}
+	// the type of the primary database is the name of the wrapped driver when the database metrics are enabled
+	dbType := ss.Dialect.DriverName()
+	if dbType == migrator.SQLite {
+		ss.log.Warn("Read replicas are not supported with SQLite, reading from the primary database")
+		return nil
+	}
+
+	dbCfg := ss.dbCfg
+	dbCfg.Type = dbType
+	dbCfg.ConnectionString = ""
+	if cfg.URL != "" {
+		if err := parseDatabaseURL(cfg.URL, &dbCfg); err != nil {
+			return fmt.Errorf("invalid read replica url: %w", err)
+		}
+	}
+	if cfg.Host != "" {
+		dbCfg.Host = cfg.Host
+	}
+	if cfg.Name != "" {
+		dbCfg.Name = cfg.Name
+	}
+	if cfg.User != "" {
+		dbCfg.User = cfg.User
+	}
+	if cfg.Pwd != "" {
+		dbCfg.Pwd = cfg.Pwd
+	}
+	dbCfg.StatementTimeout = cfg.StatementTimeout
+	if dbCfg.Type != dbType {
+		return fmt.Errorf("read replica type %q does not match the database type %q", dbCfg.Type, dbType)
+	}
+
+	connectionString, err := ss.connectionString(&dbCfg)
+	if err != nil {
+		return err
+	}
+	engine, err := xorm.NewEngine(ss.engine.DriverName(), connectionString)
+	if err != nil {
+		return err
+	}
+	engine.SetMaxOpenConns(cfg.MaxOpenConn)
+	engine.SetMaxIdleConns(cfg.MaxIdleConn)
+	engine.SetConnMaxLifetime(time.Second * time.Duration(cfg.ConnMaxLifetime))
+	engine.SetLogger(ss.engine.Logger())
+
+	ss.log.Info("Connecting to the read replica", "dbtype", dbCfg.Type, "max_lag", cfg.MaxLag)
+	ss.replica = newReplica(engine, dbCfg.Type, cfg.MaxLag, cfg.HealthCheckInterval)
+	ss.replica.check(context.Background())
+	return nil
+}
@@ -0,0 +1,131 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestReadReplica(t *testing.T) {
+	ctx := context.Background()
+	ss := InitTestDB(t)
+
+	engine, err := xorm.NewEngine(migrator.SQLite, filepath.Join(t.TempDir(), "replica.db"))
+	require.NoError(t, err)
+	r := newReplica(engine, migrator.SQLite, 5*time.Second, time.Hour)
+	var lag time.Duration
+	r.lagFn = func(context.Context) (time.Duration, error) { return lag, nil }
+
+	// the shared test store is left without replica after the test
+	ss.replica = r
+	t.Cleanup(func() {
+		ss.replica = nil
+		_, err := ss.engine.Exec("DROP TABLE replica_marker")
+		require.NoError(t, err)
+	})
+
+	for name, e := range map[string]*xorm.Engine{"primary": ss.engine, "replica": engine} {
+		_, err := e.Exec("CREATE TABLE replica_marker (name TEXT)")
+		require.NoError(t, err)
+		_, err = e.Exec("INSERT INTO replica_marker (name) VALUES (?)", name)
+		require.NoError(t, err)
+	}
+	readFrom := func(ctx context.Context) string {
+		t.Helper()
+		var name string
+		err := ss.WithReadDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.SQL("SELECT name FROM replica_marker").Get(&name)
+			return err
+		})
+		require.NoError(t, err)
+		return name
+	}
+	r.check(ctx)
+
+	t.Run("should read from the replica", func(t *testing.T) {
+		assert.Equal(t, "replica", readFrom(ctx))
+	})
+
+	t.Run("should read from the primary when requested", func(t *testing.T) {
+		assert.Equal(t, "primary", readFrom(WithPrimary(ctx)))
+	})
+
+	t.Run("should read from the primary within transactions", func(t *testing.T) {
+		err := ss.InTransaction(ctx, func(ctx context.Context) error {
+			assert.Equal(t, "primary", readFrom(ctx))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should read from the primary after a write", func(t *testing.T) {
+		ctx := TrackWrites(ctx)
+		assert.Equal(t, "replica", readFrom(ctx))
+
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.Exec("UPDATE replica_marker SET name = ?", "primary")
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "primary", readFrom(ctx))
+	})
+
+	t.Run("should keep reading from the replica after a failed write", func(t *testing.T) {
+		ctx := TrackWrites(ctx)
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			return errors.New("failed")
+		})
+		require.Error(t, err)
+		assert.Equal(t, "replica", readFrom(ctx))
+	})
+
+	t.Run("should return the errors of the reads while the replica is up", func(t *testing.T) {
+		err := ss.WithReadDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.Exec("SELECT * FROM missing_table")
+			return err
+		})
+		require.Error(t, err)
+		assert.True(t, r.available())
+	})
+
+	t.Run("should report the statistics of both connection pools", func(t *testing.T) {
+		stats := ss.GetPoolStats()
+		require.Len(t, stats, 2)
+		assert.Equal(t, PoolPrimary, stats[0].Name)
+		assert.Equal(t, PoolReplica, stats[1].Name)
+		assert.True(t, stats[1].Healthy)
+		assert.Positive(t, stats[1].OpenConnections)
+
+		// each of the metrics is labeled with the name of the pool
+		assert.Equal(t, 18, testutil.CollectAndCount(newPoolCollector(ss)))
+	})
+
+	t.Run("should read from the primary while the replica is lagging", func(t *testing.T) {
+		lag = 10 * time.Second
+		r.check(ctx)
+		assert.Equal(t, "primary", readFrom(ctx))
+		assert.False(t, ss.GetPoolStats()[1].Healthy)
+
+		lag = time.Second
+		r.check(ctx)
+		assert.Equal(t, "replica", readFrom(ctx))
+	})
+
+	t.Run("should fall back to the primary when the replica is down", func(t *testing.T) {
+		require.NoError(t, engine.Close())
+		assert.Equal(t, "primary", readFrom(ctx))
+		assert.False(t, r.available())
+	})
+}
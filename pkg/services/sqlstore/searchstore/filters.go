@@ -86,6 +86,29 @@ func (f TitleFilter) Where() (string, []interface{}) {
 	return fmt.Sprintf("dashboard.title %s ?", f.Dialect.LikeStr()), []interface{}{"%" + f.Title + "%"}
 }
 
+// TitleTermsFilter matches the dashboards whose title contains the title searched for, or whose analyzed title has
+// terms starting with each of the analyzed terms of the title searched for
+type TitleTermsFilter struct {
+	Dialect migrator.Dialect
+	OrgId   int64
+	Title   string
+	Terms   []string
+}
+
+func (f TitleTermsFilter) Where() (string, []interface{}) {
+	sql := fmt.Sprintf("(dashboard.title %s ?", f.Dialect.LikeStr())
+	params := []interface{}{"%" + f.Title + "%"}
+	if len(f.Terms) > 0 {
+		matches := make([]string, 0, len(f.Terms))
+		for _, term := range f.Terms {
+			matches = append(matches, "dashboard.id IN (SELECT dashboard_id FROM dashboard_search_term WHERE org_id = ? AND term LIKE ?)")
+			params = append(params, f.OrgId, term+"%")
+		}
+		sql += " OR (" + strings.Join(matches, " AND ") + ")"
+	}
+	return sql + ")", params
+}
+
 type FolderFilter struct {
 	IDs []int64
 }
@@ -59,7 +59,10 @@ func (ss *SQLStore) WithDbSession(ctx context.Context, callback dbTransactionFun
 	return withDbSession(ctx, ss.engine, callback)
 }
 
-func withDbSession(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) error {
+func withDbSession(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) (err error) {
+	ctx, end := startQuery(ctx, engine)
+	defer func() { end(err) }()
+
 	sess := &DBSession{Session: engine.NewSession()}
 	sess.Session = sess.Session.Context(ctx)
 	defer sess.Close()
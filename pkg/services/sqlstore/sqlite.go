@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// ErrNotSQLite is returned by the SQLite maintenance operations when the database is not SQLite
+var ErrNotSQLite = errors.New("the database is not SQLite")
+
+// SQLiteCheckpoint is the result of a checkpoint of the WAL file, the page counts are -1 when the journal mode is not
+// WAL
+type SQLiteCheckpoint struct {
+	// Busy is true when the checkpoint could not complete because of the readers or writers
+	Busy bool
+	// LogPages is the number of pages of the WAL file, CheckpointedPages the number of pages written to the database
+	LogPages          int64
+	CheckpointedPages int64
+}
+
+// SQLiteMaintenanceIntervals returns how often the WAL file of SQLite should be checkpointed and the database
+// vacuumed, zero is never. They are zero for the other databases.
+func (ss *SQLStore) SQLiteMaintenanceIntervals() (checkpoint time.Duration, vacuum time.Duration) {
+	if ss.Dialect.DriverName() != migrator.SQLite {
+		return 0, 0
+	}
+	return ss.dbCfg.CheckpointInterval, ss.dbCfg.VacuumInterval
+}
+
+// CheckpointSQLite writes the pages of the WAL file to the database and truncates the WAL file
+func (ss *SQLStore) CheckpointSQLite(ctx context.Context) (*SQLiteCheckpoint, error) {
+	if ss.Dialect.DriverName() != migrator.SQLite {
+		return nil, ErrNotSQLite
+	}
+
+	var busy int
+	result := &SQLiteCheckpoint{}
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		row := sess.DB().QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
+		return row.Scan(&busy, &result.LogPages, &result.CheckpointedPages)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Busy = busy != 0
+	return result, nil
+}
+
+// VacuumSQLite rebuilds the database file, which releases the free pages left by the deleted rows. The writes wait
+// for the vacuum to complete.
+func (ss *SQLStore) VacuumSQLite(ctx context.Context) error {
+	if ss.Dialect.DriverName() != migrator.SQLite {
+		return ErrNotSQLite
+	}
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.DB().ExecContext(ctx, "VACUUM")
+		return err
+	})
+}
+
+// BackupSQLite writes a consistent copy of the database to path while Grafana keeps using it. The file must not
+// exist.
+func (ss *SQLStore) BackupSQLite(ctx context.Context, path string) error {
+	if ss.Dialect.DriverName() != migrator.SQLite {
+		return ErrNotSQLite
+	}
+	if _, err := os.Stat(path); err == nil {
+		return os.ErrExist
+	}
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.DB().ExecContext(ctx, "VACUUM INTO ?", path)
+		return err
+	})
+}
@@ -0,0 +1,51 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestSQLiteMaintenance(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := InitTestDB(t)
+	_, err := sqlStore.CreateOrgWithMember("Backed up", 0)
+	require.NoError(t, err)
+
+	t.Run("should checkpoint the database", func(t *testing.T) {
+		result, err := sqlStore.CheckpointSQLite(ctx)
+		require.NoError(t, err)
+		assert.False(t, result.Busy)
+	})
+
+	t.Run("should vacuum the database", func(t *testing.T) {
+		require.NoError(t, sqlStore.VacuumSQLite(ctx))
+	})
+
+	t.Run("should back up the database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.db")
+		require.NoError(t, sqlStore.BackupSQLite(ctx, path))
+
+		engine, err := xorm.NewEngine("sqlite3", path)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = engine.Close()
+		})
+		org := models.Org{}
+		exists, err := engine.Where("name = ?", "Backed up").Get(&org)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		require.ErrorIs(t, sqlStore.BackupSQLite(ctx, path), os.ErrExist)
+	})
+}
@@ -26,12 +26,15 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"xorm.io/xorm"
 )
 
 var (
 	x       *xorm.Engine
 	dialect migrator.Dialect
+	// readReplica is the read replica of x, nil when none is configured
+	readReplica *replica
 
 	sqlog log.Logger = log.New("sqlstore")
 )
@@ -46,6 +49,8 @@ type SQLStore struct {
 
 	dbCfg                       DatabaseConfig
 	engine                      *xorm.Engine
+	replicaCfg                  ReplicaConfig
+	replica                     *replica
 	log                         log.Logger
 	Dialect                     migrator.Dialect
 	skipEnsureDefaultOrgAndUser bool
@@ -70,6 +75,10 @@ func ProvideService(cfg *setting.Cfg, cacheService *localcache.CacheService, bus
 		return nil, err
 	}
 
+	if err := prometheus.Register(newPoolCollector(s)); err != nil {
+		s.log.Warn("Failed to register the database connection pool metrics", "error", err)
+	}
+
 	return s, nil
 }
 
@@ -99,9 +108,25 @@ func newSQLStore(cfg *setting.Cfg, cacheService *localcache.CacheService, bus bu
 
 	ss.Dialect = migrator.NewDialect(ss.engine)
 
+	if err := ss.initReplica(); err != nil {
+		return nil, errutil.Wrap("failed to connect to read replica", err)
+	}
+
 	// temporarily still set global var
 	x = ss.engine
 	dialect = ss.Dialect
+	readReplica = ss.replica
+	slowQueryThreshold = ss.dbCfg.SlowQueryThreshold
+	transactionRetries = retryPolicy{MaxRetries: ss.dbCfg.TransactionRetries, Backoff: ss.dbCfg.TransactionRetryBackoff}
+	ss.initPreparedStatements()
+
+	storage, err := newDashboardDataStorage(ss.Cfg.DashboardStorage, ss.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	dashboardStorage = storage
+	recordDashboardEvents = ss.Cfg.DashboardEventSourcing
+	trashDeletedDashboards = ss.Cfg.Cleanup.DeletedDashboardsRetention > 0
 
 	// Init repo instances
 	annotations.SetRepository(&SQLAnnotationRepo{})
@@ -114,12 +139,14 @@ func newSQLStore(cfg *setting.Cfg, cacheService *localcache.CacheService, bus bu
 	ss.addPreferencesQueryAndCommandHandlers()
 	ss.addDashboardQueryAndCommandHandlers()
 	ss.addDashboardACLQueryAndCommandHandlers()
+	ss.addDashboardTrashQueryAndCommandHandlers()
 	ss.addQuotaQueryAndCommandHandlers()
 	ss.addOrgUsersQueryAndCommandHandlers()
 	ss.addStarQueryAndCommandHandlers()
 	ss.addAlertQueryAndCommandHandlers()
 	ss.addTempUserQueryAndCommandHandlers()
 	ss.addDashboardVersionQueryAndCommandHandlers()
+	ss.addDashboardEventQueryHandlers()
 	ss.addAPIKeysQueryAndCommandHandlers()
 	ss.addPlaylistQueryAndCommandHandlers()
 
@@ -147,6 +174,9 @@ func (ss *SQLStore) Migrate() error {
 
 	migrator := migrator.NewMigrator(ss.engine, ss.Cfg)
 	ss.migrations.AddMigration(migrator)
+	if ss.dbCfg.MigrationLocking {
+		migrator.LockTimeout = ss.dbCfg.MigrationLockTimeout
+	}
 
 	return migrator.Start()
 }
@@ -217,13 +247,13 @@ func (ss *SQLStore) ensureMainOrgAndAdminUser() error {
 	return err
 }
 
-func (ss *SQLStore) buildExtraConnectionString(sep rune) string {
-	if ss.dbCfg.UrlQueryParams == nil {
+func buildExtraConnectionString(dbCfg *DatabaseConfig, sep rune) string {
+	if dbCfg.UrlQueryParams == nil {
 		return ""
 	}
 
 	var sb strings.Builder
-	for key, values := range ss.dbCfg.UrlQueryParams {
+	for key, values := range dbCfg.UrlQueryParams {
 		for _, value := range values {
 			sb.WriteRune(sep)
 			sb.WriteString(key)
@@ -238,26 +268,30 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 	if err := ss.readConfig(); err != nil {
 		return "", err
 	}
+	return ss.connectionString(&ss.dbCfg)
+}
 
-	cnnstr := ss.dbCfg.ConnectionString
+// connectionString returns the connection string of the database, the primary one or its read replica
+func (ss *SQLStore) connectionString(dbCfg *DatabaseConfig) (string, error) {
+	cnnstr := dbCfg.ConnectionString
 
 	// special case used by integration tests
 	if cnnstr != "" {
 		return cnnstr, nil
 	}
 
-	switch ss.dbCfg.Type {
+	switch dbCfg.Type {
 	case migrator.MySQL:
 		protocol := "tcp"
-		if strings.HasPrefix(ss.dbCfg.Host, "/") {
+		if strings.HasPrefix(dbCfg.Host, "/") {
 			protocol = "unix"
 		}
 
 		cnnstr = fmt.Sprintf("%s:%s@%s(%s)/%s?collation=utf8mb4_unicode_ci&allowNativePasswords=true",
-			ss.dbCfg.User, ss.dbCfg.Pwd, protocol, ss.dbCfg.Host, ss.dbCfg.Name)
+			dbCfg.User, dbCfg.Pwd, protocol, dbCfg.Host, dbCfg.Name)
 
-		if ss.dbCfg.SslMode == "true" || ss.dbCfg.SslMode == "skip-verify" {
-			tlsCert, err := makeCert(ss.dbCfg)
+		if dbCfg.SslMode == "true" || dbCfg.SslMode == "skip-verify" {
+			tlsCert, err := makeCert(*dbCfg)
 			if err != nil {
 				return "", err
 			}
@@ -268,42 +302,64 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 			cnnstr += "&tls=custom"
 		}
 
-		if isolation := ss.dbCfg.IsolationLevel; isolation != "" {
+		if isolation := dbCfg.IsolationLevel; isolation != "" {
 			val := url.QueryEscape(fmt.Sprintf("'%s'", isolation))
 			cnnstr += fmt.Sprintf("&tx_isolation=%s", val)
 		}
 
-		cnnstr += ss.buildExtraConnectionString('&')
+		// MySQL only bounds the duration of the SELECT statements
+		if dbCfg.StatementTimeout > 0 {
+			cnnstr += fmt.Sprintf("&max_execution_time=%d", dbCfg.StatementTimeout.Milliseconds())
+		}
+
+		cnnstr += buildExtraConnectionString(dbCfg, '&')
 	case migrator.Postgres:
-		addr, err := util.SplitHostPortDefault(ss.dbCfg.Host, "127.0.0.1", "5432")
+		addr, err := util.SplitHostPortDefault(dbCfg.Host, "127.0.0.1", "5432")
 		if err != nil {
-			return "", errutil.Wrapf(err, "Invalid host specifier '%s'", ss.dbCfg.Host)
+			return "", errutil.Wrapf(err, "Invalid host specifier '%s'", dbCfg.Host)
 		}
 
-		if ss.dbCfg.Pwd == "" {
-			ss.dbCfg.Pwd = "''"
+		if dbCfg.Pwd == "" {
+			dbCfg.Pwd = "''"
 		}
-		if ss.dbCfg.User == "" {
-			ss.dbCfg.User = "''"
+		if dbCfg.User == "" {
+			dbCfg.User = "''"
 		}
 		cnnstr = fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=%s sslcert=%s sslkey=%s sslrootcert=%s",
-			ss.dbCfg.User, ss.dbCfg.Pwd, addr.Host, addr.Port, ss.dbCfg.Name, ss.dbCfg.SslMode, ss.dbCfg.ClientCertPath,
-			ss.dbCfg.ClientKeyPath, ss.dbCfg.CaCertPath)
+			dbCfg.User, dbCfg.Pwd, addr.Host, addr.Port, dbCfg.Name, dbCfg.SslMode, dbCfg.ClientCertPath,
+			dbCfg.ClientKeyPath, dbCfg.CaCertPath)
+
+		if dbCfg.StatementTimeout > 0 {
+			cnnstr += fmt.Sprintf(" statement_timeout=%d", dbCfg.StatementTimeout.Milliseconds())
+		}
 
-		cnnstr += ss.buildExtraConnectionString(' ')
+		cnnstr += buildExtraConnectionString(dbCfg, ' ')
 	case migrator.SQLite:
 		// special case for tests
-		if !filepath.IsAbs(ss.dbCfg.Path) {
-			ss.dbCfg.Path = filepath.Join(ss.Cfg.DataPath, ss.dbCfg.Path)
+		if !filepath.IsAbs(dbCfg.Path) {
+			dbCfg.Path = filepath.Join(ss.Cfg.DataPath, dbCfg.Path)
 		}
-		if err := os.MkdirAll(path.Dir(ss.dbCfg.Path), os.ModePerm); err != nil {
+		if err := os.MkdirAll(path.Dir(dbCfg.Path), os.ModePerm); err != nil {
 			return "", err
 		}
 
-		cnnstr = fmt.Sprintf("file:%s?cache=%s&mode=rwc", ss.dbCfg.Path, ss.dbCfg.CacheMode)
-		cnnstr += ss.buildExtraConnectionString('&')
+		if dbCfg.StatementTimeout > 0 {
+			ss.log.Warn("The statement timeout is not supported with SQLite")
+		}
+
+		cnnstr = fmt.Sprintf("file:%s?cache=%s&mode=rwc", dbCfg.Path, dbCfg.CacheMode)
+		if dbCfg.WAL {
+			cnnstr += "&_journal_mode=WAL"
+		}
+		if dbCfg.BusyTimeout > 0 {
+			cnnstr += fmt.Sprintf("&_busy_timeout=%d", dbCfg.BusyTimeout.Milliseconds())
+		}
+		if dbCfg.Synchronous != "" {
+			cnnstr += "&_synchronous=" + dbCfg.Synchronous
+		}
+		cnnstr += buildExtraConnectionString(dbCfg, '&')
 	default:
-		return "", fmt.Errorf("unknown database type: %s", ss.dbCfg.Type)
+		return "", fmt.Errorf("unknown database type: %s", dbCfg.Type)
 	}
 
 	return cnnstr, nil
@@ -321,6 +377,9 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		return err
 	}
 
+	if ss.dbCfg.PreparedStatements {
+		ss.dbCfg.Type = wrapDatabaseDriverWithStatementCache(ss.dbCfg.Type)
+	}
 	if ss.Cfg.IsDatabaseMetricsEnabled() {
 		ss.dbCfg.Type = WrapDatabaseDriverWithHooks(ss.dbCfg.Type)
 	}
@@ -387,25 +446,9 @@ func (ss *SQLStore) readConfig() error {
 
 	cfgURL := sec.Key("url").String()
 	if len(cfgURL) != 0 {
-		dbURL, err := url.Parse(cfgURL)
-		if err != nil {
+		if err := parseDatabaseURL(cfgURL, &ss.dbCfg); err != nil {
 			return err
 		}
-		ss.dbCfg.Type = dbURL.Scheme
-		ss.dbCfg.Host = dbURL.Host
-
-		pathSplit := strings.Split(dbURL.Path, "/")
-		if len(pathSplit) > 1 {
-			ss.dbCfg.Name = pathSplit[1]
-		}
-
-		userInfo := dbURL.User
-		if userInfo != nil {
-			ss.dbCfg.User = userInfo.Username()
-			ss.dbCfg.Pwd, _ = userInfo.Password()
-		}
-
-		ss.dbCfg.UrlQueryParams = dbURL.Query()
 	} else {
 		ss.dbCfg.Type = sec.Key("type").String()
 		ss.dbCfg.Host = sec.Key("host").String()
@@ -418,6 +461,13 @@ func (ss *SQLStore) readConfig() error {
 	ss.dbCfg.MaxOpenConn = sec.Key("max_open_conn").MustInt(0)
 	ss.dbCfg.MaxIdleConn = sec.Key("max_idle_conn").MustInt(2)
 	ss.dbCfg.ConnMaxLifetime = sec.Key("conn_max_lifetime").MustInt(14400)
+	ss.dbCfg.StatementTimeout = sec.Key("statement_timeout").MustDuration(0)
+	ss.dbCfg.SlowQueryThreshold = sec.Key("slow_query_threshold").MustDuration(0)
+	ss.dbCfg.PreparedStatements = sec.Key("prepared_statements").MustBool(false)
+	ss.dbCfg.PreparedStatementCacheSize = sec.Key("prepared_statement_cache_size").MustInt(32)
+	if ss.dbCfg.PreparedStatementCacheSize <= 0 {
+		return fmt.Errorf("database prepared_statement_cache_size must be positive")
+	}
 
 	ss.dbCfg.SslMode = sec.Key("ssl_mode").String()
 	ss.dbCfg.CaCertPath = sec.Key("ca_cert_path").String()
@@ -428,7 +478,65 @@ func (ss *SQLStore) readConfig() error {
 	ss.dbCfg.IsolationLevel = sec.Key("isolation_level").String()
 
 	ss.dbCfg.CacheMode = sec.Key("cache_mode").MustString("private")
+	ss.dbCfg.WAL = sec.Key("wal").MustBool(false)
+	ss.dbCfg.BusyTimeout = sec.Key("busy_timeout").MustDuration(0)
+	ss.dbCfg.Synchronous = strings.ToUpper(sec.Key("synchronous").String())
+	switch ss.dbCfg.Synchronous {
+	case "", "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		return fmt.Errorf("invalid database synchronous %q, it must be OFF, NORMAL, FULL or EXTRA", ss.dbCfg.Synchronous)
+	}
+	ss.dbCfg.CheckpointInterval = sec.Key("checkpoint_interval").MustDuration(0)
+	ss.dbCfg.VacuumInterval = sec.Key("vacuum_interval").MustDuration(0)
 	ss.dbCfg.SkipMigrations = sec.Key("skip_migrations").MustBool()
+	ss.dbCfg.MigrationLocking = sec.Key("migration_locking").MustBool(true)
+	ss.dbCfg.MigrationLockTimeout = sec.Key("migration_lock_timeout").MustDuration(10 * time.Minute)
+
+	retries := defaultTransactionRetries[ss.dbCfg.Type]
+	ss.dbCfg.TransactionRetries = sec.Key("transaction_retries").MustInt(retries.MaxRetries)
+	ss.dbCfg.TransactionRetryBackoff = sec.Key("transaction_retry_backoff").MustDuration(retries.Backoff)
+
+	replica := ss.Cfg.Raw.Section("database.replica")
+	ss.replicaCfg = ReplicaConfig{
+		URL:                 replica.Key("url").String(),
+		Host:                replica.Key("host").String(),
+		Name:                replica.Key("name").String(),
+		User:                replica.Key("user").String(),
+		Pwd:                 replica.Key("password").String(),
+		MaxOpenConn:         replica.Key("max_open_conn").MustInt(ss.dbCfg.MaxOpenConn),
+		MaxIdleConn:         replica.Key("max_idle_conn").MustInt(ss.dbCfg.MaxIdleConn),
+		ConnMaxLifetime:     replica.Key("conn_max_lifetime").MustInt(ss.dbCfg.ConnMaxLifetime),
+		StatementTimeout:    replica.Key("statement_timeout").MustDuration(ss.dbCfg.StatementTimeout),
+		MaxLag:              replica.Key("max_lag").MustDuration(5 * time.Second),
+		HealthCheckInterval: replica.Key("health_check_interval").MustDuration(10 * time.Second),
+	}
+	if ss.replicaCfg.HealthCheckInterval <= 0 {
+		return fmt.Errorf("read replica health_check_interval must be positive")
+	}
+	return nil
+}
+
+// parseDatabaseURL reads the type, host, name, credentials and query parameters of a database URL
+func parseDatabaseURL(cfgURL string, dbCfg *DatabaseConfig) error {
+	dbURL, err := url.Parse(cfgURL)
+	if err != nil {
+		return err
+	}
+	dbCfg.Type = dbURL.Scheme
+	dbCfg.Host = dbURL.Host
+
+	pathSplit := strings.Split(dbURL.Path, "/")
+	if len(pathSplit) > 1 {
+		dbCfg.Name = pathSplit[1]
+	}
+
+	userInfo := dbURL.User
+	if userInfo != nil {
+		dbCfg.User = userInfo.Username()
+		dbCfg.Pwd, _ = userInfo.Password()
+	}
+
+	dbCfg.UrlQueryParams = dbURL.Query()
 	return nil
 }
 
@@ -607,7 +715,31 @@ type DatabaseConfig struct {
 	MaxOpenConn      int
 	MaxIdleConn      int
 	ConnMaxLifetime  int
-	CacheMode        string
-	UrlQueryParams   map[string][]string
-	SkipMigrations   bool
+	// StatementTimeout bounds the duration of the statements on the database server, they are not bounded when zero
+	StatementTimeout time.Duration
+	// SlowQueryThreshold is the duration above which the store calls are logged, they are not logged when zero
+	SlowQueryThreshold time.Duration
+	// PreparedStatements is true when the prepared statements of the hot queries are cached on the connections of
+	// MySQL and Postgres, PreparedStatementCacheSize is the number of statements cached on each connection
+	PreparedStatements         bool
+	PreparedStatementCacheSize int
+	CacheMode                  string
+	// WAL, BusyTimeout and Synchronous set the journal mode, the wait for the locks and the synchronous level of
+	// SQLite, the defaults of the driver are used when they are unset
+	WAL         bool
+	BusyTimeout time.Duration
+	Synchronous string
+	// CheckpointInterval and VacuumInterval are how often the WAL file of SQLite is checkpointed and the database
+	// vacuumed, never when zero
+	CheckpointInterval time.Duration
+	VacuumInterval     time.Duration
+	UrlQueryParams     map[string][]string
+	SkipMigrations     bool
+	// MigrationLocking is true when the instances sharing the database take a lock before running the migrations
+	MigrationLocking     bool
+	MigrationLockTimeout time.Duration
+	// TransactionRetries is the number of retries of the transactions failing on transient errors such as deadlocks,
+	// the first retry waits for about TransactionRetryBackoff and the next ones twice as long as the previous one
+	TransactionRetries      int
+	TransactionRetryBackoff time.Duration
 }
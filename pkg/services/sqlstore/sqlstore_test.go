@@ -92,6 +92,24 @@ func TestSQLConnectionString(t *testing.T) {
 	}
 }
 
+func TestSQLConnectionStringWithStatementTimeout(t *testing.T) {
+	for dbType, expected := range map[string]string{
+		"mysql":    "&max_execution_time=30000",
+		"postgres": " statement_timeout=30000",
+	} {
+		t.Run(dbType, func(t *testing.T) {
+			sqlstore := &SQLStore{}
+			sqlstore.Cfg = makeSQLStoreTestConfig(t, dbType, "1.2.3.4", "")
+			_, err := sqlstore.Cfg.Raw.Section("database").NewKey("statement_timeout", "30s")
+			require.NoError(t, err)
+
+			connStr, err := sqlstore.buildConnectionString()
+			require.NoError(t, err)
+			require.Contains(t, connStr, expected)
+		})
+	}
+}
+
 func makeSQLStoreTestConfig(t *testing.T, dbType, host, dbURL string) *setting.Cfg {
 	t.Helper()
 
@@ -114,3 +132,31 @@ func makeSQLStoreTestConfig(t *testing.T, dbType, host, dbURL string) *setting.C
 
 	return cfg
 }
+
+func TestSQLConnectionStringWithSQLiteOptions(t *testing.T) {
+	newStore := func(t *testing.T, options map[string]string) *SQLStore {
+		t.Helper()
+		sqlstore := &SQLStore{}
+		sqlstore.Cfg = makeSQLStoreTestConfig(t, "sqlite3", "", "")
+		sqlstore.Cfg.DataPath = t.TempDir()
+		for key, value := range options {
+			_, err := sqlstore.Cfg.Raw.Section("database").NewKey(key, value)
+			require.NoError(t, err)
+		}
+		return sqlstore
+	}
+
+	connStr, err := newStore(t, map[string]string{"wal": "true", "busy_timeout": "10s", "synchronous": "normal"}).buildConnectionString()
+	require.NoError(t, err)
+	require.Contains(t, connStr, "&_journal_mode=WAL")
+	require.Contains(t, connStr, "&_busy_timeout=10000")
+	require.Contains(t, connStr, "&_synchronous=NORMAL")
+
+	connStr, err = newStore(t, nil).buildConnectionString()
+	require.NoError(t, err)
+	require.NotContains(t, connStr, "_journal_mode")
+	require.NotContains(t, connStr, "_synchronous")
+
+	_, err = newStore(t, map[string]string{"synchronous": "sometimes"}).buildConnectionString()
+	require.Error(t, err)
+}
@@ -0,0 +1,289 @@
+package sqlstore
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"xorm.io/core"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+var (
+	// preparedStatements is true when the queries of the contexts returned by withPreparedStatement are run with the
+	// prepared statements cached on the connections
+	preparedStatements bool
+	// statementCacheSize is the number of prepared statements cached on each connection
+	statementCacheSize = 32
+
+	statementCacheCounter         *prometheus.CounterVec
+	statementCacheEvictionCounter prometheus.Counter
+
+	// statementCacheDrivers are the database drivers supporting the prepared statement cache
+	statementCacheDrivers = map[string]driver.Driver{
+		migrator.MySQL:    &mysql.MySQLDriver{},
+		migrator.Postgres: &pq.Driver{},
+	}
+	// registeredStatementCacheDrivers are the registered drivers caching the prepared statements, by name
+	registeredStatementCacheDrivers sync.Map
+)
+
+func init() {
+	statementCacheCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "database_prepared_statements_total",
+		Help:      "Number of queries run with a prepared statement, by whether the statement was cached on the connection",
+	}, []string{"result"})
+	statementCacheEvictionCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "database_prepared_statement_evictions_total",
+		Help:      "Number of prepared statements closed because of the size of the cache or of an error",
+	})
+
+	prometheus.MustRegister(statementCacheCounter, statementCacheEvictionCounter)
+}
+
+// preparedStatementKey is the context key marking the queries run with a cached prepared statement
+type preparedStatementKey struct{}
+
+// withPreparedStatement returns a context whose queries are run with the prepared statements cached on the
+// connections, when the prepared_statements setting is enabled. It is meant for the hot queries, whose statements are
+// the same on every call.
+func withPreparedStatement(ctx context.Context) context.Context {
+	return context.WithValue(ctx, preparedStatementKey{}, true)
+}
+
+func usePreparedStatement(ctx context.Context) bool {
+	marked, _ := ctx.Value(preparedStatementKey{}).(bool)
+	return marked && preparedStatements
+}
+
+// wrapDatabaseDriverWithStatementCache registers a database driver caching the prepared statements of the hot
+// queries on its connections, and returns its name. The database type is returned unchanged when its driver does not
+// support the cache.
+func wrapDatabaseDriverWithStatementCache(dbType string) string {
+	d, exist := statementCacheDrivers[dbType]
+	if !exist {
+		return dbType
+	}
+
+	driverWithStatementCache := dbType + "WithStatementCache"
+	wrapped := &statementCacheDriver{Driver: d}
+	if _, registered := registeredStatementCacheDrivers.LoadOrStore(driverWithStatementCache, wrapped); !registered {
+		sql.Register(driverWithStatementCache, wrapped)
+		core.RegisterDriver(driverWithStatementCache, core.QueryDriver(dbType))
+	}
+	return driverWithStatementCache
+}
+
+// initPreparedStatements enables the prepared statement cache when the dialect and the database server support it
+func (ss *SQLStore) initPreparedStatements() {
+	preparedStatements = false
+	if !ss.dbCfg.PreparedStatements {
+		return
+	}
+	if !ss.Dialect.SupportsPreparedStatements() {
+		ss.log.Warn("The database does not support the prepared statement cache", "dbtype", ss.Dialect.DriverName())
+		return
+	}
+	statementCacheSize = ss.dbCfg.PreparedStatementCacheSize
+
+	if ss.Dialect.DriverName() == migrator.MySQL {
+		// the prepared statements of all the sessions are bounded by the server
+		var maxStatements int64
+		if _, err := ss.engine.SQL("SELECT @@max_prepared_stmt_count").Get(&maxStatements); err != nil {
+			ss.log.Warn("Failed to read the maximum number of prepared statements of the database", "error", err)
+			return
+		}
+		if maxStatements == 0 {
+			ss.log.Warn("The prepared statements are disabled on the database server by max_prepared_stmt_count")
+			return
+		}
+		if needed := int64(ss.dbCfg.MaxOpenConn * ss.dbCfg.PreparedStatementCacheSize); needed > maxStatements {
+			ss.log.Warn("The prepared statement cache may exceed the max_prepared_stmt_count of the database server",
+				"max_prepared_stmt_count", maxStatements, "cached_statements", needed)
+		}
+	}
+
+	preparedStatements = true
+	ss.log.Info("Caching the prepared statements of the hot queries", "cache_size", statementCacheSize)
+}
+
+// statementCacheDriver wraps a database driver to keep the prepared statements of the queries marked by
+// withPreparedStatement open on the connections, so that the database server parses them once per connection
+type statementCacheDriver struct {
+	driver.Driver
+}
+
+func (d *statementCacheDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &statementCacheConn{Conn: conn, cache: newStatementCache(statementCacheSize)}, nil
+}
+
+// statementCacheConn implements the context interfaces of the connections of the MySQL and PostgreSQL drivers like
+// rowsCountingConn. The connections are not used concurrently, so the cache is not locked.
+type statementCacheConn struct {
+	driver.Conn
+	cache *statementCache
+}
+
+func (c *statementCacheConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if conn, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return conn.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("the database driver does not support transaction options")
+	}
+	return c.Conn.Begin() // nolint:staticcheck
+}
+
+func (c *statementCacheConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if conn, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return conn.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *statementCacheConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !usePreparedStatement(ctx) {
+		conn, ok := c.Conn.(driver.QueryerContext)
+		if !ok {
+			return nil, driver.ErrSkip
+		}
+		return conn.QueryContext(ctx, query, args)
+	}
+
+	stmt, err := c.statement(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	var rows driver.Rows
+	if s, ok := stmt.(driver.StmtQueryContext); ok {
+		rows, err = s.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = stmt.Query(values) // nolint:staticcheck
+		}
+	}
+	if err != nil {
+		// the statement may be invalid, for example after a schema change
+		c.cache.remove(query)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// statement returns the cached prepared statement of the query, or prepares and caches it
+func (c *statementCacheConn) statement(ctx context.Context, query string) (driver.Stmt, error) {
+	if stmt := c.cache.get(query); stmt != nil {
+		statementCacheCounter.WithLabelValues("hit").Inc()
+		return stmt, nil
+	}
+
+	statementCacheCounter.WithLabelValues("miss").Inc()
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.add(query, stmt)
+	return stmt, nil
+}
+
+func (c *statementCacheConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return conn.ExecContext(ctx, query, args)
+}
+
+func (c *statementCacheConn) Ping(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.Pinger); ok {
+		return conn.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *statementCacheConn) ResetSession(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.SessionResetter); ok {
+		return conn.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *statementCacheConn) CheckNamedValue(value *driver.NamedValue) error {
+	if conn, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return conn.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *statementCacheConn) Close() error {
+	c.cache.clear()
+	return c.Conn.Close()
+}
+
+// statementCache is a least recently used cache of the prepared statements of a connection
+type statementCache struct {
+	size       int
+	order      *list.List
+	statements map[string]*list.Element
+}
+
+type cachedStatement struct {
+	query string
+	stmt  driver.Stmt
+}
+
+func newStatementCache(size int) *statementCache {
+	return &statementCache{size: size, order: list.New(), statements: map[string]*list.Element{}}
+}
+
+func (c *statementCache) get(query string) driver.Stmt {
+	element, ok := c.statements[query]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedStatement).stmt
+}
+
+// add caches the statement, and closes the least recently used one when the cache is full
+func (c *statementCache) add(query string, stmt driver.Stmt) {
+	c.statements[query] = c.order.PushFront(&cachedStatement{query: query, stmt: stmt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back().Value.(*cachedStatement).query)
+	}
+}
+
+// remove closes the statement of the query and removes it from the cache
+func (c *statementCache) remove(query string) {
+	element, ok := c.statements[query]
+	if !ok {
+		return
+	}
+	c.order.Remove(element)
+	delete(c.statements, query)
+	statementCacheEvictionCounter.Inc()
+	_ = element.Value.(*cachedStatement).stmt.Close()
+}
+
+// clear closes the statements of the cache without counting them as evictions
+func (c *statementCache) clear() {
+	for _, element := range c.statements {
+		_ = element.Value.(*cachedStatement).stmt.Close()
+	}
+	c.order.Init()
+	c.statements = map[string]*list.Element{}
+}
@@ -0,0 +1,139 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// fakeStatementConn is a connection counting the statements it prepares and closes
+type fakeStatementConn struct {
+	prepared  map[string]int
+	closed    map[string]int
+	failQuery bool
+}
+
+func (c *fakeStatementConn) Prepare(query string) (driver.Stmt, error) {
+	c.prepared[query]++
+	return &fakeStatement{conn: c, query: query}, nil
+}
+
+func (c *fakeStatementConn) Close() error { return nil }
+
+func (c *fakeStatementConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+func (c *fakeStatementConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeStatement struct {
+	conn  *fakeStatementConn
+	query string
+}
+
+func (s *fakeStatement) Close() error {
+	s.conn.closed[s.query]++
+	return nil
+}
+
+func (s *fakeStatement) NumInput() int { return -1 }
+
+func (s *fakeStatement) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStatement) Query(args []driver.Value) (driver.Rows, error) {
+	if s.conn.failQuery {
+		return nil, errors.New("cached plan must not change result type")
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (r *fakeRows) Columns() []string { return nil }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestStatementCacheConn(t *testing.T) {
+	enabled := preparedStatements
+	preparedStatements = true
+	t.Cleanup(func() { preparedStatements = enabled })
+
+	newConn := func() (*fakeStatementConn, *statementCacheConn) {
+		fake := &fakeStatementConn{prepared: map[string]int{}, closed: map[string]int{}}
+		return fake, &statementCacheConn{Conn: fake, cache: newStatementCache(2)}
+	}
+	marked := withPreparedStatement(context.Background())
+	query := func(t *testing.T, conn *statementCacheConn, ctx context.Context, sql string) error {
+		t.Helper()
+		_, err := conn.QueryContext(ctx, sql, nil)
+		return err
+	}
+
+	t.Run("should prepare the marked queries once", func(t *testing.T) {
+		fake, conn := newConn()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, query(t, conn, marked, "SELECT 1"))
+		}
+		assert.Equal(t, 1, fake.prepared["SELECT 1"])
+
+		require.NoError(t, query(t, conn, context.Background(), "SELECT 2"))
+		assert.Zero(t, fake.prepared["SELECT 2"], "the queries which are not marked should not be prepared")
+	})
+
+	t.Run("should close the least recently used statement when the cache is full", func(t *testing.T) {
+		fake, conn := newConn()
+		for _, sql := range []string{"SELECT 1", "SELECT 2", "SELECT 1", "SELECT 3"} {
+			require.NoError(t, query(t, conn, marked, sql))
+		}
+		assert.Equal(t, map[string]int{"SELECT 2": 1}, fake.closed)
+
+		require.NoError(t, conn.Close())
+		assert.Equal(t, map[string]int{"SELECT 1": 1, "SELECT 2": 1, "SELECT 3": 1}, fake.closed)
+	})
+
+	t.Run("should close the statement of a failed query", func(t *testing.T) {
+		fake, conn := newConn()
+		fake.failQuery = true
+		require.Error(t, query(t, conn, marked, "SELECT 1"))
+		assert.Equal(t, 1, fake.closed["SELECT 1"])
+
+		fake.failQuery = false
+		require.NoError(t, query(t, conn, marked, "SELECT 1"))
+		assert.Equal(t, 2, fake.prepared["SELECT 1"])
+	})
+
+	t.Run("should not prepare the queries when the cache is disabled", func(t *testing.T) {
+		preparedStatements = false
+		t.Cleanup(func() { preparedStatements = true })
+
+		fake, conn := newConn()
+		require.NoError(t, query(t, conn, marked, "SELECT 1"))
+		assert.Empty(t, fake.prepared)
+	})
+}
+
+func TestInitPreparedStatements(t *testing.T) {
+	ss := InitTestDB(t)
+	ss.dbCfg.PreparedStatements = true
+	ss.initPreparedStatements()
+	t.Cleanup(func() { preparedStatements = false })
+
+	assert.Equal(t, ss.Dialect.SupportsPreparedStatements(), preparedStatements)
+	// the hot queries work with the cache
+	err := ss.GetUserByLogin(context.Background(), &models.GetUserByLoginQuery{LoginOrEmail: "missing"})
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
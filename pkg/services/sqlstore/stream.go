@@ -0,0 +1,38 @@
+package sqlstore
+
+import (
+	"context"
+	"reflect"
+)
+
+// StreamRows runs the query of the session and calls fn with each row as it is read, in a new bean of the type of
+// bean, so that large result sets are not materialized in memory. It stops at the first error of fn, or once the
+// context is cancelled.
+//
+// Unlike the queries of WithReadDbSession, the streamed queries are never retried on another database: the rows
+// already handed to fn cannot be taken back.
+func (sess *DBSession) StreamRows(ctx context.Context, bean interface{}, fn func(row interface{}) error) error {
+	rows, err := sess.Rows(bean)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	beanType := reflect.Indirect(reflect.ValueOf(bean)).Type()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row := reflect.New(beanType).Interface()
+		if err := rows.Scan(row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	// The rows end early once the context is cancelled, which must not pass for the end of the result set
+	return ctx.Err()
+}
@@ -0,0 +1,114 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+func TestStreamRows(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	ctx := context.Background()
+
+	var orgID int64
+	for i := 0; i < 3; i++ {
+		user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{
+			Login: fmt.Sprintf("user%d", i),
+			Email: fmt.Sprintf("user%d@test.com", i),
+		})
+		require.NoError(t, err)
+		if orgID == 0 {
+			orgID = user.OrgId
+			continue
+		}
+		require.NoError(t, sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: models.ROLE_VIEWER}))
+	}
+
+	t.Run("should stream the org users in the order of the list", func(t *testing.T) {
+		query := &models.GetOrgUsersQuery{OrgId: orgID}
+		require.NoError(t, sqlStore.GetOrgUsers(ctx, query))
+		require.Len(t, query.Result, 3)
+
+		var streamed []*models.OrgUserDTO
+		err := sqlStore.StreamOrgUsers(ctx, &models.GetOrgUsersQuery{OrgId: orgID}, func(user *models.OrgUserDTO) error {
+			streamed = append(streamed, user)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, query.Result, streamed)
+	})
+
+	t.Run("should stop at the first error of the callback", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := sqlStore.StreamOrgUsers(ctx, &models.GetOrgUsersQuery{OrgId: orgID}, func(user *models.OrgUserDTO) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should stop once the context is cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		calls := 0
+		err := sqlStore.StreamOrgUsers(cancelled, &models.GetOrgUsersQuery{OrgId: orgID}, func(user *models.OrgUserDTO) error {
+			calls++
+			cancel()
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should stream the annotations without a default limit", func(t *testing.T) {
+		repo := SQLAnnotationRepo{}
+		items := make([]*annotations.Item, 0, 150)
+		for i := 0; i < 150; i++ {
+			items = append(items, &annotations.Item{OrgId: orgID, Text: "deploy", Epoch: int64(i + 1)})
+		}
+		require.NoError(t, repo.SaveMany(items))
+
+		streamed := 0
+		var previous int64
+		err := repo.Stream(ctx, &annotations.ItemQuery{OrgId: orgID}, func(item *annotations.ItemDTO) error {
+			if streamed > 0 {
+				assert.Less(t, item.Time, previous)
+			}
+			previous = item.Time
+			streamed++
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 150, streamed)
+
+		found, err := repo.Find(&annotations.ItemQuery{OrgId: orgID})
+		require.NoError(t, err)
+		assert.Len(t, found, 100)
+	})
+
+	t.Run("should stream the dashboards of the organization without the folders", func(t *testing.T) {
+		folder := insertTestDashboard(t, sqlStore, "Folder", orgID, 0, true)
+		first := insertTestDashboard(t, sqlStore, "First", orgID, folder.Id, false)
+		second := insertTestDashboard(t, sqlStore, "Second", orgID, 0, false)
+		insertTestDashboard(t, sqlStore, "Other org", 2, 0, false)
+
+		var titles []string
+		err := sqlStore.StreamDashboards(ctx, orgID, func(dash *models.Dashboard) error {
+			titles = append(titles, dash.Data.Get("title").MustString())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.Title, second.Title}, titles)
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 )
 
 func init() {
@@ -136,6 +138,7 @@ func DeleteTeam(ctx context.Context, cmd *models.DeleteTeamCommand) error {
 			"DELETE FROM team_member WHERE org_id=? and team_id = ?",
 			"DELETE FROM team WHERE org_id=? and id = ?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
+			"DELETE FROM team_role WHERE org_id=? and team_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -144,6 +147,13 @@ func DeleteTeam(ctx context.Context, cmd *models.DeleteTeamCommand) error {
 				return err
 			}
 		}
+
+		sess.publishAfterCommit(&events.TeamMembershipChanged{
+			Timestamp: time.Now(),
+			OrgID:     cmd.OrgId,
+			TeamID:    cmd.Id,
+			Removed:   true,
+		})
 		return nil
 	})
 }
@@ -208,6 +218,16 @@ func SearchTeams(ctx context.Context, query *models.SearchTeamsQuery) error {
 		params = append(params, query.Name)
 	}
 
+	var filters []searchstore.FilterWhere
+	for _, filter := range query.Filters {
+		if f, ok := filter.(searchstore.FilterWhere); ok {
+			where, args := f.Where()
+			sql.WriteString(` and ` + where)
+			params = append(params, args...)
+			filters = append(filters, f)
+		}
+	}
+
 	sql.WriteString(` order by team.name asc`)
 
 	if query.Limit != 0 {
@@ -229,6 +249,11 @@ func SearchTeams(ctx context.Context, query *models.SearchTeamsQuery) error {
 		countSess.Where("name=?", query.Name)
 	}
 
+	for _, f := range filters {
+		where, args := f.Where()
+		countSess.Where(where, args...)
+	}
+
 	count, err := countSess.Count(&team)
 	query.Result.TotalCount = count
 
@@ -301,8 +326,17 @@ func (ss *SQLStore) AddTeamMember(userID, orgID, teamID int64, isExternal bool,
 			Permission: permission,
 		}
 
-		_, err := sess.Insert(&entity)
-		return err
+		if _, err := sess.Insert(&entity); err != nil {
+			return err
+		}
+
+		sess.publishAfterCommit(&events.TeamMembershipChanged{
+			Timestamp: entity.Created,
+			OrgID:     orgID,
+			TeamID:    teamID,
+			UserID:    userID,
+		})
+		return nil
 	})
 }
 
@@ -367,11 +401,21 @@ func RemoveTeamMember(ctx context.Context, cmd *models.RemoveTeamMemberCommand)
 			return err
 		}
 		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
 		if rows == 0 {
 			return models.ErrTeamMemberNotFound
 		}
 
-		return err
+		sess.publishAfterCommit(&events.TeamMembershipChanged{
+			Timestamp: time.Now(),
+			OrgID:     cmd.OrgId,
+			TeamID:    cmd.TeamId,
+			UserID:    cmd.UserId,
+			Removed:   true,
+		})
+		return nil
 	})
 }
 
@@ -15,12 +15,13 @@ func (ss *SQLStore) addTempUserQueryAndCommandHandlers() {
 	bus.AddHandler("sql", ss.GetTempUserByCode)
 	bus.AddHandler("sql", ss.UpdateTempUserWithEmailSent)
 	bus.AddHandler("sql", ss.ExpireOldUserInvites)
+	bus.AddHandler("sql", ss.DeleteExpiredUserInvites)
 }
 
 func (ss *SQLStore) UpdateTempUserStatus(ctx context.Context, cmd *models.UpdateTempUserStatusCommand) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
-		var rawSQL = "UPDATE temp_user SET status=? WHERE code=?"
-		_, err := sess.Exec(rawSQL, string(cmd.Status), cmd.Code)
+		var rawSQL = "UPDATE temp_user SET status=?, updated=? WHERE code=?"
+		_, err := sess.Exec(rawSQL, string(cmd.Status), time.Now().Unix(), cmd.Code)
 		return err
 	})
 }
@@ -151,3 +152,12 @@ func (ss *SQLStore) ExpireOldUserInvites(ctx context.Context, cmd *models.Expire
 		return nil
 	})
 }
+
+// DeleteExpiredUserInvites deletes the invites and sign ups which expired before cmd.ExpiredBefore, they are
+// expired by ExpireOldUserInvites or revoked
+func (ss *SQLStore) DeleteExpiredUserInvites(ctx context.Context, cmd *models.DeleteExpiredTempUsersCommand) error {
+	deleted, err := deleteInBatches(ctx, "temp_user", "status IN (?, ?) AND updated <= ?", cmd.BatchSize,
+		string(models.TmpUserExpired), string(models.TmpUserRevoked), cmd.ExpiredBefore.Unix())
+	cmd.DeletedRows = deleted
+	return err
+}
@@ -91,5 +91,21 @@ func TestTempUserCommandsAndQueries(t *testing.T) {
 			require.Nil(t, err)
 			require.Equal(t, int64(0), cmd2.NumExpired)
 		})
+
+		t.Run("Should delete the temp users expired before the retention", func(t *testing.T) {
+			cmd3 := models.DeleteExpiredTempUsersCommand{ExpiredBefore: time.Now().Add(-time.Hour), BatchSize: 1}
+			err := ss.DeleteExpiredUserInvites(context.Background(), &cmd3)
+			require.Nil(t, err)
+			require.Equal(t, int64(0), cmd3.DeletedRows)
+
+			cmd3 = models.DeleteExpiredTempUsersCommand{ExpiredBefore: time.Now().Add(time.Second), BatchSize: 1}
+			err = ss.DeleteExpiredUserInvites(context.Background(), &cmd3)
+			require.Nil(t, err)
+			require.Equal(t, int64(1), cmd3.DeletedRows)
+
+			query := models.GetTempUserByCodeQuery{Code: "asd"}
+			err = ss.GetTempUserByCode(context.Background(), &query)
+			require.Equal(t, models.ErrTempUserNotFound, err)
+		})
 	})
 }
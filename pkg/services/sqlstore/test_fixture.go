@@ -0,0 +1,300 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// testFixture is a row of the test_fixture table, data holds the JSON encoded TestFixture
+type testFixture struct {
+	Id      int64
+	Uid     string
+	Data    string
+	Created time.Time
+}
+
+// CreateTestFixture creates the organizations, users, folders and dashboards of the fixture in a single transaction,
+// either everything is created or nothing is.
+func (ss *SQLStore) CreateTestFixture(ctx context.Context, cmd *models.CreateTestFixtureCommand) error {
+	if err := validateTestFixture(cmd); err != nil {
+		return err
+	}
+
+	fixture := &models.TestFixture{
+		Uid:     util.GenerateShortUID(),
+		Created: time.Now(),
+		Users:   make([]models.TestFixtureUserRef, 0, len(cmd.Users)),
+		Orgs:    make([]models.TestFixtureOrgRef, 0, len(cmd.Orgs)),
+	}
+
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		userIDs := make(map[string]int64, len(cmd.Users))
+		for _, u := range cmd.Users {
+			user, err := ss.createUser(ctx, sess, userCreationArgs{
+				Login:         u.Login,
+				Email:         u.Email,
+				Name:          u.Name,
+				Password:      u.Password,
+				IsAdmin:       u.IsGrafanaAdmin,
+				EmailVerified: true,
+			}, true)
+			if err != nil {
+				return fmt.Errorf("failed to create user %q: %w", u.Login, err)
+			}
+			userIDs[u.Login] = user.Id
+			fixture.Users = append(fixture.Users, models.TestFixtureUserRef{Id: user.Id, Login: user.Login})
+		}
+
+		// The users are created without organization, their current organization is the first they are a member of
+		usingOrg := make(map[int64]int64, len(cmd.Users))
+		for _, o := range cmd.Orgs {
+			ref, err := createTestFixtureOrg(sess, o, userIDs)
+			if err != nil {
+				return err
+			}
+			for _, m := range o.Members {
+				if _, ok := usingOrg[userIDs[m.Login]]; !ok {
+					usingOrg[userIDs[m.Login]] = ref.Id
+				}
+			}
+			fixture.Orgs = append(fixture.Orgs, *ref)
+		}
+		for userID, orgID := range usingOrg {
+			if err := setUsingOrgInTransaction(sess, userID, orgID); err != nil {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(fixture)
+		if err != nil {
+			return err
+		}
+		_, err = sess.Insert(&testFixture{Uid: fixture.Uid, Data: string(data), Created: fixture.Created})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd.Result = fixture
+	return nil
+}
+
+func createTestFixtureOrg(sess *DBSession, o models.TestFixtureOrg, userIDs map[string]int64) (*models.TestFixtureOrgRef, error) {
+	if isNameTaken, err := isOrgNameTaken(o.Name, 0, sess); err != nil {
+		return nil, err
+	} else if isNameTaken {
+		return nil, fmt.Errorf("failed to create organization %q: %w", o.Name, models.ErrOrgNameTaken)
+	}
+
+	org := models.Org{Name: o.Name, Created: time.Now(), Updated: time.Now()}
+	if _, err := sess.Insert(&org); err != nil {
+		return nil, err
+	}
+	sess.publishAfterCommit(&events.OrgCreated{
+		Timestamp: org.Created,
+		Id:        org.Id,
+		Name:      org.Name,
+	})
+
+	for _, m := range o.Members {
+		if _, err := sess.Insert(&models.OrgUser{
+			OrgId:   org.Id,
+			UserId:  userIDs[m.Login],
+			Role:    m.Role,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	ref := &models.TestFixtureOrgRef{
+		Id:         org.Id,
+		Name:       org.Name,
+		Folders:    make([]models.TestFixtureDashboardRef, 0, len(o.Folders)),
+		Dashboards: make([]models.TestFixtureDashboardRef, 0, len(o.Dashboards)),
+	}
+
+	folderIDs := make(map[string]int64, len(o.Folders))
+	for _, f := range o.Folders {
+		folder := simplejson.New()
+		folder.Set("uid", f.Uid)
+		folder.Set("title", f.Title)
+		cmd := &models.SaveDashboardCommand{Dashboard: folder, OrgId: org.Id, IsFolder: true}
+		if err := saveDashboard(sess, cmd); err != nil {
+			return nil, fmt.Errorf("failed to create folder %q: %w", f.Title, err)
+		}
+		folderIDs[f.Uid] = cmd.Result.Id
+		ref.Folders = append(ref.Folders, models.TestFixtureDashboardRef{Id: cmd.Result.Id, Uid: cmd.Result.Uid, Title: cmd.Result.Title})
+	}
+
+	for _, d := range o.Dashboards {
+		// The dashboards are always created, ids of other instances would only match existing dashboards
+		d.Dashboard.Del("id")
+		cmd := &models.SaveDashboardCommand{Dashboard: d.Dashboard, OrgId: org.Id, FolderId: folderIDs[d.FolderUid]}
+		if err := saveDashboard(sess, cmd); err != nil {
+			return nil, fmt.Errorf("failed to create dashboard %q: %w", d.Dashboard.Get("title").MustString(), err)
+		}
+		ref.Dashboards = append(ref.Dashboards, models.TestFixtureDashboardRef{Id: cmd.Result.Id, Uid: cmd.Result.Uid, Title: cmd.Result.Title})
+	}
+
+	return ref, nil
+}
+
+// validateTestFixture checks the fixture can be created and torn down entirely
+func validateTestFixture(cmd *models.CreateTestFixtureCommand) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", models.ErrTestFixtureInvalid, fmt.Sprintf(format, args...))
+	}
+
+	if len(cmd.Orgs) == 0 {
+		return invalid("at least one organization is required")
+	}
+
+	members := make(map[string]bool, len(cmd.Users))
+	for _, u := range cmd.Users {
+		if u.Login == "" {
+			return invalid("users require a login")
+		}
+		if _, ok := members[u.Login]; ok {
+			return invalid("user %q is declared several times", u.Login)
+		}
+		members[u.Login] = false
+	}
+
+	orgNames := make(map[string]bool, len(cmd.Orgs))
+	for _, o := range cmd.Orgs {
+		if o.Name == "" {
+			return invalid("organizations require a name")
+		}
+		if orgNames[o.Name] {
+			return invalid("organization %q is declared several times", o.Name)
+		}
+		orgNames[o.Name] = true
+
+		for _, m := range o.Members {
+			if _, ok := members[m.Login]; !ok {
+				return invalid("member %q of organization %q is not a user of the fixture", m.Login, o.Name)
+			}
+			if !m.Role.IsValid() {
+				return invalid("invalid role %q of member %q of organization %q", m.Role, m.Login, o.Name)
+			}
+			members[m.Login] = true
+		}
+
+		folders := make(map[string]bool, len(o.Folders))
+		for _, f := range o.Folders {
+			if f.Uid == "" || f.Title == "" {
+				return invalid("folders of organization %q require a uid and a title", o.Name)
+			}
+			if folders[f.Uid] {
+				return invalid("folder %q of organization %q is declared several times", f.Uid, o.Name)
+			}
+			folders[f.Uid] = true
+		}
+
+		for _, d := range o.Dashboards {
+			if d.Dashboard == nil || d.Dashboard.Get("title").MustString() == "" {
+				return invalid("dashboards of organization %q require a title", o.Name)
+			}
+			if d.FolderUid != "" && !folders[d.FolderUid] {
+				return invalid("folder %q of organization %q is not a folder of the fixture", d.FolderUid, o.Name)
+			}
+		}
+	}
+
+	for login, member := range members {
+		if !member {
+			return invalid("user %q is not a member of any organization of the fixture", login)
+		}
+	}
+
+	return nil
+}
+
+func (ss *SQLStore) GetTestFixtures(ctx context.Context, query *models.GetTestFixturesQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var rows []testFixture
+		if err := sess.OrderBy("id").Find(&rows); err != nil {
+			return err
+		}
+
+		query.Result = make([]*models.TestFixture, 0, len(rows))
+		for _, row := range rows {
+			fixture, err := row.decode()
+			if err != nil {
+				return err
+			}
+			query.Result = append(query.Result, fixture)
+		}
+		return nil
+	})
+}
+
+func (ss *SQLStore) GetTestFixture(ctx context.Context, query *models.GetTestFixtureQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		row, err := getTestFixture(sess, query.Uid)
+		if err != nil {
+			return err
+		}
+		query.Result, err = row.decode()
+		return err
+	})
+}
+
+// DeleteTestFixture deletes the organizations and users of the fixture in a single transaction. Organizations and
+// users already deleted by the tests are skipped.
+func (ss *SQLStore) DeleteTestFixture(ctx context.Context, cmd *models.DeleteTestFixtureCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		row, err := getTestFixture(sess, cmd.Uid)
+		if err != nil {
+			return err
+		}
+		fixture, err := row.decode()
+		if err != nil {
+			return err
+		}
+
+		for _, o := range fixture.Orgs {
+			if err := deleteOrgInTransaction(sess, o.Id); err != nil && err != models.ErrOrgNotFound {
+				return err
+			}
+		}
+		for _, u := range fixture.Users {
+			if err := deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: u.Id}); err != nil && err != models.ErrUserNotFound {
+				return err
+			}
+		}
+
+		_, err = sess.ID(row.Id).Delete(&testFixture{})
+		return err
+	})
+}
+
+func getTestFixture(sess *DBSession, uid string) (*testFixture, error) {
+	var row testFixture
+	exists, err := sess.Where("uid = ?", uid).Get(&row)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrTestFixtureNotFound
+	}
+	return &row, nil
+}
+
+func (f testFixture) decode() (*models.TestFixture, error) {
+	var fixture models.TestFixture
+	if err := json.Unmarshal([]byte(f.Data), &fixture); err != nil {
+		return nil, fmt.Errorf("invalid test fixture %s: %w", f.Uid, err)
+	}
+	return &fixture, nil
+}
@@ -0,0 +1,132 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestTestFixtures(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	ctx := context.Background()
+
+	newCmd := func() *models.CreateTestFixtureCommand {
+		return &models.CreateTestFixtureCommand{
+			Users: []models.TestFixtureUser{
+				{Login: "fixture-admin", Password: "secret", IsGrafanaAdmin: true},
+				{Login: "fixture-editor", Email: "editor@example.org"},
+			},
+			Orgs: []models.TestFixtureOrg{
+				{
+					Name: "Fixture org",
+					Members: []models.TestFixtureOrgMember{
+						{Login: "fixture-admin", Role: models.ROLE_ADMIN},
+						{Login: "fixture-editor", Role: models.ROLE_EDITOR},
+					},
+					Folders: []models.TestFixtureFolder{{Uid: "folder", Title: "Folder"}},
+					Dashboards: []models.TestFixtureDashboard{
+						{FolderUid: "folder", Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": 1000, "uid": "dash", "title": "Dashboard"})},
+					},
+				},
+				{
+					Name:    "Other fixture org",
+					Members: []models.TestFixtureOrgMember{{Login: "fixture-editor", Role: models.ROLE_VIEWER}},
+				},
+			},
+		}
+	}
+
+	cmd := newCmd()
+	require.NoError(t, sqlStore.CreateTestFixture(ctx, cmd))
+	fixture := cmd.Result
+	require.Len(t, fixture.Users, 2)
+	require.Len(t, fixture.Orgs, 2)
+
+	t.Run("should create the users in their first organization", func(t *testing.T) {
+		query := &models.GetUserOrgListQuery{UserId: fixture.Users[1].Id}
+		require.NoError(t, GetUserOrgList(ctx, query))
+		require.Len(t, query.Result, 2)
+
+		signedIn := &models.GetSignedInUserQuery{UserId: fixture.Users[1].Id}
+		require.NoError(t, GetSignedInUser(ctx, signedIn))
+		assert.Equal(t, fixture.Orgs[0].Id, signedIn.Result.OrgId)
+		assert.Equal(t, models.ROLE_EDITOR, signedIn.Result.OrgRole)
+	})
+
+	t.Run("should create the folders and dashboards", func(t *testing.T) {
+		org := fixture.Orgs[0]
+		require.Len(t, org.Folders, 1)
+		require.Len(t, org.Dashboards, 1)
+		assert.Equal(t, "dash", org.Dashboards[0].Uid)
+		assert.NotEqual(t, int64(1000), org.Dashboards[0].Id)
+
+		query := &models.GetDashboardQuery{OrgId: org.Id, Uid: "dash"}
+		require.NoError(t, GetDashboard(ctx, query))
+		assert.Equal(t, org.Folders[0].Id, query.Result.FolderId)
+	})
+
+	t.Run("should list the fixtures", func(t *testing.T) {
+		query := &models.GetTestFixturesQuery{}
+		require.NoError(t, sqlStore.GetTestFixtures(ctx, query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, fixture.Uid, query.Result[0].Uid)
+		assert.Equal(t, fixture.Orgs, query.Result[0].Orgs)
+	})
+
+	t.Run("should create nothing when part of the fixture fails", func(t *testing.T) {
+		cmd := newCmd()
+		cmd.Users[0].Login = "other-admin"
+		cmd.Orgs[0].Name = "New fixture org"
+		cmd.Orgs[0].Members[0].Login = "other-admin"
+		// the editor login is already taken by the first fixture
+		err := sqlStore.CreateTestFixture(ctx, cmd)
+		require.ErrorIs(t, err, models.ErrUserAlreadyExists)
+
+		err = sqlStore.GetUserByLogin(ctx, &models.GetUserByLoginQuery{LoginOrEmail: "other-admin"})
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+		_, err = sqlStore.GetOrgByName("New fixture org")
+		assert.ErrorIs(t, err, models.ErrOrgNotFound)
+	})
+
+	t.Run("should reject invalid fixtures", func(t *testing.T) {
+		cmd := newCmd()
+		cmd.Orgs[1].Members = nil
+		cmd.Orgs[0].Members = cmd.Orgs[0].Members[:1]
+		err := sqlStore.CreateTestFixture(ctx, cmd)
+		assert.ErrorIs(t, err, models.ErrTestFixtureInvalid)
+
+		cmd = newCmd()
+		cmd.Orgs[0].Dashboards[0].FolderUid = "unknown"
+		err = sqlStore.CreateTestFixture(ctx, cmd)
+		assert.ErrorIs(t, err, models.ErrTestFixtureInvalid)
+	})
+
+	t.Run("should tear down the fixture", func(t *testing.T) {
+		// organizations deleted by the tests are skipped
+		require.NoError(t, DeleteOrg(ctx, &models.DeleteOrgCommand{Id: fixture.Orgs[1].Id}))
+
+		require.NoError(t, sqlStore.DeleteTestFixture(ctx, &models.DeleteTestFixtureCommand{Uid: fixture.Uid}))
+
+		_, err := sqlStore.GetOrgByName("Fixture org")
+		assert.ErrorIs(t, err, models.ErrOrgNotFound)
+		for _, u := range fixture.Users {
+			err := GetUserById(ctx, &models.GetUserByIdQuery{Id: u.Id})
+			assert.ErrorIs(t, err, models.ErrUserNotFound)
+		}
+		err = GetDashboard(ctx, &models.GetDashboardQuery{OrgId: fixture.Orgs[0].Id, Uid: "dash"})
+		assert.ErrorIs(t, err, models.ErrDashboardNotFound)
+
+		err = sqlStore.GetTestFixture(ctx, &models.GetTestFixtureQuery{Uid: fixture.Uid})
+		assert.ErrorIs(t, err, models.ErrTestFixtureNotFound)
+		err = sqlStore.DeleteTestFixture(ctx, &models.DeleteTestFixtureCommand{Uid: fixture.Uid})
+		assert.ErrorIs(t, err, models.ErrTestFixtureNotFound)
+	})
+}
@@ -2,19 +2,79 @@ package sqlstore
 
 import (
 	"context"
-	"errors"
+	"math/rand"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
 	"xorm.io/xorm"
 )
 
 var tsclogger = log.New("sqlstore.transactions")
 
-// WithTransactionalDbSession calls the callback with a session within a transaction.
+var (
+	// transactionRetries is the policy of the retries of the transactions failing on transient errors
+	transactionRetries = defaultTransactionRetries[migrator.SQLite]
+
+	transactionRetriesCounter          *prometheus.CounterVec
+	transactionRetriesExhaustedCounter *prometheus.CounterVec
+)
+
+// maxTransactionRetryBackoff bounds the backoff of the retries of a transaction
+const maxTransactionRetryBackoff = time.Second
+
+// retryPolicy is the number of retries of a transaction failing on a transient error, and the backoff of the first
+// retry, doubled on each of the next retries
+type retryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// defaultTransactionRetries are the retry policies of the dialects, SQLite is locked by the concurrent writes far
+// more often than the other databases deadlock.
+var defaultTransactionRetries = map[string]retryPolicy{
+	migrator.SQLite:   {MaxRetries: 5, Backoff: 10 * time.Millisecond},
+	migrator.MySQL:    {MaxRetries: 3, Backoff: 50 * time.Millisecond},
+	migrator.Postgres: {MaxRetries: 3, Backoff: 50 * time.Millisecond},
+}
+
+func init() {
+	transactionRetriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "database_transaction_retries_total",
+		Help:      "Number of database transactions retried after a transient error, by reason",
+	}, []string{"reason"})
+	transactionRetriesExhaustedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "database_transaction_retries_exhausted_total",
+		Help:      "Number of database transactions failing on a transient error after all their retries, by reason",
+	}, []string{"reason"})
+
+	prometheus.MustRegister(transactionRetriesCounter, transactionRetriesExhaustedCounter)
+}
+
+// backoff returns the jittered duration to wait before a retry, between half and all of the exponential backoff
+func (p retryPolicy) backoff(retry int) time.Duration {
+	if p.Backoff <= 0 {
+		return 0
+	}
+	backoff := p.Backoff
+	for i := 0; i < retry && backoff < maxTransactionRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxTransactionRetryBackoff {
+		backoff = maxTransactionRetryBackoff
+	}
+	half := int64(backoff / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// WithTransactionalDbSession calls the callback with a session within a transaction. The transaction is rolled
+// back and the callback called again when it fails on a transient error, such as a deadlock, so the callback must
+// not have side effects outside of the database.
 func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback dbTransactionFunc) error {
 	return inTransactionWithRetryCtx(ctx, ss.engine, callback, 0)
 }
@@ -34,7 +94,46 @@ func inTransactionWithRetry(callback dbTransactionFunc, retry int) error {
 	return inTransactionWithRetryCtx(context.Background(), x, callback, retry)
 }
 
-func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) error {
+// inTransactionWithRetryCtx runs the callback within a transaction, retried on the transient errors of the
+// dialect until retry reaches the maximum number of retries. The transactions nested in the transaction of the
+// context are retried with it.
+func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) (err error) {
+	ctx, end := startQuery(ctx, engine)
+	defer func() { end(err) }()
+
+	_, nested := ctx.Value(ContextSessionKey{}).(*DBSession)
+	policy := transactionRetries
+	for {
+		err = inTransactionAttempt(ctx, engine, callback)
+		if err == nil || nested || dialect == nil {
+			return err
+		}
+
+		reason := dialect.RetryReason(err)
+		if reason == "" {
+			return err
+		}
+		if retry >= policy.MaxRetries {
+			transactionRetriesExhaustedCounter.WithLabelValues(reason).Inc()
+			tsclogger.Warn("Transaction failed after retries", "reason", reason, "retries", retry, "error", err)
+			return err
+		}
+
+		transactionRetriesCounter.WithLabelValues(reason).Inc()
+		tsclogger.Info("Transaction failed on a transient error, sleeping then retrying", "reason", reason, "retry", retry, "error", err)
+		timer := time.NewTimer(policy.backoff(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		retry++
+	}
+}
+
+// inTransactionAttempt runs the callback within a transaction, rolled back when the callback fails
+func inTransactionAttempt(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) error {
 	sess, err := startSession(ctx, engine, true)
 	if err != nil {
 		return err
@@ -43,19 +142,6 @@ func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callbac
 	defer sess.Close()
 
 	err = callback(sess)
-
-	// special handling of database locked errors for sqlite, then we can retry 5 times
-	var sqlError sqlite3.Error
-	if errors.As(err, &sqlError) && retry < 5 && (sqlError.Code == sqlite3.ErrLocked || sqlError.Code == sqlite3.ErrBusy) {
-		if rollErr := sess.Rollback(); rollErr != nil {
-			return errutil.Wrapf(err, "Rolling back transaction due to error failed: %s", rollErr)
-		}
-
-		time.Sleep(time.Millisecond * time.Duration(10))
-		sqlog.Info("Database locked, sleeping then retrying", "error", err, "retry", retry)
-		return inTransactionWithRetry(callback, retry+1)
-	}
-
 	if err != nil {
 		if rollErr := sess.Rollback(); rollErr != nil {
 			return errutil.Wrapf(err, "Rolling back transaction due to error failed: %s", rollErr)
@@ -65,6 +151,7 @@ func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callbac
 	if err := sess.Commit(); err != nil {
 		return err
 	}
+	markWrite(ctx)
 
 	if len(sess.events) > 0 {
 		for _, e := range sess.events {
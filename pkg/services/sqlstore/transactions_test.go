@@ -6,9 +6,15 @@ package sqlstore
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,4 +59,57 @@ func TestTransaction(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, cmd.Result.Id, query.Result.Id)
 	})
+
+	t.Run("retries the transactions failing on transient errors", func(t *testing.T) {
+		policy := transactionRetries
+		transactionRetries = retryPolicy{MaxRetries: 2, Backoff: time.Millisecond}
+		t.Cleanup(func() { transactionRetries = policy })
+
+		var transientErr error
+		switch {
+		case strings.HasPrefix(ss.Dialect.DriverName(), migrator.MySQL):
+			transientErr = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+		case strings.HasPrefix(ss.Dialect.DriverName(), migrator.Postgres):
+			transientErr = &pq.Error{Code: "40P01", Message: "deadlock detected"}
+		default:
+			transientErr = sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+
+		attempts := 0
+		err := ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			attempts++
+			if attempts < 3 {
+				return transientErr
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+
+		attempts = 0
+		err = ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			attempts++
+			return transientErr
+		})
+		require.ErrorIs(t, err, transientErr)
+		require.Equal(t, 3, attempts)
+
+		attempts = 0
+		err = ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			attempts++
+			return ErrProvokedError
+		})
+		require.Equal(t, ErrProvokedError, err)
+		require.Equal(t, 1, attempts)
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := retryPolicy{MaxRetries: 5, Backoff: 100 * time.Millisecond}
+	for retry, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		backoff := policy.backoff(retry)
+		require.GreaterOrEqual(t, backoff, max/2)
+		require.LessOrEqual(t, backoff, max)
+	}
+	require.Zero(t, retryPolicy{MaxRetries: 5}.backoff(1))
 }
@@ -33,6 +33,7 @@ func (ss *SQLStore) addUserQueryAndCommandHandlers() {
 	bus.AddHandler("sql", DisableUser)
 	bus.AddHandler("sql", BatchDisableUsers)
 	bus.AddHandler("sql", DeleteUser)
+	bus.AddHandler("sql", ss.DeleteExpiredDeletedUsers)
 	bus.AddHandler("sql", SetUserHelpFlag)
 }
 
@@ -338,7 +339,7 @@ func GetUserById(ctx context.Context, query *models.GetUserByIdQuery) error {
 }
 
 func (ss *SQLStore) GetUserByLogin(ctx context.Context, query *models.GetUserByLoginQuery) error {
-	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+	return ss.WithDbSession(withPreparedStatement(ctx), func(sess *DBSession) error {
 		if query.LoginOrEmail == "" {
 			return models.ErrUserNotFound
 		}
@@ -632,6 +633,12 @@ func SearchUsers(ctx context.Context, query *models.SearchUsersQuery) error {
 	// service accounts table in the modelling
 	whereConditions = append(whereConditions, "u.is_service_account = false")
 
+	if query.IsDeleted {
+		whereConditions = append(whereConditions, "u.deleted IS NOT NULL")
+	} else {
+		whereConditions = append(whereConditions, "u.deleted IS NULL")
+	}
+
 	// Join with only most recent auth module
 	joinCondition := `(
 		SELECT id from user_auth
@@ -788,6 +795,106 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 	return nil
 }
 
+// softDeleteUserInTransaction marks a user removed from their last organization as deleted, disables them and revokes
+// their sessions. The user is kept with their preferences, stars and API keys until it is restored or purged once the
+// retention of the deleted users is over.
+func softDeleteUserInTransaction(sess *DBSession, userID int64, orgID int64) error {
+	// the organization is the one the user is added back to when restored
+	rawSQL := "UPDATE " + dialect.Quote("user") + " SET deleted = ?, is_disabled = ?, org_id = ? WHERE id = ?"
+	if _, err := sess.Exec(rawSQL, time.Now(), true, orgID, userID); err != nil {
+		return err
+	}
+	_, err := sess.Exec("DELETE FROM user_auth_token WHERE user_id = ?", userID)
+	return err
+}
+
+// restoreUserInTransaction clears the deletion of a deleted user and enables them, it returns false when the user is
+// not deleted
+func restoreUserInTransaction(sess *DBSession, userID int64) (bool, error) {
+	rawSQL := "UPDATE " + dialect.Quote("user") + " SET deleted = NULL, is_disabled = ? WHERE id = ? AND deleted IS NOT NULL"
+	res, err := sess.Exec(rawSQL, false, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// RestoreUser restores a user removed from their last organization and not purged yet, and adds them back to this
+// organization with cmd.Role, unless the organization has been deleted since
+func (ss *SQLStore) RestoreUser(ctx context.Context, cmd *models.RestoreUserCommand) error {
+	return ss.InTransaction(ctx, func(ctx context.Context) error {
+		var user models.User
+		orgExists := false
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			if has, err := sess.ID(cmd.UserId).Get(&user); err != nil {
+				return err
+			} else if !has {
+				return models.ErrUserNotFound
+			}
+
+			restored, err := restoreUserInTransaction(sess, user.Id)
+			if err != nil {
+				return err
+			}
+			if !restored {
+				return models.ErrUserNotDeleted
+			}
+
+			res, err := sess.Query("SELECT 1 FROM org WHERE id = ?", user.OrgId)
+			orgExists = len(res) == 1
+			return err
+		})
+		if err != nil || !orgExists {
+			return err
+		}
+
+		return ss.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: user.OrgId, UserId: user.Id, Role: cmd.Role})
+	})
+}
+
+// DeleteExpiredDeletedUsers purges the users deleted before cmd.DeletedBefore, by batches of at most cmd.BatchSize
+// users. Each user is purged in its own transaction, like DeleteUser.
+func (ss *SQLStore) DeleteExpiredDeletedUsers(ctx context.Context, cmd *models.DeleteExpiredDeletedUsersCommand) error {
+	batchSize := cmd.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultDeleteBatchSize
+	}
+	expired := "deleted IS NOT NULL AND deleted <= ?"
+
+	for {
+		var userIDs []int64
+		err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+			return sess.Table("user").Where(expired, cmd.DeletedBefore).Cols("id").Asc("id").Limit(int(batchSize)).Find(&userIDs)
+		})
+		if err != nil || len(userIDs) == 0 {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			purged := false
+			err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+				// the user may have been restored since
+				count, err := sess.Table("user").Where("id = ? AND "+expired, userID, cmd.DeletedBefore).Count()
+				if err != nil || count == 0 {
+					return err
+				}
+				purged = true
+				return deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: userID})
+			})
+			if err != nil {
+				return err
+			}
+			if purged {
+				cmd.DeletedRows++
+			}
+		}
+	}
+}
+
 func userDeletions() []string {
 	deletes := []string{
 		"DELETE FROM star WHERE user_id = ?",
@@ -798,14 +905,22 @@ func userDeletions() []string {
 		"DELETE FROM team_member WHERE user_id = ?",
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
+		"DELETE FROM oauth_session WHERE user_id = ?",
+		"DELETE FROM user_totp WHERE user_id = ?",
+		"DELETE FROM user_totp_recovery_code WHERE user_id = ?",
+		"DELETE FROM user_devices WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM api_key_permission WHERE api_key_id IN (SELECT id FROM api_key WHERE user_id = ?)",
+		"DELETE FROM api_key WHERE user_id = ?",
 	}
 	return deletes
 }
 
 func ServiceAccountDeletions() []string {
 	deletes := []string{
+		"DELETE FROM api_key_permission WHERE api_key_id IN (SELECT id FROM api_key WHERE service_account_id = ?)",
 		"DELETE FROM api_key WHERE service_account_id = ?",
+		"DELETE FROM service_account_federated_credential WHERE service_account_id = ?",
 	}
 	deletes = append(deletes, userDeletions()...)
 	return deletes
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -533,3 +534,94 @@ func createFiveTestUsers(t *testing.T, sqlStore *SQLStore, fn func(i int) *model
 
 	return users
 }
+
+func TestSoftDeletedUsers(t *testing.T) {
+	ss := InitTestDB(t)
+	ctx := context.Background()
+	ss.Cfg.Cleanup.DeletedUsersRetention = time.Hour
+	t.Cleanup(func() { ss.Cfg.Cleanup.DeletedUsersRetention = 0 })
+
+	admin, err := ss.CreateUser(ctx, models.CreateUserCommand{Login: "admin", Email: "admin@test.com"})
+	require.NoError(t, err)
+
+	// removeUser adds a new user to the organization of the admin only, and removes them from it
+	removeUser := func(t *testing.T, login string) *models.User {
+		t.Helper()
+		user, err := ss.CreateUser(ctx, models.CreateUserCommand{Login: login, Email: login + "@test.com"})
+		require.NoError(t, err)
+		require.NoError(t, ss.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: admin.OrgId, UserId: user.Id, Role: models.ROLE_EDITOR}))
+		require.NoError(t, DeleteOrg(ctx, &models.DeleteOrgCommand{Id: user.OrgId}))
+		require.NoError(t, ss.StarDashboard(ctx, &models.StarDashboardCommand{UserId: user.Id, DashboardId: 1}))
+
+		cmd := models.RemoveOrgUserCommand{OrgId: admin.OrgId, UserId: user.Id, ShouldDeleteOrphanedUser: true}
+		require.NoError(t, ss.RemoveOrgUser(ctx, &cmd))
+		require.True(t, cmd.UserWasDeleted)
+		return user
+	}
+
+	searchUsers := func(t *testing.T, isDeleted bool) []string {
+		t.Helper()
+		query := models.SearchUsersQuery{IsDeleted: isDeleted}
+		require.NoError(t, SearchUsers(ctx, &query))
+		logins := make([]string, 0, len(query.Result.Users))
+		for _, u := range query.Result.Users {
+			logins = append(logins, u.Login)
+		}
+		return logins
+	}
+
+	t.Run("should keep the user removed from their last organization disabled", func(t *testing.T) {
+		user := removeUser(t, "removed")
+
+		query := models.GetUserByIdQuery{Id: user.Id}
+		require.NoError(t, GetUserById(ctx, &query))
+		require.True(t, query.Result.IsDisabled)
+
+		stars := models.GetUserStarsQuery{UserId: user.Id}
+		require.NoError(t, ss.GetUserStars(ctx, &stars))
+		require.Len(t, stars.Result, 1)
+
+		require.NotContains(t, searchUsers(t, false), "removed")
+		require.Contains(t, searchUsers(t, true), "removed")
+	})
+
+	t.Run("should restore the user in the organization they were removed from", func(t *testing.T) {
+		user := removeUser(t, "restored")
+
+		require.NoError(t, ss.RestoreUser(ctx, &models.RestoreUserCommand{UserId: user.Id, Role: models.ROLE_VIEWER}))
+		require.ErrorIs(t, ss.RestoreUser(ctx, &models.RestoreUserCommand{UserId: user.Id, Role: models.ROLE_VIEWER}), models.ErrUserNotDeleted)
+
+		userQuery := models.GetUserByIdQuery{Id: user.Id}
+		require.NoError(t, GetUserById(ctx, &userQuery))
+		require.False(t, userQuery.Result.IsDisabled)
+
+		query := models.GetSignedInUserQuery{UserId: user.Id}
+		require.NoError(t, GetSignedInUser(ctx, &query))
+		require.Equal(t, admin.OrgId, query.Result.OrgId)
+		require.Equal(t, models.ROLE_VIEWER, query.Result.OrgRole)
+
+		stars := models.GetUserStarsQuery{UserId: user.Id}
+		require.NoError(t, ss.GetUserStars(ctx, &stars))
+		require.Len(t, stars.Result, 1)
+		require.Contains(t, searchUsers(t, false), "restored")
+	})
+
+	t.Run("should purge the users deleted before the retention", func(t *testing.T) {
+		user := removeUser(t, "purged")
+
+		cmd := models.DeleteExpiredDeletedUsersCommand{DeletedBefore: time.Now().Add(-time.Hour)}
+		require.NoError(t, ss.DeleteExpiredDeletedUsers(ctx, &cmd))
+		require.Zero(t, cmd.DeletedRows)
+
+		cmd = models.DeleteExpiredDeletedUsersCommand{DeletedBefore: time.Now().Add(time.Second), BatchSize: 1}
+		require.NoError(t, ss.DeleteExpiredDeletedUsers(ctx, &cmd))
+		require.Equal(t, int64(2), cmd.DeletedRows)
+
+		err := GetUserById(ctx, &models.GetUserByIdQuery{Id: user.Id})
+		require.ErrorIs(t, err, models.ErrUserNotFound)
+		stars := models.GetUserStarsQuery{UserId: user.Id}
+		require.NoError(t, ss.GetUserStars(ctx, &stars))
+		require.Empty(t, stars.Result)
+		require.Empty(t, searchUsers(t, true))
+	})
+}
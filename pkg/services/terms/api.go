@@ -0,0 +1,99 @@
+package terms
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := acmiddleware.Middleware(s.accessControl)
+	writeEvaluator := accesscontrol.EvalPermission(ActionWrite)
+	acceptancesEvaluator := accesscontrol.EvalPermission(ActionAcceptancesRead)
+
+	s.routeRegister.Group("/api/org/terms", func(termsRoute routing.RouteRegister) {
+		termsRoute.Get("/", middleware.ReqSignedIn, routing.Wrap(s.getTermsHandler))
+		termsRoute.Post("/", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.publishTermsHandler))
+		termsRoute.Delete("/", auth(middleware.ReqOrgAdmin, writeEvaluator), routing.Wrap(s.retireTermsHandler))
+		termsRoute.Post("/accept", middleware.ReqSignedIn, routing.Wrap(s.acceptTermsHandler))
+		termsRoute.Get("/acceptances", auth(middleware.ReqOrgAdmin, acceptancesEvaluator), routing.Wrap(s.searchAcceptancesHandler))
+	})
+}
+
+// GET /api/org/terms
+func (s *Service) getTermsHandler(c *models.ReqContext) response.Response {
+	terms, err := s.GetTerms(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return errorResponse(err, "Failed to get terms of use")
+	}
+	return response.JSON(http.StatusOK, terms)
+}
+
+// POST /api/org/terms
+func (s *Service) publishTermsHandler(c *models.ReqContext) response.Response {
+	cmd := PublishTermsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	terms, err := s.PublishTerms(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to publish terms of use")
+	}
+	return response.JSON(http.StatusOK, terms)
+}
+
+// DELETE /api/org/terms
+func (s *Service) retireTermsHandler(c *models.ReqContext) response.Response {
+	if err := s.RetireTerms(c.Req.Context(), c.OrgId); err != nil {
+		return errorResponse(err, "Failed to retire terms of use")
+	}
+	return response.Success("Terms of use retired")
+}
+
+// POST /api/org/terms/accept
+func (s *Service) acceptTermsHandler(c *models.ReqContext) response.Response {
+	cmd := AcceptTermsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := s.AcceptTerms(c.Req.Context(), c.SignedInUser, c.RemoteAddr(), cmd); err != nil {
+		return errorResponse(err, "Failed to accept terms of use")
+	}
+	return response.Success("Terms of use accepted")
+}
+
+// GET /api/org/terms/acceptances
+func (s *Service) searchAcceptancesHandler(c *models.ReqContext) response.Response {
+	result, err := s.SearchAcceptances(c.Req.Context(), AcceptancesQuery{
+		OrgId:   c.OrgId,
+		Version: c.QueryInt64("version"),
+		UserId:  c.QueryInt64("userId"),
+		Page:    c.QueryInt("page"),
+		PerPage: c.QueryInt("perpage"),
+	})
+	if err != nil {
+		return errorResponse(err, "Failed to search terms of use acceptances")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrTermsNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrInvalidTerms), errors.Is(err, ErrNotAUser):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrVersionMismatch):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,57 @@
+package terms
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// allowedPaths are the API paths the users can request before accepting the terms of use, to read and accept them,
+// to switch to another organization and to sign out
+var allowedPaths = []string{
+	"/api/org/terms",
+	"/api/user/orgs",
+	"/api/user/using/",
+	"/api/frontend/",
+	"/api/login/",
+}
+
+// Middleware denies the API requests of the users who have not accepted the current terms of use of their
+// organization, with the status 451 Unavailable For Legal Reasons. The pages are still served, so that the terms
+// can be shown. API keys, which service accounts sign in with, anonymous users and render calls are never denied.
+func (s *Service) Middleware(c *models.ReqContext) {
+	if !c.IsSignedIn || c.IsAnonymous || c.ApiKeyId != 0 || c.IsRenderCall {
+		return
+	}
+	if !isGated(c.Req.URL.Path) {
+		return
+	}
+
+	terms, err := s.pendingTerms(c.Req.Context(), c.OrgId, c.UserId)
+	if err != nil {
+		// Failing open keeps Grafana available when the terms cannot be checked
+		s.log.Warn("Failed to check the acceptance of the terms of use", "orgId", c.OrgId, "userId", c.UserId, "error", err)
+		return
+	}
+	if terms == nil {
+		return
+	}
+
+	c.JSON(http.StatusUnavailableForLegalReasons, map[string]interface{}{
+		"message":      "The terms of use of the organization must be accepted",
+		"termsVersion": terms.Version,
+	})
+}
+
+func isGated(path string) bool {
+	if !strings.HasPrefix(path, "/api/") || path == "/api/user" {
+		return false
+	}
+	for _, allowed := range allowedPaths {
+		if strings.HasPrefix(path, allowed) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package terms
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTermsNotFound   = errors.New("organization has no terms of use")
+	ErrInvalidTerms    = errors.New("terms of use must have a title and a content")
+	ErrVersionMismatch = errors.New("version is not the current version of the terms of use")
+	ErrNotAUser        = errors.New("terms of use can only be accepted by users")
+)
+
+const (
+	ActionWrite           = "org.terms:write"
+	ActionAcceptancesRead = "org.terms.acceptances:read"
+)
+
+// Terms is a version of the terms of use of an organization. Publishing new terms adds a version, which every user
+// of the organization has to accept. Retired terms are no longer enforced.
+type Terms struct {
+	Id        int64     `json:"id"`
+	OrgId     int64     `json:"orgId"`
+	Version   int64     `json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Retired   bool      `json:"retired"`
+	CreatedBy int64     `json:"createdBy"`
+	Created   time.Time `json:"created"`
+}
+
+func (t *Terms) TableName() string {
+	return "org_terms"
+}
+
+// Acceptance records that a user accepted a version of the terms of use. The login and the email of the user at the
+// time of the acceptance are kept, the acceptances of the deleted users remain for the audits.
+type Acceptance struct {
+	Id        int64     `json:"id"`
+	OrgId     int64     `json:"orgId"`
+	Version   int64     `json:"version"`
+	UserId    int64     `json:"userId"`
+	Login     string    `json:"login"`
+	Email     string    `json:"email"`
+	IpAddress string    `json:"ipAddress"`
+	Accepted  time.Time `json:"accepted"`
+}
+
+func (a *Acceptance) TableName() string {
+	return "org_terms_acceptance"
+}
+
+type PublishTermsCommand struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+type AcceptTermsCommand struct {
+	Version int64 `json:"version"`
+}
+
+// UserTerms are the current terms of use of an organization, and whether the user has accepted them
+type UserTerms struct {
+	*Terms
+	Accepted   bool       `json:"accepted"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// AcceptancesQuery searches the acceptances of an organization, of all the versions when Version is zero and of all
+// the users when UserId is zero
+type AcceptancesQuery struct {
+	OrgId   int64
+	Version int64
+	UserId  int64
+	Page    int
+	PerPage int
+}
+
+type AcceptancesResult struct {
+	TotalCount  int64         `json:"totalCount"`
+	Acceptances []*Acceptance `json:"acceptances"`
+	Page        int           `json:"page"`
+	PerPage     int           `json:"perPage"`
+}
@@ -0,0 +1,230 @@
+package terms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// termsCacheTTL is how long the current terms of the organizations and the acceptances are cached. New terms are
+// enforced by the other Grafana instances of a cluster after this delay.
+const termsCacheTTL = time.Minute
+
+// Service manages the terms of use of the organizations. The users of an organization with terms of use are denied
+// access to the API, by Middleware, until they accept the current version of the terms.
+type Service struct {
+	sqlStore      *sqlstore.SQLStore
+	accessControl accesscontrol.AccessControl
+	routeRegister routing.RouteRegister
+	cache         *localcache.CacheService
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	cacheRegistry *localcache.Registry) *Service {
+	s := &Service{
+		sqlStore:      sqlStore,
+		accessControl: ac,
+		routeRegister: routeRegister,
+		cache:         localcache.NewNamed("terms", termsCacheTTL, 2*termsCacheTTL),
+		log:           log.New("terms"),
+		now:           time.Now,
+	}
+	cacheRegistry.Register(s.cache)
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints()
+
+	return s
+}
+
+// GetTerms returns the current terms of the organization, and whether the user has accepted them.
+func (s *Service) GetTerms(ctx context.Context, user *models.SignedInUser) (*UserTerms, error) {
+	terms, err := s.getCurrentTerms(ctx, user.OrgId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UserTerms{Terms: terms}
+	acceptance, err := s.getAcceptance(ctx, user.OrgId, user.UserId, terms.Version)
+	if err != nil {
+		return nil, err
+	}
+	if acceptance != nil {
+		result.Accepted = true
+		result.AcceptedAt = &acceptance.Accepted
+	}
+	return result, nil
+}
+
+// PublishTerms adds a version of the terms of the organization, which all its users have to accept.
+func (s *Service) PublishTerms(ctx context.Context, user *models.SignedInUser, cmd PublishTermsCommand) (*Terms, error) {
+	if strings.TrimSpace(cmd.Title) == "" || strings.TrimSpace(cmd.Content) == "" {
+		return nil, ErrInvalidTerms
+	}
+
+	terms := &Terms{
+		OrgId:     user.OrgId,
+		Title:     cmd.Title,
+		Content:   cmd.Content,
+		CreatedBy: user.UserId,
+		Created:   s.now(),
+	}
+	if err := s.insertTerms(ctx, terms); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(currentTermsKey(user.OrgId))
+
+	s.log.Info("Published terms of use", "orgId", user.OrgId, "version", terms.Version, "userId", user.UserId)
+	return terms, nil
+}
+
+// RetireTerms stops enforcing the terms of the organization, the terms and their acceptances are kept.
+func (s *Service) RetireTerms(ctx context.Context, orgID int64) error {
+	if _, err := s.getCurrentTerms(ctx, orgID); err != nil {
+		return err
+	}
+	if err := s.retireTerms(ctx, orgID); err != nil {
+		return err
+	}
+	s.cache.Delete(currentTermsKey(orgID))
+	return nil
+}
+
+// AcceptTerms records that the user accepted the version of the terms, which must be the current version.
+func (s *Service) AcceptTerms(ctx context.Context, user *models.SignedInUser, ipAddress string, cmd AcceptTermsCommand) error {
+	if user.UserId == 0 || user.IsAnonymous {
+		return ErrNotAUser
+	}
+
+	terms, err := s.getCurrentTerms(ctx, user.OrgId)
+	if err != nil {
+		return err
+	}
+	if terms.Retired {
+		return ErrTermsNotFound
+	}
+	if cmd.Version != terms.Version {
+		return ErrVersionMismatch
+	}
+
+	err = s.insertAcceptance(ctx, &Acceptance{
+		OrgId:     user.OrgId,
+		Version:   terms.Version,
+		UserId:    user.UserId,
+		Login:     user.Login,
+		Email:     user.Email,
+		IpAddress: ipAddress,
+		Accepted:  s.now(),
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.Set(acceptanceKey(user.OrgId, user.UserId, terms.Version), true, 0)
+	return nil
+}
+
+// SearchAcceptances returns the acceptances of the terms of an organization, the most recent first.
+func (s *Service) SearchAcceptances(ctx context.Context, query AcceptancesQuery) (*AcceptancesResult, error) {
+	if query.PerPage <= 0 {
+		query.PerPage = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.searchAcceptances(ctx, query)
+}
+
+// pendingTerms returns the current terms of the organization when they are enforced and the user has not accepted
+// them, nil otherwise
+func (s *Service) pendingTerms(ctx context.Context, orgID, userID int64) (*Terms, error) {
+	terms, err := s.cachedCurrentTerms(ctx, orgID)
+	if err != nil || terms == nil {
+		return nil, err
+	}
+
+	key := acceptanceKey(orgID, userID, terms.Version)
+	if _, ok := s.cache.Get(key); ok {
+		return nil, nil
+	}
+	acceptance, err := s.getAcceptance(ctx, orgID, userID, terms.Version)
+	if err != nil {
+		return nil, err
+	}
+	if acceptance == nil {
+		return terms, nil
+	}
+	// Only the acceptances are cached, the users have to be let in as soon as they accept the terms
+	s.cache.Set(key, true, 0)
+	return nil, nil
+}
+
+// cachedCurrentTerms returns the enforced terms of the organization, nil when it has none
+func (s *Service) cachedCurrentTerms(ctx context.Context, orgID int64) (*Terms, error) {
+	key := currentTermsKey(orgID)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*Terms), nil
+	}
+
+	terms, err := s.getCurrentTerms(ctx, orgID)
+	if err != nil && !errors.Is(err, ErrTermsNotFound) {
+		return nil, err
+	}
+	if terms != nil && terms.Retired {
+		terms = nil
+	}
+	s.cache.Set(key, terms, 0)
+	return terms, nil
+}
+
+func currentTermsKey(orgID int64) string {
+	return localcache.OrgKey(orgID, "current")
+}
+
+func acceptanceKey(orgID, userID, version int64) string {
+	return localcache.OrgKey(orgID, fmt.Sprintf("accepted-%d-%d", userID, version))
+}
+
+func (s *Service) registerRoles() error {
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:org.terms:writer",
+			DisplayName: "Terms of use writer",
+			Description: "Publish and retire the terms of use of the organization.",
+			Group:       "Organizations",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionWrite},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	acceptancesReader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:org.terms.acceptances:reader",
+			DisplayName: "Terms of use acceptances reader",
+			Description: "Read which users accepted the terms of use of the organization.",
+			Group:       "Organizations",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionAcceptancesRead},
+			},
+		},
+		Grants: []string{"Admin"},
+	}
+
+	return s.accessControl.DeclareFixedRoles(writer, acceptancesReader)
+}
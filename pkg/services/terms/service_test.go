@@ -0,0 +1,145 @@
+package terms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestService_Terms(t *testing.T) {
+	ctx := context.Background()
+	s := ProvideService(sqlstore.InitTestDB(t), accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, Login: "admin", Email: "admin@example.org"}
+	viewer := &models.SignedInUser{UserId: 2, OrgId: 1, Login: "viewer", Email: "viewer@example.org"}
+
+	t.Run("should not find terms in an organization without terms", func(t *testing.T) {
+		_, err := s.GetTerms(ctx, viewer)
+		require.ErrorIs(t, err, ErrTermsNotFound)
+
+		pending, err := s.pendingTerms(ctx, viewer.OrgId, viewer.UserId)
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+	})
+
+	t.Run("should refuse terms without a content", func(t *testing.T) {
+		_, err := s.PublishTerms(ctx, admin, PublishTermsCommand{Title: "Terms"})
+		require.ErrorIs(t, err, ErrInvalidTerms)
+	})
+
+	t.Run("should require the acceptance of each published version", func(t *testing.T) {
+		first, err := s.PublishTerms(ctx, admin, PublishTermsCommand{Title: "Terms", Content: "Be nice"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), first.Version)
+
+		pending, err := s.pendingTerms(ctx, viewer.OrgId, viewer.UserId)
+		require.NoError(t, err)
+		require.NotNil(t, pending)
+		assert.Equal(t, int64(1), pending.Version)
+
+		require.NoError(t, s.AcceptTerms(ctx, viewer, "10.0.0.1", AcceptTermsCommand{Version: 1}))
+		// Accepting twice keeps the first acceptance
+		require.NoError(t, s.AcceptTerms(ctx, viewer, "10.0.0.2", AcceptTermsCommand{Version: 1}))
+		pending, err = s.pendingTerms(ctx, viewer.OrgId, viewer.UserId)
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+
+		userTerms, err := s.GetTerms(ctx, viewer)
+		require.NoError(t, err)
+		assert.True(t, userTerms.Accepted)
+		assert.Equal(t, now, userTerms.AcceptedAt.UTC())
+
+		second, err := s.PublishTerms(ctx, admin, PublishTermsCommand{Title: "Terms", Content: "Be very nice"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), second.Version)
+
+		pending, err = s.pendingTerms(ctx, viewer.OrgId, viewer.UserId)
+		require.NoError(t, err)
+		require.NotNil(t, pending)
+		assert.Equal(t, int64(2), pending.Version)
+
+		require.ErrorIs(t, s.AcceptTerms(ctx, viewer, "10.0.0.1", AcceptTermsCommand{Version: 1}), ErrVersionMismatch)
+		require.ErrorIs(t, s.AcceptTerms(ctx, &models.SignedInUser{OrgId: 1, ApiKeyId: 1}, "10.0.0.1", AcceptTermsCommand{Version: 2}), ErrNotAUser)
+		require.NoError(t, s.AcceptTerms(ctx, admin, "10.0.0.3", AcceptTermsCommand{Version: 2}))
+	})
+
+	t.Run("should search the acceptances", func(t *testing.T) {
+		result, err := s.SearchAcceptances(ctx, AcceptancesQuery{OrgId: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Acceptances, 2)
+
+		result, err = s.SearchAcceptances(ctx, AcceptancesQuery{OrgId: 1, Version: 1})
+		require.NoError(t, err)
+		require.Len(t, result.Acceptances, 1)
+		assert.Equal(t, "viewer", result.Acceptances[0].Login)
+		assert.Equal(t, "viewer@example.org", result.Acceptances[0].Email)
+		assert.Equal(t, "10.0.0.1", result.Acceptances[0].IpAddress)
+
+		result, err = s.SearchAcceptances(ctx, AcceptancesQuery{OrgId: 1, UserId: admin.UserId})
+		require.NoError(t, err)
+		require.Len(t, result.Acceptances, 1)
+		assert.Equal(t, int64(2), result.Acceptances[0].Version)
+
+		result, err = s.SearchAcceptances(ctx, AcceptancesQuery{OrgId: 2})
+		require.NoError(t, err)
+		assert.Empty(t, result.Acceptances)
+	})
+
+	t.Run("should no longer require retired terms", func(t *testing.T) {
+		require.NoError(t, s.RetireTerms(ctx, 1))
+
+		pending, err := s.pendingTerms(ctx, viewer.OrgId, viewer.UserId)
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+		require.ErrorIs(t, s.AcceptTerms(ctx, viewer, "10.0.0.1", AcceptTermsCommand{Version: 2}), ErrTermsNotFound)
+		require.ErrorIs(t, s.RetireTerms(ctx, 2), ErrTermsNotFound)
+	})
+}
+
+func TestService_Middleware(t *testing.T) {
+	ctx := context.Background()
+	s := ProvideService(sqlstore.InitTestDB(t), accesscontrolmock.New(), routing.NewRouteRegister(), localcache.ProvideRegistry())
+	admin := &models.SignedInUser{UserId: 1, OrgId: 1, Login: "admin"}
+	_, err := s.PublishTerms(ctx, admin, PublishTermsCommand{Title: "Terms", Content: "Be nice"})
+	require.NoError(t, err)
+
+	request := func(user *models.SignedInUser, path string) int {
+		rec := httptest.NewRecorder()
+		c := &models.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(http.MethodGet, path, nil), Resp: web.NewResponseWriter(http.MethodGet, rec)},
+			SignedInUser: user,
+			IsSignedIn:   true,
+		}
+		s.Middleware(c)
+		return rec.Code
+	}
+
+	viewer := &models.SignedInUser{UserId: 2, OrgId: 1, Login: "viewer"}
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request(viewer, "/api/dashboards/uid/abc"))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request(viewer, "/api/search"))
+	for _, path := range []string{"/api/org/terms", "/api/org/terms/accept", "/api/user", "/api/user/using/2", "/d/abc/dashboard"} {
+		assert.Equal(t, http.StatusOK, request(viewer, path), path)
+	}
+
+	assert.Equal(t, http.StatusOK, request(&models.SignedInUser{OrgId: 1, ApiKeyId: 1}, "/api/search"))
+	assert.Equal(t, http.StatusOK, request(&models.SignedInUser{OrgId: 1, IsAnonymous: true}, "/api/search"))
+	assert.Equal(t, http.StatusOK, request(&models.SignedInUser{UserId: 2, OrgId: 2}, "/api/search"))
+
+	require.NoError(t, s.AcceptTerms(ctx, viewer, "10.0.0.1", AcceptTermsCommand{Version: 1}))
+	assert.Equal(t, http.StatusOK, request(viewer, "/api/search"))
+}
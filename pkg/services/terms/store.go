@@ -0,0 +1,100 @@
+package terms
+
+import (
+	"context"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// getCurrentTerms returns the latest version of the terms of the organization, retired or not
+func (s *Service) getCurrentTerms(ctx context.Context, orgID int64) (*Terms, error) {
+	terms := &Terms{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ?", orgID).Desc("version").Limit(1).Get(terms)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrTermsNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+// insertTerms adds the terms as the next version of the terms of the organization
+func (s *Service) insertTerms(ctx context.Context, terms *Terms) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var version int64
+		if _, err := sess.SQL("SELECT COALESCE(MAX(version), 0) FROM org_terms WHERE org_id = ?", terms.OrgId).Get(&version); err != nil {
+			return err
+		}
+		terms.Version = version + 1
+		_, err := sess.Insert(terms)
+		return err
+	})
+}
+
+func (s *Service) retireTerms(ctx context.Context, orgID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("org_id = ?", orgID).Cols("retired").Update(&Terms{Retired: true})
+		return err
+	})
+}
+
+func (s *Service) getAcceptance(ctx context.Context, orgID, userID, version int64) (*Acceptance, error) {
+	var acceptance *Acceptance
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		result := &Acceptance{}
+		exists, err := sess.Where("org_id = ? AND user_id = ? AND version = ?", orgID, userID, version).Get(result)
+		if exists {
+			acceptance = result
+		}
+		return err
+	})
+	return acceptance, err
+}
+
+// insertAcceptance records the acceptance, a version accepted already by the user is left as it is
+func (s *Service) insertAcceptance(ctx context.Context, acceptance *Acceptance) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND user_id = ? AND version = ?", acceptance.OrgId, acceptance.UserId,
+			acceptance.Version).Exist(&Acceptance{})
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(acceptance)
+		return err
+	})
+}
+
+func (s *Service) searchAcceptances(ctx context.Context, query AcceptancesQuery) (*AcceptancesResult, error) {
+	result := &AcceptancesResult{Acceptances: make([]*Acceptance, 0), Page: query.Page, PerPage: query.PerPage}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		filter := func() *xorm.Session {
+			q := sess.Where("org_id = ?", query.OrgId)
+			if query.Version > 0 {
+				q = q.And("version = ?", query.Version)
+			}
+			if query.UserId > 0 {
+				q = q.And("user_id = ?", query.UserId)
+			}
+			return q
+		}
+
+		count, err := filter().Count(&Acceptance{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		offset := query.PerPage * (query.Page - 1)
+		return filter().Desc("accepted", "id").Limit(query.PerPage, offset).Find(&result.Acceptances)
+	})
+	return result, err
+}
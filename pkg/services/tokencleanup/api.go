@@ -0,0 +1,60 @@
+package tokencleanup
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	auth := acmiddleware.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/admin/tokens", func(tokensRoute routing.RouteRegister) {
+		tokensRoute.Get("/unused", auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(ActionUnusedTokensRead)), routing.Wrap(s.getUnusedTokensHandler))
+		tokensRoute.Post("/revoke", auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(ActionUnusedTokensRevoke)), routing.Wrap(s.revokeHandler))
+	})
+}
+
+// GET /api/admin/tokens/unused?days=90&orgId=&kind=&includeExpired=
+func (s *Service) getUnusedTokensHandler(c *models.ReqContext) response.Response {
+	query := UnusedTokensQuery{
+		Days:           c.QueryInt("days"),
+		OrgId:          c.QueryInt64("orgId"),
+		Kind:           Kind(c.Query("kind")),
+		IncludeExpired: c.QueryBool("includeExpired"),
+	}
+	tokens, err := s.UnusedTokens(c.Req.Context(), query)
+	if err != nil {
+		return errorResponse(err, "Failed to get the unused tokens")
+	}
+	return response.JSON(http.StatusOK, tokens)
+}
+
+// POST /api/admin/tokens/revoke
+func (s *Service) revokeHandler(c *models.ReqContext) response.Response {
+	cmd := RevokeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	result, err := s.Revoke(c.Req.Context(), cmd)
+	if err != nil {
+		return errorResponse(err, "Failed to revoke the unused tokens")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func errorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrInvalidDays), errors.Is(err, ErrInvalidKind):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,123 @@
+package tokencleanup
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	ErrInvalidDays = errors.New("the number of days without use must be positive")
+	ErrInvalidKind = errors.New("invalid token kind")
+)
+
+const (
+	ActionUnusedTokensRead   = "tokens.unused:read"
+	ActionUnusedTokensRevoke = "tokens.unused:revoke"
+)
+
+// Kind tells whether a token is an API key or the token of a service account
+type Kind string
+
+const (
+	KindAPIKey         Kind = "api_key"
+	KindServiceAccount Kind = "service_account"
+)
+
+func (k Kind) IsValid() bool {
+	return k == "" || k == KindAPIKey || k == KindServiceAccount
+}
+
+// Token is an API key or a service account token, with the number of days since it was last used, or since it was
+// created when it was never used
+type Token struct {
+	Id               int64           `json:"id"`
+	OrgId            int64           `json:"orgId"`
+	Name             string          `json:"name"`
+	Kind             Kind            `json:"kind"`
+	ServiceAccountId int64           `json:"serviceAccountId,omitempty"`
+	Role             models.RoleType `json:"role"`
+	Created          time.Time       `json:"created"`
+	LastUsedAt       *time.Time      `json:"lastUsedAt,omitempty"`
+	Expiration       *time.Time      `json:"expiration,omitempty"`
+	UnusedDays       int             `json:"unusedDays"`
+}
+
+// UnusedTokensQuery selects the tokens not used for at least Days days, of all the organizations when OrgId is 0 and
+// of both kinds when Kind is empty
+type UnusedTokensQuery struct {
+	Days           int
+	OrgId          int64
+	Kind           Kind
+	IncludeExpired bool
+}
+
+// RevokeCommand deletes the tokens not used for at least Days days. Ids, when set, restricts the revoked tokens to the
+// listed ones. Nothing is deleted with DryRun, the result lists the tokens that would be revoked.
+type RevokeCommand struct {
+	Days   int     `json:"days"`
+	OrgId  int64   `json:"orgId"`
+	Kind   Kind    `json:"kind"`
+	Ids    []int64 `json:"ids"`
+	DryRun bool    `json:"dryRun"`
+}
+
+type RevokeResult struct {
+	DryRun  bool     `json:"dryRun"`
+	Revoked []*Token `json:"revoked"`
+}
+
+// warning records when the administrators of the organization of a token were notified of its expiration
+type warning struct {
+	OrgId    int64     `json:"orgId"`
+	Warned   time.Time `json:"warned"`
+	Expires  time.Time `json:"expires"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// apiKey is a row of the api_key table, the key itself is not read
+type apiKey struct {
+	Id               int64
+	OrgId            int64
+	Name             string
+	Role             models.RoleType
+	Created          time.Time
+	Expires          *int64
+	ServiceAccountId int64
+	LastUsedAt       *time.Time
+}
+
+func (k *apiKey) TableName() string {
+	return "api_key"
+}
+
+// lastActive returns when the key was last used, or created when it was never used
+func (k *apiKey) lastActive() time.Time {
+	if k.LastUsedAt != nil {
+		return *k.LastUsedAt
+	}
+	return k.Created
+}
+
+func (k *apiKey) toToken(now time.Time) *Token {
+	token := &Token{
+		Id:               k.Id,
+		OrgId:            k.OrgId,
+		Name:             k.Name,
+		Kind:             KindAPIKey,
+		ServiceAccountId: k.ServiceAccountId,
+		Role:             k.Role,
+		Created:          k.Created,
+		LastUsedAt:       k.LastUsedAt,
+		UnusedDays:       int(now.Sub(k.lastActive()).Hours() / 24),
+	}
+	if k.ServiceAccountId > 0 {
+		token.Kind = KindServiceAccount
+	}
+	if k.Expires != nil {
+		expiration := time.Unix(*k.Expires, 0)
+		token.Expiration = &expiration
+	}
+	return token
+}
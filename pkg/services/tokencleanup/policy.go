@@ -0,0 +1,170 @@
+package tokencleanup
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const tmplExpirationWarning = "token_expiration_warning"
+
+// ApplyPolicy expires the tokens not used for the number of days of their kind. The administrators of the
+// organization of a token are notified warning_days before it expires, and a token always expires at least
+// warning_days after the notification, so that enabling the policy does not expire tokens without notice. A token
+// used again after the notification is no longer expired.
+func (s *Service) ApplyPolicy(ctx context.Context) error {
+	now := s.now()
+	settings := s.cfg.TokenCleanup
+
+	warnings, err := s.getWarnings(ctx)
+	if err != nil {
+		return err
+	}
+
+	policies := []struct {
+		kind Kind
+		days int
+	}{
+		{kind: KindAPIKey, days: settings.APIKeyUnusedDays},
+		{kind: KindServiceAccount, days: settings.ServiceAccountTokenUnusedDays},
+	}
+	candidates := map[int64]bool{}
+	notifications := map[int64][]*Token{}
+
+	for _, policy := range policies {
+		if policy.days <= 0 {
+			continue
+		}
+		notifyDays := policy.days - settings.WarningDays
+		if notifyDays < 0 {
+			notifyDays = 0
+		}
+
+		keys, err := s.getUnusedKeys(ctx, UnusedTokensQuery{Days: notifyDays, Kind: policy.kind}, now)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			candidates[key.Id] = true
+
+			w, ok := warnings[key.Id]
+			if !ok || !w.LastUsed.Equal(key.lastActive()) {
+				expires := key.lastActive().AddDate(0, 0, policy.days)
+				if earliest := now.AddDate(0, 0, settings.WarningDays); expires.Before(earliest) {
+					expires = earliest
+				}
+				warnings[key.Id] = &warning{OrgId: key.OrgId, Warned: now, Expires: expires, LastUsed: key.lastActive()}
+
+				token := key.toToken(now)
+				token.Expiration = &expires
+				notifications[key.OrgId] = append(notifications[key.OrgId], token)
+				continue
+			}
+
+			if now.Before(w.Expires) {
+				continue
+			}
+			if err := s.expireKey(ctx, key, now); err != nil {
+				return err
+			}
+			s.log.Info("Expired unused token", "orgId", key.OrgId, "id", key.Id, "name", key.Name, "lastUsed", key.lastActive())
+			delete(warnings, key.Id)
+		}
+	}
+
+	// Forget the tokens used again, deleted or expired otherwise
+	for id := range warnings {
+		if !candidates[id] {
+			delete(warnings, id)
+		}
+	}
+	if err := s.saveWarnings(ctx, warnings); err != nil {
+		return err
+	}
+
+	for orgID, tokens := range notifications {
+		if err := s.notify(ctx, orgID, tokens); err != nil {
+			s.log.Warn("Failed to notify the organization administrators of the expiration of unused tokens", "orgId", orgID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) getWarnings(ctx context.Context) (map[int64]*warning, error) {
+	warnings := map[int64]*warning{}
+	value, ok, err := s.kv.Get(ctx, warningsKey)
+	if err != nil || !ok {
+		return warnings, err
+	}
+	if err := json.Unmarshal([]byte(value), &warnings); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+func (s *Service) saveWarnings(ctx context.Context, warnings map[int64]*warning) error {
+	value, err := json.Marshal(warnings)
+	if err != nil {
+		return err
+	}
+	return s.kv.Set(ctx, warningsKey, string(value))
+}
+
+// notify emails the administrators of the organization the tokens that will expire
+func (s *Service) notify(ctx context.Context, orgID int64, tokens []*Token) error {
+	org := &models.GetOrgByIdQuery{Id: orgID}
+	if err := bus.Dispatch(ctx, org); err != nil {
+		return err
+	}
+	users := &models.GetOrgUsersQuery{OrgId: orgID}
+	if err := bus.Dispatch(ctx, users); err != nil {
+		return err
+	}
+
+	to := make([]string, 0)
+	for _, user := range users.Result {
+		if user.Role == string(models.ROLE_ADMIN) && user.Email != "" {
+			to = append(to, user.Email)
+		}
+	}
+	if len(to) == 0 {
+		s.log.Debug("No organization administrator to notify of the expiration of unused tokens", "orgId", orgID)
+		return nil
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].Expiration.Equal(*tokens[j].Expiration) {
+			return tokens[i].Expiration.Before(*tokens[j].Expiration)
+		}
+		return tokens[i].Name < tokens[j].Name
+	})
+	items := make([]map[string]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		lastUsed := "never"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.Format("2006-01-02")
+		}
+		kind := "API key"
+		if token.Kind == KindServiceAccount {
+			kind = "Service account token"
+		}
+		items = append(items, map[string]interface{}{
+			"Name":     token.Name,
+			"Kind":     kind,
+			"LastUsed": lastUsed,
+			"Expires":  token.Expiration.Format("2006-01-02"),
+		})
+	}
+
+	return bus.Dispatch(ctx, &models.SendEmailCommand{
+		To:       to,
+		Template: tmplExpirationWarning,
+		Data: map[string]interface{}{
+			"OrgName": org.Result.Name,
+			"Tokens":  items,
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package tokencleanup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	kvNamespace = "tokencleanup"
+	warningsKey = "warnings"
+)
+
+// Service lists and revokes the API keys and service account tokens that are no longer used, and expires them
+// according to the [token_cleanup] policy after notifying the administrators of their organization.
+type Service struct {
+	cfg           *setting.Cfg
+	sqlStore      *sqlstore.SQLStore
+	kv            *kvstore.NamespacedKVStore
+	accessControl accesscontrol.AccessControl
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore,
+	ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	schedulerService *scheduler.Service) (*Service, error) {
+	s := &Service{
+		cfg:           cfg,
+		sqlStore:      sqlStore,
+		kv:            kvstore.WithNamespace(kvStore, 0, kvNamespace),
+		accessControl: ac,
+		log:           log.New("tokencleanup"),
+		now:           time.Now,
+	}
+
+	if err := s.registerRoles(); err != nil {
+		s.log.Error("Failed to register roles", "error", err)
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	// Only one Grafana instance applies the expiration policy when running in a cluster
+	err := schedulerService.Register(scheduler.Task{
+		Name:        "token-cleanup",
+		Description: "Applies the expiration policy to the API keys and service account tokens that are no longer used.",
+		Cron:        "@hourly",
+		Enabled:     cfg.TokenCleanup.Enabled,
+		Exclusive:   true,
+		Run:         s.ApplyPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UnusedTokens returns the tokens not used for the number of days of the query, sorted by organization.
+func (s *Service) UnusedTokens(ctx context.Context, query UnusedTokensQuery) ([]*Token, error) {
+	if query.Days <= 0 {
+		return nil, ErrInvalidDays
+	}
+	if !query.Kind.IsValid() {
+		return nil, ErrInvalidKind
+	}
+
+	now := s.now()
+	keys, err := s.getUnusedKeys(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Token, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key.toToken(now))
+	}
+	return result, nil
+}
+
+// Revoke deletes the tokens not used for the number of days of the command, expired ones included.
+func (s *Service) Revoke(ctx context.Context, cmd RevokeCommand) (*RevokeResult, error) {
+	if cmd.Days <= 0 {
+		return nil, ErrInvalidDays
+	}
+	if !cmd.Kind.IsValid() {
+		return nil, ErrInvalidKind
+	}
+
+	now := s.now()
+	keys, err := s.getUnusedKeys(ctx, UnusedTokensQuery{Days: cmd.Days, OrgId: cmd.OrgId, Kind: cmd.Kind, IncludeExpired: true}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[int64]bool{}
+	for _, id := range cmd.Ids {
+		selected[id] = true
+	}
+
+	result := &RevokeResult{DryRun: cmd.DryRun, Revoked: make([]*Token, 0)}
+	for _, key := range keys {
+		if len(selected) > 0 && !selected[key.Id] {
+			continue
+		}
+		if !cmd.DryRun {
+			if err := s.deleteKey(ctx, key); err != nil {
+				// Deleted in the meantime
+				if errors.Is(err, models.ErrApiKeyNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			s.log.Info("Revoked unused token", "orgId", key.OrgId, "id", key.Id, "name", key.Name)
+		}
+		result.Revoked = append(result.Revoked, key.toToken(now))
+	}
+	return result, nil
+}
+
+func (s *Service) registerRoles() error {
+	reader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:tokens.unused:reader",
+			DisplayName: "Unused tokens reader",
+			Description: "List the API keys and service account tokens that are no longer used, in all organizations.",
+			Group:       "User administration (global)",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionUnusedTokensRead},
+			},
+		},
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	revoker := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:tokens.unused:revoker",
+			DisplayName: "Unused tokens revoker",
+			Description: "List and revoke the API keys and service account tokens that are no longer used, in all organizations.",
+			Group:       "User administration (global)",
+			Permissions: []accesscontrol.Permission{
+				{Action: ActionUnusedTokensRead},
+				{Action: ActionUnusedTokensRevoke},
+			},
+		},
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	return s.accessControl.DeclareFixedRoles(reader, revoker)
+}
@@ -0,0 +1,234 @@
+package tokencleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/models"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/scheduler"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_UnusedTokens(t *testing.T) {
+	ctx := context.Background()
+	s, now := setupTestService(t, setting.TokenCleanupSettings{})
+	orgID := createOrgAdmin(t, s.sqlStore, "admin")
+
+	stale := createKey(t, s.sqlStore, orgID, "stale", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -100))
+	never := createKey(t, s.sqlStore, orgID, "never", 0, now.AddDate(0, 0, -120), time.Time{})
+	createKey(t, s.sqlStore, orgID, "recent", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -10))
+	createKey(t, s.sqlStore, orgID, "token", 5, now.AddDate(0, 0, -200), now.AddDate(0, 0, -95))
+	expired := createKey(t, s.sqlStore, orgID, "expired", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -100))
+	err := s.expireKey(ctx, expired, now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	t.Run("should validate the query", func(t *testing.T) {
+		_, err := s.UnusedTokens(ctx, UnusedTokensQuery{})
+		assert.ErrorIs(t, err, ErrInvalidDays)
+		_, err = s.UnusedTokens(ctx, UnusedTokensQuery{Days: 90, Kind: "user"})
+		assert.ErrorIs(t, err, ErrInvalidKind)
+		_, err = s.Revoke(ctx, RevokeCommand{Days: -1})
+		assert.ErrorIs(t, err, ErrInvalidDays)
+	})
+
+	t.Run("should list the tokens not used for the number of days", func(t *testing.T) {
+		tokens, err := s.UnusedTokens(ctx, UnusedTokensQuery{Days: 90})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stale", "never", "token"}, tokenNames(tokens))
+		assert.Equal(t, 100, tokens[0].UnusedDays)
+		assert.Equal(t, 120, tokens[1].UnusedDays)
+		assert.Nil(t, tokens[1].LastUsedAt)
+		assert.Equal(t, KindServiceAccount, tokens[2].Kind)
+		assert.Equal(t, int64(5), tokens[2].ServiceAccountId)
+
+		tokens, err = s.UnusedTokens(ctx, UnusedTokensQuery{Days: 90, Kind: KindAPIKey, IncludeExpired: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stale", "never", "expired"}, tokenNames(tokens))
+
+		tokens, err = s.UnusedTokens(ctx, UnusedTokensQuery{Days: 90, OrgId: orgID + 1})
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("should not delete the tokens on dry run", func(t *testing.T) {
+		result, err := s.Revoke(ctx, RevokeCommand{Days: 90, Ids: []int64{stale.Id, never.Id}, DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, []string{"stale", "never"}, tokenNames(result.Revoked))
+		assert.Len(t, getKeys(t, s.sqlStore, orgID), 5)
+	})
+
+	t.Run("should delete the unused tokens", func(t *testing.T) {
+		result, err := s.Revoke(ctx, RevokeCommand{Days: 90, Kind: KindServiceAccount})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"token"}, tokenNames(result.Revoked))
+
+		result, err = s.Revoke(ctx, RevokeCommand{Days: 110})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"never"}, tokenNames(result.Revoked))
+
+		var names []string
+		for _, key := range getKeys(t, s.sqlStore, orgID) {
+			names = append(names, key.Name)
+		}
+		assert.ElementsMatch(t, []string{"stale", "recent", "expired"}, names)
+	})
+}
+
+func TestService_ApplyPolicy(t *testing.T) {
+	ctx := context.Background()
+	s, now := setupTestService(t, setting.TokenCleanupSettings{
+		Enabled:          true,
+		APIKeyUnusedDays: 90,
+		WarningDays:      7,
+	})
+	orgID := createOrgAdmin(t, s.sqlStore, "admin")
+
+	var emails []*models.SendEmailCommand
+	bus.AddHandler("test", func(_ context.Context, cmd *models.SendEmailCommand) error {
+		emails = append(emails, cmd)
+		return nil
+	})
+
+	createKey(t, s.sqlStore, orgID, "stale", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -100))
+	soon := createKey(t, s.sqlStore, orgID, "soon", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -80))
+	createKey(t, s.sqlStore, orgID, "recent", 0, now.AddDate(0, 0, -200), now.AddDate(0, 0, -10))
+	createKey(t, s.sqlStore, orgID, "token", 5, now.AddDate(0, 0, -200), now.AddDate(0, 0, -100))
+
+	t.Run("should notify the organization administrators before expiring the tokens", func(t *testing.T) {
+		require.NoError(t, s.ApplyPolicy(ctx))
+		require.Len(t, emails, 1)
+		assert.Equal(t, tmplExpirationWarning, emails[0].Template)
+		assert.Equal(t, []string{"admin@example.org"}, emails[0].To)
+		assert.Equal(t, []map[string]interface{}{
+			{"Name": "stale", "Kind": "API key", "LastUsed": "2021-08-02", "Expires": "2021-11-17"},
+		}, emails[0].Data["Tokens"])
+		assert.Empty(t, expiredKeys(t, s))
+
+		// Not notified twice
+		require.NoError(t, s.ApplyPolicy(ctx))
+		assert.Len(t, emails, 1)
+
+		s.now = func() time.Time { return now.AddDate(0, 0, 4) }
+		require.NoError(t, s.ApplyPolicy(ctx))
+		require.Len(t, emails, 2)
+		assert.Equal(t, []map[string]interface{}{
+			{"Name": "soon", "Kind": "API key", "LastUsed": "2021-08-22", "Expires": "2021-11-21"},
+		}, emails[1].Data["Tokens"])
+		assert.Empty(t, expiredKeys(t, s))
+	})
+
+	t.Run("should expire the tokens once notified", func(t *testing.T) {
+		s.now = func() time.Time { return now.AddDate(0, 0, 7) }
+		require.NoError(t, s.ApplyPolicy(ctx))
+		assert.Len(t, emails, 2)
+		assert.Equal(t, []string{"stale"}, expiredKeys(t, s))
+	})
+
+	t.Run("should forget the tokens used again", func(t *testing.T) {
+		err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.Exec("UPDATE api_key SET last_used_at = ? WHERE id = ?", now.AddDate(0, 0, 7), soon.Id)
+			return err
+		})
+		require.NoError(t, err)
+
+		s.now = func() time.Time { return now.AddDate(0, 0, 12) }
+		require.NoError(t, s.ApplyPolicy(ctx))
+		assert.Equal(t, []string{"stale"}, expiredKeys(t, s))
+
+		warnings, err := s.getWarnings(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+}
+
+func setupTestService(t *testing.T, settings setting.TokenCleanupSettings) (*Service, time.Time) {
+	t.Helper()
+
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.TokenCleanup = settings
+
+	kv := kvstore.ProvideService(store)
+	s, err := ProvideService(cfg, store, kv, accesscontrolmock.New(), routing.NewRouteRegister(),
+		scheduler.ProvideService(nil, kv, accesscontrolmock.New(), routing.NewRouteRegister()))
+	require.NoError(t, err)
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, now
+}
+
+func createOrgAdmin(t *testing.T, store *sqlstore.SQLStore, login string) int64 {
+	t.Helper()
+
+	user, err := store.CreateUser(context.Background(), models.CreateUserCommand{Login: login, Email: login + "@example.org"})
+	require.NoError(t, err)
+	return user.OrgId
+}
+
+func createKey(t *testing.T, store *sqlstore.SQLStore, orgID int64, name string, serviceAccountID int64, created, lastUsed time.Time) *apiKey {
+	t.Helper()
+
+	cmd := &models.AddApiKeyCommand{OrgId: orgID, Name: name, Role: models.ROLE_VIEWER, Key: name, ServiceAccountId: serviceAccountID}
+	require.NoError(t, store.AddAPIKey(context.Background(), cmd))
+
+	key := &apiKey{Id: cmd.Result.Id, OrgId: orgID, Name: name, Created: created, ServiceAccountId: serviceAccountID}
+	err := store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		if lastUsed.IsZero() {
+			_, err := sess.Exec("UPDATE api_key SET created = ? WHERE id = ?", created, key.Id)
+			return err
+		}
+		key.LastUsedAt = &lastUsed
+		_, err := sess.Exec("UPDATE api_key SET created = ?, last_used_at = ? WHERE id = ?", created, lastUsed, key.Id)
+		return err
+	})
+	require.NoError(t, err)
+	return key
+}
+
+func getKeys(t *testing.T, store *sqlstore.SQLStore, orgID int64) []*apiKey {
+	t.Helper()
+
+	var keys []*apiKey
+	err := store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("id").Find(&keys)
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+// expiredKeys returns the names of the keys expired at the current time of the service
+func expiredKeys(t *testing.T, s *Service) []string {
+	t.Helper()
+
+	names := make([]string, 0)
+	err := s.sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		var keys []*apiKey
+		if err := sess.Where("expires IS NOT NULL AND expires <= ?", s.now().Unix()).Asc("id").Find(&keys); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			names = append(names, key.Name)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return names
+}
+
+func tokenNames(tokens []*Token) []string {
+	names := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		names = append(names, token.Name)
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package tokencleanup
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// getUnusedKeys returns the API keys and service account tokens not used since the number of days of the query
+func (s *Service) getUnusedKeys(ctx context.Context, query UnusedTokensQuery, now time.Time) ([]*apiKey, error) {
+	cutoff := now.AddDate(0, 0, -query.Days)
+	result := make([]*apiKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := sess.Where("(last_used_at < ? OR (last_used_at IS NULL AND created < ?))", cutoff, cutoff)
+		if query.OrgId > 0 {
+			q = q.And("org_id = ?", query.OrgId)
+		}
+		switch query.Kind {
+		case KindAPIKey:
+			q = q.And("(service_account_id IS NULL OR service_account_id < 1)")
+		case KindServiceAccount:
+			q = q.And("service_account_id > 0")
+		}
+		if !query.IncludeExpired {
+			q = q.And("(expires IS NULL OR expires >= ?)", now.Unix())
+		}
+		return q.Asc("org_id", "id").Find(&result)
+	})
+	return result, err
+}
+
+// expireKey sets the expiration of the key to now, the key is kept so that its owners can see it expired
+func (s *Service) expireKey(ctx context.Context, key *apiKey, now time.Time) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("UPDATE api_key SET expires = ?, updated = ? WHERE id = ? AND org_id = ?", now.Unix(), now, key.Id, key.OrgId)
+		return err
+	})
+}
+
+func (s *Service) deleteKey(ctx context.Context, key *apiKey) error {
+	return s.sqlStore.DeleteApiKey(ctx, &models.DeleteApiKeyCommand{Id: key.Id, OrgId: key.OrgId})
+}
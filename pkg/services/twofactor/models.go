@@ -0,0 +1,76 @@
+package twofactor
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTOTPDisabled        = errors.New("two-factor authentication is disabled")
+	ErrTOTPNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPRequired        = errors.New("two-factor authentication is required for the user")
+	ErrInvalidCode         = errors.New("invalid two-factor authentication code")
+	ErrCodeRequired        = errors.New("two-factor authentication code required")
+	ErrEnrollmentRequired  = errors.New("two-factor authentication enrollment required")
+	ErrEnrollmentExpired   = errors.New("two-factor authentication enrollment expired")
+	ErrNotSupportedForUser = errors.New("two-factor authentication is only supported for users")
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes generated for a user
+	recoveryCodeCount = 10
+	// enrollmentTokenTTL is how long the users required to enroll when they log in have to confirm the enrollment
+	enrollmentTokenTTL = 10 * time.Minute
+)
+
+// UserTOTP is the TOTP secret of a user. It is pending until the user confirms the enrollment with a code, and
+// LastUsedStep prevents reusing a code. The enrollments started when logging in are bound to the hash of a token,
+// which must be sent back with the code before EnrollmentExpires, a Unix timestamp.
+type UserTOTP struct {
+	Id                int64
+	UserId            int64
+	Secret            string
+	Enabled           bool
+	LastUsedStep      int64
+	EnrollmentToken   string
+	EnrollmentExpires int64
+	Created           time.Time
+	Updated           time.Time
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// RecoveryCode is the hash of a recovery code of a user, deleted once used
+type RecoveryCode struct {
+	Id      int64
+	UserId  int64
+	Code    string
+	Salt    string
+	Created time.Time
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_totp_recovery_code"
+}
+
+// Enrollment is a pending TOTP enrollment, to add to an authenticator app with the URL or the secret. The enrollments
+// started when logging in have a token, sent back along with the first code to confirm the enrollment.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+	Token  string `json:"token,omitempty"`
+}
+
+type Status struct {
+	Enabled bool `json:"enabled"`
+	// Required is whether an enforcement policy requires the user to use two-factor authentication
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type CodeCommand struct {
+	Code string `json:"code"`
+}
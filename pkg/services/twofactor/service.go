@@ -0,0 +1,413 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Service manages the two-factor authentication of the users with time-based one-time passwords (TOTP). Users
+// enroll a secret in an authenticator app, and get recovery codes once they confirm the enrollment with a code. The
+// logins with a password then require a code, or a recovery code. The [auth.totp] settings require some users to
+// enroll, based on their roles or organizations.
+type Service struct {
+	cfg            *setting.Cfg
+	sqlStore       *sqlstore.SQLStore
+	secretsService secrets.Service
+	log            log.Logger
+	now            func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, secretsService secrets.Service) *Service {
+	return &Service{
+		cfg:            cfg,
+		sqlStore:       sqlStore,
+		secretsService: secretsService,
+		log:            log.New("twofactor"),
+		now:            time.Now,
+	}
+}
+
+// LoginResult is the two-factor authentication state of a successful login
+type LoginResult struct {
+	// Enrollment is the pending enrollment of a user required to enroll, returned with ErrEnrollmentRequired
+	Enrollment *Enrollment
+	// RecoveryCodes are the recovery codes of a user who confirmed their enrollment when logging in
+	RecoveryCodes []string
+}
+
+// VerifyLogin verifies the two-factor authentication of a user who logged in with a password. Users who enrolled
+// must send a code or a recovery code, else ErrCodeRequired is returned. Users required to enroll get
+// ErrEnrollmentRequired along with a new pending enrollment, and confirm it by logging in again with a code and the
+// token of the enrollment. The secret of a pending enrollment is never returned again, ErrEnrollmentExpired is
+// returned when the token does not match or has expired.
+func (s *Service) VerifyLogin(ctx context.Context, user *models.User, code, recoveryCode, enrollmentToken string) (*LoginResult, error) {
+	if !s.cfg.TOTPEnabled {
+		return &LoginResult{}, nil
+	}
+
+	totp, err := s.getTOTP(ctx, user.Id)
+	if err != nil && !errors.Is(err, ErrTOTPNotEnrolled) {
+		return nil, err
+	}
+	if totp != nil && totp.Enabled {
+		if code == "" && recoveryCode == "" {
+			return nil, ErrCodeRequired
+		}
+		return &LoginResult{}, s.verify(ctx, totp, code, recoveryCode)
+	}
+
+	required, err := s.IsRequired(ctx, user.Id, user.IsAdmin)
+	if err != nil || !required {
+		return &LoginResult{}, err
+	}
+	if code == "" || enrollmentToken == "" {
+		enrollment, err := s.newLoginEnrollment(ctx, user.Id, accountName(user.Login, user.Email))
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Enrollment: enrollment}, ErrEnrollmentRequired
+	}
+	if totp == nil || !s.validEnrollmentToken(totp, enrollmentToken) {
+		return nil, ErrEnrollmentExpired
+	}
+
+	recoveryCodes, err := s.confirm(ctx, totp, code)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{RecoveryCodes: recoveryCodes}, nil
+}
+
+// GetStatus returns the two-factor authentication status of the user
+func (s *Service) GetStatus(ctx context.Context, user *models.SignedInUser) (*Status, error) {
+	required, err := s.IsRequired(ctx, user.UserId, user.IsGrafanaAdmin)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Required: required}
+
+	totp, err := s.getTOTP(ctx, user.UserId)
+	if err != nil {
+		if errors.Is(err, ErrTOTPNotEnrolled) {
+			return status, nil
+		}
+		return nil, err
+	}
+	status.Enabled = totp.Enabled
+	if totp.Enabled {
+		codes, err := s.getRecoveryCodes(ctx, user.UserId)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = len(codes)
+	}
+	return status, nil
+}
+
+// Enroll creates a pending enrollment of the user with a new secret, replacing any pending one. The enrollment is
+// enabled once confirmed with a code.
+func (s *Service) Enroll(ctx context.Context, user *models.SignedInUser) (*Enrollment, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, ErrTOTPDisabled
+	}
+	totp, err := s.getTOTP(ctx, user.UserId)
+	if err != nil && !errors.Is(err, ErrTOTPNotEnrolled) {
+		return nil, err
+	}
+	if totp != nil && totp.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	return s.newEnrollment(ctx, user.UserId, accountName(user.Login, user.Email))
+}
+
+// Confirm enables the pending enrollment of the user with a code of the secret, and returns the recovery codes of the
+// user
+func (s *Service) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, ErrTOTPDisabled
+	}
+	totp, err := s.getTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	return s.confirm(ctx, totp, code)
+}
+
+// Disable removes the two-factor authentication of the user, after verifying a code or a recovery code. Users required
+// to use two-factor authentication cannot disable it.
+func (s *Service) Disable(ctx context.Context, user *models.SignedInUser, code, recoveryCode string) error {
+	totp, err := s.getTOTP(ctx, user.UserId)
+	if err != nil {
+		return err
+	}
+	if totp.Enabled {
+		required, err := s.IsRequired(ctx, user.UserId, user.IsGrafanaAdmin)
+		if err != nil {
+			return err
+		}
+		if required {
+			return ErrTOTPRequired
+		}
+		if err := s.verify(ctx, totp, code, recoveryCode); err != nil {
+			return err
+		}
+	}
+	_, err = s.deleteTOTP(ctx, user.UserId)
+	return err
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, after verifying a code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	totp, err := s.getTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !totp.Enabled {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if err := s.verify(ctx, totp, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes(userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.replaceRecoveryCodes(ctx, userID, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Reset removes the two-factor authentication of a user who lost their authenticator app and recovery codes. Users
+// required to use two-factor authentication enroll again on their next login.
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	deleted, err := s.deleteTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTOTPNotEnrolled
+	}
+	s.log.Info("Reset two-factor authentication", "userId", userID)
+	return nil
+}
+
+// IsRequired returns whether the enforcement policies of the [auth.totp] settings require the user to use two-factor
+// authentication
+func (s *Service) IsRequired(ctx context.Context, userID int64, isGrafanaAdmin bool) (bool, error) {
+	if !s.cfg.TOTPEnabled || (len(s.cfg.TOTPEnforcedRoles) == 0 && len(s.cfg.TOTPEnforcedOrgIDs) == 0) {
+		return false, nil
+	}
+
+	roles := map[string]bool{}
+	for _, role := range s.cfg.TOTPEnforcedRoles {
+		roles[strings.ToLower(role)] = true
+	}
+	if isGrafanaAdmin && roles["grafana admin"] {
+		return true, nil
+	}
+	orgIDs := map[int64]bool{}
+	for _, orgID := range s.cfg.TOTPEnforcedOrgIDs {
+		orgIDs[orgID] = true
+	}
+
+	orgUsers, err := s.getOrgUsers(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, orgUser := range orgUsers {
+		if orgIDs[orgUser.OrgId] || roles[strings.ToLower(string(orgUser.Role))] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsEnforced returns whether the logins of the user require a second factor, because the user enrolled or is
+// required to. The authentication methods which cannot verify a second factor, such as basic auth, must be refused
+// for these users.
+func (s *Service) IsEnforced(ctx context.Context, user *models.User) (bool, error) {
+	if !s.cfg.TOTPEnabled {
+		return false, nil
+	}
+	totp, err := s.getTOTP(ctx, user.Id)
+	if err != nil && !errors.Is(err, ErrTOTPNotEnrolled) {
+		return false, err
+	}
+	if totp != nil && totp.Enabled {
+		return true, nil
+	}
+	return s.IsRequired(ctx, user.Id, user.IsAdmin)
+}
+
+// verify verifies the code, or else the recovery code, of the enabled TOTP
+func (s *Service) verify(ctx context.Context, totp *UserTOTP, code, recoveryCode string) error {
+	if code == "" {
+		if recoveryCode != "" {
+			return s.useRecoveryCode(ctx, totp.UserId, recoveryCode)
+		}
+		return ErrInvalidCode
+	}
+
+	secret, err := s.decryptSecret(ctx, totp.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := validateCode(secret, code, s.now(), totp.LastUsedStep)
+	if !ok {
+		return ErrInvalidCode
+	}
+	// Another login may have used the same code in the meantime
+	updated, err := s.updateLastUsedStep(ctx, totp.Id, step)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func (s *Service) confirm(ctx context.Context, totp *UserTOTP, code string) ([]string, error) {
+	secret, err := s.decryptSecret(ctx, totp.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := validateCode(secret, code, s.now(), totp.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashed, err := generateRecoveryCodes(totp.UserId, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enableTOTP(ctx, totp, step, hashed); err != nil {
+		return nil, err
+	}
+	s.log.Info("Enabled two-factor authentication", "userId", totp.UserId)
+	return codes, nil
+}
+
+// newLoginEnrollment creates a pending enrollment bound to a new token, replacing any pending one, for a user required
+// to enroll when logging in. Logging in again with the password only starts a new enrollment, so that the secret of
+// an enrollment cannot be retrieved with the password.
+func (s *Service) newLoginEnrollment(ctx context.Context, userID int64, account string) (*Enrollment, error) {
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	enrollment, err := s.saveEnrollment(ctx, userID, account, hashEnrollmentToken(token), s.now().Add(enrollmentTokenTTL).Unix())
+	if err != nil {
+		return nil, err
+	}
+	enrollment.Token = token
+	return enrollment, nil
+}
+
+func (s *Service) newEnrollment(ctx context.Context, userID int64, account string) (*Enrollment, error) {
+	return s.saveEnrollment(ctx, userID, account, "", 0)
+}
+
+func (s *Service) saveEnrollment(ctx context.Context, userID int64, account, hashedToken string, expires int64) (*Enrollment, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	totp := &UserTOTP{
+		UserId:            userID,
+		Secret:            base64.StdEncoding.EncodeToString(encrypted),
+		EnrollmentToken:   hashedToken,
+		EnrollmentExpires: expires,
+		Created:           now,
+		Updated:           now,
+	}
+	if err := s.savePendingTOTP(ctx, totp); err != nil {
+		return nil, err
+	}
+	return &Enrollment{Secret: secret, URL: keyURI(s.cfg.TOTPIssuer, account, secret)}, nil
+}
+
+// validEnrollmentToken tells whether the token is the one of the pending enrollment started when logging in, and has
+// not expired
+func (s *Service) validEnrollmentToken(totp *UserTOTP, token string) bool {
+	if totp.EnrollmentToken == "" || s.now().Unix() >= totp.EnrollmentExpires {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(totp.EnrollmentToken), []byte(hashEnrollmentToken(token))) == 1
+}
+
+func hashEnrollmentToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *Service) decryptSecret(ctx context.Context, secret string) (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := s.secretsService.Decrypt(ctx, encrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
+
+// generateRecoveryCodes returns new recovery codes of the user, and their hashes to store
+func generateRecoveryCodes(userID int64, now time.Time) ([]string, []*RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashed := make([]*RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := util.GetRandomString(10, []byte("abcdefghijkmnpqrstuvwxyz23456789")...)
+		if err != nil {
+			return nil, nil, err
+		}
+		salt, err := util.GetRandomString(10)
+		if err != nil {
+			return nil, nil, err
+		}
+		hash, err := util.EncodePassword(code, salt)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashed = append(hashed, &RecoveryCode{UserId: userID, Code: hash, Salt: salt, Created: now})
+	}
+	return codes, hashed, nil
+}
+
+// normalizeRecoveryCode removes the separators users may type in a recovery code
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+func accountName(login, email string) string {
+	if login != "" {
+		return login
+	}
+	return email
+}
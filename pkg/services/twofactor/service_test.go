@@ -0,0 +1,155 @@
+package twofactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.TOTPEnabled = true
+	cfg.TOTPIssuer = "Grafana"
+
+	now := time.Unix(1_600_000_000, 0)
+	s := ProvideService(cfg, sqlStore, fakes.NewFakeSecretsService())
+	s.now = func() time.Time { return now }
+
+	createUser := func(t *testing.T, login string) (*models.User, *models.SignedInUser) {
+		t.Helper()
+		user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: login, Email: login + "@example.org"})
+		require.NoError(t, err)
+		return user, &models.SignedInUser{UserId: user.Id, OrgId: user.OrgId, Login: user.Login, Email: user.Email}
+	}
+	codeOf := func(t *testing.T, secret string, at time.Time) string {
+		t.Helper()
+		key, err := secretEncoding.DecodeString(secret)
+		require.NoError(t, err)
+		return totpCode(key, at.Unix()/totpPeriod)
+	}
+
+	t.Run("should require a code to log in once enrolled", func(t *testing.T) {
+		user, signedInUser := createUser(t, "enrolled")
+
+		result, err := s.VerifyLogin(ctx, user, "", "", "")
+		require.NoError(t, err)
+		assert.Nil(t, result.Enrollment)
+
+		enrollment, err := s.Enroll(ctx, signedInUser)
+		require.NoError(t, err)
+		assert.Contains(t, enrollment.URL, "otpauth://totp/Grafana:enrolled?")
+
+		_, err = s.Confirm(ctx, user.Id, "000000")
+		require.ErrorIs(t, err, ErrInvalidCode)
+		recoveryCodes, err := s.Confirm(ctx, user.Id, codeOf(t, enrollment.Secret, now))
+		require.NoError(t, err)
+		require.Len(t, recoveryCodes, recoveryCodeCount)
+
+		_, err = s.VerifyLogin(ctx, user, "", "", "")
+		require.ErrorIs(t, err, ErrCodeRequired)
+		// The code of the confirmation cannot be used again
+		_, err = s.VerifyLogin(ctx, user, codeOf(t, enrollment.Secret, now), "", "")
+		require.ErrorIs(t, err, ErrInvalidCode)
+		_, err = s.VerifyLogin(ctx, user, codeOf(t, enrollment.Secret, now.Add(totpPeriod*time.Second)), "", "")
+		require.NoError(t, err)
+
+		_, err = s.VerifyLogin(ctx, user, "", recoveryCodes[0], "")
+		require.NoError(t, err)
+		_, err = s.VerifyLogin(ctx, user, "", recoveryCodes[0], "")
+		require.ErrorIs(t, err, ErrInvalidCode)
+
+		status, err := s.GetStatus(ctx, signedInUser)
+		require.NoError(t, err)
+		assert.Equal(t, &Status{Enabled: true, RecoveryCodesRemaining: recoveryCodeCount - 1}, status)
+
+		_, err = s.Enroll(ctx, signedInUser)
+		require.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+
+		require.NoError(t, s.Disable(ctx, signedInUser, "", recoveryCodes[1]))
+		_, err = s.VerifyLogin(ctx, user, "", "", "")
+		require.NoError(t, err)
+	})
+
+	t.Run("should enroll the users required to use two-factor authentication when they log in", func(t *testing.T) {
+		user, signedInUser := createUser(t, "required")
+		cfg.TOTPEnforcedOrgIDs = []int64{user.OrgId}
+		t.Cleanup(func() { cfg.TOTPEnforcedOrgIDs = nil })
+
+		result, err := s.VerifyLogin(ctx, user, "", "", "")
+		require.ErrorIs(t, err, ErrEnrollmentRequired)
+		require.NotNil(t, result.Enrollment)
+		require.NotEmpty(t, result.Enrollment.Token)
+		// Logging in again with the password only does not return the pending secret
+		again, err := s.VerifyLogin(ctx, user, "", "", "")
+		require.ErrorIs(t, err, ErrEnrollmentRequired)
+		assert.NotEqual(t, result.Enrollment.Secret, again.Enrollment.Secret)
+		assert.NotEqual(t, result.Enrollment.Token, again.Enrollment.Token)
+
+		// The enrollment is confirmed with its token only, before it expires
+		_, err = s.VerifyLogin(ctx, user, codeOf(t, again.Enrollment.Secret, now), "", result.Enrollment.Token)
+		require.ErrorIs(t, err, ErrEnrollmentExpired)
+		s.now = func() time.Time { return now.Add(enrollmentTokenTTL) }
+		_, err = s.VerifyLogin(ctx, user, codeOf(t, again.Enrollment.Secret, now), "", again.Enrollment.Token)
+		require.ErrorIs(t, err, ErrEnrollmentExpired)
+		s.now = func() time.Time { return now }
+
+		result, err = s.VerifyLogin(ctx, user, codeOf(t, again.Enrollment.Secret, now), "", again.Enrollment.Token)
+		require.NoError(t, err)
+		require.Len(t, result.RecoveryCodes, recoveryCodeCount)
+		enforced, err := s.IsEnforced(ctx, user)
+		require.NoError(t, err)
+		assert.True(t, enforced)
+
+		err = s.Disable(ctx, signedInUser, codeOf(t, again.Enrollment.Secret, now.Add(totpPeriod*time.Second)), "")
+		require.ErrorIs(t, err, ErrTOTPRequired)
+
+		require.NoError(t, s.Reset(ctx, user.Id))
+		require.ErrorIs(t, s.Reset(ctx, user.Id), ErrTOTPNotEnrolled)
+		_, err = s.VerifyLogin(ctx, user, "", "", "")
+		require.ErrorIs(t, err, ErrEnrollmentRequired)
+	})
+
+	t.Run("should require two-factor authentication of the enforced roles", func(t *testing.T) {
+		user, _ := createUser(t, "role")
+		cfg.TOTPEnforcedRoles = []string{"Grafana Admin"}
+		t.Cleanup(func() { cfg.TOTPEnforcedRoles = nil })
+
+		required, err := s.IsRequired(ctx, user.Id, false)
+		require.NoError(t, err)
+		assert.False(t, required)
+		required, err = s.IsRequired(ctx, user.Id, true)
+		require.NoError(t, err)
+		assert.True(t, required)
+
+		cfg.TOTPEnforcedRoles = []string{"Admin"}
+		required, err = s.IsRequired(ctx, user.Id, false)
+		require.NoError(t, err)
+		assert.True(t, required)
+	})
+
+	t.Run("should regenerate the recovery codes", func(t *testing.T) {
+		user, signedInUser := createUser(t, "regenerate")
+		enrollment, err := s.Enroll(ctx, signedInUser)
+		require.NoError(t, err)
+		oldCodes, err := s.Confirm(ctx, user.Id, codeOf(t, enrollment.Secret, now))
+		require.NoError(t, err)
+
+		newCodes, err := s.RegenerateRecoveryCodes(ctx, user.Id, codeOf(t, enrollment.Secret, now.Add(totpPeriod*time.Second)))
+		require.NoError(t, err)
+		require.Len(t, newCodes, recoveryCodeCount)
+
+		_, err = s.VerifyLogin(ctx, user, "", oldCodes[0], "")
+		require.ErrorIs(t, err, ErrInvalidCode)
+		_, err = s.VerifyLogin(ctx, user, "", newCodes[0], "")
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) getTOTP(ctx context.Context, userID int64) (*UserTOTP, error) {
+	totp := &UserTOTP{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("user_id = ?", userID).Get(totp)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrTOTPNotEnrolled
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return totp, nil
+}
+
+// savePendingTOTP replaces the TOTP of the user by the pending one
+func (s *Service) savePendingTOTP(ctx context.Context, totp *UserTOTP) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Where("user_id = ?", totp.UserId).Delete(&UserTOTP{}); err != nil {
+			return err
+		}
+		_, err := sess.Insert(totp)
+		return err
+	})
+}
+
+func (s *Service) enableTOTP(ctx context.Context, totp *UserTOTP, step int64, codes []*RecoveryCode) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		totp.Enabled = true
+		totp.LastUsedStep = step
+		totp.EnrollmentToken = ""
+		totp.EnrollmentExpires = 0
+		totp.Updated = s.now()
+		if _, err := sess.ID(totp.Id).Cols("enabled", "last_used_step", "enrollment_token", "enrollment_expires", "updated").Update(totp); err != nil {
+			return err
+		}
+		return insertRecoveryCodes(sess, totp.UserId, codes)
+	})
+}
+
+// updateLastUsedStep updates the last used step of the TOTP, and returns false when it was already at or after the
+// step
+func (s *Service) updateLastUsedStep(ctx context.Context, id int64, step int64) (bool, error) {
+	var updated bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("UPDATE user_totp SET last_used_step = ?, updated = ? WHERE id = ? AND last_used_step < ?",
+			step, s.now(), id, step)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		updated = affected == 1
+		return err
+	})
+	return updated, err
+}
+
+// deleteTOTP deletes the TOTP and the recovery codes of the user, and returns whether the user had a TOTP
+func (s *Service) deleteTOTP(ctx context.Context, userID int64) (bool, error) {
+	var deleted bool
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("user_id = ?", userID).Delete(&UserTOTP{})
+		if err != nil {
+			return err
+		}
+		deleted = affected > 0
+		_, err = sess.Where("user_id = ?", userID).Delete(&RecoveryCode{})
+		return err
+	})
+	return deleted, err
+}
+
+func (s *Service) getRecoveryCodes(ctx context.Context, userID int64) ([]*RecoveryCode, error) {
+	codes := make([]*RecoveryCode, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("user_id = ?", userID).Find(&codes)
+	})
+	return codes, err
+}
+
+func (s *Service) replaceRecoveryCodes(ctx context.Context, userID int64, codes []*RecoveryCode) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return insertRecoveryCodes(sess, userID, codes)
+	})
+}
+
+// useRecoveryCode deletes the recovery code of the user, or returns ErrInvalidCode when the user has no such code
+func (s *Service) useRecoveryCode(ctx context.Context, userID int64, code string) error {
+	codes, err := s.getRecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	code = normalizeRecoveryCode(code)
+	for _, recoveryCode := range codes {
+		hash, err := util.EncodePassword(code, recoveryCode.Salt)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(recoveryCode.Code)) != 1 {
+			continue
+		}
+
+		var deleted int64
+		err = s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			deleted, err = sess.ID(recoveryCode.Id).Delete(&RecoveryCode{})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		// Another login may have used the same code in the meantime
+		if deleted == 0 {
+			return ErrInvalidCode
+		}
+		s.log.Info("Used a recovery code", "userId", userID, "remaining", len(codes)-1)
+		return nil
+	}
+	return ErrInvalidCode
+}
+
+func insertRecoveryCodes(sess *sqlstore.DBSession, userID int64, codes []*RecoveryCode) error {
+	if _, err := sess.Where("user_id = ?", userID).Delete(&RecoveryCode{}); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		if _, err := sess.Insert(code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOrgUsers returns the organization memberships of the user
+func (s *Service) getOrgUsers(ctx context.Context, userID int64) ([]*models.OrgUser, error) {
+	orgUsers := make([]*models.OrgUser, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("user_id = ?", userID).Find(&orgUsers)
+	})
+	return orgUsers, err
+}
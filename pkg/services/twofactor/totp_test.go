@@ -0,0 +1,68 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238 for SHA1
+	secret := []byte("12345678901234567890")
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range tests {
+		assert.Equal(t, expected, totpCode(secret, unix/totpPeriod), "time %d", unix)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	secret, err := generateSecret()
+	require.NoError(t, err)
+	key, err := secretEncoding.DecodeString(secret)
+	require.NoError(t, err)
+
+	now := time.Unix(1_600_000_000, 0)
+	step := now.Unix() / totpPeriod
+
+	t.Run("should accept the codes of the current and adjacent periods", func(t *testing.T) {
+		for _, s := range []int64{step - 1, step, step + 1} {
+			validStep, ok := validateCode(secret, totpCode(key, s), now, 0)
+			assert.True(t, ok)
+			assert.Equal(t, s, validStep)
+		}
+	})
+
+	t.Run("should reject the codes of other periods", func(t *testing.T) {
+		_, ok := validateCode(secret, totpCode(key, step-2), now, 0)
+		assert.False(t, ok)
+		_, ok = validateCode(secret, totpCode(key, step+2), now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject a code already used", func(t *testing.T) {
+		_, ok := validateCode(secret, totpCode(key, step), now, step)
+		assert.False(t, ok)
+		_, ok = validateCode(secret, totpCode(key, step+1), now, step)
+		assert.True(t, ok)
+	})
+
+	t.Run("should reject malformed codes", func(t *testing.T) {
+		_, ok := validateCode(secret, "12345", now, 0)
+		assert.False(t, ok)
+		_, ok = validateCode("not base32!", "123456", now, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestKeyURI(t *testing.T) {
+	assert.Equal(t, "otpauth://totp/Grafana:admin@example.com?algorithm=SHA1&digits=6&issuer=Grafana&period=30&secret=JBSWY3DPEHPK3PXP",
+		keyURI("Grafana", "admin@example.com", "JBSWY3DPEHPK3PXP"))
+}
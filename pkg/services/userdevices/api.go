@@ -0,0 +1,38 @@
+package userdevices
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/user/devices", func(devicesRoute routing.RouteRegister) {
+		devicesRoute.Get("/", routing.Wrap(s.getDevicesHandler))
+		devicesRoute.Delete("/:id", routing.Wrap(s.forgetDeviceHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+}
+
+// GET /api/user/devices
+func (s *Service) getDevicesHandler(c *models.ReqContext) response.Response {
+	devices, err := s.GetDevices(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get devices", err)
+	}
+	return response.JSON(http.StatusOK, devices)
+}
+
+// DELETE /api/user/devices/:id
+func (s *Service) forgetDeviceHandler(c *models.ReqContext) response.Response {
+	if err := s.ForgetDevice(c.Req.Context(), c.UserId, c.ParamsInt64(":id")); err != nil {
+		if errors.Is(err, ErrDeviceNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to forget device", err)
+	}
+	return response.Success("Device forgotten")
+}
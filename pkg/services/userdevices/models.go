@@ -0,0 +1,23 @@
+package userdevices
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+// Device is a device a user signed in from, identified by the fingerprint of its user agent and IP address
+type Device struct {
+	Id          int64     `json:"id"`
+	UserId      int64     `json:"userId"`
+	Fingerprint string    `json:"-"`
+	UserAgent   string    `json:"userAgent"`
+	ClientIp    string    `json:"clientIp"`
+	Created     time.Time `json:"created"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+func (d *Device) TableName() string {
+	return "user_devices"
+}
@@ -0,0 +1,128 @@
+package userdevices
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const tmplNewDeviceLogin = "new_device_login"
+
+// Service records the devices the users sign in from, and emails the users signing in from a device or an IP
+// address not seen before. The users list and forget their devices through the /api/user/devices endpoints.
+type Service struct {
+	cfg      *setting.Cfg
+	sqlStore *sqlstore.SQLStore
+	log      log.Logger
+	now      func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, b bus.Bus, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:      cfg,
+		sqlStore: sqlStore,
+		log:      log.New("userdevices"),
+		now:      time.Now,
+	}
+	b.AddEventListener(s.onUserTokenCreated)
+	s.registerAPIEndpoints(routeRegister)
+	return s
+}
+
+// GetDevices returns the devices of the user, the last seen first
+func (s *Service) GetDevices(ctx context.Context, userID int64) ([]*Device, error) {
+	return s.getDevices(ctx, userID)
+}
+
+// ForgetDevice deletes a device of the user, the next sign in from the device is notified again
+func (s *Service) ForgetDevice(ctx context.Context, userID, id int64) error {
+	return s.deleteDevice(ctx, userID, id)
+}
+
+// SeeDevice records the sign in of the user from the device with the user agent and IP address, and returns true
+// when the device was not seen before. The first device of a user is not considered new, as there is nothing to
+// compare it with.
+func (s *Service) SeeDevice(ctx context.Context, userID int64, userAgent, clientIP string) (*Device, bool, error) {
+	now := s.now()
+	device := &Device{
+		UserId:      userID,
+		Fingerprint: fingerprint(userAgent, clientIP),
+		UserAgent:   userAgent,
+		ClientIp:    clientIP,
+		Created:     now,
+		LastSeen:    now,
+	}
+	created, count, err := s.saveDevice(ctx, device)
+	if err != nil {
+		return nil, false, err
+	}
+	return device, created && count > 1, nil
+}
+
+// onUserTokenCreated records the device of the user signing in, and notifies the user when it is new
+func (s *Service) onUserTokenCreated(ctx context.Context, e *events.UserTokenCreated) error {
+	device, isNew, err := s.SeeDevice(ctx, e.UserID, e.UserAgent, e.ClientIP)
+	if err != nil {
+		s.log.Error("Failed to record the device of the user", "userId", e.UserID, "error", err)
+		return nil
+	}
+	if !isNew || !s.cfg.NewDeviceNotifications {
+		return nil
+	}
+
+	if err := s.notify(ctx, device); err != nil {
+		s.log.Error("Failed to notify the user of the sign in from a new device", "userId", e.UserID, "error", err)
+	}
+	return nil
+}
+
+func (s *Service) notify(ctx context.Context, device *Device) error {
+	if !s.cfg.Smtp.Enabled {
+		s.log.Debug("SMTP is disabled, the user is not notified of the sign in from a new device", "userId", device.UserId)
+		return nil
+	}
+
+	query := &models.GetUserByIdQuery{Id: device.UserId}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		return err
+	}
+	user := query.Result
+	if user.Email == "" || user.IsServiceAccount {
+		return nil
+	}
+
+	userAgent := device.UserAgent
+	if userAgent == "" {
+		userAgent = "Unknown"
+	}
+	clientIP := device.ClientIp
+	if clientIP == "" {
+		clientIP = "Unknown"
+	}
+	return bus.Dispatch(ctx, &models.SendEmailCommand{
+		To:       []string{user.Email},
+		Template: tmplNewDeviceLogin,
+		Data: map[string]interface{}{
+			"Name":      user.NameOrFallback(),
+			"Login":     user.Login,
+			"UserAgent": userAgent,
+			"ClientIP":  clientIP,
+			"Time":      device.Created.UTC().Format("2006-01-02 15:04:05 MST"),
+		},
+	})
+}
+
+// fingerprint identifies a device by its user agent and IP address
+func fingerprint(userAgent, clientIP string) string {
+	sum := sha256.Sum256([]byte(userAgent + "\n" + clientIP))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package userdevices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_NewDeviceNotifications(t *testing.T) {
+	ctx := context.Background()
+	store := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.NewDeviceNotifications = true
+	cfg.Smtp.Enabled = true
+	b := bus.New()
+	s := ProvideService(cfg, store, b, routing.NewRouteRegister())
+	now := time.Date(2021, 11, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	user, err := store.CreateUser(ctx, models.CreateUserCommand{Login: "alice", Email: "alice@example.org"})
+	require.NoError(t, err)
+
+	var emails []*models.SendEmailCommand
+	bus.AddHandler("test", func(_ context.Context, cmd *models.SendEmailCommand) error {
+		emails = append(emails, cmd)
+		return nil
+	})
+	signIn := func(userAgent, clientIP string) {
+		t.Helper()
+		err := b.Publish(ctx, &events.UserTokenCreated{Timestamp: now, UserID: user.Id, ClientIP: clientIP, UserAgent: userAgent})
+		require.NoError(t, err)
+	}
+
+	t.Run("should not notify the first sign in of a user", func(t *testing.T) {
+		signIn("Firefox", "10.0.0.1")
+		assert.Empty(t, emails)
+
+		devices, err := s.GetDevices(ctx, user.Id)
+		require.NoError(t, err)
+		require.Len(t, devices, 1)
+		assert.Equal(t, "Firefox", devices[0].UserAgent)
+		assert.Equal(t, "10.0.0.1", devices[0].ClientIp)
+	})
+
+	t.Run("should not notify the sign ins from a known device", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		signIn("Firefox", "10.0.0.1")
+		assert.Empty(t, emails)
+
+		devices, err := s.GetDevices(ctx, user.Id)
+		require.NoError(t, err)
+		require.Len(t, devices, 1)
+		assert.Equal(t, now, devices[0].LastSeen.UTC())
+		assert.True(t, devices[0].Created.Before(devices[0].LastSeen))
+	})
+
+	t.Run("should notify the sign ins from a new device or IP address", func(t *testing.T) {
+		signIn("Firefox", "192.168.1.1")
+		signIn("Chrome", "10.0.0.1")
+		require.Len(t, emails, 2)
+		assert.Equal(t, []string{"alice@example.org"}, emails[0].To)
+		assert.Equal(t, tmplNewDeviceLogin, emails[0].Template)
+		assert.Equal(t, "192.168.1.1", emails[0].Data["ClientIP"])
+		assert.Equal(t, "Chrome", emails[1].Data["UserAgent"])
+
+		devices, err := s.GetDevices(ctx, user.Id)
+		require.NoError(t, err)
+		require.Len(t, devices, 3)
+	})
+
+	t.Run("should notify again the sign ins from a forgotten device", func(t *testing.T) {
+		devices, err := s.GetDevices(ctx, user.Id)
+		require.NoError(t, err)
+		require.NoError(t, s.ForgetDevice(ctx, user.Id, devices[0].Id))
+		require.ErrorIs(t, s.ForgetDevice(ctx, user.Id, devices[0].Id), ErrDeviceNotFound)
+		require.ErrorIs(t, s.ForgetDevice(ctx, user.Id+1, devices[1].Id), ErrDeviceNotFound)
+
+		emails = nil
+		signIn(devices[0].UserAgent, devices[0].ClientIp)
+		require.Len(t, emails, 1)
+	})
+
+	t.Run("should not notify when the notifications are disabled", func(t *testing.T) {
+		cfg.NewDeviceNotifications = false
+		t.Cleanup(func() { cfg.NewDeviceNotifications = true })
+
+		emails = nil
+		signIn("Safari", "10.0.0.2")
+		assert.Empty(t, emails)
+
+		devices, err := s.GetDevices(ctx, user.Id)
+		require.NoError(t, err)
+		assert.Len(t, devices, 4)
+	})
+}
@@ -0,0 +1,56 @@
+package userdevices
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getDevices(ctx context.Context, userID int64) ([]*Device, error) {
+	devices := make([]*Device, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("user_id = ?", userID).Desc("last_seen").Asc("id").Find(&devices)
+	})
+	return devices, err
+}
+
+// saveDevice inserts the device, or updates when it was last seen when the user already signed in from it. It
+// returns whether the device was inserted, with the number of devices of the user.
+func (s *Service) saveDevice(ctx context.Context, device *Device) (bool, int64, error) {
+	var created bool
+	var count int64
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		existing := &Device{}
+		exists, err := sess.Where("user_id = ? AND fingerprint = ?", device.UserId, device.Fingerprint).Get(existing)
+		if err != nil {
+			return err
+		}
+		if exists {
+			device.Id = existing.Id
+			device.Created = existing.Created
+			_, err = sess.ID(existing.Id).Cols("last_seen").Update(device)
+			return err
+		}
+
+		if _, err := sess.Insert(device); err != nil {
+			return err
+		}
+		created = true
+		count, err = sess.Where("user_id = ?", device.UserId).Count(&Device{})
+		return err
+	})
+	return created, count, err
+}
+
+func (s *Service) deleteDevice(ctx context.Context, userID, id int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("user_id = ? AND id = ?", userID, id).Delete(&Device{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrDeviceNotFound
+		}
+		return nil
+	})
+}
@@ -228,9 +228,48 @@ type Cfg struct {
 	PluginsPath        string
 	BundledPluginsPath string
 
+	// RoleBindingsReconcileInterval is the interval at which the role bindings provisioning files are applied again
+	RoleBindingsReconcileInterval time.Duration
+
 	// SMTP email settings
 	Smtp SmtpSettings
 
+	// Permission export
+	PermissionExport PermissionExportSettings
+
+	// Dashboard thumbnails
+	DashboardThumbnails DashboardThumbnailsSettings
+
+	// Permission webhooks
+	PermissionWebhooks PermissionWebhooksSettings
+
+	// API recording and replay
+	APIReplay APIReplaySettings
+
+	// API explorer
+	APIExplorer APIExplorerSettings
+
+	// Expiration of the unused API keys and service account tokens
+	TokenCleanup TokenCleanupSettings
+
+	// Retention of the data deleted by the cleanup service
+	Cleanup CleanupSettings
+
+	// Backend of the entity store
+	EntityStore EntityStoreSettings
+
+	// Sinks of the authentication audit events
+	AuthAudit AuthAuditSettings
+
+	// Public dashboards
+	PublicDashboards PublicDashboardsSettings
+
+	// Recording of the dashboards displayed in kiosk mode around the alert firings
+	KioskRecording KioskRecordingSettings
+
+	// Usage insights of the dashboards
+	DashboardUsage DashboardUsageSettings
+
 	// Rendering
 	ImagesDir                      string
 	CSVsDir                        string
@@ -241,6 +280,10 @@ type Cfg struct {
 	// Security
 	DisableInitAdminCreation          bool
 	DisableBruteForceLoginProtection  bool
+	BruteForceLoginMaxAttempts        int64
+	BruteForceLoginMaxAttemptsPerIP   int64
+	BruteForceLoginWindow             time.Duration
+	BruteForceLoginCooldown           time.Duration
 	CookieSecure                      bool
 	CookieSameSiteDisabled            bool
 	CookieSameSiteMode                http.SameSite
@@ -277,6 +320,9 @@ type Cfg struct {
 
 	// Dashboards
 	DefaultHomeDashboardPath string
+	DashboardStorage         DashboardStorageSettings
+	// DashboardEventSourcing records the changes of the dashboard saves as events
+	DashboardEventSourcing bool
 
 	// Auth
 	LoginCookieName              string
@@ -288,6 +334,14 @@ type Cfg struct {
 	AdminUser                    string
 	AdminPassword                string
 
+	// TOTP two-factor authentication of the logins with a password
+	TOTPEnabled bool
+	TOTPIssuer  string
+	// TOTPEnforcedRoles are the organization roles, or Grafana Admin, whose users must use two-factor authentication
+	TOTPEnforcedRoles []string
+	// TOTPEnforcedOrgIDs are the organizations whose members must use two-factor authentication
+	TOTPEnforcedOrgIDs []int64
+
 	// AWS Plugin Auth
 	AWSAllowedAuthProviders []string
 	AWSAssumeRoleEnabled    bool
@@ -308,6 +362,10 @@ type Cfg struct {
 
 	// OAuth
 	OAuthCookieMaxAge int
+	// OAuthTokenRefresh settings refresh the OAuth tokens of the users with a session before they expire
+	OAuthTokenRefreshEnabled  bool
+	OAuthTokenRefreshInterval time.Duration
+	OAuthTokenRefreshAhead    time.Duration
 
 	// JWT Auth
 	JWTAuthEnabled       bool
@@ -319,6 +377,41 @@ type Cfg struct {
 	JWTAuthCacheTTL      time.Duration
 	JWTAuthKeyFile       string
 	JWTAuthJWKSetFile    string
+	// JWTAuthJWKSetMinRefreshInterval limits how often the key set is fetched again when a token is signed with an
+	// unknown key
+	JWTAuthJWKSetMinRefreshInterval time.Duration
+	JWTAuthAutoSignUp               bool
+	JWTAuthNameClaim                string
+	// JWTAuthClaimMapping is a JSON array of the rules mapping the claims of the tokens to the organizations, roles
+	// and teams of the users
+	JWTAuthClaimMapping string
+
+	// Client certificate auth
+	ClientCertAuthEnabled bool
+	// ClientCertAuthCAFile is the bundle of the certificate authorities the client certificates are verified with
+	ClientCertAuthCAFile         string
+	ClientCertAuthRequired       bool
+	ClientCertAuthLoginAttribute string
+	ClientCertAuthAutoSignUp     bool
+	// ClientCertAuthHeaderName is the header a terminating proxy forwards the client certificates in
+	ClientCertAuthHeaderName     string
+	ClientCertAuthAllowedProxies []string
+
+	// Workload identity federation
+	WorkloadIdentityEnabled        bool
+	WorkloadIdentityAllowedIssuers []string
+	WorkloadIdentityTokenTTL       time.Duration
+	WorkloadIdentityCacheTTL       time.Duration
+
+	// Authentication providers of the app plugins
+	PluginAuthEnabled bool
+	// PluginAuthAllowedProviders are the providers, as plugin ID/provider ID, users can sign in with, all the
+	// providers of the installed plugins when empty
+	PluginAuthAllowedProviders []string
+	PluginAuthAutoSignUp       bool
+	// PluginAuthTokenHeaderName is the header of the tokens validated by PluginAuthTokenProvider
+	PluginAuthTokenHeaderName string
+	PluginAuthTokenProvider   string
 
 	// Dataproxy
 	SendUserHeader                 bool
@@ -340,6 +433,10 @@ type Cfg struct {
 	EditorsCanAdmin bool
 
 	ApiKeyMaxSecondsToLive int64
+	// ApiKeyRotationGracePeriod is how long the previous key of a rotated API key remains valid
+	ApiKeyRotationGracePeriod time.Duration
+	// NewDeviceNotifications emails the users signing in from a device or an IP address not seen before
+	NewDeviceNotifications bool
 
 	// Use to enable new features which may still be in alpha/beta stage.
 	FeatureToggles       map[string]bool
@@ -347,6 +444,11 @@ type Cfg struct {
 	AnonymousOrgName     string
 	AnonymousOrgRole     string
 	AnonymousHideVersion bool
+	// AnonymousAllowedDashboards, AnonymousAllowedFolders and AnonymousAllowedPaths restrict anonymous access to the
+	// dashboards, the folders and the URL prefixes listed, the whole organization is accessible when they are empty
+	AnonymousAllowedDashboards []string
+	AnonymousAllowedFolders    []string
+	AnonymousAllowedPaths      []string
 
 	DateFormats DateFormats
 
@@ -359,6 +461,7 @@ type Cfg struct {
 	AlertingAnnotationCleanupSetting   AnnotationCleanupSettings
 	DashboardAnnotationCleanupSettings AnnotationCleanupSettings
 	APIAnnotationCleanupSettings       AnnotationCleanupSettings
+	AnnotationColdStorage              AnnotationColdStorageSettings
 
 	// Sentry config
 	Sentry Sentry
@@ -381,8 +484,10 @@ type Cfg struct {
 	ApplicationInsightsEndpointUrl      string
 
 	// LDAP
-	LDAPEnabled     bool
-	LDAPAllowSignup bool
+	LDAPEnabled           bool
+	LDAPAllowSignup       bool
+	LDAPSyncCron          string
+	LDAPActiveSyncEnabled bool
 
 	Quota QuotaSettings
 
@@ -396,6 +501,9 @@ type Cfg struct {
 	// ExpressionsEnabled specifies whether expressions are enabled.
 	ExpressionsEnabled bool
 
+	// TestFixturesEnabled specifies whether the test fixtures API is enabled.
+	TestFixturesEnabled bool
+
 	ImageUploadProvider string
 
 	// LiveMaxConnections is a maximum number of WebSocket connections to
@@ -410,6 +518,9 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveExclusiveDashboardEditing lets the first user editing a dashboard
+	// lock it, the other editors can only save after taking over.
+	LiveExclusiveDashboardEditing bool
 
 	// Grafana.com URL
 	GrafanaComURL string
@@ -631,6 +742,14 @@ func (cfg *Cfg) readExpressionsSettings() {
 	cfg.ExpressionsEnabled = expressions.Key("enabled").MustBool(true)
 }
 
+func (cfg *Cfg) readTestFixturesSettings() {
+	testFixtures := cfg.Raw.Section("test_fixtures")
+	cfg.TestFixturesEnabled = testFixtures.Key("enabled").MustBool(false)
+	if cfg.TestFixturesEnabled {
+		cfg.Logger.Warn("The test fixtures API is enabled, it must never be enabled in production")
+	}
+}
+
 type AnnotationCleanupSettings struct {
 	MaxAge   time.Duration
 	MaxCount int64
@@ -910,6 +1029,7 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.BundledPluginsPath = makeAbsolute("plugins-bundled", HomePath)
 	provisioning := valueAsString(iniFile.Section("paths"), "provisioning", "")
 	cfg.ProvisioningPath = makeAbsolute(provisioning, HomePath)
+	cfg.RoleBindingsReconcileInterval = iniFile.Section("paths").Key("role_bindings_reconcile_interval").MustDuration(time.Minute)
 
 	if err := cfg.readServerSettings(iniFile); err != nil {
 		return err
@@ -933,6 +1053,7 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	MinRefreshInterval = valueAsString(dashboards, "min_refresh_interval", "5s")
 
 	cfg.DefaultHomeDashboardPath = dashboards.Key("default_home_dashboard_path").MustString("")
+	cfg.DashboardEventSourcing = dashboards.Key("event_sourcing").MustBool(false)
 
 	if err := readUserSettings(iniFile, cfg); err != nil {
 		return err
@@ -1000,13 +1121,67 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.readSmtpSettings()
 	cfg.readQuotaSettings()
 	cfg.readAnnotationSettings()
+	if err := cfg.readAnnotationColdStorageSettings(); err != nil {
+		return err
+	}
 	cfg.readExpressionsSettings()
+	cfg.readTestFixturesSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
 		return err
 	}
 
 	cfg.readDataSourcesSettings()
 
+	if err := cfg.readPermissionExportSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readDashboardThumbnailsSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readPermissionWebhooksSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readAPIReplaySettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readAPIExplorerSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readTokenCleanupSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readCleanupSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readEntityStoreSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readAuthAuditSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readDashboardStorageSettings(); err != nil {
+		return err
+	}
+
+	cfg.readPublicDashboardsSettings()
+
+	if err := cfg.readKioskRecordingSettings(); err != nil {
+		return err
+	}
+
+	if err := cfg.readDashboardUsageSettings(); err != nil {
+		return err
+	}
+
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		cfg.Logger.Warn("require_email_validation is enabled but smtp is disabled")
 	}
@@ -1071,10 +1246,12 @@ type RemoteCacheOptions struct {
 func (cfg *Cfg) readLDAPConfig() {
 	ldapSec := cfg.Raw.Section("auth.ldap")
 	LDAPConfigFile = ldapSec.Key("config_file").String()
-	LDAPSyncCron = ldapSec.Key("sync_cron").String()
+	LDAPSyncCron = ldapSec.Key("sync_cron").MustString("0 0 1 * * *")
+	cfg.LDAPSyncCron = LDAPSyncCron
 	LDAPEnabled = ldapSec.Key("enabled").MustBool(false)
 	cfg.LDAPEnabled = LDAPEnabled
 	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
+	cfg.LDAPActiveSyncEnabled = LDAPActiveSyncEnabled
 	LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
 	cfg.LDAPAllowSignup = LDAPAllowSignup
 }
@@ -1187,6 +1364,16 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 	cfg.SecretKey = SecretKey
 	DisableGravatar = security.Key("disable_gravatar").MustBool(true)
 	cfg.DisableBruteForceLoginProtection = security.Key("disable_brute_force_login_protection").MustBool(false)
+	cfg.BruteForceLoginMaxAttempts = security.Key("brute_force_login_max_attempts").MustInt64(5)
+	cfg.BruteForceLoginMaxAttemptsPerIP = security.Key("brute_force_login_max_attempts_per_ip").MustInt64(0)
+	cfg.BruteForceLoginWindow = security.Key("brute_force_login_window").MustDuration(5 * time.Minute)
+	cfg.BruteForceLoginCooldown = security.Key("brute_force_login_cooldown").MustDuration(0)
+	if cfg.BruteForceLoginMaxAttempts <= 0 || cfg.BruteForceLoginMaxAttemptsPerIP < 0 {
+		return errors.New("brute_force_login_max_attempts must be positive and brute_force_login_max_attempts_per_ip cannot be negative")
+	}
+	if cfg.BruteForceLoginWindow <= 0 || cfg.BruteForceLoginCooldown < 0 {
+		return errors.New("brute_force_login_window must be positive and brute_force_login_cooldown cannot be negative")
+	}
 
 	CookieSecure = security.Key("cookie_secure").MustBool(false)
 	cfg.CookieSecure = CookieSecure
@@ -1269,6 +1456,12 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	}
 
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
+	cfg.ApiKeyRotationGracePeriod, err = gtime.ParseDuration(valueAsString(auth, "api_key_rotation_grace_period", "24h"))
+	if err != nil {
+		return err
+	}
+
+	cfg.NewDeviceNotifications = auth.Key("new_device_notifications").MustBool(true)
 
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
 	if cfg.TokenRotationIntervalMinutes < 2 {
@@ -1279,6 +1472,12 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	DisableSignoutMenu = auth.Key("disable_signout_menu").MustBool(false)
 	OAuthAutoLogin = auth.Key("oauth_auto_login").MustBool(false)
 	cfg.OAuthCookieMaxAge = auth.Key("oauth_state_cookie_max_age").MustInt(600)
+	cfg.OAuthTokenRefreshEnabled = auth.Key("oauth_token_refresh_enabled").MustBool(false)
+	cfg.OAuthTokenRefreshInterval = auth.Key("oauth_token_refresh_interval").MustDuration(time.Minute)
+	if cfg.OAuthTokenRefreshInterval < 10*time.Second {
+		cfg.OAuthTokenRefreshInterval = 10 * time.Second
+	}
+	cfg.OAuthTokenRefreshAhead = auth.Key("oauth_token_refresh_ahead").MustDuration(5 * time.Minute)
 	SignoutRedirectUrl = valueAsString(auth, "signout_redirect_url", "")
 
 	// SigV4
@@ -1291,12 +1490,29 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.AnonymousOrgName = valueAsString(iniFile.Section("auth.anonymous"), "org_name", "")
 	cfg.AnonymousOrgRole = valueAsString(iniFile.Section("auth.anonymous"), "org_role", "")
 	cfg.AnonymousHideVersion = iniFile.Section("auth.anonymous").Key("hide_version").MustBool(false)
+	cfg.AnonymousAllowedDashboards = util.SplitString(iniFile.Section("auth.anonymous").Key("allowed_dashboards").String())
+	cfg.AnonymousAllowedFolders = util.SplitString(iniFile.Section("auth.anonymous").Key("allowed_folders").String())
+	cfg.AnonymousAllowedPaths = util.SplitString(iniFile.Section("auth.anonymous").Key("allowed_paths").String())
 
 	// basic auth
 	authBasic := iniFile.Section("auth.basic")
 	BasicAuthEnabled = authBasic.Key("enabled").MustBool(true)
 	cfg.BasicAuthEnabled = BasicAuthEnabled
 
+	// TOTP two-factor auth
+	authTOTP := iniFile.Section("auth.totp")
+	cfg.TOTPEnabled = authTOTP.Key("enabled").MustBool(true)
+	cfg.TOTPIssuer = valueAsString(authTOTP, "issuer", "Grafana")
+	cfg.TOTPEnforcedRoles = util.SplitString(valueAsString(authTOTP, "enforced_roles", ""))
+	cfg.TOTPEnforcedOrgIDs = nil
+	for _, orgID := range util.SplitString(valueAsString(authTOTP, "enforced_org_ids", "")) {
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID %q in enforced_org_ids of [auth.totp]", orgID)
+		}
+		cfg.TOTPEnforcedOrgIDs = append(cfg.TOTPEnforcedOrgIDs, id)
+	}
+
 	// JWT auth
 	authJWT := iniFile.Section("auth.jwt")
 	cfg.JWTAuthEnabled = authJWT.Key("enabled").MustBool(false)
@@ -1308,6 +1524,39 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.JWTAuthCacheTTL = authJWT.Key("cache_ttl").MustDuration(time.Minute * 60)
 	cfg.JWTAuthKeyFile = valueAsString(authJWT, "key_file", "")
 	cfg.JWTAuthJWKSetFile = valueAsString(authJWT, "jwk_set_file", "")
+	cfg.JWTAuthJWKSetMinRefreshInterval = authJWT.Key("jwk_set_min_refresh_interval").MustDuration(time.Minute)
+	cfg.JWTAuthAutoSignUp = authJWT.Key("auto_sign_up").MustBool(false)
+	cfg.JWTAuthNameClaim = valueAsString(authJWT, "name_claim", "")
+	cfg.JWTAuthClaimMapping = valueAsString(authJWT, "claim_mapping", "")
+
+	// client certificate auth
+	clientCert := iniFile.Section("auth.client_cert")
+	cfg.ClientCertAuthEnabled = clientCert.Key("enabled").MustBool(false)
+	cfg.ClientCertAuthCAFile = valueAsString(clientCert, "ca_file", "")
+	cfg.ClientCertAuthRequired = clientCert.Key("require_certificate").MustBool(false)
+	cfg.ClientCertAuthLoginAttribute = valueAsString(clientCert, "login_attribute", "common_name")
+	cfg.ClientCertAuthAutoSignUp = clientCert.Key("auto_sign_up").MustBool(false)
+	cfg.ClientCertAuthHeaderName = valueAsString(clientCert, "header_name", "")
+	cfg.ClientCertAuthAllowedProxies = util.SplitString(valueAsString(clientCert, "allowed_proxies", ""))
+
+	// workload identity federation
+	workloadIdentity := iniFile.Section("auth.workload_identity")
+	cfg.WorkloadIdentityEnabled = workloadIdentity.Key("enabled").MustBool(false)
+	cfg.WorkloadIdentityAllowedIssuers = util.SplitString(valueAsString(workloadIdentity, "allowed_issuers", ""))
+	cfg.WorkloadIdentityTokenTTL = workloadIdentity.Key("token_ttl").MustDuration(time.Hour)
+	if cfg.WorkloadIdentityTokenTTL < time.Second {
+		// tokens without a time to live would never expire
+		cfg.WorkloadIdentityTokenTTL = time.Hour
+	}
+	cfg.WorkloadIdentityCacheTTL = workloadIdentity.Key("cache_ttl").MustDuration(time.Minute * 60)
+
+	// authentication providers of the app plugins
+	pluginAuth := iniFile.Section("auth.plugins")
+	cfg.PluginAuthEnabled = pluginAuth.Key("enabled").MustBool(false)
+	cfg.PluginAuthAllowedProviders = util.SplitString(valueAsString(pluginAuth, "allowed_providers", ""))
+	cfg.PluginAuthAutoSignUp = pluginAuth.Key("auto_sign_up").MustBool(true)
+	cfg.PluginAuthTokenHeaderName = valueAsString(pluginAuth, "token_header_name", "")
+	cfg.PluginAuthTokenProvider = valueAsString(pluginAuth, "token_provider", "")
 
 	authProxy := iniFile.Section("auth.proxy")
 	AuthProxyEnabled = authProxy.Key("enabled").MustBool(false)
@@ -1570,5 +1819,6 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return err
 	}
 	cfg.LiveAllowedOrigins = originPatterns
+	cfg.LiveExclusiveDashboardEditing = section.Key("exclusive_dashboard_editing").MustBool(false)
 	return nil
 }
@@ -0,0 +1,86 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+const (
+	AnnotationColdStorageLocal = "local"
+	AnnotationColdStorageS3    = "s3"
+)
+
+// AnnotationColdStorageSettings configures the tiering of the old annotations and alert history, which are moved out
+// of the database to compressed Parquet files and still returned by the historical queries.
+type AnnotationColdStorageSettings struct {
+	Enabled bool
+	// AnnotationsOlderThan and AlertHistoryOlderThan are the ages, from their end, after which the annotations and
+	// the alert annotations are moved. Zero keeps them in the database.
+	AnnotationsOlderThan  time.Duration
+	AlertHistoryOlderThan time.Duration
+	// BatchSize is the number of annotations read from the database at once, and the maximum number of rows of a file
+	BatchSize int
+	Storage   string
+	// Path is the directory the files are written to with the local storage
+	Path string
+
+	S3 AnnotationColdStorageS3Settings
+}
+
+type AnnotationColdStorageS3Settings struct {
+	Bucket          string
+	Region          string
+	Path            string
+	Endpoint        string
+	PathStyleAccess bool
+	AccessKey       string
+	SecretKey       string
+}
+
+func (cfg *Cfg) readAnnotationColdStorageSettings() error {
+	sec := cfg.Raw.Section("annotations.cold_storage")
+	cfg.AnnotationColdStorage.Enabled = sec.Key("enabled").MustBool(false)
+
+	annotationsOlderThan, err := gtime.ParseDuration(valueAsString(sec, "annotations_older_than", "90d"))
+	if err != nil {
+		return fmt.Errorf("invalid annotation cold storage annotations_older_than: %w", err)
+	}
+	alertHistoryOlderThan, err := gtime.ParseDuration(valueAsString(sec, "alert_history_older_than", "90d"))
+	if err != nil {
+		return fmt.Errorf("invalid annotation cold storage alert_history_older_than: %w", err)
+	}
+	if annotationsOlderThan < 0 || alertHistoryOlderThan < 0 {
+		return fmt.Errorf("annotation cold storage ages must not be negative")
+	}
+	cfg.AnnotationColdStorage.AnnotationsOlderThan = annotationsOlderThan
+	cfg.AnnotationColdStorage.AlertHistoryOlderThan = alertHistoryOlderThan
+
+	cfg.AnnotationColdStorage.BatchSize = sec.Key("batch_size").MustInt(10000)
+	if cfg.AnnotationColdStorage.BatchSize <= 0 {
+		return fmt.Errorf("annotation cold storage batch_size must be positive, got %d", cfg.AnnotationColdStorage.BatchSize)
+	}
+
+	cfg.AnnotationColdStorage.Storage = valueAsString(sec, "storage", AnnotationColdStorageLocal)
+	if cfg.AnnotationColdStorage.Storage != AnnotationColdStorageLocal && cfg.AnnotationColdStorage.Storage != AnnotationColdStorageS3 {
+		return fmt.Errorf("invalid annotation cold storage %q, expected %q or %q", cfg.AnnotationColdStorage.Storage, AnnotationColdStorageLocal, AnnotationColdStorageS3)
+	}
+	cfg.AnnotationColdStorage.Path = makeAbsolute(valueAsString(sec, "path", "annotations-archive"), cfg.DataPath)
+
+	s3 := cfg.Raw.Section("annotations.cold_storage.s3")
+	cfg.AnnotationColdStorage.S3 = AnnotationColdStorageS3Settings{
+		Bucket:          s3.Key("bucket").String(),
+		Region:          s3.Key("region").String(),
+		Path:            s3.Key("path").String(),
+		Endpoint:        s3.Key("endpoint").String(),
+		PathStyleAccess: s3.Key("path_style_access").MustBool(false),
+		AccessKey:       s3.Key("access_key").String(),
+		SecretKey:       s3.Key("secret_key").String(),
+	}
+	if cfg.AnnotationColdStorage.Storage == AnnotationColdStorageS3 && cfg.AnnotationColdStorage.S3.Bucket == "" {
+		return fmt.Errorf("annotation cold storage to s3 requires a bucket")
+	}
+
+	return nil
+}
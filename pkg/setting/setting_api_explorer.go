@@ -0,0 +1,47 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// APIExplorerSettings configures the API explorer, which lets the organization admins send requests described by
+// the OpenAPI specifications of the HTTP API to their own instance, with their own permissions.
+type APIExplorerSettings struct {
+	Enabled bool
+	// SpecPaths are the OpenAPI specifications explored along with the specification of the alerting API, relative
+	// to the home path
+	SpecPaths []string
+	// Timeout is the maximum duration of a request
+	Timeout time.Duration
+	// MaxBodySize is the size above which the request bodies are rejected and the response bodies truncated
+	MaxBodySize int64
+}
+
+func (cfg *Cfg) readAPIExplorerSettings() error {
+	sec := cfg.Raw.Section("api_explorer")
+	cfg.APIExplorer.Enabled = sec.Key("enabled").MustBool(false)
+
+	cfg.APIExplorer.SpecPaths = nil
+	for _, path := range util.SplitString(sec.Key("spec_paths").String()) {
+		cfg.APIExplorer.SpecPaths = append(cfg.APIExplorer.SpecPaths, makeAbsolute(path, HomePath))
+	}
+
+	timeout, err := gtime.ParseDuration(valueAsString(sec, "timeout", "30s"))
+	if err != nil {
+		return fmt.Errorf("invalid api explorer timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("api explorer timeout must be positive, got %s", timeout)
+	}
+	cfg.APIExplorer.Timeout = timeout
+
+	cfg.APIExplorer.MaxBodySize = sec.Key("max_body_size").MustInt64(1 << 20)
+	if cfg.APIExplorer.MaxBodySize <= 0 {
+		return fmt.Errorf("api explorer max_body_size must be positive, got %d", cfg.APIExplorer.MaxBodySize)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package setting
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	APIReplayModeRecord = "record"
+	APIReplayModeReplay = "replay"
+)
+
+// APIReplaySettings configures the recording of the API responses and their replay, which lets the frontend and
+// plugins be developed against realistic data without the database the responses were recorded from.
+type APIReplaySettings struct {
+	// Mode is empty when disabled, record or replay
+	Mode string
+	// Path is the directory the recordings are saved to and replayed from
+	Path string
+	// RedactKeys are the keys, matched case insensitively as substrings, of the JSON fields, query parameters and
+	// headers whose values are stripped from the recordings
+	RedactKeys []string
+}
+
+func (cfg *Cfg) readAPIReplaySettings() error {
+	sec := cfg.Raw.Section("api_replay")
+	cfg.APIReplay.Mode = sec.Key("mode").String()
+	if cfg.APIReplay.Mode != "" && cfg.APIReplay.Mode != APIReplayModeRecord && cfg.APIReplay.Mode != APIReplayModeReplay {
+		return fmt.Errorf("invalid api replay mode %q, expected %q or %q", cfg.APIReplay.Mode, APIReplayModeRecord, APIReplayModeReplay)
+	}
+	cfg.APIReplay.Path = makeAbsolute(valueAsString(sec, "path", "api-recordings"), cfg.DataPath)
+	cfg.APIReplay.RedactKeys = util.SplitString(valueAsString(sec, "redact_keys", "password,secret,token,apikey,api_key,private_key,privatekey,cookie,authorization,credentials"))
+	return nil
+}
@@ -0,0 +1,123 @@
+package setting
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+const (
+	AuthAuditSinkFile    = "file"
+	AuthAuditSinkLoki    = "loki"
+	AuthAuditSinkSyslog  = "syslog"
+	AuthAuditSinkWebhook = "webhook"
+
+	// AuthAuditFormatJSON writes the events as JSON, AuthAuditFormatCEF in the ArcSight Common Event Format read
+	// by the SIEMs
+	AuthAuditFormatJSON = "json"
+	AuthAuditFormatCEF  = "cef"
+)
+
+// AuthAuditSettings configures the sinks the authentication events are sent to, in addition to the database the
+// admin API lists them from.
+type AuthAuditSettings struct {
+	// Sinks are the names of the enabled sinks: file, loki, syslog or webhook
+	Sinks []string
+	// FilePath is the file the events are appended to as JSON lines, it defaults to auth-audit.log in the logs path
+	FilePath string
+	// LokiURL is the push URL of Loki, such as http://localhost:3100/loki/api/v1/push
+	LokiURL string
+	// SyslogNetwork and SyslogAddress default to the local syslog daemon
+	SyslogNetwork string
+	SyslogAddress string
+	SyslogTag     string
+	WebhookURL    string
+	// Timeout applies to the requests sent to Loki and to the webhook
+	Timeout time.Duration
+	// QueueSize is the number of events waiting to be sent above which further events are dropped by the sinks
+	QueueSize int
+	// Format is the format of the events written by the file and syslog sinks, json or cef
+	Format string
+	// SinkEventTypes are the types of the events sent to the sinks: auth, role or team
+	SinkEventTypes []string
+	// Retention is how long the events of all the types are kept in the database, they are kept forever when zero
+	Retention time.Duration
+}
+
+func (cfg *Cfg) readAuthAuditSettings() error {
+	sec := cfg.Raw.Section("auth.audit")
+
+	cfg.AuthAudit.Sinks = make([]string, 0)
+	for _, sink := range strings.Split(valueAsString(sec, "sinks", ""), ",") {
+		sink = strings.TrimSpace(sink)
+		switch sink {
+		case "":
+			continue
+		case AuthAuditSinkFile, AuthAuditSinkLoki, AuthAuditSinkSyslog, AuthAuditSinkWebhook:
+			cfg.AuthAudit.Sinks = append(cfg.AuthAudit.Sinks, sink)
+		default:
+			return fmt.Errorf("unknown auth audit sink %q", sink)
+		}
+	}
+
+	cfg.AuthAudit.FilePath = valueAsString(sec, "file_path", "")
+	if cfg.AuthAudit.FilePath == "" {
+		cfg.AuthAudit.FilePath = filepath.Join(cfg.LogsPath, "auth-audit.log")
+	}
+	cfg.AuthAudit.LokiURL = valueAsString(sec, "loki_url", "")
+	cfg.AuthAudit.SyslogNetwork = valueAsString(sec, "syslog_network", "")
+	cfg.AuthAudit.SyslogAddress = valueAsString(sec, "syslog_address", "")
+	cfg.AuthAudit.SyslogTag = valueAsString(sec, "syslog_tag", "grafana-auth-audit")
+	cfg.AuthAudit.WebhookURL = valueAsString(sec, "webhook_url", "")
+
+	for _, sink := range cfg.AuthAudit.Sinks {
+		if sink == AuthAuditSinkLoki && cfg.AuthAudit.LokiURL == "" {
+			return fmt.Errorf("the loki auth audit sink requires loki_url")
+		}
+		if sink == AuthAuditSinkWebhook && cfg.AuthAudit.WebhookURL == "" {
+			return fmt.Errorf("the webhook auth audit sink requires webhook_url")
+		}
+	}
+
+	timeout, err := gtime.ParseDuration(valueAsString(sec, "timeout", "10s"))
+	if err != nil {
+		return fmt.Errorf("invalid auth audit timeout: %w", err)
+	}
+	cfg.AuthAudit.Timeout = timeout
+
+	cfg.AuthAudit.QueueSize = sec.Key("queue_size").MustInt(1000)
+	if cfg.AuthAudit.QueueSize < 1 {
+		return fmt.Errorf("auth audit queue size must be positive, got %d", cfg.AuthAudit.QueueSize)
+	}
+
+	cfg.AuthAudit.Format = valueAsString(sec, "format", AuthAuditFormatJSON)
+	if cfg.AuthAudit.Format != AuthAuditFormatJSON && cfg.AuthAudit.Format != AuthAuditFormatCEF {
+		return fmt.Errorf("unknown auth audit format %q", cfg.AuthAudit.Format)
+	}
+
+	cfg.AuthAudit.SinkEventTypes = make([]string, 0)
+	for _, eventType := range strings.Split(valueAsString(sec, "sink_event_types", "auth"), ",") {
+		eventType = strings.TrimSpace(eventType)
+		switch eventType {
+		case "":
+			continue
+		case "auth", "role", "team":
+			cfg.AuthAudit.SinkEventTypes = append(cfg.AuthAudit.SinkEventTypes, eventType)
+		default:
+			return fmt.Errorf("unknown auth audit event type %q", eventType)
+		}
+	}
+
+	retention, err := gtime.ParseDuration(valueAsString(sec, "retention", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid auth audit retention: %w", err)
+	}
+	if retention < 0 {
+		return fmt.Errorf("auth audit retention must not be negative, got %s", retention)
+	}
+	cfg.AuthAudit.Retention = retention
+	return nil
+}
@@ -0,0 +1,63 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// CleanupSettings are the retention of the data deleted by the cleanup service, and the size of the batches it
+// deletes them in, so that no statement holds its locks for long. The dashboard versions and the annotations keep
+// their retention settings of the dashboards and annotations sections.
+type CleanupSettings struct {
+	// BatchSize is the maximum number of rows deleted by a statement
+	BatchSize int64
+	// ExpiredSnapshotsRetention is how long the snapshots are kept once expired
+	ExpiredSnapshotsRetention time.Duration
+	// ExpiredUserInvitesRetention is how long the expired invites are kept, they are kept forever when zero
+	ExpiredUserInvitesRetention time.Duration
+	// LoginAttemptsRetention is how long the login attempts are kept, at least as long as they can lock a user out
+	LoginAttemptsRetention time.Duration
+	// ShortURLsRetention is how long the short URLs which have never been used are kept
+	ShortURLsRetention time.Duration
+	// DeletedUsersRetention is how long the users removed from their last organization can be restored before they
+	// are deleted, they are deleted immediately when zero
+	DeletedUsersRetention time.Duration
+	// DeletedDashboardsRetention is how long the deleted dashboards can be restored from the trash of their
+	// organization, they are deleted immediately when zero
+	DeletedDashboardsRetention time.Duration
+}
+
+func (cfg *Cfg) readCleanupSettings() error {
+	sec := cfg.Raw.Section("cleanup")
+
+	cfg.Cleanup.BatchSize = sec.Key("batch_size").MustInt64(1000)
+	if cfg.Cleanup.BatchSize < 1 {
+		return fmt.Errorf("invalid cleanup batch_size %d, it must be positive", cfg.Cleanup.BatchSize)
+	}
+
+	durations := []struct {
+		key          string
+		defaultValue string
+		value        *time.Duration
+	}{
+		{"expired_snapshots_retention", "0", &cfg.Cleanup.ExpiredSnapshotsRetention},
+		{"expired_user_invites_retention", "0", &cfg.Cleanup.ExpiredUserInvitesRetention},
+		{"login_attempts_retention", "0", &cfg.Cleanup.LoginAttemptsRetention},
+		{"short_urls_retention", "7d", &cfg.Cleanup.ShortURLsRetention},
+		{"deleted_users_retention", "30d", &cfg.Cleanup.DeletedUsersRetention},
+		{"deleted_dashboards_retention", "30d", &cfg.Cleanup.DeletedDashboardsRetention},
+	}
+	for _, d := range durations {
+		value, err := gtime.ParseDuration(valueAsString(sec, d.key, d.defaultValue))
+		if err != nil {
+			return fmt.Errorf("invalid cleanup %s: %w", d.key, err)
+		}
+		if value < 0 {
+			return fmt.Errorf("invalid cleanup %s: it cannot be negative", d.key)
+		}
+		*d.value = value
+	}
+	return nil
+}
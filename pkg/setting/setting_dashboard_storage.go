@@ -0,0 +1,77 @@
+package setting
+
+import (
+	"fmt"
+)
+
+const (
+	DashboardStorageDatabase = "database"
+	DashboardStorageJSONB    = "jsonb"
+
+	DashboardBlobStorageLocal = "local"
+	DashboardBlobStorageS3    = "s3"
+)
+
+// DashboardStorageSettings configures how the JSON models of the dashboards are persisted.
+type DashboardStorageSettings struct {
+	// Type is how the JSON models are stored in the dashboard table, as text or as JSONB on PostgreSQL
+	Type string
+	// BlobThreshold is the size, in bytes, above which the JSON models are offloaded to the blob storage.
+	// Offloading is disabled when it is 0.
+	BlobThreshold int64
+	BlobStorage   string
+	// BlobPath is the directory the JSON models are written to with the local blob storage
+	BlobPath string
+
+	S3 DashboardBlobS3Settings
+}
+
+type DashboardBlobS3Settings struct {
+	Bucket          string
+	Region          string
+	Path            string
+	Endpoint        string
+	PathStyleAccess bool
+	AccessKey       string
+	SecretKey       string
+}
+
+// BlobStorageEnabled returns true when large dashboards are offloaded to the blob storage
+func (s DashboardStorageSettings) BlobStorageEnabled() bool {
+	return s.BlobThreshold > 0
+}
+
+func (cfg *Cfg) readDashboardStorageSettings() error {
+	sec := cfg.Raw.Section("dashboard_storage")
+	cfg.DashboardStorage.Type = valueAsString(sec, "type", DashboardStorageDatabase)
+	if cfg.DashboardStorage.Type != DashboardStorageDatabase && cfg.DashboardStorage.Type != DashboardStorageJSONB {
+		return fmt.Errorf("invalid dashboard storage type %q, expected %q or %q", cfg.DashboardStorage.Type, DashboardStorageDatabase, DashboardStorageJSONB)
+	}
+
+	cfg.DashboardStorage.BlobThreshold = sec.Key("blob_threshold").MustInt64(0)
+	if cfg.DashboardStorage.BlobThreshold < 0 {
+		return fmt.Errorf("dashboard blob threshold must not be negative, got %d", cfg.DashboardStorage.BlobThreshold)
+	}
+
+	cfg.DashboardStorage.BlobStorage = valueAsString(sec, "blob_storage", DashboardBlobStorageLocal)
+	if cfg.DashboardStorage.BlobStorage != DashboardBlobStorageLocal && cfg.DashboardStorage.BlobStorage != DashboardBlobStorageS3 {
+		return fmt.Errorf("invalid dashboard blob storage %q, expected %q or %q", cfg.DashboardStorage.BlobStorage, DashboardBlobStorageLocal, DashboardBlobStorageS3)
+	}
+	cfg.DashboardStorage.BlobPath = makeAbsolute(valueAsString(sec, "blob_path", "dashboards"), cfg.DataPath)
+
+	s3 := cfg.Raw.Section("dashboard_storage.s3")
+	cfg.DashboardStorage.S3 = DashboardBlobS3Settings{
+		Bucket:          s3.Key("bucket").String(),
+		Region:          s3.Key("region").String(),
+		Path:            s3.Key("path").String(),
+		Endpoint:        s3.Key("endpoint").String(),
+		PathStyleAccess: s3.Key("path_style_access").MustBool(false),
+		AccessKey:       s3.Key("access_key").String(),
+		SecretKey:       s3.Key("secret_key").String(),
+	}
+	if cfg.DashboardStorage.BlobStorageEnabled() && cfg.DashboardStorage.BlobStorage == DashboardBlobStorageS3 && cfg.DashboardStorage.S3.Bucket == "" {
+		return fmt.Errorf("dashboard blob storage in s3 requires a bucket")
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// DashboardThumbnailsSettings configures the thumbnails of the dashboards, rendered with the image renderer when
+// the dashboards are saved and on schedule.
+type DashboardThumbnailsSettings struct {
+	Enabled bool
+	// Interval is how often the missing and outdated thumbnails are rendered
+	Interval time.Duration
+	// MaxAge is the age above which the thumbnails are rendered again, so that they show recent data
+	MaxAge time.Duration
+	Width  int
+	Height int
+	Theme  string
+}
+
+func (cfg *Cfg) readDashboardThumbnailsSettings() error {
+	sec := cfg.Raw.Section("dashboard_thumbnails")
+	cfg.DashboardThumbnails.Enabled = sec.Key("enabled").MustBool(false)
+
+	interval, err := gtime.ParseDuration(valueAsString(sec, "interval", "1h"))
+	if err != nil {
+		return fmt.Errorf("invalid dashboard thumbnails interval: %w", err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("dashboard thumbnails interval must be positive, got %s", interval)
+	}
+	cfg.DashboardThumbnails.Interval = interval
+
+	maxAge, err := gtime.ParseDuration(valueAsString(sec, "max_age", "24h"))
+	if err != nil {
+		return fmt.Errorf("invalid dashboard thumbnails max_age: %w", err)
+	}
+	cfg.DashboardThumbnails.MaxAge = maxAge
+
+	cfg.DashboardThumbnails.Width = sec.Key("width").MustInt(320)
+	cfg.DashboardThumbnails.Height = sec.Key("height").MustInt(240)
+	if cfg.DashboardThumbnails.Width <= 0 || cfg.DashboardThumbnails.Height <= 0 {
+		return fmt.Errorf("dashboard thumbnails width and height must be positive")
+	}
+
+	cfg.DashboardThumbnails.Theme = valueAsString(sec, "theme", "dark")
+	if cfg.DashboardThumbnails.Theme != "dark" && cfg.DashboardThumbnails.Theme != "light" {
+		return fmt.Errorf("invalid dashboard thumbnails theme %q, expected \"dark\" or \"light\"", cfg.DashboardThumbnails.Theme)
+	}
+	return nil
+}
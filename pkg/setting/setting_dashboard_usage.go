@@ -0,0 +1,42 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// DashboardUsageSettings configures the usage insights of the dashboards, the daily counts of their views, queries
+// and query errors.
+type DashboardUsageSettings struct {
+	Enabled bool
+	// FlushInterval is how often the counts of each Grafana instance are added to the database
+	FlushInterval time.Duration
+	// Retention is how long the daily counts are kept, the insights and the search sort on the counts kept
+	Retention time.Duration
+}
+
+func (cfg *Cfg) readDashboardUsageSettings() error {
+	sec := cfg.Raw.Section("dashboard_usage")
+	cfg.DashboardUsage.Enabled = sec.Key("enabled").MustBool(false)
+
+	flushInterval, err := gtime.ParseDuration(valueAsString(sec, "flush_interval", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid dashboard usage flush_interval: %w", err)
+	}
+	if flushInterval <= 0 {
+		return fmt.Errorf("dashboard usage flush_interval must be positive, got %s", flushInterval)
+	}
+	cfg.DashboardUsage.FlushInterval = flushInterval
+
+	retention, err := gtime.ParseDuration(valueAsString(sec, "retention", "90d"))
+	if err != nil {
+		return fmt.Errorf("invalid dashboard usage retention: %w", err)
+	}
+	if retention < 24*time.Hour {
+		return fmt.Errorf("dashboard usage retention must be at least a day, got %s", retention)
+	}
+	cfg.DashboardUsage.Retention = retention
+	return nil
+}
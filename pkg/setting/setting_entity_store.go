@@ -0,0 +1,23 @@
+package setting
+
+import "fmt"
+
+// EntityStoreSettings select the backend keeping the documents of the entity store, which is enabled by the
+// entityStore feature toggle
+type EntityStoreSettings struct {
+	// Backend is the name of the backend, database or file, or of a backend registered by an extension
+	Backend string
+	// Path is the directory of the file backend
+	Path string
+}
+
+func (cfg *Cfg) readEntityStoreSettings() error {
+	sec := cfg.Raw.Section("entity_store")
+
+	cfg.EntityStore.Backend = valueAsString(sec, "backend", "database")
+	if cfg.EntityStore.Backend == "" {
+		return fmt.Errorf("invalid entity_store backend, it cannot be empty")
+	}
+	cfg.EntityStore.Path = makeAbsolute(valueAsString(sec, "path", "entities"), cfg.DataPath)
+	return nil
+}
@@ -0,0 +1,138 @@
+package setting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	KioskRecordingLocal = "local"
+	KioskRecordingS3    = "s3"
+)
+
+// KioskRecordingSettings configures the recording of the dashboards displayed in kiosk mode, such as on the wall
+// screens of an operations room. Their frames are rendered with the image renderer on schedule, and the ones around
+// the alert firings of their organization are kept for the incident reviews.
+type KioskRecordingSettings struct {
+	Enabled    bool
+	Dashboards []KioskRecordingDashboard
+	// Interval is how often the frames of the dashboards are rendered
+	Interval time.Duration
+	// Before and After are the durations recorded before and after an alert firing
+	Before time.Duration
+	After  time.Duration
+	Width  int
+	Height int
+	Theme  string
+	// Storage is where the frames are saved, Path is the directory of the local storage
+	Storage string
+	Path    string
+
+	S3 KioskRecordingS3Settings
+}
+
+// KioskRecordingDashboard is a dashboard recorded in kiosk mode
+type KioskRecordingDashboard struct {
+	OrgID int64
+	UID   string
+}
+
+type KioskRecordingS3Settings struct {
+	Bucket          string
+	Region          string
+	Path            string
+	Endpoint        string
+	PathStyleAccess bool
+	AccessKey       string
+	SecretKey       string
+}
+
+func (cfg *Cfg) readKioskRecordingSettings() error {
+	sec := cfg.Raw.Section("kiosk_recording")
+	cfg.KioskRecording.Enabled = sec.Key("enabled").MustBool(false)
+
+	cfg.KioskRecording.Dashboards = nil
+	for _, ref := range util.SplitString(sec.Key("dashboards").String()) {
+		dashboard, err := parseKioskRecordingDashboard(ref)
+		if err != nil {
+			return err
+		}
+		cfg.KioskRecording.Dashboards = append(cfg.KioskRecording.Dashboards, dashboard)
+	}
+
+	interval, err := gtime.ParseDuration(valueAsString(sec, "interval", "30s"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk recording interval: %w", err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("kiosk recording interval must be positive, got %s", interval)
+	}
+	cfg.KioskRecording.Interval = interval
+
+	before, err := gtime.ParseDuration(valueAsString(sec, "before", "5m"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk recording before: %w", err)
+	}
+	after, err := gtime.ParseDuration(valueAsString(sec, "after", "10m"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk recording after: %w", err)
+	}
+	if before < 0 || after < 0 {
+		return fmt.Errorf("kiosk recording before and after must not be negative")
+	}
+	cfg.KioskRecording.Before = before
+	cfg.KioskRecording.After = after
+
+	cfg.KioskRecording.Width = sec.Key("width").MustInt(1920)
+	cfg.KioskRecording.Height = sec.Key("height").MustInt(1080)
+	if cfg.KioskRecording.Width <= 0 || cfg.KioskRecording.Height <= 0 {
+		return fmt.Errorf("kiosk recording width and height must be positive")
+	}
+
+	cfg.KioskRecording.Theme = valueAsString(sec, "theme", "dark")
+	if cfg.KioskRecording.Theme != "dark" && cfg.KioskRecording.Theme != "light" {
+		return fmt.Errorf("invalid kiosk recording theme %q, expected \"dark\" or \"light\"", cfg.KioskRecording.Theme)
+	}
+
+	cfg.KioskRecording.Storage = valueAsString(sec, "storage", KioskRecordingLocal)
+	if cfg.KioskRecording.Storage != KioskRecordingLocal && cfg.KioskRecording.Storage != KioskRecordingS3 {
+		return fmt.Errorf("invalid kiosk recording storage %q, expected %q or %q", cfg.KioskRecording.Storage, KioskRecordingLocal, KioskRecordingS3)
+	}
+	cfg.KioskRecording.Path = makeAbsolute(valueAsString(sec, "path", "kiosk-recordings"), cfg.DataPath)
+
+	s3 := cfg.Raw.Section("kiosk_recording.s3")
+	cfg.KioskRecording.S3 = KioskRecordingS3Settings{
+		Bucket:          s3.Key("bucket").String(),
+		Region:          s3.Key("region").String(),
+		Path:            s3.Key("path").String(),
+		Endpoint:        s3.Key("endpoint").String(),
+		PathStyleAccess: s3.Key("path_style_access").MustBool(false),
+		AccessKey:       s3.Key("access_key").String(),
+		SecretKey:       s3.Key("secret_key").String(),
+	}
+	if cfg.KioskRecording.Storage == KioskRecordingS3 && cfg.KioskRecording.S3.Bucket == "" {
+		return fmt.Errorf("kiosk recording to s3 requires a bucket")
+	}
+
+	return nil
+}
+
+// parseKioskRecordingDashboard parses a dashboard reference such as 1/cIBgcSjkk, the ID of the organization and the
+// UID of the dashboard
+func parseKioskRecordingDashboard(ref string) (KioskRecordingDashboard, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return KioskRecordingDashboard{}, fmt.Errorf("invalid kiosk recording dashboard %q, expected <org id>/<dashboard uid>", ref)
+	}
+	orgID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || orgID <= 0 {
+		return KioskRecordingDashboard{}, fmt.Errorf("invalid kiosk recording dashboard %q, expected <org id>/<dashboard uid>", ref)
+	}
+	return KioskRecordingDashboard{OrgID: orgID, UID: parts[1]}, nil
+}
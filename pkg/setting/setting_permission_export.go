@@ -0,0 +1,91 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+const (
+	PermissionExportFormatJSON = "json"
+	PermissionExportFormatCSV  = "csv"
+	// PermissionExportFormatRego and PermissionExportFormatCedar export the effective permissions of the users as
+	// policies, which can be validated and imported back
+	PermissionExportFormatRego  = "rego"
+	PermissionExportFormatCedar = "cedar"
+
+	PermissionExportStorageLocal = "local"
+	PermissionExportStorageS3    = "s3"
+)
+
+// PermissionExportSettings configures the periodic export of the permission model of all the organizations,
+// kept as audit evidence.
+type PermissionExportSettings struct {
+	Enabled  bool
+	Interval time.Duration
+	Format   string
+	Storage  string
+	// Path is the directory the exports are written to with the local storage
+	Path string
+	// SigningKey is the key the exports are signed with, it defaults to the secret key
+	SigningKey string
+
+	S3 PermissionExportS3Settings
+}
+
+type PermissionExportS3Settings struct {
+	Bucket          string
+	Region          string
+	Path            string
+	Endpoint        string
+	PathStyleAccess bool
+	AccessKey       string
+	SecretKey       string
+}
+
+func (cfg *Cfg) readPermissionExportSettings() error {
+	sec := cfg.Raw.Section("permission_export")
+	cfg.PermissionExport.Enabled = sec.Key("enabled").MustBool(false)
+
+	interval, err := gtime.ParseDuration(valueAsString(sec, "interval", "24h"))
+	if err != nil {
+		return fmt.Errorf("invalid permission export interval: %w", err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("permission export interval must be positive, got %s", interval)
+	}
+	cfg.PermissionExport.Interval = interval
+
+	cfg.PermissionExport.Format = valueAsString(sec, "format", PermissionExportFormatJSON)
+	switch cfg.PermissionExport.Format {
+	case PermissionExportFormatJSON, PermissionExportFormatCSV, PermissionExportFormatRego, PermissionExportFormatCedar:
+	default:
+		return fmt.Errorf("invalid permission export format %q, expected %q, %q, %q or %q", cfg.PermissionExport.Format,
+			PermissionExportFormatJSON, PermissionExportFormatCSV, PermissionExportFormatRego, PermissionExportFormatCedar)
+	}
+
+	cfg.PermissionExport.Storage = valueAsString(sec, "storage", PermissionExportStorageLocal)
+	if cfg.PermissionExport.Storage != PermissionExportStorageLocal && cfg.PermissionExport.Storage != PermissionExportStorageS3 {
+		return fmt.Errorf("invalid permission export storage %q, expected %q or %q", cfg.PermissionExport.Storage, PermissionExportStorageLocal, PermissionExportStorageS3)
+	}
+
+	cfg.PermissionExport.Path = makeAbsolute(valueAsString(sec, "path", "permission-exports"), cfg.DataPath)
+	cfg.PermissionExport.SigningKey = valueAsString(sec, "signing_key", cfg.SecretKey)
+
+	s3 := cfg.Raw.Section("permission_export.s3")
+	cfg.PermissionExport.S3 = PermissionExportS3Settings{
+		Bucket:          s3.Key("bucket").String(),
+		Region:          s3.Key("region").String(),
+		Path:            s3.Key("path").String(),
+		Endpoint:        s3.Key("endpoint").String(),
+		PathStyleAccess: s3.Key("path_style_access").MustBool(false),
+		AccessKey:       s3.Key("access_key").String(),
+		SecretKey:       s3.Key("secret_key").String(),
+	}
+	if cfg.PermissionExport.Storage == PermissionExportStorageS3 && cfg.PermissionExport.S3.Bucket == "" {
+		return fmt.Errorf("permission export to s3 requires a bucket")
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// PermissionWebhooksSettings configures the webhooks sent when the organization memberships, the team memberships or
+// the role assignments change.
+type PermissionWebhooksSettings struct {
+	Enabled bool
+	URLs    []string
+	// SigningKey is the key the payloads are signed with, it defaults to the secret key
+	SigningKey string
+	Timeout    time.Duration
+	// MaxRetries is the number of times a failed delivery is retried, waiting RetryBackoff and then twice as
+	// long after each attempt
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// QueueSize is the number of changes waiting to be delivered above which further changes are dropped
+	QueueSize int
+}
+
+func (cfg *Cfg) readPermissionWebhooksSettings() error {
+	sec := cfg.Raw.Section("permission_webhooks")
+	cfg.PermissionWebhooks.Enabled = sec.Key("enabled").MustBool(false)
+
+	cfg.PermissionWebhooks.URLs = make([]string, 0)
+	for _, u := range strings.Split(valueAsString(sec, "urls", ""), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			cfg.PermissionWebhooks.URLs = append(cfg.PermissionWebhooks.URLs, u)
+		}
+	}
+	cfg.PermissionWebhooks.SigningKey = valueAsString(sec, "signing_key", cfg.SecretKey)
+
+	timeout, err := gtime.ParseDuration(valueAsString(sec, "timeout", "10s"))
+	if err != nil {
+		return fmt.Errorf("invalid permission webhooks timeout: %w", err)
+	}
+	cfg.PermissionWebhooks.Timeout = timeout
+
+	backoff, err := gtime.ParseDuration(valueAsString(sec, "retry_backoff", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid permission webhooks retry backoff: %w", err)
+	}
+	cfg.PermissionWebhooks.RetryBackoff = backoff
+
+	cfg.PermissionWebhooks.MaxRetries = sec.Key("max_retries").MustInt(5)
+	if cfg.PermissionWebhooks.MaxRetries < 0 {
+		return fmt.Errorf("permission webhooks max retries must not be negative, got %d", cfg.PermissionWebhooks.MaxRetries)
+	}
+	cfg.PermissionWebhooks.QueueSize = sec.Key("queue_size").MustInt(1000)
+	if cfg.PermissionWebhooks.QueueSize < 1 {
+		return fmt.Errorf("permission webhooks queue size must be positive, got %d", cfg.PermissionWebhooks.QueueSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package setting
+
+// PublicDashboardsSettings configures the public dashboards, published at unauthenticated URLs with signed access
+// tokens.
+type PublicDashboardsSettings struct {
+	Enabled bool
+}
+
+func (cfg *Cfg) readPublicDashboardsSettings() {
+	sec := cfg.Raw.Section("public_dashboards")
+	cfg.PublicDashboards.Enabled = sec.Key("enabled").MustBool(false)
+}
@@ -2,6 +2,8 @@ package setting
 
 import (
 	"reflect"
+	"strings"
+	"time"
 )
 
 type OrgQuota struct {
@@ -59,6 +61,13 @@ type QuotaSettings struct {
 	Org     *OrgQuota
 	User    *UserQuota
 	Global  *GlobalQuota
+	// WarningThreshold is the percentage of an organization quota above which the organization administrators are
+	// warned before the quota is enforced, 0 disables the warnings
+	WarningThreshold int64
+	// WarningInterval is how long the warning of an organization quota is not repeated
+	WarningInterval time.Duration
+	// WarningWebhookURLs are the URLs the warnings are posted to
+	WarningWebhookURLs []string
 }
 
 func (cfg *Cfg) readQuotaSettings() {
@@ -97,5 +106,18 @@ func (cfg *Cfg) readQuotaSettings() {
 		AlertRule:  alertGlobalQuota,
 	}
 
+	Quota.WarningThreshold = quota.Key("warning_threshold").MustInt64(80)
+	if Quota.WarningThreshold < 0 || Quota.WarningThreshold >= 100 {
+		cfg.Logger.Warn("Quota warning threshold must be between 0 and 99, the warnings are disabled", "threshold", Quota.WarningThreshold)
+		Quota.WarningThreshold = 0
+	}
+	Quota.WarningInterval = quota.Key("warning_interval").MustDuration(24 * time.Hour)
+	Quota.WarningWebhookURLs = make([]string, 0)
+	for _, u := range strings.Split(valueAsString(quota, "warning_webhook_urls", ""), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			Quota.WarningWebhookURLs = append(Quota.WarningWebhookURLs, u)
+		}
+	}
+
 	cfg.Quota = Quota
 }
@@ -680,3 +680,52 @@ func TestAlertingEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanupSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		require.NoError(t, cfg.readCleanupSettings())
+		require.Equal(t, int64(1000), cfg.Cleanup.BatchSize)
+		require.Equal(t, 7*24*time.Hour, cfg.Cleanup.ShortURLsRetention)
+		require.Zero(t, cfg.Cleanup.ExpiredUserInvitesRetention)
+		require.Equal(t, 30*24*time.Hour, cfg.Cleanup.DeletedUsersRetention)
+		require.Equal(t, 30*24*time.Hour, cfg.Cleanup.DeletedDashboardsRetention)
+	})
+
+	t.Run("retentions", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("cleanup")
+		require.NoError(t, err)
+		_, err = sec.NewKey("expired_user_invites_retention", "30d")
+		require.NoError(t, err)
+		_, err = sec.NewKey("login_attempts_retention", "2h")
+		require.NoError(t, err)
+		_, err = sec.NewKey("deleted_users_retention", "0")
+		require.NoError(t, err)
+		_, err = sec.NewKey("deleted_dashboards_retention", "7d")
+		require.NoError(t, err)
+		require.NoError(t, cfg.readCleanupSettings())
+		require.Equal(t, 30*24*time.Hour, cfg.Cleanup.ExpiredUserInvitesRetention)
+		require.Equal(t, 2*time.Hour, cfg.Cleanup.LoginAttemptsRetention)
+		require.Zero(t, cfg.Cleanup.DeletedUsersRetention)
+		require.Equal(t, 7*24*time.Hour, cfg.Cleanup.DeletedDashboardsRetention)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("cleanup")
+		require.NoError(t, err)
+		_, err = sec.NewKey("batch_size", "0")
+		require.NoError(t, err)
+		require.Error(t, cfg.readCleanupSettings())
+
+		_, err = sec.NewKey("batch_size", "10")
+		require.NoError(t, err)
+		_, err = sec.NewKey("short_urls_retention", "soon")
+		require.NoError(t, err)
+		require.Error(t, cfg.readCleanupSettings())
+	})
+}
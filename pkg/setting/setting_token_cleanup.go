@@ -0,0 +1,28 @@
+package setting
+
+import "fmt"
+
+// TokenCleanupSettings configures the policy expiring the API keys and the service account tokens that are no
+// longer used. The administrators of the organization of a token are notified before it expires.
+type TokenCleanupSettings struct {
+	Enabled bool
+	// APIKeyUnusedDays is the number of days without use after which the API keys expire, 0 to never expire them
+	APIKeyUnusedDays int
+	// ServiceAccountTokenUnusedDays is the same for the tokens of the service accounts
+	ServiceAccountTokenUnusedDays int
+	// WarningDays is the number of days the administrators are notified before the tokens expire
+	WarningDays int
+}
+
+func (cfg *Cfg) readTokenCleanupSettings() error {
+	sec := cfg.Raw.Section("token_cleanup")
+	cfg.TokenCleanup.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.TokenCleanup.APIKeyUnusedDays = sec.Key("api_key_unused_days").MustInt(90)
+	cfg.TokenCleanup.ServiceAccountTokenUnusedDays = sec.Key("service_account_token_unused_days").MustInt(90)
+	cfg.TokenCleanup.WarningDays = sec.Key("warning_days").MustInt(7)
+
+	if cfg.TokenCleanup.APIKeyUnusedDays < 0 || cfg.TokenCleanup.ServiceAccountTokenUnusedDays < 0 || cfg.TokenCleanup.WarningDays < 0 {
+		return fmt.Errorf("invalid token cleanup settings: the numbers of days cannot be negative")
+	}
+	return nil
+}
@@ -48,6 +48,7 @@ const (
 	schedulerDefaultMaxAttempts             = 3
 	schedulerDefaultLegacyMinInterval       = 1
 	schedulerDefaultMinInterval             = 10 * time.Second
+	schedulerDefaultEvaluationDebugBuffer   = 10
 )
 
 type UnifiedAlertingSettings struct {
@@ -66,6 +67,8 @@ type UnifiedAlertingSettings struct {
 	DefaultConfiguration           string
 	Enabled                        *bool // determines whether unified alerting is enabled. If it is nil then user did not define it and therefore its value will be determined during migration. Services should not use it directly.
 	DisabledOrgs                   map[int64]struct{}
+	// EvaluationDebugBufferSize is the number of recent evaluations kept in memory for each alert rule
+	EvaluationDebugBufferSize int
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -216,6 +219,11 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.MinInterval = uaMinInterval
 
+	uaCfg.EvaluationDebugBufferSize = ua.Key("evaluation_debug_buffer_size").MustInt(schedulerDefaultEvaluationDebugBuffer)
+	if uaCfg.EvaluationDebugBufferSize < 0 {
+		return errors.New("evaluation_debug_buffer_size must not be negative")
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
@@ -0,0 +1,80 @@
+package apikeys
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/tests/testinfra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionScopedAPIKeys(t *testing.T) {
+	dir, path := testinfra.CreateGrafDir(t, testinfra.GrafanaOpts{
+		DisableAnonymous:     true,
+		EnableFeatureToggles: []string{"accesscontrol"},
+	})
+	grafanaListedAddr, store := testinfra.StartGrafana(t, dir, path)
+	user, err := store.CreateUser(context.Background(), models.CreateUserCommand{
+		DefaultOrgRole: string(models.ROLE_ADMIN),
+		Password:       "admin",
+		Login:          "admin",
+	})
+	require.NoError(t, err)
+
+	t.Run("should not add permission-scoped keys with a role above Viewer", func(t *testing.T) {
+		body := `{"name": "scoped", "role": "Admin", "permissions": [{"action": "dashboards:read", "scope": "dashboards:*"}]}`
+		u := fmt.Sprintf("http://admin:admin@%s/api/auth/keys", grafanaListedAddr)
+		// nolint:gosec
+		resp, err := http.Post(u, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, resp.Body.Close())
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should only grant the Viewer role to permission-scoped keys", func(t *testing.T) {
+		// Keys stored with a higher role, before it was refused, are downgraded when used
+		scoped := addAPIKey(t, store, user.OrgId, "scoped-admin", []models.ApiKeyPermissionDTO{{Action: "dashboards:read", Scope: "dashboards:*"}})
+		assert.Equal(t, http.StatusForbidden, getAlertNotifiers(t, grafanaListedAddr, scoped))
+
+		admin := addAPIKey(t, store, user.OrgId, "admin", nil)
+		assert.Equal(t, http.StatusOK, getAlertNotifiers(t, grafanaListedAddr, admin))
+	})
+}
+
+func addAPIKey(t *testing.T, store *sqlstore.SQLStore, orgID int64, name string, permissions []models.ApiKeyPermissionDTO) string {
+	t.Helper()
+
+	key, err := apikeygen.New(orgID, name)
+	require.NoError(t, err)
+	err = store.AddAPIKey(context.Background(), &models.AddApiKeyCommand{
+		Name:        name,
+		Role:        models.ROLE_ADMIN,
+		OrgId:       orgID,
+		Key:         key.HashedKey,
+		Permissions: permissions,
+	})
+	require.NoError(t, err)
+	return key.ClientSecret
+}
+
+// getAlertNotifiers calls an endpoint only restricted to editors, without fine-grained access control
+func getAlertNotifiers(t *testing.T, addr, key string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/api/alert-notifiers", addr), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp.StatusCode
+}
@@ -0,0 +1,36 @@
+// Package queryprogress lets the data sources report the progress of their long-running queries, which Grafana
+// publishes to the clients having requested it.
+package queryprogress
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Progress is the progress of a query
+type Progress struct {
+	// RefID is the query the progress is about, empty when it is about all the queries of the request
+	RefID        string
+	BytesScanned int64
+	RowsScanned  int64
+	// Frames are the partial results of the query
+	Frames data.Frames
+}
+
+// Reporter receives the progress of the queries
+type Reporter func(p Progress)
+
+type reporterKey struct{}
+
+// WithReporter returns a context whose queries report their progress to the reporter
+func WithReporter(ctx context.Context, reporter Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, reporter)
+}
+
+// Report reports the progress of a query made with the context. It does nothing when the progress is not requested.
+func Report(ctx context.Context, p Progress) {
+	if reporter, ok := ctx.Value(reporterKey{}).(Reporter); ok {
+		reporter(p)
+	}
+}
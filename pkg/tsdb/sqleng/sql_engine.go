@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/tsdb/queryprogress"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"xorm.io/core"
 	"xorm.io/xorm"
@@ -190,18 +191,33 @@ func (e *DataSourceHandler) QueryData(ctx context.Context, req *backend.QueryDat
 		go e.executeQuery(query, &wg, ctx, ch, queryjson)
 	}
 
-	wg.Wait()
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
 
-	// Read results from channels
-	close(ch)
+	// Read the results as the queries finish, each one being a partial result of the request
 	result.Responses = make(map[string]backend.DataResponse)
 	for queryResult := range ch {
 		result.Responses[queryResult.refID] = queryResult.dataResponse
+		reportProgress(ctx, queryResult)
 	}
 
 	return result, nil
 }
 
+// reportProgress reports a finished query as a partial result, when the progress of the queries is requested
+func reportProgress(ctx context.Context, queryResult DBDataResponse) {
+	if queryResult.dataResponse.Error != nil {
+		return
+	}
+	p := queryprogress.Progress{RefID: queryResult.refID, Frames: queryResult.dataResponse.Frames}
+	for _, frame := range p.Frames {
+		p.RowsScanned += int64(frame.Rows())
+	}
+	queryprogress.Report(ctx, p)
+}
+
 func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitGroup, queryContext context.Context,
 	ch chan DBDataResponse, queryJson QueryJson) {
 	defer wg.Done()
@@ -19,6 +19,16 @@ export interface LoginServices {
 
 const loginServices: () => LoginServices = () => {
   const oauthEnabled = !!config.oauth;
+  const pluginServices: LoginServices = {};
+  for (const provider of config.pluginAuthProviders ?? []) {
+    pluginServices[`plugin_${provider.id}`] = {
+      bgColor: '#262628',
+      enabled: true,
+      name: provider.name,
+      icon: 'plug',
+      hrefName: `plugin/${provider.id}`,
+    };
+  }
 
   return {
     saml: {
@@ -71,6 +81,7 @@ const loginServices: () => LoginServices = () => {
       icon: 'signin',
       hrefName: 'generic_oauth',
     },
+    ...pluginServices,
   };
 };
 
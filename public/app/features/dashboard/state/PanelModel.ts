@@ -96,6 +96,7 @@ const mustKeepProps: { [str: string]: boolean } = {
   hasRefreshed: true,
   events: true,
   cacheTimeout: true,
+  cacheTTL: true,
   cachedPluginOptions: true,
   transparent: true,
   pluginVersion: true,
@@ -159,6 +160,8 @@ export class PanelModel implements DataConfigSource, IPanelModel {
 
   maxDataPoints?: number | null;
   interval?: string | null;
+  // How long the query results can be cached by the backend, bounded by the data source cache policy
+  cacheTTL?: string | null;
   description?: string;
   links?: DataLink[];
   declare transparent: boolean;
@@ -317,6 +320,7 @@ export class PanelModel implements DataConfigSource, IPanelModel {
       minInterval: this.interval,
       scopedVars: this.scopedVars,
       cacheTimeout: this.cacheTimeout,
+      cacheTTL: this.cacheTTL,
       transformations: this.transformations,
     });
   }
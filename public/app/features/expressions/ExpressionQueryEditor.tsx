@@ -5,6 +5,8 @@ import { Resample } from './components/Resample';
 import { Reduce } from './components/Reduce';
 import { Math } from './components/Math';
 import { ClassicConditions } from './components/ClassicConditions';
+import { Anomaly } from './components/Anomaly';
+import { Forecast } from './components/Forecast';
 import { getDefaults } from './utils/expressionTypes';
 import { ExpressionQuery, ExpressionQueryType, gelTypes } from './types';
 
@@ -34,6 +36,12 @@ export class ExpressionQueryEditor extends PureComponent<Props> {
 
       case ExpressionQueryType.classic:
         return <ClassicConditions onChange={onChange} query={query} refIds={refIds} />;
+
+      case ExpressionQueryType.anomaly:
+        return <Anomaly query={query} labelWidth={labelWidth} onChange={onChange} refIds={refIds} />;
+
+      case ExpressionQueryType.forecast:
+        return <Forecast query={query} labelWidth={labelWidth} onChange={onChange} refIds={refIds} />;
     }
   }
 
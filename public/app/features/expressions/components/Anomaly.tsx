@@ -0,0 +1,78 @@
+import React, { ChangeEvent, FC } from 'react';
+import { SelectableValue } from '@grafana/data';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+import { anomalyMethods, anomalyOutputs, ExpressionQuery } from '../types';
+
+interface Props {
+  refIds: Array<SelectableValue<string>>;
+  query: ExpressionQuery;
+  labelWidth: number;
+  onChange: (query: ExpressionQuery) => void;
+}
+
+export const Anomaly: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
+  const method = anomalyMethods.find((o) => o.value === query.method);
+  const output = anomalyOutputs.find((o) => o.value === query.output);
+
+  const onRefIdChange = (value: SelectableValue<string>) => {
+    onChange({ ...query, expression: value.value });
+  };
+
+  const onSelectMethod = (value: SelectableValue<string>) => {
+    onChange({ ...query, method: value.value });
+  };
+
+  const onSelectOutput = (value: SelectableValue<string>) => {
+    onChange({ ...query, output: value.value });
+  };
+
+  const onSensitivityChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, sensitivity: Number(event.target.value) });
+  };
+
+  const onAlphaChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, alpha: Number(event.target.value) });
+  };
+
+  const onSeasonChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, season: event.target.value });
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Input" labelWidth={labelWidth}>
+          <Select menuShouldPortal onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
+        </InlineField>
+        <InlineField label="Method">
+          <Select menuShouldPortal options={anomalyMethods} value={method} onChange={onSelectMethod} width={25} />
+        </InlineField>
+        <InlineField label="Output">
+          <Select menuShouldPortal options={anomalyOutputs} value={output} onChange={onSelectOutput} width={25} />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField
+          label="Sensitivity"
+          labelWidth={labelWidth}
+          tooltip="Number of standard deviations from the expected value beyond which a value is an anomaly"
+        >
+          <Input type="number" onChange={onSensitivityChange} value={query.sensitivity} width={15} />
+        </InlineField>
+        {query.method === 'ewma' && (
+          <InlineField
+            label="Alpha"
+            tooltip="Smoothing factor between 0 and 1, the higher the faster the average follows"
+          >
+            <Input type="number" step={0.1} onChange={onAlphaChange} value={query.alpha ?? 0.3} width={15} />
+          </InlineField>
+        )}
+        {query.method === 'seasonal' && (
+          <InlineField label="Season" tooltip="1h, 1d, 7d">
+            <Input onChange={onSeasonChange} value={query.season} width={15} />
+          </InlineField>
+        )}
+      </InlineFieldRow>
+    </>
+  );
+};
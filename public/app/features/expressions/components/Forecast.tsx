@@ -0,0 +1,96 @@
+import React, { ChangeEvent, FC } from 'react';
+import { SelectableValue } from '@grafana/data';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+import { ExpressionQuery, forecastMethods, forecastOutputs } from '../types';
+
+interface Props {
+  refIds: Array<SelectableValue<string>>;
+  query: ExpressionQuery;
+  labelWidth: number;
+  onChange: (query: ExpressionQuery) => void;
+}
+
+export const Forecast: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
+  const method = forecastMethods.find((o) => o.value === query.method);
+  const output = forecastOutputs.find((o) => o.value === query.output);
+
+  const onRefIdChange = (value: SelectableValue<string>) => {
+    onChange({ ...query, expression: value.value });
+  };
+
+  const onSelectMethod = (value: SelectableValue<string>) => {
+    onChange({ ...query, method: value.value });
+  };
+
+  const onSelectOutput = (value: SelectableValue<string>) => {
+    onChange({ ...query, output: value.value });
+  };
+
+  const onHorizonChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, horizon: event.target.value });
+  };
+
+  const onConfidenceChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, confidence: Number(event.target.value) });
+  };
+
+  const onSeasonChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, season: event.target.value });
+  };
+
+  const onFactorChange = (factor: 'alpha' | 'beta' | 'gamma') => (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, [factor]: Number(event.target.value) });
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Input" labelWidth={labelWidth}>
+          <Select menuShouldPortal onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
+        </InlineField>
+        <InlineField label="Method">
+          <Select menuShouldPortal options={forecastMethods} value={method} onChange={onSelectMethod} width={25} />
+        </InlineField>
+        <InlineField label="Output">
+          <Select menuShouldPortal options={forecastOutputs} value={output} onChange={onSelectOutput} width={25} />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField label="Horizon" labelWidth={labelWidth} tooltip="How far to forecast: 1h, 1d, 30d">
+          <Input onChange={onHorizonChange} value={query.horizon} width={15} />
+        </InlineField>
+        {query.output === 'bands' && (
+          <InlineField label="Confidence" tooltip="Probability of the values to be within the bands, between 0 and 1">
+            <Input
+              type="number"
+              step={0.01}
+              onChange={onConfidenceChange}
+              value={query.confidence ?? 0.95}
+              width={15}
+            />
+          </InlineField>
+        )}
+      </InlineFieldRow>
+      {query.method === 'holtwinters' && (
+        <InlineFieldRow>
+          <InlineField
+            label="Alpha"
+            labelWidth={labelWidth}
+            tooltip="Smoothing factor of the level between 0 and 1, the higher the faster it follows"
+          >
+            <Input type="number" step={0.1} onChange={onFactorChange('alpha')} value={query.alpha ?? 0.5} width={10} />
+          </InlineField>
+          <InlineField label="Beta" tooltip="Smoothing factor of the trend between 0 and 1">
+            <Input type="number" step={0.1} onChange={onFactorChange('beta')} value={query.beta ?? 0.1} width={10} />
+          </InlineField>
+          <InlineField label="Gamma" tooltip="Smoothing factor of the seasonality between 0 and 1">
+            <Input type="number" step={0.1} onChange={onFactorChange('gamma')} value={query.gamma ?? 0.1} width={10} />
+          </InlineField>
+          <InlineField label="Season" tooltip="Period of the seasonality, such as 1d, none when empty">
+            <Input onChange={onSeasonChange} value={query.season} width={15} />
+          </InlineField>
+        </InlineFieldRow>
+      )}
+    </>
+  );
+};
@@ -6,6 +6,8 @@ export enum ExpressionQueryType {
   reduce = 'reduce',
   resample = 'resample',
   classic = 'classic_conditions',
+  anomaly = 'anomaly',
+  forecast = 'forecast',
 }
 
 export const gelTypes: Array<SelectableValue<ExpressionQueryType>> = [
@@ -13,6 +15,8 @@ export const gelTypes: Array<SelectableValue<ExpressionQueryType>> = [
   { value: ExpressionQueryType.reduce, label: 'Reduce' },
   { value: ExpressionQueryType.resample, label: 'Resample' },
   { value: ExpressionQueryType.classic, label: 'Classic condition' },
+  { value: ExpressionQueryType.anomaly, label: 'Anomaly detection' },
+  { value: ExpressionQueryType.forecast, label: 'Forecast' },
 ];
 
 export const reducerTypes: Array<SelectableValue<string>> = [
@@ -36,6 +40,40 @@ export const upsamplingTypes: Array<SelectableValue<string>> = [
   { value: 'fillna', label: 'fillna', description: 'Fill with NaNs' },
 ];
 
+export const anomalyMethods: Array<SelectableValue<string>> = [
+  { value: 'zscore', label: 'Z-score', description: 'Compare the values to the mean of the series' },
+  { value: 'ewma', label: 'EWMA', description: 'Compare the values to the moving average of the previous values' },
+  {
+    value: 'seasonal',
+    label: 'Seasonal',
+    description: 'Compare the values to the trend and seasonality of the series',
+  },
+];
+
+export const anomalyOutputs: Array<SelectableValue<string>> = [
+  { value: 'anomalies', label: 'Anomalies', description: '1 at the anomalies, 0 elsewhere' },
+  { value: 'score', label: 'Score', description: 'Deviation from the expected value, in standard deviations' },
+  { value: 'bands', label: 'Bands', description: 'Expected value, lower and upper bands' },
+];
+
+export const forecastMethods: Array<SelectableValue<string>> = [
+  { value: 'linear', label: 'Linear', description: 'Extend the least squares line of the series' },
+  {
+    value: 'holtwinters',
+    label: 'Holt-Winters',
+    description: 'Extend the smoothed level, trend and seasonality of the series',
+  },
+];
+
+export const forecastOutputs: Array<SelectableValue<string>> = [
+  { value: 'forecast', label: 'Forecast', description: 'Predicted values' },
+  {
+    value: 'bands',
+    label: 'Bands',
+    description: 'Predicted values, lower and upper bounds of the confidence interval',
+  },
+];
+
 /**
  * For now this is a single object to cover all the types.... would likely
  * want to split this up by type as the complexity increases
@@ -48,6 +86,15 @@ export interface ExpressionQuery extends DataQuery {
   downsampler?: string;
   upsampler?: string;
   conditions?: ClassicCondition[];
+  method?: string;
+  sensitivity?: number;
+  alpha?: number;
+  season?: string;
+  output?: string;
+  horizon?: string;
+  beta?: number;
+  gamma?: number;
+  confidence?: number;
 }
 export interface ClassicCondition {
   evaluator: {
@@ -1,5 +1,5 @@
 import { ReducerID } from '@grafana/data';
-import { ClassicCondition, ExpressionQuery, ExpressionQueryType } from '../types';
+import { ClassicCondition, ExpressionQuery, ExpressionQueryType, forecastMethods, forecastOutputs } from '../types';
 import { EvalFunction } from '../../alerting/state/alertDef';
 
 export const getDefaults = (query: ExpressionQuery) => {
@@ -23,6 +23,38 @@ export const getDefaults = (query: ExpressionQuery) => {
       query.reducer = undefined;
       break;
 
+    case ExpressionQueryType.anomaly:
+      if (!query.method) {
+        query.method = 'zscore';
+      }
+
+      if (!query.sensitivity) {
+        query.sensitivity = 3;
+      }
+
+      if (!query.output) {
+        query.output = 'anomalies';
+      }
+
+      query.reducer = undefined;
+      break;
+
+    case ExpressionQueryType.forecast:
+      if (!forecastMethods.some((o) => o.value === query.method)) {
+        query.method = 'linear';
+      }
+
+      if (!query.horizon) {
+        query.horizon = '1d';
+      }
+
+      if (!forecastOutputs.some((o) => o.value === query.output)) {
+        query.output = 'forecast';
+      }
+
+      query.reducer = undefined;
+      break;
+
     case ExpressionQueryType.classic:
       if (!query.conditions) {
         query.conditions = [defaultCondition];
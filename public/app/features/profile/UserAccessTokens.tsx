@@ -0,0 +1,126 @@
+import React, { FC, FormEvent, useCallback, useEffect, useState } from 'react';
+import { getBackendSrv } from '@grafana/runtime';
+import { dateTimeFormat } from '@grafana/data';
+import { Alert, Button, ClipboardButton, DeleteButton, HorizontalGroup, Input, LoadingPlaceholder } from '@grafana/ui';
+
+import { UserAccessToken } from 'app/types';
+
+interface NewAccessToken {
+  name: string;
+  key: string;
+}
+
+// the number of seconds the new tokens are valid, one of the choices of the form
+const expirations: Array<{ label: string; seconds: number }> = [
+  { label: '7 days', seconds: 7 * 24 * 3600 },
+  { label: '30 days', seconds: 30 * 24 * 3600 },
+  { label: '90 days', seconds: 90 * 24 * 3600 },
+];
+
+export const UserAccessTokens: FC = () => {
+  const [tokens, setTokens] = useState<UserAccessToken[] | undefined>(undefined);
+  const [name, setName] = useState('');
+  const [secondsToLive, setSecondsToLive] = useState(expirations[1].seconds);
+  const [added, setAdded] = useState<NewAccessToken | undefined>(undefined);
+
+  const loadTokens = useCallback(async () => {
+    setTokens(await getBackendSrv().get('/api/user/access-tokens'));
+  }, []);
+
+  useEffect(() => {
+    loadTokens();
+  }, [loadTokens]);
+
+  const onAdd = async (event: FormEvent) => {
+    event.preventDefault();
+    const result = await getBackendSrv().post('/api/user/access-tokens', { name, secondsToLive });
+    setAdded({ name: result.name, key: result.key });
+    setName('');
+    await loadTokens();
+  };
+
+  const onRevoke = async (token: UserAccessToken) => {
+    await getBackendSrv().delete(`/api/user/access-tokens/${token.id}`);
+    await loadTokens();
+  };
+
+  if (!tokens) {
+    return <LoadingPlaceholder text="Loading personal access tokens..." />;
+  }
+
+  return (
+    <div>
+      <h3 className="page-sub-heading">Personal access tokens</h3>
+      {added && (
+        <Alert severity="success" title={`Token ${added.name} created`} onRemove={() => setAdded(undefined)}>
+          <p>Copy the token now, it is not shown again.</p>
+          <HorizontalGroup>
+            <code>{added.key}</code>
+            <ClipboardButton size="sm" getText={() => added.key}>
+              Copy
+            </ClipboardButton>
+          </HorizontalGroup>
+        </Alert>
+      )}
+      <form className="gf-form-group" onSubmit={onAdd}>
+        <HorizontalGroup>
+          <Input
+            placeholder="Token name"
+            aria-label="Personal access token name"
+            value={name}
+            onChange={(event) => setName(event.currentTarget.value)}
+          />
+          <select
+            className="gf-form-input"
+            aria-label="Personal access token expiration"
+            value={secondsToLive}
+            onChange={(event) => setSecondsToLive(Number(event.currentTarget.value))}
+          >
+            {expirations.map((expiration) => (
+              <option key={expiration.seconds} value={expiration.seconds}>
+                {expiration.label}
+              </option>
+            ))}
+          </select>
+          <Button type="submit" disabled={!name}>
+            Create token
+          </Button>
+        </HorizontalGroup>
+      </form>
+      {tokens.length > 0 && (
+        <div className="gf-form-group">
+          <table className="filter-table form-inline" aria-label="Personal access tokens table">
+            <thead>
+              <tr>
+                <th>Name</th>
+                <th>Expires</th>
+                <th>Last used</th>
+                <th>IP address</th>
+                <th style={{ width: '34px' }} />
+              </tr>
+            </thead>
+            <tbody>
+              {tokens.map((token) => (
+                <tr key={token.id}>
+                  <td>{token.name}</td>
+                  <td>{token.expiration ? dateTimeFormat(token.expiration) : 'Never'}</td>
+                  <td>{token.lastUsedAt ? dateTimeFormat(token.lastUsedAt) : 'Never'}</td>
+                  <td>{token.lastUsedIp}</td>
+                  <td>
+                    <DeleteButton
+                      aria-label="Revoke personal access token"
+                      size="sm"
+                      onConfirm={() => onRevoke(token)}
+                    />
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default UserAccessTokens;
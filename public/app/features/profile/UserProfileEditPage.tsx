@@ -13,6 +13,7 @@ import SharedPreferences from 'app/core/components/SharedPreferences/SharedPrefe
 import { UserTeams } from './UserTeams';
 import UserOrganizations from './UserOrganizations';
 import UserSessions from './UserSessions';
+import UserAccessTokens from './UserAccessTokens';
 
 export interface OwnProps {
   navModel: NavModel;
@@ -71,6 +72,7 @@ export function UserProfileEditPage({
           <UserTeams isLoading={teamsAreLoading} teams={teams} />
           <UserOrganizations isLoading={orgsAreLoading} setUserOrg={changeUserOrg} orgs={orgs} user={user} />
           <UserSessions isLoading={sessionsAreLoading} revokeUserSession={revokeUserSession} sessions={sessions} />
+          <UserAccessTokens />
         </VerticalGroup>
       </Page.Contents>
     </Page>
@@ -54,6 +54,7 @@ export interface QueryRunnerOptions<
   minInterval: string | undefined | null;
   scopedVars?: ScopedVars;
   cacheTimeout?: string | null;
+  cacheTTL?: string | null;
   transformations?: DataTransformerConfig[];
 }
 
@@ -198,6 +199,7 @@ export class PanelQueryRunner {
       timeRange,
       timeInfo,
       cacheTimeout,
+      cacheTTL,
       maxDataPoints,
       scopedVars,
       minInterval,
@@ -222,6 +224,7 @@ export class PanelQueryRunner {
       maxDataPoints: maxDataPoints,
       scopedVars: scopedVars || {},
       cacheTimeout,
+      cacheTTL,
       startTime: Date.now(),
     };
 
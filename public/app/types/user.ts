@@ -87,6 +87,16 @@ export interface UserSession {
   device: string;
 }
 
+export interface UserAccessToken {
+  id: number;
+  name: string;
+  created: string;
+  expiration?: string;
+  lastUsedAt?: string;
+  lastUsedIp?: string;
+  permissions?: Array<{ action: string; scope: string }>;
+}
+
 export interface UserOrg {
   name: string;
   orgId: number;